@@ -18,8 +18,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft/proto"
 )
 
 // LeaderInfo represents the leader's information
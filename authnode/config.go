@@ -20,8 +20,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
-	"github.com/tiglabs/raft/proto"
 )
 
 //config key
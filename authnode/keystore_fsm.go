@@ -22,11 +22,11 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
 	"github.com/chubaofs/chubaofs/util/keystore"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft"
-	"github.com/tiglabs/raft/proto"
 )
 
 const (
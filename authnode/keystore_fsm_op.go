@@ -21,9 +21,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/util/keystore"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft/proto"
 )
 
 // RaftCmd defines the Raft commands.
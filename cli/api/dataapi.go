@@ -0,0 +1,121 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// DataHttpClient defines the client of the http service of a data node.
+type DataHttpClient struct {
+	useSSL bool
+	host   string
+}
+
+// NewDataHttpClient returns a new DataHttpClient instance.
+func NewDataHttpClient(host string, useSSL bool) *DataHttpClient {
+	return &DataHttpClient{host: host, useSSL: useSSL}
+}
+
+// DataPartition defines the data partition replica on a data node.
+type DataPartition struct {
+	VolName              string                `json:"volName"`
+	ID                   uint64                `json:"id"`
+	Size                 int                   `json:"size"`
+	Used                 int                   `json:"used"`
+	Status               int                   `json:"status"`
+	Path                 string                `json:"path"`
+	Files                []*storage.ExtentInfo `json:"extents"`
+	FileCount            int                   `json:"fileCount"`
+	Replicas             []string              `json:"replicas"`
+	TinyDeleteRecordSize int64                 `json:"tinyDeleteRecordSize"`
+}
+
+func (dc *DataHttpClient) serveRequest(path string, params url.Values, data interface{}) (err error) {
+	schema := "http"
+	if dc.useSSL {
+		schema = "https"
+	}
+	reqURL := fmt.Sprintf("%s://%s%s?%s", schema, dc.host, path, params.Encode())
+	client := &http.Client{Timeout: requestTimeout}
+	var resp *http.Response
+	if resp, err = client.Get(reqURL); err != nil {
+		log.LogErrorf("serveRequest: send http request fail: url(%v) err(%v)", reqURL, err)
+		return
+	}
+	var respData []byte
+	respData, err = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		log.LogErrorf("serveRequest: read http response body fail: url(%v) err(%v)", reqURL, err)
+		return
+	}
+	var body = &struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err = json.Unmarshal(respData, body); err != nil {
+		return fmt.Errorf("unmarshal response body of %v err:%v", reqURL, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Code != http.StatusOK {
+		return fmt.Errorf("request %v fail: status(%v) msg(%v)", reqURL, resp.StatusCode, body.Msg)
+	}
+	return json.Unmarshal(body.Data, data)
+}
+
+// GetPartition returns the data partition replica with the extents on the data node.
+func (dc *DataHttpClient) GetPartition(pid uint64) (partition *DataPartition, err error) {
+	params := url.Values{}
+	params.Set("id", fmt.Sprintf("%v", pid))
+	partition = &DataPartition{}
+	if err = dc.serveRequest("/partition", params, partition); err != nil {
+		log.LogErrorf("action[GetPartition],host:%v,pid:%v,err:%v", dc.host, pid, err)
+		return nil, err
+	}
+	return
+}
+
+// GetExtent returns the size, crc and modify time of the extent on the data node.
+func (dc *DataHttpClient) GetExtent(pid, extentID uint64) (extent *storage.ExtentInfo, err error) {
+	params := url.Values{}
+	params.Set("partitionID", fmt.Sprintf("%v", pid))
+	params.Set("extentID", fmt.Sprintf("%v", extentID))
+	extent = &storage.ExtentInfo{}
+	if err = dc.serveRequest("/extent", params, extent); err != nil {
+		log.LogErrorf("action[GetExtent],host:%v,pid:%v,extentID:%v,err:%v", dc.host, pid, extentID, err)
+		return nil, err
+	}
+	return
+}
+
+// GetExtentBlockCrcs returns the crc of each block of the extent on the data node.
+func (dc *DataHttpClient) GetExtentBlockCrcs(pid, extentID uint64) (blocks []*storage.BlockCrc, err error) {
+	params := url.Values{}
+	params.Set("partitionID", fmt.Sprintf("%v", pid))
+	params.Set("extentID", fmt.Sprintf("%v", extentID))
+	if err = dc.serveRequest("/block", params, &blocks); err != nil {
+		log.LogErrorf("action[GetExtentBlockCrcs],host:%v,pid:%v,extentID:%v,err:%v", dc.host, pid, extentID, err)
+		return nil, err
+	}
+	return
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -27,7 +28,6 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/chubaofs/chubaofs/metanode"
-	"bufio"
 	"io"
 )
 
@@ -169,6 +169,39 @@ func (c *MetaHttpClient) serveRequest(r *request) (respData []byte, err error) {
 	return
 }
 
+// serveOperationRequest serves the request of the handlers which execute the meta operations. The handlers
+// reply the result of the operation with the code http.StatusSeeOther.
+func (c *MetaHttpClient) serveOperationRequest(r *request) (respData []byte, err error) {
+	var schema = "http"
+	if c.useSSL {
+		schema = "https"
+	}
+	var resp *http.Response
+	if resp, err = c.httpRequest(r.method, fmt.Sprintf("%s://%s%s", schema, c.host, r.path), r.params, r.header, r.body); err != nil {
+		return
+	}
+	respData, err = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return
+	}
+	var body = &struct {
+		Code int32           `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err = json.Unmarshal(respData, body); err != nil {
+		return nil, fmt.Errorf("unmarshal response body err:%v", err)
+	}
+	if body.Code != http.StatusSeeOther && body.Code != http.StatusOK {
+		return nil, fmt.Errorf("code[%v] msg[%v]", body.Code, body.Msg)
+	}
+	if body.Msg != "Ok" {
+		return nil, fmt.Errorf("%v", body.Msg)
+	}
+	return []byte(body.Data), nil
+}
+
 func (c *MetaHttpClient) httpRequest(method, url string, param, header map[string]string, reqData []byte) (resp *http.Response, err error) {
 	client := http.DefaultClient
 	reader := bytes.NewReader(reqData)
@@ -231,91 +264,393 @@ func (mc *MetaHttpClient) GetMetaPartition(pid uint64) (cursor uint64, err error
 	return body.Cursor, nil
 }
 
-func (mc *MetaHttpClient) GetAllDentry(pid uint64) (dentryMap map[string]*metanode.Dentry, err error, ) {
+// MetaPartitionStatus defines the state of a meta partition replica on a meta node.
+type MetaPartitionStatus struct {
+	LeaderAddr  string       `json:"leaderAddr"`
+	NodeID      uint64       `json:"nodeId"`
+	Peers       []proto.Peer `json:"peers"`
+	Cursor      uint64       `json:"cursor"`
+	InodeCount  uint64       `json:"inodeCount"`
+	DentryCount uint64       `json:"dentryCount"`
+	ApplyID     uint64       `json:"applyID"`
+	RaftStatus  *RaftStatus  `json:"raftStatus"`
+}
+
+// RaftStatus defines the raft status of a partition replica.
+type RaftStatus struct {
+	NodeID            uint64
+	Leader            uint64
+	Term              uint64
+	Index             uint64
+	Commit            uint64
+	Applied           uint64
+	Stopped           bool
+	RestoringSnapshot bool
+	State             string
+}
+
+// GetMetaPartitionStatus returns the state of the meta partition replica on the meta node.
+func (mc *MetaHttpClient) GetMetaPartitionStatus(pid uint64) (status *MetaPartitionStatus, err error) {
 	defer func() {
 		if err != nil {
-			log.LogErrorf("action[GetAllDentry],pid:%v,err:%v", pid, err)
+			log.LogErrorf("action[GetMetaPartitionStatus],host:%v,pid:%v,err:%v", mc.host, pid, err)
 		}
 	}()
-	dentryMap = make(map[string]*metanode.Dentry, 0)
-	request := newAPIRequest(http.MethodGet, "/getAllDentry")
+	request := newAPIRequest(http.MethodGet, "/getPartitionById")
 	request.params["pid"] = fmt.Sprintf("%v", pid)
 	respData, err := mc.serveRequest(request)
-	log.LogInfof("err:%v,respData:%v\n", err, string(respData))
 	if err != nil {
 		return
 	}
+	status = &MetaPartitionStatus{}
+	if err = json.Unmarshal(respData, status); err != nil {
+		return
+	}
+	return
+}
 
-	dec := json.NewDecoder(bytes.NewBuffer(respData))
-	dec.UseNumber()
+// GetMemoryUsage returns the memory used by the meta node, broken down by the meta partitions.
+func (mc *MetaHttpClient) GetMemoryUsage() (usage *proto.MetaNodeMemory, err error) {
+	request := newAPIRequest(http.MethodGet, "/getMemoryUsage")
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	usage = &proto.MetaNodeMemory{}
+	if err = json.Unmarshal(respData, usage); err != nil {
+		return
+	}
+	return
+}
 
-	// It's the "items". We expect it to be an array
-	if err = parseToken(dec, '['); err != nil {
+// GetInode returns the attributes of the inode in the meta partition replica.
+func (mc *MetaHttpClient) GetInode(pid, ino uint64) (info *proto.InodeInfo, err error) {
+	request := newAPIRequest(http.MethodGet, "/getInode")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	respData, err := mc.serveOperationRequest(request)
+	if err != nil {
 		return
 	}
-	// Read items (large objects)
-	for dec.More() {
-		// Read next item (large object)
-		lo := &metanode.Dentry{}
-		if err = dec.Decode(lo); err != nil {
-			return
-		}
-		dentryMap[fmt.Sprintf("%v_%v", lo.ParentId, lo.Name)] = lo
+	resp := &proto.InodeGetResponse{}
+	if err = json.Unmarshal(respData, resp); err != nil {
+		return
+	}
+	if resp.Info == nil {
+		return nil, fmt.Errorf("inode[%v] not found", ino)
+	}
+	return resp.Info, nil
+}
+
+// GetXAttrs returns all the extended attributes of the inode in the meta partition replica.
+func (mc *MetaHttpClient) GetXAttrs(pid, ino uint64) (info *proto.XAttrInfo, err error) {
+	request := newAPIRequest(http.MethodGet, "/getXAttrs")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	info = &proto.XAttrInfo{}
+	if err = json.Unmarshal(respData, info); err != nil {
+		return
+	}
+	return
+}
+
+// GetACL returns the owner, the mode and the POSIX ACLs of the inode in the meta partition replica.
+func (mc *MetaHttpClient) GetACL(pid, ino uint64) (info *proto.ACLInfo, err error) {
+	request := newAPIRequest(http.MethodGet, "/getACL")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	info = &proto.ACLInfo{}
+	if err = json.Unmarshal(respData, info); err != nil {
+		return
 	}
-	// Array closing delimiter
-	if err = parseToken(dec, ']'); err != nil {
+	return
+}
+
+// LookupDentry returns the inode and the mode of the dentry in the parent directory.
+func (mc *MetaHttpClient) LookupDentry(pid, parentIno uint64, name string) (dentry *proto.LookupResponse, err error) {
+	request := newAPIRequest(http.MethodGet, "/getDentry")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["parentIno"] = fmt.Sprintf("%v", parentIno)
+	request.params["name"] = name
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	dentry = &proto.LookupResponse{}
+	if err = json.Unmarshal(respData, dentry); err != nil {
 		return
 	}
 	return
 }
 
-func parseToken(dec *json.Decoder, expectToken rune) (err error) {
-	t, err := dec.Token()
+// GetSummary returns the summary of the directory in the meta partition replica.
+func (mc *MetaHttpClient) GetSummary(pid, ino uint64) (summary *proto.DirSummary, err error) {
+	request := newAPIRequest(http.MethodGet, "/getSummary")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	respData, err := mc.serveRequest(request)
 	if err != nil {
 		return
 	}
-	if delim, ok := t.(json.Delim); !ok || delim != json.Delim(expectToken) {
-		err = fmt.Errorf("expected token[%v],delim[%v],ok[%v]", string(expectToken), delim, ok)
+	summary = &proto.DirSummary{}
+	if err = json.Unmarshal(respData, summary); err != nil {
 		return
 	}
 	return
 }
 
-func (mc *MetaHttpClient) GetAllInodes(pid uint64) (rstMap map[uint64]*Inode, err error, ) {
+// GetOrphanReport returns the result of the latest orphan inode scan of the meta partition replica.
+func (mc *MetaHttpClient) GetOrphanReport(pid uint64) (report *proto.OrphanReport, err error) {
+	request := newAPIRequest(http.MethodGet, "/getOrphanReport")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	report = &proto.OrphanReport{}
+	if err = json.Unmarshal(respData, report); err != nil {
+		return
+	}
+	return
+}
+
+// GetRaftStatus returns the raft progress of the meta partition replica, with the ones of the other replicas if it
+// is the leader.
+func (mc *MetaHttpClient) GetRaftStatus(pid uint64) (status *proto.MetaPartitionRaftStatus, err error) {
+	request := newAPIRequest(http.MethodGet, "/getRaftStatus")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	status = &proto.MetaPartitionRaftStatus{}
+	if err = json.Unmarshal(respData, status); err != nil {
+		return
+	}
+	return
+}
+
+// CheckNLink starts checking the link counts of the inodes by the leader of the meta partition, the mismatches are
+// fixed if fix is true. The limit is the max number of the dentries scanned per second, 0 for the default.
+func (mc *MetaHttpClient) CheckNLink(pid uint64, fix bool, limit int) (report *proto.NLinkCheckReport, err error) {
+	request := newAPIRequest(http.MethodGet, "/checkNLink")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["fix"] = fmt.Sprintf("%v", fix)
+	request.params["limit"] = fmt.Sprintf("%v", limit)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	report = &proto.NLinkCheckReport{}
+	if err = json.Unmarshal(respData, report); err != nil {
+		return
+	}
+	return
+}
+
+// GetNLinkCheckReport returns the progress or the result of the latest link count check of the meta partition replica.
+func (mc *MetaHttpClient) GetNLinkCheckReport(pid uint64) (report *proto.NLinkCheckReport, err error) {
+	request := newAPIRequest(http.MethodGet, "/getNLinkCheck")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	report = &proto.NLinkCheckReport{}
+	if err = json.Unmarshal(respData, report); err != nil {
+		return
+	}
+	return
+}
+
+// GetDeletedInodes returns the deleted files of the meta partition which are not purged yet, whose inode numbers are
+// larger than the marker.
+func (mc *MetaHttpClient) GetDeletedInodes(pid, marker uint64, limit int) (inodes []*proto.DeletedInode, err error) {
+	request := newAPIRequest(http.MethodGet, "/getDeletedInodes")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["marker"] = fmt.Sprintf("%v", marker)
+	request.params["limit"] = fmt.Sprintf("%v", limit)
+	respData, err := mc.serveRequest(request)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(respData, &inodes); err != nil {
+		return
+	}
+	return
+}
+
+// PurgeInode purges the deleted file by the leader of the meta partition without waiting for the retention.
+func (mc *MetaHttpClient) PurgeInode(pid, ino uint64) (err error) {
+	request := newAPIRequest(http.MethodGet, "/purgeInode")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	_, err = mc.serveRequest(request)
+	return
+}
+
+// CancelPurge holds the deleted file by the leader of the meta partition from being purged.
+func (mc *MetaHttpClient) CancelPurge(pid, ino uint64) (err error) {
+	request := newAPIRequest(http.MethodGet, "/cancelPurge")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	_, err = mc.serveRequest(request)
+	return
+}
+
+// GetExtentsByInode returns the extent keys of the inode in the meta partition replica.
+func (mc *MetaHttpClient) GetExtentsByInode(pid, ino uint64) (extents *proto.GetExtentsResponse, err error) {
+	request := newAPIRequest(http.MethodGet, "/getExtentsByInode")
+	request.params["pid"] = fmt.Sprintf("%v", pid)
+	request.params["ino"] = fmt.Sprintf("%v", ino)
+	respData, err := mc.serveOperationRequest(request)
+	if err != nil {
+		return
+	}
+	extents = &proto.GetExtentsResponse{}
+	if err = json.Unmarshal(respData, extents); err != nil {
+		return
+	}
+	return
+}
+
+// GetAllDentry returns all the dentries of the meta partition replica, which are streamed by the meta node.
+func (mc *MetaHttpClient) GetAllDentry(pid uint64) (dentryMap map[string]*metanode.Dentry, err error) {
 	defer func() {
 		if err != nil {
-			log.LogErrorf("action[GetAllInodes],pid:%v,err:%v", pid, err)
+			log.LogErrorf("action[GetAllDentry],pid:%v,err:%v", pid, err)
 		}
 	}()
-	reqURL := fmt.Sprintf("http://%v%v?pid=%v", mc.host, "/getAllInodes", pid)
-	log.LogDebugf("reqURL=%v", reqURL)
-	resp, err := http.Get(reqURL)
+	body, err := mc.dumpMeta("/dumpDentries", pid, nil)
 	if err != nil {
 		return
 	}
-	return unmarshalInodes(resp)
+	defer body.Close()
+	dentryMap = make(map[string]*metanode.Dentry, 0)
+	dec := json.NewDecoder(body)
+	dec.UseNumber()
+	for dec.More() {
+		dentry := &metanode.Dentry{}
+		if err = dec.Decode(dentry); err != nil {
+			return
+		}
+		dentryMap[fmt.Sprintf("%v_%v", dentry.ParentId, dentry.Name)] = dentry
+	}
+	return
 }
 
-func unmarshalInodes(resp *http.Response) (rstMap map[uint64]*Inode, err error) {
-	bufReader := bufio.NewReader(resp.Body)
-	rstMap = make(map[uint64]*Inode)
-	var buf []byte
-	for {
-		buf, err = bufReader.ReadBytes('\n')
-		log.LogInfof("buf[%v],err[%v]", string(buf), err)
-		if err != nil && err != io.EOF {
-			return
+// GetAllInodes returns all the inodes of the meta partition replica, which are streamed by the meta node.
+func (mc *MetaHttpClient) GetAllInodes(pid uint64) (rstMap map[uint64]*Inode, err error) {
+	defer func() {
+		if err != nil {
+			log.LogErrorf("action[GetAllInodes],pid:%v,err:%v", pid, err)
 		}
+	}()
+	body, err := mc.dumpMeta("/dumpInodes", pid, nil)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	rstMap = make(map[uint64]*Inode)
+	dec := json.NewDecoder(body)
+	for dec.More() {
 		inode := &Inode{}
-		if err1 := json.Unmarshal(buf, inode); err1 != nil {
-			err = err1
+		if err = dec.Decode(inode); err != nil {
 			return
 		}
 		rstMap[inode.Inode] = inode
-		log.LogInfof("after unmarshal current inode[%v]", inode)
-		if err == io.EOF {
-			err = nil
-			return
-		}
 	}
+	return
+}
+
+// DumpMeta writes the inodes or the dentries of the meta partition replica matching the filter to the writer as
+// JSON lines, the path is /dumpInodes or /dumpDentries. There is no timeout since a large partition takes a long time.
+func (mc *MetaHttpClient) DumpMeta(path string, pid uint64, filter map[string]string, w io.Writer) (err error) {
+	body, err := mc.dumpMeta(path, pid, filter)
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	_, err = io.Copy(w, body)
+	return
+}
+
+func (mc *MetaHttpClient) dumpMeta(path string, pid uint64, filter map[string]string) (body io.ReadCloser, err error) {
+	reqURL := mc.partitionURL(path, pid)
+	for key, value := range filter {
+		reqURL += fmt.Sprintf("&%v=%v", key, url.QueryEscape(value))
+	}
+	resp, err := http.Get(reqURL)
+	if err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("status[%v] body[%s]", resp.StatusCode, data)
+	}
+	return resp.Body, nil
+}
+
+// MetaPartitionImportResponse defines the result of importing a snapshot into a meta partition.
+type MetaPartitionImportResponse struct {
+	Header *proto.MetaPartitionExportHeader `json:"header"`
+	Result *proto.MetaPartitionImportResult `json:"result"`
+}
+
+func (mc *MetaHttpClient) partitionURL(path string, pid uint64) string {
+	schema := "http"
+	if mc.useSSL {
+		schema = "https"
+	}
+	return fmt.Sprintf("%s://%s%s?pid=%v", schema, mc.host, path, pid)
+}
+
+// ExportPartition writes the snapshot of the meta partition exported by the leader to the writer. There is no
+// timeout since the snapshot of a large partition takes a long time.
+func (mc *MetaHttpClient) ExportPartition(pid uint64, w io.Writer) (err error) {
+	resp, err := http.Get(mc.partitionURL("/exportPartition", pid))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("status[%v] body[%s]", resp.StatusCode, data)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return
+}
+
+// ImportPartition imports the snapshot read from the reader into the meta partition by the leader.
+func (mc *MetaHttpClient) ImportPartition(pid uint64, r io.Reader) (result *MetaPartitionImportResponse, err error) {
+	resp, err := http.Post(mc.partitionURL("/importPartition", pid), "application/octet-stream", r)
+	if err != nil {
+		return
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return
+	}
+	var body = &struct {
+		Code int32                        `json:"code"`
+		Msg  string                       `json:"msg"`
+		Data *MetaPartitionImportResponse `json:"data"`
+	}{}
+	if err = json.Unmarshal(data, body); err != nil {
+		return nil, fmt.Errorf("unmarshal response body err:%v", err)
+	}
+	if body.Code != http.StatusOK {
+		return nil, fmt.Errorf("code[%v] msg[%v]", body.Code, body.Msg)
+	}
+	return body.Data, nil
 }
@@ -32,19 +32,35 @@ var (
 func runCLI() (err error) {
 	var cfg *cmd.Config
 	if cfg, err = cmd.LoadConfig(); err != nil {
-		fmt.Printf("init cli log err[%v]", err)
+		err = cmd.NewArgumentError("load config failed: %v", err)
 		return
 	}
-	cfsCli := setupCommands(cfg)
+	var profileName = cmd.ProfileFromArgs(os.Args[1:])
+	cmd.InitAudit(cfg, profileName)
+	var profile *cmd.ProfileConfig
+	if profile, err = cfg.Profile(profileName); err != nil {
+		err = cmd.NewArgumentError("%v", err)
+		return
+	}
+	cfsCli, err := setupCommands(profile)
+	if err != nil {
+		err = cmd.NewArgumentError("%v", err)
+		return
+	}
+	// the commands exit by themselves on failures, so Execute only fails on the invalid commands, arguments or flags
 	if err = cfsCli.Execute(); err != nil {
 		log.LogErrorf("Command fail, err:%v", err)
+		err = cmd.NewArgumentError("%v", err)
 	}
 	return
 }
 
-func setupCommands(cfg *cmd.Config) *cobra.Command {
-	var mc = master.NewMasterClient(cfg.MasterAddr, false)
+func setupCommands(cfg *cmd.ProfileConfig) (*cobra.Command, error) {
+	var mc = master.NewMasterClient(cfg.MasterAddr, cfg.UseSSL)
 	mc.SetTimeout(cfg.Timeout)
+	if err := cfg.ApplyTo(mc); err != nil {
+		return nil, err
+	}
 	cfsRootCmd := cmd.NewRootCmd(mc)
 	var completionCmd = &cobra.Command{
 		Use:   "completion",
@@ -58,9 +74,10 @@ then execute the following command:
    $ source ~/.bashrc
 `,
 		Example: "cfs-cli completion",
-		Run: func(cmd *cobra.Command, args []string) {
+		Run: func(_ *cobra.Command, _ []string) {
 			if err := cfsRootCmd.CFSCmd.GenBashCompletionFile("cfs-cli.sh"); err != nil {
-				_, _ = fmt.Fprintf(os.Stdout, "generate bash file failed")
+				_, _ = fmt.Fprintf(os.Stderr, "generate bash file failed: %v\n", err)
+				cmd.OsExitWithLogFlush()
 			}
 			_, _ = fmt.Fprintf(os.Stdout, `File "cfs-cli.sh" has been generated successfully under the present working directory,
 following command to execute:
@@ -72,7 +89,7 @@ following command to execute:
 		},
 	}
 	cfsRootCmd.CFSCmd.AddCommand(completionCmd)
-	return cfsRootCmd.CFSCmd
+	return cfsRootCmd.CFSCmd, nil
 }
 
 func main() {
@@ -82,6 +99,6 @@ func main() {
 	if err = runCLI(); err != nil {
 		log.LogFlush()
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,182 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdAlertUse         = "alert [COMMAND]"
+	cmdAlertShort       = "Manage the alert rules of the master"
+	cmdAlertListShort   = "List the alert rules and the alerts whose conditions hold"
+	cmdAlertSetShort    = "Add an alert rule, or replace the alert rule of the same name"
+	cmdAlertDeleteShort = "Delete an alert rule"
+)
+
+func newAlertCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdAlertUse,
+		Short: cmdAlertShort,
+		Long: `The master evaluates the alert rules every minute. An alert of a rule on a target fires once the condition
+"metric operator threshold" of the target lasts for the duration, and is resolved once the condition no longer holds.
+The firing and the resolving of the alerts are emitted as the events of the master, which are pushed to the webhooks
+and the kafka topic configured on the master.`,
+	}
+	cmd.AddCommand(
+		newAlertListCmd(client),
+		newAlertSetCmd(client),
+		newAlertDeleteCmd(client),
+	)
+	return cmd
+}
+
+func newAlertListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdAlertListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.AlertRulesView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.AdminAPI().ListAlertRules(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("[Alert rules]\n")
+			stdout("%v\n", alertRuleTableHeader)
+			for _, rule := range view.Rules {
+				stdout("%v\n", formatAlertRule(rule))
+			}
+			stdout("\n[Alerts]\n")
+			stdout("%v\n", alertTableHeader)
+			for _, alert := range view.Alerts {
+				stdout("%v\n", formatAlert(alert))
+			}
+		},
+	}
+	return cmd
+}
+
+func newAlertSetCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optMetric    string
+		optOperator  string
+		optThreshold float64
+		optDuration  time.Duration
+		optDisable   bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [RULE NAME]",
+		Short: cmdAlertSetShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !cmd.Flags().Changed(CliFlagMetric) || !cmd.Flags().Changed(CliFlagThreshold) {
+				err = NewArgumentError("--%v and --%v are required", CliFlagMetric, CliFlagThreshold)
+				return
+			}
+			rule := &proto.AlertRule{
+				Name:      args[0],
+				Metric:    optMetric,
+				Operator:  optOperator,
+				Threshold: optThreshold,
+				Duration:  int64(optDuration / time.Second),
+				Disabled:  optDisable,
+			}
+			if err = client.AdminAPI().SetAlertRule(rule); err != nil {
+				return
+			}
+			stdout("Alert rule [%v] is set: %v\n", rule.Name, formatAlertCondition(rule))
+		},
+	}
+	cmd.Flags().StringVar(&optMetric, CliFlagMetric, "", fmt.Sprintf("Specify the metric [%v]", strings.Join(proto.AlertMetrics, " | ")))
+	cmd.Flags().StringVar(&optOperator, CliFlagOperator, ">", fmt.Sprintf("Specify the operator to compare the metric with the threshold [%v]", strings.Join(proto.AlertOperators, " | ")))
+	cmd.Flags().Float64Var(&optThreshold, CliFlagThreshold, 0, "Specify the threshold, the ratios are between 0 and 1")
+	cmd.Flags().DurationVar(&optDuration, CliFlagDuration, 0, "Specify how long the condition lasts before the alert fires")
+	cmd.Flags().BoolVar(&optDisable, CliFlagDisable, false, "Disable the rule")
+	return cmd
+}
+
+func newAlertDeleteCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [RULE NAME]",
+		Short: cmdAlertDeleteShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if err = client.AdminAPI().DeleteAlertRule(args[0]); err != nil {
+				return
+			}
+			stdout("Alert rule [%v] is deleted.\n", args[0])
+		},
+	}
+	return cmd
+}
+
+var (
+	alertRuleTablePattern = "%-24v    %-48v    %-10v    %-8v"
+	alertRuleTableHeader  = fmt.Sprintf(alertRuleTablePattern, "NAME", "CONDITION", "DURATION", "STATUS")
+	alertTablePattern     = "%-24v    %-24v    %-12v    %-19v    %-8v"
+	alertTableHeader      = fmt.Sprintf(alertTablePattern, "RULE", "TARGET", "VALUE", "SINCE", "STATUS")
+)
+
+func formatAlertCondition(rule *proto.AlertRule) string {
+	return fmt.Sprintf("%v %v %v", rule.Metric, rule.Operator, rule.Threshold)
+}
+
+func formatAlertRule(rule *proto.AlertRule) string {
+	var status = "Enabled"
+	if rule.Disabled {
+		status = "Disabled"
+	}
+	return fmt.Sprintf(alertRuleTablePattern, rule.Name, formatAlertCondition(rule),
+		time.Duration(rule.Duration)*time.Second, status)
+}
+
+func formatAlert(alert *proto.AlertView) string {
+	var status = "Pending"
+	if alert.Firing {
+		status = "Firing"
+	}
+	return fmt.Sprintf(alertTablePattern, alert.Rule, alert.Target, fmt.Sprintf("%.4g", alert.Value),
+		formatTime(alert.Since), status)
+}
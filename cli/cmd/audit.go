@@ -0,0 +1,320 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultAuditLogName = ".cfs-cli-audit.log"
+	auditRedactedValue  = "******"
+	defaultAuditSince   = 24 * time.Hour
+
+	cmdAuditUse       = "audit [COMMAND]"
+	cmdAuditShort     = "Query the audit records of the admin calls on the master"
+	cmdAuditListShort = "List the audit records since the time"
+)
+
+var (
+	// the commands which change the cluster
+	mutatingOps = map[string]bool{
+		CliOpCreate:            true,
+		CliOpDelete:            true,
+		CliOpAdd:               true,
+		CliOpSet:               true,
+		CliOpUpdate:            true,
+		CliOpPerm:              true,
+		CliOpTransfer:          true,
+		CliOpAddDataPartition:  true,
+		CliOpDecommission:      true,
+		CliOpDecommissionDisk:  true,
+		CliOpFreeze:            true,
+		CliOpSetThreshold:      true,
+		CliOpSetDelRate:        true,
+		CliOpReset:             true,
+		CliOpReplicate:         true,
+		CliOpDelReplica:        true,
+		CliOpExpand:            true,
+		CliOpShrink:            true,
+		CliOpTransferLeader:    true,
+		CliOpClone:             true,
+		CliOpRollingRestart:    true,
+		CliOpSupplement:        true,
+		CliOpVolDeletionDelay:  true,
+		CliOpMpSplitThreshold:  true,
+		CliOpDecommissionLimit: true,
+		CliOpRollback:          true,
+		CliOpRestore:           true,
+		CliOpStart:             true,
+		CliOpStop:              true,
+		CliOpPause:             true,
+		CliOpResume:            true,
+	}
+	// the commands which change the cluster only if the bool flag is set
+	mutatingFlags = map[string]string{
+		CliOpRepair:           CliFlagAuto,
+		CliOpOrphanPartitions: CliFlagClean,
+		CliOpCheckNLink:       CliFlagFix,
+	}
+	// the values of the flags are not written into the audit records
+	redactedFlags = []string{"password", "access-key", "secret-key"}
+
+	auditTablePattern = "%-19v    %-4v    %-24v    %-21v    %-40v    %-4v    %-8v    %v"
+	auditTableHeader  = fmt.Sprintf(auditTablePattern, "TIME", "SRC", "CALLER", "REMOTE", "API", "CODE", "DURATION", "PARAMS / RESULT")
+)
+
+// cliAudit records the mutating invocation of the command into the local audit file,
+// and optionally sends it to master.
+type cliAudit struct {
+	path     string
+	toMaster bool
+	profile  string
+	client   *master.MasterClient
+	record   *proto.CliAuditRecord
+	start    time.Time
+}
+
+var audit = &cliAudit{path: path.Join(defaultHomeDir, defaultAuditLogName)}
+
+// InitAudit applies the audit settings of the config file.
+func InitAudit(config *Config, profile string) {
+	if config.AuditLog != "" {
+		audit.path = config.AuditLog
+	}
+	audit.toMaster = config.AuditToMaster
+	if profile == "" {
+		profile = config.CurrentProfile
+	}
+	audit.profile = profile
+}
+
+func isMutatingCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == CliResourceConfig {
+			// the config commands only change the local config file
+			return false
+		}
+		if c.Name() == CliResourceDryRun {
+			// the dry runs only analyze the impact
+			return false
+		}
+	}
+	if dryRun, err := cmd.Flags().GetBool(CliFlagDryRun); err == nil && dryRun {
+		return false
+	}
+	if flag, ok := mutatingFlags[cmd.Name()]; ok {
+		enabled, err := cmd.Flags().GetBool(flag)
+		return err == nil && enabled
+	}
+	return mutatingOps[cmd.Name()]
+}
+
+// beginAudit starts the audit record if the command changes the cluster.
+func beginAudit(client *master.MasterClient, cmd *cobra.Command) {
+	if !isMutatingCommand(cmd) {
+		return
+	}
+	audit.client = client
+	audit.start = time.Now()
+	audit.record = &proto.CliAuditRecord{
+		Time:    audit.start.Unix(),
+		Profile: audit.profile,
+		Cluster: client.Nodes(),
+		Command: cmd.CommandPath(),
+		Args:    redactArgs(os.Args[1:]),
+	}
+	audit.record.User, audit.record.Host = currentCaller()
+}
+
+// currentCaller returns the operating system user who runs the command, and the host name.
+func currentCaller() (userName, host string) {
+	if u, err := user.Current(); err == nil {
+		userName = u.Username
+	}
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		userName = fmt.Sprintf("%v(sudo %v)", sudoUser, userName)
+	}
+	host, _ = os.Hostname()
+	return
+}
+
+// finishAudit writes the audit record with the result of the command. It does nothing if the command
+// is not audited or the record has been written.
+func finishAudit(exitCode int, result string) {
+	if audit.record == nil {
+		return
+	}
+	record := audit.record
+	audit.record = nil
+	record.ExitCode = exitCode
+	record.Result = strings.TrimSpace(result)
+	record.Duration = time.Since(audit.start).Milliseconds()
+	if err := appendAuditRecord(audit.path, record); err != nil {
+		log.LogErrorf("write audit record to [%v] failed: %v", audit.path, err)
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: write audit record to [%v] failed: %v\n", audit.path, err)
+	}
+	if audit.toMaster {
+		if err := audit.client.AdminAPI().RecordCliAudit(record); err != nil {
+			log.LogErrorf("send audit record to master failed: %v", err)
+			_, _ = fmt.Fprintf(os.Stderr, "Warning: send audit record to master failed: %v\n", err)
+		}
+	}
+}
+
+// appendAuditRecord appends the record to the file as a line of json.
+func appendAuditRecord(filePath string, record *proto.CliAuditRecord) (err error) {
+	var data []byte
+	if data, err = json.Marshal(record); err != nil {
+		return
+	}
+	var file *os.File
+	if file, err = os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = file.Write(append(data, '\n'))
+	return
+}
+
+// redactArgs hides the values of the secret flags, in both the "--flag value" and "--flag=value" forms.
+func redactArgs(args []string) (redacted []string) {
+	redacted = make([]string, len(args))
+	copy(redacted, args)
+	for i := 0; i < len(redacted); i++ {
+		for _, name := range redactedFlags {
+			flag := "--" + name
+			if redacted[i] == flag && i+1 < len(redacted) {
+				i++
+				redacted[i] = auditRedactedValue
+				break
+			}
+			if strings.HasPrefix(redacted[i], flag+"=") {
+				redacted[i] = flag + "=" + auditRedactedValue
+				break
+			}
+		}
+	}
+	return
+}
+
+func newAuditCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdAuditUse,
+		Short: cmdAuditShort,
+		Long: `The leader of the masters records every admin call which changes the cluster, such as creating or deleting
+the volumes, decommissioning, adding the replicas and changing the settings, with the caller, the parameters and the
+outcome. The mutating commands of the CLI are recorded too if "auditToMaster" is enabled in the config file. The
+records are kept for the days of "auditRetentionDays" in the config of the master.`,
+	}
+	cmd.AddCommand(
+		newAuditListCmd(client),
+	)
+	return cmd
+}
+
+func newAuditListCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optSince string
+		optLimit int
+	)
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdAuditListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err     error
+				since   time.Time
+				records []*proto.AuditRecord
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if since, err = parseAuditSince(optSince, time.Now()); err != nil {
+				err = NewArgumentError("%v", err)
+				return
+			}
+			if records, err = client.AdminAPI().ListAuditRecords(since.Unix(), optLimit); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(records)
+				return
+			}
+			stdout("%v\n", auditTableHeader)
+			for _, record := range records {
+				stdout("%v\n", formatAuditRecord(record))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optSince, CliFlagSince, defaultAuditSince.String(),
+		`List the records since the duration ago such as "2h", or since the time such as "2006-01-02 15:04:05"`)
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, 0, "Specify the number of the latest records to list, 0 for the default of the master")
+	return cmd
+}
+
+// parseAuditSince parses the duration before now, or the time in the local time zone.
+func parseAuditSince(value string, now time.Time) (since time.Time, err error) {
+	var duration time.Duration
+	if duration, err = time.ParseDuration(value); err == nil {
+		if duration < 0 {
+			return since, fmt.Errorf("invalid %v: %v", CliFlagSince, value)
+		}
+		return now.Add(-duration), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02", time.RFC3339} {
+		if since, err = time.ParseInLocation(layout, value, time.Local); err == nil {
+			return since, nil
+		}
+	}
+	return since, fmt.Errorf("invalid %v: %v", CliFlagSince, value)
+}
+
+func formatAuditRecord(record *proto.AuditRecord) string {
+	var detail = record.Result
+	if detail == "" {
+		params := make([]string, 0, len(record.Params))
+		for key, value := range record.Params {
+			params = append(params, fmt.Sprintf("%v=%v", key, value))
+		}
+		sort.Strings(params)
+		detail = strings.Join(params, " ")
+	}
+	var caller = record.Caller
+	if caller == "" {
+		caller = "-"
+	}
+	return fmt.Sprintf(auditTablePattern, formatTime(record.Time), record.Source, caller, record.Remote, record.API,
+		record.Code, fmt.Sprintf("%vms", record.Duration), detail)
+}
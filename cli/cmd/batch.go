@@ -0,0 +1,161 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+)
+
+const (
+	defaultBatchConcurrency = 4
+	defaultBatchRetry       = 3
+	batchRetryInterval      = 2 * time.Second
+)
+
+// readPartitionIDsFromFile reads partition IDs separated by spaces, commas or lines.
+// Empty lines and lines starting with "#" are ignored.
+func readPartitionIDsFromFile(filePath string) (ids []uint64, err error) {
+	var file *os.File
+	if file, err = os.Open(filePath); err != nil {
+		return
+	}
+	defer file.Close()
+	var (
+		scanner = bufio.NewScanner(file)
+		visited = make(map[uint64]bool)
+		lineNo  int
+	)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		for _, field := range fields {
+			var id uint64
+			if id, err = strconv.ParseUint(field, 10, 64); err != nil {
+				err = NewArgumentError("invalid partition id [%v] at line %v", field, lineNo)
+				return
+			}
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if len(ids) == 0 {
+		err = NewArgumentError("no partition id found in file [%v]", filePath)
+	}
+	return
+}
+
+// isTransientError returns true if the operation may succeed by retrying.
+func isTransientError(err error) bool {
+	switch err {
+	case master.ErrNoValidMaster, proto.ErrInternalError, proto.ErrNoLeader, proto.ErrPersistenceByRaft:
+		return true
+	default:
+		return false
+	}
+}
+
+type batchFailure struct {
+	PartitionID uint64
+	Error       string
+}
+
+type batchSummary struct {
+	Total     int
+	Succeeded []uint64
+	Failed    []batchFailure
+}
+
+// runBatchPartitionOperation runs the operation on each partition with bounded concurrency,
+// and the transient errors are retried.
+func runBatchPartitionOperation(ids []uint64, concurrency, retry int, op func(id uint64) error) (summary *batchSummary) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		limit = make(chan struct{}, concurrency)
+	)
+	summary = &batchSummary{Total: len(ids), Succeeded: make([]uint64, 0), Failed: make([]batchFailure, 0)}
+	for _, id := range ids {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(id uint64) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			var err error
+			for i := 0; i <= retry; i++ {
+				if i > 0 {
+					time.Sleep(batchRetryInterval * time.Duration(i))
+				}
+				if err = op(id); err == nil || !isTransientError(err) {
+					break
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				summary.Failed = append(summary.Failed, batchFailure{PartitionID: id, Error: err.Error()})
+				return
+			}
+			summary.Succeeded = append(summary.Succeeded, id)
+		}(id)
+	}
+	wg.Wait()
+	sort.Slice(summary.Succeeded, func(i, j int) bool { return summary.Succeeded[i] < summary.Succeeded[j] })
+	sort.Slice(summary.Failed, func(i, j int) bool { return summary.Failed[i].PartitionID < summary.Failed[j].PartitionID })
+	return
+}
+
+func printBatchSummary(action string, summary *batchSummary) (err error) {
+	if isStructuredOutput() {
+		return printStructured(summary)
+	}
+	stdout("%v finished: total %v, succeeded %v, failed %v\n",
+		action, summary.Total, len(summary.Succeeded), len(summary.Failed))
+	if len(summary.Failed) == 0 {
+		return
+	}
+	stdout("[Failed partitions]\n")
+	failureTablePattern := "%-12v    %v\n"
+	stdout(failureTablePattern, "PARTITION ID", "ERROR")
+	for _, failure := range summary.Failed {
+		stdout(failureTablePattern, failure.PartitionID, failure.Error)
+	}
+	return
+}
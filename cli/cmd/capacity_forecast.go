@@ -0,0 +1,102 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolCapacityForecastUse   = CliOpCapacityForecast + " [VOLUME NAME]"
+	cmdVolCapacityForecastShort = "Forecast the time the volumes and the zones become full"
+)
+
+var (
+	capacityForecastTablePattern = "%-20v    %-10v    %-10v    %-12v    %-12v    %-19v    %v"
+	capacityForecastTableHeader  = fmt.Sprintf(capacityForecastTablePattern, "NAME", "TOTAL", "USED", "GROWTH/DAY",
+		"DAYS TO FULL", "FULL TIME", "SAMPLES")
+)
+
+func newVolCapacityForecastCmd(client *master.MasterClient) *cobra.Command {
+	var optDays int
+	var cmd = &cobra.Command{
+		Use:   cmdVolCapacityForecastUse,
+		Short: cmdVolCapacityForecastShort,
+		Long: `Forecast the time the volume becomes full, or all the volumes and the zones if the volume is omitted,
+by the growth of the used space in the samples of the recent days. The samples are taken by the leader master
+every 10 minutes and start over after the leader changes.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				name string
+				view *proto.CapacityForecastView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(args) > 0 {
+				name = args[0]
+			}
+			if view, err = client.AdminAPI().GetCapacityForecast(name, optDays); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("[Volumes]\n")
+			stdout("%v\n", capacityForecastTableHeader)
+			for _, forecast := range view.Vols {
+				stdout("%v\n", formatCapacityForecast(forecast))
+			}
+			if name != "" {
+				return
+			}
+			stdout("\n[Zones]\n")
+			stdout("%v\n", capacityForecastTableHeader)
+			for _, forecast := range view.Zones {
+				stdout("%v\n", formatCapacityForecast(forecast))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().IntVar(&optDays, CliFlagDays, 0, "Specify the days of the recent samples to forecast by, 0 for the default of the master")
+	return cmd
+}
+
+func formatCapacityForecast(forecast *proto.CapacityForecast) string {
+	var growth = formatSize(uint64(forecast.GrowthPerDay))
+	if forecast.GrowthPerDay < 0 {
+		growth = "-" + formatSize(uint64(-forecast.GrowthPerDay))
+	}
+	var daysToFull, fullTime = "N/A", "N/A"
+	if forecast.DaysToFull >= 0 {
+		daysToFull = fmt.Sprintf("%.2f", forecast.DaysToFull)
+		fullTime = formatTime(forecast.FullTime)
+	}
+	return fmt.Sprintf(capacityForecastTablePattern, forecast.Name, formatSize(forecast.Total), formatSize(forecast.Used),
+		growth, daysToFull, fullTime, forecast.Samples)
+}
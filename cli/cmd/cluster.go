@@ -17,9 +17,12 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util"
 	"github.com/spf13/cobra"
 )
 
@@ -39,6 +42,21 @@ func (cmd *ChubaoFSCmd) newClusterCmd(client *master.MasterClient) *cobra.Comman
 		newClusterFreezeCmd(client),
 		newClusterSetThresholdCmd(client),
 		newClusterDeleteParasCmd(client),
+		newClusterOrphanPartitionsCmd(client),
+		newClusterStalePartitionsCmd(client),
+		newClusterHealthCmd(client),
+		newClusterSnapshotCmd(client),
+		newClusterDiffCmd(client),
+		newClusterRollingRestartCmd(client),
+		newClusterReplicaSupplementCmd(client),
+		newClusterVolDeletionDelayCmd(client),
+		newClusterMpSplitThresholdCmd(client),
+		newClusterDecommissionLimitCmd(client),
+		newClusterEventsCmd(client),
+		newClusterConfigCmd(client),
+		newClusterBackupCmd(client),
+		newClusterRestoreCmd(client),
+		newClusterFederationCmd(client),
 	)
 	return clusterCmd
 }
@@ -49,10 +67,25 @@ const (
 	cmdClusterFreezeShort    = "Freeze cluster"
 	cmdClusterThresholdShort = "Set memory threshold of metanodes"
 	cmdClusterDelParaShort   = "Set delete parameters"
+	cmdClusterOrphanShort    = "List the partition replicas on the nodes which are unknown to the master"
+	cmdClusterStaleShort     = "Show the replicas of the purged volumes found and deleted by the stale partition GC"
+	cmdClusterHealthShort    = "Show the health summary of the cluster"
+	cmdClusterRestartShort   = "Restart the meta nodes or the data nodes batch by batch"
+	cmdClusterReplicaShort   = "Set the limit of partitions recovering from automatic replica supplement"
+	cmdClusterVolDelayShort  = "Set the retention window of the deleted volumes"
+	cmdClusterMpSplitShort   = "Set the thresholds of a meta partition to trigger the split"
+	cmdClusterDecommShort    = "Set the concurrency and the bandwidth of the data node decommission"
 	nodeDeleteBatchCountKey  = "batchCount"
 	nodeMarkDeleteRateKey    = "markDeleteRate"
 	nodeDeleteWorkerSleepMs  = "deleteWorkerSleepMs"
 	nodeAutoRepairRateKey    = "autoRepairRate"
+	autoSupplementLimitKey   = "autoSupplementLimit"
+	autoSupplementingKey     = "autoSupplementing"
+	volDeletionDelayKey      = "volDeletionDelay"
+	mpSplitInodeCountKey     = "mpSplitInodeCount"
+	mpSplitMemoryKey         = "mpSplitMemory"
+	decommissionLimitKey     = "decommissionLimit"
+	decommissionBwKey        = "decommissionBandwidth"
 )
 
 func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
@@ -66,15 +99,29 @@ func newClusterInfoCmd(client *master.MasterClient) *cobra.Command {
 			if cv, err = client.AdminAPI().GetCluster(); err != nil {
 				errout("Error: %v", err)
 			}
-			stdout("[Cluster]\n")
-			stdout(formatClusterView(cv))
 			if delPara, err = client.AdminAPI().GetDeleteParas(); err != nil {
 				errout("Error: %v", err)
 			}
+			if isStructuredOutput() {
+				var info = struct {
+					Cluster     *proto.ClusterView
+					DeleteParas map[string]string
+				}{Cluster: cv, DeleteParas: delPara}
+				if err = printStructured(info); err != nil {
+					errout("Error: %v", err)
+				}
+				return
+			}
+			stdout("[Cluster]\n")
+			stdout(formatClusterView(cv))
 			stdout(fmt.Sprintf("  BatchCount         : %v\n", delPara[nodeDeleteBatchCountKey]))
 			stdout(fmt.Sprintf("  MarkDeleteRate     : %v\n", delPara[nodeMarkDeleteRateKey]))
 			stdout(fmt.Sprintf("  DeleteWorkerSleepMs: %v\n", delPara[nodeDeleteWorkerSleepMs]))
 			stdout(fmt.Sprintf("  AutoRepairRate     : %v\n", delPara[nodeAutoRepairRateKey]))
+			stdout("  AutoSupplement     : %v (recovering %v)\n", delPara[autoSupplementLimitKey], delPara[autoSupplementingKey])
+			stdout("  VolDeletionDelay   : %v\n", formatVolDeletionDelay(delPara[volDeletionDelayKey]))
+			stdout("  MpSplitThreshold   : %v\n", formatMpSplitThreshold(delPara[mpSplitInodeCountKey], delPara[mpSplitMemoryKey]))
+			stdout("  DecommissionLimit  : %v\n", formatDecommissionLimit(delPara[decommissionLimitKey], delPara[decommissionBwKey]))
 			stdout("\n")
 		},
 	}
@@ -96,7 +143,11 @@ func newClusterStatCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 			if cs, err = client.AdminAPI().GetClusterStat(); err != nil {
-				err = fmt.Errorf("Get cluster info fail:\n%v\n", err)
+				err = annotateError(err, "Get cluster info fail:\n%v\n", err)
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(cs)
 				return
 			}
 			stdout("[Cluster Status]\n")
@@ -130,7 +181,7 @@ If 'freeze=true', ChubaoFS WILL NOT automatically allocate new data partitions `
 				}
 			}()
 			if enable, err = strconv.ParseBool(args[0]); err != nil {
-				err = fmt.Errorf("Parse bool fail: %v\n", err)
+				err = NewArgumentError("Parse bool fail: %v\n", err)
 				return
 			}
 			if err = client.AdminAPI().IsFreezeCluster(enable); err != nil {
@@ -164,11 +215,11 @@ If the memory usage reaches this threshold, all the mata partition will be readO
 				}
 			}()
 			if threshold, err = strconv.ParseFloat(args[0], 64); err != nil {
-				err = fmt.Errorf("Parse Float fail: %v\n", err)
+				err = NewArgumentError("Parse Float fail: %v\n", err)
 				return
 			}
 			if threshold > 1.0 {
-				err = fmt.Errorf("Threshold too big\n")
+				err = NewArgumentError("Threshold too big\n")
 				return
 			}
 			if err = client.AdminAPI().SetMetaNodeThreshold(threshold); err != nil {
@@ -180,6 +231,216 @@ If the memory usage reaches this threshold, all the mata partition will be readO
 	return cmd
 }
 
+func newClusterReplicaSupplementCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpSupplement + " [LIMIT]",
+		Short: cmdClusterReplicaShort,
+		Args:  cobra.MinimumNArgs(1),
+		Long: `Set the max number of partitions recovering from the replicas added automatically by the master.
+The master checks the meta partitions and the data partitions lacking replicas periodically, and adds the
+lacked replicas on the nodes chosen by the zones of the remaining replicas and the available space of the
+nodes. Set the limit to 0 to disable the automatic replica supplement.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err   error
+				limit uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if limit, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				err = NewArgumentError("invalid limit [%v]", args[0])
+				return
+			}
+			if err = client.AdminAPI().SetAutoReplicaSupplementLimit(limit); err != nil {
+				return
+			}
+			if limit == 0 {
+				stdout("Automatic replica supplement is disabled.\n")
+				return
+			}
+			stdout("Automatic replica supplement limit is set to %v.\n", limit)
+		},
+	}
+	return cmd
+}
+
+func newClusterVolDeletionDelayCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpVolDeletionDelay + " [DURATION]",
+		Short: cmdClusterVolDelayShort,
+		Args:  cobra.MinimumNArgs(1),
+		Long: `Set how long the deleted volumes are kept before the partitions of them are deleted, for example "72h".
+The deleted volume is hidden from the volume list and can not be mounted, but can be restored by "volume restore"
+within the window. The window applies to the volumes already deleted as well. Set the window to 0 to purge the
+deleted volumes immediately.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err   error
+				delay time.Duration
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if delay, err = time.ParseDuration(args[0]); err != nil || delay < 0 {
+				err = NewArgumentError("invalid duration [%v]", args[0])
+				return
+			}
+			if err = client.AdminAPI().SetVolDeletionDelay(uint64(delay / time.Second)); err != nil {
+				return
+			}
+			if delay < time.Second {
+				stdout("Deleted volumes are purged immediately.\n")
+				return
+			}
+			stdout("Deleted volumes are kept for %v.\n", delay.Truncate(time.Second))
+		},
+	}
+	return cmd
+}
+
+func formatVolDeletionDelay(value string) string {
+	delay, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return value
+	}
+	if delay == 0 {
+		return "disabled"
+	}
+	return (time.Duration(delay) * time.Second).String()
+}
+
+func newClusterMpSplitThresholdCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optInodeCount uint64
+		optMemory     uint64
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpMpSplitThreshold,
+		Short: cmdClusterMpSplitShort,
+		Long: `Set the inodes and the estimated memory of a meta partition to trigger the split. The last meta partition of
+the volume reaching either threshold is split, and the other meta partitions reaching the thresholds are set read
+only, so that the new inodes are allocated in the new meta partition. The memory is estimated by the numbers of the
+inodes and the dentries. Set a threshold to 0 for no limit, the thresholds not specified are kept.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				delPara    map[string]string
+				inodeCount uint64
+				memory     uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !cmd.Flags().Changed(CliFlagInodeCount) && !cmd.Flags().Changed(CliFlagMemory) {
+				err = NewArgumentError("no threshold specified")
+				return
+			}
+			if delPara, err = client.AdminAPI().GetDeleteParas(); err != nil {
+				return
+			}
+			inodeCount, _ = strconv.ParseUint(delPara[mpSplitInodeCountKey], 10, 64)
+			memory, _ = strconv.ParseUint(delPara[mpSplitMemoryKey], 10, 64)
+			if cmd.Flags().Changed(CliFlagInodeCount) {
+				inodeCount = optInodeCount
+			}
+			if cmd.Flags().Changed(CliFlagMemory) {
+				memory = optMemory * util.GB
+			}
+			if err = client.AdminAPI().SetMetaPartitionSplitThreshold(inodeCount, memory); err != nil {
+				return
+			}
+			stdout("Meta partition split threshold is set to %v.\n",
+				formatMpSplitThreshold(strconv.FormatUint(inodeCount, 10), strconv.FormatUint(memory, 10)))
+		},
+	}
+	cmd.Flags().Uint64Var(&optInodeCount, CliFlagInodeCount, 0, "Specify the inodes of a meta partition to trigger the split, 0 for no limit")
+	cmd.Flags().Uint64Var(&optMemory, CliFlagMemory, 0, "Specify the memory of a meta partition to trigger the split, 0 for no limit [Unit: GB]")
+	return cmd
+}
+
+func formatMpSplitThreshold(inodeCount, memory string) string {
+	var thresholds []string
+	if v, err := strconv.ParseUint(inodeCount, 10, 64); err == nil && v > 0 {
+		thresholds = append(thresholds, fmt.Sprintf("%v inodes", v))
+	}
+	if v, err := strconv.ParseUint(memory, 10, 64); err == nil && v > 0 {
+		thresholds = append(thresholds, formatSize(v))
+	}
+	if len(thresholds) == 0 {
+		return "disabled"
+	}
+	return strings.Join(thresholds, " or ")
+}
+
+func newClusterDecommissionLimitCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optLimit     uint64
+		optBandwidth uint64
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpDecommissionLimit,
+		Short: cmdClusterDecommShort,
+		Long: `Set the max number of the data partitions migrating from a decommissioned data node or disk at the same time,
+and the bandwidth to repair the new replica of a migrated data partition. A partition is migrating until its new
+replica is recovered. The changes take effect on the decommissions in progress, and the data nodes pull the bandwidth
+from the master every minute. Set a limit to 0 for no limit, the limits not specified are kept.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err       error
+				delPara   map[string]string
+				limit     uint64
+				bandwidth uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !cmd.Flags().Changed(CliFlagLimit) && !cmd.Flags().Changed(CliFlagBandwidth) {
+				err = NewArgumentError("no limit specified")
+				return
+			}
+			if delPara, err = client.AdminAPI().GetDeleteParas(); err != nil {
+				return
+			}
+			limit, _ = strconv.ParseUint(delPara[decommissionLimitKey], 10, 64)
+			bandwidth, _ = strconv.ParseUint(delPara[decommissionBwKey], 10, 64)
+			if cmd.Flags().Changed(CliFlagLimit) {
+				limit = optLimit
+			}
+			if cmd.Flags().Changed(CliFlagBandwidth) {
+				bandwidth = optBandwidth
+			}
+			if err = client.AdminAPI().SetDecommissionControls(limit, bandwidth); err != nil {
+				return
+			}
+			stdout("Decommission limit is set to %v.\n",
+				formatDecommissionLimit(strconv.FormatUint(limit, 10), strconv.FormatUint(bandwidth, 10)))
+		},
+	}
+	cmd.Flags().Uint64Var(&optLimit, CliFlagLimit, 0, "Specify the max number of the partitions migrating from a node at the same time, 0 for no limit")
+	cmd.Flags().Uint64Var(&optBandwidth, CliFlagBandwidth, 0, "Specify the bandwidth to repair a migrated partition, 0 for no limit [Unit: byte/s]")
+	return cmd
+}
+
+func formatDecommissionLimit(limit, bandwidth string) string {
+	var limits = []string{"unlimited partitions", "unlimited bandwidth"}
+	if v, err := strconv.ParseUint(limit, 10, 64); err == nil && v > 0 {
+		limits[0] = fmt.Sprintf("%v partitions", v)
+	}
+	if v, err := strconv.ParseUint(bandwidth, 10, 64); err == nil && v > 0 {
+		limits[1] = formatSize(v) + "/s per partition"
+	}
+	return strings.Join(limits, ", ")
+}
+
 func newClusterDeleteParasCmd(client *master.MasterClient) *cobra.Command {
 	var optAutoRepairRate, optMarkDeleteRate, optDelBatchCount, optDelWorkerSleepMs string
 	var cmd = &cobra.Command{
@@ -208,3 +469,251 @@ func newClusterDeleteParasCmd(client *master.MasterClient) *cobra.Command {
 
 	return cmd
 }
+
+func newClusterOrphanPartitionsCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optClean bool
+		optYes   bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpOrphanPartitions,
+		Short: cmdClusterOrphanShort,
+		Long: `Cross-reference the partitions reported by the meta nodes and data nodes in the last heartbeat against the
+partitions of the master, and list the orphan replicas, which belong to unknown volumes or partitions, or are not
+members of their partitions. With the "--clean" flag, the orphan replicas are deleted from the nodes. The replicas
+being created may be reported as orphans for a short time, so check the list again before cleaning them.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err     error
+				orphans []*proto.OrphanPartitionView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if orphans, err = client.AdminAPI().ListOrphanPartitions(); err != nil {
+				return
+			}
+			if isStructuredOutput() && !optClean {
+				err = printStructured(orphans)
+				return
+			}
+			if !isStructuredOutput() {
+				stdout("%v\n", orphanPartitionTableHeader)
+				for _, orphan := range orphans {
+					stdout("%v\n", formatOrphanPartitionTableRow(orphan))
+				}
+				stdout("\nTotal: %v\n", len(orphans))
+			}
+			if !optClean || len(orphans) == 0 {
+				return
+			}
+			if !optYes {
+				if err = confirmBatchOperation("Delete the orphan replicas of", len(orphans)); err != nil {
+					return
+				}
+			}
+			summary := &batchSummary{Total: len(orphans), Succeeded: make([]uint64, 0), Failed: make([]batchFailure, 0)}
+			for _, orphan := range orphans {
+				if cleanErr := client.AdminAPI().CleanOrphanPartition(orphan.PartitionType, orphan.PartitionID, orphan.NodeAddr); cleanErr != nil {
+					summary.Failed = append(summary.Failed, batchFailure{PartitionID: orphan.PartitionID,
+						Error: fmt.Sprintf("%v: %v", orphan.NodeAddr, cleanErr)})
+				} else {
+					summary.Succeeded = append(summary.Succeeded, orphan.PartitionID)
+				}
+			}
+			if err = printBatchSummary("Clean", summary); err != nil {
+				return
+			}
+			if len(summary.Failed) > 0 {
+				err = newPartialFailureError("clean %v orphan replicas failed", len(summary.Failed))
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&optClean, CliFlagClean, false, "Delete the orphan replicas from the nodes")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newClusterStalePartitionsCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpStalePartitions,
+		Short: cmdClusterStaleShort,
+		Long: `The master scans the partitions reported by the nodes every 10 minutes, and deletes the replicas of the
+purged volumes which have been found for longer than the grace period, which is set by the "stalePartitionGracePeriod"
+parameter of the master. The replicas are only listed as pending if the grace period is 0.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.StalePartitionGCView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.AdminAPI().GetStalePartitionGC(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			if view.GracePeriod > 0 {
+				stdout("Grace period : %v\n", time.Duration(view.GracePeriod)*time.Second)
+			} else {
+				stdout("Grace period : disabled\n")
+			}
+			if view.LastScan > 0 {
+				stdout("Last scan    : %v\n", formatTime(view.LastScan))
+			} else {
+				stdout("Last scan    : never\n")
+			}
+			stdout("\nPending:\n%v\n", stalePartitionTableHeader)
+			for _, stale := range view.Pending {
+				stdout("%v\n", formatStalePartitionTableRow(stale, stale.FirstSeen))
+			}
+			stdout("\nReclaimed:\n%v\n", stalePartitionTableHeader)
+			for _, stale := range view.Reclaimed {
+				stdout("%v\n", formatStalePartitionTableRow(stale, stale.Reclaimed))
+			}
+		},
+	}
+	return cmd
+}
+
+func newClusterHealthCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optMaxLag    uint64
+		optThreshold float64
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpHealth,
+		Short: cmdClusterHealthShort,
+		Long: `Aggregate the inactive nodes, the corrupt and lack-replica partitions, the disks with errors, the raft
+followers lagging behind the leaders and the capacity pressure into a health summary. The command exits with 0 if
+the cluster is healthy, 6 if it is degraded and other codes if the check fails, so it can be used as a monitoring probe.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err    error
+				health *proto.ClusterHealth
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optThreshold < 0 || optThreshold > 1 {
+				err = NewArgumentError("invalid threshold [%v], should be in (0, 1]", optThreshold)
+				return
+			}
+			if health, err = client.AdminAPI().GetClusterHealth(optMaxLag, optThreshold); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(health)
+			} else {
+				stdout("%v", formatClusterHealth(health))
+			}
+			if err == nil && !health.Healthy {
+				osExitWithCode(ExitCodeDegraded)
+			}
+		},
+	}
+	cmd.Flags().Uint64Var(&optMaxLag, CliFlagMaxLag, 0, "Max applied index lag of the raft followers, 0 means the default of master")
+	cmd.Flags().Float64Var(&optThreshold, CliFlagThreshold, 0, "Max used ratio of the capacity, 0 means the default of master")
+	return cmd
+}
+
+func newClusterRollingRestartCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optRole        string
+		optBatch       int
+		optNodes       []string
+		optAgentPort   int
+		optNodeTimeout time.Duration
+		optYes         bool
+		optAsync       bool
+		optInterval    time.Duration
+		optTimeout     time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpRollingRestart,
+		Short: cmdClusterRestartShort,
+		Long: `Restart the meta nodes or the data nodes batch by batch, for example to upgrade the nodes. For each node,
+master transfers the raft leaders on the node to the other replicas, and requests the node agent on the host of the
+node to restart it. The next batch starts after the restarted nodes report heartbeats again and their replicas catch
+up with the leaders. The restart is executed by master in background, and the command waits for it to finish with
+the progress printed. With the "--async" flag, the command returns the task ID immediately.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				task *proto.AsyncTaskInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optRole != proto.MetaNode && optRole != proto.DataNode {
+				err = NewArgumentError("invalid role [%v], should be %v or %v", optRole, proto.MetaNode, proto.DataNode)
+				return
+			}
+			if optBatch <= 0 {
+				err = NewArgumentError("invalid batch [%v]", optBatch)
+				return
+			}
+			if optInterval <= 0 {
+				err = NewArgumentError("invalid interval [%v]", optInterval)
+				return
+			}
+			if !optYes {
+				nodes := "all the"
+				if len(optNodes) > 0 {
+					nodes = strconv.Itoa(len(optNodes))
+				}
+				stdout("Restart %v %vs, %v nodes per batch (yes/no)[no]: ", nodes, optRole, optBatch)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if task, err = client.AdminAPI().RollingRestart(optRole, optNodes, optBatch, optAgentPort, optNodeTimeout); err != nil {
+				return
+			}
+			if optAsync {
+				if isStructuredOutput() {
+					err = printStructured(task)
+					return
+				}
+				stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", task.ID, task.ID, task.ID)
+				return
+			}
+			if task, err = waitAsyncTask(client, task.ID, optInterval, optTimeout, newTaskProgressPrinter()); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(task)
+			} else {
+				stdout("[Task]\n")
+				stdout("%v", formatAsyncTaskInfo(task))
+			}
+			if err == nil && task.Status == proto.AsyncTaskFailed {
+				err = fmt.Errorf("task %v failed", task.ID)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optRole, CliFlagRole, "", "Role of the nodes to restart, metanode or datanode")
+	cmd.Flags().IntVar(&optBatch, CliFlagBatch, 1, "Number of the nodes restarted at the same time")
+	cmd.Flags().StringSliceVar(&optNodes, CliFlagNodes, nil, "Addresses of the nodes to restart, defaults to all the nodes of the role")
+	cmd.Flags().IntVar(&optAgentPort, CliFlagAgentPort, 0, "Port of the node agents, 0 means the default of master")
+	cmd.Flags().DurationVar(&optNodeTimeout, CliFlagNodeTimeout, 0, "Maximum time to wait for a batch to recover, 0 means the default of master")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Return the task ID without waiting for the restart to finish")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the task status")
+	cmd.Flags().DurationVar(&optTimeout, CliFlagTimeout, 0, "Maximum time to wait, 0 means no limit")
+	return cmd
+}
@@ -67,7 +67,7 @@ func newMetaCompatibilityCmd() *cobra.Command {
 			}()
 			id, err := strconv.ParseUint(pid, 10, 64)
 			if err != nil {
-				err = fmt.Errorf("parse pid[%v] failed: %v\n", pid, err)
+				err = NewArgumentError("parse pid[%v] failed: %v\n", pid, err)
 				return
 			}
 			cursor, err := client.GetMetaPartition(id)
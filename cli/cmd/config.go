@@ -20,7 +20,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"strings"
 
+	"github.com/chubaofs/chubaofs/proto"
+	authSDK "github.com/chubaofs/chubaofs/sdk/auth"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/auth"
 	"github.com/spf13/cobra"
 )
 
@@ -43,9 +49,100 @@ var (
 	defaultConfigTimeout uint16 = 60
 )
 
+const (
+	// the profile used if "--profile" is not specified, which overrides the current profile of the config file
+	envConfigProfile = "CFS_CLI_PROFILE"
+)
+
+// Config defines the config file. The top level master addresses and timeout are used if no profile is selected.
 type Config struct {
+	MasterAddr     []string                  `json:"masterAddr"`
+	Timeout        uint16                    `json:"timeout"`
+	CurrentProfile string                    `json:"currentProfile,omitempty"`
+	Profiles       map[string]*ProfileConfig `json:"profiles,omitempty"`
+	AuditLog       string                    `json:"auditLog,omitempty"`      // defaults to ~/.cfs-cli-audit.log
+	AuditToMaster  bool                      `json:"auditToMaster,omitempty"` // also send the audit records to master
+	CredentialConfig
+}
+
+// ProfileConfig defines the connection config of a named cluster.
+type ProfileConfig struct {
 	MasterAddr []string `json:"masterAddr"`
 	Timeout    uint16   `json:"timeout"`
+	UseSSL     bool     `json:"useSSL,omitempty"`
+	CredentialConfig
+}
+
+// CredentialConfig defines the credential to call the admin APIs if the access control is enabled on master,
+// either the token granted a role by the config of master, or the key of the client on the authnodes whose
+// caps grant the role.
+type CredentialConfig struct {
+	AuthToken string   `json:"authToken,omitempty"`
+	AuthNodes []string `json:"authNodes,omitempty"`
+	ClientID  string   `json:"clientID,omitempty"`
+	ClientKey string   `json:"clientKey,omitempty"`
+}
+
+// ApplyTo sets the credential to the master client, the ticket of the master service is got from the authnodes
+// if the client key is configured.
+func (c *CredentialConfig) ApplyTo(client *master.MasterClient) (err error) {
+	if c.AuthToken != "" {
+		client.SetAuthToken(c.AuthToken)
+	}
+	if c.ClientKey == "" {
+		return
+	}
+	if len(c.AuthNodes) == 0 || c.ClientID == "" {
+		return fmt.Errorf("authNodes and clientID are required by clientKey")
+	}
+	var ticket *auth.Ticket
+	if ticket, err = authSDK.NewAuthClient(c.AuthNodes, false, "").API().GetTicket(c.ClientID, c.ClientKey,
+		proto.MasterServiceID); err != nil {
+		return fmt.Errorf("get ticket from authnodes failed: %v", err)
+	}
+	return client.SetAuthTicket(c.ClientID, ticket.Ticket, ticket.SessionKey)
+}
+
+// optProfile is set by the global "--profile" flag.
+var optProfile string
+
+// Profile returns the config of the named profile. If the name is empty, the current profile is used,
+// and the top level config is used if there is no current profile.
+func (c *Config) Profile(name string) (profile *ProfileConfig, err error) {
+	if name == "" {
+		name = c.CurrentProfile
+	}
+	if name == "" {
+		profile = &ProfileConfig{MasterAddr: c.MasterAddr, Timeout: c.Timeout, CredentialConfig: c.CredentialConfig}
+		return
+	}
+	var ok bool
+	if profile, ok = c.Profiles[name]; !ok {
+		err = fmt.Errorf("profile [%v] not found", name)
+		return
+	}
+	if profile.Timeout == 0 {
+		profile.Timeout = defaultConfigTimeout
+	}
+	return
+}
+
+// ProfileFromArgs returns the profile specified by the "--profile" flag or the environment variable.
+// The master client is created before the flags are parsed, so the flag is looked up in the arguments directly.
+func ProfileFromArgs(args []string) string {
+	flag := "--" + CliFlagProfile
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"=")
+		}
+	}
+	return os.Getenv(envConfigProfile)
 }
 
 func newConfigCmd() *cobra.Command {
@@ -55,12 +152,18 @@ func newConfigCmd() *cobra.Command {
 	}
 	cmd.AddCommand(newConfigSetCmd())
 	cmd.AddCommand(newConfigInfoCmd())
+	cmd.AddCommand(newConfigSetProfileCmd())
+	cmd.AddCommand(newConfigUseProfileCmd())
+	cmd.AddCommand(newConfigDeleteProfileCmd())
 	return cmd
 }
 
 const (
-	cmdConfigSetShort  = "set value of config file"
-	cmdConfigInfoShort = "show info of config file"
+	cmdConfigSetShort           = "set value of config file"
+	cmdConfigInfoShort          = "show info of config file"
+	cmdConfigSetProfileShort    = "create or update a cluster profile"
+	cmdConfigUseProfileShort    = "set the current cluster profile"
+	cmdConfigDeleteProfileShort = "delete a cluster profile"
 )
 
 func newConfigSetCmd() *cobra.Command {
@@ -118,21 +221,176 @@ func newConfigInfoCmd() *cobra.Command {
 }
 
 func printConfigInfo(config *Config) {
+	if isStructuredOutput() {
+		if err := printStructured(config); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
 	stdout("Config info:\n")
 	stdout("  Master  Address    : %v\n", config.MasterAddr)
 	stdout("  Request Timeout [s]: %v\n", config.Timeout)
+	stdout("  Current Profile    : %v\n", config.CurrentProfile)
+	if len(config.Profiles) == 0 {
+		return
+	}
+	names := make([]string, 0, len(config.Profiles))
+	for name := range config.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	stdout("Profiles:\n")
+	for _, name := range names {
+		profile := config.Profiles[name]
+		stdout("  %v:\n", name)
+		stdout("    Master  Address    : %v\n", profile.MasterAddr)
+		stdout("    Request Timeout [s]: %v\n", profile.Timeout)
+		stdout("    Use SSL            : %v\n", formatYesNo(profile.UseSSL))
+	}
+}
+
+func newConfigSetProfileCmd() *cobra.Command {
+	var (
+		optMasterHosts string
+		optTimeout     uint16
+		optUseSSL      bool
+		optCurrent     bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpSetProfile + " [NAME]",
+		Short: cmdConfigSetProfileShort,
+		Long: `Create or update a named cluster profile. The profile is used by the commands with the "--profile" flag or the
+CFS_CLI_PROFILE environment variable, or by default if it is the current profile.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			name := args[0]
+			err = updateConfig(func(config *Config) error {
+				if config.Profiles == nil {
+					config.Profiles = make(map[string]*ProfileConfig)
+				}
+				profile, ok := config.Profiles[name]
+				if !ok {
+					if optMasterHosts == "" {
+						return fmt.Errorf("master address is required to create profile [%v]", name)
+					}
+					profile = &ProfileConfig{Timeout: defaultConfigTimeout}
+					config.Profiles[name] = profile
+				}
+				if optMasterHosts != "" {
+					profile.MasterAddr = strings.Split(optMasterHosts, ",")
+				}
+				if optTimeout != 0 {
+					profile.Timeout = optTimeout
+				}
+				if cmd.Flags().Changed(CliFlagSSL) {
+					profile.UseSSL = optUseSSL
+				}
+				if optCurrent {
+					config.CurrentProfile = name
+				}
+				return nil
+			})
+			if err != nil {
+				return
+			}
+			stdout("Profile [%v] has been set successfully!\n", name)
+		},
+	}
+	cmd.Flags().StringVar(&optMasterHosts, CliFlagAddress, "", "Specify master addresses separated by comma [{HOST}:{PORT}]")
+	cmd.Flags().Uint16Var(&optTimeout, "timeout", 0, "Specify timeout for requests [Unit: s]")
+	cmd.Flags().BoolVar(&optUseSSL, CliFlagSSL, false, "Use https to connect the master")
+	cmd.Flags().BoolVar(&optCurrent, CliFlagCurrent, false, "Set the profile as the current profile")
+	return cmd
+}
+
+func newConfigUseProfileCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpUseProfile + " [NAME]",
+		Short: cmdConfigUseProfileShort,
+		Long:  `Set the current cluster profile, an empty name "" means using the top level config.`,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			name := args[0]
+			err = updateConfig(func(config *Config) error {
+				if _, ok := config.Profiles[name]; name != "" && !ok {
+					return fmt.Errorf("profile [%v] not found", name)
+				}
+				config.CurrentProfile = name
+				return nil
+			})
+			if err != nil {
+				return
+			}
+			stdout("Current profile has been set to [%v] successfully!\n", name)
+		},
+	}
+	return cmd
+}
+
+func newConfigDeleteProfileCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpDeleteProfile + " [NAME]",
+		Short: cmdConfigDeleteProfileShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			name := args[0]
+			err = updateConfig(func(config *Config) error {
+				if _, ok := config.Profiles[name]; !ok {
+					return fmt.Errorf("profile [%v] not found", name)
+				}
+				delete(config.Profiles, name)
+				if config.CurrentProfile == name {
+					config.CurrentProfile = ""
+				}
+				return nil
+			})
+			if err != nil {
+				return
+			}
+			stdout("Profile [%v] has been deleted successfully!\n", name)
+		},
+	}
+	return cmd
 }
 
 func setConfig(masterHosts []string, timeout uint16) (err error) {
+	return updateConfig(func(config *Config) error {
+		if len(masterHosts) > 0 {
+			config.MasterAddr = masterHosts
+		}
+		if timeout != 0 {
+			config.Timeout = timeout
+		}
+		return nil
+	})
+}
+
+// updateConfig loads the config file, applies the update and writes it back.
+func updateConfig(update func(config *Config) error) (err error) {
 	var config *Config
 	if config, err = LoadConfig(); err != nil {
 		return
 	}
-	if len(masterHosts) > 0 {
-		config.MasterAddr = masterHosts
-	}
-	if timeout != 0 {
-		config.Timeout = timeout
+	if err = update(config); err != nil {
+		return
 	}
 	var configData []byte
 	if configData, err = json.Marshal(config); err != nil {
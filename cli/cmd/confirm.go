@@ -0,0 +1,76 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+)
+
+// confirmDataPartitionOperation shows the current replicas of the data partition and
+// asks the user to type the partition ID to confirm the operation.
+func confirmDataPartitionOperation(client *master.MasterClient, action string, partitionID uint64) (err error) {
+	var partition *proto.DataPartitionInfo
+	if partition, err = client.AdminAPI().GetDataPartition("", partitionID); err != nil {
+		return
+	}
+	stdout("Data partition [%v] of volume [%v], status [%v]:\n",
+		partition.PartitionID, partition.VolName, formatDataPartitionStatus(partition.Status))
+	stdout("%v\n", formatDataReplicaTableHeader())
+	for _, replica := range partition.Replicas {
+		stdout("%v\n", formatDataReplica("", replica, true))
+	}
+	return confirmPartitionID(action, partitionID)
+}
+
+// confirmMetaPartitionOperation shows the current replicas of the meta partition and
+// asks the user to type the partition ID to confirm the operation.
+func confirmMetaPartitionOperation(client *master.MasterClient, action string, partitionID uint64) (err error) {
+	var partition *proto.MetaPartitionInfo
+	if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
+		return
+	}
+	stdout("Meta partition [%v] of volume [%v], status [%v]:\n",
+		partition.PartitionID, partition.VolName, formatMetaPartitionStatus(partition.Status))
+	stdout("%v\n", formatMetaReplicaTableHeader())
+	for _, replica := range partition.Replicas {
+		stdout("%v\n", formatMetaReplica("", replica, true))
+	}
+	return confirmPartitionID(action, partitionID)
+}
+
+func confirmPartitionID(action string, partitionID uint64) (err error) {
+	stdout("%v, type the partition ID [%v] to confirm: ", action, partitionID)
+	var userConfirm string
+	_, _ = fmt.Scanln(&userConfirm)
+	if userConfirm != strconv.FormatUint(partitionID, 10) {
+		err = fmt.Errorf("Abort by user.\n")
+	}
+	return
+}
+
+// confirmBatchOperation asks the user to confirm the operation on a batch of partitions.
+func confirmBatchOperation(action string, count int) (err error) {
+	stdout("%v %v partitions (yes/no)[no]: ", action, count)
+	var userConfirm string
+	_, _ = fmt.Scanln(&userConfirm)
+	if userConfirm != "yes" {
+		err = fmt.Errorf("Abort by user.\n")
+	}
+	return
+}
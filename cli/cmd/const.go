@@ -36,6 +36,63 @@ const (
 	CliOpDelReplica        = "del-replica"
 	CliOpExpand              = "expand"
 	CliOpShrink              = "shrink"
+	CliOpWait              = "wait"
+	CliOpSetProfile        = "set-profile"
+	CliOpUseProfile        = "use-profile"
+	CliOpDeleteProfile     = "delete-profile"
+	CliOpRepair            = "repair"
+	CliOpPartitions        = "partitions"
+	CliOpVerify            = "verify"
+	CliOpOrphanPartitions  = "orphan-partitions"
+	CliOpStalePartitions   = "stale-partitions"
+	CliOpDecommissionDisk  = "decommission-disk"
+	CliOpTransferLeader    = "transfer-leader"
+	CliOpHealth            = "health"
+	CliOpUpdate            = "update"
+	CliOpPerm              = "perm"
+	CliOpTransfer          = "transfer"
+	CliOpAddDataPartition  = "add-dp"
+	CliOpSnapshot          = "snapshot"
+	CliOpDiff              = "diff"
+	CliOpClone             = "clone"
+	CliOpRollback          = "rollback"
+	CliOpRestore           = "restore"
+	CliOpVolDeletionDelay  = "vol-deletion-delay"
+	CliOpPath              = "path"
+	CliOpRollingRestart    = "rolling-restart"
+	CliOpSupplement        = "replica-supplement"
+	CliOpMpSplitThreshold  = "mp-split-threshold"
+	CliOpDecommissionLimit = "decommission-limit"
+	CliOpEvents            = "events"
+	CliOpRebalance         = "rebalance"
+	CliOpPause             = "pause"
+	CliOpResume            = "resume"
+	CliOpStart             = "start"
+	CliOpStop              = "stop"
+	CliOpConfig            = "config"
+	CliOpBackup            = "backup"
+	CliOpCapacityForecast  = "capacity-forecast"
+	CliOpReplication       = "replication"
+	CliOpSetLabels         = "set-labels"
+	CliOpFailover          = "failover"
+	CliOpFederation        = "federation"
+	CliOpRemove            = "remove"
+	CliOpSetStatus         = "set-status"
+	CliOpRotateKey         = "rotate-key"
+	CliOpRetireDisk        = "retire-disk"
+	CliOpXAttr             = "xattr"
+	CliOpACL               = "acl"
+	CliOpDu                = "du"
+	CliOpOrphanInodes      = "orphan-inodes"
+	CliOpExport            = "export"
+	CliOpImport            = "import"
+	CliOpDeletedInodes     = "deleted-inodes"
+	CliOpPurgeInode        = "purge-inode"
+	CliOpCancelPurge       = "cancel-purge"
+	CliOpRaftStatus        = "raft-status"
+	CliOpCheckNLink        = "check-nlink"
+	CliOpNLinkReport       = "nlink-report"
+	CliOpDump              = "dump"
 
 	//Shorthand format of operation name
 	CliOpDecommissionShortHand = "dec"
@@ -49,6 +106,7 @@ const (
 	CliResourceRaftNode      = "raftnode"
 	CliResourceDisk          = "disk"
 	CliResourceConfig        = "config"
+	CliResourceDryRun        = "dryrun"
 
 	//Flags
 	CliFlagName               = "name"
@@ -73,6 +131,90 @@ const (
 	CliFlagDelBatchCount      = "delete-batch-count"
 	CliFlagDelWorkerSleepMs   = "delete-worker-sleep-ms"
 	CliFlagMarkDelRate        = "mark-delete-rate"
+	CliFlagForce              = "force"
+	CliFlagOutput             = "output"
+	CliFlagFromFile           = "from-file"
+	CliFlagConcurrency        = "concurrency"
+	CliFlagRetry              = "retry"
+	CliFlagWatch              = "watch"
+	CliFlagCached             = "cached"
+	CliFlagInterval           = "interval"
+	CliFlagAsync              = "async"
+	CliFlagTimeout            = "timeout"
+	CliFlagVol                = "vol"
+	CliFlagStatus             = "status"
+	CliFlagNode               = "node"
+	CliFlagOffset             = "offset"
+	CliFlagLimit              = "limit"
+	CliFlagProfile            = "profile"
+	CliFlagSSL                = "ssl"
+	CliFlagCurrent            = "current"
+	CliFlagDryRun             = "dry-run"
+	CliFlagAuto               = "auto"
+	CliFlagExport             = "export"
+	CliFlagProfPort           = "prof-port"
+	CliFlagIgnoreRecent       = "ignore-recent"
+	CliFlagBlock              = "block"
+	CliFlagMaxLag             = "max-lag"
+	CliFlagClean              = "clean"
+	CliFlagSave               = "save"
+	CliFlagOwner              = "owner"
+	CliFlagRegenerateKeys     = "regenerate-keys"
+	CliFlagDataNode           = "data-node"
+	CliFlagMetaNode           = "meta-node"
+	CliFlagRole               = "role"
+	CliFlagBatch              = "batch"
+	CliFlagNodes              = "nodes"
+	CliFlagAgentPort          = "agent-port"
+	CliFlagNodeTimeout        = "node-timeout"
+	CliFlagPageSize           = "page-size"
+	CliFlagPlacementPolicy    = "placement-policy"
+	CliFlagPlacementZone      = "placement-zone"
+	CliFlagPlacementLabels    = "placement-labels"
+	CliFlagReadIops           = "read-iops"
+	CliFlagWriteIops          = "write-iops"
+	CliFlagReadBandwidth      = "read-bandwidth"
+	CliFlagWriteBandwidth     = "write-bandwidth"
+	CliFlagVolCount           = "vol-count"
+	CliFlagMaxBytes           = "max-bytes"
+	CliFlagMaxFiles           = "max-files"
+	CliFlagDeleted            = "deleted"
+	CliFlagTrashTTL           = "trash-ttl"
+	CliFlagMetaStore          = "meta-store"
+	CliFlagInodeRetention     = "inode-retention"
+	CliFlagBasis              = "basis"
+	CliFlagStorageClass       = "storage-class"
+	CliFlagIPAllow            = "ip-allow"
+	CliFlagIPDeny             = "ip-deny"
+	CliFlagInodeCount         = "inode-count"
+	CliFlagMemory             = "memory"
+	CliFlagHighRatio          = "high-ratio"
+	CliFlagLowRatio           = "low-ratio"
+	CliFlagBandwidth          = "bandwidth"
+	CliFlagType               = "type"
+	CliFlagMetric             = "metric"
+	CliFlagOperator           = "operator"
+	CliFlagDuration           = "duration"
+	CliFlagDisable            = "disable"
+	CliFlagSince              = "since"
+	CliFlagKey                = "key"
+	CliFlagTime               = "time"
+	CliFlagDays               = "days"
+	CliFlagTargetMaster       = "target-master"
+	CliFlagRegion             = "region"
+	CliFlagMasters            = "masters"
+	CliFlagReason             = "reason"
+	CliFlagCaseInsensitive    = "case-insensitive"
+	CliFlagVerifyReadCrc      = "verify-read-crc"
+	CliFlagCompression        = "compression"
+	CliFlagEncrypted          = "encrypted"
+	CliFlagCancel             = "cancel"
+	CliFlagFix                = "fix"
+	CliFlagDentry             = "dentry"
+	CliFlagStart              = "start"
+	CliFlagEnd                = "end"
+	CliFlagModifiedAfter      = "mtime-after"
+	CliFlagModifiedBefore     = "mtime-before"
 
 	//CliFlagSetDataPartitionCount	= "count" use dp-count instead
 
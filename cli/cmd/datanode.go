@@ -15,8 +15,10 @@
 package cmd
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
@@ -36,6 +38,14 @@ func newDataNodeCmd(client *master.MasterClient) *cobra.Command {
 		newDataNodeListCmd(client),
 		newDataNodeInfoCmd(client),
 		newDataNodeDecommissionCmd(client),
+		newDataNodeDecommissionDiskCmd(client),
+		newDataNodeRetireDiskCmd(client),
+		newDataNodePartitionsCmd(client),
+		newNodeSetLabelsCmd(&nodeLabelAPI{
+			nodeType:   "data",
+			set:        client.NodeAPI().SetDataNodeLabels,
+			validNodes: func(toComplete string) []string { return validDataNodes(client, toComplete) },
+		}),
 	)
 	return cmd
 }
@@ -44,6 +54,9 @@ const (
 	cmdDataNodeListShort             = "List information of data nodes"
 	cmdDataNodeInfoShort             = "Show information of a data node"
 	cmdDataNodeDecommissionInfoShort = "decommission partitions in a data node to others"
+	cmdDataNodePartitionsShort       = "List the data partitions hosted on a data node"
+	cmdDataNodeDecommissionDiskShort = "Migrate all the data partitions off a disk of a data node"
+	cmdDataNodeRetireDiskShort       = "Mark a disk of a data node retiring, or cancel it"
 )
 
 func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -67,8 +80,7 @@ func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
 			sort.SliceStable(view.DataNodes, func(i, j int) bool {
 				return view.DataNodes[i].ID < view.DataNodes[j].ID
 			})
-			stdout("[Data nodes]\n")
-			stdout("%v\n", formatNodeViewTableHeader())
+			var nodes = make([]proto.NodeView, 0, len(view.DataNodes))
 			for _, node := range view.DataNodes {
 				if optFilterStatus != "" &&
 					!strings.Contains(formatNodeStatus(node.Status), optFilterStatus) {
@@ -78,6 +90,15 @@ func newDataNodeListCmd(client *master.MasterClient) *cobra.Command {
 					!strings.Contains(formatYesNo(node.IsWritable), optFilterWritable) {
 					continue
 				}
+				nodes = append(nodes, node)
+			}
+			if isStructuredOutput() {
+				err = printStructured(nodes)
+				return
+			}
+			stdout("[Data nodes]\n")
+			stdout("%v\n", formatNodeViewTableHeader())
+			for _, node := range nodes {
 				stdout("%v\n", formatNodeView(&node, true))
 			}
 		},
@@ -105,6 +126,10 @@ func newDataNodeInfoCmd(client *master.MasterClient) *cobra.Command {
 			if datanodeInfo, err = client.NodeAPI().GetDataNode(nodeAddr); err != nil {
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(datanodeInfo)
+				return
+			}
 			stdout("[Data node info]\n")
 			stdout(formatDataNodeDetail(datanodeInfo, false))
 
@@ -148,3 +173,175 @@ func newDataNodeDecommissionCmd(client *master.MasterClient) *cobra.Command {
 	}
 	return cmd
 }
+
+func newDataNodeDecommissionDiskCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optAsync    bool
+		optYes      bool
+		optInterval time.Duration
+		optTimeout  time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpDecommissionDisk + " [NODE ADDRESS] [DISK PATH]",
+		Short: cmdDataNodeDecommissionDiskShort,
+		Long: `Migrate the replicas of all the data partitions on a failing disk to other data nodes, while the other
+disks of the node keep serving. The migration is executed by master in background, and the command waits for it to
+finish with the progress printed. With the "--async" flag, the command returns the task ID immediately, which can
+be checked by the "task" commands. The partitions which fail to migrate are reported in the error of the task.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				partitions []*proto.NodePartitionView
+				task       *proto.AsyncTaskInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			nodeAddr, diskPath := args[0], args[1]
+			if optInterval <= 0 {
+				err = NewArgumentError("invalid interval [%v]", optInterval)
+				return
+			}
+			if !optYes {
+				if partitions, err = client.NodeAPI().GetDataNodePartitions(nodeAddr); err != nil {
+					return
+				}
+				var count int
+				for _, partition := range partitions {
+					if partition.DiskPath == diskPath {
+						count++
+					}
+				}
+				if err = confirmBatchOperation(fmt.Sprintf("Migrate off disk %v of data node %v", diskPath, nodeAddr), count); err != nil {
+					return
+				}
+			}
+			if task, err = client.NodeAPI().DataNodeDiskDecommission(nodeAddr, diskPath); err != nil {
+				return
+			}
+			if optAsync {
+				if isStructuredOutput() {
+					err = printStructured(task)
+					return
+				}
+				stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", task.ID, task.ID, task.ID)
+				return
+			}
+			if task, err = waitAsyncTask(client, task.ID, optInterval, optTimeout, newTaskProgressPrinter()); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(task)
+			} else {
+				stdout("[Task]\n")
+				stdout("%v", formatAsyncTaskInfo(task))
+			}
+			if err == nil && task.Status == proto.AsyncTaskFailed {
+				err = fmt.Errorf("task %v failed", task.ID)
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Return the task ID without waiting for the migration to finish")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the task status")
+	cmd.Flags().DurationVar(&optTimeout, CliFlagTimeout, 0, "Maximum time to wait, 0 means no limit")
+	return cmd
+}
+
+func newDataNodeRetireDiskCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optCancel bool
+		optReason string
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpRetireDisk + " [NODE ADDRESS] [DISK PATH]",
+		Short: cmdDataNodeRetireDiskShort,
+		Long: `Mark a disk of a data node retiring, no data partition is created on it, and the data partitions on it are
+migrated off by master in background unless master is configured not to. The disks whose failure is predicted by
+their SMART attributes are retired by master automatically. With the "--cancel" flag, the disk is not retiring any
+more, e.g. after it is replaced.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			nodeAddr, diskPath := args[0], args[1]
+			if err = client.NodeAPI().SetDiskRetiring(nodeAddr, diskPath, !optCancel, optReason); err != nil {
+				return
+			}
+			if optCancel {
+				stdout("Disk %v of data node %v is not retiring now\n", diskPath, nodeAddr)
+				return
+			}
+			stdout("Disk %v of data node %v is retiring now\n", diskPath, nodeAddr)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVar(&optCancel, CliFlagCancel, false, "Cancel the retiring of the disk")
+	cmd.Flags().StringVar(&optReason, CliFlagReason, "", "Specify why the disk is retired")
+	return cmd
+}
+
+func newDataNodePartitionsCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpPartitions + " [NODE ADDRESS]",
+		Short: cmdDataNodePartitionsShort,
+		Long: `List all the data partitions hosted on the node with the role and the status of the replicas, which is
+useful for planning the decommission of the node.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				partitions []*proto.NodePartitionView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitions, err = client.NodeAPI().GetDataNodePartitions(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(partitions)
+				return
+			}
+			var leaders, missing int
+			stdout("%v\n", dataNodePartitionTableHeader)
+			for _, partition := range partitions {
+				if partition.IsLeader {
+					leaders++
+				}
+				if partition.IsMissing {
+					missing++
+				}
+				stdout("%v\n", formatDataNodePartitionTableRow(partition))
+			}
+			stdout("\nTotal: %v, leaders: %v, missing: %v\n", len(partitions), leaders, missing)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
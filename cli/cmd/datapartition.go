@@ -21,6 +21,7 @@ import (
 	"github.com/spf13/cobra"
 	"sort"
 	"strconv"
+	"time"
 )
 
 const (
@@ -39,17 +40,27 @@ func newDataPartitionCmd(client *master.MasterClient) *cobra.Command {
 		newDataPartitionDecommissionCmd(client),
 		newDataPartitionReplicateCmd(client),
 		newDataPartitionDeleteReplicaCmd(client),
+		newDataPartitionResetCmd(client),
+		newDataPartitionRepairCmd(client),
+		newDataPartitionVerifyCmd(client),
+		newDataPartitionTransferLeaderCmd(client),
+		newDataPartitionSetStatusCmd(client),
 	)
 	return cmd
 }
 
 const (
-	cmdDataPartitionGetShort              = "Display detail information of a data partition"
-	cmdCheckCorruptDataPartitionShort     = "Check and list unhealthy data partitions"
-	cmdDataPartitionDecommissionShort     = "Decommission a replication of the data partition to a new address"
-	cmdDataPartitionReplicateShort        = "Add a replication of the data partition on a new address"
-	cmdDataPartitionDeleteReplicaShort    = "Delete a replication of the data partition on a fixed address"
-	)
+	cmdDataPartitionGetShort            = "Display detail information of a data partition"
+	cmdCheckCorruptDataPartitionShort   = "Check and list unhealthy data partitions"
+	cmdDataPartitionDecommissionShort   = "Decommission a replication of the data partition to a new address"
+	cmdDataPartitionReplicateShort      = "Add a replication of the data partition on a new address"
+	cmdDataPartitionDeleteReplicaShort  = "Delete a replication of the data partition on a fixed address"
+	cmdDataPartitionResetShort          = "Reset the raft members of a corrupt data partition to the remaining replicas"
+	cmdDataPartitionRepairShort         = "Add the lacked replicas of the data partitions found by check"
+	cmdDataPartitionVerifyShort         = "Verify the consistency of the extents among the replicas of a data partition"
+	cmdDataPartitionTransferLeaderShort = "Transfer the raft leader of a data partition to the replica on a node"
+	cmdDataPartitionSetStatusShort      = "Force a data partition read-only or writable, or return it to the automatic status"
+)
 
 func newDataPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 	var cmd = &cobra.Command{
@@ -73,6 +84,10 @@ func newDataPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 			if partition, err = client.AdminAPI().GetDataPartition("", partitionID); err != nil {
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(partition)
+				return
+			}
 			stdout(formatDataPartitionInfo(partition))
 		},
 	}
@@ -80,6 +95,10 @@ func newDataPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 }
 
 func newListCorruptDataPartitionCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optWatch    bool
+		optInterval time.Duration
+	)
 	var cmd = &cobra.Command{
 		Use:   CliOpCheck,
 		Short: cmdCheckCorruptDataPartitionShort,
@@ -87,93 +106,149 @@ func newListCorruptDataPartitionCmd(client *master.MasterClient) *cobra.Command
 eliminate the network, disk or other problems first. Once the bad nodes can never be "active", they are called corrupt 
 nodes. The "decommission" command can be used to discard the corrupt nodes. However, if more than half replicas of
 a partition are on the corrupt nodes, the few remaining replicas can not reach an agreement with one leader. In this case, 
-you can use the "reset" command to fix the problem.The "reset" command may lead to data loss, be careful to do this.
-The "reset" command will be released in next version`,
+you can use the "reset" command to fix the problem.The "reset" command may lead to data loss, be careful to do this.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			var (
-				diagnosis     *proto.DataPartitionDiagnosis
-				dataNodes     []*proto.DataNodeInfo
-				err           error
-			)
+			var err error
 			defer func() {
 				if err != nil {
 					errout("Error: %v", err)
 				}
 			}()
-			if diagnosis, err = client.AdminAPI().DiagnoseDataPartition(); err != nil {
+			if !optWatch {
+				_, err = checkCorruptDataPartitions(client)
 				return
 			}
-			stdout("[Inactive Data nodes]:\n")
-			stdout("%v\n", formatDataNodeDetailTableHeader())
-			for _, addr := range diagnosis.InactiveDataNodes {
-				var node *proto.DataNodeInfo
-				if node, err = client.NodeAPI().GetDataNode(addr); err != nil {
-					return
-				}
-				dataNodes = append(dataNodes, node)
-			}
-			sort.SliceStable(dataNodes, func(i, j int) bool {
-				return dataNodes[i].ID < dataNodes[j].ID
-			})
-			for _, node := range dataNodes {
-				stdout("%v\n", formatDataNodeDetail(node, true))
-			}
-			stdout("\n")
-			stdout("[Corrupt data partitions](no leader):\n")
-			stdout("%v\n", partitionInfoTableHeader)
-			sort.SliceStable(diagnosis.CorruptDataPartitionIDs, func(i, j int) bool {
-				return diagnosis.CorruptDataPartitionIDs[i] < diagnosis.CorruptDataPartitionIDs[j]
+			err = watchPartitionDiagnosis(optInterval, func() ([]uint64, error) {
+				return checkCorruptDataPartitions(client)
 			})
-			for _, pid := range diagnosis.CorruptDataPartitionIDs {
-				var partition *proto.DataPartitionInfo
-				if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
-					err = fmt.Errorf("Partition not found, err:[%v] ", err)
-					return
-				}
-				stdout("%v\n", formatDataPartitionInfoRow(partition))
-			}
+		},
+	}
+	cmd.Flags().BoolVarP(&optWatch, CliFlagWatch, "w", false, "Re-run the diagnosis periodically and show the changes")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultWatchInterval, "Interval of re-running the diagnosis with --watch")
+	return cmd
+}
 
-			stdout("\n")
-			stdout("%v\n", "[Partition lack replicas]:")
-			stdout("%v\n", partitionInfoTableHeader)
-			sort.SliceStable(diagnosis.LackReplicaDataPartitionIDs, func(i, j int) bool {
-				return diagnosis.LackReplicaDataPartitionIDs[i] < diagnosis.LackReplicaDataPartitionIDs[j]
-			})
-			for _, pid := range diagnosis.LackReplicaDataPartitionIDs {
-				var partition *proto.DataPartitionInfo
-				if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
-					err = fmt.Errorf("Partition not found, err:[%v] ", err)
-					return
-				}
-				if partition != nil {
-					stdout("%v\n", formatDataPartitionInfoRow(partition))
-				}
-			}
+// checkCorruptDataPartitions prints the diagnosis and returns the IDs of the unhealthy partitions.
+func checkCorruptDataPartitions(client *master.MasterClient) (unhealthyIDs []uint64, err error) {
+	var (
+		diagnosis *proto.DataPartitionDiagnosis
+		dataNodes []*proto.DataNodeInfo
+	)
+	if diagnosis, err = client.AdminAPI().DiagnoseDataPartition(); err != nil {
+		return
+	}
+	unhealthyIDs = append(unhealthyIDs, diagnosis.CorruptDataPartitionIDs...)
+	unhealthyIDs = append(unhealthyIDs, diagnosis.LackReplicaDataPartitionIDs...)
+	if isStructuredOutput() {
+		err = printStructured(diagnosis)
+		return
+	}
+	stdout("[Inactive Data nodes]:\n")
+	stdout("%v\n", formatDataNodeDetailTableHeader())
+	for _, addr := range diagnosis.InactiveDataNodes {
+		var node *proto.DataNodeInfo
+		if node, err = client.NodeAPI().GetDataNode(addr); err != nil {
+			return
+		}
+		dataNodes = append(dataNodes, node)
+	}
+	sort.SliceStable(dataNodes, func(i, j int) bool {
+		return dataNodes[i].ID < dataNodes[j].ID
+	})
+	for _, node := range dataNodes {
+		stdout("%v\n", formatDataNodeDetail(node, true))
+	}
+	stdout("\n")
+	stdout("[Corrupt data partitions](no leader):\n")
+	stdout("%v\n", partitionInfoTableHeader)
+	sort.SliceStable(diagnosis.CorruptDataPartitionIDs, func(i, j int) bool {
+		return diagnosis.CorruptDataPartitionIDs[i] < diagnosis.CorruptDataPartitionIDs[j]
+	})
+	for _, pid := range diagnosis.CorruptDataPartitionIDs {
+		var partition *proto.DataPartitionInfo
+		if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
+			err = annotateError(err, "Partition not found, err:[%v] ", err)
+			return
+		}
+		stdout("%v\n", formatDataPartitionInfoRow(partition))
+	}
 
+	stdout("\n")
+	stdout("%v\n", "[Partition lack replicas]:")
+	stdout("%v\n", partitionInfoTableHeader)
+	sort.SliceStable(diagnosis.LackReplicaDataPartitionIDs, func(i, j int) bool {
+		return diagnosis.LackReplicaDataPartitionIDs[i] < diagnosis.LackReplicaDataPartitionIDs[j]
+	})
+	for _, pid := range diagnosis.LackReplicaDataPartitionIDs {
+		var partition *proto.DataPartitionInfo
+		if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
+			err = annotateError(err, "Partition not found, err:[%v] ", err)
+			return
+		}
+		if partition != nil {
+			stdout("%v\n", formatDataPartitionInfoRow(partition))
+		}
+	}
 
-			stdout("\n")
-			stdout("%v\n", "[Bad data partitions(decommission not completed)]:")
-			badPartitionTablePattern := "%-8v    %-10v\n"
-			stdout(badPartitionTablePattern, "PATH", "PARTITION ID")
-			for _, bdpv := range diagnosis.BadDataPartitionIDs {
-				sort.SliceStable(bdpv.PartitionIDs, func(i, j int) bool {
-					return bdpv.PartitionIDs[i] < bdpv.PartitionIDs[j]
-				})
-				for _, pid := range bdpv.PartitionIDs {
-					stdout(badPartitionTablePattern, bdpv.Path, pid)
-				}
-			}
+
+	stdout("\n")
+	stdout("%v\n", "[Data partitions with majority replicas in one rack]:")
+	stdout("%v\n", partitionInfoTableHeader)
+	for _, pid := range diagnosis.RackRiskDataPartitionIDs {
+		var partition *proto.DataPartitionInfo
+		if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
+			err = annotateError(err, "Partition not found, err:[%v] ", err)
 			return
-		},
+		}
+		if partition != nil {
+			stdout("%v\n", formatDataPartitionInfoRow(partition))
+		}
 	}
-	return cmd
+
+	stdout("\n")
+	stdout("%v\n", "[Data partitions with the status forced by the admin]:")
+	stdout("%v\n", partitionInfoTableHeader)
+	for _, pid := range diagnosis.ForcedStatusDataPartitionIDs {
+		var partition *proto.DataPartitionInfo
+		if partition, err = client.AdminAPI().GetDataPartition("", pid); err != nil {
+			err = annotateError(err, "Partition not found, err:[%v] ", err)
+			return
+		}
+		if partition != nil {
+			stdout("%v\n", formatDataPartitionInfoRow(partition))
+		}
+	}
+
+	stdout("\n")
+	stdout("%v\n", "[Bad data partitions(decommission not completed)]:")
+	badPartitionTablePattern := "%-8v    %-10v\n"
+	stdout(badPartitionTablePattern, "PATH", "PARTITION ID")
+	for _, bdpv := range diagnosis.BadDataPartitionIDs {
+		sort.SliceStable(bdpv.PartitionIDs, func(i, j int) bool {
+			return bdpv.PartitionIDs[i] < bdpv.PartitionIDs[j]
+		})
+		for _, pid := range bdpv.PartitionIDs {
+			stdout(badPartitionTablePattern, bdpv.Path, pid)
+		}
+	}
+	return
 }
 
 func newDataPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optFromFile    string
+		optConcurrency int
+		optRetry       int
+		optAsync       bool
+		optDryRun      bool
+		optYes         bool
+	)
 	var cmd = &cobra.Command{
 		Use:   CliOpDecommission + " [ADDRESS] [DATA PARTITION ID]",
 		Short: cmdDataPartitionDecommissionShort,
-		Args:  cobra.MinimumNArgs(2),
+		Long: `Decommission a replication of the data partition on the address to a new address. With the "--from-file" flag,
+the IDs of the partitions to be decommissioned are read from the file, which are separated by spaces, commas or lines.`,
+		Args: cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			var (
 				err         error
@@ -185,10 +260,48 @@ func newDataPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command
 				}
 			}()
 			address := args[0]
+			if optFromFile != "" {
+				var ids []uint64
+				if ids, err = readPartitionIDsFromFile(optFromFile); err != nil {
+					return
+				}
+				if !optYes {
+					if err = confirmBatchOperation(fmt.Sprintf("Decommission the replicas on [%v] of", address), len(ids)); err != nil {
+						return
+					}
+				}
+				summary := runBatchPartitionOperation(ids, optConcurrency, optRetry, func(id uint64) error {
+					return client.AdminAPI().DecommissionDataPartition(id, address)
+				})
+				if err = printBatchSummary("Decommission", summary); err != nil {
+					return
+				}
+				if len(summary.Failed) > 0 {
+					err = newPartialFailureError("decommission %v data partitions failed", len(summary.Failed))
+				}
+				return
+			}
+			if len(args) < 2 {
+				err = NewArgumentError("data partition id is required without --%v", CliFlagFromFile)
+				return
+			}
 			partitionID, err = strconv.ParseUint(args[1], 10, 64)
 			if err != nil {
 				return
 			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminDecommissionDataPartition, partitionID, address)
+				return
+			}
+			if !optYes {
+				if err = confirmDataPartitionOperation(client, fmt.Sprintf("Decommission the replica on [%v]", address), partitionID); err != nil {
+					return
+				}
+			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminDecommissionDataPartition, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().DecommissionDataPartition(partitionID, address); err != nil {
 				return
 			}
@@ -200,10 +313,17 @@ func newDataPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command
 			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Decommission in background and print the task ID")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().StringVar(&optFromFile, CliFlagFromFile, "", "Read the partition IDs from the file")
+	cmd.Flags().IntVar(&optConcurrency, CliFlagConcurrency, defaultBatchConcurrency, "Number of partitions decommissioned concurrently with --from-file")
+	cmd.Flags().IntVar(&optRetry, CliFlagRetry, defaultBatchRetry, "Retry times of transient errors with --from-file")
 	return cmd
 }
 
 func newDataPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
+	var optAsync bool
 	var cmd = &cobra.Command{
 		Use:   CliOpReplicate + " [ADDRESS] [DATA PARTITION ID]",
 		Short: cmdDataPartitionReplicateShort,
@@ -222,6 +342,10 @@ func newDataPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
 			if partitionID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
 				return
 			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminAddDataReplica, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().AddDataReplica(partitionID, address); err != nil {
 				return
 			}
@@ -233,10 +357,16 @@ func newDataPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
 			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Add the replica in background and print the task ID")
 	return cmd
 }
 
 func newDataPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optAsync  bool
+		optDryRun bool
+		optYes    bool
+	)
 	var cmd = &cobra.Command{
 		Use:   CliOpDelReplica + " [ADDRESS] [DATA PARTITION ID]",
 		Short: cmdDataPartitionDeleteReplicaShort,
@@ -255,6 +385,19 @@ func newDataPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 			if partitionID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
 				return
 			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminDeleteDataReplica, partitionID, address)
+				return
+			}
+			if !optYes {
+				if err = confirmDataPartitionOperation(client, fmt.Sprintf("Delete the replica on [%v]", address), partitionID); err != nil {
+					return
+				}
+			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminDeleteDataReplica, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().DeleteDataReplica(partitionID, address); err != nil {
 				return
 			}
@@ -266,5 +409,94 @@ func newDataPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 			return validDataNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Delete the replica in background and print the task ID")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newDataPartitionResetCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optForce  bool
+		optDryRun bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpReset + " [DATA PARTITION ID]",
+		Short: cmdDataPartitionResetShort,
+		Long: `Reset the raft members of a data partition whose majority of replicas are on the corrupt data nodes. The 
+replicas on the inactive data nodes will be removed, and the remaining replicas will elect a new leader. Each remaining 
+replica is validated by the block crc before it is promoted, the reset is refused if any extent is corrupt. The data 
+which has not been synchronized to the remaining replicas will be LOST. After resetting, use "datapartition add-replica" 
+to add the lacked replicas back. The "--force" flag is required to confirm this action.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminResetDataPartition, partitionID, "")
+				return
+			}
+			if !optForce {
+				err = NewArgumentError("resetting data partition may lead to data loss, use --%v to confirm it", CliFlagForce)
+				return
+			}
+			if err = client.AdminAPI().ResetDataPartition(partitionID); err != nil {
+				return
+			}
+			stdout("Data partition [%v] has been reset successfully.\n", partitionID)
+		},
+	}
+	cmd.Flags().BoolVar(&optForce, CliFlagForce, false, "Confirm to reset the data partition, data may be lost")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
+	return cmd
+}
+
+func newDataPartitionSetStatusCmd(client *master.MasterClient) *cobra.Command {
+	var optReason string
+	var cmd = &cobra.Command{
+		Use:   CliOpSetStatus + " [DATA PARTITION ID] [STATUS]",
+		Short: cmdDataPartitionSetStatusShort,
+		Long: `Force the data partition "readonly", such as fencing a partition suspected of corruption while it is
+investigated, or "writable" regardless of the replica status and the free space reported by the data nodes. The
+forced status overrides the status decided by the master until the partition is set to "auto". A partition forced
+writable stays read-only if any replica is not alive.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if err = client.AdminAPI().SetDataPartitionStatus(partitionID, args[1], optReason); err != nil {
+				return
+			}
+			stdout("Status of data partition %v is set to %v\n", partitionID, args[1])
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{proto.PartitionStatusReadOnly, proto.PartitionStatusWritable, proto.PartitionStatusAuto},
+				cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optReason, CliFlagReason, "", "Specify the reason to force the status, such as the investigation")
 	return cmd
 }
@@ -0,0 +1,156 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const cliFlagMarker = "marker"
+
+func newMetaPartitionDeletedInodesCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort uint16
+		optMarker   uint64
+		optLimit    int
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpDeletedInodes + " [META PARTITION ID]",
+		Short: cmdMetaPartitionDeletedInodesShort,
+		Long: `List the deleted files of the meta partition which are not purged yet, which are read from its leader. A deleted
+file is kept for the inode retention of the volume after its last link is removed, or for a day until it is closed
+by the clients if the retention is not set. The held files are kept until they are linked again or purged by force.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+				inodes      []*proto.DeletedInode
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if inodes, err = mc.GetDeletedInodes(partitionID, optMarker, optLimit); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(inodes)
+				return
+			}
+			stdout("%v", formatDeletedInodeTableHeader())
+			for _, inode := range inodes {
+				stdout("%v", formatDeletedInodeTableRow(inode))
+			}
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	cmd.Flags().Uint64Var(&optMarker, cliFlagMarker, 0, "List the inodes after the inode number")
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, 0, "Specify the max number of the inodes to list, 0 for the default of the meta nodes")
+	return cmd
+}
+
+func newMetaPartitionPurgeInodeCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort uint16
+		optYes      bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpPurgeInode + " [META PARTITION ID] [INODE]",
+		Short: cmdMetaPartitionPurgeInodeShort,
+		Long: `Purge the deleted file of the meta partition with its extents without waiting for the inode retention of the
+volume, even if it is held. The file can not be restored any more.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				ino         uint64
+				mc          *api.MetaHttpClient
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if ino, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return
+			}
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if !optYes {
+				if err = confirmPartitionID(fmt.Sprintf("Purge the deleted inode [%v]", ino), partitionID); err != nil {
+					return
+				}
+			}
+			if err = mc.PurgeInode(partitionID, ino); err != nil {
+				return
+			}
+			stdout("Inode %v of meta partition %v is purged\n", ino, partitionID)
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newMetaPartitionCancelPurgeCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpCancelPurge + " [META PARTITION ID] [INODE]",
+		Short: cmdMetaPartitionCancelPurgeShort,
+		Long: `Hold the deleted file of the meta partition from being purged, so that it can be restored by linking the inode
+into a directory again, e.g. by the Link of the SDK. The file is kept until it is linked or purged by force. The file
+can not be held once its purge has started.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				ino         uint64
+				mc          *api.MetaHttpClient
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if ino, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				return
+			}
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if err = mc.CancelPurge(partitionID, ino); err != nil {
+				return
+			}
+			stdout("Inode %v of meta partition %v is held from being purged\n", ino, partitionID)
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
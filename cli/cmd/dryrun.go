@@ -0,0 +1,124 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdDryRunShort             = "Analyze the impact of the admin operations without executing them"
+	cmdDryRunDecommissionShort = "Analyze the impact of decommissioning a data node or a meta node"
+)
+
+func newDryRunCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliResourceDryRun + " [COMMAND]",
+		Short: cmdDryRunShort,
+	}
+	cmd.AddCommand(
+		newDryRunDecommissionCmd(client),
+	)
+	return cmd
+}
+
+func newDryRunDecommissionCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpDecommission + " [NODE ADDRESS]",
+		Short: cmdDryRunDecommissionShort,
+		Long: `Report how many partitions would be migrated off the node and the bytes to move, the nodes which may
+hold the new replicas, and the partitions which would drop below the quorum while their replicas are moved,
+since the replica on the node is removed before the new one is added. Nothing is migrated.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				dryRun *proto.DecommissionDryRun
+				err    error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if dryRun, err = client.NodeAPI().DecommissionDryRun(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(dryRun)
+				return
+			}
+			stdout("%v", formatDecommissionDryRun(dryRun))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return append(validDataNodes(client, toComplete), validMetaNodes(client, toComplete)...), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+var (
+	decommissionCandidateTablePattern = "%-24v    %-12v    %-12v    %v"
+	decommissionPartitionTablePattern = "%-8v    %-20v    %-12v    %-8v    %-6v    %-10v    %v"
+)
+
+func formatDecommissionDryRun(dryRun *proto.DecommissionDryRun) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("[Decommission dry run of %v node %v]\n", dryRun.NodeType, dryRun.Addr))
+	sb.WriteString(fmt.Sprintf("  Partitions to migrate : %v\n", dryRun.PartitionCount))
+	sb.WriteString(fmt.Sprintf("  Bytes to move         : %v\n", formatSize(dryRun.MigrateBytes)))
+	sb.WriteString(fmt.Sprintf("  Below quorum          : %v %v\n", len(dryRun.BelowQuorum), dryRun.BelowQuorum))
+	sb.WriteString(fmt.Sprintf("  Blocked               : %v %v\n", len(dryRun.Blocked), dryRun.Blocked))
+	sb.WriteString(fmt.Sprintf("  No candidate          : %v %v\n", len(dryRun.NoCandidate), dryRun.NoCandidate))
+	sb.WriteString("\n[Destination candidates]\n")
+	sb.WriteString(fmt.Sprintf(decommissionCandidateTablePattern+"\n", "ADDRESS", "ZONE", "AVAILABLE", "PARTITIONS"))
+	for _, candidate := range dryRun.Candidates {
+		sb.WriteString(fmt.Sprintf(decommissionCandidateTablePattern+"\n", candidate.Addr, candidate.ZoneName,
+			formatSize(candidate.Avail), candidate.Partitions))
+	}
+	sb.WriteString("\n[Partitions]\n")
+	sb.WriteString(fmt.Sprintf(decommissionPartitionTablePattern+"\n", "ID", "VOLUME", "SIZE", "REPLICAS", "LIVE",
+		"CANDIDATES", "ISSUE"))
+	for _, impact := range dryRun.Partitions {
+		sb.WriteString(fmt.Sprintf(decommissionPartitionTablePattern+"\n", impact.PartitionID, impact.VolName,
+			formatSize(impact.Bytes), impact.ReplicaNum, impact.LiveReplicas, impact.CandidateCount,
+			formatDecommissionIssue(impact)))
+	}
+	return sb.String()
+}
+
+func formatDecommissionIssue(impact *proto.DecommissionPartitionImpact) string {
+	issues := make([]string, 0)
+	if impact.BelowQuorum {
+		issues = append(issues, "below quorum")
+	}
+	if impact.CandidateCount == 0 {
+		issues = append(issues, "no candidate")
+	}
+	if impact.Error != "" {
+		issues = append(issues, impact.Error)
+	}
+	if len(issues) == 0 {
+		return "-"
+	}
+	return strings.Join(issues, "; ")
+}
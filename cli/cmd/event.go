@@ -0,0 +1,104 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdClusterEventsShort = "List the recent events emitted by the master"
+)
+
+var (
+	eventTablePattern = "%-8v    %-19v    %-20v    %-24v    %v"
+	eventTableHeader  = fmt.Sprintf(eventTablePattern, "ID", "TIME", "TYPE", "TARGET", "MESSAGE")
+)
+
+func newClusterEventsCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optType     string
+		optLimit    int
+		optWatch    bool
+		optInterval time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpEvents,
+		Short: cmdClusterEventsShort,
+		Long: `List the recent events emitted by the master, such as the inactive nodes, the partitions lost the leaders,
+the finished decommissions and the bad disks. The master keeps the recent events in memory only, and the IDs of the
+events restart when the leader of the masters changes. Configure the webhooks or the kafka topic on the master to
+receive all the events without polling.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err    error
+				events []*proto.Event
+				since  uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if events, err = client.AdminAPI().ListEvents(0, optType, 0); err != nil {
+				return
+			}
+			if optLimit > 0 && len(events) > optLimit {
+				events = events[len(events)-optLimit:]
+			}
+			if isStructuredOutput() && !optWatch {
+				err = printStructured(events)
+				return
+			}
+			stdout("%v\n", eventTableHeader)
+			since = printEvents(events, since)
+			for optWatch {
+				time.Sleep(optInterval)
+				if events, err = client.AdminAPI().ListEvents(since, optType, 0); err != nil {
+					return
+				}
+				since = printEvents(events, since)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optType, CliFlagType, "", fmt.Sprintf("Specify the event type [%v | %v | %v | %v]",
+		proto.EventNodeInactive, proto.EventPartitionLostLeader, proto.EventDecommissionFinished, proto.EventDiskError))
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, 0, "Specify the number of the latest events to list, 0 for all")
+	cmd.Flags().BoolVarP(&optWatch, CliFlagWatch, "w", false, "Poll the master periodically and show the new events")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultWatchInterval, "Interval of polling the events with --watch")
+	return cmd
+}
+
+// printEvents prints the events and returns the ID of the last event.
+func printEvents(events []*proto.Event, since uint64) uint64 {
+	for _, event := range events {
+		stdout("%v\n", formatEvent(event))
+		since = event.ID
+	}
+	return since
+}
+
+func formatEvent(event *proto.Event) string {
+	var target = event.Addr
+	if event.PartitionID != 0 {
+		target = fmt.Sprintf("%v/%v", event.VolName, event.PartitionID)
+	}
+	return fmt.Sprintf(eventTablePattern, event.ID, formatTime(event.Time), event.Type, target, event.Message)
+}
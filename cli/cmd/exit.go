@@ -0,0 +1,85 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+)
+
+// Exit codes of the commands, so that the scripts can tell the kinds of the failures.
+const (
+	ExitCodeOK                = 0
+	ExitCodeError             = 1 // unclassified errors
+	ExitCodeArgument          = 2 // invalid arguments, flags or config
+	ExitCodeMasterUnreachable = 3 // none of the masters can be reached
+	ExitCodeRejected          = 4 // the operation is rejected by the master
+	ExitCodePartialFailure    = 5 // some of the partitions of a batch operation failed
+	ExitCodeDegraded          = 6 // the checked cluster or partition is unhealthy
+)
+
+// cliError is an error with the exit code of the command.
+type cliError struct {
+	code int
+	msg  string
+}
+
+func (e *cliError) Error() string {
+	return e.msg
+}
+
+// NewArgumentError returns an error of the invalid arguments, flags or config.
+func NewArgumentError(format string, a ...interface{}) error {
+	return &cliError{code: ExitCodeArgument, msg: fmt.Sprintf(format, a...)}
+}
+
+func newPartialFailureError(format string, a ...interface{}) error {
+	return &cliError{code: ExitCodePartialFailure, msg: fmt.Sprintf(format, a...)}
+}
+
+func newDegradedError(format string, a ...interface{}) error {
+	return &cliError{code: ExitCodeDegraded, msg: fmt.Sprintf(format, a...)}
+}
+
+// annotateError formats the message of the error, and keeps the exit code of the error.
+func annotateError(err error, format string, a ...interface{}) error {
+	return &cliError{code: ExitCode(err), msg: fmt.Sprintf(format, a...)}
+}
+
+// ExitCode classifies the error into the exit code of the command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	switch e := err.(type) {
+	case *cliError:
+		return e.code
+	case *strconv.NumError:
+		return ExitCodeArgument
+	case net.Error:
+		return ExitCodeMasterUnreachable
+	}
+	if err == master.ErrNoValidMaster {
+		return ExitCodeMasterUnreachable
+	}
+	if _, ok := proto.Err2CodeMap[err]; ok {
+		return ExitCodeRejected
+	}
+	return ExitCodeError
+}
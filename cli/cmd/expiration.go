@@ -0,0 +1,214 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdExpirationUse   = "expiration [COMMAND]"
+	cmdExpirationShort = "Manage file expiration rules of volumes"
+)
+
+func newExpirationCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdExpirationUse,
+		Short: cmdExpirationShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newExpirationListCmd(client),
+		newExpirationSetCmd(client),
+		newExpirationDeleteCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdExpirationListShort   = "List the expiration rules of the volume"
+	cmdExpirationSetShort    = "Delete the files of a directory subtree or the volume after the days"
+	cmdExpirationDeleteShort = "Delete an expiration rule of the volume"
+)
+
+func newExpirationListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList + " [VOLUME]",
+		Short:   cmdExpirationListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var rules []*proto.ExpirationRule
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if rules, err = client.AdminAPI().ListExpirationRules(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(rules)
+				return
+			}
+			stdout("%v\n", formatExpirationRuleTableHeader())
+			for _, rule := range rules {
+				stdout("%v\n", formatExpirationRuleTableRow(rule))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newExpirationSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var optDays uint32
+	var optBasis string
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [VOLUME] [PATH]",
+		Short: cmdExpirationSetShort,
+		Long: `Delete the files in the directory subtree, or in the whole volume if the path is "/", which are not modified
+or accessed for the days. The files are deleted by the leaders of the meta partitions periodically, and purged after
+the inode retention of the volume. The rule of the directory is replaced if it exists.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volName, dirPath = args[0], args[1]
+			var reply *proto.ExpirationRuleReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optDays == 0 {
+				err = NewArgumentError("the days must be positive")
+				return
+			}
+			if !proto.IsValidExpirationBasis(optBasis) {
+				err = NewArgumentError("invalid basis[%v], expect %v or %v", optBasis, proto.ExpireByModifyTime, proto.ExpireByAccessTime)
+				return
+			}
+			if !optYes {
+				stdout("Set the expiration rule of directory [%v] in volume [%v]\n", dirPath, volName)
+				stdout("  Days : %v\n", optDays)
+				stdout("  Basis: %v\n", optBasis)
+				stdout("The expired files will be deleted.\n")
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().SetExpirationRule(volName, dirPath, optDays, optBasis); err != nil {
+				err = annotateError(err, "Set expiration rule failed: %v\n", err)
+				return
+			}
+			printExpirationRuleReply("Set expiration rule success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint32Var(&optDays, CliFlagDays, 0, "Specify the days after which the files are expired")
+	cmd.Flags().StringVar(&optBasis, CliFlagBasis, proto.ExpireByModifyTime,
+		fmt.Sprintf("Specify the time the files are expired by [%v | %v]", proto.ExpireByModifyTime, proto.ExpireByAccessTime))
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newExpirationDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [VOLUME] [RULE ID]",
+		Short: cmdExpirationDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var ruleID uint64
+			var reply *proto.ExpirationRuleReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if ruleID, err = strconv.ParseUint(args[1], 10, 32); err != nil {
+				err = NewArgumentError("invalid rule ID[%v]", args[1])
+				return
+			}
+			if !optYes {
+				stdout("Delete the expiration rule [%v] of volume [%v]\n", ruleID, args[0])
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().DeleteExpirationRule(args[0], uint32(ruleID)); err != nil {
+				err = annotateError(err, "Delete expiration rule failed: %v\n", err)
+				return
+			}
+			printExpirationRuleReply("Delete expiration rule success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+var expirationRuleTablePattern = "%-8v    %-32v    %-12v    %-8v    %-8v"
+
+func formatExpirationRuleTableHeader() string {
+	return fmt.Sprintf(expirationRuleTablePattern, "ID", "PATH", "ROOT INODE", "DAYS", "BASIS")
+}
+
+func formatExpirationRuleTableRow(rule *proto.ExpirationRule) string {
+	return fmt.Sprintf(expirationRuleTablePattern, rule.RuleID, rule.Path, rule.RootInode, rule.Days, rule.Basis)
+}
+
+func printExpirationRuleReply(msg string, reply *proto.ExpirationRuleReply) {
+	if isStructuredOutput() {
+		if err := printStructured(reply); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("%v:\n", msg)
+	stdout("%v\n", formatExpirationRuleTableHeader())
+	stdout("%v\n", formatExpirationRuleTableRow(reply.Rule))
+}
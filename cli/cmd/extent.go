@@ -0,0 +1,205 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdExtentUse   = "extent [COMMAND]"
+	cmdExtentShort = "Inspect the extents of a data partition"
+)
+
+func newExtentCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdExtentUse,
+		Short: cmdExtentShort,
+	}
+	cmd.AddCommand(
+		newExtentInfoCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdExtentInfoShort = "Show the size, crc and modify time of an extent on each replica"
+)
+
+// extentReplica defines the extent on a data partition replica.
+type extentReplica struct {
+	Addr          string
+	IsLeader      bool
+	Extent        *storage.ExtentInfo `json:",omitempty"`
+	Authoritative bool
+	Error         string `json:",omitempty"`
+}
+
+// extentInspection defines the result of inspecting an extent.
+type extentInspection struct {
+	PartitionID uint64
+	ExtentID    uint64
+	Replicas    []*extentReplica
+	Consistent  bool
+}
+
+// inspectExtent queries the extent on each replica of the data partition. The replicas with the max size are
+// authoritative, since the repair of the data partition syncs the extent from the replica with the max size.
+func inspectExtent(client *master.MasterClient, partitionID, extentID uint64, profPort uint16) (inspection *extentInspection, err error) {
+	var partition *proto.DataPartitionInfo
+	if partition, err = client.AdminAPI().GetDataPartition("", partitionID); err != nil {
+		return
+	}
+	inspection = &extentInspection{PartitionID: partitionID, ExtentID: extentID}
+	leaders := make(map[string]bool)
+	for _, replica := range partition.Replicas {
+		leaders[replica.Addr] = replica.IsLeader
+	}
+	var wg sync.WaitGroup
+	for _, host := range partition.Hosts {
+		replica := &extentReplica{Addr: host, IsLeader: leaders[host]}
+		inspection.Replicas = append(inspection.Replicas, replica)
+		var httpAddr string
+		if httpAddr, err = replicaHttpAddr(host, profPort); err != nil {
+			return
+		}
+		wg.Add(1)
+		go func(replica *extentReplica, httpAddr string) {
+			defer wg.Done()
+			extent, getErr := api.NewDataHttpClient(httpAddr, false).GetExtent(partitionID, extentID)
+			if getErr != nil {
+				replica.Error = getErr.Error()
+				return
+			}
+			replica.Extent = extent
+		}(replica, httpAddr)
+	}
+	wg.Wait()
+	sort.Slice(inspection.Replicas, func(i, j int) bool {
+		return inspection.Replicas[i].Addr < inspection.Replicas[j].Addr
+	})
+
+	var maxSize uint64
+	for _, replica := range inspection.Replicas {
+		if replica.Extent != nil && !replica.Extent.IsDeleted && replica.Extent.Size > maxSize {
+			maxSize = replica.Extent.Size
+		}
+	}
+	crcs := make(map[uint32]bool)
+	inspection.Consistent = true
+	for _, replica := range inspection.Replicas {
+		if replica.Extent == nil || replica.Extent.IsDeleted {
+			inspection.Consistent = false
+			continue
+		}
+		if replica.Extent.Size != maxSize {
+			inspection.Consistent = false
+			continue
+		}
+		replica.Authoritative = true
+		if replica.Extent.Crc != 0 {
+			crcs[replica.Extent.Crc] = true
+		}
+	}
+	if !storage.IsTinyExtent(extentID) && len(crcs) > 1 {
+		// the replicas with the same size but different crc can not be told apart
+		inspection.Consistent = false
+		for _, replica := range inspection.Replicas {
+			replica.Authoritative = false
+		}
+	}
+	return
+}
+
+func printExtentInspection(inspection *extentInspection) {
+	stdout("Partition : %v\n", inspection.PartitionID)
+	stdout("Extent    : %v\n", inspection.ExtentID)
+	stdout("\n")
+	tablePattern := "%-22v    %-8v    %-12v    %-10v    %-19v    %-8v    %-13v    %v\n"
+	stdout(tablePattern, "ADDRESS", "LEADER", "SIZE", "CRC", "MODIFY TIME", "DELETED", "AUTHORITATIVE", "ERROR")
+	for _, replica := range inspection.Replicas {
+		if replica.Extent == nil {
+			stdout(tablePattern, replica.Addr, formatYesNo(replica.IsLeader), "N/A", "N/A", "N/A", "N/A",
+				formatYesNo(replica.Authoritative), replica.Error)
+			continue
+		}
+		stdout(tablePattern, replica.Addr, formatYesNo(replica.IsLeader), replica.Extent.Size, replica.Extent.Crc,
+			formatTime(replica.Extent.ModifyTime), formatYesNo(replica.Extent.IsDeleted),
+			formatYesNo(replica.Authoritative), replica.Error)
+	}
+	stdout("\n")
+	if inspection.Consistent {
+		stdout("The extent is consistent among the replicas.\n")
+		return
+	}
+	for _, replica := range inspection.Replicas {
+		if replica.Authoritative {
+			stdout("The extent is inconsistent, the replicas with the max size are authoritative.\n")
+			return
+		}
+	}
+	stdout("The extent is inconsistent, and no replica is authoritative.\n")
+}
+
+func newExtentInfoCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpInfo + " [DATA PARTITION ID] [EXTENT ID]",
+		Short: cmdExtentInfoShort,
+		Long: `Query the extent on each replica of the data partition. The repair of the data partition syncs the extent
+from the replica with the max size, so the replicas with the max size are authoritative. If the replicas with
+the max size have different crc, no replica is authoritative and the extent should be checked manually.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				extentID    uint64
+				inspection  *extentInspection
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				err = NewArgumentError("invalid data partition ID [%v]", args[0])
+				return
+			}
+			if extentID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				err = NewArgumentError("invalid extent ID [%v]", args[1])
+				return
+			}
+			if inspection, err = inspectExtent(client, partitionID, extentID, optProfPort); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(inspection)
+				return
+			}
+			printExtentInspection(inspection)
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultDataNodeProfPort, "Port of the http service of the data nodes")
+	return cmd
+}
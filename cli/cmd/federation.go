@@ -0,0 +1,234 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdClusterFederationUse         = CliOpFederation + " [COMMAND]"
+	cmdClusterFederationShort       = "Manage the peer clusters and show the capacity and the health across the clusters"
+	cmdClusterFederationAddShort    = "Register a peer cluster, or replace the peer of the same name"
+	cmdClusterFederationRemoveShort = "Remove a peer cluster"
+	cmdClusterFederationListShort   = "List the peer clusters"
+	cmdClusterFederationInfoShort   = "Show the capacity and the health of the local cluster and the peer clusters"
+)
+
+var (
+	federationPeerTablePattern = "%-16v    %-12v    %-19v    %v"
+	federationPeerTableHeader  = fmt.Sprintf(federationPeerTablePattern, "NAME", "REGION", "ADDED", "MASTERS")
+
+	federationTablePattern = "%-16v    %-12v    %-21v    %-16v    %-16v    %-10v    %-10v    %-6v    %v"
+	federationTableHeader  = fmt.Sprintf(federationTablePattern, "NAME", "REGION", "LEADER", "DATA USED/TOTAL",
+		"META USED/TOTAL", "DATANODES", "METANODES", "VOLS", "HEALTH")
+)
+
+func newClusterFederationCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdClusterFederationUse,
+		Short: cmdClusterFederationShort,
+		Long: `The peer clusters are registered on the masters of the local cluster, which get the capacity and the health of
+the peers from their masters when the federation is shown. The token of "federationAuthToken" in the config of the
+masters is used to call the peers, it should be granted the monitor role by the masters of the peers if the access
+control is enabled on them.`,
+	}
+	cmd.AddCommand(
+		newClusterFederationAddCmd(client),
+		newClusterFederationRemoveCmd(client),
+		newClusterFederationListCmd(client),
+		newClusterFederationInfoCmd(client),
+	)
+	return cmd
+}
+
+func newClusterFederationAddCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optRegion  string
+		optMasters []string
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpAdd + " [NAME]",
+		Short: cmdClusterFederationAddShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(optMasters) == 0 {
+				err = fmt.Errorf("%v is required", CliFlagMasters)
+				return
+			}
+			if err = client.AdminAPI().AddFederationPeer(args[0], optRegion, optMasters); err != nil {
+				return
+			}
+			stdout("Peer cluster [%v] is added.\n", args[0])
+		},
+	}
+	cmd.Flags().StringVar(&optRegion, CliFlagRegion, "", "Specify the region of the peer cluster")
+	cmd.Flags().StringSliceVar(&optMasters, CliFlagMasters, nil, "Specify the addresses of the masters of the peer cluster")
+	return cmd
+}
+
+func newClusterFederationRemoveCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpRemove + " [NAME]",
+		Short: cmdClusterFederationRemoveShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if err = client.AdminAPI().RemoveFederationPeer(args[0]); err != nil {
+				return
+			}
+			stdout("Peer cluster [%v] is removed.\n", args[0])
+		},
+	}
+	return cmd
+}
+
+func newClusterFederationListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdClusterFederationListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err   error
+				peers []*proto.FederationPeer
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if peers, err = client.AdminAPI().ListFederationPeers(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(peers)
+				return
+			}
+			stdout("%v\n", federationPeerTableHeader)
+			for _, peer := range peers {
+				stdout("%v\n", fmt.Sprintf(federationPeerTablePattern, peer.Name, peer.Region, formatTime(peer.AddTime),
+					strings.Join(peer.Masters, ",")))
+			}
+		},
+	}
+	return cmd
+}
+
+func newClusterFederationInfoCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpInfo,
+		Short: cmdClusterFederationInfoShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.FederationView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.AdminAPI().GetFederation(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("%v", formatFederationView(view))
+		},
+	}
+	return cmd
+}
+
+func formatFederationView(view *proto.FederationView) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%v\n", federationTableHeader))
+	for _, cluster := range view.Clusters {
+		sb.WriteString(fmt.Sprintf("%v\n", formatFederationCluster(cluster)))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("  Clusters            : %v reachable, %v healthy of %v\n", view.ReachableClusters,
+		view.HealthyClusters, len(view.Clusters)))
+	sb.WriteString(fmt.Sprintf("  Data used/total     : %v/%v GB\n", view.DataUsedGB, view.DataTotalGB))
+	sb.WriteString(fmt.Sprintf("  Meta used/total     : %v/%v GB\n", view.MetaUsedGB, view.MetaTotalGB))
+	sb.WriteString(fmt.Sprintf("  Data/Meta nodes     : %v/%v\n", view.DataNodes, view.MetaNodes))
+	sb.WriteString(fmt.Sprintf("  Volumes             : %v\n", view.Volumes))
+	for _, cluster := range view.Clusters {
+		if cluster.Error != "" {
+			sb.WriteString(fmt.Sprintf("  [%v] error: %v\n", cluster.Name, cluster.Error))
+		}
+		for _, issue := range cluster.Issues {
+			sb.WriteString(fmt.Sprintf("  [%v] %v\n", cluster.Name, issue))
+		}
+	}
+	return sb.String()
+}
+
+func formatFederationCluster(cluster *proto.FederationClusterView) string {
+	name := cluster.Name
+	if cluster.Local {
+		name += "*"
+	}
+	if !cluster.Reachable {
+		return fmt.Sprintf(federationTablePattern, name, cluster.Region, "N/A", "N/A", "N/A", "N/A", "N/A", "N/A",
+			"unreachable")
+	}
+	return fmt.Sprintf(federationTablePattern, name, cluster.Region, cluster.LeaderAddr,
+		formatFederationUsage(cluster.DataNodeStatInfo), formatFederationUsage(cluster.MetaNodeStatInfo),
+		formatFederationNodes(cluster.DataNodes, cluster.InactiveDataNodes),
+		formatFederationNodes(cluster.MetaNodes, cluster.InactiveMetaNodes), cluster.Volumes,
+		formatFederationHealth(cluster))
+}
+
+func formatFederationUsage(stat *proto.NodeStatInfo) string {
+	if stat == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%v/%v GB", stat.UsedGB, stat.TotalGB)
+}
+
+// formatFederationNodes shows the active nodes of all the nodes.
+func formatFederationNodes(total, inactive int) string {
+	return fmt.Sprintf("%v/%v", total-inactive, total)
+}
+
+func formatFederationHealth(cluster *proto.FederationClusterView) string {
+	switch {
+	case cluster.Healthy:
+		return "healthy"
+	case len(cluster.Issues) > 0:
+		return fmt.Sprintf("%v issues", len(cluster.Issues))
+	default:
+		return "unknown"
+	}
+}
@@ -17,6 +17,7 @@ package cmd
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -68,22 +69,24 @@ func formatClusterStat(cs *proto.ClusterStatInfo) string {
 	return sb.String()
 }
 
-var nodeViewTableRowPattern = "%-6v    %-18v    %-8v    %-8v"
+var nodeViewTableRowPattern = "%-6v    %-18v    %-8v    %-8v    %-8v    %-6v"
 
 func formatNodeViewTableHeader() string {
-	return fmt.Sprintf(nodeViewTableRowPattern, "ID", "ADDRESS", "WRITABLE", "STATUS")
+	return fmt.Sprintf(nodeViewTableRowPattern, "ID", "ADDRESS", "WRITABLE", "STATUS", "RACK", "HEALTH")
 }
 
 func formatNodeView(view *proto.NodeView, tableRow bool) string {
 	if tableRow {
 		return fmt.Sprintf(nodeViewTableRowPattern, view.ID, view.Addr,
-			formatYesNo(view.IsWritable), formatNodeStatus(view.Status))
+			formatYesNo(view.IsWritable), formatNodeStatus(view.Status), view.RackName, view.HealthScore)
 	}
 	var sb = strings.Builder{}
 	sb.WriteString(fmt.Sprintf("  ID      : %v\n", view.ID))
 	sb.WriteString(fmt.Sprintf("  Address : %v\n", view.Addr))
 	sb.WriteString(fmt.Sprintf("  Writable: %v\n", formatYesNo(view.IsWritable)))
-	sb.WriteString(fmt.Sprintf("  Status  : %v", formatNodeStatus(view.Status)))
+	sb.WriteString(fmt.Sprintf("  Status  : %v\n", formatNodeStatus(view.Status)))
+	sb.WriteString(fmt.Sprintf("  Rack    : %v\n", view.RackName))
+	sb.WriteString(fmt.Sprintf("  Health  : %v", view.HealthScore))
 	return sb.String()
 }
 
@@ -101,6 +104,17 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	sb.WriteString(fmt.Sprintf("  Follower read        : %v\n", formatEnabledDisabled(svv.FollowerRead)))
 	sb.WriteString(fmt.Sprintf("  Enable token         : %v\n", formatEnabledDisabled(svv.EnableToken)))
 	sb.WriteString(fmt.Sprintf("  Cross zone           : %v\n", formatEnabledDisabled(svv.CrossZone)))
+	sb.WriteString(fmt.Sprintf("  Placement policy     : %v\n", formatPlacementPolicy(svv.PlacementPolicy, svv.PlacementZone)))
+	sb.WriteString(fmt.Sprintf("  Placement labels     : %v\n", formatLabels(svv.PlacementLabels)))
+	sb.WriteString(fmt.Sprintf("  QoS                  : %v\n", formatVolQos(svv.Qos)))
+	sb.WriteString(fmt.Sprintf("  Client IPs           : %v\n", formatVolIPAcl(svv.IPAcl)))
+	sb.WriteString(fmt.Sprintf("  Trash                : %v\n", formatTrashTTL(svv.TrashTTL)))
+	sb.WriteString(fmt.Sprintf("  Meta store           : %v\n", formatMetaStore(svv.MetaStore)))
+	sb.WriteString(fmt.Sprintf("  Inode retention      : %v\n", formatInodeRetention(svv.InodeRetention)))
+	sb.WriteString(fmt.Sprintf("  Case insensitive     : %v\n", formatEnabledDisabled(svv.CaseInsensitive)))
+	sb.WriteString(fmt.Sprintf("  Verify read crc      : %v\n", formatEnabledDisabled(svv.VerifyReadCrc)))
+	sb.WriteString(fmt.Sprintf("  Compression          : %v\n", formatCompression(svv.Compression)))
+	sb.WriteString(fmt.Sprintf("  Encryption           : %v\n", formatEncryption(svv.Encrypted, svv.KeyVersion)))
 	sb.WriteString(fmt.Sprintf("  Inode count          : %v\n", svv.InodeCount))
 	sb.WriteString(fmt.Sprintf("  Dentry count         : %v\n", svv.DentryCount))
 	sb.WriteString(fmt.Sprintf("  Max metaPartition ID : %v\n", svv.MaxMetaPartitionID))
@@ -111,6 +125,84 @@ func formatSimpleVolView(svv *proto.SimpleVolView) string {
 	return sb.String()
 }
 
+func formatPlacementPolicy(policy, zoneName string) string {
+	switch policy {
+	case proto.PlacementDefault, "default":
+		return "default"
+	case proto.PlacementZonePinned:
+		return fmt.Sprintf("%v(%v)", policy, zoneName)
+	default:
+		return policy
+	}
+}
+
+func formatTrashTTL(ttl uint64) string {
+	if ttl == 0 {
+		return "Disabled"
+	}
+	return fmt.Sprintf("kept for %v", time.Duration(ttl)*time.Second)
+}
+
+func formatInodeRetention(retention uint64) string {
+	if retention == 0 {
+		return "default"
+	}
+	return fmt.Sprintf("%v", time.Duration(retention)*time.Second)
+}
+
+func formatMetaStore(store string) string {
+	if store == "" {
+		return "default"
+	}
+	return store
+}
+
+func formatCompression(algorithm string) string {
+	if algorithm == "" {
+		return "Disabled"
+	}
+	return algorithm
+}
+
+func formatEncryption(encrypted bool, keyVersion uint32) string {
+	if !encrypted {
+		return "Disabled"
+	}
+	return fmt.Sprintf("Enabled(key v%v)", keyVersion)
+}
+
+func formatVolQos(qos proto.VolQos) string {
+	if !qos.IsLimited() {
+		return "unlimited"
+	}
+	formatLimit := func(limit uint64, format func(uint64) string) string {
+		if limit == 0 {
+			return "unlimited"
+		}
+		return format(limit)
+	}
+	formatIops := func(iops uint64) string { return fmt.Sprintf("%v IOPS", iops) }
+	formatBps := func(bps uint64) string { return formatSize(bps) + "/s" }
+	return fmt.Sprintf("read %v, %v; write %v, %v",
+		formatLimit(qos.ReadIops, formatIops), formatLimit(qos.ReadBps, formatBps),
+		formatLimit(qos.WriteIops, formatIops), formatLimit(qos.WriteBps, formatBps))
+}
+
+func formatVolIPAcl(ipAcl proto.VolIPAcl) string {
+	if !ipAcl.IsSet() {
+		return "unrestricted"
+	}
+	allow := "all"
+	if len(ipAcl.Allow) != 0 {
+		allow = strings.Join(ipAcl.Allow, ",")
+	}
+	deny := "none"
+	if len(ipAcl.Deny) != 0 {
+		deny = strings.Join(ipAcl.Deny, ",")
+	}
+	return fmt.Sprintf("allow %v; deny %v", allow, deny)
+}
+
 func formatVolumeStatus(status uint8) string {
 	switch status {
 	case 0:
@@ -127,6 +219,16 @@ var (
 	volumeInfoTableHeader  = fmt.Sprintf(volumeInfoTablePattern, "VOLUME", "OWNER", "USED", "TOTAL", "STATUS", "CREATE TIME")
 )
 
+var (
+	deletedVolumeTablePattern = "%-63v    %-20v    %-8v    %-20v    %-20v"
+	deletedVolumeTableHeader  = fmt.Sprintf(deletedVolumeTablePattern, "VOLUME", "OWNER", "USED", "DELETE TIME", "PURGE TIME")
+)
+
+func formatDeletedVolTableRow(vi *proto.VolInfo) string {
+	return fmt.Sprintf(deletedVolumeTablePattern,
+		vi.Name, vi.Owner, formatSize(vi.UsedSize), formatTime(vi.DeleteTime), formatTime(vi.ExpireTime))
+}
+
 func formatVolInfoTableRow(vi *proto.VolInfo) string {
 	return fmt.Sprintf(volumeInfoTablePattern,
 		vi.Name, vi.Owner, formatSize(vi.UsedSize), formatSize(vi.TotalSize),
@@ -168,6 +270,10 @@ func formatDataPartitionInfo(partition *proto.DataPartitionInfo) string {
 	sb.WriteString(fmt.Sprintf("volume ID     : %v\n", partition.VolID))
 	sb.WriteString(fmt.Sprintf("PartitionID   : %v\n", partition.PartitionID))
 	sb.WriteString(fmt.Sprintf("Status        : %v\n", formatDataPartitionStatus(partition.Status)))
+	if partition.ForcedStatus != 0 {
+		sb.WriteString(fmt.Sprintf("ForcedStatus  : %v since %v, reason: %v\n", formatDataPartitionStatus(partition.ForcedStatus),
+			formatTime(partition.ForceTime), partition.ForceReason))
+	}
 	sb.WriteString(fmt.Sprintf("LastLoadedTime: %v\n", formatTime(partition.LastLoadedTime)))
 	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("Replicas : \n"))
@@ -262,6 +368,92 @@ func formatMetaPartitionTableRow(view *proto.MetaPartitionView) string {
 		view.LeaderAddr, strings.Join(view.Members, ","))
 }
 
+var (
+	metaPartitionListTablePattern = "%-8v    %-16v    %-12v    %-12v    %-8v    %-8v    %-22v    %v"
+	metaPartitionListTableHeader  = fmt.Sprintf(metaPartitionListTablePattern,
+		"ID", "VOLUME", "INODE COUNT", "DENTRY COUNT", "STATUS", "RECOVER", "LEADER", "MEMBERS")
+)
+
+func formatMetaPartitionListTableRow(item *proto.MetaPartitionListItem) string {
+	return fmt.Sprintf(metaPartitionListTablePattern,
+		item.PartitionID, item.VolName, item.InodeCount, item.DentryCount, formatMetaPartitionStatus(item.Status),
+		formatIsRecover(item.IsRecover), item.LeaderAddr, strings.Join(item.Members, ","))
+}
+
+var (
+	metaNodePartitionTablePattern = "%-8v    %-16v    %-8v    %-12v    %-12v    %-12v    %-14v    %-22v    %v"
+	metaNodePartitionTableHeader  = fmt.Sprintf(metaNodePartitionTablePattern,
+		"ID", "VOLUME", "ROLE", "STATUS", "INODE COUNT", "DENTRY COUNT", "RAFT", "LEADER", "MEMBERS")
+	dataNodePartitionTablePattern = "%-8v    %-16v    %-8v    %-12v    %-12v    %-20v    %-14v    %-22v    %v"
+	dataNodePartitionTableHeader  = fmt.Sprintf(dataNodePartitionTablePattern,
+		"ID", "VOLUME", "ROLE", "STATUS", "USED", "DISK", "RAFT", "LEADER", "MEMBERS")
+)
+
+func formatMetaNodePartitionTableRow(view *proto.NodePartitionView) string {
+	return fmt.Sprintf(metaNodePartitionTablePattern,
+		view.PartitionID, view.VolName, formatNodePartitionRole(view), formatMetaPartitionStatus(view.Status),
+		view.InodeCount, view.DentryCount, formatNodePartitionRaftStatus(view), view.LeaderAddr, strings.Join(view.Hosts, ","))
+}
+
+func formatDataNodePartitionTableRow(view *proto.NodePartitionView) string {
+	return fmt.Sprintf(dataNodePartitionTablePattern,
+		view.PartitionID, view.VolName, formatNodePartitionRole(view), formatDataPartitionStatus(view.Status),
+		formatSize(view.Used), view.DiskPath, formatNodePartitionRaftStatus(view), view.LeaderAddr, strings.Join(view.Hosts, ","))
+}
+
+func formatNodePartitionRole(view *proto.NodePartitionView) string {
+	switch {
+	case view.IsMissing:
+		return "Missing"
+	case view.IsLeader:
+		return "Leader"
+	default:
+		return "Follower"
+	}
+}
+
+func formatNodePartitionRaftStatus(view *proto.NodePartitionView) string {
+	switch {
+	case view.LeaderAddr == "":
+		return "No leader"
+	case view.IsRecover:
+		return "Recovering"
+	case len(view.Hosts) < int(view.ReplicaNum):
+		return "Lack replicas"
+	default:
+		return "Normal"
+	}
+}
+
+var (
+	orphanPartitionTablePattern = "%-6v    %-10v    %-16v    %-22v    %v"
+	orphanPartitionTableHeader  = fmt.Sprintf(orphanPartitionTablePattern, "TYPE", "ID", "VOLUME", "NODE", "REASON")
+)
+
+func formatOrphanPartitionTableRow(orphan *proto.OrphanPartitionView) string {
+	return fmt.Sprintf(orphanPartitionTablePattern,
+		orphan.PartitionType, orphan.PartitionID, orphan.VolName, orphan.NodeAddr, orphan.Reason)
+}
+
+var (
+	stalePartitionTablePattern = "%-6v    %-10v    %-16v    %-22v    %v"
+	stalePartitionTableHeader  = fmt.Sprintf(stalePartitionTablePattern, "TYPE", "ID", "VOLUME", "NODE", "TIME")
+)
+
+func formatStalePartitionTableRow(stale *proto.StalePartition, timeUnix int64) string {
+	return fmt.Sprintf(stalePartitionTablePattern,
+		stale.PartitionType, stale.PartitionID, stale.VolName, stale.NodeAddr, formatTime(timeUnix))
+}
+
+var (
+	topologyChangeTablePattern = "%-14v    %-22v    %-8v    %v"
+	topologyChangeTableHeader  = fmt.Sprintf(topologyChangeTablePattern, "KIND", "ID", "CHANGE", "DETAIL")
+)
+
+func formatTopologyChangeTableRow(change *topologyChange) string {
+	return fmt.Sprintf(topologyChangeTablePattern, change.Kind, change.ID, change.Change, change.Detail)
+}
+
 var (
 	userInfoTablePattern = "%-20v    %-6v    %-16v    %-32v    %-10v"
 	userInfoTableHeader  = fmt.Sprintf(userInfoTablePattern,
@@ -386,6 +578,14 @@ func formatDataReplica(indentation string, replica *proto.DataReplica, rowTable
 	sb.WriteString(fmt.Sprintf("%v  NeedsToCompare : %v\n", indentation, replica.NeedsToCompare))
 	sb.WriteString(fmt.Sprintf("%v  Status         : %v\n", indentation, formatDataPartitionStatus(replica.Status)))
 	sb.WriteString(fmt.Sprintf("%v  DiskPath       : %v\n", indentation, replica.DiskPath))
+	if replica.CompressedExtents > 0 {
+		sb.WriteString(fmt.Sprintf("%v  Compressed     : %v extents, %v -> %v\n", indentation, replica.CompressedExtents,
+			formatSize(replica.CompressedRawSize), formatSize(replica.CompressedSize)))
+	}
+	if replica.KeyVersion > 0 {
+		sb.WriteString(fmt.Sprintf("%v  Encrypted      : key v%v, %v extents to re-encrypt\n", indentation, replica.KeyVersion,
+			replica.StaleKeyExtents))
+	}
 	sb.WriteString(fmt.Sprintf("%v  ReportTime     : %v\n", indentation, formatTime(replica.ReportTime)))
 	return sb.String()
 }
@@ -417,20 +617,24 @@ func formatPeerTableHeader() string {
 	return fmt.Sprintf(peerTableRowPattern, "ID", "PEER")
 }
 func formatPeer(peer proto.Peer) string {
-	return fmt.Sprintf(peerTableRowPattern, peer.ID, peer.Addr)
+	addr := peer.Addr
+	if peer.IsLearner {
+		addr += " (learner)"
+	}
+	return fmt.Sprintf(peerTableRowPattern, peer.ID, addr)
 }
 
 
-var dataNodeDetailTableRowPattern = "%-6v    %-6v    %-18v    %-6v    %-6v    %-6v    %-10v"
+var dataNodeDetailTableRowPattern = "%-6v    %-6v    %-18v    %-6v    %-6v    %-6v    %-6v    %-10v"
 
 func formatDataNodeDetailTableHeader() string {
-	return fmt.Sprintf(dataNodeDetailTableRowPattern, "ID", "ZONE", "ADDRESS", "USED", "TOTAL", "STATUS", "REPORT TIME")
+	return fmt.Sprintf(dataNodeDetailTableRowPattern, "ID", "ZONE", "ADDRESS", "USED", "TOTAL", "STATUS", "HEALTH", "REPORT TIME")
 }
 
 func formatDataNodeDetail(dn *proto.DataNodeInfo, rowTable bool) string {
 	if rowTable {
 		return fmt.Sprintf(dataNodeDetailTableRowPattern, dn.ID, dn.ZoneName, dn.Addr, formatSize(dn.Used),
-			formatSize(dn.Total), formatNodeStatus(dn.IsActive), formatTimeToString(dn.ReportTime))
+			formatSize(dn.Total), formatNodeStatus(dn.IsActive), dn.HealthScore, formatTimeToString(dn.ReportTime))
 	}
 	var sb = strings.Builder{}
 	sb.WriteString(fmt.Sprintf("  ID                  : %v\n", dn.ID))
@@ -441,23 +645,58 @@ func formatDataNodeDetail(dn *proto.DataNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Available           : %v\n", formatSize(dn.AvailableSpace)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(dn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", dn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", dn.RackName))
+	sb.WriteString(fmt.Sprintf("  Labels              : %v\n", formatLabels(dn.Labels)))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(dn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(dn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", dn.DataPartitionCount))
 	sb.WriteString(fmt.Sprintf("  Bad disks           : %v\n", dn.BadDisks))
+	retiringDisks := make([]string, 0, len(dn.RetiringDisks))
+	for path := range dn.RetiringDisks {
+		retiringDisks = append(retiringDisks, path)
+	}
+	sort.Strings(retiringDisks)
+	for _, path := range retiringDisks {
+		sb.WriteString(fmt.Sprintf("  Retiring disk       : %v, %v\n", path, dn.RetiringDisks[path]))
+	}
+	for _, smart := range dn.DiskSmarts {
+		sb.WriteString(fmt.Sprintf("  Disk SMART          : %v\n", formatDiskSmart(smart)))
+	}
+	sb.WriteString(fmt.Sprintf("  Heartbeat latency   : %.2fs\n", dn.HeartbeatLatency))
+	sb.WriteString(fmt.Sprintf("  Health score        : %v\n", dn.HealthScore))
 	sb.WriteString(fmt.Sprintf("  Persist partitions  : %v\n", dn.PersistenceDataPartitions))
 	return sb.String()
 }
 
-var metaNodeDetailTableRowPattern = "%-6v    %-6v    %-18v    %-6v    %-6v    %-6v    %-10v"
+func formatDiskSmart(smart *proto.DiskSmart) string {
+	if smart.Error != "" {
+		return fmt.Sprintf("%v(%v) error: %v", smart.Path, smart.Device, smart.Error)
+	}
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%v(%v) passed %v, %v°C, %vh", smart.Path, smart.Device, smart.Passed,
+		smart.Temperature, smart.PowerOnHours))
+	if smart.AvailableSpareThreshold > 0 {
+		sb.WriteString(fmt.Sprintf(", life used %v%%, spare %v%%, media errors %v, warning 0x%x", smart.PercentageUsed,
+			smart.AvailableSpare, smart.MediaErrors, smart.CriticalWarning))
+	} else {
+		sb.WriteString(fmt.Sprintf(", sectors reallocated %v, pending %v, uncorrectable %v", smart.ReallocatedSectors,
+			smart.PendingSectors, smart.UncorrectableSectors))
+	}
+	if len(smart.FailingAttributes) != 0 {
+		sb.WriteString(fmt.Sprintf(", failing %v", smart.FailingAttributes))
+	}
+	return sb.String()
+}
+
+var metaNodeDetailTableRowPattern = "%-6v    %-6v    %-18v    %-6v    %-6v    %-6v    %-6v    %-10v"
 
 func formatMetaNodeDetailTableHeader() string {
-	return fmt.Sprintf(metaNodeDetailTableRowPattern, "ID", "ZONE", "ADDRESS", "USED", "TOTAL", "STATUS", "REPORT TIME")
+	return fmt.Sprintf(metaNodeDetailTableRowPattern, "ID", "ZONE", "ADDRESS", "USED", "TOTAL", "STATUS", "HEALTH", "REPORT TIME")
 }
 
 func formatMetaNodeDetail(mn *proto.MetaNodeInfo, rowTable bool) string {
 	if rowTable {
-		return fmt.Sprintf(metaNodeDetailTableRowPattern, mn.ID, mn.ZoneName, mn.Addr, mn.Used, mn.Total, mn.IsActive, formatTimeToString(mn.ReportTime))
+		return fmt.Sprintf(metaNodeDetailTableRowPattern, mn.ID, mn.ZoneName, mn.Addr, mn.Used, mn.Total, mn.IsActive, mn.HealthScore, formatTimeToString(mn.ReportTime))
 	}
 	var sb = strings.Builder{}
 	sb.WriteString(fmt.Sprintf("  ID                  : %v\n", mn.ID))
@@ -468,13 +707,55 @@ func formatMetaNodeDetail(mn *proto.MetaNodeInfo, rowTable bool) string {
 	sb.WriteString(fmt.Sprintf("  Used                : %v\n", formatSize(mn.Used)))
 	sb.WriteString(fmt.Sprintf("  Total               : %v\n", formatSize(mn.Total)))
 	sb.WriteString(fmt.Sprintf("  Zone                : %v\n", mn.ZoneName))
+	sb.WriteString(fmt.Sprintf("  Rack                : %v\n", mn.RackName))
+	sb.WriteString(fmt.Sprintf("  Labels              : %v\n", formatLabels(mn.Labels)))
 	sb.WriteString(fmt.Sprintf("  IsActive            : %v\n", formatNodeStatus(mn.IsActive)))
 	sb.WriteString(fmt.Sprintf("  Report time         : %v\n", formatTimeToString(mn.ReportTime)))
 	sb.WriteString(fmt.Sprintf("  Partition count     : %v\n", mn.MetaPartitionCount))
+	sb.WriteString(fmt.Sprintf("  Heartbeat latency   : %.2fs\n", mn.HeartbeatLatency))
+	sb.WriteString(fmt.Sprintf("  Health score        : %v\n", mn.HealthScore))
 	sb.WriteString(fmt.Sprintf("  Persist partitions  : %v\n", mn.PersistenceMetaPartitions))
 	return sb.String()
 }
 
+var metaPartitionMemoryTableRowPattern = "%-8v    %-12v    %-8v    %-10v    %-10v    %-10v    %-10v    %-10v    %-10v"
+
+func formatMetaPartitionMemoryTableHeader() string {
+	return fmt.Sprintf(metaPartitionMemoryTableRowPattern, "ID", "VOLUME", "STORE", "INODES", "DENTRIES", "EXTENTS", "XATTRS", "RAFT LOG", "TOTAL")
+}
+
+func formatMetaPartitionMemory(m *proto.MetaPartitionMemory) string {
+	return fmt.Sprintf(metaPartitionMemoryTableRowPattern, m.PartitionID, m.VolName, m.MetaStore, formatSize(m.InodeTree),
+		formatSize(m.DentryTree), formatSize(m.Extents), formatSize(m.Extend+m.Multipart), formatSize(m.RaftLog), formatSize(m.Total()))
+}
+
+// formatMetaNodeMemory formats the memory of the meta node with the total of each volume, the largest first.
+func formatMetaNodeMemory(usage *proto.MetaNodeMemory) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Heap allocated      : %v\n", formatSize(usage.HeapAlloc)))
+	sb.WriteString(fmt.Sprintf("  Heap from OS        : %v\n", formatSize(usage.HeapSys)))
+	sb.WriteString(fmt.Sprintf("  Partitions          : %v\n", formatSize(usage.Total)))
+	var volumes = make(map[string]uint64)
+	var volNames = make([]string, 0)
+	for _, partition := range usage.Partitions {
+		if _, ok := volumes[partition.VolName]; !ok {
+			volNames = append(volNames, partition.VolName)
+		}
+		volumes[partition.VolName] += partition.Total()
+	}
+	sort.SliceStable(volNames, func(i, j int) bool {
+		return volumes[volNames[i]] > volumes[volNames[j]]
+	})
+	for _, volName := range volNames {
+		sb.WriteString(fmt.Sprintf("    %-18v: %v\n", volName, formatSize(volumes[volName])))
+	}
+	sb.WriteString(fmt.Sprintf("\n  %v\n", formatMetaPartitionMemoryTableHeader()))
+	for _, partition := range usage.Partitions {
+		sb.WriteString(fmt.Sprintf("  %v\n", formatMetaPartitionMemory(partition)))
+	}
+	return sb.String()
+}
+
 func formatZoneView(zv *proto.ZoneView) string {
 	var sb = strings.Builder{}
 	sb.WriteString(fmt.Sprintf("Zone Name:   %v\n", zv.Name))
@@ -496,3 +777,217 @@ func formatZoneView(zv *proto.ZoneView) string {
 	}
 	return sb.String()
 }
+
+var (
+	asyncTaskTablePattern = "%-8v    %-26v    %-12v    %-22v    %-10v    %-19v    %v"
+	asyncTaskTableHeader  = fmt.Sprintf(asyncTaskTablePattern,
+		"ID", "TYPE", "PARTITION ID", "ADDRESS", "STATUS", "UPDATE TIME", "ERROR")
+)
+
+func formatAsyncTaskTableRow(task *proto.AsyncTaskInfo) string {
+	return fmt.Sprintf(asyncTaskTablePattern,
+		task.ID, task.Type, task.PartitionID, task.Addr, task.Status, formatTime(task.UpdateTime), task.Err)
+}
+
+func formatAsyncTaskInfo(task *proto.AsyncTaskInfo) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  ID          : %v\n", task.ID))
+	sb.WriteString(fmt.Sprintf("  Type        : %v\n", task.Type))
+	sb.WriteString(fmt.Sprintf("  PartitionID : %v\n", task.PartitionID))
+	sb.WriteString(fmt.Sprintf("  Address     : %v\n", task.Addr))
+	if task.DiskPath != "" {
+		sb.WriteString(fmt.Sprintf("  Disk        : %v\n", task.DiskPath))
+	}
+	if task.VolName != "" {
+		sb.WriteString(fmt.Sprintf("  Volume      : %v\n", task.VolName))
+	}
+	if task.Total != 0 {
+		sb.WriteString(fmt.Sprintf("  Progress    : %v/%v\n", task.Done, task.Total))
+	}
+	sb.WriteString(fmt.Sprintf("  Status      : %v\n", task.Status))
+	sb.WriteString(fmt.Sprintf("  Error       : %v\n", task.Err))
+	sb.WriteString(fmt.Sprintf("  Create time : %v\n", formatTime(task.CreateTime)))
+	sb.WriteString(fmt.Sprintf("  Update time : %v\n", formatTime(task.UpdateTime)))
+	return sb.String()
+}
+
+func formatPartitionOperationPlan(plan *proto.PartitionOperationPlan) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("  Operation      : %v\n", plan.Operation))
+	sb.WriteString(fmt.Sprintf("  PartitionID    : %v\n", plan.PartitionID))
+	sb.WriteString(fmt.Sprintf("  Volume         : %v\n", plan.VolName))
+	sb.WriteString(fmt.Sprintf("  ReplicaNum     : %v\n", plan.ReplicaNum))
+	sb.WriteString(fmt.Sprintf("  Hosts before   : %v\n", strings.Join(plan.HostsBefore, ",")))
+	sb.WriteString(fmt.Sprintf("  Hosts after    : %v\n", strings.Join(plan.HostsAfter, ",")))
+	sb.WriteString(fmt.Sprintf("  Removed        : %v\n", strings.Join(plan.RemovedAddrs, ",")))
+	if plan.TargetAddr != "" {
+		sb.WriteString(fmt.Sprintf("  Target         : %v\n", plan.TargetAddr))
+		sb.WriteString(fmt.Sprintf("  Target avail   : %v\n", formatSize(plan.TargetAvailableSpace)))
+		sb.WriteString(fmt.Sprintf("  Size to move   : %v\n", formatSize(plan.MoveSize)))
+	}
+	return sb.String()
+}
+
+func formatClusterHealth(health *proto.ClusterHealth) string {
+	var sb = strings.Builder{}
+	if health.Healthy {
+		sb.WriteString("Status : Healthy\n")
+	} else {
+		sb.WriteString("Status : Degraded\n")
+	}
+	for _, issue := range health.Issues {
+		sb.WriteString(fmt.Sprintf("  - %v\n", issue))
+	}
+	sb.WriteString("\n[Nodes]\n")
+	sb.WriteString(fmt.Sprintf("  Inactive data nodes : %v\n", strings.Join(health.InactiveDataNodes, ",")))
+	sb.WriteString(fmt.Sprintf("  Inactive meta nodes : %v\n", strings.Join(health.InactiveMetaNodes, ",")))
+	sb.WriteString("\n[Partitions]\n")
+	sb.WriteString(fmt.Sprintf("  Corrupt data partitions      : %v\n", formatPartitionIDs(health.CorruptDataPartitionIDs)))
+	sb.WriteString(fmt.Sprintf("  Lack replica data partitions : %v\n", formatPartitionIDs(health.LackReplicaDataPartitionIDs)))
+	sb.WriteString(fmt.Sprintf("  Corrupt meta partitions      : %v\n", formatPartitionIDs(health.CorruptMetaPartitionIDs)))
+	sb.WriteString(fmt.Sprintf("  Lack replica meta partitions : %v\n", formatPartitionIDs(health.LackReplicaMetaPartitionIDs)))
+	sb.WriteString("\n[Bad disks]\n")
+	for _, disk := range health.BadDisks {
+		sb.WriteString(fmt.Sprintf("  %v:%v\n", disk.Addr, disk.Path))
+	}
+	sb.WriteString("\n[Retiring disks]\n")
+	for _, disk := range health.RetiringDisks {
+		sb.WriteString(fmt.Sprintf("  %v:%v\n", disk.Addr, disk.Path))
+	}
+	sb.WriteString(fmt.Sprintf("\n[Raft lag (max %v)]\n", health.MaxRaftLag))
+	for _, lag := range health.RaftLagReplicas {
+		sb.WriteString(fmt.Sprintf("  %v partition %v on %v: applied %v, leader applied %v\n",
+			lag.PartitionType, lag.PartitionID, lag.Addr, lag.ApplyID, lag.LeaderApplyID))
+	}
+	sb.WriteString(fmt.Sprintf("\n[Capacity (threshold %.2f)]\n", health.CapacityThreshold))
+	sb.WriteString(fmt.Sprintf("  Data used ratio : %.2f\n", health.DataUsedRatio))
+	sb.WriteString(fmt.Sprintf("  Meta used ratio : %.2f\n", health.MetaUsedRatio))
+	sb.WriteString(fmt.Sprintf("  Full data nodes : %v\n", strings.Join(health.FullDataNodes, ",")))
+	sb.WriteString(fmt.Sprintf("  Full meta nodes : %v\n", strings.Join(health.FullMetaNodes, ",")))
+	return sb.String()
+}
+
+func formatInodeACL(info *proto.ACLInfo) string {
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf("# inode: %v\n", info.Inode))
+	sb.WriteString(fmt.Sprintf("# owner: %v\n", info.Uid))
+	sb.WriteString(fmt.Sprintf("# group: %v\n", info.Gid))
+	access := info.Access
+	if access == nil {
+		access = proto.ACL{
+			{Tag: proto.ACLUserObj, Perm: uint16(info.Mode>>6) & 0x7},
+			{Tag: proto.ACLGroupObj, Perm: uint16(info.Mode>>3) & 0x7},
+			{Tag: proto.ACLOther, Perm: uint16(info.Mode) & 0x7},
+		}
+	}
+	sb.WriteString(access.String())
+	for _, line := range strings.Split(strings.TrimSuffix(info.Default.String(), "\n"), "\n") {
+		if line != "" {
+			sb.WriteString(fmt.Sprintf("default:%v\n", line))
+		}
+	}
+	return sb.String()
+}
+
+var dirSummaryTablePattern = "%-12v    %-16v    %-12v    %v\n"
+
+func formatDirSummary(summary *proto.DirSummary) string {
+	// the counts may be negative for a while if the changes are propagated out of order
+	formatBytes := func(bytes int64) string {
+		if bytes < 0 {
+			return fmt.Sprintf("%v B", bytes)
+		}
+		return formatSize(uint64(bytes))
+	}
+	var sb = strings.Builder{}
+	sb.WriteString(fmt.Sprintf(dirSummaryTablePattern, "SCOPE", "BYTES", "FILES", "SUBDIRS"))
+	sb.WriteString(fmt.Sprintf(dirSummaryTablePattern, "direct", formatBytes(summary.Bytes), summary.Files, summary.Subdirs))
+	sb.WriteString(fmt.Sprintf(dirSummaryTablePattern, "recursive", formatBytes(summary.RBytes), summary.RFiles, summary.RSubdirs))
+	return sb.String()
+}
+
+var deletedInodeTablePattern = "%-20v    %-12v    %-8v    %-6v    %-20v    %v\n"
+
+func formatDeletedInodeTableHeader() string {
+	return fmt.Sprintf(deletedInodeTablePattern, "INODE", "SIZE", "EVICTED", "HELD", "DELETE TIME", "PURGE TIME")
+}
+
+func formatDeletedInodeTableRow(inode *proto.DeletedInode) string {
+	purgeTime := "never"
+	if !inode.Held {
+		purgeTime = formatTime(inode.PurgeTime)
+	}
+	return fmt.Sprintf(deletedInodeTablePattern, inode.Inode, formatSize(inode.Size), formatYesNo(inode.Evicted),
+		formatYesNo(inode.Held), formatTime(inode.DeleteTime), purgeTime)
+}
+
+var orphanInodeTablePattern = "%-20v    %-10v    %-6v    %-12v    %-20v    %v\n"
+
+func formatOrphanReport(report *proto.OrphanReport) string {
+	var sb = strings.Builder{}
+	if report.StartTime == 0 {
+		sb.WriteString("  Not scanned yet\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  Start time          : %v\n", formatTime(report.StartTime)))
+	sb.WriteString(fmt.Sprintf("  End time            : %v\n", formatTime(report.EndTime)))
+	sb.WriteString(fmt.Sprintf("  Scanned inodes      : %v\n", report.Scanned))
+	sb.WriteString(fmt.Sprintf("  Requeued inodes     : %v\n", report.Requeued))
+	sb.WriteString(fmt.Sprintf("  Suspects            : %v\n", report.Suspects))
+	sb.WriteString(fmt.Sprintf("  Orphans             : %v\n", len(report.Orphans)))
+	if report.Error != "" {
+		sb.WriteString(fmt.Sprintf("  Error               : %v\n", report.Error))
+	}
+	if len(report.Orphans) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf(orphanInodeTablePattern, "INODE", "TYPE", "NLINK", "SIZE", "CREATE TIME", "PURGED"))
+	for _, orphan := range report.Orphans {
+		kind := "file"
+		if proto.IsDir(orphan.Mode) {
+			kind = "dir"
+		}
+		sb.WriteString(fmt.Sprintf(orphanInodeTablePattern, orphan.Inode, kind, orphan.NLink, formatSize(orphan.Size),
+			formatTime(orphan.CreateTime), formatYesNo(orphan.Purged)))
+	}
+	return sb.String()
+}
+
+var nlinkMismatchTablePattern = "%-20v    %-6v    %-8v    %-8v    %v\n"
+
+func formatNLinkCheckReport(report *proto.NLinkCheckReport) string {
+	var sb = strings.Builder{}
+	if report.StartTime == 0 {
+		sb.WriteString("  Not checked yet\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  Start time          : %v\n", formatTime(report.StartTime)))
+	if report.EndTime == 0 {
+		sb.WriteString("  End time            : running\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("  End time            : %v\n", formatTime(report.EndTime)))
+	}
+	sb.WriteString(fmt.Sprintf("  Fix                 : %v\n", formatYesNo(report.Fix)))
+	sb.WriteString(fmt.Sprintf("  Limit               : %v dentries/s\n", report.Limit))
+	sb.WriteString(fmt.Sprintf("  Scanned dentries    : %v\n", report.Dentries))
+	sb.WriteString(fmt.Sprintf("  Checked inodes      : %v\n", report.Inodes))
+	sb.WriteString(fmt.Sprintf("  Suspects            : %v\n", report.Suspects))
+	sb.WriteString(fmt.Sprintf("  Mismatches          : %v\n", len(report.Mismatches)))
+	if report.Error != "" {
+		sb.WriteString(fmt.Sprintf("  Error               : %v\n", report.Error))
+	}
+	if len(report.Mismatches) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf(nlinkMismatchTablePattern, "INODE", "TYPE", "NLINK", "EXPECTED", "FIXED"))
+	for _, m := range report.Mismatches {
+		kind := "file"
+		if proto.IsDir(m.Mode) {
+			kind = "dir"
+		}
+		sb.WriteString(fmt.Sprintf(nlinkMismatchTablePattern, m.Inode, kind, m.NLink, m.Expected, formatYesNo(m.Fixed)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,507 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/metanode"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdInodeUse   = "inode [COMMAND]"
+	cmdInodeShort = "Inspect the inodes of a volume"
+)
+
+const (
+	// the max depth of the directory tree walked up when resolving the paths
+	maxInodePathDepth = 4096
+)
+
+func newInodeCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdInodeUse,
+		Short: cmdInodeShort,
+		Long: `The inode commands query the http service of the meta nodes directly, which is useful to debug the
+"stale file handle" errors reported by the clients.`,
+	}
+	cmd.AddCommand(
+		newInodeInfoCmd(client),
+		newInodePathCmd(client),
+		newInodeXAttrCmd(client),
+		newInodeACLCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdInodeInfoShort  = "Show the attributes and the extents of an inode on each replica"
+	cmdInodePathShort  = "Resolve the paths of an inode by the dentries of the volume"
+	cmdInodeXAttrShort = "Show the extended attributes of an inode"
+	cmdInodeACLShort   = "Show the POSIX ACLs of an inode"
+)
+
+// inodeReplica defines the attributes of the inode on a meta partition replica.
+type inodeReplica struct {
+	Addr     string
+	IsLeader bool
+	Info     *proto.InodeInfo `json:",omitempty"`
+	Error    string           `json:",omitempty"`
+}
+
+// inodeInspection defines the result of inspecting an inode.
+type inodeInspection struct {
+	VolName     string
+	Inode       uint64
+	PartitionID uint64
+	Replicas    []*inodeReplica
+	Extents     *proto.GetExtentsResponse `json:",omitempty"`
+	ExtentError string                    `json:",omitempty"`
+}
+
+// findInodeMetaPartition returns the meta partition of the volume which the inode belongs to.
+func findInodeMetaPartition(client *master.MasterClient, volName string, ino uint64) (partition *proto.MetaPartitionInfo, err error) {
+	var views []*proto.MetaPartitionView
+	if views, err = client.ClientAPI().GetMetaPartitions(volName); err != nil {
+		return
+	}
+	for _, view := range views {
+		if view.Start <= ino && ino <= view.End {
+			return client.ClientAPI().GetMetaPartition(view.PartitionID)
+		}
+	}
+	return nil, NewArgumentError("inode[%v] is out of the range of the meta partitions of volume[%v]", ino, volName)
+}
+
+// inspectInode queries the attributes of the inode on each replica, and the extents on the leader.
+func inspectInode(client *master.MasterClient, volName string, ino uint64, profPort uint16) (inspection *inodeInspection, err error) {
+	var partition *proto.MetaPartitionInfo
+	if partition, err = findInodeMetaPartition(client, volName, ino); err != nil {
+		return
+	}
+	inspection = &inodeInspection{VolName: volName, Inode: ino, PartitionID: partition.PartitionID}
+	var leader *inodeReplica
+	for _, replica := range partition.Replicas {
+		r := &inodeReplica{Addr: replica.Addr, IsLeader: replica.IsLeader}
+		inspection.Replicas = append(inspection.Replicas, r)
+		if r.IsLeader {
+			leader = r
+		}
+	}
+	sort.Slice(inspection.Replicas, func(i, j int) bool {
+		return inspection.Replicas[i].Addr < inspection.Replicas[j].Addr
+	})
+	var wg sync.WaitGroup
+	for _, replica := range inspection.Replicas {
+		var httpAddr string
+		if httpAddr, err = replicaHttpAddr(replica.Addr, profPort); err != nil {
+			return
+		}
+		wg.Add(1)
+		go func(replica *inodeReplica, httpAddr string) {
+			defer wg.Done()
+			info, getErr := api.NewMetaHttpClient(httpAddr, false).GetInode(partition.PartitionID, ino)
+			if getErr != nil {
+				replica.Error = getErr.Error()
+				return
+			}
+			replica.Info = info
+		}(replica, httpAddr)
+	}
+	wg.Wait()
+	if leader == nil {
+		inspection.ExtentError = "no leader"
+		return
+	}
+	if leader.Info == nil || !proto.IsRegular(leader.Info.Mode) {
+		return
+	}
+	var httpAddr string
+	if httpAddr, err = replicaHttpAddr(leader.Addr, profPort); err != nil {
+		return
+	}
+	var extentErr error
+	if inspection.Extents, extentErr = api.NewMetaHttpClient(httpAddr, false).GetExtentsByInode(partition.PartitionID, ino); extentErr != nil {
+		inspection.ExtentError = extentErr.Error()
+	}
+	return
+}
+
+func formatInodeType(mode uint32) string {
+	switch {
+	case proto.IsDir(mode):
+		return "directory"
+	case proto.IsSymlink(mode):
+		return "symlink"
+	case proto.IsRegular(mode):
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+func printInodeInspection(inspection *inodeInspection) {
+	var info *proto.InodeInfo
+	for _, replica := range inspection.Replicas {
+		if replica.Info != nil && (info == nil || replica.IsLeader) {
+			info = replica.Info
+		}
+	}
+	stdout("Inode:\n")
+	stdout("  Volume      : %v\n", inspection.VolName)
+	stdout("  Inode       : %v\n", inspection.Inode)
+	stdout("  Partition   : %v\n", inspection.PartitionID)
+	if info != nil {
+		stdout("  Type        : %v\n", formatInodeType(info.Mode))
+		stdout("  Mode        : %v\n", os.FileMode(info.Mode))
+		stdout("  Uid / Gid   : %v / %v\n", info.Uid, info.Gid)
+		stdout("  Size        : %v\n", info.Size)
+		stdout("  Nlink       : %v\n", info.Nlink)
+		stdout("  Generation  : %v\n", info.Generation)
+		stdout("  Create time : %v\n", formatTimeToString(info.CreateTime))
+		stdout("  Access time : %v\n", formatTimeToString(info.AccessTime))
+		stdout("  Modify time : %v\n", formatTimeToString(info.ModifyTime))
+		if len(info.Target) > 0 {
+			stdout("  Target      : %v\n", string(info.Target))
+		}
+	}
+	stdout("\n[Replicas]\n")
+	replicaTablePattern := "%-22v    %-8v    %-8v    %-12v    %-12v    %v\n"
+	stdout(replicaTablePattern, "ADDRESS", "LEADER", "NLINK", "SIZE", "GENERATION", "ERROR")
+	for _, replica := range inspection.Replicas {
+		if replica.Info == nil {
+			stdout(replicaTablePattern, replica.Addr, formatYesNo(replica.IsLeader), "N/A", "N/A", "N/A", replica.Error)
+			continue
+		}
+		stdout(replicaTablePattern, replica.Addr, formatYesNo(replica.IsLeader), replica.Info.Nlink, replica.Info.Size,
+			replica.Info.Generation, "")
+	}
+	if inspection.Extents == nil && inspection.ExtentError == "" {
+		return
+	}
+	stdout("\n[Extents]\n")
+	if inspection.ExtentError != "" {
+		stdout("Error: %v\n", inspection.ExtentError)
+		return
+	}
+	extentTablePattern := "%-12v    %-12v    %-10v    %-13v    %-10v    %v\n"
+	stdout(extentTablePattern, "FILE OFFSET", "PARTITION ID", "EXTENT ID", "EXTENT OFFSET", "SIZE", "CRC")
+	for _, ek := range inspection.Extents.Extents {
+		stdout(extentTablePattern, ek.FileOffset, ek.PartitionId, ek.ExtentId, ek.ExtentOffset, ek.Size, ek.CRC)
+	}
+}
+
+func parseInodeArgs(args []string) (volName string, ino uint64, err error) {
+	volName = args[0]
+	if ino, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+		err = NewArgumentError("invalid inode [%v]", args[1])
+	}
+	return
+}
+
+func newInodeInfoCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpInfo + " [VOLUME] [INODE]",
+		Short: cmdInodeInfoShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				volName    string
+				ino        uint64
+				inspection *inodeInspection
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if volName, ino, err = parseInodeArgs(args); err != nil {
+				return
+			}
+			if inspection, err = inspectInode(client, volName, ino, optProfPort); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(inspection)
+				return
+			}
+			printInodeInspection(inspection)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
+
+// collectDentries collects the dentries of the directories and the dentries of the inode from the leaders of
+// all meta partitions of the volume, the dentries are indexed by the inode.
+func collectDentries(client *master.MasterClient, volName string, ino uint64, profPort uint16) (dentries map[uint64][]*metanode.Dentry, err error) {
+	var views []*proto.MetaPartitionView
+	if views, err = client.ClientAPI().GetMetaPartitions(volName); err != nil {
+		return
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	dentries = make(map[uint64][]*metanode.Dentry)
+	for _, view := range views {
+		if view.LeaderAddr == "" {
+			return nil, fmt.Errorf("meta partition[%v] has no leader", view.PartitionID)
+		}
+		var httpAddr string
+		if httpAddr, err = replicaHttpAddr(view.LeaderAddr, profPort); err != nil {
+			return
+		}
+		wg.Add(1)
+		go func(partitionID uint64, httpAddr string) {
+			defer wg.Done()
+			all, getErr := api.NewMetaHttpClient(httpAddr, false).GetAllDentry(partitionID)
+			mu.Lock()
+			defer mu.Unlock()
+			if getErr != nil {
+				if err == nil {
+					err = fmt.Errorf("get dentries of meta partition[%v] from [%v] failed: %v", partitionID, httpAddr, getErr)
+				}
+				return
+			}
+			for _, dentry := range all {
+				if dentry.Inode == ino || proto.IsDir(dentry.Type) {
+					dentries[dentry.Inode] = append(dentries[dentry.Inode], dentry)
+				}
+			}
+		}(view.PartitionID, httpAddr)
+	}
+	wg.Wait()
+	return
+}
+
+// resolveInodePaths walks up the directory tree from each dentry of the inode. The paths of the inodes
+// whose ancestors are missing start with the inode of the missing ancestor, such as "<inode 1234>/a/b".
+func resolveInodePaths(dentries map[uint64][]*metanode.Dentry, ino uint64) (paths []string) {
+	paths = make([]string, 0)
+	if ino == proto.RootIno {
+		return append(paths, "/")
+	}
+	for _, dentry := range dentries[ino] {
+		names := []string{dentry.Name}
+		parent := dentry.ParentId
+		root := "/"
+		for depth := 0; parent != proto.RootIno; depth++ {
+			ancestors := dentries[parent]
+			if len(ancestors) == 0 || depth >= maxInodePathDepth {
+				root = fmt.Sprintf("<inode %v>", parent)
+				break
+			}
+			names = append(names, ancestors[0].Name)
+			parent = ancestors[0].ParentId
+		}
+		for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+			names[i], names[j] = names[j], names[i]
+		}
+		paths = append(paths, path.Join(append([]string{root}, names...)...))
+	}
+	sort.Strings(paths)
+	return
+}
+
+func newInodePathCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpPath + " [VOLUME] [INODE]",
+		Short: cmdInodePathShort,
+		Long: `Resolve the paths of the inode by walking up the dentries. All the dentries of the volume are read from the
+leaders of the meta partitions, so it may take a long time for a large volume. A file with hard links has
+multiple paths, and no path is found for the inodes which are not linked by any dentry.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err      error
+				volName  string
+				ino      uint64
+				dentries map[uint64][]*metanode.Dentry
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if volName, ino, err = parseInodeArgs(args); err != nil {
+				return
+			}
+			if dentries, err = collectDentries(client, volName, ino, optProfPort); err != nil {
+				return
+			}
+			paths := resolveInodePaths(dentries, ino)
+			if isStructuredOutput() {
+				err = printStructured(paths)
+				return
+			}
+			if len(paths) == 0 {
+				stdout("No dentry links to inode[%v].\n", ino)
+				return
+			}
+			for _, p := range paths {
+				stdout("%v\n", p)
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
+
+// leaderHttpAddr returns the address of the http service of the leader of the meta partition.
+func leaderHttpAddr(partition *proto.MetaPartitionInfo, profPort uint16) (string, error) {
+	for _, replica := range partition.Replicas {
+		if replica.IsLeader {
+			return replicaHttpAddr(replica.Addr, profPort)
+		}
+	}
+	return "", fmt.Errorf("meta partition[%v] has no leader", partition.PartitionID)
+}
+
+func newInodeXAttrCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpXAttr + " [VOLUME] [INODE]",
+		Short: cmdInodeXAttrShort,
+		Long:  `Show the extended attributes of the inode, which are read from the leader of its meta partition.`,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err       error
+				volName   string
+				ino       uint64
+				partition *proto.MetaPartitionInfo
+				httpAddr  string
+				info      *proto.XAttrInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if volName, ino, err = parseInodeArgs(args); err != nil {
+				return
+			}
+			if partition, err = findInodeMetaPartition(client, volName, ino); err != nil {
+				return
+			}
+			if httpAddr, err = leaderHttpAddr(partition, optProfPort); err != nil {
+				return
+			}
+			if info, err = api.NewMetaHttpClient(httpAddr, false).GetXAttrs(partition.PartitionID, ino); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(info)
+				return
+			}
+			keys := make([]string, 0, len(info.XAttrs))
+			for key := range info.XAttrs {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			stdout("[Extended attributes of inode %v]\n", ino)
+			xattrTablePattern := "%-32v    %-8v    %v\n"
+			stdout(xattrTablePattern, "NAME", "SIZE", "VALUE")
+			for _, key := range keys {
+				value := info.XAttrs[key]
+				stdout(xattrTablePattern, key, len(value), strconv.Quote(value))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
+
+func newInodeACLCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpACL + " [VOLUME] [INODE]",
+		Short: cmdInodeACLShort,
+		Long: `Show the access ACL and the default ACL of the inode in the format of getfacl, which are read from the
+leader of its meta partition. The inode without any ACL is shown by its mode bits.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err       error
+				volName   string
+				ino       uint64
+				partition *proto.MetaPartitionInfo
+				httpAddr  string
+				info      *proto.ACLInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if volName, ino, err = parseInodeArgs(args); err != nil {
+				return
+			}
+			if partition, err = findInodeMetaPartition(client, volName, ino); err != nil {
+				return
+			}
+			if httpAddr, err = leaderHttpAddr(partition, optProfPort); err != nil {
+				return
+			}
+			if info, err = api.NewMetaHttpClient(httpAddr, false).GetACL(partition.PartitionID, ino); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(info)
+				return
+			}
+			stdout("%v", formatInodeACL(info))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
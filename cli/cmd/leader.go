@@ -0,0 +1,71 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+func newTransferLeaderCmd(short, kind string, transfer func(partitionID uint64, addr string) error,
+	validNodes func(client *master.MasterClient, toComplete string) []string, client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpTransferLeader + " [PARTITION ID] [TARGET ADDRESS]",
+		Short: short,
+		Long: `Make the replica on the target node the raft leader of the partition, so that the leadership can be
+drained from a node before maintenance instead of waiting for the elections after the node goes down. The target
+replica campaigns for the leadership, and the command fails if it does not win the election in time, for example
+when its raft log falls behind the others.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			address := args[1]
+			if err = transfer(partitionID, address); err != nil {
+				return
+			}
+			stdout("Transfer the leader of %v partition %v to %v successfully\n", kind, partitionID, address)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newMetaPartitionTransferLeaderCmd(client *master.MasterClient) *cobra.Command {
+	return newTransferLeaderCmd(cmdMetaPartitionTransferLeaderShort, "meta",
+		client.AdminAPI().TransferMetaPartitionLeader, validMetaNodes, client)
+}
+
+func newDataPartitionTransferLeaderCmd(client *master.MasterClient) *cobra.Command {
+	return newTransferLeaderCmd(cmdDataPartitionTransferLeaderShort, "data",
+		client.AdminAPI().TransferDataPartitionLeader, validDataNodes, client)
+}
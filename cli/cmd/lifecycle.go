@@ -0,0 +1,220 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdLifecycleUse   = "lifecycle [COMMAND]"
+	cmdLifecycleShort = "Manage storage class lifecycle rules of volumes"
+)
+
+func newLifecycleCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdLifecycleUse,
+		Short: cmdLifecycleShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newLifecycleListCmd(client),
+		newLifecycleSetCmd(client),
+		newLifecycleDeleteCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdLifecycleListShort   = "List the lifecycle rules of the volume"
+	cmdLifecycleSetShort    = "Transit the files of a directory subtree or the volume to a storage class after the days"
+	cmdLifecycleDeleteShort = "Delete a lifecycle rule of the volume"
+)
+
+func newLifecycleListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList + " [VOLUME]",
+		Short:   cmdLifecycleListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var rules []*proto.LifecycleRule
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if rules, err = client.AdminAPI().ListLifecycleRules(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(rules)
+				return
+			}
+			stdout("%v\n", formatLifecycleRuleTableHeader())
+			for _, rule := range rules {
+				stdout("%v\n", formatLifecycleRuleTableRow(rule))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newLifecycleSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var optDays uint32
+	var optBasis string
+	var optClass string
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [VOLUME] [PATH]",
+		Short: cmdLifecycleSetShort,
+		Long: `Transit the files in the directory subtree, or in the whole volume if the path is "/", which are not modified
+or accessed for the days to the storage class. The extents of the cold class are moved out of the cache disks and
+compressed by the data nodes, and the ones of the replica class are decompressed back. The files are transited by the
+leaders of the meta partitions periodically. The rule of the directory is replaced if it exists.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volName, dirPath = args[0], args[1]
+			var reply *proto.LifecycleRuleReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !proto.IsValidExpirationBasis(optBasis) {
+				err = NewArgumentError("invalid basis[%v], expect %v or %v", optBasis, proto.ExpireByModifyTime, proto.ExpireByAccessTime)
+				return
+			}
+			if !proto.IsValidStorageClass(optClass) {
+				err = NewArgumentError("invalid storage class[%v], expect %v or %v", optClass, proto.StorageClassReplica, proto.StorageClassCold)
+				return
+			}
+			if !optYes {
+				stdout("Set the lifecycle rule of directory [%v] in volume [%v]\n", dirPath, volName)
+				stdout("  Days         : %v\n", optDays)
+				stdout("  Basis        : %v\n", optBasis)
+				stdout("  Storage class: %v\n", optClass)
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().SetLifecycleRule(volName, dirPath, optDays, optBasis, optClass); err != nil {
+				err = annotateError(err, "Set lifecycle rule failed: %v\n", err)
+				return
+			}
+			printLifecycleRuleReply("Set lifecycle rule success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint32Var(&optDays, CliFlagDays, 0, "Specify the days after which the files are transited")
+	cmd.Flags().StringVar(&optBasis, CliFlagBasis, proto.ExpireByModifyTime,
+		fmt.Sprintf("Specify the time the files are transited by [%v | %v]", proto.ExpireByModifyTime, proto.ExpireByAccessTime))
+	cmd.Flags().StringVar(&optClass, CliFlagStorageClass, proto.StorageClassCold,
+		fmt.Sprintf("Specify the storage class to transit the files to [%v | %v]", proto.StorageClassReplica, proto.StorageClassCold))
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newLifecycleDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [VOLUME] [RULE ID]",
+		Short: cmdLifecycleDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var ruleID uint64
+			var reply *proto.LifecycleRuleReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if ruleID, err = strconv.ParseUint(args[1], 10, 32); err != nil {
+				err = NewArgumentError("invalid rule ID[%v]", args[1])
+				return
+			}
+			if !optYes {
+				stdout("Delete the lifecycle rule [%v] of volume [%v]\n", ruleID, args[0])
+				stdout("The files transited by the rule are kept in their storage classes.\n")
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().DeleteLifecycleRule(args[0], uint32(ruleID)); err != nil {
+				err = annotateError(err, "Delete lifecycle rule failed: %v\n", err)
+				return
+			}
+			printLifecycleRuleReply("Delete lifecycle rule success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+var lifecycleRuleTablePattern = "%-8v    %-32v    %-12v    %-8v    %-8v    %-8v"
+
+func formatLifecycleRuleTableHeader() string {
+	return fmt.Sprintf(lifecycleRuleTablePattern, "ID", "PATH", "ROOT INODE", "DAYS", "BASIS", "CLASS")
+}
+
+func formatLifecycleRuleTableRow(rule *proto.LifecycleRule) string {
+	return fmt.Sprintf(lifecycleRuleTablePattern, rule.RuleID, rule.Path, rule.RootInode, rule.Days, rule.Basis,
+		rule.StorageClass)
+}
+
+func printLifecycleRuleReply(msg string, reply *proto.LifecycleRuleReply) {
+	if isStructuredOutput() {
+		if err := printStructured(reply); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("%v:\n", msg)
+	stdout("%v\n", formatLifecycleRuleTableHeader())
+	stdout("%v\n", formatLifecycleRuleTableRow(reply.Rule))
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdClusterBackupUse         = CliOpBackup + " [COMMAND]"
+	cmdClusterBackupShort       = "Manage the backups of the metadata of the masters in the object store"
+	cmdClusterBackupCreateShort = "Back up the metadata of the masters now"
+	cmdClusterBackupListShort   = "List the backups of the metadata of the cluster"
+	cmdClusterRestoreShort      = "Restore the metadata of an empty cluster from a backup"
+)
+
+var (
+	metadataBackupTablePattern = "%-19v    %-12v    %-10v    %v"
+	metadataBackupTableHeader  = fmt.Sprintf(metadataBackupTablePattern, "TIME", "APPLIED", "SIZE", "KEY")
+)
+
+func newClusterBackupCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdClusterBackupUse,
+		Short: cmdClusterBackupShort,
+		Long: `The leader master backs up the metadata, including the volumes, the partitions, the nodes and the users,
+to the S3 compatible object store configured by "metadataBackupEndpoint" and "metadataBackupBucket" every
+"metadataBackupInterval" seconds, and keeps the latest "metadataBackupRetention" backups.`,
+	}
+	cmd.AddCommand(
+		newClusterBackupCreateCmd(client),
+		newClusterBackupListCmd(client),
+	)
+	return cmd
+}
+
+func newClusterBackupCreateCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpCreate,
+		Short: cmdClusterBackupCreateShort,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				info *proto.MetadataBackupInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if info, err = client.AdminAPI().CreateMetadataBackup(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(info)
+				return
+			}
+			stdout("Metadata is backed up to [%v].\n", info.Key)
+		},
+	}
+	return cmd
+}
+
+func newClusterBackupListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdClusterBackupListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err     error
+				backups []*proto.MetadataBackupInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if backups, err = client.AdminAPI().ListMetadataBackups(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(backups)
+				return
+			}
+			stdout("%v\n", metadataBackupTableHeader)
+			for _, backup := range backups {
+				stdout("%v\n", formatMetadataBackup(backup))
+			}
+		},
+	}
+	return cmd
+}
+
+func newClusterRestoreCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optKey  string
+		optTime string
+		optYes  bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpRestore,
+		Short: cmdClusterRestoreShort,
+		Long: `Restore the metadata from the backup of the key, or from the latest backup taken before the time. The masters
+must be newly deployed with the same cluster name and without any volume or node, and the data nodes and the meta
+nodes are started after the restore.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err    error
+				before int64
+				info   *proto.MetadataBackupInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optTime != "" {
+				var t time.Time
+				if t, err = parseAuditSince(optTime, time.Now()); err != nil {
+					err = fmt.Errorf("invalid %v: %v", CliFlagTime, optTime)
+					return
+				}
+				before = t.Unix()
+			}
+			if !optYes {
+				stdout("Restore the metadata of the cluster from the backup (yes/no)[no]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if info, err = client.AdminAPI().RestoreMetadataBackup(optKey, before); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(info)
+				return
+			}
+			stdout("Metadata is restored from [%v] backed up at %v.\n", info.Key, formatTime(info.Time))
+		},
+	}
+	cmd.Flags().StringVar(&optKey, CliFlagKey, "", "Specify the key of the backup to restore")
+	cmd.Flags().StringVar(&optTime, CliFlagTime, "",
+		`Restore the latest backup taken before the duration ago such as "2h", or before the time such as "2006-01-02 15:04:05"`)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func formatMetadataBackup(backup *proto.MetadataBackupInfo) string {
+	return fmt.Sprintf(metadataBackupTablePattern, formatTime(backup.Time), backup.Applied, formatSize(uint64(backup.Size)),
+		backup.Key)
+}
@@ -18,6 +18,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/cli/api"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
 	"github.com/spf13/cobra"
@@ -37,6 +38,19 @@ func newMetaNodeCmd(client *master.MasterClient) *cobra.Command {
 		newMetaNodeListCmd(client),
 		newMetaNodeInfoCmd(client),
 		newMetaNodeDecommissionCmd(client),
+		newMetaNodePartitionsCmd(client),
+		newNodeSetLabelsCmd(&nodeLabelAPI{
+			nodeType:   "meta",
+			set:        client.NodeAPI().SetMetaNodeLabels,
+			validNodes: func(toComplete string) []string { return validMetaNodes(client, toComplete) },
+		}),
+		newNodeRebalanceCmd(&rebalanceAPI{
+			nodeType: "meta",
+			get:      client.AdminAPI().GetMetaRebalance,
+			set:      client.AdminAPI().SetMetaRebalance,
+			pause:    client.AdminAPI().PauseMetaRebalance,
+			resume:   client.AdminAPI().ResumeMetaRebalance,
+		}, cmdMetaNodeRebalanceLong),
 	)
 	return cmd
 }
@@ -45,6 +59,12 @@ const (
 	cmdMetaNodeListShort             = "List information of meta nodes"
 	cmdMetaNodeInfoShort             = "Show information of meta nodes"
 	cmdMetaNodeDecommissionInfoShort = "Decommission partitions in a meta node to other nodes"
+	cmdMetaNodePartitionsShort       = "List the meta partitions hosted on a meta node"
+	cmdMetaNodeRebalanceLong         = `The rebalancer of the master migrates the meta partitions from the meta nodes whose memory usage ratio
+is above the high ratio to the meta nodes in the same zone whose ratio stays below the low ratio after the
+migration, the largest partitions first. The memory of a partition is estimated by its inodes and dentries.
+No more than the limit of partitions are migrating at the same time, and the rebalancer is disabled if the
+limit is 0.`
 )
 
 func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
@@ -68,8 +88,7 @@ func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
 			sort.SliceStable(view.MetaNodes, func(i, j int) bool {
 				return view.MetaNodes[i].ID < view.MetaNodes[j].ID
 			})
-			stdout("[Meta nodes]\n")
-			stdout("%v\n", formatNodeViewTableHeader())
+			var nodes = make([]proto.NodeView, 0, len(view.MetaNodes))
 			for _, node := range view.MetaNodes {
 				if optFilterStatus != "" &&
 					!strings.Contains(formatNodeStatus(node.Status), optFilterStatus) {
@@ -79,6 +98,15 @@ func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
 					!strings.Contains(formatYesNo(node.IsWritable), optFilterWritable) {
 					continue
 				}
+				nodes = append(nodes, node)
+			}
+			if isStructuredOutput() {
+				err = printStructured(nodes)
+				return
+			}
+			stdout("[Meta nodes]\n")
+			stdout("%v\n", formatNodeViewTableHeader())
+			for _, node := range nodes {
 				stdout("%v\n", formatNodeView(&node, true))
 			}
 		},
@@ -89,14 +117,19 @@ func newMetaNodeListCmd(client *master.MasterClient) *cobra.Command {
 }
 
 func newMetaNodeInfoCmd(client *master.MasterClient) *cobra.Command {
+	var optMemory bool
+	var optProfPort uint16
 	var cmd = &cobra.Command{
 		Use:   CliOpInfo + " [NODE ADDRESS]",
 		Short: cmdMetaNodeInfoShort,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Show the information of the meta node. With --memory, the memory used by the node is shown with the
+estimated memory of each meta partition, which is read from the http service of the node.`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			var err error
 			var nodeAddr string
 			var metanodeInfo *proto.MetaNodeInfo
+			var memory *proto.MetaNodeMemory
 			defer func() {
 				if err != nil {
 					errout("Error: %v", err)
@@ -106,8 +139,32 @@ func newMetaNodeInfoCmd(client *master.MasterClient) *cobra.Command {
 			if metanodeInfo, err = client.NodeAPI().GetMetaNode(nodeAddr); err != nil {
 				return
 			}
+			if optMemory {
+				var httpAddr string
+				if httpAddr, err = replicaHttpAddr(nodeAddr, optProfPort); err != nil {
+					return
+				}
+				if memory, err = api.NewMetaHttpClient(httpAddr, false).GetMemoryUsage(); err != nil {
+					return
+				}
+			}
+			if isStructuredOutput() {
+				if memory != nil {
+					err = printStructured(struct {
+						*proto.MetaNodeInfo
+						Memory *proto.MetaNodeMemory
+					}{metanodeInfo, memory})
+					return
+				}
+				err = printStructured(metanodeInfo)
+				return
+			}
 			stdout("[Meta node info]\n")
 			stdout(formatMetaNodeDetail(metanodeInfo, false))
+			if memory != nil {
+				stdout("\n[Memory]\n")
+				stdout(formatMetaNodeMemory(memory))
+			}
 
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -117,6 +174,8 @@ func newMetaNodeInfoCmd(client *master.MasterClient) *cobra.Command {
 			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optMemory, CliFlagMemory, false, "Show the memory used by each meta partition")
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
 	return cmd
 }
 func newMetaNodeDecommissionCmd(client *master.MasterClient) *cobra.Command {
@@ -148,3 +207,50 @@ func newMetaNodeDecommissionCmd(client *master.MasterClient) *cobra.Command {
 	}
 	return cmd
 }
+
+func newMetaNodePartitionsCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpPartitions + " [NODE ADDRESS]",
+		Short: cmdMetaNodePartitionsShort,
+		Long: `List all the meta partitions hosted on the node with the role and the status of the replicas, which is
+useful for planning the decommission of the node.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err        error
+				partitions []*proto.NodePartitionView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitions, err = client.NodeAPI().GetMetaNodePartitions(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(partitions)
+				return
+			}
+			var leaders, missing int
+			stdout("%v\n", metaNodePartitionTableHeader)
+			for _, partition := range partitions {
+				if partition.IsLeader {
+					leaders++
+				}
+				if partition.IsMissing {
+					missing++
+				}
+				stdout("%v\n", formatMetaNodePartitionTableRow(partition))
+			}
+			stdout("\nTotal: %v, leaders: %v, missing: %v\n", len(partitions), leaders, missing)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
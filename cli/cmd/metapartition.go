@@ -21,6 +21,9 @@ import (
 	"github.com/spf13/cobra"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -35,20 +38,50 @@ func newMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
 	}
 	cmd.AddCommand(
 		newMetaPartitionGetCmd(client),
+		newMetaPartitionListCmd(client),
 		newListCorruptMetaPartitionCmd(client),
 		newMetaPartitionDecommissionCmd(client),
 		newMetaPartitionReplicateCmd(client),
 		newMetaPartitionDeleteReplicaCmd(client),
+		newMetaPartitionResetCmd(client),
+		newMetaPartitionRepairCmd(client),
+		newMetaPartitionVerifyCmd(client),
+		newMetaPartitionTransferLeaderCmd(client),
+		newMetaPartitionOrphanInodesCmd(client),
+		newMetaPartitionExportCmd(client),
+		newMetaPartitionImportCmd(client),
+		newMetaPartitionDeletedInodesCmd(client),
+		newMetaPartitionPurgeInodeCmd(client),
+		newMetaPartitionCancelPurgeCmd(client),
+		newMetaPartitionRaftStatusCmd(client),
+		newMetaPartitionCheckNLinkCmd(client),
+		newMetaPartitionNLinkReportCmd(client),
+		newMetaPartitionDumpCmd(client),
 	)
 	return cmd
 }
 
 const (
 	cmdMetaPartitionGetShort              = "Display detail information of a meta partition"
+	cmdMetaPartitionListShort             = "List meta partitions filtered by volume, status and node"
 	cmdCheckCorruptMetaPartitionShort     = "Check out corrupt meta partitions"
 	cmdMetaPartitionDecommissionShort     = "Decommission a replication of the meta partition to a new address"
 	cmdMetaPartitionReplicateShort        = "Add a replication of the meta partition on a new address"
 	cmdMetaPartitionDeleteReplicaShort    = "Delete a replication of the meta partition on a fixed address"
+	cmdMetaPartitionResetShort            = "Reset the raft members of a corrupt meta partition to the remaining replicas"
+	cmdMetaPartitionRepairShort           = "Add the lacked replicas of the meta partitions found by check"
+	cmdMetaPartitionVerifyShort           = "Verify the consistency of the metadata among the replicas of a meta partition"
+	cmdMetaPartitionTransferLeaderShort   = "Transfer the raft leader of a meta partition to the replica on a node"
+	cmdMetaPartitionOrphanInodesShort     = "Show the orphan inodes found by the latest scan of a meta partition"
+	cmdMetaPartitionExportShort           = "Export a consistent snapshot of the metadata of a meta partition"
+	cmdMetaPartitionImportShort           = "Import the snapshot exported from a meta partition into a meta partition"
+	cmdMetaPartitionDeletedInodesShort    = "List the deleted files of a meta partition which are not purged yet"
+	cmdMetaPartitionPurgeInodeShort       = "Purge a deleted file of a meta partition without waiting for the retention"
+	cmdMetaPartitionCancelPurgeShort      = "Hold a deleted file of a meta partition from being purged"
+	cmdMetaPartitionRaftStatusShort       = "Show the raft progress and the lag of the replicas of a meta partition"
+	cmdMetaPartitionCheckNLinkShort       = "Check the link counts of the inodes of a meta partition against the dentries"
+	cmdMetaPartitionNLinkReportShort      = "Show the result of the latest link count check of a meta partition"
+	cmdMetaPartitionDumpShort             = "Dump the inodes or the dentries of a meta partition matching the filter as JSON lines"
 	)
 
 func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
@@ -73,108 +106,485 @@ func newMetaPartitionGetCmd(client *master.MasterClient) *cobra.Command {
 			if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(partition)
+				return
+			}
 			stdout(formatMetaPartitionInfo(partition))
 		},
 	}
 	return cmd
 }
 
-func newListCorruptMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
+const (
+	defaultPartitionListLimit = 100
+)
+
+func newMetaPartitionListCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optVol    string
+		optStatus string
+		optNode   string
+		optOffset int
+		optLimit  int
+	)
 	var cmd = &cobra.Command{
-		Use:   CliOpCheck,
-		Short: cmdCheckCorruptMetaPartitionShort,
-		Long: `If the meta nodes are marked as "Inactive", it means the nodes has been not available for a long time. It is suggested to eliminate
-the network, disk or other problems first. If the bad nodes can never be "active" again, they are called corrupt nodes. And the 
-"decommission" command can be used to discard the corrupt nodes. However, if more than half replicas of a partition are on 
-the corrupt nodes, the few remaining replicas can not reach an agreement with one leader. In this case, you can use the 
-"metapartition reset" command to fix the problem, however this action may lead to data loss, be careful to do this. The 
-"reset" command will be released in next version.`,
+		Use:     CliOpList,
+		Short:   cmdMetaPartitionListShort,
+		Aliases: []string{"ls"},
+		Long: `List the meta partitions matching all the given filters, the partitions are sorted by ID. The "--status"
+flag accepts "unhealthy", "readonly" and "rw". A partition is unhealthy if it is unavailable, has no leader, is
+recovering or lacks replicas. Use "--offset" and "--limit" to page through the result, a zero limit shows all.`,
 		Run: func(cmd *cobra.Command, args []string) {
 			var (
-				diagnosis     *proto.MetaPartitionDiagnosis
-				metaNodes     []*proto.MetaNodeInfo
-				err           error
+				err  error
+				view *proto.MetaPartitionListView
 			)
 			defer func() {
 				if err != nil {
 					errout("Error: %v", err)
 				}
 			}()
-			if diagnosis, err = client.AdminAPI().DiagnoseMetaPartition(); err != nil {
+			switch optStatus {
+			case "", proto.PartitionFilterUnhealthy, proto.PartitionFilterReadOnly, proto.PartitionFilterReadWrite:
+			default:
+				err = NewArgumentError("invalid status [%v], should be one of %v, %v, %v", optStatus,
+					proto.PartitionFilterUnhealthy, proto.PartitionFilterReadOnly, proto.PartitionFilterReadWrite)
 				return
 			}
-			stdout("[Inactive Meta nodes]:\n")
-			stdout("%v\n", formatMetaNodeDetailTableHeader())
-			sort.SliceStable(diagnosis.InactiveMetaNodes, func(i, j int) bool {
-				return diagnosis.InactiveMetaNodes[i] < diagnosis.InactiveMetaNodes[j]
-			})
-			for _, addr := range diagnosis.InactiveMetaNodes {
-				var node *proto.MetaNodeInfo
-				node, err = client.NodeAPI().GetMetaNode(addr)
-				metaNodes = append(metaNodes, node)
+			if optOffset < 0 || optLimit < 0 {
+				err = NewArgumentError("offset and limit should not be negative")
+				return
 			}
-			sort.SliceStable(metaNodes, func(i, j int) bool {
-				return metaNodes[i].ID < metaNodes[j].ID
-			})
-			for _, node := range metaNodes {
-				stdout("%v\n", formatMetaNodeDetail(node, true))
+			if view, err = client.AdminAPI().ListMetaPartitions(optVol, optStatus, optNode, optOffset, optLimit); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("%v\n", metaPartitionListTableHeader)
+			for _, item := range view.Partitions {
+				stdout("%v\n", formatMetaPartitionListTableRow(item))
 			}
+			if end := optOffset + len(view.Partitions); end < view.Total {
+				stdout("\nShowing %v-%v of %v partitions, use \"--%v %v\" to show the next page\n",
+					optOffset+1, end, view.Total, CliFlagOffset, end)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&optVol, CliFlagVol, "", "Show the partitions of the volume only")
+	cmd.Flags().StringVar(&optStatus, CliFlagStatus, "", "Show the partitions in the status only [unhealthy, readonly, rw]")
+	cmd.Flags().StringVar(&optNode, CliFlagNode, "", "Show the partitions which have a replica on the node only")
+	cmd.Flags().IntVar(&optOffset, CliFlagOffset, 0, "Number of partitions to skip")
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, defaultPartitionListLimit, "Maximum number of partitions to show, 0 means no limit")
+	return cmd
+}
 
-			stdout("\n")
-			stdout("[Corrupt meta partitions](no leader):\n")
-			stdout("%v\n", partitionInfoTableHeader)
-			sort.SliceStable(diagnosis.CorruptMetaPartitionIDs, func(i, j int) bool {
-				return diagnosis.CorruptMetaPartitionIDs[i] < diagnosis.CorruptMetaPartitionIDs[j]
-			})
-			for _, pid := range diagnosis.CorruptMetaPartitionIDs {
-				var partition *proto.MetaPartitionInfo
-				if partition, err = client.ClientAPI().GetMetaPartition(pid); err != nil {
-					err = fmt.Errorf("Partition not found, err:[%v] ", err)
+func newListCorruptMetaPartitionCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optWatch       bool
+		optInterval    time.Duration
+		optExport      string
+		optVols        []string
+		optConcurrency int
+		optCached      bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpCheck + " [REPORT PATH]",
+		Short: cmdCheckCorruptMetaPartitionShort,
+		Long: `If the meta nodes are marked as "Inactive", it means the nodes has been not available for a long time. It is suggested to eliminate
+the network, disk or other problems first. If the bad nodes can never be "active" again, they are called corrupt nodes. And the 
+"decommission" command can be used to discard the corrupt nodes. However, if more than half replicas of a partition are on 
+the corrupt nodes, the few remaining replicas can not reach an agreement with one leader. In this case, you can use the 
+"metapartition reset" command to fix the problem, however this action may lead to data loss, be careful to do this.
+With the "--export" flag, the diagnosis is also written to the report file in csv or html format.
+With the "--vol" flag, only the partitions of the volumes are checked, and the volumes are checked concurrently.
+With the "--cached" flag, the findings cached by the consistency checker of the master are shown at once, including
+the replicas found inconsistent with the others by the checker.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optCached {
+				if optWatch || optExport != "" || len(optVols) > 0 {
+					err = NewArgumentError("--%v can not be used with --%v, --%v or --%v", CliFlagCached, CliFlagWatch,
+						CliFlagExport, CliFlagVol)
 					return
 				}
-				stdout("%v\n", formatMetaPartitionInfoRow(partition))
+				err = checkCachedMetaPartitions(client)
+				return
 			}
-
-			stdout("\n")
-			stdout("%v\n", "[Meta partition lack replicas]:")
-			stdout("%v\n", partitionInfoTableHeader)
-			sort.SliceStable(diagnosis.LackReplicaMetaPartitionIDs, func(i, j int) bool {
-				return diagnosis.LackReplicaMetaPartitionIDs[i] < diagnosis.LackReplicaMetaPartitionIDs[j]
-			})
-			for _, pid := range diagnosis.LackReplicaMetaPartitionIDs {
-				var partition *proto.MetaPartitionInfo
-				if partition, err = client.ClientAPI().GetMetaPartition( pid); err != nil {
-					err = fmt.Errorf("Partition not found, err:[%v] ", err)
+			if optExport != "" {
+				if len(args) == 0 {
+					err = NewArgumentError("the path of the report is required with --%v", CliFlagExport)
+					return
+				}
+				if optWatch {
+					err = NewArgumentError("--%v can not be used with --%v", CliFlagExport, CliFlagWatch)
+					return
+				}
+				if err = validateReportFormat(optExport); err != nil {
 					return
 				}
-				if partition != nil {
-					stdout("%v\n", formatMetaPartitionInfoRow(partition))
+				var detail *metaPartitionDiagnosisDetail
+				if detail, err = collectMetaPartitionDiagnosis(client, optVols, optConcurrency); err != nil {
+					return
+				}
+				if err = printMetaPartitionDiagnosis(detail); err != nil {
+					return
 				}
+				err = exportMetaPartitionDiagnosis(client, detail, optExport, args[0])
+				return
+			}
+			if len(args) > 0 {
+				err = NewArgumentError("the report path is only used with --%v", CliFlagExport)
+				return
 			}
+			if !optWatch {
+				_, err = checkCorruptMetaPartitions(client, optVols, optConcurrency)
+				return
+			}
+			err = watchPartitionDiagnosis(optInterval, func() ([]uint64, error) {
+				return checkCorruptMetaPartitions(client, optVols, optConcurrency)
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&optWatch, CliFlagWatch, "w", false, "Re-run the diagnosis periodically and show the changes")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultWatchInterval, "Interval of re-running the diagnosis with --watch")
+	cmd.Flags().StringVar(&optExport, CliFlagExport, "", "Export the diagnosis to the report path [csv | html]")
+	cmd.Flags().StringSliceVar(&optVols, CliFlagVol, nil, "Check the partitions of the volumes only")
+	cmd.Flags().IntVar(&optConcurrency, CliFlagConcurrency, defaultBatchConcurrency, "Number of volumes checked concurrently with --vol")
+	cmd.Flags().BoolVar(&optCached, CliFlagCached, false, "Show the findings cached by the consistency checker of the master")
+	return cmd
+}
 
-			stdout("\n")
-			stdout("%v\n", "[Bad meta partitions(decommission not completed)]:")
-			badPartitionTablePattern := "%-8v    %-10v\n"
-			stdout(badPartitionTablePattern, "PATH", "PARTITION ID")
-			for _, bmpv := range diagnosis.BadMetaPartitionIDs {
-				sort.SliceStable(bmpv.PartitionIDs, func(i, j int) bool {
-					return bmpv.PartitionIDs[i] < bmpv.PartitionIDs[j]
-				})
-				for _, pid := range bmpv.PartitionIDs {
-					stdout(badPartitionTablePattern, bmpv.Path, pid)
+// metaPartitionDiagnosisDetail is the diagnosis with the detail of the inactive nodes and the unhealthy partitions.
+type metaPartitionDiagnosisDetail struct {
+	diagnosis             *proto.MetaPartitionDiagnosis
+	inactiveNodes         []*proto.MetaNodeInfo
+	corruptPartitions     []*proto.MetaPartitionInfo
+	lackReplicaPartitions []*proto.MetaPartitionInfo
+	rackRiskPartitions    []*proto.MetaPartitionInfo
+}
+
+// diagnoseVolsMetaPartitions diagnoses the meta partitions of the volumes concurrently, and merges the results.
+func diagnoseVolsMetaPartitions(client *master.MasterClient, vols []string, concurrency int) (diagnosis *proto.MetaPartitionDiagnosis, err error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		limit   = make(chan struct{}, concurrency)
+		results = make([]*proto.MetaPartitionDiagnosis, len(vols))
+	)
+	for i, volName := range vols {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, volName string) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			result, volErr := client.AdminAPI().DiagnoseVolMetaPartition(volName)
+			mu.Lock()
+			defer mu.Unlock()
+			if volErr != nil {
+				if err == nil {
+					err = annotateError(volErr, "diagnose volume[%v] failed: %v", volName, volErr)
 				}
+				return
 			}
+			results[i] = result
+		}(i, volName)
+	}
+	wg.Wait()
+	if err != nil {
+		return
+	}
+	diagnosis = &proto.MetaPartitionDiagnosis{
+		InactiveMetaNodes:           make([]string, 0),
+		CorruptMetaPartitionIDs:     make([]uint64, 0),
+		LackReplicaMetaPartitionIDs: make([]uint64, 0),
+		BadMetaPartitionIDs:         make([]proto.BadPartitionView, 0),
+		PeerInconsistentPartitions:  make([]proto.PeerInconsistentPartition, 0),
+		RackRiskMetaPartitionIDs:    make([]uint64, 0),
+	}
+	badPartitions := make(map[string][]uint64)
+	for _, result := range results {
+		for _, addr := range result.InactiveMetaNodes {
+			if !containsString(diagnosis.InactiveMetaNodes, addr) {
+				diagnosis.InactiveMetaNodes = append(diagnosis.InactiveMetaNodes, addr)
+			}
+		}
+		diagnosis.CorruptMetaPartitionIDs = append(diagnosis.CorruptMetaPartitionIDs, result.CorruptMetaPartitionIDs...)
+		diagnosis.LackReplicaMetaPartitionIDs = append(diagnosis.LackReplicaMetaPartitionIDs, result.LackReplicaMetaPartitionIDs...)
+		for _, bmpv := range result.BadMetaPartitionIDs {
+			badPartitions[bmpv.Path] = append(badPartitions[bmpv.Path], bmpv.PartitionIDs...)
+		}
+		diagnosis.PeerInconsistentPartitions = append(diagnosis.PeerInconsistentPartitions, result.PeerInconsistentPartitions...)
+		diagnosis.RackRiskMetaPartitionIDs = append(diagnosis.RackRiskMetaPartitionIDs, result.RackRiskMetaPartitionIDs...)
+	}
+	for path, ids := range badPartitions {
+		diagnosis.BadMetaPartitionIDs = append(diagnosis.BadMetaPartitionIDs, proto.BadPartitionView{Path: path, PartitionIDs: ids})
+	}
+	sort.Slice(diagnosis.BadMetaPartitionIDs, func(i, j int) bool {
+		return diagnosis.BadMetaPartitionIDs[i].Path < diagnosis.BadMetaPartitionIDs[j].Path
+	})
+	sort.Slice(diagnosis.PeerInconsistentPartitions, func(i, j int) bool {
+		return diagnosis.PeerInconsistentPartitions[i].PartitionID < diagnosis.PeerInconsistentPartitions[j].PartitionID
+	})
+	return
+}
+
+// collectMetaPartitionDiagnosis diagnoses the partitions of the volumes, or all partitions if vols is empty.
+func collectMetaPartitionDiagnosis(client *master.MasterClient, vols []string, concurrency int) (detail *metaPartitionDiagnosisDetail, err error) {
+	var diagnosis *proto.MetaPartitionDiagnosis
+	if len(vols) == 0 {
+		diagnosis, err = client.AdminAPI().DiagnoseMetaPartition()
+	} else {
+		diagnosis, err = diagnoseVolsMetaPartitions(client, vols, concurrency)
+	}
+	if err != nil {
+		return
+	}
+	return newMetaPartitionDiagnosisDetail(client, diagnosis)
+}
+
+func newMetaPartitionDiagnosisDetail(client *master.MasterClient, diagnosis *proto.MetaPartitionDiagnosis) (detail *metaPartitionDiagnosisDetail, err error) {
+	detail = &metaPartitionDiagnosisDetail{diagnosis: diagnosis}
+	for _, addr := range diagnosis.InactiveMetaNodes {
+		var node *proto.MetaNodeInfo
+		if node, err = client.NodeAPI().GetMetaNode(addr); err != nil {
+			err = annotateError(err, "Meta node not found, err:[%v] ", err)
 			return
-		},
+		}
+		detail.inactiveNodes = append(detail.inactiveNodes, node)
 	}
-	return cmd
+	sort.SliceStable(detail.inactiveNodes, func(i, j int) bool {
+		return detail.inactiveNodes[i].ID < detail.inactiveNodes[j].ID
+	})
+	getPartitions := func(ids []uint64) (partitions []*proto.MetaPartitionInfo, err error) {
+		sort.SliceStable(ids, func(i, j int) bool {
+			return ids[i] < ids[j]
+		})
+		for _, pid := range ids {
+			var partition *proto.MetaPartitionInfo
+			if partition, err = client.ClientAPI().GetMetaPartition(pid); err != nil {
+				err = annotateError(err, "Partition not found, err:[%v] ", err)
+				return
+			}
+			if partition != nil {
+				partitions = append(partitions, partition)
+			}
+		}
+		return
+	}
+	if detail.corruptPartitions, err = getPartitions(diagnosis.CorruptMetaPartitionIDs); err != nil {
+		return
+	}
+	if detail.lackReplicaPartitions, err = getPartitions(diagnosis.LackReplicaMetaPartitionIDs); err != nil {
+		return
+	}
+	if detail.rackRiskPartitions, err = getPartitions(diagnosis.RackRiskMetaPartitionIDs); err != nil {
+		return
+	}
+	for _, bmpv := range diagnosis.BadMetaPartitionIDs {
+		sort.SliceStable(bmpv.PartitionIDs, func(i, j int) bool {
+			return bmpv.PartitionIDs[i] < bmpv.PartitionIDs[j]
+		})
+	}
+	return
+}
+
+// checkCorruptMetaPartitions prints the diagnosis and returns the IDs of the unhealthy partitions.
+func checkCorruptMetaPartitions(client *master.MasterClient, vols []string, concurrency int) (unhealthyIDs []uint64, err error) {
+	var detail *metaPartitionDiagnosisDetail
+	if detail, err = collectMetaPartitionDiagnosis(client, vols, concurrency); err != nil {
+		return
+	}
+	unhealthyIDs = append(unhealthyIDs, detail.diagnosis.CorruptMetaPartitionIDs...)
+	unhealthyIDs = append(unhealthyIDs, detail.diagnosis.LackReplicaMetaPartitionIDs...)
+	for _, partition := range detail.diagnosis.PeerInconsistentPartitions {
+		unhealthyIDs = append(unhealthyIDs, partition.PartitionID)
+	}
+	err = printMetaPartitionDiagnosis(detail)
+	return
+}
+
+// checkCachedMetaPartitions prints the findings cached by the consistency checker of the master.
+func checkCachedMetaPartitions(client *master.MasterClient) (err error) {
+	var report *proto.ConsistencyReport
+	if report, err = client.AdminAPI().GetConsistencyReport(); err != nil {
+		return
+	}
+	if report.CheckTime == 0 || report.MetaPartitionDiagnosis == nil {
+		return fmt.Errorf("no findings cached, the consistency checker is disabled or has not finished a round since the master became the leader")
+	}
+	if isStructuredOutput() {
+		return printStructured(report)
+	}
+	var detail *metaPartitionDiagnosisDetail
+	if detail, err = newMetaPartitionDiagnosisDetail(client, report.MetaPartitionDiagnosis); err != nil {
+		return
+	}
+	stdout("Cached by the consistency checker at %v, round %v checked %v meta partitions and %v data partitions against the replicas\n\n",
+		formatTime(report.CheckTime), report.Rounds, report.CheckedMetaPartitions, report.CheckedDataPartitions)
+	if err = printMetaPartitionDiagnosis(detail); err != nil {
+		return
+	}
+	stdout("\n")
+	stdout("%v\n", "[Meta partition replicas inconsistent with the others]:")
+	printReplicaInconsistencies(report.MetaReplicaInconsistencies)
+	stdout("\n")
+	stdout("%v\n", "[Data partition replicas inconsistent with the others]:")
+	printReplicaInconsistencies(report.DataReplicaInconsistencies)
+	return
+}
+
+func printReplicaInconsistencies(found []proto.ReplicaInconsistency) {
+	tablePattern := "%-8v    %-12v    %-22v    %-20v    %v\n"
+	stdout(tablePattern, "ID", "VOLUME", "ADDRESS", "FOUND TIME", "ISSUE")
+	for _, inconsistency := range found {
+		addr := inconsistency.Addr
+		if addr == "" {
+			addr = "N/A"
+		}
+		stdout(tablePattern, inconsistency.PartitionID, inconsistency.VolName, addr, formatTime(inconsistency.FoundTime),
+			inconsistency.Issue)
+	}
+}
+
+func printMetaPartitionDiagnosis(detail *metaPartitionDiagnosisDetail) (err error) {
+	if isStructuredOutput() {
+		return printStructured(detail.diagnosis)
+	}
+	stdout("[Inactive Meta nodes]:\n")
+	stdout("%v\n", formatMetaNodeDetailTableHeader())
+	for _, node := range detail.inactiveNodes {
+		stdout("%v\n", formatMetaNodeDetail(node, true))
+	}
+
+	stdout("\n")
+	stdout("[Corrupt meta partitions](no leader):\n")
+	stdout("%v\n", partitionInfoTableHeader)
+	for _, partition := range detail.corruptPartitions {
+		stdout("%v\n", formatMetaPartitionInfoRow(partition))
+	}
+
+	stdout("\n")
+	stdout("%v\n", "[Meta partition lack replicas]:")
+	stdout("%v\n", partitionInfoTableHeader)
+	for _, partition := range detail.lackReplicaPartitions {
+		stdout("%v\n", formatMetaPartitionInfoRow(partition))
+	}
+
+	stdout("\n")
+	stdout("%v\n", "[Bad meta partitions(decommission not completed)]:")
+	badPartitionTablePattern := "%-8v    %-10v\n"
+	stdout(badPartitionTablePattern, "PATH", "PARTITION ID")
+	for _, bmpv := range detail.diagnosis.BadMetaPartitionIDs {
+		for _, pid := range bmpv.PartitionIDs {
+			stdout(badPartitionTablePattern, bmpv.Path, pid)
+		}
+	}
+
+	stdout("\n")
+	stdout("%v\n", "[Meta partitions with peers inconsistent with hosts]:")
+	peerTablePattern := "%-8v    %-12v    %-54v    %v\n"
+	stdout(peerTablePattern, "ID", "VOLUME", "HOSTS", "INCONSISTENT PEERS")
+	for _, partition := range detail.diagnosis.PeerInconsistentPartitions {
+		stdout(peerTablePattern, partition.PartitionID, partition.VolName, strings.Join(partition.Hosts, ","),
+			formatInconsistentPeers(partition))
+	}
+
+	stdout("\n")
+	stdout("%v\n", "[Meta partitions with majority replicas in one rack]:")
+	stdout("%v\n", partitionInfoTableHeader)
+	for _, partition := range detail.rackRiskPartitions {
+		stdout("%v\n", formatMetaPartitionInfoRow(partition))
+	}
+	return
+}
+
+// formatInconsistentPeers formats the raft peers of master and the replicas which are inconsistent with the hosts.
+func formatInconsistentPeers(partition proto.PeerInconsistentPartition) string {
+	items := make([]string, 0)
+	if strings.Join(partition.Peers, ",") != strings.Join(partition.Hosts, ",") {
+		items = append(items, fmt.Sprintf("master: %v", strings.Join(partition.Peers, ",")))
+	}
+	addrs := make([]string, 0, len(partition.ReplicaPeers))
+	for addr := range partition.ReplicaPeers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		items = append(items, fmt.Sprintf("%v: %v", addr, strings.Join(partition.ReplicaPeers[addr], ",")))
+	}
+	return strings.Join(items, "; ")
+}
+
+func exportMetaPartitionDiagnosis(client *master.MasterClient, detail *metaPartitionDiagnosisDetail, format, path string) (err error) {
+	var cv *proto.ClusterView
+	if cv, err = client.AdminAPI().GetCluster(); err != nil {
+		return
+	}
+	report := newDiagnosisReport("Meta partition diagnosis", cv.Name)
+	nodes := report.addSection("Inactive meta nodes", "ID", "ZONE", "ADDRESS", "USED", "TOTAL", "STATUS", "REPORT TIME")
+	for _, node := range detail.inactiveNodes {
+		nodes.addRow(node.ID, node.ZoneName, node.Addr, node.Used, node.Total, formatNodeStatus(node.IsActive),
+			formatTimeToString(node.ReportTime))
+	}
+	addPartitions := func(name string, partitions []*proto.MetaPartitionInfo) {
+		section := report.addSection(name, "ID", "VOLUME", "REPLICAS", "STATUS", "MEMBERS")
+		for _, partition := range partitions {
+			section.addRow(partition.PartitionID, partition.VolName, partition.ReplicaNum,
+				formatDataPartitionStatus(partition.Status), strings.Join(partition.Hosts, ", "))
+		}
+	}
+	addPartitions("Corrupt meta partitions (no leader)", detail.corruptPartitions)
+	addPartitions("Meta partitions lack replicas", detail.lackReplicaPartitions)
+	addPartitions("Meta partitions with majority replicas in one rack", detail.rackRiskPartitions)
+	bad := report.addSection("Bad meta partitions (decommission not completed)", "PATH", "PARTITION ID")
+	for _, bmpv := range detail.diagnosis.BadMetaPartitionIDs {
+		for _, pid := range bmpv.PartitionIDs {
+			bad.addRow(bmpv.Path, pid)
+		}
+	}
+	peers := report.addSection("Meta partitions with peers inconsistent with hosts", "ID", "VOLUME", "HOSTS", "INCONSISTENT PEERS")
+	for _, partition := range detail.diagnosis.PeerInconsistentPartitions {
+		peers.addRow(partition.PartitionID, partition.VolName, strings.Join(partition.Hosts, ", "),
+			formatInconsistentPeers(partition))
+	}
+	if err = exportDiagnosisReport(report, format, path); err != nil {
+		return
+	}
+	if !isStructuredOutput() {
+		stdout("\nThe report is exported to %v\n", path)
+	}
+	return
 }
 
 func newMetaPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optFromFile    string
+		optConcurrency int
+		optRetry       int
+		optAsync       bool
+		optDryRun      bool
+		optYes         bool
+	)
 	var cmd = &cobra.Command{
 		Use:   CliOpDecommission + " [ADDRESS] [META PARTITION ID]",
 		Short: cmdMetaPartitionDecommissionShort,
-		Args:  cobra.MinimumNArgs(2),
+		Long: `Decommission a replication of the meta partition on the address to a new address. With the "--from-file" flag,
+the IDs of the partitions to be decommissioned are read from the file, which are separated by spaces, commas or lines.`,
+		Args: cobra.RangeArgs(1, 2),
 		Run: func(cmd *cobra.Command, args []string) {
 			var (
 				err         error
@@ -186,7 +596,48 @@ func newMetaPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command
 				}
 			}()
 			address := args[0]
+			if optFromFile != "" {
+				var ids []uint64
+				if ids, err = readPartitionIDsFromFile(optFromFile); err != nil {
+					return
+				}
+				if !optYes {
+					if err = confirmBatchOperation(fmt.Sprintf("Decommission the replicas on [%v] of", address), len(ids)); err != nil {
+						return
+					}
+				}
+				summary := runBatchPartitionOperation(ids, optConcurrency, optRetry, func(id uint64) error {
+					return client.AdminAPI().DecommissionMetaPartition(id, address)
+				})
+				if err = printBatchSummary("Decommission", summary); err != nil {
+					return
+				}
+				if len(summary.Failed) > 0 {
+					err = newPartialFailureError("decommission %v meta partitions failed", len(summary.Failed))
+				}
+				return
+			}
+			if len(args) < 2 {
+				err = NewArgumentError("meta partition id is required without --%v", CliFlagFromFile)
+				return
+			}
 			partitionID, err = strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return
+			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminDecommissionMetaPartition, partitionID, address)
+				return
+			}
+			if !optYes {
+				if err = confirmMetaPartitionOperation(client, fmt.Sprintf("Decommission the replica on [%v]", address), partitionID); err != nil {
+					return
+				}
+			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminDecommissionMetaPartition, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().DecommissionMetaPartition(partitionID, address); err != nil {
 				return
 			}
@@ -198,10 +649,17 @@ func newMetaPartitionDecommissionCmd(client *master.MasterClient) *cobra.Command
 			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Decommission in background and print the task ID")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().StringVar(&optFromFile, CliFlagFromFile, "", "Read the partition IDs from the file")
+	cmd.Flags().IntVar(&optConcurrency, CliFlagConcurrency, defaultBatchConcurrency, "Number of partitions decommissioned concurrently with --from-file")
+	cmd.Flags().IntVar(&optRetry, CliFlagRetry, defaultBatchRetry, "Retry times of transient errors with --from-file")
 	return cmd
 }
 
 func newMetaPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
+	var optAsync bool
 	var cmd = &cobra.Command{
 		Use:   CliOpReplicate + " [ADDRESS] [META PARTITION ID]",
 		Short: cmdMetaPartitionReplicateShort,
@@ -218,6 +676,13 @@ func newMetaPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
 			}()
 			address := args[0]
 			partitionID, err = strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return
+			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminAddMetaReplica, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().AddMetaReplica(partitionID, address); err != nil {
 				return
 			}
@@ -229,10 +694,16 @@ func newMetaPartitionReplicateCmd(client *master.MasterClient) *cobra.Command {
 			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Add the replica in background and print the task ID")
 	return cmd
 }
 
 func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optAsync  bool
+		optDryRun bool
+		optYes    bool
+	)
 	var cmd = &cobra.Command{
 		Use:   CliOpDelReplica + " [ADDRESS] [META PARTITION ID]",
 		Short: cmdMetaPartitionDeleteReplicaShort,
@@ -252,6 +723,19 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 			if err != nil {
 				return
 			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminDeleteMetaReplica, partitionID, address)
+				return
+			}
+			if !optYes {
+				if err = confirmMetaPartitionOperation(client, fmt.Sprintf("Delete the replica on [%v]", address), partitionID); err != nil {
+					return
+				}
+			}
+			if optAsync {
+				err = submitPartitionTask(client, proto.AdminDeleteMetaReplica, partitionID, address)
+				return
+			}
 			if err = client.AdminAPI().DeleteMetaReplica(partitionID, address); err != nil {
 				return
 			}
@@ -263,5 +747,53 @@ func newMetaPartitionDeleteReplicaCmd(client *master.MasterClient) *cobra.Comman
 			return validMetaNodes(client, toComplete), cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Delete the replica in background and print the task ID")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newMetaPartitionResetCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optForce  bool
+		optDryRun bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpReset + " [META PARTITION ID]",
+		Short: cmdMetaPartitionResetShort,
+		Long: `Reset the raft members of a meta partition whose majority of replicas are on the corrupt meta nodes. The 
+replicas on the inactive meta nodes will be removed, and the remaining replicas will elect a new leader. The metadata 
+which has not been synchronized to the remaining replicas will be LOST. After resetting, use "metapartition add-replica" 
+to add the lacked replicas back. The "--force" flag is required to confirm this action.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if optDryRun {
+				err = printPartitionOperationPlan(client, proto.AdminResetMetaPartition, partitionID, "")
+				return
+			}
+			if !optForce {
+				err = NewArgumentError("resetting meta partition may lead to data loss, use --%v to confirm it", CliFlagForce)
+				return
+			}
+			if err = client.AdminAPI().ResetMetaPartition(partitionID); err != nil {
+				return
+			}
+			stdout("Meta partition [%v] has been reset successfully.\n", partitionID)
+		},
+	}
+	cmd.Flags().BoolVar(&optForce, CliFlagForce, false, "Confirm to reset the meta partition, data may be lost")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
 	return cmd
 }
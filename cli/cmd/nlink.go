@@ -0,0 +1,130 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+func newMetaPartitionCheckNLinkCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort uint16
+		optFix      bool
+		optLimit    int
+		optAsync    bool
+		optInterval time.Duration
+		optYes      bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpCheckNLink + " [META PARTITION ID]",
+		Short: cmdMetaPartitionCheckNLinkShort,
+		Long: `Check the link counts of the inodes of the meta partition online by its leader. The leader counts the dentries
+linking to its inodes in all the meta partitions of the volume, and the children of its directories, at the limited
+rate of the dentries scanned per second. The link count of a file is expected to be the number of its dentries, and
+the one of a directory is 2 plus the number of its children. The mismatches are counted again a minute later, and only
+the ones found the same by both passes are reported. With --fix, their link counts are set to the expected ones if
+they are still unchanged. The inodes without any dentry are left to the orphan inode scan.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+				report      *proto.NLinkCheckReport
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if optFix && !optYes {
+				if err = confirmPartitionID("Fix the link counts of the inodes", partitionID); err != nil {
+					return
+				}
+			}
+			if report, err = mc.CheckNLink(partitionID, optFix, optLimit); err != nil {
+				return
+			}
+			for !optAsync && report.EndTime == 0 {
+				time.Sleep(optInterval)
+				if report, err = mc.GetNLinkCheckReport(partitionID); err != nil {
+					return
+				}
+				if !isStructuredOutput() && report.EndTime == 0 {
+					stdout("Checking: scanned %v dentries\n", report.Dentries)
+				}
+			}
+			if isStructuredOutput() {
+				err = printStructured(report)
+				return
+			}
+			stdout("[Link count check of meta partition %v]\n", partitionID)
+			stdout("%v", formatNLinkCheckReport(report))
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	cmd.Flags().BoolVar(&optFix, CliFlagFix, false, "Fix the mismatched link counts")
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, 0, "Max number of the dentries scanned per second, 0 for the default of the meta node")
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Return without waiting for the check to finish")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the check progress")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newMetaPartitionNLinkReportCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpNLinkReport + " [META PARTITION ID]",
+		Short: cmdMetaPartitionNLinkReportShort,
+		Long: `Show the progress or the result of the latest link count check of the meta partition, which is read from its
+leader. The result is kept in memory, it is lost when the leader restarts or changes.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+				report      *proto.NLinkCheckReport
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if report, err = mc.GetNLinkCheckReport(partitionID); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(report)
+				return
+			}
+			stdout("[Link count check of meta partition %v]\n", partitionID)
+			stdout("%v", formatNLinkCheckReport(report))
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
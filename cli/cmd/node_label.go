@@ -0,0 +1,73 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/spf13/cobra"
+)
+
+// nodeLabelAPI defines the APIs of the data nodes or the meta nodes to set the labels.
+type nodeLabelAPI struct {
+	nodeType   string
+	set        func(nodeAddr string, labels map[string]string) error
+	validNodes func(toComplete string) []string
+}
+
+func newNodeSetLabelsCmd(api *nodeLabelAPI) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpSetLabels + " [NODE ADDRESS] [LABELS]",
+		Short: fmt.Sprintf("Replace the labels of a %v node", api.nodeType),
+		Long: fmt.Sprintf(`Replace the labels of the %v node by the comma separated key=value pairs, such as disk=nvme,gen=2023.
+An empty string removes all the labels. The new replicas of the volumes with placement labels are only
+placed on the nodes having all of them.`, api.nodeType),
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				labels map[string]string
+				err    error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if labels, err = proto.ParseLabels(args[1]); err != nil {
+				err = NewArgumentError("%v", err)
+				return
+			}
+			if err = api.set(args[0], labels); err != nil {
+				return
+			}
+			stdout("Labels of %v node [%v] are set to [%v]\n", api.nodeType, args[0], formatLabels(labels))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return api.validNodes(toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "none"
+	}
+	return proto.FormatLabels(labels)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+func newMetaPartitionOrphanInodesCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpOrphanInodes + " [META PARTITION ID]",
+		Short: cmdMetaPartitionOrphanInodesShort,
+		Long: `Show the report of the latest orphan inode scan of the meta partition, which is read from its leader. The leader
+scans the inodes once a day, and checks the ones created more than an hour ago against the dentries and the multipart
+uploads of all the meta partitions of the volume. The files found unreferenced by two scans in a row are purged with
+their extents, the directories are reported only. The deleted inodes missed by the free list are put back to it.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				partition   *proto.MetaPartitionInfo
+				httpAddr    string
+				report      *proto.OrphanReport
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
+				return
+			}
+			if httpAddr, err = leaderHttpAddr(partition, optProfPort); err != nil {
+				return
+			}
+			if report, err = api.NewMetaHttpClient(httpAddr, false).GetOrphanReport(partitionID); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(report)
+				return
+			}
+			stdout("[Orphan inodes of meta partition %v]\n", partitionID)
+			stdout("%v", formatOrphanReport(report))
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
+)
+
+// optOutputFormat is set by the global "--output" flag.
+var optOutputFormat = OutputFormatTable
+
+func validateOutputFormat() (err error) {
+	switch optOutputFormat {
+	case OutputFormatTable, OutputFormatJSON, OutputFormatYAML:
+	default:
+		err = NewArgumentError("unsupported output format [%v], should be one of %v, %v, %v",
+			optOutputFormat, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+	}
+	return
+}
+
+// isStructuredOutput returns true if the result should be serialized instead of printed as a table.
+func isStructuredOutput() bool {
+	return optOutputFormat == OutputFormatJSON || optOutputFormat == OutputFormatYAML
+}
+
+// printStructured serializes the value to stdout in the format specified by the "--output" flag.
+// The fields are named and ordered as the json encoding of the value in both formats.
+func printStructured(v interface{}) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(v, "", "  "); err != nil {
+		return
+	}
+	if optOutputFormat == OutputFormatJSON {
+		stdout("%s\n", data)
+		return
+	}
+	if data, err = jsonToYAML(data); err != nil {
+		return
+	}
+	stdout("%s", data)
+	return
+}
+
+// jsonToYAML converts the json document to a yaml document of the same keys in the same order.
+func jsonToYAML(data []byte) (yaml []byte, err error) {
+	var node *yamlNode
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if node, err = decodeYAMLNode(decoder); err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	node.write(&buf, 0)
+	return buf.Bytes(), nil
+}
+
+const (
+	yamlScalar = iota
+	yamlMapping
+	yamlSequence
+)
+
+// yamlNode keeps the key order of the json objects, which the map based decoding loses.
+type yamlNode struct {
+	kind     int
+	value    string
+	keys     []string
+	children []*yamlNode
+}
+
+func decodeYAMLNode(decoder *json.Decoder) (node *yamlNode, err error) {
+	var token json.Token
+	if token, err = decoder.Token(); err != nil {
+		return
+	}
+	node = &yamlNode{}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			node.kind = yamlMapping
+		} else {
+			node.kind = yamlSequence
+		}
+		for decoder.More() {
+			if node.kind == yamlMapping {
+				var key json.Token
+				if key, err = decoder.Token(); err != nil {
+					return
+				}
+				node.keys = append(node.keys, key.(string))
+			}
+			var child *yamlNode
+			if child, err = decodeYAMLNode(decoder); err != nil {
+				return
+			}
+			node.children = append(node.children, child)
+		}
+		// consume the closing delimiter
+		if _, err = decoder.Token(); err != nil {
+			return
+		}
+	case string:
+		node.value = yamlQuote(t)
+	case json.Number:
+		node.value = t.String()
+	case bool:
+		node.value = fmt.Sprintf("%v", t)
+	case nil:
+		node.value = "null"
+	default:
+		err = fmt.Errorf("unexpected json token [%v]", token)
+	}
+	return
+}
+
+func (node *yamlNode) isInline() bool {
+	return node.kind == yamlScalar || len(node.children) == 0
+}
+
+func (node *yamlNode) inline() string {
+	switch node.kind {
+	case yamlMapping:
+		return "{}"
+	case yamlSequence:
+		return "[]"
+	default:
+		return node.value
+	}
+}
+
+func (node *yamlNode) write(w io.Writer, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	if node.isInline() {
+		_, _ = fmt.Fprintf(w, "%s%s\n", prefix, node.inline())
+		return
+	}
+	for i, child := range node.children {
+		if node.kind == yamlMapping {
+			key := yamlQuote(node.keys[i])
+			if child.isInline() {
+				_, _ = fmt.Fprintf(w, "%s%s: %s\n", prefix, key, child.inline())
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "%s%s:\n", prefix, key)
+			child.write(w, indent+2)
+			continue
+		}
+		if child.isInline() {
+			_, _ = fmt.Fprintf(w, "%s- %s\n", prefix, child.inline())
+			continue
+		}
+		// write the child with a deeper indent and put the dash before its first line
+		var buf bytes.Buffer
+		child.write(&buf, indent+2)
+		_, _ = fmt.Fprintf(w, "%s- %s", prefix, strings.TrimLeft(buf.String(), " "))
+	}
+}
+
+// the strings starting with "." are quoted, since ".inf" and ".nan" are numbers
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./:@-]*$`)
+
+// yamlQuote returns the string as a plain scalar if it is not ambiguous, or a double-quoted scalar otherwise.
+func yamlQuote(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "y", "n":
+	default:
+		if yamlPlainRegexp.MatchString(s) && !strings.HasSuffix(s, ":") {
+			return s
+		}
+	}
+	// a json string is a valid yaml double-quoted scalar
+	data, _ := json.Marshal(s)
+	return string(data)
+}
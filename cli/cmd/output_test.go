@@ -0,0 +1,127 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestYAMLQuote(t *testing.T) {
+	tests := []struct {
+		s, expect string
+	}{
+		{"vol1", `vol1`},
+		{"/cfs/mnt", `/cfs/mnt`},
+		{"http://192.168.0.1:17010", `http://192.168.0.1:17010`},
+		{"", `""`},
+		{"key: value", `"key: value"`},
+		{"key:", `"key:"`},
+		{"a # comment", `"a # comment"`},
+		{"#comment", `"#comment"`},
+		{"  leading spaces", `"  leading spaces"`},
+		{"trailing space ", `"trailing space "`},
+		{"true", `"true"`},
+		{"False", `"False"`},
+		{"yes", `"yes"`},
+		{"off", `"off"`},
+		{"null", `"null"`},
+		{"~", `"~"`},
+		{"123", `"123"`},
+		{"1.5", `"1.5"`},
+		{"0x1f", `"0x1f"`},
+		{".inf", `".inf"`},
+		{"-1", `"-1"`},
+		{"- item", `"- item"`},
+		{"[a]", `"[a]"`},
+		{"{a}", `"{a}"`},
+		{"*ref", `"*ref"`},
+		{"it's", `"it's"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"line1\nline2", `"line1\nline2"`},
+	}
+	for _, test := range tests {
+		if actual := yamlQuote(test.s); actual != test.expect {
+			t.Errorf("quote %q: expect %v, but got %v", test.s, test.expect, actual)
+		}
+	}
+}
+
+func TestJSONToYAML(t *testing.T) {
+	type replica struct {
+		Addr     string
+		IsLeader bool
+	}
+	type partition struct {
+		ID       uint64
+		Status   string
+		Replicas []replica
+		Hosts    []string
+		Labels   map[string]string
+		Quota    *replica
+		Size     float64
+	}
+	tests := []struct {
+		name   string
+		value  interface{}
+		expect string
+	}{
+		{"scalar", "true", "\"true\"\n"},
+		{"empty map", map[string]int{}, "{}\n"},
+		{"empty slice", []string{}, "[]\n"},
+		{"nil slice", []string(nil), "null\n"},
+		{"slice of scalars", []interface{}{"a", 1, false}, "- a\n- 1\n- false\n"},
+		{"nested slices", [][]int{{1, 2}, {}}, "- - 1\n  - 2\n- []\n"},
+		{
+			name: "nested structs",
+			value: partition{
+				ID:       1,
+				Status:   "Read Write",
+				Replicas: []replica{{Addr: "192.168.0.1:17310", IsLeader: true}, {Addr: "192.168.0.2:17310"}},
+				Hosts:    []string{},
+				Labels:   map[string]string{"zone": "default", "on": "1"},
+				Size:     0.5,
+			},
+			expect: `ID: 1
+Status: "Read Write"
+Replicas:
+  - Addr: "192.168.0.1:17310"
+    IsLeader: true
+  - Addr: "192.168.0.2:17310"
+    IsLeader: false
+Hosts: []
+Labels:
+  "on": "1"
+  zone: default
+Quota: null
+Size: 0.5
+`,
+		},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(test.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := jsonToYAML(data)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if string(actual) != test.expect {
+			t.Errorf("%v: expect\n%v\nbut got\n%v", test.name, test.expect, string(actual))
+		}
+	}
+}
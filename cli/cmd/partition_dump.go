@@ -0,0 +1,109 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+func newMetaPartitionDumpCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort    uint16
+		optDentry      bool
+		optStart       uint64
+		optEnd         uint64
+		optType        string
+		optMtimeAfter  string
+		optMtimeBefore string
+		optLimit       int
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpDump + " [META PARTITION ID]",
+		Short: cmdMetaPartitionDumpShort,
+		Long: `Dump the inodes of the meta partition, or its dentries with --dentry, to the standard output as JSON lines, one
+item per line, which are streamed from the leader of the partition in the order of the inode IDs, or of the parent
+IDs of the dentries. The range of the IDs, the types and the modify time of the inodes select the items dumped, so a
+part of a large partition can be extracted without walking through all of it.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			filter := make(map[string]string)
+			if optStart > 0 {
+				filter["start"] = strconv.FormatUint(optStart, 10)
+			}
+			if optEnd > 0 {
+				filter["end"] = strconv.FormatUint(optEnd, 10)
+			}
+			if optType != "" {
+				filter["type"] = optType
+			}
+			if optLimit > 0 {
+				filter["limit"] = strconv.Itoa(optLimit)
+			}
+			times := []struct {
+				flag, value, param string
+			}{
+				{CliFlagModifiedAfter, optMtimeAfter, "mtimeAfter"},
+				{CliFlagModifiedBefore, optMtimeBefore, "mtimeBefore"},
+			}
+			for _, t := range times {
+				if t.value == "" {
+					continue
+				}
+				var mtime time.Time
+				if mtime, err = parseAuditSince(t.value, time.Now()); err != nil {
+					err = fmt.Errorf("invalid %v: %v", t.flag, t.value)
+					return
+				}
+				filter[t.param] = strconv.FormatInt(mtime.Unix(), 10)
+			}
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			path := "/dumpInodes"
+			if optDentry {
+				path = "/dumpDentries"
+			}
+			err = mc.DumpMeta(path, partitionID, filter, os.Stdout)
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	cmd.Flags().BoolVar(&optDentry, CliFlagDentry, false, "Dump the dentries instead of the inodes")
+	cmd.Flags().Uint64Var(&optStart, CliFlagStart, 0, "Smallest inode ID, or parent ID of the dentries, to dump")
+	cmd.Flags().Uint64Var(&optEnd, CliFlagEnd, 0, "Largest inode ID, or parent ID of the dentries, to dump, 0 for no limit")
+	cmd.Flags().StringVar(&optType, CliFlagType, "", `Types to dump separated by commas, of "file", "dir" and "symlink"`)
+	cmd.Flags().StringVar(&optMtimeAfter, CliFlagModifiedAfter, "",
+		`Dump the inodes modified since the duration ago such as "2h", or since the time such as "2006-01-02 15:04:05"`)
+	cmd.Flags().StringVar(&optMtimeBefore, CliFlagModifiedBefore, "",
+		`Dump the inodes modified before the duration ago such as "2h", or before the time such as "2006-01-02 15:04:05"`)
+	cmd.Flags().IntVar(&optLimit, CliFlagLimit, 0, "Max number of the items to dump, 0 for no limit")
+	return cmd
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+// the file of the snapshot, "-" means the standard output or input
+const snapshotStdio = "-"
+
+func metaPartitionLeaderClient(client *master.MasterClient, arg string, profPort uint16) (partitionID uint64,
+	mc *api.MetaHttpClient, err error) {
+	var (
+		partition *proto.MetaPartitionInfo
+		httpAddr  string
+	)
+	if partitionID, err = strconv.ParseUint(arg, 10, 64); err != nil {
+		return
+	}
+	if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
+		return
+	}
+	if httpAddr, err = leaderHttpAddr(partition, profPort); err != nil {
+		return
+	}
+	mc = api.NewMetaHttpClient(httpAddr, false)
+	return
+}
+
+func newMetaPartitionExportCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpExport + " [META PARTITION ID] [FILE]",
+		Short: cmdMetaPartitionExportShort,
+		Long: `Export a consistent snapshot of the inodes, the dentries, the extended attributes and the multipart uploads of
+the meta partition to the file, which is streamed from the leader of the partition. The snapshot is written to the
+standard output if the file is "-", so that it can be piped to an object store.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+				f           *os.File
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if args[1] == snapshotStdio {
+				err = mc.ExportPartition(partitionID, os.Stdout)
+				return
+			}
+			if f, err = os.OpenFile(args[1], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+				return
+			}
+			if err = mc.ExportPartition(partitionID, f); err == nil {
+				err = f.Sync()
+			}
+			_ = f.Close()
+			if err != nil {
+				_ = os.Remove(args[1])
+				return
+			}
+			stdout("Meta partition %v is exported to %v\n", partitionID, args[1])
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
+
+func newMetaPartitionImportCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpImport + " [META PARTITION ID] [FILE]",
+		Short: cmdMetaPartitionImportShort,
+		Long: `Import the snapshot exported by "metapartition export" into the meta partition by its leader. The snapshot is
+read from the standard input if the file is "-". The items in the snapshot replace the existing ones, so the import
+can be retried after a failure, and the partition should be empty to be reconstructed from the snapshot. The inodes
+out of the range of the partition are refused.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				mc          *api.MetaHttpClient
+				f           = os.Stdin
+				resp        *api.MetaPartitionImportResponse
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, mc, err = metaPartitionLeaderClient(client, args[0], optProfPort); err != nil {
+				return
+			}
+			if args[1] != snapshotStdio {
+				if f, err = os.Open(args[1]); err != nil {
+					return
+				}
+				defer f.Close()
+			}
+			if resp, err = mc.ImportPartition(partitionID, f); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(resp)
+				return
+			}
+			stdout("Snapshot of meta partition %v at apply ID %v is imported into meta partition %v\n",
+				resp.Header.PartitionID, resp.Header.ApplyID, partitionID)
+			stdout("  Inodes     : %v\n", resp.Result.Inodes)
+			stdout("  Dentries   : %v\n", resp.Result.Dentries)
+			stdout("  Extends    : %v\n", resp.Result.Extends)
+			stdout("  Multiparts : %v\n", resp.Result.Multiparts)
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+)
+
+const (
+	dryRunFlagUsage = "Show what would change without executing the operation"
+)
+
+// printPartitionOperationPlan asks master for the plan of the operation without executing it.
+// The target node chosen in the plan is a candidate, the real operation may choose another node.
+func printPartitionOperationPlan(client *master.MasterClient, path string, partitionID uint64, address string) (err error) {
+	var plan *proto.PartitionOperationPlan
+	if plan, err = client.AdminAPI().PlanPartitionOperation(path, partitionID, address); err != nil {
+		return
+	}
+	if isStructuredOutput() {
+		return printStructured(plan)
+	}
+	stdout("[Dry run, nothing has been changed]\n")
+	stdout("%v", formatPartitionOperationPlan(plan))
+	if plan.TargetAddr != "" {
+		stdout("\nThe target is a candidate chosen by weight, the real operation may choose another node.\n")
+	}
+	return
+}
+
+func printVolumeDeletePlan(view *proto.SimpleVolView) (err error) {
+	if isStructuredOutput() {
+		return printStructured(view)
+	}
+	stdout("[Dry run, nothing has been changed]\n")
+	stdout("  Volume          : %v\n", view.Name)
+	stdout("  Owner           : %v\n", view.Owner)
+	stdout("  Capacity        : %v GB\n", view.Capacity)
+	stdout("  Inode count     : %v\n", view.InodeCount)
+	stdout("  Dentry count    : %v\n", view.DentryCount)
+	stdout("  Meta partitions : %v\n", view.MpCnt)
+	stdout("  Data partitions : %v\n", view.DpCnt)
+	stdout("\nThe volume will be marked as deleted, and all the partitions above will be deleted with their data.\n")
+	return
+}
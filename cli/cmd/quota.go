@@ -0,0 +1,220 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdQuotaUse   = "quota [COMMAND]"
+	cmdQuotaShort = "Manage directory quotas of volumes"
+)
+
+func newQuotaCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdQuotaUse,
+		Short: cmdQuotaShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newQuotaListCmd(client),
+		newQuotaSetCmd(client),
+		newQuotaDeleteCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdQuotaListShort   = "List the directory quotas of the volume"
+	cmdQuotaSetShort    = "Set the byte and file limits of a directory subtree"
+	cmdQuotaDeleteShort = "Delete a directory quota of the volume"
+)
+
+func newQuotaListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList + " [VOLUME]",
+		Short:   cmdQuotaListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var quotas []*proto.DirQuota
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if quotas, err = client.AdminAPI().ListDirQuotas(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(quotas)
+				return
+			}
+			stdout("%v\n", formatDirQuotaTableHeader())
+			for _, quota := range quotas {
+				stdout("%v\n", formatDirQuotaTableRow(quota))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newQuotaSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var optMaxBytes uint64
+	var optMaxFiles uint64
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [VOLUME] [PATH]",
+		Short: cmdQuotaSetShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volName, dirPath = args[0], args[1]
+			var reply *proto.DirQuotaReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !cmd.Flags().Changed(CliFlagMaxBytes) && !cmd.Flags().Changed(CliFlagMaxFiles) {
+				err = NewArgumentError("no limit is specified")
+				return
+			}
+			if !optYes {
+				stdout("Set the quota of directory [%v] in volume [%v]\n", dirPath, volName)
+				stdout("  Max bytes: %v\n", formatQuotaLimit(optMaxBytes, true))
+				stdout("  Max files: %v\n", formatQuotaLimit(optMaxFiles, false))
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().SetDirQuota(volName, dirPath, optMaxBytes, optMaxFiles); err != nil {
+				err = annotateError(err, "Set directory quota failed: %v\n", err)
+				return
+			}
+			printDirQuotaReply("Set directory quota success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint64Var(&optMaxBytes, CliFlagMaxBytes, 0, "Specify the max bytes of the files in the directory subtree, 0 means unlimited")
+	cmd.Flags().Uint64Var(&optMaxFiles, CliFlagMaxFiles, 0, "Specify the max number of the inodes in the directory subtree, 0 means unlimited")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newQuotaDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [VOLUME] [QUOTA ID]",
+		Short: cmdQuotaDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var quotaID uint64
+			var reply *proto.DirQuotaReply
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if quotaID, err = strconv.ParseUint(args[1], 10, 32); err != nil {
+				err = NewArgumentError("invalid quota ID[%v]", args[1])
+				return
+			}
+			if !optYes {
+				stdout("Delete the quota [%v] of volume [%v]\n", quotaID, args[0])
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if reply, err = client.AdminAPI().DeleteDirQuota(args[0], uint32(quotaID)); err != nil {
+				err = annotateError(err, "Delete directory quota failed: %v\n", err)
+				return
+			}
+			printDirQuotaReply("Delete directory quota success", reply)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func formatQuotaLimit(limit uint64, size bool) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	if size {
+		return formatSize(limit)
+	}
+	return strconv.FormatUint(limit, 10)
+}
+
+var dirQuotaTablePattern = "%-8v    %-32v    %-12v    %-24v    %-24v"
+
+func formatDirQuotaTableHeader() string {
+	return fmt.Sprintf(dirQuotaTablePattern, "ID", "PATH", "ROOT INODE", "BYTES(USED/MAX)", "FILES(USED/MAX)")
+}
+
+func formatDirQuotaTableRow(quota *proto.DirQuota) string {
+	return fmt.Sprintf(dirQuotaTablePattern, quota.QuotaID, quota.Path, quota.RootInode,
+		fmt.Sprintf("%v/%v", formatSize(quota.UsedBytes), formatQuotaLimit(quota.MaxBytes, true)),
+		fmt.Sprintf("%v/%v", quota.UsedFiles, formatQuotaLimit(quota.MaxFiles, false)))
+}
+
+func printDirQuotaReply(msg string, reply *proto.DirQuotaReply) {
+	if isStructuredOutput() {
+		if err := printStructured(reply); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("%v:\n", msg)
+	stdout("%v\n", formatDirQuotaTableHeader())
+	stdout("%v\n", formatDirQuotaTableRow(reply.Quota))
+	if reply.Task != nil {
+		stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", reply.Task.ID, reply.Task.ID, reply.Task.ID)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+// replicaRaftStatus defines the raft progress read from a replica, the error is set if it is not read.
+type replicaRaftStatus struct {
+	Addr   string
+	Status *proto.MetaPartitionRaftStatus
+	Error  string
+}
+
+func newMetaPartitionRaftStatusCmd(client *master.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpRaftStatus + " [META PARTITION ID]",
+		Short: cmdMetaPartitionRaftStatusShort,
+		Long: `Show the raft progress of each replica of the meta partition, which is read from the replicas. The commit,
+applied and snapshot indexes are the ones of the replica itself, and the match index and the lag are known by the
+leader, where the lag is the raft log entries not replicated to the replica yet.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				partition   *proto.MetaPartitionInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
+				return
+			}
+			statuses := make([]*replicaRaftStatus, 0, len(partition.Replicas))
+			for _, replica := range partition.Replicas {
+				rs := &replicaRaftStatus{Addr: replica.Addr}
+				statuses = append(statuses, rs)
+				var httpAddr string
+				if httpAddr, err = replicaHttpAddr(replica.Addr, optProfPort); err != nil {
+					return
+				}
+				if rs.Status, err = api.NewMetaHttpClient(httpAddr, false).GetRaftStatus(partitionID); err != nil {
+					rs.Error, err = err.Error(), nil
+				}
+			}
+			if isStructuredOutput() {
+				err = printStructured(statuses)
+				return
+			}
+			stdout("[Raft status of meta partition %v]\n", partitionID)
+			stdout("%v", formatRaftStatusTableHeader())
+			for _, row := range formatRaftStatusTableRows(statuses) {
+				stdout("%v", row)
+			}
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
+
+var raftStatusTablePattern = "%-24v    %-10v    %-8v    %-12v    %-12v    %-12v    %-12v    %v\n"
+
+func formatRaftStatusTableHeader() string {
+	return fmt.Sprintf(raftStatusTablePattern, "ADDRESS", "STATE", "TERM", "COMMIT", "APPLIED", "SNAPSHOT", "MATCH", "LAG")
+}
+
+// formatRaftStatusTableRows formats the replicas with the match index and the lag known by the leader.
+func formatRaftStatusTableRows(statuses []*replicaRaftStatus) (rows []string) {
+	progress := make(map[string]*proto.RaftReplicaStatus)
+	for _, rs := range statuses {
+		if rs.Status == nil {
+			continue
+		}
+		// the progress of the replicas is known by the leader only
+		for _, replica := range rs.Status.Replicas {
+			progress[replica.Addr] = replica
+		}
+	}
+	for _, rs := range statuses {
+		if rs.Status == nil {
+			rows = append(rows, fmt.Sprintf(raftStatusTablePattern, rs.Addr, "N/A", "", "", "", "", "", rs.Error))
+			continue
+		}
+		match, lag := "N/A", "N/A"
+		if replica, ok := progress[rs.Addr]; ok {
+			match, lag = strconv.FormatUint(replica.Match, 10), strconv.FormatUint(replica.Lag, 10)
+		}
+		state := rs.Status.State
+		if rs.Status.Stopped {
+			state = "stopped"
+		}
+		rows = append(rows, fmt.Sprintf(raftStatusTablePattern, rs.Addr, state, rs.Status.Term, rs.Status.Commit,
+			rs.Status.Applied, rs.Status.SnapshotIndex, match, lag))
+	}
+	return
+}
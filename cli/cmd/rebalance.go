@@ -0,0 +1,277 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdRebalanceUse   = "rebalance [COMMAND]"
+	cmdRebalanceShort = "Manage the rebalancer of the data partitions"
+	cmdRebalanceLong  = `The rebalancer of the master migrates the data partitions from the data nodes whose disk usage ratio is
+above the high ratio to the data nodes in the same zone whose ratio stays below the low ratio after the
+migration, the largest partitions first. No more than the limit of partitions are migrating at the same
+time, and the size of the partitions started to migrate every minute is limited by the bandwidth.`
+)
+
+// rebalanceAPI defines the master APIs of a rebalancer.
+type rebalanceAPI struct {
+	nodeType string
+	get      func() (*proto.RebalanceView, error)
+	set      func(highRatio, lowRatio float64, limit, bandwidth uint64) error
+	pause    func() error
+	resume   func() error
+}
+
+// rebalanceOptions defines the flags to set a rebalancer.
+type rebalanceOptions struct {
+	highRatio float64
+	lowRatio  float64
+	limit     uint64
+	bandwidth uint64
+}
+
+func (opt *rebalanceOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&opt.highRatio, CliFlagHighRatio, 0, "Specify the usage ratio of the nodes to migrate the partitions from")
+	cmd.Flags().Float64Var(&opt.lowRatio, CliFlagLowRatio, 0, "Specify the usage ratio of the nodes not to exceed by the migration")
+	cmd.Flags().Uint64Var(&opt.limit, CliFlagLimit, 0, "Specify the max number of the partitions migrating at the same time, 0 to disable")
+	cmd.Flags().Uint64Var(&opt.bandwidth, CliFlagBandwidth, 0, "Specify the size per second of the partitions started to migrate, 0 for unlimited [Unit: byte/s]")
+}
+
+// apply returns if any flag is specified, and applies the specified flags to the configuration.
+func (opt *rebalanceOptions) apply(cmd *cobra.Command, config *proto.RebalanceConfig) (changed bool) {
+	if cmd.Flags().Changed(CliFlagHighRatio) {
+		config.HighRatio, changed = opt.highRatio, true
+	}
+	if cmd.Flags().Changed(CliFlagLowRatio) {
+		config.LowRatio, changed = opt.lowRatio, true
+	}
+	if cmd.Flags().Changed(CliFlagLimit) {
+		config.Limit, changed = opt.limit, true
+	}
+	if cmd.Flags().Changed(CliFlagBandwidth) {
+		config.Bandwidth, changed = opt.bandwidth, true
+	}
+	return
+}
+
+func newDataRebalanceAPI(client *master.MasterClient) *rebalanceAPI {
+	return &rebalanceAPI{
+		nodeType: "data",
+		get:      client.AdminAPI().GetDataRebalance,
+		set:      client.AdminAPI().SetDataRebalance,
+		pause:    client.AdminAPI().PauseDataRebalance,
+		resume:   client.AdminAPI().ResumeDataRebalance,
+	}
+}
+
+func newRebalanceCmd(client *master.MasterClient) *cobra.Command {
+	var api = newDataRebalanceAPI(client)
+	var cmd = &cobra.Command{
+		Use:   cmdRebalanceUse,
+		Short: cmdRebalanceShort,
+		Long:  cmdRebalanceLong,
+	}
+	cmd.AddCommand(
+		newRebalanceStartCmd(api),
+		newRebalanceSwitchCmd(api, CliOpStop, true),
+		newRebalanceInfoCmd(api, "status"),
+	)
+	return cmd
+}
+
+func newRebalanceStartCmd(api *rebalanceAPI) *cobra.Command {
+	var opt rebalanceOptions
+	var cmd = &cobra.Command{
+		Use:   CliOpStart,
+		Short: "Set and start the rebalancer",
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.RebalanceView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = api.get(); err != nil {
+				return
+			}
+			config := view.RebalanceConfig
+			if opt.apply(cmd, &config) {
+				if config.Limit == 0 {
+					err = NewArgumentError("the rebalancer is disabled by the limit 0")
+					return
+				}
+				if err = api.set(config.HighRatio, config.LowRatio, config.Limit, config.Bandwidth); err != nil {
+					return
+				}
+			} else if config.Limit == 0 {
+				err = NewArgumentError("the rebalancer is disabled, specify --%v to start it", CliFlagLimit)
+				return
+			}
+			if err = api.resume(); err != nil {
+				return
+			}
+			config.Paused = false
+			stdout("Rebalancer of the %v partitions is started: %v\n", api.nodeType, formatRebalanceConfig(config))
+		},
+	}
+	opt.addFlags(cmd)
+	return cmd
+}
+
+func newNodeRebalanceCmd(api *rebalanceAPI, long string) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpRebalance + " [COMMAND]",
+		Short: fmt.Sprintf("Manage the rebalancer of the %v partitions", api.nodeType),
+		Long:  long,
+	}
+	cmd.AddCommand(
+		newRebalanceInfoCmd(api, CliOpInfo),
+		newRebalanceSetCmd(api),
+		newRebalanceSwitchCmd(api, CliOpPause, true),
+		newRebalanceSwitchCmd(api, CliOpResume, false),
+	)
+	return cmd
+}
+
+func newRebalanceInfoCmd(api *rebalanceAPI, op string) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   op,
+		Short: fmt.Sprintf("Show the rebalancer, the usage of the %v nodes and the migrating partitions", api.nodeType),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.RebalanceView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = api.get(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("%v", formatRebalanceView(view))
+		},
+	}
+	return cmd
+}
+
+func newRebalanceSetCmd(api *rebalanceAPI) *cobra.Command {
+	var opt rebalanceOptions
+	var cmd = &cobra.Command{
+		Use:   CliOpSet,
+		Short: "Set the usage ratios and the limit of the migrating partitions of the rebalancer",
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				view *proto.RebalanceView
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = api.get(); err != nil {
+				return
+			}
+			config := view.RebalanceConfig
+			if !opt.apply(cmd, &config) {
+				err = NewArgumentError("nothing to set")
+				return
+			}
+			if err = api.set(config.HighRatio, config.LowRatio, config.Limit, config.Bandwidth); err != nil {
+				return
+			}
+			stdout("Rebalancer of the %v partitions is set: %v\n", api.nodeType, formatRebalanceConfig(config))
+		},
+	}
+	opt.addFlags(cmd)
+	return cmd
+}
+
+func newRebalanceSwitchCmd(api *rebalanceAPI, op string, paused bool) *cobra.Command {
+	var short, result = "Resume the rebalancer", "resumed"
+	if paused {
+		short, result = "Pause the rebalancer, the migrating partitions keep recovering", "paused"
+	}
+	var cmd = &cobra.Command{
+		Use:   op,
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if paused {
+				err = api.pause()
+			} else {
+				err = api.resume()
+			}
+			if err != nil {
+				errout("Error: %v", err)
+			}
+			stdout("Rebalancer of the %v partitions is %v.\n", api.nodeType, result)
+		},
+	}
+	return cmd
+}
+
+var (
+	rebalanceNodeTablePattern      = "%-22v    %-12v    %-10v    %-10v    %-8v    %-10v    %-14v"
+	rebalanceNodeTableHeader       = fmt.Sprintf(rebalanceNodeTablePattern, "ADDRESS", "ZONE", "USED", "TOTAL", "RATIO", "PARTITIONS", "PARTITION USED")
+	rebalanceMigrationTablePattern = "%-8v    %-16v    %-22v    %-22v    %-10v    %-20v"
+	rebalanceMigrationTableHeader  = fmt.Sprintf(rebalanceMigrationTablePattern, "ID", "VOLUME", "SOURCE", "TARGET", "USED", "START TIME")
+)
+
+func formatRebalanceConfig(config proto.RebalanceConfig) string {
+	if config.Limit == 0 {
+		return "disabled"
+	}
+	var status = "running"
+	if config.Paused {
+		status = "paused"
+	}
+	var bandwidth = "unlimited"
+	if config.Bandwidth > 0 {
+		bandwidth = formatSize(config.Bandwidth) + "/s"
+	}
+	return fmt.Sprintf("%v, from ratio above %.2f to ratio below %.2f, %v partitions at most, bandwidth %v", status,
+		config.HighRatio, config.LowRatio, config.Limit, bandwidth)
+}
+
+func formatRebalanceView(view *proto.RebalanceView) string {
+	var sb = fmt.Sprintf("Rebalancer: %v\n\n", formatRebalanceConfig(view.RebalanceConfig))
+	sb += fmt.Sprintf("Nodes:\n%v\n", rebalanceNodeTableHeader)
+	for _, node := range view.Nodes {
+		sb += fmt.Sprintf(rebalanceNodeTablePattern+"\n", node.Addr, node.ZoneName, formatSize(node.Used),
+			formatSize(node.Total), fmt.Sprintf("%.2f", node.Ratio), node.PartitionCount, formatSize(node.PartitionUsed))
+	}
+	sb += fmt.Sprintf("\nMigrations:\n%v\n", rebalanceMigrationTableHeader)
+	for _, migration := range view.Migrations {
+		sb += fmt.Sprintf(rebalanceMigrationTablePattern+"\n", migration.PartitionID, migration.VolName,
+			migration.Source, migration.Target, formatSize(migration.Used), formatTime(migration.StartTime))
+	}
+	return sb
+}
@@ -0,0 +1,405 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultRepairInterval = time.Second
+	// the nodes whose usage ratio would exceed it after adding the replica are not chosen
+	repairMaxUsageRatio = 0.9
+)
+
+// repairNode defines the load of a node which may host the new replicas.
+type repairNode struct {
+	Addr       string
+	ZoneName   string
+	Total      uint64
+	Used       uint64
+	Available  uint64
+	Partitions int
+}
+
+func (node *repairNode) usageRatio(extra uint64) float64 {
+	if node.Total == 0 {
+		return 1
+	}
+	return float64(node.Used+extra) / float64(node.Total)
+}
+
+// repairPartition defines the state of a partition which lacks replicas.
+type repairPartition struct {
+	PartitionID uint64
+	VolName     string
+	ReplicaNum  int
+	Hosts       []string
+	Zones       []string
+	Size        uint64
+}
+
+// repairTask defines adding a replica of the partition on the target node.
+type repairTask struct {
+	PartitionID uint64
+	VolName     string
+	Target      string
+	Error       string // the reason why no target is chosen
+}
+
+// partitionRepairSource defines how to get the partitions and the nodes of a kind of partition.
+type partitionRepairSource struct {
+	kind           string
+	lackReplicaIDs func() ([]uint64, error)
+	partition      func(id uint64) (*repairPartition, error)
+	nodes          func() ([]*repairNode, error)
+	addReplica     func(id uint64, addr string) error
+}
+
+// chooseRepairTarget chooses the node with the lowest usage ratio for the new replica.
+// If the allowed zones are not empty, only the nodes in them are chosen. The nodes out of
+// the avoided zones are preferred. The load of the chosen node is updated for the next choice.
+func chooseRepairTarget(nodes []*repairNode, hosts, allowedZones, avoidZones []string, size uint64) (target *repairNode, err error) {
+	var preferred, others []*repairNode
+	for _, node := range nodes {
+		if containsString(hosts, node.Addr) {
+			continue
+		}
+		if len(allowedZones) > 0 && !containsString(allowedZones, node.ZoneName) {
+			continue
+		}
+		if node.Available < size || node.usageRatio(size) > repairMaxUsageRatio {
+			continue
+		}
+		if containsString(avoidZones, node.ZoneName) {
+			others = append(others, node)
+		} else {
+			preferred = append(preferred, node)
+		}
+	}
+	candidates := preferred
+	if len(candidates) == 0 {
+		candidates = others
+	}
+	if len(candidates) == 0 {
+		err = fmt.Errorf("no available node in zones %v", allowedZones)
+		return
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := candidates[i].usageRatio(0), candidates[j].usageRatio(0)
+		if ri != rj {
+			return ri < rj
+		}
+		return candidates[i].Partitions < candidates[j].Partitions
+	})
+	target = candidates[0]
+	target.Used += size
+	target.Available -= size
+	target.Partitions++
+	return
+}
+
+func containsString(arr []string, element string) bool {
+	for _, e := range arr {
+		if e == element {
+			return true
+		}
+	}
+	return false
+}
+
+// planPartitionRepair chooses the target nodes for all the lacked replicas of the partitions.
+func planPartitionRepair(client *master.MasterClient, src *partitionRepairSource) (tasks []*repairTask, err error) {
+	var (
+		ids   []uint64
+		nodes []*repairNode
+		vols  = make(map[string]*proto.SimpleVolView)
+	)
+	if ids, err = src.lackReplicaIDs(); err != nil {
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if nodes, err = src.nodes(); err != nil {
+		return
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		var partition *repairPartition
+		if partition, err = src.partition(id); err != nil {
+			return
+		}
+		vol, ok := vols[partition.VolName]
+		if !ok {
+			if vol, err = client.AdminAPI().GetVolumeSimpleInfo(partition.VolName); err != nil {
+				return
+			}
+			vols[partition.VolName] = vol
+		}
+		var allowedZones []string
+		if !vol.CrossZone && vol.ZoneName != "" {
+			allowedZones = strings.Split(vol.ZoneName, ",")
+		}
+		hosts := append([]string{}, partition.Hosts...)
+		zones := append([]string{}, partition.Zones...)
+		for i := len(hosts); i < partition.ReplicaNum; i++ {
+			task := &repairTask{PartitionID: id, VolName: partition.VolName}
+			tasks = append(tasks, task)
+			var avoidZones []string
+			if vol.CrossZone {
+				avoidZones = zones
+			}
+			target, chooseErr := chooseRepairTarget(nodes, hosts, allowedZones, avoidZones, partition.Size)
+			if chooseErr != nil {
+				task.Error = chooseErr.Error()
+				break
+			}
+			task.Target = target.Addr
+			hosts = append(hosts, target.Addr)
+			zones = append(zones, target.ZoneName)
+		}
+	}
+	return
+}
+
+// runPartitionRepair plans the repair of the partitions which lack replicas, and adds the replicas one by one
+// with the interval if auto is true.
+func runPartitionRepair(client *master.MasterClient, src *partitionRepairSource, auto, yes bool, interval time.Duration) (err error) {
+	var tasks []*repairTask
+	if tasks, err = planPartitionRepair(client, src); err != nil {
+		return
+	}
+	if !auto {
+		if isStructuredOutput() {
+			return printStructured(tasks)
+		}
+		printRepairTasks(src.kind, tasks)
+		if len(tasks) > 0 {
+			stdout("\nUse --%v to add the replicas as planned.\n", CliFlagAuto)
+		}
+		return
+	}
+	if !isStructuredOutput() {
+		printRepairTasks(src.kind, tasks)
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	if !yes {
+		if err = confirmBatchOperation("Add replicas for", len(tasks)); err != nil {
+			return
+		}
+	}
+	summary := &batchSummary{Total: len(tasks), Succeeded: make([]uint64, 0), Failed: make([]batchFailure, 0)}
+	for i, task := range tasks {
+		if i > 0 && interval > 0 {
+			time.Sleep(interval)
+		}
+		var opErr error
+		if task.Target == "" {
+			opErr = errors.New(task.Error)
+		} else {
+			opErr = src.addReplica(task.PartitionID, task.Target)
+		}
+		if opErr != nil {
+			summary.Failed = append(summary.Failed, batchFailure{PartitionID: task.PartitionID, Error: opErr.Error()})
+		} else {
+			summary.Succeeded = append(summary.Succeeded, task.PartitionID)
+		}
+		if !isStructuredOutput() {
+			result := "ok"
+			if opErr != nil {
+				result = fmt.Sprintf("failed, %v", opErr)
+			}
+			stdout("[%v/%v] %v partition %v add replica on [%v]: %v\n",
+				i+1, len(tasks), src.kind, task.PartitionID, task.Target, result)
+		}
+	}
+	if err = printBatchSummary("Repair", summary); err != nil {
+		return
+	}
+	if len(summary.Failed) > 0 {
+		err = newPartialFailureError("add %v replicas failed", len(summary.Failed))
+	}
+	return
+}
+
+func printRepairTasks(kind string, tasks []*repairTask) {
+	if len(tasks) == 0 {
+		stdout("No %v partition lacks replicas.\n", kind)
+		return
+	}
+	stdout("[Repair plan]\n")
+	repairTablePattern := "%-12v    %-16v    %-22v    %v\n"
+	stdout(repairTablePattern, "PARTITION ID", "VOLUME", "TARGET", "ERROR")
+	for _, task := range tasks {
+		stdout(repairTablePattern, task.PartitionID, task.VolName, task.Target, task.Error)
+	}
+}
+
+func newMetaPartitionRepairSource(client *master.MasterClient) *partitionRepairSource {
+	return &partitionRepairSource{
+		kind: "meta",
+		lackReplicaIDs: func() (ids []uint64, err error) {
+			var diagnosis *proto.MetaPartitionDiagnosis
+			if diagnosis, err = client.AdminAPI().DiagnoseMetaPartition(); err != nil {
+				return
+			}
+			return diagnosis.LackReplicaMetaPartitionIDs, nil
+		},
+		partition: func(id uint64) (partition *repairPartition, err error) {
+			var info *proto.MetaPartitionInfo
+			if info, err = client.ClientAPI().GetMetaPartition(id); err != nil {
+				return
+			}
+			partition = &repairPartition{
+				PartitionID: info.PartitionID,
+				VolName:     info.VolName,
+				ReplicaNum:  int(info.ReplicaNum),
+				Hosts:       info.Hosts,
+				Zones:       info.Zones,
+			}
+			return
+		},
+		nodes: func() (nodes []*repairNode, err error) {
+			var view *proto.ClusterView
+			if view, err = client.AdminAPI().GetCluster(); err != nil {
+				return
+			}
+			for _, nv := range view.MetaNodes {
+				if !nv.Status || !nv.IsWritable {
+					continue
+				}
+				var info *proto.MetaNodeInfo
+				if info, err = client.NodeAPI().GetMetaNode(nv.Addr); err != nil {
+					return
+				}
+				node := &repairNode{Addr: info.Addr, ZoneName: info.ZoneName, Total: info.Total, Used: info.Used,
+					Partitions: info.MetaPartitionCount}
+				if info.Total > info.Used {
+					node.Available = info.Total - info.Used
+				}
+				nodes = append(nodes, node)
+			}
+			return
+		},
+		addReplica: func(id uint64, addr string) error {
+			return client.AdminAPI().AddMetaReplica(id, addr)
+		},
+	}
+}
+
+func newDataPartitionRepairSource(client *master.MasterClient) *partitionRepairSource {
+	return &partitionRepairSource{
+		kind: "data",
+		lackReplicaIDs: func() (ids []uint64, err error) {
+			var diagnosis *proto.DataPartitionDiagnosis
+			if diagnosis, err = client.AdminAPI().DiagnoseDataPartition(); err != nil {
+				return
+			}
+			return diagnosis.LackReplicaDataPartitionIDs, nil
+		},
+		partition: func(id uint64) (partition *repairPartition, err error) {
+			var info *proto.DataPartitionInfo
+			if info, err = client.AdminAPI().GetDataPartition("", id); err != nil {
+				return
+			}
+			partition = &repairPartition{
+				PartitionID: info.PartitionID,
+				VolName:     info.VolName,
+				ReplicaNum:  int(info.ReplicaNum),
+				Hosts:       info.Hosts,
+				Zones:       info.Zones,
+			}
+			for _, replica := range info.Replicas {
+				if replica.Used > partition.Size {
+					partition.Size = replica.Used
+				}
+			}
+			return
+		},
+		nodes: func() (nodes []*repairNode, err error) {
+			var view *proto.ClusterView
+			if view, err = client.AdminAPI().GetCluster(); err != nil {
+				return
+			}
+			for _, nv := range view.DataNodes {
+				if !nv.Status || !nv.IsWritable {
+					continue
+				}
+				var info *proto.DataNodeInfo
+				if info, err = client.NodeAPI().GetDataNode(nv.Addr); err != nil {
+					return
+				}
+				nodes = append(nodes, &repairNode{Addr: info.Addr, ZoneName: info.ZoneName, Total: info.Total,
+					Used: info.Used, Available: info.AvailableSpace, Partitions: int(info.DataPartitionCount)})
+			}
+			return
+		},
+		addReplica: func(id uint64, addr string) error {
+			return client.AdminAPI().AddDataReplica(id, addr)
+		},
+	}
+}
+
+func newPartitionRepairCmd(short string, newSource func(client *master.MasterClient) *partitionRepairSource,
+	client *master.MasterClient) *cobra.Command {
+	var (
+		optAuto     bool
+		optYes      bool
+		optInterval time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpRepair,
+		Short: short,
+		Long: `Choose a target node for each lacked replica of the partitions found by check. The nodes in the zones
+of the volume with enough space and the lowest usage are preferred, and the replicas of a cross zone volume
+are spread to different zones. Only the plan is displayed unless --auto is specified.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optInterval < 0 {
+				err = NewArgumentError("invalid interval [%v]", optInterval)
+				return
+			}
+			err = runPartitionRepair(client, newSource(client), optAuto, optYes, optInterval)
+		},
+	}
+	cmd.Flags().BoolVar(&optAuto, CliFlagAuto, false, "Add the replicas as planned")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultRepairInterval, "Interval between adding two replicas")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newMetaPartitionRepairCmd(client *master.MasterClient) *cobra.Command {
+	return newPartitionRepairCmd(cmdMetaPartitionRepairShort, newMetaPartitionRepairSource, client)
+}
+
+func newDataPartitionRepairCmd(client *master.MasterClient) *cobra.Command {
+	return newPartitionRepairCmd(cmdDataPartitionRepairShort, newDataPartitionRepairSource, client)
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	ReportFormatCSV  = "csv"
+	ReportFormatHTML = "html"
+)
+
+// diagnosisReport is the plain text report of a diagnosis, which can be attached to the incident tickets.
+type diagnosisReport struct {
+	Title      string
+	Cluster    string
+	CreateTime string
+	Sections   []*reportSection
+}
+
+type reportSection struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+func newDiagnosisReport(title, cluster string) *diagnosisReport {
+	return &diagnosisReport{
+		Title:      title,
+		Cluster:    cluster,
+		CreateTime: formatTimeToString(time.Now()),
+	}
+}
+
+func (report *diagnosisReport) addSection(name string, header ...string) (section *reportSection) {
+	section = &reportSection{Name: name, Header: header, Rows: make([][]string, 0)}
+	report.Sections = append(report.Sections, section)
+	return
+}
+
+func (section *reportSection) addRow(values ...interface{}) {
+	row := make([]string, 0, len(values))
+	for _, v := range values {
+		row = append(row, fmt.Sprintf("%v", v))
+	}
+	section.Rows = append(section.Rows, row)
+}
+
+func validateReportFormat(format string) (err error) {
+	switch format {
+	case ReportFormatCSV, ReportFormatHTML:
+	default:
+		err = NewArgumentError("unsupported report format [%v], should be one of %v, %v", format, ReportFormatCSV, ReportFormatHTML)
+	}
+	return
+}
+
+// exportDiagnosisReport writes the report to the file in the format.
+func exportDiagnosisReport(report *diagnosisReport, format, path string) (err error) {
+	if err = validateReportFormat(format); err != nil {
+		return
+	}
+	var file *os.File
+	if file, err = os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if format == ReportFormatCSV {
+		return writeCSVReport(file, report)
+	}
+	return writeHTMLReport(file, report)
+}
+
+// writeCSVReport writes the sections one by one, each of which starts with a line of its name
+// and a line of its header, and the sections are separated by an empty line.
+func writeCSVReport(w io.Writer, report *diagnosisReport) (err error) {
+	writer := csv.NewWriter(w)
+	records := [][]string{
+		{report.Title},
+		{"Cluster", report.Cluster},
+		{"Time", report.CreateTime},
+	}
+	for _, section := range report.Sections {
+		records = append(records, []string{}, []string{section.Name}, section.Header)
+		records = append(records, section.Rows...)
+	}
+	if err = writer.WriteAll(records); err != nil {
+		return
+	}
+	return writer.Error()
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Cluster: {{.Cluster}}<br>Time: {{.CreateTime}}</p>
+{{range .Sections}}<h2>{{.Name}} ({{len .Rows}})</h2>
+<table>
+<tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+func writeHTMLReport(w io.Writer, report *diagnosisReport) error {
+	return htmlReportTemplate.Execute(w, report)
+}
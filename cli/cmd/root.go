@@ -41,6 +41,17 @@ func NewRootCmd(client *master.MasterClient) *ChubaoFSCmd {
 			Use:   path.Base(os.Args[0]),
 			Short: cmdRootShort,
 			Args:  cobra.MinimumNArgs(0),
+			PersistentPreRun: func(cmd *cobra.Command, args []string) {
+				if err := validateOutputFormat(); err != nil {
+					errout("Error: %v\n", err)
+				}
+				userName, host := currentCaller()
+				client.SetCaller(fmt.Sprintf("%v@%v", userName, host))
+				beginAudit(client, cmd)
+			},
+			PersistentPostRun: func(cmd *cobra.Command, args []string) {
+				finishAudit(ExitCodeOK, "")
+			},
 			Run: func(cmd *cobra.Command, args []string) {
 				if optShowVersion {
 					stdout(proto.DumpVersion("CLI"))
@@ -51,6 +62,10 @@ func NewRootCmd(client *master.MasterClient) *ChubaoFSCmd {
 	}
 
 	cmd.CFSCmd.Flags().BoolVarP(&optShowVersion, "version", "v", false, "Show version information")
+	cmd.CFSCmd.PersistentFlags().StringVarP(&optOutputFormat, CliFlagOutput, "o", OutputFormatTable,
+		fmt.Sprintf("Output format [%v, %v, %v]", OutputFormatTable, OutputFormatJSON, OutputFormatYAML))
+	cmd.CFSCmd.PersistentFlags().StringVar(&optProfile, CliFlagProfile, "",
+		fmt.Sprintf("Cluster profile in the config file, defaults to $%v or the current profile", envConfigProfile))
 
 	cmd.CFSCmd.AddCommand(
 		cmd.newClusterCmd(client),
@@ -63,6 +78,16 @@ func NewRootCmd(client *master.MasterClient) *ChubaoFSCmd {
 		newConfigCmd(),
 		newCompatibilityCmd(),
 		newZoneCmd(client),
+		newTaskCmd(client),
+		newInodeCmd(client),
+		newExtentCmd(client),
+		newQuotaCmd(client),
+		newExpirationCmd(client),
+		newLifecycleCmd(client),
+		newRebalanceCmd(client),
+		newAlertCmd(client),
+		newAuditCmd(client),
+		newDryRunCmd(client),
 	)
 	return cmd
 }
@@ -71,13 +96,27 @@ func stdout(format string, a ...interface{}) {
 	_, _ = fmt.Fprintf(os.Stdout, format, a...)
 }
 
+// errout prints the message to stderr and exits with the code classified from the first error in the arguments.
 func errout(format string, a ...interface{}) {
 	log.LogErrorf(format + "\n", a...)
 	_, _ = fmt.Fprintf(os.Stderr, format, a...)
-	OsExitWithLogFlush()
+	var code = ExitCodeError
+	for _, arg := range a {
+		if err, ok := arg.(error); ok {
+			code = ExitCode(err)
+			break
+		}
+	}
+	finishAudit(code, fmt.Sprintf(format, a...))
+	osExitWithCode(code)
 }
 
 func OsExitWithLogFlush() {
+	osExitWithCode(ExitCodeError)
+}
+
+func osExitWithCode(code int) {
+	finishAudit(code, "")
 	log.LogFlush()
-	os.Exit(1)
+	os.Exit(code)
 }
@@ -0,0 +1,137 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdClusterConfigUse       = CliOpConfig + " [COMMAND]"
+	cmdClusterConfigShort     = "Manage the parameters of the master which can be changed at runtime"
+	cmdClusterConfigGetShort  = "Show a parameter of the master"
+	cmdClusterConfigSetShort  = "Change a parameter of the master without restarting"
+	cmdClusterConfigListShort = "List the parameters of the master which can be changed at runtime"
+)
+
+var (
+	runtimeConfigTablePattern = "%-36v    %-12v    %-12v    %-10v    %v"
+	runtimeConfigTableHeader  = fmt.Sprintf(runtimeConfigTablePattern, "NAME", "VALUE", "STATIC", "OVERRIDDEN", "DESCRIPTION")
+)
+
+func newClusterConfigCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdClusterConfigUse,
+		Short: cmdClusterConfigShort,
+		Long: `The parameters changed at runtime are persisted by the masters and applied without restarting. They override
+the values in the config file of the masters, which are shown as the static values.`,
+	}
+	cmd.AddCommand(
+		newClusterConfigGetCmd(client),
+		newClusterConfigSetCmd(client),
+		newClusterConfigListCmd(client),
+	)
+	return cmd
+}
+
+func newClusterConfigGetCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpGet + " [NAME]",
+		Short: cmdClusterConfigGetShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				item *proto.RuntimeConfigItem
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if item, err = client.AdminAPI().GetConfig(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(item)
+				return
+			}
+			stdout("%v\n", runtimeConfigTableHeader)
+			stdout("%v\n", formatRuntimeConfigItem(item))
+		},
+	}
+	return cmd
+}
+
+func newClusterConfigSetCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [NAME] [VALUE]",
+		Short: cmdClusterConfigSetShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if err = client.AdminAPI().SetConfig(args[0], args[1]); err != nil {
+				return
+			}
+			stdout("Parameter [%v] is set to %v.\n", args[0], args[1])
+		},
+	}
+	return cmd
+}
+
+func newClusterConfigListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdClusterConfigListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err   error
+				items []*proto.RuntimeConfigItem
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if items, err = client.AdminAPI().ListConfig(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(items)
+				return
+			}
+			stdout("%v\n", runtimeConfigTableHeader)
+			for _, item := range items {
+				stdout("%v\n", formatRuntimeConfigItem(item))
+			}
+		},
+	}
+	return cmd
+}
+
+func formatRuntimeConfigItem(item *proto.RuntimeConfigItem) string {
+	return fmt.Sprintf(runtimeConfigTablePattern, item.Name, item.Value, item.Static, formatYesNo(item.Overridden),
+		item.Description)
+}
@@ -0,0 +1,222 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdTaskUse   = "task [COMMAND]"
+	cmdTaskShort = "Manage the async tasks of admin operations"
+)
+
+const (
+	defaultTaskWaitInterval = 5 * time.Second
+)
+
+func newTaskCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdTaskUse,
+		Short: cmdTaskShort,
+		Long: `The decommission, add-replica and del-replica commands of partitions return a task ID immediately with the
+"--async" flag, and the operations are executed by master in background. The tasks are kept in the memory of the
+leader master, so they are lost if the leader master changes.`,
+	}
+	cmd.AddCommand(
+		newTaskListCmd(client),
+		newTaskInfoCmd(client),
+		newTaskWaitCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdTaskListShort = "List all the async tasks"
+	cmdTaskInfoShort = "Show the status of an async task"
+	cmdTaskWaitShort = "Wait for an async task to finish"
+)
+
+func newTaskListCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdTaskListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				tasks []*proto.AsyncTaskInfo
+				err   error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if tasks, err = client.AdminAPI().ListTasks(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(tasks)
+				return
+			}
+			stdout("%v\n", asyncTaskTableHeader)
+			for _, task := range tasks {
+				stdout("%v\n", formatAsyncTaskTableRow(task))
+			}
+		},
+	}
+	return cmd
+}
+
+func newTaskInfoCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpInfo + " [TASK ID]",
+		Short: cmdTaskInfoShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				taskID uint64
+				task   *proto.AsyncTaskInfo
+				err    error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if taskID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if task, err = client.AdminAPI().GetTaskStatus(taskID); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(task)
+				return
+			}
+			stdout("[Task]\n")
+			stdout("%v", formatAsyncTaskInfo(task))
+		},
+	}
+	return cmd
+}
+
+func newTaskWaitCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optInterval time.Duration
+		optTimeout  time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpWait + " [TASK ID]",
+		Short: cmdTaskWaitShort,
+		Long: `Poll the status of the task until it is finished. The command exits with an error if the task failed
+or the timeout is reached. A zero timeout means waiting forever.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				taskID uint64
+				task   *proto.AsyncTaskInfo
+				err    error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if taskID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if optInterval <= 0 {
+				err = NewArgumentError("invalid interval [%v]", optInterval)
+				return
+			}
+			if task, err = waitAsyncTask(client, taskID, optInterval, optTimeout, newTaskProgressPrinter()); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(task)
+			} else {
+				stdout("[Task]\n")
+				stdout("%v", formatAsyncTaskInfo(task))
+			}
+			if err == nil && task.Status == proto.AsyncTaskFailed {
+				err = fmt.Errorf("task %v failed", taskID)
+			}
+		},
+	}
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the task status")
+	cmd.Flags().DurationVar(&optTimeout, CliFlagTimeout, 0, "Maximum time to wait, 0 means no limit")
+	return cmd
+}
+
+// waitAsyncTask polls the status of the task until it is finished. The onPoll function, if not nil, is called with
+// the status of each poll.
+func waitAsyncTask(client *master.MasterClient, taskID uint64, interval, timeout time.Duration, onPoll func(task *proto.AsyncTaskInfo)) (task *proto.AsyncTaskInfo, err error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		if task, err = client.AdminAPI().GetTaskStatus(taskID); err != nil {
+			return
+		}
+		if onPoll != nil {
+			onPoll(task)
+		}
+		if task.IsFinished() {
+			return
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			err = fmt.Errorf("timeout waiting for task %v, current status [%v]", taskID, task.Status)
+			return
+		}
+		time.Sleep(interval)
+	}
+}
+
+// newTaskProgressPrinter returns a function which prints the progress of the tasks processing multiple partitions
+// whenever it changes. It returns nil for the structured output.
+func newTaskProgressPrinter() func(task *proto.AsyncTaskInfo) {
+	if isStructuredOutput() {
+		return nil
+	}
+	var lastDone = -1
+	return func(task *proto.AsyncTaskInfo) {
+		if task.Total == 0 || task.Done == lastDone {
+			return
+		}
+		lastDone = task.Done
+		stdout("Progress: %v/%v partitions\n", task.Done, task.Total)
+	}
+}
+
+// submitPartitionTask submits the admin operation of the partition to master and prints the task ID.
+func submitPartitionTask(client *master.MasterClient, path string, partitionID uint64, address string) (err error) {
+	var task *proto.AsyncTaskInfo
+	if task, err = client.AdminAPI().SubmitPartitionTask(path, partitionID, address); err != nil {
+		return
+	}
+	if isStructuredOutput() {
+		return printStructured(task)
+	}
+	stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", task.ID, task.ID, task.ID)
+	return
+}
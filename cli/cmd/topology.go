@@ -0,0 +1,387 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdClusterSnapshotShort = "Capture the topology of the nodes, zones and partitions"
+	cmdClusterDiffShort     = "Show the topology changes between two snapshots"
+)
+
+// Kinds and changes of the topology diff
+const (
+	topoKindZone          = "zone"
+	topoKindDataNode      = "datanode"
+	topoKindMetaNode      = "metanode"
+	topoKindDataPartition = "datapartition"
+	topoKindMetaPartition = "metapartition"
+
+	topoChangeAdded  = "added"
+	topoChangeLost   = "lost"
+	topoChangeMoved  = "moved"
+	topoChangeStatus = "status"
+	topoChangeLeader = "leader"
+)
+
+// topologySnapshot is the topology of the cluster at a point in time, which is saved as a json file.
+type topologySnapshot struct {
+	Time           int64
+	Cluster        string
+	Zones          []*zoneSnapshot
+	DataNodes      []*nodeSnapshot
+	MetaNodes      []*nodeSnapshot
+	DataPartitions []*partitionSnapshot
+	MetaPartitions []*partitionSnapshot
+}
+
+type zoneSnapshot struct {
+	Name   string
+	Status string
+}
+
+type nodeSnapshot struct {
+	Addr   string
+	ID     uint64
+	Zone   string
+	Active bool
+}
+
+type partitionSnapshot struct {
+	PartitionID uint64
+	VolName     string
+	Hosts       []string // sorted
+	Leader      string
+	Status      string
+}
+
+// topologyChange is a difference of a zone, node or partition between two snapshots.
+type topologyChange struct {
+	Kind   string
+	ID     string
+	Change string
+	Detail string
+}
+
+type topologyDiff struct {
+	OldTime string
+	NewTime string
+	Changes []*topologyChange
+}
+
+func newClusterSnapshotCmd(client *master.MasterClient) *cobra.Command {
+	var optSave string
+	var cmd = &cobra.Command{
+		Use:   CliOpSnapshot,
+		Short: cmdClusterSnapshotShort,
+		Long: `Capture the zones, the nodes with their zones and status, and the replicas and leaders of all partitions
+into a json file, which can be compared with a later snapshot by "cluster diff". The snapshot is printed to stdout
+if "--save" is not specified.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err      error
+				snapshot *topologySnapshot
+				data     []byte
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if snapshot, err = captureTopology(client); err != nil {
+				return
+			}
+			if data, err = json.MarshalIndent(snapshot, "", "  "); err != nil {
+				return
+			}
+			if optSave == "" {
+				stdout("%s\n", data)
+				return
+			}
+			if err = ioutil.WriteFile(optSave, data, 0644); err != nil {
+				return
+			}
+			stdout("Topology of %v zones, %v data nodes, %v meta nodes, %v data partitions and %v meta partitions "+
+				"is saved to [%v]\n", len(snapshot.Zones), len(snapshot.DataNodes), len(snapshot.MetaNodes),
+				len(snapshot.DataPartitions), len(snapshot.MetaPartitions), optSave)
+		},
+	}
+	cmd.Flags().StringVar(&optSave, CliFlagSave, "", "Save the snapshot to the file")
+	return cmd
+}
+
+func newClusterDiffCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpDiff + " [OLD SNAPSHOT] [NEW SNAPSHOT]",
+		Short: cmdClusterDiffShort,
+		Long: `Compare two snapshots captured by "cluster snapshot", and show the zones, nodes and partitions which were
+added or lost, the nodes which moved to other zones or changed status, and the partitions whose replicas moved or
+whose leader changed. The current topology of the cluster is used if the new snapshot is not specified.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err      error
+				from, to *topologySnapshot
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if from, err = loadTopologySnapshot(args[0]); err != nil {
+				return
+			}
+			if len(args) > 1 {
+				to, err = loadTopologySnapshot(args[1])
+			} else {
+				to, err = captureTopology(client)
+			}
+			if err != nil {
+				return
+			}
+			diff := diffTopology(from, to)
+			if isStructuredOutput() {
+				err = printStructured(diff)
+				return
+			}
+			stdout("Topology changes from %v to %v:\n", diff.OldTime, diff.NewTime)
+			stdout("%v\n", topologyChangeTableHeader)
+			for _, change := range diff.Changes {
+				stdout("%v\n", formatTopologyChangeTableRow(change))
+			}
+			stdout("\nTotal: %v\n", len(diff.Changes))
+		},
+	}
+	return cmd
+}
+
+func captureTopology(client *master.MasterClient) (snapshot *topologySnapshot, err error) {
+	var (
+		cv   *proto.ClusterView
+		topo *proto.TopologyView
+		vols []*proto.VolInfo
+	)
+	snapshot = &topologySnapshot{Time: time.Now().Unix()}
+	if cv, err = client.AdminAPI().GetCluster(); err != nil {
+		return
+	}
+	if topo, err = client.AdminAPI().Topo(); err != nil {
+		return
+	}
+	snapshot.Cluster = cv.Name
+	nodeZones := make(map[string]string)
+	for _, zone := range topo.Zones {
+		snapshot.Zones = append(snapshot.Zones, &zoneSnapshot{Name: zone.Name, Status: zone.Status})
+		for _, ns := range zone.NodeSet {
+			for _, node := range ns.DataNodes {
+				nodeZones[node.Addr] = zone.Name
+			}
+			for _, node := range ns.MetaNodes {
+				nodeZones[node.Addr] = zone.Name
+			}
+		}
+	}
+	for _, node := range cv.DataNodes {
+		snapshot.DataNodes = append(snapshot.DataNodes,
+			&nodeSnapshot{Addr: node.Addr, ID: node.ID, Zone: nodeZones[node.Addr], Active: node.Status})
+	}
+	for _, node := range cv.MetaNodes {
+		snapshot.MetaNodes = append(snapshot.MetaNodes,
+			&nodeSnapshot{Addr: node.Addr, ID: node.ID, Zone: nodeZones[node.Addr], Active: node.Status})
+	}
+	if vols, err = client.AdminAPI().ListVols(""); err != nil {
+		return
+	}
+	for _, vol := range vols {
+		var (
+			dpView *proto.DataPartitionsView
+			mpView []*proto.MetaPartitionView
+		)
+		if dpView, err = client.ClientAPI().GetDataPartitions(vol.Name); err != nil {
+			err = annotateError(err, "get data partitions of volume [%v] failed: %v", vol.Name, err)
+			return
+		}
+		for _, dp := range dpView.DataPartitions {
+			snapshot.DataPartitions = append(snapshot.DataPartitions, &partitionSnapshot{PartitionID: dp.PartitionID,
+				VolName: vol.Name, Hosts: sortedHosts(dp.Hosts), Leader: dp.LeaderAddr,
+				Status: formatDataPartitionStatus(dp.Status)})
+		}
+		if mpView, err = client.ClientAPI().GetMetaPartitions(vol.Name); err != nil {
+			err = annotateError(err, "get meta partitions of volume [%v] failed: %v", vol.Name, err)
+			return
+		}
+		for _, mp := range mpView {
+			snapshot.MetaPartitions = append(snapshot.MetaPartitions, &partitionSnapshot{PartitionID: mp.PartitionID,
+				VolName: vol.Name, Hosts: sortedHosts(mp.Members), Leader: mp.LeaderAddr,
+				Status: formatMetaPartitionStatus(mp.Status)})
+		}
+	}
+	// keep the order stable, so that the snapshot files can also be compared by text diff tools
+	sort.Slice(snapshot.Zones, func(i, j int) bool { return snapshot.Zones[i].Name < snapshot.Zones[j].Name })
+	sort.Slice(snapshot.DataNodes, func(i, j int) bool { return snapshot.DataNodes[i].Addr < snapshot.DataNodes[j].Addr })
+	sort.Slice(snapshot.MetaNodes, func(i, j int) bool { return snapshot.MetaNodes[i].Addr < snapshot.MetaNodes[j].Addr })
+	sort.Slice(snapshot.DataPartitions, func(i, j int) bool {
+		return snapshot.DataPartitions[i].PartitionID < snapshot.DataPartitions[j].PartitionID
+	})
+	sort.Slice(snapshot.MetaPartitions, func(i, j int) bool {
+		return snapshot.MetaPartitions[i].PartitionID < snapshot.MetaPartitions[j].PartitionID
+	})
+	return
+}
+
+func loadTopologySnapshot(filePath string) (snapshot *topologySnapshot, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(filePath); err != nil {
+		err = NewArgumentError("read snapshot [%v] failed: %v", filePath, err)
+		return
+	}
+	snapshot = &topologySnapshot{}
+	if err = json.Unmarshal(data, snapshot); err != nil {
+		err = NewArgumentError("parse snapshot [%v] failed: %v", filePath, err)
+		return
+	}
+	return
+}
+
+func sortedHosts(hosts []string) []string {
+	sorted := make([]string, len(hosts))
+	copy(sorted, hosts)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// diffTopology compares the snapshots, and returns the changes grouped by kind.
+func diffTopology(from, to *topologySnapshot) (diff *topologyDiff) {
+	diff = &topologyDiff{
+		OldTime: formatTime(from.Time),
+		NewTime: formatTime(to.Time),
+		Changes: make([]*topologyChange, 0),
+	}
+	addChange := func(kind, id, change, format string, a ...interface{}) {
+		diff.Changes = append(diff.Changes, &topologyChange{Kind: kind, ID: id, Change: change, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	oldZones := make(map[string]*zoneSnapshot)
+	for _, zone := range from.Zones {
+		oldZones[zone.Name] = zone
+	}
+	newZones := make(map[string]*zoneSnapshot)
+	for _, zone := range to.Zones {
+		newZones[zone.Name] = zone
+		if oldZone, ok := oldZones[zone.Name]; !ok {
+			addChange(topoKindZone, zone.Name, topoChangeAdded, "")
+		} else if oldZone.Status != zone.Status {
+			addChange(topoKindZone, zone.Name, topoChangeStatus, "%v -> %v", oldZone.Status, zone.Status)
+		}
+	}
+	for _, zone := range from.Zones {
+		if _, ok := newZones[zone.Name]; !ok {
+			addChange(topoKindZone, zone.Name, topoChangeLost, "")
+		}
+	}
+
+	diffNodes := func(kind string, oldNodes, newNodes []*nodeSnapshot) {
+		oldMap := make(map[string]*nodeSnapshot)
+		for _, node := range oldNodes {
+			oldMap[node.Addr] = node
+		}
+		newMap := make(map[string]*nodeSnapshot)
+		for _, node := range newNodes {
+			newMap[node.Addr] = node
+			oldNode, ok := oldMap[node.Addr]
+			if !ok {
+				addChange(kind, node.Addr, topoChangeAdded, "zone %v", node.Zone)
+				continue
+			}
+			if oldNode.Zone != node.Zone {
+				addChange(kind, node.Addr, topoChangeMoved, "zone %v -> %v", oldNode.Zone, node.Zone)
+			}
+			if oldNode.Active != node.Active {
+				addChange(kind, node.Addr, topoChangeStatus, "%v -> %v",
+					formatNodeStatus(oldNode.Active), formatNodeStatus(node.Active))
+			}
+		}
+		for _, node := range oldNodes {
+			if _, ok := newMap[node.Addr]; !ok {
+				addChange(kind, node.Addr, topoChangeLost, "zone %v", node.Zone)
+			}
+		}
+	}
+	diffNodes(topoKindDataNode, from.DataNodes, to.DataNodes)
+	diffNodes(topoKindMetaNode, from.MetaNodes, to.MetaNodes)
+
+	diffPartitions := func(kind string, oldPartitions, newPartitions []*partitionSnapshot) {
+		oldMap := make(map[uint64]*partitionSnapshot)
+		for _, partition := range oldPartitions {
+			oldMap[partition.PartitionID] = partition
+		}
+		newMap := make(map[uint64]*partitionSnapshot)
+		for _, partition := range newPartitions {
+			newMap[partition.PartitionID] = partition
+			id := fmt.Sprintf("%v", partition.PartitionID)
+			oldPartition, ok := oldMap[partition.PartitionID]
+			if !ok {
+				addChange(kind, id, topoChangeAdded, "volume %v, hosts %v", partition.VolName, strings.Join(partition.Hosts, ","))
+				continue
+			}
+			if removed, added := diffHosts(oldPartition.Hosts, partition.Hosts); len(removed) != 0 || len(added) != 0 {
+				addChange(kind, id, topoChangeMoved, "-[%v] +[%v]", strings.Join(removed, ","), strings.Join(added, ","))
+			}
+			if oldPartition.Leader != partition.Leader {
+				addChange(kind, id, topoChangeLeader, "%v -> %v", oldPartition.Leader, partition.Leader)
+			}
+			if oldPartition.Status != partition.Status {
+				addChange(kind, id, topoChangeStatus, "%v -> %v", oldPartition.Status, partition.Status)
+			}
+		}
+		for _, partition := range oldPartitions {
+			if _, ok := newMap[partition.PartitionID]; !ok {
+				addChange(kind, fmt.Sprintf("%v", partition.PartitionID), topoChangeLost, "volume %v, hosts %v",
+					partition.VolName, strings.Join(partition.Hosts, ","))
+			}
+		}
+	}
+	diffPartitions(topoKindDataPartition, from.DataPartitions, to.DataPartitions)
+	diffPartitions(topoKindMetaPartition, from.MetaPartitions, to.MetaPartitions)
+	return
+}
+
+// diffHosts returns the hosts only in the old list and the hosts only in the new list.
+func diffHosts(oldHosts, newHosts []string) (removed, added []string) {
+	removed = make([]string, 0)
+	added = make([]string, 0)
+	for _, host := range oldHosts {
+		if !containsString(newHosts, host) {
+			removed = append(removed, host)
+		}
+	}
+	for _, host := range newHosts {
+		if !containsString(oldHosts, host) {
+			added = append(added, host)
+		}
+	}
+	return
+}
@@ -41,6 +41,7 @@ func newUserCmd(client *master.MasterClient) *cobra.Command {
 		newUserPermCmd(client),
 		newUserUpdateCmd(client),
 		newUserDeleteCmd(client),
+		newUserQuotaCmd(client),
 	)
 	return cmd
 }
@@ -73,7 +74,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 			if !userType.Valid() {
-				err = fmt.Errorf("Invalid user type. ")
+				err = NewArgumentError("Invalid user type. ")
 				return
 			}
 
@@ -115,7 +116,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 			}
 			var userInfo *proto.UserInfo
 			if userInfo, err = client.UserAPI().CreateUser(&param); err != nil {
-				err = fmt.Errorf("Create user failed: %v\n", err)
+				err = annotateError(err, "Create user failed: %v\n", err)
 				return
 			}
 
@@ -134,7 +135,7 @@ func newUserCreateCmd(client *master.MasterClient) *cobra.Command {
 }
 
 const (
-	cmdUserUpdateUse   = "update [USER ID]"
+	cmdUserUpdateUse   = CliOpUpdate + " [USER ID]"
 	cmdUserUpdateShort = "Update information about specified user"
 )
 
@@ -142,6 +143,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 	var optAccessKey string
 	var optSecretKey string
 	var optUserType string
+	var optRegenerateKeys bool
 	var optYes bool
 	var cmd = &cobra.Command{
 		Use:   cmdUserUpdateUse,
@@ -161,7 +163,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 			if optUserType != "" {
 				userType = proto.UserTypeFromString(optUserType)
 				if !userType.Valid() {
-					err = fmt.Errorf("Invalid user type ")
+					err = NewArgumentError("Invalid user type ")
 					return
 				}
 			}
@@ -170,10 +172,14 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 				var displayAccessKey = "[no change]"
 				if optAccessKey != "" {
 					displayAccessKey = optAccessKey
+				} else if optRegenerateKeys {
+					displayAccessKey = "[regenerate]"
 				}
 				var displaySecretKey = "[no change]"
 				if optSecretKey != "" {
 					displaySecretKey = optSecretKey
+				} else if optRegenerateKeys {
+					displaySecretKey = "[regenerate]"
 				}
 				var displayUserType = "[no change]"
 				if optUserType != "" {
@@ -192,15 +198,16 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 					return
 				}
 			}
-			if accessKey == "" && secretKey == "" && optUserType == "" {
-				err = fmt.Errorf("no update")
+			if accessKey == "" && secretKey == "" && optUserType == "" && !optRegenerateKeys {
+				err = NewArgumentError("no update")
 				return
 			}
 			var param = proto.UserUpdateParam{
-				UserID:    userID,
-				AccessKey: accessKey,
-				SecretKey: secretKey,
-				Type:      userType,
+				UserID:         userID,
+				AccessKey:      accessKey,
+				SecretKey:      secretKey,
+				Type:           userType,
+				RegenerateKeys: optRegenerateKeys,
 			}
 			var userInfo *proto.UserInfo
 			if userInfo, err = client.UserAPI().UpdateUser(&param); err != nil {
@@ -215,6 +222,7 @@ func newUserUpdateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().StringVar(&optAccessKey, "access-key", "", "Update user access key")
 	cmd.Flags().StringVar(&optSecretKey, "secret-key", "", "Update user secret key")
 	cmd.Flags().StringVar(&optUserType, "user-type", "", "Update user type [normal | admin]")
+	cmd.Flags().BoolVar(&optRegenerateKeys, CliFlagRegenerateKeys, false, "Generate new random access key and secret key, except the specified ones")
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
@@ -250,7 +258,7 @@ func newUserDeleteCmd(client *master.MasterClient) *cobra.Command {
 			}
 
 			if err = client.UserAPI().DeleteUser(userID); err != nil {
-				err = fmt.Errorf("Delete user failed:\n%v\n", err)
+				err = annotateError(err, "Delete user failed:\n%v\n", err)
 				return
 			}
 			stdout("Delete user success.\n")
@@ -288,7 +296,7 @@ func newUserInfoCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 			if userInfo, err = client.UserAPI().GetUserInfo(userID); err != nil {
-				err = fmt.Errorf("Get user info failed: %v\n", err)
+				err = annotateError(err, "Get user info failed: %v\n", err)
 				return
 			}
 			printUserInfo(userInfo)
@@ -305,12 +313,13 @@ func newUserInfoCmd(client *master.MasterClient) *cobra.Command {
 }
 
 const (
-	cmdUserPermUse   = "perm [USER ID] [VOLUME] [PERM (READONLY,RO,READWRITE,RW,NONE)]"
+	cmdUserPermUse   = CliOpPerm + " [USER ID] [VOLUME] [PERM (READONLY,RO,READWRITE,RW,NONE)]"
 	cmdUserPermShort = "Setup volume permission for a user"
 )
 
 func newUserPermCmd(client *master.MasterClient) *cobra.Command {
 	var subdir string
+	var optYes bool
 	var cmd = &cobra.Command{
 		Use:   cmdUserPermUse,
 		Short: cmdUserPermShort,
@@ -339,7 +348,7 @@ func newUserPermCmd(client *master.MasterClient) *cobra.Command {
 			case "none":
 				perm = proto.NonePermission
 			default:
-				err = fmt.Errorf("Permission must be on of ro, rw, none ")
+				err = NewArgumentError("Permission must be on of ro, rw, none ")
 				return
 			}
 			stdout("Setup volume permission\n")
@@ -349,12 +358,14 @@ func newUserPermCmd(client *master.MasterClient) *cobra.Command {
 			stdout("  Permission: %v\n", perm.ReadableString())
 
 			// ask user for confirm
-			stdout("\nConfirm (yes/no)[yes]: ")
-			var userConfirm string
-			_, _ = fmt.Scanln(&userConfirm)
-			if userConfirm != "yes" && len(userConfirm) != 0 {
-				err = fmt.Errorf("Abort by user.\n")
-				return
+			if !optYes {
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
 			}
 			var userInfo *proto.UserInfo
 			if userInfo, err = client.UserAPI().GetUserInfo(userID); err != nil {
@@ -384,6 +395,7 @@ func newUserPermCmd(client *master.MasterClient) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&subdir, "subdir", "", "Subdir")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
 
@@ -408,6 +420,10 @@ func newUserListCmd(client *master.MasterClient) *cobra.Command {
 			if users, err = client.UserAPI().ListUsers(optKeyword); err != nil {
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(users)
+				return
+			}
 			stdout("%v\n", userInfoTableHeader)
 			for _, user := range users {
 				stdout("%v\n", formatUserInfoTableRow(user))
@@ -418,7 +434,148 @@ func newUserListCmd(client *master.MasterClient) *cobra.Command {
 	return cmd
 }
 
+const (
+	cmdUserQuotaUse       = "quota [COMMAND]"
+	cmdUserQuotaShort     = "Manage the volume quota of a user"
+	cmdUserQuotaInfoUse   = CliOpInfo + " [USER ID]"
+	cmdUserQuotaInfoShort = "Show the volume quota of a user and the usage of it"
+	cmdUserQuotaSetUse    = CliOpSet + " [USER ID]"
+	cmdUserQuotaSetShort  = "Set the total capacity and the number of the volumes owned by a user, 0 means unlimited"
+)
+
+func newUserQuotaCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdUserQuotaUse,
+		Short: cmdUserQuotaShort,
+	}
+	cmd.AddCommand(
+		newUserQuotaInfoCmd(client),
+		newUserQuotaSetCmd(client),
+	)
+	return cmd
+}
+
+func newUserQuotaInfoCmd(client *master.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdUserQuotaInfoUse,
+		Short: cmdUserQuotaInfoShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var quotaInfo *proto.UserQuotaInfo
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if quotaInfo, err = client.UserAPI().GetQuota(args[0]); err != nil {
+				err = annotateError(err, "Get user quota failed: %v\n", err)
+				return
+			}
+			printUserQuotaInfo(quotaInfo)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newUserQuotaSetCmd(client *master.MasterClient) *cobra.Command {
+	var optCapacity uint64
+	var optVolCount uint64
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdUserQuotaSetUse,
+		Short: cmdUserQuotaSetShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var userID = args[0]
+			var userInfo *proto.UserInfo
+			var quotaInfo *proto.UserQuotaInfo
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !cmd.Flags().Changed(CliFlagCapacity) && !cmd.Flags().Changed(CliFlagVolCount) {
+				err = NewArgumentError("no update")
+				return
+			}
+			if userInfo, err = client.UserAPI().GetUserInfo(userID); err != nil {
+				err = annotateError(err, "Get user info failed: %v\n", err)
+				return
+			}
+			var quota = userInfo.Quota
+			if cmd.Flags().Changed(CliFlagCapacity) {
+				quota.Capacity = optCapacity
+			}
+			if cmd.Flags().Changed(CliFlagVolCount) {
+				quota.VolCount = optVolCount
+			}
+			if !optYes {
+				stdout("Set the quota of user [%v]\n", userID)
+				stdout("  Capacity    : %v -> %v\n", formatUserQuota(userInfo.Quota.Capacity, "GB"), formatUserQuota(quota.Capacity, "GB"))
+				stdout("  Volume count: %v -> %v\n", formatUserQuota(userInfo.Quota.VolCount, ""), formatUserQuota(quota.VolCount, ""))
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			var param = proto.UserQuotaSetParam{UserID: userID, Quota: quota}
+			if quotaInfo, err = client.UserAPI().SetQuota(&param); err != nil {
+				err = annotateError(err, "Set user quota failed: %v\n", err)
+				return
+			}
+			stdout("Set user quota success:\n")
+			printUserQuotaInfo(quotaInfo)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validUsers(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint64Var(&optCapacity, CliFlagCapacity, 0, "Specify the total capacity of the volumes owned by the user in GB, 0 means unlimited")
+	cmd.Flags().Uint64Var(&optVolCount, CliFlagVolCount, 0, "Specify the number of the volumes owned by the user, 0 means unlimited")
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func formatUserQuota(limit uint64, unit string) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%v%v", limit, unit)
+}
+
+func printUserQuotaInfo(quotaInfo *proto.UserQuotaInfo) {
+	if isStructuredOutput() {
+		if err := printStructured(quotaInfo); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("  User ID     : %v\n", quotaInfo.UserID)
+	stdout("  Capacity    : %vGB / %v\n", quotaInfo.UsedCapacity, formatUserQuota(quotaInfo.Quota.Capacity, "GB"))
+	stdout("  Volume count: %v / %v\n", quotaInfo.UsedVolCount, formatUserQuota(quotaInfo.Quota.VolCount, ""))
+}
+
 func printUserInfo(userInfo *proto.UserInfo) {
+	if isStructuredOutput() {
+		if err := printStructured(userInfo); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
 	stdout("[Summary]\n")
 	stdout("  User ID    : %v\n", userInfo.UserID)
 	stdout("  Access Key : %v\n", userInfo.AccessKey)
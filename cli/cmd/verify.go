@@ -0,0 +1,459 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultDataNodeProfPort = 17320
+	// the extents modified recently may be written by the clients, which are skipped by default
+	defaultVerifyIgnoreRecent = time.Minute
+)
+
+// replicaHttpAddr returns the address of the http service on the host of the replica.
+func replicaHttpAddr(addr string, port uint16) (httpAddr string, err error) {
+	var host string
+	if host, _, err = net.SplitHostPort(addr); err != nil {
+		return
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port))), nil
+}
+
+// dataReplicaExtents defines the extents of a data partition replica.
+type dataReplicaExtents struct {
+	Addr        string
+	ExtentCount int
+	Used        int
+	Error       string `json:",omitempty"`
+	extents     map[uint64]*storage.ExtentInfo
+}
+
+// extentMismatch defines an extent which is different among the replicas.
+type extentMismatch struct {
+	ExtentID uint64
+	Reason   string
+	Replicas map[string]string // the size and crc of the extent on each replica
+}
+
+// dataPartitionVerifyResult defines the result of verifying the replicas of a data partition.
+type dataPartitionVerifyResult struct {
+	PartitionID uint64
+	Replicas    []*dataReplicaExtents
+	Skipped     int // count of the extents skipped for modified recently
+	Mismatches  []*extentMismatch
+}
+
+func formatExtentWatermark(ei *storage.ExtentInfo) string {
+	if ei == nil {
+		return "missing"
+	}
+	return fmt.Sprintf("size %v crc %v", ei.Size, ei.Crc)
+}
+
+func newExtentMismatch(extentID uint64, reason string, replicas []*dataReplicaExtents) *extentMismatch {
+	mismatch := &extentMismatch{ExtentID: extentID, Reason: reason, Replicas: make(map[string]string)}
+	for _, replica := range replicas {
+		mismatch.Replicas[replica.Addr] = formatExtentWatermark(replica.extents[extentID])
+	}
+	return mismatch
+}
+
+// compareExtentBlocks compares the crc of each block of the extent among the replicas and returns
+// the first different block.
+func compareExtentBlocks(clients map[string]*api.DataHttpClient, replicas []*dataReplicaExtents, partitionID,
+	extentID uint64) (reason string, err error) {
+	var base []*storage.BlockCrc
+	for i, replica := range replicas {
+		var blocks []*storage.BlockCrc
+		if blocks, err = clients[replica.Addr].GetExtentBlockCrcs(partitionID, extentID); err != nil {
+			return
+		}
+		if i == 0 {
+			base = blocks
+			continue
+		}
+		if len(blocks) != len(base) {
+			return fmt.Sprintf("block count mismatch: %v on %v, %v on %v",
+				len(base), replicas[0].Addr, len(blocks), replica.Addr), nil
+		}
+		for j := range blocks {
+			if blocks[j].Crc != base[j].Crc {
+				return fmt.Sprintf("block %v crc mismatch between %v and %v",
+					blocks[j].BlockNo, replicas[0].Addr, replica.Addr), nil
+			}
+		}
+	}
+	return
+}
+
+// verifyDataPartition compares the extents of the replicas of the data partition. The extents missing on some
+// replicas, and the extents with different sizes or crc are reported. The crc of each block is compared too if
+// checkBlocks is true, which reads all the data of the extents.
+func verifyDataPartition(client *master.MasterClient, partitionID uint64, profPort uint16, ignoreRecent time.Duration,
+	checkBlocks bool) (result *dataPartitionVerifyResult, err error) {
+	var partition *proto.DataPartitionInfo
+	if partition, err = client.AdminAPI().GetDataPartition("", partitionID); err != nil {
+		return
+	}
+	result = &dataPartitionVerifyResult{PartitionID: partitionID, Mismatches: make([]*extentMismatch, 0)}
+	clients := make(map[string]*api.DataHttpClient)
+	for _, host := range partition.Hosts {
+		var httpAddr string
+		if httpAddr, err = replicaHttpAddr(host, profPort); err != nil {
+			return
+		}
+		clients[host] = api.NewDataHttpClient(httpAddr, false)
+		result.Replicas = append(result.Replicas, &dataReplicaExtents{Addr: host})
+	}
+
+	var wg sync.WaitGroup
+	for _, replica := range result.Replicas {
+		wg.Add(1)
+		go func(replica *dataReplicaExtents) {
+			defer wg.Done()
+			dp, getErr := clients[replica.Addr].GetPartition(partitionID)
+			if getErr != nil {
+				replica.Error = getErr.Error()
+				return
+			}
+			replica.Used = dp.Used
+			replica.ExtentCount = len(dp.Files)
+			replica.extents = make(map[uint64]*storage.ExtentInfo, len(dp.Files))
+			for _, ei := range dp.Files {
+				replica.extents[ei.FileID] = ei
+			}
+		}(replica)
+	}
+	wg.Wait()
+
+	var (
+		available []*dataReplicaExtents
+		extentIDs []uint64
+		seen      = make(map[uint64]bool)
+	)
+	for _, replica := range result.Replicas {
+		if replica.Error != "" {
+			continue
+		}
+		available = append(available, replica)
+		for extentID := range replica.extents {
+			if !seen[extentID] {
+				seen[extentID] = true
+				extentIDs = append(extentIDs, extentID)
+			}
+		}
+	}
+	if len(available) < 2 {
+		err = newDegradedError("less than 2 replicas of data partition %v are available to compare", partitionID)
+		return
+	}
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+
+	recent := time.Now().Add(-ignoreRecent).Unix()
+	for _, extentID := range extentIDs {
+		var (
+			missing  []string
+			sizes    = make(map[uint64]bool)
+			crcs     = make(map[uint32]bool)
+			modified bool
+		)
+		for _, replica := range available {
+			ei := replica.extents[extentID]
+			if ei == nil {
+				missing = append(missing, replica.Addr)
+				continue
+			}
+			if ei.ModifyTime > recent {
+				modified = true
+			}
+			sizes[ei.Size] = true
+			if ei.Crc != 0 {
+				crcs[ei.Crc] = true
+			}
+		}
+		if modified {
+			result.Skipped++
+			continue
+		}
+		var reason string
+		switch {
+		case len(missing) > 0:
+			reason = fmt.Sprintf("missing on %v", strings.Join(missing, ","))
+		case len(sizes) > 1:
+			reason = "size mismatch"
+		case !storage.IsTinyExtent(extentID) && len(crcs) > 1:
+			reason = "crc mismatch"
+		case checkBlocks && !storage.IsTinyExtent(extentID):
+			if reason, err = compareExtentBlocks(clients, available, partitionID, extentID); err != nil {
+				return
+			}
+		}
+		if reason != "" {
+			result.Mismatches = append(result.Mismatches, newExtentMismatch(extentID, reason, available))
+		}
+	}
+	return
+}
+
+func printDataPartitionVerifyResult(result *dataPartitionVerifyResult) {
+	stdout("[Replicas]\n")
+	replicaTablePattern := "%-22v    %-12v    %-12v    %v\n"
+	stdout(replicaTablePattern, "ADDRESS", "EXTENTS", "USED", "ERROR")
+	for _, replica := range result.Replicas {
+		stdout(replicaTablePattern, replica.Addr, replica.ExtentCount, formatSize(uint64(replica.Used)), replica.Error)
+	}
+	stdout("\n[Mismatched extents]\n")
+	mismatchTablePattern := "%-10v    %-40v    %v\n"
+	stdout(mismatchTablePattern, "EXTENT ID", "REASON", "REPLICAS")
+	for _, mismatch := range result.Mismatches {
+		addrs := make([]string, 0, len(mismatch.Replicas))
+		for addr := range mismatch.Replicas {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+		details := make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			details = append(details, fmt.Sprintf("%v(%v)", addr, mismatch.Replicas[addr]))
+		}
+		stdout(mismatchTablePattern, mismatch.ExtentID, mismatch.Reason, strings.Join(details, ", "))
+	}
+	stdout("\nMismatched extents: %v, skipped extents modified recently: %v\n", len(result.Mismatches), result.Skipped)
+}
+
+func newDataPartitionVerifyCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort     uint16
+		optIgnoreRecent time.Duration
+		optBlock        bool
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpVerify + " [DATA PARTITION ID]",
+		Short: cmdDataPartitionVerifyShort,
+		Long: `Fetch the extents of each replica from the http service of the data nodes, and report the extents which are
+missing on some replicas or have different sizes or crc. The extents modified recently are skipped, because they may
+be written by the clients. With the "--block" flag, the crc of each block of the extents are compared too, which reads
+all the data of the partition on each replica.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				result      *dataPartitionVerifyResult
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if result, err = verifyDataPartition(client, partitionID, optProfPort, optIgnoreRecent, optBlock); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(result)
+			} else {
+				printDataPartitionVerifyResult(result)
+			}
+			if err == nil && len(result.Mismatches) > 0 {
+				err = newDegradedError("data partition %v has %v mismatched extents", partitionID, len(result.Mismatches))
+			}
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultDataNodeProfPort, "Port of the http service of the data nodes")
+	cmd.Flags().DurationVar(&optIgnoreRecent, CliFlagIgnoreRecent, defaultVerifyIgnoreRecent, "Skip the extents modified within the duration")
+	cmd.Flags().BoolVar(&optBlock, CliFlagBlock, false, "Compare the crc of each block of the extents")
+	return cmd
+}
+
+const (
+	defaultMetaNodeProfPort = 17220
+	// the followers whose applied index lags behind the leader more than it are reported
+	defaultVerifyMaxApplyLag = 1000
+)
+
+// metaReplicaState defines the metadata state of a meta partition replica.
+type metaReplicaState struct {
+	Addr        string
+	IsLeader    bool
+	InodeCount  uint64
+	DentryCount uint64
+	MaxInodeID  uint64
+	ApplyID     uint64
+	Commit      uint64
+	Peers       []string
+	Issues      []string
+	Error       string `json:",omitempty"`
+}
+
+// metaPartitionVerifyResult defines the result of verifying the replicas of a meta partition.
+type metaPartitionVerifyResult struct {
+	PartitionID uint64
+	Hosts       []string
+	Replicas    []*metaReplicaState
+	IssueCount  int
+}
+
+// verifyMetaPartition compares the metadata of the replicas of the meta partition. The replicas whose applied
+// index lags behind the leader too much, and the replicas with the same applied index but different inode count,
+// dentry count or max inode ID are reported, as well as the replicas whose raft peers differ from the master.
+func verifyMetaPartition(client *master.MasterClient, partitionID uint64, profPort uint16,
+	maxApplyLag uint64) (result *metaPartitionVerifyResult, err error) {
+	var partition *proto.MetaPartitionInfo
+	if partition, err = client.ClientAPI().GetMetaPartition(partitionID); err != nil {
+		return
+	}
+	result = &metaPartitionVerifyResult{PartitionID: partitionID, Hosts: partition.Hosts}
+	var wg sync.WaitGroup
+	for _, host := range partition.Hosts {
+		replica := &metaReplicaState{Addr: host}
+		result.Replicas = append(result.Replicas, replica)
+		var httpAddr string
+		if httpAddr, err = replicaHttpAddr(host, profPort); err != nil {
+			return
+		}
+		wg.Add(1)
+		go func(replica *metaReplicaState, httpAddr string) {
+			defer wg.Done()
+			status, getErr := api.NewMetaHttpClient(httpAddr, false).GetMetaPartitionStatus(partitionID)
+			if getErr != nil {
+				replica.Error = getErr.Error()
+				return
+			}
+			replica.IsLeader = status.LeaderAddr == replica.Addr
+			replica.InodeCount = status.InodeCount
+			replica.DentryCount = status.DentryCount
+			replica.MaxInodeID = status.Cursor
+			replica.ApplyID = status.ApplyID
+			if status.RaftStatus != nil {
+				replica.Commit = status.RaftStatus.Commit
+			}
+			for _, peer := range status.Peers {
+				replica.Peers = append(replica.Peers, peer.Addr)
+			}
+			sort.Strings(replica.Peers)
+		}(replica, httpAddr)
+	}
+	wg.Wait()
+
+	hosts := append([]string{}, partition.Hosts...)
+	sort.Strings(hosts)
+	var leader *metaReplicaState
+	for _, replica := range result.Replicas {
+		if replica.Error == "" && replica.IsLeader {
+			leader = replica
+		}
+	}
+	byApplyID := make(map[uint64]*metaReplicaState)
+	for _, replica := range result.Replicas {
+		if replica.Error != "" {
+			replica.Issues = append(replica.Issues, "unreachable")
+			continue
+		}
+		if strings.Join(replica.Peers, ",") != strings.Join(hosts, ",") {
+			replica.Issues = append(replica.Issues, "raft peers differ from master")
+		}
+		if leader == nil {
+			replica.Issues = append(replica.Issues, "no leader")
+		} else if leader.ApplyID > replica.ApplyID && leader.ApplyID-replica.ApplyID > maxApplyLag {
+			replica.Issues = append(replica.Issues, fmt.Sprintf("applied index lags %v", leader.ApplyID-replica.ApplyID))
+		}
+		if base, ok := byApplyID[replica.ApplyID]; ok {
+			if base.InodeCount != replica.InodeCount || base.DentryCount != replica.DentryCount ||
+				base.MaxInodeID != replica.MaxInodeID {
+				replica.Issues = append(replica.Issues, fmt.Sprintf("metadata differs from %v at the same applied index", base.Addr))
+			}
+		} else {
+			byApplyID[replica.ApplyID] = replica
+		}
+	}
+	for _, replica := range result.Replicas {
+		result.IssueCount += len(replica.Issues)
+	}
+	return
+}
+
+func printMetaPartitionVerifyResult(result *metaPartitionVerifyResult) {
+	tablePattern := "%-22v    %-8v    %-12v    %-12v    %-12v    %-12v    %-12v    %v\n"
+	stdout(tablePattern, "ADDRESS", "LEADER", "INODE COUNT", "DENTRY COUNT", "MAX INODE", "APPLIED", "COMMIT", "ISSUES")
+	for _, replica := range result.Replicas {
+		issues := strings.Join(replica.Issues, "; ")
+		if replica.Error != "" {
+			issues = fmt.Sprintf("%v: %v", issues, replica.Error)
+		}
+		stdout(tablePattern, replica.Addr, formatYesNo(replica.IsLeader), replica.InodeCount, replica.DentryCount,
+			replica.MaxInodeID, replica.ApplyID, replica.Commit, issues)
+	}
+	stdout("\nIssues: %v\n", result.IssueCount)
+}
+
+func newMetaPartitionVerifyCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optProfPort    uint16
+		optMaxApplyLag uint64
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpVerify + " [META PARTITION ID]",
+		Short: cmdMetaPartitionVerifyShort,
+		Long: `Query the inode count, dentry count, max inode ID and raft applied index of each replica from the http service
+of the meta nodes. The replicas lagging behind the leader, the replicas with different metadata at the same applied index,
+and the replicas whose raft peers differ from the master are reported.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err         error
+				partitionID uint64
+				result      *metaPartitionVerifyResult
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if partitionID, err = strconv.ParseUint(args[0], 10, 64); err != nil {
+				return
+			}
+			if result, err = verifyMetaPartition(client, partitionID, optProfPort, optMaxApplyLag); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(result)
+			} else {
+				printMetaPartitionVerifyResult(result)
+			}
+			if err == nil && result.IssueCount > 0 {
+				err = newDegradedError("meta partition %v has %v issues", partitionID, result.IssueCount)
+			}
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	cmd.Flags().Uint64Var(&optMaxApplyLag, CliFlagMaxLag, defaultVerifyMaxApplyLag, "Max applied index lag of the followers")
+	return cmd
+}
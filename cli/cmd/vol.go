@@ -21,6 +21,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/sdk/master"
@@ -47,8 +48,15 @@ func newVolCmd(client *master.MasterClient) *cobra.Command {
 		newVolSetCmd(client),
 		newVolInfoCmd(client),
 		newVolDeleteCmd(client),
+		newVolRestoreCmd(client),
+		newVolRotateKeyCmd(client),
 		newVolTransferCmd(client),
 		newVolAddDPCmd(client),
+		newVolCloneCmd(client),
+		newVolSnapshotCmd(client),
+		newVolReplicationCmd(client),
+		newVolCapacityForecastCmd(client),
+		newVolDuCmd(client),
 	)
 	return cmd
 }
@@ -57,30 +65,61 @@ const (
 	cmdVolListShort = "List cluster volumes"
 )
 
+// the number of the volumes or the partitions got from master in a request
+const defaultListPageSize = 1000
+
 func newVolListCmd(client *master.MasterClient) *cobra.Command {
 	var optKeyword string
+	var optPageSize int
+	var optDeleted bool
 	var cmd = &cobra.Command{
 		Use:     CliOpList,
 		Short:   cmdVolListShort,
 		Aliases: []string{"ls"},
 		Run: func(cmd *cobra.Command, args []string) {
-			var vols []*proto.VolInfo
+			var vols = make([]*proto.VolInfo, 0)
 			var err error
 			defer func() {
 				if err != nil {
 					errout("Error: %v", err)
 				}
 			}()
-			if vols, err = client.AdminAPI().ListVols(optKeyword); err != nil {
+			if optDeleted {
+				if vols, err = client.AdminAPI().ListDeletedVols(optKeyword); err != nil {
+					return
+				}
+				if isStructuredOutput() {
+					err = printStructured(vols)
+					return
+				}
+				stdout("%v\n", deletedVolumeTableHeader)
+				for _, vol := range vols {
+					stdout("%v\n", formatDeletedVolTableRow(vol))
+				}
 				return
 			}
-			stdout("%v\n", volumeInfoTableHeader)
-			for _, vol := range vols {
-				stdout("%v\n", formatVolInfoTableRow(vol))
+			if !isStructuredOutput() {
+				stdout("%v\n", volumeInfoTableHeader)
+			}
+			// the volumes are printed page by page as they are listed
+			err = client.AdminAPI().RangeVols(optKeyword, optPageSize, func(page []*proto.VolInfo) error {
+				if isStructuredOutput() {
+					vols = append(vols, page...)
+					return nil
+				}
+				for _, vol := range page {
+					stdout("%v\n", formatVolInfoTableRow(vol))
+				}
+				return nil
+			})
+			if err == nil && isStructuredOutput() {
+				err = printStructured(vols)
 			}
 		},
 	}
 	cmd.Flags().StringVar(&optKeyword, "keyword", "", "Specify keyword of volume name to filter")
+	cmd.Flags().IntVar(&optPageSize, CliFlagPageSize, defaultListPageSize, "Number of the volumes got from master in a request")
+	cmd.Flags().BoolVar(&optDeleted, CliFlagDeleted, false, "List the deleted volumes which can be restored")
 	return cmd
 }
 
@@ -103,6 +142,7 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	var optFollowerRead bool
 	var optYes bool
 	var optZoneName string
+	var optCaseInsensitive bool
 	var cmd = &cobra.Command{
 		Use:   cmdVolCreateUse,
 		Short: cmdVolCreateShort,
@@ -127,6 +167,7 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 				stdout("  Replicas            : %v\n", optReplicas)
 				stdout("  Allow follower read : %v\n", formatEnabledDisabled(optFollowerRead))
 				stdout("  ZoneName            : %v\n", optZoneName)
+				stdout("  Case insensitive    : %v\n", formatEnabledDisabled(optCaseInsensitive))
 				stdout("\nConfirm (yes/no)[yes]: ")
 				var userConfirm string
 				_, _ = fmt.Scanln(&userConfirm)
@@ -138,9 +179,9 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 
 			err = client.AdminAPI().CreateVolume(
 				volumeName, userID, optMPCount, optDPSize,
-				optCapacity, optReplicas, optFollowerRead, optZoneName)
+				optCapacity, optReplicas, optFollowerRead, optZoneName, optCaseInsensitive)
 			if err != nil {
-				err = fmt.Errorf("Create volume failed case:\n%v\n", err)
+				err = annotateError(err, "Create volume failed case:\n%v\n", err)
 				return
 			}
 			stdout("Create volume success.\n")
@@ -153,6 +194,8 @@ func newVolCreateCmd(client *master.MasterClient) *cobra.Command {
 	cmd.Flags().IntVar(&optReplicas, CliFlagReplicas, cmdVolDefaultReplicas, "Specify data partition replicas number")
 	cmd.Flags().BoolVar(&optFollowerRead, CliFlagEnableFollowerRead, cmdVolDefaultFollowerReader, "Enable read form replica follower")
 	cmd.Flags().StringVar(&optZoneName, CliFlagZoneName, cmdVolDefaultZoneName, "Specify volume zone name")
+	cmd.Flags().BoolVar(&optCaseInsensitive, CliFlagCaseInsensitive, false,
+		"Look up the file names case-insensitively but preserve them, which can not be changed later")
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
@@ -166,6 +209,18 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 	var optAuthenticate string
 	var optEnableToken string
 	var optZoneName string
+	var optPlacement string
+	var optPlacementZone string
+	var optPlacementLabels string
+	var optQos proto.VolQos
+	var optTrashTTL time.Duration
+	var optMetaStore string
+	var optInodeRetention time.Duration
+	var optIPAllow []string
+	var optIPDeny []string
+	var optVerifyReadCrc string
+	var optCompression string
+	var optEncrypted string
 	var optYes bool
 	var confirmString = strings.Builder{}
 	var vv *proto.SimpleVolView
@@ -243,7 +298,128 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 				confirmString.WriteString(fmt.Sprintf("  ZoneName            : %v\n", vv.ZoneName))
 			}
 			if vv.CrossZone == true && "" != optZoneName {
-				err = fmt.Errorf("Can not set zone name of the volume that cross zone\n")
+				err = NewArgumentError("Can not set zone name of the volume that cross zone\n")
+			}
+			var isPlacementChange = optPlacement != ""
+			if isPlacementChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Placement policy    : %v -> %v\n",
+					formatPlacementPolicy(vv.PlacementPolicy, vv.PlacementZone), formatPlacementPolicy(optPlacement, optPlacementZone)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Placement policy    : %v\n", formatPlacementPolicy(vv.PlacementPolicy, vv.PlacementZone)))
+				if optPlacementZone != "" {
+					err = NewArgumentError("--%v is required by --%v\n", CliFlagPlacementPolicy, CliFlagPlacementZone)
+				}
+			}
+			var newLabels = vv.PlacementLabels
+			if cmd.Flags().Changed(CliFlagPlacementLabels) {
+				var labels map[string]string
+				if labels, err = proto.ParseLabels(optPlacementLabels); err != nil {
+					err = NewArgumentError("%v", err)
+					return
+				}
+				newLabels = labels
+			}
+			var isLabelsChange = proto.FormatLabels(newLabels) != proto.FormatLabels(vv.PlacementLabels)
+			if isLabelsChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Placement labels    : %v -> %v\n", formatLabels(vv.PlacementLabels), formatLabels(newLabels)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Placement labels    : %v\n", formatLabels(vv.PlacementLabels)))
+			}
+			var newQos = vv.Qos
+			for flag, limit := range map[string]struct{ opt, new *uint64 }{
+				CliFlagReadIops:       {&optQos.ReadIops, &newQos.ReadIops},
+				CliFlagWriteIops:      {&optQos.WriteIops, &newQos.WriteIops},
+				CliFlagReadBandwidth:  {&optQos.ReadBps, &newQos.ReadBps},
+				CliFlagWriteBandwidth: {&optQos.WriteBps, &newQos.WriteBps},
+			} {
+				if cmd.Flags().Changed(flag) {
+					*limit.new = *limit.opt
+				}
+			}
+			var isQosChange = newQos != vv.Qos
+			if isQosChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  QoS                 : %v -> %v\n", formatVolQos(vv.Qos), formatVolQos(newQos)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  QoS                 : %v\n", formatVolQos(vv.Qos)))
+			}
+			var newIPAcl = vv.IPAcl
+			if cmd.Flags().Changed(CliFlagIPAllow) {
+				newIPAcl.Allow = optIPAllow
+			}
+			if cmd.Flags().Changed(CliFlagIPDeny) {
+				newIPAcl.Deny = optIPDeny
+			}
+			var isIPAclChange = formatVolIPAcl(newIPAcl) != formatVolIPAcl(vv.IPAcl)
+			if isIPAclChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Client IPs          : %v -> %v\n", formatVolIPAcl(vv.IPAcl), formatVolIPAcl(newIPAcl)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Client IPs          : %v\n", formatVolIPAcl(vv.IPAcl)))
+			}
+			var newTrashTTL = uint64(optTrashTTL / time.Second)
+			var isTrashChange = cmd.Flags().Changed(CliFlagTrashTTL) && newTrashTTL != vv.TrashTTL
+			if isTrashChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Trash               : %v -> %v\n", formatTrashTTL(vv.TrashTTL), formatTrashTTL(newTrashTTL)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Trash               : %v\n", formatTrashTTL(vv.TrashTTL)))
+			}
+			var isMetaStoreChange = cmd.Flags().Changed(CliFlagMetaStore) && optMetaStore != vv.MetaStore
+			if isMetaStoreChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Meta store          : %v -> %v\n", formatMetaStore(vv.MetaStore), formatMetaStore(optMetaStore)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Meta store          : %v\n", formatMetaStore(vv.MetaStore)))
+			}
+			var newInodeRetention = uint64(optInodeRetention / time.Second)
+			var isInodeRetentionChange = cmd.Flags().Changed(CliFlagInodeRetention) && newInodeRetention != vv.InodeRetention
+			if isInodeRetentionChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Inode retention     : %v -> %v\n", formatInodeRetention(vv.InodeRetention), formatInodeRetention(newInodeRetention)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Inode retention     : %v\n", formatInodeRetention(vv.InodeRetention)))
+			}
+			var newVerifyReadCrc = vv.VerifyReadCrc
+			if optVerifyReadCrc != "" {
+				if newVerifyReadCrc, err = strconv.ParseBool(optVerifyReadCrc); err != nil {
+					return
+				}
+			}
+			var isVerifyReadCrcChange = newVerifyReadCrc != vv.VerifyReadCrc
+			if isVerifyReadCrcChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Verify read crc     : %v -> %v\n", formatEnabledDisabled(vv.VerifyReadCrc), formatEnabledDisabled(newVerifyReadCrc)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Verify read crc     : %v\n", formatEnabledDisabled(vv.VerifyReadCrc)))
+			}
+			var newCompression = vv.Compression
+			if cmd.Flags().Changed(CliFlagCompression) {
+				if newCompression = optCompression; newCompression == "none" {
+					newCompression = ""
+				}
+			}
+			var isCompressionChange = newCompression != vv.Compression
+			if isCompressionChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Compression         : %v -> %v\n", formatCompression(vv.Compression), formatCompression(newCompression)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Compression         : %v\n", formatCompression(vv.Compression)))
+			}
+			var newEncrypted = vv.Encrypted
+			if optEncrypted != "" {
+				if newEncrypted, err = strconv.ParseBool(optEncrypted); err != nil {
+					return
+				}
+			}
+			var isEncryptedChange = newEncrypted != vv.Encrypted
+			if isEncryptedChange {
+				isChange = true
+				confirmString.WriteString(fmt.Sprintf("  Encryption          : %v -> %v\n", formatEncryption(vv.Encrypted, vv.KeyVersion), formatEnabledDisabled(newEncrypted)))
+			} else {
+				confirmString.WriteString(fmt.Sprintf("  Encryption          : %v\n", formatEncryption(vv.Encrypted, vv.KeyVersion)))
 			}
 			if err != nil {
 				return
@@ -268,6 +444,56 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 			if err != nil {
 				return
 			}
+			if isPlacementChange {
+				if err = client.AdminAPI().SetVolumePlacement(vv.Name, calcAuthKey(vv.Owner), optPlacement, optPlacementZone); err != nil {
+					return
+				}
+			}
+			if isLabelsChange {
+				if err = client.AdminAPI().SetVolumePlacementLabels(vv.Name, calcAuthKey(vv.Owner), newLabels); err != nil {
+					return
+				}
+			}
+			if isQosChange {
+				if err = client.AdminAPI().SetVolumeQos(vv.Name, calcAuthKey(vv.Owner), newQos); err != nil {
+					return
+				}
+			}
+			if isIPAclChange {
+				if err = client.AdminAPI().SetVolumeIPAcl(vv.Name, calcAuthKey(vv.Owner), newIPAcl); err != nil {
+					return
+				}
+			}
+			if isTrashChange {
+				if err = client.AdminAPI().SetVolumeTrashTTL(vv.Name, calcAuthKey(vv.Owner), newTrashTTL); err != nil {
+					return
+				}
+			}
+			if isMetaStoreChange {
+				if err = client.AdminAPI().SetVolumeMetaStore(vv.Name, calcAuthKey(vv.Owner), optMetaStore); err != nil {
+					return
+				}
+			}
+			if isInodeRetentionChange {
+				if err = client.AdminAPI().SetVolumeInodeRetention(vv.Name, calcAuthKey(vv.Owner), newInodeRetention); err != nil {
+					return
+				}
+			}
+			if isVerifyReadCrcChange {
+				if err = client.AdminAPI().SetVolumeVerifyReadCrc(vv.Name, calcAuthKey(vv.Owner), newVerifyReadCrc); err != nil {
+					return
+				}
+			}
+			if isCompressionChange {
+				if err = client.AdminAPI().SetVolumeCompression(vv.Name, calcAuthKey(vv.Owner), newCompression); err != nil {
+					return
+				}
+			}
+			if isEncryptedChange {
+				if err = client.AdminAPI().SetVolumeEncrypted(vv.Name, calcAuthKey(vv.Owner), newEncrypted); err != nil {
+					return
+				}
+			}
 			stdout("Volume configuration has been set successfully.\n")
 			return
 		},
@@ -279,11 +505,26 @@ func newVolSetCmd(client *master.MasterClient) *cobra.Command {
 		},
 	}
 	cmd.Flags().Uint64Var(&optCapacity, CliFlagCapacity, 0, "Specify volume capacity [Unit: GB]")
-	cmd.Flags().IntVar(&optReplicas, CliFlagReplicas, 0, "Specify data partition replicas number")
+	cmd.Flags().IntVar(&optReplicas, CliFlagReplicas, 0, "Specify data partition replicas number [2|3], the replicas are added or removed in the background")
 	cmd.Flags().StringVar(&optFollowerRead, CliFlagEnableFollowerRead, "", "Enable read form replica follower")
 	cmd.Flags().StringVar(&optAuthenticate, CliFlagAuthenticate, "", "Enable authenticate")
 	cmd.Flags().StringVar(&optEnableToken, CliFlagEnableToken, "", "ReadOnly/ReadWrite token validation for fuse client")
 	cmd.Flags().StringVar(&optZoneName, CliFlagZoneName, "", "Specify volume zone name")
+	cmd.Flags().StringVar(&optPlacement, CliFlagPlacementPolicy, "", "Specify replica placement policy [default|zone-spread|zone-pinned|rack-diverse|capacity-weighted|round-robin]")
+	cmd.Flags().StringVar(&optPlacementZone, CliFlagPlacementZone, "", "Specify the zone of the zone-pinned placement policy")
+	cmd.Flags().StringVar(&optPlacementLabels, CliFlagPlacementLabels, "", "Specify the comma separated key=value labels the nodes of the new replicas must have, empty to remove the constraints")
+	cmd.Flags().Uint64Var(&optQos.ReadIops, CliFlagReadIops, 0, "Specify read IOPS limit, 0 for unlimited")
+	cmd.Flags().Uint64Var(&optQos.WriteIops, CliFlagWriteIops, 0, "Specify write IOPS limit, 0 for unlimited")
+	cmd.Flags().Uint64Var(&optQos.ReadBps, CliFlagReadBandwidth, 0, "Specify read bandwidth limit, 0 for unlimited [Unit: byte/s]")
+	cmd.Flags().Uint64Var(&optQos.WriteBps, CliFlagWriteBandwidth, 0, "Specify write bandwidth limit, 0 for unlimited [Unit: byte/s]")
+	cmd.Flags().DurationVar(&optTrashTTL, CliFlagTrashTTL, 0, "Specify how long the removed files are kept in the trash, 0 to disable the trash")
+	cmd.Flags().StringVar(&optMetaStore, CliFlagMetaStore, "", "Specify the store of the new meta partitions [memory|rocksdb], empty for the default of the meta nodes")
+	cmd.Flags().DurationVar(&optInodeRetention, CliFlagInodeRetention, 0, "Specify how long the deleted files are kept before their data is purged, 0 for the default of the meta nodes")
+	cmd.Flags().StringSliceVar(&optIPAllow, CliFlagIPAllow, nil, "Specify the comma separated CIDRs of the clients allowed to access the volume, empty to allow all")
+	cmd.Flags().StringSliceVar(&optIPDeny, CliFlagIPDeny, nil, "Specify the comma separated CIDRs of the clients denied to access the volume, empty to deny none")
+	cmd.Flags().StringVar(&optVerifyReadCrc, CliFlagVerifyReadCrc, "", "Check the data read against the crc of the extent blocks, and read it from another replica if it is corrupt")
+	cmd.Flags().StringVar(&optCompression, CliFlagCompression, "", "Specify the algorithm to compress the cold extents at rest with [lz4|deflate|none]")
+	cmd.Flags().StringVar(&optEncrypted, CliFlagEncrypted, "", "Encrypt the extents at rest with the data key of the volume, it can not be disabled once enabled")
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
@@ -297,6 +538,7 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 	var (
 		optMetaDetail bool
 		optDataDetail bool
+		optPageSize   int
 	)
 
 	var cmd = &cobra.Command{
@@ -313,43 +555,73 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 				}
 			}()
 			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
-				err = fmt.Errorf("Get volume info failed:\n%v\n", err)
+				err = annotateError(err, "Get volume info failed:\n%v\n", err)
 				return
 			}
+			var volInfo = struct {
+				Summary        *proto.SimpleVolView
+				MetaPartitions []*proto.MetaPartitionView     `json:",omitempty"`
+				DataPartitions []*proto.DataPartitionResponse `json:",omitempty"`
+			}{Summary: svv}
+			defer func() {
+				if err == nil && isStructuredOutput() {
+					err = printStructured(volInfo)
+				}
+			}()
 			// print summary info
-			stdout("Summary:\n%s\n", formatSimpleVolView(svv))
+			if !isStructuredOutput() {
+				stdout("Summary:\n%s\n", formatSimpleVolView(svv))
+			}
 
 			// print metadata detail
 			if optMetaDetail {
-				var views []*proto.MetaPartitionView
-				if views, err = client.ClientAPI().GetMetaPartitions(volumeName); err != nil {
-					err = fmt.Errorf("Get volume metadata detail information failed:\n%v\n", err)
-					return
+				if !isStructuredOutput() {
+					stdout("Meta partitions:\n")
+					stdout("%v\n", metaPartitionTableHeader)
 				}
-				stdout("Meta partitions:\n")
-				stdout("%v\n", metaPartitionTableHeader)
-				sort.SliceStable(views, func(i, j int) bool {
-					return views[i].PartitionID < views[j].PartitionID
+				volInfo.MetaPartitions = make([]*proto.MetaPartitionView, 0)
+				err = client.ClientAPI().RangeMetaPartitions(volumeName, optPageSize, func(views []*proto.MetaPartitionView) error {
+					sort.SliceStable(views, func(i, j int) bool {
+						return views[i].PartitionID < views[j].PartitionID
+					})
+					if isStructuredOutput() {
+						volInfo.MetaPartitions = append(volInfo.MetaPartitions, views...)
+						return nil
+					}
+					for _, view := range views {
+						stdout("%v\n", formatMetaPartitionTableRow(view))
+					}
+					return nil
 				})
-				for _, view := range views {
-					stdout("%v\n", formatMetaPartitionTableRow(view))
+				if err != nil {
+					err = annotateError(err, "Get volume metadata detail information failed:\n%v\n", err)
+					return
 				}
 			}
 
 			// print data detail
 			if optDataDetail {
-				var view *proto.DataPartitionsView
-				if view, err = client.ClientAPI().GetDataPartitions(volumeName); err != nil {
-					err = fmt.Errorf("Get volume data detail information failed:\n%v\n", err)
-					return
+				if !isStructuredOutput() {
+					stdout("Data partitions:\n")
+					stdout("%v\n", dataPartitionTableHeader)
 				}
-				stdout("Data partitions:\n")
-				stdout("%v\n", dataPartitionTableHeader)
-				sort.SliceStable(view.DataPartitions, func(i, j int) bool {
-					return view.DataPartitions[i].PartitionID < view.DataPartitions[j].PartitionID
+				volInfo.DataPartitions = make([]*proto.DataPartitionResponse, 0)
+				err = client.ClientAPI().RangeDataPartitions(volumeName, optPageSize, func(partitions []*proto.DataPartitionResponse) error {
+					sort.SliceStable(partitions, func(i, j int) bool {
+						return partitions[i].PartitionID < partitions[j].PartitionID
+					})
+					if isStructuredOutput() {
+						volInfo.DataPartitions = append(volInfo.DataPartitions, partitions...)
+						return nil
+					}
+					for _, dp := range partitions {
+						stdout("%v\n", formatDataPartitionTableRow(dp))
+					}
+					return nil
 				})
-				for _, dp := range view.DataPartitions {
-					stdout("%v\n", formatDataPartitionTableRow(dp))
+				if err != nil {
+					err = annotateError(err, "Get volume data detail information failed:\n%v\n", err)
+					return
 				}
 			}
 			return
@@ -363,6 +635,109 @@ func newVolInfoCmd(client *master.MasterClient) *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&optMetaDetail, "meta-partition", "m", false, "Display meta partition detail information")
 	cmd.Flags().BoolVarP(&optDataDetail, "data-partition", "d", false, "Display data partition detail information")
+	cmd.Flags().IntVar(&optPageSize, CliFlagPageSize, defaultListPageSize, "Number of the partitions got from master in a request")
+	return cmd
+}
+
+const (
+	cmdVolRestoreUse   = CliOpRestore + " [VOLUME NAME]"
+	cmdVolRestoreShort = "Restore a deleted volume within the retention window"
+)
+
+func newVolRestoreCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolRestoreUse,
+		Short: cmdVolRestoreShort,
+		Long: `Restore the volume deleted within the retention window, which is set by "cluster vol-deletion-delay".
+The volume gets back to its owner, but the permissions granted to the other users are not restored.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				err = annotateError(err, "Restore volume failed:\n%v\n", err)
+				return
+			}
+			if !optYes {
+				stdout("Restore volume [%v] of owner [%v] (yes/no)[no]:", volumeName, svv.Owner)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().RestoreVolume(volumeName, calcAuthKey(svv.Owner)); err != nil {
+				err = annotateError(err, "Restore volume failed:\n%v\n", err)
+				return
+			}
+			stdout("Restore volume success.\n")
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+const (
+	cmdVolRotateKeyUse   = CliOpRotateKey + " [VOLUME NAME]"
+	cmdVolRotateKeyShort = "Rotate the data key of an encrypted volume"
+)
+
+func newVolRotateKeyCmd(client *master.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   cmdVolRotateKeyUse,
+		Short: cmdVolRotateKeyShort,
+		Long: `Generate a new data key of the encrypted volume. The new extents are encrypted by the new key, and the
+data nodes encrypt the existing extents again by it in the background, the former keys are kept to read them.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var volumeName = args[0]
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			var svv *proto.SimpleVolView
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+				err = annotateError(err, "Rotate volume key failed:\n%v\n", err)
+				return
+			}
+			if !svv.Encrypted {
+				err = fmt.Errorf("volume [%v] is not encrypted", volumeName)
+				return
+			}
+			if !optYes {
+				stdout("Rotate the data key v%v of volume [%v] (yes/no)[no]:", svv.KeyVersion, volumeName)
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if err = client.AdminAPI().RotateVolumeKey(volumeName, calcAuthKey(svv.Owner)); err != nil {
+				err = annotateError(err, "Rotate volume key failed:\n%v\n", err)
+				return
+			}
+			stdout("Rotate volume key success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
 	return cmd
 }
 
@@ -373,7 +748,8 @@ const (
 
 func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 	var (
-		optYes bool
+		optYes    bool
+		optDryRun bool
 	)
 	var cmd = &cobra.Command{
 		Use:   cmdVolDeleteUse,
@@ -387,6 +763,14 @@ func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 					errout("Error: %v", err)
 				}
 			}()
+			if optDryRun {
+				var svv *proto.SimpleVolView
+				if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
+					return
+				}
+				err = printVolumeDeletePlan(svv)
+				return
+			}
 			// ask user for confirm
 			if !optYes {
 				stdout("Delete volume [%v] (yes/no)[no]:", volumeName)
@@ -400,12 +784,12 @@ func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 
 			var svv *proto.SimpleVolView
 			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(volumeName); err != nil {
-				err = fmt.Errorf("Delete volume failed:\n%v\n", err)
+				err = annotateError(err, "Delete volume failed:\n%v\n", err)
 				return
 			}
 
 			if err = client.AdminAPI().DeleteVolume(volumeName, calcAuthKey(svv.Owner)); err != nil {
-				err = fmt.Errorf("Delete volume failed:\n%v\n", err)
+				err = annotateError(err, "Delete volume failed:\n%v\n", err)
 				return
 			}
 			stdout("Delete volume success.\n")
@@ -418,11 +802,12 @@ func newVolDeleteCmd(client *master.MasterClient) *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	cmd.Flags().BoolVar(&optDryRun, CliFlagDryRun, false, dryRunFlagUsage)
 	return cmd
 }
 
 const (
-	cmdVolTransferUse   = "transfer [VOLUME NAME] [USER ID]"
+	cmdVolTransferUse   = CliOpTransfer + " [VOLUME NAME] [USER ID]"
 	cmdVolTransferShort = "Transfer volume to another user. (Change owner of volume)"
 )
 
@@ -486,7 +871,7 @@ func newVolTransferCmd(client *master.MasterClient) *cobra.Command {
 }
 
 const (
-	cmdVolAddDPCmdUse   = "add-dp [VOLUME] [NUMBER]"
+	cmdVolAddDPCmdUse   = CliOpAddDataPartition + " [VOLUME] [NUMBER]"
 	cmdVolAddDPCmdShort = "Create and add more data partition to a volume"
 )
 
@@ -509,7 +894,7 @@ func newVolAddDPCmd(client *master.MasterClient) *cobra.Command {
 				return
 			}
 			if count < 1 {
-				err = fmt.Errorf("number must be larger than 0")
+				err = NewArgumentError("number must be larger than 0")
 				return
 			}
 			if err = client.AdminAPI().CreateDataPartition(volume, int(count)); err != nil {
@@ -577,6 +962,80 @@ func newVolSetCapacityCmd(use, short string, r clientHandler) *cobra.Command {
 	return cmd
 }
 
+const (
+	cmdVolCloneUse   = CliOpClone + " [SOURCE VOLUME] [TARGET VOLUME]"
+	cmdVolCloneShort = "Create a volume with a copy of the files of another volume"
+)
+
+func newVolCloneCmd(client *master.MasterClient) *cobra.Command {
+	var (
+		optOwner    string
+		optAsync    bool
+		optInterval time.Duration
+		optTimeout  time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   cmdVolCloneUse,
+		Short: cmdVolCloneShort,
+		Long: `Create the target volume with the settings of the source volume, and copy the directory tree, the
+attributes and the file data of the source volume into it, for example to refresh a test environment from a
+production volume. The copy is executed by master in background, and the command waits for it to finish with the
+progress printed. With the "--async" flag, the command returns the task ID immediately. The source volume should
+not be written during the copy, otherwise the files being written may be copied partially.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err  error
+				task *proto.AsyncTaskInfo
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			srcName, dstName := args[0], args[1]
+			if optInterval <= 0 {
+				err = NewArgumentError("invalid interval [%v]", optInterval)
+				return
+			}
+			if task, err = client.AdminAPI().CloneVolume(srcName, dstName, optOwner); err != nil {
+				return
+			}
+			if optAsync {
+				if isStructuredOutput() {
+					err = printStructured(task)
+					return
+				}
+				stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", task.ID, task.ID, task.ID)
+				return
+			}
+			if task, err = waitAsyncTask(client, task.ID, optInterval, optTimeout, newTaskProgressPrinter()); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(task)
+			} else {
+				stdout("[Task]\n")
+				stdout("%v", formatAsyncTaskInfo(task))
+			}
+			if err == nil && task.Status == proto.AsyncTaskFailed {
+				err = fmt.Errorf("task %v failed", task.ID)
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optOwner, CliFlagOwner, "", "Owner of the target volume, defaults to the owner of the source volume")
+	cmd.Flags().BoolVar(&optAsync, CliFlagAsync, false, "Return the task ID without waiting for the copy to finish")
+	cmd.Flags().DurationVar(&optInterval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the task status")
+	cmd.Flags().DurationVar(&optTimeout, CliFlagTimeout, 0, "Maximum time to wait, 0 means no limit")
+	return cmd
+}
+
 func calcAuthKey(key string) (authKey string) {
 	h := md5.New()
 	_, _ = h.Write([]byte(key))
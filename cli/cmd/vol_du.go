@@ -0,0 +1,149 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/cli/api"
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolDuShort = "Show the summary of a directory of the volume"
+)
+
+// dirSummaryView defines the summary of a directory shown by the du command.
+type dirSummaryView struct {
+	Path    string
+	Inode   uint64
+	Summary *proto.DirSummary
+}
+
+// volMetaResolver finds the leaders of the meta partitions of the inodes of a volume.
+type volMetaResolver struct {
+	client   *sdk.MasterClient
+	views    []*proto.MetaPartitionView
+	profPort uint16
+}
+
+func newVolMetaResolver(client *sdk.MasterClient, volName string, profPort uint16) (r *volMetaResolver, err error) {
+	r = &volMetaResolver{client: client, profPort: profPort}
+	if r.views, err = client.ClientAPI().GetMetaPartitions(volName); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// leader returns the meta partition of the inode and the http client of its leader.
+func (r *volMetaResolver) leader(ino uint64) (pid uint64, mc *api.MetaHttpClient, err error) {
+	for _, view := range r.views {
+		if view.Start > ino || ino > view.End {
+			continue
+		}
+		var partition *proto.MetaPartitionInfo
+		if partition, err = r.client.ClientAPI().GetMetaPartition(view.PartitionID); err != nil {
+			return
+		}
+		var httpAddr string
+		if httpAddr, err = leaderHttpAddr(partition, r.profPort); err != nil {
+			return
+		}
+		return partition.PartitionID, api.NewMetaHttpClient(httpAddr, false), nil
+	}
+	return 0, nil, fmt.Errorf("inode[%v] is out of the range of the meta partitions", ino)
+}
+
+// lookupPath resolves the inode of the directory by the dentries from the root of the volume.
+func (r *volMetaResolver) lookupPath(dirPath string) (ino uint64, err error) {
+	ino = proto.RootIno
+	for _, name := range strings.Split(dirPath, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		var (
+			pid    uint64
+			mc     *api.MetaHttpClient
+			dentry *proto.LookupResponse
+		)
+		if pid, mc, err = r.leader(ino); err != nil {
+			return
+		}
+		if dentry, err = mc.LookupDentry(pid, ino, name); err != nil {
+			return 0, fmt.Errorf("lookup [%v] in inode[%v]: %v", name, ino, err)
+		}
+		if !proto.IsDir(dentry.Mode) {
+			return 0, fmt.Errorf("[%v] is not a directory", name)
+		}
+		ino = dentry.Inode
+	}
+	return
+}
+
+func newVolDuCmd(client *sdk.MasterClient) *cobra.Command {
+	var optProfPort uint16
+	var cmd = &cobra.Command{
+		Use:   CliOpDu + " [VOLUME] [PATH]",
+		Short: cmdVolDuShort,
+		Long: `Show the total bytes, the files and the subdirectories of the directory and its subtree, which are read
+from the summary kept by the meta partition of the directory without walking the subtree. The summaries are
+maintained by the clients mounted with enableSummary, and they are eventually consistent.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err      error
+				volName  = args[0]
+				view     = &dirSummaryView{Path: args[1]}
+				resolver *volMetaResolver
+				pid      uint64
+				mc       *api.MetaHttpClient
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if resolver, err = newVolMetaResolver(client, volName, optProfPort); err != nil {
+				return
+			}
+			if view.Inode, err = resolver.lookupPath(view.Path); err != nil {
+				return
+			}
+			if pid, mc, err = resolver.leader(view.Inode); err != nil {
+				return
+			}
+			if view.Summary, err = mc.GetSummary(pid, view.Inode); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			stdout("[Summary of directory %v (inode %v) in volume %v]\n", view.Path, view.Inode, volName)
+			stdout("%v", formatDirSummary(view.Summary))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().Uint16Var(&optProfPort, CliFlagProfPort, defaultMetaNodeProfPort, "Port of the http service of the meta nodes")
+	return cmd
+}
@@ -0,0 +1,353 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolReplicationUse   = CliOpReplication + " [COMMAND]"
+	cmdVolReplicationShort = "Manage the replication of volumes to standby clusters"
+)
+
+func newVolReplicationCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolReplicationUse,
+		Short: cmdVolReplicationShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newVolReplicationSetCmd(client),
+		newVolReplicationInfoCmd(client),
+		newVolReplicationListCmd(client),
+		newVolReplicationDeleteCmd(client),
+		newVolReplicationFailoverCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdVolReplicationSetShort      = "Replicate the volume to a volume of a standby cluster"
+	cmdVolReplicationInfoShort     = "Show the replication of the volume and its lag"
+	cmdVolReplicationListShort     = "List the replications of the volumes"
+	cmdVolReplicationDeleteShort   = "Stop replicating the volume and remove the replication"
+	cmdVolReplicationFailoverShort = "Fail over the volume to its standby volume"
+)
+
+func newVolReplicationSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var (
+		optTargetMasters []string
+		optMaxLag        time.Duration
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [VOLUME] [TARGET VOLUME]",
+		Short: cmdVolReplicationSetShort,
+		Long: `Replicate the volume to the volume of the standby cluster asynchronously. The replicators of the cluster
+mirror the files of the volume to the standby volume pass by pass, and the master alarms if the standby volume lags
+behind more than the max lag. The standby volume should be created in the standby cluster beforehand, and should
+not be written by others while it is replicated.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				svv  *proto.SimpleVolView
+				view *proto.VolReplicationView
+				err  error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if len(optTargetMasters) == 0 {
+				err = NewArgumentError("the masters of the standby cluster are required by --%v", CliFlagTargetMaster)
+				return
+			}
+			if optMaxLag < 0 {
+				err = NewArgumentError("invalid max lag [%v]", optMaxLag)
+				return
+			}
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(args[0]); err != nil {
+				return
+			}
+			if view, err = client.AdminAPI().SetVolReplication(args[0], calcAuthKey(svv.Owner), optTargetMasters,
+				args[1], int64(optMaxLag/time.Second)); err != nil {
+				err = annotateError(err, "Set volume replication failed: %v\n", err)
+				return
+			}
+			printVolReplication("Set volume replication success", view)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringSliceVar(&optTargetMasters, CliFlagTargetMaster, nil, "Addresses of the masters of the standby cluster")
+	cmd.Flags().DurationVar(&optMaxLag, CliFlagMaxLag, time.Hour, "Alarm if the standby volume lags behind more than it, 0 to disable the alarm")
+	return cmd
+}
+
+func newVolReplicationInfoCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   CliOpInfo + " [VOLUME]",
+		Short: cmdVolReplicationInfoShort,
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				view *proto.VolReplicationView
+				err  error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.AdminAPI().GetVolReplication(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(view)
+				return
+			}
+			if view.Replication == nil {
+				stdout("Volume [%v] is not replicated\n", view.VolName)
+				return
+			}
+			stdout("%v", formatVolReplication(view))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newVolReplicationListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList,
+		Short:   cmdVolReplicationListShort,
+		Aliases: []string{"ls"},
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				views []*proto.VolReplicationView
+				err   error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if views, err = client.AdminAPI().ListVolReplications(); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(views)
+				return
+			}
+			stdout("%v\n", formatVolReplicationTableHeader())
+			for _, view := range views {
+				stdout("%v\n", formatVolReplicationTableRow(view))
+			}
+		},
+	}
+	return cmd
+}
+
+func newVolReplicationDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [VOLUME]",
+		Short: cmdVolReplicationDeleteShort,
+		Long: `Stop replicating the volume and remove the replication. The standby volume keeps the files replicated,
+and is replicated from scratch if the replication is set again.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				svv *proto.SimpleVolView
+				err error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if !optYes {
+				stdout("Stop replicating volume [%v] and remove the replication\n", args[0])
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(args[0]); err != nil {
+				return
+			}
+			if _, err = client.AdminAPI().SetVolReplication(args[0], calcAuthKey(svv.Owner), nil, "", 0); err != nil {
+				err = annotateError(err, "Delete volume replication failed: %v\n", err)
+				return
+			}
+			stdout("Delete volume replication success.\n")
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newVolReplicationFailoverCmd(client *sdk.MasterClient) *cobra.Command {
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpFailover + " [VOLUME]",
+		Short: cmdVolReplicationFailoverShort,
+		Long: `Stop replicating the volume, and the standby volume becomes the primary. The changes not replicated yet,
+which are made after the last sync time of the replication, are not in the standby volume. Stop writing the volume
+before the failover if the cluster is still available, and mount the standby volume on the clients after it.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				svv  *proto.SimpleVolView
+				view *proto.VolReplicationView
+				err  error
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if view, err = client.AdminAPI().GetVolReplication(args[0]); err != nil {
+				return
+			}
+			if !view.Replication.IsActive() {
+				err = fmt.Errorf("volume [%v] is not replicated", args[0])
+				return
+			}
+			if !optYes {
+				stdout("Fail over volume [%v] to volume [%v] of %v, the changes after %v are not replicated\n",
+					args[0], view.Replication.TargetVol, view.Replication.TargetMasters, formatReplicationSyncTime(view))
+				stdout("\nConfirm (yes/no)[no]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			if svv, err = client.AdminAPI().GetVolumeSimpleInfo(args[0]); err != nil {
+				return
+			}
+			if view, err = client.AdminAPI().FailoverVolReplication(args[0], calcAuthKey(svv.Owner)); err != nil {
+				err = annotateError(err, "Fail over volume replication failed: %v\n", err)
+				return
+			}
+			printVolReplication("Fail over volume replication success", view)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func printVolReplication(msg string, view *proto.VolReplicationView) {
+	if isStructuredOutput() {
+		if err := printStructured(view); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("%v:\n", msg)
+	stdout("%v", formatVolReplication(view))
+}
+
+func formatReplicationSyncTime(view *proto.VolReplicationView) string {
+	if view.Status == nil || view.Status.LastSyncTime == 0 {
+		return "the replication is set"
+	}
+	return formatTime(view.Status.LastSyncTime)
+}
+
+func formatReplicationState(replication *proto.VolReplication) string {
+	if replication.IsActive() {
+		return "Replicating"
+	}
+	return "FailedOver"
+}
+
+func formatVolReplication(view *proto.VolReplicationView) string {
+	var sb = strings.Builder{}
+	replication := view.Replication
+	sb.WriteString(fmt.Sprintf("  Volume               : %v\n", view.VolName))
+	sb.WriteString(fmt.Sprintf("  Target volume        : %v\n", replication.TargetVol))
+	sb.WriteString(fmt.Sprintf("  Target masters       : %v\n", strings.Join(replication.TargetMasters, ",")))
+	sb.WriteString(fmt.Sprintf("  State                : %v\n", formatReplicationState(replication)))
+	sb.WriteString(fmt.Sprintf("  Create time          : %v\n", formatTime(replication.CreateTime)))
+	if !replication.IsActive() {
+		sb.WriteString(fmt.Sprintf("  Failover time        : %v\n", formatTime(replication.FailoverTime)))
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  Max lag              : %v\n", time.Duration(replication.MaxLag)*time.Second))
+	sb.WriteString(fmt.Sprintf("  Lag                  : %v\n", time.Duration(view.Lag)*time.Second))
+	sb.WriteString(fmt.Sprintf("  Last sync time       : %v\n", formatReplicationSyncTime(view)))
+	status := view.Status
+	if status == nil {
+		sb.WriteString("  Replicator           : not reported\n")
+		return sb.String()
+	}
+	sb.WriteString(fmt.Sprintf("  Replicator           : %v\n", status.Replicator))
+	sb.WriteString(fmt.Sprintf("  Report time          : %v\n", formatTime(status.ReportTime)))
+	if status.PassStart != 0 {
+		sb.WriteString(fmt.Sprintf("  Running pass since   : %v\n", formatTime(status.PassStart)))
+	}
+	sb.WriteString(fmt.Sprintf("  Last pass            : %v, %v files, %v, %v deleted, %v errors\n",
+		time.Duration(status.LastPassTime)*time.Second, status.Files, formatSize(status.Bytes), status.Deleted, status.Errors))
+	if status.LastError != "" {
+		sb.WriteString(fmt.Sprintf("  Last error           : %v\n", status.LastError))
+	}
+	return sb.String()
+}
+
+var volReplicationTablePattern = "%-20v    %-20v    %-12v    %-10v    %-10v    %-20v"
+
+func formatVolReplicationTableHeader() string {
+	return fmt.Sprintf(volReplicationTablePattern, "VOLUME", "TARGET VOLUME", "STATE", "LAG", "MAX LAG", "LAST SYNC")
+}
+
+func formatVolReplicationTableRow(view *proto.VolReplicationView) string {
+	return fmt.Sprintf(volReplicationTablePattern, view.VolName, view.Replication.TargetVol,
+		formatReplicationState(view.Replication), time.Duration(view.Lag)*time.Second,
+		time.Duration(view.Replication.MaxLag)*time.Second, formatReplicationSyncTime(view))
+}
@@ -0,0 +1,283 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cmdVolSnapshotUse   = CliOpSnapshot + " [COMMAND]"
+	cmdVolSnapshotShort = "Manage snapshots of volumes"
+)
+
+func newVolSnapshotCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   cmdVolSnapshotUse,
+		Short: cmdVolSnapshotShort,
+		Args:  cobra.MinimumNArgs(0),
+	}
+	cmd.AddCommand(
+		newVolSnapshotCreateCmd(client),
+		newVolSnapshotListCmd(client),
+		newVolSnapshotDeleteCmd(client),
+		newVolSnapshotRollbackCmd(client),
+	)
+	return cmd
+}
+
+const (
+	cmdVolSnapshotCreateShort   = "Create a snapshot of the volume"
+	cmdVolSnapshotListShort     = "List the snapshots of the volume"
+	cmdVolSnapshotDeleteShort   = "Delete a snapshot of the volume"
+	cmdVolSnapshotRollbackShort = "Roll back the volume to a snapshot"
+)
+
+// volSnapshotTaskOptions are the options of the snapshot commands which are executed by master in background.
+type volSnapshotTaskOptions struct {
+	async    bool
+	interval time.Duration
+	timeout  time.Duration
+}
+
+func (opt *volSnapshotTaskOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&opt.async, CliFlagAsync, false, "Return the task ID without waiting for the task to finish")
+	cmd.Flags().DurationVar(&opt.interval, CliFlagInterval, defaultTaskWaitInterval, "Interval of polling the task status")
+	cmd.Flags().DurationVar(&opt.timeout, CliFlagTimeout, 0, "Maximum time to wait, 0 means no limit")
+}
+
+// run submits the snapshot operation and waits for the task of it unless the async flag is set.
+func (opt *volSnapshotTaskOptions) run(client *sdk.MasterClient, msg string, submit func() (*proto.VolSnapshotReply, error)) (err error) {
+	var (
+		reply *proto.VolSnapshotReply
+		task  *proto.AsyncTaskInfo
+	)
+	if opt.interval <= 0 {
+		return NewArgumentError("invalid interval [%v]", opt.interval)
+	}
+	if reply, err = submit(); err != nil {
+		return annotateError(err, "%v failed: %v\n", msg, err)
+	}
+	if opt.async || reply.Task == nil {
+		printVolSnapshotReply(msg, reply)
+		return
+	}
+	if task, err = waitAsyncTask(client, reply.Task.ID, opt.interval, opt.timeout, newTaskProgressPrinter()); err != nil {
+		return
+	}
+	reply.Task = task
+	if isStructuredOutput() {
+		err = printStructured(reply)
+	} else {
+		stdout("[Task]\n")
+		stdout("%v", formatAsyncTaskInfo(task))
+	}
+	if err == nil && task.Status == proto.AsyncTaskFailed {
+		err = fmt.Errorf("task %v failed", task.ID)
+	}
+	return
+}
+
+func newVolSnapshotCreateCmd(client *sdk.MasterClient) *cobra.Command {
+	var opt volSnapshotTaskOptions
+	var cmd = &cobra.Command{
+		Use:   CliOpCreate + " [VOLUME] [SNAPSHOT NAME]",
+		Short: cmdVolSnapshotCreateShort,
+		Long: `Create a snapshot of the metadata of the volume. The files and directories in the snapshot keep their
+data, since the extents referenced by the snapshot are never deleted, and the overwrites are written into new extents
+while the volume has snapshots. The meta partitions take the snapshot one after another about a minute later, after
+the clients see it, so stop the writes to the volume for a consistent snapshot.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			err = opt.run(client, "Create volume snapshot", func() (*proto.VolSnapshotReply, error) {
+				return client.AdminAPI().CreateVolSnapshot(args[0], args[1])
+			})
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	opt.addFlags(cmd)
+	return cmd
+}
+
+func newVolSnapshotListCmd(client *sdk.MasterClient) *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     CliOpList + " [VOLUME]",
+		Short:   cmdVolSnapshotListShort,
+		Aliases: []string{"ls"},
+		Args:    cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var snapshots []*proto.VolSnapshot
+			var err error
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if snapshots, err = client.AdminAPI().ListVolSnapshots(args[0]); err != nil {
+				return
+			}
+			if isStructuredOutput() {
+				err = printStructured(snapshots)
+				return
+			}
+			stdout("%v\n", formatVolSnapshotTableHeader())
+			for _, snapshot := range snapshots {
+				stdout("%v\n", formatVolSnapshotTableRow(snapshot))
+			}
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	return cmd
+}
+
+func newVolSnapshotDeleteCmd(client *sdk.MasterClient) *cobra.Command {
+	var opt volSnapshotTaskOptions
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpDelete + " [VOLUME] [SNAPSHOT ID]",
+		Short: cmdVolSnapshotDeleteShort,
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var snapshotID uint64
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if snapshotID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				err = NewArgumentError("invalid snapshot ID[%v]", args[1])
+				return
+			}
+			if !optYes {
+				stdout("Delete the snapshot [%v] of volume [%v], the data only referenced by the snapshot will be deleted\n", snapshotID, args[0])
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			err = opt.run(client, "Delete volume snapshot", func() (*proto.VolSnapshotReply, error) {
+				return client.AdminAPI().DeleteVolSnapshot(args[0], snapshotID)
+			})
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	opt.addFlags(cmd)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+func newVolSnapshotRollbackCmd(client *sdk.MasterClient) *cobra.Command {
+	var opt volSnapshotTaskOptions
+	var optYes bool
+	var cmd = &cobra.Command{
+		Use:   CliOpRollback + " [VOLUME] [SNAPSHOT ID]",
+		Short: cmdVolSnapshotRollbackShort,
+		Long: `Replace the metadata of the volume with the snapshot. The changes after the snapshot are discarded, and
+the data only referenced by them is deleted. The clients of the volume should be unmounted before the rollback, and
+the files in the meta partitions created after the snapshot are not rolled back.`,
+		Args: cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			var snapshotID uint64
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if snapshotID, err = strconv.ParseUint(args[1], 10, 64); err != nil {
+				err = NewArgumentError("invalid snapshot ID[%v]", args[1])
+				return
+			}
+			if !optYes {
+				stdout("Roll back volume [%v] to snapshot [%v], the changes after the snapshot will be discarded\n", args[0], snapshotID)
+				stdout("\nConfirm (yes/no)[yes]: ")
+				var userConfirm string
+				_, _ = fmt.Scanln(&userConfirm)
+				if userConfirm != "yes" && len(userConfirm) != 0 {
+					err = fmt.Errorf("Abort by user.\n")
+					return
+				}
+			}
+			err = opt.run(client, "Roll back volume snapshot", func() (*proto.VolSnapshotReply, error) {
+				return client.AdminAPI().RollbackVolSnapshot(args[0], snapshotID)
+			})
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validVols(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	opt.addFlags(cmd)
+	cmd.Flags().BoolVarP(&optYes, "yes", "y", false, "Answer yes for all questions")
+	return cmd
+}
+
+var volSnapshotTablePattern = "%-8v    %-24v    %-20v    %-10v"
+
+func formatVolSnapshotTableHeader() string {
+	return fmt.Sprintf(volSnapshotTablePattern, "ID", "NAME", "CREATE TIME", "STATUS")
+}
+
+func formatVolSnapshotTableRow(snapshot *proto.VolSnapshot) string {
+	return fmt.Sprintf(volSnapshotTablePattern, snapshot.ID, snapshot.Name, formatTime(snapshot.CreateTime), snapshot.Status)
+}
+
+func printVolSnapshotReply(msg string, reply *proto.VolSnapshotReply) {
+	if isStructuredOutput() {
+		if err := printStructured(reply); err != nil {
+			errout("Error: %v\n", err)
+		}
+		return
+	}
+	stdout("%v success:\n", msg)
+	stdout("%v\n", formatVolSnapshotTableHeader())
+	stdout("%v\n", formatVolSnapshotTableRow(reply.Snapshot))
+	if reply.Task != nil {
+		stdout("Task %v submitted, use \"task info %v\" or \"task wait %v\" to check the status\n", reply.Task.ID, reply.Task.ID, reply.Task.ID)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWatchInterval = 10 * time.Second
+)
+
+type partitionHealthChanges struct {
+	Time                       string
+	NewlyUnhealthyPartitionIDs []uint64
+	RecoveredPartitionIDs      []uint64
+}
+
+// watchPartitionDiagnosis re-runs the check every interval until the process is interrupted,
+// and shows the partitions which newly became unhealthy or recovered since the last round.
+func watchPartitionDiagnosis(interval time.Duration, check func() ([]uint64, error)) (err error) {
+	if interval <= 0 {
+		err = NewArgumentError("invalid interval [%v]", interval)
+		return
+	}
+	var last map[uint64]bool
+	for {
+		now := formatTimeToString(time.Now())
+		if !isStructuredOutput() {
+			stdout("======== %v ========\n", now)
+		}
+		var unhealthyIDs []uint64
+		if unhealthyIDs, err = check(); err != nil {
+			return
+		}
+		current := make(map[uint64]bool, len(unhealthyIDs))
+		for _, id := range unhealthyIDs {
+			current[id] = true
+		}
+		if last != nil {
+			changes := &partitionHealthChanges{
+				Time:                       now,
+				NewlyUnhealthyPartitionIDs: diffPartitionIDs(current, last),
+				RecoveredPartitionIDs:      diffPartitionIDs(last, current),
+			}
+			if err = printPartitionHealthChanges(changes); err != nil {
+				return
+			}
+		}
+		last = current
+		if !isStructuredOutput() {
+			stdout("\n")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// diffPartitionIDs returns the sorted IDs which are in a but not in b.
+func diffPartitionIDs(a, b map[uint64]bool) (ids []uint64) {
+	ids = make([]uint64, 0)
+	for id := range a {
+		if !b[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return
+}
+
+func printPartitionHealthChanges(changes *partitionHealthChanges) (err error) {
+	if isStructuredOutput() {
+		return printStructured(changes)
+	}
+	stdout("\n")
+	stdout("[Newly unhealthy partitions]: %v\n", formatPartitionIDs(changes.NewlyUnhealthyPartitionIDs))
+	stdout("[Recovered partitions]      : %v\n", formatPartitionIDs(changes.RecoveredPartitionIDs))
+	return
+}
+
+func formatPartitionIDs(ids []uint64) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	var sb strings.Builder
+	for i, id := range ids {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("%v", id))
+	}
+	return sb.String()
+}
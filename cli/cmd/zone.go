@@ -35,6 +35,7 @@ func newZoneCmd(client *sdk.MasterClient) *cobra.Command {
 	cmd.AddCommand(
 		newZoneListCmd(client),
 		newZoneInfoCmd(client),
+		newZoneSetCmd(client),
 	)
 	return cmd
 }
@@ -42,6 +43,7 @@ func newZoneCmd(client *sdk.MasterClient) *cobra.Command {
 const (
 	cmdZoneListShort = "List cluster zones"
 	cmdZoneInfoShort = "Show zone information"
+	cmdZoneSetShort  = "Set zone status, move nodes or volumes into the zone"
 )
 
 func newZoneListCmd(client *sdk.MasterClient) *cobra.Command {
@@ -60,6 +62,10 @@ func newZoneListCmd(client *sdk.MasterClient) *cobra.Command {
 			if zones, err = client.AdminAPI().ListZones(); err != nil {
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(zones)
+				return
+			}
 			zoneTablePattern := "%-8v    %-10v\n"
 			stdout(zoneTablePattern, "ZONE", "STATUS")
 			for _, zone := range zones {
@@ -103,6 +109,10 @@ func newZoneInfoCmd(client *sdk.MasterClient) *cobra.Command {
 				err = fmt.Errorf("Zone[%v] not exists in cluster\n ", zoneName)
 				return
 			}
+			if isStructuredOutput() {
+				err = printStructured(zoneView)
+				return
+			}
 			stdout(formatZoneView(zoneView))
 			return
 		},
@@ -115,3 +125,90 @@ func newZoneInfoCmd(client *sdk.MasterClient) *cobra.Command {
 	}
 	return cmd
 }
+
+func newZoneSetCmd(client *sdk.MasterClient) *cobra.Command {
+	var (
+		optStatus    string
+		optDataNodes []string
+		optMetaNodes []string
+		optVols      []string
+	)
+	var cmd = &cobra.Command{
+		Use:   CliOpSet + " [NAME]",
+		Short: cmdZoneSetShort,
+		Long: `Set the status of the zone, move the data nodes and the meta nodes into the zone, or set the zone
+of the volumes. The zone is created if it does not exist. The partitions on the moved nodes are not
+migrated, the new zone only takes effect on the partitions created later.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				err      error
+				zoneName = args[0]
+			)
+			defer func() {
+				if err != nil {
+					errout("Error: %v", err)
+				}
+			}()
+			if optStatus == "" && len(optDataNodes) == 0 && len(optMetaNodes) == 0 && len(optVols) == 0 {
+				err = NewArgumentError("no update, specify at least one of --%v, --%v, --%v and --%v",
+					CliFlagStatus, CliFlagDataNode, CliFlagMetaNode, CliFlagVol)
+				return
+			}
+			if optStatus != "" && optStatus != "available" && optStatus != "unavailable" {
+				err = NewArgumentError("invalid status [%v], should be available or unavailable", optStatus)
+				return
+			}
+			for _, addr := range optDataNodes {
+				if err = client.AdminAPI().MoveZoneNode(proto.PartitionTypeData, addr, zoneName); err != nil {
+					err = annotateError(err, "move data node[%v] to zone[%v] failed: %v", addr, zoneName, err)
+					return
+				}
+				stdout("Data node [%v] has been moved to zone [%v].\n", addr, zoneName)
+			}
+			for _, addr := range optMetaNodes {
+				if err = client.AdminAPI().MoveZoneNode(proto.PartitionTypeMeta, addr, zoneName); err != nil {
+					err = annotateError(err, "move meta node[%v] to zone[%v] failed: %v", addr, zoneName, err)
+					return
+				}
+				stdout("Meta node [%v] has been moved to zone [%v].\n", addr, zoneName)
+			}
+			for _, volName := range optVols {
+				var vv *proto.SimpleVolView
+				if vv, err = client.AdminAPI().GetVolumeSimpleInfo(volName); err != nil {
+					err = annotateError(err, "get volume[%v] failed: %v", volName, err)
+					return
+				}
+				if vv.CrossZone {
+					err = NewArgumentError("can not set zone of volume[%v] that cross zone", volName)
+					return
+				}
+				if err = client.AdminAPI().UpdateVolume(vv.Name, vv.Capacity, int(vv.DpReplicaNum), vv.FollowerRead,
+					vv.Authenticate, vv.EnableToken, calcAuthKey(vv.Owner), zoneName); err != nil {
+					err = annotateError(err, "set zone of volume[%v] failed: %v", volName, err)
+					return
+				}
+				stdout("Volume [%v] has been placed in zone [%v].\n", volName, zoneName)
+			}
+			if optStatus != "" {
+				// the status is set at last, since the zone may be created by moving the nodes
+				if err = client.AdminAPI().UpdateZoneStatus(zoneName, optStatus == "available"); err != nil {
+					return
+				}
+				stdout("Zone [%v] status has been set to [%v].\n", zoneName, optStatus)
+			}
+			return
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return validZones(client, toComplete), cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	cmd.Flags().StringVar(&optStatus, CliFlagStatus, "", "Set zone status [available | unavailable]")
+	cmd.Flags().StringSliceVar(&optDataNodes, CliFlagDataNode, nil, "Move the data nodes into the zone")
+	cmd.Flags().StringSliceVar(&optMetaNodes, CliFlagMetaNode, nil, "Move the meta nodes into the zone")
+	cmd.Flags().StringSliceVar(&optVols, CliFlagVol, nil, "Create the new partitions of the volumes in the zone")
+	return cmd
+}
@@ -0,0 +1,294 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The POSIX ACLs are enforced by the client if they are enabled by the mount options. The permissions are
+// checked by the access ACLs of the inodes, or by the mode bits if the inodes have no access ACL. A new inode
+// inherits the default ACL of its parent directory.
+
+// ACLCache caches the ACLs of the inodes, the missing ACLs are cached too.
+type ACLCache struct {
+	sync.RWMutex
+	cache      map[string]*aclCacheEntry
+	expiration time.Duration
+}
+
+type aclCacheEntry struct {
+	acl    proto.ACL
+	expire time.Time
+}
+
+// NewACLCache returns a new ACL cache.
+func NewACLCache(exp time.Duration) *ACLCache {
+	return &ACLCache{
+		cache:      make(map[string]*aclCacheEntry),
+		expiration: exp,
+	}
+}
+
+func aclCacheKey(ino uint64, name string) string {
+	return fmt.Sprintf("%v/%v", ino, name)
+}
+
+// Get returns the cached ACL, the ok is false if the ACL is not cached or expired.
+func (ac *ACLCache) Get(ino uint64, name string) (acl proto.ACL, ok bool) {
+	ac.RLock()
+	defer ac.RUnlock()
+	entry, ok := ac.cache[aclCacheKey(ino, name)]
+	if !ok || time.Now().After(entry.expire) {
+		return nil, false
+	}
+	return entry.acl, true
+}
+
+// Put caches the ACL of the inode.
+func (ac *ACLCache) Put(ino uint64, name string, acl proto.ACL) {
+	ac.Lock()
+	defer ac.Unlock()
+	now := time.Now()
+	if len(ac.cache) >= MaxInodeCache {
+		for key, entry := range ac.cache {
+			if now.After(entry.expire) {
+				delete(ac.cache, key)
+			}
+		}
+	}
+	ac.cache[aclCacheKey(ino, name)] = &aclCacheEntry{acl: acl, expire: now.Add(ac.expiration)}
+}
+
+// Delete removes the ACLs of the inode from the cache.
+func (ac *ACLCache) Delete(ino uint64) {
+	ac.Lock()
+	defer ac.Unlock()
+	delete(ac.cache, aclCacheKey(ino, proto.XAttrACLAccess))
+	delete(ac.cache, aclCacheKey(ino, proto.XAttrACLDefault))
+}
+
+func (s *Super) getACL(ino uint64, name string) (acl proto.ACL, err error) {
+	if acl, ok := s.acls.Get(ino, name); ok {
+		return acl, nil
+	}
+	info, err := s.mw.XAttrGet_ll(ino, name)
+	if err != nil {
+		log.LogErrorf("getACL: ino(%v) name(%v) err(%v)", ino, name, err)
+		return nil, ParseError(err)
+	}
+	if value, ok := info.XAttrs[name]; ok {
+		if acl, err = proto.ParseACL([]byte(value)); err != nil {
+			log.LogErrorf("getACL: ino(%v) name(%v) err(%v)", ino, name, err)
+			return nil, fuse.Errno(syscall.EIO)
+		}
+	}
+	s.acls.Put(ino, name, acl)
+	return
+}
+
+// callerGroups returns the primary group and the supplementary groups of the calling process.
+func callerGroups(header fuse.Header) []uint32 {
+	gids := []uint32{header.Gid}
+	f, err := os.Open(fmt.Sprintf("/proc/%v/status", header.Pid))
+	if err != nil {
+		return gids
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Groups:") {
+			continue
+		}
+		for _, field := range strings.Fields(strings.TrimPrefix(line, "Groups:")) {
+			if gid, err := strconv.ParseUint(field, 10, 32); err == nil {
+				gids = append(gids, uint32(gid))
+			}
+		}
+		break
+	}
+	return gids
+}
+
+// checkPermission checks if the caller is granted the wanted permissions on the inode.
+func (s *Super) checkPermission(ino uint64, header fuse.Header, want uint16) error {
+	if !s.enablePosixACL || want == 0 {
+		return nil
+	}
+	info, err := s.InodeGet(ino)
+	if err != nil {
+		return err
+	}
+	if header.Uid == 0 {
+		// the root is granted all permissions except executing a file which is not executable by anyone
+		if want&proto.ACLExecute == 0 || proto.IsDir(info.Mode) || info.Mode&0111 != 0 {
+			return nil
+		}
+		return fuse.Errno(syscall.EACCES)
+	}
+	acl, err := s.getACL(ino, proto.XAttrACLAccess)
+	if err != nil {
+		return err
+	}
+	if acl == nil {
+		acl = proto.ACL{
+			{Tag: proto.ACLUserObj, Perm: uint16(info.Mode>>6) & 0x7},
+			{Tag: proto.ACLGroupObj, Perm: uint16(info.Mode>>3) & 0x7},
+			{Tag: proto.ACLOther, Perm: uint16(info.Mode) & 0x7},
+		}
+	}
+	if !acl.Permit(info.Uid, info.Gid, header.Uid, callerGroups(header), want) {
+		log.LogDebugf("checkPermission: ino(%v) uid(%v) gid(%v) want(%v) denied", ino, header.Uid, header.Gid, want)
+		return fuse.Errno(syscall.EACCES)
+	}
+	return nil
+}
+
+// inheritACL returns the ACLs and the mode of a new inode created in the parent directory by the default ACL
+// of the parent, the ACLs are nil if the parent has no default ACL.
+func (s *Super) inheritACL(parent uint64, mode uint32) (access, dflt proto.ACL, newMode uint32, err error) {
+	newMode = mode
+	if !s.enablePosixACL || proto.IsSymlink(mode) {
+		return
+	}
+	if dflt, err = s.getACL(parent, proto.XAttrACLDefault); err != nil || dflt == nil {
+		return
+	}
+	access, newMode = dflt.Inherit(mode)
+	if access.Equiv() {
+		access = nil
+	}
+	if !proto.IsDir(mode) {
+		dflt = nil
+	}
+	return
+}
+
+// applyACL sets the inherited ACLs of the new inode.
+func (s *Super) applyACL(ino uint64, access, dflt proto.ACL) error {
+	if access != nil {
+		if err := s.mw.XAttrSet_ll(ino, []byte(proto.XAttrACLAccess), access.Bytes()); err != nil {
+			log.LogErrorf("applyACL: ino(%v) err(%v)", ino, err)
+			return ParseError(err)
+		}
+	}
+	if dflt != nil {
+		if err := s.mw.XAttrSet_ll(ino, []byte(proto.XAttrACLDefault), dflt.Bytes()); err != nil {
+			log.LogErrorf("applyACL: ino(%v) err(%v)", ino, err)
+			return ParseError(err)
+		}
+	}
+	s.acls.Delete(ino)
+	return nil
+}
+
+// checkACLOwner checks if the caller is allowed to change the ACLs of the inode, only the owner and the root are.
+func (s *Super) checkACLOwner(ino uint64, header fuse.Header) (info *proto.InodeInfo, err error) {
+	if info, err = s.InodeGet(ino); err != nil {
+		return
+	}
+	if header.Uid != 0 && header.Uid != info.Uid {
+		return nil, fuse.EPERM
+	}
+	return
+}
+
+// setACL sets the ACL of the inode, the mode is changed to the permissions of the access ACL, and the access
+// ACL equivalent to the mode is not kept.
+func (s *Super) setACL(ino uint64, header fuse.Header, name string, value []byte) error {
+	info, err := s.checkACLOwner(ino, header)
+	if err != nil {
+		return err
+	}
+	acl, err := proto.ParseACL(value)
+	if err != nil {
+		return fuse.Errno(syscall.EINVAL)
+	}
+	defer s.acls.Delete(ino)
+	if name == proto.XAttrACLDefault {
+		if !proto.IsDir(info.Mode) {
+			return fuse.Errno(syscall.EACCES)
+		}
+		if err = s.mw.XAttrSet_ll(ino, []byte(name), acl.Bytes()); err != nil {
+			log.LogErrorf("setACL: ino(%v) name(%v) err(%v)", ino, name, err)
+			return ParseError(err)
+		}
+		return nil
+	}
+	if acl.Equiv() {
+		err = s.mw.XAttrDel_ll(ino, name)
+	} else {
+		err = s.mw.XAttrSet_ll(ino, []byte(name), acl.Bytes())
+	}
+	if err != nil {
+		log.LogErrorf("setACL: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	if mode := info.Mode&^0777 | acl.Mode(); mode != info.Mode {
+		err = s.mw.Setattr(ino, proto.AttrMode, mode, info.Uid, info.Gid, info.AccessTime.Unix(), info.ModifyTime.Unix())
+		s.ic.Delete(ino)
+		if err != nil {
+			log.LogErrorf("setACL: ino(%v) mode(%v) err(%v)", ino, proto.OsMode(mode), err)
+			return ParseError(err)
+		}
+	}
+	return nil
+}
+
+// chmodACL changes the access ACL of the inode to the new mode.
+func (s *Super) chmodACL(ino uint64, mode uint32) error {
+	if !s.enablePosixACL {
+		return nil
+	}
+	acl, err := s.getACL(ino, proto.XAttrACLAccess)
+	if err != nil || acl == nil {
+		return err
+	}
+	defer s.acls.Delete(ino)
+	if err = s.mw.XAttrSet_ll(ino, []byte(proto.XAttrACLAccess), acl.Chmod(mode).Bytes()); err != nil {
+		log.LogErrorf("chmodACL: ino(%v) mode(%v) err(%v)", ino, proto.OsMode(mode), err)
+		return ParseError(err)
+	}
+	return nil
+}
+
+// openPermission returns the permissions wanted by the open flags.
+func openPermission(flags fuse.OpenFlags) (want uint16) {
+	switch {
+	case flags.IsReadOnly():
+		want = proto.ACLRead
+	case flags.IsWriteOnly():
+		want = proto.ACLWrite
+	case flags.IsReadWrite():
+		want = proto.ACLRead | proto.ACLWrite
+	}
+	if flags&fuse.OpenTruncate != 0 {
+		want |= proto.ACLWrite
+	}
+	return
+}
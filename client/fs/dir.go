@@ -24,6 +24,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
 )
@@ -33,6 +34,10 @@ type Dir struct {
 	super  *Super
 	info   *proto.InodeInfo
 	dcache *DentryCache
+	// the files removed from the trash are deleted directly
+	inTrash bool
+	// the parent which the changes of the summary are propagated to, 0 if it is not known
+	parentIno uint64
 }
 
 // Functions that Dir needs to implement
@@ -45,7 +50,6 @@ var (
 	_ fs.NodeRemover         = (*Dir)(nil)
 	_ fs.NodeFsyncer         = (*Dir)(nil)
 	_ fs.NodeRequestLookuper = (*Dir)(nil)
-	_ fs.HandleReadDirAller  = (*Dir)(nil)
 	_ fs.NodeRenamer         = (*Dir)(nil)
 	_ fs.NodeSetattrer       = (*Dir)(nil)
 	_ fs.NodeSymlinker       = (*Dir)(nil)
@@ -53,6 +57,8 @@ var (
 	_ fs.NodeListxattrer     = (*Dir)(nil)
 	_ fs.NodeSetxattrer      = (*Dir)(nil)
 	_ fs.NodeRemovexattrer   = (*Dir)(nil)
+	_ fs.NodeOpener          = (*Dir)(nil)
+	_ fs.NodeAccesser        = (*Dir)(nil)
 )
 
 // NewDir returns a new directory.
@@ -84,14 +90,26 @@ func (d *Dir) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.Cr
 	metric := exporter.NewTPCnt("filecreate")
 	defer metric.Set(err)
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(req.Mode.Perm()), req.Uid, req.Gid, nil)
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return nil, nil, err
+	}
+	access, dflt, mode, err := d.super.inheritACL(d.info.Inode, proto.Mode(req.Mode.Perm()))
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, mode, req.Uid, req.Gid, nil)
 	if err != nil {
 		log.LogErrorf("Create: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, nil, ParseError(err)
 	}
+	if err = d.super.applyACL(info.Inode, access, dflt); err != nil {
+		return nil, nil, err
+	}
 
 	d.super.ic.Put(info)
 	child := NewFile(d.super, info)
+	d.super.setParent(child, d.info.Inode)
+	d.addEntrySummary(info)
 	d.super.ec.OpenStream(info.Inode)
 
 	d.super.fslock.Lock()
@@ -132,14 +150,27 @@ func (d *Dir) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error
 	metric := exporter.NewTPCnt("mkdir")
 	defer metric.Set(err)
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(os.ModeDir|req.Mode.Perm()), req.Uid, req.Gid, nil)
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return nil, err
+	}
+	access, dflt, mode, err := d.super.inheritACL(d.info.Inode, proto.Mode(os.ModeDir|req.Mode.Perm()))
+	if err != nil {
+		return nil, err
+	}
+	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, mode, req.Uid, req.Gid, nil)
 	if err != nil {
 		log.LogErrorf("Mkdir: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, ParseError(err)
 	}
+	if err = d.super.applyACL(info.Inode, access, dflt); err != nil {
+		return nil, err
+	}
 
 	d.super.ic.Put(info)
 	child := NewDir(d.super, info)
+	child.(*Dir).inTrash = d.inTrash
+	d.super.setParent(child, d.info.Inode)
+	d.addEntrySummary(info)
 
 	d.super.fslock.Lock()
 	d.super.nodeCache[info.Inode] = child
@@ -161,11 +192,23 @@ func (d *Dir) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 	metric := exporter.NewTPCnt("remove")
 	defer metric.Set(err)
 
-	info, err := d.super.mw.Delete_ll(d.info.Inode, req.Name, req.Dir)
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return err
+	}
+	entry, _ := d.entrySummary(req.Name)
+	var info *proto.InodeInfo
+	if d.super.mw.TrashEnabled() && !d.inTrash {
+		info, err = d.super.mw.Trash_ll(d.info.Inode, req.Name, req.Dir)
+	} else {
+		info, err = d.super.mw.Delete_ll(d.info.Inode, req.Name, req.Dir)
+	}
 	if err != nil {
 		log.LogErrorf("Remove: parent(%v) name(%v) err(%v)", d.info.Inode, req.Name, err)
 		return ParseError(err)
 	}
+	if entry != nil {
+		d.super.updateSummary(d.info.Inode, entry.Negate())
+	}
 
 	d.super.ic.Delete(d.info.Inode)
 
@@ -192,6 +235,10 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 
 	log.LogDebugf("TRACE Lookup: parent(%v) req(%v)", d.info.Inode, req)
 
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLExecute); err != nil {
+		return nil, err
+	}
+
 	ino, ok := d.dcache.Get(req.Name)
 	if !ok {
 		ino, _, err = d.super.mw.Lookup_ll(d.info.Inode, req.Name)
@@ -217,61 +264,19 @@ func (d *Dir) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.Lo
 	if !ok {
 		if mode.IsDir() {
 			child = NewDir(d.super, info)
+			child.(*Dir).inTrash = d.inTrash || (d.info.Inode == proto.RootIno && req.Name == meta.TrashDirName)
 		} else {
 			child = NewFile(d.super, info)
 		}
 		d.super.nodeCache[ino] = child
 	}
+	d.super.setParent(child, d.info.Inode)
 	d.super.fslock.Unlock()
 
 	resp.EntryValid = LookupValidDuration
 	return child, nil
 }
 
-// ReadDirAll gets all the dentries in a directory and puts them into the cache.
-func (d *Dir) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
-	start := time.Now()
-
-	var err error
-	metric := exporter.NewTPCnt("readdir")
-	defer metric.Set(err)
-
-	children, err := d.super.mw.ReadDir_ll(d.info.Inode)
-	if err != nil {
-		log.LogErrorf("Readdir: ino(%v) err(%v)", d.info.Inode, err)
-		return make([]fuse.Dirent, 0), ParseError(err)
-	}
-
-	inodes := make([]uint64, 0, len(children))
-	dirents := make([]fuse.Dirent, 0, len(children))
-
-	var dcache *DentryCache
-	if !d.super.disableDcache {
-		dcache = NewDentryCache()
-	}
-
-	for _, child := range children {
-		dentry := fuse.Dirent{
-			Inode: child.Inode,
-			Type:  ParseType(child.Type),
-			Name:  child.Name,
-		}
-		inodes = append(inodes, child.Inode)
-		dirents = append(dirents, dentry)
-		dcache.Put(child.Name, child.Inode)
-	}
-
-	infos := d.super.mw.BatchInodeGet(inodes)
-	for _, info := range infos {
-		d.super.ic.Put(info)
-	}
-	d.dcache = dcache
-
-	elapsed := time.Since(start)
-	log.LogDebugf("TRACE ReadDir: ino(%v) (%v)ns", d.info.Inode, elapsed.Nanoseconds())
-	return dirents, nil
-}
-
 // Rename handles the rename request.
 func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Node) error {
 	dstDir, ok := newDir.(*Dir)
@@ -286,11 +291,20 @@ func (d *Dir) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Nod
 	metric := exporter.NewTPCnt("rename")
 	defer metric.Set(err)
 
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return err
+	}
+	if err = d.super.checkPermission(dstDir.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return err
+	}
+	entry, ino := d.entrySummary(req.OldName)
+	replaced, _ := dstDir.entrySummary(req.NewName)
 	err = d.super.mw.Rename_ll(d.info.Inode, req.OldName, dstDir.info.Inode, req.NewName)
 	if err != nil {
 		log.LogErrorf("Rename: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return ParseError(err)
 	}
+	d.renameSummary(dstDir, ino, entry, replaced)
 
 	d.super.ic.Delete(d.info.Inode)
 	d.super.ic.Delete(dstDir.info.Inode)
@@ -317,6 +331,11 @@ func (d *Dir) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.
 			d.super.ic.Delete(ino)
 			return ParseError(err)
 		}
+		if valid&proto.AttrMode != 0 {
+			if err = d.super.chmodACL(ino, info.Mode); err != nil {
+				return err
+			}
+		}
 	}
 
 	fillAttr(info, &resp.Attr)
@@ -337,14 +356,26 @@ func (d *Dir) Mknod(ctx context.Context, req *fuse.MknodRequest) (fs.Node, error
 	metric := exporter.NewTPCnt("mknod")
 	defer metric.Set(err)
 
-	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, proto.Mode(req.Mode), req.Uid, req.Gid, nil)
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return nil, err
+	}
+	access, dflt, mode, err := d.super.inheritACL(d.info.Inode, proto.Mode(req.Mode))
+	if err != nil {
+		return nil, err
+	}
+	info, err := d.super.mw.Create_ll(d.info.Inode, req.Name, mode, req.Uid, req.Gid, nil)
 	if err != nil {
 		log.LogErrorf("Mknod: parent(%v) req(%v) err(%v)", d.info.Inode, req, err)
 		return nil, ParseError(err)
 	}
+	if err = d.super.applyACL(info.Inode, access, dflt); err != nil {
+		return nil, err
+	}
 
 	d.super.ic.Put(info)
 	child := NewFile(d.super, info)
+	d.super.setParent(child, d.info.Inode)
+	d.addEntrySummary(info)
 
 	d.super.fslock.Lock()
 	d.super.nodeCache[info.Inode] = child
@@ -364,6 +395,9 @@ func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (fs.Node, e
 	metric := exporter.NewTPCnt("symlink")
 	defer metric.Set(err)
 
+	if err = d.super.checkPermission(parentIno, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return nil, err
+	}
 	info, err := d.super.mw.Create_ll(parentIno, req.NewName, proto.Mode(os.ModeSymlink|os.ModePerm), req.Uid, req.Gid, []byte(req.Target))
 	if err != nil {
 		log.LogErrorf("Symlink: parent(%v) NewName(%v) err(%v)", parentIno, req.NewName, err)
@@ -372,6 +406,7 @@ func (d *Dir) Symlink(ctx context.Context, req *fuse.SymlinkRequest) (fs.Node, e
 
 	d.super.ic.Put(info)
 	child := NewFile(d.super, info)
+	d.addEntrySummary(info)
 
 	d.super.fslock.Lock()
 	d.super.nodeCache[info.Inode] = child
@@ -403,6 +438,9 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	metric := exporter.NewTPCnt("link")
 	defer metric.Set(err)
 
+	if err = d.super.checkPermission(d.info.Inode, req.Header, proto.ACLWrite|proto.ACLExecute); err != nil {
+		return nil, err
+	}
 	info, err := d.super.mw.Link(d.info.Inode, req.NewName, oldInode.Inode)
 	if err != nil {
 		log.LogErrorf("Link: parent(%v) name(%v) ino(%v) err(%v)", d.info.Inode, req.NewName, oldInode.Inode, err)
@@ -410,6 +448,7 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	}
 
 	d.super.ic.Put(info)
+	d.addEntrySummary(info)
 
 	d.super.fslock.Lock()
 	newFile, ok := d.super.nodeCache[info.Inode]
@@ -424,22 +463,35 @@ func (d *Dir) Link(ctx context.Context, req *fuse.LinkRequest, old fs.Node) (fs.
 	return newFile, nil
 }
 
-// Getxattr has not been implemented yet.
+// Open checks the permission of reading the directory, and returns a new handle which reads the dentries page by page.
+func (d *Dir) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	if err := d.super.checkPermission(d.info.Inode, req.Header, proto.ACLRead); err != nil {
+		return nil, err
+	}
+	return NewDirHandle(d), nil
+}
+
+// Access checks the permissions of the caller on the directory.
+func (d *Dir) Access(ctx context.Context, req *fuse.AccessRequest) error {
+	return d.super.checkPermission(d.info.Inode, req.Header, uint16(req.Mask))
+}
+
+// Getxattr returns the value of the extended attribute of the directory.
 func (d *Dir) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	return fuse.ENOSYS
+	return d.super.getxattr(d.info.Inode, req, resp)
 }
 
-// Listxattr has not been implemented yet.
+// Listxattr lists the names of the extended attributes of the directory.
 func (d *Dir) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	return fuse.ENOSYS
+	return d.super.listxattr(d.info.Inode, req, resp)
 }
 
-// Setxattr has not been implemented yet.
+// Setxattr sets the extended attribute of the directory.
 func (d *Dir) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	return fuse.ENOSYS
+	return d.super.setxattr(d.info.Inode, req)
 }
 
-// Removexattr has not been implemented yet.
+// Removexattr removes the extended attribute of the directory.
 func (d *Dir) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	return fuse.ENOSYS
+	return d.super.removexattr(d.info.Inode, req)
 }
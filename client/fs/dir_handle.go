@@ -0,0 +1,144 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	"golang.org/x/net/context"
+
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The dentries cached by reading a directory, the others are looked up from the meta partition.
+const readDirCacheLimit = 16 * meta.ReadDirPageSize
+
+// DirHandle defines the handle of an opened directory. The dentries are read from the meta partition page by page
+// when the kernel reads the directory stream, so that a huge directory is not kept in the memory.
+type DirHandle struct {
+	sync.Mutex
+	d      *Dir
+	data   []byte // the encoded dentries not read yet, which start at the offset base of the directory stream
+	base   uint64
+	marker string // the marker of the next page
+	done   bool
+	dcache *DentryCache
+	cached int
+}
+
+// Functions that DirHandle needs to implement
+var (
+	_ fs.Handle          = (*DirHandle)(nil)
+	_ fs.HandleReadDirer = (*DirHandle)(nil)
+)
+
+// NewDirHandle returns a new handle of the directory.
+func NewDirHandle(d *Dir) *DirHandle {
+	return &DirHandle{d: d}
+}
+
+// ReadDir reads the directory stream from the offset, the pages are read until the response is filled.
+func (h *DirHandle) ReadDir(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	start := time.Now()
+
+	var err error
+	metric := exporter.NewTPCnt("readdir")
+	defer metric.Set(err)
+
+	h.Lock()
+	defer h.Unlock()
+
+	offset := uint64(req.Offset)
+	if offset == 0 || offset < h.base {
+		// the stream is read again from the beginning after rewinddir or seekdir backwards
+		h.reset()
+	}
+	for {
+		h.discard(offset)
+		if h.done || len(h.data) >= req.Size {
+			break
+		}
+		if err = h.readPage(); err != nil {
+			log.LogErrorf("Readdir: ino(%v) marker(%v) err(%v)", h.d.info.Inode, h.marker, err)
+			return ParseError(err)
+		}
+	}
+	size := len(h.data)
+	if size > req.Size {
+		// the entry cut off is read again by the next request
+		size = req.Size
+	}
+	resp.Data = append(resp.Data[:0], h.data[:size]...)
+
+	elapsed := time.Since(start)
+	log.LogDebugf("TRACE ReadDir: ino(%v) offset(%v) size(%v) (%v)ns", h.d.info.Inode, offset, size, elapsed.Nanoseconds())
+	return nil
+}
+
+func (h *DirHandle) reset() {
+	h.data, h.base, h.marker, h.done = nil, 0, "", false
+	h.dcache, h.cached = nil, 0
+	if !h.d.super.disableDcache {
+		h.dcache = NewDentryCache()
+	}
+	h.d.dcache = h.dcache
+}
+
+// discard drops the encoded dentries before the offset, which are read by the kernel.
+func (h *DirHandle) discard(offset uint64) {
+	if offset <= h.base {
+		return
+	}
+	skip := offset - h.base
+	if skip > uint64(len(h.data)) {
+		skip = uint64(len(h.data))
+	}
+	h.data = h.data[skip:]
+	h.base += skip
+}
+
+// readPage reads the next page of the dentries, and puts them into the caches.
+func (h *DirHandle) readPage() error {
+	children, next, err := h.d.super.mw.ReadDirLimit_ll(h.d.info.Inode, h.marker, meta.ReadDirPageSize)
+	if err != nil {
+		return err
+	}
+	inodes := make([]uint64, 0, len(children))
+	for _, child := range children {
+		dentry := fuse.Dirent{
+			Inode: child.Inode,
+			Type:  ParseType(child.Type),
+			Name:  child.Name,
+		}
+		h.data = fuse.AppendDirentAt(h.data, dentry, h.base)
+		inodes = append(inodes, child.Inode)
+		if h.cached < readDirCacheLimit {
+			h.dcache.Put(child.Name, child.Inode)
+			h.cached++
+		}
+	}
+
+	infos := h.d.super.mw.BatchInodeGet(inodes)
+	for _, info := range infos {
+		h.d.super.ic.Put(info)
+	}
+	h.marker, h.done = next, next == ""
+	return nil
+}
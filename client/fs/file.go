@@ -35,6 +35,9 @@ type File struct {
 	super *Super
 	info  *proto.InodeInfo
 	sync.RWMutex
+	// the parent and the size counted in the summary of the parent
+	parentIno   uint64
+	countedSize int64
 }
 
 // Functions that File needs to implement
@@ -54,11 +57,13 @@ var (
 	_ fs.NodeListxattrer   = (*File)(nil)
 	_ fs.NodeSetxattrer    = (*File)(nil)
 	_ fs.NodeRemovexattrer = (*File)(nil)
+	_ fs.NodeAccesser      = (*File)(nil)
+	_ fs.HandleLocker      = (*File)(nil)
 )
 
 // NewFile returns a new file.
 func NewFile(s *Super, i *proto.InodeInfo) fs.Node {
-	return &File{super: s, info: i}
+	return &File{super: s, info: i, countedSize: int64(i.Size)}
 }
 
 // Attr sets the attributes of a file.
@@ -120,6 +125,10 @@ func (f *File) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenR
 	ino := f.info.Inode
 	start := time.Now()
 
+	if err = f.super.checkPermission(ino, req.Header, openPermission(req.Flags)); err != nil {
+		return nil, err
+	}
+
 	f.super.ec.OpenStream(ino)
 
 	f.super.ec.RefreshExtentsCache(ino)
@@ -140,6 +149,10 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 
 	start := time.Now()
 
+	if req.ReleaseFlags&fuse.ReleaseFlockUnlock != 0 {
+		f.super.releaseFileLocks(ino, req.LockOwner, true)
+	}
+
 	//log.LogDebugf("TRACE Release close stream: ino(%v) req(%v)", ino, req)
 
 	err = f.super.ec.CloseStream(ino)
@@ -149,6 +162,7 @@ func (f *File) Release(ctx context.Context, req *fuse.ReleaseRequest) (err error
 	}
 
 	f.super.ic.Delete(ino)
+	f.accountSize()
 	elapsed := time.Since(start)
 	log.LogDebugf("TRACE Release: ino(%v) req(%v) (%v)ns", ino, req, elapsed.Nanoseconds())
 	return nil
@@ -196,6 +210,10 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 
 	log.LogDebugf("TRACE Write enter: ino(%v) offset(%v) len(%v) filesize(%v) flags(%v) fileflags(%v) req(%v)", ino, req.Offset, reqlen, filesize, req.Flags, req.FileFlags, req)
 
+	if err = f.super.mw.CheckWriteQuota(ino); err != nil {
+		return ParseError(err)
+	}
+
 	if req.Offset > int64(filesize) && reqlen == 1 && req.Data[0] == 0 {
 		// workaround: posix_fallocate would write 1 byte if fallocate is not supported.
 		err = f.super.ec.Truncate(ino, int(req.Offset)+reqlen)
@@ -256,9 +274,16 @@ func (f *File) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.Wri
 	return nil
 }
 
-// Flush only when fsyncOnClose is enabled.
+// Flush only when fsyncOnClose is enabled, the fcntl locks of the lock owner are released on each close.
 func (f *File) Flush(ctx context.Context, req *fuse.FlushRequest) (err error) {
+	if f.super.enableFileLock {
+		f.super.releaseFileLocks(f.info.Inode, req.LockOwner, false)
+	}
 	if !f.super.fsyncOnClose {
+		if f.super.enableFileLock {
+			// the kernel stops sending the flush requests if it is not implemented
+			return nil
+		}
 		return fuse.ENOSYS
 	}
 	log.LogDebugf("TRACE Flush enter: ino(%v)", f.info.Inode)
@@ -310,6 +335,7 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 		}
 		f.super.ic.Delete(ino)
 		f.super.ec.RefreshExtentsCache(ino)
+		defer f.accountSize()
 	}
 
 	info, err := f.super.InodeGet(ino)
@@ -331,6 +357,11 @@ func (f *File) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse
 			f.super.ic.Delete(ino)
 			return ParseError(err)
 		}
+		if valid&proto.AttrMode != 0 {
+			if err = f.super.chmodACL(ino, info.Mode); err != nil {
+				return err
+			}
+		}
 	}
 
 	fillAttr(info, &resp.Attr)
@@ -352,83 +383,29 @@ func (f *File) Readlink(ctx context.Context, req *fuse.ReadlinkRequest) (string,
 	return string(info.Target), nil
 }
 
-// Getxattr has not been implemented yet.
+// Access checks the permissions of the caller on the file.
+func (f *File) Access(ctx context.Context, req *fuse.AccessRequest) error {
+	return f.super.checkPermission(f.info.Inode, req.Header, uint16(req.Mask))
+}
+
+// Getxattr returns the value of the extended attribute of the file.
 func (f *File) Getxattr(ctx context.Context, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
-	if !f.super.enableXattr {
-		return fuse.ENOSYS
-	}
-	ino := f.info.Inode
-	name := req.Name
-	size := req.Size
-	pos := req.Position
-	info, err := f.super.mw.XAttrGet_ll(ino, name)
-	if err != nil {
-		log.LogErrorf("GetXattr: ino(%v) name(%v) err(%v)", ino, name, err)
-		return ParseError(err)
-	}
-	value := info.Get(name)
-	if pos > 0 {
-		value = value[pos:]
-	}
-	if size > 0 && size < uint32(len(value)) {
-		value = value[:size]
-	}
-	resp.Xattr = value
-	log.LogDebugf("TRACE GetXattr: ino(%v) name(%v)", ino, name)
-	return nil
+	return f.super.getxattr(f.info.Inode, req, resp)
 }
 
-// Listxattr has not been implemented yet.
+// Listxattr lists the names of the extended attributes of the file.
 func (f *File) Listxattr(ctx context.Context, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
-	if !f.super.enableXattr {
-		return fuse.ENOSYS
-	}
-	ino := f.info.Inode
-	_ = req.Size     // ignore currently
-	_ = req.Position // ignore currently
-
-	keys, err := f.super.mw.XAttrsList_ll(ino)
-	if err != nil {
-		log.LogErrorf("ListXattr: ino(%v) err(%v)", ino, err)
-		return ParseError(err)
-	}
-	for _, key := range keys {
-		resp.Append(key)
-	}
-	log.LogDebugf("TRACE Listxattr: ino(%v)", ino)
-	return nil
+	return f.super.listxattr(f.info.Inode, req, resp)
 }
 
-// Setxattr has not been implemented yet.
+// Setxattr sets the extended attribute of the file.
 func (f *File) Setxattr(ctx context.Context, req *fuse.SetxattrRequest) error {
-	if !f.super.enableXattr {
-		return fuse.ENOSYS
-	}
-	ino := f.info.Inode
-	name := req.Name
-	value := req.Xattr
-	// TODO： implement flag to improve compatible (Mofei Zhang)
-	if err := f.super.mw.XAttrSet_ll(ino, []byte(name), []byte(value)); err != nil {
-		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
-		return ParseError(err)
-	}
-	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v)", ino, name)
-	return nil
+	return f.super.setxattr(f.info.Inode, req)
 }
 
-// Removexattr has not been implemented yet.
+// Removexattr removes the extended attribute of the file.
 func (f *File) Removexattr(ctx context.Context, req *fuse.RemovexattrRequest) error {
-	if !f.super.enableXattr {
-		return fuse.ENOSYS
-	}
-	ino := f.info.Inode
-	name := req.Name
-	if err := f.super.mw.XAttrDel_ll(ino, name); err != nil {
-		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
-		return ParseError(err)
-	}
-	log.LogDebugf("TRACE RemoveXattr: ino(%v) name(%v)", ino, name)
-	return nil
+	return f.super.removexattr(f.info.Inode, req)
 }
 
 func (f *File) fileSize(ino uint64) (size int, gen uint64) {
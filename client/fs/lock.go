@@ -0,0 +1,218 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+	"golang.org/x/net/context"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The flock and the fcntl locks are kept by the meta partitions of the inodes if the file lock is enabled by
+// the mount options, so that they are honored by all the mount points of the volume. The client records the
+// locks held by the mount point and renews their leases periodically.
+
+const (
+	lockWaitMinInterval = 10 * time.Millisecond
+	lockWaitMaxInterval = time.Second
+)
+
+// FileLockTable records the locks held by the mount point.
+type FileLockTable struct {
+	sync.Mutex
+	session string
+	locks   map[uint64]proto.FileLocks
+}
+
+// NewFileLockTable returns a new file lock table with a unique session of the mount point.
+func NewFileLockTable() *FileLockTable {
+	hostname, _ := os.Hostname()
+	return &FileLockTable{
+		session: fmt.Sprintf("%v-%v-%v", hostname, os.Getpid(), time.Now().UnixNano()),
+		locks:   make(map[uint64]proto.FileLocks),
+	}
+}
+
+// Set records the lock or the unlock of the owner.
+func (t *FileLockTable) Set(ino uint64, lock *proto.FileLock) {
+	t.Lock()
+	defer t.Unlock()
+	locks := t.locks[ino].Set(lock)
+	if len(locks) == 0 {
+		delete(t.locks, ino)
+	} else {
+		t.locks[ino] = locks
+	}
+}
+
+// Holds checks if the owner holds any lock of the kind on the inode.
+func (t *FileLockTable) Holds(ino uint64, owner uint64, flock bool) bool {
+	t.Lock()
+	defer t.Unlock()
+	for _, lock := range t.locks[ino] {
+		if lock.Owner == owner && lock.Flock == flock {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot returns a copy of the locks held by the mount point.
+func (t *FileLockTable) Snapshot() map[uint64]proto.FileLocks {
+	t.Lock()
+	defer t.Unlock()
+	snapshot := make(map[uint64]proto.FileLocks, len(t.locks))
+	for ino, locks := range t.locks {
+		copied := make(proto.FileLocks, 0, len(locks))
+		for _, lock := range locks {
+			l := *lock
+			copied = append(copied, &l)
+		}
+		snapshot[ino] = copied
+	}
+	return snapshot
+}
+
+func (s *Super) newFileLock(owner uint64, lock fuse.FileLock, flags fuse.LockFlags) *proto.FileLock {
+	fileLock := &proto.FileLock{
+		Session: s.fileLocks.session,
+		Owner:   owner,
+		Pid:     lock.Pid,
+		Start:   lock.Start,
+		End:     lock.End,
+		Flock:   flags&fuse.LockFlock != 0,
+	}
+	switch lock.Type {
+	case fuse.LockRead:
+		fileLock.Type = proto.LockTypeRead
+	case fuse.LockWrite:
+		fileLock.Type = proto.LockTypeWrite
+	default:
+		fileLock.Type = proto.LockTypeUnlock
+	}
+	if fileLock.End > proto.LockEndMax {
+		fileLock.End = proto.LockEndMax
+	}
+	return fileLock
+}
+
+// setFileLock takes or releases the lock on the meta partition and records it if it succeeds.
+func (s *Super) setFileLock(ino uint64, lock *proto.FileLock) error {
+	if err := s.mw.SetLock_ll(ino, lock); err != nil {
+		if err != syscall.EAGAIN {
+			log.LogErrorf("setFileLock: ino(%v) lock(%v) err(%v)", ino, lock, err)
+		}
+		return ParseError(err)
+	}
+	s.fileLocks.Set(ino, lock)
+	return nil
+}
+
+// releaseFileLocks releases all the locks of the kind held by the owner on the inode.
+func (s *Super) releaseFileLocks(ino uint64, owner uint64, flock bool) {
+	if !s.enableFileLock || !s.fileLocks.Holds(ino, owner, flock) {
+		return
+	}
+	lock := &proto.FileLock{
+		Session: s.fileLocks.session,
+		Owner:   owner,
+		Start:   0,
+		End:     proto.LockEndMax,
+		Type:    proto.LockTypeUnlock,
+		Flock:   flock,
+	}
+	if err := s.setFileLock(ino, lock); err != nil {
+		// the lock expires after the lease even if it is not released
+		log.LogWarnf("releaseFileLocks: ino(%v) owner(%v) flock(%v) err(%v)", ino, owner, flock, err)
+	}
+}
+
+// renewFileLocks renews the leases of the locks held by the mount point periodically.
+func (s *Super) renewFileLocks() {
+	ticker := time.NewTicker(proto.DefaultLockLease * time.Second / 3)
+	defer ticker.Stop()
+	for range ticker.C {
+		locks := s.fileLocks.Snapshot()
+		if len(locks) == 0 {
+			continue
+		}
+		if err := s.mw.RenewLocks(s.fileLocks.session, locks); err != nil {
+			log.LogWarnf("renewFileLocks: session(%v) inodes(%v) err(%v)", s.fileLocks.session, len(locks), err)
+		}
+	}
+}
+
+// Lock takes the lock without waiting.
+func (f *File) Lock(ctx context.Context, req *fuse.LockRequest) error {
+	lock := f.super.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	err := f.super.setFileLock(f.info.Inode, lock)
+	log.LogDebugf("TRACE Lock: ino(%v) req(%v) err(%v)", f.info.Inode, req, err)
+	return err
+}
+
+// LockWait takes the lock, it retries until the lock is available or the request is interrupted.
+func (f *File) LockWait(ctx context.Context, req *fuse.LockWaitRequest) error {
+	lock := f.super.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	interval := lockWaitMinInterval
+	for {
+		err := f.super.setFileLock(f.info.Inode, lock)
+		if err != fuse.Errno(syscall.EAGAIN) {
+			log.LogDebugf("TRACE LockWait: ino(%v) req(%v) err(%v)", f.info.Inode, req, err)
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fuse.EINTR
+		case <-time.After(interval):
+		}
+		if interval *= 2; interval > lockWaitMaxInterval {
+			interval = lockWaitMaxInterval
+		}
+	}
+}
+
+// Unlock releases the locks on the range.
+func (f *File) Unlock(ctx context.Context, req *fuse.UnlockRequest) error {
+	lock := f.super.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	err := f.super.setFileLock(f.info.Inode, lock)
+	log.LogDebugf("TRACE Unlock: ino(%v) req(%v) err(%v)", f.info.Inode, req, err)
+	return err
+}
+
+// QueryLock returns the lock conflicting with the given one.
+func (f *File) QueryLock(ctx context.Context, req *fuse.QueryLockRequest, resp *fuse.QueryLockResponse) error {
+	lock := f.super.newFileLock(req.LockOwner, req.Lock, req.LockFlags)
+	conflict, err := f.super.mw.GetLock_ll(f.info.Inode, lock)
+	if err != nil {
+		log.LogErrorf("QueryLock: ino(%v) req(%v) err(%v)", f.info.Inode, req, err)
+		return ParseError(err)
+	}
+	if conflict != nil {
+		resp.Lock = fuse.FileLock{Start: conflict.Start, End: conflict.End, Pid: conflict.Pid, Type: fuse.LockRead}
+		if conflict.Type == proto.LockTypeWrite {
+			resp.Lock.Type = fuse.LockWrite
+		}
+	}
+	log.LogDebugf("TRACE QueryLock: ino(%v) req(%v) resp(%v)", f.info.Inode, req, resp)
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The summaries of the directories are maintained if they are enabled by the mount options. The changes of the
+// directories are accumulated by the client and sent to the meta partitions periodically, the recursive part of
+// the changes is propagated to the parent directories in the next round, so the summaries are eventually
+// consistent. The entries in the trash are not counted.
+
+const summaryFlushInterval = time.Second
+
+// SummaryUpdater accumulates the changes of the summaries of the directories.
+type SummaryUpdater struct {
+	sync.Mutex
+	deltas map[uint64]*proto.DirSummary
+}
+
+// NewSummaryUpdater returns a new summary updater.
+func NewSummaryUpdater() *SummaryUpdater {
+	return &SummaryUpdater{deltas: make(map[uint64]*proto.DirSummary)}
+}
+
+// Add merges the delta of the directory into the pending ones.
+func (u *SummaryUpdater) Add(ino uint64, delta *proto.DirSummary) {
+	u.Lock()
+	defer u.Unlock()
+	pending, ok := u.deltas[ino]
+	if !ok {
+		pending = &proto.DirSummary{}
+		u.deltas[ino] = pending
+	}
+	pending.Add(delta)
+}
+
+// Take returns the pending deltas and resets them.
+func (u *SummaryUpdater) Take() map[uint64]*proto.DirSummary {
+	u.Lock()
+	defer u.Unlock()
+	deltas := u.deltas
+	u.deltas = make(map[uint64]*proto.DirSummary)
+	return deltas
+}
+
+// updateSummary adds the delta of the directory, which is sent to the meta partition asynchronously.
+func (s *Super) updateSummary(ino uint64, delta *proto.DirSummary) {
+	if !s.enableSummary || delta == nil || delta.IsZero() {
+		return
+	}
+	s.summaries.Add(ino, delta)
+}
+
+// flushSummaries sends the pending deltas periodically, and adds the recursive part of them to the parents.
+func (s *Super) flushSummaries() {
+	ticker := time.NewTicker(summaryFlushInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		for ino, delta := range s.summaries.Take() {
+			summary, err := s.mw.UpdateSummary_ll(ino, delta)
+			if err != nil {
+				// the deltas of a removed directory are dropped
+				if err != syscall.ENOENT {
+					log.LogWarnf("flushSummaries: ino(%v) delta(%v) err(%v)", ino, *delta, err)
+				}
+				continue
+			}
+			if ino == proto.RootIno || summary.Parent == 0 {
+				continue
+			}
+			if recursive := delta.Recursive(); !recursive.IsZero() {
+				s.summaries.Add(summary.Parent, recursive)
+			}
+		}
+	}
+}
+
+// entrySummary returns the counts of the entry in the summary of the directory, nil if the entry does not exist.
+func (d *Dir) entrySummary(name string) (entry *proto.DirSummary, ino uint64) {
+	if !d.super.enableSummary || d.inTrash {
+		return
+	}
+	ino, mode, err := d.super.mw.Lookup_ll(d.info.Inode, name)
+	if err != nil {
+		return
+	}
+	if !proto.IsDir(mode) {
+		entry = &proto.DirSummary{Files: 1, RFiles: 1}
+		if info, err := d.super.InodeGet(ino); err == nil && proto.IsRegular(info.Mode) {
+			entry.Bytes = int64(info.Size)
+			entry.RBytes = entry.Bytes
+		}
+		return
+	}
+	summary, err := d.super.mw.GetSummary_ll(ino)
+	if err != nil {
+		log.LogWarnf("entrySummary: parent(%v) name(%v) ino(%v) err(%v)", d.info.Inode, name, ino, err)
+		summary = &proto.DirSummary{}
+	}
+	entry = &proto.DirSummary{
+		Subdirs:  1,
+		RSubdirs: 1 + summary.RSubdirs,
+		RFiles:   summary.RFiles,
+		RBytes:   summary.RBytes,
+	}
+	return
+}
+
+// addEntrySummary adds the counts of a new entry to the summary of the directory.
+func (d *Dir) addEntrySummary(info *proto.InodeInfo) {
+	if !d.super.enableSummary || d.inTrash {
+		return
+	}
+	delta := &proto.DirSummary{Parent: d.parentIno}
+	if proto.IsDir(info.Mode) {
+		delta.Subdirs, delta.RSubdirs = 1, 1
+		d.super.updateSummary(info.Inode, &proto.DirSummary{Parent: d.info.Inode})
+	} else {
+		delta.Files, delta.RFiles = 1, 1
+		if proto.IsRegular(info.Mode) {
+			delta.Bytes, delta.RBytes = int64(info.Size), int64(info.Size)
+		}
+	}
+	d.super.updateSummary(d.info.Inode, delta)
+}
+
+// setParent records the parent directory of the node which the changes of the summaries are sent to.
+func (s *Super) setParent(node interface{}, parent uint64) {
+	if !s.enableSummary {
+		return
+	}
+	switch node := node.(type) {
+	case *Dir:
+		atomic.StoreUint64(&node.parentIno, parent)
+	case *File:
+		atomic.StoreUint64(&node.parentIno, parent)
+	}
+}
+
+// accountSize adds the change of the size of the file since it is counted last time to the summary of the parent.
+func (f *File) accountSize() {
+	parent := atomic.LoadUint64(&f.parentIno)
+	if !f.super.enableSummary || parent == 0 || !proto.IsRegular(f.info.Mode) {
+		return
+	}
+	size, _ := f.fileSize(f.info.Inode)
+	if delta := int64(size) - atomic.SwapInt64(&f.countedSize, int64(size)); delta != 0 {
+		f.super.updateSummary(parent, &proto.DirSummary{Bytes: delta, RBytes: delta})
+	}
+}
+
+// renameSummary moves the counts of the renamed entry from the source directory to the destination, the replaced
+// entry of the destination is not counted any more.
+func (d *Dir) renameSummary(dstDir *Dir, ino uint64, entry, replaced *proto.DirSummary) {
+	if !d.super.enableSummary {
+		return
+	}
+	if replaced != nil {
+		d.super.updateSummary(dstDir.info.Inode, replaced.Negate())
+	}
+	if entry == nil {
+		return
+	}
+	d.super.updateSummary(d.info.Inode, entry.Negate())
+	if !dstDir.inTrash {
+		d.super.updateSummary(dstDir.info.Inode, entry)
+	}
+	if entry.Subdirs != 0 {
+		d.super.updateSummary(ino, &proto.DirSummary{Parent: dstDir.info.Inode})
+	}
+	d.super.fslock.Lock()
+	if node, ok := d.super.nodeCache[ino]; ok {
+		d.super.setParent(node, dstDir.info.Inode)
+	}
+	d.super.fslock.Unlock()
+}
@@ -54,6 +54,15 @@ type Super struct {
 	fsyncOnClose  bool
 	enableXattr   bool
 	rootIno       uint64
+
+	enablePosixACL bool
+	acls           *ACLCache
+
+	enableFileLock bool
+	fileLocks      *FileLockTable
+
+	enableSummary bool
+	summaries     *SummaryUpdater
 }
 
 // Functions that Super needs to implement
@@ -67,12 +76,13 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s = new(Super)
 	var masters = strings.Split(opt.Master, meta.HostsSeparator)
 	var metaConfig = &meta.MetaConfig{
-		Volume:        opt.Volname,
-		Owner:         opt.Owner,
-		Masters:       masters,
-		Authenticate:  opt.Authenticate,
-		TicketMess:    opt.TicketMess,
-		ValidateOwner: opt.Authenticate || opt.AccessKey == "",
+		Volume:            opt.Volname,
+		Owner:             opt.Owner,
+		Masters:           masters,
+		Authenticate:      opt.Authenticate,
+		TicketMess:        opt.TicketMess,
+		ValidateOwner:     opt.Authenticate || opt.AccessKey == "",
+		EnableTransaction: opt.EnableTransaction,
 	}
 	s.mw, err = meta.NewMetaWrapper(metaConfig)
 	if err != nil {
@@ -102,12 +112,19 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 	s.disableDcache = opt.DisableDcache
 	s.fsyncOnClose = opt.FsyncOnClose
 	s.enableXattr = opt.EnableXattr
+	s.enablePosixACL = opt.EnablePosixACL
+	s.acls = NewACLCache(inodeExpiration)
+	s.enableFileLock = opt.EnableFileLock
+	s.fileLocks = NewFileLockTable()
+	s.enableSummary = opt.EnableSummary
+	s.summaries = NewSummaryUpdater()
 
 	var extentConfig = &stream.ExtentConfig{
 		Volume:            opt.Volname,
 		Masters:           masters,
 		FollowerRead:      opt.FollowerRead,
 		NearRead:          opt.NearRead,
+		ReadCrc:           opt.ReadCrc,
 		ReadRate:          opt.ReadRate,
 		WriteRate:         opt.WriteRate,
 		OnAppendExtentKey: s.mw.AppendExtentKey,
@@ -124,6 +141,14 @@ func NewSuper(opt *proto.MountOptions) (s *Super, err error) {
 		return nil, err
 	}
 
+	go s.mw.PurgeTrash()
+	if s.enableFileLock {
+		go s.renewFileLocks()
+	}
+	if s.enableSummary {
+		go s.flushSummaries()
+	}
+
 	log.LogInfof("NewSuper: cluster(%v) volname(%v) icacheExpiration(%v) LookupValidDuration(%v) AttrValidDuration(%v)", s.cluster, s.volname, inodeExpiration, LookupValidDuration, AttrValidDuration)
 	return s, nil
 }
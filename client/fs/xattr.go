@@ -0,0 +1,150 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package fs
+
+import (
+	"syscall"
+
+	"bazil.org/fuse"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The flags of setxattr, which are not exposed by the syscall package.
+const (
+	xattrCreate  = 0x1 // fails if the attribute exists
+	xattrReplace = 0x2 // fails if the attribute does not exist
+)
+
+// The extended attributes of the files and the directories are kept in the meta partitions of the inodes, and
+// they are served only if the xattr is enabled by the mount options, except that the POSIX ACLs are served if
+// the posix ACL is enabled. The attribute of the directory quotas is managed by the master, so it can not be
+// changed through the mount points.
+
+func (s *Super) xattrEnabled(name string) bool {
+	return s.enableXattr || (s.enablePosixACL && proto.IsACLXAttr(name))
+}
+
+func (s *Super) getxattr(ino uint64, req *fuse.GetxattrRequest, resp *fuse.GetxattrResponse) error {
+	if !s.xattrEnabled(req.Name) {
+		return fuse.ENOSYS
+	}
+	info, err := s.mw.XAttrGet_ll(ino, req.Name)
+	if err != nil {
+		log.LogErrorf("Getxattr: ino(%v) name(%v) err(%v)", ino, req.Name, err)
+		return ParseError(err)
+	}
+	value, ok := info.XAttrs[req.Name]
+	if !ok {
+		return fuse.ErrNoXattr
+	}
+	if req.Position > uint32(len(value)) {
+		return fuse.ERANGE
+	}
+	// the size of the buffer is checked by the fuse server
+	resp.Xattr = []byte(value[req.Position:])
+	log.LogDebugf("TRACE Getxattr: ino(%v) name(%v)", ino, req.Name)
+	return nil
+}
+
+func (s *Super) listxattr(ino uint64, req *fuse.ListxattrRequest, resp *fuse.ListxattrResponse) error {
+	if !s.enableXattr && !s.enablePosixACL {
+		return fuse.ENOSYS
+	}
+	keys, err := s.mw.XAttrsList_ll(ino)
+	if err != nil {
+		log.LogErrorf("Listxattr: ino(%v) err(%v)", ino, err)
+		return ParseError(err)
+	}
+	for _, key := range keys {
+		if s.xattrEnabled(key) {
+			resp.Append(key)
+		}
+	}
+	log.LogDebugf("TRACE Listxattr: ino(%v)", ino)
+	return nil
+}
+
+func (s *Super) setxattr(ino uint64, req *fuse.SetxattrRequest) error {
+	name := req.Name
+	if !s.xattrEnabled(name) {
+		return fuse.ENOSYS
+	}
+	if name == proto.QuotaXAttrKey || name == proto.SummaryXAttrKey {
+		return fuse.EPERM
+	}
+	if len(name) == 0 || len(name) > proto.XAttrNameMax {
+		return fuse.ERANGE
+	}
+	if len(req.Xattr) > proto.XAttrValueMax {
+		return fuse.Errno(syscall.E2BIG)
+	}
+	if req.Flags&(xattrCreate|xattrReplace) != 0 {
+		info, err := s.mw.XAttrGet_ll(ino, name)
+		if err != nil {
+			log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
+			return ParseError(err)
+		}
+		_, exist := info.XAttrs[name]
+		if exist && req.Flags&xattrCreate != 0 {
+			return fuse.EEXIST
+		}
+		if !exist && req.Flags&xattrReplace != 0 {
+			return fuse.ErrNoXattr
+		}
+	}
+	if s.enablePosixACL && proto.IsACLXAttr(name) {
+		return s.setACL(ino, req.Header, name, req.Xattr)
+	}
+	if err := s.mw.XAttrSet_ll(ino, []byte(name), req.Xattr); err != nil {
+		log.LogErrorf("Setxattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	log.LogDebugf("TRACE Setxattr: ino(%v) name(%v)", ino, name)
+	return nil
+}
+
+func (s *Super) removexattr(ino uint64, req *fuse.RemovexattrRequest) error {
+	name := req.Name
+	if !s.xattrEnabled(name) {
+		return fuse.ENOSYS
+	}
+	if name == proto.QuotaXAttrKey || name == proto.SummaryXAttrKey {
+		return fuse.EPERM
+	}
+	if s.enablePosixACL && proto.IsACLXAttr(name) {
+		if _, err := s.checkACLOwner(ino, req.Header); err != nil {
+			return err
+		}
+	}
+	info, err := s.mw.XAttrGet_ll(ino, name)
+	if err != nil {
+		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	if _, exist := info.XAttrs[name]; !exist {
+		return fuse.ErrNoXattr
+	}
+	if err = s.mw.XAttrDel_ll(ino, name); err != nil {
+		log.LogErrorf("Removexattr: ino(%v) name(%v) err(%v)", ino, name, err)
+		return ParseError(err)
+	}
+	if proto.IsACLXAttr(name) {
+		s.acls.Delete(ino)
+	}
+	log.LogDebugf("TRACE Removexattr: ino(%v) name(%v)", ino, name)
+	return nil
+}
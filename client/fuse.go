@@ -285,6 +285,10 @@ func mount(opt *proto.MountOptions) (fsConn *fuse.Conn, super *cfs.Super, err er
 		options = append(options, fuse.PosixACL())
 	}
 
+	if opt.EnableFileLock {
+		options = append(options, fuse.LockingFlock(), fuse.LockingPOSIX())
+	}
+
 	fsConn, err = fuse.Mount(opt.MountPoint, options...)
 	return
 }
@@ -350,6 +354,10 @@ func parseMountOption(cfg *config.Config) (*proto.MountOptions, error) {
 	opt.EnableXattr = GlobalMountOptions[proto.EnableXattr].GetBool()
 	opt.NearRead = GlobalMountOptions[proto.NearRead].GetBool()
 	opt.EnablePosixACL = GlobalMountOptions[proto.EnablePosixACL].GetBool()
+	opt.EnableFileLock = GlobalMountOptions[proto.EnableFileLock].GetBool()
+	opt.EnableSummary = GlobalMountOptions[proto.EnableSummary].GetBool()
+	opt.EnableTransaction = GlobalMountOptions[proto.EnableTransaction].GetBool()
+	opt.ReadCrc = GlobalMountOptions[proto.ReadCrc].GetBool()
 
 	if opt.MountPoint == "" || opt.Volname == "" || opt.Owner == "" || opt.Master == "" {
 		return nil, errors.New(fmt.Sprintf("invalid config file: lack of mandatory fields, mountPoint(%v), volName(%v), owner(%v), masterAddr(%v)", opt.MountPoint, opt.Volname, opt.Owner, opt.Master))
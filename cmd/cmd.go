@@ -42,6 +42,7 @@ import (
 	"github.com/chubaofs/chubaofs/datanode"
 	"github.com/chubaofs/chubaofs/master"
 	"github.com/chubaofs/chubaofs/metanode"
+	"github.com/chubaofs/chubaofs/replicator"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/log"
 	"github.com/chubaofs/chubaofs/util/ump"
@@ -56,21 +57,23 @@ const (
 )
 
 const (
-	RoleMaster  = "master"
-	RoleMeta    = "metanode"
-	RoleData    = "datanode"
-	RoleAuth    = "authnode"
-	RoleObject  = "objectnode"
-	RoleConsole = "console"
+	RoleMaster     = "master"
+	RoleMeta       = "metanode"
+	RoleData       = "datanode"
+	RoleAuth       = "authnode"
+	RoleObject     = "objectnode"
+	RoleConsole    = "console"
+	RoleReplicator = "replicator"
 )
 
 const (
-	ModuleMaster  = "master"
-	ModuleMeta    = "metaNode"
-	ModuleData    = "dataNode"
-	ModuleAuth    = "authNode"
-	ModuleObject  = "objectNode"
-	ModuleConsole = "console"
+	ModuleMaster     = "master"
+	ModuleMeta       = "metaNode"
+	ModuleData       = "dataNode"
+	ModuleAuth       = "authNode"
+	ModuleObject     = "objectNode"
+	ModuleConsole    = "console"
+	ModuleReplicator = "replicator"
 )
 
 const (
@@ -177,6 +180,9 @@ func main() {
 	case RoleConsole:
 		server = console.NewServer()
 		module = ModuleConsole
+	case RoleReplicator:
+		server = replicator.NewServer()
+		module = ModuleReplicator
 	default:
 		daemonize.SignalOutcome(fmt.Errorf("Fatal: role mismatch: %v", role))
 		os.Exit(1)
@@ -0,0 +1,195 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// The compactor of a disk packs the small normal extents of its partitions, which are not modified for
+// compactColdAge, into the pack files of the partitions at the limited rate, so that the small files written by the
+// clients take neither a file nor the rounded up blocks of their own on the disk. The pack files of which most of
+// the data is deleted or unpacked are packed again to reclaim the space. The packed extents are read as usual, and
+// are unpacked back before they are written.
+
+const (
+	DefaultCompactColdAge    = 3600 // seconds an extent is not modified before it is packed
+	DefaultCompactExtentSize = 128  // KB of the largest extent to pack
+	DefaultCompactBandwidth  = 10   // MB per second to pack the extents of a disk
+	compactRoundInterval     = 10 * time.Minute
+	compactPackSize          = 64 * util.MB // bytes of the extents packed into a pack file
+	compactMinExtents        = 16           // min extents of a partition to pack into a new pack file
+	compactGarbageRatio      = 0.5          // ratio of the garbage in a pack file to pack it again
+)
+
+var (
+	compactColdAge    int64 = DefaultCompactColdAge
+	compactExtentSize int64 = DefaultCompactExtentSize * util.KB
+	compactBandwidth  int64 = DefaultCompactBandwidth * util.MB // bytes per second, 0 to stop compacting
+)
+
+// DiskCompactStatus is the progress of the compactor of a disk and the space taken by the packed extents on it.
+type DiskCompactStatus struct {
+	Path             string `json:"path"`
+	Rounds           uint64 `json:"rounds"`           // rounds finished since the data node starts
+	RoundStartTime   int64  `json:"roundStartTime"`   // start time of the current or the last round
+	LastRoundTime    int64  `json:"lastRoundTime"`    // end time of the last finished round
+	NewPackedExtents uint64 `json:"newPackedExtents"` // extents packed by the current or the last round
+	RepackedExtents  uint64 `json:"repackedExtents"`  // extents packed again by the current or the last round
+	storage.PackStats
+}
+
+// CompactStatus returns a copy of the progress of the compactor, along with the space taken by the packed extents of
+// the partitions on the disk.
+func (d *Disk) CompactStatus() DiskCompactStatus {
+	d.compactLock.Lock()
+	status := d.compactStatus
+	d.compactLock.Unlock()
+	status.Path = d.Path
+	d.RLock()
+	defer d.RUnlock()
+	for _, dp := range d.partitionMap {
+		stats := dp.ExtentStore().PackStats()
+		status.PackFiles += stats.PackFiles
+		status.PackedExtents += stats.PackedExtents
+		status.PackedBytes += stats.PackedBytes
+		status.PackFileBytes += stats.PackFileBytes
+		status.SavedBytes += stats.SavedBytes
+		status.RemovedBytes += stats.RemovedBytes
+	}
+	return status
+}
+
+func (d *Disk) updateCompactStatus(f func(status *DiskCompactStatus)) {
+	d.compactLock.Lock()
+	defer d.compactLock.Unlock()
+	f(&d.compactStatus)
+}
+
+// startCompact packs the small cold extents of the partitions on the disk until the data node stops.
+func (d *Disk) startCompact() {
+	limiter := rate.NewLimiter(rate.Inf, util.BlockSize)
+	ctx := context.Background()
+	wait := func(size int) {
+		if bandwidth := atomic.LoadInt64(&compactBandwidth); bandwidth > 0 && limiter.Limit() != rate.Limit(bandwidth) {
+			setLimiter(limiter, uint64(bandwidth))
+		}
+		limiter.WaitN(ctx, size)
+	}
+	for {
+		if atomic.LoadInt64(&compactBandwidth) > 0 {
+			d.compactRound(wait)
+		}
+		if !d.waitScrub(compactRoundInterval) {
+			return
+		}
+	}
+}
+
+func (d *Disk) compactRound(wait func(size int)) {
+	partitions := make([]*DataPartition, 0)
+	d.RLock()
+	for _, dp := range d.partitionMap {
+		partitions = append(partitions, dp)
+	}
+	d.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].partitionID < partitions[j].partitionID })
+
+	d.updateCompactStatus(func(status *DiskCompactStatus) {
+		status.RoundStartTime = time.Now().Unix()
+		status.NewPackedExtents = 0
+		status.RepackedExtents = 0
+	})
+	for _, dp := range partitions {
+		if !dp.compact(wait) {
+			log.LogInfof("action[compactRound] disk(%v) stopped at partition(%v)", d.Path, dp.partitionID)
+			return
+		}
+	}
+	d.updateCompactStatus(func(status *DiskCompactStatus) {
+		status.Rounds++
+		status.LastRoundTime = time.Now().Unix()
+	})
+	round := d.CompactStatus()
+	log.LogInfof("action[compactRound] disk(%v) partitions(%v) packed(%v) repacked(%v) saved(%v) cost(%vs)", d.Path,
+		len(partitions), round.NewPackedExtents, round.RepackedExtents, round.SavedBytes,
+		round.LastRoundTime-round.RoundStartTime)
+}
+
+// compact packs the extents in the pack files with much garbage and the small cold extents of the partition, it
+// returns false if the compaction is disabled or the data node is stopping.
+func (dp *DataPartition) compact(wait func(size int)) bool {
+	store := dp.ExtentStore()
+	store.ReclaimPacks()
+	if !dp.packExtents(store.RepackCandidates(compactGarbageRatio), true, wait) {
+		return false
+	}
+	extents := store.PackCandidates(atomic.LoadInt64(&compactColdAge), atomic.LoadInt64(&compactExtentSize))
+	if len(extents) < compactMinExtents {
+		return true
+	}
+	return dp.packExtents(extents, false, wait)
+}
+
+// packExtents packs the extents into the pack files of up to compactPackSize, it returns false if the compaction is
+// disabled or the data node is stopping.
+func (dp *DataPartition) packExtents(extents []uint64, repack bool, wait func(size int)) bool {
+	store := dp.ExtentStore()
+	for len(extents) > 0 {
+		if atomic.LoadInt64(&compactBandwidth) == 0 {
+			return false
+		}
+		select {
+		case <-dp.disk.space.stopC:
+			return false
+		case <-dp.stopC:
+			return true
+		default:
+		}
+		var (
+			batch []uint64
+			size  uint64
+		)
+		for len(extents) > 0 && (len(batch) == 0 || size < compactPackSize) {
+			if ei, err := store.Watermark(extents[0]); err == nil {
+				size += ei.Size
+			}
+			batch, extents = append(batch, extents[0]), extents[1:]
+		}
+		packed, err := store.PackExtents(batch, wait)
+		if err != nil {
+			if dp.checkIsDiskError(err) {
+				return true
+			}
+			log.LogWarnf("action[packExtents] partition(%v) extents(%v) err(%v)", dp.partitionID, len(batch), err)
+		}
+		dp.disk.updateCompactStatus(func(status *DiskCompactStatus) {
+			if repack {
+				status.RepackedExtents += uint64(packed)
+			} else {
+				status.NewPackedExtents += uint64(packed)
+			}
+		})
+	}
+	return true
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// The compressor of a disk compresses the normal extents of the partitions of the volumes with compression, which
+// are not modified for compressColdAge, at the limited rate. The data is decompressed by the data node when it is
+// read, so the clients and the other replicas see the same data whether it is compressed or not, and an extent is
+// decompressed back before it is written.
+
+const (
+	DefaultCompressColdAge   = 24 * 3600 // seconds an extent is not modified before it is compressed
+	DefaultCompressBandwidth = 20        // MB per second to compress the extents of a disk
+	compressRoundInterval    = 10 * time.Minute
+)
+
+var (
+	compressColdAge   int64 = DefaultCompressColdAge
+	compressBandwidth int64 = DefaultCompressBandwidth * util.MB // bytes per second, 0 to stop compressing
+)
+
+// compressionVols holds the compression algorithms of the volumes with compression, which are pulled from the master.
+var compressionVols = struct {
+	sync.RWMutex
+	algorithms map[string]string
+}{algorithms: make(map[string]string)}
+
+func updateCompressionVols(algorithms map[string]string) {
+	compressionVols.Lock()
+	defer compressionVols.Unlock()
+	compressionVols.algorithms = algorithms
+}
+
+func volCompression(volName string) string {
+	compressionVols.RLock()
+	defer compressionVols.RUnlock()
+	return compressionVols.algorithms[volName]
+}
+
+// PartitionCompression is the compression ratio of a partition.
+type PartitionCompression struct {
+	Algorithm         string `json:"algorithm"`
+	CompressedExtents int    `json:"compressedExtents"`
+	RawBytes          uint64 `json:"rawBytes"`        // size of the data of the compressed extents
+	CompressedBytes   uint64 `json:"compressedBytes"` // size of the compressed files of them
+}
+
+// Compression returns the compression ratio of the partition.
+func (dp *DataPartition) Compression() (c PartitionCompression) {
+	c.Algorithm = volCompression(dp.volumeID)
+	c.CompressedExtents, c.RawBytes, c.CompressedBytes = dp.ExtentStore().CompressionStats()
+	return
+}
+
+// startCompress compresses the cold extents of the partitions on the disk until the data node stops.
+func (d *Disk) startCompress() {
+	limiter := rate.NewLimiter(rate.Inf, util.BlockSize)
+	ctx := context.Background()
+	wait := func(size int) {
+		if bandwidth := atomic.LoadInt64(&compressBandwidth); bandwidth > 0 && limiter.Limit() != rate.Limit(bandwidth) {
+			setLimiter(limiter, uint64(bandwidth))
+		}
+		limiter.WaitN(ctx, size)
+	}
+	for {
+		if atomic.LoadInt64(&compressBandwidth) > 0 {
+			d.compressRound(wait)
+		}
+		if !d.waitScrub(compressRoundInterval) {
+			return
+		}
+	}
+}
+
+func (d *Disk) compressRound(wait func(size int)) {
+	partitions := make([]*DataPartition, 0)
+	d.RLock()
+	for _, dp := range d.partitionMap {
+		partitions = append(partitions, dp)
+	}
+	d.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].partitionID < partitions[j].partitionID })
+	for _, dp := range partitions {
+		if !dp.transit(wait) || !dp.compress(wait) {
+			return
+		}
+	}
+}
+
+// compress compresses the cold extents of the partition with the algorithm of its volume, it returns false if the
+// compression is disabled or the data node is stopping.
+func (dp *DataPartition) compress(wait func(size int)) bool {
+	algorithm := volCompression(dp.volumeID)
+	if algorithm == "" {
+		return true
+	}
+	store := dp.ExtentStore()
+	for _, extentID := range store.CompressCandidates(atomic.LoadInt64(&compressColdAge)) {
+		if atomic.LoadInt64(&compressBandwidth) == 0 {
+			return false
+		}
+		select {
+		case <-dp.disk.space.stopC:
+			return false
+		case <-dp.stopC:
+			return true
+		default:
+		}
+		if err := store.CompressExtent(extentID, algorithm, wait); err != nil {
+			if dp.checkIsDiskError(err) {
+				return true
+			}
+			log.LogWarnf("action[compress] partition(%v) extent(%v) err(%v)", dp.partitionID, extentID, err)
+		}
+	}
+	return true
+}
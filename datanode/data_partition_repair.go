@@ -19,6 +19,7 @@ import (
 	"github.com/chubaofs/chubaofs/util"
 	"math"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -36,6 +37,7 @@ import (
 type DataPartitionRepairTask struct {
 	TaskType                       uint8
 	addr                           string
+	sourceIndex                    int // the index of the source of the next extent to repair
 	extents                        map[uint64]*storage.ExtentInfo
 	ExtentsToBeCreated             []*storage.ExtentInfo
 	ExtentsToBeRepaired            []*storage.ExtentInfo
@@ -235,19 +237,7 @@ func (dp *DataPartition) DoRepair(repairTasks []*DataPartitionRepairTask) {
 		}
 		store.Create(extentInfo.FileID)
 	}
-	for _, extentInfo := range repairTasks[0].ExtentsToBeRepaired {
-		err := dp.streamRepairExtent(extentInfo)
-		if err != nil {
-			err = errors.Trace(err, "doStreamExtentFixRepair %v", dp.applyRepairKey(int(extentInfo.FileID)))
-			localExtentInfo, opErr := dp.ExtentStore().Watermark(uint64(extentInfo.FileID))
-			if opErr != nil {
-				err = errors.Trace(err, opErr.Error())
-			}
-			err = errors.Trace(err, "partition(%v) remote(%v) local(%v)",
-				dp.partitionID, extentInfo, localExtentInfo)
-			log.LogWarnf("action[doStreamExtentFixRepair] err(%v).", err)
-		}
-	}
+	dp.repairExtents(repairTasks[0].ExtentsToBeRepaired, NumOfFilesToRecoverInParallel, nil)
 }
 
 func (dp *DataPartition) moveToBrokenTinyExtentC(extentType uint8, extents []uint64) {
@@ -319,13 +309,58 @@ func (dp *DataPartition) prepareRepairTasks(repairTasks []*DataPartitionRepairTa
 			extentInfoMap[extentID] = extentInfo
 		}
 	}
-	dp.buildExtentCreationTasks(repairTasks, extentInfoMap)
-	availableTinyExtents, brokenTinyExtents = dp.buildExtentRepairTasks(repairTasks, extentInfoMap)
+	sources := repairSources(repairTasks, extentInfoMap)
+	dp.buildExtentCreationTasks(repairTasks, extentInfoMap, sources)
+	availableTinyExtents, brokenTinyExtents = dp.buildExtentRepairTasks(repairTasks, extentInfoMap, sources)
+	return
+}
+
+// repairSources returns the replicas having each extent in the max size, which the extent can be repaired from.
+func repairSources(repairTasks []*DataPartitionRepairTask, extentInfoMap map[uint64]*storage.ExtentInfo) (sources map[uint64][]string) {
+	sources = make(map[uint64][]string, len(extentInfoMap))
+	for _, repairTask := range repairTasks {
+		if repairTask == nil {
+			continue
+		}
+		for extentID, extentInfo := range repairTask.extents {
+			maxInfo, ok := extentInfoMap[extentID]
+			if !ok || extentInfo.IsDeleted || extentInfo.Size != maxInfo.Size {
+				continue
+			}
+			sources[extentID] = append(sources[extentID], extentInfo.Source)
+		}
+	}
+	for _, addrs := range sources {
+		sort.Strings(addrs)
+	}
 	return
 }
 
+// newRepairExtentInfo returns the extent to repair on the replica of the index, whose source is picked from the
+// replicas having the extent in turns, so the extents are repaired from all of them in parallel. The others are
+// the sources to fall back on.
+func newRepairExtentInfo(repairTask *DataPartitionRepairTask, extentInfo *storage.ExtentInfo, sources []string) *storage.ExtentInfo {
+	ei := &storage.ExtentInfo{Source: extentInfo.Source, FileID: extentInfo.FileID, Size: extentInfo.Size}
+	candidates := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if source != repairTask.addr {
+			candidates = append(candidates, source)
+		}
+	}
+	if len(candidates) == 0 {
+		return ei
+	}
+	first := repairTask.sourceIndex % len(candidates)
+	repairTask.sourceIndex++
+	ei.Source = candidates[first]
+	for i := 1; i < len(candidates); i++ {
+		ei.Sources = append(ei.Sources, candidates[(first+i)%len(candidates)])
+	}
+	return ei
+}
+
 // Create a new extent if one of the replica is missing.
-func (dp *DataPartition) buildExtentCreationTasks(repairTasks []*DataPartitionRepairTask, extentInfoMap map[uint64]*storage.ExtentInfo) {
+func (dp *DataPartition) buildExtentCreationTasks(repairTasks []*DataPartitionRepairTask, extentInfoMap map[uint64]*storage.ExtentInfo, sources map[uint64][]string) {
 	for extentID, extentInfo := range extentInfoMap {
 		if storage.IsTinyExtent(extentID) {
 			continue
@@ -345,7 +380,7 @@ func (dp *DataPartition) buildExtentCreationTasks(repairTasks []*DataPartitionRe
 				if dp.ExtentStore().IsDeletedNormalExtent(extentID) {
 					continue
 				}
-				ei := &storage.ExtentInfo{Source: extentInfo.Source, FileID: extentID, Size: extentInfo.Size}
+				ei := newRepairExtentInfo(repairTask, extentInfo, sources[extentID])
 				repairTask.ExtentsToBeCreated = append(repairTask.ExtentsToBeCreated, ei)
 				repairTask.ExtentsToBeRepaired = append(repairTask.ExtentsToBeRepaired, ei)
 				log.LogInfof("action[generatorAddExtentsTasks] addFile(%v_%v) on Index(%v).", dp.partitionID, ei, index)
@@ -355,7 +390,7 @@ func (dp *DataPartition) buildExtentCreationTasks(repairTasks []*DataPartitionRe
 }
 
 // Repair an extent if the replicas do not have the same length.
-func (dp *DataPartition) buildExtentRepairTasks(repairTasks []*DataPartitionRepairTask, maxSizeExtentMap map[uint64]*storage.ExtentInfo) (availableTinyExtents []uint64, brokenTinyExtents []uint64) {
+func (dp *DataPartition) buildExtentRepairTasks(repairTasks []*DataPartitionRepairTask, maxSizeExtentMap map[uint64]*storage.ExtentInfo, sources map[uint64][]string) (availableTinyExtents []uint64, brokenTinyExtents []uint64) {
 	availableTinyExtents = make([]uint64, 0)
 	brokenTinyExtents = make([]uint64, 0)
 	for extentID, maxFileInfo := range maxSizeExtentMap {
@@ -376,7 +411,7 @@ func (dp *DataPartition) buildExtentRepairTasks(repairTasks []*DataPartitionRepa
 				continue
 			}
 			if extentInfo.Size < maxFileInfo.Size {
-				fixExtent := &storage.ExtentInfo{Source: maxFileInfo.Source, FileID: extentID, Size: maxFileInfo.Size}
+				fixExtent := newRepairExtentInfo(repairTasks[index], maxFileInfo, sources[extentID])
 				repairTasks[index].ExtentsToBeRepaired = append(repairTasks[index].ExtentsToBeRepaired, fixExtent)
 				log.LogInfof("action[generatorFixExtentSizeTasks] fixExtent(%v_%v) on Index(%v) on(%v).",
 					dp.partitionID, fixExtent, index, repairTasks[index].addr)
@@ -433,11 +468,20 @@ func (dp *DataPartition) NotifyExtentRepair(members []*DataPartitionRepairTask)
 	return
 }
 
-// DoStreamExtentFixRepair executes the repair on the followers.
-func (dp *DataPartition) doStreamExtentFixRepair(wg *sync.WaitGroup, remoteExtentInfo *storage.ExtentInfo) {
-	defer wg.Done()
-
+// DoStreamExtentFixRepair repairs the extent from its source, and from the other sources in turn if it fails.
+func (dp *DataPartition) doStreamExtentFixRepair(remoteExtentInfo *storage.ExtentInfo) {
 	err := dp.streamRepairExtent(remoteExtentInfo)
+	for _, source := range remoteExtentInfo.Sources {
+		if err == nil {
+			break
+		}
+		log.LogWarnf("action[doStreamExtentFixRepair] partition(%v) extent(%v) source(%v) err(%v), try source(%v)",
+			dp.partitionID, remoteExtentInfo.FileID, remoteExtentInfo.Source, err, source)
+		extentInfo := *remoteExtentInfo
+		extentInfo.Source = source
+		err = dp.streamRepairExtent(&extentInfo)
+	}
+	dp.finishRepairExtent(remoteExtentInfo.FileID, err)
 
 	if err != nil {
 		err = errors.Trace(err, "doStreamExtentFixRepair %v", dp.applyRepairKey(int(remoteExtentInfo.FileID)))
@@ -543,6 +587,7 @@ func (dp *DataPartition) streamRepairExtent(remoteExtentInfo *storage.ExtentInfo
 				remoteExtentInfo.Source, remoteExtentInfo.Size, currFixOffset, request.GetUniqueLogId(), reply.GetUniqueLogId())
 			return errors.Trace(err, "streamRepairExtent receive data error")
 		}
+		dp.waitRepairBandwidth(int(reply.Size))
 		isEmptyResponse := false
 		// Write it to local extent file
 		if storage.IsTinyExtent(uint64(localExtentInfo.FileID)) {
@@ -558,7 +603,14 @@ func (dp *DataPartition) streamRepairExtent(remoteExtentInfo *storage.ExtentInfo
 				currRecoverySize = binary.BigEndian.Uint64(reply.Arg[1:9])
 				reply.Size = uint32(currRecoverySize)
 			}
-			err = store.TinyExtentRecover(uint64(localExtentInfo.FileID), int64(currFixOffset), int64(currRecoverySize), reply.Data, reply.CRC, isEmptyResponse)
+			// the holes of the tiny extent are punched without writing the data
+			writeSize := int(reply.Size)
+			if isEmptyResponse {
+				writeSize = 0
+			}
+			err = dp.disk.doIO(ioClassRepair, ioWrite, writeSize, func() error {
+				return store.TinyExtentRecover(uint64(localExtentInfo.FileID), int64(currFixOffset), int64(currRecoverySize), reply.Data, reply.CRC, isEmptyResponse)
+			})
 			if hasRecoverySize+currRecoverySize >= remoteAvaliSize {
 				log.LogInfof("streamRepairTinyExtent(%v) recover fininsh,remoteAvaliSize(%v) "+
 					"hasRecoverySize(%v) currRecoverySize(%v)", dp.applyRepairKey(int(localExtentInfo.FileID)),
@@ -566,7 +618,9 @@ func (dp *DataPartition) streamRepairExtent(remoteExtentInfo *storage.ExtentInfo
 				break
 			}
 		} else {
-			err = store.Write(uint64(localExtentInfo.FileID), int64(currFixOffset), int64(reply.Size), reply.Data, reply.CRC, storage.AppendWriteType, BufferWrite)
+			err = dp.disk.doIO(ioClassRepair, ioWrite, int(reply.Size), func() error {
+				return store.Write(uint64(localExtentInfo.FileID), int64(currFixOffset), int64(reply.Size), reply.Data, reply.CRC, storage.AppendWriteType, BufferWrite)
+			})
 		}
 
 		// write to the local extent file
@@ -574,6 +628,7 @@ func (dp *DataPartition) streamRepairExtent(remoteExtentInfo *storage.ExtentInfo
 			err = errors.Trace(err, "streamRepairExtent repair data error ")
 			return
 		}
+		dp.addRepairedBytes(remoteExtentInfo.FileID, uint64(reply.Size))
 		hasRecoverySize += uint64(reply.Size)
 		currFixOffset += uint64(reply.Size)
 		if currFixOffset >= remoteExtentInfo.Size {
@@ -60,6 +60,13 @@ type Disk struct {
 	partitionMap                              map[uint64]*DataPartition
 	syncTinyDeleteRecordFromLeaderOnEveryDisk chan bool
 	space                                     *SpaceManager
+	scrubStatus                               DiskScrubStatus
+	scrubLock                                 sync.Mutex
+	compactStatus                             DiskCompactStatus
+	compactLock                               sync.Mutex
+	smart                                     atomic.Value // *proto.DiskSmart collected last time
+	retiring                                  int32        // 1 if the disk is retired by the master
+	io                                        diskIO
 }
 
 const (
@@ -77,6 +84,7 @@ func NewDisk(path string, reservedSpace uint64, maxErrCnt int, space *SpaceManag
 	d.space = space
 	d.partitionMap = make(map[uint64]*DataPartition)
 	d.syncTinyDeleteRecordFromLeaderOnEveryDisk = make(chan bool, SyncTinyDeleteRecordFromLeaderOnEveryDisk)
+	d.initIO()
 	d.computeUsage()
 	d.updateSpaceInfo()
 	d.startScheduleToUpdateSpaceInfo()
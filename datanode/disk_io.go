@@ -0,0 +1,254 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/chubaofs/chubaofs/util/qos"
+)
+
+// The reads and the writes of the extents on a disk are throttled and counted by the class of the traffic, so the
+// repair of the replicas can be capped without throttling the clients and the other way around. The time an IO waits
+// for the throttle of its class is the queue latency, and the IOPS, the throughput and the latencies are sampled
+// every ioStatInterval.
+
+type ioClass int
+
+const (
+	ioClassClient ioClass = iota // the reads and the writes of the clients, including the replicated writes
+	ioClassRepair                // the reads and the writes to repair the replicas
+	ioClassCount
+)
+
+var ioClassNames = [ioClassCount]string{"client", "repair"}
+
+func parseIOClass(name string) (class ioClass, err error) {
+	for class = 0; class < ioClassCount; class++ {
+		if ioClassNames[class] == name {
+			return
+		}
+	}
+	return 0, fmt.Errorf("unknown io class %v", name)
+}
+
+const (
+	ioRead = iota
+	ioWrite
+	ioOpCount
+)
+
+const (
+	DefaultDiskRepairBandwidth = 100 // MB per second to read and to write to repair on a disk
+	ioStatInterval             = 10 * time.Second
+)
+
+// DiskIOLimits are the limits of a class of the traffic on a disk, 0 for no limit.
+type DiskIOLimits struct {
+	ReadIops  uint64 `json:"readIops"`
+	WriteIops uint64 `json:"writeIops"`
+	ReadBps   uint64 `json:"readBps"`
+	WriteBps  uint64 `json:"writeBps"`
+}
+
+// diskIOLimits are the limits of the disks when they are loaded, which are set by the configuration.
+var diskIOLimits [ioClassCount]DiskIOLimits
+
+type ioCounter struct {
+	ops       uint64
+	bytes     uint64
+	queueTime uint64 // nanoseconds waiting for the throttle
+	time      uint64 // nanoseconds from waiting for the throttle to the completion
+}
+
+func (c *ioCounter) load() ioCounter {
+	return ioCounter{
+		ops:       atomic.LoadUint64(&c.ops),
+		bytes:     atomic.LoadUint64(&c.bytes),
+		queueTime: atomic.LoadUint64(&c.queueTime),
+		time:      atomic.LoadUint64(&c.time),
+	}
+}
+
+// DiskIOOpStat is the reads or the writes of a class of the traffic on a disk in the last sampling interval.
+type DiskIOOpStat struct {
+	Iops           float64 `json:"iops"`
+	Bps            uint64  `json:"bps"`
+	QueueLatencyUs uint64  `json:"queueLatencyUs"` // average microseconds waiting for the throttle
+	LatencyUs      uint64  `json:"latencyUs"`      // average microseconds including the queue latency
+	TotalOps       uint64  `json:"totalOps"`       // since the data node starts
+	TotalBytes     uint64  `json:"totalBytes"`
+}
+
+// DiskIOClassStat is the IO of a class of the traffic on a disk.
+type DiskIOClassStat struct {
+	Class  string       `json:"class"`
+	Read   DiskIOOpStat `json:"read"`
+	Write  DiskIOOpStat `json:"write"`
+	Limits DiskIOLimits `json:"limits"`
+}
+
+// DiskIOStat is the IO of a disk by the classes of the traffic.
+type DiskIOStat struct {
+	Path    string            `json:"path"`
+	Classes []DiskIOClassStat `json:"classes"`
+}
+
+type diskIO struct {
+	limiters [ioClassCount]*qos.Limiter
+	counters [ioClassCount][ioOpCount]ioCounter
+
+	sync.Mutex
+	sampled    [ioClassCount][ioOpCount]ioCounter
+	sampleTime time.Time
+	stats      [ioClassCount][ioOpCount]DiskIOOpStat
+}
+
+func (d *Disk) initIO() {
+	for class := ioClass(0); class < ioClassCount; class++ {
+		d.io.limiters[class] = qos.NewLimiter()
+		limits := diskIOLimits[class]
+		d.io.limiters[class].Update(limits.ReadIops, limits.WriteIops, limits.ReadBps, limits.WriteBps)
+	}
+	d.io.sampleTime = time.Now()
+}
+
+// doIO throttles the IO of the size by the limits of its class on the disk, and counts it.
+func (d *Disk) doIO(class ioClass, op int, size int, do func() error) (err error) {
+	start := time.Now()
+	limiter := d.io.limiters[class]
+	var waitErr error
+	if op == ioWrite {
+		waitErr = limiter.WaitWrite(context.Background(), size)
+	} else {
+		waitErr = limiter.WaitRead(context.Background(), size)
+	}
+	if waitErr != nil {
+		log.LogWarnf("action[doIO] disk(%v) class(%v) err(%v)", d.Path, ioClassNames[class], waitErr)
+	}
+	queued := time.Since(start)
+	err = do()
+	counter := &d.io.counters[class][op]
+	atomic.AddUint64(&counter.ops, 1)
+	atomic.AddUint64(&counter.bytes, uint64(size))
+	atomic.AddUint64(&counter.queueTime, uint64(queued))
+	atomic.AddUint64(&counter.time, uint64(time.Since(start)))
+	return
+}
+
+// setIOLimits sets the limits of the class of the traffic on the disk.
+func (d *Disk) setIOLimits(class ioClass, limits DiskIOLimits) {
+	d.io.limiters[class].Update(limits.ReadIops, limits.WriteIops, limits.ReadBps, limits.WriteBps)
+	log.LogWarnf("action[setIOLimits] disk(%v) class(%v) limits(%+v)", d.Path, ioClassNames[class], limits)
+}
+
+// sampleIO computes the IO of the disk since the last sampling.
+func (d *Disk) sampleIO() {
+	d.io.Lock()
+	defer d.io.Unlock()
+	now := time.Now()
+	seconds := now.Sub(d.io.sampleTime).Seconds()
+	if seconds <= 0 {
+		return
+	}
+	for class := ioClass(0); class < ioClassCount; class++ {
+		for op := 0; op < ioOpCount; op++ {
+			current := d.io.counters[class][op].load()
+			last := d.io.sampled[class][op]
+			stat := DiskIOOpStat{TotalOps: current.ops, TotalBytes: current.bytes}
+			if ops := current.ops - last.ops; ops > 0 {
+				stat.Iops = float64(ops) / seconds
+				stat.Bps = uint64(float64(current.bytes-last.bytes) / seconds)
+				stat.QueueLatencyUs = (current.queueTime - last.queueTime) / ops / uint64(time.Microsecond)
+				stat.LatencyUs = (current.time - last.time) / ops / uint64(time.Microsecond)
+			}
+			d.io.stats[class][op] = stat
+			d.io.sampled[class][op] = current
+		}
+	}
+	d.io.sampleTime = now
+}
+
+// IOStat returns the IO of the disk in the last sampling interval and the limits by the classes of the traffic.
+func (d *Disk) IOStat() DiskIOStat {
+	d.io.Lock()
+	defer d.io.Unlock()
+	stat := DiskIOStat{Path: d.Path, Classes: make([]DiskIOClassStat, 0, ioClassCount)}
+	for class := ioClass(0); class < ioClassCount; class++ {
+		limits := DiskIOLimits{}
+		limits.ReadIops, limits.WriteIops, limits.ReadBps, limits.WriteBps = d.io.limiters[class].Limits()
+		stat.Classes = append(stat.Classes, DiskIOClassStat{
+			Class:  ioClassNames[class],
+			Read:   d.io.stats[class][ioRead],
+			Write:  d.io.stats[class][ioWrite],
+			Limits: limits,
+		})
+	}
+	return stat
+}
+
+// setDiskIOLimits sets the limits of the class of the traffic on the disk, or on all the disks if the path is empty.
+func (manager *SpaceManager) setDiskIOLimits(path string, class ioClass, limits DiskIOLimits) (err error) {
+	if path == "" {
+		for _, d := range manager.GetDisks() {
+			d.setIOLimits(class, limits)
+		}
+		return
+	}
+	d, err := manager.GetDisk(path)
+	if err != nil {
+		return
+	}
+	d.setIOLimits(class, limits)
+	return
+}
+
+// startSampleIO samples the IO of the disks every ioStatInterval until the data node stops.
+func (manager *SpaceManager) startSampleIO() {
+	ticker := time.NewTicker(ioStatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-manager.stopC:
+			return
+		case <-ticker.C:
+			for _, d := range manager.GetDisks() {
+				d.sampleIO()
+			}
+		}
+	}
+}
+
+// setConfigIOLimits sets the limits of the class of the traffic on the disks by the configuration, the bandwidth is in MB per second and applies to both the reads and the writes.
+func setConfigIOLimits(class ioClass, iops, bandwidth int64) {
+	if iops < 0 {
+		iops = 0
+	}
+	if bandwidth < 0 {
+		bandwidth = 0
+	}
+	diskIOLimits[class] = DiskIOLimits{
+		ReadIops:  uint64(iops),
+		WriteIops: uint64(iops),
+		ReadBps:   uint64(bandwidth) * util.MB,
+		WriteBps:  uint64(bandwidth) * util.MB,
+	}
+}
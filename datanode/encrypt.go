@@ -0,0 +1,234 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	authSDK "github.com/chubaofs/chubaofs/sdk/auth"
+	masterSDK "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/cryptoutil"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// The data keys of the encrypted volumes are pulled from the master with the ticket of the data node issued by the
+// authnode, the new extents are encrypted by the current key of their volume when they are created, and the
+// encryptor of a disk re-encrypts the cold extents written by a former key or before the volume is encrypted at the
+// limited rate, so the keys rotated are retired in the background.
+
+const (
+	DefaultEncryptColdAge   = 600 // seconds an extent is not modified before it is re-encrypted
+	DefaultEncryptBandwidth = 20  // MB per second to re-encrypt the extents of a disk
+	encryptRoundInterval    = 10 * time.Minute
+)
+
+var (
+	encryptColdAge   int64 = DefaultEncryptColdAge
+	encryptBandwidth int64 = DefaultEncryptBandwidth * util.MB // bytes per second, 0 to stop re-encrypting
+)
+
+// volKeys holds the data keys of the encrypted volumes, which are pulled from the master.
+var volKeys = struct {
+	sync.RWMutex
+	keys map[string]*proto.VolEncryptionKeys
+}{keys: make(map[string]*proto.VolEncryptionKeys)}
+
+func updateVolKeys(keys map[string]*proto.VolEncryptionKeys) {
+	volKeys.Lock()
+	defer volKeys.Unlock()
+	volKeys.keys = keys
+}
+
+func volEncryptionKeys(volName string) *proto.VolEncryptionKeys {
+	volKeys.RLock()
+	defer volKeys.RUnlock()
+	return volKeys.keys[volName]
+}
+
+// keyClient is the master client with the ticket to fetch the data keys, it is nil if the authnode is not configured.
+type keyClient struct {
+	sync.Mutex
+	authNodes  []string
+	clientID   string
+	clientKey  string
+	mc         *masterSDK.MasterClient
+	ticketTime time.Time
+}
+
+var volKeyClient *keyClient
+
+func newKeyClient(authNodes []string, clientID, clientKey string, masters []string) *keyClient {
+	return &keyClient{
+		authNodes: authNodes,
+		clientID:  clientID,
+		clientKey: clientKey,
+		mc:        masterSDK.NewMasterClient(masters, false),
+	}
+}
+
+func (c *keyClient) refreshTicket(force bool) (err error) {
+	if !force && time.Since(c.ticketTime) < cryptoutil.TicketAge/2*time.Second {
+		return
+	}
+	ticket, err := authSDK.NewAuthClient(c.authNodes, false, "").API().GetTicket(c.clientID, c.clientKey, proto.MasterServiceID)
+	if err != nil {
+		return fmt.Errorf("get ticket: %v", err)
+	}
+	if err = c.mc.SetAuthTicket(c.clientID, ticket.Ticket, ticket.SessionKey); err != nil {
+		return
+	}
+	c.ticketTime = time.Now()
+	return
+}
+
+// fetchKeys fetches the data keys of the encrypted volumes, the ticket is refreshed and the keys are fetched again
+// if the master rejects the ticket.
+func (c *keyClient) fetchKeys() (keys map[string]*proto.VolEncryptionKeys, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if err = c.refreshTicket(false); err != nil {
+		return
+	}
+	if keys, err = c.mc.AdminAPI().GetVolEncryptionKeys(); err == nil {
+		return
+	}
+	log.LogWarnf("action[fetchKeys] fetch vol keys err(%v), refresh the ticket", err)
+	if err = c.refreshTicket(true); err != nil {
+		return
+	}
+	return c.mc.AdminAPI().GetVolEncryptionKeys()
+}
+
+// updateVolEncryptionKeys pulls the data keys of the encrypted volumes and sets them to the loaded partitions.
+func (s *DataNode) updateVolEncryptionKeys() (err error) {
+	if volKeyClient == nil {
+		return
+	}
+	keys, err := volKeyClient.fetchKeys()
+	if err != nil {
+		return
+	}
+	updateVolKeys(keys)
+	if s.space == nil {
+		return
+	}
+	s.space.RangePartitions(func(dp *DataPartition) bool {
+		dp.updateEncryptionKeys()
+		return true
+	})
+	return
+}
+
+// updateEncryptionKeys sets the data keys of the volume to the extent store of the partition.
+func (dp *DataPartition) updateEncryptionKeys() {
+	volKey := volEncryptionKeys(dp.volumeID)
+	if volKey == nil {
+		return
+	}
+	keys := make(map[uint32][]byte, len(volKey.Keys))
+	for version, encoded := range volKey.Keys {
+		key, err := cryptoutil.Base64Decode(encoded)
+		if err != nil {
+			log.LogErrorf("action[updateEncryptionKeys] partition(%v) key(%v) err(%v)", dp.partitionID, version, err)
+			continue
+		}
+		keys[version] = key
+	}
+	dp.ExtentStore().SetEncryptionKeys(volKey.Current, keys)
+}
+
+// PartitionEncryption is the encryption status of a partition.
+type PartitionEncryption struct {
+	KeyVersion       uint32 `json:"keyVersion"`
+	EncryptedExtents int    `json:"encryptedExtents"`
+	StaleKeyExtents  int    `json:"staleKeyExtents"` // extents to be re-encrypted by the current key
+}
+
+// Encryption returns the encryption status of the partition.
+func (dp *DataPartition) Encryption() (e PartitionEncryption) {
+	e.KeyVersion, e.EncryptedExtents, e.StaleKeyExtents = dp.ExtentStore().EncryptionStats()
+	return
+}
+
+// startEncrypt re-encrypts the cold extents of the partitions on the disk until the data node stops.
+func (d *Disk) startEncrypt() {
+	limiter := rate.NewLimiter(rate.Inf, util.BlockSize)
+	ctx := context.Background()
+	wait := func(size int) {
+		if bandwidth := atomic.LoadInt64(&encryptBandwidth); bandwidth > 0 && limiter.Limit() != rate.Limit(bandwidth) {
+			setLimiter(limiter, uint64(bandwidth))
+		}
+		limiter.WaitN(ctx, size)
+	}
+	for {
+		if atomic.LoadInt64(&encryptBandwidth) > 0 {
+			d.encryptRound(wait)
+		}
+		if !d.waitScrub(encryptRoundInterval) {
+			return
+		}
+	}
+}
+
+func (d *Disk) encryptRound(wait func(size int)) {
+	partitions := make([]*DataPartition, 0)
+	d.RLock()
+	for _, dp := range d.partitionMap {
+		partitions = append(partitions, dp)
+	}
+	d.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].partitionID < partitions[j].partitionID })
+	for _, dp := range partitions {
+		if !dp.encrypt(wait) {
+			return
+		}
+	}
+}
+
+// encrypt re-encrypts the cold extents of the partition by the current key of its volume, it returns false if the
+// re-encryption is disabled or the data node is stopping.
+func (dp *DataPartition) encrypt(wait func(size int)) bool {
+	store := dp.ExtentStore()
+	if store.CurrentKeyVersion() == 0 {
+		return true
+	}
+	for _, extentID := range store.EncryptCandidates(atomic.LoadInt64(&encryptColdAge)) {
+		if atomic.LoadInt64(&encryptBandwidth) == 0 {
+			return false
+		}
+		select {
+		case <-dp.disk.space.stopC:
+			return false
+		case <-dp.stopC:
+			return true
+		default:
+		}
+		if err := store.EncryptExtent(extentID, wait); err != nil {
+			if dp.checkIsDiskError(err) {
+				return true
+			}
+			log.LogWarnf("action[encrypt] partition(%v) extent(%v) err(%v)", dp.partitionID, extentID, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+)
+
+var volIPAcls proto.VolIPAclStore
+
+// isClientPacket returns whether the packet is sent by a client rather than by the other nodes, the appends and
+// the new extents are forwarded to the followers by the leader, and the extents are deleted by the meta nodes.
+func isClientPacket(p *repl.Packet) bool {
+	switch p.Opcode {
+	case proto.OpStreamRead, proto.OpRead, proto.OpStreamFollowerRead, proto.OpRandomWrite, proto.OpSyncRandomWrite:
+		return true
+	case proto.OpWrite, proto.OpSyncWrite, proto.OpCreateExtent:
+		return p.IsLeaderPacket()
+	}
+	return false
+}
+
+// checkVolIPAcl rejects the packet of the client if the client is denied by the volume of the partition.
+func checkVolIPAcl(p *repl.Packet, remoteIP net.IP) error {
+	partition, ok := p.Object.(*DataPartition)
+	if !ok || !isClientPacket(p) {
+		return nil
+	}
+	if acl := volIPAcls.Get(partition.volumeID); !acl.Allowed(remoteIP) {
+		return fmt.Errorf("client %v is denied by vol %v", remoteIP, partition.volumeID)
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/compress"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The leaders of the meta partitions transit the files by the lifecycle rules of the volumes, and ask each replica
+// to convert the extents of the files to the storage classes. The transitions are persisted in the partition
+// directory when they are accepted, and the compressor of the disk converts the extents before it compresses the
+// cold ones, at the same rate: the extents of the cold class are moved out of the cache disk and compressed with the
+// algorithm of the volume, or lz4 if the volume has no compression, and the ones of the replica class are
+// decompressed. The extents written later are decompressed before they are written like the other compressed ones,
+// and the read extents may be promoted into the cache disk again.
+
+const (
+	TransitionFileName     = "TRANSITION"
+	TempTransitionFileName = ".transition"
+)
+
+// ActionTransitExtents is the action of the requests to transit the extents
+const ActionTransitExtents = "ActionTransitExtents"
+
+type transitionTracker struct {
+	sync.Mutex
+	pending map[uint64]string // extent ID -> storage class
+}
+
+func (s *DataNode) handlePacketToTransitExtents(p *repl.Packet) {
+	var (
+		err error
+		req = &proto.TransitExtentsRequest{}
+	)
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionTransitExtents, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		return
+	}
+	if !proto.IsValidStorageClass(req.StorageClass) {
+		err = fmt.Errorf("unknown storage class %v", req.StorageClass)
+		return
+	}
+	partition := p.Object.(*DataPartition)
+	err = partition.addTransitions(req.ExtentIDs, req.StorageClass)
+	return
+}
+
+// addTransitions persists the transitions of the normal extents, the latest class of an extent wins.
+func (dp *DataPartition) addTransitions(extentIDs []uint64, class string) (err error) {
+	t := &dp.transitions
+	t.Lock()
+	defer t.Unlock()
+	pending := make(map[uint64]string, len(t.pending)+len(extentIDs))
+	for extentID, c := range t.pending {
+		pending[extentID] = c
+	}
+	for _, extentID := range extentIDs {
+		if !storage.IsTinyExtent(extentID) {
+			pending[extentID] = class
+		}
+	}
+	if err = dp.persistTransitions(pending); err != nil {
+		return
+	}
+	t.pending = pending
+	return
+}
+
+// finishTransitions drops the extents converted from the pending transitions, unless they are transited to another
+// class in the meantime.
+func (dp *DataPartition) finishTransitions(done map[uint64]string) {
+	if len(done) == 0 {
+		return
+	}
+	t := &dp.transitions
+	t.Lock()
+	defer t.Unlock()
+	pending := make(map[uint64]string, len(t.pending))
+	for extentID, class := range t.pending {
+		if done[extentID] != class {
+			pending[extentID] = class
+		}
+	}
+	if err := dp.persistTransitions(pending); err != nil {
+		log.LogWarnf("action[finishTransitions] partition(%v) err(%v)", dp.partitionID, err)
+		return
+	}
+	t.pending = pending
+}
+
+func (dp *DataPartition) persistTransitions(pending map[uint64]string) (err error) {
+	if len(pending) == 0 {
+		if err = os.Remove(path.Join(dp.Path(), TransitionFileName)); os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return
+	}
+	fileName := path.Join(dp.Path(), TempTransitionFileName)
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return
+	}
+	defer os.Remove(fileName)
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(fileName, path.Join(dp.Path(), TransitionFileName))
+}
+
+// loadTransitions loads the pending transitions persisted, which are dropped if they are unreadable since the meta
+// nodes transit the files again.
+func (dp *DataPartition) loadTransitions() {
+	data, err := ioutil.ReadFile(path.Join(dp.Path(), TransitionFileName))
+	if os.IsNotExist(err) {
+		return
+	}
+	pending := make(map[uint64]string)
+	if err == nil {
+		err = json.Unmarshal(data, &pending)
+	}
+	if err != nil {
+		log.LogErrorf("action[loadTransitions] partition(%v) err(%v), the transitions are dropped", dp.partitionID, err)
+		os.Remove(path.Join(dp.Path(), TransitionFileName))
+		return
+	}
+	dp.transitions.pending = pending
+}
+
+// transit converts the extents of the pending transitions, it returns false if the compression is disabled or the
+// data node is stopping. The extents failed are converted again by the next round.
+func (dp *DataPartition) transit(wait func(size int)) bool {
+	t := &dp.transitions
+	t.Lock()
+	extentIDs := make([]uint64, 0, len(t.pending))
+	classes := make(map[uint64]string, len(t.pending))
+	for extentID, class := range t.pending {
+		extentIDs = append(extentIDs, extentID)
+		classes[extentID] = class
+	}
+	t.Unlock()
+	if len(extentIDs) == 0 {
+		return true
+	}
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	algorithm := volCompression(dp.volumeID)
+	if algorithm == compress.AlgorithmNone {
+		algorithm = compress.AlgorithmLZ4
+	}
+	store := dp.ExtentStore()
+	done := make(map[uint64]string)
+	defer func() {
+		dp.finishTransitions(done)
+	}()
+	for _, extentID := range extentIDs {
+		if atomic.LoadInt64(&compressBandwidth) == 0 {
+			return false
+		}
+		select {
+		case <-dp.disk.space.stopC:
+			return false
+		case <-dp.stopC:
+			return true
+		default:
+		}
+		class := classes[extentID]
+		err := dp.transitExtent(store, extentID, class, algorithm, wait)
+		if err == storage.ExtentNotFoundError || !store.HasExtent(extentID) {
+			done[extentID] = class
+			continue
+		}
+		if err != nil {
+			if dp.checkIsDiskError(err) {
+				return true
+			}
+			log.LogWarnf("action[transit] partition(%v) extent(%v) class(%v) err(%v)", dp.partitionID, extentID,
+				class, err)
+			continue
+		}
+		done[extentID] = class
+	}
+	return true
+}
+
+func (dp *DataPartition) transitExtent(store *storage.ExtentStore, extentID uint64, class, algorithm string,
+	wait func(size int)) (err error) {
+	if class == proto.StorageClassReplica {
+		return store.DecompressExtent(extentID)
+	}
+	if store.IsPackedExtent(extentID) {
+		// the packed extents are small and stored in the pack files, which are not compressed
+		return
+	}
+	if store.IsCachedExtent(extentID) {
+		if err = store.MoveExtent(extentID, false, wait); err != nil {
+			return
+		}
+	}
+	return store.CompressExtent(extentID, algorithm, wait)
+}
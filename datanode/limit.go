@@ -3,14 +3,20 @@ package datanode
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/util"
 	"golang.org/x/time/rate"
 )
 
+const repairLimitBurst = util.BlockSize
+
 var (
 	deleteLimiteRater       = rate.NewLimiter(rate.Inf, defaultMarkDeleteLimitBurst)
 	MaxExtentRepairLimit    = 20000
 	MinExtentRepairLimit    = 5
 	extentRepairLimiteRater = make(chan struct{}, MaxExtentRepairLimit)
+	repairBandwidth         uint64 // bytes per second to repair a data partition, 0 for no limit
 )
 
 func requestDoExtentRepair() (err error) {
@@ -53,6 +59,31 @@ func DeleteLimiterWait() {
 	deleteLimiteRater.Wait(ctx)
 }
 
+func setRepairBandwidth(value uint64) {
+	atomic.StoreUint64(&repairBandwidth, value)
+}
+
+// waitRepairBandwidth throttles the repair of the partition by the repair bandwidth pulled from the master, which
+// caps the traffic to recover the new replica of a migrated partition.
+func (dp *DataPartition) waitRepairBandwidth(size int) {
+	bandwidth := atomic.LoadUint64(&repairBandwidth)
+	if bandwidth == 0 && dp.repairLimiter.Limit() == rate.Inf {
+		return
+	}
+	if dp.repairLimiter.Limit() != rate.Limit(bandwidth) {
+		setLimiter(dp.repairLimiter, bandwidth)
+	}
+	ctx := context.Background()
+	for size > 0 {
+		n := size
+		if n > repairLimitBurst {
+			n = repairLimitBurst
+		}
+		dp.repairLimiter.WaitN(ctx, n)
+		size -= n
+	}
+}
+
 func setLimiter(limiter *rate.Limiter, limitValue uint64) {
 	r := limitValue
 	l := rate.Limit(r)
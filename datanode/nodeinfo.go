@@ -43,7 +43,40 @@ func (m *DataNode) updateNodeInfo() {
 	}
 	setLimiter(deleteLimiteRater, clusterInfo.DataNodeDeleteLimitRate)
 	setDoExtentRepair(int(clusterInfo.DataNodeAutoRepairLimitRate))
+	setRepairBandwidth(clusterInfo.DataNodeRepairBandwidth)
 	log.LogInfof("updateNodeInfo from master:"+
-		"deleteLimite(%v),autoRepairLimit(%v)", clusterInfo.DataNodeDeleteLimitRate,
-		clusterInfo.DataNodeAutoRepairLimitRate)
+		"deleteLimite(%v),autoRepairLimit(%v),repairBandwidth(%v)", clusterInfo.DataNodeDeleteLimitRate,
+		clusterInfo.DataNodeAutoRepairLimitRate, clusterInfo.DataNodeRepairBandwidth)
+	volQos, err := MasterClient.AdminAPI().GetVolQos()
+	if err != nil {
+		log.LogErrorf("[updateDataNodeInfo] get vol qos: %s", err.Error())
+		return
+	}
+	updateVolQos(volQos)
+	log.LogInfof("updateNodeInfo from master: volQos(%v)", volQos)
+	volIPAcl, err := MasterClient.AdminAPI().GetVolIPAcl()
+	if err != nil {
+		log.LogErrorf("[updateDataNodeInfo] get vol ip acl: %s", err.Error())
+		return
+	}
+	volIPAcls.Update(volIPAcl)
+	log.LogInfof("updateNodeInfo from master: volIPAcl(%v)", volIPAcl)
+	verifyVols, err := MasterClient.AdminAPI().GetVerifyReadCrcVols()
+	if err != nil {
+		log.LogErrorf("[updateDataNodeInfo] get verify read crc vols: %s", err.Error())
+		return
+	}
+	updateVerifyReadCrcVols(verifyVols)
+	log.LogInfof("updateNodeInfo from master: verifyReadCrcVols(%v)", verifyVols)
+	compressionAlgorithms, err := MasterClient.AdminAPI().GetCompressionVols()
+	if err != nil {
+		log.LogErrorf("[updateDataNodeInfo] get compression vols: %s", err.Error())
+		return
+	}
+	updateCompressionVols(compressionAlgorithms)
+	log.LogInfof("updateNodeInfo from master: compressionVols(%v)", compressionAlgorithms)
+	if err = m.updateVolEncryptionKeys(); err != nil {
+		log.LogErrorf("[updateDataNodeInfo] get vol encryption keys: %s", err.Error())
+		return
+	}
 }
@@ -32,6 +32,7 @@ import (
 	"sort"
 	"syscall"
 
+	raftProto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
 	"github.com/chubaofs/chubaofs/repl"
@@ -39,7 +40,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
-	raftProto "github.com/tiglabs/raft/proto"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -118,6 +119,12 @@ type DataPartition struct {
 	loadExtentHeaderStatus        int
 	DataPartitionCreateType       int
 	isLoadingDataPartition        bool
+	repairLimiter                 *rate.Limiter
+	corruptExtents                map[uint64][]int // corrupt blocks of the extents found by the scrubber
+	corruptLock                   sync.RWMutex
+	repairTracker                 repairTracker
+	snapshotBarrier               volSnapshotBarrier
+	transitions                   transitionTracker
 }
 
 func CreateDataPartition(dpCfg *dataPartitionCfg, disk *Disk, request *proto.CreateDataPartitionRequest) (dp *DataPartition, err error) {
@@ -125,6 +132,12 @@ func CreateDataPartition(dpCfg *dataPartitionCfg, disk *Disk, request *proto.Cre
 	if dp, err = newDataPartition(dpCfg, disk); err != nil {
 		return
 	}
+	// the extents of the volume with snapshots must not be overwritten in place before the replica is leader
+	if request.SnapshotEpoch > 0 {
+		if err = dp.operateVolSnapshotBarrier(request.SnapshotEpoch, proto.DataSnapshotFreeze); err != nil {
+			return nil, err
+		}
+	}
 	dp.ForceLoadHeader()
 	if request.CreateType == proto.NormalCreateDataPartition {
 		err = dp.StartRaft()
@@ -213,6 +226,8 @@ func LoadDataPartition(partitionDir string, disk *Disk) (dp *DataPartition, err
 	log.LogInfof("Action(LoadDataPartition) PartitionID(%v) meta(%v)", dp.partitionID, meta)
 	dp.DataPartitionCreateType = meta.DataPartitionCreateType
 	dp.lastTruncateID = meta.LastTruncateID
+	dp.loadVolSnapshotBarrier()
+	dp.loadTransitions()
 	if meta.DataPartitionCreateType == proto.NormalCreateDataPartition {
 		err = dp.StartRaft()
 	} else {
@@ -226,6 +241,7 @@ func LoadDataPartition(partitionDir string, disk *Disk) (dp *DataPartition, err
 	go dp.StartRaftLoggingSchedule()
 	disk.AddSize(uint64(dp.Size()))
 	dp.ForceLoadHeader()
+	go dp.resumeRepair()
 	return
 }
 
@@ -246,12 +262,20 @@ func newDataPartition(dpCfg *dataPartitionCfg, disk *Disk) (dp *DataPartition, e
 		snapshot:        make([]*proto.File, 0),
 		partitionStatus: proto.ReadWrite,
 		config:          dpCfg,
+		repairLimiter:   rate.NewLimiter(rate.Inf, repairLimitBurst),
+		corruptExtents:  make(map[uint64][]int),
 	}
 	partition.replicasInit()
 	partition.extentStore, err = storage.NewExtentStore(partition.path, dpCfg.PartitionID, dpCfg.PartitionSize)
 	if err != nil {
 		return
 	}
+	partition.updateEncryptionKeys()
+	if cacheDisk := disk.space.CacheDiskOf(disk.Path); cacheDisk != nil {
+		if err = partition.extentStore.SetCacheDir(cacheDisk.partitionCacheDir(partitionID), cacheDisk.HasSpace); err != nil {
+			return
+		}
+	}
 
 	disk.AttachDataPartition(partition)
 	dp = partition
@@ -661,7 +685,7 @@ func (dp *DataPartition) DoExtentStoreRepair(repairTask *DataPartitionRepairTask
 			continue
 		}
 		if store.HasExtent(uint64(extentInfo.FileID)) {
-			info := &storage.ExtentInfo{Source: extentInfo.Source, FileID: extentInfo.FileID, Size: extentInfo.Size}
+			info := &storage.ExtentInfo{Source: extentInfo.Source, Sources: extentInfo.Sources, FileID: extentInfo.FileID, Size: extentInfo.Size}
 			repairTask.ExtentsToBeRepaired = append(repairTask.ExtentsToBeRepaired, info)
 			continue
 		}
@@ -673,30 +697,16 @@ func (dp *DataPartition) DoExtentStoreRepair(repairTask *DataPartitionRepairTask
 		if err != nil {
 			continue
 		}
-		info := &storage.ExtentInfo{Source: extentInfo.Source, FileID: extentInfo.FileID, Size: extentInfo.Size}
+		info := &storage.ExtentInfo{Source: extentInfo.Source, Sources: extentInfo.Sources, FileID: extentInfo.FileID, Size: extentInfo.Size}
 		repairTask.ExtentsToBeRepaired = append(repairTask.ExtentsToBeRepaired, info)
 	}
-	var (
-		wg           *sync.WaitGroup
-		recoverIndex int
-	)
-	wg = new(sync.WaitGroup)
+	extents := make([]*storage.ExtentInfo, 0, len(repairTask.ExtentsToBeRepaired))
 	for _, extentInfo := range repairTask.ExtentsToBeRepaired {
-
-		if !store.HasExtent(uint64(extentInfo.FileID)) {
-			continue
-		}
-		wg.Add(1)
-
-		// repair the extents
-		go dp.doStreamExtentFixRepair(wg, extentInfo)
-		recoverIndex++
-
-		if recoverIndex%NumOfFilesToRecoverInParallel == 0 {
-			wg.Wait()
+		if store.HasExtent(uint64(extentInfo.FileID)) {
+			extents = append(extents, extentInfo)
 		}
 	}
-	wg.Wait()
+	dp.repairExtents(extents, NumOfFilesToRecoverInParallel, nil)
 	dp.doStreamFixTinyDeleteRecord(repairTask)
 }
 
@@ -27,13 +27,13 @@ import (
 	"sync/atomic"
 	"time"
 
+	raftproto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
 	"github.com/chubaofs/chubaofs/repl"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
-	raftproto "github.com/tiglabs/raft/proto"
 )
 
 type dataPartitionCfg struct {
@@ -372,6 +372,43 @@ func (dp *DataPartition) removeRaftNode(req *proto.RemoveDataPartitionRaftMember
 	return
 }
 
+// ResetRaftMember forcibly resets the raft members with the specified peers. It is only used to recover
+// the partition whose majority of replicas are lost, so the local replica must pass the crc validation
+// before it is promoted, and the local node must be one of the new peers.
+func (dp *DataPartition) ResetRaftMember(peers []proto.Peer) (err error) {
+	var isMember bool
+	hosts := make([]string, 0, len(peers))
+	raftPeers := make([]raftproto.Peer, 0, len(peers))
+	for _, peer := range peers {
+		if peer.ID == dp.config.NodeID {
+			isMember = true
+		}
+		hosts = append(hosts, peer.Addr)
+		raftPeers = append(raftPeers, raftproto.Peer{ID: peer.ID})
+	}
+	if !isMember {
+		err = errors.NewErrorf("ResetRaftMember: node[%v] is not in new peers %v", dp.config.NodeID, peers)
+		return
+	}
+	if err = dp.extentStore.VerifyExtentsCrc(); err != nil {
+		err = errors.Trace(err, "ResetRaftMember: partition[%v] crc validation failed", dp.partitionID)
+		return
+	}
+	if err = dp.raftPartition.ResetMember(raftPeers); err != nil {
+		return
+	}
+	log.LogWarnf("ResetRaftMember: partitionID(%v) nodeID(%v) reset peers from %v to %v",
+		dp.partitionID, dp.config.NodeID, dp.config.Peers, peers)
+	dp.config.Peers = peers
+	dp.config.Hosts = hosts
+	dp.replicasLock.Lock()
+	dp.replicas = make([]string, len(hosts))
+	copy(dp.replicas, hosts)
+	dp.replicasLock.Unlock()
+	err = dp.PersistMetadata()
+	return
+}
+
 func (dp *DataPartition) storeAppliedID(applyIndex uint64) (err error) {
 	filename := path.Join(dp.Path(), TempApplyIndexFile)
 	fp, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_TRUNC|os.O_CREATE, 0755)
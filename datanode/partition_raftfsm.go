@@ -22,11 +22,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	raftproto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/storage"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft"
-	raftproto "github.com/tiglabs/raft/proto"
 )
 
 /* The functions below implement the interfaces defined in the raft library. */
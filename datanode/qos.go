@@ -0,0 +1,86 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/chubaofs/chubaofs/util/qos"
+)
+
+// volQosLimiters holds the limiters of the volumes with the QoS limits, which are pulled from the master.
+var volQosLimiters = struct {
+	sync.RWMutex
+	limiters map[string]*qos.Limiter
+}{limiters: make(map[string]*qos.Limiter)}
+
+// updateVolQos applies the QoS limits of the volumes, and removes the limiters of the volumes which are no longer
+// limited.
+func updateVolQos(volQos map[string]proto.VolQos) {
+	volQosLimiters.Lock()
+	defer volQosLimiters.Unlock()
+	for name, limiter := range volQosLimiters.limiters {
+		if _, ok := volQos[name]; !ok {
+			limiter.Update(0, 0, 0, 0)
+			delete(volQosLimiters.limiters, name)
+		}
+	}
+	for name, limits := range volQos {
+		limiter, ok := volQosLimiters.limiters[name]
+		if !ok {
+			limiter = qos.NewLimiter()
+			volQosLimiters.limiters[name] = limiter
+		}
+		limiter.Update(limits.ReadIops, limits.WriteIops, limits.ReadBps, limits.WriteBps)
+	}
+}
+
+func getVolQosLimiter(volName string) *qos.Limiter {
+	volQosLimiters.RLock()
+	defer volQosLimiters.RUnlock()
+	return volQosLimiters.limiters[volName]
+}
+
+// waitVolQos throttles the reads and the writes of the clients by the QoS limits of the volume. The appended data
+// is throttled by the leader only, since the followers receive it from the leader.
+func waitVolQos(p *repl.Packet) {
+	partition, ok := p.Object.(*DataPartition)
+	if !ok {
+		return
+	}
+	limiter := getVolQosLimiter(partition.volumeID)
+	if limiter == nil {
+		return
+	}
+	var err error
+	switch p.Opcode {
+	case proto.OpStreamRead, proto.OpStreamFollowerRead:
+		err = limiter.WaitRead(context.Background(), int(p.Size))
+	case proto.OpWrite, proto.OpSyncWrite:
+		if !p.IsLeaderPacket() {
+			return
+		}
+		err = limiter.WaitWrite(context.Background(), int(p.Size))
+	case proto.OpRandomWrite, proto.OpSyncRandomWrite:
+		err = limiter.WaitWrite(context.Background(), int(p.Size))
+	}
+	if err != nil {
+		log.LogWarnf("action[waitVolQos] vol[%v] op[%v] err[%v]", partition.volumeID, p.GetOpMsg(), err)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// verifyReadCrcVols holds the names of the volumes whose data read by the clients is checked against the crc of the
+// extent blocks, which are pulled from the master.
+var verifyReadCrcVols = struct {
+	sync.RWMutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+func updateVerifyReadCrcVols(names []string) {
+	verifyReadCrcVols.Lock()
+	defer verifyReadCrcVols.Unlock()
+	verifyReadCrcVols.names = make(map[string]bool, len(names))
+	for _, name := range names {
+		verifyReadCrcVols.names[name] = true
+	}
+}
+
+func isVerifyReadCrcVol(volName string) bool {
+	verifyReadCrcVols.RLock()
+	defer verifyReadCrcVols.RUnlock()
+	return verifyReadCrcVols.names[volName]
+}
+
+// verifyRead checks the data read by a client against the crc of the extent blocks if the volume requires it. The
+// corrupt blocks are recorded to be repaired by the scrubber, and an error with proto.ErrDataCorrupted is returned
+// instead of the data, so that the client reads it from another replica.
+func (dp *DataPartition) verifyRead(extentID uint64, offset, size int64, data []byte) (err error) {
+	if !isVerifyReadCrcVol(dp.volumeID) {
+		return
+	}
+	corrupt, err := dp.ExtentStore().VerifyRead(extentID, offset, size, data)
+	if err != nil || len(corrupt) == 0 {
+		return
+	}
+	msg := fmt.Sprintf("read: partition(%v) extent(%v) has corrupt blocks %v on %v(%v)", dp.partitionID,
+		extentID, corrupt, LocalIP, dp.disk.Path)
+	exporter.Warning(msg)
+	log.LogError(msg)
+	dp.addCorruptBlocks(extentID, corrupt)
+	return fmt.Errorf("%v: partition(%v) extent(%v) blocks %v", proto.ErrDataCorrupted, dp.partitionID, extentID,
+		corrupt)
+}
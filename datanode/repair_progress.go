@@ -0,0 +1,301 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The normal extents to repair on a replica are checkpointed in the partition directory, along with the sizes to
+// repair them to and their sources, and the checkpoint drops the extents as they are repaired. If the data node
+// restarts in the middle, the extents left in the checkpoint are repaired once the partition is loaded again. An
+// extent is always repaired from its local size, so the extents partially repaired go on from where they stopped.
+// The repair of the tiny extents is not checkpointed, since the broken tiny extents are repaired again anyway.
+
+const (
+	RepairCheckpointFileName     = "REPAIR"
+	TempRepairCheckpointFileName = ".repair"
+	repairCheckpointInterval     = 10 * time.Second
+)
+
+// RepairProgress is the progress of the latest repair of the normal extents of a partition.
+type RepairProgress struct {
+	PartitionID    uint64 `json:"partitionID"`
+	Running        bool   `json:"running"`
+	Resumed        bool   `json:"resumed"` // resumed from the checkpoint after the data node restarts
+	StartTime      int64  `json:"startTime"`
+	FinishTime     int64  `json:"finishTime"`
+	TotalExtents   int    `json:"totalExtents"`
+	DoneExtents    int    `json:"doneExtents"`
+	FailedExtents  int    `json:"failedExtents"` // repaired again by the next repair of the partition
+	TotalBytes     uint64 `json:"totalBytes"`
+	DoneBytes      uint64 `json:"doneBytes"`
+	CheckpointTime int64  `json:"checkpointTime"`
+}
+
+// repairCheckpoint is the progress persisted with the extents not repaired yet.
+type repairCheckpoint struct {
+	StartTime     int64
+	TotalExtents  int
+	DoneExtents   int
+	FailedExtents int
+	TotalBytes    uint64
+	DoneBytes     uint64
+	Extents       []*storage.ExtentInfo
+}
+
+type repairTracker struct {
+	running      sync.Mutex // held by the repair of the partition, so the extents are not repaired concurrently
+	sync.RWMutex            // protects the progress and the pending extents
+	progress     RepairProgress
+	pending      map[uint64]*storage.ExtentInfo
+}
+
+// repairExtents repairs the extents from their sources by the given number of workers, so the extents from different
+// sources are repaired in parallel. The repair of the normal extents is checkpointed, and resumed is the checkpoint
+// loaded if the repair is resumed by it.
+func (dp *DataPartition) repairExtents(extents []*storage.ExtentInfo, parallel int, resumed *repairCheckpoint) {
+	if len(extents) == 0 {
+		return
+	}
+	t := &dp.repairTracker
+	t.running.Lock()
+	defer t.running.Unlock()
+	tracked := dp.startRepairProgress(extents, resumed)
+	if tracked {
+		dp.checkpointRepair()
+	}
+	extentC := make(chan *storage.ExtentInfo)
+	wg := new(sync.WaitGroup)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for extentInfo := range extentC {
+				dp.doStreamExtentFixRepair(extentInfo)
+			}
+		}()
+	}
+	stopped := false
+	lastCheckpoint := time.Now()
+	for _, extentInfo := range extents {
+		select {
+		case <-dp.stopC:
+			stopped = true
+		case extentC <- extentInfo:
+		}
+		if stopped {
+			break
+		}
+		if tracked && time.Since(lastCheckpoint) >= repairCheckpointInterval {
+			dp.checkpointRepair()
+			lastCheckpoint = time.Now()
+		}
+	}
+	close(extentC)
+	wg.Wait()
+	if stopped {
+		// the checkpoint is kept to resume the repair after the partition is loaded again
+		dp.stopRepairProgress()
+		return
+	}
+	if tracked {
+		dp.finishRepairProgress()
+	}
+}
+
+// startRepairProgress starts tracking the normal extents to repair, it returns false if there is none.
+func (dp *DataPartition) startRepairProgress(extents []*storage.ExtentInfo, resumed *repairCheckpoint) bool {
+	pending := make(map[uint64]*storage.ExtentInfo)
+	var totalBytes uint64
+	for _, extentInfo := range extents {
+		if storage.IsTinyExtent(extentInfo.FileID) {
+			continue
+		}
+		pending[extentInfo.FileID] = extentInfo
+		var localSize uint64
+		if local, err := dp.extentStore.Watermark(extentInfo.FileID); err == nil {
+			localSize = local.Size
+		}
+		if extentInfo.Size > localSize {
+			totalBytes += extentInfo.Size - localSize
+		}
+	}
+	if len(pending) == 0 {
+		return false
+	}
+	progress := RepairProgress{
+		PartitionID:  dp.partitionID,
+		Running:      true,
+		StartTime:    time.Now().Unix(),
+		TotalExtents: len(pending),
+		TotalBytes:   totalBytes,
+	}
+	if resumed != nil {
+		progress.Resumed = true
+		progress.StartTime = resumed.StartTime
+		progress.TotalExtents = resumed.TotalExtents
+		progress.DoneExtents = resumed.DoneExtents
+		progress.FailedExtents = resumed.FailedExtents
+		progress.TotalBytes = resumed.DoneBytes + totalBytes
+		progress.DoneBytes = resumed.DoneBytes
+	}
+	t := &dp.repairTracker
+	t.Lock()
+	t.progress = progress
+	t.pending = pending
+	t.Unlock()
+	return true
+}
+
+// finishRepairExtent drops the extent from the checkpoint once it is repaired or fails.
+func (dp *DataPartition) finishRepairExtent(extentID uint64, err error) {
+	t := &dp.repairTracker
+	t.Lock()
+	defer t.Unlock()
+	if _, ok := t.pending[extentID]; !ok {
+		return
+	}
+	delete(t.pending, extentID)
+	if err != nil {
+		t.progress.FailedExtents++
+	} else {
+		t.progress.DoneExtents++
+	}
+}
+
+// addRepairedBytes counts the bytes written to repair the normal extents.
+func (dp *DataPartition) addRepairedBytes(extentID uint64, size uint64) {
+	t := &dp.repairTracker
+	t.Lock()
+	defer t.Unlock()
+	if _, ok := t.pending[extentID]; ok {
+		t.progress.DoneBytes += size
+	}
+}
+
+func (dp *DataPartition) stopRepairProgress() {
+	t := &dp.repairTracker
+	t.Lock()
+	defer t.Unlock()
+	t.progress.Running = false
+	t.pending = nil
+}
+
+func (dp *DataPartition) finishRepairProgress() {
+	t := &dp.repairTracker
+	t.Lock()
+	t.progress.Running = false
+	t.progress.FinishTime = time.Now().Unix()
+	t.pending = nil
+	progress := t.progress
+	t.Unlock()
+	if err := os.Remove(path.Join(dp.Path(), RepairCheckpointFileName)); err != nil && !os.IsNotExist(err) {
+		log.LogWarnf("action[finishRepairProgress] partition(%v) remove checkpoint err(%v)", dp.partitionID, err)
+	}
+	log.LogInfof("action[finishRepairProgress] partition(%v) progress(%+v)", dp.partitionID, progress)
+}
+
+// RepairProgress returns the progress of the latest repair of the normal extents, false if there is none since the
+// data node starts.
+func (dp *DataPartition) RepairProgress() (progress RepairProgress, ok bool) {
+	t := &dp.repairTracker
+	t.RLock()
+	defer t.RUnlock()
+	return t.progress, t.progress.StartTime != 0
+}
+
+// checkpointRepair persists the normal extents not repaired yet.
+func (dp *DataPartition) checkpointRepair() {
+	t := &dp.repairTracker
+	t.Lock()
+	cp := &repairCheckpoint{
+		StartTime:     t.progress.StartTime,
+		TotalExtents:  t.progress.TotalExtents,
+		DoneExtents:   t.progress.DoneExtents,
+		FailedExtents: t.progress.FailedExtents,
+		TotalBytes:    t.progress.TotalBytes,
+		DoneBytes:     t.progress.DoneBytes,
+		Extents:       make([]*storage.ExtentInfo, 0, len(t.pending)),
+	}
+	for _, extentInfo := range t.pending {
+		cp.Extents = append(cp.Extents, extentInfo)
+	}
+	t.Unlock()
+	if err := dp.persistRepairCheckpoint(cp); err != nil {
+		log.LogWarnf("action[checkpointRepair] partition(%v) err(%v)", dp.partitionID, err)
+		return
+	}
+	t.Lock()
+	t.progress.CheckpointTime = time.Now().Unix()
+	t.Unlock()
+}
+
+func (dp *DataPartition) persistRepairCheckpoint(cp *repairCheckpoint) (err error) {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return
+	}
+	fileName := path.Join(dp.Path(), TempRepairCheckpointFileName)
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return
+	}
+	defer os.Remove(fileName)
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(fileName, path.Join(dp.Path(), RepairCheckpointFileName))
+}
+
+// resumeRepair repairs the extents left in the checkpoint after the partition is loaded.
+func (dp *DataPartition) resumeRepair() {
+	data, err := ioutil.ReadFile(path.Join(dp.Path(), RepairCheckpointFileName))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.LogErrorf("action[resumeRepair] partition(%v) read checkpoint err(%v)", dp.partitionID, err)
+		return
+	}
+	cp := new(repairCheckpoint)
+	if err = json.Unmarshal(data, cp); err != nil {
+		log.LogErrorf("action[resumeRepair] partition(%v) unmarshal checkpoint err(%v)", dp.partitionID, err)
+		os.Remove(path.Join(dp.Path(), RepairCheckpointFileName))
+		return
+	}
+	log.LogWarnf("action[resumeRepair] partition(%v) resume the repair of %v extents", dp.partitionID, len(cp.Extents))
+	if len(cp.Extents) == 0 {
+		os.Remove(path.Join(dp.Path(), RepairCheckpointFileName))
+		return
+	}
+	dp.repairExtents(cp.Extents, NumOfFilesToRecoverInParallel, cp)
+}
@@ -0,0 +1,325 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// The scrubber of a disk reads the normal extents of its partitions round by round at the limited rate, and checks
+// them against the crc of their blocks, which are computed once the extents are not modified for a while. The corrupt
+// blocks are overwritten with the data read from the other replicas which matches the crc, and the extents whose
+// corrupt blocks cannot be repaired are reported to the master in the heartbeats.
+
+const (
+	DefaultScrubBandwidth = 10 // MB per second to scrub a disk
+	scrubIdleInterval     = time.Minute
+	scrubRoundInterval    = time.Hour // min interval between the starts of two rounds of a disk
+	scrubReadTimeout      = 60        // seconds to read a block from another replica
+)
+
+var scrubBandwidth int64 = DefaultScrubBandwidth * util.MB // bytes per second to scrub a disk, 0 to disable
+
+// setScrubBandwidth sets the bandwidth to scrub a disk in MB per second, the scrubbing is disabled if it is not
+// positive.
+func setScrubBandwidth(value int64) {
+	if value < 0 {
+		value = 0
+	}
+	atomic.StoreInt64(&scrubBandwidth, value*util.MB)
+}
+
+// DiskScrubStatus is the progress of the scrubber of a disk.
+type DiskScrubStatus struct {
+	Path           string `json:"path"`
+	Rounds         uint64 `json:"rounds"`         // rounds finished since the data node starts
+	RoundStartTime int64  `json:"roundStartTime"` // start time of the current or the last round
+	LastRoundTime  int64  `json:"lastRoundTime"`  // end time of the last finished round
+	ScrubbedBytes  uint64 `json:"scrubbedBytes"`  // bytes read by the current or the last round
+	CorruptBlocks  uint64 `json:"corruptBlocks"`  // blocks found corrupt since the data node starts
+	RepairedBlocks uint64 `json:"repairedBlocks"` // blocks repaired from the other replicas
+}
+
+// ScrubStatus returns a copy of the progress of the scrubber.
+func (d *Disk) ScrubStatus() DiskScrubStatus {
+	d.scrubLock.Lock()
+	defer d.scrubLock.Unlock()
+	status := d.scrubStatus
+	status.Path = d.Path
+	return status
+}
+
+func (d *Disk) updateScrubStatus(f func(status *DiskScrubStatus)) {
+	d.scrubLock.Lock()
+	defer d.scrubLock.Unlock()
+	f(&d.scrubStatus)
+}
+
+// startScrub scrubs the partitions on the disk until the data node stops.
+func (d *Disk) startScrub() {
+	limiter := rate.NewLimiter(rate.Inf, util.BlockSize)
+	for {
+		interval := scrubIdleInterval
+		if atomic.LoadInt64(&scrubBandwidth) > 0 {
+			start := time.Now()
+			if d.scrubRound(limiter) {
+				interval = scrubRoundInterval - time.Since(start)
+			}
+		}
+		if !d.waitScrub(interval) {
+			return
+		}
+	}
+}
+
+// waitScrub waits for the duration, it returns false if the data node is stopping.
+func (d *Disk) waitScrub(duration time.Duration) bool {
+	if duration < 0 {
+		duration = 0
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-d.space.stopC:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// scrubRound scrubs the partitions on the disk in the order of their IDs, it returns false if the round is stopped
+// since the scrubbing is disabled.
+func (d *Disk) scrubRound(limiter *rate.Limiter) (finished bool) {
+	partitions := make([]*DataPartition, 0)
+	d.RLock()
+	for _, dp := range d.partitionMap {
+		partitions = append(partitions, dp)
+	}
+	d.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].partitionID < partitions[j].partitionID })
+
+	d.updateScrubStatus(func(status *DiskScrubStatus) {
+		status.RoundStartTime = time.Now().Unix()
+		status.ScrubbedBytes = 0
+	})
+	ctx := context.Background()
+	wait := func(size int) {
+		if bandwidth := atomic.LoadInt64(&scrubBandwidth); bandwidth > 0 && limiter.Limit() != rate.Limit(bandwidth) {
+			setLimiter(limiter, uint64(bandwidth))
+		}
+		limiter.WaitN(ctx, size)
+		d.updateScrubStatus(func(status *DiskScrubStatus) {
+			status.ScrubbedBytes += uint64(size)
+		})
+	}
+	for _, dp := range partitions {
+		if !dp.scrub(wait) {
+			log.LogInfof("action[scrubRound] disk(%v) stopped at partition(%v)", d.Path, dp.partitionID)
+			return false
+		}
+	}
+	d.updateScrubStatus(func(status *DiskScrubStatus) {
+		status.Rounds++
+		status.LastRoundTime = time.Now().Unix()
+	})
+	round := d.ScrubStatus()
+	log.LogInfof("action[scrubRound] disk(%v) partitions(%v) scrubbed(%v) cost(%vs)", d.Path, len(partitions),
+		round.ScrubbedBytes, round.LastRoundTime-round.RoundStartTime)
+	return true
+}
+
+// scrub checks the extents of the partition against the crc of their blocks, and repairs the corrupt blocks from
+// the other replicas. It returns false if the scrubbing is disabled or the data node is stopping.
+func (dp *DataPartition) scrub(wait func(size int)) bool {
+	store := dp.ExtentStore()
+	for _, extentID := range store.ScrubExtentIDs() {
+		if atomic.LoadInt64(&scrubBandwidth) == 0 {
+			return false
+		}
+		select {
+		case <-dp.disk.space.stopC:
+			return false
+		case <-dp.stopC:
+			return true
+		default:
+		}
+		corrupt, err := store.ScrubExtent(extentID, wait)
+		if err != nil {
+			if dp.checkIsDiskError(err) {
+				return true
+			}
+			log.LogWarnf("action[scrub] partition(%v) extent(%v) err(%v)", dp.partitionID, extentID, err)
+			continue
+		}
+		if len(corrupt) == 0 {
+			dp.setCorruptBlocks(extentID, nil)
+			continue
+		}
+		msg := fmt.Sprintf("scrub: partition(%v) extent(%v) has corrupt blocks %v on %v(%v)", dp.partitionID,
+			extentID, corrupt, LocalIP, dp.disk.Path)
+		exporter.Warning(msg)
+		log.LogError(msg)
+		remaining := dp.repairCorruptBlocks(extentID, corrupt)
+		dp.disk.updateScrubStatus(func(status *DiskScrubStatus) {
+			status.CorruptBlocks += uint64(len(corrupt))
+			status.RepairedBlocks += uint64(len(corrupt) - len(remaining))
+		})
+		dp.setCorruptBlocks(extentID, remaining)
+	}
+	return true
+}
+
+func (dp *DataPartition) setCorruptBlocks(extentID uint64, blocks []int) {
+	dp.corruptLock.Lock()
+	defer dp.corruptLock.Unlock()
+	if len(blocks) == 0 {
+		delete(dp.corruptExtents, extentID)
+		return
+	}
+	dp.corruptExtents[extentID] = blocks
+}
+
+// addCorruptBlocks adds the corrupt blocks found out of the scrubbing, which are repaired once the extent is scrubbed.
+func (dp *DataPartition) addCorruptBlocks(extentID uint64, blocks []int) {
+	dp.corruptLock.Lock()
+	defer dp.corruptLock.Unlock()
+	merged := append([]int{}, dp.corruptExtents[extentID]...)
+	for _, blockNo := range blocks {
+		found := false
+		for _, b := range merged {
+			if b == blockNo {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, blockNo)
+		}
+	}
+	sort.Ints(merged)
+	dp.corruptExtents[extentID] = merged
+}
+
+// CorruptExtents returns the extents with the corrupt blocks which are not repaired, the ones deleted since they
+// are scrubbed are left out.
+func (dp *DataPartition) CorruptExtents() (extents map[uint64][]int) {
+	dp.corruptLock.RLock()
+	defer dp.corruptLock.RUnlock()
+	extents = make(map[uint64][]int, len(dp.corruptExtents))
+	for extentID, blocks := range dp.corruptExtents {
+		if dp.extentStore.HasExtent(extentID) && !dp.extentStore.IsDeletedNormalExtent(extentID) {
+			extents[extentID] = blocks
+		}
+	}
+	return
+}
+
+// corruptExtentIDs returns the IDs of the extents with the corrupt blocks, which are reported to the master.
+func (dp *DataPartition) corruptExtentIDs() (extentIDs []uint64) {
+	for extentID := range dp.CorruptExtents() {
+		extentIDs = append(extentIDs, extentID)
+	}
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// repairCorruptBlocks reads the corrupt blocks from the other replicas, and overwrites the local ones with the data
+// matching the crc. It returns the blocks which are not repaired, e.g. the auto repair is disabled or the blocks of
+// all the replicas are corrupt.
+func (dp *DataPartition) repairCorruptBlocks(extentID uint64, blocks []int) (remaining []int) {
+	if !AutoRepairStatus {
+		log.LogWarnf("action[repairCorruptBlocks] AutoRepairStatus is False, partition(%v) extent(%v) blocks %v "+
+			"are not repaired", dp.partitionID, extentID, blocks)
+		return blocks
+	}
+	store := dp.ExtentStore()
+	ei, err := store.Watermark(extentID)
+	if err != nil {
+		return blocks
+	}
+	for _, blockNo := range blocks {
+		offset := int64(blockNo) * util.BlockSize
+		size := util.Min(util.BlockSize, int(int64(ei.Size)-offset))
+		repaired := false
+		for _, addr := range dp.Replicas() {
+			if strings.TrimSpace(strings.Split(addr, ":")[0]) == LocalIP {
+				continue
+			}
+			data, err := dp.readReplicaBlock(addr, extentID, offset, size)
+			if err == nil {
+				err = store.RepairExtentBlock(extentID, blockNo, data)
+			}
+			if err != nil {
+				log.LogWarnf("action[repairCorruptBlocks] partition(%v) extent(%v) block(%v) from(%v) err(%v)",
+					dp.partitionID, extentID, blockNo, addr, err)
+				continue
+			}
+			log.LogWarnf("action[repairCorruptBlocks] partition(%v) extent(%v) block(%v) is repaired from(%v)",
+				dp.partitionID, extentID, blockNo, addr)
+			repaired = true
+			break
+		}
+		if !repaired {
+			remaining = append(remaining, blockNo)
+		}
+	}
+	return
+}
+
+// readReplicaBlock reads the data of the extent from the replica on the address by the repair read.
+func (dp *DataPartition) readReplicaBlock(addr string, extentID uint64, offset int64, size int) (data []byte, err error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid size(%v) at offset(%v)", size, offset)
+	}
+	request := repl.NewExtentRepairReadPacket(dp.partitionID, extentID, int(offset), size)
+	var conn *net.TCPConn
+	if conn, err = gConnPool.GetConnect(addr); err != nil {
+		return
+	}
+	defer gConnPool.PutConnect(conn, true)
+	if err = request.WriteToConn(conn); err != nil {
+		return
+	}
+	data = make([]byte, 0, size)
+	for len(data) < size {
+		reply := repl.NewPacket()
+		if err = reply.ReadFromConn(conn, scrubReadTimeout); err != nil {
+			return nil, err
+		}
+		if reply.ResultCode != proto.OpOk {
+			return nil, fmt.Errorf("reply(%v) result(%v) msg(%v)", reply.GetUniqueLogId(), reply.ResultCode,
+				string(reply.Data[:reply.Size]))
+		}
+		if reply.ReqID != request.ReqID || reply.ExtentID != extentID || reply.Size == 0 ||
+			reply.ExtentOffset != offset+int64(len(data)) {
+			return nil, fmt.Errorf("unexpected reply(%v) of request(%v)", reply.GetUniqueLogId(), request.GetUniqueLogId())
+		}
+		data = append(data, reply.Data[:reply.Size]...)
+	}
+	return data, nil
+}
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"errors"
@@ -34,9 +35,11 @@ import (
 	"github.com/chubaofs/chubaofs/raftstore"
 	"github.com/chubaofs/chubaofs/repl"
 	masterSDK "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/storage"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/config"
 	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/iputil"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -65,14 +68,48 @@ const (
 )
 
 const (
-	ConfigKeyLocalIP       = "localIP"       // string
-	ConfigKeyPort          = "port"          // int
-	ConfigKeyMasterAddr    = "masterAddr"    // array
-	ConfigKeyZone          = "zoneName"      // string
-	ConfigKeyDisks         = "disks"         // array
-	ConfigKeyRaftDir       = "raftDir"       // string
-	ConfigKeyRaftHeartbeat = "raftHeartbeat" // string
-	ConfigKeyRaftReplica   = "raftReplica"   // string
+	ConfigKeyLocalIP        = "localIP"        // string
+	ConfigKeyPort           = "port"           // int
+	ConfigKeyMasterAddr     = "masterAddr"     // array
+	ConfigKeyZone           = "zoneName"       // string
+	ConfigKeyRack           = "rackName"       // string
+	ConfigKeyDisks          = "disks"          // array
+	ConfigKeyRaftDir        = "raftDir"        // string
+	ConfigKeyRaftHeartbeat  = "raftHeartbeat"  // string
+	ConfigKeyRaftReplica    = "raftReplica"    // string
+	ConfigKeyScrubBandwidth = "scrubBandwidth" // int, MB per second to scrub a disk
+
+	ConfigKeyCompressColdAge   = "compressColdAge"   // int, seconds an extent is not modified before it is compressed
+	ConfigKeyCompressBandwidth = "compressBandwidth" // int, MB per second to compress the extents of a disk
+
+	ConfigKeyCompactColdAge    = "compactColdAge"    // int, seconds an extent is not modified before it is packed
+	ConfigKeyCompactExtentSize = "compactExtentSize" // int, KB of the largest extent to pack
+	ConfigKeyCompactBandwidth  = "compactBandwidth"  // int, MB per second to pack the extents of a disk
+
+	ConfigKeyCacheDisks       = "cacheDisks"       // array, "CACHE_PATH:RESERVE_SIZE:DISK,DISK"
+	ConfigKeyTierColdAge      = "tierColdAge"      // int, seconds an extent is idle before it is demoted
+	ConfigKeyTierPromoteReads = "tierPromoteReads" // int, reads of an extent in a round to promote it, -1 to disable
+	ConfigKeyTierBandwidth    = "tierBandwidth"    // int, MB per second to move the extents of a cache disk
+
+	ConfigKeyAuthNodes        = "authNodes"        // array, authnodes to get the ticket to fetch the volume keys
+	ConfigKeyClientID         = "clientID"         // string
+	ConfigKeyClientKey        = "clientKey"        // string
+	ConfigKeyEncryptColdAge   = "encryptColdAge"   // int, seconds an extent is not modified before it is re-encrypted
+	ConfigKeyEncryptBandwidth = "encryptBandwidth" // int, MB per second to re-encrypt the extents of a disk
+
+	ConfigKeySmartInterval = "smartInterval" // int, seconds between the collections of the SMART attributes
+	ConfigKeySmartctl      = "smartctlPath"  // string, path of smartctl
+
+	ConfigKeyDiskClientIops      = "diskClientIops"      // int, reads and writes per second of the clients on a disk
+	ConfigKeyDiskClientBandwidth = "diskClientBandwidth" // int, MB per second to read and to write for the clients on a disk
+	ConfigKeyDiskRepairIops      = "diskRepairIops"      // int, reads and writes per second to repair on a disk
+	ConfigKeyDiskRepairBandwidth = "diskRepairBandwidth" // int, MB per second to read and to write to repair on a disk, -1 for no limit
+
+	ConfigKeyIOEngine       = "ioEngine"       // string, "psync" or "io_uring" to read and write the extents
+	ConfigKeyIOUringEntries = "ioUringEntries" // int, entries of the submission queue of each io_uring
+	ConfigKeyIOUringSQPoll  = "ioUringSQPoll"  // bool, poll the io_uring submissions by the kernel threads
+
+	ConfigKeyZeroCopyReadSize = "zeroCopyReadSize" // int, KB of the smallest read to reply by zero copy, -1 to disable
 )
 
 // DataNode defines the structure of a data node.
@@ -80,6 +117,7 @@ type DataNode struct {
 	space           *SpaceManager
 	port            string
 	zoneName        string
+	rackName        string
 	clusterID       string
 	localIP         string
 	localServerAddr string
@@ -136,6 +174,12 @@ func doStart(server common.Server, cfg *config.Config) (err error) {
 		return
 	}
 
+	// the volume keys are pulled before the partitions are loaded to read their encrypted extents
+	if err = s.updateVolEncryptionKeys(); err != nil {
+		log.LogErrorf("action[doStart] update vol encryption keys err(%v)", err)
+		err = nil
+	}
+
 	// create space manager (disk, partition, etc.)
 	if err = s.startSpaceManager(cfg); err != nil {
 		return
@@ -154,6 +198,10 @@ func doStart(server common.Server, cfg *config.Config) (err error) {
 	}
 	go s.registerHandler()
 
+	go s.space.startCollectSmart()
+
+	go s.space.startSampleIO()
+
 	go s.startUpdateNodeInfo()
 
 	return
@@ -196,10 +244,91 @@ func (s *DataNode) parseConfig(cfg *config.Config) (err error) {
 	if s.zoneName == "" {
 		s.zoneName = DefaultZoneName
 	}
+	s.rackName = cfg.GetString(ConfigKeyRack)
+	if bandwidth := cfg.GetInt64(ConfigKeyScrubBandwidth); bandwidth != 0 {
+		setScrubBandwidth(bandwidth)
+	}
+	if coldAge := cfg.GetInt64(ConfigKeyCompressColdAge); coldAge > 0 {
+		atomic.StoreInt64(&compressColdAge, coldAge)
+	}
+	if bandwidth := cfg.GetInt64(ConfigKeyCompressBandwidth); bandwidth != 0 {
+		if bandwidth < 0 {
+			bandwidth = 0
+		}
+		atomic.StoreInt64(&compressBandwidth, bandwidth*util.MB)
+	}
+	if coldAge := cfg.GetInt64(ConfigKeyCompactColdAge); coldAge > 0 {
+		atomic.StoreInt64(&compactColdAge, coldAge)
+	}
+	if size := cfg.GetInt64(ConfigKeyCompactExtentSize); size > 0 {
+		atomic.StoreInt64(&compactExtentSize, size*util.KB)
+	}
+	if bandwidth := cfg.GetInt64(ConfigKeyCompactBandwidth); bandwidth != 0 {
+		if bandwidth < 0 {
+			bandwidth = 0
+		}
+		atomic.StoreInt64(&compactBandwidth, bandwidth*util.MB)
+	}
+	if coldAge := cfg.GetInt64(ConfigKeyTierColdAge); coldAge > 0 {
+		atomic.StoreInt64(&tierColdAge, coldAge)
+	}
+	if promoteReads := cfg.GetInt64(ConfigKeyTierPromoteReads); promoteReads != 0 {
+		atomic.StoreInt64(&tierPromoteReads, promoteReads)
+	}
+	if bandwidth := cfg.GetInt64(ConfigKeyTierBandwidth); bandwidth > 0 {
+		atomic.StoreInt64(&tierBandwidth, bandwidth*util.MB)
+	}
+	if coldAge := cfg.GetInt64(ConfigKeyEncryptColdAge); coldAge > 0 {
+		atomic.StoreInt64(&encryptColdAge, coldAge)
+	}
+	if bandwidth := cfg.GetInt64(ConfigKeyEncryptBandwidth); bandwidth != 0 {
+		if bandwidth < 0 {
+			bandwidth = 0
+		}
+		atomic.StoreInt64(&encryptBandwidth, bandwidth*util.MB)
+	}
+	if interval := cfg.GetInt64(ConfigKeySmartInterval); interval != 0 {
+		atomic.StoreInt64(&smartInterval, interval)
+	}
+	if smartctl := cfg.GetString(ConfigKeySmartctl); smartctl != "" {
+		smartctlPath = smartctl
+	}
+	setConfigIOLimits(ioClassClient, cfg.GetInt64(ConfigKeyDiskClientIops), cfg.GetInt64(ConfigKeyDiskClientBandwidth))
+	repairBandwidth := cfg.GetInt64(ConfigKeyDiskRepairBandwidth)
+	if repairBandwidth == 0 {
+		repairBandwidth = DefaultDiskRepairBandwidth
+	}
+	setConfigIOLimits(ioClassRepair, cfg.GetInt64(ConfigKeyDiskRepairIops), repairBandwidth)
+	if engine := cfg.GetString(ConfigKeyIOEngine); engine != "" {
+		if engine != storage.IOEnginePsync && engine != storage.IOEngineIOUring {
+			return fmt.Errorf("Err:unknown ioEngine %v", engine)
+		}
+		entries, sqPoll := cfg.GetInt64(ConfigKeyIOUringEntries), cfg.GetBool(ConfigKeyIOUringSQPoll)
+		if err = storage.SetIOEngine(engine, uint32(entries), sqPoll); err != nil {
+			log.LogWarnf("action[parseConfig] io engine(%v) not available, use %v, err(%v)", engine, storage.IOEngine(), err)
+			err = nil
+		}
+	}
+	if size := cfg.GetInt64(ConfigKeyZeroCopyReadSize); size != 0 {
+		if size < 0 {
+			size = 0
+		}
+		atomic.StoreInt64(&zeroCopyReadSize, size*util.KB)
+	}
+	if authNodes := cfg.GetStringSlice(ConfigKeyAuthNodes); len(authNodes) > 0 {
+		clientID, clientKey := cfg.GetString(ConfigKeyClientID), cfg.GetString(ConfigKeyClientKey)
+		if clientID == "" || clientKey == "" {
+			return fmt.Errorf("Err:clientID and clientKey are required by authNodes")
+		}
+		volKeyClient = newKeyClient(authNodes, clientID, clientKey, MasterClient.Nodes())
+	}
 
 	log.LogDebugf("action[parseConfig] load masterAddrs(%v).", MasterClient.Nodes())
 	log.LogDebugf("action[parseConfig] load port(%v).", s.port)
 	log.LogDebugf("action[parseConfig] load zoneName(%v).", s.zoneName)
+	log.LogDebugf("action[parseConfig] load rackName(%v).", s.rackName)
+	log.LogDebugf("action[parseConfig] load scrubBandwidth(%v).", atomic.LoadInt64(&scrubBandwidth))
+	log.LogDebugf("action[parseConfig] load ioEngine(%v).", storage.IOEngine())
 	return
 }
 
@@ -214,6 +343,21 @@ func (s *DataNode) startSpaceManager(cfg *config.Config) (err error) {
 	s.space.SetNodeID(s.nodeID)
 	s.space.SetClusterID(s.clusterID)
 
+	var (
+		diskPaths  []string
+		cacheDisks []string
+	)
+	for _, d := range cfg.GetSlice(ConfigKeyDisks) {
+		diskPaths = append(diskPaths, strings.Split(d.(string), ":")[0])
+	}
+	for _, c := range cfg.GetSlice(ConfigKeyCacheDisks) {
+		cacheDisks = append(cacheDisks, c.(string))
+	}
+	// the cache disks are set up before the partitions on the disks they cache are loaded
+	if err = s.space.LoadCacheDisks(cacheDisks, diskPaths); err != nil {
+		return
+	}
+
 	var wg sync.WaitGroup
 	for _, d := range cfg.GetSlice(ConfigKeyDisks) {
 		log.LogDebugf("action[startSpaceManager] load disk raw config(%v).", d)
@@ -285,7 +429,7 @@ func (s *DataNode) register(cfg *config.Config) {
 
 			// register this data node on the master
 			var nodeID uint64
-			if nodeID, err = MasterClient.NodeAPI().AddDataNode(fmt.Sprintf("%s:%v", LocalIP, s.port), s.zoneName); err != nil {
+			if nodeID, err = MasterClient.NodeAPI().AddDataNode(fmt.Sprintf("%s:%v", LocalIP, s.port), s.zoneName, s.rackName); err != nil {
 				log.LogErrorf("action[registerToMaster] cannot register this node to master[%v] err(%v).",
 					masterAddr, err)
 				timer.Reset(2 * time.Second)
@@ -352,8 +496,15 @@ func (s *DataNode) registerHandler() {
 	http.HandleFunc("/extent", s.getExtentAPI)
 	http.HandleFunc("/block", s.getBlockCrcAPI)
 	http.HandleFunc("/stats", s.getStatAPI)
+	http.HandleFunc("/setDiskIOLimits", s.setDiskIOLimitsAPI)
+	http.HandleFunc("/repairStatus", s.getRepairStatusAPI)
 	http.HandleFunc("/raftStatus", s.getRaftStatus)
 	http.HandleFunc("/setAutoRepairStatus", s.setAutoRepairStatus)
+	http.HandleFunc("/scrubStatus", s.getScrubStatusAPI)
+	http.HandleFunc("/setScrubBandwidth", s.setScrubBandwidthAPI)
+	http.HandleFunc("/tierStatus", s.getTierStatusAPI)
+	http.HandleFunc("/compactStatus", s.getCompactStatusAPI)
+	http.HandleFunc("/setCacheDiskDraining", s.setCacheDiskDrainingAPI)
 }
 
 func (s *DataNode) startTCPService() (err error) {
@@ -395,7 +546,18 @@ func (s *DataNode) serveConn(conn net.Conn) {
 	c, _ := conn.(*net.TCPConn)
 	c.SetKeepAlive(true)
 	c.SetNoDelay(true)
-	packetProcessor := repl.NewReplProtocol(c, s.Prepare, s.OperatePacket, s.Post)
+	remoteIP := iputil.IPOfAddr(c.RemoteAddr().String())
+	prepare := func(p *repl.Packet) (err error) {
+		if err = s.Prepare(p); err != nil {
+			return
+		}
+		if err = checkVolIPAcl(p, remoteIP); err != nil {
+			p.PackErrorBody(repl.ActionPreparePkt, err.Error())
+			p.ResultCode = proto.OpNotPerm
+		}
+		return
+	}
+	packetProcessor := repl.NewReplProtocol(c, prepare, s.OperatePacket, s.Post)
 	packetProcessor.ServerConn()
 }
 
@@ -18,11 +18,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
+	"sync/atomic"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/storage"
-	"github.com/tiglabs/raft"
+	"github.com/chubaofs/chubaofs/util"
 )
 
 var (
@@ -33,15 +36,17 @@ func (s *DataNode) getDiskAPI(w http.ResponseWriter, r *http.Request) {
 	disks := make([]interface{}, 0)
 	for _, diskItem := range s.space.GetDisks() {
 		disk := &struct {
-			Path        string `json:"path"`
-			Total       uint64 `json:"total"`
-			Used        uint64 `json:"used"`
-			Available   uint64 `json:"available"`
-			Unallocated uint64 `json:"unallocated"`
-			Allocated   uint64 `json:"allocated"`
-			Status      int    `json:"status"`
-			RestSize    uint64 `json:"restSize"`
-			Partitions  int    `json:"partitions"`
+			Path        string           `json:"path"`
+			Total       uint64           `json:"total"`
+			Used        uint64           `json:"used"`
+			Available   uint64           `json:"available"`
+			Unallocated uint64           `json:"unallocated"`
+			Allocated   uint64           `json:"allocated"`
+			Status      int              `json:"status"`
+			RestSize    uint64           `json:"restSize"`
+			Partitions  int              `json:"partitions"`
+			Retiring    bool             `json:"retiring"`
+			Smart       *proto.DiskSmart `json:"smart"`
 		}{
 			Path:        diskItem.Path,
 			Total:       diskItem.Total,
@@ -52,6 +57,8 @@ func (s *DataNode) getDiskAPI(w http.ResponseWriter, r *http.Request) {
 			Status:      diskItem.Status,
 			RestSize:    diskItem.ReservedSpace,
 			Partitions:  diskItem.PartitionCount(),
+			Retiring:    diskItem.isRetiring(),
+			Smart:       diskItem.Smart(),
 		}
 		disks = append(disks, disk)
 	}
@@ -68,8 +75,112 @@ func (s *DataNode) getDiskAPI(w http.ResponseWriter, r *http.Request) {
 func (s *DataNode) getStatAPI(w http.ResponseWriter, r *http.Request) {
 	response := &proto.DataNodeHeartbeatResponse{}
 	s.buildHeartBeatResponse(response)
+	diskIO := make([]DiskIOStat, 0)
+	for _, d := range s.space.GetDisks() {
+		diskIO = append(diskIO, d.IOStat())
+	}
+	sort.Slice(diskIO, func(i, j int) bool { return diskIO[i].Path < diskIO[j].Path })
+
+	s.buildSuccessResp(w, &struct {
+		*proto.DataNodeHeartbeatResponse
+		DiskIO            []DiskIOStat
+		IOEngine          string
+		ZeroCopyReadBytes uint64 // bytes replied by zero copy since the data node starts
+	}{
+		DataNodeHeartbeatResponse: response,
+		DiskIO:                    diskIO,
+		IOEngine:                  storage.IOEngine(),
+		ZeroCopyReadBytes:         atomic.LoadUint64(&zeroCopyReadBytes),
+	})
+}
 
-	s.buildSuccessResp(w, response)
+// getRepairStatusAPI replies the progress of the latest repairs of the normal extents of the partitions, or of the
+// partition given.
+func (s *DataNode) getRepairStatusAPI(w http.ResponseWriter, r *http.Request) {
+	const (
+		paramPartitionID = "id"
+	)
+	if err := r.ParseForm(); err != nil {
+		err = fmt.Errorf("parse form fail: %v", err)
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	partitions := make([]RepairProgress, 0)
+	if value := r.FormValue(paramPartitionID); value != "" {
+		partitionID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			err = fmt.Errorf("parse param %v fail: %v", paramPartitionID, err)
+			s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		partition := s.space.Partition(partitionID)
+		if partition == nil {
+			s.buildFailureResp(w, http.StatusNotFound, "partition not exist")
+			return
+		}
+		if progress, ok := partition.RepairProgress(); ok {
+			partitions = append(partitions, progress)
+		}
+	} else {
+		s.space.RangePartitions(func(dp *DataPartition) bool {
+			if progress, ok := dp.RepairProgress(); ok {
+				partitions = append(partitions, progress)
+			}
+			return true
+		})
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].PartitionID < partitions[j].PartitionID })
+	}
+	s.buildSuccessResp(w, &struct {
+		Partitions []RepairProgress `json:"partitions"`
+	}{
+		Partitions: partitions,
+	})
+}
+
+// setDiskIOLimitsAPI sets the limits of a class of the traffic on a disk, or on all the disks if the disk is not
+// given, until the data node restarts. The limits not given are removed.
+func (s *DataNode) setDiskIOLimitsAPI(w http.ResponseWriter, r *http.Request) {
+	const (
+		paramDisk           = "disk"
+		paramClass          = "class"
+		paramReadIops       = "readIops"
+		paramWriteIops      = "writeIops"
+		paramReadBandwidth  = "readBandwidth"
+		paramWriteBandwidth = "writeBandwidth"
+	)
+	if err := r.ParseForm(); err != nil {
+		err = fmt.Errorf("parse form fail: %v", err)
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	class, err := parseIOClass(r.FormValue(paramClass))
+	if err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var values [4]uint64
+	for i, param := range []string{paramReadIops, paramWriteIops, paramReadBandwidth, paramWriteBandwidth} {
+		value := r.FormValue(param)
+		if value == "" {
+			continue
+		}
+		if values[i], err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = fmt.Errorf("parse param %v fail: %v", param, value)
+			s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	limits := DiskIOLimits{
+		ReadIops:  values[0],
+		WriteIops: values[1],
+		ReadBps:   values[2] * util.MB,
+		WriteBps:  values[3] * util.MB,
+	}
+	if err = s.space.setDiskIOLimits(r.FormValue(paramDisk), class, limits); err != nil {
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.buildSuccessResp(w, limits)
 }
 
 func (s *DataNode) setAutoRepairStatus(w http.ResponseWriter, r *http.Request) {
@@ -91,6 +202,123 @@ func (s *DataNode) setAutoRepairStatus(w http.ResponseWriter, r *http.Request) {
 	s.buildSuccessResp(w, autoRepair)
 }
 
+// getScrubStatusAPI replies the progress of the scrubbers of the disks, and the corrupt blocks of the extents which
+// are not repaired from the other replicas.
+func (s *DataNode) getScrubStatusAPI(w http.ResponseWriter, r *http.Request) {
+	disks := make([]DiskScrubStatus, 0)
+	for _, d := range s.space.GetDisks() {
+		disks = append(disks, d.ScrubStatus())
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Path < disks[j].Path })
+	corrupt := make(map[uint64]map[uint64][]int)
+	s.space.RangePartitions(func(dp *DataPartition) bool {
+		if extents := dp.CorruptExtents(); len(extents) > 0 {
+			corrupt[dp.partitionID] = extents
+		}
+		return true
+	})
+	result := &struct {
+		Bandwidth      int64                       `json:"bandwidth"`
+		Disks          []DiskScrubStatus           `json:"disks"`
+		CorruptExtents map[uint64]map[uint64][]int `json:"corruptExtents"`
+	}{
+		Bandwidth:      atomic.LoadInt64(&scrubBandwidth),
+		Disks:          disks,
+		CorruptExtents: corrupt,
+	}
+	s.buildSuccessResp(w, result)
+}
+
+// setScrubBandwidthAPI sets the bandwidth to scrub a disk in MB per second until the data node restarts, 0 disables
+// the scrubbing.
+func (s *DataNode) setScrubBandwidthAPI(w http.ResponseWriter, r *http.Request) {
+	const (
+		paramBandwidth = "bandwidth"
+	)
+	if err := r.ParseForm(); err != nil {
+		err = fmt.Errorf("parse form fail: %v", err)
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	bandwidth, err := strconv.ParseInt(r.FormValue(paramBandwidth), 10, 64)
+	if err != nil || bandwidth < 0 {
+		err = fmt.Errorf("parse param %v fail: %v", paramBandwidth, r.FormValue(paramBandwidth))
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setScrubBandwidth(bandwidth)
+	s.buildSuccessResp(w, bandwidth)
+}
+
+// getTierStatusAPI replies the usage and the migration of the cache disks.
+func (s *DataNode) getTierStatusAPI(w http.ResponseWriter, r *http.Request) {
+	cacheDisks := make([]CacheDiskStatus, 0)
+	for _, c := range s.space.GetCacheDisks() {
+		cacheDisks = append(cacheDisks, c.Status())
+	}
+	result := &struct {
+		ColdAge      int64             `json:"coldAge"`
+		PromoteReads int64             `json:"promoteReads"`
+		Bandwidth    int64             `json:"bandwidth"`
+		CacheDisks   []CacheDiskStatus `json:"cacheDisks"`
+	}{
+		ColdAge:      atomic.LoadInt64(&tierColdAge),
+		PromoteReads: atomic.LoadInt64(&tierPromoteReads),
+		Bandwidth:    atomic.LoadInt64(&tierBandwidth),
+		CacheDisks:   cacheDisks,
+	}
+	s.buildSuccessResp(w, result)
+}
+
+// setCacheDiskDrainingAPI starts or stops demoting all the extents from the cache disk until the data node restarts,
+// which is done before the cache disk is removed from the configuration.
+func (s *DataNode) setCacheDiskDrainingAPI(w http.ResponseWriter, r *http.Request) {
+	const (
+		paramPath  = "path"
+		paramDrain = "drain"
+	)
+	if err := r.ParseForm(); err != nil {
+		err = fmt.Errorf("parse form fail: %v", err)
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	drain, err := strconv.ParseBool(r.FormValue(paramDrain))
+	if err != nil {
+		err = fmt.Errorf("parse param %v fail: %v", paramDrain, r.FormValue(paramDrain))
+		s.buildFailureResp(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	for _, c := range s.space.GetCacheDisks() {
+		if c.Path == r.FormValue(paramPath) {
+			c.SetDraining(drain)
+			s.buildSuccessResp(w, c.Status())
+			return
+		}
+	}
+	s.buildFailureResp(w, http.StatusNotFound, fmt.Sprintf("cache disk %v not found", r.FormValue(paramPath)))
+}
+
+// getCompactStatusAPI replies the progress of the compactors of the disks and the space saved by the packed extents.
+func (s *DataNode) getCompactStatusAPI(w http.ResponseWriter, r *http.Request) {
+	disks := make([]DiskCompactStatus, 0)
+	for _, d := range s.space.GetDisks() {
+		disks = append(disks, d.CompactStatus())
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Path < disks[j].Path })
+	result := &struct {
+		ColdAge    int64               `json:"coldAge"`
+		ExtentSize int64               `json:"extentSize"`
+		Bandwidth  int64               `json:"bandwidth"`
+		Disks      []DiskCompactStatus `json:"disks"`
+	}{
+		ColdAge:    atomic.LoadInt64(&compactColdAge),
+		ExtentSize: atomic.LoadInt64(&compactExtentSize),
+		Bandwidth:  atomic.LoadInt64(&compactBandwidth),
+		Disks:      disks,
+	}
+	s.buildSuccessResp(w, result)
+}
+
 func (s *DataNode) getRaftStatus(w http.ResponseWriter, r *http.Request) {
 	const (
 		paramRaftID = "raftID"
@@ -183,6 +411,9 @@ func (s *DataNode) getPartitionAPI(w http.ResponseWriter, r *http.Request) {
 		Replicas             []string              `json:"replicas"`
 		TinyDeleteRecordSize int64                 `json:"tinyDeleteRecordSize"`
 		RaftStatus           *raft.Status          `json:"raftStatus"`
+		Compression          PartitionCompression  `json:"compression"`
+		Encryption           PartitionEncryption   `json:"encryption"`
+		Packing              storage.PackStats     `json:"packing"`
 	}{
 		VolName:              partition.volumeID,
 		ID:                   partition.partitionID,
@@ -195,6 +426,9 @@ func (s *DataNode) getPartitionAPI(w http.ResponseWriter, r *http.Request) {
 		Replicas:             partition.Replicas(),
 		TinyDeleteRecordSize: tinyDeleteRecordSize,
 		RaftStatus:           partition.raftPartition.Status(),
+		Compression:          partition.Compression(),
+		Encryption:           partition.Encryption(),
+		Packing:              partition.ExtentStore().PackStats(),
 	}
 	s.buildSuccessResp(w, result)
 }
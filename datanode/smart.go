@@ -0,0 +1,256 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The SMART attributes of the disks are collected by smartctl of smartmontools 7.0 or later, which prints them in
+// json, every smartInterval and reported to the master in the heartbeats. The master predicts the failure of the
+// disks by them and retires the failing ones, the data node creates no partition on the retiring disks.
+
+const (
+	DefaultSmartInterval = 600 // seconds between the collections of the SMART attributes
+	defaultSmartctl      = "smartctl"
+	smartctlTimeout      = 30 * time.Second
+
+	smartctlFatalStatus = 0x3 // the bits of the exit status that the command line or the device is invalid
+
+	ataReallocatedSectors   = 5
+	ataPendingSectors       = 197
+	ataUncorrectableSectors = 198
+)
+
+var (
+	smartInterval int64 = DefaultSmartInterval
+	smartctlPath        = defaultSmartctl
+)
+
+// smartctlOutput is the part of the json output of smartctl used.
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String string `json:"string"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+	SmartStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaSmartAttributes struct {
+		Table []struct {
+			ID         int    `json:"id"`
+			Name       string `json:"name"`
+			WhenFailed string `json:"when_failed"`
+			Raw        struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	NvmeSmartHealth *struct {
+		CriticalWarning         int    `json:"critical_warning"`
+		AvailableSpare          int    `json:"available_spare"`
+		AvailableSpareThreshold int    `json:"available_spare_threshold"`
+		PercentageUsed          int    `json:"percentage_used"`
+		MediaErrors             uint64 `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// parseSmartctlOutput fills the SMART attributes of the disk by the output of smartctl. The disk without the
+// health self-assessment is taken as passed.
+func parseSmartctlOutput(data []byte, smart *proto.DiskSmart) (err error) {
+	output := new(smartctlOutput)
+	if err = json.Unmarshal(data, output); err != nil {
+		return fmt.Errorf("parse smartctl output: %v", err)
+	}
+	if output.Smartctl.ExitStatus&smartctlFatalStatus != 0 {
+		messages := make([]string, 0, len(output.Smartctl.Messages))
+		for _, message := range output.Smartctl.Messages {
+			messages = append(messages, message.String)
+		}
+		return fmt.Errorf("smartctl exit status %v: %v", output.Smartctl.ExitStatus, strings.Join(messages, "; "))
+	}
+	smart.Passed = output.SmartStatus == nil || output.SmartStatus.Passed
+	smart.Temperature = output.Temperature.Current
+	smart.PowerOnHours = output.PowerOnTime.Hours
+	for _, attr := range output.AtaSmartAttributes.Table {
+		switch attr.ID {
+		case ataReallocatedSectors:
+			smart.ReallocatedSectors = attr.Raw.Value
+		case ataPendingSectors:
+			smart.PendingSectors = attr.Raw.Value
+		case ataUncorrectableSectors:
+			smart.UncorrectableSectors = attr.Raw.Value
+		}
+		if attr.WhenFailed == "now" {
+			smart.FailingAttributes = append(smart.FailingAttributes, attr.Name)
+		}
+	}
+	if nvme := output.NvmeSmartHealth; nvme != nil {
+		smart.CriticalWarning = nvme.CriticalWarning
+		smart.AvailableSpare = nvme.AvailableSpare
+		smart.AvailableSpareThreshold = nvme.AvailableSpareThreshold
+		smart.PercentageUsed = nvme.PercentageUsed
+		smart.MediaErrors = nvme.MediaErrors
+	}
+	return
+}
+
+// diskDevice returns the block device the path is mounted on, the whole disk if it is mounted on a partition.
+func diskDevice(path string) (device string, err error) {
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return
+	}
+	f, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var mountPoint string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+		if fields[1] != path && fields[1] != "/" && !strings.HasPrefix(path, fields[1]+"/") {
+			continue
+		}
+		if len(fields[1]) >= len(mountPoint) {
+			device, mountPoint = fields[0], fields[1]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if device == "" {
+		return "", fmt.Errorf("no device is mounted on %v", path)
+	}
+	if device, err = filepath.EvalSymlinks(device); err != nil {
+		return
+	}
+	// the sysfs directory of a partition is under the directory of its disk
+	name := filepath.Base(device)
+	if _, err = os.Stat(filepath.Join("/sys/class/block", name, "partition")); err != nil {
+		return device, nil
+	}
+	sysPath, err := filepath.EvalSymlinks(filepath.Join("/sys/class/block", name))
+	if err != nil {
+		return
+	}
+	return filepath.Join("/dev", filepath.Base(filepath.Dir(sysPath))), nil
+}
+
+// collectSmart collects the SMART attributes of the disk by smartctl, the error is kept in the attributes.
+func (d *Disk) collectSmart() {
+	smart := &proto.DiskSmart{Path: d.Path, CollectTime: time.Now().Unix()}
+	defer func() {
+		if smart.Error != "" {
+			log.LogWarnf("action[collectSmart] disk(%v) device(%v) err(%v)", d.Path, smart.Device, smart.Error)
+		}
+		d.smart.Store(smart)
+	}()
+	var err error
+	if smart.Device, err = diskDevice(d.Path); err != nil {
+		smart.Error = err.Error()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), smartctlTimeout)
+	defer cancel()
+	// the exit status is not zero if the disk is failing, which is checked by the output
+	output, err := exec.CommandContext(ctx, smartctlPath, "--json", "-H", "-A", smart.Device).Output()
+	if len(output) == 0 && err != nil {
+		smart.Error = fmt.Sprintf("run smartctl: %v", err)
+		return
+	}
+	if err = parseSmartctlOutput(output, smart); err != nil {
+		smart.Error = err.Error()
+	}
+}
+
+// Smart returns the SMART attributes of the disk collected last time, nil if they are not collected yet.
+func (d *Disk) Smart() *proto.DiskSmart {
+	smart, _ := d.smart.Load().(*proto.DiskSmart)
+	return smart
+}
+
+func (d *Disk) isRetiring() bool {
+	return atomic.LoadInt32(&d.retiring) == 1
+}
+
+func (d *Disk) setRetiring(retiring bool) {
+	var value int32
+	if retiring {
+		value = 1
+	}
+	if atomic.SwapInt32(&d.retiring, value) != value {
+		log.LogWarnf("action[setRetiring] disk(%v) retiring(%v)", d.Path, retiring)
+	}
+}
+
+// setRetiringDisks sets the disks retired by the master, no partition is created on them.
+func (manager *SpaceManager) setRetiringDisks(paths []string) {
+	retiring := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		retiring[path] = true
+	}
+	for _, d := range manager.GetDisks() {
+		d.setRetiring(retiring[d.Path])
+	}
+}
+
+// startCollectSmart collects the SMART attributes of the disks every smartInterval until the data node stops.
+func (manager *SpaceManager) startCollectSmart() {
+	interval := atomic.LoadInt64(&smartInterval)
+	if interval <= 0 {
+		return
+	}
+	if _, err := exec.LookPath(smartctlPath); err != nil {
+		log.LogWarnf("action[startCollectSmart] SMART attributes are not collected: %v", err)
+		return
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-manager.stopC:
+			return
+		case <-timer.C:
+		}
+		for _, d := range manager.GetDisks() {
+			d.collectSmart()
+		}
+		timer.Reset(time.Duration(interval) * time.Second)
+	}
+}
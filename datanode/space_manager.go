@@ -32,6 +32,7 @@ import (
 type SpaceManager struct {
 	clusterID            string
 	disks                map[string]*Disk
+	cacheDisks           map[string]*CacheDisk // disk path -> cache disk of the disk
 	partitions           map[uint64]*DataPartition
 	raftStore            raftstore.RaftStore
 	nodeID               uint64
@@ -50,6 +51,7 @@ func NewSpaceManager(dataNode *DataNode) *SpaceManager {
 	var space *SpaceManager
 	space = &SpaceManager{}
 	space.disks = make(map[string]*Disk)
+	space.cacheDisks = make(map[string]*CacheDisk)
 	space.diskList = make([]string, 0)
 	space.partitions = make(map[uint64]*DataPartition)
 	space.stats = NewStats(dataNode.zoneName)
@@ -171,6 +173,10 @@ func (manager *SpaceManager) LoadDisk(path string, reservedSpace uint64, maxErrC
 		manager.putDisk(disk)
 		err = nil
 		go disk.doBackendTask()
+		go disk.startScrub()
+		go disk.startCompress()
+		go disk.startEncrypt()
+		go disk.startCompact()
 	}
 	return
 }
@@ -229,7 +235,7 @@ func (manager *SpaceManager) minPartitionCnt() (d *Disk) {
 	)
 	minWeight = math.MaxFloat64
 	for _, disk := range manager.disks {
-		if disk.Available <= 5*util.GB || disk.Status != proto.ReadWrite {
+		if disk.Available <= 5*util.GB || disk.Status != proto.ReadWrite || disk.isRetiring() {
 			continue
 		}
 		diskWeight := disk.getSelectWeight()
@@ -327,6 +333,9 @@ func (manager *SpaceManager) DeletePartition(dpID uint64) {
 	dp.Stop()
 	dp.Disk().DetachDataPartition(dp)
 	os.RemoveAll(dp.Path())
+	if cacheDir := dp.ExtentStore().CacheDir(); cacheDir != "" {
+		os.RemoveAll(cacheDir)
+	}
 }
 
 func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatResponse) {
@@ -358,7 +367,11 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 			IsLeader:        isLeader,
 			ExtentCount:     partition.GetExtentCount(),
 			NeedCompare:     true,
+			ApplyID:         partition.GetAppliedID(),
+			CorruptExtents:  partition.corruptExtentIDs(),
 		}
+		vr.CompressedExtents, vr.CompressedRawSize, vr.CompressedSize = partition.ExtentStore().CompressionStats()
+		vr.KeyVersion, _, vr.StaleKeyExtents = partition.ExtentStore().EncryptionStats()
 		log.LogDebugf("action[Heartbeats] dpid(%v), status(%v) total(%v) used(%v) leader(%v) isLeader(%v).", vr.PartitionID, vr.PartitionStatus, vr.Total, vr.Used, leaderAddr, vr.IsLeader)
 		response.PartitionReports = append(response.PartitionReports, vr)
 		return true
@@ -369,5 +382,8 @@ func (s *DataNode) buildHeartBeatResponse(response *proto.DataNodeHeartbeatRespo
 		if d.Status == proto.Unavailable {
 			response.BadDisks = append(response.BadDisks, d.Path)
 		}
+		if smart := d.Smart(); smart != nil {
+			response.DiskSmarts = append(response.DiskSmarts, smart)
+		}
 	}
 }
@@ -0,0 +1,337 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// A cache disk is a fast disk such as an SSD in front of a group of the disks. The new normal extents of the
+// partitions on the disks of the group are created on the cache disk while it has space, and its migrator demotes the
+// extents neither written nor read for tierColdAge to the disks of their partitions, and promotes the ones read
+// frequently back. The coldest extents are demoted regardless of their age once the cache disk is short of space.
+
+const (
+	DefaultTierColdAge      = 3600 // seconds an extent is neither written nor read before it is demoted
+	DefaultTierPromoteReads = 64   // reads of an extent in a round to promote it
+	DefaultTierBandwidth    = 50   // MB per second to move the extents of a cache disk
+	tierRoundInterval       = time.Minute
+	tierSpaceInterval       = 10 * time.Second
+)
+
+var (
+	tierColdAge      int64 = DefaultTierColdAge
+	tierPromoteReads int64 = DefaultTierPromoteReads
+	tierBandwidth    int64 = DefaultTierBandwidth * util.MB // bytes per second, 0 to stop moving the extents
+)
+
+// CacheDisk is a cache disk and the group of the disks it caches.
+type CacheDisk struct {
+	Path          string
+	ReservedSpace uint64
+	Disks         []string
+	space         *SpaceManager
+	total         uint64 // updated atomically
+	available     uint64 // updated atomically
+	draining      int32  // updated atomically
+	status        CacheDiskStatus
+	statusLock    sync.Mutex
+}
+
+// CacheDiskStatus is the usage and the migration of a cache disk.
+type CacheDiskStatus struct {
+	Path          string   `json:"path"`
+	Disks         []string `json:"disks"`
+	Total         uint64   `json:"total"`
+	Available     uint64   `json:"available"`
+	Draining      bool     `json:"draining"`
+	CachedExtents int      `json:"cachedExtents"`
+	CachedBytes   uint64   `json:"cachedBytes"`
+	Demoted       uint64   `json:"demoted"` // extents demoted since the data node starts
+	DemotedBytes  uint64   `json:"demotedBytes"`
+	Promoted      uint64   `json:"promoted"` // extents promoted since the data node starts
+	PromotedBytes uint64   `json:"promotedBytes"`
+	LastRoundTime int64    `json:"lastRoundTime"`
+}
+
+// parseCacheDisk parses the cache disk configured in the format "PATH:RESERVE_SIZE:DISK,DISK".
+func parseCacheDisk(raw string) (c *CacheDisk, err error) {
+	arr := strings.Split(raw, ":")
+	if len(arr) != 3 {
+		return nil, fmt.Errorf("Invalid cache disk configuration. Example: PATH:RESERVE_SIZE:DISK,DISK")
+	}
+	fileInfo, err := os.Stat(arr[0])
+	if err != nil {
+		return nil, fmt.Errorf("Stat cache disk path error: %v", err)
+	}
+	if !fileInfo.IsDir() {
+		return nil, fmt.Errorf("Cache disk path %v is not dir", arr[0])
+	}
+	c = &CacheDisk{Path: arr[0]}
+	if c.ReservedSpace, err = strconv.ParseUint(arr[1], 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid cache disk reserved space. Error: %v", err)
+	}
+	if c.ReservedSpace < DefaultDiskRetainMin {
+		c.ReservedSpace = DefaultDiskRetainMin
+	}
+	for _, d := range strings.Split(arr[2], ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			c.Disks = append(c.Disks, d)
+		}
+	}
+	if len(c.Disks) == 0 {
+		return nil, fmt.Errorf("Cache disk %v caches no disk", c.Path)
+	}
+	return
+}
+
+// LoadCacheDisks sets up the cache disks before the disks they cache are loaded.
+func (manager *SpaceManager) LoadCacheDisks(raws []string, disks []string) (err error) {
+	isDisk := make(map[string]bool)
+	for _, d := range disks {
+		isDisk[d] = true
+	}
+	for _, raw := range raws {
+		var c *CacheDisk
+		if c, err = parseCacheDisk(raw); err != nil {
+			return
+		}
+		if isDisk[c.Path] {
+			return fmt.Errorf("Cache disk %v is also a disk", c.Path)
+		}
+		for _, d := range c.Disks {
+			if !isDisk[d] {
+				return fmt.Errorf("Disk %v cached by %v is not configured", d, c.Path)
+			}
+			if other, ok := manager.cacheDisks[d]; ok {
+				return fmt.Errorf("Disk %v is cached by both %v and %v", d, other.Path, c.Path)
+			}
+			manager.cacheDisks[d] = c
+		}
+		c.space = manager
+		c.updateSpace()
+		log.LogInfof("action[LoadCacheDisks] cache disk(%v) reserved(%v) disks(%v)", c.Path, c.ReservedSpace, c.Disks)
+		go c.startMigrate()
+	}
+	return
+}
+
+// CacheDiskOf returns the cache disk of the disk, nil if the disk is not cached.
+func (manager *SpaceManager) CacheDiskOf(diskPath string) *CacheDisk {
+	return manager.cacheDisks[diskPath]
+}
+
+// GetCacheDisks returns the cache disks in the order of their paths.
+func (manager *SpaceManager) GetCacheDisks() (cacheDisks []*CacheDisk) {
+	seen := make(map[*CacheDisk]bool)
+	for _, c := range manager.cacheDisks {
+		if !seen[c] {
+			seen[c] = true
+			cacheDisks = append(cacheDisks, c)
+		}
+	}
+	sort.Slice(cacheDisks, func(i, j int) bool { return cacheDisks[i].Path < cacheDisks[j].Path })
+	return
+}
+
+// partitionCacheDir returns the directory of the cached extents of the partition on the cache disk.
+func (c *CacheDisk) partitionCacheDir(partitionID uint64) string {
+	return path.Join(c.Path, fmt.Sprintf(DataPartitionPrefix+"_%v", partitionID))
+}
+
+func (c *CacheDisk) updateSpace() {
+	fs := syscall.Statfs_t{}
+	if err := syscall.Statfs(c.Path, &fs); err != nil {
+		log.LogErrorf("action[updateSpace] cache disk(%v) err(%v)", c.Path, err)
+		atomic.StoreUint64(&c.available, 0)
+		return
+	}
+	atomic.StoreUint64(&c.total, fs.Blocks*uint64(fs.Bsize))
+	atomic.StoreUint64(&c.available, fs.Bavail*uint64(fs.Bsize))
+}
+
+// HasSpace tells if a new extent can be created on the cache disk.
+func (c *CacheDisk) HasSpace() bool {
+	return !c.IsDraining() && atomic.LoadUint64(&c.available) > c.ReservedSpace
+}
+
+func (c *CacheDisk) underPressure() bool {
+	return atomic.LoadUint64(&c.available) < 2*c.ReservedSpace
+}
+
+// IsDraining tells if all the extents are being demoted from the cache disk, e.g. before it is removed.
+func (c *CacheDisk) IsDraining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// SetDraining starts or stops demoting all the extents from the cache disk, no new extent is created on the cache
+// disk while it is draining.
+func (c *CacheDisk) SetDraining(drain bool) {
+	var value int32
+	if drain {
+		value = 1
+	}
+	atomic.StoreInt32(&c.draining, value)
+}
+
+// Status returns the usage and the migration of the cache disk.
+func (c *CacheDisk) Status() CacheDiskStatus {
+	c.statusLock.Lock()
+	status := c.status
+	c.statusLock.Unlock()
+	status.Path = c.Path
+	status.Disks = c.Disks
+	status.Total = atomic.LoadUint64(&c.total)
+	status.Available = atomic.LoadUint64(&c.available)
+	status.Draining = c.IsDraining()
+	for _, dp := range c.partitions() {
+		count, size := dp.ExtentStore().CachedExtents()
+		status.CachedExtents += count
+		status.CachedBytes += size
+	}
+	return status
+}
+
+func (c *CacheDisk) updateStatus(f func(status *CacheDiskStatus)) {
+	c.statusLock.Lock()
+	defer c.statusLock.Unlock()
+	f(&c.status)
+}
+
+// partitions returns the partitions with the extents cached on the cache disk.
+func (c *CacheDisk) partitions() (partitions []*DataPartition) {
+	c.space.RangePartitions(func(dp *DataPartition) bool {
+		if c.space.CacheDiskOf(dp.disk.Path) == c && dp.ExtentStore().CacheDir() != "" {
+			partitions = append(partitions, dp)
+		}
+		return true
+	})
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].partitionID < partitions[j].partitionID })
+	return
+}
+
+// startMigrate moves the extents between the cache disk and the disks it caches until the data node stops.
+func (c *CacheDisk) startMigrate() {
+	limiter := rate.NewLimiter(rate.Inf, util.BlockSize)
+	var lastRound time.Time
+	for {
+		c.updateSpace()
+		if atomic.LoadInt64(&tierBandwidth) > 0 &&
+			(time.Since(lastRound) >= tierRoundInterval || c.underPressure() || c.IsDraining()) {
+			c.migrateRound(limiter)
+			lastRound = time.Now()
+		}
+		timer := time.NewTimer(tierSpaceInterval)
+		select {
+		case <-c.space.stopC:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// migrateRound demotes the cold extents of the partitions, or the coldest ones while the cache disk is short of
+// space, and then promotes the hot ones while it has space.
+func (c *CacheDisk) migrateRound(limiter *rate.Limiter) {
+	ctx := context.Background()
+	wait := func(size int) {
+		if bandwidth := atomic.LoadInt64(&tierBandwidth); bandwidth > 0 && limiter.Limit() != rate.Limit(bandwidth) {
+			setLimiter(limiter, uint64(bandwidth))
+		}
+		limiter.WaitN(ctx, size)
+	}
+	drain := c.IsDraining()
+	for _, dp := range c.partitions() {
+		coldAge := atomic.LoadInt64(&tierColdAge)
+		pressure := c.underPressure()
+		if pressure {
+			coldAge = 0
+		}
+		demote, promote := dp.ExtentStore().TierCandidates(coldAge, atomic.LoadInt64(&tierPromoteReads), drain)
+		for _, extentID := range demote {
+			if !drain && coldAge == 0 && !c.underPressure() {
+				break
+			}
+			if !c.moveExtent(dp, extentID, false, wait) {
+				return
+			}
+		}
+		for _, extentID := range promote {
+			if drain || pressure || !c.HasSpace() {
+				break
+			}
+			if !c.moveExtent(dp, extentID, true, wait) {
+				return
+			}
+		}
+	}
+	c.updateStatus(func(status *CacheDiskStatus) {
+		status.LastRoundTime = time.Now().Unix()
+	})
+}
+
+// moveExtent moves the extent of the partition, it returns false if the moving is disabled or the data node is
+// stopping.
+func (c *CacheDisk) moveExtent(dp *DataPartition, extentID uint64, toCache bool, wait func(size int)) bool {
+	if atomic.LoadInt64(&tierBandwidth) == 0 {
+		return false
+	}
+	select {
+	case <-c.space.stopC:
+		return false
+	case <-dp.stopC:
+		return true
+	default:
+	}
+	store := dp.ExtentStore()
+	ei, err := store.Watermark(extentID)
+	if err != nil {
+		return true
+	}
+	size := ei.Size
+	if err = store.MoveExtent(extentID, toCache, wait); err != nil {
+		log.LogWarnf("action[moveExtent] partition(%v) extent(%v) toCache(%v) err(%v)", dp.partitionID, extentID,
+			toCache, err)
+		return true
+	}
+	c.updateSpace()
+	c.updateStatus(func(status *CacheDiskStatus) {
+		if toCache {
+			status.Promoted++
+			status.PromotedBytes += size
+		} else {
+			status.Demoted++
+			status.DemotedBytes += size
+		}
+	})
+	log.LogDebugf("action[moveExtent] partition(%v) extent(%v) size(%v) toCache(%v)", dp.partitionID, extentID, size,
+		toCache)
+	return true
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package datanode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The extents referenced by the volume snapshots must not be overwritten in place. The clients write the overwrites
+// into new extents once they see the snapshots in the view of the volume, and the data nodes refuse the random writes
+// of the partitions frozen for the snapshots, so the clients failing to update the view and the ones not knowing the
+// snapshots can not change the data of the snapshots either. Master freezes the partitions of the volume before the
+// meta partitions take a snapshot, and releases them after the volume has no snapshot. The extents created after the
+// snapshots are frozen as well, which costs nothing since the clients do not overwrite them in place either.
+// The replicas created for the volume with snapshots are frozen when they are created.
+
+const (
+	VolSnapshotBarrierFileName     = "SNAPSHOT"
+	TempVolSnapshotBarrierFileName = ".snapshot"
+)
+
+// ActionVolSnapshot is the action of the requests to freeze and release the partition
+const ActionVolSnapshot = "ActionVolSnapshot"
+
+// volSnapshotState is the barrier persisted in the partition directory.
+type volSnapshotState struct {
+	Epoch  uint64 // the epoch of the latest request applied
+	Frozen bool
+}
+
+type volSnapshotBarrier struct {
+	sync.RWMutex // held for read by the random writes until they are submitted, so the freeze waits for them
+	state        volSnapshotState
+}
+
+// checkVolSnapshotBarrier returns the error if the random write is refused, otherwise the barrier is held for read
+// and must be released by the caller after the write is submitted.
+func (dp *DataPartition) checkVolSnapshotBarrier(p *repl.Packet) error {
+	dp.snapshotBarrier.RLock()
+	if !dp.snapshotBarrier.state.Frozen {
+		return nil
+	}
+	epoch := dp.snapshotBarrier.state.Epoch
+	dp.snapshotBarrier.RUnlock()
+	return fmt.Errorf("%v: extent(%v) epoch(%v)", proto.ErrExtentFrozen, p.ExtentID, epoch)
+}
+
+// operateVolSnapshotBarrier freezes or releases the partition at the epoch, the request older than the barrier is
+// ignored.
+func (dp *DataPartition) operateVolSnapshotBarrier(epoch uint64, operation string) (err error) {
+	var frozen bool
+	switch operation {
+	case proto.DataSnapshotFreeze:
+		frozen = true
+	case proto.DataSnapshotRelease:
+	default:
+		return fmt.Errorf("unknown snapshot operation[%v]", operation)
+	}
+	dp.snapshotBarrier.Lock()
+	defer dp.snapshotBarrier.Unlock()
+	old := dp.snapshotBarrier.state
+	if epoch < old.Epoch {
+		log.LogWarnf("action[operateVolSnapshotBarrier] partition(%v) ignore %v at epoch(%v) older than %+v",
+			dp.partitionID, operation, epoch, old)
+		return
+	}
+	dp.snapshotBarrier.state = volSnapshotState{Epoch: epoch, Frozen: frozen}
+	if err = dp.persistVolSnapshotBarrier(); err != nil {
+		dp.snapshotBarrier.state = old
+		return
+	}
+	log.LogWarnf("action[operateVolSnapshotBarrier] partition(%v) %v at epoch(%v)", dp.partitionID, operation, epoch)
+	return
+}
+
+func (dp *DataPartition) persistVolSnapshotBarrier() (err error) {
+	data, err := json.Marshal(dp.snapshotBarrier.state)
+	if err != nil {
+		return
+	}
+	fileName := path.Join(dp.Path(), TempVolSnapshotBarrierFileName)
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return
+	}
+	defer os.Remove(fileName)
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(fileName, path.Join(dp.Path(), VolSnapshotBarrierFileName))
+}
+
+// loadVolSnapshotBarrier loads the barrier persisted, the partition is frozen if the barrier is unreadable.
+func (dp *DataPartition) loadVolSnapshotBarrier() {
+	data, err := ioutil.ReadFile(path.Join(dp.Path(), VolSnapshotBarrierFileName))
+	if os.IsNotExist(err) {
+		return
+	}
+	state := volSnapshotState{}
+	if err == nil {
+		err = json.Unmarshal(data, &state)
+	}
+	if err != nil {
+		log.LogErrorf("action[loadVolSnapshotBarrier] partition(%v) err(%v), the partition is frozen", dp.partitionID, err)
+		state = volSnapshotState{Frozen: true}
+	}
+	dp.snapshotBarrier.state = state
+}
+
+func (s *DataNode) handlePacketToDataPartitionSnapshot(p *repl.Packet) {
+	var (
+		err     error
+		reqData []byte
+		req     = &proto.DataPartitionSnapshotRequest{}
+	)
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionVolSnapshot, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+	adminTask := &proto.AdminTask{}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		return
+	}
+	if reqData, err = json.Marshal(adminTask.Request); err != nil {
+		return
+	}
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	p.AddMesgLog(string(reqData))
+	dp := s.space.Partition(req.PartitionID)
+	if dp == nil {
+		err = proto.ErrDataPartitionNotExists
+		return
+	}
+	p.PartitionID = req.PartitionID
+	err = dp.operateVolSnapshotBarrier(req.SnapshotID, req.Operation)
+	return
+}
@@ -26,6 +26,8 @@ import (
 	"hash/crc32"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	raftProto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/repl"
 	"github.com/chubaofs/chubaofs/storage"
@@ -33,8 +35,6 @@ import (
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft"
-	raftProto "github.com/tiglabs/raft/proto"
 )
 
 func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
@@ -65,6 +65,7 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
 		p.Size = resultSize
 		tpObject.Set(err)
 	}()
+	waitVolQos(p)
 	switch p.Opcode {
 	case proto.OpCreateExtent:
 		s.handlePacketToCreateExtent(p)
@@ -106,6 +107,12 @@ func (s *DataNode) OperatePacket(p *repl.Packet, c *net.TCPConn) (err error) {
 		s.handlePacketToRemoveDataPartitionRaftMember(p)
 	case proto.OpDataPartitionTryToLeader:
 		s.handlePacketToDataPartitionTryToLeaderrr(p)
+	case proto.OpResetDataPartitionRaftMember:
+		s.handlePacketToResetDataPartitionRaftMember(p)
+	case proto.OpDataPartitionSnapshot:
+		s.handlePacketToDataPartitionSnapshot(p)
+	case proto.OpTransitExtents:
+		s.handlePacketToTransitExtents(p)
 	case proto.OpGetPartitionSize:
 		s.handlePacketToGetPartitionSize(p)
 	case proto.OpGetMaxExtentIDAndPartitionSize:
@@ -211,6 +218,7 @@ func (s *DataNode) handleHeartbeatPacket(p *repl.Packet) {
 		if task.OpCode == proto.OpDataNodeHeartbeat {
 			marshaled, _ := json.Marshal(task.Request)
 			_ = json.Unmarshal(marshaled, request)
+			s.space.setRetiringDisks(request.RetiringDisks)
 			response.Status = proto.TaskSucceeds
 		} else {
 			response.Status = proto.TaskFailed
@@ -391,13 +399,17 @@ func (s *DataNode) handleWritePacket(p *repl.Packet) {
 	}
 	store := partition.ExtentStore()
 	if p.ExtentType == proto.TinyExtentType {
-		err = store.Write(p.ExtentID, p.ExtentOffset, int64(p.Size), p.Data, p.CRC, storage.AppendWriteType, p.IsSyncWrite())
+		err = partition.disk.doIO(ioClassClient, ioWrite, int(p.Size), func() error {
+			return store.Write(p.ExtentID, p.ExtentOffset, int64(p.Size), p.Data, p.CRC, storage.AppendWriteType, p.IsSyncWrite())
+		})
 		s.incDiskErrCnt(p.PartitionID, err, WriteFlag)
 		return
 	}
 
 	if p.Size <= util.BlockSize {
-		err = store.Write(p.ExtentID, p.ExtentOffset, int64(p.Size), p.Data, p.CRC, storage.AppendWriteType, p.IsSyncWrite())
+		err = partition.disk.doIO(ioClassClient, ioWrite, int(p.Size), func() error {
+			return store.Write(p.ExtentID, p.ExtentOffset, int64(p.Size), p.Data, p.CRC, storage.AppendWriteType, p.IsSyncWrite())
+		})
 		partition.checkIsDiskError(err)
 	} else {
 		size := p.Size
@@ -409,7 +421,9 @@ func (s *DataNode) handleWritePacket(p *repl.Packet) {
 			currSize := util.Min(int(size), util.BlockSize)
 			data := p.Data[offset : offset+currSize]
 			crc := crc32.ChecksumIEEE(data)
-			err = store.Write(p.ExtentID, p.ExtentOffset+int64(offset), int64(currSize), data, crc, storage.AppendWriteType, p.IsSyncWrite())
+			err = partition.disk.doIO(ioClassClient, ioWrite, currSize, func() error {
+				return store.Write(p.ExtentID, p.ExtentOffset+int64(offset), int64(currSize), data, crc, storage.AppendWriteType, p.IsSyncWrite())
+			})
 			partition.checkIsDiskError(err)
 			if err != nil {
 				break
@@ -437,7 +451,11 @@ func (s *DataNode) handleRandomWritePacket(p *repl.Packet) {
 		err = raft.ErrNotLeader
 		return
 	}
+	if err = partition.checkVolSnapshotBarrier(p); err != nil {
+		return
+	}
 	err = partition.RandomWriteSubmit(p)
+	partition.snapshotBarrier.RUnlock()
 	if err != nil && strings.Contains(err.Error(), raft.ErrNotLeader.Error()) {
 		err = raft.ErrNotLeader
 		return
@@ -507,6 +525,11 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 	needReplySize := p.Size
 	offset := p.ExtentOffset
 	store := partition.ExtentStore()
+	class := ioClassClient
+	if isRepairRead {
+		class = ioClassRepair
+	}
+	zeroCopy := !isRepairRead && partition.canZeroCopyRead(p)
 
 	for {
 		if needReplySize <= 0 {
@@ -516,32 +539,48 @@ func (s *DataNode) extentRepairReadPacket(p *repl.Packet, connect net.Conn, isRe
 		reply := repl.NewStreamReadResponsePacket(p.ReqID, p.PartitionID, p.ExtentID)
 		reply.StartT = p.StartT
 		currReadSize := uint32(util.Min(int(needReplySize), util.ReadBlockSize))
-		if currReadSize == util.ReadBlockSize {
-			reply.Data, _ = proto.Buffers.Get(util.ReadBlockSize)
-		} else {
-			reply.Data = make([]byte, currReadSize)
-		}
 		tpObject := exporter.NewTPCnt(p.GetOpMsg())
 		reply.ExtentOffset = offset
 		p.Size = uint32(currReadSize)
 		p.ExtentOffset = offset
-		reply.CRC, err = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, isRepairRead)
+		var sent bool
+		if zeroCopy {
+			// the rest is read through the user space too once the extent is found not sendable
+			sent, err = partition.sendByZeroCopy(reply, p.Opcode, currReadSize, class, connect)
+			zeroCopy = sent
+		}
+		if !sent && err == nil {
+			if currReadSize == util.ReadBlockSize {
+				reply.Data, _ = proto.Buffers.Get(util.ReadBlockSize)
+			} else {
+				reply.Data = make([]byte, currReadSize)
+			}
+			err = partition.disk.doIO(class, ioRead, int(currReadSize), func() (readErr error) {
+				reply.CRC, readErr = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, isRepairRead)
+				return
+			})
+			if err == nil && !isRepairRead {
+				err = partition.verifyRead(reply.ExtentID, offset, int64(currReadSize), reply.Data)
+			}
+		}
 		partition.checkIsDiskError(err)
 		tpObject.Set(err)
 		p.CRC = reply.CRC
 		if err != nil {
 			return
 		}
-		reply.Size = uint32(currReadSize)
-		reply.ResultCode = proto.OpOk
-		reply.Opcode = p.Opcode
 		p.ResultCode = proto.OpOk
-		if err = reply.WriteToConn(connect); err != nil {
-			return
+		if !sent {
+			reply.Size = uint32(currReadSize)
+			reply.ResultCode = proto.OpOk
+			reply.Opcode = p.Opcode
+			if err = reply.WriteToConn(connect); err != nil {
+				return
+			}
 		}
 		needReplySize -= currReadSize
 		offset += int64(currReadSize)
-		if currReadSize == util.ReadBlockSize {
+		if !sent && currReadSize == util.ReadBlockSize {
 			proto.Buffers.Put(reply.Data)
 		}
 		logContent := fmt.Sprintf("action[operatePacket] %v.",
@@ -667,7 +706,10 @@ func (s *DataNode) tinyExtentRepairRead(request *repl.Packet, connect net.Conn)
 			reply.Data = make([]byte, currReadSize)
 		}
 		reply.ExtentOffset = offset
-		reply.CRC, err = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, false)
+		err = partition.disk.doIO(ioClassRepair, ioRead, int(currReadSize), func() (readErr error) {
+			reply.CRC, readErr = store.Read(reply.ExtentID, offset, int64(currReadSize), reply.Data, false)
+			return
+		})
 		if err != nil {
 			return
 		}
@@ -977,9 +1019,57 @@ func (s *DataNode) handlePacketToRemoveDataPartitionRaftMember(p *repl.Packet) {
 	return
 }
 
+// The reset request is executed on every surviving replica, it is not forwarded
+// to the raft leader since the partition has lost its quorum.
+func (s *DataNode) handlePacketToResetDataPartitionRaftMember(p *repl.Packet) {
+	var (
+		err     error
+		reqData []byte
+		req     = &proto.ResetDataPartitionRaftMemberRequest{}
+	)
+
+	defer func() {
+		if err != nil {
+			p.PackErrorBody(ActionResetDataPartitionRaftMember, err.Error())
+		} else {
+			p.PacketOkReply()
+		}
+	}()
+
+	adminTask := &proto.AdminTask{}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		return
+	}
+
+	reqData, err = json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(reqData, req); err != nil {
+		return
+	}
+	p.AddMesgLog(string(reqData))
+	dp := s.space.Partition(req.PartitionId)
+	if dp == nil {
+		err = proto.ErrDataPartitionNotExists
+		return
+	}
+	p.PartitionID = req.PartitionId
+	if len(req.NewPeers) == 0 {
+		err = fmt.Errorf("partition %v new peers is empty", req.PartitionId)
+		return
+	}
+	err = dp.ResetRaftMember(req.NewPeers)
+	return
+}
+
 func (s *DataNode) handlePacketToDataPartitionTryToLeaderrr(p *repl.Packet) {
 	var (
-		err error
+		err       error
+		adminTask = &proto.AdminTask{}
+		req       = &proto.TransferLeaderRequest{}
 	)
 
 	defer func() {
@@ -999,6 +1089,12 @@ func (s *DataNode) handlePacketToDataPartitionTryToLeaderrr(p *repl.Packet) {
 	if dp.raftPartition.IsRaftLeader() {
 		return
 	}
+	// the request is only carried by the tasks of transferring the leader, which wait for the result
+	adminTask.Request = req
+	if len(p.Data) != 0 && json.Unmarshal(p.Data, adminTask) == nil && req.WaitSeconds > 0 {
+		err = dp.raftPartition.TransferLeader(time.Duration(req.WaitSeconds) * time.Second)
+		return
+	}
 	err = dp.raftPartition.TryToLeader(dp.partitionID)
 	return
 }
@@ -0,0 +1,79 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package datanode
+
+import (
+	"net"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/repl"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util"
+)
+
+// The stream reads of at least zeroCopyReadSize from the clients without the need of the crc are replied by zero
+// copy. The data of each reply is sent from the extent file to the connection by sendfile right after the header,
+// which has ReadFlagZeroCopy in the arg and no crc. The extents encrypted or compressed, the tiny extents and the
+// volumes verifying the crc of the data read are read through the user space as usual.
+
+const (
+	DefaultZeroCopyReadSize = 128 // KB of the smallest read to reply by zero copy
+)
+
+var (
+	zeroCopyReadSize  int64 = DefaultZeroCopyReadSize * util.KB // bytes, 0 to read through the user space always
+	zeroCopyReadBytes uint64
+)
+
+// canZeroCopyRead returns if the stream read may be replied by zero copy.
+func (dp *DataPartition) canZeroCopyRead(p *repl.Packet) bool {
+	size := atomic.LoadInt64(&zeroCopyReadSize)
+	return size > 0 && int64(p.Size) >= size && p.IsZeroCopyRead() && !storage.IsTinyExtent(p.ExtentID) &&
+		!isVerifyReadCrcVol(dp.volumeID)
+}
+
+// sendByZeroCopy sends the reply of the size by zero copy, it returns false if the data has to be read through the user
+// space instead. The connection is closed if the data fails to send after the header, since the client can not find
+// the next reply any more.
+func (dp *DataPartition) sendByZeroCopy(reply *repl.Packet, opcode uint8, size uint32, class ioClass,
+	connect net.Conn) (sent bool, err error) {
+	conn, ok := connect.(syscall.Conn)
+	if !ok {
+		return
+	}
+	reply.Size = size
+	reply.ResultCode = proto.OpOk
+	reply.Opcode = opcode
+	reply.SetZeroCopyRead()
+	err = dp.disk.doIO(class, ioRead, int(size), func() error {
+		return dp.ExtentStore().SendExtent(reply.ExtentID, reply.ExtentOffset, int64(size), conn, func() error {
+			sent = true
+			return reply.WriteToConn(connect)
+		})
+	})
+	if err == storage.ExtentNotSendableError {
+		return false, nil
+	}
+	if err != nil {
+		if sent {
+			connect.Close()
+		}
+		return
+	}
+	atomic.AddUint64(&zeroCopyReadBytes, uint64(size))
+	return
+}
@@ -20,8 +20,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/storage"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/storage"
 )
 
 const (
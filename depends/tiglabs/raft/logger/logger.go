@@ -18,7 +18,7 @@ package logger
 import (
 	"fmt"
 
-	"github.com/tiglabs/raft/util/log"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util/log"
 )
 
 // Logger encapsulation the log interface.
@@ -18,7 +18,7 @@ import (
 	"io"
 	"sort"
 
-	"github.com/tiglabs/raft/util"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 )
 
 const (
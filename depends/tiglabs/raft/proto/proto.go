@@ -54,6 +54,7 @@ const (
 
 	PeerNormal  PeerType = 0
 	PeerArbiter PeerType = 1
+	PeerLearner PeerType = 2
 )
 
 // The Snapshot interface is supplied by the application to access the snapshot data of application.
@@ -195,10 +196,17 @@ func (t PeerType) String() string {
 		return "PeerNormal"
 	case 1:
 		return "PeerArbiter"
+	case 2:
+		return "PeerLearner"
 	}
 	return "unkown"
 }
 
+// IsLearner returns true if the peer replicates the logs without voting.
+func (p Peer) IsLearner() bool {
+	return p.Type == PeerLearner
+}
+
 func (p Peer) String() string {
 	return fmt.Sprintf(`"nodeID":"%v","peerID":"%v","priority":"%v","type":"%v"`,
 		p.ID, p.PeerID, p.Priority, p.Type.String())
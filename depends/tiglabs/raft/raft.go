@@ -23,10 +23,10 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 	"github.com/chubaofs/chubaofs/util/exporter"
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/util"
 )
 
 type proposal struct {
@@ -51,6 +51,12 @@ type entryRequest struct {
 	onlyCommit bool
 }
 
+// handle user's reset peers request
+type resetPeerRequest struct {
+	future *Future
+	peers  []proto.Peer
+}
+
 type softState struct {
 	leader uint64
 	term   uint64
@@ -119,6 +125,7 @@ type raft struct {
 	readIndexC        chan *Future
 	statusc           chan chan *Status
 	entryRequestC     chan *entryRequest
+	resetPeerC        chan *resetPeerRequest
 	readyc            chan struct{}
 	tickc             chan struct{}
 	electc            chan struct{}
@@ -158,6 +165,7 @@ func newRaft(config *Config, raftConfig *RaftConfig) (*raft, error) {
 		readIndexC:    make(chan *Future, 256),
 		statusc:       make(chan chan *Status, 1),
 		entryRequestC: make(chan *entryRequest, 16),
+		resetPeerC:    make(chan *resetPeerRequest, 1),
 		tickc:         make(chan struct{}, 64),
 		readyc:        make(chan struct{}, 1),
 		electc:        make(chan struct{}, 1),
@@ -403,6 +411,12 @@ func (s *raft) run() {
 
 		case req := <-s.entryRequestC:
 			s.getEntriesInLoop(req)
+
+		case req := <-s.resetPeerC:
+			s.raftFsm.resetPeers(req.peers)
+			s.peerState.replace(req.peers)
+			s.maybeChange(true)
+			req.future.respond(nil, nil)
 		}
 	}
 }
@@ -503,6 +517,15 @@ func (s *raft) proposeMemberChange(cc *proto.ConfChange, future *Future) {
 	}
 }
 
+func (s *raft) resetMember(peers []proto.Peer, future *Future) {
+	req := &resetPeerRequest{future: future, peers: peers}
+	select {
+	case <-s.stopc:
+		future.respond(nil, ErrStopped)
+	case s.resetPeerC <- req:
+	}
+}
+
 func (s *raft) reciveMessage(m *proto.Message) {
 	if s.restoringSnapshot.Get() {
 		return
@@ -799,6 +822,7 @@ func (s *raft) getStatus() *Status {
 				Active:      p.active,
 				LastActive:  p.lastActive,
 				Inflight:    p.count,
+				Learner:     p.peer.IsLearner(),
 			}
 		}
 	}
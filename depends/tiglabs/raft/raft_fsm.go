@@ -20,8 +20,8 @@ import (
 	"math/rand"
 	"strings"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"time"
 )
 
@@ -330,8 +330,34 @@ func (r *raftFsm) updatePeer(peer proto.Peer) {
 	}
 }
 
+func (r *raftFsm) resetPeers(peers []proto.Peer) {
+	r.replicas = make(map[uint64]*replica)
+	for _, p := range peers {
+		r.replicas[p.ID] = newReplica(p, 0)
+	}
+	if logger.IsEnableWarn() {
+		logger.Warn("raft[%v] reset peers to [%v] at term %d.", r.id, r.getReplicas(), r.term)
+	}
+	r.becomeFollower(r.term, NoLeader)
+}
+
+// quorum returns the majority of the voters, the learners are not counted.
 func (r *raftFsm) quorum() int {
-	return len(r.replicas)/2 + 1
+	return r.voters()/2 + 1
+}
+
+func (r *raftFsm) voters() (n int) {
+	for _, rp := range r.replicas {
+		if !rp.peer.IsLearner() {
+			n++
+		}
+	}
+	return
+}
+
+func (r *raftFsm) isVoter(id uint64) bool {
+	rp, ok := r.replicas[id]
+	return ok && !rp.peer.IsLearner()
 }
 
 func (r *raftFsm) send(m *proto.Message) {
@@ -18,8 +18,8 @@ package raft
 import (
 	"fmt"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 )
 
 func (r *raftFsm) becomeCandidate() {
@@ -109,7 +109,7 @@ func (r *raftFsm) campaign(force bool) {
 	}
 
 	for id := range r.replicas {
-		if id == r.config.NodeID {
+		if id == r.config.NodeID || !r.isVoter(id) {
 			continue
 		}
 		li, lt := r.raftLog.lastIndexAndTerm()
@@ -136,7 +136,7 @@ func (r *raftFsm) poll(id uint64, v bool) (granted int) {
 			logger.Debug("raft[%v] received vote rejection from %v at term %d.", r.id, id, r.term)
 		}
 	}
-	if _, ok := r.votes[id]; !ok {
+	if _, ok := r.votes[id]; !ok && r.isVoter(id) {
 		r.votes[id] = v
 	}
 	for _, vv := range r.votes {
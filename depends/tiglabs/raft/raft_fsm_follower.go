@@ -18,8 +18,8 @@ package raft
 import (
 	"math"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 )
 
 func (r *raftFsm) becomeFollower(term, lead uint64) {
@@ -186,6 +186,5 @@ func (r *raftFsm) handleAppendEntries(m *proto.Message) {
 }
 
 func (r *raftFsm) promotable() bool {
-	_, ok := r.replicas[r.config.NodeID]
-	return ok
+	return r.isVoter(r.config.NodeID)
 }
@@ -20,9 +20,9 @@ import (
 	"sort"
 	"time"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/util"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 )
 
 func (r *raftFsm) becomeLeader() {
@@ -202,7 +202,9 @@ func stepLeader(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		if r.isVoter(m.From) {
+			r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		}
 		proto.ReturnMessage(m)
 		return
 	}
@@ -223,7 +225,7 @@ func (r *raftFsm) becomeElectionAck() {
 	r.tick = r.tickElectionAck
 	r.state = stateElectionACK
 	for id := range r.replicas {
-		if id == r.config.NodeID {
+		if id == r.config.NodeID || !r.isVoter(id) {
 			continue
 		}
 
@@ -267,7 +269,9 @@ func stepElectionAck(r *raftFsm, m *proto.Message) {
 		if logger.IsEnableDebug() {
 			logger.Debug("raft[%d] recv check quorum resp from %d, index=%d", r.id, m.From, m.Index)
 		}
-		r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		if r.isVoter(m.From) {
+			r.readOnly.recvAck(m.Index, m.From, r.quorum())
+		}
 		proto.ReturnMessage(m)
 		return
 
@@ -283,7 +287,9 @@ func stepElectionAck(r *raftFsm, m *proto.Message) {
 	case proto.RespMsgElectAck:
 		r.replicas[m.From].active = true
 		r.replicas[m.From].lastActive = time.Now()
-		r.acks[m.From] = true
+		if r.isVoter(m.From) {
+			r.acks[m.From] = true
+		}
 		if len(r.acks) >= r.quorum() {
 			r.becomeLeader()
 			r.bcastAppend()
@@ -340,6 +346,10 @@ func (r *raftFsm) tickElectionAck() {
 func (r *raftFsm) checkLeaderLease() bool {
 	var act int
 	for id := range r.replicas {
+		if !r.isVoter(id) {
+			r.replicas[id].active = false
+			continue
+		}
 		if id == r.config.NodeID || r.replicas[id].state == replicaStateSnapshot {
 			act++
 			continue
@@ -357,7 +367,13 @@ func (r *raftFsm) checkLeaderLease() bool {
 func (r *raftFsm) maybeCommit() bool {
 	mis := make(util.Uint64Slice, 0, len(r.replicas))
 	for _, rp := range r.replicas {
-		mis = append(mis, rp.match)
+		// the logs are committed by the voters only
+		if !rp.peer.IsLearner() {
+			mis = append(mis, rp.match)
+		}
+	}
+	if len(mis) == 0 {
+		return false
 	}
 	sort.Sort(sort.Reverse(mis))
 	mci := mis[r.quorum()-1]
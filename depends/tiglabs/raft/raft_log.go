@@ -19,10 +19,10 @@ import (
 	"fmt"
 	"math"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/storage"
-	"github.com/tiglabs/raft/util"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/storage"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 )
 
 const noLimit = math.MaxUint64
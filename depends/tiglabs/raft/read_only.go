@@ -18,7 +18,7 @@ package raft
 import (
 	"fmt"
 
-	"github.com/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
 )
 
 // ReadOnlyOption read only option
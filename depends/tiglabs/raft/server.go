@@ -19,9 +19,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/util"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 )
 
 var (
@@ -191,6 +191,23 @@ func (rs *RaftServer) ChangeMember(id uint64, changeType proto.ConfChangeType, p
 	return
 }
 
+// ResetMember forcibly replaces the members of the raft group with the given peers without
+// going through the raft log. It must only be used to recover a raft group which has
+// permanently lost its quorum, and must be called on every surviving member.
+func (rs *RaftServer) ResetMember(id uint64, peers []proto.Peer) (future *Future) {
+	rs.mu.RLock()
+	raft, ok := rs.rafts[id]
+	rs.mu.RUnlock()
+
+	future = newFuture()
+	if !ok {
+		future.respond(nil, ErrRaftNotExists)
+		return
+	}
+	raft.resetMember(peers, future)
+	return
+}
+
 func (rs *RaftServer) Status(id uint64) (status *Status) {
 	rs.mu.RLock()
 	raft, ok := rs.rafts[id]
@@ -14,7 +14,7 @@
 
 package wal
 
-import "github.com/tiglabs/raft/util"
+import "github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 
 const (
 	DefaultFileCacheCapacity = 2
@@ -15,8 +15,8 @@
 package wal
 
 import (
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/google/btree"
-	"github.com/tiglabs/raft/proto"
 )
 
 type cacheItem proto.Entry
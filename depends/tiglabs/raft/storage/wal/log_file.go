@@ -21,8 +21,8 @@ import (
 	"os"
 	"path"
 
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/util/log"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util/log"
 )
 
 type logEntryFile struct {
@@ -20,8 +20,8 @@ import (
 	"os"
 	"path"
 
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/util/bufalloc"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util/bufalloc"
 )
 
 type truncateMeta struct {
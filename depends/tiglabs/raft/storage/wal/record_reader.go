@@ -20,7 +20,7 @@ import (
 	"io"
 	"os"
 
-	"github.com/tiglabs/raft/util"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/util"
 )
 
 // 初始化完成之后，读取记录只能调用ReadAt方法
@@ -0,0 +1,319 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	alertCheckInterval = time.Minute
+)
+
+// alertSample is the value of a metric on a target.
+type alertSample struct {
+	target string
+	value  float64
+}
+
+// alertState records since when the condition of a rule holds on a target.
+type alertState struct {
+	rule   string
+	target string
+	value  float64
+	since  time.Time
+	firing bool
+}
+
+// alertManager holds the alert rules and the states of the alerts whose conditions hold.
+type alertManager struct {
+	sync.RWMutex
+	rules  map[string]*proto.AlertRule
+	states map[string]*alertState
+}
+
+func newAlertManager() *alertManager {
+	return &alertManager{
+		rules:  make(map[string]*proto.AlertRule),
+		states: make(map[string]*alertState),
+	}
+}
+
+func (am *alertManager) getRules() (rules []*proto.AlertRule) {
+	am.RLock()
+	defer am.RUnlock()
+	rules = make([]*proto.AlertRule, 0, len(am.rules))
+	for _, rule := range am.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return
+}
+
+func (am *alertManager) setRules(rules []*proto.AlertRule) {
+	am.Lock()
+	defer am.Unlock()
+	am.rules = make(map[string]*proto.AlertRule, len(rules))
+	for _, rule := range rules {
+		am.rules[rule.Name] = rule
+	}
+	am.states = make(map[string]*alertState)
+}
+
+func (am *alertManager) getRule(name string) (rule *proto.AlertRule, ok bool) {
+	am.RLock()
+	defer am.RUnlock()
+	rule, ok = am.rules[name]
+	return
+}
+
+// putRule adds or replaces the rule, and forgets the alerts of the rule replaced.
+func (am *alertManager) putRule(rule *proto.AlertRule) {
+	am.Lock()
+	defer am.Unlock()
+	am.rules[rule.Name] = rule
+	am.forgetAlerts(rule.Name)
+}
+
+func (am *alertManager) deleteRule(name string) {
+	am.Lock()
+	defer am.Unlock()
+	delete(am.rules, name)
+	am.forgetAlerts(name)
+}
+
+func (am *alertManager) forgetAlerts(rule string) {
+	for key, state := range am.states {
+		if state.rule == rule {
+			delete(am.states, key)
+		}
+	}
+}
+
+func (am *alertManager) view() (view *proto.AlertRulesView) {
+	view = &proto.AlertRulesView{Rules: am.getRules(), Alerts: make([]*proto.AlertView, 0)}
+	am.RLock()
+	defer am.RUnlock()
+	for _, state := range am.states {
+		view.Alerts = append(view.Alerts, &proto.AlertView{
+			Rule:   state.rule,
+			Target: state.target,
+			Value:  state.value,
+			Since:  state.since.Unix(),
+			Firing: state.firing,
+		})
+	}
+	sort.Slice(view.Alerts, func(i, j int) bool {
+		if view.Alerts[i].Rule != view.Alerts[j].Rule {
+			return view.Alerts[i].Rule < view.Alerts[j].Rule
+		}
+		return view.Alerts[i].Target < view.Alerts[j].Target
+	})
+	return
+}
+
+// evaluate compares the samples of the metrics with the rules, and emits the alerts fired and resolved.
+func (am *alertManager) evaluate(samples map[string][]alertSample, now time.Time, emit func(eventType, msg string)) {
+	am.Lock()
+	defer am.Unlock()
+	holding := make(map[string]bool)
+	for _, rule := range am.rules {
+		if rule.Disabled {
+			continue
+		}
+		for _, sample := range samples[rule.Metric] {
+			if !compareAlertValue(sample.value, rule.Operator, rule.Threshold) {
+				continue
+			}
+			key := rule.Name + keySeparator + sample.target
+			holding[key] = true
+			state, ok := am.states[key]
+			if !ok {
+				state = &alertState{rule: rule.Name, target: sample.target, since: now}
+				am.states[key] = state
+			}
+			state.value = sample.value
+			if !state.firing && now.Sub(state.since) >= time.Duration(rule.Duration)*time.Second {
+				state.firing = true
+				emit(proto.EventAlertFired, fmt.Sprintf("alert[%v] fired on [%v]: %v[%v] %v %v since %v", rule.Name,
+					sample.target, rule.Metric, sample.value, rule.Operator, rule.Threshold, state.since.Format(time.RFC3339)))
+			}
+		}
+	}
+	for key, state := range am.states {
+		if holding[key] {
+			continue
+		}
+		if state.firing {
+			emit(proto.EventAlertResolved, fmt.Sprintf("alert[%v] resolved on [%v]", state.rule, state.target))
+		}
+		delete(am.states, key)
+	}
+}
+
+func compareAlertValue(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	}
+	return false
+}
+
+func validateAlertRule(rule *proto.AlertRule) (err error) {
+	if rule.Name == "" {
+		return keyNotFound(nameKey)
+	}
+	if !contains(proto.AlertMetrics, rule.Metric) {
+		return fmt.Errorf("unknown metric[%v], expect one of %v", rule.Metric, proto.AlertMetrics)
+	}
+	if !contains(proto.AlertOperators, rule.Operator) {
+		return fmt.Errorf("unknown operator[%v], expect one of %v", rule.Operator, proto.AlertOperators)
+	}
+	if rule.Duration < 0 {
+		return fmt.Errorf("duration[%v] must not be negative", rule.Duration)
+	}
+	return
+}
+
+func (c *Cluster) scheduleToEvaluateAlertRules() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.evaluateAlertRules()
+			}
+			time.Sleep(alertCheckInterval)
+		}
+	}()
+}
+
+func (c *Cluster) evaluateAlertRules() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("evaluateAlertRules occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"evaluateAlertRules occurred panic")
+		}
+	}()
+	if len(c.alerts.getRules()) == 0 {
+		return
+	}
+	samples, err := c.alertSamples()
+	if err != nil {
+		log.LogErrorf("action[evaluateAlertRules] clusterID[%v] err[%v]", c.Name, err)
+		return
+	}
+	c.alerts.evaluate(samples, time.Now(), func(eventType, msg string) {
+		c.events.emit(eventType, "", "", 0, msg)
+	})
+}
+
+// alertSamples samples the metrics of the alert rules.
+func (c *Cluster) alertSamples() (samples map[string][]alertSample, err error) {
+	var health *proto.ClusterHealth
+	if health, err = c.checkHealth(defaultHealthMaxRaftLag, defaultHealthCapacityThreshold); err != nil {
+		return
+	}
+	samples = map[string][]alertSample{
+		proto.AlertMetricInactiveDataNodes:         {{c.Name, float64(len(health.InactiveDataNodes))}},
+		proto.AlertMetricInactiveMetaNodes:         {{c.Name, float64(len(health.InactiveMetaNodes))}},
+		proto.AlertMetricCorruptDataPartitions:     {{c.Name, float64(len(health.CorruptDataPartitionIDs))}},
+		proto.AlertMetricLackReplicaDataPartitions: {{c.Name, float64(len(health.LackReplicaDataPartitionIDs))}},
+		proto.AlertMetricCorruptMetaPartitions:     {{c.Name, float64(len(health.CorruptMetaPartitionIDs))}},
+		proto.AlertMetricLackReplicaMetaPartitions: {{c.Name, float64(len(health.LackReplicaMetaPartitionIDs))}},
+		proto.AlertMetricBadDisks:                  {{c.Name, float64(len(health.BadDisks))}},
+		proto.AlertMetricRaftLagReplicas:           {{c.Name, float64(len(health.RaftLagReplicas))}},
+		proto.AlertMetricDataUsedRatio:             {{c.Name, health.DataUsedRatio}},
+		proto.AlertMetricMetaUsedRatio:             {{c.Name, health.MetaUsedRatio}},
+	}
+	for zoneName, zoneStat := range c.zoneStatInfos {
+		if zoneStat.DataNodeStat != nil {
+			samples[proto.AlertMetricZoneDataUsedRatio] = append(samples[proto.AlertMetricZoneDataUsedRatio],
+				alertSample{zoneName, zoneStat.DataNodeStat.UsedRatio})
+		}
+		if zoneStat.MetaNodeStat != nil {
+			samples[proto.AlertMetricZoneMetaUsedRatio] = append(samples[proto.AlertMetricZoneMetaUsedRatio],
+				alertSample{zoneName, zoneStat.MetaNodeStat.UsedRatio})
+		}
+	}
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		if dataNode.isActive {
+			samples[proto.AlertMetricDataNodeUsedRatio] = append(samples[proto.AlertMetricDataNodeUsedRatio],
+				alertSample{dataNode.Addr, dataNode.UsageRatio})
+		}
+		dataNode.RUnlock()
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		metaNode.RLock()
+		if metaNode.IsActive {
+			samples[proto.AlertMetricMetaNodeUsedRatio] = append(samples[proto.AlertMetricMetaNodeUsedRatio],
+				alertSample{metaNode.Addr, metaNode.Ratio})
+		}
+		metaNode.RUnlock()
+		return true
+	})
+	return
+}
+
+func (c *Cluster) setAlertRule(rule *proto.AlertRule) (err error) {
+	if err = validateAlertRule(rule); err != nil {
+		return
+	}
+	oldRule, exist := c.alerts.getRule(rule.Name)
+	c.alerts.putRule(rule)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setAlertRule] rule[%v] err[%v]", rule.Name, err)
+		if exist {
+			c.alerts.putRule(oldRule)
+		} else {
+			c.alerts.deleteRule(rule.Name)
+		}
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) deleteAlertRule(name string) (err error) {
+	oldRule, exist := c.alerts.getRule(name)
+	if !exist {
+		return fmt.Errorf("alert rule[%v] not found", name)
+	}
+	c.alerts.deleteRule(name)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[deleteAlertRule] rule[%v] err[%v]", name, err)
+		c.alerts.putRule(oldRule)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
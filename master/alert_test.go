@@ -0,0 +1,43 @@
+package master
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestAlertManagerEvaluate(t *testing.T) {
+	am := newAlertManager()
+	am.putRule(&proto.AlertRule{Name: "lack", Metric: proto.AlertMetricLackReplicaDataPartitions, Operator: ">", Duration: 600})
+	am.putRule(&proto.AlertRule{Name: "full", Metric: proto.AlertMetricZoneDataUsedRatio, Operator: ">", Threshold: 0.9})
+	var fired, resolved []string
+	emit := func(eventType, msg string) {
+		if eventType == proto.EventAlertFired {
+			fired = append(fired, msg)
+		} else {
+			resolved = append(resolved, msg)
+		}
+	}
+	now := time.Now()
+	samples := map[string][]alertSample{
+		proto.AlertMetricLackReplicaDataPartitions: {{"test", 2}},
+		proto.AlertMetricZoneDataUsedRatio:         {{"z1", 0.95}, {"z2", 0.5}},
+	}
+	am.evaluate(samples, now, emit)
+	if len(fired) != 1 || len(am.view().Alerts) != 2 {
+		t.Fatalf("expect the zone alert fired and the lack alert pending, but got fired%v alerts%v", fired, am.view().Alerts)
+	}
+	am.evaluate(samples, now.Add(10*time.Minute), emit)
+	if len(fired) != 2 {
+		t.Fatalf("expect the lack alert fired after the duration, but got fired%v", fired)
+	}
+	samples[proto.AlertMetricZoneDataUsedRatio] = []alertSample{{"z1", 0.5}}
+	am.evaluate(samples, now.Add(11*time.Minute), emit)
+	if len(resolved) != 1 || len(am.view().Alerts) != 1 {
+		t.Fatalf("expect the zone alert resolved, but got resolved%v alerts%v", resolved, am.view().Alerts)
+	}
+	if err := validateAlertRule(&proto.AlertRule{Name: "bad", Metric: "unknown", Operator: ">"}); err == nil {
+		t.Fatalf("expect the unknown metric invalid")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -28,8 +29,10 @@ import (
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/compress"
 	"github.com/chubaofs/chubaofs/util/cryptoutil"
 	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/iputil"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
@@ -136,12 +139,12 @@ func (m *Server) getTopology(w http.ResponseWriter, r *http.Request) {
 			cv.NodeSet[ns.ID] = nsView
 			ns.dataNodes.Range(func(key, value interface{}) bool {
 				dataNode := value.(*DataNode)
-				nsView.DataNodes = append(nsView.DataNodes, proto.NodeView{ID: dataNode.ID, Addr: dataNode.Addr, Status: dataNode.isActive, IsWritable: dataNode.isWriteAble()})
+				nsView.DataNodes = append(nsView.DataNodes, proto.NodeView{ID: dataNode.ID, Addr: dataNode.Addr, Status: dataNode.isActive, IsWritable: dataNode.isWriteAble(), RackName: dataNode.RackName, HealthScore: dataNode.HealthScore})
 				return true
 			})
 			ns.metaNodes.Range(func(key, value interface{}) bool {
 				metaNode := value.(*MetaNode)
-				nsView.MetaNodes = append(nsView.MetaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable()})
+				nsView.MetaNodes = append(nsView.MetaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable(), RackName: metaNode.RackName, HealthScore: metaNode.HealthScore})
 				return true
 			})
 		}
@@ -177,6 +180,40 @@ func (m *Server) updateZone(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("update zone status to [%v] successfully", status)))
 }
 
+// Move a data node or a meta node into another zone.
+func (m *Server) moveZoneNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeType string
+		nodeAddr string
+		zoneName string
+		dataNode *DataNode
+		metaNode *MetaNode
+		err      error
+	)
+	if nodeType, nodeAddr, zoneName, err = parseRequestToMoveZoneNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if nodeType == proto.PartitionTypeData {
+		if dataNode, err = m.cluster.dataNode(nodeAddr); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeDataNodeNotExists, Msg: err.Error()})
+			return
+		}
+		err = m.cluster.moveDataNodeToZone(dataNode, zoneName)
+	} else {
+		if metaNode, err = m.cluster.metaNode(nodeAddr); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeMetaNodeNotExists, Msg: err.Error()})
+			return
+		}
+		err = m.cluster.moveMetaNodeToZone(metaNode, zoneName)
+	}
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("move %v node[%v] to zone[%v] successfully", nodeType, nodeAddr, zoneName)))
+}
+
 func (m *Server) listZone(w http.ResponseWriter, r *http.Request) {
 	zones := m.cluster.t.getAllZones()
 	zoneViews := make([]*ZoneView, 0)
@@ -188,6 +225,86 @@ func (m *Server) listZone(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(zoneViews))
 }
 
+// Check the health of the cluster, the reply is healthy only if no issue is found.
+func (m *Server) clusterHealth(w http.ResponseWriter, r *http.Request) {
+	var (
+		maxRaftLag        uint64
+		capacityThreshold float64
+		health            *proto.ClusterHealth
+		err               error
+	)
+	if maxRaftLag, capacityThreshold, err = parseRequestToCheckHealth(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if health, err = m.cluster.checkHealth(maxRaftLag, capacityThreshold); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(health))
+}
+
+// recordCliAudit writes the mutating invocations of the CLI into the log of master,
+// so that the operations on the cluster can be traced even if the local audit files are lost.
+func (m *Server) recordCliAudit(w http.ResponseWriter, r *http.Request) {
+	var (
+		body   []byte
+		record = &proto.CliAuditRecord{}
+		err    error
+	)
+	if body, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, record); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if record.Command == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: "command is empty"})
+		return
+	}
+	log.LogWarnf("action[recordCliAudit] clusterID[%v] remote[%v] user[%v] host[%v] time[%v] command[%v] args%v "+
+		"exitCode[%v] result[%v] duration[%vms]", m.cluster.Name, r.RemoteAddr, record.User, record.Host,
+		time.Unix(record.Time, 0).Format(time.RFC3339), record.Command, record.Args, record.ExitCode, record.Result,
+		record.Duration)
+	if err = m.cluster.syncPutAuditRecord(newCLIAuditRecord(r, record)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply("record cli audit successfully"))
+}
+
+// Restart the meta nodes or the data nodes batch by batch through the node agents in background.
+func (m *Server) rollingRestart(w http.ResponseWriter, r *http.Request) {
+	var (
+		role             string
+		nodes            []string
+		batch, agentPort int
+		timeout          time.Duration
+		rr               *rollingRestart
+		task             *proto.AsyncTaskInfo
+		err              error
+	)
+	if role, nodes, batch, agentPort, timeout, err = parseRequestToRollingRestart(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if rr, err = m.cluster.newRollingRestart(role, nodes, batch, agentPort, timeout); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	op := func(progress progressFunc) error {
+		return m.cluster.doRollingRestart(rr, progress)
+	}
+	if task, err = m.cluster.asyncTasks.submitClusterTask(proto.AsyncTaskRollingRestart, op); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive rollingRestart role[%v] nodes%v batch[%v], task[%v]", rr.role, rr.nodes, rr.batch, task.ID))
+	sendOkReply(w, r, newSuccessHTTPReply(task))
+}
+
 func (m *Server) clusterStat(w http.ResponseWriter, r *http.Request) {
 	cs := &proto.ClusterStatInfo{
 		DataNodeStatInfo: m.cluster.dataNodeStatInfo,
@@ -242,12 +359,14 @@ func (m *Server) getIPAddr(w http.ResponseWriter, r *http.Request) {
 	limitRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeDeleteLimitRate)
 	deleteSleepMs := atomic.LoadUint64(&m.cluster.cfg.MetaNodeDeleteWorkerSleepMs)
 	autoRepairRate := atomic.LoadUint64(&m.cluster.cfg.DataNodeAutoRepairLimitRate)
+	_, repairBandwidth := m.cluster.dataNodeDecommissionControls()
 	cInfo := &proto.ClusterInfo{
 		Cluster:                     m.cluster.Name,
 		MetaNodeDeleteBatchCount:    batchCount,
 		MetaNodeDeleteWorkerSleepMs: deleteSleepMs,
 		DataNodeDeleteLimitRate:     limitRate,
 		DataNodeAutoRepairLimitRate: autoRepairRate,
+		DataNodeRepairBandwidth:     repairBandwidth,
 		Ip:                          strings.Split(r.RemoteAddr, ":")[0],
 	}
 	sendOkReply(w, r, newSuccessHTTPReply(cInfo))
@@ -381,15 +500,17 @@ func (m *Server) addDataReplica(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = m.cluster.addDataReplica(dp, addr); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	op := func() (err error) {
+		if err = m.cluster.addDataReplica(dp, addr); err != nil {
+			return
+		}
+		dp.Status = proto.ReadOnly
+		dp.isRecover = true
+		m.cluster.putBadDataPartitionIDs(nil, addr, dp.PartitionID)
 		return
 	}
-	dp.Status = proto.ReadOnly
-	dp.isRecover = true
-	m.cluster.putBadDataPartitionIDs(nil, addr, dp.PartitionID)
 	msg = fmt.Sprintf("data partitionID :%v  add replica [%v] successfully", partitionID, addr)
-	sendOkReply(w, r, newSuccessHTTPReply(msg))
+	m.runAdminOperation(w, r, proto.AsyncTaskAddDataReplica, partitionID, addr, op, msg)
 }
 
 func (m *Server) deleteDataReplica(w http.ResponseWriter, r *http.Request) {
@@ -411,12 +532,85 @@ func (m *Server) deleteDataReplica(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = m.cluster.removeDataReplica(dp, addr, true); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planDeleteDataReplica(dp, addr)
+	}) {
 		return
 	}
+	op := func() error {
+		return m.cluster.removeDataReplica(dp, addr, true)
+	}
 	msg = fmt.Sprintf("data partitionID :%v  delete replica [%v] successfully", partitionID, addr)
-	sendOkReply(w, r, newSuccessHTTPReply(msg))
+	m.runAdminOperation(w, r, proto.AsyncTaskDeleteDataReplica, partitionID, addr, op, msg)
+}
+
+func (m *Server) transferDataPartitionLeader(w http.ResponseWriter, r *http.Request) {
+	var (
+		addr        string
+		dp          *DataPartition
+		partitionID uint64
+		err         error
+	)
+	if partitionID, addr, err = extractDataPartitionIDAndAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if dp, err = m.cluster.getDataPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+		return
+	}
+	if err = m.cluster.transferDataPartitionLeader(dp, addr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("data partitionID :%v  transfer leader to [%v] successfully", partitionID, addr)))
+}
+
+// setDataPartitionStatus forces the data partition read-only or writable, which overrides the status decided by the
+// master until it is set to auto.
+func (m *Server) setDataPartitionStatus(w http.ResponseWriter, r *http.Request) {
+	var (
+		dp          *DataPartition
+		partitionID uint64
+		status      int8
+		err         error
+	)
+	if partitionID, status, err = parseRequestToSetDataPartitionStatus(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if dp, err = m.cluster.getDataPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+		return
+	}
+	if err = m.cluster.setDataPartitionForcedStatus(dp, status, r.FormValue(reasonKey)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("data partitionID :%v  status is set to %v successfully",
+		partitionID, formatForcedStatus(status))))
+}
+
+func (m *Server) transferMetaPartitionLeader(w http.ResponseWriter, r *http.Request) {
+	var (
+		addr        string
+		mp          *MetaPartition
+		partitionID uint64
+		err         error
+	)
+	if partitionID, addr, err = extractMetaPartitionIDAndAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+	if err = m.cluster.transferMetaPartitionLeader(mp, addr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("meta partitionID :%v  transfer leader to [%v] successfully", partitionID, addr)))
 }
 
 func (m *Server) addMetaReplica(w http.ResponseWriter, r *http.Request) {
@@ -438,14 +632,16 @@ func (m *Server) addMetaReplica(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = m.cluster.addMetaReplica(mp, addr); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	op := func() (err error) {
+		if err = m.cluster.addMetaReplica(mp, addr); err != nil {
+			return
+		}
+		mp.IsRecover = true
+		m.cluster.putBadMetaPartitions(addr, mp.PartitionID)
 		return
 	}
-	mp.IsRecover = true
-	m.cluster.putBadMetaPartitions(addr, mp.PartitionID)
 	msg = fmt.Sprintf("meta partitionID :%v  add replica [%v] successfully", partitionID, addr)
-	sendOkReply(w, r, newSuccessHTTPReply(msg))
+	m.runAdminOperation(w, r, proto.AsyncTaskAddMetaReplica, partitionID, addr, op, msg)
 }
 
 func (m *Server) deleteMetaReplica(w http.ResponseWriter, r *http.Request) {
@@ -467,12 +663,16 @@ func (m *Server) deleteMetaReplica(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err = m.cluster.deleteMetaReplica(mp, addr, true); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planDeleteMetaReplica(mp, addr)
+	}) {
 		return
 	}
+	op := func() error {
+		return m.cluster.deleteMetaReplica(mp, addr, true)
+	}
 	msg = fmt.Sprintf("meta partitionID :%v  delete replica [%v] successfully", partitionID, addr)
-	sendOkReply(w, r, newSuccessHTTPReply(msg))
+	m.runAdminOperation(w, r, proto.AsyncTaskDeleteMetaReplica, partitionID, addr, op, msg)
 }
 
 // Decommission a data partition. This usually happens when disk error has been reported.
@@ -494,11 +694,45 @@ func (m *Server) decommissionDataPartition(w http.ResponseWriter, r *http.Reques
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
 		return
 	}
-	if err = m.cluster.decommissionDataPartition(addr, dp, handleDataPartitionOfflineErr); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planDecommissionDataPartition(dp, addr)
+	}) {
 		return
 	}
+	op := func() error {
+		return m.cluster.decommissionDataPartition(addr, dp, handleDataPartitionOfflineErr)
+	}
 	rstMsg = fmt.Sprintf(proto.AdminDecommissionDataPartition+" dataPartitionID :%v  on node:%v successfully", partitionID, addr)
+	m.runAdminOperation(w, r, proto.AsyncTaskDecommissionDataPartition, partitionID, addr, op, rstMsg)
+}
+
+// Reset the raft members of a data partition which has lost the majority of its replicas.
+// This function needs to be called manually by the admin and may lead to data loss.
+func (m *Server) resetDataPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		rstMsg      string
+		dp          *DataPartition
+		partitionID uint64
+		err         error
+	)
+	if partitionID, err = parseRequestToResetDataPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if dp, err = m.cluster.getDataPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataPartitionNotExists))
+		return
+	}
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planResetDataPartition(dp)
+	}) {
+		return
+	}
+	if err = m.cluster.resetDataPartition(dp); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	rstMsg = fmt.Sprintf(proto.AdminResetDataPartition+" dataPartitionID :%v reset successfully", partitionID)
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
@@ -532,10 +766,12 @@ func (m *Server) diagnoseDataPartition(w http.ResponseWriter, r *http.Request) {
 	}
 	badDataPartitions = m.cluster.getBadDataPartitionsView()
 	rstMsg = &proto.DataPartitionDiagnosis{
-		InactiveDataNodes:           inactiveNodes,
-		CorruptDataPartitionIDs:     corruptDpIDs,
-		LackReplicaDataPartitionIDs: lackReplicaDpIDs,
-		BadDataPartitionIDs:         badDataPartitions,
+		InactiveDataNodes:            inactiveNodes,
+		CorruptDataPartitionIDs:      corruptDpIDs,
+		LackReplicaDataPartitionIDs:  lackReplicaDpIDs,
+		BadDataPartitionIDs:          badDataPartitions,
+		RackRiskDataPartitionIDs:     m.cluster.checkRackRiskDataPartitions(),
+		ForcedStatusDataPartitionIDs: m.cluster.getForcedStatusDataPartitionIDs(),
 	}
 	log.LogInfof("diagnose dataPartition[%v] inactiveNodes:[%v], corruptDpIDs:[%v], lackReplicaDpIDs:[%v]", m.cluster.Name, inactiveNodes, corruptDpIDs, lackReplicaDpIDs)
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
@@ -563,6 +799,44 @@ func (m *Server) markDeleteVol(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	msg = fmt.Sprintf("delete vol[%v] successfully,from[%v]", name, r.RemoteAddr)
+	if delay := m.cluster.volDeletionDelay(); delay > 0 {
+		msg += fmt.Sprintf(",the vol can be restored in [%v]", time.Duration(delay)*time.Second)
+	}
+	log.LogWarn(msg)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// restoreVol recovers the volume deleted within the retention window, and the owner gets the volume back.
+func (m *Server) restoreVol(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		vol     *Vol
+		err     error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if err = m.user.checkQuota(m.cluster, vol.Owner, name, vol.Capacity); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if vol, err = m.cluster.restoreVol(name, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.associateVolWithUser(vol.Owner, name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("restore vol[%v] successfully,from[%v]", name, r.RemoteAddr)
 	log.LogWarn(msg)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
@@ -582,6 +856,17 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		description    string
 		dpSelectorName string
 		dpSelectorParm string
+		placement      string
+		placementZone  string
+		labels         map[string]string
+		qos            proto.VolQos
+		ipAcl          proto.VolIPAcl
+		trashTTL       uint64
+		metaStore      string
+		inodeRetention uint64
+		verifyReadCrc  bool
+		compression    string
+		encryptionKeys []*volEncryptionKey
 		vol            *Vol
 	)
 
@@ -608,6 +893,86 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if placement, placementZone, err = parsePlacementToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	labels = vol.placementLabels
+	if _, ok := r.Form[placementLabelsKey]; ok {
+		if labels, err = proto.ParseLabels(r.FormValue(placementLabelsKey)); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
+	if qos, err = parseQosToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ipAcl, err = parseIPAclToUpdateVol(r, vol); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	trashTTL = vol.trashTTL
+	if value := r.FormValue(trashTTLKey); value != "" {
+		if trashTTL, err = strconv.ParseUint(value, 10, 64); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(trashTTLKey).Error()})
+			return
+		}
+	}
+	metaStore = vol.metaStore
+	if _, ok := r.Form[metaStoreKey]; ok {
+		if metaStore = r.FormValue(metaStoreKey); !proto.IsValidMetaStore(metaStore) {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(metaStoreKey).Error()})
+			return
+		}
+	}
+
+	inodeRetention = vol.inodeRetention
+	if value := r.FormValue(inodeRetentionKey); value != "" {
+		if inodeRetention, err = strconv.ParseUint(value, 10, 64); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(inodeRetentionKey).Error()})
+			return
+		}
+	}
+	verifyReadCrc = vol.verifyReadCrc
+	if value := r.FormValue(verifyReadCrcKey); value != "" {
+		if verifyReadCrc, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(verifyReadCrcKey).Error()})
+			return
+		}
+	}
+	compression = vol.compression
+	if _, ok := r.Form[compressionKey]; ok {
+		if compression = r.FormValue(compressionKey); compression == "none" {
+			compression = compress.AlgorithmNone
+		}
+		if !compress.Valid(compression) {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(compressionKey).Error()})
+			return
+		}
+	}
+
+	encryptionKeys = vol.encryptionKeys
+	if value := r.FormValue(encryptedKey); value != "" {
+		var encrypted bool
+		if encrypted, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(encryptedKey).Error()})
+			return
+		}
+		if !encrypted && len(encryptionKeys) != 0 {
+			err = fmt.Errorf("the encryption of vol[%v] can not be disabled", name)
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+		if encrypted && len(encryptionKeys) == 0 {
+			var key *volEncryptionKey
+			if key, err = m.cluster.newVolEncryptionKey(name, nil); err != nil {
+				sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+				return
+			}
+			encryptionKeys = []*volEncryptionKey{key}
+		}
+	}
 
 	newArgs := getVolVarargs(vol)
 
@@ -619,7 +984,26 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	newArgs.enableToken = enableToken
 	newArgs.dpSelectorName = dpSelectorName
 	newArgs.dpSelectorParm = dpSelectorParm
-
+	newArgs.placementPolicy = placement
+	newArgs.placementZone = placementZone
+	newArgs.placementLabels = labels
+	newArgs.qos = qos
+	newArgs.ipAcl = ipAcl
+	newArgs.trashTTL = trashTTL
+	newArgs.metaStore = metaStore
+	newArgs.inodeRetention = inodeRetention
+	newArgs.verifyReadCrc = verifyReadCrc
+	newArgs.compression = compression
+	newArgs.encryptionKeys = encryptionKeys
+
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if capacity > vol.Capacity {
+		if err = m.user.checkQuota(m.cluster, vol.Owner, name, capacity); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
 	if err = m.cluster.updateVol(name, authKey, newArgs); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -628,32 +1012,475 @@ func (m *Server) updateVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
-func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
+// getVolQos replies the IOPS and the bandwidth limits of the volumes which are limited, the data nodes pull them
+// periodically to throttle the requests of the volumes.
+func (m *Server) getVolQos(w http.ResponseWriter, r *http.Request) {
+	volQos := make(map[string]proto.VolQos)
+	for name, vol := range m.cluster.copyVols() {
+		vol.RLock()
+		qos := vol.qos
+		vol.RUnlock()
+		if qos.IsLimited() {
+			volQos[name] = qos
+		}
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(volQos))
+}
+
+// getVolIPAcl replies the client IP restrictions of the volumes which are restricted, the meta nodes and the data
+// nodes pull them periodically to reject the requests of the denied clients.
+func (m *Server) getVolIPAcl(w http.ResponseWriter, r *http.Request) {
+	volIPAcl := make(map[string]proto.VolIPAcl)
+	for name, vol := range m.cluster.copyVols() {
+		vol.RLock()
+		ipAcl := vol.ipAcl
+		vol.RUnlock()
+		if ipAcl.IsSet() {
+			volIPAcl[name] = ipAcl
+		}
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(volIPAcl))
+}
+
+// getVerifyReadCrcVols replies the names of the volumes whose data read is checked against the crc of the extent
+// blocks, the data nodes pull them periodically.
+func (m *Server) getVerifyReadCrcVols(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0)
+	for name, vol := range m.cluster.copyVols() {
+		vol.RLock()
+		verify := vol.verifyReadCrc
+		vol.RUnlock()
+		if verify {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	sendOkReply(w, r, newSuccessHTTPReply(names))
+}
+
+// getCompressionVols replies the compression algorithms of the volumes with compression, the data nodes pull them
+// periodically to compress the cold extents of the volumes.
+func (m *Server) getCompressionVols(w http.ResponseWriter, r *http.Request) {
+	algorithms := make(map[string]string)
+	for name, vol := range m.cluster.copyVols() {
+		vol.RLock()
+		algorithm := vol.compression
+		vol.RUnlock()
+		if algorithm != compress.AlgorithmNone {
+			algorithms[name] = algorithm
+		}
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(algorithms))
+}
+
+func (m *Server) setDirQuota(w http.ResponseWriter, r *http.Request) {
 	var (
 		name     string
-		authKey  string
+		dirPath  string
+		maxBytes uint64
+		maxFiles uint64
+		reply    = &proto.DirQuotaReply{}
 		err      error
-		msg      string
-		capacity int
-		vol      *Vol
 	)
-	if name, authKey, capacity, err = parseRequestToSetVolCapacity(r); err != nil {
+	if name, dirPath, maxBytes, maxFiles, err = parseRequestToSetDirQuota(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.getVol(name); err != nil {
-		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+	if reply.Quota, reply.Task, err = m.cluster.setDirQuota(name, dirPath, maxBytes, maxFiles); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	if uint64(capacity) <= vol.Capacity {
-		err = fmt.Errorf("expand capacity[%v] should be larger than the old capacity[%v]", capacity, vol.Capacity)
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) deleteDirQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		quotaID uint64
+		reply   = &proto.DirQuotaReply{}
+		err     error
+	)
+	if name, err = parseVolName(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if quotaID, err = strconv.ParseUint(r.FormValue(quotaIDKey), 10, 32); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(quotaIDKey).Error()})
+		return
+	}
+	if reply.Quota, reply.Task, err = m.cluster.deleteDirQuota(name, uint32(quotaID)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+// listDirQuotas replies the directory quotas of the volume with the usage, the clients pull them periodically
+// to enforce the quotas.
+func (m *Server) listDirQuotas(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(vol.getDirQuotas()))
+}
+
+func (m *Server) setExpirationRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		dirPath string
+		days    uint64
+		basis   string
+		reply   = &proto.ExpirationRuleReply{}
+		err     error
+	)
+	if name, dirPath, days, basis, err = parseRequestToSetExpirationRule(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if reply.Rule, err = m.cluster.setExpirationRule(name, dirPath, uint32(days), basis); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) deleteExpirationRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		ruleID uint64
+		reply  = &proto.ExpirationRuleReply{}
+		err    error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ruleID, err = strconv.ParseUint(r.FormValue(ruleIDKey), 10, 32); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(ruleIDKey).Error()})
+		return
+	}
+	if reply.Rule, err = m.cluster.deleteExpirationRule(name, uint32(ruleID)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) listExpirationRules(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	vol.RLock()
+	rules := vol.expirationRules
+	vol.RUnlock()
+	if rules == nil {
+		rules = make([]*proto.ExpirationRule, 0)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(rules))
+}
+
+func (m *Server) setLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		dirPath string
+		days    uint64
+		basis   string
+		class   string
+		reply   = &proto.LifecycleRuleReply{}
+		err     error
+	)
+	if name, dirPath, days, basis, class, err = parseRequestToSetLifecycleRule(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if reply.Rule, err = m.cluster.setLifecycleRule(name, dirPath, uint32(days), basis, class); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) deleteLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		ruleID uint64
+		reply  = &proto.LifecycleRuleReply{}
+		err    error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if ruleID, err = strconv.ParseUint(r.FormValue(ruleIDKey), 10, 32); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(ruleIDKey).Error()})
+		return
+	}
+	if reply.Rule, err = m.cluster.deleteLifecycleRule(name, uint32(ruleID)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) listLifecycleRules(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	vol.RLock()
+	rules := vol.lifecycleRules
+	vol.RUnlock()
+	if rules == nil {
+		rules = make([]*proto.LifecycleRule, 0)
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(rules))
+}
+
+func (m *Server) createVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		snapshot string
+		reply    = &proto.VolSnapshotReply{}
+		err      error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if snapshot = r.FormValue(snapshotKey); snapshot == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(snapshotKey).Error()})
+		return
+	}
+	if reply.Snapshot, reply.Task, err = m.cluster.createVolSnapshot(name, snapshot); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive createVolSnapshot vol[%v] snapshot[%v] name[%v]", name, reply.Snapshot.ID, snapshot))
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) listVolSnapshots(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(vol.getSnapshots()))
+}
+
+func (m *Server) deleteVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name  string
+		id    uint64
+		reply = &proto.VolSnapshotReply{}
+		err   error
+	)
+	if name, id, err = parseRequestToOperateVolSnapshot(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if reply.Snapshot, reply.Task, err = m.cluster.deleteVolSnapshot(name, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive deleteVolSnapshot vol[%v] snapshot[%v]", name, id))
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+// rollbackVolSnapshot replaces the metadata of the volume with the snapshot, the clients should be unmounted
+// before the rollback.
+func (m *Server) rollbackVolSnapshot(w http.ResponseWriter, r *http.Request) {
+	var (
+		name  string
+		id    uint64
+		reply = &proto.VolSnapshotReply{}
+		err   error
+	)
+	if name, id, err = parseRequestToOperateVolSnapshot(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if reply.Snapshot, reply.Task, err = m.cluster.rollbackVolSnapshot(name, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive rollbackVolSnapshot vol[%v] snapshot[%v]", name, id))
+	sendOkReply(w, r, newSuccessHTTPReply(reply))
+}
+
+func (m *Server) setVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name          string
+		authKey       string
+		targetMasters []string
+		targetVol     string
+		maxLag        int64 = defaultReplicationMaxLag
+		view          *proto.VolReplicationView
+		err           error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	targetVol = r.FormValue(targetVolKey)
+	for _, addr := range strings.Split(r.FormValue(targetMasterKey), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			targetMasters = append(targetMasters, addr)
+		}
+	}
+	if value := r.FormValue(maxLagKey); value != "" {
+		if maxLag, err = strconv.ParseInt(value, 10, 64); err != nil || maxLag < 0 {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(maxLagKey).Error()})
+			return
+		}
+	}
+	if view, err = m.cluster.setVolReplication(name, authKey, targetMasters, targetVol, maxLag); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive setVolReplication vol[%v] target[%v] masters%v maxLag[%v]",
+		name, targetVol, targetMasters, maxLag))
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
+func (m *Server) getVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name string
+		vol  *Vol
+		err  error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.volReplicationView(vol, time.Now().Unix())))
+}
+
+func (m *Server) listVolReplications(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.listVolReplications()))
+}
+
+// reportVolReplication records the progress reported by the replicator of the volume.
+func (m *Server) reportVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name   string
+		body   []byte
+		status = &proto.VolReplicationStatus{}
+		err    error
+	)
+	if name, err = parseVolName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if body, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = json.Unmarshal(body, status); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.reportVolReplication(name, status); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("report replication of vol[%v] successfully", name)))
+}
+
+// failoverVolReplication stops replicating the volume, the clients should be switched to the standby volume.
+func (m *Server) failoverVolReplication(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		view    *proto.VolReplicationView
+		err     error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if view, err = m.cluster.failoverVolReplication(name, authKey); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive failoverVolReplication vol[%v] target[%v] masters%v",
+		name, view.Replication.TargetVol, view.Replication.TargetMasters))
+	sendOkReply(w, r, newSuccessHTTPReply(view))
+}
+
+func (m *Server) volExpand(w http.ResponseWriter, r *http.Request) {
+	var (
+		name     string
+		authKey  string
+		err      error
+		msg      string
+		capacity int
+		vol      *Vol
+	)
+	if name, authKey, capacity, err = parseRequestToSetVolCapacity(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	if uint64(capacity) <= vol.Capacity {
+		err = fmt.Errorf("expand capacity[%v] should be larger than the old capacity[%v]", capacity, vol.Capacity)
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if err = m.user.checkQuota(m.cluster, vol.Owner, name, uint64(capacity)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+
+	newArgs := getVolVarargs(vol)
+	newArgs.capacity = uint64(capacity)
 
-	newArgs := getVolVarargs(vol)
-	newArgs.capacity = uint64(capacity)
-
 	if err = m.cluster.updateVol(name, authKey, newArgs); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -698,24 +1525,25 @@ func (m *Server) volShrink(w http.ResponseWriter, r *http.Request) {
 
 func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 	var (
-		name         string
-		owner        string
-		err          error
-		msg          string
-		size         int
-		mpCount      int
-		dpReplicaNum int
-		capacity     int
-		vol          *Vol
-		followerRead bool
-		authenticate bool
-		crossZone    bool
-		enableToken  bool
-		zoneName     string
-		description  string
+		name            string
+		owner           string
+		err             error
+		msg             string
+		size            int
+		mpCount         int
+		dpReplicaNum    int
+		capacity        int
+		vol             *Vol
+		followerRead    bool
+		authenticate    bool
+		crossZone       bool
+		enableToken     bool
+		caseInsensitive bool
+		zoneName        string
+		description     string
 	)
 
-	if name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, err = parseRequestToCreateVol(r); err != nil {
+	if name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, caseInsensitive, err = parseRequestToCreateVol(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
@@ -724,7 +1552,13 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.createVol(name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken); err != nil {
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if err = m.user.checkQuota(m.cluster, owner, name, uint64(capacity)); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if vol, err = m.cluster.createVol(name, owner, zoneName, description, mpCount, dpReplicaNum, size, capacity, followerRead, authenticate, crossZone, enableToken, caseInsensitive); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -737,25 +1571,71 @@ func (m *Server) createVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
-func (m *Server) getVolSimpleInfo(w http.ResponseWriter, r *http.Request) {
+// cloneVol creates a volume with the settings of the source volume, and copies the files of the source volume
+// into it in background. The reply is the task which tracks the copy.
+func (m *Server) cloneVol(w http.ResponseWriter, r *http.Request) {
 	var (
-		err     error
-		name    string
-		vol     *Vol
-		volView *proto.SimpleVolView
+		srcName, dstName, owner string
+		src, dst                *Vol
+		task                    *proto.AsyncTaskInfo
+		err                     error
 	)
-	if name, err = parseAndExtractName(r); err != nil {
+	if srcName, dstName, owner, err = parseRequestToCloneVol(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if vol, err = m.cluster.getVol(name); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+	if src, err = m.cluster.getVol(srcName); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
 		return
 	}
-	volView = newSimpleView(vol)
-	sendOkReply(w, r, newSuccessHTTPReply(volView))
-}
-
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	quotaOwner := owner
+	if quotaOwner == "" {
+		quotaOwner = src.Owner
+	}
+	if err = m.user.checkQuota(m.cluster, quotaOwner, dstName, src.Capacity); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if _, dst, err = m.cluster.createCloneVol(srcName, dstName, owner); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.associateVolWithUser(dst.Owner, dstName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	op := func(progress progressFunc) error {
+		return m.cluster.cloneVolume(srcName, dstName, progress)
+	}
+	if task, err = m.cluster.asyncTasks.submitVolumeTask(proto.AsyncTaskCloneVolume, dstName, op); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive cloneVol src[%v] dst[%v] owner[%v], task[%v]", srcName, dstName, dst.Owner, task.ID))
+	sendOkReply(w, r, newSuccessHTTPReply(task))
+}
+
+func (m *Server) getVolSimpleInfo(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		name    string
+		vol     *Vol
+		volView *proto.SimpleVolView
+	)
+	if name, err = parseAndExtractName(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
+	volView = newSimpleView(vol)
+	sendOkReply(w, r, newSuccessHTTPReply(volView))
+}
+
 func newSimpleView(vol *Vol) *proto.SimpleVolView {
 	var (
 		volInodeCount  uint64
@@ -791,187 +1671,641 @@ func newSimpleView(vol *Vol) *proto.SimpleVolView {
 		Description:        vol.description,
 		DpSelectorName:     vol.dpSelectorName,
 		DpSelectorParm:     vol.dpSelectorParm,
+		PlacementPolicy:    vol.placementPolicy,
+		PlacementZone:      vol.placementZone,
+		PlacementLabels:    vol.placementLabels,
+		Qos:                vol.qos,
+		IPAcl:              vol.ipAcl,
+		SnapshotCount:      len(vol.snapshots),
+		SnapshotEpoch:      vol.maxSnapshotID,
+		TrashTTL:           vol.trashTTL,
+		MetaStore:          vol.metaStore,
+		InodeRetention:     vol.inodeRetention,
+		ExpirationRules:    vol.expirationRules,
+		LifecycleRules:     vol.lifecycleRules,
+		CaseInsensitive:    vol.caseInsensitive,
+		VerifyReadCrc:      vol.verifyReadCrc,
+		Compression:        vol.compression,
+		Encrypted:          len(vol.encryptionKeys) != 0,
+		KeyVersion:         encryptedVolKeyVersion(vol.encryptionKeys),
+	}
+}
+
+func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		zoneName string
+		rackName string
+		id       uint64
+		err      error
+	)
+	if nodeAddr, zoneName, rackName, err = parseRequestForAddNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if id, err = m.cluster.addDataNode(nodeAddr, zoneName, rackName); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(id))
+}
+
+func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr     string
+		dataNode     *DataNode
+		dataNodeInfo *proto.DataNodeInfo
+		err          error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if dataNode, err = m.cluster.dataNode(nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	dataNode.PersistenceDataPartitions = m.cluster.getAllDataPartitionIDByDatanode(nodeAddr)
+
+	dataNodeInfo = &proto.DataNodeInfo{
+		Total:                     dataNode.Total,
+		Used:                      dataNode.Used,
+		AvailableSpace:            dataNode.AvailableSpace,
+		ID:                        dataNode.ID,
+		ZoneName:                  dataNode.ZoneName,
+		RackName:                  dataNode.RackName,
+		Addr:                      dataNode.Addr,
+		ReportTime:                dataNode.ReportTime,
+		IsActive:                  dataNode.isActive,
+		UsageRatio:                dataNode.UsageRatio,
+		SelectedTimes:             dataNode.SelectedTimes,
+		Carry:                     dataNode.Carry,
+		DataPartitionReports:      dataNode.DataPartitionReports,
+		DataPartitionCount:        dataNode.DataPartitionCount,
+		NodeSetID:                 dataNode.NodeSetID,
+		PersistenceDataPartitions: dataNode.PersistenceDataPartitions,
+		BadDisks:                  dataNode.BadDisks,
+		HeartbeatLatency:          dataNode.HeartbeatLatency,
+		HealthScore:               dataNode.HealthScore,
+		Labels:                    dataNode.getLabels(),
+		DiskSmarts:                dataNode.DiskSmarts,
+		RetiringDisks:             dataNode.getRetiringDisks(),
+	}
+
+	sendOkReply(w, r, newSuccessHTTPReply(dataNodeInfo))
+}
+
+// getDataNodePartitions lists the data partitions hosted on the data node with the state of the replicas.
+func (m *Server) getDataNodePartitions(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.dataNode(nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getDataNodePartitionViews(nodeAddr)))
+}
+
+// Decommission a data node. This will decommission all the data partition on that node.
+func (m *Server) decommissionDataNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		node        *DataNode
+		rstMsg      string
+		offLineAddr string
+		err         error
+	)
+
+	if offLineAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if node, err = m.cluster.dataNode(offLineAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	if err = m.cluster.decommissionDataNode(node); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	rstMsg = fmt.Sprintf("decommission data node [%v] successfully", offLineAddr)
+	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+}
+
+func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		params map[string]interface{}
+		err    error
+	)
+	if params, err = parseAndExtractSetNodeInfoParams(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+
+	if batchCount, ok := params[nodeDeleteBatchCountKey]; ok {
+		if bc, ok := batchCount.(uint64); ok {
+			if err = m.cluster.setMetaNodeDeleteBatchCount(bc); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+	if val, ok := params[nodeMarkDeleteRateKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setDataNodeDeleteLimitRate(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	if val, ok := params[nodeAutoRepairRateKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setDataNodeAutoRepairLimitRate(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	if val, ok := params[nodeDeleteWorkerSleepMs]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setMetaNodeDeleteWorkerSleepMs(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	if val, ok := params[autoSupplementLimitKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setAutoReplicaSupplementLimit(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	if val, ok := params[volDeletionDelayKey]; ok {
+		if v, ok := val.(uint64); ok {
+			if err = m.cluster.setVolDeletionDelay(v); err != nil {
+				sendErrReply(w, r, newErrHTTPReply(err))
+				return
+			}
+		}
+	}
+
+	_, okInodeCount := params[mpSplitInodeCountKey]
+	_, okMemory := params[mpSplitMemoryKey]
+	if okInodeCount || okMemory {
+		inodeCount, memory := m.cluster.metaPartitionSplitThreshold()
+		if v, ok := params[mpSplitInodeCountKey].(uint64); ok {
+			inodeCount = v
+		}
+		if v, ok := params[mpSplitMemoryKey].(uint64); ok {
+			memory = v
+		}
+		if err = m.cluster.setMetaPartitionSplitThreshold(inodeCount, memory); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
+
+	_, okLimit := params[decommissionLimitKey]
+	_, okBandwidth := params[decommissionBwKey]
+	if okLimit || okBandwidth {
+		limit, bandwidth := m.cluster.dataNodeDecommissionControls()
+		if v, ok := params[decommissionLimitKey].(uint64); ok {
+			limit = v
+		}
+		if v, ok := params[decommissionBwKey].(uint64); ok {
+			bandwidth = v
+		}
+		if err = m.cluster.setDataNodeDecommissionControls(limit, bandwidth); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(err))
+			return
+		}
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set nodeinfo params %v successfully", params)))
+
+}
+
+// get metanode some interval params
+func (m *Server) getNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
+	resp := make(map[string]string)
+	resp[nodeDeleteBatchCountKey] = fmt.Sprintf("%v", m.cluster.cfg.MetaNodeDeleteBatchCount)
+	resp[nodeMarkDeleteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDeleteLimitRate)
+	resp[nodeDeleteWorkerSleepMs] = fmt.Sprintf("%v", m.cluster.cfg.MetaNodeDeleteWorkerSleepMs)
+	resp[nodeAutoRepairRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeAutoRepairLimitRate)
+	resp[autoSupplementLimitKey] = fmt.Sprintf("%v", atomic.LoadUint64(&m.cluster.cfg.AutoReplicaSupplementLimit))
+	resp[autoSupplementingKey] = fmt.Sprintf("%v", m.cluster.replicaSupplements.count())
+	resp[volDeletionDelayKey] = fmt.Sprintf("%v", m.cluster.volDeletionDelay())
+	inodeCount, memory := m.cluster.metaPartitionSplitThreshold()
+	resp[mpSplitInodeCountKey] = fmt.Sprintf("%v", inodeCount)
+	resp[mpSplitMemoryKey] = fmt.Sprintf("%v", memory)
+	limit, bandwidth := m.cluster.dataNodeDecommissionControls()
+	resp[decommissionLimitKey] = fmt.Sprintf("%v", limit)
+	resp[decommissionBwKey] = fmt.Sprintf("%v", bandwidth)
+
+	sendOkReply(w, r, newSuccessHTTPReply(resp))
+}
+
+func (m *Server) getMetaRebalance(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.metaRebalanceView()))
+}
+
+func (m *Server) setMetaRebalance(w http.ResponseWriter, r *http.Request) {
+	setRebalance(w, r, m.cluster.metaRebalancer, m.cluster.setMetaRebalanceConfig)
+}
+
+func (m *Server) pauseMetaRebalance(w http.ResponseWriter, r *http.Request) {
+	switchRebalance(w, r, m.cluster.metaRebalancer, m.cluster.setMetaRebalanceConfig, true)
+}
+
+func (m *Server) resumeMetaRebalance(w http.ResponseWriter, r *http.Request) {
+	switchRebalance(w, r, m.cluster.metaRebalancer, m.cluster.setMetaRebalanceConfig, false)
+}
+
+func (m *Server) listEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		since     uint64
+		eventType string
+		limit     int
+		err       error
+	)
+	if since, eventType, limit, err = parseRequestToListEvents(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.events.list(since, eventType, limit)))
+}
+
+func (m *Server) listAuditRecords(w http.ResponseWriter, r *http.Request) {
+	var (
+		since   int64
+		limit   int
+		records []*proto.AuditRecord
+		err     error
+	)
+	if since, limit, err = parseRequestToListAuditRecords(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if records, err = m.cluster.listAuditRecords(since, limit); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(records))
+}
+
+func (m *Server) setAlertRule(w http.ResponseWriter, r *http.Request) {
+	var (
+		rule *proto.AlertRule
+		err  error
+	)
+	if rule, err = parseRequestToSetAlertRule(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setAlertRule(rule); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set alert rule[%v] successfully", rule.Name)))
+}
+
+func (m *Server) deleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	var name string
+	if name = r.FormValue(nameKey); name == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(nameKey).Error()})
+		return
+	}
+	if err := m.cluster.deleteAlertRule(name); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("delete alert rule[%v] successfully", name)))
+}
+
+func (m *Server) listAlertRules(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.alerts.view()))
+}
+
+func (m *Server) getRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	item, err := parseRuntimeConfigItem(r)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.runtimeConfigView(item)))
+}
+
+func (m *Server) setRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	var (
+		item  *runtimeConfigItem
+		value string
+		err   error
+	)
+	if item, err = parseRuntimeConfigItem(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if value = r.FormValue(configValueKey); value == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(configValueKey).Error()})
+		return
+	}
+	if err = item.validate(value); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setRuntimeConfig(item, value); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
 	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set %v to %v successfully", item.name, item.get(m.cluster.cfg))))
 }
 
-func (m *Server) addDataNode(w http.ResponseWriter, r *http.Request) {
+func (m *Server) listRuntimeConfig(w http.ResponseWriter, r *http.Request) {
+	items := make([]*proto.RuntimeConfigItem, 0, len(runtimeConfigItems))
+	for _, item := range runtimeConfigItems {
+		items = append(items, m.cluster.runtimeConfigView(item))
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(items))
+}
+
+func (m *Server) createMetadataBackup(w http.ResponseWriter, r *http.Request) {
+	info, err := m.cluster.backupMetadata()
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(info))
+}
+
+func (m *Server) listMetadataBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := m.cluster.listMetadataBackups()
+	if err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(backups))
+}
+
+// restoreMetadataBackup restores the metadata from a backup, and reloads the restored metadata into the memory
+// of the leader. The followers load it when they become the leader.
+func (m *Server) restoreMetadataBackup(w http.ResponseWriter, r *http.Request) {
 	var (
-		nodeAddr string
-		zoneName string
-		id       uint64
-		err      error
+		key    string
+		before int64
+		info   *proto.MetadataBackupInfo
+		err    error
 	)
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if err = r.ParseForm(); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if id, err = m.cluster.addDataNode(nodeAddr, zoneName); err != nil {
+	key = r.FormValue(backupKeyKey)
+	before = time.Now().Unix()
+	if value := r.FormValue(backupTimeKey); value != "" {
+		if before, err = strconv.ParseInt(value, 10, 64); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+			return
+		}
+	}
+	if info, err = m.cluster.findMetadataBackup(key, before); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if err = m.cluster.restoreMetadata(info); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(id))
+	m.loadMetadata()
+	sendOkReply(w, r, newSuccessHTTPReply(info))
 }
 
-func (m *Server) getDataNode(w http.ResponseWriter, r *http.Request) {
+func (m *Server) getCapacityForecast(w http.ResponseWriter, r *http.Request) {
 	var (
-		nodeAddr     string
-		dataNode     *DataNode
-		dataNodeInfo *proto.DataNodeInfo
-		err          error
+		name string
+		days = defaultCapacityForecastDays
+		err  error
 	)
-	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+	if err = r.ParseForm(); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-
-	if dataNode, err = m.cluster.dataNode(nodeAddr); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
-		return
+	if name = r.FormValue(nameKey); name != "" {
+		if _, err = m.cluster.getVol(name); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+			return
+		}
 	}
-	dataNode.PersistenceDataPartitions = m.cluster.getAllDataPartitionIDByDatanode(nodeAddr)
-
-	dataNodeInfo = &proto.DataNodeInfo{
-		Total:                     dataNode.Total,
-		Used:                      dataNode.Used,
-		AvailableSpace:            dataNode.AvailableSpace,
-		ID:                        dataNode.ID,
-		ZoneName:                  dataNode.ZoneName,
-		Addr:                      dataNode.Addr,
-		ReportTime:                dataNode.ReportTime,
-		IsActive:                  dataNode.isActive,
-		UsageRatio:                dataNode.UsageRatio,
-		SelectedTimes:             dataNode.SelectedTimes,
-		Carry:                     dataNode.Carry,
-		DataPartitionReports:      dataNode.DataPartitionReports,
-		DataPartitionCount:        dataNode.DataPartitionCount,
-		NodeSetID:                 dataNode.NodeSetID,
-		PersistenceDataPartitions: dataNode.PersistenceDataPartitions,
-		BadDisks:                  dataNode.BadDisks,
+	if value := r.FormValue(daysKey); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days <= 0 || days > maxUsageHistoryDays {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError,
+				Msg: fmt.Sprintf("%v must be an integer in [1, %v]", daysKey, maxUsageHistoryDays)})
+			return
+		}
 	}
-
-	sendOkReply(w, r, newSuccessHTTPReply(dataNodeInfo))
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.capacityForecast(name, days)))
 }
 
-// Decommission a data node. This will decommission all the data partition on that node.
-func (m *Server) decommissionDataNode(w http.ResponseWriter, r *http.Request) {
+func (m *Server) addFederationPeer(w http.ResponseWriter, r *http.Request) {
 	var (
-		node        *DataNode
-		rstMsg      string
-		offLineAddr string
-		err         error
+		peer *proto.FederationPeer
+		err  error
 	)
-
-	if offLineAddr, err = parseAndExtractNodeAddr(r); err != nil {
+	if peer, err = parseRequestToAddFederationPeer(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if err = m.cluster.addFederationPeer(peer); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("add federation peer[%v] successfully", peer.Name)))
+}
 
-	if node, err = m.cluster.dataNode(offLineAddr); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+func (m *Server) removeFederationPeer(w http.ResponseWriter, r *http.Request) {
+	var name string
+	if name = r.FormValue(nameKey); name == "" {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: keyNotFound(nameKey).Error()})
 		return
 	}
-	if err = m.cluster.decommissionDataNode(node); err != nil {
+	if err := m.cluster.removeFederationPeer(name); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	rstMsg = fmt.Sprintf("decommission data node [%v] successfully", offLineAddr)
-	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("remove federation peer[%v] successfully", name)))
 }
 
-func (m *Server) setNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
+func (m *Server) listFederationPeers(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.federation.getPeers()))
+}
+
+// getFederation gets the views of the peer clusters from their masters, the unreachable peers are shown with
+// the errors.
+func (m *Server) getFederation(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.federationView()))
+}
+
+func parseRuntimeConfigItem(r *http.Request) (item *runtimeConfigItem, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	name := r.FormValue(nameKey)
+	if name == "" {
+		return nil, keyNotFound(nameKey)
+	}
+	if item = runtimeConfigItemOf(name); item == nil {
+		return nil, fmt.Errorf("unknown parameter [%v]", name)
+	}
+	return
+}
+
+func (m *Server) getDataRebalance(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.dataRebalanceView()))
+}
+
+func (m *Server) setDataRebalance(w http.ResponseWriter, r *http.Request) {
+	setRebalance(w, r, m.cluster.dataRebalancer, m.cluster.setDataRebalanceConfig)
+}
+
+func (m *Server) pauseDataRebalance(w http.ResponseWriter, r *http.Request) {
+	switchRebalance(w, r, m.cluster.dataRebalancer, m.cluster.setDataRebalanceConfig, true)
+}
+
+func (m *Server) resumeDataRebalance(w http.ResponseWriter, r *http.Request) {
+	switchRebalance(w, r, m.cluster.dataRebalancer, m.cluster.setDataRebalanceConfig, false)
+}
+
+func setRebalance(w http.ResponseWriter, r *http.Request, rb *rebalancer, set func(proto.RebalanceConfig) error) {
 	var (
-		params map[string]interface{}
+		config proto.RebalanceConfig
 		err    error
 	)
-	if params, err = parseAndExtractSetNodeInfoParams(r); err != nil {
+	if config, err = parseRequestToSetRebalance(r, rb.getConfig()); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if err = set(config); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set %v rebalance %+v successfully", rb.partitionType, config)))
+}
 
-	if batchCount, ok := params[nodeDeleteBatchCountKey]; ok {
-		if bc, ok := batchCount.(uint64); ok {
-			if err = m.cluster.setMetaNodeDeleteBatchCount(bc); err != nil {
-				sendErrReply(w, r, newErrHTTPReply(err))
-				return
-			}
+// switchRebalance pauses or resumes the rebalancer, the migrating partitions keep recovering when paused.
+func switchRebalance(w http.ResponseWriter, r *http.Request, rb *rebalancer, set func(proto.RebalanceConfig) error, paused bool) {
+	config := rb.getConfig()
+	config.Paused = paused
+	if err := set(config); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	if paused {
+		sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("%v rebalance is paused", rb.partitionType)))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("%v rebalance is resumed", rb.partitionType)))
+}
+
+// parseRequestToSetRebalance parses the configuration of a rebalancer, the parameters absent are kept.
+func parseRequestToSetRebalance(r *http.Request, config proto.RebalanceConfig) (newConfig proto.RebalanceConfig, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	newConfig = config
+	noParams := true
+	if value := r.FormValue(highRatioKey); value != "" {
+		noParams = false
+		if newConfig.HighRatio, err = strconv.ParseFloat(value, 64); err != nil {
+			err = unmatchedKey(highRatioKey)
+			return
 		}
 	}
-	if val, ok := params[nodeMarkDeleteRateKey]; ok {
-		if v, ok := val.(uint64); ok {
-			if err = m.cluster.setDataNodeDeleteLimitRate(v); err != nil {
-				sendErrReply(w, r, newErrHTTPReply(err))
-				return
-			}
+	if value := r.FormValue(lowRatioKey); value != "" {
+		noParams = false
+		if newConfig.LowRatio, err = strconv.ParseFloat(value, 64); err != nil {
+			err = unmatchedKey(lowRatioKey)
+			return
 		}
 	}
-
-	if val, ok := params[nodeAutoRepairRateKey]; ok {
-		if v, ok := val.(uint64); ok {
-			if err = m.cluster.setDataNodeAutoRepairLimitRate(v); err != nil {
-				sendErrReply(w, r, newErrHTTPReply(err))
-				return
-			}
+	if value := r.FormValue(limitKey); value != "" {
+		noParams = false
+		if newConfig.Limit, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(limitKey)
+			return
 		}
 	}
-
-	if val, ok := params[nodeDeleteWorkerSleepMs]; ok {
-		if v, ok := val.(uint64); ok {
-			if err = m.cluster.setMetaNodeDeleteWorkerSleepMs(v); err != nil {
-				sendErrReply(w, r, newErrHTTPReply(err))
-				return
-			}
+	if value := r.FormValue(bandwidthKey); value != "" {
+		noParams = false
+		if newConfig.Bandwidth, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(bandwidthKey)
+			return
 		}
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set nodeinfo params %v successfully", params)))
-
-}
-
-// get metanode some interval params
-func (m *Server) getNodeInfoHandler(w http.ResponseWriter, r *http.Request) {
-	resp := make(map[string]string)
-	resp[nodeDeleteBatchCountKey] = fmt.Sprintf("%v", m.cluster.cfg.MetaNodeDeleteBatchCount)
-	resp[nodeMarkDeleteRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeDeleteLimitRate)
-	resp[nodeDeleteWorkerSleepMs] = fmt.Sprintf("%v", m.cluster.cfg.MetaNodeDeleteWorkerSleepMs)
-	resp[nodeAutoRepairRateKey] = fmt.Sprintf("%v", m.cluster.cfg.DataNodeAutoRepairLimitRate)
-
-	sendOkReply(w, r, newSuccessHTTPReply(resp))
+	if noParams {
+		err = keyNotFound(limitKey)
+		return
+	}
+	err = validateRebalanceConfig(newConfig)
+	return
 }
 
 func (m *Server) diagnoseMetaPartition(w http.ResponseWriter, r *http.Request) {
 	var (
-		err               error
-		rstMsg            *proto.MetaPartitionDiagnosis
-		inactiveNodes     []string
-		corruptMps        []*MetaPartition
-		lackReplicaMps    []*MetaPartition
-		corruptMpIDs      []uint64
-		lackReplicaMpIDs  []uint64
-		badMetaPartitions []badPartitionView
+		err    error
+		vol    *Vol
+		rstMsg *proto.MetaPartitionDiagnosis
 	)
-	corruptMpIDs = make([]uint64, 0)
-	lackReplicaMpIDs = make([]uint64, 0)
-	if inactiveNodes, corruptMps, err = m.cluster.checkCorruptMetaPartitions(); err != nil {
+	if volName := r.FormValue(nameKey); volName != "" {
+		// only the partitions of the volume are checked
+		if vol, err = m.cluster.getVol(volName); err != nil {
+			sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+			return
+		}
+	}
+	if rstMsg, err = m.cluster.diagnoseMetaPartitions(vol); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
+		return
 	}
+	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+}
 
-	if lackReplicaMps, err = m.cluster.checkLackReplicaMetaPartitions(); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+// List the meta partitions filtered by volume, status and node address, the result is paged by offset and limit.
+func (m *Server) listMetaPartitions(w http.ResponseWriter, r *http.Request) {
+	var (
+		volName, status, addr string
+		offset, limit         int
+		items                 []*proto.MetaPartitionListItem
+		err                   error
+	)
+	if volName, status, addr, offset, limit, err = parseRequestToListMetaPartitions(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
 	}
-	for _, mp := range corruptMps {
-		corruptMpIDs = append(corruptMpIDs, mp.PartitionID)
+	if items, err = m.cluster.listMetaPartitions(volName, status, addr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
 	}
-	for _, mp := range lackReplicaMps {
-		lackReplicaMpIDs = append(lackReplicaMpIDs, mp.PartitionID)
+	view := &proto.MetaPartitionListView{Total: len(items)}
+	if offset > len(items) {
+		offset = len(items)
 	}
-	badMetaPartitions = m.cluster.getBadMetaPartitionsView()
-	rstMsg = &proto.MetaPartitionDiagnosis{
-		InactiveMetaNodes:           inactiveNodes,
-		CorruptMetaPartitionIDs:     corruptMpIDs,
-		LackReplicaMetaPartitionIDs: lackReplicaMpIDs,
-		BadMetaPartitionIDs:         badMetaPartitions,
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
 	}
-	log.LogInfof("diagnose metaPartition[%v] inactiveNodes:[%v], corruptMpIDs:[%v], lackReplicaMpIDs:[%v]", m.cluster.Name, inactiveNodes, corruptMpIDs, lackReplicaMpIDs)
-	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
+	view.Partitions = items
+	sendOkReply(w, r, newSuccessHTTPReply(view))
 }
 
 // Decommission a disk. This will decommission all the data partitions on this disk.
@@ -1014,6 +2348,34 @@ func (m *Server) decommissionDisk(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(rstMsg))
 }
 
+// decommissionDiskAsync migrates all the data partitions off the disk in background, and replies the task
+// which tracks the progress.
+func (m *Server) decommissionDiskAsync(w http.ResponseWriter, r *http.Request) {
+	var (
+		node                  *DataNode
+		offLineAddr, diskPath string
+		task                  *proto.AsyncTaskInfo
+		err                   error
+	)
+	if offLineAddr, diskPath, err = parseRequestToDecommissionNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if node, err = m.cluster.dataNode(offLineAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	op := func(progress progressFunc) error {
+		return m.cluster.migrateDiskPartitions(node, diskPath, progress)
+	}
+	if task, err = m.cluster.asyncTasks.submitDiskTask(proto.AsyncTaskDecommissionDisk, node.Addr, diskPath, op); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	Warn(m.clusterName, fmt.Sprintf("receive decommissionDiskAsync node[%v] disk[%v], task[%v]", node.Addr, diskPath, task.ID))
+	sendOkReply(w, r, newSuccessHTTPReply(task))
+}
+
 // handle tasks such as heartbeat，loadDataPartition，deleteDataPartition, etc.
 func (m *Server) handleDataNodeTaskResponse(w http.ResponseWriter, r *http.Request) {
 	tr, err := parseRequestToGetTaskResponse(r)
@@ -1029,14 +2391,15 @@ func (m *Server) addMetaNode(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr string
 		zoneName string
+		rackName string
 		id       uint64
 		err      error
 	)
-	if nodeAddr, zoneName, err = parseRequestForAddNode(r); err != nil {
+	if nodeAddr, zoneName, rackName, err = parseRequestForAddNode(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName); err != nil {
+	if id, err = m.cluster.addMetaNode(nodeAddr, zoneName, rackName); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
@@ -1048,38 +2411,94 @@ func (m *Server) checkInvalidIDNodes(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(nodes))
 }
 
-func (m *Server) updateDataNode(w http.ResponseWriter, r *http.Request) {
+func (m *Server) updateDataNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		id       uint64
+		err      error
+	)
+	if nodeAddr, id, err = parseRequestForUpdateMetaNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.updateDataNodeBaseInfo(nodeAddr, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(id))
+}
+
+func (m *Server) updateMetaNode(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		id       uint64
+		err      error
+	)
+	if nodeAddr, id, err = parseRequestForUpdateMetaNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.updateMetaNodeBaseInfo(nodeAddr, id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(id))
+}
+
+// setDataNodeLabels replaces the labels of the data node, the empty labels remove all of them.
+func (m *Server) setDataNodeLabels(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		labels   map[string]string
+		err      error
+	)
+	if nodeAddr, labels, err = parseRequestToSetNodeLabels(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.setDataNodeLabels(nodeAddr, labels); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set labels of data node[%v] to [%v] successfully",
+		nodeAddr, proto.FormatLabels(labels))))
+}
+
+// setMetaNodeLabels replaces the labels of the meta node, the empty labels remove all of them.
+func (m *Server) setMetaNodeLabels(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr string
-		id       uint64
+		labels   map[string]string
 		err      error
 	)
-	if nodeAddr, id, err = parseRequestForUpdateMetaNode(r); err != nil {
+	if nodeAddr, labels, err = parseRequestToSetNodeLabels(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if err = m.cluster.updateDataNodeBaseInfo(nodeAddr, id); err != nil {
+	if err = m.cluster.setMetaNodeLabels(nodeAddr, labels); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(id))
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set labels of meta node[%v] to [%v] successfully",
+		nodeAddr, proto.FormatLabels(labels))))
 }
 
-func (m *Server) updateMetaNode(w http.ResponseWriter, r *http.Request) {
+// decommissionDryRun reports the impact of decommissioning the data node or the meta node without migrating anything.
+func (m *Server) decommissionDryRun(w http.ResponseWriter, r *http.Request) {
 	var (
 		nodeAddr string
-		id       uint64
+		dryRun   *proto.DecommissionDryRun
 		err      error
 	)
-	if nodeAddr, id, err = parseRequestForUpdateMetaNode(r); err != nil {
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
-	if err = m.cluster.updateMetaNodeBaseInfo(nodeAddr, id); err != nil {
+	if dryRun, err = m.cluster.decommissionDryRun(nodeAddr); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
 	}
-	sendOkReply(w, r, newSuccessHTTPReply(id))
+	sendOkReply(w, r, newSuccessHTTPReply(dryRun))
 }
 
 func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
@@ -1104,6 +2523,7 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		Addr:                      metaNode.Addr,
 		IsActive:                  metaNode.IsActive,
 		ZoneName:                  metaNode.ZoneName,
+		RackName:                  metaNode.RackName,
 		MaxMemAvailWeight:         metaNode.MaxMemAvailWeight,
 		Total:                     metaNode.Total,
 		Used:                      metaNode.Used,
@@ -1115,10 +2535,30 @@ func (m *Server) getMetaNode(w http.ResponseWriter, r *http.Request) {
 		MetaPartitionCount:        metaNode.MetaPartitionCount,
 		NodeSetID:                 metaNode.NodeSetID,
 		PersistenceMetaPartitions: metaNode.PersistenceMetaPartitions,
+		HeartbeatLatency:          metaNode.HeartbeatLatency,
+		HealthScore:               metaNode.HealthScore,
+		Labels:                    metaNode.getLabels(),
 	}
 	sendOkReply(w, r, newSuccessHTTPReply(metaNodeInfo))
 }
 
+// getMetaNodePartitions lists the meta partitions hosted on the meta node with the state of the replicas.
+func (m *Server) getMetaNodePartitions(w http.ResponseWriter, r *http.Request) {
+	var (
+		nodeAddr string
+		err      error
+	)
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if _, err = m.cluster.metaNode(nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaNodeNotExists))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getMetaNodePartitionViews(nodeAddr)))
+}
+
 func (m *Server) decommissionMetaPartition(w http.ResponseWriter, r *http.Request) {
 	var (
 		partitionID uint64
@@ -1135,11 +2575,146 @@ func (m *Server) decommissionMetaPartition(w http.ResponseWriter, r *http.Reques
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
 		return
 	}
-	if err = m.cluster.decommissionMetaPartition(nodeAddr, mp); err != nil {
-		sendErrReply(w, r, newErrHTTPReply(err))
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planDecommissionMetaPartition(mp, nodeAddr)
+	}) {
 		return
 	}
+	op := func() error {
+		return m.cluster.decommissionMetaPartition(nodeAddr, mp)
+	}
 	msg = fmt.Sprintf(proto.AdminDecommissionMetaPartition+" partitionID :%v  decommissionMetaPartition successfully", partitionID)
+	m.runAdminOperation(w, r, proto.AsyncTaskDecommissionMetaPartition, partitionID, nodeAddr, op, msg)
+}
+
+// runAdminOperation runs the operation in background and replies the task info if the request is asynchronous,
+// otherwise it waits for the operation to finish and replies the result.
+func (m *Server) runAdminOperation(w http.ResponseWriter, r *http.Request, taskType string, partitionID uint64, addr string, op func() error, msg string) {
+	var (
+		async bool
+		err   error
+	)
+	if async, err = extractBoolParam(r, asyncKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if async {
+		task := m.cluster.asyncTasks.submit(taskType, partitionID, addr, op)
+		sendOkReply(w, r, newSuccessHTTPReply(task))
+		return
+	}
+	if err = op(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// replyOperationPlan replies the plan of the operation instead of executing it if the request is a dry run.
+// It returns true if the request has been replied.
+func replyOperationPlan(w http.ResponseWriter, r *http.Request, plan func() (*proto.PartitionOperationPlan, error)) (replied bool) {
+	var (
+		dryRun bool
+		result *proto.PartitionOperationPlan
+		err    error
+	)
+	if dryRun, err = extractBoolParam(r, dryRunKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return true
+	}
+	if !dryRun {
+		return false
+	}
+	if result, err = plan(); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return true
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(result))
+	return true
+}
+
+func (m *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	var (
+		id   uint64
+		task *proto.AsyncTaskInfo
+		err  error
+	)
+	if id, err = parseRequestToGetTask(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if task, err = m.cluster.asyncTasks.get(id); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(task))
+}
+
+func (m *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.asyncTasks.list()))
+}
+
+func (m *Server) listOrphanPartitions(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.findOrphanPartitions()))
+}
+
+// Delete an orphan partition replica from the node.
+// getStalePartitionGC replies the stale replicas pending and the ones deleted by the stale partition GC.
+func (m *Server) getStalePartitionGC(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.stalePartitions.view(m.cluster.cfg.stalePartitionGracePeriod)))
+}
+
+// getConsistencyReport replies the findings cached by the consistency checker.
+func (m *Server) getConsistencyReport(w http.ResponseWriter, r *http.Request) {
+	sendOkReply(w, r, newSuccessHTTPReply(m.cluster.getConsistencyReport()))
+}
+
+func (m *Server) cleanOrphanPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		partitionType string
+		partitionID   uint64
+		nodeAddr      string
+		err           error
+	)
+	if partitionType, partitionID, nodeAddr, err = parseRequestToCleanOrphanPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if err = m.cluster.cleanOrphanPartition(partitionType, partitionID, nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg := fmt.Sprintf("delete orphan %v partition[%v] on node[%v] successfully", partitionType, partitionID, nodeAddr)
+	sendOkReply(w, r, newSuccessHTTPReply(msg))
+}
+
+// Reset the raft members of a meta partition which has lost the majority of its replicas.
+// This function needs to be called manually by the admin and may lead to data loss.
+func (m *Server) resetMetaPartition(w http.ResponseWriter, r *http.Request) {
+	var (
+		partitionID uint64
+		mp          *MetaPartition
+		msg         string
+		err         error
+	)
+	if partitionID, err = parseRequestToResetMetaPartition(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if mp, err = m.cluster.getMetaPartitionByID(partitionID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrMetaPartitionNotExists))
+		return
+	}
+	if replyOperationPlan(w, r, func() (*proto.PartitionOperationPlan, error) {
+		return m.cluster.planResetMetaPartition(mp)
+	}) {
+		return
+	}
+	if err = m.cluster.resetMetaPartition(mp); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	msg = fmt.Sprintf(proto.AdminResetMetaPartition+" partitionID :%v reset successfully", partitionID)
 	sendOkReply(w, r, newSuccessHTTPReply(msg))
 }
 
@@ -1275,7 +2850,7 @@ func parseRequestForUpdateMetaNode(r *http.Request) (nodeAddr string, id uint64,
 	return
 }
 
-func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err error) {
+func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName, rackName string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -1285,6 +2860,7 @@ func parseRequestForAddNode(r *http.Request) (nodeAddr, zoneName string, err err
 	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
 		zoneName = DefaultZoneName
 	}
+	rackName = r.FormValue(rackNameKey)
 	return
 }
 
@@ -1295,6 +2871,18 @@ func parseAndExtractNodeAddr(r *http.Request) (nodeAddr string, err error) {
 	return extractNodeAddr(r)
 }
 
+func parseRequestToSetNodeLabels(r *http.Request) (nodeAddr string, labels map[string]string, err error) {
+	if nodeAddr, err = parseAndExtractNodeAddr(r); err != nil {
+		return
+	}
+	if _, ok := r.Form[labelsKey]; !ok {
+		err = keyNotFound(labelsKey)
+		return
+	}
+	labels, err = proto.ParseLabels(r.FormValue(labelsKey))
+	return
+}
+
 func parseRequestToDecommissionNode(r *http.Request) (nodeAddr, diskPath string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1322,6 +2910,63 @@ func parseRequestToGetTaskResponse(r *http.Request) (tr *proto.AdminTask, err er
 	return
 }
 
+func parseRequestToRollingRestart(r *http.Request) (role string, nodes []string, batch, agentPort int,
+	timeout time.Duration, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if role = r.FormValue(roleKey); role == "" {
+		err = keyNotFound(roleKey)
+		return
+	}
+	if value := r.FormValue(nodeHostsKey); value != "" {
+		nodes = strings.Split(value, ",")
+	}
+	if value := r.FormValue(batchKey); value != "" {
+		if batch, err = strconv.Atoi(value); err != nil || batch <= 0 {
+			err = unmatchedKey(batchKey)
+			return
+		}
+	}
+	if value := r.FormValue(agentPortKey); value != "" {
+		if agentPort, err = strconv.Atoi(value); err != nil || agentPort <= 0 || agentPort > 65535 {
+			err = unmatchedKey(agentPortKey)
+			return
+		}
+	}
+	if value := r.FormValue(timeoutKey); value != "" {
+		var seconds int
+		if seconds, err = strconv.Atoi(value); err != nil || seconds <= 0 {
+			err = unmatchedKey(timeoutKey)
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	return
+}
+
+func parseRequestToCloneVol(r *http.Request) (srcName, dstName, owner string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if srcName, err = extractName(r); err != nil {
+		return
+	}
+	if dstName = r.FormValue(targetKey); dstName == "" {
+		err = keyNotFound(targetKey)
+		return
+	}
+	if !volNameRegexp.MatchString(dstName) {
+		err = errors.New("target can only be number and letters")
+		return
+	}
+	if owner = r.FormValue(volOwnerKey); owner != "" && !ownerRegexp.MatchString(owner) {
+		err = errors.New("owner can only be number and letters")
+		return
+	}
+	return
+}
+
 func parseVolName(r *http.Request) (name string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1365,78 +3010,229 @@ func parseVolNameAndAuthKey(r *http.Request) (name, authKey string, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
-	if name, err = extractName(r); err != nil {
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	return
+
+}
+
+func parseRequestToDeleteVol(r *http.Request) (name, authKey string, err error) {
+	return parseVolNameAndAuthKey(r)
+
+}
+
+func parseRequestToUpdateVol(r *http.Request) (name, authKey, description string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if name, err = extractName(r); err != nil {
+		return
+	}
+	if authKey, err = extractAuthKey(r); err != nil {
+		return
+	}
+	description = r.FormValue(descriptionKey)
+	return
+}
+
+func parseDefaultInfoToUpdateVol(r *http.Request, vol *Vol) (zoneName string, capacity uint64, replicaNum int,
+	enableToken bool, dpSelectorName string, dpSelectorParm string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
+		zoneName = vol.zoneName
+	}
+	if capacityStr := r.FormValue(volCapacityKey); capacityStr != "" {
+		var capacityInt int
+		if capacityInt, err = strconv.Atoi(capacityStr); err != nil {
+			err = unmatchedKey(volCapacityKey)
+			return
+		}
+		capacity = uint64(capacityInt)
+	} else {
+		capacity = vol.Capacity
+	}
+	if replicaNumStr := r.FormValue(replicaNumKey); replicaNumStr != "" {
+		if replicaNum, err = strconv.Atoi(replicaNumStr); err != nil {
+			err = unmatchedKey(replicaNumKey)
+			return
+		}
+	} else {
+		replicaNum = int(vol.dpReplicaNum)
+	}
+	if enableTokenStr := r.FormValue(enableTokenKey); enableTokenStr != "" {
+		if enableToken, err = strconv.ParseBool(enableTokenStr); err != nil {
+			err = unmatchedKey(enableTokenKey)
+			return
+		}
+	} else {
+		enableToken = vol.enableToken
+	}
+	dpSelectorName = r.FormValue(dpSelectorNameKey)
+	dpSelectorParm = r.FormValue(dpSelectorParmKey)
+	if (dpSelectorName == "") || (dpSelectorParm == "") {
+		if (dpSelectorName != "") || (dpSelectorParm != "") {
+			err = keyNotFound(dpSelectorNameKey + " or " + dpSelectorParmKey)
+			return
+		}
+		dpSelectorName = vol.dpSelectorName
+		dpSelectorParm = vol.dpSelectorParm
+	}
+	return
+}
+
+// parsePlacementToUpdateVol parses the placement policy and the placement zone, the policy "default" resets the
+// placement policy of the volume.
+func parsePlacementToUpdateVol(r *http.Request, vol *Vol) (policy, zoneName string, err error) {
+	policy = r.FormValue(placementPolicyKey)
+	zoneName = r.FormValue(placementZoneKey)
+	if policy == "" {
+		if zoneName != "" {
+			err = keyNotFound(placementPolicyKey)
+			return
+		}
+		return vol.placementPolicy, vol.placementZone, nil
+	}
+	if policy == "default" {
+		policy = proto.PlacementDefault
+	}
+	return
+}
+
+func parseRequestToSetDirQuota(r *http.Request) (name, dirPath string, maxBytes, maxFiles uint64, err error) {
+	if name, err = parseVolName(r); err != nil {
+		return
+	}
+	if dirPath = r.FormValue(quotaPathKey); dirPath == "" {
+		err = keyNotFound(quotaPathKey)
+		return
+	}
+	for key, limit := range map[string]*uint64{
+		maxBytesKey: &maxBytes,
+		maxFilesKey: &maxFiles,
+	} {
+		value := r.FormValue(key)
+		if value == "" {
+			continue
+		}
+		if *limit, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(key)
+			return
+		}
+	}
+	return
+}
+
+func parseRequestToSetExpirationRule(r *http.Request) (name, dirPath string, days uint64, basis string, err error) {
+	if name, err = parseVolName(r); err != nil {
+		return
+	}
+	if dirPath = r.FormValue(quotaPathKey); dirPath == "" {
+		err = keyNotFound(quotaPathKey)
+		return
+	}
+	if days, err = strconv.ParseUint(r.FormValue(daysKey), 10, 32); err != nil || days == 0 {
+		err = unmatchedKey(daysKey)
+		return
+	}
+	if basis = r.FormValue(basisKey); basis == "" {
+		basis = proto.ExpireByModifyTime
+	}
+	if !proto.IsValidExpirationBasis(basis) {
+		err = unmatchedKey(basisKey)
+		return
+	}
+	return
+}
+
+// parseRequestToSetLifecycleRule parses the lifecycle rule like an expiration rule, except that the days may be 0
+// to transit the files regardless of their times.
+func parseRequestToSetLifecycleRule(r *http.Request) (name, dirPath string, days uint64, basis, class string, err error) {
+	if name, err = parseVolName(r); err != nil {
+		return
+	}
+	if dirPath = r.FormValue(quotaPathKey); dirPath == "" {
+		err = keyNotFound(quotaPathKey)
+		return
+	}
+	if days, err = strconv.ParseUint(r.FormValue(daysKey), 10, 32); err != nil {
+		err = unmatchedKey(daysKey)
+		return
+	}
+	if basis = r.FormValue(basisKey); basis == "" {
+		basis = proto.ExpireByModifyTime
+	}
+	if !proto.IsValidExpirationBasis(basis) {
+		err = unmatchedKey(basisKey)
 		return
 	}
-	if authKey, err = extractAuthKey(r); err != nil {
+	if class = r.FormValue(storageClassKey); !proto.IsValidStorageClass(class) {
+		err = unmatchedKey(storageClassKey)
 		return
 	}
 	return
-
-}
-
-func parseRequestToDeleteVol(r *http.Request) (name, authKey string, err error) {
-	return parseVolNameAndAuthKey(r)
-
 }
 
-func parseRequestToUpdateVol(r *http.Request) (name, authKey, description string, err error) {
-	if err = r.ParseForm(); err != nil {
-		return
-	}
-	if name, err = extractName(r); err != nil {
+func parseRequestToOperateVolSnapshot(r *http.Request) (name string, id uint64, err error) {
+	if name, err = parseVolName(r); err != nil {
 		return
 	}
-	if authKey, err = extractAuthKey(r); err != nil {
+	if id, err = strconv.ParseUint(r.FormValue(snapshotIDKey), 10, 64); err != nil {
+		err = unmatchedKey(snapshotIDKey)
 		return
 	}
-	description = r.FormValue(descriptionKey)
 	return
 }
 
-func parseDefaultInfoToUpdateVol(r *http.Request, vol *Vol) (zoneName string, capacity uint64, replicaNum int,
-	enableToken bool, dpSelectorName string, dpSelectorParm string, err error) {
-	if err = r.ParseForm(); err != nil {
-		return
-	}
-	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
-		zoneName = vol.zoneName
-	}
-	if capacityStr := r.FormValue(volCapacityKey); capacityStr != "" {
-		var capacityInt int
-		if capacityInt, err = strconv.Atoi(capacityStr); err != nil {
-			err = unmatchedKey(volCapacityKey)
-			return
+// parseQosToUpdateVol parses the IOPS and the bandwidth limits, the limits not specified are kept, and 0 removes
+// the limit.
+func parseQosToUpdateVol(r *http.Request, vol *Vol) (qos proto.VolQos, err error) {
+	qos = vol.qos
+	for key, limit := range map[string]*uint64{
+		readIopsLimitKey:  &qos.ReadIops,
+		writeIopsLimitKey: &qos.WriteIops,
+		readBpsLimitKey:   &qos.ReadBps,
+		writeBpsLimitKey:  &qos.WriteBps,
+	} {
+		value := r.FormValue(key)
+		if value == "" {
+			continue
 		}
-		capacity = uint64(capacityInt)
-	} else {
-		capacity = vol.Capacity
-	}
-	if replicaNumStr := r.FormValue(replicaNumKey); replicaNumStr != "" {
-		if replicaNum, err = strconv.Atoi(replicaNumStr); err != nil {
-			err = unmatchedKey(replicaNumKey)
+		if *limit, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(key)
 			return
 		}
-	} else {
-		replicaNum = int(vol.dpReplicaNum)
 	}
-	if enableTokenStr := r.FormValue(enableTokenKey); enableTokenStr != "" {
-		if enableToken, err = strconv.ParseBool(enableTokenStr); err != nil {
-			err = unmatchedKey(enableTokenKey)
-			return
+	return
+}
+
+// parseIPAclToUpdateVol parses the comma separated CIDRs of the allowed and the denied clients, the lists not
+// specified are kept, and an empty list removes the restriction.
+func parseIPAclToUpdateVol(r *http.Request, vol *Vol) (ipAcl proto.VolIPAcl, err error) {
+	ipAcl = vol.ipAcl
+	for key, cidrs := range map[string]*[]string{
+		ipAllowKey: &ipAcl.Allow,
+		ipDenyKey:  &ipAcl.Deny,
+	} {
+		values, ok := r.Form[key]
+		if !ok {
+			continue
 		}
-	} else {
-		enableToken = vol.enableToken
-	}
-	dpSelectorName = r.FormValue(dpSelectorNameKey)
-	dpSelectorParm = r.FormValue(dpSelectorParmKey)
-	if (dpSelectorName == "") || (dpSelectorParm == "") {
-		if (dpSelectorName != "") || (dpSelectorParm != "") {
-			err = keyNotFound(dpSelectorNameKey + " or " + dpSelectorParmKey)
+		*cidrs = nil
+		for _, cidr := range strings.Split(strings.Join(values, ","), ",") {
+			if cidr = strings.TrimSpace(cidr); cidr != "" {
+				*cidrs = append(*cidrs, cidr)
+			}
+		}
+		if _, err = iputil.ParseCIDRs(*cidrs); err != nil {
+			err = fmt.Errorf("%v: %v", key, err)
 			return
 		}
-		dpSelectorName = vol.dpSelectorName
-		dpSelectorParm = vol.dpSelectorParm
 	}
 	return
 }
@@ -1477,7 +3273,7 @@ func parseRequestToSetVolCapacity(r *http.Request) (name, authKey string, capaci
 	return
 }
 
-func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken bool, err error) {
+func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken, caseInsensitive bool, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
 	}
@@ -1523,12 +3319,218 @@ func parseRequestToCreateVol(r *http.Request) (name, owner, zoneName, descriptio
 	if crossZone, err = extractCrossZone(r); err != nil {
 		return
 	}
+	if value := r.FormValue(caseInsensitiveKey); value != "" {
+		if caseInsensitive, err = strconv.ParseBool(value); err != nil {
+			err = unmatchedKey(caseInsensitiveKey)
+			return
+		}
+	}
 	zoneName = r.FormValue(zoneNameKey)
 	enableToken = extractEnableToken(r)
 	description = r.FormValue(descriptionKey)
 	return
 }
 
+func extractBoolParam(r *http.Request, key string) (value bool, err error) {
+	var str string
+	if str = r.FormValue(key); str == "" {
+		return
+	}
+	if value, err = strconv.ParseBool(str); err != nil {
+		err = unmatchedKey(key)
+	}
+	return
+}
+
+func parseRequestToListMetaPartitions(r *http.Request) (volName, status, addr string, offset, limit int, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	volName = r.FormValue(nameKey)
+	addr = r.FormValue(addrKey)
+	status = r.FormValue(statusKey)
+	switch status {
+	case "", proto.PartitionFilterUnhealthy, proto.PartitionFilterReadOnly, proto.PartitionFilterReadWrite:
+	default:
+		err = unmatchedKey(statusKey)
+		return
+	}
+	if offset, err = extractNonNegativeInt(r, offsetKey); err != nil {
+		return
+	}
+	limit, err = extractNonNegativeInt(r, limitKey)
+	return
+}
+
+func parseRequestToSetAlertRule(r *http.Request) (rule *proto.AlertRule, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	rule = &proto.AlertRule{
+		Name:     r.FormValue(nameKey),
+		Metric:   r.FormValue(metricKey),
+		Operator: r.FormValue(operatorKey),
+	}
+	var value string
+	if value = r.FormValue(thresholdKey); value == "" {
+		err = keyNotFound(thresholdKey)
+		return
+	}
+	if rule.Threshold, err = strconv.ParseFloat(value, 64); err != nil {
+		err = unmatchedKey(thresholdKey)
+		return
+	}
+	if value = r.FormValue(durationKey); value != "" {
+		if rule.Duration, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(durationKey)
+			return
+		}
+	}
+	if rule.Disabled, err = extractBoolParam(r, disabledKey); err != nil {
+		return
+	}
+	err = validateAlertRule(rule)
+	return
+}
+
+func parseRequestToAddFederationPeer(r *http.Request) (peer *proto.FederationPeer, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	peer = &proto.FederationPeer{
+		Name:   r.FormValue(nameKey),
+		Region: r.FormValue(regionKey),
+	}
+	if peer.Name == "" {
+		err = keyNotFound(nameKey)
+		return
+	}
+	for _, addr := range strings.Split(r.FormValue(mastersKey), commaSplit) {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			peer.Masters = append(peer.Masters, addr)
+		}
+	}
+	if len(peer.Masters) == 0 {
+		err = keyNotFound(mastersKey)
+	}
+	return
+}
+
+// parseRequestToListEvents parses the event ID to list the events after, the event type and the max number of the
+// events to list.
+func parseRequestToListEvents(r *http.Request) (since uint64, eventType string, limit int, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if value := r.FormValue(sinceKey); value != "" {
+		if since, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(sinceKey)
+			return
+		}
+	}
+	eventType = r.FormValue(eventTypeKey)
+	limit, err = extractNonNegativeInt(r, limitKey)
+	return
+}
+
+func parseRequestToListAuditRecords(r *http.Request) (since int64, limit int, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if value := r.FormValue(sinceKey); value != "" {
+		if since, err = strconv.ParseInt(value, 10, 64); err != nil {
+			err = unmatchedKey(sinceKey)
+			return
+		}
+	}
+	limit, err = extractNonNegativeInt(r, limitKey)
+	return
+}
+
+// parsePartitionPage parses the marker and limit of listing the partitions in pages, the partitions are listed
+// in the order of ID, starting after the partition ID of the marker. paged is false if neither is specified.
+func parsePartitionPage(r *http.Request) (marker uint64, limit int, paged bool, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if value := r.FormValue(markerKey); value != "" {
+		if marker, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(markerKey)
+			return
+		}
+		paged = true
+	}
+	if limit, err = extractNonNegativeInt(r, limitKey); err != nil {
+		return
+	}
+	paged = paged || limit > 0
+	return
+}
+
+func extractNonNegativeInt(r *http.Request, key string) (value int, err error) {
+	var str string
+	if str = r.FormValue(key); str == "" {
+		return
+	}
+	if value, err = strconv.Atoi(str); err != nil || value < 0 {
+		err = unmatchedKey(key)
+	}
+	return
+}
+
+func parseRequestToGetTask(r *http.Request) (ID uint64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	var value string
+	if value = r.FormValue(idKey); value == "" {
+		err = keyNotFound(idKey)
+		return
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func parseRequestToCleanOrphanPartition(r *http.Request) (partitionType string, ID uint64, nodeAddr string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if partitionType = r.FormValue(partitionTypeKey); partitionType == "" {
+		err = keyNotFound(partitionTypeKey)
+		return
+	}
+	if partitionType != proto.PartitionTypeData && partitionType != proto.PartitionTypeMeta {
+		err = unmatchedKey(partitionTypeKey)
+		return
+	}
+	if ID, err = extractDataPartitionID(r); err != nil {
+		return
+	}
+	nodeAddr, err = extractNodeAddr(r)
+	return
+}
+
+func parseRequestToMoveZoneNode(r *http.Request) (nodeType, nodeAddr, zoneName string, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if nodeType = r.FormValue(partitionTypeKey); nodeType == "" {
+		err = keyNotFound(partitionTypeKey)
+		return
+	}
+	if nodeType != proto.PartitionTypeData && nodeType != proto.PartitionTypeMeta {
+		err = unmatchedKey(partitionTypeKey)
+		return
+	}
+	if nodeAddr, err = extractNodeAddr(r); err != nil {
+		return
+	}
+	if zoneName = r.FormValue(zoneNameKey); zoneName == "" {
+		err = keyNotFound(zoneNameKey)
+		return
+	}
+	return
+}
+
 func extractEnableToken(r *http.Request) (enableToken bool) {
 	enableToken, err := strconv.ParseBool(r.FormValue(enableTokenKey))
 	if err != nil {
@@ -1575,6 +3577,10 @@ func parseRequestToLoadDataPartition(r *http.Request) (ID uint64, err error) {
 	return
 }
 
+func parseRequestToResetDataPartition(r *http.Request) (ID uint64, err error) {
+	return parseRequestToLoadDataPartition(r)
+}
+
 func parseRequestToAddMetaReplica(r *http.Request) (ID uint64, addr string, err error) {
 	return extractMetaPartitionIDAndAddr(r)
 }
@@ -1626,6 +3632,17 @@ func extractDataPartitionID(r *http.Request) (ID uint64, err error) {
 	return strconv.ParseUint(value, 10, 64)
 }
 
+func parseRequestToSetDataPartitionStatus(r *http.Request) (ID uint64, status int8, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	if ID, err = extractDataPartitionID(r); err != nil {
+		return
+	}
+	status, err = parseForcedStatus(r.FormValue(statusKey))
+	return
+}
+
 func parseRequestToDecommissionDataPartition(r *http.Request) (ID uint64, nodeAddr string, err error) {
 	return extractDataPartitionIDAndAddr(r)
 }
@@ -1665,6 +3682,10 @@ func parseRequestToLoadMetaPartition(r *http.Request) (partitionID uint64, err e
 	return
 }
 
+func parseRequestToResetMetaPartition(r *http.Request) (partitionID uint64, err error) {
+	return parseRequestToLoadMetaPartition(r)
+}
+
 func parseRequestToDecommissionMetaPartition(r *http.Request) (partitionID uint64, nodeAddr string, err error) {
 	return extractMetaPartitionIDAndAddr(r)
 }
@@ -1740,6 +3761,27 @@ func parseAndExtractThreshold(r *http.Request) (threshold float64, err error) {
 	return
 }
 
+func parseRequestToCheckHealth(r *http.Request) (maxRaftLag uint64, capacityThreshold float64, err error) {
+	if err = r.ParseForm(); err != nil {
+		return
+	}
+	maxRaftLag = defaultHealthMaxRaftLag
+	if value := r.FormValue(maxRaftLagKey); value != "" {
+		if maxRaftLag, err = strconv.ParseUint(value, 10, 64); err != nil {
+			err = unmatchedKey(maxRaftLagKey)
+			return
+		}
+	}
+	capacityThreshold = defaultHealthCapacityThreshold
+	if value := r.FormValue(thresholdKey); value != "" {
+		if capacityThreshold, err = strconv.ParseFloat(value, 64); err != nil || capacityThreshold <= 0 || capacityThreshold > 1 {
+			err = unmatchedKey(thresholdKey)
+			return
+		}
+	}
+	return
+}
+
 func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interface{}, err error) {
 	if err = r.ParseForm(); err != nil {
 		return
@@ -1789,6 +3831,41 @@ func parseAndExtractSetNodeInfoParams(r *http.Request) (params map[string]interf
 		}
 		params[nodeDeleteWorkerSleepMs] = val
 	}
+
+	if value = r.FormValue(autoSupplementLimitKey); value != "" {
+		noParams = false
+		var val = uint64(0)
+		val, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			err = unmatchedKey(autoSupplementLimitKey)
+			return
+		}
+		params[autoSupplementLimitKey] = val
+	}
+
+	if value = r.FormValue(volDeletionDelayKey); value != "" {
+		noParams = false
+		var val = uint64(0)
+		val, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			err = unmatchedKey(volDeletionDelayKey)
+			return
+		}
+		params[volDeletionDelayKey] = val
+	}
+
+	for _, key := range []string{mpSplitInodeCountKey, mpSplitMemoryKey, decommissionLimitKey, decommissionBwKey} {
+		if value = r.FormValue(key); value != "" {
+			noParams = false
+			var val = uint64(0)
+			val, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				err = unmatchedKey(key)
+				return
+			}
+			params[key] = val
+		}
+	}
 	if noParams {
 		err = keyNotFound(nodeDeleteBatchCountKey)
 		return
@@ -1895,6 +3972,13 @@ func (m *Server) getMetaPartitions(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
+	if marker, limit, paged, err := parsePartitionPage(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	} else if paged {
+		sendOkReply(w, r, newSuccessHTTPReply(vol.getMetaPartitionsViewPage(marker, limit)))
+		return
+	}
 	mpsCache := vol.getMpsCache()
 	if len(mpsCache) == 0 {
 		vol.updateViewCache(m.cluster)
@@ -1920,7 +4004,15 @@ func (m *Server) getDataPartitions(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
-
+	if marker, limit, paged, err := parsePartitionPage(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	} else if paged {
+		view := proto.NewDataPartitionsView()
+		view.DataPartitions = vol.dataPartitions.getDataPartitionsViewPage(marker, limit)
+		sendOkReply(w, r, newSuccessHTTPReply(view))
+		return
+	}
 	if body, err = vol.getDataPartitionsView(); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -1946,6 +4038,11 @@ func (m *Server) getVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 		return
 	}
+	// the volume in the trash can not be mounted
+	if vol.status() == markDelete {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
+		return
+	}
 	if !param.skipOwnerValidation && !matchKey(vol.Owner, param.authKey) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrVolAuthKeyNotMatch))
 		return
@@ -2087,6 +4184,9 @@ func (m *Server) listVols(w http.ResponseWriter, r *http.Request) {
 	var (
 		err      error
 		keywords string
+		marker   string
+		limit    int
+		deleted  bool
 		vol      *Vol
 		volsInfo []*proto.VolInfo
 	)
@@ -2094,15 +4194,44 @@ func (m *Server) listVols(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
 		return
 	}
+	if limit, err = extractNonNegativeInt(r, limitKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	marker = r.FormValue(markerKey)
+	if value := r.FormValue(deletedKey); value != "" {
+		if deleted, err = strconv.ParseBool(value); err != nil {
+			sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: unmatchedKey(deletedKey).Error()})
+			return
+		}
+	}
+	delay := m.cluster.volDeletionDelay()
 	volsInfo = make([]*proto.VolInfo, 0)
-	for _, name := range m.cluster.allVolNames() {
+	names := m.cluster.allVolNames()
+	sort.Strings(names)
+	for _, name := range names {
+		if limit > 0 && len(volsInfo) >= limit {
+			break
+		}
+		// the volumes are listed in the order of name, starting after the marker
+		if name <= marker {
+			continue
+		}
 		if strings.Contains(name, keywords) {
 			if vol, err = m.cluster.getVol(name); err != nil {
 				sendErrReply(w, r, newErrHTTPReply(proto.ErrVolNotExists))
 				return
 			}
+			// the deleted volumes are listed only if they are asked for
+			if (vol.status() == markDelete) != deleted {
+				continue
+			}
 			stat := volStat(vol)
 			volInfo := proto.NewVolInfo(vol.Name, vol.Owner, vol.createTime, vol.status(), stat.TotalSize, stat.UsedSize)
+			if deleted && vol.deleteTime > 0 {
+				volInfo.DeleteTime = vol.deleteTime
+				volInfo.ExpireTime = vol.deleteTime + int64(delay)
+			}
 			volsInfo = append(volsInfo, volInfo)
 		}
 	}
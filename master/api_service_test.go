@@ -102,7 +102,7 @@ func createDefaultMasterServerForTest() *Server {
 	testServer.cluster.checkMetaNodeHeartbeat()
 	time.Sleep(5 * time.Second)
 	testServer.cluster.scheduleToUpdateStatInfo()
-	vol, err := testServer.cluster.createVol(commonVolName, "cfs", testZone2, "", 3, 3, 3, 100, false, false, false, false)
+	vol, err := testServer.cluster.createVol(commonVolName, "cfs", testZone2, "", 3, 3, 3, 100, false, false, false, false, false)
 	if err != nil {
 		panic(err)
 	}
@@ -346,6 +346,27 @@ func TestUpdateVol(t *testing.T) {
 
 }
 
+func TestUpdateVolVerifyReadCrc(t *testing.T) {
+	verifyVols := func() (names []interface{}) {
+		reply := process(fmt.Sprintf("%v%v", hostAddr, proto.AdminGetVerifyReadCrcVols), t)
+		if reply != nil {
+			names, _ = reply.Data.([]interface{})
+		}
+		return
+	}
+	for _, verify := range []bool{true, false} {
+		reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v&verifyReadCrc=%v",
+			hostAddr, proto.AdminUpdateVol, commonVol.Name, buildAuthKey("cfs"), verify)
+		process(reqURL, t)
+		if commonVol.verifyReadCrc != verify {
+			t.Fatalf("expect verifyReadCrc is %v, but is %v", verify, commonVol.verifyReadCrc)
+		}
+		if names := verifyVols(); verify != (len(names) == 1 && names[0] == commonVol.Name) {
+			t.Fatalf("unexpected verify read crc vols %v", names)
+		}
+	}
+}
+
 func setVolCapacity(capacity uint64, url string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?name=%v&capacity=%v&authKey=%v",
 		hostAddr, url, commonVol.Name, capacity, buildAuthKey("cfs"))
@@ -532,6 +553,18 @@ func TestAddMetaReplica(t *testing.T) {
 		return
 	}
 	partition.RUnlock()
+	learners := partition.learners()
+	if len(learners) != 1 || learners[0].Addr != msAddr {
+		t.Errorf("the new replica[%v] should be a learner, learners[%v]", msAddr, learners)
+		return
+	}
+	if err := server.cluster.promoteMetaLearner(partition, learners[0]); err != nil {
+		t.Errorf("promote learner[%v] err[%v]", learners[0], err)
+		return
+	}
+	if learners = partition.learners(); len(learners) != 0 {
+		t.Errorf("learners[%v] should be promoted", learners)
+	}
 }
 
 func TestRemoveMetaReplica(t *testing.T) {
@@ -846,3 +879,44 @@ func TestListUsersOfVol(t *testing.T) {
 	fmt.Println(reqURL)
 	process(reqURL, t)
 }
+
+func TestUserQuota(t *testing.T) {
+	userID, volName := "quota_user", "quota_vol"
+	reqURL := fmt.Sprintf("%v%v", hostAddr, proto.UserCreate)
+	data, err := json.Marshal(&proto.UserCreateParam{ID: userID, Type: proto.UserTypeNormal})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	reqURL = fmt.Sprintf("%v%v?name=%v&replicas=3&type=extent&capacity=100&owner=%v&mpCount=2&zoneName=%v",
+		hostAddr, proto.AdminCreateVol, volName, userID, testZone2)
+	process(reqURL, t)
+	defer process(fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminDeleteVol, volName, buildAuthKey(userID)), t)
+
+	// the quota is just taken up by the vol of the user
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.UserSetQuota)
+	if data, err = json.Marshal(&proto.UserQuotaSetParam{UserID: userID, Quota: proto.UserQuota{Capacity: 100, VolCount: 1}}); err != nil {
+		t.Error(err)
+		return
+	}
+	post(reqURL, data, t)
+	userInfo, err := server.user.getUserInfo(userID)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if info := server.user.quotaInfo(server.cluster, userInfo); info.UsedCapacity != 100 || info.UsedVolCount != 1 {
+		t.Errorf("expect used capacity[100] and vol count[1], real[%v] [%v]", info.UsedCapacity, info.UsedVolCount)
+	}
+	process(fmt.Sprintf("%v%v?user=%v", hostAddr, proto.UserGetQuota, userID), t)
+	if err = server.user.checkQuota(server.cluster, userID, "test_quota_vol", 1); err != proto.ErrUserQuotaExceeded {
+		t.Errorf("expect err[%v], real err[%v]", proto.ErrUserQuotaExceeded, err)
+	}
+	if err = server.user.checkQuota(server.cluster, userID, volName, 100); err != nil {
+		t.Errorf("expect the owned vol[%v] within the quota, but err[%v]", volName, err)
+	}
+	if err = server.user.checkQuota(server.cluster, userID, volName, 101); err != proto.ErrUserQuotaExceeded {
+		t.Errorf("expect err[%v], real err[%v]", proto.ErrUserQuotaExceeded, err)
+	}
+}
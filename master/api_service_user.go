@@ -213,6 +213,12 @@ func (m *Server) transferUserVol(w http.ResponseWriter, r *http.Request) {
 		sendErrReply(w, r, newErrHTTPReply(proto.ErrHaveNoPolicy))
 		return
 	}
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if err = m.user.checkQuota(m.cluster, param.UserDst, param.Volume, vol.Capacity); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
 	if userInfo, err = m.user.transferVol(&param); err != nil {
 		sendErrReply(w, r, newErrHTTPReply(err))
 		return
@@ -228,6 +234,45 @@ func (m *Server) transferUserVol(w http.ResponseWriter, r *http.Request) {
 	sendOkReply(w, r, newSuccessHTTPReply(userInfo))
 }
 
+func (m *Server) setUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		userInfo *proto.UserInfo
+		bytes    []byte
+		err      error
+	)
+	if bytes, err = ioutil.ReadAll(r.Body); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	var param = proto.UserQuotaSetParam{}
+	if err = json.Unmarshal(bytes, &param); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.setQuota(&param); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.user.quotaInfo(m.cluster, userInfo)))
+}
+
+func (m *Server) getUserQuota(w http.ResponseWriter, r *http.Request) {
+	var (
+		userID   string
+		userInfo *proto.UserInfo
+		err      error
+	)
+	if userID, err = parseUser(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if userInfo, err = m.user.getUserInfo(userID); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(m.user.quotaInfo(m.cluster, userInfo)))
+}
+
 func (m *Server) getAllUsers(w http.ResponseWriter, r *http.Request) {
 	var (
 		keywords string
@@ -0,0 +1,246 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const openAPIVersion = "3.0.3"
+
+var (
+	apiSpecOnce sync.Once
+	apiSpecDoc  []byte
+)
+
+// getAPISpec replies the OpenAPI document of the master APIs. The document is not wrapped by the
+// reply of the other APIs, so it can be fed to the OpenAPI tools directly.
+func (m *Server) getAPISpec(w http.ResponseWriter, r *http.Request) {
+	apiSpecOnce.Do(func() {
+		var err error
+		if apiSpecDoc, err = json.MarshalIndent(newOpenAPIDocument(proto.MasterAPISpecs), "", "  "); err != nil {
+			log.LogErrorf("action[getAPISpec] marshal the document err[%v]", err)
+		}
+	})
+	if apiSpecDoc == nil {
+		http.Error(w, "failed to build the api spec", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(apiSpecDoc); err != nil {
+		log.LogErrorf("action[getAPISpec] send response has err:[%s]", err)
+	}
+}
+
+// newOpenAPIDocument builds the OpenAPI document of the APIs. The data of the replies are described by
+// the schemas reflected from the samples in the specs.
+func newOpenAPIDocument(specs []*proto.APISpec) map[string]interface{} {
+	schemas := newOpenAPISchemas()
+	paths := make(map[string]interface{})
+	for _, spec := range specs {
+		item := make(map[string]interface{})
+		for _, method := range spec.Methods {
+			operationID := spec.Name
+			if len(spec.Methods) > 1 && method != http.MethodGet {
+				operationID = spec.Name + strings.Title(strings.ToLower(method))
+			}
+			item[strings.ToLower(method)] = newOpenAPIOperation(spec, operationID, schemas)
+		}
+		paths[spec.Path] = item
+	}
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "ChubaoFS Master API",
+			"version": proto.Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+		},
+	}
+}
+
+func newOpenAPIOperation(spec *proto.APISpec, operationID string, schemas *openAPISchemas) map[string]interface{} {
+	data := map[string]interface{}{"type": "string"}
+	if spec.Response != nil {
+		data = schemas.of(reflect.TypeOf(spec.Response))
+	}
+	reply := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"code": map[string]interface{}{"type": "integer", "format": "int32"},
+			"msg":  map[string]interface{}{"type": "string"},
+			"data": data,
+		},
+	}
+	op := map[string]interface{}{
+		"operationId": operationID,
+		"summary":     spec.Summary,
+		"tags":        []string{spec.Tag},
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "the reply, the code is 0 if the call succeeds",
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": reply},
+				},
+			},
+		},
+	}
+	if spec.ReadOnly {
+		op["x-follower-read"] = true
+	}
+	if len(spec.Params) > 0 {
+		params := make([]interface{}, 0, len(spec.Params))
+		for _, param := range spec.Params {
+			params = append(params, map[string]interface{}{
+				"name":        param.Name,
+				"in":          "query",
+				"required":    param.Required,
+				"description": param.Description,
+				"schema":      openAPIParamSchema(param.Type),
+			})
+		}
+		op["parameters"] = params
+	}
+	if spec.Body != nil {
+		op["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.of(reflect.TypeOf(spec.Body))},
+			},
+		}
+	}
+	return op
+}
+
+func openAPIParamSchema(paramType string) map[string]interface{} {
+	switch paramType {
+	case proto.APIParamBool:
+		return map[string]interface{}{"type": "boolean"}
+	case proto.APIParamInt:
+		return map[string]interface{}{"type": "integer"}
+	case proto.APIParamInt64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case proto.APIParamUint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case proto.APIParamFloat64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// openAPISchemas keeps the schemas of the named structs, which are referred by the other schemas.
+type openAPISchemas struct {
+	components map[string]interface{}
+}
+
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{components: make(map[string]interface{})}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// of returns the schema of the JSON encoding of the type.
+func (s *openAPISchemas) of(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32", "minimum": 0}
+	case reflect.Uint, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// registered before the fields are reflected, so the recursive types end up with a reference
+			s.components[t.Name()] = map[string]interface{}{}
+			s.components[t.Name()] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	s.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// addFields adds the exported fields of the struct, and the fields of the embedded structs, as encoding/json does.
+func (s *openAPISchemas) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.addFields(ft, properties)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		properties[name] = s.of(field.Type)
+	}
+}
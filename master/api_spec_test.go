@@ -0,0 +1,99 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestAPISpecsMatchRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	server.registerAPIRoutes(router)
+	graphql := map[string]bool{proto.AdminClusterAPI: true, proto.AdminUserAPI: true, proto.AdminVolumeAPI: true}
+	routes := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || graphql[path] {
+			return nil
+		}
+		routes[path] = true
+		spec := proto.MasterAPISpecOf(path)
+		if spec == nil {
+			t.Errorf("route %v has no spec", path)
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		sort.Strings(methods)
+		expected := append([]string(nil), spec.Methods...)
+		sort.Strings(expected)
+		if !reflect.DeepEqual(methods, expected) {
+			t.Errorf("route %v methods %v, spec %v", path, methods, expected)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, spec := range proto.MasterAPISpecs {
+		if !routes[spec.Path] {
+			t.Errorf("spec %v has no route", spec.Path)
+		}
+		if names[spec.Name] {
+			t.Errorf("duplicated name %v", spec.Name)
+		}
+		names[spec.Name] = true
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	data, err := json.Marshal(newOpenAPIDocument(proto.MasterAPISpecs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := &struct {
+		Paths      map[string]map[string]map[string]interface{}
+		Components struct {
+			Schemas map[string]interface{}
+		}
+	}{}
+	if err = json.Unmarshal(data, doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths) != len(proto.MasterAPISpecs) {
+		t.Errorf("paths %v, specs %v", len(doc.Paths), len(proto.MasterAPISpecs))
+	}
+	if _, ok := doc.Paths[proto.AdminCreateVol]["post"]; !ok {
+		t.Errorf("no post operation of %v", proto.AdminCreateVol)
+	}
+	if _, ok := doc.Components.Schemas["SimpleVolView"]; !ok {
+		t.Errorf("no schema of the reply of %v", proto.AdminGetVol)
+	}
+	// all the references are resolvable
+	for _, ref := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := ref[:strings.Index(ref, `"`)]
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("unresolved reference %v", name)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	// the finished tasks are kept for a while so that the clients can fetch the result
+	asyncTaskExpiration = 24 * time.Hour
+)
+
+// asyncTaskManager tracks the long-running admin operations, such as decommission, add replica and
+// delete replica, which are executed in background. The tasks are kept in the memory of the leader
+// master only, and they are lost if the leader changes.
+type asyncTaskManager struct {
+	sync.RWMutex
+	lastID uint64
+	tasks  map[uint64]*proto.AsyncTaskInfo
+}
+
+func newAsyncTaskManager() *asyncTaskManager {
+	return &asyncTaskManager{tasks: make(map[uint64]*proto.AsyncTaskInfo)}
+}
+
+// progressFunc reports the number of the processed partitions and the total number of the partitions of a task.
+type progressFunc func(done, total int)
+
+// submit runs the operation in background and returns the task which tracks it.
+func (m *asyncTaskManager) submit(taskType string, partitionID uint64, addr string, op func() error) (task *proto.AsyncTaskInfo) {
+	t := &proto.AsyncTaskInfo{
+		Type:        taskType,
+		PartitionID: partitionID,
+		Addr:        addr,
+	}
+	m.Lock()
+	task = m.add(t)
+	m.Unlock()
+	m.start(t, func(progress progressFunc) error {
+		return op()
+	})
+	return
+}
+
+// submitDiskTask runs the operation on the partitions of the disk in background, and the operation reports its
+// progress to the task. At most one task of the type can be running on a disk at the same time.
+func (m *asyncTaskManager) submitDiskTask(taskType string, addr, diskPath string, op func(progress progressFunc) error) (task *proto.AsyncTaskInfo, err error) {
+	m.Lock()
+	for _, running := range m.tasks {
+		if running.Type == taskType && running.Addr == addr && running.DiskPath == diskPath && !running.IsFinished() {
+			m.Unlock()
+			err = fmt.Errorf("task[%v] of disk[%v] on node[%v] is still running", running.ID, diskPath, addr)
+			return
+		}
+	}
+	t := &proto.AsyncTaskInfo{
+		Type:     taskType,
+		Addr:     addr,
+		DiskPath: diskPath,
+	}
+	task = m.add(t)
+	m.Unlock()
+	m.start(t, op)
+	return
+}
+
+// submitVolumeTask runs the operation on the volume in background, and the operation reports its progress
+// to the task. At most one task of the type can be running on a volume at the same time.
+func (m *asyncTaskManager) submitVolumeTask(taskType string, volName string, op func(progress progressFunc) error) (task *proto.AsyncTaskInfo, err error) {
+	m.Lock()
+	for _, running := range m.tasks {
+		if running.Type == taskType && running.VolName == volName && !running.IsFinished() {
+			m.Unlock()
+			err = fmt.Errorf("task[%v] of vol[%v] is still running", running.ID, volName)
+			return
+		}
+	}
+	t := &proto.AsyncTaskInfo{
+		Type:    taskType,
+		VolName: volName,
+	}
+	task = m.add(t)
+	m.Unlock()
+	m.start(t, op)
+	return
+}
+
+// isVolumeTaskRunning returns if a task of the type is running on the volume.
+func (m *asyncTaskManager) isVolumeTaskRunning(taskType string, volName string) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for _, running := range m.tasks {
+		if running.Type == taskType && running.VolName == volName && !running.IsFinished() {
+			return true
+		}
+	}
+	return false
+}
+
+// submitClusterTask runs the operation on the cluster in background, and the operation reports its progress
+// to the task. At most one task of the type can be running at the same time.
+func (m *asyncTaskManager) submitClusterTask(taskType string, op func(progress progressFunc) error) (task *proto.AsyncTaskInfo, err error) {
+	m.Lock()
+	for _, running := range m.tasks {
+		if running.Type == taskType && !running.IsFinished() {
+			m.Unlock()
+			err = fmt.Errorf("task[%v] of type[%v] is still running", running.ID, taskType)
+			return
+		}
+	}
+	t := &proto.AsyncTaskInfo{Type: taskType}
+	task = m.add(t)
+	m.Unlock()
+	m.start(t, op)
+	return
+}
+
+// add must be called with the lock held.
+func (m *asyncTaskManager) add(t *proto.AsyncTaskInfo) (task *proto.AsyncTaskInfo) {
+	now := time.Now().Unix()
+	m.removeExpiredTasks()
+	m.lastID++
+	t.ID = m.lastID
+	t.Status = proto.AsyncTaskRunning
+	t.CreateTime = now
+	t.UpdateTime = now
+	m.tasks[t.ID] = t
+	return copyAsyncTask(t)
+}
+
+func (m *asyncTaskManager) start(t *proto.AsyncTaskInfo, op func(progress progressFunc) error) {
+	progress := func(done, total int) {
+		m.Lock()
+		defer m.Unlock()
+		t.Done = done
+		t.Total = total
+		t.UpdateTime = time.Now().Unix()
+	}
+	go func() {
+		err := op(progress)
+		m.Lock()
+		defer m.Unlock()
+		t.UpdateTime = time.Now().Unix()
+		if err != nil {
+			t.Status = proto.AsyncTaskFailed
+			t.Err = err.Error()
+			log.LogWarnf("action[asyncTask] task[%v] type[%v] partitionID[%v] addr[%v] disk[%v] vol[%v] failed, err[%v]",
+				t.ID, t.Type, t.PartitionID, t.Addr, t.DiskPath, t.VolName, err)
+			return
+		}
+		t.Status = proto.AsyncTaskSucceeded
+		log.LogInfof("action[asyncTask] task[%v] type[%v] partitionID[%v] addr[%v] disk[%v] vol[%v] succeeded",
+			t.ID, t.Type, t.PartitionID, t.Addr, t.DiskPath, t.VolName)
+	}()
+}
+
+func (m *asyncTaskManager) get(id uint64) (task *proto.AsyncTaskInfo, err error) {
+	m.RLock()
+	defer m.RUnlock()
+	t, ok := m.tasks[id]
+	if !ok {
+		err = proto.ErrTaskNotExists
+		return
+	}
+	task = copyAsyncTask(t)
+	return
+}
+
+func (m *asyncTaskManager) list() (tasks []*proto.AsyncTaskInfo) {
+	m.RLock()
+	tasks = make([]*proto.AsyncTaskInfo, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		tasks = append(tasks, copyAsyncTask(t))
+	}
+	m.RUnlock()
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return
+}
+
+// removeExpiredTasks must be called with the lock held.
+func (m *asyncTaskManager) removeExpiredTasks() {
+	expireTime := time.Now().Add(-asyncTaskExpiration).Unix()
+	for id, t := range m.tasks {
+		if t.IsFinished() && t.UpdateTime < expireTime {
+			delete(m.tasks, id)
+		}
+	}
+}
+
+func copyAsyncTask(t *proto.AsyncTaskInfo) *proto.AsyncTaskInfo {
+	task := *t
+	return &task
+}
@@ -0,0 +1,339 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	auditRedactedValue          = "******"
+	auditMaxResultLen           = 1024
+	defaultAuditListLimit       = 1000
+	intervalToCleanAuditRecords = time.Hour
+)
+
+var (
+	// the admin APIs which change the cluster, the calls of them are audited, and they are denied to
+	// the read-only role
+	mutatingAPIs = map[string]bool{
+		proto.AdminClusterFreeze:             true,
+		proto.AddRaftNode:                    true,
+		proto.RemoveRaftNode:                 true,
+		proto.AdminRollingRestart:            true,
+		proto.AdminCreateVol:                 true,
+		proto.AdminCloneVol:                  true,
+		proto.AdminDeleteVol:                 true,
+		proto.AdminRestoreVol:                true,
+		proto.AdminUpdateVol:                 true,
+		proto.QuotaSet:                       true,
+		proto.QuotaDelete:                    true,
+		proto.VolSnapshotCreate:              true,
+		proto.VolSnapshotDelete:              true,
+		proto.VolSnapshotRollback:            true,
+		proto.VolReplicationSet:              true,
+		proto.VolReplicationFailover:         true,
+		proto.AdminVolShrink:                 true,
+		proto.AdminRotateVolKey:              true,
+		proto.AdminVolExpand:                 true,
+		proto.AdminLoadMetaPartition:         true,
+		proto.AdminDecommissionMetaPartition: true,
+		proto.AdminResetMetaPartition:        true,
+		proto.AdminCreateMetaPartition:       true,
+		proto.AdminAddMetaReplica:            true,
+		proto.AdminTransferMetaLeader:        true,
+		proto.AdminDeleteMetaReplica:         true,
+		proto.AdminCleanOrphanPartitions:     true,
+		proto.AdminCreateDataPartition:       true,
+		proto.AdminLoadDataPartition:         true,
+		proto.AdminDecommissionDataPartition: true,
+		proto.AdminResetDataPartition:        true,
+		proto.AdminAddDataReplica:            true,
+		proto.AdminTransferDataLeader:        true,
+		proto.AdminSetDataPartitionStatus:    true,
+		proto.AdminDeleteDataReplica:         true,
+		proto.DecommissionMetaNode:           true,
+		proto.DecommissionDataNode:           true,
+		proto.DecommissionDisk:               true,
+		proto.AdminDecommissionDiskAsync:     true,
+		proto.AdminSetDiskRetiring:           true,
+		proto.AdminSetMetaNodeThreshold:      true,
+		proto.AdminUpdateMetaNode:            true,
+		proto.AdminUpdateDataNode:            true,
+		proto.AdminSetMetaNodeLabels:         true,
+		proto.AdminSetDataNodeLabels:         true,
+		proto.AdminSetNodeInfo:               true,
+		proto.AdminMetaRebalanceSet:          true,
+		proto.AdminMetaRebalancePause:        true,
+		proto.AdminMetaRebalanceResume:       true,
+		proto.AdminDataRebalanceSet:          true,
+		proto.AdminDataRebalancePause:        true,
+		proto.AdminDataRebalanceResume:       true,
+		proto.AdminSetAlertRule:              true,
+		proto.AdminSetConfig:                 true,
+		proto.AdminCreateMetadataBackup:      true,
+		proto.AdminRestoreMetadataBackup:     true,
+		proto.AdminAddFederationPeer:         true,
+		proto.AdminRemoveFederationPeer:      true,
+		proto.AdminDeleteAlertRule:           true,
+		proto.UserCreate:                     true,
+		proto.UserDelete:                     true,
+		proto.UserUpdate:                     true,
+		proto.UserUpdatePolicy:               true,
+		proto.UserRemovePolicy:               true,
+		proto.UserDeleteVolPolicy:            true,
+		proto.UserTransferVol:                true,
+		proto.UserSetQuota:                   true,
+		proto.UpdateZone:                     true,
+		proto.MoveZoneNode:                   true,
+		proto.TokenAddURI:                    true,
+		proto.TokenDelURI:                    true,
+		proto.TokenUpdateURI:                 true,
+	}
+	// the values of the params are not written into the audit records
+	auditRedactedParams = []string{volAuthKey, tokenKey}
+)
+
+// auditResponseWriter keeps the status and the body of the reply to fill the outcome of the audit record.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// serveAudited serves the admin call and records it with the outcome. The failure of recording is logged
+// but does not change the reply of the call.
+func (m *Server) serveAudited(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	aw := &auditResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(aw, r)
+	record := newAPIAuditRecord(r, start)
+	record.Code, record.Result = parseAuditOutcome(aw.status, aw.body.Bytes())
+	if err := m.cluster.syncPutAuditRecord(record); err != nil {
+		log.LogErrorf("action[serveAudited] api[%v] caller[%v] remote[%v] params%v code[%v] result[%v] err[%v]",
+			record.API, record.Caller, record.Remote, record.Params, record.Code, record.Result, err)
+	}
+}
+
+func newAPIAuditRecord(r *http.Request, start time.Time) (record *proto.AuditRecord) {
+	record = &proto.AuditRecord{
+		Time:     start.Unix(),
+		Source:   proto.AuditSourceAPI,
+		Caller:   r.Header.Get(proto.AuditCaller),
+		Remote:   auditRemoteAddr(r),
+		API:      r.URL.Path,
+		Duration: time.Since(start).Milliseconds(),
+	}
+	// the identity authenticated by the access control is trusted more than the header
+	if id := rbacIdentityOf(r); id != nil {
+		record.Caller = strings.TrimSpace(fmt.Sprintf("%v %v", id, record.Caller))
+	}
+	// only the query is recorded, the form in the body has been consumed by the handler
+	query := r.URL.Query()
+	if len(query) > 0 {
+		record.Params = make(map[string]string, len(query))
+		for key := range query {
+			record.Params[key] = query.Get(key)
+		}
+		for _, key := range auditRedactedParams {
+			if _, ok := record.Params[key]; ok {
+				record.Params[key] = auditRedactedValue
+			}
+		}
+	}
+	return
+}
+
+func newCLIAuditRecord(r *http.Request, cliRecord *proto.CliAuditRecord) *proto.AuditRecord {
+	caller := cliRecord.User
+	if cliRecord.Host != "" {
+		caller = fmt.Sprintf("%v@%v", cliRecord.User, cliRecord.Host)
+	}
+	record := &proto.AuditRecord{
+		Time:     cliRecord.Time,
+		Source:   proto.AuditSourceCLI,
+		Caller:   caller,
+		Remote:   auditRemoteAddr(r),
+		API:      cliRecord.Command,
+		Code:     int32(cliRecord.ExitCode),
+		Result:   truncateAuditResult(cliRecord.Result),
+		Duration: cliRecord.Duration,
+	}
+	if len(cliRecord.Args) > 0 {
+		record.Params = map[string]string{"args": strings.Join(cliRecord.Args, " ")}
+	}
+	return record
+}
+
+// auditRemoteAddr returns the address of the client, which is kept in the header if the call
+// is proxied by a follower.
+func auditRemoteAddr(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	return r.RemoteAddr
+}
+
+// parseAuditOutcome returns the reply code and the error message of the call.
+func parseAuditOutcome(status int, body []byte) (code int32, result string) {
+	reply := &proto.HTTPReply{}
+	if err := json.Unmarshal(body, reply); err != nil {
+		if status == http.StatusOK {
+			return proto.ErrCodeSuccess, ""
+		}
+		return proto.ErrCodeInternalError, truncateAuditResult(fmt.Sprintf("%v %v", status, string(body)))
+	}
+	if reply.Code != proto.ErrCodeSuccess {
+		result = truncateAuditResult(reply.Msg)
+	}
+	return reply.Code, result
+}
+
+func truncateAuditResult(result string) string {
+	result = strings.TrimSpace(result)
+	if len(result) > auditMaxResultLen {
+		result = result[:auditMaxResultLen] + "..."
+	}
+	return result
+}
+
+// the sequence in the keys of the audit records, which keeps the keys unique if the records are
+// put in the same nanosecond
+var auditSeq uint64
+
+// key=#audit#unixNano#seq, so the records are sorted by time
+func (c *Cluster) syncPutAuditRecord(record *proto.AuditRecord) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncPutAuditRecord
+	metadata.K = fmt.Sprintf("%v%020d%v%06d", auditPrefix, time.Now().UnixNano(), keySeparator,
+		atomic.AddUint64(&auditSeq, 1)%1000000)
+	if metadata.V, err = json.Marshal(record); err != nil {
+		return
+	}
+	return c.submit(metadata)
+}
+
+func (c *Cluster) syncDeleteAuditRecord(key string) (err error) {
+	metadata := new(RaftCmd)
+	metadata.Op = opSyncDeleteAuditRecord
+	metadata.K = key
+	return c.submit(metadata)
+}
+
+// loadAuditRecords returns the audit records sorted by key.
+func (c *Cluster) loadAuditRecords() (keys []string, records []*proto.AuditRecord, err error) {
+	result, err := c.fsm.store.SeekForPrefix([]byte(auditPrefix))
+	if err != nil {
+		err = fmt.Errorf("action[loadAuditRecords],err:%v", err.Error())
+		return
+	}
+	keys = make([]string, 0, len(result))
+	for key := range result {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	records = make([]*proto.AuditRecord, 0, len(keys))
+	for i := 0; i < len(keys); i++ {
+		record := &proto.AuditRecord{}
+		if err = json.Unmarshal(result[keys[i]], record); err != nil {
+			err = fmt.Errorf("action[loadAuditRecords],key:%v,err:%v", keys[i], err.Error())
+			return
+		}
+		records = append(records, record)
+	}
+	return
+}
+
+// listAuditRecords returns the latest records started since the unix time, up to the limit, in the order of time.
+func (c *Cluster) listAuditRecords(since int64, limit int) (records []*proto.AuditRecord, err error) {
+	var all []*proto.AuditRecord
+	if _, all, err = c.loadAuditRecords(); err != nil {
+		return
+	}
+	if limit <= 0 {
+		limit = defaultAuditListLimit
+	}
+	records = make([]*proto.AuditRecord, 0)
+	for _, record := range all {
+		if record.Time >= since {
+			records = append(records, record)
+		}
+	}
+	if len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return
+}
+
+func (c *Cluster) scheduleToCleanAuditRecords() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.cleanAuditRecords(time.Now().AddDate(0, 0, -int(c.cfg.auditRetentionDays)).Unix())
+			}
+			time.Sleep(intervalToCleanAuditRecords)
+		}
+	}()
+}
+
+// cleanAuditRecords deletes the records started before the unix time.
+func (c *Cluster) cleanAuditRecords(before int64) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("cleanAuditRecords occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"cleanAuditRecords occurred panic")
+		}
+	}()
+	keys, records, err := c.loadAuditRecords()
+	if err != nil {
+		log.LogErrorf("action[cleanAuditRecords] err[%v]", err)
+		return
+	}
+	var count int
+	for i, record := range records {
+		if record.Time >= before {
+			break
+		}
+		if err = c.syncDeleteAuditRecord(keys[i]); err != nil {
+			log.LogErrorf("action[cleanAuditRecords] key[%v] err[%v]", keys[i], err)
+			return
+		}
+		count++
+	}
+	if count > 0 {
+		log.LogInfof("action[cleanAuditRecords] deleted %v audit records before %v", count, time.Unix(before, 0))
+	}
+}
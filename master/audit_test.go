@@ -0,0 +1,78 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestNewAPIAuditRecord(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, proto.AdminCreateVol+"?name=vol1&authKey=secret&capacity=10", nil)
+	r.Header.Set(proto.AuditCaller, "root@host1")
+	r.Header.Set("X-Forwarded-For", "192.168.0.10:30000, 192.168.0.11:17010")
+	record := newAPIAuditRecord(r, time.Now())
+	if record.Source != proto.AuditSourceAPI || record.API != proto.AdminCreateVol || record.Caller != "root@host1" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record.Remote != "192.168.0.10:30000" {
+		t.Errorf("remote %v, expect the first forwarded address", record.Remote)
+	}
+	if record.Params[nameKey] != "vol1" || record.Params["capacity"] != "10" {
+		t.Errorf("unexpected params %v", record.Params)
+	}
+	if record.Params[volAuthKey] != auditRedactedValue {
+		t.Errorf("auth key is not redacted: %v", record.Params[volAuthKey])
+	}
+}
+
+func TestParseAuditOutcome(t *testing.T) {
+	code, result := parseAuditOutcome(http.StatusOK, []byte(`{"code":0,"msg":"success","data":"ok"}`))
+	if code != proto.ErrCodeSuccess || result != "" {
+		t.Errorf("success: code %v result %v", code, result)
+	}
+	code, result = parseAuditOutcome(http.StatusOK, []byte(`{"code":2,"msg":"parameter error"}`))
+	if code != proto.ErrCodeParamError || result != "parameter error" {
+		t.Errorf("failure: code %v result %v", code, result)
+	}
+	code, result = parseAuditOutcome(http.StatusBadRequest, []byte("no leader\n"))
+	if code != proto.ErrCodeInternalError || result != "400 no leader" {
+		t.Errorf("http error: code %v result %v", code, result)
+	}
+}
+
+func TestNewCLIAuditRecord(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, proto.AdminRecordCliAudit, nil)
+	record := newCLIAuditRecord(r, &proto.CliAuditRecord{
+		Time:     100,
+		User:     "root",
+		Host:     "host1",
+		Command:  "cfs-cli volume delete",
+		Args:     []string{"volume", "delete", "vol1"},
+		ExitCode: 1,
+		Result:   "volume not exists",
+	})
+	if record.Source != proto.AuditSourceCLI || record.Caller != "root@host1" || record.API != "cfs-cli volume delete" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record.Time != 100 || record.Code != 1 || record.Result != "volume not exists" ||
+		record.Params["args"] != "volume delete vol1" {
+		t.Errorf("unexpected record %+v", record)
+	}
+}
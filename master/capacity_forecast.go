@@ -0,0 +1,183 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util"
+)
+
+const (
+	intervalToSampleUsage       = 10 * 60 // seconds between the samples of the used space
+	maxUsageHistoryDays         = 30      // days of the samples kept
+	defaultCapacityForecastDays = 7       // days of the recent samples to forecast by
+	minCapacityForecastSamples  = 2       // samples required to forecast
+	secondsPerDay               = 24 * 60 * 60
+)
+
+type usageSample struct {
+	time  int64
+	used  uint64
+	total uint64
+}
+
+// usageHistory keeps the samples of the used space of the volumes and the zones in the memory of the leader.
+// The samples are taken with the statistics of the cluster, so the history starts over on the new leader.
+type usageHistory struct {
+	sync.RWMutex
+	vols  map[string][]*usageSample
+	zones map[string][]*usageSample
+}
+
+func newUsageHistory() *usageHistory {
+	return &usageHistory{
+		vols:  make(map[string][]*usageSample),
+		zones: make(map[string][]*usageSample),
+	}
+}
+
+// add appends the samples if the latest ones are older than the interval, the samples of the volumes or the
+// zones not sampled any more are dropped.
+func (h *usageHistory) add(history map[string][]*usageSample, samples map[string]*usageSample) {
+	for name := range history {
+		if _, ok := samples[name]; !ok {
+			delete(history, name)
+		}
+	}
+	for name, sample := range samples {
+		series := history[name]
+		if len(series) > 0 && sample.time-series[len(series)-1].time < intervalToSampleUsage {
+			continue
+		}
+		series = append(series, sample)
+		expired := 0
+		for expired < len(series) && sample.time-series[expired].time > maxUsageHistoryDays*secondsPerDay {
+			expired++
+		}
+		history[name] = series[expired:]
+	}
+}
+
+func (h *usageHistory) addSamples(vols, zones map[string]*usageSample) {
+	h.Lock()
+	defer h.Unlock()
+	h.add(h.vols, vols)
+	h.add(h.zones, zones)
+}
+
+func (h *usageHistory) forecasts(history map[string][]*usageSample, name string, since int64) (forecasts []*proto.CapacityForecast) {
+	h.RLock()
+	defer h.RUnlock()
+	forecasts = make([]*proto.CapacityForecast, 0)
+	for n, series := range history {
+		if name != "" && n != name {
+			continue
+		}
+		start := sort.Search(len(series), func(i int) bool { return series[i].time >= since })
+		if start == len(series) {
+			continue
+		}
+		forecasts = append(forecasts, forecastCapacity(n, series[start:]))
+	}
+	sort.Slice(forecasts, func(i, j int) bool { return forecasts[i].Name < forecasts[j].Name })
+	return
+}
+
+// forecastCapacity fits the used space of the samples to a line by the least squares, and forecasts the time
+// the used space reaches the total of the latest sample by the slope.
+func forecastCapacity(name string, samples []*usageSample) (forecast *proto.CapacityForecast) {
+	latest := samples[len(samples)-1]
+	forecast = &proto.CapacityForecast{
+		Name:       name,
+		Total:      latest.total,
+		Used:       latest.used,
+		DaysToFull: -1,
+		Samples:    len(samples),
+		Since:      samples[0].time,
+	}
+	if len(samples) < minCapacityForecastSamples {
+		return
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	n := float64(len(samples))
+	for _, sample := range samples {
+		// relative to the earliest sample to keep the precision
+		x := float64(sample.time - samples[0].time)
+		y := float64(sample.used)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator // bytes per second
+	forecast.GrowthPerDay = math.Round(slope * secondsPerDay)
+	if slope <= 0 {
+		return
+	}
+	if latest.used >= latest.total {
+		forecast.DaysToFull = 0
+		forecast.FullTime = latest.time
+		return
+	}
+	seconds := float64(latest.total-latest.used) / slope
+	forecast.DaysToFull = math.Round(seconds/secondsPerDay*100) / 100
+	forecast.FullTime = latest.time + int64(math.Round(seconds))
+	return
+}
+
+// sampleUsage records the used space of the volumes and of the data nodes in the zones.
+func (c *Cluster) sampleUsage() {
+	now := time.Now().Unix()
+	vols := make(map[string]*usageSample)
+	for _, vol := range c.copyVols() {
+		vols[vol.Name] = &usageSample{time: now, used: vol.totalUsedSpace(), total: vol.Capacity * util.GB}
+	}
+	zones := make(map[string]*usageSample)
+	for _, zone := range c.t.getAllZones() {
+		sample := &usageSample{time: now}
+		zone.dataNodes.Range(func(key, value interface{}) bool {
+			node := value.(*DataNode)
+			sample.used += node.Used
+			sample.total += node.Total
+			return true
+		})
+		zones[zone.name] = sample
+	}
+	c.usageHistory.addSamples(vols, zones)
+}
+
+// capacityForecast forecasts the volume of the name, or all the volumes and the zones if the name is empty.
+func (c *Cluster) capacityForecast(name string, days int) (view *proto.CapacityForecastView) {
+	since := time.Now().Unix() - int64(days)*secondsPerDay
+	view = &proto.CapacityForecastView{
+		Days: days,
+		Vols: c.usageHistory.forecasts(c.usageHistory.vols, name, since),
+	}
+	if name == "" {
+		view.Zones = c.usageHistory.forecasts(c.usageHistory.zones, "", since)
+	} else {
+		view.Zones = make([]*proto.CapacityForecast, 0)
+	}
+	return
+}
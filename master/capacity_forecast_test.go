@@ -0,0 +1,71 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/util"
+)
+
+func TestForecastCapacity(t *testing.T) {
+	samples := make([]*usageSample, 0)
+	for i := int64(0); i < 10; i++ {
+		samples = append(samples, &usageSample{time: i * secondsPerDay, used: uint64(i) * util.GB, total: 100 * util.GB})
+	}
+	forecast := forecastCapacity("vol", samples)
+	if forecast.GrowthPerDay != util.GB {
+		t.Errorf("growth %v", forecast.GrowthPerDay)
+	}
+	if forecast.DaysToFull != 91 || forecast.FullTime != 100*secondsPerDay {
+		t.Errorf("days to full %v, full time %v", forecast.DaysToFull, forecast.FullTime)
+	}
+	if forecast.Samples != 10 || forecast.Since != 0 {
+		t.Errorf("samples %v since %v", forecast.Samples, forecast.Since)
+	}
+
+	// not growing
+	flat := []*usageSample{{time: 0, used: util.GB, total: 100 * util.GB}, {time: secondsPerDay, used: util.GB, total: 100 * util.GB}}
+	if forecast = forecastCapacity("vol", flat); forecast.DaysToFull != -1 || forecast.FullTime != 0 {
+		t.Errorf("days to full %v, full time %v", forecast.DaysToFull, forecast.FullTime)
+	}
+	// too few samples
+	if forecast = forecastCapacity("vol", samples[:1]); forecast.DaysToFull != -1 {
+		t.Errorf("days to full %v", forecast.DaysToFull)
+	}
+}
+
+func TestUsageHistory(t *testing.T) {
+	h := newUsageHistory()
+	h.addSamples(map[string]*usageSample{"a": {time: 0}, "b": {time: 0}}, nil)
+	// too close to the latest sample
+	h.addSamples(map[string]*usageSample{"a": {time: intervalToSampleUsage - 1}, "b": {time: 0}}, nil)
+	if len(h.vols["a"]) != 1 {
+		t.Errorf("samples %v", len(h.vols["a"]))
+	}
+	// the volume b is dropped
+	h.addSamples(map[string]*usageSample{"a": {time: intervalToSampleUsage}}, nil)
+	if len(h.vols["a"]) != 2 || h.vols["b"] != nil {
+		t.Errorf("samples %v %v", len(h.vols["a"]), len(h.vols["b"]))
+	}
+	// the expired samples are dropped
+	h.addSamples(map[string]*usageSample{"a": {time: maxUsageHistoryDays*secondsPerDay + intervalToSampleUsage}}, nil)
+	if len(h.vols["a"]) != 2 || h.vols["a"][0].time != intervalToSampleUsage {
+		t.Errorf("samples %v", len(h.vols["a"]))
+	}
+	if forecasts := h.forecasts(h.vols, "a", maxUsageHistoryDays*secondsPerDay); len(forecasts) != 1 || forecasts[0].Samples != 1 {
+		t.Errorf("forecasts %v", forecasts)
+	}
+}
@@ -16,6 +16,7 @@ package master
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -55,6 +56,20 @@ type Cluster struct {
 	MasterSecretKey           []byte
 	lastMasterZoneForDataNode string
 	lastMasterZoneForMetaNode string
+	asyncTasks                *asyncTaskManager
+	replicaSupplements        *replicaSupplementer
+	metaRebalancer            *rebalancer
+	dataRebalancer            *rebalancer
+	events                    *eventBus
+	alerts                    *alertManager
+	runtimeConfig             *runtimeConfig
+	metadataBackups           *metadataBackupManager
+	usageHistory              *usageHistory
+	stalePartitions           *stalePartitionGC
+	replications              *replicationTracker
+	federation                *federation
+	consistency               *consistencyChecker
+	volDataKeys               sync.Map // unwrapped data keys of the volumes, volume name/wrapped key -> data key
 }
 
 func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition raftstore.Partition, cfg *clusterConfig) (c *Cluster) {
@@ -72,6 +87,19 @@ func newCluster(name string, leaderInfo *LeaderInfo, fsm *MetadataFsm, partition
 	c.fsm = fsm
 	c.partition = partition
 	c.idAlloc = newIDAllocator(c.fsm.store, c.partition)
+	c.asyncTasks = newAsyncTaskManager()
+	c.replicaSupplements = newReplicaSupplementer()
+	c.metaRebalancer = newRebalancer("meta")
+	c.dataRebalancer = newRebalancer("data")
+	c.events = newEventBus(name, cfg)
+	c.alerts = newAlertManager()
+	c.runtimeConfig = newRuntimeConfig(cfg)
+	c.metadataBackups = newMetadataBackupManager(&cfg.metadataBackup)
+	c.usageHistory = newUsageHistory()
+	c.stalePartitions = newStalePartitionGC()
+	c.replications = newReplicationTracker()
+	c.federation = newFederation()
+	c.consistency = newConsistencyChecker()
 	return
 }
 
@@ -87,7 +115,17 @@ func (c *Cluster) scheduleTask() {
 	c.scheduleToCheckDiskRecoveryProgress()
 	c.scheduleToCheckMetaPartitionRecoveryProgress()
 	c.scheduleToLoadMetaPartitions()
-	c.scheduleToReduceReplicaNum()
+	c.scheduleToChangeReplicaNum()
+	c.scheduleToSupplementReplicas()
+	c.scheduleToRebalanceMetaPartitions()
+	c.scheduleToRebalanceDataPartitions()
+	c.scheduleToEvaluateAlertRules()
+	c.scheduleToCleanAuditRecords()
+	c.scheduleToBackupMetadata()
+	c.scheduleToCollectStalePartitions()
+	c.scheduleToCheckReplicationLag()
+	c.scheduleToCheckConsistency()
+	c.scheduleToPromoteMetaLearners()
 }
 
 func (c *Cluster) masterAddr() (addr string) {
@@ -250,7 +288,9 @@ func (c *Cluster) checkDataNodeHeartbeat() {
 	tasks := make([]*proto.AdminTask, 0)
 	c.dataNodes.Range(func(addr, dataNode interface{}) bool {
 		node := dataNode.(*DataNode)
-		node.checkLiveness()
+		if node.checkLiveness() {
+			c.events.emit(proto.EventNodeInactive, node.Addr, "", 0, "data node is inactive")
+		}
 		task := node.createHeartbeatTask(c.masterAddr())
 		tasks = append(tasks, task)
 		return true
@@ -262,7 +302,9 @@ func (c *Cluster) checkMetaNodeHeartbeat() {
 	tasks := make([]*proto.AdminTask, 0)
 	c.metaNodes.Range(func(addr, metaNode interface{}) bool {
 		node := metaNode.(*MetaNode)
-		node.checkHeartbeat()
+		if node.checkHeartbeat() {
+			c.events.emit(proto.EventNodeInactive, node.Addr, "", 0, "meta node is inactive")
+		}
 		task := node.createHeartbeatTask(c.masterAddr())
 		tasks = append(tasks, task)
 		return true
@@ -295,31 +337,6 @@ func (c *Cluster) checkMetaPartitions() {
 	}
 }
 
-func (c *Cluster) scheduleToReduceReplicaNum() {
-	go func() {
-		for {
-			if c.partition != nil && c.partition.IsRaftLeader() {
-				c.checkVolReduceReplicaNum()
-			}
-			time.Sleep(5 * time.Minute)
-		}
-	}()
-}
-
-func (c *Cluster) checkVolReduceReplicaNum() {
-	defer func() {
-		if r := recover(); r != nil {
-			log.LogWarnf("checkVolReduceReplicaNum occurred panic,err[%v]", r)
-			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
-				"checkVolReduceReplicaNum occurred panic")
-		}
-	}()
-	vols := c.allVols()
-	for _, vol := range vols {
-		vol.checkReplicaNum(c)
-	}
-}
-
 func (c *Cluster) getInvalidIDNodes() (nodes []*InvalidNodeView) {
 	metaNodes := c.getNotConsistentIDMetaNodes()
 	nodes = append(nodes, metaNodes...)
@@ -430,15 +447,19 @@ func (c *Cluster) updateMetaNodeBaseInfo(nodeAddr string, id uint64) (err error)
 	return
 }
 
-func (c *Cluster) addMetaNode(nodeAddr, zoneName string) (id uint64, err error) {
+func (c *Cluster) addMetaNode(nodeAddr, zoneName, rackName string) (id uint64, err error) {
 	c.mnMutex.Lock()
 	defer c.mnMutex.Unlock()
 	var metaNode *MetaNode
 	if value, ok := c.metaNodes.Load(nodeAddr); ok {
 		metaNode = value.(*MetaNode)
-		return metaNode.ID, nil
+		if metaNode.RackName != rackName {
+			err = c.updateMetaNodeRack(metaNode, rackName)
+		}
+		return metaNode.ID, err
 	}
 	metaNode = newMetaNode(nodeAddr, zoneName, c.Name)
+	metaNode.RackName = rackName
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -462,8 +483,8 @@ func (c *Cluster) addMetaNode(nodeAddr, zoneName string) (id uint64, err error)
 	}
 	c.t.putMetaNode(metaNode)
 	c.metaNodes.Store(nodeAddr, metaNode)
-	log.LogInfof("action[addMetaNode],clusterID[%v] metaNodeAddr:%v,nodeSetId[%v],capacity[%v]",
-		c.Name, nodeAddr, ns.ID, ns.Capacity)
+	log.LogInfof("action[addMetaNode],clusterID[%v] metaNodeAddr:%v,nodeSetId[%v],rack[%v],capacity[%v]",
+		c.Name, nodeAddr, ns.ID, rackName, ns.Capacity)
 	return
 errHandler:
 	err = fmt.Errorf("action[addMetaNode],clusterID[%v] metaNodeAddr:%v err:%v ",
@@ -473,16 +494,20 @@ errHandler:
 	return
 }
 
-func (c *Cluster) addDataNode(nodeAddr, zoneName string) (id uint64, err error) {
+func (c *Cluster) addDataNode(nodeAddr, zoneName, rackName string) (id uint64, err error) {
 	c.dnMutex.Lock()
 	defer c.dnMutex.Unlock()
 	var dataNode *DataNode
 	if node, ok := c.dataNodes.Load(nodeAddr); ok {
 		dataNode = node.(*DataNode)
-		return dataNode.ID, nil
+		if dataNode.RackName != rackName {
+			err = c.updateDataNodeRack(dataNode, rackName)
+		}
+		return dataNode.ID, err
 	}
 
 	dataNode = newDataNode(nodeAddr, zoneName, c.Name)
+	dataNode.RackName = rackName
 	zone, err := c.t.getZone(zoneName)
 	if err != nil {
 		zone = c.t.putZoneIfAbsent(newZone(zoneName))
@@ -507,8 +532,8 @@ func (c *Cluster) addDataNode(nodeAddr, zoneName string) (id uint64, err error)
 	}
 	c.t.putDataNode(dataNode)
 	c.dataNodes.Store(nodeAddr, dataNode)
-	log.LogInfof("action[addDataNode],clusterID[%v] dataNodeAddr:%v,nodeSetId[%v],capacity[%v]",
-		c.Name, nodeAddr, ns.ID, ns.Capacity)
+	log.LogInfof("action[addDataNode],clusterID[%v] dataNodeAddr:%v,nodeSetId[%v],rack[%v],capacity[%v]",
+		c.Name, nodeAddr, ns.ID, rackName, ns.Capacity)
 	return
 errHandler:
 	err = fmt.Errorf("action[addDataNode],clusterID[%v] dataNodeAddr:%v err:%v ", c.Name, nodeAddr, err.Error())
@@ -517,6 +542,41 @@ errHandler:
 	return
 }
 
+// updateDataNodeRack updates the rack of the registered data node, which is reported again at registration after
+// the node is moved to another rack.
+func (c *Cluster) updateDataNodeRack(dataNode *DataNode, rackName string) (err error) {
+	dataNode.Lock()
+	oldRack := dataNode.RackName
+	dataNode.RackName = rackName
+	dataNode.Unlock()
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.Lock()
+		dataNode.RackName = oldRack
+		dataNode.Unlock()
+		return
+	}
+	log.LogInfof("action[updateDataNodeRack] clusterID[%v] dataNode[%v] rack[%v] -> [%v]",
+		c.Name, dataNode.Addr, oldRack, rackName)
+	return
+}
+
+// updateMetaNodeRack updates the rack of the registered meta node in the same way as updateDataNodeRack.
+func (c *Cluster) updateMetaNodeRack(metaNode *MetaNode, rackName string) (err error) {
+	metaNode.Lock()
+	oldRack := metaNode.RackName
+	metaNode.RackName = rackName
+	metaNode.Unlock()
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Lock()
+		metaNode.RackName = oldRack
+		metaNode.Unlock()
+		return
+	}
+	log.LogInfof("action[updateMetaNodeRack] clusterID[%v] metaNode[%v] rack[%v] -> [%v]",
+		c.Name, metaNode.Addr, oldRack, rackName)
+	return
+}
+
 func (c *Cluster) checkCorruptDataPartitions() (inactiveDataNodes []string, corruptPartitions []*DataPartition, err error) {
 	partitionMap := make(map[uint64]uint8)
 	inactiveDataNodes = make([]string, 0)
@@ -568,6 +628,48 @@ func (c *Cluster) checkLackReplicaDataPartitions() (lackReplicaDataPartitions []
 	return
 }
 
+// checkRackRiskDataPartitions finds the data partitions whose majority of replicas are in the same rack, which
+// lose the quorum if the rack fails.
+func (c *Cluster) checkRackRiskDataPartitions() (partitionIDs []uint64) {
+	partitionIDs = make([]uint64, 0)
+	rackOf := func(addr string) string {
+		if dataNode, err := c.dataNode(addr); err == nil && dataNode.GetRack() != "" {
+			return dataNode.ZoneName + "/" + dataNode.GetRack()
+		}
+		return ""
+	}
+	for _, vol := range c.copyVols() {
+		for _, dp := range vol.dataPartitions.partitions {
+			dp.RLock()
+			risky := isQuorumInOneRack(dp.Hosts, dp.ReplicaNum, rackOf)
+			dp.RUnlock()
+			if risky {
+				partitionIDs = append(partitionIDs, dp.PartitionID)
+			}
+		}
+	}
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+	log.LogInfof("clusterID[%v] rackRiskDataPartitions count:[%v]", c.Name, len(partitionIDs))
+	return
+}
+
+// isQuorumInOneRack checks whether more than half of the replicas are in the same rack. The replicas whose nodes
+// report no rack are not counted.
+func isQuorumInOneRack(hosts []string, replicaNum uint8, rackOf func(addr string) string) bool {
+	racks := make(map[string]uint8)
+	for _, host := range hosts {
+		rack := rackOf(host)
+		if rack == "" {
+			continue
+		}
+		racks[rack]++
+		if racks[rack] > replicaNum/2 {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cluster) getDataPartitionByID(partitionID uint64) (dp *DataPartition, err error) {
 	vols := c.copyVols()
 	for _, vol := range vols {
@@ -590,6 +692,55 @@ func (c *Cluster) getMetaPartitionByID(id uint64) (mp *MetaPartition, err error)
 	return
 }
 
+// listMetaPartitions returns the meta partitions matching the filters sorted by ID, the empty filters match all.
+func (c *Cluster) listMetaPartitions(volName, status, addr string) (items []*proto.MetaPartitionListItem, err error) {
+	var vols map[string]*Vol
+	if volName != "" {
+		var vol *Vol
+		if vol, err = c.getVol(volName); err != nil {
+			return
+		}
+		vols = map[string]*Vol{vol.Name: vol}
+	} else {
+		vols = c.copyVols()
+	}
+	items = make([]*proto.MetaPartitionListItem, 0)
+	for _, vol := range vols {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			view := getMetaPartitionView(mp)
+			if addr != "" && !contains(view.Members, addr) {
+				continue
+			}
+			if !matchMetaPartitionStatus(mp, view, status) {
+				continue
+			}
+			items = append(items, &proto.MetaPartitionListItem{
+				VolName:           vol.Name,
+				ReplicaNum:        mp.ReplicaNum,
+				MetaPartitionView: view,
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].PartitionID < items[j].PartitionID
+	})
+	return
+}
+
+func matchMetaPartitionStatus(mp *MetaPartition, view *proto.MetaPartitionView, status string) bool {
+	switch status {
+	case proto.PartitionFilterUnhealthy:
+		return view.Status == proto.Unavailable || view.LeaderAddr == "" || view.IsRecover ||
+			int(mp.ReplicaNum) > len(view.Members)
+	case proto.PartitionFilterReadOnly:
+		return view.Status == proto.ReadOnly
+	case proto.PartitionFilterReadWrite:
+		return view.Status == proto.ReadWrite
+	default:
+		return true
+	}
+}
+
 func (c *Cluster) putVol(vol *Vol) {
 	c.volMutex.Lock()
 	defer c.volMutex.Unlock()
@@ -629,14 +780,54 @@ func (c *Cluster) markDeleteVol(name, authKey string) (err error) {
 		return proto.ErrVolAuthKeyNotMatch
 	}
 
+	vol.Lock()
+	defer vol.Unlock()
+	oldDeleteTime := vol.deleteTime
+	// deleting the volume in the trash again does not extend the retention window
+	if vol.Status != markDelete {
+		vol.deleteTime = time.Now().Unix()
+	}
 	vol.Status = markDelete
 	if err = c.syncUpdateVol(vol); err != nil {
 		vol.Status = normal
+		vol.deleteTime = oldDeleteTime
 		return proto.ErrPersistenceByRaft
 	}
 	return
 }
 
+// restoreVol recovers the volume marked deleted within the retention window.
+func (c *Cluster) restoreVol(name, authKey string) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err != nil {
+		log.LogErrorf("action[restoreVol] err[%v]", err)
+		return nil, proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	if vol.Status != markDelete {
+		return nil, fmt.Errorf("vol[%v] is not deleted", name)
+	}
+	if !vol.isInTrash(c.volDeletionDelay()) {
+		return nil, fmt.Errorf("vol[%v] is being purged since the retention window expired", name)
+	}
+	oldDeleteTime := vol.deleteTime
+	vol.Status = normal
+	vol.deleteTime = 0
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.Status = markDelete
+		vol.deleteTime = oldDeleteTime
+		return nil, proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+func (c *Cluster) volDeletionDelay() uint64 {
+	return atomic.LoadUint64(&c.cfg.VolDeletionDelay)
+}
+
 func (c *Cluster) batchCreateDataPartition(vol *Vol, reqCount int) (err error) {
 	var zoneNum int
 	for i := 0; i < reqCount; i++ {
@@ -677,7 +868,12 @@ func (c *Cluster) createDataPartition(volName string, zoneNum int) (dp *DataPart
 	vol.createDpMutex.Lock()
 	defer vol.createDpMutex.Unlock()
 	errChannel := make(chan error, vol.dpReplicaNum)
-	if targetHosts, targetPeers, err = c.chooseTargetDataNodes("", nil, nil, int(vol.dpReplicaNum), zoneNum, vol.zoneName); err != nil {
+	if vol.usesPlacementPolicy() {
+		targetHosts, targetPeers, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, int(vol.dpReplicaNum))
+	} else {
+		targetHosts, targetPeers, err = c.chooseTargetDataNodes("", nil, nil, int(vol.dpReplicaNum), zoneNum, vol.zoneName)
+	}
+	if err != nil {
 		goto errHandler
 	}
 	if partitionID, err = c.idAlloc.allocateDataPartitionID(); err != nil {
@@ -743,7 +939,7 @@ errHandler:
 }
 
 func (c *Cluster) syncCreateDataPartitionToDataNode(host string, size uint64, dp *DataPartition, peers []proto.Peer, hosts []string, createType int) (diskPath string, err error) {
-	task := dp.createTaskToCreateDataPartition(host, size, peers, hosts, createType)
+	task := dp.createTaskToCreateDataPartition(host, size, peers, hosts, createType, c.volFrozenEpoch(dp.VolName))
 	dataNode, err := c.dataNode(host)
 	if err != nil {
 		return
@@ -755,10 +951,29 @@ func (c *Cluster) syncCreateDataPartitionToDataNode(host string, size uint64, dp
 	return string(resp.Data), nil
 }
 
+// volMetaStore returns the store of the new meta partitions of the volume, empty for the default of the meta nodes.
+func (c *Cluster) volMetaStore(volName string) string {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return ""
+	}
+	return vol.metaStore
+}
+
+// volCaseInsensitive returns if the names are looked up case-insensitively by the meta partitions of the volume.
+func (c *Cluster) volCaseInsensitive(volName string) bool {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return false
+	}
+	return vol.caseInsensitive
+}
+
 func (c *Cluster) syncCreateMetaPartitionToMetaNode(host string, mp *MetaPartition) (err error) {
 	hosts := make([]string, 0)
 	hosts = append(hosts, host)
-	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName)
+	tasks := mp.buildNewMetaPartitionTasks(hosts, mp.Peers, mp.volName, c.volMetaStore(mp.volName),
+		c.volCaseInsensitive(mp.volName))
 	metaNode, err := c.metaNode(host)
 	if err != nil {
 		return
@@ -937,6 +1152,71 @@ func (c *Cluster) getAllMetaPartitionByMetaNode(addr string) (partitions []*Meta
 	return
 }
 
+// getDataNodePartitionViews returns the views of the data partition replicas on the data node.
+func (c *Cluster) getDataNodePartitionViews(addr string) (views []*proto.NodePartitionView) {
+	partitions := c.getAllDataPartitionByDataNode(addr)
+	views = make([]*proto.NodePartitionView, 0, len(partitions))
+	for _, dp := range partitions {
+		dp.RLock()
+		view := &proto.NodePartitionView{
+			PartitionID: dp.PartitionID,
+			VolName:     dp.VolName,
+			ReplicaNum:  dp.ReplicaNum,
+			Hosts:       append([]string{}, dp.Hosts...),
+			LeaderAddr:  dp.getLeaderAddr(),
+			IsRecover:   dp.isRecover,
+			Status:      proto.Unavailable,
+			IsMissing:   true,
+		}
+		for _, replica := range dp.Replicas {
+			if replica.Addr != addr {
+				continue
+			}
+			view.IsLeader = replica.IsLeader
+			view.Status = replica.Status
+			view.Used = replica.Used
+			view.DiskPath = replica.DiskPath
+			view.IsMissing = replica.isMissing(defaultDataPartitionTimeOutSec)
+		}
+		dp.RUnlock()
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].PartitionID < views[j].PartitionID })
+	return
+}
+
+// getMetaNodePartitionViews returns the views of the meta partition replicas on the meta node.
+func (c *Cluster) getMetaNodePartitionViews(addr string) (views []*proto.NodePartitionView) {
+	partitions := c.getAllMetaPartitionByMetaNode(addr)
+	views = make([]*proto.NodePartitionView, 0, len(partitions))
+	for _, mp := range partitions {
+		mp.RLock()
+		view := &proto.NodePartitionView{
+			PartitionID: mp.PartitionID,
+			VolName:     mp.volName,
+			ReplicaNum:  mp.ReplicaNum,
+			Hosts:       append([]string{}, mp.Hosts...),
+			IsRecover:   mp.IsRecover,
+			Status:      proto.Unavailable,
+			IsMissing:   true,
+		}
+		if leader, err := mp.getMetaReplicaLeader(); err == nil {
+			view.LeaderAddr = leader.Addr
+		}
+		if replica, err := mp.getMetaReplica(addr); err == nil {
+			view.IsLeader = replica.IsLeader
+			view.Status = replica.Status
+			view.InodeCount = replica.InodeCount
+			view.DentryCount = replica.DentryCount
+			view.IsMissing = replica.isMissing()
+		}
+		mp.RUnlock()
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].PartitionID < views[j].PartitionID })
+	return
+}
+
 func (c *Cluster) getAllDataPartitionIDByDatanode(addr string) (partitionIDs []uint64) {
 	partitionIDs = make([]uint64, 0)
 	safeVols := c.allVols()
@@ -998,12 +1278,18 @@ func (c *Cluster) decommissionDataNode(dataNode *DataNode) (err error) {
 	defer func() {
 		dataNode.ToBeOffline = false
 		close(errChannel)
+		c.emitDecommissionFinished(dataNode.Addr, "data node", err)
 	}()
+	limiter := newDecommissionLimiter(&c.cfg.DataNodeDecommissionLimit)
 	for _, dp := range partitions {
+		limiter.wait()
+		limiter.start()
 		wg.Add(1)
 		go func(dp *DataPartition) {
 			defer wg.Done()
-			if err1 := c.decommissionDataPartition(dataNode.Addr, dp, dataNodeOfflineErr); err1 != nil {
+			err1 := c.decommissionDataPartition(dataNode.Addr, dp, dataNodeOfflineErr)
+			limiter.done(dp, err1)
+			if err1 != nil {
 				errChannel <- err1
 			}
 		}(dp)
@@ -1045,16 +1331,9 @@ func (c *Cluster) delDataNodeFromCache(dataNode *DataNode) {
 // 6. persistent the new host list
 func (c *Cluster) decommissionDataPartition(offlineAddr string, dp *DataPartition, errMsg string) (err error) {
 	var (
-		targetHosts     []string
-		newAddr         string
-		msg             string
-		dataNode        *DataNode
-		zone            *Zone
-		replica         *DataReplica
-		ns              *nodeSet
-		excludeNodeSets []uint64
-		zones           []string
-		excludeZone     string
+		newAddr string
+		msg     string
+		replica *DataReplica
 	)
 	dp.RLock()
 	if ok := dp.hasHost(offlineAddr); !ok {
@@ -1066,20 +1345,81 @@ func (c *Cluster) decommissionDataPartition(offlineAddr string, dp *DataPartitio
 	if err = c.validateDecommissionDataPartition(dp, offlineAddr); err != nil {
 		goto errHandler
 	}
-
-	if dataNode, err = c.dataNode(offlineAddr); err != nil {
+	if newAddr, err = c.chooseDataPartitionDecommissionTarget(dp, offlineAddr); err != nil {
 		goto errHandler
 	}
+	if err = c.moveDataReplica(dp, replica, offlineAddr, newAddr); err != nil {
+		goto errHandler
+	}
+	log.LogWarnf("clusterID[%v] partitionID:%v  on Node:%v offline success,newHost[%v],PersistenceHosts:[%v]",
+		c.Name, dp.PartitionID, offlineAddr, newAddr, dp.Hosts)
+	return
+errHandler:
+	msg = fmt.Sprintf(errMsg+" clusterID[%v] partitionID:%v  on Node:%v  "+
+		"Then Fix It on newHost:%v   Err:%v , PersistenceHosts:%v  ",
+		c.Name, dp.PartitionID, offlineAddr, newAddr, err, dp.Hosts)
+	if err != nil {
+		Warn(c.Name, msg)
+		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", dp.VolName, dp.PartitionID, err)
+	}
+	return
+}
+
+// moveDataReplica replaces the replica on the offline address with a new replica on the new address, the partition is
+// read only and recovering until the new replica catches up.
+func (c *Cluster) moveDataReplica(dp *DataPartition, replica *DataReplica, offlineAddr, newAddr string) (err error) {
+	if err = c.removeDataReplica(dp, offlineAddr, false); err != nil {
+		return
+	}
+	if err = c.addDataReplica(dp, newAddr); err != nil {
+		return
+	}
+	dp.Status = proto.ReadOnly
+	dp.isRecover = true
+	c.putBadDataPartitionIDs(replica, offlineAddr, dp.PartitionID)
+	dp.RLock()
+	c.syncUpdateDataPartition(dp)
+	dp.RUnlock()
+	return
+}
 
+// chooseDataPartitionDecommissionTarget chooses the data node for the new replica of the partition decommissioned
+// from the offline address. The node set of the offline node is preferred, then its zone, then the other zones.
+func (c *Cluster) chooseDataPartitionDecommissionTarget(dp *DataPartition, offlineAddr string) (newAddr string, err error) {
+	var (
+		targetHosts     []string
+		dataNode        *DataNode
+		zone            *Zone
+		ns              *nodeSet
+		excludeNodeSets []uint64
+		zones           []string
+		excludeZone     string
+		vol             *Vol
+	)
+	if vol, err = c.getVol(dp.VolName); err != nil {
+		return
+	}
+	if vol.usesPlacementPolicy() {
+		dp.RLock()
+		hosts := append([]string{}, dp.Hosts...)
+		dp.RUnlock()
+		if targetHosts, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), excludeHost(hosts, offlineAddr), hosts, 1); err != nil {
+			return
+		}
+		return targetHosts[0], nil
+	}
+	if dataNode, err = c.dataNode(offlineAddr); err != nil {
+		return
+	}
 	if dataNode.ZoneName == "" {
 		err = fmt.Errorf("dataNode[%v] zone is nil", dataNode.Addr)
-		goto errHandler
+		return
 	}
 	if zone, err = c.t.getZone(dataNode.ZoneName); err != nil {
-		goto errHandler
+		return
 	}
 	if ns, err = zone.getNodeSet(dataNode.NodeSetID); err != nil {
-		goto errHandler
+		return
 	}
 	if targetHosts, _, err = ns.getAvailDataNodeHosts(dp.Hosts, 1); err != nil {
 		// select data nodes from the other node set in same zone
@@ -1093,34 +1433,11 @@ func (c *Cluster) decommissionDataPartition(offlineAddr string, dp *DataPartitio
 				excludeZone = zones[0]
 			}
 			if targetHosts, _, err = c.chooseTargetDataNodes(excludeZone, excludeNodeSets, dp.Hosts, 1, 1, ""); err != nil {
-				goto errHandler
+				return
 			}
 		}
 	}
-	if err = c.removeDataReplica(dp, offlineAddr, false); err != nil {
-		goto errHandler
-	}
 	newAddr = targetHosts[0]
-	if err = c.addDataReplica(dp, newAddr); err != nil {
-		goto errHandler
-	}
-	dp.Status = proto.ReadOnly
-	dp.isRecover = true
-	c.putBadDataPartitionIDs(replica, offlineAddr, dp.PartitionID)
-	dp.RLock()
-	c.syncUpdateDataPartition(dp)
-	dp.RUnlock()
-	log.LogWarnf("clusterID[%v] partitionID:%v  on Node:%v offline success,newHost[%v],PersistenceHosts:[%v]",
-		c.Name, dp.PartitionID, offlineAddr, newAddr, dp.Hosts)
-	return
-errHandler:
-	msg = fmt.Sprintf(errMsg+" clusterID[%v] partitionID:%v  on Node:%v  "+
-		"Then Fix It on newHost:%v   Err:%v , PersistenceHosts:%v  ",
-		c.Name, dp.PartitionID, offlineAddr, newAddr, err, dp.Hosts)
-	if err != nil {
-		Warn(c.Name, msg)
-		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", dp.VolName, dp.PartitionID, err)
-	}
 	return
 }
 
@@ -1376,6 +1693,111 @@ func (c *Cluster) removeDataPartitionRaftMember(dp *DataPartition, removePeer pr
 	return
 }
 
+// Reset the raft members of a corrupt data partition to the replicas on the active data nodes.
+// This is the only way to recover the partition whose majority of replicas are on the corrupt
+// data nodes, however the data which has not been synchronized to the remaining replicas will be lost.
+// 1. check that the data partition has lost the majority of replicas
+// 2. synchronized reset the raft members on each remaining replica, the replica is validated by crc before promotion
+// 3. persistent the new host list
+// 4. the replicas lacked will be added back by "datapartition add-replica"
+// getLiveDataPartitionPeers splits the peers of the partition by whether they are on the active data nodes.
+func (c *Cluster) getLiveDataPartitionPeers(dp *DataPartition) (liveHosts []string, livePeers []proto.Peer, removedAddr []string) {
+	dp.RLock()
+	defer dp.RUnlock()
+	for _, peer := range dp.Peers {
+		if dataNode, err := c.dataNode(peer.Addr); err == nil && dataNode.isActive {
+			liveHosts = append(liveHosts, peer.Addr)
+			livePeers = append(livePeers, peer)
+			continue
+		}
+		removedAddr = append(removedAddr, peer.Addr)
+	}
+	return
+}
+
+// validateResetPartition checks that the partition has lost the majority of its replicas but not all of them.
+func validateResetPartition(liveHosts []string, replicaNum uint8) (err error) {
+	if len(liveHosts) == 0 {
+		return proto.ErrNoEnoughReplica
+	}
+	if len(liveHosts) > int(replicaNum/2) {
+		return fmt.Errorf("live replicas %v are more than half of replica num[%v], no need to reset", liveHosts, replicaNum)
+	}
+	return
+}
+
+func (c *Cluster) resetDataPartition(dp *DataPartition) (err error) {
+	var (
+		newHosts    []string
+		newPeers    []proto.Peer
+		removedAddr []string
+		dataNode    *DataNode
+		task        *proto.AdminTask
+	)
+	dp.offlineMutex.Lock()
+	defer dp.offlineMutex.Unlock()
+	log.LogWarnf("action[resetDataPartition],volName[%v],partitionID[%v] begin", dp.VolName, dp.PartitionID)
+	newHosts, newPeers, removedAddr = c.getLiveDataPartitionPeers(dp)
+	if err = validateResetPartition(newHosts, dp.ReplicaNum); err != nil {
+		goto errHandler
+	}
+	for _, host := range newHosts {
+		if task, err = dp.createTaskToResetRaftMembers(newPeers, host); err != nil {
+			goto errHandler
+		}
+		if dataNode, err = c.dataNode(host); err != nil {
+			goto errHandler
+		}
+		if _, err = dataNode.TaskManager.syncSendAdminTask(task); err != nil {
+			goto errHandler
+		}
+	}
+	dp.Lock()
+	if err = dp.update("resetDataPartition", dp.VolName, newPeers, newHosts, c); err != nil {
+		dp.Unlock()
+		goto errHandler
+	}
+	for _, addr := range removedAddr {
+		dp.removeReplicaByAddr(addr)
+		dp.checkAndRemoveMissReplica(addr)
+	}
+	dp.Unlock()
+	for _, addr := range removedAddr {
+		c.removeDataPartitionFromDataNode(addr, dp.PartitionID)
+	}
+	Warn(c.Name, fmt.Sprintf("action[resetDataPartition] clusterID[%v] vol[%v] data partition[%v] "+
+		"reset success, removed hosts%v, new hosts%v", c.Name, dp.VolName, dp.PartitionID, removedAddr, newHosts))
+	return
+
+errHandler:
+	log.LogError(fmt.Sprintf("action[resetDataPartition],volName: %v,partitionID: %v,err: %v",
+		dp.VolName, dp.PartitionID, errors.Stack(err)))
+	Warn(c.Name, fmt.Sprintf("clusterID[%v] data partition[%v] reset failed,err:%v",
+		c.Name, dp.PartitionID, err))
+	if err != nil {
+		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", dp.VolName, dp.PartitionID, err)
+	}
+	return
+}
+
+// Remove the partition from the persisted partitions reported by a data node, so that the
+// reset partition will not be counted as corrupt again.
+func (c *Cluster) removeDataPartitionFromDataNode(addr string, partitionID uint64) {
+	dataNode, err := c.dataNode(addr)
+	if err != nil {
+		return
+	}
+	dataNode.Lock()
+	defer dataNode.Unlock()
+	partitions := make([]uint64, 0, len(dataNode.PersistenceDataPartitions))
+	for _, pid := range dataNode.PersistenceDataPartitions {
+		if pid != partitionID {
+			partitions = append(partitions, pid)
+		}
+	}
+	dataNode.PersistenceDataPartitions = partitions
+}
+
 func (c *Cluster) updateDataPartitionOfflinePeerIDWithLock(dp *DataPartition, peerID uint64) (err error) {
 	dp.Lock()
 	defer dp.Unlock()
@@ -1425,6 +1847,23 @@ func (c *Cluster) getBadMetaPartitionsView() (bmpvs []badPartitionView) {
 	return
 }
 
+// getVolBadMetaPartitionsView returns the bad meta partitions of the volume only.
+func (c *Cluster) getVolBadMetaPartitionsView(vol *Vol) (bmpvs []badPartitionView) {
+	bmpvs = make([]badPartitionView, 0)
+	for _, bmpv := range c.getBadMetaPartitionsView() {
+		ids := make([]uint64, 0)
+		for _, id := range bmpv.PartitionIDs {
+			if _, err := vol.metaPartition(id); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) != 0 {
+			bmpvs = append(bmpvs, badPartitionView{Path: bmpv.Path, PartitionIDs: ids})
+		}
+	}
+	return
+}
+
 func (c *Cluster) putBadDataPartitionIDs(replica *DataReplica, addr string, partitionID uint64) {
 	var key string
 	newBadPartitionIDs := make([]uint64, 0)
@@ -1464,6 +1903,7 @@ func (c *Cluster) decommissionMetaNode(metaNode *MetaNode) (err error) {
 	defer func() {
 		metaNode.ToBeOffline = false
 		close(errChannel)
+		c.emitDecommissionFinished(metaNode.Addr, "meta node", err)
 	}()
 	for _, mp := range partitions {
 		wg.Add(1)
@@ -1511,6 +1951,18 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 		oldDescription    string
 		oldDpSelectorName string
 		oldDpSelectorParm string
+		oldPlacement      string
+		oldPlacementZone  string
+		oldLabels         map[string]string
+		oldQos            proto.VolQos
+		oldIPAcl          proto.VolIPAcl
+		oldTrashTTL       uint64
+		oldMetaStore      string
+		oldInodeRetention uint64
+		oldVerifyReadCrc  bool
+		oldCompression    string
+		oldKeys           []*volEncryptionKey
+		encryptionKeys    []*volEncryptionKey
 		volUsedSpace      uint64
 	)
 	if vol, err = c.getVol(name); err != nil {
@@ -1530,11 +1982,6 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 			volUsedSpace/util.GB)
 		goto errHandler
 	}
-	if newArgs.dpReplicaNum > vol.dpReplicaNum {
-		err = fmt.Errorf("don't support new replicaNum[%v] larger than old dpReplicaNum[%v]", newArgs.dpReplicaNum,
-			vol.dpReplicaNum)
-		goto errHandler
-	}
 	if newArgs.enableToken == true && len(vol.tokens) == 0 {
 		if err = c.createToken(vol, proto.ReadOnlyToken); err != nil {
 			goto errHandler
@@ -1554,6 +2001,17 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 			goto errHandler
 		}
 	}
+	if err = c.validatePlacementPolicy(vol, newArgs.placementPolicy, newArgs.placementZone); err != nil {
+		goto errHandler
+	}
+	if proto.FormatLabels(newArgs.placementLabels) != proto.FormatLabels(vol.placementLabels) {
+		if err = c.validatePlacementLabels(vol, newArgs.placementLabels); err != nil {
+			goto errHandler
+		}
+	}
+	if encryptionKeys, err = mergeVolEncryptionKeys(vol.encryptionKeys, newArgs.encryptionKeys); err != nil {
+		goto errHandler
+	}
 
 	oldCapacity = vol.Capacity
 	oldDpReplicaNum = vol.dpReplicaNum
@@ -1564,6 +2022,17 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	oldDescription = vol.description
 	oldDpSelectorName = vol.dpSelectorName
 	oldDpSelectorParm = vol.dpSelectorParm
+	oldPlacement = vol.placementPolicy
+	oldPlacementZone = vol.placementZone
+	oldLabels = vol.placementLabels
+	oldQos = vol.qos
+	oldIPAcl = vol.ipAcl
+	oldTrashTTL = vol.trashTTL
+	oldMetaStore = vol.metaStore
+	oldInodeRetention = vol.inodeRetention
+	oldVerifyReadCrc = vol.verifyReadCrc
+	oldCompression = vol.compression
+	oldKeys = vol.encryptionKeys
 
 	vol.zoneName = newArgs.zoneName
 	vol.Capacity = newArgs.capacity
@@ -1573,12 +2042,26 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 	if newArgs.description != "" {
 		vol.description = newArgs.description
 	}
-	//only reduced replica num is supported
-	if newArgs.dpReplicaNum != 0 && newArgs.dpReplicaNum < vol.dpReplicaNum {
+	// the replicas of the data partitions are added or removed by the scheduler in the background
+	if newArgs.dpReplicaNum != 0 {
 		vol.dpReplicaNum = newArgs.dpReplicaNum
 	}
 	vol.dpSelectorName = newArgs.dpSelectorName
 	vol.dpSelectorParm = newArgs.dpSelectorParm
+	vol.placementPolicy = newArgs.placementPolicy
+	vol.placementZone = newArgs.placementZone
+	vol.placementLabels = newArgs.placementLabels
+	if len(vol.placementLabels) == 0 {
+		vol.placementLabels = nil
+	}
+	vol.qos = newArgs.qos
+	vol.ipAcl = newArgs.ipAcl
+	vol.trashTTL = newArgs.trashTTL
+	vol.metaStore = newArgs.metaStore
+	vol.inodeRetention = newArgs.inodeRetention
+	vol.verifyReadCrc = newArgs.verifyReadCrc
+	vol.compression = newArgs.compression
+	vol.encryptionKeys = encryptionKeys
 
 	if err = c.syncUpdateVol(vol); err != nil {
 		vol.Capacity = oldCapacity
@@ -1590,6 +2073,17 @@ func (c *Cluster) updateVol(name, authKey string, newArgs *VolVarargs) (err erro
 		vol.description = oldDescription
 		vol.dpSelectorName = oldDpSelectorName
 		vol.dpSelectorParm = oldDpSelectorParm
+		vol.placementPolicy = oldPlacement
+		vol.placementZone = oldPlacementZone
+		vol.placementLabels = oldLabels
+		vol.qos = oldQos
+		vol.ipAcl = oldIPAcl
+		vol.trashTTL = oldTrashTTL
+		vol.metaStore = oldMetaStore
+		vol.inodeRetention = oldInodeRetention
+		vol.verifyReadCrc = oldVerifyReadCrc
+		vol.compression = oldCompression
+		vol.encryptionKeys = oldKeys
 
 		log.LogErrorf("action[updateVol] vol[%v] err[%v]", name, err)
 		err = proto.ErrPersistenceByRaft
@@ -1605,7 +2099,7 @@ errHandler:
 
 // Create a new volume.
 // By default we create 3 meta partitions and 10 data partitions during initialization.
-func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken bool) (vol *Vol, err error) {
+func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount, dpReplicaNum, size, capacity int, followerRead, authenticate, crossZone, enableToken, caseInsensitive bool) (vol *Vol, err error) {
 	var (
 		dataPartitionSize       uint64
 		readWriteDataPartitions int
@@ -1629,7 +2123,7 @@ func (c *Cluster) createVol(name, owner, zoneName, description string, mpCount,
 	} else if !crossZone {
 		zoneName = DefaultZoneName
 	}
-	if vol, err = c.doCreateVol(name, owner, zoneName, description, dataPartitionSize, uint64(capacity), dpReplicaNum, followerRead, authenticate, crossZone, enableToken); err != nil {
+	if vol, err = c.doCreateVol(name, owner, zoneName, description, dataPartitionSize, uint64(capacity), dpReplicaNum, followerRead, authenticate, crossZone, enableToken, caseInsensitive); err != nil {
 		goto errHandler
 	}
 	if err = vol.initMetaPartitions(c, mpCount); err != nil {
@@ -1657,7 +2151,7 @@ errHandler:
 	return
 }
 
-func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize, capacity uint64, dpReplicaNum int, followerRead, authenticate, crossZone, enableToken bool) (vol *Vol, err error) {
+func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize, capacity uint64, dpReplicaNum int, followerRead, authenticate, crossZone, enableToken, caseInsensitive bool) (vol *Vol, err error) {
 	var id uint64
 	c.createVolMutex.Lock()
 	defer c.createVolMutex.Unlock()
@@ -1671,6 +2165,7 @@ func (c *Cluster) doCreateVol(name, owner, zoneName, description string, dpSize,
 		goto errHandler
 	}
 	vol = newVol(id, name, owner, zoneName, dpSize, capacity, uint8(dpReplicaNum), defaultReplicaNum, followerRead, authenticate, crossZone, enableToken, createTime, description)
+	vol.caseInsensitive = caseInsensitive
 	// refresh oss secure
 	vol.refreshOSSSecure()
 	if err = c.syncAddVol(vol); err != nil {
@@ -1844,7 +2339,7 @@ func (c *Cluster) allDataNodes() (dataNodes []proto.NodeView) {
 	dataNodes = make([]proto.NodeView, 0)
 	c.dataNodes.Range(func(addr, node interface{}) bool {
 		dataNode := node.(*DataNode)
-		dataNodes = append(dataNodes, proto.NodeView{Addr: dataNode.Addr, Status: dataNode.isActive, ID: dataNode.ID, IsWritable: dataNode.isWriteAble()})
+		dataNodes = append(dataNodes, proto.NodeView{Addr: dataNode.Addr, Status: dataNode.isActive, ID: dataNode.ID, IsWritable: dataNode.isWriteAble(), ZoneName: dataNode.ZoneName, RackName: dataNode.RackName, HealthScore: dataNode.HealthScore})
 		return true
 	})
 	return
@@ -1854,7 +2349,7 @@ func (c *Cluster) allMetaNodes() (metaNodes []proto.NodeView) {
 	metaNodes = make([]proto.NodeView, 0)
 	c.metaNodes.Range(func(addr, node interface{}) bool {
 		metaNode := node.(*MetaNode)
-		metaNodes = append(metaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable()})
+		metaNodes = append(metaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable(), ZoneName: metaNode.ZoneName, RackName: metaNode.RackName, HealthScore: metaNode.HealthScore})
 		return true
 	})
 	return
@@ -1970,6 +2465,68 @@ func (c *Cluster) setMetaNodeDeleteWorkerSleepMs(val uint64) (err error) {
 	return
 }
 
+func (c *Cluster) setAutoReplicaSupplementLimit(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.AutoReplicaSupplementLimit)
+	atomic.StoreUint64(&c.cfg.AutoReplicaSupplementLimit, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setAutoReplicaSupplementLimit] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.AutoReplicaSupplementLimit, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) setVolDeletionDelay(val uint64) (err error) {
+	oldVal := atomic.LoadUint64(&c.cfg.VolDeletionDelay)
+	atomic.StoreUint64(&c.cfg.VolDeletionDelay, val)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setVolDeletionDelay] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.VolDeletionDelay, oldVal)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) setMetaPartitionSplitThreshold(inodeCount, memory uint64) (err error) {
+	oldInodeCount := atomic.LoadUint64(&c.cfg.MetaPartitionSplitInodeCount)
+	oldMemory := atomic.LoadUint64(&c.cfg.MetaPartitionSplitMemory)
+	atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, inodeCount)
+	atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemory, memory)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setMetaPartitionSplitThreshold] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, oldInodeCount)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemory, oldMemory)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) metaPartitionSplitThreshold() (inodeCount, memory uint64) {
+	return atomic.LoadUint64(&c.cfg.MetaPartitionSplitInodeCount), atomic.LoadUint64(&c.cfg.MetaPartitionSplitMemory)
+}
+
+func (c *Cluster) setDataNodeDecommissionControls(limit, bandwidth uint64) (err error) {
+	oldLimit := atomic.LoadUint64(&c.cfg.DataNodeDecommissionLimit)
+	oldBandwidth := atomic.LoadUint64(&c.cfg.DataNodeDecommissionBandwidth)
+	atomic.StoreUint64(&c.cfg.DataNodeDecommissionLimit, limit)
+	atomic.StoreUint64(&c.cfg.DataNodeDecommissionBandwidth, bandwidth)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setDataNodeDecommissionControls] err[%v]", err)
+		atomic.StoreUint64(&c.cfg.DataNodeDecommissionLimit, oldLimit)
+		atomic.StoreUint64(&c.cfg.DataNodeDecommissionBandwidth, oldBandwidth)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
+
+func (c *Cluster) dataNodeDecommissionControls() (limit, bandwidth uint64) {
+	return atomic.LoadUint64(&c.cfg.DataNodeDecommissionLimit), atomic.LoadUint64(&c.cfg.DataNodeDecommissionBandwidth)
+}
+
 func (c *Cluster) setDisableAutoAllocate(disableAutoAllocate bool) (err error) {
 	oldFlag := c.DisableAutoAllocate
 	c.DisableAutoAllocate = disableAutoAllocate
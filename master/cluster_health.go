@@ -0,0 +1,216 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	// the applied indexes are reported by the heartbeats at different time, so a small lag is normal
+	defaultHealthMaxRaftLag        = 10000
+	defaultHealthCapacityThreshold = 0.85
+)
+
+// checkHealth aggregates the inactive nodes, the corrupt and lack-replica partitions, the bad disks,
+// the lagging raft followers and the capacity pressure into a summary of the cluster health.
+func (c *Cluster) checkHealth(maxRaftLag uint64, capacityThreshold float64) (health *proto.ClusterHealth, err error) {
+	var (
+		corruptDps     []*DataPartition
+		lackReplicaDps []*DataPartition
+		corruptMps     []*MetaPartition
+		lackReplicaMps []*MetaPartition
+	)
+	health = &proto.ClusterHealth{
+		CorruptDataPartitionIDs:     make([]uint64, 0),
+		LackReplicaDataPartitionIDs: make([]uint64, 0),
+		CorruptMetaPartitionIDs:     make([]uint64, 0),
+		LackReplicaMetaPartitionIDs: make([]uint64, 0),
+		BadDisks:                    make([]proto.BadDiskView, 0),
+		RetiringDisks:               make([]proto.BadDiskView, 0),
+		FullDataNodes:               make([]string, 0),
+		FullMetaNodes:               make([]string, 0),
+		MaxRaftLag:                  maxRaftLag,
+		CapacityThreshold:           capacityThreshold,
+	}
+	if health.InactiveDataNodes, corruptDps, err = c.checkCorruptDataPartitions(); err != nil {
+		return
+	}
+	if lackReplicaDps, err = c.checkLackReplicaDataPartitions(); err != nil {
+		return
+	}
+	if health.InactiveMetaNodes, corruptMps, err = c.checkCorruptMetaPartitions(); err != nil {
+		return
+	}
+	if lackReplicaMps, err = c.checkLackReplicaMetaPartitions(); err != nil {
+		return
+	}
+	for _, dp := range corruptDps {
+		health.CorruptDataPartitionIDs = append(health.CorruptDataPartitionIDs, dp.PartitionID)
+	}
+	for _, dp := range lackReplicaDps {
+		health.LackReplicaDataPartitionIDs = append(health.LackReplicaDataPartitionIDs, dp.PartitionID)
+	}
+	for _, mp := range corruptMps {
+		health.CorruptMetaPartitionIDs = append(health.CorruptMetaPartitionIDs, mp.PartitionID)
+	}
+	for _, mp := range lackReplicaMps {
+		health.LackReplicaMetaPartitionIDs = append(health.LackReplicaMetaPartitionIDs, mp.PartitionID)
+	}
+	c.checkNodesCapacity(health)
+	health.RaftLagReplicas = c.checkRaftLag(maxRaftLag)
+	health.Issues = summarizeHealthIssues(health)
+	health.Healthy = len(health.Issues) == 0
+	log.LogInfof("action[checkHealth] clusterID[%v] healthy[%v] issues%v", c.Name, health.Healthy, health.Issues)
+	return
+}
+
+func (c *Cluster) checkNodesCapacity(health *proto.ClusterHealth) {
+	var dataTotal, dataUsed, metaTotal, metaUsed uint64
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		dataNode.RLock()
+		defer dataNode.RUnlock()
+		for _, disk := range dataNode.BadDisks {
+			health.BadDisks = append(health.BadDisks, proto.BadDiskView{Addr: dataNode.Addr, Path: disk})
+		}
+		for disk := range dataNode.retiringDisks {
+			health.RetiringDisks = append(health.RetiringDisks, proto.BadDiskView{Addr: dataNode.Addr, Path: disk})
+		}
+		if !dataNode.isActive {
+			return true
+		}
+		dataTotal += dataNode.Total
+		dataUsed += dataNode.Used
+		if dataNode.UsageRatio > health.CapacityThreshold {
+			health.FullDataNodes = append(health.FullDataNodes, dataNode.Addr)
+		}
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		metaNode.RLock()
+		defer metaNode.RUnlock()
+		if !metaNode.IsActive {
+			return true
+		}
+		metaTotal += metaNode.Total
+		metaUsed += metaNode.Used
+		if metaNode.Ratio > health.CapacityThreshold {
+			health.FullMetaNodes = append(health.FullMetaNodes, metaNode.Addr)
+		}
+		return true
+	})
+	if dataTotal != 0 {
+		health.DataUsedRatio = float64(dataUsed) / float64(dataTotal)
+	}
+	if metaTotal != 0 {
+		health.MetaUsedRatio = float64(metaUsed) / float64(metaTotal)
+	}
+	sort.Strings(health.FullDataNodes)
+	sort.Strings(health.FullMetaNodes)
+	sort.Slice(health.BadDisks, func(i, j int) bool {
+		if health.BadDisks[i].Addr != health.BadDisks[j].Addr {
+			return health.BadDisks[i].Addr < health.BadDisks[j].Addr
+		}
+		return health.BadDisks[i].Path < health.BadDisks[j].Path
+	})
+	sort.Slice(health.RetiringDisks, func(i, j int) bool {
+		if health.RetiringDisks[i].Addr != health.RetiringDisks[j].Addr {
+			return health.RetiringDisks[i].Addr < health.RetiringDisks[j].Addr
+		}
+		return health.RetiringDisks[i].Path < health.RetiringDisks[j].Path
+	})
+}
+
+func summarizeHealthIssues(health *proto.ClusterHealth) (issues []string) {
+	issues = make([]string, 0)
+	addIssue := func(count int, format string) {
+		if count != 0 {
+			issues = append(issues, fmt.Sprintf(format, count))
+		}
+	}
+	addIssue(len(health.InactiveDataNodes), "%v inactive data nodes")
+	addIssue(len(health.InactiveMetaNodes), "%v inactive meta nodes")
+	addIssue(len(health.CorruptDataPartitionIDs), "%v corrupt data partitions")
+	addIssue(len(health.LackReplicaDataPartitionIDs), "%v data partitions lack of replicas")
+	addIssue(len(health.CorruptMetaPartitionIDs), "%v corrupt meta partitions")
+	addIssue(len(health.LackReplicaMetaPartitionIDs), "%v meta partitions lack of replicas")
+	addIssue(len(health.BadDisks), "%v bad disks")
+	addIssue(len(health.RetiringDisks), "%v retiring disks")
+	addIssue(len(health.RaftLagReplicas), "%v lagging raft followers")
+	addIssue(len(health.FullDataNodes), "%v data nodes exceed the capacity threshold")
+	addIssue(len(health.FullMetaNodes), "%v meta nodes exceed the capacity threshold")
+	if health.DataUsedRatio > health.CapacityThreshold {
+		issues = append(issues, fmt.Sprintf("data used ratio %.2f exceeds the capacity threshold", health.DataUsedRatio))
+	}
+	if health.MetaUsedRatio > health.CapacityThreshold {
+		issues = append(issues, fmt.Sprintf("meta used ratio %.2f exceeds the capacity threshold", health.MetaUsedRatio))
+	}
+	return
+}
+
+// checkRaftLag finds the live followers whose applied index lags behind the leader more than maxRaftLag.
+// The replicas of the nodes which do not report the applied index are skipped.
+func (c *Cluster) checkRaftLag(maxRaftLag uint64) (lags []proto.RaftLagView) {
+	lags = make([]proto.RaftLagView, 0)
+	for _, vol := range c.copyVols() {
+		for _, dp := range vol.cloneDataPartitionMap() {
+			dp.RLock()
+			var leader *DataReplica
+			for _, replica := range dp.Replicas {
+				if replica.IsLeader {
+					leader = replica
+				}
+			}
+			for _, replica := range dp.Replicas {
+				if leader == nil || replica == leader || replica.ApplyID == 0 ||
+					!replica.isLive(defaultDataPartitionTimeOutSec) || leader.ApplyID <= replica.ApplyID+maxRaftLag {
+					continue
+				}
+				lags = append(lags, proto.RaftLagView{PartitionType: proto.PartitionTypeData, PartitionID: dp.PartitionID,
+					Addr: replica.Addr, LeaderApplyID: leader.ApplyID, ApplyID: replica.ApplyID})
+			}
+			dp.RUnlock()
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			leader, err := mp.getMetaReplicaLeader()
+			for _, replica := range mp.Replicas {
+				if err != nil || replica == leader || replica.ApplyID == 0 ||
+					!replica.isActive() || leader.ApplyID <= replica.ApplyID+maxRaftLag {
+					continue
+				}
+				lags = append(lags, proto.RaftLagView{PartitionType: proto.PartitionTypeMeta, PartitionID: mp.PartitionID,
+					Addr: replica.Addr, LeaderApplyID: leader.ApplyID, ApplyID: replica.ApplyID})
+			}
+			mp.RUnlock()
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool {
+		if lags[i].PartitionType != lags[j].PartitionType {
+			return lags[i].PartitionType < lags[j].PartitionType
+		}
+		if lags[i].PartitionID != lags[j].PartitionID {
+			return lags[i].PartitionID < lags[j].PartitionID
+		}
+		return lags[i].Addr < lags[j].Addr
+	})
+	return
+}
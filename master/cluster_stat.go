@@ -55,6 +55,7 @@ func (c *Cluster) updateStatInfo() {
 	c.updateMetaNodeStatInfo()
 	c.updateVolStatInfo()
 	c.updateZoneStatInfo()
+	c.sampleUsage()
 }
 
 func (c *Cluster) updateZoneStatInfo() {
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -95,14 +96,8 @@ func (c *Cluster) loadDataPartition(dp *DataPartition) {
 // 5. persistent the new host list
 func (c *Cluster) decommissionMetaPartition(nodeAddr string, mp *MetaPartition) (err error) {
 	var (
-		newPeers        []proto.Peer
-		metaNode        *MetaNode
-		zone            *Zone
-		ns              *nodeSet
-		excludeNodeSets []uint64
-		oldHosts        []string
-		zones           []string
-		excludeZone     string
+		newAddr  string
+		oldHosts []string
 	)
 	log.LogWarnf("action[decommissionMetaPartition],volName[%v],nodeAddr[%v],partitionID[%v] begin", mp.volName, nodeAddr, mp.PartitionID)
 	mp.RLock()
@@ -115,14 +110,74 @@ func (c *Cluster) decommissionMetaPartition(nodeAddr string, mp *MetaPartition)
 	if err = c.validateDecommissionMetaPartition(mp, nodeAddr); err != nil {
 		goto errHandler
 	}
-	if metaNode, err = c.metaNode(nodeAddr); err != nil {
+	if newAddr, err = c.chooseMetaPartitionDecommissionTarget(mp, nodeAddr, oldHosts); err != nil {
 		goto errHandler
 	}
-	if zone, err = c.t.getZone(metaNode.ZoneName); err != nil {
+	if err = c.moveMetaReplica(mp, nodeAddr, newAddr); err != nil {
 		goto errHandler
 	}
+	Warn(c.Name, fmt.Sprintf("action[decommissionMetaPartition] clusterID[%v] vol[%v] meta partition[%v] "+
+		"offline addr[%v] success,new addr[%v]", c.Name, mp.volName, mp.PartitionID, nodeAddr, newAddr))
+	return
+
+errHandler:
+	log.LogError(fmt.Sprintf("action[decommissionMetaPartition],volName: %v,partitionID: %v,err: %v",
+		mp.volName, mp.PartitionID, errors.Stack(err)))
+	Warn(c.Name, fmt.Sprintf("clusterID[%v] meta partition[%v] offline addr[%v] failed,err:%v",
+		c.Name, mp.PartitionID, nodeAddr, err))
+	if err != nil {
+		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", mp.volName, mp.PartitionID, err)
+	}
+	return
+}
+
+// moveMetaReplica replaces the replica on the node address with a new replica on the new address, the partition is
+// recovering until the new replica catches up.
+func (c *Cluster) moveMetaReplica(mp *MetaPartition, nodeAddr, newAddr string) (err error) {
+	if err = c.deleteMetaReplica(mp, nodeAddr, false); err != nil {
+		return
+	}
+	if err = c.addMetaReplica(mp, newAddr); err != nil {
+		return
+	}
+	mp.IsRecover = true
+	c.putBadMetaPartitions(nodeAddr, mp.PartitionID)
+	mp.RLock()
+	c.syncUpdateMetaPartition(mp)
+	mp.RUnlock()
+	return
+}
+
+// chooseMetaPartitionDecommissionTarget chooses the meta node for the new replica of the partition decommissioned
+// from the node address. The node set of the node is preferred, then its zone, then the other zones.
+func (c *Cluster) chooseMetaPartitionDecommissionTarget(mp *MetaPartition, nodeAddr string, oldHosts []string) (newAddr string, err error) {
+	var (
+		newPeers        []proto.Peer
+		metaNode        *MetaNode
+		zone            *Zone
+		ns              *nodeSet
+		excludeNodeSets []uint64
+		zones           []string
+		excludeZone     string
+		vol             *Vol
+	)
+	if vol, err = c.getVol(mp.volName); err != nil {
+		return
+	}
+	if vol.usesPlacementPolicy() {
+		if _, newPeers, err = c.choosePlacementHosts(vol, c.metaPlacementNodes(), excludeHost(oldHosts, nodeAddr), oldHosts, 1); err != nil {
+			return
+		}
+		return newPeers[0].Addr, nil
+	}
+	if metaNode, err = c.metaNode(nodeAddr); err != nil {
+		return
+	}
+	if zone, err = c.t.getZone(metaNode.ZoneName); err != nil {
+		return
+	}
 	if ns, err = zone.getNodeSet(metaNode.NodeSetID); err != nil {
-		goto errHandler
+		return
 	}
 	if _, newPeers, err = ns.getAvailMetaNodeHosts(oldHosts, 1); err != nil {
 		// choose a meta node in other node set in the same zone
@@ -136,33 +191,11 @@ func (c *Cluster) decommissionMetaPartition(nodeAddr string, mp *MetaPartition)
 			}
 			// choose a meta node in other zone
 			if _, newPeers, err = c.chooseTargetMetaHosts(excludeZone, excludeNodeSets, oldHosts, 1, false, ""); err != nil {
-				goto errHandler
+				return
 			}
 		}
 	}
-	if err = c.deleteMetaReplica(mp, nodeAddr, false); err != nil {
-		goto errHandler
-	}
-	if err = c.addMetaReplica(mp, newPeers[0].Addr); err != nil {
-		goto errHandler
-	}
-	mp.IsRecover = true
-	c.putBadMetaPartitions(nodeAddr, mp.PartitionID)
-	mp.RLock()
-	c.syncUpdateMetaPartition(mp)
-	mp.RUnlock()
-	Warn(c.Name, fmt.Sprintf("action[decommissionMetaPartition] clusterID[%v] vol[%v] meta partition[%v] "+
-		"offline addr[%v] success,new addr[%v]", c.Name, mp.volName, mp.PartitionID, nodeAddr, newPeers[0].Addr))
-	return
-
-errHandler:
-	log.LogError(fmt.Sprintf("action[decommissionMetaPartition],volName: %v,partitionID: %v,err: %v",
-		mp.volName, mp.PartitionID, errors.Stack(err)))
-	Warn(c.Name, fmt.Sprintf("clusterID[%v] meta partition[%v] offline addr[%v] failed,err:%v",
-		c.Name, mp.PartitionID, nodeAddr, err))
-	if err != nil {
-		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", mp.volName, mp.PartitionID, err)
-	}
+	newAddr = newPeers[0].Addr
 	return
 }
 
@@ -223,6 +256,65 @@ func (c *Cluster) checkCorruptMetaPartitions() (inactiveMetaNodes []string, corr
 	return
 }
 
+// checkVolMetaPartitions checks the meta partitions of the volume only. A partition is corrupt if more than
+// half of its replicas are on the inactive meta nodes, and the inactive nodes hosting the partitions of the
+// volume are returned.
+func (c *Cluster) checkVolMetaPartitions(vol *Vol) (inactiveMetaNodes []string, corruptPartitions,
+	lackReplicaPartitions []*MetaPartition) {
+	inactiveMetaNodes = make([]string, 0)
+	corruptPartitions = make([]*MetaPartition, 0)
+	lackReplicaPartitions = make([]*MetaPartition, 0)
+	inactive := make(map[string]bool)
+	isInactive := func(addr string) bool {
+		if value, ok := inactive[addr]; ok {
+			return value
+		}
+		metaNode, err := c.metaNode(addr)
+		value := err != nil || !metaNode.IsActive
+		if value {
+			inactiveMetaNodes = append(inactiveMetaNodes, addr)
+		}
+		inactive[addr] = value
+		return value
+	}
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.RLock()
+		var badNum uint8
+		for _, host := range mp.Hosts {
+			if isInactive(host) {
+				badNum++
+			}
+		}
+		if badNum > mp.ReplicaNum/2 {
+			corruptPartitions = append(corruptPartitions, mp)
+		}
+		if mp.ReplicaNum > uint8(len(mp.Hosts)) {
+			lackReplicaPartitions = append(lackReplicaPartitions, mp)
+		}
+		mp.RUnlock()
+	}
+	log.LogInfof("clusterID[%v] vol[%v] inactiveMetaNodes:%v corruptPartitions count:[%v] lackReplicaPartitions count:[%v]",
+		c.Name, vol.Name, inactiveMetaNodes, len(corruptPartitions), len(lackReplicaPartitions))
+	return
+}
+
+// checkPeerInconsistentMetaPartitions finds the meta partitions of the volumes whose raft peers are inconsistent
+// with the hosts, either in the metadata of master or as reported by the replicas in the heartbeats.
+func (c *Cluster) checkPeerInconsistentMetaPartitions(vols []*Vol) (partitions []proto.PeerInconsistentPartition) {
+	partitions = make([]proto.PeerInconsistentPartition, 0)
+	for _, vol := range vols {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			if inconsistency := mp.getPeerInconsistency(); inconsistency != nil {
+				partitions = append(partitions, *inconsistency)
+			}
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+	return
+}
+
 // check corrupt partitions related to this meta node
 func (c *Cluster) checkCorruptMetaNode(metaNode *MetaNode) (corruptPartitions []*MetaPartition, err error) {
 	var (
@@ -256,6 +348,81 @@ func (c *Cluster) checkCorruptMetaNode(metaNode *MetaNode) (corruptPartitions []
 	return
 }
 
+// checkRackRiskMetaPartitions finds the meta partitions of the volumes whose majority of replicas are in the
+// same rack, which lose the quorum if the rack fails.
+func (c *Cluster) checkRackRiskMetaPartitions(vols []*Vol) (partitionIDs []uint64) {
+	partitionIDs = make([]uint64, 0)
+	rackOf := func(addr string) string {
+		if metaNode, err := c.metaNode(addr); err == nil && metaNode.GetRack() != "" {
+			return metaNode.ZoneName + "/" + metaNode.GetRack()
+		}
+		return ""
+	}
+	for _, vol := range vols {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			risky := isQuorumInOneRack(mp.Hosts, mp.ReplicaNum, rackOf)
+			mp.RUnlock()
+			if risky {
+				partitionIDs = append(partitionIDs, mp.PartitionID)
+			}
+		}
+	}
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+	return
+}
+
+// diagnoseMetaPartitions diagnoses the meta partitions of the volume, or all the volumes if the volume is nil.
+func (c *Cluster) diagnoseMetaPartitions(vol *Vol) (diagnosis *proto.MetaPartitionDiagnosis, err error) {
+	var (
+		inactiveNodes     []string
+		corruptMps        []*MetaPartition
+		lackReplicaMps    []*MetaPartition
+		badMetaPartitions []badPartitionView
+		inconsistentMps   []proto.PeerInconsistentPartition
+		rackRiskMpIDs     []uint64
+	)
+	if vol != nil {
+		inactiveNodes, corruptMps, lackReplicaMps = c.checkVolMetaPartitions(vol)
+		badMetaPartitions = c.getVolBadMetaPartitionsView(vol)
+		inconsistentMps = c.checkPeerInconsistentMetaPartitions([]*Vol{vol})
+		rackRiskMpIDs = c.checkRackRiskMetaPartitions([]*Vol{vol})
+	} else {
+		if inactiveNodes, corruptMps, err = c.checkCorruptMetaPartitions(); err != nil {
+			return
+		}
+		if lackReplicaMps, err = c.checkLackReplicaMetaPartitions(); err != nil {
+			return
+		}
+		badMetaPartitions = c.getBadMetaPartitionsView()
+		vols := make([]*Vol, 0)
+		for _, vol := range c.copyVols() {
+			vols = append(vols, vol)
+		}
+		inconsistentMps = c.checkPeerInconsistentMetaPartitions(vols)
+		rackRiskMpIDs = c.checkRackRiskMetaPartitions(vols)
+	}
+	corruptMpIDs := make([]uint64, 0)
+	for _, mp := range corruptMps {
+		corruptMpIDs = append(corruptMpIDs, mp.PartitionID)
+	}
+	lackReplicaMpIDs := make([]uint64, 0)
+	for _, mp := range lackReplicaMps {
+		lackReplicaMpIDs = append(lackReplicaMpIDs, mp.PartitionID)
+	}
+	diagnosis = &proto.MetaPartitionDiagnosis{
+		InactiveMetaNodes:           inactiveNodes,
+		CorruptMetaPartitionIDs:     corruptMpIDs,
+		LackReplicaMetaPartitionIDs: lackReplicaMpIDs,
+		BadMetaPartitionIDs:         badMetaPartitions,
+		PeerInconsistentPartitions:  inconsistentMps,
+		RackRiskMetaPartitionIDs:    rackRiskMpIDs,
+	}
+	log.LogInfof("diagnose metaPartition[%v] inactiveNodes:[%v], corruptMpIDs:[%v], lackReplicaMpIDs:[%v]",
+		c.Name, inactiveNodes, corruptMpIDs, lackReplicaMpIDs)
+	return
+}
+
 func (c *Cluster) checkLackReplicaMetaPartitions() (lackReplicaMetaPartitions []*MetaPartition, err error) {
 	lackReplicaMetaPartitions = make([]*MetaPartition, 0)
 	vols := c.copyVols()
@@ -399,7 +566,8 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 	if err != nil {
 		return
 	}
-	addPeer := proto.Peer{ID: metaNode.ID, Addr: addr}
+	// the new replica does not vote until it catches up and is promoted by scheduleToPromoteMetaLearners
+	addPeer := proto.Peer{ID: metaNode.ID, Addr: addr, IsLearner: true}
 	if err = c.addMetaPartitionRaftMember(partition, addPeer); err != nil {
 		return
 	}
@@ -420,7 +588,8 @@ func (c *Cluster) addMetaReplica(partition *MetaPartition, addr string) (err err
 }
 
 func (c *Cluster) createMetaReplica(partition *MetaPartition, addPeer proto.Peer) (err error) {
-	task, err := partition.createTaskToCreateReplica(addPeer.Addr)
+	task, err := partition.createTaskToCreateReplica(addPeer.Addr, c.volMetaStore(partition.volName),
+		c.volCaseInsensitive(partition.volName))
 	if err != nil {
 		return
 	}
@@ -495,6 +664,100 @@ func (c *Cluster) addMetaPartitionRaftMember(partition *MetaPartition, addPeer p
 	return
 }
 
+// Reset the raft members of a corrupt meta partition to the replicas on the active meta nodes.
+// This is the only way to recover the partition whose majority of replicas are on the corrupt
+// meta nodes, however the data which has not been synchronized to the remaining replicas will be lost.
+// 1. check that the meta partition has lost the majority of replicas
+// 2. synchronized reset the raft members on each remaining replica
+// 3. persistent the new host list
+// 4. the replicas lacked will be added back by "metapartition add-replica"
+// getLiveMetaPartitionPeers splits the peers of the partition by whether they are on the active meta nodes.
+func (c *Cluster) getLiveMetaPartitionPeers(mp *MetaPartition) (liveHosts []string, livePeers []proto.Peer, removedAddr []string) {
+	mp.RLock()
+	defer mp.RUnlock()
+	for _, peer := range mp.Peers {
+		if metaNode, err := c.metaNode(peer.Addr); err == nil && metaNode.IsActive {
+			liveHosts = append(liveHosts, peer.Addr)
+			livePeers = append(livePeers, peer)
+			continue
+		}
+		removedAddr = append(removedAddr, peer.Addr)
+	}
+	return
+}
+
+func (c *Cluster) resetMetaPartition(mp *MetaPartition) (err error) {
+	var (
+		newHosts    []string
+		newPeers    []proto.Peer
+		removedAddr []string
+		metaNode    *MetaNode
+		task        *proto.AdminTask
+	)
+	mp.offlineMutex.Lock()
+	defer mp.offlineMutex.Unlock()
+	log.LogWarnf("action[resetMetaPartition],volName[%v],partitionID[%v] begin", mp.volName, mp.PartitionID)
+	newHosts, newPeers, removedAddr = c.getLiveMetaPartitionPeers(mp)
+	if err = validateResetPartition(newHosts, mp.ReplicaNum); err != nil {
+		goto errHandler
+	}
+	for _, host := range newHosts {
+		if task, err = mp.createTaskToResetRaftMembers(newPeers, host); err != nil {
+			goto errHandler
+		}
+		if metaNode, err = c.metaNode(host); err != nil {
+			goto errHandler
+		}
+		if _, err = metaNode.Sender.syncSendAdminTask(task); err != nil {
+			goto errHandler
+		}
+	}
+	mp.Lock()
+	if err = mp.persistToRocksDB("resetMetaPartition", mp.volName, newHosts, newPeers, c); err != nil {
+		mp.Unlock()
+		goto errHandler
+	}
+	for _, addr := range removedAddr {
+		mp.removeReplicaByAddr(addr)
+		mp.removeMissingReplica(addr)
+	}
+	mp.Unlock()
+	for _, addr := range removedAddr {
+		c.removeMetaPartitionFromMetaNode(addr, mp.PartitionID)
+	}
+	Warn(c.Name, fmt.Sprintf("action[resetMetaPartition] clusterID[%v] vol[%v] meta partition[%v] "+
+		"reset success, removed hosts%v, new hosts%v", c.Name, mp.volName, mp.PartitionID, removedAddr, newHosts))
+	return
+
+errHandler:
+	log.LogError(fmt.Sprintf("action[resetMetaPartition],volName: %v,partitionID: %v,err: %v",
+		mp.volName, mp.PartitionID, errors.Stack(err)))
+	Warn(c.Name, fmt.Sprintf("clusterID[%v] meta partition[%v] reset failed,err:%v",
+		c.Name, mp.PartitionID, err))
+	if err != nil {
+		err = fmt.Errorf("vol[%v],partition[%v],err[%v]", mp.volName, mp.PartitionID, err)
+	}
+	return
+}
+
+// Remove the partition from the persisted partitions reported by a meta node, so that the
+// reset partition will not be counted as corrupt again.
+func (c *Cluster) removeMetaPartitionFromMetaNode(addr string, partitionID uint64) {
+	metaNode, err := c.metaNode(addr)
+	if err != nil {
+		return
+	}
+	metaNode.Lock()
+	defer metaNode.Unlock()
+	partitions := make([]uint64, 0, len(metaNode.PersistenceMetaPartitions))
+	for _, pid := range metaNode.PersistenceMetaPartitions {
+		if pid != partitionID {
+			partitions = append(partitions, pid)
+		}
+	}
+	metaNode.PersistenceMetaPartitions = partitions
+}
+
 func (c *Cluster) loadMetaPartitionAndCheckResponse(mp *MetaPartition) {
 	go func() {
 		c.doLoadMetaPartition(mp)
@@ -593,6 +856,7 @@ func (c *Cluster) handleMetaNodeTaskResponse(nodeAddr string, task *proto.AdminT
 	switch task.OpCode {
 	case proto.OpMetaNodeHeartbeat:
 		response := task.Response.(*proto.MetaNodeHeartbeatResponse)
+		metaNode.updateHeartbeatLatency(task.SendTime)
 		err = c.dealMetaNodeHeartbeatResp(task.OperatorAddr, response)
 	case proto.OpDeleteMetaPartition:
 		response := task.Response.(*proto.DeleteMetaPartitionResponse)
@@ -766,6 +1030,7 @@ func (c *Cluster) handleDataNodeTaskResponse(nodeAddr string, task *proto.AdminT
 		err = c.handleResponseToLoadDataPartition(task.OperatorAddr, response)
 	case proto.OpDataNodeHeartbeat:
 		response := task.Response.(*proto.DataNodeHeartbeatResponse)
+		dataNode.updateHeartbeatLatency(task.SendTime)
 		err = c.handleDataNodeHeartbeatResp(task.OperatorAddr, response)
 	default:
 		err = fmt.Errorf(fmt.Sprintf("unknown operate code %v", task.OpCode))
@@ -860,7 +1125,10 @@ func (c *Cluster) handleDataNodeHeartbeatResp(nodeAddr string, resp *proto.DataN
 		log.LogWarnf("dataNode zone changed from [%v] to [%v]", oldZoneName, resp.ZoneName)
 	}
 
-	dataNode.updateNodeMetric(resp)
+	for _, disk := range dataNode.updateNodeMetric(resp) {
+		c.events.emit(proto.EventDiskError, dataNode.Addr, "", 0, fmt.Sprintf("disk[%v] is bad", disk))
+	}
+	c.checkDiskSmarts(dataNode, resp.DiskSmarts)
 
 	if err = c.t.putDataNode(dataNode); err != nil {
 		log.LogErrorf("action[handleDataNodeHeartbeatResp] dataNode[%v],zone[%v],node set[%v], err[%v]", dataNode.Addr, dataNode.ZoneName, dataNode.NodeSetID, err)
@@ -20,8 +20,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
-	"github.com/tiglabs/raft/proto"
 )
 
 //config key
@@ -38,6 +38,33 @@ const (
 	cfgMetaNodeReservedMem              = "metaNodeReservedMem"
 	heartbeatPortKey                    = "heartbeatPort"
 	replicaPortKey                      = "replicaPort"
+	cfgEventWebhooks                    = "eventWebhooks"
+	cfgEventKafkaProxy                  = "eventKafkaProxy"
+	cfgEventKafkaTopic                  = "eventKafkaTopic"
+	cfgAuditRetentionDays               = "auditRetentionDays"
+	cfgRBACEnable                       = "rbacEnable"
+	cfgRBACTokens                       = "rbacTokens"
+	cfgRBACAnonymousRole                = "rbacAnonymousRole"
+	cfgMetadataBackupEndpoint           = "metadataBackupEndpoint"
+	cfgMetadataBackupRegion             = "metadataBackupRegion"
+	cfgMetadataBackupBucket             = "metadataBackupBucket"
+	cfgMetadataBackupPrefix             = "metadataBackupPrefix"
+	cfgMetadataBackupAccessKey          = "metadataBackupAccessKey"
+	cfgMetadataBackupSecretKey          = "metadataBackupSecretKey"
+	cfgMetadataBackupInterval           = "metadataBackupInterval"
+	cfgMetadataBackupRetention          = "metadataBackupRetention"
+	cfgStalePartitionGracePeriod        = "stalePartitionGracePeriod"
+	cfgReplicaNumChangeLimit            = "replicaNumChangeLimit"
+	cfgRegion                           = "region"
+	cfgFederationAuthToken              = "federationAuthToken"
+	cfgConsistencyCheckInterval         = "consistencyCheckInterval"
+	cfgConsistencyCheckSampleSize       = "consistencyCheckSampleSize"
+	cfgVolKeyManager                    = "volKeyManager"
+	cfgVaultAddr                        = "vaultAddr"
+	cfgVaultToken                       = "vaultToken"
+	cfgVaultKeyName                     = "vaultKeyName"
+	cfgDiskRetireSectorThreshold        = "diskRetireSectorThreshold"
+	cfgDiskRetireMigrate                = "diskRetireMigrate"
 )
 
 //default value
@@ -65,6 +92,9 @@ const (
 	defaultMaxMetaPartitionCountOnEachNode             = 10000
 	defaultReplicaNum                                  = 3
 	defaultDiffSpaceUsage                              = 1024 * 1024 * 1024
+	defaultAuditRetentionDays                          = 90
+	defaultMetadataBackupRetention                     = 7
+	defaultReplicaNumChangeLimit                       = 10
 )
 
 // AddrDatabase is a map that stores the address of a given host (e.g., the leader)
@@ -87,11 +117,39 @@ type clusterConfig struct {
 	DataNodeDeleteLimitRate             uint64 //datanode delete limit rate
 	MetaNodeDeleteWorkerSleepMs         uint64 //datanode delete limit rate
 	DataNodeAutoRepairLimitRate         uint64 //datanode autorepair limit rate
+	AutoReplicaSupplementLimit          uint64 //max partitions recovering from automatic replica supplement, 0 to disable
+	VolDeletionDelay                    uint64 //seconds to keep the deleted volumes recoverable, 0 to delete immediately
+	MetaPartitionSplitInodeCount        uint64 //inodes of a meta partition to trigger the split, 0 for no limit
+	MetaPartitionSplitMemory            uint64 //estimated memory of a meta partition to trigger the split, 0 for no limit
+	DataNodeDecommissionLimit           uint64 //max partitions migrating from a decommissioned data node, 0 for no limit
+	DataNodeDecommissionBandwidth       uint64 //bytes per second to repair a migrated data partition, 0 for no limit
 	peers                               []raftstore.PeerAddress
 	peerAddrs                           []string
 	heartbeatPort                       int64
 	replicaPort                         int64
 	diffSpaceUsage                      uint64
+	eventWebhooks                       []string // urls to post the events to
+	eventKafkaProxy                     string   // url of the kafka REST proxy to produce the events by
+	eventKafkaTopic                     string
+	auditRetentionDays                  int64 // days to keep the audit records of the admin calls
+	rbacEnabled                         bool  // check the roles of the callers of the admin APIs
+	rbacTokens                          map[string]*rbacIdentity
+	rbacAnonymousRole                   string // the role of the callers without the credentials, empty to deny them
+	metadataBackup                      metadataBackupConfig
+	metadataBackupInterval              int64 // seconds between the scheduled backups of the metadata, 0 to disable
+	metadataBackupRetention             int64 // number of the latest backups to keep in the object store
+	stalePartitionGracePeriod           int64 // seconds a replica of a purged volume is found before it is deleted, 0 to keep them
+	replicaNumChangeLimit               int64 // max data partitions adding or removing the replicas to change the replica number
+	region                              string
+	federationAuthToken                 string // the token to get the views of the peer clusters from their masters
+	consistencyCheckInterval            int64  // seconds between the rounds of the consistency checker, 0 to disable
+	consistencyCheckSampleSize          int    // max partitions of each type checked against the replicas by a round
+	volKeyManager                       string // the key manager generating the data keys of the encrypted volumes
+	vaultAddr                           string // the address of vault for the key manager "vault"
+	vaultToken                          string
+	vaultKeyName                        string // the name of the transit key wrapping the data keys
+	diskRetireSectorThreshold           uint64 // bad sectors of a disk to retire it, 0 to ignore the sectors
+	diskRetireMigrate                   bool   // migrate the data partitions off the retiring disks
 }
 
 func newClusterConfig() (cfg *clusterConfig) {
@@ -108,6 +166,14 @@ func newClusterConfig() (cfg *clusterConfig) {
 	cfg.MetaNodeThreshold = defaultMetaPartitionMemUsageThreshold
 	cfg.metaNodeReservedMem = defaultMetaNodeReservedMem
 	cfg.diffSpaceUsage = defaultDiffSpaceUsage
+	cfg.auditRetentionDays = defaultAuditRetentionDays
+	cfg.metadataBackupRetention = defaultMetadataBackupRetention
+	cfg.replicaNumChangeLimit = defaultReplicaNumChangeLimit
+	cfg.consistencyCheckInterval = defaultConsistencyCheckInterval
+	cfg.consistencyCheckSampleSize = defaultConsistencyCheckSampleSize
+	cfg.volKeyManager = volKeyManagerAuthnode
+	cfg.diskRetireSectorThreshold = defaultDiskRetireSectorThreshold
+	cfg.diskRetireMigrate = true
 	return
 }
 
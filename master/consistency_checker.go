@@ -0,0 +1,377 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The consistency checker diagnoses the meta partitions by rounds, and checks a sample of the partitions against
+// the replicas by each round with the states reported by the nodes, which are the applied index and the counts of
+// the meta replicas and the CRC of the extents loaded from the data replicas. The sample of a round follows the
+// one of the last round, so all the partitions are checked in turn. The findings are cached on the leader, and
+// replied at once instead of diagnosing the cluster by the callers.
+
+const (
+	defaultConsistencyCheckInterval   = 10 * 60 // seconds
+	defaultConsistencyCheckSampleSize = 1000
+	consistencyCheckMaxApplyLag       = 1000 // max applied index lag of the meta replicas behind the leader
+)
+
+type consistencyChecker struct {
+	sync.RWMutex
+	rounds     uint64
+	checkTime  int64
+	checkedMps int
+	checkedDps int
+	diagnosis  *proto.MetaPartitionDiagnosis
+	mpCursor   uint64 // the ID of the last meta partition checked against the replicas
+	dpCursor   uint64
+	mpFindings map[uint64][]proto.ReplicaInconsistency // keyed by the ID of the partition
+	dpFindings map[uint64][]proto.ReplicaInconsistency
+}
+
+func newConsistencyChecker() *consistencyChecker {
+	return &consistencyChecker{
+		mpFindings: make(map[uint64][]proto.ReplicaInconsistency),
+		dpFindings: make(map[uint64][]proto.ReplicaInconsistency),
+	}
+}
+
+// reset forgets the findings, the partitions are checked from the beginning when the master becomes the leader again.
+func (cc *consistencyChecker) reset() {
+	cc.Lock()
+	defer cc.Unlock()
+	cc.rounds, cc.checkTime, cc.checkedMps, cc.checkedDps = 0, 0, 0, 0
+	cc.diagnosis = nil
+	cc.mpCursor, cc.dpCursor = 0, 0
+	cc.mpFindings = make(map[uint64][]proto.ReplicaInconsistency)
+	cc.dpFindings = make(map[uint64][]proto.ReplicaInconsistency)
+}
+
+func (cc *consistencyChecker) cursors() (mpCursor, dpCursor uint64) {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.mpCursor, cc.dpCursor
+}
+
+// samplePartitionIDs returns at most size IDs following the cursor in the sorted IDs, which wraps around to the
+// beginning, and the cursor of the next round.
+func samplePartitionIDs(ids []uint64, cursor uint64, size int) (sampled []uint64, next uint64) {
+	if len(ids) == 0 || size <= 0 {
+		return nil, cursor
+	}
+	if size > len(ids) {
+		size = len(ids)
+	}
+	start := sort.Search(len(ids), func(i int) bool { return ids[i] > cursor })
+	sampled = make([]uint64, 0, size)
+	for i := 0; i < size; i++ {
+		sampled = append(sampled, ids[(start+i)%len(ids)])
+	}
+	return sampled, sampled[len(sampled)-1]
+}
+
+// updateFindings replaces the findings of the checked partitions, and drops the ones of the partitions which do
+// not exist any more. It returns the partitions found inconsistent which were not before.
+func updateFindings(findings map[uint64][]proto.ReplicaInconsistency, checked map[uint64][]proto.ReplicaInconsistency,
+	exists func(id uint64) bool) (newlyFound []uint64) {
+	for id := range findings {
+		if !exists(id) {
+			delete(findings, id)
+		}
+	}
+	for id, found := range checked {
+		if len(found) == 0 {
+			delete(findings, id)
+			continue
+		}
+		if _, ok := findings[id]; !ok {
+			newlyFound = append(newlyFound, id)
+		}
+		findings[id] = found
+	}
+	sort.Slice(newlyFound, func(i, j int) bool { return newlyFound[i] < newlyFound[j] })
+	return
+}
+
+func (cc *consistencyChecker) update(diagnosis *proto.MetaPartitionDiagnosis, mpCursor, dpCursor uint64,
+	checkedMps, checkedDps map[uint64][]proto.ReplicaInconsistency, mpExists, dpExists func(id uint64) bool,
+	now int64) (newMps, newDps []uint64) {
+	cc.Lock()
+	defer cc.Unlock()
+	cc.rounds++
+	cc.checkTime = now
+	cc.diagnosis = diagnosis
+	cc.mpCursor, cc.dpCursor = mpCursor, dpCursor
+	cc.checkedMps, cc.checkedDps = len(checkedMps), len(checkedDps)
+	newMps = updateFindings(cc.mpFindings, checkedMps, mpExists)
+	newDps = updateFindings(cc.dpFindings, checkedDps, dpExists)
+	return
+}
+
+func sortedFindings(findings map[uint64][]proto.ReplicaInconsistency) (sorted []proto.ReplicaInconsistency) {
+	sorted = make([]proto.ReplicaInconsistency, 0, len(findings))
+	for _, found := range findings {
+		sorted = append(sorted, found...)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].PartitionID != sorted[j].PartitionID {
+			return sorted[i].PartitionID < sorted[j].PartitionID
+		}
+		return sorted[i].Addr < sorted[j].Addr
+	})
+	return
+}
+
+func (cc *consistencyChecker) report(interval int64, sampleSize int) (report *proto.ConsistencyReport) {
+	cc.RLock()
+	defer cc.RUnlock()
+	return &proto.ConsistencyReport{
+		Interval:                   interval,
+		SampleSize:                 sampleSize,
+		Rounds:                     cc.rounds,
+		CheckTime:                  cc.checkTime,
+		CheckedMetaPartitions:      cc.checkedMps,
+		CheckedDataPartitions:      cc.checkedDps,
+		MetaPartitionDiagnosis:     cc.diagnosis,
+		MetaReplicaInconsistencies: sortedFindings(cc.mpFindings),
+		DataReplicaInconsistencies: sortedFindings(cc.dpFindings),
+	}
+}
+
+func (c *Cluster) scheduleToCheckConsistency() {
+	go func() {
+		for {
+			interval := c.cfg.consistencyCheckInterval
+			if interval > 0 && c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkConsistency()
+			} else {
+				c.consistency.reset()
+			}
+			if interval <= 0 {
+				interval = defaultConsistencyCheckInterval
+			}
+			time.Sleep(time.Duration(interval) * time.Second)
+		}
+	}()
+}
+
+// checkConsistency runs a round of the consistency checker.
+func (c *Cluster) checkConsistency() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkConsistency occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkConsistency occurred panic")
+		}
+	}()
+	diagnosis, err := c.diagnoseMetaPartitions(nil)
+	if err != nil {
+		log.LogWarnf("action[checkConsistency] diagnose meta partitions err[%v]", err)
+		return
+	}
+	mps := make(map[uint64]*MetaPartition)
+	dps := make(map[uint64]*DataPartition)
+	for _, vol := range c.copyVols() {
+		for id, mp := range vol.cloneMetaPartitionMap() {
+			mps[id] = mp
+		}
+		for id, dp := range vol.cloneDataPartitionMap() {
+			dps[id] = dp
+		}
+	}
+	mpIDs := make([]uint64, 0, len(mps))
+	for id := range mps {
+		mpIDs = append(mpIDs, id)
+	}
+	sort.Slice(mpIDs, func(i, j int) bool { return mpIDs[i] < mpIDs[j] })
+	dpIDs := make([]uint64, 0, len(dps))
+	for id := range dps {
+		dpIDs = append(dpIDs, id)
+	}
+	sort.Slice(dpIDs, func(i, j int) bool { return dpIDs[i] < dpIDs[j] })
+	now := time.Now().Unix()
+	mpCursor, dpCursor := c.consistency.cursors()
+	sampledMps, mpCursor := samplePartitionIDs(mpIDs, mpCursor, c.cfg.consistencyCheckSampleSize)
+	sampledDps, dpCursor := samplePartitionIDs(dpIDs, dpCursor, c.cfg.consistencyCheckSampleSize)
+	checkedMps := make(map[uint64][]proto.ReplicaInconsistency, len(sampledMps))
+	for _, id := range sampledMps {
+		checkedMps[id] = mps[id].checkReplicaConsistency(consistencyCheckMaxApplyLag, now)
+	}
+	checkedDps := make(map[uint64][]proto.ReplicaInconsistency, len(sampledDps))
+	for _, id := range sampledDps {
+		checkedDps[id] = dps[id].checkReplicaConsistency(c.cfg.DataPartitionTimeOutSec, now)
+	}
+	newMps, newDps := c.consistency.update(diagnosis, mpCursor, dpCursor, checkedMps, checkedDps,
+		func(id uint64) bool { return mps[id] != nil }, func(id uint64) bool { return dps[id] != nil }, now)
+	for _, id := range newMps {
+		c.reportReplicaInconsistency(proto.PartitionTypeMeta, checkedMps[id])
+	}
+	for _, id := range newDps {
+		c.reportReplicaInconsistency(proto.PartitionTypeData, checkedDps[id])
+	}
+	log.LogInfof("action[checkConsistency] checked meta partitions[%v/%v] data partitions[%v/%v] newly inconsistent[%v/%v]",
+		len(sampledMps), len(mpIDs), len(sampledDps), len(dpIDs), len(newMps), len(newDps))
+}
+
+func (c *Cluster) reportReplicaInconsistency(partitionType string, found []proto.ReplicaInconsistency) {
+	for _, inconsistency := range found {
+		msg := fmt.Sprintf("%v partition replica[%v] is inconsistent: %v", partitionType, inconsistency.Addr, inconsistency.Issue)
+		Warn(c.Name, fmt.Sprintf("vol[%v] partition[%v] %v", inconsistency.VolName, inconsistency.PartitionID, msg))
+		c.events.emit(proto.EventReplicaInconsistent, inconsistency.Addr, inconsistency.VolName, inconsistency.PartitionID, msg)
+	}
+}
+
+// checkReplicaConsistency compares the replicas reported within the timeout with the leader. The followers whose
+// applied index lags behind the leader more than the max lag, the replicas whose counts differ from another
+// replica at the same applied index, and the replicas whose checksums differ from the leader by the latest replica
+// check of the leader are inconsistent.
+func (mp *MetaPartition) checkReplicaConsistency(maxApplyLag uint64, now int64) (found []proto.ReplicaInconsistency) {
+	mp.RLock()
+	defer mp.RUnlock()
+	var (
+		leader    *MetaReplica
+		reported  = make([]*MetaReplica, 0, len(mp.Replicas))
+		byApplyID = make(map[uint64]*MetaReplica)
+	)
+	for _, mr := range mp.Replicas {
+		if mr.isMissing() {
+			continue
+		}
+		reported = append(reported, mr)
+		if mr.IsLeader {
+			leader = mr
+		}
+	}
+	newInconsistency := func(addr, issue string) proto.ReplicaInconsistency {
+		return proto.ReplicaInconsistency{PartitionID: mp.PartitionID, VolName: mp.volName, Addr: addr, Issue: issue, FoundTime: now}
+	}
+	for _, mr := range reported {
+		if leader != nil && leader.ApplyID > mr.ApplyID && leader.ApplyID-mr.ApplyID > maxApplyLag {
+			found = append(found, newInconsistency(mr.Addr, fmt.Sprintf("applied index lags %v behind the leader", leader.ApplyID-mr.ApplyID)))
+		}
+		base, ok := byApplyID[mr.ApplyID]
+		if !ok {
+			byApplyID[mr.ApplyID] = mr
+			continue
+		}
+		if base.InodeCount != mr.InodeCount || base.DentryCount != mr.DentryCount || base.MaxInodeID != mr.MaxInodeID {
+			found = append(found, newInconsistency(mr.Addr, fmt.Sprintf("inode count[%v] dentry count[%v] max inode[%v] differ from replica[%v] at the same applied index",
+				mr.InodeCount, mr.DentryCount, mr.MaxInodeID, base.Addr)))
+		}
+	}
+	if mp.replicaCheck != nil {
+		found = append(found, replicaCheckInconsistencies(mp.replicaCheck, newInconsistency)...)
+	}
+	return
+}
+
+// replicaCheckInconsistencies returns an inconsistency for each replica diverging from the leader by the replica
+// check, with the first diverging range.
+func replicaCheckInconsistencies(check *proto.ReplicaCheckReport,
+	newInconsistency func(addr, issue string) proto.ReplicaInconsistency) (found []proto.ReplicaInconsistency) {
+	diverged := make(map[string][]*proto.ReplicaDivergence)
+	addrs := make([]string, 0)
+	for _, divergence := range check.Divergences {
+		if _, ok := diverged[divergence.Addr]; !ok {
+			addrs = append(addrs, divergence.Addr)
+		}
+		diverged[divergence.Addr] = append(diverged[divergence.Addr], divergence)
+	}
+	for _, addr := range addrs {
+		first := diverged[addr][0]
+		found = append(found, newInconsistency(addr, fmt.Sprintf("%v ranges differ from the leader at raft index[%v], e.g. inodes [%v, %v): %v",
+			len(diverged[addr]), check.CheckID, first.Start, first.End, first.Issue)))
+	}
+	return
+}
+
+// checkReplicaConsistency compares the CRC of the extents loaded from the live replicas. The replica whose CRC of
+// an extent differs from the majority is inconsistent, and the extent is reported without the replica if no CRC
+// is agreed by the majority. The extents modified recently are skipped since the replicas may be syncing. The
+// replica reporting the corrupt extents found by the scrubber of the data node is inconsistent too.
+func (partition *DataPartition) checkReplicaConsistency(timeOutSec int64, now int64) (found []proto.ReplicaInconsistency) {
+	partition.RLock()
+	defer partition.RUnlock()
+	liveReplicas := partition.liveReplicas(timeOutSec)
+	if len(liveReplicas) == 0 {
+		return
+	}
+	badExtents := make(map[string][]uint64) // the extents of the replicas with a CRC differing from the majority
+	noMajority := make([]uint64, 0)
+	for _, fc := range partition.FileInCoreMap {
+		extentID, err := strconv.ParseUint(fc.Name, 10, 64)
+		if err != nil || !fc.shouldCheckCrc() {
+			continue
+		}
+		fms, needRepair := fc.needCrcRepair(liveReplicas)
+		if !needRepair {
+			continue
+		}
+		fileCrcArr := fc.calculateCrc(fms)
+		sort.Sort((fileCrcSorter)(fileCrcArr))
+		majority := fileCrcArr[len(fileCrcArr)-1]
+		if majority.count*2 <= len(fms) {
+			noMajority = append(noMajority, extentID)
+			continue
+		}
+		for _, fm := range fms {
+			if fm.getFileCrc() != majority.crc {
+				badExtents[fm.getLocationAddr()] = append(badExtents[fm.getLocationAddr()], extentID)
+			}
+		}
+	}
+	newInconsistency := func(addr string, extents []uint64, issue string) proto.ReplicaInconsistency {
+		sort.Slice(extents, func(i, j int) bool { return extents[i] < extents[j] })
+		return proto.ReplicaInconsistency{PartitionID: partition.PartitionID, VolName: partition.VolName, Addr: addr,
+			Issue: fmt.Sprintf("%v extents %v", issue, formatExtentIDs(extents)), FoundTime: now}
+	}
+	for addr, extents := range badExtents {
+		found = append(found, newInconsistency(addr, extents, "crc differs from the majority of the replicas on"))
+	}
+	for _, replica := range liveReplicas {
+		if len(replica.CorruptExtents) > 0 {
+			found = append(found, newInconsistency(replica.Addr, append([]uint64(nil), replica.CorruptExtents...),
+				"corrupt blocks found by the scrubber are not repaired on"))
+		}
+	}
+	if len(noMajority) > 0 {
+		found = append(found, newInconsistency("", noMajority, "crc is not agreed by the majority of the replicas on"))
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Addr < found[j].Addr })
+	return
+}
+
+// formatExtentIDs formats the first extent IDs and the count of the rest.
+func formatExtentIDs(extents []uint64) string {
+	const maxShown = 10
+	if len(extents) <= maxShown {
+		return fmt.Sprintf("%v", extents)
+	}
+	return fmt.Sprintf("%v and %v more", extents[:maxShown], len(extents)-maxShown)
+}
+
+// getConsistencyReport returns the findings of the consistency checker.
+func (c *Cluster) getConsistencyReport() *proto.ConsistencyReport {
+	return c.consistency.report(c.cfg.consistencyCheckInterval, c.cfg.consistencyCheckSampleSize)
+}
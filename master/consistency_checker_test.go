@@ -0,0 +1,117 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestSamplePartitionIDs(t *testing.T) {
+	ids := []uint64{1, 2, 3, 5, 8}
+	sampled, next := samplePartitionIDs(ids, 0, 2)
+	if !reflect.DeepEqual(sampled, []uint64{1, 2}) || next != 2 {
+		t.Errorf("expect [1 2] and cursor 2, but got %v and %v", sampled, next)
+	}
+	sampled, next = samplePartitionIDs(ids, 4, 3)
+	if !reflect.DeepEqual(sampled, []uint64{5, 8, 1}) || next != 1 {
+		t.Errorf("expect the sample wrapped around, but got %v and %v", sampled, next)
+	}
+	sampled, next = samplePartitionIDs(ids, 8, 10)
+	if !reflect.DeepEqual(sampled, ids) || next != 8 {
+		t.Errorf("expect all the partitions sampled once, but got %v and %v", sampled, next)
+	}
+	if sampled, next = samplePartitionIDs(nil, 3, 10); len(sampled) != 0 || next != 3 {
+		t.Errorf("expect nothing sampled and the cursor kept, but got %v and %v", sampled, next)
+	}
+}
+
+func TestUpdateFindings(t *testing.T) {
+	findings := map[uint64][]proto.ReplicaInconsistency{
+		1: {{PartitionID: 1, Addr: "a"}},
+		2: {{PartitionID: 2, Addr: "b"}},
+		3: {{PartitionID: 3, Addr: "c"}},
+	}
+	checked := map[uint64][]proto.ReplicaInconsistency{
+		1: nil,
+		2: {{PartitionID: 2, Addr: "c"}},
+		4: {{PartitionID: 4, Addr: "a"}},
+	}
+	newlyFound := updateFindings(findings, checked, func(id uint64) bool { return id != 3 })
+	if !reflect.DeepEqual(newlyFound, []uint64{4}) {
+		t.Errorf("expect partition 4 newly found, but got %v", newlyFound)
+	}
+	if len(findings) != 2 || findings[2][0].Addr != "c" || findings[4] == nil {
+		t.Errorf("expect the findings of partitions 2 and 4, but got %v", findings)
+	}
+}
+
+func TestMetaPartitionCheckReplicaConsistency(t *testing.T) {
+	now := time.Now().Unix()
+	mp := newMetaPartition(1, 1, defaultMaxMetaPartitionInodeID, 3, "vol", 1)
+	mp.Replicas = []*MetaReplica{
+		{Addr: "a", IsLeader: true, ApplyID: 5000, InodeCount: 10, DentryCount: 9, ReportTime: now},
+		{Addr: "b", ApplyID: 5000, InodeCount: 11, DentryCount: 9, ReportTime: now},
+		{Addr: "c", ApplyID: 100, InodeCount: 1, DentryCount: 1, ReportTime: now},
+		{Addr: "d", ApplyID: 100, InodeCount: 1, DentryCount: 1, ReportTime: now - 2*defaultMetaPartitionTimeOutSec},
+	}
+	found := mp.checkReplicaConsistency(1000, now)
+	addrs := make([]string, 0)
+	for _, inconsistency := range found {
+		addrs = append(addrs, inconsistency.Addr)
+	}
+	if !reflect.DeepEqual(addrs, []string{"b", "c"}) {
+		t.Errorf("expect replicas b and c inconsistent, but got %v", found)
+	}
+	mp.Replicas[1].InodeCount = 10
+	mp.Replicas[2].ApplyID = 4500
+	if found = mp.checkReplicaConsistency(1000, now); len(found) != 0 {
+		t.Errorf("expect the replicas consistent, but got %v", found)
+	}
+	mp.replicaCheck = &proto.ReplicaCheckReport{CheckID: 4000, Divergences: []*proto.ReplicaDivergence{
+		{Addr: "b", Start: 0, End: 100, Issue: "inode checksum differs from the leader"},
+		{Addr: "b", Start: 100, End: 200, Issue: "dentry checksum differs from the leader"},
+	}}
+	if found = mp.checkReplicaConsistency(1000, now); len(found) != 1 || found[0].Addr != "b" {
+		t.Errorf("expect replica b diverging from the leader, but got %v", found)
+	}
+}
+
+func TestDataPartitionCheckScrubbedExtents(t *testing.T) {
+	now := time.Now().Unix()
+	dp := newDataPartition(1, 3, "vol", 1)
+	for _, addr := range []string{"a", "b", "c"} {
+		dataNode := newDataNode(addr, "zone", "cluster")
+		dataNode.isActive = true
+		replica := newDataReplica(dataNode)
+		replica.Status = proto.ReadWrite
+		dp.Hosts = append(dp.Hosts, addr)
+		dp.Replicas = append(dp.Replicas, replica)
+	}
+	if found := dp.checkReplicaConsistency(defaultDataPartitionTimeOutSec, now); len(found) != 0 {
+		t.Fatalf("expect the replicas consistent, but got %v", found)
+	}
+	dp.Replicas[1].CorruptExtents = []uint64{1030, 1025}
+	found := dp.checkReplicaConsistency(defaultDataPartitionTimeOutSec, now)
+	if len(found) != 1 || found[0].Addr != "b" || found[0].Issue != "corrupt blocks found by the scrubber are not repaired on extents [1025 1030]" {
+		t.Errorf("expect the corrupt extents of replica b, but got %v", found)
+	}
+	if !reflect.DeepEqual(dp.Replicas[1].CorruptExtents, []uint64{1030, 1025}) {
+		t.Errorf("expect the reported extents unchanged, but got %v", dp.Replicas[1].CorruptExtents)
+	}
+}
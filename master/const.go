@@ -42,6 +42,7 @@ const (
 	akKey                   = "ak"
 	keywordsKey             = "keywords"
 	zoneNameKey             = "zoneName"
+	rackNameKey             = "rackName"
 	crossZoneKey            = "crossZone"
 	tokenKey                = "token"
 	tokenTypeKey            = "tokenType"
@@ -52,9 +53,75 @@ const (
 	nodeMarkDeleteRateKey   = "markDeleteRate"
 	nodeDeleteWorkerSleepMs = "deleteWorkerSleepMs"
 	nodeAutoRepairRateKey   = "autoRepairRate"
+	autoSupplementLimitKey  = "autoSupplementLimit"
+	autoSupplementingKey    = "autoSupplementing"
+	volDeletionDelayKey     = "volDeletionDelay"
+	mpSplitInodeCountKey    = "mpSplitInodeCount"
+	mpSplitMemoryKey        = "mpSplitMemory"
+	decommissionLimitKey    = "decommissionLimit"
+	decommissionBwKey       = "decommissionBandwidth"
+	highRatioKey            = "highRatio"
+	lowRatioKey             = "lowRatio"
+	bandwidthKey            = "bandwidth"
+	deletedKey              = "deleted"
+	trashTTLKey             = "trashTTL"
+	metaStoreKey            = "metaStore"
+	inodeRetentionKey       = "inodeRetention"
+	caseInsensitiveKey      = "caseInsensitive"
+	verifyReadCrcKey        = "verifyReadCrc"
+	compressionKey          = "compression"
+	encryptedKey            = "encrypted"
+	ipAllowKey              = "ipAllow"
+	ipDenyKey               = "ipDeny"
 	descriptionKey          = "description"
 	dpSelectorNameKey       = "dpSelectorName"
 	dpSelectorParmKey       = "dpSelectorParm"
+	placementPolicyKey      = "placementPolicy"
+	placementZoneKey        = "placementZone"
+	placementLabelsKey      = "placementLabels"
+	labelsKey               = "labels"
+	readIopsLimitKey        = "readIopsLimit"
+	writeIopsLimitKey       = "writeIopsLimit"
+	readBpsLimitKey         = "readBpsLimit"
+	writeBpsLimitKey        = "writeBpsLimit"
+	asyncKey                = "async"
+	statusKey               = "status"
+	offsetKey               = "offset"
+	limitKey                = "limit"
+	markerKey               = "marker"
+	dryRunKey               = "dryRun"
+	partitionTypeKey        = "type"
+	eventTypeKey            = "type"
+	sinceKey                = "since"
+	metricKey               = "metric"
+	operatorKey             = "operator"
+	durationKey             = "duration"
+	disabledKey             = "disabled"
+	maxRaftLagKey           = "maxRaftLag"
+	targetKey               = "target"
+	roleKey                 = "role"
+	batchKey                = "batch"
+	agentPortKey            = "agentPort"
+	timeoutKey              = "timeout"
+	quotaPathKey            = "path"
+	quotaIDKey              = "quotaId"
+	ruleIDKey               = "ruleId"
+	basisKey                = "basis"
+	storageClassKey         = "storageClass"
+	maxBytesKey             = "maxBytes"
+	maxFilesKey             = "maxFiles"
+	snapshotKey             = "snapshot"
+	snapshotIDKey           = "snapshotId"
+	backupKeyKey            = "key"
+	backupTimeKey           = "time"
+	daysKey                 = "days"
+	targetMasterKey         = "targetMaster"
+	targetVolKey            = "targetVol"
+	maxLagKey               = "maxLag"
+	regionKey               = "region"
+	mastersKey              = "masters"
+	reasonKey               = "reason"
+	retiringKey             = "retiring"
 )
 
 const (
@@ -96,6 +163,8 @@ const (
 	retrySendSyncTaskInternal                    = 3 * time.Second
 	defaultRangeOfCountDifferencesAllowed        = 50
 	defaultMinusOfMaxInodeID                     = 1000
+	metaInodeMemSize                      uint64 = 256 // estimated memory used by an inode on the meta node
+	metaDentryMemSize                     uint64 = 128 // estimated memory used by a dentry on the meta node
 )
 
 const (
@@ -140,6 +209,9 @@ const (
 	OpSyncAddToken    uint32 = 0x20
 	OpSyncDelToken    uint32 = 0x21
 	OpSyncUpdateToken uint32 = 0x22
+
+	opSyncPutAuditRecord    uint32 = 0x23
+	opSyncDeleteAuditRecord uint32 = 0x24
 )
 
 const (
@@ -153,6 +225,7 @@ const (
 	clusterAcronym        = "c"
 	nodeSetAcronym        = "s"
 	tokenAcronym          = "t"
+	auditAcronym          = "audit"
 	maxDataPartitionIDKey = keySeparator + "max_dp_id"
 	maxMetaPartitionIDKey = keySeparator + "max_mp_id"
 	maxCommonIDKey        = keySeparator + "max_common_id"
@@ -163,6 +236,7 @@ const (
 	metaPartitionPrefix   = keySeparator + metaPartitionAcronym + keySeparator
 	clusterPrefix         = keySeparator + clusterAcronym + keySeparator
 	nodeSetPrefix         = keySeparator + nodeSetAcronym + keySeparator
+	auditPrefix           = keySeparator + auditAcronym + keySeparator
 
 	akAcronym      = "ak"
 	userAcronym    = "user"
@@ -30,6 +30,7 @@ type DataNode struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	Addr                      string
 	ReportTime                time.Time
 	isActive                  bool
@@ -44,6 +45,11 @@ type DataNode struct {
 	PersistenceDataPartitions []uint64
 	BadDisks                  []string
 	ToBeOffline               bool
+	HeartbeatLatency          float64 // moving average of the seconds the heartbeats are replied in
+	HealthScore               float64
+	labels                    map[string]string // attached by the admin to constrain the placement of the volumes
+	DiskSmarts                []*proto.DiskSmart
+	retiringDisks             map[string]string // reasons of the disks retired for the failure predicted, by their paths
 }
 
 func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
@@ -56,11 +62,14 @@ func newDataNode(addr, zoneName, clusterID string) (dataNode *DataNode) {
 	return
 }
 
-func (dataNode *DataNode) checkLiveness() {
+// checkLiveness returns if the data node becomes inactive.
+func (dataNode *DataNode) checkLiveness() (inactivated bool) {
 	dataNode.Lock()
 	defer dataNode.Unlock()
 	if time.Since(dataNode.ReportTime) > time.Second*time.Duration(defaultNodeTimeOutSec) {
+		inactivated = dataNode.isActive
 		dataNode.isActive = false
+		dataNode.HealthScore = 0
 	}
 
 	return
@@ -79,9 +88,15 @@ func (dataNode *DataNode) badPartitions(diskPath string, c *Cluster) (partitions
 	return
 }
 
-func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse) {
+// updateNodeMetric returns the bad disks which are not reported before.
+func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse) (newBadDisks []string) {
 	dataNode.Lock()
 	defer dataNode.Unlock()
+	for _, disk := range resp.BadDisks {
+		if !contains(dataNode.BadDisks, disk) {
+			newBadDisks = append(newBadDisks, disk)
+		}
+	}
 	dataNode.Total = resp.Total
 	dataNode.Used = resp.Used
 	dataNode.AvailableSpace = resp.Available
@@ -89,6 +104,7 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 	dataNode.DataPartitionCount = resp.CreatedPartitionCnt
 	dataNode.DataPartitionReports = resp.PartitionReports
 	dataNode.BadDisks = resp.BadDisks
+	dataNode.DiskSmarts = resp.DiskSmarts
 	if dataNode.Total == 0 {
 		dataNode.UsageRatio = 0.0
 	} else {
@@ -96,6 +112,15 @@ func (dataNode *DataNode) updateNodeMetric(resp *proto.DataNodeHeartbeatResponse
 	}
 	dataNode.ReportTime = time.Now()
 	dataNode.isActive = true
+	dataNode.HealthScore = healthScore(true, dataNode.HeartbeatLatency, len(dataNode.BadDisks), dataNode.UsageRatio)
+	return
+}
+
+// updateHeartbeatLatency updates the latency by the heartbeat task sent at the unix time.
+func (dataNode *DataNode) updateHeartbeatLatency(sendTime int64) {
+	dataNode.Lock()
+	defer dataNode.Unlock()
+	dataNode.HeartbeatLatency = smoothHeartbeatLatency(dataNode.HeartbeatLatency, sendTime)
 }
 
 func (dataNode *DataNode) isWriteAble() (ok bool) {
@@ -128,6 +153,13 @@ func (dataNode *DataNode) GetAddr() string {
 	return dataNode.Addr
 }
 
+// GetRack returns the rack of the data node reported at registration.
+func (dataNode *DataNode) GetRack() string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return dataNode.RackName
+}
+
 // SetCarry implements "SetCarry" in the Node interface
 func (dataNode *DataNode) SetCarry(carry float64) {
 	dataNode.Lock()
@@ -150,8 +182,9 @@ func (dataNode *DataNode) clean() {
 
 func (dataNode *DataNode) createHeartbeatTask(masterAddr string) (task *proto.AdminTask) {
 	request := &proto.HeartBeatRequest{
-		CurrTime:   time.Now().Unix(),
-		MasterAddr: masterAddr,
+		CurrTime:      time.Now().Unix(),
+		MasterAddr:    masterAddr,
+		RetiringDisks: dataNode.retiringDiskPaths(),
 	}
 	task = proto.NewAdminTask(proto.OpDataNodeHeartbeat, dataNode.Addr, request)
 	return
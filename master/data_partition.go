@@ -50,6 +50,9 @@ type DataPartition struct {
 	OfflinePeerID           uint64
 	FileInCoreMap           map[string]*FileInCore
 	FilesWithMissingReplica map[string]int64 // key: file name, value: last time when a missing replica is found
+	forcedStatus            int8             // the status forced by the admin, 0 if the status is decided by checkStatus
+	forceReason             string
+	forceTime               int64
 }
 
 func newDataPartition(ID uint64, replicaNum uint8, volName string, volID uint64) (partition *DataPartition) {
@@ -121,10 +124,17 @@ func (partition *DataPartition) createTaskToRemoveRaftMember(removePeer proto.Pe
 	return
 }
 
-func (partition *DataPartition) createTaskToCreateDataPartition(addr string, dataPartitionSize uint64, peers []proto.Peer, hosts []string, createType int) (task *proto.AdminTask) {
+func (partition *DataPartition) createTaskToResetRaftMembers(newPeers []proto.Peer, address string) (task *proto.AdminTask, err error) {
+	req := &proto.ResetDataPartitionRaftMemberRequest{PartitionId: partition.PartitionID, NewPeers: newPeers}
+	task = proto.NewAdminTask(proto.OpResetDataPartitionRaftMember, address, req)
+	partition.resetTaskID(task)
+	return
+}
+
+func (partition *DataPartition) createTaskToCreateDataPartition(addr string, dataPartitionSize uint64, peers []proto.Peer, hosts []string, createType int, snapshotEpoch uint64) (task *proto.AdminTask) {
 
 	task = proto.NewAdminTask(proto.OpCreateDataPartition, addr, newCreateDataPartitionRequest(
-		partition.VolName, partition.PartitionID, peers, int(dataPartitionSize), hosts, createType))
+		partition.VolName, partition.PartitionID, peers, int(dataPartitionSize), hosts, createType, snapshotEpoch))
 	partition.resetTaskID(task)
 	return
 }
@@ -526,6 +536,13 @@ func (partition *DataPartition) updateMetric(vr *proto.PartitionReport, dataNode
 	replica.setAlive()
 	replica.IsLeader = vr.IsLeader
 	replica.NeedsToCompare = vr.NeedCompare
+	replica.ApplyID = vr.ApplyID
+	replica.CorruptExtents = vr.CorruptExtents
+	replica.CompressedExtents = vr.CompressedExtents
+	replica.CompressedRawSize = vr.CompressedRawSize
+	replica.CompressedSize = vr.CompressedSize
+	replica.KeyVersion = vr.KeyVersion
+	replica.StaleKeyExtents = vr.StaleKeyExtents
 	if replica.DiskPath != vr.DiskPath && vr.DiskPath != "" {
 		oldDiskPath := replica.DiskPath
 		replica.DiskPath = vr.DiskPath
@@ -681,5 +698,8 @@ func (partition *DataPartition) ToProto(c *Cluster) *proto.DataPartitionInfo {
 		FileInCoreMap:           fileInCoreMap,
 		OfflinePeerID:           partition.OfflinePeerID,
 		FilesWithMissingReplica: partition.FilesWithMissingReplica,
+		ForcedStatus:            partition.forcedStatus,
+		ForceReason:             partition.forceReason,
+		ForceTime:               partition.forceTime,
 	}
 }
@@ -44,6 +44,7 @@ func (partition *DataPartition) checkStatus(clusterName string, needLog bool, dp
 	default:
 		partition.Status = proto.ReadOnly
 	}
+	partition.applyForcedStatus(len(liveReplicas))
 	if needLog == true && len(liveReplicas) != int(partition.ReplicaNum) {
 		msg := fmt.Sprintf("action[extractStatus],partitionID:%v  replicaNum:%v  liveReplicas:%v   Status:%v  RocksDBHost:%v ",
 			partition.PartitionID, partition.ReplicaNum, len(liveReplicas), partition.Status, partition.Hosts)
@@ -83,11 +84,13 @@ func (partition *DataPartition) checkReplicaStatus(timeOutSec int64) {
 	}
 }
 
-func (partition *DataPartition) checkLeader(timeOut int64) {
+// checkLeader returns if the leader of the data partition is lost.
+func (partition *DataPartition) checkLeader(timeOut int64) (lostLeader bool) {
 	partition.Lock()
 	defer partition.Unlock()
 	for _, dr := range partition.Replicas {
 		if !dr.isLive(timeOut) {
+			lostLeader = lostLeader || dr.IsLeader
 			dr.IsLeader = false
 		}
 	}
@@ -20,6 +20,7 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 )
@@ -141,6 +142,30 @@ func (dpMap *DataPartitionMap) getDataPartitionsView(minPartitionID uint64) (dpR
 	return
 }
 
+// getDataPartitionsViewPage returns the views of at most limit data partitions whose IDs are greater than
+// the marker in the order of ID, 0 limit means no limit.
+func (dpMap *DataPartitionMap) getDataPartitionsViewPage(marker uint64, limit int) (dpResps []*proto.DataPartitionResponse) {
+	dpMap.RLock()
+	partitions := make([]*DataPartition, 0)
+	for _, dp := range dpMap.partitionMap {
+		if dp.PartitionID > marker {
+			partitions = append(partitions, dp)
+		}
+	}
+	dpMap.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+	if limit > 0 && len(partitions) > limit {
+		partitions = partitions[:limit]
+	}
+	dpResps = make([]*proto.DataPartitionResponse, 0, len(partitions))
+	for _, dp := range partitions {
+		dpResps = append(dpResps, dp.convertToDataPartitionResponse())
+	}
+	return
+}
+
 func (dpMap *DataPartitionMap) getDataPartitionsToBeReleased(numberOfDataPartitionsToFree int, secondsToFreeDataPartitionAfterLoad int64) (partitions []*DataPartition, startIndex uint64) {
 	partitions = make([]*DataPartition, 0)
 	dpMap.RLock()
@@ -0,0 +1,105 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The admin forces a data partition read-only, such as fencing a partition suspected of corruption while it is
+// investigated, or writable regardless of the replica status and the free space reported by the data nodes. The
+// forced status is persisted with the partition and overrides the status decided by checkStatus until it is reset.
+
+// parseForcedStatus returns the status to force the data partition to, 0 for auto.
+func parseForcedStatus(value string) (status int8, err error) {
+	switch value {
+	case proto.PartitionStatusReadOnly:
+		return proto.ReadOnly, nil
+	case proto.PartitionStatusWritable:
+		return proto.ReadWrite, nil
+	case proto.PartitionStatusAuto:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("%v must be one of %v, %v and %v", statusKey, proto.PartitionStatusReadOnly,
+			proto.PartitionStatusWritable, proto.PartitionStatusAuto)
+	}
+}
+
+// applyForcedStatus overrides the status decided by checkStatus. The partition forced writable stays read-only
+// if any replica is not alive, since the writes to it fail anyway.
+func (partition *DataPartition) applyForcedStatus(liveReplicas int) {
+	switch partition.forcedStatus {
+	case proto.ReadOnly:
+		partition.Status = proto.ReadOnly
+	case proto.ReadWrite:
+		if liveReplicas == int(partition.ReplicaNum) {
+			partition.Status = proto.ReadWrite
+		}
+	}
+}
+
+func (c *Cluster) setDataPartitionForcedStatus(dp *DataPartition, status int8, reason string) (err error) {
+	dp.Lock()
+	oldStatus, oldReason, oldTime := dp.forcedStatus, dp.forceReason, dp.forceTime
+	dp.forcedStatus, dp.forceReason, dp.forceTime = status, reason, 0
+	if status != 0 {
+		dp.forceTime = time.Now().Unix()
+	}
+	if err = c.syncUpdateDataPartition(dp); err != nil {
+		dp.forcedStatus, dp.forceReason, dp.forceTime = oldStatus, oldReason, oldTime
+		dp.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	dp.Unlock()
+	// apply the status at once instead of waiting for the next check
+	dp.checkStatus(c.Name, false, c.cfg.DataPartitionTimeOutSec)
+	msg := fmt.Sprintf("data partition status forced to %v, reason: %v", formatForcedStatus(status), reason)
+	log.LogWarnf("action[setDataPartitionForcedStatus] vol[%v] dp[%v] %v", dp.VolName, dp.PartitionID, msg)
+	c.events.emit(proto.EventPartitionStatusForced, "", dp.VolName, dp.PartitionID, msg)
+	return
+}
+
+func formatForcedStatus(status int8) string {
+	switch status {
+	case proto.ReadOnly:
+		return proto.PartitionStatusReadOnly
+	case proto.ReadWrite:
+		return proto.PartitionStatusWritable
+	default:
+		return proto.PartitionStatusAuto
+	}
+}
+
+func (c *Cluster) getForcedStatusDataPartitionIDs() (partitionIDs []uint64) {
+	partitionIDs = make([]uint64, 0)
+	for _, vol := range c.copyVols() {
+		vol.dataPartitions.RLock()
+		for _, dp := range vol.dataPartitions.partitions {
+			dp.RLock()
+			if dp.forcedStatus != 0 {
+				partitionIDs = append(partitionIDs, dp.PartitionID)
+			}
+			dp.RUnlock()
+		}
+		vol.dataPartitions.RUnlock()
+	}
+	sort.Slice(partitionIDs, func(i, j int) bool { return partitionIDs[i] < partitionIDs[j] })
+	return
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestParseForcedStatus(t *testing.T) {
+	cases := map[string]int8{
+		proto.PartitionStatusReadOnly: proto.ReadOnly,
+		proto.PartitionStatusWritable: proto.ReadWrite,
+		proto.PartitionStatusAuto:     0,
+	}
+	for value, expect := range cases {
+		if status, err := parseForcedStatus(value); err != nil || status != expect {
+			t.Errorf("parse %v: expect %v, but got %v err %v", value, expect, status, err)
+		}
+	}
+	if _, err := parseForcedStatus("rw"); err == nil {
+		t.Errorf("expect the unknown status rejected")
+	}
+}
+
+func TestApplyForcedStatus(t *testing.T) {
+	dp := newDataPartition(1, 3, "vol", 1)
+	dp.Status = proto.ReadWrite
+	dp.forcedStatus = proto.ReadOnly
+	dp.applyForcedStatus(3)
+	if dp.Status != proto.ReadOnly {
+		t.Errorf("expect the partition forced read-only")
+	}
+	dp.forcedStatus = proto.ReadWrite
+	dp.applyForcedStatus(2)
+	if dp.Status != proto.ReadOnly {
+		t.Errorf("expect the partition lacking live replicas stays read-only")
+	}
+	dp.applyForcedStatus(3)
+	if dp.Status != proto.ReadWrite {
+		t.Errorf("expect the partition forced writable")
+	}
+	dp.forcedStatus = 0
+	dp.Status = proto.ReadOnly
+	dp.applyForcedStatus(3)
+	if dp.Status != proto.ReadOnly {
+		t.Errorf("expect the status decided by the master kept")
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+func (c *Cluster) scheduleToRebalanceDataPartitions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.rebalanceDataPartitions()
+			}
+			time.Sleep(rebalanceCheckInterval)
+		}
+	}()
+}
+
+// rebalanceDataPartitions migrates the data partitions from the data nodes whose disk usage ratio is above the high
+// ratio. The migrated partitions are read only and recovering on the targets, and no more than the limit of them are
+// migrating at the same time.
+func (c *Cluster) rebalanceDataPartitions() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("rebalanceDataPartitions occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"rebalanceDataPartitions occurred panic")
+		}
+	}()
+	rb := c.dataRebalancer
+	rb.releaseMigrations(func(id uint64) bool {
+		dp, err := c.getDataPartitionByID(id)
+		return err == nil && dp.isRecover
+	})
+	if !rb.isRunnable() {
+		return
+	}
+	rb.rebalance(c.dataNodeLoads(), func(partition *partitionLoad, src, target string) (err error) {
+		var dp *DataPartition
+		if dp, err = c.getDataPartitionByID(partition.id); err != nil {
+			return
+		}
+		return c.rebalanceDataPartition(dp, src, target)
+	})
+}
+
+func (c *Cluster) rebalanceDataPartition(dp *DataPartition, offlineAddr, newAddr string) (err error) {
+	dp.RLock()
+	ok := !dp.isRecover && len(dp.Hosts) == int(dp.ReplicaNum) && dp.hasHost(offlineAddr)
+	replica, _ := dp.getReplica(offlineAddr)
+	dp.RUnlock()
+	if !ok {
+		return fmt.Errorf("data partition is recovering or lacks replicas")
+	}
+	if err = c.validateDecommissionDataPartition(dp, offlineAddr); err != nil {
+		return
+	}
+	if err = c.moveDataReplica(dp, replica, offlineAddr, newAddr); err != nil {
+		return
+	}
+	Warn(c.Name, fmt.Sprintf("action[rebalanceDataPartition] clusterID[%v] vol[%v] data partition[%v] "+
+		"migrated from[%v] to[%v]", c.Name, dp.VolName, dp.PartitionID, offlineAddr, newAddr))
+	return
+}
+
+// dataNodeLoads returns the disk usage of the active data nodes, with the data partitions hosted by them.
+func (c *Cluster) dataNodeLoads() (loads map[string]*nodeLoad) {
+	loads = make(map[string]*nodeLoad)
+	c.dataNodes.Range(func(addr, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		writable := dataNode.isWriteAble()
+		dataNode.RLock()
+		defer dataNode.RUnlock()
+		if !dataNode.isActive {
+			return true
+		}
+		load := &nodeLoad{
+			addr:     dataNode.Addr,
+			zoneName: dataNode.ZoneName,
+			rackName: dataNode.RackName,
+			total:    dataNode.Total,
+			used:     dataNode.Used,
+			writable: writable && !dataNode.ToBeOffline,
+			labels:   dataNode.labels,
+		}
+		if load.rackName == "" {
+			// the node set is taken as the rack of the nodes without a rack
+			load.rackName = fmt.Sprintf("nodeSet-%v", dataNode.NodeSetID)
+		}
+		loads[load.addr] = load
+		return true
+	})
+	for _, vol := range c.allVols() {
+		for _, dp := range vol.cloneDataPartitionMap() {
+			dp.RLock()
+			partition := &partitionLoad{
+				id:          dp.PartitionID,
+				volName:     dp.VolName,
+				used:        dp.getMaxUsedSpace(),
+				hosts:       append([]string(nil), dp.Hosts...),
+				rackDiverse: vol.placementPolicy == proto.PlacementRackDiverse,
+				labels:      vol.placementLabels,
+			}
+			dp.RUnlock()
+			for _, host := range partition.hosts {
+				if load, ok := loads[host]; ok {
+					load.partitions = append(load.partitions, partition)
+				}
+			}
+		}
+	}
+	return
+}
+
+func (c *Cluster) dataRebalanceView() *proto.RebalanceView {
+	loads := c.dataNodeLoads()
+	nodes := make([]*proto.RebalanceNodeView, 0, len(loads))
+	for _, load := range loads {
+		nodes = append(nodes, load.view())
+	}
+	return c.dataRebalancer.view(nodes)
+}
+
+func (c *Cluster) setDataRebalanceConfig(config proto.RebalanceConfig) (err error) {
+	if err = validateRebalanceConfig(config); err != nil {
+		return
+	}
+	oldConfig := c.dataRebalancer.getConfig()
+	c.dataRebalancer.setConfig(config)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setDataRebalanceConfig] err[%v]", err)
+		c.dataRebalancer.setConfig(oldConfig)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
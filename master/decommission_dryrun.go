@@ -0,0 +1,199 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The dry run of a decommission checks each partition on the node the way the decommission does, without choosing
+// the targets or migrating anything. The replica on the node is removed before the new one is added, so a partition
+// drops below the quorum while it is moved if its other live replicas are fewer than the majority.
+//
+// The candidates of a partition of the volume with a placement policy are the nodes the policy accepts when each of
+// them is offered alone. The candidates of the other partitions are the nodes in the node set of the decommissioned
+// node, or in its zone if the node set has none, or in the other zones, in the order the decommission prefers them.
+
+// decommissionDryRun reports the impact of decommissioning the data node or the meta node of the address.
+func (c *Cluster) decommissionDryRun(nodeAddr string) (*proto.DecommissionDryRun, error) {
+	if dataNode, err := c.dataNode(nodeAddr); err == nil {
+		return c.dataNodeDecommissionDryRun(dataNode), nil
+	}
+	if metaNode, err := c.metaNode(nodeAddr); err == nil {
+		return c.metaNodeDecommissionDryRun(metaNode), nil
+	}
+	return nil, fmt.Errorf("node[%v] is neither a data node nor a meta node", nodeAddr)
+}
+
+// decommissionDryRunner sums up the impacts of the partitions on the decommissioned node.
+type decommissionDryRunner struct {
+	node       *PlacementNode
+	nodes      *placementNodes
+	dryRun     *proto.DecommissionDryRun
+	candidates map[string]*proto.DecommissionCandidate
+}
+
+func newDecommissionDryRunner(node *PlacementNode, nodes *placementNodes, nodeType string) *decommissionDryRunner {
+	return &decommissionDryRunner{
+		node:  node,
+		nodes: nodes,
+		dryRun: &proto.DecommissionDryRun{
+			Addr:        node.Addr,
+			NodeType:    nodeType,
+			BelowQuorum: make([]uint64, 0),
+			Blocked:     make([]uint64, 0),
+			NoCandidate: make([]uint64, 0),
+			Partitions:  make([]*proto.DecommissionPartitionImpact, 0),
+		},
+		candidates: make(map[string]*proto.DecommissionCandidate),
+	}
+}
+
+// add adds the impact of the partition, the hosts are the replicas of the partition including the one on the node.
+func (r *decommissionDryRunner) add(c *Cluster, vol *Vol, impact *proto.DecommissionPartitionImpact, hosts []string) {
+	quorum := impact.ReplicaNum/2 + 1
+	impact.BelowQuorum = impact.LiveReplicas < quorum
+	candidates := c.decommissionCandidates(vol, r.nodes, r.node, hosts)
+	impact.CandidateCount = len(candidates)
+	for _, node := range candidates {
+		candidate, ok := r.candidates[node.Addr]
+		if !ok {
+			candidate = &proto.DecommissionCandidate{Addr: node.Addr, ZoneName: node.ZoneName, Avail: node.Avail}
+			r.candidates[node.Addr] = candidate
+		}
+		candidate.Partitions++
+	}
+	dryRun := r.dryRun
+	dryRun.PartitionCount++
+	dryRun.MigrateBytes += impact.Bytes
+	if impact.BelowQuorum {
+		dryRun.BelowQuorum = append(dryRun.BelowQuorum, impact.PartitionID)
+	}
+	if impact.Error != "" {
+		dryRun.Blocked = append(dryRun.Blocked, impact.PartitionID)
+	}
+	if impact.CandidateCount == 0 {
+		dryRun.NoCandidate = append(dryRun.NoCandidate, impact.PartitionID)
+	}
+	dryRun.Partitions = append(dryRun.Partitions, impact)
+}
+
+// result returns the dry run with the candidates of the most partitions first.
+func (r *decommissionDryRunner) result() *proto.DecommissionDryRun {
+	dryRun := r.dryRun
+	dryRun.Candidates = make([]*proto.DecommissionCandidate, 0, len(r.candidates))
+	for _, candidate := range r.candidates {
+		dryRun.Candidates = append(dryRun.Candidates, candidate)
+	}
+	sort.Slice(dryRun.Candidates, func(i, j int) bool {
+		if dryRun.Candidates[i].Partitions != dryRun.Candidates[j].Partitions {
+			return dryRun.Candidates[i].Partitions > dryRun.Candidates[j].Partitions
+		}
+		return dryRun.Candidates[i].Addr < dryRun.Candidates[j].Addr
+	})
+	for _, ids := range [][]uint64{dryRun.BelowQuorum, dryRun.Blocked, dryRun.NoCandidate} {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+	sort.Slice(dryRun.Partitions, func(i, j int) bool {
+		return dryRun.Partitions[i].PartitionID < dryRun.Partitions[j].PartitionID
+	})
+	return dryRun
+}
+
+func (c *Cluster) dataNodeDecommissionDryRun(dataNode *DataNode) *proto.DecommissionDryRun {
+	runner := newDecommissionDryRunner(newDataPlacementNode(dataNode), c.dataPlacementNodes(), "data")
+	for _, dp := range c.getAllDataPartitionByDataNode(dataNode.Addr) {
+		vol, err := c.getVol(dp.VolName)
+		if err != nil {
+			continue
+		}
+		impact := &proto.DecommissionPartitionImpact{PartitionID: dp.PartitionID, VolName: dp.VolName}
+		if err = c.validateDecommissionDataPartition(dp, dataNode.Addr); err != nil {
+			impact.Error = err.Error()
+		}
+		dp.RLock()
+		hosts := append([]string{}, dp.Hosts...)
+		impact.ReplicaNum = int(dp.ReplicaNum)
+		if replica, err := dp.getReplica(dataNode.Addr); err == nil {
+			impact.Bytes = replica.Used
+		} else {
+			impact.Bytes = dp.getMaxUsedSpace()
+		}
+		for _, replica := range dp.liveReplicas(c.cfg.DataPartitionTimeOutSec) {
+			if replica.Addr != dataNode.Addr {
+				impact.LiveReplicas++
+			}
+		}
+		dp.RUnlock()
+		runner.add(c, vol, impact, hosts)
+	}
+	return runner.result()
+}
+
+func (c *Cluster) metaNodeDecommissionDryRun(metaNode *MetaNode) *proto.DecommissionDryRun {
+	runner := newDecommissionDryRunner(newMetaPlacementNode(metaNode), c.metaPlacementNodes(), "meta")
+	for _, mp := range c.getAllMetaPartitionByMetaNode(metaNode.Addr) {
+		vol, err := c.getVol(mp.volName)
+		if err != nil {
+			continue
+		}
+		impact := &proto.DecommissionPartitionImpact{PartitionID: mp.PartitionID, VolName: mp.volName}
+		if err = c.validateDecommissionMetaPartition(mp, metaNode.Addr); err != nil {
+			impact.Error = err.Error()
+		}
+		mp.RLock()
+		hosts := append([]string{}, mp.Hosts...)
+		impact.ReplicaNum = int(mp.ReplicaNum)
+		impact.Bytes = mp.estimateMemory()
+		for _, replica := range mp.getLiveReplicas() {
+			if replica.Addr != metaNode.Addr && contains(hosts, replica.Addr) {
+				impact.LiveReplicas++
+			}
+		}
+		mp.RUnlock()
+		runner.add(c, vol, impact, hosts)
+	}
+	return runner.result()
+}
+
+// decommissionCandidates returns the nodes which may hold the new replica of the partition moved off the node.
+func (c *Cluster) decommissionCandidates(vol *Vol, nodes *placementNodes, node *PlacementNode, hosts []string) []*PlacementNode {
+	if vol.usesPlacementPolicy() {
+		policy, err := getPlacementPolicy(vol.effectivePlacementPolicy())
+		if err != nil {
+			return nil
+		}
+		req := c.newPlacementRequest(vol, nodes, excludeHost(hosts, node.Addr))
+		return filterPlacementNodes(placementCandidates(req, nodes, hosts), func(candidate *PlacementNode) bool {
+			_, err := policy.Choose(req, []*PlacementNode{candidate})
+			return err == nil
+		})
+	}
+	candidates := nodes.candidates(hosts)
+	for _, accept := range []func(candidate *PlacementNode) bool{
+		func(candidate *PlacementNode) bool {
+			return candidate.ZoneName == node.ZoneName && candidate.NodeSetID == node.NodeSetID
+		},
+		func(candidate *PlacementNode) bool { return candidate.ZoneName == node.ZoneName },
+	} {
+		if preferred := filterPlacementNodes(candidates, accept); len(preferred) > 0 {
+			return preferred
+		}
+	}
+	return candidates
+}
@@ -0,0 +1,53 @@
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestDecommissionDryRun(t *testing.T) {
+	c := newPlacementTestCluster(2, 2)
+	vol := newVol(1, "dryrun", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	nodes := c.dataPlacementNodes()
+	node := nodes.node("127.0.1.1:17310")
+	// the other node in the node set is preferred
+	hosts := []string{"127.0.1.1:17310", "127.0.2.1:17310", "127.0.3.1:17310"}
+	candidates := c.decommissionCandidates(vol, nodes, node, hosts)
+	if len(candidates) != 1 || candidates[0].Addr != "127.0.1.2:17310" {
+		t.Errorf("expect the candidate in the node set, but %v", placementNodeAddrs(candidates))
+	}
+	// then the nodes in the zone
+	hosts = append(hosts, "127.0.1.2:17310")
+	candidates = c.decommissionCandidates(vol, nodes, node, hosts)
+	if len(candidates) != 1 || candidates[0].Addr != "127.0.2.2:17310" {
+		t.Errorf("expect the candidate in the zone, but %v", placementNodeAddrs(candidates))
+	}
+	// the placement labels restrict the candidates
+	vol.placementLabels = map[string]string{"disk": "nvme"}
+	if candidates = c.decommissionCandidates(vol, nodes, node, hosts); len(candidates) != 0 {
+		t.Errorf("expect no candidate with the labels, but %v", placementNodeAddrs(candidates))
+	}
+	vol.placementLabels = nil
+
+	runner := newDecommissionDryRunner(node, nodes, "data")
+	runner.add(c, vol, &proto.DecommissionPartitionImpact{PartitionID: 2, Bytes: 10, ReplicaNum: 3, LiveReplicas: 1}, hosts)
+	runner.add(c, vol, &proto.DecommissionPartitionImpact{PartitionID: 1, Bytes: 20, ReplicaNum: 3, LiveReplicas: 2}, hosts[:3])
+	dryRun := runner.result()
+	if dryRun.PartitionCount != 2 || dryRun.MigrateBytes != 30 {
+		t.Errorf("unexpected partitions[%v] bytes[%v]", dryRun.PartitionCount, dryRun.MigrateBytes)
+	}
+	if len(dryRun.BelowQuorum) != 1 || dryRun.BelowQuorum[0] != 2 {
+		t.Errorf("expect partition[2] below the quorum, but %v", dryRun.BelowQuorum)
+	}
+	if len(dryRun.Candidates) != 2 || dryRun.Partitions[0].PartitionID != 1 {
+		t.Errorf("unexpected candidates[%v] partitions[%v]", len(dryRun.Candidates), len(dryRun.Partitions))
+	}
+}
+
+func placementNodeAddrs(nodes []*PlacementNode) (addrs []string) {
+	for _, node := range nodes {
+		addrs = append(addrs, node.Addr)
+	}
+	return
+}
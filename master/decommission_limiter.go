@@ -0,0 +1,80 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const decommissionLimitCheckInterval = 5 * time.Second
+
+// decommissionLimiter limits the data partitions migrating from a decommissioned node at the same time. A partition
+// is migrating from the start of its decommission until its new replica is recovered. The limit is loaded in every
+// check, so that the change of the limit takes effect on the decommissions in progress.
+type decommissionLimiter struct {
+	sync.Mutex
+	limit      *uint64
+	pending    int
+	recovering []*DataPartition
+}
+
+func newDecommissionLimiter(limit *uint64) *decommissionLimiter {
+	return &decommissionLimiter{limit: limit}
+}
+
+// migrating returns the number of the migrating partitions, and forgets the partitions which are recovered.
+func (l *decommissionLimiter) migrating() int {
+	l.Lock()
+	defer l.Unlock()
+	recovering := l.recovering[:0]
+	for _, dp := range l.recovering {
+		dp.RLock()
+		if dp.isRecover {
+			recovering = append(recovering, dp)
+		}
+		dp.RUnlock()
+	}
+	l.recovering = recovering
+	return l.pending + len(l.recovering)
+}
+
+// wait blocks until the number of the migrating partitions is below the limit, 0 for no limit.
+func (l *decommissionLimiter) wait() {
+	for {
+		limit := atomic.LoadUint64(l.limit)
+		if limit == 0 || uint64(l.migrating()) < limit {
+			return
+		}
+		time.Sleep(decommissionLimitCheckInterval)
+	}
+}
+
+func (l *decommissionLimiter) start() {
+	l.Lock()
+	defer l.Unlock()
+	l.pending++
+}
+
+// done records the end of the decommission of the partition, which keeps migrating until recovered if succeeded.
+func (l *decommissionLimiter) done(dp *DataPartition, err error) {
+	l.Lock()
+	defer l.Unlock()
+	l.pending--
+	if err == nil {
+		l.recovering = append(l.recovering, dp)
+	}
+}
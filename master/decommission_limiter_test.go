@@ -0,0 +1,25 @@
+package master
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecommissionLimiter(t *testing.T) {
+	limit := uint64(2)
+	limiter := newDecommissionLimiter(&limit)
+	dp1, dp2 := &DataPartition{isRecover: true}, &DataPartition{isRecover: true}
+	limiter.start()
+	limiter.done(dp1, nil)
+	limiter.start()
+	limiter.done(dp2, fmt.Errorf("decommission failed"))
+	limiter.start()
+	if n := limiter.migrating(); n != 2 {
+		t.Fatalf("expect 2 partitions migrating, but got %v", n)
+	}
+	dp1.isRecover = false
+	if n := limiter.migrating(); n != 1 {
+		t.Fatalf("expect 1 partition migrating after recovered, but got %v", n)
+	}
+	limiter.wait()
+}
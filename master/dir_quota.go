@@ -0,0 +1,266 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// getDirQuotas returns the directory quotas of the volume with the usage summed from the meta partitions.
+func (vol *Vol) getDirQuotas() (quotas []*proto.DirQuota) {
+	vol.RLock()
+	dirQuotas := vol.dirQuotas
+	vol.RUnlock()
+	usage := vol.dirQuotaUsage()
+	quotas = make([]*proto.DirQuota, 0, len(dirQuotas))
+	for id, quota := range dirQuotas {
+		q := *quota
+		if u, ok := usage[id]; ok {
+			q.UsedBytes, q.UsedFiles = u.UsedBytes, u.UsedFiles
+		}
+		quotas = append(quotas, &q)
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].QuotaID < quotas[j].QuotaID })
+	return
+}
+
+func (vol *Vol) getDirQuotaByPath(dirPath string) *proto.DirQuota {
+	vol.RLock()
+	defer vol.RUnlock()
+	for _, quota := range vol.dirQuotas {
+		if quota.Path == dirPath {
+			return quota
+		}
+	}
+	return nil
+}
+
+func (vol *Vol) dirQuotaUsage() (usage map[uint32]*proto.QuotaUsage) {
+	usage = make(map[uint32]*proto.QuotaUsage)
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		mp.RLock()
+		for id, u := range mp.quotaUsage {
+			if _, ok := usage[id]; !ok {
+				usage[id] = &proto.QuotaUsage{}
+			}
+			usage[id].UsedBytes += u.UsedBytes
+			usage[id].UsedFiles += u.UsedFiles
+		}
+		mp.RUnlock()
+	}
+	return
+}
+
+// setDirQuota sets the limits of the quota of the directory. A new quota is created if the directory has no quota,
+// and the inodes in the directory subtree are tagged with the new quota by the returned task in background.
+func (c *Cluster) setDirQuota(volName, dirPath string, maxBytes, maxFiles uint64) (quota *proto.DirQuota, task *proto.AsyncTaskInfo, err error) {
+	var (
+		vol       *Vol
+		mw        *meta.MetaWrapper
+		rootInode uint64
+	)
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	if vol.authenticate {
+		err = fmt.Errorf("directory quota of vol[%v] with authentication is not supported", volName)
+		return
+	}
+	dirPath = path.Clean("/" + dirPath)
+	if quota = vol.getDirQuotaByPath(dirPath); quota == nil {
+		if c.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskSetDirQuota, volName) {
+			err = fmt.Errorf("the quota of vol[%v] is being updated, retry later", volName)
+			return
+		}
+		if mw, err = meta.NewMetaWrapper(&meta.MetaConfig{Volume: volName, Masters: c.masterAddrs()}); err != nil {
+			return
+		}
+		rootInode, err = mw.GetRootIno(dirPath)
+		_ = mw.Close()
+		if err != nil {
+			err = fmt.Errorf("lookup path[%v] of vol[%v] failed: %v", dirPath, volName, err)
+			return
+		}
+	}
+
+	vol.Lock()
+	if quota == nil {
+		// the quota may be created by another request meanwhile
+		for _, q := range vol.dirQuotas {
+			if q.Path == dirPath {
+				quota, rootInode = q, 0
+			}
+		}
+	}
+	dirQuotas := make(map[uint32]*proto.DirQuota, len(vol.dirQuotas)+1)
+	for id, q := range vol.dirQuotas {
+		dirQuotas[id] = q
+	}
+	oldQuotas, oldMaxQuotaID := vol.dirQuotas, vol.maxQuotaID
+	if quota == nil {
+		vol.maxQuotaID++
+		quota = &proto.DirQuota{QuotaID: vol.maxQuotaID, Path: dirPath, RootInode: rootInode}
+	} else {
+		q := *quota
+		quota = &q
+	}
+	quota.MaxBytes, quota.MaxFiles = maxBytes, maxFiles
+	dirQuotas[quota.QuotaID] = quota
+	vol.dirQuotas = dirQuotas
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.dirQuotas, vol.maxQuotaID = oldQuotas, oldMaxQuotaID
+		vol.Unlock()
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	vol.Unlock()
+	log.LogWarnf("action[setDirQuota] clusterID[%v] vol[%v] path[%v] quota[%v] maxBytes[%v] maxFiles[%v]",
+		c.Name, volName, dirPath, quota.QuotaID, maxBytes, maxFiles)
+	if rootInode == 0 {
+		return
+	}
+	id := quota.QuotaID
+	task, err = c.asyncTasks.submitVolumeTask(proto.AsyncTaskSetDirQuota, volName, func(progress progressFunc) error {
+		return c.walkDirQuota(vol, rootInode, progress, func(ids []uint32) ([]uint32, bool) {
+			for _, existing := range ids {
+				if existing == id {
+					return ids, false
+				}
+			}
+			return append(ids, id), true
+		})
+	})
+	return
+}
+
+// deleteDirQuota deletes the quota, and the quota is removed from the inodes of the directory subtree in background.
+func (c *Cluster) deleteDirQuota(volName string, quotaID uint32) (quota *proto.DirQuota, task *proto.AsyncTaskInfo, err error) {
+	var (
+		vol *Vol
+		ok  bool
+	)
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	if c.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskSetDirQuota, volName) {
+		err = fmt.Errorf("the quota of vol[%v] is being updated, retry later", volName)
+		return
+	}
+	vol.Lock()
+	if quota, ok = vol.dirQuotas[quotaID]; !ok {
+		vol.Unlock()
+		err = fmt.Errorf("quota[%v] of vol[%v] not exists", quotaID, volName)
+		return
+	}
+	dirQuotas := make(map[uint32]*proto.DirQuota, len(vol.dirQuotas))
+	for id, q := range vol.dirQuotas {
+		if id != quotaID {
+			dirQuotas[id] = q
+		}
+	}
+	oldQuotas := vol.dirQuotas
+	vol.dirQuotas = dirQuotas
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.dirQuotas = oldQuotas
+		vol.Unlock()
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	vol.Unlock()
+	log.LogWarnf("action[deleteDirQuota] clusterID[%v] vol[%v] path[%v] quota[%v] is deleted",
+		c.Name, volName, quota.Path, quotaID)
+	task, err = c.asyncTasks.submitVolumeTask(proto.AsyncTaskSetDirQuota, volName, func(progress progressFunc) error {
+		return c.walkDirQuota(vol, quota.RootInode, progress, func(ids []uint32) ([]uint32, bool) {
+			for i, existing := range ids {
+				if existing == quotaID {
+					return append(ids[:i:i], ids[i+1:]...), true
+				}
+			}
+			return ids, false
+		})
+	})
+	return
+}
+
+// walkDirQuota updates the quota extend attribute of the inodes in the directory subtree by the update function,
+// which returns the new quota IDs of the inode and whether they are changed. The inodes which fail to be updated
+// are skipped, so that the quota of the other inodes takes effect.
+func (c *Cluster) walkDirQuota(vol *Vol, rootInode uint64, progress progressFunc, update func(ids []uint32) ([]uint32, bool)) (err error) {
+	var (
+		mw     *meta.MetaWrapper
+		total  int
+		done   int
+		failed int
+		dirs   = []uint64{rootInode}
+	)
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		total += int(mp.InodeCount)
+	}
+	if mw, err = meta.NewMetaWrapper(&meta.MetaConfig{Volume: vol.Name, Masters: c.masterAddrs()}); err != nil {
+		return
+	}
+	defer mw.Close()
+	tag := func(ino uint64) {
+		done++
+		progress(done, total)
+		info, e := mw.XAttrGet_ll(ino, proto.QuotaXAttrKey)
+		if e != nil {
+			failed++
+			log.LogWarnf("action[walkDirQuota] vol[%v] get quota of inode[%v] err[%v]", vol.Name, ino, e)
+			return
+		}
+		ids, changed := update(proto.ParseQuotaIDs(string(info.Get(proto.QuotaXAttrKey))))
+		if !changed {
+			return
+		}
+		if len(ids) == 0 {
+			e = mw.XAttrDel_ll(ino, proto.QuotaXAttrKey)
+		} else {
+			e = mw.XAttrSet_ll(ino, []byte(proto.QuotaXAttrKey), []byte(proto.FormatQuotaIDs(ids)))
+		}
+		if e != nil {
+			failed++
+			log.LogWarnf("action[walkDirQuota] vol[%v] update quota of inode[%v] err[%v]", vol.Name, ino, e)
+		}
+	}
+	for len(dirs) > 0 {
+		dir := dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+		tag(dir)
+		children, e := mw.ReadDir_ll(dir)
+		if e != nil {
+			failed++
+			log.LogWarnf("action[walkDirQuota] vol[%v] read dir[%v] err[%v]", vol.Name, dir, e)
+			continue
+		}
+		for _, child := range children {
+			if proto.IsDir(child.Type) {
+				dirs = append(dirs, child.Inode)
+			} else {
+				tag(child.Inode)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update the quota of [%v] inodes in vol[%v]", failed, vol.Name)
+	}
+	return
+}
@@ -0,0 +1,36 @@
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestDirQuotaUsage(t *testing.T) {
+	vol := newVol(1, "quota", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	vol.dirQuotas = map[uint32]*proto.DirQuota{
+		2: {QuotaID: 2, Path: "/b", MaxFiles: 10},
+		1: {QuotaID: 1, Path: "/a", MaxBytes: 100},
+	}
+	for i := uint64(1); i <= 2; i++ {
+		mp := newMetaPartition(i, (i-1)*100, i*100, 3, vol.Name, vol.ID)
+		mp.quotaUsage = map[uint32]*proto.QuotaUsage{1: {UsedBytes: 60, UsedFiles: 1}}
+		vol.addMetaPartition(mp)
+	}
+	quotas := vol.getDirQuotas()
+	if len(quotas) != 2 || quotas[0].QuotaID != 1 || quotas[1].QuotaID != 2 {
+		t.Fatalf("expect quotas sorted by ID, but got %v", quotas)
+	}
+	if quotas[0].UsedBytes != 120 || quotas[0].UsedFiles != 2 || !quotas[0].BytesExceeded() {
+		t.Errorf("expect quota 1 used 120 bytes and 2 files, but got %v", *quotas[0])
+	}
+	if quotas[1].UsedFiles != 0 || quotas[1].FilesExceeded() {
+		t.Errorf("expect quota 2 not used, but got %v", *quotas[1])
+	}
+	if vol.dirQuotas[1].UsedBytes != 0 {
+		t.Errorf("expect the usage not saved in the quota of the volume")
+	}
+	if ids := proto.ParseQuotaIDs(proto.FormatQuotaIDs([]uint32{3, 1})); len(ids) != 2 || ids[0] != 3 || ids[1] != 1 {
+		t.Errorf("expect quota IDs [3 1], but got %v", ids)
+	}
+}
@@ -84,9 +84,17 @@ func (c *Cluster) checkDiskRecoveryProgress() {
 func (c *Cluster) decommissionDisk(dataNode *DataNode, badDiskPath string, badPartitions []*DataPartition) (err error) {
 	msg := fmt.Sprintf("action[decommissionDisk], Node[%v] OffLine,disk[%v]", dataNode.Addr, badDiskPath)
 	log.LogWarn(msg)
+	defer func() {
+		c.emitDecommissionFinished(dataNode.Addr, fmt.Sprintf("disk[%v]", badDiskPath), err)
+	}()
 
+	limiter := newDecommissionLimiter(&c.cfg.DataNodeDecommissionLimit)
 	for _, dp := range badPartitions {
-		if err = c.decommissionDataPartition(dataNode.Addr, dp, diskOfflineErr); err != nil {
+		limiter.wait()
+		limiter.start()
+		err = c.decommissionDataPartition(dataNode.Addr, dp, diskOfflineErr)
+		limiter.done(dp, err)
+		if err != nil {
 			return
 		}
 	}
@@ -95,3 +103,38 @@ func (c *Cluster) decommissionDisk(dataNode *DataNode, badDiskPath string, badPa
 	Warn(c.Name, msg)
 	return
 }
+
+// migrateDiskPartitions decommissions the replicas of all the data partitions on the disk one by one. Unlike
+// decommissionDisk, it goes on with the other partitions if a partition fails, so that as much data as possible
+// is moved off a failing disk, and the failed partitions are returned in the error.
+func (c *Cluster) migrateDiskPartitions(dataNode *DataNode, diskPath string, progress progressFunc) (err error) {
+	badPartitions := dataNode.badPartitions(diskPath, c)
+	failedIDs := make([]uint64, 0)
+	defer func() {
+		c.emitDecommissionFinished(dataNode.Addr, fmt.Sprintf("disk[%v]", diskPath), err)
+	}()
+	log.LogWarnf("action[migrateDiskPartitions] clusterID[%v] node[%v] disk[%v] partitions[%v]",
+		c.Name, dataNode.Addr, diskPath, len(badPartitions))
+	progress(0, len(badPartitions))
+	limiter := newDecommissionLimiter(&c.cfg.DataNodeDecommissionLimit)
+	for i, dp := range badPartitions {
+		limiter.wait()
+		limiter.start()
+		err = c.decommissionDataPartition(dataNode.Addr, dp, diskOfflineErr)
+		limiter.done(dp, err)
+		if err != nil {
+			log.LogErrorf("action[migrateDiskPartitions] clusterID[%v] node[%v] disk[%v] partitionID[%v] err[%v]",
+				c.Name, dataNode.Addr, diskPath, dp.PartitionID, err)
+			failedIDs = append(failedIDs, dp.PartitionID)
+		}
+		progress(i+1, len(badPartitions))
+	}
+	if len(failedIDs) != 0 {
+		err = fmt.Errorf("failed to decommission %v of %v partitions on disk[%v], partitionIDs%v",
+			len(failedIDs), len(badPartitions), diskPath, failedIDs)
+		return
+	}
+	Warn(c.Name, fmt.Sprintf("action[migrateDiskPartitions],clusterID[%v] Node[%v] disk[%v] OffLine success",
+		c.Name, dataNode.Addr, diskPath))
+	return
+}
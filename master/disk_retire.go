@@ -0,0 +1,189 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The data nodes collect the SMART attributes of their disks and report them in the heartbeats. The leader master
+// predicts the failure of a disk by its attributes, marks the disk retiring and migrates the data partitions off it
+// before it fails. The retiring disks are persisted with the data nodes and sent to them in the heartbeats, the data
+// nodes create no partition on them. A disk keeps retiring until the admin cancels it, e.g. after it is replaced.
+//
+// The retiring disks of a node are replaced as a whole like the labels, the map is never modified in place.
+
+const (
+	defaultDiskRetireSectorThreshold = 100
+	diskRetireReasonAdmin            = "retired by the admin"
+	nvmeWarningTemperature           = 0x2 // the bit of the critical warning which is transient
+)
+
+// diskFailureReason returns why the failure of the disk is predicted by its SMART attributes, empty if it is not.
+// The disk is predicted to fail if it fails the health self-assessment, any ATA attribute is at or below its
+// threshold, the bad sectors reach the threshold, or the NVMe disk warns of anything other than the temperature,
+// uses up its life or runs out of the spare.
+func diskFailureReason(smart *proto.DiskSmart, sectorThreshold uint64) string {
+	if smart == nil || smart.Error != "" {
+		return ""
+	}
+	badSectors := smart.ReallocatedSectors + smart.PendingSectors + smart.UncorrectableSectors
+	switch {
+	case !smart.Passed:
+		return "failed the SMART health self-assessment"
+	case len(smart.FailingAttributes) != 0:
+		return fmt.Sprintf("SMART attributes [%v] are at or below the thresholds", strings.Join(smart.FailingAttributes, ","))
+	case sectorThreshold > 0 && badSectors >= sectorThreshold:
+		return fmt.Sprintf("%v reallocated, %v pending and %v uncorrectable sectors", smart.ReallocatedSectors,
+			smart.PendingSectors, smart.UncorrectableSectors)
+	case smart.CriticalWarning&^nvmeWarningTemperature != 0:
+		return fmt.Sprintf("NVMe critical warning 0x%x", smart.CriticalWarning)
+	case smart.PercentageUsed >= 100:
+		return fmt.Sprintf("%v%% of the NVMe life is used", smart.PercentageUsed)
+	case smart.AvailableSpareThreshold > 0 && smart.AvailableSpare < smart.AvailableSpareThreshold:
+		return fmt.Sprintf("NVMe available spare %v%% is below the threshold %v%%", smart.AvailableSpare,
+			smart.AvailableSpareThreshold)
+	}
+	return ""
+}
+
+func (dataNode *DataNode) getRetiringDisks() map[string]string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return dataNode.retiringDisks
+}
+
+func (dataNode *DataNode) retiringDiskPaths() (paths []string) {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	for path := range dataNode.retiringDisks {
+		paths = append(paths, path)
+	}
+	return
+}
+
+// checkDiskSmarts retires the disks of the data node whose failure is predicted by the SMART attributes.
+func (c *Cluster) checkDiskSmarts(dataNode *DataNode, smarts []*proto.DiskSmart) {
+	for _, smart := range smarts {
+		reason := diskFailureReason(smart, c.cfg.diskRetireSectorThreshold)
+		if reason == "" {
+			continue
+		}
+		if _, ok := dataNode.getRetiringDisks()[smart.Path]; ok {
+			continue
+		}
+		if err := c.setDiskRetiring(dataNode, smart.Path, true, reason); err != nil {
+			log.LogErrorf("action[checkDiskSmarts] clusterID[%v] node[%v] disk[%v] err[%v]", c.Name, dataNode.Addr,
+				smart.Path, err)
+		}
+	}
+}
+
+// setDiskRetiring marks the disk of the data node retiring for the reason or cancels it. The data partitions are
+// migrated off the newly retiring disk in background unless diskRetireMigrate is disabled.
+func (c *Cluster) setDiskRetiring(dataNode *DataNode, diskPath string, retiring bool, reason string) (err error) {
+	dataNode.Lock()
+	oldDisks := dataNode.retiringDisks
+	if _, ok := oldDisks[diskPath]; ok == retiring {
+		dataNode.Unlock()
+		return
+	}
+	disks := make(map[string]string, len(oldDisks)+1)
+	for path, r := range oldDisks {
+		disks[path] = r
+	}
+	if retiring {
+		disks[diskPath] = reason
+	} else {
+		delete(disks, diskPath)
+	}
+	if len(disks) == 0 {
+		disks = nil
+	}
+	dataNode.retiringDisks = disks
+	dataNode.Unlock()
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		log.LogErrorf("action[setDiskRetiring] node[%v] disk[%v] err[%v]", dataNode.Addr, diskPath, err)
+		dataNode.Lock()
+		dataNode.retiringDisks = oldDisks
+		dataNode.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	if !retiring {
+		log.LogWarnf("action[setDiskRetiring] clusterID[%v] node[%v] disk[%v] is not retiring any more", c.Name,
+			dataNode.Addr, diskPath)
+		return
+	}
+	msg := fmt.Sprintf("disk[%v] is retiring, %v", diskPath, reason)
+	Warn(c.Name, fmt.Sprintf("clusterID[%v] node[%v] %v", c.Name, dataNode.Addr, msg))
+	c.events.emit(proto.EventDiskRetiring, dataNode.Addr, "", 0, msg)
+	if c.cfg.diskRetireMigrate {
+		c.migrateRetiringDisk(dataNode, diskPath)
+	}
+	return
+}
+
+// migrateRetiringDisk submits the task to migrate the data partitions off the retiring disk, which fails if the
+// disk is being decommissioned.
+func (c *Cluster) migrateRetiringDisk(dataNode *DataNode, diskPath string) {
+	op := func(progress progressFunc) error {
+		return c.migrateDiskPartitions(dataNode, diskPath, progress)
+	}
+	task, err := c.asyncTasks.submitDiskTask(proto.AsyncTaskDecommissionDisk, dataNode.Addr, diskPath, op)
+	if err != nil {
+		log.LogWarnf("action[migrateRetiringDisk] clusterID[%v] node[%v] disk[%v] err[%v]", c.Name, dataNode.Addr,
+			diskPath, err)
+		return
+	}
+	log.LogWarnf("action[migrateRetiringDisk] clusterID[%v] node[%v] disk[%v] task[%v]", c.Name, dataNode.Addr,
+		diskPath, task.ID)
+}
+
+// setDiskRetiring marks a disk retiring by the admin or cancels it.
+func (m *Server) setDiskRetiring(w http.ResponseWriter, r *http.Request) {
+	var (
+		node               *DataNode
+		nodeAddr, diskPath string
+		retiring           bool
+		err                error
+	)
+	if nodeAddr, diskPath, err = parseRequestToDecommissionNode(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if retiring, err = extractBoolParam(r, retiringKey); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if node, err = m.cluster.dataNode(nodeAddr); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(proto.ErrDataNodeNotExists))
+		return
+	}
+	reason := r.FormValue(reasonKey)
+	if reason == "" {
+		reason = diskRetireReasonAdmin
+	}
+	if err = m.cluster.setDiskRetiring(node, diskPath, retiring, reason); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("set disk[%v] of node[%v] retiring[%v] successfully",
+		diskPath, nodeAddr, retiring)))
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestDiskFailureReason(t *testing.T) {
+	cases := []struct {
+		name    string
+		smart   *proto.DiskSmart
+		failing bool
+	}{
+		{"healthy", &proto.DiskSmart{Passed: true, ReallocatedSectors: 10}, false},
+		{"not collected", &proto.DiskSmart{Error: "no device"}, false},
+		{"health failed", &proto.DiskSmart{Passed: false}, true},
+		{"failing attribute", &proto.DiskSmart{Passed: true, FailingAttributes: []string{"Spin_Up_Time"}}, true},
+		{"bad sectors", &proto.DiskSmart{Passed: true, ReallocatedSectors: 60, PendingSectors: 30, UncorrectableSectors: 10}, true},
+		{"nvme temperature", &proto.DiskSmart{Passed: true, CriticalWarning: nvmeWarningTemperature}, false},
+		{"nvme warning", &proto.DiskSmart{Passed: true, CriticalWarning: 0x4}, true},
+		{"nvme worn out", &proto.DiskSmart{Passed: true, PercentageUsed: 100}, true},
+		{"nvme spare", &proto.DiskSmart{Passed: true, AvailableSpare: 5, AvailableSpareThreshold: 10}, true},
+	}
+	for _, c := range cases {
+		if reason := diskFailureReason(c.smart, defaultDiskRetireSectorThreshold); (reason != "") != c.failing {
+			t.Errorf("%v: reason %q", c.name, reason)
+		}
+	}
+	bad := &proto.DiskSmart{Passed: true, PendingSectors: 1000}
+	if reason := diskFailureReason(bad, 0); reason != "" {
+		t.Errorf("bad sectors are checked with the threshold 0: %q", reason)
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/cryptoutil"
+)
+
+// The data of the encrypted volumes is encrypted at rest by the data nodes with the data keys of the volumes. The
+// master generates the data keys by the key manager and keeps them wrapped in the metadata of the volumes, a volume
+// has a new key of the next version when its key is rotated, and the old keys are kept for the extents encrypted by
+// them until the data nodes encrypt them again. The data nodes get the unwrapped keys by a ticket of the authnode.
+//
+// The key manager "authnode" wraps the keys by the key derived from the key of the master service issued by the
+// authnode, and "vault" by the transit secrets engine of HashiCorp Vault. The keys are unwrapped by the manager which
+// wrapped them, so the key manager can be changed without rotating the keys.
+
+const (
+	volKeyManagerAuthnode = "authnode"
+	volKeyManagerVault    = "vault"
+	volDataKeySize        = 32 // AES-256
+	vaultRequestTimeout   = 10 * time.Second
+)
+
+// volEncryptionKey is a data key of an encrypted volume wrapped by the key manager.
+type volEncryptionKey struct {
+	Version    uint32
+	WrappedKey string
+	CreateTime int64
+}
+
+// volKeyManager generates the data keys of the volumes and unwraps them.
+type volKeyManager interface {
+	generateKey(volName string) (key []byte, wrapped string, err error)
+	unwrapKey(volName, wrapped string) (key []byte, err error)
+}
+
+// authnodeKeyManager wraps the data keys by AES-GCM with the key derived from the key of the master service and the
+// name of the volume.
+type authnodeKeyManager struct {
+	serviceKey []byte
+}
+
+const authnodeWrappedKeyPrefix = volKeyManagerAuthnode + ":"
+
+func (km *authnodeKeyManager) aead(volName string) (aead cipher.AEAD, err error) {
+	if len(km.serviceKey) == 0 {
+		return nil, fmt.Errorf("%v is not configured", SecretKey)
+	}
+	mac := hmac.New(sha256.New, km.serviceKey)
+	mac.Write([]byte("volume key:" + volName))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
+
+func (km *authnodeKeyManager) generateKey(volName string) (key []byte, wrapped string, err error) {
+	aead, err := km.aead(volName)
+	if err != nil {
+		return
+	}
+	key = make([]byte, volDataKeySize)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return
+	}
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return
+	}
+	wrapped = authnodeWrappedKeyPrefix + cryptoutil.Base64Encode(aead.Seal(nonce, nonce, key, []byte(volName)))
+	return
+}
+
+func (km *authnodeKeyManager) unwrapKey(volName, wrapped string) (key []byte, err error) {
+	aead, err := km.aead(volName)
+	if err != nil {
+		return
+	}
+	sealed, err := cryptoutil.Base64Decode(strings.TrimPrefix(wrapped, authnodeWrappedKeyPrefix))
+	if err != nil {
+		return
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped key of volume[%v] is too short", volName)
+	}
+	if key, err = aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(volName)); err != nil {
+		return nil, fmt.Errorf("unwrap key of volume[%v]: %v", volName, err)
+	}
+	return
+}
+
+// vaultKeyManager generates and unwraps the data keys by the transit secrets engine of Vault, whose wrapped keys
+// start with "vault:".
+type vaultKeyManager struct {
+	addr    string
+	token   string
+	keyName string
+}
+
+const vaultWrappedKeyPrefix = volKeyManagerVault + ":"
+
+func (km *vaultKeyManager) post(path string, req interface{}) (data map[string]string, err error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(km.addr, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	httpReq.Header.Set("X-Vault-Token", km.token)
+	httpReq.Header.Set("Content-Type", "application/json")
+	client := &http.Client{Timeout: vaultRequestTimeout}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault %v: status code[%v] body[%v]", path, resp.StatusCode, string(body))
+	}
+	reply := struct {
+		Data map[string]string `json:"data"`
+	}{}
+	if err = json.Unmarshal(body, &reply); err != nil {
+		return nil, fmt.Errorf("vault %v: %v", path, err)
+	}
+	return reply.Data, nil
+}
+
+func (km *vaultKeyManager) generateKey(volName string) (key []byte, wrapped string, err error) {
+	data, err := km.post("/v1/transit/datakey/plaintext/"+km.keyName, map[string]interface{}{"bits": volDataKeySize * 8})
+	if err != nil {
+		return
+	}
+	if key, err = cryptoutil.Base64Decode(data["plaintext"]); err != nil {
+		return
+	}
+	if len(key) != volDataKeySize || !strings.HasPrefix(data["ciphertext"], vaultWrappedKeyPrefix) {
+		return nil, "", fmt.Errorf("vault returns invalid data key for volume[%v]", volName)
+	}
+	return key, data["ciphertext"], nil
+}
+
+func (km *vaultKeyManager) unwrapKey(volName, wrapped string) (key []byte, err error) {
+	data, err := km.post("/v1/transit/decrypt/"+km.keyName, map[string]string{"ciphertext": wrapped})
+	if err != nil {
+		return
+	}
+	if key, err = cryptoutil.Base64Decode(data["plaintext"]); err != nil {
+		return
+	}
+	if len(key) != volDataKeySize {
+		return nil, fmt.Errorf("vault returns invalid data key for volume[%v]", volName)
+	}
+	return
+}
+
+func (c *Cluster) volKeyManager(wrapped string) (km volKeyManager, err error) {
+	manager := c.cfg.volKeyManager
+	if wrapped != "" {
+		manager = strings.SplitN(wrapped, ":", 2)[0]
+	}
+	switch manager {
+	case volKeyManagerAuthnode:
+		return &authnodeKeyManager{serviceKey: c.MasterSecretKey}, nil
+	case volKeyManagerVault:
+		if c.cfg.vaultAddr == "" || c.cfg.vaultKeyName == "" {
+			return nil, fmt.Errorf("%v and %v are required by the key manager %v", cfgVaultAddr, cfgVaultKeyName,
+				volKeyManagerVault)
+		}
+		return &vaultKeyManager{addr: c.cfg.vaultAddr, token: c.cfg.vaultToken, keyName: c.cfg.vaultKeyName}, nil
+	}
+	return nil, fmt.Errorf("unknown key manager[%v]", manager)
+}
+
+// newVolEncryptionKey generates the data key of the volume of the next version.
+func (c *Cluster) newVolEncryptionKey(volName string, keys []*volEncryptionKey) (key *volEncryptionKey, err error) {
+	km, err := c.volKeyManager("")
+	if err != nil {
+		return
+	}
+	dataKey, wrapped, err := km.generateKey(volName)
+	if err != nil {
+		return
+	}
+	key = &volEncryptionKey{Version: 1, WrappedKey: wrapped, CreateTime: time.Now().Unix()}
+	if len(keys) != 0 {
+		key.Version = keys[len(keys)-1].Version + 1
+	}
+	c.volDataKeys.Store(volName+"/"+wrapped, dataKey)
+	return
+}
+
+// unwrapVolKey returns the data key of the volume, the keys unwrapped are cached since they never change.
+func (c *Cluster) unwrapVolKey(volName string, key *volEncryptionKey) (dataKey []byte, err error) {
+	cacheKey := volName + "/" + key.WrappedKey
+	if value, ok := c.volDataKeys.Load(cacheKey); ok {
+		return value.([]byte), nil
+	}
+	km, err := c.volKeyManager(key.WrappedKey)
+	if err != nil {
+		return
+	}
+	if dataKey, err = km.unwrapKey(volName, key.WrappedKey); err != nil {
+		return
+	}
+	c.volDataKeys.Store(cacheKey, dataKey)
+	return
+}
+
+// mergeVolEncryptionKeys returns the keys of the volume updated by the update of the volume. The keys are only
+// appended, so the keys of an update made on the stale metadata are ignored if they are the leading part of the
+// current keys.
+func mergeVolEncryptionKeys(current, keys []*volEncryptionKey) (merged []*volEncryptionKey, err error) {
+	n := len(current)
+	if len(keys) < n {
+		n = len(keys)
+	}
+	for i := 0; i < n; i++ {
+		if *current[i] != *keys[i] {
+			return nil, fmt.Errorf("the encryption key of version[%v] can not be changed", current[i].Version)
+		}
+	}
+	if len(keys) < len(current) {
+		return current, nil
+	}
+	return keys, nil
+}
+
+// encryptedVolKeyVersion returns the version of the current key of the volume, 0 if it is not encrypted.
+func encryptedVolKeyVersion(keys []*volEncryptionKey) uint32 {
+	if len(keys) == 0 {
+		return 0
+	}
+	return keys[len(keys)-1].Version
+}
+
+// rotateVolKey generates a new data key of the encrypted volume, which the new extents are encrypted with.
+func (m *Server) rotateVolKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		name    string
+		authKey string
+		key     *volEncryptionKey
+		vol     *Vol
+		err     error
+	)
+	if name, authKey, err = parseVolNameAndAuthKey(r); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: err.Error()})
+		return
+	}
+	if vol, err = m.cluster.getVol(name); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeVolNotExists, Msg: err.Error()})
+		return
+	}
+	newArgs := getVolVarargs(vol)
+	if len(newArgs.encryptionKeys) == 0 {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeParamError, Msg: fmt.Sprintf("vol[%v] is not encrypted", name)})
+		return
+	}
+	if key, err = m.cluster.newVolEncryptionKey(name, newArgs.encryptionKeys); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	newArgs.encryptionKeys = append(newArgs.encryptionKeys[:len(newArgs.encryptionKeys):len(newArgs.encryptionKeys)], key)
+	if err = m.cluster.updateVol(name, authKey, newArgs); err != nil {
+		sendErrReply(w, r, newErrHTTPReply(err))
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(fmt.Sprintf("rotate key of vol[%v] to version[%v] successfully\n", name, key.Version)))
+}
+
+// getVolEncryptionKeys replies the data keys of the encrypted volumes to the data nodes. The caller must have a
+// ticket of the authnode with the caps "API:master:getvolkeys:access" in the header "Auth-Ticket", and the reply is
+// encrypted by the session key of the ticket.
+func (m *Server) getVolEncryptionKeys(w http.ResponseWriter, r *http.Request) {
+	req, ticket, ts, err := decodeAuthTicket(r.Header.Get(proto.AuthTicket), m.cluster.MasterSecretKey)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInvalidTicket, Msg: err.Error()})
+		return
+	}
+	if err = proto.CheckAPIAccessCaps(&ticket, proto.APIRsc, proto.MsgMasterFetchVolKeysReq, proto.APIAccess); err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeNoPermission, Msg: err.Error()})
+		return
+	}
+	volKeys := make(map[string]*proto.VolEncryptionKeys)
+	for name, vol := range m.cluster.copyVols() {
+		vol.RLock()
+		keys := vol.encryptionKeys
+		vol.RUnlock()
+		if len(keys) == 0 {
+			continue
+		}
+		view := &proto.VolEncryptionKeys{Current: encryptedVolKeyVersion(keys), Keys: make(map[uint32]string, len(keys))}
+		for _, key := range keys {
+			dataKey, unwrapErr := m.cluster.unwrapVolKey(name, key)
+			if unwrapErr != nil {
+				sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: unwrapErr.Error()})
+				return
+			}
+			view.Keys[key.Version] = cryptoutil.Base64Encode(dataKey)
+		}
+		volKeys[name] = view
+	}
+	data, err := json.Marshal(volKeys)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeInternalError, Msg: err.Error()})
+		return
+	}
+	message, err := genRespMessage(data, &req, ts, ticket.SessionKey.Key)
+	if err != nil {
+		sendErrReply(w, r, &proto.HTTPReply{Code: proto.ErrCodeMasterAPIGenRespError, Msg: err.Error()})
+		return
+	}
+	sendOkReply(w, r, newSuccessHTTPReply(message))
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/util/cryptoutil"
+)
+
+func TestAuthnodeKeyManager(t *testing.T) {
+	km := &authnodeKeyManager{serviceKey: []byte("0123456789abcdef0123456789abcdef")}
+	key, wrapped, err := km.generateKey("vol1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != volDataKeySize {
+		t.Fatalf("key size %v", len(key))
+	}
+	unwrapped, err := km.unwrapKey("vol1", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("unwrap key %v err %v", unwrapped, err)
+	}
+	if _, err = km.unwrapKey("vol2", wrapped); err == nil {
+		t.Fatal("key of vol1 is unwrapped for vol2")
+	}
+	other := &authnodeKeyManager{serviceKey: []byte("fedcba9876543210fedcba9876543210")}
+	if _, err = other.unwrapKey("vol1", wrapped); err == nil {
+		t.Fatal("key is unwrapped by another service key")
+	}
+	if _, _, err = (&authnodeKeyManager{}).generateKey("vol1"); err == nil {
+		t.Fatal("key is generated without the service key")
+	}
+}
+
+func TestVaultKeyManager(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, volDataKeySize)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		data := map[string]string{"plaintext": cryptoutil.Base64Encode(key)}
+		switch r.URL.Path {
+		case "/v1/transit/datakey/plaintext/cfs":
+			data["ciphertext"] = "vault:v1:wrapped"
+		case "/v1/transit/decrypt/cfs":
+			req := make(map[string]string)
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["ciphertext"] != "vault:v1:wrapped" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer vault.Close()
+
+	km := &vaultKeyManager{addr: vault.URL, token: "token", keyName: "cfs"}
+	generated, wrapped, err := km.generateKey("vol1")
+	if err != nil || !bytes.Equal(generated, key) || wrapped != "vault:v1:wrapped" {
+		t.Fatalf("generate key %v wrapped %v err %v", generated, wrapped, err)
+	}
+	unwrapped, err := km.unwrapKey("vol1", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("unwrap key %v err %v", unwrapped, err)
+	}
+	km.token = "bad"
+	if _, err = km.unwrapKey("vol1", wrapped); err == nil {
+		t.Fatal("key is unwrapped by a bad token")
+	}
+}
+
+func TestMergeVolEncryptionKeys(t *testing.T) {
+	v1 := &volEncryptionKey{Version: 1, WrappedKey: "authnode:a"}
+	v2 := &volEncryptionKey{Version: 2, WrappedKey: "authnode:b"}
+	current := []*volEncryptionKey{v1, v2}
+	if merged, err := mergeVolEncryptionKeys(current, []*volEncryptionKey{v1}); err != nil || len(merged) != 2 {
+		t.Fatalf("stale keys are merged into %v err %v", merged, err)
+	}
+	if merged, err := mergeVolEncryptionKeys(current, nil); err != nil || len(merged) != 2 {
+		t.Fatalf("no keys are merged into %v err %v", merged, err)
+	}
+	v3 := &volEncryptionKey{Version: 3, WrappedKey: "authnode:c"}
+	if merged, err := mergeVolEncryptionKeys(current, []*volEncryptionKey{v1, v2, v3}); err != nil || len(merged) != 3 {
+		t.Fatalf("new key is merged into %v err %v", merged, err)
+	}
+	changed := &volEncryptionKey{Version: 2, WrappedKey: "authnode:x"}
+	if _, err := mergeVolEncryptionKeys(current, []*volEncryptionKey{v1, changed, v3}); err == nil {
+		t.Fatal("key of version 2 is changed")
+	}
+	if version := encryptedVolKeyVersion(current); version != 2 {
+		t.Fatalf("current version %v", version)
+	}
+}
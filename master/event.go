@@ -0,0 +1,208 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	eventQueueSize     = 1024
+	eventHistorySize   = 1000
+	eventBatchSize     = 100
+	eventSendTimeout   = 5 * time.Second
+	eventSendRetries   = 3
+	eventRetryInterval = time.Second
+)
+
+// eventSink defines the destination to push the events to.
+type eventSink interface {
+	String() string
+	send(events []*proto.Event) error
+}
+
+// webhookSink posts the events as a json array to the url.
+type webhookSink struct {
+	url string
+}
+
+func (s *webhookSink) String() string {
+	return fmt.Sprintf("webhook[%v]", s.url)
+}
+
+func (s *webhookSink) send(events []*proto.Event) (err error) {
+	var body []byte
+	if body, err = json.Marshal(events); err != nil {
+		return
+	}
+	return postEvents(s.url, "application/json", body)
+}
+
+// kafkaSink produces the events to the kafka topic by the kafka REST proxy.
+type kafkaSink struct {
+	proxy string
+	topic string
+}
+
+func (s *kafkaSink) String() string {
+	return fmt.Sprintf("kafka[%v/%v]", s.proxy, s.topic)
+}
+
+func (s *kafkaSink) send(events []*proto.Event) (err error) {
+	type record struct {
+		Value *proto.Event `json:"value"`
+	}
+	records := make([]record, 0, len(events))
+	for _, event := range events {
+		records = append(records, record{Value: event})
+	}
+	var body []byte
+	if body, err = json.Marshal(map[string][]record{"records": records}); err != nil {
+		return
+	}
+	url := fmt.Sprintf("%v/topics/%v", strings.TrimSuffix(s.proxy, "/"), s.topic)
+	return postEvents(url, "application/vnd.kafka.json.v2+json", body)
+}
+
+func postEvents(url, contentType string, body []byte) (err error) {
+	client := &http.Client{Timeout: eventSendTimeout}
+	resp, err := client.Post(url, contentType, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status code[%v]", resp.StatusCode)
+	}
+	return
+}
+
+// eventBus records the recent events emitted by the master, and pushes them to the sinks in the background. The
+// events are dropped if the sinks fall behind, so that emitting an event never blocks the master.
+type eventBus struct {
+	sync.RWMutex
+	cluster string
+	sinks   []eventSink
+	queue   chan *proto.Event
+	history []*proto.Event
+	nextID  uint64
+}
+
+func newEventBus(cluster string, cfg *clusterConfig) (bus *eventBus) {
+	bus = &eventBus{cluster: cluster, queue: make(chan *proto.Event, eventQueueSize)}
+	for _, url := range cfg.eventWebhooks {
+		bus.sinks = append(bus.sinks, &webhookSink{url: url})
+	}
+	if cfg.eventKafkaProxy != "" {
+		bus.sinks = append(bus.sinks, &kafkaSink{proxy: cfg.eventKafkaProxy, topic: cfg.eventKafkaTopic})
+	}
+	if len(bus.sinks) != 0 {
+		go bus.deliver()
+	}
+	return
+}
+
+func (bus *eventBus) emit(eventType, addr, volName string, partitionID uint64, msg string) {
+	bus.Lock()
+	bus.nextID++
+	event := &proto.Event{
+		ID:          bus.nextID,
+		Time:        time.Now().Unix(),
+		Cluster:     bus.cluster,
+		Type:        eventType,
+		Addr:        addr,
+		VolName:     volName,
+		PartitionID: partitionID,
+		Message:     msg,
+	}
+	bus.history = append(bus.history, event)
+	if len(bus.history) > eventHistorySize {
+		bus.history = bus.history[len(bus.history)-eventHistorySize:]
+	}
+	bus.Unlock()
+	log.LogWarnf("action[emitEvent] event[%v] id[%v] addr[%v] vol[%v] partition[%v] msg[%v]",
+		eventType, event.ID, addr, volName, partitionID, msg)
+	if len(bus.sinks) == 0 {
+		return
+	}
+	select {
+	case bus.queue <- event:
+	default:
+		log.LogErrorf("action[emitEvent] event queue is full, event[%v] id[%v] dropped", eventType, event.ID)
+	}
+}
+
+// list returns the recent events after the event ID in order, filtered by the type if it is not empty.
+func (bus *eventBus) list(since uint64, eventType string, limit int) (events []*proto.Event) {
+	bus.RLock()
+	defer bus.RUnlock()
+	events = make([]*proto.Event, 0)
+	for _, event := range bus.history {
+		if event.ID <= since || (eventType != "" && event.Type != eventType) {
+			continue
+		}
+		events = append(events, event)
+		if limit > 0 && len(events) >= limit {
+			break
+		}
+	}
+	return
+}
+
+func (bus *eventBus) deliver() {
+	for event := range bus.queue {
+		events := []*proto.Event{event}
+	batch:
+		for len(events) < eventBatchSize {
+			select {
+			case event = <-bus.queue:
+				events = append(events, event)
+			default:
+				break batch
+			}
+		}
+		for _, sink := range bus.sinks {
+			bus.send(sink, events)
+		}
+	}
+}
+
+func (bus *eventBus) send(sink eventSink, events []*proto.Event) {
+	var err error
+	for i := 0; i < eventSendRetries; i++ {
+		if err = sink.send(events); err == nil {
+			return
+		}
+		time.Sleep(eventRetryInterval)
+	}
+	log.LogErrorf("action[sendEvents] sink[%v] events[%v-%v] err[%v]", sink, events[0].ID, events[len(events)-1].ID, err)
+}
+
+func (c *Cluster) emitDecommissionFinished(addr, target string, err error) {
+	msg := fmt.Sprintf("decommission of %v succeeded", target)
+	if err != nil {
+		msg = fmt.Sprintf("decommission of %v failed: %v", target, err)
+	}
+	c.events.emit(proto.EventDecommissionFinished, addr, "", 0, msg)
+}
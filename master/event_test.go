@@ -0,0 +1,46 @@
+package master
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestEventBus(t *testing.T) {
+	received := make(chan []*proto.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []*proto.Event
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case received <- events:
+		default:
+		}
+	}))
+	defer server.Close()
+	cfg := newClusterConfig()
+	cfg.eventWebhooks = []string{server.URL}
+	bus := newEventBus("test", cfg)
+	bus.emit(proto.EventNodeInactive, "127.0.0.1:17310", "", 0, "data node is inactive")
+	bus.emit(proto.EventPartitionLostLeader, "", "vol", 1, "data partition lost the leader")
+	if events := bus.list(1, "", 0); len(events) != 1 || events[0].ID != 2 {
+		t.Fatalf("expect the event 2 after the event 1, but got %v", events)
+	}
+	if events := bus.list(0, proto.EventNodeInactive, 0); len(events) != 1 || events[0].Addr != "127.0.0.1:17310" {
+		t.Fatalf("expect the node inactive event, but got %v", events)
+	}
+	select {
+	case events := <-received:
+		if len(events) == 0 || events[0].Type != proto.EventNodeInactive || events[0].Cluster != "test" {
+			t.Fatalf("unexpected events received by the webhook %v", events)
+		}
+	case <-time.After(eventSendTimeout):
+		t.Fatalf("no events received by the webhook")
+	}
+}
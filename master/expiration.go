@@ -0,0 +1,125 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The expiration rules are delivered to the meta nodes by the simple view of the volume, and enforced by the leaders
+// of the meta partitions, which delete the expired files in the directory subtrees of the rules periodically.
+
+// setExpirationRule sets the days and the basis of the expiration rule of the directory, a new rule is created if
+// the directory has no rule.
+func (c *Cluster) setExpirationRule(volName, dirPath string, days uint32, basis string) (rule *proto.ExpirationRule, err error) {
+	var (
+		vol       *Vol
+		rootInode uint64
+	)
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	dirPath = path.Clean("/" + dirPath)
+	if rootInode, err = c.lookupRuleDir(vol, dirPath, "expiration"); err != nil {
+		return
+	}
+
+	vol.Lock()
+	defer vol.Unlock()
+	rules := make([]*proto.ExpirationRule, 0, len(vol.expirationRules)+1)
+	for _, r := range vol.expirationRules {
+		if r.Path == dirPath {
+			rule = &proto.ExpirationRule{RuleID: r.RuleID, Path: r.Path, RootInode: r.RootInode}
+			continue
+		}
+		rules = append(rules, r)
+	}
+	oldRules, oldMaxID := vol.expirationRules, vol.maxExpirationID
+	if rule == nil {
+		vol.maxExpirationID++
+		rule = &proto.ExpirationRule{RuleID: vol.maxExpirationID, Path: dirPath, RootInode: rootInode}
+	}
+	rule.Days, rule.Basis = days, basis
+	rules = append(rules, rule)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].RuleID < rules[j].RuleID })
+	vol.expirationRules = rules
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.expirationRules, vol.maxExpirationID = oldRules, oldMaxID
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogWarnf("action[setExpirationRule] clusterID[%v] vol[%v] path[%v] rule[%v] days[%v] basis[%v]",
+		c.Name, volName, dirPath, rule.RuleID, days, basis)
+	return
+}
+
+// lookupRuleDir returns the inode of the directory of a rule of the kind, the path of which is cleaned.
+func (c *Cluster) lookupRuleDir(vol *Vol, dirPath, kind string) (rootInode uint64, err error) {
+	if dirPath == "/" {
+		return proto.RootIno, nil
+	}
+	if vol.authenticate {
+		err = fmt.Errorf("%v rule of directory in vol[%v] with authentication is not supported", kind, vol.Name)
+		return
+	}
+	mw, err := meta.NewMetaWrapper(&meta.MetaConfig{Volume: vol.Name, Masters: c.masterAddrs()})
+	if err != nil {
+		return
+	}
+	rootInode, err = mw.GetRootIno(dirPath)
+	_ = mw.Close()
+	if err != nil {
+		err = fmt.Errorf("lookup path[%v] of vol[%v] failed: %v", dirPath, vol.Name, err)
+	}
+	return
+}
+
+// deleteExpirationRule deletes the rule, the tags of the rule left on the directories are ignored by the meta nodes.
+func (c *Cluster) deleteExpirationRule(volName string, ruleID uint32) (rule *proto.ExpirationRule, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	rules := make([]*proto.ExpirationRule, 0, len(vol.expirationRules))
+	for _, r := range vol.expirationRules {
+		if r.RuleID == ruleID {
+			rule = r
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if rule == nil {
+		err = fmt.Errorf("expiration rule[%v] of vol[%v] not exists", ruleID, volName)
+		return
+	}
+	oldRules := vol.expirationRules
+	vol.expirationRules = rules
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.expirationRules = oldRules
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogWarnf("action[deleteExpirationRule] clusterID[%v] vol[%v] path[%v] rule[%v] is deleted",
+		c.Name, volName, rule.Path, ruleID)
+	return
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	sdk "github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The peer clusters registered on the master are kept in the cluster value. The federation view is aggregated
+// on demand, the views of the peers are got from their masters concurrently, and a peer unreachable within the
+// timeout is shown with the error instead of failing the view.
+
+const federationPeerTimeout = 5 * time.Second
+
+type federation struct {
+	sync.RWMutex
+	peers map[string]*proto.FederationPeer // keyed by the name of the peer cluster
+}
+
+func newFederation() *federation {
+	return &federation{peers: make(map[string]*proto.FederationPeer)}
+}
+
+func (f *federation) getPeer(name string) (peer *proto.FederationPeer, ok bool) {
+	f.RLock()
+	defer f.RUnlock()
+	peer, ok = f.peers[name]
+	return
+}
+
+func (f *federation) putPeer(peer *proto.FederationPeer) {
+	f.Lock()
+	defer f.Unlock()
+	f.peers[peer.Name] = peer
+}
+
+func (f *federation) deletePeer(name string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.peers, name)
+}
+
+// getPeers returns the peers sorted by the regions and the names.
+func (f *federation) getPeers() (peers []*proto.FederationPeer) {
+	f.RLock()
+	defer f.RUnlock()
+	peers = make([]*proto.FederationPeer, 0, len(f.peers))
+	for _, peer := range f.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Region != peers[j].Region {
+			return peers[i].Region < peers[j].Region
+		}
+		return peers[i].Name < peers[j].Name
+	})
+	return
+}
+
+func (f *federation) setPeers(peers []*proto.FederationPeer) {
+	f.Lock()
+	defer f.Unlock()
+	f.peers = make(map[string]*proto.FederationPeer, len(peers))
+	for _, peer := range peers {
+		f.peers[peer.Name] = peer
+	}
+}
+
+func (c *Cluster) addFederationPeer(peer *proto.FederationPeer) (err error) {
+	if peer.Name == c.Name {
+		return fmt.Errorf("federation peer[%v] is the local cluster", peer.Name)
+	}
+	oldPeer, exist := c.federation.getPeer(peer.Name)
+	peer.AddTime = time.Now().Unix()
+	c.federation.putPeer(peer)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[addFederationPeer] peer[%v] err[%v]", peer.Name, err)
+		if exist {
+			c.federation.putPeer(oldPeer)
+		} else {
+			c.federation.deletePeer(peer.Name)
+		}
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[addFederationPeer] peer[%v] region[%v] masters%v", peer.Name, peer.Region, peer.Masters)
+	return
+}
+
+func (c *Cluster) removeFederationPeer(name string) (err error) {
+	oldPeer, exist := c.federation.getPeer(name)
+	if !exist {
+		return fmt.Errorf("federation peer[%v] not found", name)
+	}
+	c.federation.deletePeer(name)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[removeFederationPeer] peer[%v] err[%v]", name, err)
+		c.federation.putPeer(oldPeer)
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[removeFederationPeer] peer[%v]", name)
+	return
+}
+
+// federationView aggregates the views of the local cluster and the peers.
+func (c *Cluster) federationView() *proto.FederationView {
+	peers := c.federation.getPeers()
+	clusters := make([]*proto.FederationClusterView, len(peers)+1)
+	clusters[0] = c.localFederationClusterView()
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer *proto.FederationPeer) {
+			defer wg.Done()
+			clusters[i+1] = c.peerFederationClusterView(peer)
+		}(i, peer)
+	}
+	wg.Wait()
+	return summarizeFederation(clusters)
+}
+
+func (c *Cluster) localFederationClusterView() (view *proto.FederationClusterView) {
+	view = &proto.FederationClusterView{
+		Name:       c.Name,
+		Region:     c.cfg.region,
+		Local:      true,
+		Masters:    c.masterAddrs(),
+		LeaderAddr: c.leaderInfo.addr,
+		Reachable:  true,
+	}
+	fillFederationClusterView(view, c.dataNodeStatInfo, c.metaNodeStatInfo, len(c.allVolNames()),
+		c.allDataNodes(), c.allMetaNodes())
+	health, err := c.checkHealth(defaultHealthMaxRaftLag, defaultHealthCapacityThreshold)
+	if err != nil {
+		view.Error = err.Error()
+		return
+	}
+	view.Healthy, view.Issues = health.Healthy, health.Issues
+	return
+}
+
+func (c *Cluster) peerFederationClusterView(peer *proto.FederationPeer) (view *proto.FederationClusterView) {
+	view = &proto.FederationClusterView{
+		Name:    peer.Name,
+		Region:  peer.Region,
+		Masters: peer.Masters,
+	}
+	mc := sdk.NewMasterClient(peer.Masters, false)
+	mc.SetRequestTimeout(federationPeerTimeout)
+	mc.SetRetry(0, 0, 0)
+	mc.SetAuthToken(c.cfg.federationAuthToken)
+	cv, err := mc.AdminAPI().GetCluster()
+	if err != nil {
+		log.LogWarnf("action[peerFederationClusterView] get cluster of peer[%v] err[%v]", peer.Name, err)
+		view.Error = err.Error()
+		return
+	}
+	view.Reachable = true
+	view.LeaderAddr = cv.LeaderAddr
+	fillFederationClusterView(view, cv.DataNodeStatInfo, cv.MetaNodeStatInfo, len(cv.VolStatInfo), cv.DataNodes, cv.MetaNodes)
+	if cv.Name != peer.Name {
+		view.Error = fmt.Sprintf("the name of the cluster is %v", cv.Name)
+		return
+	}
+	health, err := mc.AdminAPI().GetClusterHealth(0, 0)
+	if err != nil {
+		log.LogWarnf("action[peerFederationClusterView] get health of peer[%v] err[%v]", peer.Name, err)
+		view.Error = err.Error()
+		return
+	}
+	view.Healthy, view.Issues = health.Healthy, health.Issues
+	return
+}
+
+func fillFederationClusterView(view *proto.FederationClusterView, dataStat, metaStat *proto.NodeStatInfo, volumes int,
+	dataNodes, metaNodes []proto.NodeView) {
+	view.DataNodeStatInfo, view.MetaNodeStatInfo = dataStat, metaStat
+	view.Volumes = volumes
+	view.DataNodes, view.MetaNodes = len(dataNodes), len(metaNodes)
+	for _, node := range dataNodes {
+		if !node.Status {
+			view.InactiveDataNodes++
+		}
+	}
+	for _, node := range metaNodes {
+		if !node.Status {
+			view.InactiveMetaNodes++
+		}
+	}
+}
+
+// summarizeFederation sums up the capacity of the reachable clusters.
+func summarizeFederation(clusters []*proto.FederationClusterView) (view *proto.FederationView) {
+	view = &proto.FederationView{Clusters: clusters}
+	for _, cluster := range clusters {
+		if !cluster.Reachable {
+			continue
+		}
+		view.ReachableClusters++
+		if cluster.Healthy {
+			view.HealthyClusters++
+		}
+		if cluster.DataNodeStatInfo != nil {
+			view.DataTotalGB += cluster.DataNodeStatInfo.TotalGB
+			view.DataUsedGB += cluster.DataNodeStatInfo.UsedGB
+		}
+		if cluster.MetaNodeStatInfo != nil {
+			view.MetaTotalGB += cluster.MetaNodeStatInfo.TotalGB
+			view.MetaUsedGB += cluster.MetaNodeStatInfo.UsedGB
+		}
+		view.Volumes += cluster.Volumes
+		view.DataNodes += cluster.DataNodes
+		view.MetaNodes += cluster.MetaNodes
+	}
+	return
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestFederationPeers(t *testing.T) {
+	f := newFederation()
+	f.putPeer(&proto.FederationPeer{Name: "c2", Region: "east"})
+	f.putPeer(&proto.FederationPeer{Name: "c1", Region: "west"})
+	f.putPeer(&proto.FederationPeer{Name: "c3", Region: "east"})
+	peers := f.getPeers()
+	if len(peers) != 3 || peers[0].Name != "c2" || peers[1].Name != "c3" || peers[2].Name != "c1" {
+		t.Errorf("expect the peers sorted by the regions and the names, but got %v", peers)
+	}
+	f.deletePeer("c2")
+	if _, ok := f.getPeer("c2"); ok {
+		t.Errorf("expect the peer deleted")
+	}
+	f.setPeers(peers[:1])
+	if peers = f.getPeers(); len(peers) != 1 || peers[0].Name != "c2" {
+		t.Errorf("expect the peers replaced, but got %v", peers)
+	}
+}
+
+func TestSummarizeFederation(t *testing.T) {
+	local := &proto.FederationClusterView{Name: "local", Local: true, Reachable: true, Healthy: true}
+	fillFederationClusterView(local, &proto.NodeStatInfo{TotalGB: 100, UsedGB: 40}, &proto.NodeStatInfo{TotalGB: 10, UsedGB: 2},
+		3, []proto.NodeView{{Status: true}, {Status: false}}, []proto.NodeView{{Status: true}})
+	if local.DataNodes != 2 || local.InactiveDataNodes != 1 || local.MetaNodes != 1 || local.InactiveMetaNodes != 0 {
+		t.Errorf("unexpected node counts %+v", local)
+	}
+	peer := &proto.FederationClusterView{Name: "peer", Reachable: true}
+	fillFederationClusterView(peer, &proto.NodeStatInfo{TotalGB: 200, UsedGB: 50}, nil, 2, nil, nil)
+	unreachable := &proto.FederationClusterView{Name: "down", Error: "timeout", Volumes: 5}
+	view := summarizeFederation([]*proto.FederationClusterView{local, peer, unreachable})
+	if view.ReachableClusters != 2 || view.HealthyClusters != 1 {
+		t.Errorf("expect 2 reachable and 1 healthy clusters, but got %v and %v", view.ReachableClusters, view.HealthyClusters)
+	}
+	if view.DataTotalGB != 300 || view.DataUsedGB != 90 || view.MetaTotalGB != 10 || view.Volumes != 5 {
+		t.Errorf("expect the capacity of the reachable clusters summed up, but got %+v", view)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	followerReadCacheCapacity = 4096
+	followerReadMaxStaleness  = time.Minute
+)
+
+// the read-only APIs which can be served by the followers
+var followerReadPaths = map[string]bool{
+	proto.AdminGetCluster:       true,
+	proto.AdminClusterStat:      true,
+	proto.AdminGetVol:           true,
+	proto.AdminListVols:         true,
+	proto.AdminGetDataPartition: true,
+	proto.ClientDataPartitions:  true,
+	proto.ClientVol:             true,
+	proto.ClientVolStat:         true,
+	proto.ClientMetaPartition:   true,
+	proto.ClientMetaPartitions:  true,
+	proto.GetTopologyView:       true,
+	proto.GetAllZones:           true,
+}
+
+type followerReply struct {
+	status int
+	header http.Header
+	body   []byte
+	time   time.Time
+}
+
+// followerReadCache caches the replies of the read-only requests which the follower proxied to the leader,
+// so that the follower serves the same requests without proxying them again within the staleness bound
+// given by the clients.
+type followerReadCache struct {
+	sync.RWMutex
+	replies map[string]*followerReply
+}
+
+func newFollowerReadCache() *followerReadCache {
+	return &followerReadCache{replies: make(map[string]*followerReply)}
+}
+
+func (fc *followerReadCache) get(key string, staleness time.Duration) *followerReply {
+	fc.RLock()
+	defer fc.RUnlock()
+	reply, ok := fc.replies[key]
+	if !ok || time.Since(reply.time) > staleness {
+		return nil
+	}
+	return reply
+}
+
+func (fc *followerReadCache) put(key string, reply *followerReply) {
+	fc.Lock()
+	defer fc.Unlock()
+	if len(fc.replies) >= followerReadCacheCapacity {
+		for k, r := range fc.replies {
+			if time.Since(r.time) > followerReadMaxStaleness {
+				delete(fc.replies, k)
+			}
+		}
+	}
+	if len(fc.replies) >= followerReadCacheCapacity {
+		fc.replies = make(map[string]*followerReply)
+	}
+	fc.replies[key] = reply
+}
+
+func (fc *followerReadCache) clear() {
+	fc.Lock()
+	fc.replies = make(map[string]*followerReply)
+	fc.Unlock()
+}
+
+// replyRecorder records the reply written to the client.
+type replyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *replyRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *replyRecorder) Write(data []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(data)
+	return rr.ResponseWriter.Write(data)
+}
+
+// parseFollowerReadStaleness returns the max staleness of the reply accepted by the client,
+// and false if the request can not be served by the follower.
+func parseFollowerReadStaleness(r *http.Request) (staleness time.Duration, ok bool) {
+	value := r.Header.Get(proto.FollowerReadStaleness)
+	if value == "" || !followerReadPaths[r.URL.Path] || r.ContentLength > 0 {
+		return
+	}
+	ms, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || ms == 0 {
+		return
+	}
+	if staleness = time.Duration(ms) * time.Millisecond; staleness > followerReadMaxStaleness {
+		staleness = followerReadMaxStaleness
+	}
+	return staleness, true
+}
+
+// serveFollowerRead serves the read-only request on the follower. The cached reply is returned if it is not
+// older than the staleness accepted by the client, otherwise the request is proxied to the leader and the
+// successful reply is cached.
+func (m *Server) serveFollowerRead(w http.ResponseWriter, r *http.Request) bool {
+	staleness, ok := parseFollowerReadStaleness(r)
+	if !ok {
+		return false
+	}
+	key := r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get(proto.SkipOwnerValidation)
+	if reply := m.followerReads.get(key, staleness); reply != nil {
+		for k, v := range reply.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(reply.status)
+		if _, err := w.Write(reply.body); err != nil {
+			log.LogErrorf("action[serveFollowerRead] send response has err:[%s]", err)
+		}
+		return true
+	}
+	recorder := &replyRecorder{ResponseWriter: w}
+	m.proxy(recorder, r)
+	if recorder.status != http.StatusOK {
+		return true
+	}
+	reply := &struct {
+		Code int32 `json:"code"`
+	}{}
+	if err := json.Unmarshal(recorder.body.Bytes(), reply); err != nil || reply.Code != proto.ErrCodeSuccess {
+		return true
+	}
+	m.followerReads.put(key, &followerReply{
+		status: recorder.status,
+		header: w.Header().Clone(),
+		body:   recorder.body.Bytes(),
+		time:   time.Now(),
+	})
+	return true
+}
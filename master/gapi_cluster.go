@@ -161,6 +161,7 @@ func (s *ClusterService) registerQuery(schema *schemabuilder.Schema) {
 	query.FieldFunc("masterList", s.masterList)
 	query.FieldFunc("getTopology", s.getTopology)
 	query.FieldFunc("alarmList", s.alarmList)
+	query.FieldFunc("capacityForecast", s.capacityForecast)
 }
 
 func (s *ClusterService) registerMutation(schema *schemabuilder.Schema) {
@@ -309,12 +310,12 @@ func (m *ClusterService) getTopology(ctx context.Context, args struct{}) (*proto
 			cv.NodeSet[ns.ID] = nsView
 			ns.dataNodes.Range(func(key, value interface{}) bool {
 				dataNode := value.(*DataNode)
-				nsView.DataNodes = append(nsView.DataNodes, proto.NodeView{ID: dataNode.ID, Addr: dataNode.Addr, Status: dataNode.isActive, IsWritable: dataNode.isWriteAble()})
+				nsView.DataNodes = append(nsView.DataNodes, proto.NodeView{ID: dataNode.ID, Addr: dataNode.Addr, Status: dataNode.isActive, IsWritable: dataNode.isWriteAble(), RackName: dataNode.RackName})
 				return true
 			})
 			ns.metaNodes.Range(func(key, value interface{}) bool {
 				metaNode := value.(*MetaNode)
-				nsView.MetaNodes = append(nsView.MetaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable()})
+				nsView.MetaNodes = append(nsView.MetaNodes, proto.NodeView{ID: metaNode.ID, Addr: metaNode.Addr, Status: metaNode.IsActive, IsWritable: metaNode.isWritable(), RackName: metaNode.RackName})
 				return true
 			})
 		}
@@ -340,6 +341,22 @@ type MasterInfo struct {
 	IsLeader bool
 }
 
+func (s *ClusterService) capacityForecast(ctx context.Context, args struct {
+	Days *int64
+}) (*proto.CapacityForecastView, error) {
+	if _, _, err := permissions(ctx, ADMIN); err != nil {
+		return nil, err
+	}
+	days := defaultCapacityForecastDays
+	if args.Days != nil {
+		if *args.Days <= 0 || *args.Days > maxUsageHistoryDays {
+			return nil, fmt.Errorf("days must be in [1, %v]", maxUsageHistoryDays)
+		}
+		days = int(*args.Days)
+	}
+	return s.cluster.capacityForecast("", days), nil
+}
+
 func (s *ClusterService) masterList(ctx context.Context, args struct{}) ([]*MasterInfo, error) {
 	if _, _, err := permissions(ctx, ADMIN); err != nil {
 		return nil, err
@@ -436,7 +453,7 @@ func (m *ClusterService) addMetaNode(ctx context.Context, args struct {
 	NodeAddr string
 	ZoneName string
 }) (uint64, error) {
-	if id, err := m.cluster.addMetaNode(args.NodeAddr, args.ZoneName); err != nil {
+	if id, err := m.cluster.addMetaNode(args.NodeAddr, args.ZoneName, ""); err != nil {
 		return 0, err
 	} else {
 		return id, nil
@@ -0,0 +1,41 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/samsarahq/thunder/graphql"
+)
+
+// TestGraphQLSchema builds the schemas of the GraphQL APIs, which panic on the types the schema builder rejects,
+// e.g. the maps in the views of the cluster, the users and the volumes.
+func TestGraphQLSchema(t *testing.T) {
+	schemas := map[string]func() *graphql.Schema{
+		"cluster": (&ClusterService{}).Schema,
+		"user":    (&UserService{}).Schema,
+		"volume":  (&VolumeService{}).Schema,
+	}
+	for name, build := range schemas {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("build the schema of %v: %v", name, r)
+				}
+			}()
+			build()
+		}()
+	}
+}
@@ -131,6 +131,12 @@ func (m *UserService) transferUserVol(ctx context.Context, args proto.UserTransf
 		return nil, fmt.Errorf("force param need validate user name for vol:[%s]", args.Volume)
 	}
 
+	m.user.quotaMutex.Lock()
+	defer m.user.quotaMutex.Unlock()
+	if err = m.user.checkQuota(m.cluster, args.UserDst, args.Volume, vol.Capacity); err != nil {
+		return nil, err
+	}
+
 	userInfo, err := m.user.transferVol(&args)
 	if err != nil {
 		return nil, err
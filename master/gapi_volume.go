@@ -49,6 +49,17 @@ func (s *VolumeService) registerObject(schema *schemabuilder.Schema) {
 		return used, nil
 	})
 
+	object.FieldFunc("capacityForecast", func(ctx context.Context, v *Vol) (*proto.CapacityForecast, error) {
+		if _, _, err := permissions(ctx, USER|ADMIN); err != nil {
+			return nil, err
+		}
+		forecasts := s.cluster.capacityForecast(v.Name, defaultCapacityForecastDays).Vols
+		if len(forecasts) == 0 {
+			return nil, nil
+		}
+		return forecasts[0], nil
+	})
+
 	object.FieldFunc("toSimpleVolView", func(ctx context.Context, vol *Vol) (*proto.SimpleVolView, error) {
 		if _, _, err := permissions(ctx, USER|ADMIN); err != nil {
 			return nil, err
@@ -205,7 +216,13 @@ func (s *VolumeService) createVolume(ctx context.Context, args struct {
 		return nil, fmt.Errorf("[%s] not has permission to create volume for [%s]", uid, args.Owner)
 	}
 
-	vol, err := s.cluster.createVol(args.Name, args.Owner, args.ZoneName, args.Description, int(args.MpCount), int(args.DpReplicaNum), int(args.DataPartitionSize), int(args.Capacity), args.FollowerRead, args.Authenticate, args.CrossZone, args.EnableToken)
+	s.user.quotaMutex.Lock()
+	defer s.user.quotaMutex.Unlock()
+	if err = s.user.checkQuota(s.cluster, args.Owner, args.Name, args.Capacity); err != nil {
+		return nil, err
+	}
+
+	vol, err := s.cluster.createVol(args.Name, args.Owner, args.ZoneName, args.Description, int(args.MpCount), int(args.DpReplicaNum), int(args.DataPartitionSize), int(args.Capacity), args.FollowerRead, args.Authenticate, args.CrossZone, args.EnableToken, false)
 	if err != nil {
 		return nil, err
 	}
@@ -326,6 +343,14 @@ func (s *VolumeService) updateVolume(ctx context.Context, args struct {
 		newArgs.description = *args.Description
 	}
 
+	s.user.quotaMutex.Lock()
+	defer s.user.quotaMutex.Unlock()
+	if newArgs.capacity > vol.Capacity {
+		if err = s.user.checkQuota(s.cluster, vol.Owner, args.Name, newArgs.capacity); err != nil {
+			return nil, err
+		}
+	}
+
 	if err = s.cluster.updateVol(args.Name, args.AuthKey, newArgs); err != nil {
 		return nil, err
 	}
@@ -56,12 +56,28 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 		return http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
 				log.LogDebugf("action[interceptor] request, method[%v] path[%v] query[%v]", r.Method, r.URL.Path, r.URL.Query())
-				if mux.CurrentRoute(r).GetName() == proto.AdminGetIP {
+				if name := mux.CurrentRoute(r).GetName(); name == proto.AdminGetIP || name == proto.AdminAPISpec {
 					next.ServeHTTP(w, r)
 					return
 				}
+				r, ownerChecked, err := m.authorize(r)
+				if err != nil {
+					log.LogWarnf("action[interceptor] remote[%v] path[%v] denied: %v", r.RemoteAddr, r.URL.Path, err)
+					sendErrReply(w, r, newRBACErrReply(err))
+					return
+				}
 				if m.partition.IsRaftLeader() {
 					if m.metaReady {
+						if ownerChecked {
+							if err = m.checkVolumeOwner(r); err != nil {
+								sendErrReply(w, r, newRBACErrReply(err))
+								return
+							}
+						}
+						if mutatingAPIs[r.URL.Path] {
+							m.serveAudited(next, w, r)
+							return
+						}
 						next.ServeHTTP(w, r)
 						return
 					}
@@ -74,6 +90,10 @@ func (m *Server) registerAPIMiddleware(route *mux.Router) {
 					http.Error(w, "no leader", http.StatusBadRequest)
 					return
 				}
+				// the ownership of the volumes is checked by the leader
+				if !ownerChecked && m.serveFollowerRead(w, r) {
+					return
+				}
 				m.proxy(w, r)
 			})
 	}
@@ -109,6 +129,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 		Path(proto.RemoveRaftNode).
 		HandlerFunc(m.removeRaftNode)
 	router.NewRoute().Methods(http.MethodGet).Path(proto.AdminClusterStat).HandlerFunc(m.clusterStat)
+	router.NewRoute().Methods(http.MethodGet).Path(proto.AdminClusterHealth).HandlerFunc(m.clusterHealth)
+	router.NewRoute().Methods(http.MethodPost).Path(proto.AdminRecordCliAudit).HandlerFunc(m.recordCliAudit)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).Path(proto.AdminRollingRestart).HandlerFunc(m.rollingRestart)
 
 	// volume management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -117,15 +140,93 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.AdminGetVol).
 		HandlerFunc(m.getVolSimpleInfo)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCloneVol).
+		HandlerFunc(m.cloneVol)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDeleteVol).
 		HandlerFunc(m.markDeleteVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRestoreVol).
+		HandlerFunc(m.restoreVol)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateVol).
 		HandlerFunc(m.updateVol)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolQos).
+		HandlerFunc(m.getVolQos)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolIPAcl).
+		HandlerFunc(m.getVolIPAcl)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVerifyReadCrcVols).
+		HandlerFunc(m.getVerifyReadCrcVols)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetCompressionVols).
+		HandlerFunc(m.getCompressionVols)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetVolEncryptionKeys).
+		HandlerFunc(m.getVolEncryptionKeys)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaSet).
+		HandlerFunc(m.setDirQuota)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.QuotaDelete).
+		HandlerFunc(m.deleteDirQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.QuotaList).
+		HandlerFunc(m.listDirQuotas)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ExpirationSet).
+		HandlerFunc(m.setExpirationRule)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.ExpirationDelete).
+		HandlerFunc(m.deleteExpirationRule)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.ExpirationList).
+		HandlerFunc(m.listExpirationRules)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.LifecycleSet).
+		HandlerFunc(m.setLifecycleRule)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.LifecycleDelete).
+		HandlerFunc(m.deleteLifecycleRule)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.LifecycleList).
+		HandlerFunc(m.listLifecycleRules)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.VolSnapshotCreate).
+		HandlerFunc(m.createVolSnapshot)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.VolSnapshotList).
+		HandlerFunc(m.listVolSnapshots)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.VolSnapshotDelete).
+		HandlerFunc(m.deleteVolSnapshot)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.VolSnapshotRollback).
+		HandlerFunc(m.rollbackVolSnapshot)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.VolReplicationSet).
+		HandlerFunc(m.setVolReplication)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.VolReplicationGet).
+		HandlerFunc(m.getVolReplication)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.VolReplicationList).
+		HandlerFunc(m.listVolReplications)
+	router.NewRoute().Methods(http.MethodPost).
+		Path(proto.VolReplicationReport).
+		HandlerFunc(m.reportVolReplication)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.VolReplicationFailover).
+		HandlerFunc(m.failoverVolReplication)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolShrink).
 		HandlerFunc(m.volShrink)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRotateVolKey).
+		HandlerFunc(m.rotateVolKey)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminVolExpand).
 		HandlerFunc(m.volExpand)
@@ -157,6 +258,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDecommissionMetaPartition).
 		HandlerFunc(m.decommissionMetaPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminResetMetaPartition).
+		HandlerFunc(m.resetMetaPartition)
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.ClientMetaPartitions).
 		HandlerFunc(m.getMetaPartitions)
@@ -169,12 +273,40 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminAddMetaReplica).
 		HandlerFunc(m.addMetaReplica)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminTransferMetaLeader).
+		HandlerFunc(m.transferMetaPartitionLeader)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDeleteMetaReplica).
 		HandlerFunc(m.deleteMetaReplica)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDiagnoseMetaPartition).
 		HandlerFunc(m.diagnoseMetaPartition)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListMetaPartitions).
+		HandlerFunc(m.listMetaPartitions)
+
+	// async task APIs
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetTask).
+		HandlerFunc(m.getTask)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListTasks).
+		HandlerFunc(m.listTasks)
+
+	// orphan partition APIs
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListOrphanPartitions).
+		HandlerFunc(m.listOrphanPartitions)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCleanOrphanPartitions).
+		HandlerFunc(m.cleanOrphanPartition)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminStalePartitionGC).
+		HandlerFunc(m.getStalePartitionGC)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetConsistencyReport).
+		HandlerFunc(m.getConsistencyReport)
 
 	// data partition management APIs
 	router.NewRoute().Methods(http.MethodGet).
@@ -189,6 +321,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDecommissionDataPartition).
 		HandlerFunc(m.decommissionDataPartition)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminResetDataPartition).
+		HandlerFunc(m.resetDataPartition)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDiagnoseDataPartition).
 		HandlerFunc(m.diagnoseDataPartition)
@@ -206,12 +341,21 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetMetaNode).
 		HandlerFunc(m.getMetaNode)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GetMetaNodePartitions).
+		HandlerFunc(m.getMetaNodePartitions)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetMetaNodeThreshold).
 		HandlerFunc(m.setMetaNodeThreshold)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminAddDataReplica).
 		HandlerFunc(m.addDataReplica)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminTransferDataLeader).
+		HandlerFunc(m.transferDataPartitionLeader)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetDataPartitionStatus).
+		HandlerFunc(m.setDataPartitionStatus)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminDeleteDataReplica).
 		HandlerFunc(m.deleteDataReplica)
@@ -221,6 +365,15 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminUpdateDataNode).
 		HandlerFunc(m.updateDataNode)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetMetaNodeLabels).
+		HandlerFunc(m.setMetaNodeLabels)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetDataNodeLabels).
+		HandlerFunc(m.setDataNodeLabels)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminDecommissionDryRun).
+		HandlerFunc(m.decommissionDryRun)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminGetInvalidNodes).
 		HandlerFunc(m.checkInvalidIDNodes)
@@ -235,15 +388,102 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetDataNode).
 		HandlerFunc(m.getDataNode)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.GetDataNodePartitions).
+		HandlerFunc(m.getDataNodePartitions)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.DecommissionDisk).
 		HandlerFunc(m.decommissionDisk)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDecommissionDiskAsync).
+		HandlerFunc(m.decommissionDiskAsync)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetDiskRetiring).
+		HandlerFunc(m.setDiskRetiring)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminSetNodeInfo).
 		HandlerFunc(m.setNodeInfoHandler)
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
 		Path(proto.AdminGetNodeInfo).
 		HandlerFunc(m.getNodeInfoHandler)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminMetaRebalanceStatus).
+		HandlerFunc(m.getMetaRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminMetaRebalanceSet).
+		HandlerFunc(m.setMetaRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminMetaRebalancePause).
+		HandlerFunc(m.pauseMetaRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminMetaRebalanceResume).
+		HandlerFunc(m.resumeMetaRebalance)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminDataRebalanceStatus).
+		HandlerFunc(m.getDataRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDataRebalanceSet).
+		HandlerFunc(m.setDataRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDataRebalancePause).
+		HandlerFunc(m.pauseDataRebalance)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDataRebalanceResume).
+		HandlerFunc(m.resumeDataRebalance)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListAuditRecords).
+		HandlerFunc(m.listAuditRecords)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListEvents).
+		HandlerFunc(m.listEvents)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetAlertRule).
+		HandlerFunc(m.setAlertRule)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminDeleteAlertRule).
+		HandlerFunc(m.deleteAlertRule)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListAlertRules).
+		HandlerFunc(m.listAlertRules)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetConfig).
+		HandlerFunc(m.getRuntimeConfig)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminSetConfig).
+		HandlerFunc(m.setRuntimeConfig)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListConfig).
+		HandlerFunc(m.listRuntimeConfig)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminCreateMetadataBackup).
+		HandlerFunc(m.createMetadataBackup)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListMetadataBackups).
+		HandlerFunc(m.listMetadataBackups)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRestoreMetadataBackup).
+		HandlerFunc(m.restoreMetadataBackup)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminCapacityForecast).
+		HandlerFunc(m.getCapacityForecast)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminAddFederationPeer).
+		HandlerFunc(m.addFederationPeer)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.AdminRemoveFederationPeer).
+		HandlerFunc(m.removeFederationPeer)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminListFederationPeers).
+		HandlerFunc(m.listFederationPeers)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.AdminGetFederation).
+		HandlerFunc(m.getFederation)
+
+	// the OpenAPI document of the APIs, served by any master
+	router.NewRoute().Name(proto.AdminAPISpec).
+		Methods(http.MethodGet).
+		Path(proto.AdminAPISpec).
+		HandlerFunc(m.getAPISpec)
 
 	// user management APIs
 	router.NewRoute().Methods(http.MethodPost).
@@ -279,6 +519,12 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.UsersOfVol).
 		HandlerFunc(m.getUsersOfVol)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.UserSetQuota).
+		HandlerFunc(m.setUserQuota)
+	router.NewRoute().Methods(http.MethodGet).
+		Path(proto.UserGetQuota).
+		HandlerFunc(m.getUserQuota)
 
 	// zone management APIs
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -287,6 +533,9 @@ func (m *Server) registerAPIRoutes(router *mux.Router) {
 	router.NewRoute().Methods(http.MethodGet).
 		Path(proto.GetAllZones).
 		HandlerFunc(m.listZone)
+	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
+		Path(proto.MoveZoneNode).
+		HandlerFunc(m.moveZoneNode)
 
 	// APIs for token-based client permissions control
 	router.NewRoute().Methods(http.MethodGet, http.MethodPost).
@@ -0,0 +1,104 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The lifecycle rules are delivered to the meta nodes by the simple view of the volume like the expiration rules.
+// The leaders of the meta partitions find the files due in the directory subtrees of the rules periodically, and ask
+// the data nodes to convert the extents of the files to the storage classes of the rules.
+
+// setLifecycleRule sets the days, the basis and the storage class of the lifecycle rule of the directory, a new rule
+// is created if the directory has no rule.
+func (c *Cluster) setLifecycleRule(volName, dirPath string, days uint32, basis, class string) (rule *proto.LifecycleRule, err error) {
+	var (
+		vol       *Vol
+		rootInode uint64
+	)
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	dirPath = path.Clean("/" + dirPath)
+	if rootInode, err = c.lookupRuleDir(vol, dirPath, "lifecycle"); err != nil {
+		return
+	}
+
+	vol.Lock()
+	defer vol.Unlock()
+	rules := make([]*proto.LifecycleRule, 0, len(vol.lifecycleRules)+1)
+	for _, r := range vol.lifecycleRules {
+		if r.Path == dirPath {
+			rule = &proto.LifecycleRule{RuleID: r.RuleID, Path: r.Path, RootInode: r.RootInode}
+			continue
+		}
+		rules = append(rules, r)
+	}
+	oldRules, oldMaxID := vol.lifecycleRules, vol.maxLifecycleID
+	if rule == nil {
+		vol.maxLifecycleID++
+		rule = &proto.LifecycleRule{RuleID: vol.maxLifecycleID, Path: dirPath, RootInode: rootInode}
+	}
+	rule.Days, rule.Basis, rule.StorageClass = days, basis, class
+	rules = append(rules, rule)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].RuleID < rules[j].RuleID })
+	vol.lifecycleRules = rules
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.lifecycleRules, vol.maxLifecycleID = oldRules, oldMaxID
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogWarnf("action[setLifecycleRule] clusterID[%v] vol[%v] path[%v] rule[%v] days[%v] basis[%v] storageClass[%v]",
+		c.Name, volName, dirPath, rule.RuleID, days, basis, class)
+	return
+}
+
+// deleteLifecycleRule deletes the rule, the files transited by the rule are left in their storage classes.
+func (c *Cluster) deleteLifecycleRule(volName string, ruleID uint32) (rule *proto.LifecycleRule, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	vol.Lock()
+	defer vol.Unlock()
+	rules := make([]*proto.LifecycleRule, 0, len(vol.lifecycleRules))
+	for _, r := range vol.lifecycleRules {
+		if r.RuleID == ruleID {
+			rule = r
+			continue
+		}
+		rules = append(rules, r)
+	}
+	if rule == nil {
+		err = fmt.Errorf("lifecycle rule[%v] of vol[%v] not exists", ruleID, volName)
+		return
+	}
+	oldRules := vol.lifecycleRules
+	vol.lifecycleRules = rules
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.lifecycleRules = oldRules
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogWarnf("action[deleteLifecycleRule] clusterID[%v] vol[%v] path[%v] rule[%v] is deleted",
+		c.Name, volName, rule.Path, ruleID)
+	return
+}
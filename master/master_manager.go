@@ -18,9 +18,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	cfsProto "github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft/proto"
 )
 
 // LeaderInfo represents the leader's information
@@ -37,6 +37,7 @@ func (m *Server) handleLeaderChange(leader uint64) {
 	m.leaderInfo.addr = AddrDatabase[leader]
 	log.LogWarnf("action[handleLeaderChange] change leader to [%v] ", m.leaderInfo.addr)
 	m.reverseProxy = m.newReverseProxy()
+	m.followerReads.clear()
 
 	if m.id == leader {
 		Warn(m.clusterName, fmt.Sprintf("clusterID[%v] leader is changed to %v",
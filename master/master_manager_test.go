@@ -6,9 +6,9 @@ import (
 	"net/http"
 	"testing"
 
+	rproto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
-	rproto "github.com/tiglabs/raft/proto"
 )
 
 func TestHandleLeaderChange(t *testing.T) {
@@ -0,0 +1,115 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	metaLearnerPromoteInterval = 10 * time.Second
+)
+
+// The new replicas of the meta partitions are added as the learners, which replicate the raft logs without voting,
+// so the availability of the partitions is not affected while they are catching up. The scheduler promotes the
+// learners to the voters once the leaders report that they have caught up.
+func (c *Cluster) scheduleToPromoteMetaLearners() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.promoteMetaLearners()
+			}
+			time.Sleep(metaLearnerPromoteInterval)
+		}
+	}()
+}
+
+func (c *Cluster) promoteMetaLearners() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("promoteMetaLearners occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"promoteMetaLearners occurred panic")
+		}
+	}()
+	for _, vol := range c.copyVols() {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			for _, learner := range mp.learners() {
+				if err := c.promoteMetaLearner(mp, learner); err != nil {
+					log.LogInfof("action[promoteMetaLearners] vol[%v] mp[%v] learner[%v] err[%v]",
+						mp.volName, mp.PartitionID, learner, err)
+				}
+			}
+		}
+	}
+}
+
+func (mp *MetaPartition) learners() (learners []proto.Peer) {
+	mp.RLock()
+	defer mp.RUnlock()
+	for _, peer := range mp.Peers {
+		if peer.IsLearner {
+			learners = append(learners, peer)
+		}
+	}
+	return
+}
+
+// promoteMetaLearner asks the leader of the meta partition to promote the learner, the leader refuses it until the
+// learner catches up.
+func (c *Cluster) promoteMetaLearner(mp *MetaPartition, learner proto.Peer) (err error) {
+	mp.offlineMutex.Lock()
+	defer mp.offlineMutex.Unlock()
+	mp.RLock()
+	mr, err := mp.getMetaReplicaLeader()
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+	t, err := mp.createTaskToPromoteRaftMember(learner, mr.Addr)
+	if err != nil {
+		return
+	}
+	leaderMetaNode, err := c.metaNode(mr.Addr)
+	if err != nil {
+		return
+	}
+	if _, err = leaderMetaNode.Sender.syncSendAdminTask(t); err != nil {
+		return
+	}
+	mp.Lock()
+	defer mp.Unlock()
+	newPeers := make([]proto.Peer, 0, len(mp.Peers))
+	promoted := false
+	for _, peer := range mp.Peers {
+		if peer.ID == learner.ID && peer.Addr == learner.Addr {
+			peer.IsLearner, promoted = false, true
+		}
+		newPeers = append(newPeers, peer)
+	}
+	if !promoted {
+		return errors.NewErrorf("learner[%v] has been removed", learner)
+	}
+	if err = mp.persistToRocksDB("promoteMetaLearner", mp.volName, mp.Hosts, newPeers, c); err != nil {
+		return
+	}
+	log.LogWarnf("action[promoteMetaLearner] vol[%v] mp[%v] learner[%v] promoted", mp.volName, mp.PartitionID, learner)
+	return
+}
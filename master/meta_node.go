@@ -15,6 +15,7 @@
 package master
 
 import (
+	"math"
 	"math/rand"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ type MetaNode struct {
 	IsActive                  bool
 	Sender                    *AdminTaskManager `graphql:"-"`
 	ZoneName                  string            `json:"Zone"`
+	RackName                  string            `json:"Rack"`
 	MaxMemAvailWeight         uint64            `json:"MaxMemAvailWeight"`
 	Total                     uint64            `json:"TotalWeight"`
 	Used                      uint64            `json:"UsedWeight"`
@@ -43,6 +45,9 @@ type MetaNode struct {
 	sync.RWMutex              `graphql:"-"`
 	ToBeOffline               bool
 	PersistenceMetaPartitions []uint64
+	HeartbeatLatency          float64 // moving average of the seconds the heartbeats are replied in
+	HealthScore               float64
+	labels                    map[string]string // attached by the admin to constrain the placement of the volumes
 }
 
 func newMetaNode(addr, zoneName, clusterID string) (node *MetaNode) {
@@ -70,6 +75,13 @@ func (metaNode *MetaNode) GetAddr() string {
 	return metaNode.Addr
 }
 
+// GetRack returns the rack of the meta node reported at registration.
+func (metaNode *MetaNode) GetRack() string {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return metaNode.RackName
+}
+
 // SetCarry implements the Node interface
 func (metaNode *MetaNode) SetCarry(carry float64) {
 	metaNode.Lock()
@@ -107,6 +119,19 @@ func (metaNode *MetaNode) setNodeActive() {
 	defer metaNode.Unlock()
 	metaNode.ReportTime = time.Now()
 	metaNode.IsActive = true
+	metaNode.HealthScore = healthScore(true, metaNode.HeartbeatLatency, 0, metaNode.load())
+}
+
+// load is the larger one of the used ratio of the memory and the ratio of the partitions to the max.
+func (metaNode *MetaNode) load() float64 {
+	return math.Max(metaNode.Ratio, float64(metaNode.MetaPartitionCount)/defaultMaxMetaPartitionCountOnEachNode)
+}
+
+// updateHeartbeatLatency updates the latency by the heartbeat task sent at the unix time.
+func (metaNode *MetaNode) updateHeartbeatLatency(sendTime int64) {
+	metaNode.Lock()
+	defer metaNode.Unlock()
+	metaNode.HeartbeatLatency = smoothHeartbeatLatency(metaNode.HeartbeatLatency, sendTime)
 }
 
 func (metaNode *MetaNode) updateMetric(resp *proto.MetaNodeHeartbeatResponse, threshold float32) {
@@ -142,10 +167,14 @@ func (metaNode *MetaNode) createHeartbeatTask(masterAddr string) (task *proto.Ad
 	return
 }
 
-func (metaNode *MetaNode) checkHeartbeat() {
+// checkHeartbeat returns if the meta node becomes inactive.
+func (metaNode *MetaNode) checkHeartbeat() (inactivated bool) {
 	metaNode.Lock()
 	defer metaNode.Unlock()
 	if time.Since(metaNode.ReportTime) > time.Second*time.Duration(defaultNodeTimeOutSec) {
+		inactivated = metaNode.IsActive
 		metaNode.IsActive = false
+		metaNode.HealthScore = 0
 	}
+	return
 }
@@ -22,6 +22,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
 	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -38,6 +39,9 @@ type MetaReplica struct {
 	ReportTime  int64
 	Status      int8 // unavailable, readOnly, readWrite
 	IsLeader    bool
+	ApplyID     uint64   // applied index of the raft log
+	Peers       []string // raft peers reported by the replica
+	Snapshots   []uint64 // IDs of the volume snapshots kept by the replica
 	metaNode    *MetaNode
 }
 
@@ -60,6 +64,8 @@ type MetaPartition struct {
 	OfflinePeerID uint64
 	MissNodes     map[string]int64
 	LoadResponse  []*proto.MetaPartitionLoadResponse
+	quotaUsage    map[uint32]*proto.QuotaUsage // usage of the directory quotas reported by the leader
+	replicaCheck  *proto.ReplicaCheckReport    // the latest replica check reported by the leader
 	offlineMutex  sync.RWMutex
 	sync.RWMutex
 }
@@ -226,11 +232,13 @@ func (mp *MetaPartition) removeMissingReplica(addr string) {
 	}
 }
 
-func (mp *MetaPartition) checkLeader() {
+// checkLeader returns if the leader of the meta partition is lost.
+func (mp *MetaPartition) checkLeader() (lostLeader bool) {
 	mp.Lock()
 	defer mp.Unlock()
 	for _, mr := range mp.Replicas {
 		if !mr.isActive() {
+			lostLeader = lostLeader || mr.IsLeader
 			mr.IsLeader = false
 		}
 	}
@@ -279,6 +287,33 @@ func (mp *MetaPartition) checkStatus(clusterID string, writeLog bool, replicaNum
 	return
 }
 
+// estimateMemory returns the memory used by the metadata of the partition on each meta node, which is estimated by
+// the numbers of the inodes and the dentries.
+func (mp *MetaPartition) estimateMemory() uint64 {
+	return mp.InodeCount*metaInodeMemSize + mp.DentryCount*metaDentryMemSize
+}
+
+// checkSplitThreshold returns if the partition reaches the split thresholds of the cluster and is the last partition
+// of the volume, which is expected to be split then. The other partitions reaching the thresholds are set read only,
+// so that the inodes are allocated in the partitions with fewer inodes.
+func (mp *MetaPartition) checkSplitThreshold(c *Cluster, maxPartitionID uint64) (doSplit bool) {
+	inodeCount, memory := c.metaPartitionSplitThreshold()
+	mp.Lock()
+	defer mp.Unlock()
+	if !(inodeCount > 0 && mp.InodeCount >= inodeCount) && !(memory > 0 && mp.estimateMemory() >= memory) {
+		return false
+	}
+	if mp.PartitionID == maxPartitionID {
+		log.LogWarnf("action[checkSplitThreshold] vol[%v] partition[%v] inodes[%v] memory[%v] reaches threshold",
+			mp.volName, mp.PartitionID, mp.InodeCount, mp.estimateMemory())
+		return true
+	}
+	if mp.Status == proto.ReadWrite {
+		mp.Status = proto.ReadOnly
+	}
+	return false
+}
+
 func (mp *MetaPartition) getMetaReplicaLeader() (mr *MetaReplica, err error) {
 	for _, mr = range mp.Replicas {
 		if mr.IsLeader {
@@ -341,12 +376,54 @@ func (mp *MetaPartition) updateMetaPartition(mgr *proto.MetaPartitionReport, met
 		mp.addReplica(mr)
 	}
 	mr.updateMetric(mgr)
+	if mgr.IsLeader {
+		mp.quotaUsage = mgr.QuotaUsage
+		mp.replicaCheck = mgr.ReplicaCheck
+	}
 	mp.setMaxInodeID()
 	mp.setInodeCount()
 	mp.setDentryCount()
 	mp.removeMissingReplica(metaNode.Addr)
 }
 
+// getPeerInconsistency returns the inconsistency between the raft peers and the hosts of the partition, nil if
+// they are consistent. The replicas which have not reported the raft peers are skipped.
+func (mp *MetaPartition) getPeerInconsistency() (inconsistency *proto.PeerInconsistentPartition) {
+	mp.RLock()
+	defer mp.RUnlock()
+	hosts := sortedAddrs(mp.Hosts)
+	peers := make([]string, 0, len(mp.Peers))
+	for _, peer := range mp.Peers {
+		peers = append(peers, peer.Addr)
+	}
+	peers = sortedAddrs(peers)
+	replicaPeers := make(map[string][]string)
+	for _, mr := range mp.Replicas {
+		if len(mr.Peers) == 0 {
+			continue
+		}
+		if addrs := sortedAddrs(mr.Peers); strings.Join(addrs, ",") != strings.Join(hosts, ",") {
+			replicaPeers[mr.Addr] = addrs
+		}
+	}
+	if len(replicaPeers) == 0 && strings.Join(peers, ",") == strings.Join(hosts, ",") {
+		return nil
+	}
+	return &proto.PeerInconsistentPartition{
+		PartitionID:  mp.PartitionID,
+		VolName:      mp.volName,
+		Hosts:        hosts,
+		Peers:        peers,
+		ReplicaPeers: replicaPeers,
+	}
+}
+
+func sortedAddrs(addrs []string) (sorted []string) {
+	sorted = append([]string{}, addrs...)
+	sort.Strings(sorted)
+	return
+}
+
 func (mp *MetaPartition) canBeOffline(nodeAddr string, replicaNum int) (err error) {
 	liveReplicas := mp.getLiveReplicas()
 	if len(liveReplicas) < int(mp.ReplicaNum/2+1) {
@@ -489,15 +566,17 @@ func (mp *MetaPartition) replicaCreationTasks(clusterID, volName string) (tasks
 	return
 }
 
-func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName string) (tasks []*proto.AdminTask) {
+func (mp *MetaPartition) buildNewMetaPartitionTasks(specifyAddrs []string, peers []proto.Peer, volName, metaStore string, caseInsensitive bool) (tasks []*proto.AdminTask) {
 	tasks = make([]*proto.AdminTask, 0)
 	hosts := make([]string, 0)
 	req := &proto.CreateMetaPartitionRequest{
-		Start:       mp.Start,
-		End:         mp.End,
-		PartitionID: mp.PartitionID,
-		Members:     peers,
-		VolName:     volName,
+		Start:           mp.Start,
+		End:             mp.End,
+		PartitionID:     mp.PartitionID,
+		Members:         peers,
+		VolName:         volName,
+		MetaStore:       metaStore,
+		CaseInsensitive: caseInsensitive,
 	}
 	if specifyAddrs == nil {
 		hosts = mp.Hosts
@@ -530,13 +609,15 @@ func (mp *MetaPartition) createTaskToTryToChangeLeader(addr string) (task *proto
 	return
 }
 
-func (mp *MetaPartition) createTaskToCreateReplica(host string) (t *proto.AdminTask, err error) {
+func (mp *MetaPartition) createTaskToCreateReplica(host, metaStore string, caseInsensitive bool) (t *proto.AdminTask, err error) {
 	req := &proto.CreateMetaPartitionRequest{
-		Start:       mp.Start,
-		End:         mp.End,
-		PartitionID: mp.PartitionID,
-		Members:     mp.Peers,
-		VolName:     mp.volName,
+		Start:           mp.Start,
+		End:             mp.End,
+		PartitionID:     mp.PartitionID,
+		Members:         mp.Peers,
+		VolName:         mp.volName,
+		MetaStore:       metaStore,
+		CaseInsensitive: caseInsensitive,
 	}
 	t = proto.NewAdminTask(proto.OpCreateMetaPartition, host, req)
 	resetMetaPartitionTaskID(t, mp.PartitionID)
@@ -550,6 +631,13 @@ func (mp *MetaPartition) createTaskToAddRaftMember(addPeer proto.Peer, leaderAdd
 	return
 }
 
+func (mp *MetaPartition) createTaskToPromoteRaftMember(promotePeer proto.Peer, leaderAddr string) (t *proto.AdminTask, err error) {
+	req := &proto.PromoteMetaPartitionRaftMemberRequest{PartitionId: mp.PartitionID, PromotePeer: promotePeer}
+	t = proto.NewAdminTask(proto.OpPromoteMetaPartitionRaftMember, leaderAddr, req)
+	resetMetaPartitionTaskID(t, mp.PartitionID)
+	return
+}
+
 func (mp *MetaPartition) createTaskToRemoveRaftMember(removePeer proto.Peer) (t *proto.AdminTask, err error) {
 	mr, err := mp.getMetaReplicaLeader()
 	if err != nil {
@@ -561,6 +649,13 @@ func (mp *MetaPartition) createTaskToRemoveRaftMember(removePeer proto.Peer) (t
 	return
 }
 
+func (mp *MetaPartition) createTaskToResetRaftMembers(newPeers []proto.Peer, address string) (t *proto.AdminTask, err error) {
+	req := &proto.ResetMetaPartitionRaftMemberRequest{PartitionId: mp.PartitionID, NewPeers: newPeers}
+	t = proto.NewAdminTask(proto.OpResetMetaPartitionRaftMember, address, req)
+	resetMetaPartitionTaskID(t, mp.PartitionID)
+	return
+}
+
 func (mp *MetaPartition) createTaskToDecommissionReplica(volName string, removePeer proto.Peer, addPeer proto.Peer) (t *proto.AdminTask, err error) {
 	mr, err := mp.getMetaReplicaLeader()
 	if err != nil {
@@ -623,6 +718,9 @@ func (mr *MetaReplica) updateMetric(mgr *proto.MetaPartitionReport) {
 	mr.MaxInodeID = mgr.MaxInodeID
 	mr.InodeCount = mgr.InodeCnt
 	mr.DentryCount = mgr.DentryCnt
+	mr.ApplyID = mgr.ApplyID
+	mr.Peers = mgr.Peers
+	mr.Snapshots = mgr.Snapshots
 	mr.setLastReportTime()
 }
 
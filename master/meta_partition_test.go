@@ -106,3 +106,40 @@ func decommissionMetaPartition(vol *Vol, id uint64, t *testing.T) {
 		return
 	}
 }
+
+func TestMetaPartitionPeerInconsistency(t *testing.T) {
+	mp := newMetaPartition(1, 1, defaultMaxMetaPartitionInodeID, 3, "vol", 1)
+	mp.Hosts = []string{"127.0.0.1:9021", "127.0.0.1:9022", "127.0.0.1:9023"}
+	mp.Peers = []proto.Peer{{ID: 3, Addr: "127.0.0.1:9023"}, {ID: 1, Addr: "127.0.0.1:9021"}, {ID: 2, Addr: "127.0.0.1:9022"}}
+	mp.Replicas = []*MetaReplica{
+		{Addr: "127.0.0.1:9021", Peers: []string{"127.0.0.1:9022", "127.0.0.1:9021", "127.0.0.1:9023"}},
+		{Addr: "127.0.0.1:9022"},
+	}
+	if inconsistency := mp.getPeerInconsistency(); inconsistency != nil {
+		t.Errorf("expect consistent peers, but got %v", inconsistency)
+	}
+	mp.Replicas = append(mp.Replicas, &MetaReplica{Addr: "127.0.0.1:9023", Peers: []string{"127.0.0.1:9021", "127.0.0.1:9024"}})
+	inconsistency := mp.getPeerInconsistency()
+	if inconsistency == nil || len(inconsistency.ReplicaPeers) != 1 || len(inconsistency.ReplicaPeers["127.0.0.1:9023"]) != 2 {
+		t.Errorf("expect the peers of replica 127.0.0.1:9023 inconsistent, but got %v", inconsistency)
+	}
+}
+
+func TestMetaPartitionSplitThreshold(t *testing.T) {
+	c := &Cluster{cfg: newClusterConfig()}
+	mp := newMetaPartition(2, 1, defaultMaxMetaPartitionInodeID, 3, "vol", 1)
+	mp.Status = proto.ReadWrite
+	mp.InodeCount, mp.DentryCount = 1000, 1000
+	if mp.checkSplitThreshold(c, 2) {
+		t.Errorf("expect no split without threshold")
+	}
+	c.cfg.MetaPartitionSplitMemory = 1000 * (metaInodeMemSize + metaDentryMemSize)
+	if !mp.checkSplitThreshold(c, 2) {
+		t.Errorf("expect split of the last partition reaching the memory threshold")
+	}
+	c.cfg.MetaPartitionSplitMemory = 0
+	c.cfg.MetaPartitionSplitInodeCount = 1000
+	if mp.checkSplitThreshold(c, 3) || mp.Status != proto.ReadOnly {
+		t.Errorf("expect the partition read only instead of split, status[%v]", mp.Status)
+	}
+}
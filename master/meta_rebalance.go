@@ -0,0 +1,154 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+func (c *Cluster) scheduleToRebalanceMetaPartitions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.rebalanceMetaPartitions()
+			}
+			time.Sleep(rebalanceCheckInterval)
+		}
+	}()
+}
+
+// rebalanceMetaPartitions migrates the meta partitions from the meta nodes whose memory usage ratio is above the high
+// ratio. The migrated partitions are recovering on the targets, and no more than the limit of them are migrating at
+// the same time.
+func (c *Cluster) rebalanceMetaPartitions() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("rebalanceMetaPartitions occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"rebalanceMetaPartitions occurred panic")
+		}
+	}()
+	rb := c.metaRebalancer
+	rb.releaseMigrations(func(id uint64) bool {
+		mp, err := c.getMetaPartitionByID(id)
+		return err == nil && mp.IsRecover
+	})
+	if !rb.isRunnable() {
+		return
+	}
+	rb.rebalance(c.metaNodeLoads(), func(partition *partitionLoad, src, target string) (err error) {
+		var mp *MetaPartition
+		if mp, err = c.getMetaPartitionByID(partition.id); err != nil {
+			return
+		}
+		return c.rebalanceMetaPartition(mp, src, target)
+	})
+}
+
+func (c *Cluster) rebalanceMetaPartition(mp *MetaPartition, nodeAddr, newAddr string) (err error) {
+	mp.RLock()
+	ok := !mp.IsRecover && len(mp.Hosts) == int(mp.ReplicaNum) && contains(mp.Hosts, nodeAddr)
+	mp.RUnlock()
+	if !ok {
+		return fmt.Errorf("meta partition is recovering or lacks replicas")
+	}
+	if err = c.validateDecommissionMetaPartition(mp, nodeAddr); err != nil {
+		return
+	}
+	if err = c.moveMetaReplica(mp, nodeAddr, newAddr); err != nil {
+		return
+	}
+	Warn(c.Name, fmt.Sprintf("action[rebalanceMetaPartition] clusterID[%v] vol[%v] meta partition[%v] "+
+		"migrated from[%v] to[%v]", c.Name, mp.volName, mp.PartitionID, nodeAddr, newAddr))
+	return
+}
+
+// metaNodeLoads returns the memory usage of the active meta nodes, with the meta partitions hosted by them. The
+// memory of a partition is estimated by the numbers of its inodes and dentries.
+func (c *Cluster) metaNodeLoads() (loads map[string]*nodeLoad) {
+	loads = make(map[string]*nodeLoad)
+	c.metaNodes.Range(func(addr, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		writable := metaNode.isWritable()
+		metaNode.RLock()
+		defer metaNode.RUnlock()
+		if !metaNode.IsActive {
+			return true
+		}
+		load := &nodeLoad{
+			addr:     metaNode.Addr,
+			zoneName: metaNode.ZoneName,
+			rackName: metaNode.RackName,
+			total:    metaNode.Total,
+			used:     metaNode.Used,
+			writable: writable && !metaNode.ToBeOffline,
+			labels:   metaNode.labels,
+		}
+		if load.rackName == "" {
+			// the node set is taken as the rack of the nodes without a rack
+			load.rackName = fmt.Sprintf("nodeSet-%v", metaNode.NodeSetID)
+		}
+		loads[load.addr] = load
+		return true
+	})
+	for _, vol := range c.allVols() {
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			partition := &partitionLoad{
+				id:          mp.PartitionID,
+				volName:     mp.volName,
+				used:        mp.estimateMemory(),
+				hosts:       append([]string(nil), mp.Hosts...),
+				rackDiverse: vol.placementPolicy == proto.PlacementRackDiverse,
+				labels:      vol.placementLabels,
+			}
+			mp.RUnlock()
+			for _, host := range partition.hosts {
+				if load, ok := loads[host]; ok {
+					load.partitions = append(load.partitions, partition)
+				}
+			}
+		}
+	}
+	return
+}
+
+func (c *Cluster) metaRebalanceView() *proto.RebalanceView {
+	loads := c.metaNodeLoads()
+	nodes := make([]*proto.RebalanceNodeView, 0, len(loads))
+	for _, load := range loads {
+		nodes = append(nodes, load.view())
+	}
+	return c.metaRebalancer.view(nodes)
+}
+
+func (c *Cluster) setMetaRebalanceConfig(config proto.RebalanceConfig) (err error) {
+	if err = validateRebalanceConfig(config); err != nil {
+		return
+	}
+	oldConfig := c.metaRebalancer.getConfig()
+	c.metaRebalancer.setConfig(config)
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setMetaRebalanceConfig] err[%v]", err)
+		c.metaRebalancer.setConfig(oldConfig)
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	return
+}
@@ -0,0 +1,384 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	metadataBackupSuffix           = ".json.gz"
+	metadataBackupBatchSize        = 1000
+	intervalToCheckMetadataBackup  = time.Minute
+	defaultMetadataBackupRegion    = "default"
+	metadataBackupKeyTimeSeparator = "-"
+)
+
+var (
+	errMetadataBackupNotConfigured = errors.New("the object store of the metadata backups is not configured")
+	errMetadataBackupNotFound      = errors.New("no backup of the metadata found")
+	errClusterNotEmpty             = errors.New("the cluster is not empty, the metadata can only be restored to a cluster without volumes and nodes")
+)
+
+// metadataBackupConfig is the S3 compatible object store to keep the backups of the metadata in.
+type metadataBackupConfig struct {
+	endpoint  string // such as http://127.0.0.1:9000, https is used if the scheme is omitted
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+}
+
+// metadataBackup is the document of a backup, which has all the key-values persisted by the masters
+// except the applied index of raft.
+type metadataBackup struct {
+	Cluster string
+	Time    int64
+	Applied uint64
+	Version string
+	Entries []*metadataBackupEntry
+}
+
+type metadataBackupEntry struct {
+	K string
+	V []byte
+}
+
+// metadataBackupManager uploads the backups of the metadata to the object store and downloads them.
+// The key of a backup is "<prefix>/<cluster>/<unix time>-<applied index>.json.gz", so the backups
+// are listed without downloading them.
+type metadataBackupManager struct {
+	sync.Mutex
+	cfg        *metadataBackupConfig
+	client     *s3.S3
+	lastBackup int64 // the unix time of the latest backup, 0 if it is unknown
+}
+
+func newMetadataBackupManager(cfg *metadataBackupConfig) *metadataBackupManager {
+	m := &metadataBackupManager{cfg: cfg}
+	if cfg.endpoint == "" {
+		return m
+	}
+	region := cfg.region
+	if region == "" {
+		region = defaultMetadataBackupRegion
+	}
+	var ac = aws.NewConfig()
+	ac.Endpoint = aws.String(cfg.endpoint)
+	ac.Region = aws.String(region)
+	ac.Credentials = credentials.NewStaticCredentials(cfg.accessKey, cfg.secretKey, "")
+	ac.S3ForcePathStyle = aws.Bool(true)
+	m.client = s3.New(session.Must(session.NewSession()), ac)
+	return m
+}
+
+func (m *metadataBackupManager) configured() bool {
+	return m.client != nil
+}
+
+func (m *metadataBackupManager) backupKey(cluster string, backupTime int64, applied uint64) string {
+	return path.Join(m.cfg.prefix, cluster, fmt.Sprintf("%v%v%v%v", backupTime, metadataBackupKeyTimeSeparator,
+		applied, metadataBackupSuffix))
+}
+
+// parseMetadataBackupKey returns the time and the applied index of the backup from the key.
+func parseMetadataBackupKey(key string) (backupTime int64, applied uint64, err error) {
+	name := path.Base(key)
+	if !strings.HasSuffix(name, metadataBackupSuffix) {
+		return 0, 0, fmt.Errorf("invalid key of the metadata backup %v", key)
+	}
+	arr := strings.Split(strings.TrimSuffix(name, metadataBackupSuffix), metadataBackupKeyTimeSeparator)
+	if len(arr) != 2 {
+		return 0, 0, fmt.Errorf("invalid key of the metadata backup %v", key)
+	}
+	if backupTime, err = strconv.ParseInt(arr[0], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid key of the metadata backup %v", key)
+	}
+	if applied, err = strconv.ParseUint(arr[1], 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid key of the metadata backup %v", key)
+	}
+	return
+}
+
+func encodeMetadataBackup(backup *metadataBackup) (data []byte, err error) {
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	if err = json.NewEncoder(w).Encode(backup); err != nil {
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMetadataBackup(data []byte) (backup *metadataBackup, err error) {
+	var r *gzip.Reader
+	if r, err = gzip.NewReader(bytes.NewReader(data)); err != nil {
+		return
+	}
+	defer r.Close()
+	backup = new(metadataBackup)
+	if err = json.NewDecoder(r).Decode(backup); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (m *metadataBackupManager) upload(backup *metadataBackup) (info *proto.MetadataBackupInfo, err error) {
+	var data []byte
+	if data, err = encodeMetadataBackup(backup); err != nil {
+		return
+	}
+	key := m.backupKey(backup.Cluster, backup.Time, backup.Applied)
+	if _, err = m.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(m.cfg.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}); err != nil {
+		return
+	}
+	return &proto.MetadataBackupInfo{Key: key, Cluster: backup.Cluster, Time: backup.Time, Applied: backup.Applied,
+		Size: int64(len(data))}, nil
+}
+
+func (m *metadataBackupManager) download(key string) (backup *metadataBackup, err error) {
+	var output *s3.GetObjectOutput
+	if output, err = m.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(m.cfg.bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return
+	}
+	defer output.Body.Close()
+	var data []byte
+	if data, err = ioutil.ReadAll(output.Body); err != nil {
+		return
+	}
+	return decodeMetadataBackup(data)
+}
+
+// list returns the backups of the cluster sorted by the time.
+func (m *metadataBackupManager) list(cluster string) (backups []*proto.MetadataBackupInfo, err error) {
+	backups = make([]*proto.MetadataBackupInfo, 0)
+	prefix := path.Join(m.cfg.prefix, cluster) + "/"
+	err = m.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(m.cfg.bucket),
+		Prefix: aws.String(prefix),
+	}, func(output *s3.ListObjectsV2Output, last bool) bool {
+		for _, object := range output.Contents {
+			key := aws.StringValue(object.Key)
+			backupTime, applied, err := parseMetadataBackupKey(key)
+			if err != nil {
+				log.LogWarnf("action[listMetadataBackups] skip the object err[%v]", err)
+				continue
+			}
+			backups = append(backups, &proto.MetadataBackupInfo{Key: key, Cluster: cluster, Time: backupTime,
+				Applied: applied, Size: aws.Int64Value(object.Size)})
+		}
+		return true
+	})
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].Time == backups[j].Time {
+			return backups[i].Applied < backups[j].Applied
+		}
+		return backups[i].Time < backups[j].Time
+	})
+	return
+}
+
+// prune deletes the backups except the latest ones of the retention.
+func (m *metadataBackupManager) prune(cluster string, retention int) (err error) {
+	var backups []*proto.MetadataBackupInfo
+	if backups, err = m.list(cluster); err != nil {
+		return
+	}
+	for i := 0; i < len(backups)-retention; i++ {
+		if _, err = m.client.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String(m.cfg.bucket),
+			Key:    aws.String(backups[i].Key),
+		}); err != nil {
+			return
+		}
+		log.LogInfof("action[pruneMetadataBackups] delete the backup[%v]", backups[i].Key)
+	}
+	return
+}
+
+// exportMetadata reads all the key-values from a snapshot of the store, the applied index is read from the
+// same snapshot, so the backup is consistent with the index.
+func (mf *MetadataFsm) exportMetadata() (appliedIndex uint64, entries []*metadataBackupEntry, err error) {
+	snapshot := mf.store.RocksDBSnapshot()
+	defer mf.store.ReleaseSnapshot(snapshot)
+	iterator := mf.store.Iterator(snapshot)
+	defer iterator.Close()
+	entries = make([]*metadataBackupEntry, 0)
+	for iterator.SeekToFirst(); iterator.Valid(); iterator.Next() {
+		// the data of the slices are only valid until the iterator moves
+		k := string(iterator.Key().Data())
+		v := append([]byte(nil), iterator.Value().Data()...)
+		if k == applied {
+			if appliedIndex, err = strconv.ParseUint(string(v), 10, 64); err != nil {
+				return
+			}
+			continue
+		}
+		entries = append(entries, &metadataBackupEntry{K: k, V: v})
+	}
+	err = iterator.Err()
+	return
+}
+
+func (c *Cluster) backupMetadata() (info *proto.MetadataBackupInfo, err error) {
+	if !c.metadataBackups.configured() {
+		return nil, errMetadataBackupNotConfigured
+	}
+	c.metadataBackups.Lock()
+	defer c.metadataBackups.Unlock()
+	backup := &metadataBackup{Cluster: c.Name, Time: time.Now().Unix(), Version: proto.Version}
+	if backup.Applied, backup.Entries, err = c.fsm.exportMetadata(); err != nil {
+		return
+	}
+	if info, err = c.metadataBackups.upload(backup); err != nil {
+		log.LogErrorf("action[backupMetadata] upload the backup err[%v]", err)
+		return
+	}
+	c.metadataBackups.lastBackup = backup.Time
+	log.LogInfof("action[backupMetadata] key[%v] applied[%v] entries[%v] size[%v]", info.Key, info.Applied,
+		len(backup.Entries), info.Size)
+	if err = c.metadataBackups.prune(c.Name, int(c.cfg.metadataBackupRetention)); err != nil {
+		log.LogWarnf("action[backupMetadata] prune the backups err[%v]", err)
+		err = nil
+	}
+	return
+}
+
+func (c *Cluster) listMetadataBackups() (backups []*proto.MetadataBackupInfo, err error) {
+	if !c.metadataBackups.configured() {
+		return nil, errMetadataBackupNotConfigured
+	}
+	return c.metadataBackups.list(c.Name)
+}
+
+// findMetadataBackup returns the backup of the key, or the latest backup taken before the unix time if the key is empty.
+func (c *Cluster) findMetadataBackup(key string, before int64) (found *proto.MetadataBackupInfo, err error) {
+	var backups []*proto.MetadataBackupInfo
+	if backups, err = c.listMetadataBackups(); err != nil {
+		return
+	}
+	for _, backup := range backups {
+		if (key != "" && backup.Key == key) || (key == "" && backup.Time <= before) {
+			found = backup
+		}
+	}
+	if found == nil {
+		return nil, errMetadataBackupNotFound
+	}
+	return
+}
+
+// restoreMetadata puts the key-values of the backup through raft, so all the masters have the restored metadata.
+// It's only allowed on a cluster without volumes and nodes, such as the one recreated after all the masters are lost.
+func (c *Cluster) restoreMetadata(info *proto.MetadataBackupInfo) (err error) {
+	if len(c.allVolNames()) > 0 || len(c.allDataNodes()) > 0 || len(c.allMetaNodes()) > 0 {
+		return errClusterNotEmpty
+	}
+	c.metadataBackups.Lock()
+	defer c.metadataBackups.Unlock()
+	var backup *metadataBackup
+	if backup, err = c.metadataBackups.download(info.Key); err != nil {
+		return
+	}
+	if backup.Cluster != c.Name {
+		return fmt.Errorf("the backup is of the cluster %v, not %v", backup.Cluster, c.Name)
+	}
+	cmdMap := make(map[string]*RaftCmd, metadataBackupBatchSize)
+	for i, entry := range backup.Entries {
+		cmd := &RaftCmd{K: entry.K, V: entry.V}
+		cmd.setOpType()
+		cmdMap[entry.K] = cmd
+		if len(cmdMap) < metadataBackupBatchSize && i < len(backup.Entries)-1 {
+			continue
+		}
+		if err = c.syncBatchCommitCmd(cmdMap); err != nil {
+			log.LogErrorf("action[restoreMetadata] key[%v] err[%v]", info.Key, err)
+			return proto.ErrPersistenceByRaft
+		}
+		cmdMap = make(map[string]*RaftCmd, metadataBackupBatchSize)
+	}
+	log.LogWarnf("action[restoreMetadata] key[%v] applied[%v] entries[%v] restored", info.Key, backup.Applied,
+		len(backup.Entries))
+	return
+}
+
+func (c *Cluster) scheduleToBackupMetadata() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() && c.metadataBackups.configured() &&
+				c.cfg.metadataBackupInterval > 0 {
+				c.checkMetadataBackup()
+			}
+			time.Sleep(intervalToCheckMetadataBackup)
+		}
+	}()
+}
+
+// checkMetadataBackup backs up the metadata if the latest backup is older than the interval. The time of the
+// latest backup is listed from the object store after the master becomes the leader.
+func (c *Cluster) checkMetadataBackup() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkMetadataBackup occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkMetadataBackup occurred panic")
+		}
+	}()
+	if c.metadataBackups.lastBackup == 0 {
+		backups, err := c.listMetadataBackups()
+		if err != nil {
+			log.LogErrorf("action[checkMetadataBackup] list the backups err[%v]", err)
+			return
+		}
+		if len(backups) > 0 {
+			c.metadataBackups.lastBackup = backups[len(backups)-1].Time
+		}
+	}
+	if time.Now().Unix()-c.metadataBackups.lastBackup < c.cfg.metadataBackupInterval {
+		return
+	}
+	if _, err := c.backupMetadata(); err != nil {
+		Warn(c.Name, fmt.Sprintf("action[checkMetadataBackup] clusterID[%v] back up the metadata err[%v]", c.Name, err))
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetadataBackupKey(t *testing.T) {
+	m := newMetadataBackupManager(&metadataBackupConfig{prefix: "backups"})
+	key := m.backupKey("cfs", 1600000000, 12345)
+	if key != "backups/cfs/1600000000-12345.json.gz" {
+		t.Fatalf("key %v", key)
+	}
+	backupTime, applied, err := parseMetadataBackupKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backupTime != 1600000000 || applied != 12345 {
+		t.Errorf("time %v applied %v", backupTime, applied)
+	}
+	for _, invalid := range []string{"backups/cfs/1600000000.json.gz", "backups/cfs/a-1.json.gz", "backups/cfs/1-2.json"} {
+		if _, _, err = parseMetadataBackupKey(invalid); err == nil {
+			t.Errorf("key %v is parsed", invalid)
+		}
+	}
+}
+
+func TestEncodeMetadataBackup(t *testing.T) {
+	backup := &metadataBackup{
+		Cluster: "cfs",
+		Time:    1600000000,
+		Applied: 12345,
+		Entries: []*metadataBackupEntry{
+			{K: clusterPrefix + "cfs", V: []byte(`{"Name":"cfs"}`)},
+			{K: maxDataPartitionIDKey, V: []byte("100")},
+		},
+	}
+	data, err := encodeMetadataBackup(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeMetadataBackup(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(backup, decoded) {
+		t.Errorf("decoded %v, expected %v", decoded, backup)
+	}
+	if _, err = decodeMetadataBackup([]byte("invalid")); err == nil {
+		t.Error("invalid backup is decoded")
+	}
+}
@@ -21,10 +21,10 @@ import (
 	"strings"
 	"sync/atomic"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	bsProto "github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft/proto"
 )
 
 /* We defines several "values" such as clusterValue, metaPartitionValue, dataPartitionValue, volValue, dataNodeValue,
@@ -39,9 +39,22 @@ type clusterValue struct {
 	MetaNodeDeleteBatchCount    uint64
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeAutoRepairLimitRate uint64
+	AutoReplicaSupplementLimit  uint64
+	VolDeletionDelay            uint64
+	MpSplitInodeCount           uint64
+	MpSplitMemory               uint64
+	DecommissionLimit           uint64
+	DecommissionBandwidth       uint64
+	MetaRebalance               *bsProto.RebalanceConfig
+	DataRebalance               *bsProto.RebalanceConfig
+	AlertRules                  []*bsProto.AlertRule
+	RuntimeConfig               map[string]string
+	FederationPeers             []*bsProto.FederationPeer
 }
 
 func newClusterValue(c *Cluster) (cv *clusterValue) {
+	metaRebalance := c.metaRebalancer.getConfig()
+	dataRebalance := c.dataRebalancer.getConfig()
 	cv = &clusterValue{
 		Name:                        c.Name,
 		Threshold:                   c.cfg.MetaNodeThreshold,
@@ -49,6 +62,17 @@ func newClusterValue(c *Cluster) (cv *clusterValue) {
 		MetaNodeDeleteBatchCount:    c.cfg.MetaNodeDeleteBatchCount,
 		MetaNodeDeleteWorkerSleepMs: c.cfg.MetaNodeDeleteWorkerSleepMs,
 		DataNodeAutoRepairLimitRate: c.cfg.DataNodeAutoRepairLimitRate,
+		AutoReplicaSupplementLimit:  c.cfg.AutoReplicaSupplementLimit,
+		VolDeletionDelay:            c.cfg.VolDeletionDelay,
+		MpSplitInodeCount:           c.cfg.MetaPartitionSplitInodeCount,
+		MpSplitMemory:               c.cfg.MetaPartitionSplitMemory,
+		DecommissionLimit:           c.cfg.DataNodeDecommissionLimit,
+		DecommissionBandwidth:       c.cfg.DataNodeDecommissionBandwidth,
+		MetaRebalance:               &metaRebalance,
+		DataRebalance:               &dataRebalance,
+		AlertRules:                  c.alerts.getRules(),
+		RuntimeConfig:               c.runtimeConfig.getOverrides(),
+		FederationPeers:             c.federation.getPeers(),
 		DisableAutoAllocate:         c.DisableAutoAllocate,
 	}
 	return cv
@@ -96,6 +120,9 @@ type dataPartitionValue struct {
 	OfflinePeerID uint64
 	Replicas      []*replicaValue
 	IsRecover     bool
+	ForcedStatus  int8
+	ForceReason   string
+	ForceTime     int64
 }
 
 type replicaValue struct {
@@ -115,6 +142,9 @@ func newDataPartitionValue(dp *DataPartition) (dpv *dataPartitionValue) {
 		OfflinePeerID: dp.OfflinePeerID,
 		Replicas:      make([]*replicaValue, 0),
 		IsRecover:     dp.isRecover,
+		ForcedStatus:  dp.forcedStatus,
+		ForceReason:   dp.forceReason,
+		ForceTime:     dp.forceTime,
 	}
 	for _, replica := range dp.Replicas {
 		rv := &replicaValue{Addr: replica.Addr, DiskPath: replica.DiskPath}
@@ -143,6 +173,28 @@ type volValue struct {
 	Description       string
 	DpSelectorName    string
 	DpSelectorParm    string
+	PlacementPolicy   string
+	PlacementZone     string
+	PlacementLabels   map[string]string
+	Qos               bsProto.VolQos
+	IPAcl             bsProto.VolIPAcl
+	DirQuotas         []*bsProto.DirQuota
+	MaxQuotaID        uint32
+	Snapshots         []*bsProto.VolSnapshot
+	MaxSnapshotID     uint64
+	Replication       *bsProto.VolReplication
+	DeleteTime        int64
+	TrashTTL          uint64
+	MetaStore         string
+	InodeRetention    uint64
+	ExpirationRules   []*bsProto.ExpirationRule
+	MaxExpirationID   uint32
+	LifecycleRules    []*bsProto.LifecycleRule
+	MaxLifecycleID    uint32
+	CaseInsensitive   bool
+	VerifyReadCrc     bool
+	Compression       string
+	EncryptionKeys    []*volEncryptionKey
 }
 
 func (v *volValue) Bytes() (raw []byte, err error) {
@@ -171,6 +223,30 @@ func newVolValue(vol *Vol) (vv *volValue) {
 		Description:       vol.description,
 		DpSelectorName:    vol.dpSelectorName,
 		DpSelectorParm:    vol.dpSelectorParm,
+		PlacementPolicy:   vol.placementPolicy,
+		PlacementZone:     vol.placementZone,
+		PlacementLabels:   vol.placementLabels,
+		Qos:               vol.qos,
+		IPAcl:             vol.ipAcl,
+		MaxQuotaID:        vol.maxQuotaID,
+		Snapshots:         vol.snapshots,
+		MaxSnapshotID:     vol.maxSnapshotID,
+		Replication:       vol.replication,
+		DeleteTime:        vol.deleteTime,
+		TrashTTL:          vol.trashTTL,
+		MetaStore:         vol.metaStore,
+		InodeRetention:    vol.inodeRetention,
+		ExpirationRules:   vol.expirationRules,
+		MaxExpirationID:   vol.maxExpirationID,
+		LifecycleRules:    vol.lifecycleRules,
+		MaxLifecycleID:    vol.maxLifecycleID,
+		CaseInsensitive:   vol.caseInsensitive,
+		VerifyReadCrc:     vol.verifyReadCrc,
+		Compression:       vol.compression,
+		EncryptionKeys:    vol.encryptionKeys,
+	}
+	for _, quota := range vol.dirQuotas {
+		vv.DirQuotas = append(vv.DirQuotas, quota)
 	}
 	return
 }
@@ -188,14 +264,20 @@ type dataNodeValue struct {
 	NodeSetID uint64
 	Addr      string
 	ZoneName  string
+	RackName      string
+	Labels        map[string]string
+	RetiringDisks map[string]string
 }
 
 func newDataNodeValue(dataNode *DataNode) *dataNodeValue {
 	return &dataNodeValue{
-		ID:        dataNode.ID,
-		NodeSetID: dataNode.NodeSetID,
-		Addr:      dataNode.Addr,
-		ZoneName:  dataNode.ZoneName,
+		ID:            dataNode.ID,
+		NodeSetID:     dataNode.NodeSetID,
+		Addr:          dataNode.Addr,
+		ZoneName:      dataNode.ZoneName,
+		RackName:      dataNode.RackName,
+		Labels:        dataNode.labels,
+		RetiringDisks: dataNode.retiringDisks,
 	}
 }
 
@@ -204,6 +286,8 @@ type metaNodeValue struct {
 	NodeSetID uint64
 	Addr      string
 	ZoneName  string
+	RackName  string
+	Labels    map[string]string
 }
 
 func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
@@ -212,6 +296,8 @@ func newMetaNodeValue(metaNode *MetaNode) *metaNodeValue {
 		NodeSetID: metaNode.NodeSetID,
 		Addr:      metaNode.Addr,
 		ZoneName:  metaNode.ZoneName,
+		RackName:  metaNode.RackName,
+		Labels:    metaNode.labels,
 	}
 }
 
@@ -282,6 +368,8 @@ func (m *RaftCmd) setOpType() {
 		m.Op = opSyncAddVolUser
 	case tokenAcronym:
 		m.Op = OpSyncAddToken
+	case auditAcronym:
+		m.Op = opSyncPutAuditRecord
 	default:
 		log.LogWarnf("action[setOpType] unknown opCode[%v]", keyArr[1])
 	}
@@ -554,6 +642,21 @@ func (c *Cluster) loadClusterValue() (err error) {
 		c.updateMetaNodeDeleteWorkerSleepMs(cv.MetaNodeDeleteWorkerSleepMs)
 		c.updateDataNodeDeleteLimitRate(cv.DataNodeDeleteLimitRate)
 		c.updateDataNodeAutoRepairLimit(cv.DataNodeAutoRepairLimitRate)
+		atomic.StoreUint64(&c.cfg.AutoReplicaSupplementLimit, cv.AutoReplicaSupplementLimit)
+		atomic.StoreUint64(&c.cfg.VolDeletionDelay, cv.VolDeletionDelay)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitInodeCount, cv.MpSplitInodeCount)
+		atomic.StoreUint64(&c.cfg.MetaPartitionSplitMemory, cv.MpSplitMemory)
+		atomic.StoreUint64(&c.cfg.DataNodeDecommissionLimit, cv.DecommissionLimit)
+		atomic.StoreUint64(&c.cfg.DataNodeDecommissionBandwidth, cv.DecommissionBandwidth)
+		if cv.MetaRebalance != nil {
+			c.metaRebalancer.setConfig(*cv.MetaRebalance)
+		}
+		if cv.DataRebalance != nil {
+			c.dataRebalancer.setConfig(*cv.DataRebalance)
+		}
+		c.alerts.setRules(cv.AlertRules)
+		c.loadRuntimeConfig(cv.RuntimeConfig)
+		c.federation.setPeers(cv.FederationPeers)
 		log.LogInfof("action[loadClusterValue], metaNodeThreshold[%v]", cv.Threshold)
 	}
 	return
@@ -606,6 +709,9 @@ func (c *Cluster) loadDataNodes() (err error) {
 		dataNode := newDataNode(dnv.Addr, dnv.ZoneName, c.Name)
 		dataNode.ID = dnv.ID
 		dataNode.NodeSetID = dnv.NodeSetID
+		dataNode.RackName = dnv.RackName
+		dataNode.labels = dnv.Labels
+		dataNode.retiringDisks = dnv.RetiringDisks
 		olddn, ok := c.dataNodes.Load(dataNode.Addr)
 		if ok {
 			if olddn.(*DataNode).ID <= dataNode.ID {
@@ -636,6 +742,8 @@ func (c *Cluster) loadMetaNodes() (err error) {
 		metaNode := newMetaNode(mnv.Addr, mnv.ZoneName, c.Name)
 		metaNode.ID = mnv.ID
 		metaNode.NodeSetID = mnv.NodeSetID
+		metaNode.RackName = mnv.RackName
+		metaNode.labels = mnv.Labels
 		oldmn, ok := c.metaNodes.Load(metaNode.Addr)
 		if ok {
 			if oldmn.(*MetaNode).ID <= metaNode.ID {
@@ -740,6 +848,7 @@ func (c *Cluster) loadDataPartitions() (err error) {
 		dp.Peers = dpv.Peers
 		dp.OfflinePeerID = dpv.OfflinePeerID
 		dp.isRecover = dpv.IsRecover
+		dp.forcedStatus, dp.forceReason, dp.forceTime = dpv.ForcedStatus, dpv.ForceReason, dpv.ForceTime
 		for _, rv := range dpv.Replicas {
 			if !contains(dp.Hosts, rv.Addr) {
 				continue
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
@@ -45,6 +46,8 @@ type MockDataServer struct {
 	partitions                      []*MockDataPartition
 	zoneName                        string
 	mc                              *master.MasterClient
+	snapshotLock                    sync.RWMutex
+	snapshots                       map[uint64]*proto.DataPartitionSnapshotRequest // the latest barrier of the partitions
 }
 
 func NewMockDataServer(addr string, zoneName string) *MockDataServer {
@@ -53,6 +56,7 @@ func NewMockDataServer(addr string, zoneName string) *MockDataServer {
 		zoneName:   zoneName,
 		partitions: make([]*MockDataPartition, 0),
 		mc:         master.NewMasterClient([]string{hostAddr}, false),
+		snapshots:  make(map[uint64]*proto.DataPartitionSnapshotRequest),
 	}
 
 	return mds
@@ -68,7 +72,7 @@ func (mds *MockDataServer) register() {
 	var nodeID uint64
 	var retry int
 	for retry < 3 {
-		nodeID, err = mds.mc.NodeAPI().AddDataNode(mds.TcpAddr, mds.zoneName)
+		nodeID, err = mds.mc.NodeAPI().AddDataNode(mds.TcpAddr, mds.zoneName, "")
 		if err == nil {
 			break
 		}
@@ -140,6 +144,12 @@ func (mds *MockDataServer) serveConn(rc net.Conn) {
 	case proto.OpDataPartitionTryToLeader:
 		err = mds.handleTryToLeader(conn, req, adminTask)
 		fmt.Printf("data node [%v] try to leader,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
+	case proto.OpResetDataPartitionRaftMember:
+		err = mds.handleResetDataPartitionRaftMember(conn, req, adminTask)
+		fmt.Printf("data node [%v] reset data partition raft member,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
+	case proto.OpDataPartitionSnapshot:
+		err = mds.handleDataPartitionSnapshot(conn, req, adminTask)
+		fmt.Printf("data node [%v] data partition snapshot,id[%v],err:%v\n", mds.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -155,6 +165,40 @@ func (mds *MockDataServer) handleRemoveDataPartitionRaftMember(conn net.Conn, p
 	return
 }
 
+func (mds *MockDataServer) handleResetDataPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
+func (mds *MockDataServer) handleDataPartitionSnapshot(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	defer func() {
+		if err != nil {
+			responseAckErrToMaster(conn, p, err)
+		} else {
+			responseAckOKToMaster(conn, p, nil)
+		}
+	}()
+	requestJson, err := json.Marshal(adminTask.Request)
+	if err != nil {
+		return
+	}
+	req := &proto.DataPartitionSnapshotRequest{}
+	if err = json.Unmarshal(requestJson, req); err != nil {
+		return
+	}
+	mds.snapshotLock.Lock()
+	mds.snapshots[req.PartitionID] = req
+	mds.snapshotLock.Unlock()
+	return
+}
+
+// DataPartitionSnapshot returns the latest snapshot operation on the partition, nil if there is none.
+func (mds *MockDataServer) DataPartitionSnapshot(partitionID uint64) *proto.DataPartitionSnapshotRequest {
+	mds.snapshotLock.RLock()
+	defer mds.snapshotLock.RUnlock()
+	return mds.snapshots[partitionID]
+}
+
 func (mds *MockDataServer) handleTryToLeader(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
@@ -56,7 +56,7 @@ func (mms *MockMetaServer) register() {
 	var nodeID uint64
 	var retry int
 	for retry < 3 {
-		nodeID, err = mms.mc.NodeAPI().AddMetaNode(mms.TcpAddr, mms.ZoneName)
+		nodeID, err = mms.mc.NodeAPI().AddMetaNode(mms.TcpAddr, mms.ZoneName, "")
 		if err == nil {
 			break
 		}
@@ -135,6 +135,15 @@ func (mms *MockMetaServer) serveConn(rc net.Conn) {
 	case proto.OpMetaPartitionTryToLeader:
 		err = mms.handleTryToLeader(conn, req, adminTask)
 		fmt.Printf("meta node [%v] try to leader,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpResetMetaPartitionRaftMember:
+		err = mms.handleResetMetaPartitionRaftMember(conn, req, adminTask)
+		fmt.Printf("meta node [%v] reset meta partition raft member,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpMetaPartitionSnapshot:
+		err = mms.handleMetaPartitionSnapshot(conn, req, adminTask)
+		fmt.Printf("meta node [%v] meta partition snapshot,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
+	case proto.OpPromoteMetaPartitionRaftMember:
+		err = mms.handlePromoteMetaPartitionRaftMember(conn, req, adminTask)
+		fmt.Printf("meta node [%v] promote meta partition raft member,id[%v],err:%v\n", mms.TcpAddr, adminTask.ID, err)
 	default:
 		fmt.Printf("unknown code [%v]\n", req.Opcode)
 	}
@@ -145,11 +154,26 @@ func (mms *MockMetaServer) handleAddMetaPartitionRaftMember(conn net.Conn, p *pr
 	return
 }
 
+func (mms *MockMetaServer) handleResetMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
+func (mms *MockMetaServer) handlePromoteMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
 func (mms *MockMetaServer) handleRemoveMetaPartitionRaftMember(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
 }
 
+func (mms *MockMetaServer) handleMetaPartitionSnapshot(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
+	responseAckOKToMaster(conn, p, nil)
+	return
+}
+
 func (mms *MockMetaServer) handleTryToLeader(conn net.Conn, p *proto.Packet, adminTask *proto.AdminTask) (err error) {
 	responseAckOKToMaster(conn, p, nil)
 	return
@@ -0,0 +1,64 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"math"
+	"time"
+)
+
+// The health score of a node is 100 at most, and is reduced by the heartbeat latency, the bad disks and the load
+// of the node. An inactive node scores 0. The weights of the nodes to place the partitions on are scaled by the
+// scores, so the healthier nodes are preferred.
+const (
+	maxHealthScore            = 100
+	heartbeatLatencyPenalty   = 30  // points lost by the heartbeat latency of maxHeartbeatLatency
+	maxHeartbeatLatency       = 10  // seconds
+	heartbeatLatencySmoothing = 0.3 // the weight of the latest latency in the moving average
+	badDiskPenalty            = 20  // points lost by a bad disk
+	maxBadDiskPenalty         = 40
+	loadPenalty               = 30 // points lost by the full load
+	minHealthWeightRatio      = 0.1
+	healthScoreDecimalsFactor = 10
+)
+
+// smoothHeartbeatLatency returns the moving average of the heartbeat latency with the latency of the task sent
+// at the unix time.
+func smoothHeartbeatLatency(average float64, sendTime int64) float64 {
+	latency := float64(time.Now().Unix() - sendTime)
+	if sendTime <= 0 || latency < 0 {
+		return average
+	}
+	return average*(1-heartbeatLatencySmoothing) + latency*heartbeatLatencySmoothing
+}
+
+// healthScore computes the score by the heartbeat latency in seconds, the number of the bad disks, and the load
+// in [0, 1] such as the used ratio of the space or the memory.
+func healthScore(active bool, latency float64, badDisks int, load float64) float64 {
+	if !active {
+		return 0
+	}
+	score := float64(maxHealthScore)
+	score -= math.Min(latency/maxHeartbeatLatency, 1) * heartbeatLatencyPenalty
+	score -= math.Min(float64(badDisks*badDiskPenalty), maxBadDiskPenalty)
+	score -= math.Min(math.Max(load, 0), 1) * loadPenalty
+	return math.Round(score*healthScoreDecimalsFactor) / healthScoreDecimalsFactor
+}
+
+// healthWeight scales the weight of a node to place the partitions on by its score, the nodes with the low scores
+// keep a small weight so they are still chosen if no other nodes are available.
+func healthWeight(weight, score float64) float64 {
+	return weight * math.Max(score/maxHealthScore, minHealthWeightRatio)
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthScore(t *testing.T) {
+	cases := []struct {
+		active   bool
+		latency  float64
+		badDisks int
+		load     float64
+		score    float64
+	}{
+		{true, 0, 0, 0, 100},
+		{false, 0, 0, 0, 0},
+		{true, 5, 0, 0, 85},
+		{true, 20, 0, 0, 70},
+		{true, 0, 1, 0, 80},
+		{true, 0, 3, 0, 60},
+		{true, 0, 0, 0.5, 85},
+		{true, 10, 2, 1, 0},
+	}
+	for _, c := range cases {
+		if score := healthScore(c.active, c.latency, c.badDisks, c.load); score != c.score {
+			t.Errorf("active %v latency %v bad disks %v load %v: score %v, expected %v",
+				c.active, c.latency, c.badDisks, c.load, score, c.score)
+		}
+	}
+	if weight := healthWeight(0.8, 50); weight != 0.4 {
+		t.Errorf("weight %v", weight)
+	}
+	if weight := healthWeight(1, 0); weight != minHealthWeightRatio {
+		t.Errorf("weight %v", weight)
+	}
+}
+
+func TestSmoothHeartbeatLatency(t *testing.T) {
+	now := time.Now().Unix()
+	latency := smoothHeartbeatLatency(0, now-10)
+	if latency < 3 || latency > 3.3 {
+		t.Errorf("latency %v", latency)
+	}
+	if smoothHeartbeatLatency(latency, 0) != latency {
+		t.Error("the latency is changed by the task without the send time")
+	}
+}
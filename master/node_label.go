@@ -0,0 +1,118 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The labels of a node are persisted with the node and replaced as a whole, the map is never modified in place,
+// so the holders of the old map are not affected. The placement labels of a volume restrict the nodes chosen for
+// the new replicas of its partitions, the existing replicas are not migrated.
+
+func (dataNode *DataNode) getLabels() map[string]string {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return dataNode.labels
+}
+
+func (metaNode *MetaNode) getLabels() map[string]string {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return metaNode.labels
+}
+
+func (c *Cluster) setDataNodeLabels(nodeAddr string, labels map[string]string) (err error) {
+	var dataNode *DataNode
+	if dataNode, err = c.dataNode(nodeAddr); err != nil {
+		return proto.ErrDataNodeNotExists
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+	dataNode.Lock()
+	oldLabels := dataNode.labels
+	dataNode.labels = labels
+	dataNode.Unlock()
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		log.LogErrorf("action[setDataNodeLabels] node[%v] err[%v]", nodeAddr, err)
+		dataNode.Lock()
+		dataNode.labels = oldLabels
+		dataNode.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[setDataNodeLabels] node[%v] labels[%v] -> [%v]", nodeAddr,
+		proto.FormatLabels(oldLabels), proto.FormatLabels(labels))
+	return
+}
+
+func (c *Cluster) setMetaNodeLabels(nodeAddr string, labels map[string]string) (err error) {
+	var metaNode *MetaNode
+	if metaNode, err = c.metaNode(nodeAddr); err != nil {
+		return proto.ErrMetaNodeNotExists
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+	metaNode.Lock()
+	oldLabels := metaNode.labels
+	metaNode.labels = labels
+	metaNode.Unlock()
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		log.LogErrorf("action[setMetaNodeLabels] node[%v] err[%v]", nodeAddr, err)
+		metaNode.Lock()
+		metaNode.labels = oldLabels
+		metaNode.Unlock()
+		return proto.ErrPersistenceByRaft
+	}
+	log.LogWarnf("action[setMetaNodeLabels] node[%v] labels[%v] -> [%v]", nodeAddr,
+		proto.FormatLabels(oldLabels), proto.FormatLabels(labels))
+	return
+}
+
+// validatePlacementLabels checks that enough writable nodes have the placement labels to hold the replicas of
+// the partitions of the volume.
+func (c *Cluster) validatePlacementLabels(vol *Vol, labels map[string]string) (err error) {
+	if len(labels) == 0 {
+		return
+	}
+	var dataNodes, metaNodes int
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		if dataNode.isWriteAble() && proto.MatchLabels(dataNode.getLabels(), labels) {
+			dataNodes++
+		}
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		if metaNode.isWritable() && proto.MatchLabels(metaNode.getLabels(), labels) {
+			metaNodes++
+		}
+		return true
+	})
+	if dataNodes < int(vol.dpReplicaNum) {
+		return fmt.Errorf("placement labels[%v] are matched by [%v] writable data nodes, less than the replicas[%v]",
+			proto.FormatLabels(labels), dataNodes, vol.dpReplicaNum)
+	}
+	if metaNodes < int(vol.mpReplicaNum) {
+		return fmt.Errorf("placement labels[%v] are matched by [%v] writable meta nodes, less than the replicas[%v]",
+			proto.FormatLabels(labels), metaNodes, vol.mpReplicaNum)
+	}
+	return
+}
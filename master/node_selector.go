@@ -40,6 +40,7 @@ type Node interface {
 	SelectNodeForWrite()
 	GetID() uint64
 	GetAddr() string
+	GetRack() string
 }
 
 // SortedWeightedNodes defines an array sorted by carry
@@ -134,6 +135,7 @@ func getAllCarryMetaNodes(maxTotal uint64, excludeHosts []string, metaNodes *syn
 		} else {
 			nt.Weight = (float64)(maxTotal-metaNode.Used) / (float64)(maxTotal)
 		}
+		nt.Weight = healthWeight(nt.Weight, metaNode.HealthScore)
 		nt.Ptr = metaNode
 		nodes = append(nodes, nt)
 
@@ -165,6 +167,7 @@ func getAvailCarryDataNodeTab(maxTotal uint64, excludeHosts []string, dataNodes
 		} else {
 			nt.Weight = float64(dataNode.AvailableSpace) / float64(maxTotal)
 		}
+		nt.Weight = healthWeight(nt.Weight, dataNode.HealthScore)
 		nt.Ptr = dataNode
 		nodeTabs = append(nodeTabs, nt)
 
@@ -205,8 +208,7 @@ func getAvailHosts(nodes *sync.Map, excludeHosts []string, replicaNum int, selec
 	weightedNodes.setNodeCarry(count, replicaNum)
 	sort.Sort(weightedNodes)
 
-	for i := 0; i < replicaNum; i++ {
-		node := weightedNodes[i].Ptr
+	for _, node := range spreadRacks(weightedNodes, usedRacks(nodes, excludeHosts), replicaNum) {
 		node.SelectNodeForWrite()
 		orderHosts = append(orderHosts, node.GetAddr())
 		peer := proto.Peer{ID: node.GetID(), Addr: node.GetAddr()}
@@ -220,6 +222,50 @@ func getAvailHosts(nodes *sync.Map, excludeHosts []string, replicaNum int, selec
 	return
 }
 
+// usedRacks returns the racks of the hosts, which are the existing replicas of the partition usually.
+func usedRacks(nodes *sync.Map, hosts []string) (racks []string) {
+	for _, host := range hosts {
+		value, ok := nodes.Load(host)
+		if !ok {
+			continue
+		}
+		if rack := value.(Node).GetRack(); rack != "" {
+			racks = append(racks, rack)
+		}
+	}
+	return
+}
+
+// spreadRacks picks the nodes in the order of the sorted weighted nodes, and prefers the nodes in the racks
+// without the picked nodes and the used racks, so that a rack failure takes down as few replicas as possible.
+// The nodes without a rack are never considered in the same rack.
+func spreadRacks(weightedNodes SortedWeightedNodes, racks []string, replicaNum int) (picked []Node) {
+	chosen := make([]bool, len(weightedNodes))
+	for i, wn := range weightedNodes {
+		if len(picked) >= replicaNum {
+			return
+		}
+		rack := wn.Ptr.GetRack()
+		if rack != "" && contains(racks, rack) {
+			continue
+		}
+		chosen[i] = true
+		picked = append(picked, wn.Ptr)
+		if rack != "" {
+			racks = append(racks, rack)
+		}
+	}
+	for i, wn := range weightedNodes {
+		if len(picked) >= replicaNum {
+			return
+		}
+		if !chosen[i] {
+			picked = append(picked, wn.Ptr)
+		}
+	}
+	return
+}
+
 func (ns *nodeSet) getAvailMetaNodeHosts(excludeHosts []string, replicaNum int) (newHosts []string, peers []proto.Peer, err error) {
 	return getAvailHosts(ns.metaNodes, excludeHosts, replicaNum, selectMetaNode)
 }
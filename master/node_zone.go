@@ -0,0 +1,146 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// getZoneNodeSetForDataNode returns a node set of the zone which can hold one more data node,
+// the zone and the node set are created if not exist.
+func (c *Cluster) getZoneNodeSetForDataNode(zoneName string) (zone *Zone, ns *nodeSet, err error) {
+	if zone, err = c.t.getZone(zoneName); err != nil {
+		zone, err = c.t.putZoneIfAbsent(newZone(zoneName)), nil
+	}
+	if ns = zone.getAvailNodeSetForDataNode(); ns == nil {
+		ns, err = zone.createNodeSet(c)
+	}
+	return
+}
+
+// getZoneNodeSetForMetaNode returns a node set of the zone which can hold one more meta node,
+// the zone and the node set are created if not exist.
+func (c *Cluster) getZoneNodeSetForMetaNode(zoneName string) (zone *Zone, ns *nodeSet, err error) {
+	if zone, err = c.t.getZone(zoneName); err != nil {
+		zone, err = c.t.putZoneIfAbsent(newZone(zoneName)), nil
+	}
+	if ns = zone.getAvailNodeSetForMetaNode(); ns == nil {
+		ns, err = zone.createNodeSet(c)
+	}
+	return
+}
+
+// moveDataNodeToZone moves the data node into a node set of another zone. The partitions on the node
+// are not migrated, the new zone only takes effect on the partitions created later.
+func (c *Cluster) moveDataNodeToZone(dataNode *DataNode, zoneName string) (err error) {
+	var (
+		srcZone, dstZone *Zone
+		ns               *nodeSet
+		oldZoneName      string
+		oldNodeSetID     uint64
+	)
+	c.dnMutex.Lock()
+	defer c.dnMutex.Unlock()
+	if dataNode.ZoneName == zoneName {
+		return
+	}
+	if srcZone, err = c.t.getZone(dataNode.ZoneName); err != nil {
+		goto errHandler
+	}
+	if dstZone, ns, err = c.getZoneNodeSetForDataNode(zoneName); err != nil {
+		goto errHandler
+	}
+	oldZoneName, oldNodeSetID = dataNode.ZoneName, dataNode.NodeSetID
+	// the node is deleted from the node set of the old zone before the node set ID is changed
+	srcZone.deleteDataNode(dataNode)
+	dataNode.Lock()
+	dataNode.ZoneName, dataNode.NodeSetID = zoneName, ns.ID
+	dataNode.Unlock()
+	if err = c.syncUpdateDataNode(dataNode); err != nil {
+		dataNode.Lock()
+		dataNode.ZoneName, dataNode.NodeSetID = oldZoneName, oldNodeSetID
+		dataNode.Unlock()
+		_ = srcZone.putDataNode(dataNode)
+		goto errHandler
+	}
+	if err = dstZone.putDataNode(dataNode); err != nil {
+		goto errHandler
+	}
+	if err = c.syncUpdateNodeSet(ns); err != nil {
+		goto errHandler
+	}
+	log.LogWarnf("action[moveDataNodeToZone] clusterID[%v] dataNode[%v] is moved from zone[%v] to zone[%v] nodeSet[%v]",
+		c.Name, dataNode.Addr, oldZoneName, zoneName, ns.ID)
+	return
+errHandler:
+	err = fmt.Errorf("action[moveDataNodeToZone] clusterID[%v] dataNode[%v] zone[%v] err:%v ",
+		c.Name, dataNode.Addr, zoneName, err.Error())
+	log.LogError(errors.Stack(err))
+	Warn(c.Name, err.Error())
+	return
+}
+
+// moveMetaNodeToZone moves the meta node into a node set of another zone. The partitions on the node
+// are not migrated, the new zone only takes effect on the partitions created later.
+func (c *Cluster) moveMetaNodeToZone(metaNode *MetaNode, zoneName string) (err error) {
+	var (
+		srcZone, dstZone *Zone
+		ns               *nodeSet
+		oldZoneName      string
+		oldNodeSetID     uint64
+	)
+	c.mnMutex.Lock()
+	defer c.mnMutex.Unlock()
+	if metaNode.ZoneName == zoneName {
+		return
+	}
+	if srcZone, err = c.t.getZone(metaNode.ZoneName); err != nil {
+		goto errHandler
+	}
+	if dstZone, ns, err = c.getZoneNodeSetForMetaNode(zoneName); err != nil {
+		goto errHandler
+	}
+	oldZoneName, oldNodeSetID = metaNode.ZoneName, metaNode.NodeSetID
+	// the node is deleted from the node set of the old zone before the node set ID is changed
+	_ = srcZone.deleteMetaNode(metaNode)
+	metaNode.Lock()
+	metaNode.ZoneName, metaNode.NodeSetID = zoneName, ns.ID
+	metaNode.Unlock()
+	if err = c.syncUpdateMetaNode(metaNode); err != nil {
+		metaNode.Lock()
+		metaNode.ZoneName, metaNode.NodeSetID = oldZoneName, oldNodeSetID
+		metaNode.Unlock()
+		_ = srcZone.putMetaNode(metaNode)
+		goto errHandler
+	}
+	if err = dstZone.putMetaNode(metaNode); err != nil {
+		goto errHandler
+	}
+	if err = c.syncUpdateNodeSet(ns); err != nil {
+		goto errHandler
+	}
+	log.LogWarnf("action[moveMetaNodeToZone] clusterID[%v] metaNode[%v] is moved from zone[%v] to zone[%v] nodeSet[%v]",
+		c.Name, metaNode.Addr, oldZoneName, zoneName, ns.ID)
+	return
+errHandler:
+	err = fmt.Errorf("action[moveMetaNodeToZone] clusterID[%v] metaNode[%v] zone[%v] err:%v ",
+		c.Name, metaNode.Addr, zoneName, err.Error())
+	log.LogError(errors.Stack(err))
+	Warn(c.Name, err.Error())
+	return
+}
@@ -28,7 +28,7 @@ import (
 	"time"
 )
 
-func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Peer, dataPartitionSize int, hosts []string, createType int) (req *proto.CreateDataPartitionRequest) {
+func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Peer, dataPartitionSize int, hosts []string, createType int, snapshotEpoch uint64) (req *proto.CreateDataPartitionRequest) {
 	req = &proto.CreateDataPartitionRequest{
 		PartitionId:   ID,
 		PartitionSize: dataPartitionSize,
@@ -36,6 +36,7 @@ func newCreateDataPartitionRequest(volName string, ID uint64, members []proto.Pe
 		Members:       members,
 		Hosts:         hosts,
 		CreateType:    createType,
+		SnapshotEpoch: snapshotEpoch,
 	}
 	return
 }
@@ -0,0 +1,167 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The plans below run the same validation and target selection as the admin operations without changing anything.
+// The target node is chosen by weight, so the node chosen by the real operation may differ from the plan.
+
+func newDataPartitionPlan(operation string, dp *DataPartition) (plan *proto.PartitionOperationPlan) {
+	dp.RLock()
+	defer dp.RUnlock()
+	plan = &proto.PartitionOperationPlan{
+		Operation:   operation,
+		PartitionID: dp.PartitionID,
+		VolName:     dp.VolName,
+		ReplicaNum:  dp.ReplicaNum,
+		HostsBefore: append([]string{}, dp.Hosts...),
+		MoveSize:    dp.used,
+	}
+	return
+}
+
+func newMetaPartitionPlan(operation string, mp *MetaPartition) (plan *proto.PartitionOperationPlan) {
+	mp.RLock()
+	defer mp.RUnlock()
+	plan = &proto.PartitionOperationPlan{
+		Operation:   operation,
+		PartitionID: mp.PartitionID,
+		VolName:     mp.volName,
+		ReplicaNum:  mp.ReplicaNum,
+		HostsBefore: append([]string{}, mp.Hosts...),
+	}
+	return
+}
+
+// removeHosts returns the hosts except the removed ones, and appends the added host if it is not empty.
+func removeHosts(hosts []string, removed []string, added string) (result []string) {
+	result = make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if !contains(removed, host) {
+			result = append(result, host)
+		}
+	}
+	if added != "" {
+		result = append(result, added)
+	}
+	return
+}
+
+func (c *Cluster) planDecommissionDataPartition(dp *DataPartition, offlineAddr string) (plan *proto.PartitionOperationPlan, err error) {
+	var dataNode *DataNode
+	plan = newDataPartitionPlan(proto.AdminDecommissionDataPartition, dp)
+	if !contains(plan.HostsBefore, offlineAddr) {
+		err = fmt.Errorf("data partition[%v] has no replica on [%v]", dp.PartitionID, offlineAddr)
+		return
+	}
+	if err = c.validateDecommissionDataPartition(dp, offlineAddr); err != nil {
+		return
+	}
+	if plan.TargetAddr, err = c.chooseDataPartitionDecommissionTarget(dp, offlineAddr); err != nil {
+		return
+	}
+	if dataNode, err = c.dataNode(plan.TargetAddr); err != nil {
+		return
+	}
+	plan.TargetAvailableSpace = dataNode.AvailableSpace
+	plan.RemovedAddrs = []string{offlineAddr}
+	plan.HostsAfter = removeHosts(plan.HostsBefore, plan.RemovedAddrs, plan.TargetAddr)
+	return
+}
+
+func (c *Cluster) planDeleteDataReplica(dp *DataPartition, addr string) (plan *proto.PartitionOperationPlan, err error) {
+	plan = newDataPartitionPlan(proto.AdminDeleteDataReplica, dp)
+	plan.MoveSize = 0
+	if !contains(plan.HostsBefore, addr) {
+		err = fmt.Errorf("data partition[%v] has no replica on [%v]", dp.PartitionID, addr)
+		return
+	}
+	if err = c.validateDecommissionDataPartition(dp, addr); err != nil {
+		return
+	}
+	if c.isRecovering(dp, addr) {
+		err = fmt.Errorf("vol[%v],data partition[%v] can't decommision until it has recovered", dp.VolName, dp.PartitionID)
+		return
+	}
+	plan.RemovedAddrs = []string{addr}
+	plan.HostsAfter = removeHosts(plan.HostsBefore, plan.RemovedAddrs, "")
+	return
+}
+
+func (c *Cluster) planResetDataPartition(dp *DataPartition) (plan *proto.PartitionOperationPlan, err error) {
+	var liveHosts []string
+	plan = newDataPartitionPlan(proto.AdminResetDataPartition, dp)
+	plan.MoveSize = 0
+	liveHosts, _, plan.RemovedAddrs = c.getLiveDataPartitionPeers(dp)
+	if err = validateResetPartition(liveHosts, dp.ReplicaNum); err != nil {
+		return
+	}
+	plan.HostsAfter = liveHosts
+	return
+}
+
+func (c *Cluster) planDecommissionMetaPartition(mp *MetaPartition, nodeAddr string) (plan *proto.PartitionOperationPlan, err error) {
+	var metaNode *MetaNode
+	plan = newMetaPartitionPlan(proto.AdminDecommissionMetaPartition, mp)
+	if !contains(plan.HostsBefore, nodeAddr) {
+		err = fmt.Errorf("meta partition[%v] has no replica on [%v]", mp.PartitionID, nodeAddr)
+		return
+	}
+	if err = c.validateDecommissionMetaPartition(mp, nodeAddr); err != nil {
+		return
+	}
+	if plan.TargetAddr, err = c.chooseMetaPartitionDecommissionTarget(mp, nodeAddr, plan.HostsBefore); err != nil {
+		return
+	}
+	if metaNode, err = c.metaNode(plan.TargetAddr); err != nil {
+		return
+	}
+	if metaNode.Total > metaNode.Used {
+		plan.TargetAvailableSpace = metaNode.Total - metaNode.Used
+	}
+	plan.RemovedAddrs = []string{nodeAddr}
+	plan.HostsAfter = removeHosts(plan.HostsBefore, plan.RemovedAddrs, plan.TargetAddr)
+	return
+}
+
+func (c *Cluster) planDeleteMetaReplica(mp *MetaPartition, addr string) (plan *proto.PartitionOperationPlan, err error) {
+	plan = newMetaPartitionPlan(proto.AdminDeleteMetaReplica, mp)
+	if !contains(plan.HostsBefore, addr) {
+		err = fmt.Errorf("meta partition[%v] has no replica on [%v]", mp.PartitionID, addr)
+		return
+	}
+	if err = c.validateDecommissionMetaPartition(mp, addr); err != nil {
+		return
+	}
+	plan.RemovedAddrs = []string{addr}
+	plan.HostsAfter = removeHosts(plan.HostsBefore, plan.RemovedAddrs, "")
+	return
+}
+
+func (c *Cluster) planResetMetaPartition(mp *MetaPartition) (plan *proto.PartitionOperationPlan, err error) {
+	var liveHosts []string
+	plan = newMetaPartitionPlan(proto.AdminResetMetaPartition, mp)
+	liveHosts, _, plan.RemovedAddrs = c.getLiveMetaPartitionPeers(mp)
+	if err = validateResetPartition(liveHosts, mp.ReplicaNum); err != nil {
+		return
+	}
+	plan.HostsAfter = liveHosts
+	return
+}
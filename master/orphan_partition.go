@@ -0,0 +1,207 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// An orphan replica is a partition replica reported by a node, which is unknown to the master, or whose partition
+// does not take the node as a member. The replicas of the volumes being deleted are not orphans.
+
+const (
+	orphanReasonVolNotFound       = "volume not found"
+	orphanReasonPartitionNotFound = "partition not found"
+	orphanReasonNotMember         = "node is not a member of the partition"
+)
+
+func (c *Cluster) checkOrphanDataReplica(addr string, report *proto.PartitionReport) (reason string) {
+	var (
+		dp  *DataPartition
+		err error
+	)
+	if report.VolName != "" {
+		var vol *Vol
+		if vol, err = c.getVol(report.VolName); err != nil {
+			return orphanReasonVolNotFound
+		}
+		if vol.Status == markDelete {
+			return
+		}
+		dp, err = vol.getDataPartitionByID(report.PartitionID)
+	} else {
+		dp, err = c.getDataPartitionByID(report.PartitionID)
+	}
+	if err != nil {
+		return orphanReasonPartitionNotFound
+	}
+	dp.RLock()
+	defer dp.RUnlock()
+	if !contains(dp.Hosts, addr) {
+		return orphanReasonNotMember
+	}
+	return
+}
+
+func (c *Cluster) checkOrphanMetaReplica(addr string, report *proto.MetaPartitionReport) (reason string) {
+	var (
+		mp  *MetaPartition
+		err error
+	)
+	if report.VolName != "" {
+		var vol *Vol
+		if vol, err = c.getVol(report.VolName); err != nil {
+			return orphanReasonVolNotFound
+		}
+		if vol.Status == markDelete {
+			return
+		}
+		mp, err = vol.metaPartition(report.PartitionID)
+	} else {
+		mp, err = c.getMetaPartitionByID(report.PartitionID)
+	}
+	if err != nil {
+		return orphanReasonPartitionNotFound
+	}
+	mp.RLock()
+	defer mp.RUnlock()
+	if !contains(mp.Hosts, addr) {
+		return orphanReasonNotMember
+	}
+	return
+}
+
+func (dataNode *DataNode) getPartitionReports() (reports []*proto.PartitionReport) {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return dataNode.DataPartitionReports
+}
+
+func (metaNode *MetaNode) getPartitionReports() (reports []*proto.MetaPartitionReport) {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return metaNode.metaPartitionInfos
+}
+
+// findOrphanPartitions cross-references the partitions reported by the nodes in the last heartbeat against
+// the partitions of the master.
+func (c *Cluster) findOrphanPartitions() (orphans []*proto.OrphanPartitionView) {
+	orphans = make([]*proto.OrphanPartitionView, 0)
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dataNode := value.(*DataNode)
+		for _, report := range dataNode.getPartitionReports() {
+			if report == nil {
+				continue
+			}
+			if reason := c.checkOrphanDataReplica(dataNode.Addr, report); reason != "" {
+				orphans = append(orphans, &proto.OrphanPartitionView{
+					PartitionType: proto.PartitionTypeData,
+					PartitionID:   report.PartitionID,
+					VolName:       report.VolName,
+					NodeAddr:      dataNode.Addr,
+					Reason:        reason,
+				})
+			}
+		}
+		return true
+	})
+	c.metaNodes.Range(func(key, value interface{}) bool {
+		metaNode := value.(*MetaNode)
+		for _, report := range metaNode.getPartitionReports() {
+			if report == nil {
+				continue
+			}
+			if reason := c.checkOrphanMetaReplica(metaNode.Addr, report); reason != "" {
+				orphans = append(orphans, &proto.OrphanPartitionView{
+					PartitionType: proto.PartitionTypeMeta,
+					PartitionID:   report.PartitionID,
+					VolName:       report.VolName,
+					NodeAddr:      metaNode.Addr,
+					Reason:        reason,
+				})
+			}
+		}
+		return true
+	})
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].PartitionType != orphans[j].PartitionType {
+			return orphans[i].PartitionType < orphans[j].PartitionType
+		}
+		if orphans[i].PartitionID != orphans[j].PartitionID {
+			return orphans[i].PartitionID < orphans[j].PartitionID
+		}
+		return orphans[i].NodeAddr < orphans[j].NodeAddr
+	})
+	return
+}
+
+// cleanOrphanPartition deletes the orphan replica on the node. The replica is checked again with the latest
+// report of the node, so the replica which has been taken as a member since it was found is not deleted.
+func (c *Cluster) cleanOrphanPartition(partitionType string, partitionID uint64, addr string) (err error) {
+	var task *proto.AdminTask
+	switch partitionType {
+	case proto.PartitionTypeData:
+		var dataNode *DataNode
+		if dataNode, err = c.dataNode(addr); err != nil {
+			goto errHandler
+		}
+		for _, report := range dataNode.getPartitionReports() {
+			if report == nil || report.PartitionID != partitionID {
+				continue
+			}
+			if c.checkOrphanDataReplica(addr, report) == "" {
+				break
+			}
+			task = proto.NewAdminTask(proto.OpDeleteDataPartition, addr, newDeleteDataPartitionRequest(partitionID))
+			task.ID = fmt.Sprintf("%v_DataPartitionID[%v]", task.ID, partitionID)
+			task.PartitionID = partitionID
+			c.addDataNodeTask(task)
+		}
+	case proto.PartitionTypeMeta:
+		var metaNode *MetaNode
+		if metaNode, err = c.metaNode(addr); err != nil {
+			goto errHandler
+		}
+		for _, report := range metaNode.getPartitionReports() {
+			if report == nil || report.PartitionID != partitionID {
+				continue
+			}
+			if c.checkOrphanMetaReplica(addr, report) == "" {
+				break
+			}
+			task = proto.NewAdminTask(proto.OpDeleteMetaPartition, addr, &proto.DeleteMetaPartitionRequest{PartitionID: partitionID})
+			resetMetaPartitionTaskID(task, partitionID)
+			c.addMetaNodeTasks([]*proto.AdminTask{task})
+		}
+	default:
+		err = fmt.Errorf("unknown partition type[%v]", partitionType)
+		goto errHandler
+	}
+	if task == nil {
+		err = fmt.Errorf("%v partition[%v] on node[%v] is not an orphan", partitionType, partitionID, addr)
+		goto errHandler
+	}
+	log.LogWarnf("action[cleanOrphanPartition] clusterID[%v] delete orphan %v partition[%v] on node[%v]",
+		c.Name, partitionType, partitionID, addr)
+	return
+errHandler:
+	log.LogErrorf("action[cleanOrphanPartition] clusterID[%v] %v partition[%v] node[%v] err[%v]",
+		c.Name, partitionType, partitionID, addr, err)
+	return
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	// seconds for the target replica to win the election, which must be less than proto.SyncSendTaskDeadlineTime
+	transferLeaderWaitSeconds = 10
+)
+
+func newTransferLeaderRequest(partitionID uint64) *proto.TransferLeaderRequest {
+	return &proto.TransferLeaderRequest{PartitionId: partitionID, WaitSeconds: transferLeaderWaitSeconds}
+}
+
+// transferDataPartitionLeader makes the replica on the node the raft leader of the data partition,
+// so that the leadership can be drained from a node before maintenance.
+func (c *Cluster) transferDataPartitionLeader(dp *DataPartition, addr string) (err error) {
+	var (
+		dataNode *DataNode
+		task     *proto.AdminTask
+	)
+	dp.RLock()
+	hasHost := dp.hasHost(addr)
+	leaderAddr := dp.getLeaderAddr()
+	dp.RUnlock()
+	if !hasHost {
+		err = fmt.Errorf("node[%v] is not a member of the partition", addr)
+		goto errHandler
+	}
+	if leaderAddr == addr {
+		return
+	}
+	if dataNode, err = c.dataNode(addr); err != nil {
+		goto errHandler
+	}
+	if !dataNode.isActive {
+		err = fmt.Errorf("node[%v] is inactive", addr)
+		goto errHandler
+	}
+	if task, err = dp.createTaskToTryToChangeLeader(addr); err != nil {
+		goto errHandler
+	}
+	task.Request = newTransferLeaderRequest(dp.PartitionID)
+	if _, err = dataNode.TaskManager.syncSendAdminTask(task); err != nil {
+		goto errHandler
+	}
+	dp.Lock()
+	for _, replica := range dp.Replicas {
+		replica.IsLeader = replica.Addr == addr
+	}
+	dp.Unlock()
+	log.LogWarnf("action[transferDataPartitionLeader] clusterID[%v] partitionID[%v] leader[%v] -> [%v]",
+		c.Name, dp.PartitionID, leaderAddr, addr)
+	return
+errHandler:
+	err = fmt.Errorf("action[transferDataPartitionLeader] partitionID[%v] addr[%v] err[%v]", dp.PartitionID, addr, err)
+	log.LogError(err)
+	return
+}
+
+// transferMetaPartitionLeader makes the replica on the node the raft leader of the meta partition.
+func (c *Cluster) transferMetaPartitionLeader(mp *MetaPartition, addr string) (err error) {
+	var (
+		metaNode   *MetaNode
+		task       *proto.AdminTask
+		leaderAddr string
+	)
+	mp.RLock()
+	hasHost := contains(mp.Hosts, addr)
+	if leader, leaderErr := mp.getMetaReplicaLeader(); leaderErr == nil {
+		leaderAddr = leader.Addr
+	}
+	mp.RUnlock()
+	if !hasHost {
+		err = fmt.Errorf("node[%v] is not a member of the partition", addr)
+		goto errHandler
+	}
+	if leaderAddr == addr {
+		return
+	}
+	if metaNode, err = c.metaNode(addr); err != nil {
+		goto errHandler
+	}
+	if !metaNode.IsActive {
+		err = fmt.Errorf("node[%v] is inactive", addr)
+		goto errHandler
+	}
+	if task, err = mp.createTaskToTryToChangeLeader(addr); err != nil {
+		goto errHandler
+	}
+	task.Request = newTransferLeaderRequest(mp.PartitionID)
+	if _, err = metaNode.Sender.syncSendAdminTask(task); err != nil {
+		goto errHandler
+	}
+	mp.Lock()
+	for _, replica := range mp.Replicas {
+		replica.IsLeader = replica.Addr == addr
+	}
+	mp.Unlock()
+	log.LogWarnf("action[transferMetaPartitionLeader] clusterID[%v] partitionID[%v] leader[%v] -> [%v]",
+		c.Name, mp.PartitionID, leaderAddr, addr)
+	return
+errHandler:
+	err = fmt.Errorf("action[transferMetaPartitionLeader] partitionID[%v] addr[%v] err[%v]", mp.PartitionID, addr, err)
+	log.LogError(err)
+	return
+}
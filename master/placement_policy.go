@@ -0,0 +1,508 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// PlacementNode defines a node seen by the placement policies.
+type PlacementNode struct {
+	ID          uint64
+	Addr        string
+	ZoneName    string
+	RackName    string // empty if the node reports no rack
+	NodeSetID   uint64
+	Total       uint64 // the disk space of a data node, or the memory of a meta node
+	Used        uint64
+	Avail       uint64
+	HealthScore float64
+	Labels      map[string]string // attached by the admin, never modified
+}
+
+// PlacementRequest defines the replica of a partition to place, or the volume to validate the policy for.
+type PlacementRequest struct {
+	VolName       string
+	VolZone       string   // the zone of the volume, empty if the volume is not in a specific zone
+	PlacementZone string   // the zone set along with the policy
+	ReplicaNum    int      // the replicas of the partitions of the volume
+	Zones         []string // the zones of the cluster
+	PartitionType string   // proto.PartitionTypeData or proto.PartitionTypeMeta, empty to validate the policy
+	Placed        []*PlacementNode
+	Labels        map[string]string // the placement labels of the volume, which all the candidates have
+}
+
+// PlacementPolicy chooses the node for a replica of a partition. The policies are registered by name, and the
+// volume chooses one by its placement policy. The candidates are the writable nodes with enough space in the
+// available zones having the placement labels of the volume, without the ones holding the replicas of the partition
+// or excluded by the caller.
+type PlacementPolicy interface {
+	Name() string
+	// Validate checks whether the policy can place the replicas of the volume.
+	Validate(req *PlacementRequest) error
+	// Choose chooses one of the candidates for the replica, the placed nodes are the existing replicas.
+	Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error)
+}
+
+var placementPolicies = struct {
+	sync.RWMutex
+	policies map[string]PlacementPolicy
+}{policies: make(map[string]PlacementPolicy)}
+
+func init() {
+	for _, policy := range []PlacementPolicy{
+		&zoneSpreadPolicy{},
+		&zonePinnedPolicy{},
+		&rackDiversePolicy{},
+		&capacityWeightedPolicy{},
+		&roundRobinPolicy{},
+	} {
+		if err := RegisterPlacementPolicy(policy); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterPlacementPolicy registers the placement policy to be chosen by the volumes. It should be called before
+// the master starts, such as in the init function of a package linked into the master.
+func RegisterPlacementPolicy(policy PlacementPolicy) error {
+	name := policy.Name()
+	if name == proto.PlacementDefault || name == "default" {
+		return fmt.Errorf("placement policy name[%v] is reserved", name)
+	}
+	placementPolicies.Lock()
+	defer placementPolicies.Unlock()
+	if _, ok := placementPolicies.policies[name]; ok {
+		return fmt.Errorf("placement policy[%v] is registered", name)
+	}
+	placementPolicies.policies[name] = policy
+	return nil
+}
+
+func getPlacementPolicy(name string) (policy PlacementPolicy, err error) {
+	placementPolicies.RLock()
+	defer placementPolicies.RUnlock()
+	var ok bool
+	if policy, ok = placementPolicies.policies[name]; !ok {
+		return nil, fmt.Errorf("unknown placement policy[%v]", name)
+	}
+	return
+}
+
+// placementNodes abstracts the data nodes and the meta nodes for choosing the hosts by the placement policy.
+type placementNodes struct {
+	partitionType string
+	// node returns the node, nil if it is not found.
+	node func(addr string) *PlacementNode
+	// candidates returns the writable nodes with enough space in the available zones except the excluded ones.
+	candidates func(excludeHosts []string) []*PlacementNode
+	// selected updates the carry of the node chosen for a new replica.
+	selected func(addr string)
+}
+
+func (c *Cluster) isZoneAvailable(zoneName string) bool {
+	zone, err := c.t.getZone(zoneName)
+	return err == nil && zone.getStatus() != unavailableZone
+}
+
+func newDataPlacementNode(dataNode *DataNode) *PlacementNode {
+	dataNode.RLock()
+	defer dataNode.RUnlock()
+	return &PlacementNode{
+		ID:          dataNode.ID,
+		Addr:        dataNode.Addr,
+		ZoneName:    dataNode.ZoneName,
+		RackName:    dataNode.RackName,
+		NodeSetID:   dataNode.NodeSetID,
+		Total:       dataNode.Total,
+		Used:        dataNode.Used,
+		Avail:       dataNode.AvailableSpace,
+		HealthScore: dataNode.HealthScore,
+		Labels:      dataNode.labels,
+	}
+}
+
+func newMetaPlacementNode(metaNode *MetaNode) *PlacementNode {
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	node := &PlacementNode{
+		ID:          metaNode.ID,
+		Addr:        metaNode.Addr,
+		ZoneName:    metaNode.ZoneName,
+		RackName:    metaNode.RackName,
+		NodeSetID:   metaNode.NodeSetID,
+		Total:       metaNode.Total,
+		Used:        metaNode.Used,
+		HealthScore: metaNode.HealthScore,
+		Labels:      metaNode.labels,
+	}
+	if node.Total > node.Used {
+		node.Avail = node.Total - node.Used
+	}
+	return node
+}
+
+func (c *Cluster) dataPlacementNodes() *placementNodes {
+	return &placementNodes{
+		partitionType: proto.PartitionTypeData,
+		node: func(addr string) *PlacementNode {
+			dataNode, err := c.dataNode(addr)
+			if err != nil {
+				return nil
+			}
+			return newDataPlacementNode(dataNode)
+		},
+		candidates: func(excludeHosts []string) (nodes []*PlacementNode) {
+			c.dataNodes.Range(func(key, value interface{}) bool {
+				dataNode := value.(*DataNode)
+				if !contains(excludeHosts, dataNode.Addr) && dataNode.isWriteAble() && c.isZoneAvailable(dataNode.ZoneName) {
+					nodes = append(nodes, newDataPlacementNode(dataNode))
+				}
+				return true
+			})
+			return
+		},
+		selected: func(addr string) {
+			if dataNode, err := c.dataNode(addr); err == nil {
+				dataNode.SelectNodeForWrite()
+			}
+		},
+	}
+}
+
+func (c *Cluster) metaPlacementNodes() *placementNodes {
+	return &placementNodes{
+		partitionType: proto.PartitionTypeMeta,
+		node: func(addr string) *PlacementNode {
+			metaNode, err := c.metaNode(addr)
+			if err != nil {
+				return nil
+			}
+			return newMetaPlacementNode(metaNode)
+		},
+		candidates: func(excludeHosts []string) (nodes []*PlacementNode) {
+			c.metaNodes.Range(func(key, value interface{}) bool {
+				metaNode := value.(*MetaNode)
+				if !contains(excludeHosts, metaNode.Addr) && metaNode.isWritable() && c.isZoneAvailable(metaNode.ZoneName) {
+					nodes = append(nodes, newMetaPlacementNode(metaNode))
+				}
+				return true
+			})
+			return
+		},
+		selected: func(addr string) {
+			if metaNode, err := c.metaNode(addr); err == nil {
+				metaNode.SelectNodeForWrite()
+			}
+		},
+	}
+}
+
+func (c *Cluster) zoneNames() (names []string) {
+	for _, zone := range c.t.getAllZones() {
+		names = append(names, zone.name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// usesPlacementPolicy returns whether the hosts of the partitions of the volume are chosen by a placement policy.
+// The volume with the placement labels but no policy is placed by the capacity-weighted policy.
+func (vol *Vol) usesPlacementPolicy() bool {
+	return vol.placementPolicy != proto.PlacementDefault || len(vol.placementLabels) != 0
+}
+
+func (vol *Vol) effectivePlacementPolicy() string {
+	if vol.placementPolicy == proto.PlacementDefault {
+		return proto.PlacementCapacityWeighted
+	}
+	return vol.placementPolicy
+}
+
+// validatePlacementPolicy checks the placement policy of the volume, the placement zone is only allowed by the
+// policies using it, such as zone-pinned.
+func (c *Cluster) validatePlacementPolicy(vol *Vol, policyName, zoneName string) (err error) {
+	if policyName == proto.PlacementDefault {
+		if zoneName != "" {
+			return fmt.Errorf("placement zone is not allowed by the default placement policy")
+		}
+		return
+	}
+	var policy PlacementPolicy
+	if policy, err = getPlacementPolicy(policyName); err != nil {
+		return
+	}
+	replicaNum := int(vol.dpReplicaNum)
+	if int(vol.mpReplicaNum) > replicaNum {
+		replicaNum = int(vol.mpReplicaNum)
+	}
+	return policy.Validate(&PlacementRequest{
+		VolName:       vol.Name,
+		VolZone:       vol.zoneName,
+		PlacementZone: zoneName,
+		ReplicaNum:    replicaNum,
+		Zones:         c.zoneNames(),
+	})
+}
+
+func (c *Cluster) newPlacementRequest(vol *Vol, nodes *placementNodes, placed []string) (req *PlacementRequest) {
+	req = &PlacementRequest{
+		VolName:       vol.Name,
+		VolZone:       vol.zoneName,
+		PlacementZone: vol.placementZone,
+		ReplicaNum:    int(vol.dpReplicaNum),
+		Zones:         c.zoneNames(),
+		PartitionType: nodes.partitionType,
+		Labels:        vol.placementLabels,
+	}
+	if nodes.partitionType == proto.PartitionTypeMeta {
+		req.ReplicaNum = int(vol.mpReplicaNum)
+	}
+	for _, host := range placed {
+		if node := nodes.node(host); node != nil {
+			req.Placed = append(req.Placed, node)
+		}
+	}
+	return
+}
+
+// placementCandidates returns the candidates with the placement labels of the volume.
+func placementCandidates(req *PlacementRequest, nodes *placementNodes, excludeHosts []string) []*PlacementNode {
+	return filterPlacementNodes(nodes.candidates(excludeHosts), func(node *PlacementNode) bool {
+		return proto.MatchLabels(node.Labels, req.Labels)
+	})
+}
+
+// choosePlacementHosts chooses the hosts for the replicas of a partition by the placement policy of the volume.
+// The placed hosts are the existing replicas of the partition, and the excluded hosts are never chosen.
+func (c *Cluster) choosePlacementHosts(vol *Vol, nodes *placementNodes, placed, excludeHosts []string, replicaNum int) (hosts []string, peers []proto.Peer, err error) {
+	var policy PlacementPolicy
+	if policy, err = getPlacementPolicy(vol.effectivePlacementPolicy()); err != nil {
+		return
+	}
+	req := c.newPlacementRequest(vol, nodes, placed)
+	excludeHosts = append(append([]string{}, excludeHosts...), placed...)
+	for i := 0; i < replicaNum; i++ {
+		var node *PlacementNode
+		candidates := placementCandidates(req, nodes, excludeHosts)
+		if len(candidates) == 0 {
+			err = fmt.Errorf("no writable node with labels[%v] for placement policy[%v]",
+				proto.FormatLabels(req.Labels), policy.Name())
+		} else {
+			node, err = policy.Choose(req, candidates)
+		}
+		if err != nil {
+			log.LogErrorf("action[choosePlacementHosts] vol[%v] policy[%v] labels[%v] placed[%v] err[%v]",
+				vol.Name, policy.Name(), proto.FormatLabels(req.Labels), placed, err)
+			return nil, nil, err
+		}
+		nodes.selected(node.Addr)
+		hosts = append(hosts, node.Addr)
+		peers = append(peers, proto.Peer{ID: node.ID, Addr: node.Addr})
+		placed = append(placed, node.Addr)
+		req.Placed = append(req.Placed, node)
+		excludeHosts = append(excludeHosts, node.Addr)
+	}
+	return
+}
+
+// excludeHost returns the hosts without the host.
+func excludeHost(hosts []string, host string) (remains []string) {
+	for _, h := range hosts {
+		if h != host {
+			remains = append(remains, h)
+		}
+	}
+	return
+}
+
+// filterPlacementNodes returns the nodes accepted by the filter.
+func filterPlacementNodes(nodes []*PlacementNode, accept func(node *PlacementNode) bool) (filtered []*PlacementNode) {
+	for _, node := range nodes {
+		if accept(node) {
+			filtered = append(filtered, node)
+		}
+	}
+	return
+}
+
+// inVolZone returns the nodes in the zone of the volume, or all the nodes if the volume is not in a specific zone.
+func inVolZone(req *PlacementRequest, nodes []*PlacementNode) []*PlacementNode {
+	if req.VolZone == "" {
+		return nodes
+	}
+	return filterPlacementNodes(nodes, func(node *PlacementNode) bool { return node.ZoneName == req.VolZone })
+}
+
+func placedAddrs(req *PlacementRequest) (addrs []string) {
+	for _, node := range req.Placed {
+		addrs = append(addrs, node.Addr)
+	}
+	return
+}
+
+func rejectPlacementZone(policyName string, req *PlacementRequest) error {
+	if req.PlacementZone != "" {
+		return fmt.Errorf("placement zone is only allowed by placement policy[%v], not [%v]", proto.PlacementZonePinned, policyName)
+	}
+	return nil
+}
+
+// chooseByCapacity chooses a node randomly, weighted by the available space and the health of the nodes.
+func chooseByCapacity(nodes []*PlacementNode) *PlacementNode {
+	var total float64
+	weights := make([]float64, len(nodes))
+	for i, node := range nodes {
+		weights[i] = healthWeight(float64(node.Avail), node.HealthScore)
+		total += weights[i]
+	}
+	if total <= 0 {
+		return nodes[rand.Intn(len(nodes))]
+	}
+	pick := rand.Float64() * total
+	for i, weight := range weights {
+		if pick < weight {
+			return nodes[i]
+		}
+		pick -= weight
+	}
+	return nodes[len(nodes)-1]
+}
+
+// zoneSpreadPolicy places one replica per zone.
+type zoneSpreadPolicy struct{}
+
+func (p *zoneSpreadPolicy) Name() string { return proto.PlacementZoneSpread }
+
+func (p *zoneSpreadPolicy) Validate(req *PlacementRequest) error {
+	if err := rejectPlacementZone(p.Name(), req); err != nil {
+		return err
+	}
+	if len(req.Zones) < req.ReplicaNum {
+		return fmt.Errorf("placement policy[%v] requires [%v] zones, but the cluster has [%v] zones",
+			p.Name(), req.ReplicaNum, len(req.Zones))
+	}
+	return nil
+}
+
+func (p *zoneSpreadPolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	usedZones := make([]string, 0, len(req.Placed))
+	for _, node := range req.Placed {
+		usedZones = append(usedZones, node.ZoneName)
+	}
+	if nodes := filterPlacementNodes(candidates, func(node *PlacementNode) bool {
+		return !contains(usedZones, node.ZoneName)
+	}); len(nodes) > 0 {
+		return chooseByCapacity(nodes), nil
+	}
+	return nil, fmt.Errorf("no available zone without the replicas%v for placement policy[%v]", placedAddrs(req), p.Name())
+}
+
+// zonePinnedPolicy places all the replicas in the placement zone.
+type zonePinnedPolicy struct{}
+
+func (p *zonePinnedPolicy) Name() string { return proto.PlacementZonePinned }
+
+func (p *zonePinnedPolicy) Validate(req *PlacementRequest) error {
+	if req.PlacementZone == "" {
+		return keyNotFound(placementZoneKey)
+	}
+	if !contains(req.Zones, req.PlacementZone) {
+		return fmt.Errorf("zone[%v] not found", req.PlacementZone)
+	}
+	return nil
+}
+
+func (p *zonePinnedPolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	if nodes := filterPlacementNodes(candidates, func(node *PlacementNode) bool {
+		return node.ZoneName == req.PlacementZone
+	}); len(nodes) > 0 {
+		return chooseByCapacity(nodes), nil
+	}
+	return nil, fmt.Errorf("no available node in zone[%v] for placement policy[%v]", req.PlacementZone, p.Name())
+}
+
+// rackDiversePolicy places one replica per rack, in the zone of the volume if it is specified. The node set is
+// taken as the rack of the nodes without a rack.
+type rackDiversePolicy struct{}
+
+func (p *rackDiversePolicy) Name() string { return proto.PlacementRackDiverse }
+
+func (p *rackDiversePolicy) Validate(req *PlacementRequest) error {
+	return rejectPlacementZone(p.Name(), req)
+}
+
+func (p *rackDiversePolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	rackKey := func(node *PlacementNode) string {
+		if node.RackName == "" {
+			return fmt.Sprintf("nodeSet_%v", node.NodeSetID)
+		}
+		return fmt.Sprintf("%v_%v", node.ZoneName, node.RackName)
+	}
+	usedRacks := make([]string, 0, len(req.Placed))
+	for _, node := range req.Placed {
+		usedRacks = append(usedRacks, rackKey(node))
+	}
+	if nodes := filterPlacementNodes(inVolZone(req, candidates), func(node *PlacementNode) bool {
+		return !contains(usedRacks, rackKey(node))
+	}); len(nodes) > 0 {
+		return chooseByCapacity(nodes), nil
+	}
+	return nil, fmt.Errorf("no available rack without the replicas%v for placement policy[%v]", placedAddrs(req), p.Name())
+}
+
+// capacityWeightedPolicy chooses the nodes with more available space more likely, in the zone of the volume if
+// it is specified.
+type capacityWeightedPolicy struct{}
+
+func (p *capacityWeightedPolicy) Name() string { return proto.PlacementCapacityWeighted }
+
+func (p *capacityWeightedPolicy) Validate(req *PlacementRequest) error {
+	return rejectPlacementZone(p.Name(), req)
+}
+
+func (p *capacityWeightedPolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	if nodes := inVolZone(req, candidates); len(nodes) > 0 {
+		return chooseByCapacity(nodes), nil
+	}
+	return nil, fmt.Errorf("no available node in zone[%v] for placement policy[%v]", req.VolZone, p.Name())
+}
+
+// roundRobinPolicy chooses the nodes in turn by their addresses, in the zone of the volume if it is specified.
+type roundRobinPolicy struct {
+	next uint64
+}
+
+func (p *roundRobinPolicy) Name() string { return proto.PlacementRoundRobin }
+
+func (p *roundRobinPolicy) Validate(req *PlacementRequest) error {
+	return rejectPlacementZone(p.Name(), req)
+}
+
+func (p *roundRobinPolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	nodes := inVolZone(req, candidates)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no available node in zone[%v] for placement policy[%v]", req.VolZone, p.Name())
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Addr < nodes[j].Addr })
+	return nodes[(atomic.AddUint64(&p.next, 1)-1)%uint64(len(nodes))], nil
+}
@@ -0,0 +1,198 @@
+package master
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newPlacementTestCluster(zoneCount, nodeSetCount int) (c *Cluster) {
+	c = &Cluster{t: newTopology()}
+	var nsID uint64
+	for i := 0; i < zoneCount; i++ {
+		zoneName := fmt.Sprintf("zone%v", i+1)
+		zone := newZone(zoneName)
+		c.t.putZone(zone)
+		for j := 0; j < nodeSetCount; j++ {
+			nsID++
+			ns := newNodeSet(nsID, 6, zoneName)
+			zone.putNodeSet(ns)
+			for k := 0; k < 2; k++ {
+				dn := createDataNodeForTopo(fmt.Sprintf("127.0.%v.%v:17310", nsID, k+1), zoneName, ns)
+				c.t.putDataNode(dn)
+				c.dataNodes.Store(dn.Addr, dn)
+			}
+		}
+	}
+	return
+}
+
+func TestPlacementZoneSpread(t *testing.T) {
+	c := newPlacementTestCluster(3, 1)
+	vol := newVol(1, "placement", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	vol.placementPolicy = proto.PlacementZoneSpread
+	hosts, _, err := c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zones := make(map[string]bool)
+	for _, host := range hosts {
+		dn, _ := c.dataNode(host)
+		zones[dn.ZoneName] = true
+	}
+	if len(zones) != 3 {
+		t.Errorf("expect hosts%v in 3 zones, but in %v zones", hosts, len(zones))
+	}
+	// no zone is left for the fourth replica
+	if _, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), hosts, nil, 1); err == nil {
+		t.Errorf("expect no zone available for the fourth replica")
+	}
+}
+
+func TestPlacementZonePinnedAndRackDiverse(t *testing.T) {
+	c := newPlacementTestCluster(2, 3)
+	vol := newVol(1, "placement", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	if err := c.validatePlacementPolicy(vol, proto.PlacementZonePinned, ""); err == nil {
+		t.Errorf("expect the zone is required by placement policy[%v]", proto.PlacementZonePinned)
+	}
+	if err := c.validatePlacementPolicy(vol, proto.PlacementZoneSpread, ""); err == nil {
+		t.Errorf("expect not enough zones for placement policy[%v]", proto.PlacementZoneSpread)
+	}
+	vol.placementPolicy, vol.placementZone = proto.PlacementZonePinned, "zone2"
+	hosts, _, err := c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range hosts {
+		if dn, _ := c.dataNode(host); dn.ZoneName != "zone2" {
+			t.Errorf("expect host[%v] in zone2, but in %v", host, dn.ZoneName)
+		}
+	}
+	vol.placementPolicy, vol.placementZone, vol.zoneName = proto.PlacementRackDiverse, "", "zone1"
+	if hosts, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 3); err != nil {
+		t.Fatal(err)
+	}
+	nodeSets := make(map[uint64]bool)
+	for _, host := range hosts {
+		dn, _ := c.dataNode(host)
+		nodeSets[dn.NodeSetID] = true
+	}
+	if len(nodeSets) != 3 {
+		t.Errorf("expect hosts%v in 3 node sets, but in %v node sets", hosts, len(nodeSets))
+	}
+}
+
+func TestPlacementRoundRobinAndCapacityWeighted(t *testing.T) {
+	c := newPlacementTestCluster(1, 2)
+	vol := newVol(1, "placement", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	vol.placementPolicy = proto.PlacementRoundRobin
+	hosts, _, err := c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chosen := make(map[string]bool)
+	for _, host := range hosts {
+		chosen[host] = true
+	}
+	if len(chosen) != 4 {
+		t.Errorf("expect 4 different hosts, but got %v", hosts)
+	}
+	if _, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), hosts, nil, 1); err == nil {
+		t.Errorf("expect no node left for the fifth replica")
+	}
+
+	nodes := []*PlacementNode{{Addr: "full", Avail: 0, HealthScore: maxHealthScore}, {Addr: "free", Avail: 100, HealthScore: maxHealthScore}}
+	for i := 0; i < 10; i++ {
+		if node := chooseByCapacity(nodes); node.Addr != "free" {
+			t.Fatalf("expect the node without available space never chosen")
+		}
+	}
+	if err = c.validatePlacementPolicy(vol, proto.PlacementCapacityWeighted, "zone1"); err == nil {
+		t.Errorf("expect the placement zone rejected by placement policy[%v]", proto.PlacementCapacityWeighted)
+	}
+}
+
+type firstNodePolicy struct{}
+
+func (p *firstNodePolicy) Name() string { return "first-node" }
+
+func (p *firstNodePolicy) Validate(req *PlacementRequest) error { return nil }
+
+func (p *firstNodePolicy) Choose(req *PlacementRequest, candidates []*PlacementNode) (*PlacementNode, error) {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Addr < candidates[j].Addr })
+	return candidates[0], nil
+}
+
+func TestRegisterPlacementPolicy(t *testing.T) {
+	if err := RegisterPlacementPolicy(&zoneSpreadPolicy{}); err == nil {
+		t.Errorf("expect the registered policy rejected")
+	}
+	if err := RegisterPlacementPolicy(&firstNodePolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	c := newPlacementTestCluster(1, 1)
+	vol := newVol(1, "placement", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	if err := c.validatePlacementPolicy(vol, "first-node", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.validatePlacementPolicy(vol, "unknown", ""); err == nil {
+		t.Errorf("expect the unknown policy rejected")
+	}
+	vol.placementPolicy = "first-node"
+	hosts, _, err := c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 || hosts[0] != "127.0.1.1:17310" || hosts[1] != "127.0.1.2:17310" {
+		t.Errorf("expect the hosts chosen by the registered policy, but got %v", hosts)
+	}
+}
+
+func TestPlacementLabels(t *testing.T) {
+	c := newPlacementTestCluster(2, 2)
+	vol := newVol(1, "placement", "cfs", "", 0, 100, 3, 3, false, false, false, false, 0, "")
+	labels := map[string]string{"disk": "nvme"}
+	if err := c.validatePlacementLabels(vol, labels); err == nil {
+		t.Errorf("expect no data node matching the labels")
+	}
+	var labeled []string
+	c.dataNodes.Range(func(key, value interface{}) bool {
+		dn := value.(*DataNode)
+		if dn.ZoneName == "zone2" {
+			dn.labels = map[string]string{"disk": "nvme", "gen": "2023"}
+			labeled = append(labeled, dn.Addr)
+		}
+		return true
+	})
+	vol.mpReplicaNum = 0
+	if err := c.validatePlacementLabels(vol, labels); err != nil {
+		t.Fatal(err)
+	}
+	vol.placementLabels = labels
+	if !vol.usesPlacementPolicy() {
+		t.Fatalf("expect the volume with placement labels placed by a placement policy")
+	}
+	hosts, _, err := c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range hosts {
+		if !contains(labeled, host) {
+			t.Errorf("expect host[%v] in the labeled nodes%v", host, labeled)
+		}
+	}
+	vol.placementLabels = map[string]string{"disk": "hdd"}
+	if _, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), nil, nil, 1); err == nil {
+		t.Errorf("expect no node matching the labels")
+	}
+	if labels, err = proto.ParseLabels(" disk=nvme, gen=2023 ,"); err != nil || proto.FormatLabels(labels) != "disk=nvme,gen=2023" {
+		t.Errorf("unexpected labels[%v] err[%v]", labels, err)
+	}
+	for _, value := range []string{"disk", "disk=nvme,disk=ssd", "disk=nv me", "=nvme"} {
+		if _, err = proto.ParseLabels(value); err == nil {
+			t.Errorf("expect labels[%v] invalid", value)
+		}
+	}
+}
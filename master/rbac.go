@@ -0,0 +1,273 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/cryptoutil"
+)
+
+const (
+	rbacBearerPrefix = "Bearer "
+	rbacAnonymous    = "anonymous"
+)
+
+var (
+	// the APIs called by the meta nodes, the data nodes and the clients, which carry no credentials,
+	// so they are never denied
+	rbacExemptAPIs = map[string]bool{
+		proto.AdminGetIP:                true,
+		proto.AdminGetCluster:           true,
+		proto.AddDataNode:               true,
+		proto.AddMetaNode:               true,
+		proto.GetDataNode:               true,
+		proto.GetMetaNode:               true,
+		proto.GetDataNodeTaskResponse:   true,
+		proto.GetMetaNodeTaskResponse:   true,
+		proto.AdminGetDataPartition:     true,
+		proto.AdminGetVolQos:            true,
+		proto.AdminGetVolIPAcl:          true,
+		proto.AdminGetVerifyReadCrcVols: true,
+		proto.AdminGetCompressionVols:   true,
+		proto.AdminGetVolEncryptionKeys: true, // authenticated by the ticket with the caps to get the keys
+		proto.AdminGetVol:               true,
+		proto.ClientVol:                 true,
+		proto.ClientVolStat:             true,
+		proto.ClientDataPartitions:      true,
+		proto.ClientMetaPartitions:      true,
+		proto.ClientMetaPartition:       true,
+		proto.QuotaList:                 true,
+		proto.TokenGetURI:               true,
+		// the graphql APIs authenticate the users by themselves
+		proto.AdminClusterAPI: true,
+		proto.AdminUserAPI:    true,
+		proto.AdminVolumeAPI:  true,
+	}
+	// the read-only APIs which expose the secret keys of the users
+	rbacAdminOnlyAPIs = map[string]bool{
+		proto.UserGetAKInfo: true,
+		proto.UserGetInfo:   true,
+		proto.UserList:      true,
+	}
+	// the APIs which the volume owner calls on the volumes owned by itself, the volume is named by the
+	// name param, or the owner param names the caller on creating the volume
+	rbacVolumeOwnerAPIs = map[string]bool{
+		proto.AdminCreateVol:           true,
+		proto.AdminUpdateVol:           true,
+		proto.AdminDeleteVol:           true,
+		proto.AdminRestoreVol:          true,
+		proto.AdminVolExpand:           true,
+		proto.AdminVolShrink:           true,
+		proto.AdminRotateVolKey:        true,
+		proto.AdminCreateDataPartition: true,
+		proto.QuotaSet:                 true,
+		proto.QuotaDelete:              true,
+		proto.VolSnapshotCreate:        true,
+		proto.VolSnapshotDelete:        true,
+		proto.VolSnapshotRollback:      true,
+		proto.VolReplicationSet:        true,
+		proto.VolReplicationFailover:   true,
+	}
+)
+
+// rbacIdentity is the caller of the admin APIs, authenticated by a token or an authnode ticket.
+type rbacIdentity struct {
+	name string
+	role string
+}
+
+func (id *rbacIdentity) String() string {
+	return fmt.Sprintf("%v:%v", id.role, id.name)
+}
+
+type rbacIdentityKey struct{}
+
+// rbacIdentityOf returns the identity authenticated on the request, nil if the access control is disabled.
+func rbacIdentityOf(r *http.Request) *rbacIdentity {
+	id, _ := r.Context().Value(rbacIdentityKey{}).(*rbacIdentity)
+	return id
+}
+
+func isValidRole(role string) bool {
+	for _, r := range proto.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRBACTokens parses the tokens in the form of "role:name:token".
+func parseRBACTokens(entries []string) (tokens map[string]*rbacIdentity, err error) {
+	tokens = make(map[string]*rbacIdentity, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[1] == "" || parts[2] == "" || !isValidRole(parts[0]) {
+			return nil, fmt.Errorf("invalid token [%v], expect role:name:token with the role in %v",
+				strings.SplitN(entry, ":", 2)[0], proto.Roles)
+		}
+		if _, ok := tokens[parts[2]]; ok {
+			return nil, fmt.Errorf("duplicate token of [%v:%v]", parts[0], parts[1])
+		}
+		tokens[parts[2]] = &rbacIdentity{name: parts[1], role: parts[0]}
+	}
+	return
+}
+
+// authenticate returns the identity of the caller by the token in the header "Authorization", or the access
+// request with the authnode ticket in the header "Auth-Ticket". The caller without the credentials is
+// given the anonymous role, or denied if the anonymous role is not configured.
+func (m *Server) authenticate(r *http.Request) (id *rbacIdentity, err error) {
+	if auth := r.Header.Get(proto.HeadAuthorized); auth != "" {
+		if id = m.config.rbacTokens[strings.TrimPrefix(auth, rbacBearerPrefix)]; id == nil {
+			err = fmt.Errorf("%v: invalid token", proto.ErrNoPermission)
+		}
+		return
+	}
+	if message := r.Header.Get(proto.AuthTicket); message != "" {
+		return parseRBACTicket(message, m.cluster.MasterSecretKey)
+	}
+	if m.config.rbacAnonymousRole == "" {
+		return nil, fmt.Errorf("%v: no token or ticket", proto.ErrNoPermission)
+	}
+	return &rbacIdentity{name: rbacAnonymous, role: m.config.rbacAnonymousRole}, nil
+}
+
+// decodeAuthTicket decodes the access request in the header "Auth-Ticket", and validates the ticket issued by the
+// authnode to the master service in it. The timestamp of the verifier is returned to generate the reply.
+func decodeAuthTicket(message string, key []byte) (req proto.APIAccessReq, ticket cryptoutil.Ticket, ts int64, err error) {
+	var plaintext []byte
+	if message == "" {
+		err = fmt.Errorf("%v: no ticket", proto.ErrInvalidTicket)
+		return
+	}
+	if plaintext, err = cryptoutil.Base64Decode(message); err != nil {
+		err = fmt.Errorf("%v: %v", proto.ErrInvalidTicket, err)
+		return
+	}
+	if err = json.Unmarshal(plaintext, &req); err != nil {
+		err = fmt.Errorf("%v: %v", proto.ErrInvalidTicket, err)
+		return
+	}
+	if err = proto.VerifyAPIAccessReqIDs(&req); err != nil {
+		err = fmt.Errorf("%v: %v", proto.ErrInvalidTicket, err)
+		return
+	}
+	if ticket, err = proto.ExtractTicket(req.Ticket, key); err != nil {
+		err = fmt.Errorf("%v: %v", proto.ErrInvalidTicket, err)
+		return
+	}
+	if time.Now().Unix() >= ticket.Exp {
+		err = proto.ErrExpiredTicket
+		return
+	}
+	if ts, err = proto.ParseVerifier(req.Verifier, ticket.SessionKey.Key); err != nil {
+		err = fmt.Errorf("%v: %v", proto.ErrInvalidTicket, err)
+	}
+	return
+}
+
+// parseRBACTicket validates the ticket issued by the authnode to the master service, and returns the client
+// of the ticket with the most privileged role granted by the caps "master:role:<role>".
+func parseRBACTicket(message string, key []byte) (id *rbacIdentity, err error) {
+	req, ticket, _, err := decodeAuthTicket(message, key)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range proto.Roles {
+		if proto.CheckMasterRoleCaps(&ticket, role) == nil {
+			return &rbacIdentity{name: req.ClientID, role: role}, nil
+		}
+	}
+	return nil, fmt.Errorf("%v: no role granted to [%v]", proto.ErrNoPermission, req.ClientID)
+}
+
+// checkRolePermission checks whether the role calls the API. ownerChecked is true if the API is allowed only
+// on the volumes owned by the caller, which is checked by the leader with the metadata of the volumes.
+func checkRolePermission(role, path string) (ownerChecked bool, err error) {
+	switch role {
+	case proto.RoleAdmin:
+		return false, nil
+	case proto.RoleVolumeOwner:
+		if rbacVolumeOwnerAPIs[path] {
+			return true, nil
+		}
+		fallthrough
+	case proto.RoleMonitor:
+		if !mutatingAPIs[path] && !rbacAdminOnlyAPIs[path] {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("%v: role [%v] cannot call [%v]", proto.ErrNoPermission, role, path)
+}
+
+// authorize authenticates the caller and checks its role on the API, the request carries the identity
+// of the caller on return.
+func (m *Server) authorize(r *http.Request) (req *http.Request, ownerChecked bool, err error) {
+	req = r
+	if !m.config.rbacEnabled || rbacExemptAPIs[r.URL.Path] {
+		return
+	}
+	var id *rbacIdentity
+	if id, err = m.authenticate(r); err != nil {
+		return
+	}
+	if ownerChecked, err = checkRolePermission(id.role, r.URL.Path); err != nil {
+		return
+	}
+	req = r.WithContext(context.WithValue(r.Context(), rbacIdentityKey{}, id))
+	return
+}
+
+// checkVolumeOwner checks whether the volume named by the request is owned by the caller.
+func (m *Server) checkVolumeOwner(r *http.Request) (err error) {
+	id := rbacIdentityOf(r)
+	query := r.URL.Query()
+	if r.URL.Path == proto.AdminCreateVol {
+		if query.Get(volOwnerKey) != id.name {
+			return fmt.Errorf("%v: [%v] cannot create volume for owner [%v]", proto.ErrNoPermission, id, query.Get(volOwnerKey))
+		}
+		return
+	}
+	var vol *Vol
+	if vol, err = m.cluster.getVol(query.Get(nameKey)); err != nil {
+		return
+	}
+	if vol.Owner != id.name {
+		return fmt.Errorf("%v: volume [%v] is not owned by [%v]", proto.ErrNoPermission, vol.Name, id)
+	}
+	return
+}
+
+// newRBACErrReply returns the reply to the denied request.
+func newRBACErrReply(err error) *proto.HTTPReply {
+	code := int32(proto.ErrCodeNoPermission)
+	switch {
+	case err == proto.ErrExpiredTicket:
+		code = proto.ErrCodeExpiredTicket
+	case strings.HasPrefix(err.Error(), proto.ErrInvalidTicket.Error()):
+		code = proto.ErrCodeInvalidTicket
+	case err == proto.ErrVolNotExists:
+		code = proto.ErrCodeVolNotExists
+	}
+	return &proto.HTTPReply{Code: code, Msg: err.Error()}
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestParseRBACTokens(t *testing.T) {
+	tokens, err := parseRBACTokens([]string{"admin:ops:t1", "monitor:prometheus:t2", "volumeOwner:alice:t3:x"})
+	if err != nil {
+		t.Fatalf("parse tokens failed: %v", err)
+	}
+	if id := tokens["t3:x"]; id == nil || id.role != proto.RoleVolumeOwner || id.name != "alice" {
+		t.Errorf("unexpected identity of t3:x: %v", id)
+	}
+	for _, entries := range [][]string{{"root:ops:t1"}, {"admin::t1"}, {"admin:ops"}, {"admin:a:t1", "monitor:b:t1"}} {
+		if _, err = parseRBACTokens(entries); err == nil {
+			t.Errorf("tokens %v should be invalid", entries)
+		}
+	}
+}
+
+func TestCheckRolePermission(t *testing.T) {
+	cases := []struct {
+		role         string
+		path         string
+		allowed      bool
+		ownerChecked bool
+	}{
+		{proto.RoleAdmin, proto.DecommissionDataNode, true, false},
+		{proto.RoleAdmin, proto.UserGetAKInfo, true, false},
+		{proto.RoleMonitor, proto.AdminClusterStat, true, false},
+		{proto.RoleMonitor, proto.AdminDeleteVol, false, false},
+		{proto.RoleMonitor, proto.UserGetAKInfo, false, false},
+		{proto.RoleVolumeOwner, proto.AdminDeleteVol, true, true},
+		{proto.RoleVolumeOwner, proto.AdminListVols, true, false},
+		{proto.RoleVolumeOwner, proto.DecommissionDataNode, false, false},
+	}
+	for _, c := range cases {
+		ownerChecked, err := checkRolePermission(c.role, c.path)
+		if (err == nil) != c.allowed || ownerChecked != c.ownerChecked {
+			t.Errorf("role %v path %v: ownerChecked %v err %v", c.role, c.path, ownerChecked, err)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	cfg := newClusterConfig()
+	cfg.rbacEnabled = true
+	cfg.rbacTokens, _ = parseRBACTokens([]string{"monitor:prometheus:t1"})
+	m := &Server{config: cfg}
+
+	r := httptest.NewRequest(http.MethodGet, proto.AdminClusterStat, nil)
+	if _, _, err := m.authorize(r); err == nil {
+		t.Errorf("the request without credentials should be denied")
+	}
+	r.Header.Set(proto.HeadAuthorized, "Bearer t1")
+	req, _, err := m.authorize(r)
+	if err != nil {
+		t.Fatalf("authorize failed: %v", err)
+	}
+	if id := rbacIdentityOf(req); id == nil || id.name != "prometheus" {
+		t.Errorf("unexpected identity %v", id)
+	}
+	r = httptest.NewRequest(http.MethodGet, proto.AdminDeleteVol, nil)
+	r.Header.Set(proto.HeadAuthorized, "Bearer t1")
+	if _, _, err = m.authorize(r); err == nil {
+		t.Errorf("the monitor should not delete the volume")
+	}
+	r = httptest.NewRequest(http.MethodGet, proto.ClientVol, nil)
+	if _, _, err = m.authorize(r); err != nil {
+		t.Errorf("the clients should not be denied: %v", err)
+	}
+	cfg.rbacAnonymousRole = proto.RoleMonitor
+	r = httptest.NewRequest(http.MethodGet, proto.AdminClusterStat, nil)
+	if _, _, err = m.authorize(r); err != nil {
+		t.Errorf("the anonymous monitor should be allowed: %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	rebalanceCheckInterval    = time.Minute
+	defaultRebalanceHighRatio = 0.8
+	defaultRebalanceLowRatio  = 0.6
+)
+
+// rebalancer records the configuration of a rebalancer and the partitions migrated by it which are still recovering.
+type rebalancer struct {
+	sync.Mutex
+	partitionType string
+	config        proto.RebalanceConfig
+	migrations    map[uint64]*proto.RebalanceMigration
+}
+
+func newRebalancer(partitionType string) *rebalancer {
+	return &rebalancer{
+		partitionType: partitionType,
+		config:        proto.RebalanceConfig{HighRatio: defaultRebalanceHighRatio, LowRatio: defaultRebalanceLowRatio},
+		migrations:    make(map[uint64]*proto.RebalanceMigration),
+	}
+}
+
+func (rb *rebalancer) getConfig() proto.RebalanceConfig {
+	rb.Lock()
+	defer rb.Unlock()
+	return rb.config
+}
+
+func (rb *rebalancer) setConfig(config proto.RebalanceConfig) {
+	rb.Lock()
+	defer rb.Unlock()
+	rb.config = config
+}
+
+// isRunnable returns if the rebalancer is enabled and not paused, and can migrate more partitions.
+func (rb *rebalancer) isRunnable() bool {
+	rb.Lock()
+	defer rb.Unlock()
+	return !rb.config.Paused && uint64(len(rb.migrations)) < rb.config.Limit
+}
+
+func (rb *rebalancer) isMigrating(partitionID uint64) bool {
+	rb.Lock()
+	defer rb.Unlock()
+	_, ok := rb.migrations[partitionID]
+	return ok
+}
+
+func (rb *rebalancer) addMigration(migration *proto.RebalanceMigration) {
+	rb.Lock()
+	defer rb.Unlock()
+	rb.migrations[migration.PartitionID] = migration
+}
+
+// releaseMigrations forgets the partitions which are no longer recovering.
+func (rb *rebalancer) releaseMigrations(isRecovering func(partitionID uint64) bool) {
+	rb.Lock()
+	defer rb.Unlock()
+	for id := range rb.migrations {
+		if !isRecovering(id) {
+			delete(rb.migrations, id)
+		}
+	}
+}
+
+func (rb *rebalancer) view(nodes []*proto.RebalanceNodeView) (view *proto.RebalanceView) {
+	rb.Lock()
+	defer rb.Unlock()
+	view = &proto.RebalanceView{
+		RebalanceConfig: rb.config,
+		Nodes:           nodes,
+		Migrations:      make([]*proto.RebalanceMigration, 0, len(rb.migrations)),
+	}
+	for _, migration := range rb.migrations {
+		view.Migrations = append(view.Migrations, migration)
+	}
+	sort.Slice(view.Nodes, func(i, j int) bool { return view.Nodes[i].Addr < view.Nodes[j].Addr })
+	sort.Slice(view.Migrations, func(i, j int) bool { return view.Migrations[i].PartitionID < view.Migrations[j].PartitionID })
+	return
+}
+
+// rebalance migrates the partitions from the nodes whose usage ratio is above the high ratio to the nodes in the same
+// zone, the largest partitions first. The usage of the partitions started to migrate in a round is limited by the
+// bandwidth, but at least one partition is started in a round.
+func (rb *rebalancer) rebalance(loads map[string]*nodeLoad, migrate func(partition *partitionLoad, src, target string) error) {
+	config := rb.getConfig()
+	budget := config.Bandwidth * uint64(rebalanceCheckInterval/time.Second)
+	var started uint64
+	for _, src := range overloadedNodes(loads, config.HighRatio) {
+		sort.Slice(src.partitions, func(i, j int) bool { return src.partitions[i].used > src.partitions[j].used })
+		for _, partition := range append([]*partitionLoad(nil), src.partitions...) {
+			if !rb.isRunnable() || src.ratio() <= config.HighRatio {
+				break
+			}
+			if partition.used == 0 || rb.isMigrating(partition.id) {
+				continue
+			}
+			if budget > 0 && started > 0 && started+partition.used > budget {
+				continue
+			}
+			target := chooseRebalanceTarget(loads, src, partition, config.LowRatio)
+			if target == nil {
+				continue
+			}
+			if err := migrate(partition, src.addr, target.addr); err != nil {
+				log.LogErrorf("action[rebalance] vol[%v] %v partition[%v] from[%v] to[%v] err[%v]",
+					partition.volName, rb.partitionType, partition.id, src.addr, target.addr, err)
+				continue
+			}
+			rb.addMigration(&proto.RebalanceMigration{
+				PartitionID: partition.id,
+				VolName:     partition.volName,
+				Source:      src.addr,
+				Target:      target.addr,
+				Used:        partition.used,
+				StartTime:   time.Now().Unix(),
+			})
+			started += partition.used
+			src.move(partition, target)
+		}
+	}
+}
+
+func validateRebalanceConfig(config proto.RebalanceConfig) (err error) {
+	if config.LowRatio <= 0 || config.HighRatio > 1 || config.LowRatio >= config.HighRatio {
+		err = fmt.Errorf("the ratios must meet 0 < %v < %v <= 1", config.LowRatio, config.HighRatio)
+	}
+	return
+}
+
+// nodeLoad is the load of a node seen by a rebalancer, the usage is updated by the migrations chosen in a round.
+type nodeLoad struct {
+	addr       string
+	zoneName   string
+	rackName   string
+	total      uint64
+	used       uint64
+	writable   bool
+	labels     map[string]string
+	partitions []*partitionLoad
+}
+
+// partitionLoad is the estimated usage of a partition on each of its nodes.
+type partitionLoad struct {
+	id          uint64
+	volName     string
+	used        uint64
+	hosts       []string
+	rackDiverse bool              // the replicas are expected to be in different racks
+	labels      map[string]string // the placement labels of the volume, which the target must have
+}
+
+func (load *nodeLoad) ratio() float64 {
+	if load.total == 0 {
+		return 0
+	}
+	return float64(load.used) / float64(load.total)
+}
+
+func (load *nodeLoad) partitionUsed() (used uint64) {
+	for _, partition := range load.partitions {
+		used += partition.used
+	}
+	return
+}
+
+func (load *nodeLoad) view() *proto.RebalanceNodeView {
+	return &proto.RebalanceNodeView{
+		Addr:           load.addr,
+		ZoneName:       load.zoneName,
+		Total:          load.total,
+		Used:           load.used,
+		Ratio:          load.ratio(),
+		PartitionCount: len(load.partitions),
+		PartitionUsed:  load.partitionUsed(),
+	}
+}
+
+// move records the migration of the partition from the node to the target.
+func (load *nodeLoad) move(partition *partitionLoad, target *nodeLoad) {
+	for i, p := range load.partitions {
+		if p == partition {
+			load.partitions = append(load.partitions[:i], load.partitions[i+1:]...)
+			break
+		}
+	}
+	if load.used > partition.used {
+		load.used -= partition.used
+	} else {
+		load.used = 0
+	}
+	target.used += partition.used
+	target.partitions = append(target.partitions, partition)
+	partition.hosts = append(excludeHost(partition.hosts, load.addr), target.addr)
+}
+
+// overloadedNodes returns the nodes whose ratio is above the high ratio, the most loaded first.
+func overloadedNodes(loads map[string]*nodeLoad, highRatio float64) (nodes []*nodeLoad) {
+	for _, load := range loads {
+		if load.ratio() > highRatio {
+			nodes = append(nodes, load)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ratio() > nodes[j].ratio() })
+	return
+}
+
+// chooseRebalanceTarget chooses the least loaded writable node in the zone of the source node, which does not host
+// the partition yet and whose ratio stays below the low ratio after the migration. The racks of the other replicas
+// are excluded if the replicas are expected to be in different racks, and the target must have the placement labels
+// of the volume.
+func chooseRebalanceTarget(loads map[string]*nodeLoad, src *nodeLoad, partition *partitionLoad, lowRatio float64) (target *nodeLoad) {
+	excludeRacks := make(map[string]bool)
+	if partition.rackDiverse {
+		for _, host := range partition.hosts {
+			if load, ok := loads[host]; ok && host != src.addr {
+				excludeRacks[load.rackName] = true
+			}
+		}
+	}
+	for _, load := range loads {
+		if load == src || !load.writable || load.zoneName != src.zoneName || load.total == 0 {
+			continue
+		}
+		if contains(partition.hosts, load.addr) || excludeRacks[load.rackName] || !proto.MatchLabels(load.labels, partition.labels) {
+			continue
+		}
+		if float64(load.used+partition.used)/float64(load.total) >= lowRatio {
+			continue
+		}
+		if target == nil || load.ratio() < target.ratio() {
+			target = load
+		}
+	}
+	return
+}
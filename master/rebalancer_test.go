@@ -0,0 +1,83 @@
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestChooseRebalanceTarget(t *testing.T) {
+	partition := &partitionLoad{id: 1, used: 10, hosts: []string{"a", "b", "c"}}
+	loads := map[string]*nodeLoad{
+		"a": {addr: "a", zoneName: "z1", rackName: "r1", total: 100, used: 90, writable: true, partitions: []*partitionLoad{partition}},
+		"b": {addr: "b", zoneName: "z1", rackName: "r2", total: 100, used: 10, writable: true, partitions: []*partitionLoad{partition}},
+		"c": {addr: "c", zoneName: "z1", rackName: "r3", total: 100, used: 10, writable: true, partitions: []*partitionLoad{partition}},
+		"d": {addr: "d", zoneName: "z2", rackName: "r4", total: 100, used: 0, writable: true},
+		"e": {addr: "e", zoneName: "z1", rackName: "r2", total: 100, used: 20, writable: true},
+		"f": {addr: "f", zoneName: "z1", rackName: "r5", total: 100, used: 45, writable: true},
+		"g": {addr: "g", zoneName: "z1", rackName: "r6", total: 100, used: 0, writable: false},
+	}
+	src := loads["a"]
+	if target := chooseRebalanceTarget(loads, src, partition, 0.6); target == nil || target.addr != "e" {
+		t.Fatalf("expect target e, but got %v", target)
+	}
+	partition.rackDiverse = true
+	if target := chooseRebalanceTarget(loads, src, partition, 0.6); target == nil || target.addr != "f" {
+		t.Fatalf("expect target f in a rack without replicas, but got %v", target)
+	}
+	partition.labels = map[string]string{"disk": "nvme"}
+	if target := chooseRebalanceTarget(loads, src, partition, 0.6); target != nil {
+		t.Fatalf("expect no target with the labels, but got %v", target.addr)
+	}
+	loads["f"].labels = map[string]string{"disk": "nvme"}
+	if target := chooseRebalanceTarget(loads, src, partition, 0.6); target == nil || target.addr != "f" {
+		t.Fatalf("expect target f with the labels, but got %v", target)
+	}
+	partition.labels = nil
+	if target := chooseRebalanceTarget(loads, src, partition, 0.5); target != nil {
+		t.Fatalf("expect no target below the low ratio, but got %v", target.addr)
+	}
+	src.move(partition, loads["e"])
+	if src.used != 80 || loads["e"].used != 30 || len(src.partitions) != 0 || !contains(partition.hosts, "e") ||
+		contains(partition.hosts, "a") {
+		t.Fatalf("unexpected loads after move: src[%v] target[%v] hosts%v", src.used, loads["e"].used, partition.hosts)
+	}
+	if nodes := overloadedNodes(loads, 0.7); len(nodes) != 1 || nodes[0].addr != "a" {
+		t.Fatalf("expect node a overloaded, but got %v", nodes)
+	}
+}
+
+func TestValidateRebalanceConfig(t *testing.T) {
+	if err := validateRebalanceConfig(proto.RebalanceConfig{HighRatio: 0.8, LowRatio: 0.6}); err != nil {
+		t.Fatal(err)
+	}
+	for _, config := range []proto.RebalanceConfig{{HighRatio: 0.6, LowRatio: 0.8}, {HighRatio: 1.2, LowRatio: 0.6}, {HighRatio: 0.8}} {
+		if err := validateRebalanceConfig(config); err == nil {
+			t.Fatalf("expect invalid config %+v", config)
+		}
+	}
+}
+
+func TestRebalanceBandwidth(t *testing.T) {
+	var partitions []*partitionLoad
+	for id := uint64(1); id <= 3; id++ {
+		partitions = append(partitions, &partitionLoad{id: id, used: 60 * id, hosts: []string{"a"}})
+	}
+	loads := map[string]*nodeLoad{
+		"a": {addr: "a", zoneName: "z1", rackName: "r1", total: 1000, used: 900, writable: true, partitions: partitions},
+		"b": {addr: "b", zoneName: "z1", rackName: "r2", total: 1000, used: 0, writable: true},
+	}
+	rb := newRebalancer("data")
+	rb.setConfig(proto.RebalanceConfig{HighRatio: 0.5, LowRatio: 0.9, Limit: 3, Bandwidth: 5})
+	var migrated []uint64
+	rb.rebalance(loads, func(partition *partitionLoad, src, target string) error {
+		migrated = append(migrated, partition.id)
+		return nil
+	})
+	if len(migrated) != 2 || migrated[0] != 3 || migrated[1] != 2 {
+		t.Fatalf("expect partitions [3 2] migrated within the bandwidth, but got %v", migrated)
+	}
+	if !rb.isMigrating(3) || rb.isMigrating(1) {
+		t.Fatalf("unexpected migrations %v", rb.view(nil).Migrations)
+	}
+}
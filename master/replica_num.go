@@ -0,0 +1,142 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	replicaNumChangeCheckInterval = time.Minute
+)
+
+func (c *Cluster) scheduleToChangeReplicaNum() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.changeReplicaNum()
+			}
+			time.Sleep(replicaNumChangeCheckInterval)
+		}
+	}()
+}
+
+// changeReplicaNum adds or removes the replicas of the data partitions whose replica numbers differ from the
+// ones of their volumes, one replica of a partition at a time. The partitions adding the replicas are recovering
+// until the new replicas catch up, and no more than the replica number change limit of the cluster are recovering
+// at the same time, including the ones recovering from the automatic replica supplement. No more replicas than the
+// limit are removed every time either.
+func (c *Cluster) changeReplicaNum() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("changeReplicaNum occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"changeReplicaNum occurred panic")
+		}
+	}()
+	c.releaseRecoveredSupplements()
+	limit := int(c.cfg.replicaNumChangeLimit)
+	removed := 0
+	for _, vol := range c.allVols() {
+		if vol.Status == markDelete {
+			continue
+		}
+		vol.RLock()
+		replicaNum := vol.dpReplicaNum
+		vol.RUnlock()
+		lowering := false
+		for _, dp := range vol.cloneDataPartitionMap() {
+			dp.RLock()
+			current, recovering := dp.ReplicaNum, dp.isRecover
+			dp.RUnlock()
+			switch {
+			case current > replicaNum:
+				if removed >= limit || recovering {
+					lowering = true
+					continue
+				}
+				if err := c.removeReplicaToChangeReplicaNum(dp, replicaNum); err != nil {
+					log.LogErrorf("action[changeReplicaNum] vol[%v] data partition[%v] err[%v]", vol.Name, dp.PartitionID, err)
+					lowering = true
+					continue
+				}
+				removed++
+			case current < replicaNum:
+				if recovering || c.replicaSupplements.count() >= limit {
+					continue
+				}
+				if err := c.addReplicaToChangeReplicaNum(dp, vol); err != nil {
+					log.LogErrorf("action[changeReplicaNum] vol[%v] data partition[%v] err[%v]", vol.Name, dp.PartitionID, err)
+				}
+			}
+		}
+		vol.NeedToLowerReplica = lowering
+	}
+}
+
+// removeReplicaToChangeReplicaNum removes the last replica of the data partition, the replica number of the
+// partition is lowered directly if the partition lacks the replicas.
+func (c *Cluster) removeReplicaToChangeReplicaNum(dp *DataPartition, replicaNum uint8) (err error) {
+	if host := dp.getToBeDecommissionHost(int(replicaNum)); host != "" {
+		if err = dp.removeOneReplicaByHost(c, host); err != nil {
+			return
+		}
+		Warn(c.Name, fmt.Sprintf("action[changeReplicaNum] clusterID[%v] vol[%v] data partition[%v] "+
+			"remove replica[%v] success", c.Name, dp.VolName, dp.PartitionID, host))
+		return
+	}
+	dp.Lock()
+	defer dp.Unlock()
+	oldReplicaNum := dp.ReplicaNum
+	dp.ReplicaNum = replicaNum
+	if err = c.syncUpdateDataPartition(dp); err != nil {
+		dp.ReplicaNum = oldReplicaNum
+	}
+	return
+}
+
+// addReplicaToChangeReplicaNum adds a replica to the data partition, the replica number of the partition is raised
+// after the replica is added.
+func (c *Cluster) addReplicaToChangeReplicaNum(dp *DataPartition, vol *Vol) (err error) {
+	var newAddr string
+	if newAddr, err = c.chooseDataPartitionSupplementTarget(dp, vol); err != nil {
+		return
+	}
+	if err = c.addDataReplica(dp, newAddr); err != nil {
+		return
+	}
+	dp.Lock()
+	if hosts := uint8(len(dp.Hosts)); hosts > dp.ReplicaNum {
+		dp.ReplicaNum = hosts
+	}
+	dp.Status = proto.ReadOnly
+	dp.isRecover = true
+	err = c.syncUpdateDataPartition(dp)
+	dp.Unlock()
+	if err != nil {
+		return
+	}
+	c.putBadDataPartitionIDs(nil, newAddr, dp.PartitionID)
+	c.replicaSupplements.Lock()
+	c.replicaSupplements.dataPartitions[dp.PartitionID] = true
+	c.replicaSupplements.Unlock()
+	Warn(c.Name, fmt.Sprintf("action[changeReplicaNum] clusterID[%v] vol[%v] data partition[%v] "+
+		"add replica[%v] success", c.Name, dp.VolName, dp.PartitionID, newAddr))
+	return
+}
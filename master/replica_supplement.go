@@ -0,0 +1,295 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	replicaSupplementCheckInterval = time.Minute
+)
+
+// replicaSupplementer records the partitions whose lacked replicas are added by the scheduler and are still
+// recovering. The number of the recovering partitions is limited by the auto supplement limit of the cluster.
+type replicaSupplementer struct {
+	sync.Mutex
+	dataPartitions map[uint64]bool
+	metaPartitions map[uint64]bool
+}
+
+func newReplicaSupplementer() *replicaSupplementer {
+	return &replicaSupplementer{
+		dataPartitions: make(map[uint64]bool),
+		metaPartitions: make(map[uint64]bool),
+	}
+}
+
+func (rs *replicaSupplementer) count() int {
+	rs.Lock()
+	defer rs.Unlock()
+	return len(rs.dataPartitions) + len(rs.metaPartitions)
+}
+
+func (c *Cluster) scheduleToSupplementReplicas() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.supplementLackReplicas()
+			}
+			time.Sleep(replicaSupplementCheckInterval)
+		}
+	}()
+}
+
+// supplementLackReplicas adds the lacked replicas of the meta partitions and the data partitions, no more than
+// the auto supplement limit of the cluster are recovering at the same time. It is disabled if the limit is 0.
+func (c *Cluster) supplementLackReplicas() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("supplementLackReplicas occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"supplementLackReplicas occurred panic")
+		}
+	}()
+	c.releaseRecoveredSupplements()
+	limit := int(atomic.LoadUint64(&c.cfg.AutoReplicaSupplementLimit))
+	if limit <= 0 {
+		return
+	}
+	lackMps, _ := c.checkLackReplicaMetaPartitions()
+	for _, mp := range lackMps {
+		if c.replicaSupplements.count() >= limit {
+			return
+		}
+		c.supplementMetaReplica(mp)
+	}
+	lackDps, _ := c.checkLackReplicaDataPartitions()
+	for _, dp := range lackDps {
+		if c.replicaSupplements.count() >= limit {
+			return
+		}
+		c.supplementDataReplica(dp)
+	}
+}
+
+// releaseRecoveredSupplements forgets the partitions which have finished recovering or have been deleted.
+func (c *Cluster) releaseRecoveredSupplements() {
+	rs := c.replicaSupplements
+	rs.Lock()
+	defer rs.Unlock()
+	for id := range rs.dataPartitions {
+		if dp, err := c.getDataPartitionByID(id); err != nil || !dp.isRecover {
+			delete(rs.dataPartitions, id)
+		}
+	}
+	for id := range rs.metaPartitions {
+		if mp, err := c.getMetaPartitionByID(id); err != nil || !mp.IsRecover {
+			delete(rs.metaPartitions, id)
+		}
+	}
+}
+
+func (c *Cluster) supplementDataReplica(dp *DataPartition) {
+	var (
+		vol     *Vol
+		newAddr string
+		err     error
+	)
+	dp.RLock()
+	lacked := int(dp.ReplicaNum) > len(dp.Hosts)
+	recovering := dp.isRecover
+	dp.RUnlock()
+	if !lacked || recovering {
+		return
+	}
+	if vol, err = c.getVol(dp.VolName); err != nil {
+		goto errHandler
+	}
+	if newAddr, err = c.chooseDataPartitionSupplementTarget(dp, vol); err != nil {
+		goto errHandler
+	}
+	if err = c.addDataReplica(dp, newAddr); err != nil {
+		goto errHandler
+	}
+	dp.Status = proto.ReadOnly
+	dp.isRecover = true
+	c.putBadDataPartitionIDs(nil, newAddr, dp.PartitionID)
+	dp.RLock()
+	c.syncUpdateDataPartition(dp)
+	dp.RUnlock()
+	c.replicaSupplements.Lock()
+	c.replicaSupplements.dataPartitions[dp.PartitionID] = true
+	c.replicaSupplements.Unlock()
+	Warn(c.Name, fmt.Sprintf("action[supplementDataReplica] clusterID[%v] vol[%v] data partition[%v] "+
+		"add replica[%v] success", c.Name, dp.VolName, dp.PartitionID, newAddr))
+	return
+
+errHandler:
+	log.LogErrorf("action[supplementDataReplica] clusterID[%v] vol[%v] data partition[%v] err[%v]",
+		c.Name, dp.VolName, dp.PartitionID, err)
+}
+
+func (c *Cluster) supplementMetaReplica(mp *MetaPartition) {
+	var (
+		vol     *Vol
+		newAddr string
+		err     error
+	)
+	mp.RLock()
+	lacked := int(mp.ReplicaNum) > len(mp.Hosts)
+	recovering := mp.IsRecover
+	mp.RUnlock()
+	if !lacked || recovering {
+		return
+	}
+	if vol, err = c.getVol(mp.volName); err != nil {
+		goto errHandler
+	}
+	if newAddr, err = c.chooseMetaPartitionSupplementTarget(mp, vol); err != nil {
+		goto errHandler
+	}
+	if err = c.addMetaReplica(mp, newAddr); err != nil {
+		goto errHandler
+	}
+	mp.IsRecover = true
+	c.putBadMetaPartitions(newAddr, mp.PartitionID)
+	mp.RLock()
+	c.syncUpdateMetaPartition(mp)
+	mp.RUnlock()
+	c.replicaSupplements.Lock()
+	c.replicaSupplements.metaPartitions[mp.PartitionID] = true
+	c.replicaSupplements.Unlock()
+	Warn(c.Name, fmt.Sprintf("action[supplementMetaReplica] clusterID[%v] vol[%v] meta partition[%v] "+
+		"add replica[%v] success", c.Name, mp.volName, mp.PartitionID, newAddr))
+	return
+
+errHandler:
+	log.LogErrorf("action[supplementMetaReplica] clusterID[%v] vol[%v] meta partition[%v] err[%v]",
+		c.Name, mp.volName, mp.PartitionID, err)
+}
+
+// chooseDataPartitionSupplementTarget chooses the data node for the lacked replica of the data partition. The placement
+// policy of the volume is followed if it is set. For the cross zone volume, a zone without the replicas of the partition is preferred. Otherwise the node set of a remaining
+// replica is preferred, then its zone, then the other zones. The data nodes without enough space are not chosen.
+func (c *Cluster) chooseDataPartitionSupplementTarget(dp *DataPartition, vol *Vol) (newAddr string, err error) {
+	var (
+		targetHosts     []string
+		dataNode        *DataNode
+		zone            *Zone
+		ns              *nodeSet
+		excludeNodeSets []uint64
+	)
+	dp.RLock()
+	hosts := append([]string{}, dp.Hosts...)
+	dp.RUnlock()
+	if vol.usesPlacementPolicy() {
+		if targetHosts, _, err = c.choosePlacementHosts(vol, c.dataPlacementNodes(), hosts, nil, 1); err != nil {
+			return
+		}
+		return targetHosts[0], nil
+	}
+	for _, host := range hosts {
+		if dataNode, err = c.dataNode(host); err == nil {
+			break
+		}
+	}
+	if dataNode == nil || dataNode.ZoneName == "" {
+		if targetHosts, _, err = c.chooseTargetDataNodes("", nil, hosts, 1, 1, vol.zoneName); err != nil {
+			return
+		}
+		return targetHosts[0], nil
+	}
+	if vol.crossZone {
+		if targetHosts, _, err = c.chooseTargetDataNodes(dataNode.ZoneName, nil, hosts, 1, 1, ""); err == nil {
+			return targetHosts[0], nil
+		}
+	}
+	if zone, err = c.t.getZone(dataNode.ZoneName); err != nil {
+		return
+	}
+	if ns, err = zone.getNodeSet(dataNode.NodeSetID); err != nil {
+		return
+	}
+	if targetHosts, _, err = ns.getAvailDataNodeHosts(hosts, 1); err != nil {
+		excludeNodeSets = append(excludeNodeSets, ns.ID)
+		if targetHosts, _, err = zone.getAvailDataNodeHosts(excludeNodeSets, hosts, 1); err != nil {
+			if targetHosts, _, err = c.chooseTargetDataNodes(zone.name, excludeNodeSets, hosts, 1, 1, ""); err != nil {
+				return
+			}
+		}
+	}
+	newAddr = targetHosts[0]
+	return
+}
+
+// chooseMetaPartitionSupplementTarget chooses the meta node for the lacked replica of the meta partition, in the
+// same way as chooseDataPartitionSupplementTarget.
+func (c *Cluster) chooseMetaPartitionSupplementTarget(mp *MetaPartition, vol *Vol) (newAddr string, err error) {
+	var (
+		targetHosts     []string
+		metaNode        *MetaNode
+		zone            *Zone
+		ns              *nodeSet
+		excludeNodeSets []uint64
+	)
+	mp.RLock()
+	hosts := append([]string{}, mp.Hosts...)
+	mp.RUnlock()
+	if vol.usesPlacementPolicy() {
+		if targetHosts, _, err = c.choosePlacementHosts(vol, c.metaPlacementNodes(), hosts, nil, 1); err != nil {
+			return
+		}
+		return targetHosts[0], nil
+	}
+	for _, host := range hosts {
+		if metaNode, err = c.metaNode(host); err == nil {
+			break
+		}
+	}
+	if metaNode == nil || metaNode.ZoneName == "" {
+		if targetHosts, _, err = c.chooseTargetMetaHosts("", nil, hosts, 1, false, vol.zoneName); err != nil {
+			return
+		}
+		return targetHosts[0], nil
+	}
+	if vol.crossZone {
+		if targetHosts, _, err = c.chooseTargetMetaHosts(metaNode.ZoneName, nil, hosts, 1, false, ""); err == nil {
+			return targetHosts[0], nil
+		}
+	}
+	if zone, err = c.t.getZone(metaNode.ZoneName); err != nil {
+		return
+	}
+	if ns, err = zone.getNodeSet(metaNode.NodeSetID); err != nil {
+		return
+	}
+	if targetHosts, _, err = ns.getAvailMetaNodeHosts(hosts, 1); err != nil {
+		excludeNodeSets = append(excludeNodeSets, ns.ID)
+		if targetHosts, _, err = zone.getAvailMetaNodeHosts(excludeNodeSets, hosts, 1); err != nil {
+			if targetHosts, _, err = c.chooseTargetMetaHosts(zone.name, excludeNodeSets, hosts, 1, false, ""); err != nil {
+				return
+			}
+		}
+	}
+	newAddr = targetHosts[0]
+	return
+}
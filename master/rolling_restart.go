@@ -0,0 +1,247 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	defaultNodeAgentPort         = 17900
+	defaultRollingRestartTimeout = 10 * time.Minute
+	rollingRestartCheckInterval  = 5 * time.Second
+	nodeAgentRestartTimeout      = 5 * time.Minute
+)
+
+// rollingRestart defines a rolling restart of the meta nodes or the data nodes. The nodes are restarted batch
+// by batch, and the next batch starts only after the nodes of the previous batch have recovered.
+type rollingRestart struct {
+	role      string
+	nodes     []string
+	batch     int
+	agentPort int
+	timeout   time.Duration
+}
+
+// newRollingRestart checks the nodes of the rolling restart. All the nodes of the role are restarted if no
+// node is specified.
+func (c *Cluster) newRollingRestart(role string, nodes []string, batch, agentPort int, timeout time.Duration) (rr *rollingRestart, err error) {
+	if role != proto.MetaNode && role != proto.DataNode {
+		return nil, fmt.Errorf("invalid role[%v], should be %v or %v", role, proto.MetaNode, proto.DataNode)
+	}
+	if batch <= 0 {
+		batch = 1
+	}
+	if agentPort <= 0 {
+		agentPort = defaultNodeAgentPort
+	}
+	if timeout <= 0 {
+		timeout = defaultRollingRestartTimeout
+	}
+	if len(nodes) == 0 {
+		nodes = c.allNodeAddrs(role)
+	}
+	for _, addr := range nodes {
+		if role == proto.MetaNode {
+			_, err = c.metaNode(addr)
+		} else {
+			_, err = c.dataNode(addr)
+		}
+		if err != nil {
+			return
+		}
+	}
+	sort.Strings(nodes)
+	return &rollingRestart{role: role, nodes: nodes, batch: batch, agentPort: agentPort, timeout: timeout}, nil
+}
+
+func (c *Cluster) allNodeAddrs(role string) (addrs []string) {
+	addrs = make([]string, 0)
+	nodes := &c.dataNodes
+	if role == proto.MetaNode {
+		nodes = &c.metaNodes
+	}
+	nodes.Range(func(key, value interface{}) bool {
+		addrs = append(addrs, key.(string))
+		return true
+	})
+	return
+}
+
+// doRollingRestart restarts the nodes batch by batch. For each node of a batch, the raft leaders are transferred
+// to the other replicas, and the node agent on the host of the node is requested to restart the node. Then it waits
+// until the nodes report heartbeats again and the replicas on the nodes catch up with the leaders.
+func (c *Cluster) doRollingRestart(rr *rollingRestart, progress progressFunc) (err error) {
+	progress(0, len(rr.nodes))
+	for start := 0; start < len(rr.nodes); start += rr.batch {
+		end := start + rr.batch
+		if end > len(rr.nodes) {
+			end = len(rr.nodes)
+		}
+		batch := rr.nodes[start:end]
+		restartTimes := make(map[string]time.Time)
+		for _, addr := range batch {
+			if err = c.transferLeadersOffNode(rr.role, addr, batch); err != nil {
+				return
+			}
+			if err = requestNodeAgentRestart(rr.role, addr, rr.agentPort); err != nil {
+				return
+			}
+			restartTimes[addr] = time.Now()
+			log.LogWarnf("action[doRollingRestart] clusterID[%v] %v[%v] is restarted", c.Name, rr.role, addr)
+		}
+		if err = c.waitNodesRecovered(rr.role, restartTimes, rr.timeout); err != nil {
+			return
+		}
+		progress(end, len(rr.nodes))
+	}
+	return
+}
+
+// transferLeadersOffNode transfers the raft leaders of the partitions on the node to the replicas on the other
+// active nodes, except the nodes restarted in the same batch.
+func (c *Cluster) transferLeadersOffNode(role, addr string, excluded []string) (err error) {
+	for _, vol := range c.copyVols() {
+		if role == proto.DataNode {
+			for _, dp := range vol.cloneDataPartitionMap() {
+				dp.RLock()
+				leaderAddr, hosts := dp.getLeaderAddr(), append([]string{}, dp.Hosts...)
+				dp.RUnlock()
+				if leaderAddr != addr {
+					continue
+				}
+				target := c.activeReplicaHost(role, hosts, excluded)
+				if target == "" {
+					return fmt.Errorf("no active replica to take over the leader of data partition[%v] from [%v]", dp.PartitionID, addr)
+				}
+				if err = c.transferDataPartitionLeader(dp, target); err != nil {
+					return
+				}
+			}
+			continue
+		}
+		for _, mp := range vol.cloneMetaPartitionMap() {
+			mp.RLock()
+			leader, leaderErr := mp.getMetaReplicaLeader()
+			hosts := append([]string{}, mp.Hosts...)
+			mp.RUnlock()
+			if leaderErr != nil || leader.Addr != addr {
+				continue
+			}
+			target := c.activeReplicaHost(role, hosts, excluded)
+			if target == "" {
+				return fmt.Errorf("no active replica to take over the leader of meta partition[%v] from [%v]", mp.PartitionID, addr)
+			}
+			if err = c.transferMetaPartitionLeader(mp, target); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (c *Cluster) activeReplicaHost(role string, hosts []string, excluded []string) string {
+	for _, host := range hosts {
+		if contains(excluded, host) {
+			continue
+		}
+		if role == proto.DataNode {
+			if dataNode, err := c.dataNode(host); err == nil && dataNode.isActive {
+				return host
+			}
+			continue
+		}
+		if metaNode, err := c.metaNode(host); err == nil && metaNode.IsActive {
+			return host
+		}
+	}
+	return ""
+}
+
+// requestNodeAgentRestart requests the node agent on the host of the node to restart the node of the role.
+// The agent replies after the node is restarted, such as by restarting the service of the node.
+func requestNodeAgentRestart(role, addr string, agentPort int) (err error) {
+	var host string
+	if host, _, err = net.SplitHostPort(addr); err != nil {
+		return
+	}
+	url := fmt.Sprintf("http://%v/restart?role=%v", net.JoinHostPort(host, strconv.Itoa(agentPort)), role)
+	client := &http.Client{Timeout: nodeAgentRestartTimeout}
+	var resp *http.Response
+	if resp, err = client.Post(url, "application/json", nil); err != nil {
+		return fmt.Errorf("request node agent to restart %v[%v] failed: %v", role, addr, err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("node agent failed to restart %v[%v]: status[%v] body[%s]", role, addr, resp.StatusCode, body)
+	}
+	return
+}
+
+// waitNodesRecovered waits until the nodes report heartbeats after they are restarted, and the replicas on the
+// nodes catch up with the leaders.
+func (c *Cluster) waitNodesRecovered(role string, restartTimes map[string]time.Time, timeout time.Duration) (err error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := make([]string, 0)
+		for addr, restartTime := range restartTimes {
+			if !c.isNodeRecovered(role, addr, restartTime) {
+				pending = append(pending, addr)
+			}
+		}
+		for _, lag := range c.checkRaftLag(defaultHealthMaxRaftLag) {
+			if _, ok := restartTimes[lag.Addr]; ok && !contains(pending, lag.Addr) {
+				pending = append(pending, lag.Addr)
+			}
+		}
+		if len(pending) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			sort.Strings(pending)
+			return fmt.Errorf("%v%v are not recovered in %v", role, pending, timeout)
+		}
+		time.Sleep(rollingRestartCheckInterval)
+	}
+}
+
+func (c *Cluster) isNodeRecovered(role, addr string, restartTime time.Time) bool {
+	if role == proto.DataNode {
+		dataNode, err := c.dataNode(addr)
+		if err != nil {
+			return false
+		}
+		dataNode.RLock()
+		defer dataNode.RUnlock()
+		return dataNode.isActive && dataNode.ReportTime.After(restartTime)
+	}
+	metaNode, err := c.metaNode(addr)
+	if err != nil {
+		return false
+	}
+	metaNode.RLock()
+	defer metaNode.RUnlock()
+	return metaNode.IsActive && metaNode.ReportTime.After(restartTime)
+}
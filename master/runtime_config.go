@@ -0,0 +1,252 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	cfgIntervalToCheckDataPartition        = "intervalToCheckDataPartition"
+	cfgIntervalToAlarmMissingDataPartition = "intervalToAlarmMissingDataPartition"
+	cfgPeriodToLoadAllDataPartitions       = "periodToLoadAllDataPartitions"
+	cfgNumberOfDataPartitionsToFree        = "numberOfDataPartitionsToFree"
+	cfgDiffSpaceUsage                      = "diffSpaceUsage"
+	configValueKey                         = "value"
+)
+
+// runtimeConfigItem is a parameter of the master which can be changed without restarting the master.
+// The names are the keys in the config file if the parameters can be set by the config file too.
+type runtimeConfigItem struct {
+	name        string
+	description string
+	get         func(cfg *clusterConfig) string
+	set         func(cfg *clusterConfig, value string) error // the value is validated before it is applied
+}
+
+var runtimeConfigItems = []*runtimeConfigItem{
+	int64ConfigItem(missingDataPartitionInterval, 1,
+		"seconds a data partition replica is not reported before it is considered as missing",
+		func(cfg *clusterConfig) *int64 { return &cfg.MissingDataPartitionInterval }),
+	int64ConfigItem(dataPartitionTimeOutSec, 1,
+		"seconds a data partition replica is not reported before it is considered as unavailable",
+		func(cfg *clusterConfig) *int64 { return &cfg.DataPartitionTimeOutSec }),
+	int64ConfigItem(cfgIntervalToAlarmMissingDataPartition, 1,
+		"seconds between the alarms of a missing data partition replica",
+		func(cfg *clusterConfig) *int64 { return &cfg.IntervalToAlarmMissingDataPartition }),
+	intConfigItem(cfgIntervalToCheckDataPartition, 1,
+		"seconds between the checks of the data partitions",
+		func(cfg *clusterConfig) *int { return &cfg.IntervalToCheckDataPartition }),
+	int64ConfigItem(cfgPeriodToLoadAllDataPartitions, 1,
+		"seconds to load all the data partitions to compare the replicas",
+		func(cfg *clusterConfig) *int64 { return &cfg.PeriodToLoadALLDataPartitions }),
+	intConfigItem(NumberOfDataPartitionsToLoad, 40,
+		"max number of the data partitions of a volume to load every time",
+		func(cfg *clusterConfig) *int { return &cfg.numberOfDataPartitionsToLoad }),
+	intConfigItem(cfgNumberOfDataPartitionsToFree, 1,
+		"max number of the loaded data partitions of a volume to free every time",
+		func(cfg *clusterConfig) *int { return &cfg.numberOfDataPartitionsToFree }),
+	int64ConfigItem(secondsToFreeDataPartitionAfterLoad, 0,
+		"seconds to keep a loaded data partition before it is freed",
+		func(cfg *clusterConfig) *int64 { return &cfg.secondsToFreeDataPartitionAfterLoad }),
+	uint64ConfigItem(cfgDiffSpaceUsage, 1,
+		"bytes the used sizes of the replicas of a data partition differ to alarm",
+		func(cfg *clusterConfig) *uint64 { return &cfg.diffSpaceUsage }),
+	uint64ConfigItem(cfgMetaNodeReservedMem, 32*1024*1024,
+		"bytes of the memory reserved on a meta node, the meta node is not writable if less memory is available",
+		func(cfg *clusterConfig) *uint64 { return &cfg.metaNodeReservedMem }),
+	int64ConfigItem(cfgAuditRetentionDays, 1,
+		"days to keep the audit records of the admin calls",
+		func(cfg *clusterConfig) *int64 { return &cfg.auditRetentionDays }),
+	int64ConfigItem(cfgMetadataBackupInterval, 0,
+		"seconds between the scheduled backups of the metadata, 0 to disable",
+		func(cfg *clusterConfig) *int64 { return &cfg.metadataBackupInterval }),
+	int64ConfigItem(cfgMetadataBackupRetention, 1,
+		"number of the latest backups of the metadata to keep in the object store",
+		func(cfg *clusterConfig) *int64 { return &cfg.metadataBackupRetention }),
+	int64ConfigItem(cfgStalePartitionGracePeriod, 0,
+		"seconds a replica of a purged volume is found before the replica is deleted, 0 to keep the replicas",
+		func(cfg *clusterConfig) *int64 { return &cfg.stalePartitionGracePeriod }),
+	int64ConfigItem(cfgReplicaNumChangeLimit, 1,
+		"max data partitions adding or removing the replicas at the same time to change the replica numbers of the volumes",
+		func(cfg *clusterConfig) *int64 { return &cfg.replicaNumChangeLimit }),
+}
+
+func int64ConfigItem(name string, min int64, description string, field func(cfg *clusterConfig) *int64) *runtimeConfigItem {
+	return &runtimeConfigItem{
+		name:        name,
+		description: description,
+		get:         func(cfg *clusterConfig) string { return strconv.FormatInt(*field(cfg), 10) },
+		set: func(cfg *clusterConfig, value string) error {
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || v < min {
+				return fmt.Errorf("%v must be an integer not less than %v", name, min)
+			}
+			*field(cfg) = v
+			return nil
+		},
+	}
+}
+
+func intConfigItem(name string, min int, description string, field func(cfg *clusterConfig) *int) *runtimeConfigItem {
+	return &runtimeConfigItem{
+		name:        name,
+		description: description,
+		get:         func(cfg *clusterConfig) string { return strconv.Itoa(*field(cfg)) },
+		set: func(cfg *clusterConfig, value string) error {
+			v, err := strconv.Atoi(value)
+			if err != nil || v < min {
+				return fmt.Errorf("%v must be an integer not less than %v", name, min)
+			}
+			*field(cfg) = v
+			return nil
+		},
+	}
+}
+
+func uint64ConfigItem(name string, min uint64, description string, field func(cfg *clusterConfig) *uint64) *runtimeConfigItem {
+	return &runtimeConfigItem{
+		name:        name,
+		description: description,
+		get:         func(cfg *clusterConfig) string { return strconv.FormatUint(*field(cfg), 10) },
+		set: func(cfg *clusterConfig, value string) error {
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || v < min {
+				return fmt.Errorf("%v must be an integer not less than %v", name, min)
+			}
+			*field(cfg) = v
+			return nil
+		},
+	}
+}
+
+func runtimeConfigItemOf(name string) *runtimeConfigItem {
+	for _, item := range runtimeConfigItems {
+		if item.name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// validate checks the value without applying it.
+func (item *runtimeConfigItem) validate(value string) error {
+	return item.set(new(clusterConfig), value)
+}
+
+// runtimeConfig keeps the values set at runtime, which override the values when the master started.
+type runtimeConfig struct {
+	sync.RWMutex
+	static    map[string]string
+	overrides map[string]string
+}
+
+func newRuntimeConfig(cfg *clusterConfig) *runtimeConfig {
+	rc := &runtimeConfig{
+		static:    make(map[string]string, len(runtimeConfigItems)),
+		overrides: make(map[string]string),
+	}
+	for _, item := range runtimeConfigItems {
+		rc.static[item.name] = item.get(cfg)
+	}
+	return rc
+}
+
+func (rc *runtimeConfig) getOverrides() (overrides map[string]string) {
+	rc.RLock()
+	defer rc.RUnlock()
+	overrides = make(map[string]string, len(rc.overrides))
+	for name, value := range rc.overrides {
+		overrides[name] = value
+	}
+	return
+}
+
+func (rc *runtimeConfig) getOverride(name string) (value string, ok bool) {
+	rc.RLock()
+	defer rc.RUnlock()
+	value, ok = rc.overrides[name]
+	return
+}
+
+func (rc *runtimeConfig) putOverride(name, value string) {
+	rc.Lock()
+	defer rc.Unlock()
+	rc.overrides[name] = value
+}
+
+func (rc *runtimeConfig) deleteOverride(name string) {
+	rc.Lock()
+	defer rc.Unlock()
+	delete(rc.overrides, name)
+}
+
+func (c *Cluster) setRuntimeConfig(item *runtimeConfigItem, value string) (err error) {
+	oldValue := item.get(c.cfg)
+	oldOverride, overridden := c.runtimeConfig.getOverride(item.name)
+	if err = item.set(c.cfg, value); err != nil {
+		return
+	}
+	c.runtimeConfig.putOverride(item.name, item.get(c.cfg))
+	if err = c.syncPutCluster(); err != nil {
+		log.LogErrorf("action[setRuntimeConfig] name[%v] value[%v] err[%v]", item.name, value, err)
+		item.set(c.cfg, oldValue)
+		if overridden {
+			c.runtimeConfig.putOverride(item.name, oldOverride)
+		} else {
+			c.runtimeConfig.deleteOverride(item.name)
+		}
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogInfof("action[setRuntimeConfig] name[%v] value[%v] old[%v]", item.name, item.get(c.cfg), oldValue)
+	return
+}
+
+// loadRuntimeConfig applies the persisted values set at runtime, the unknown parameters and the invalid
+// values, such as the ones persisted by the other versions of the master, are skipped.
+func (c *Cluster) loadRuntimeConfig(overrides map[string]string) {
+	for name, value := range overrides {
+		item := runtimeConfigItemOf(name)
+		if item == nil {
+			log.LogWarnf("action[loadRuntimeConfig] skip the unknown parameter[%v] value[%v]", name, value)
+			continue
+		}
+		if err := item.set(c.cfg, value); err != nil {
+			log.LogWarnf("action[loadRuntimeConfig] skip the parameter[%v] value[%v] err[%v]", name, value, err)
+			continue
+		}
+		c.runtimeConfig.putOverride(name, value)
+		log.LogInfof("action[loadRuntimeConfig] name[%v] value[%v]", name, value)
+	}
+}
+
+func (c *Cluster) runtimeConfigView(item *runtimeConfigItem) *proto.RuntimeConfigItem {
+	c.runtimeConfig.RLock()
+	defer c.runtimeConfig.RUnlock()
+	_, overridden := c.runtimeConfig.overrides[item.name]
+	return &proto.RuntimeConfigItem{
+		Name:        item.name,
+		Value:       item.get(c.cfg),
+		Static:      c.runtimeConfig.static[item.name],
+		Overridden:  overridden,
+		Description: item.description,
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestRuntimeConfigItems(t *testing.T) {
+	cfg := newClusterConfig()
+	for _, item := range runtimeConfigItems {
+		value := item.get(cfg)
+		if err := item.set(cfg, value); err != nil {
+			t.Errorf("set %v to its current value %v, err %v", item.name, value, err)
+		}
+		for _, invalid := range []string{"", "abc", "-1", "1.5"} {
+			if err := item.validate(invalid); err == nil {
+				t.Errorf("expect the value [%v] of %v invalid", invalid, item.name)
+			}
+		}
+	}
+	if item := runtimeConfigItemOf(NumberOfDataPartitionsToLoad); item.validate("39") == nil {
+		t.Errorf("expect the value less than the min invalid")
+	}
+}
+
+func TestSetRuntimeConfig(t *testing.T) {
+	reqURL := fmt.Sprintf("%v%v?name=%v&value=%v", hostAddr, proto.AdminSetConfig, dataPartitionTimeOutSec, 1200)
+	process(reqURL, t)
+	if server.cluster.cfg.DataPartitionTimeOutSec != 1200 {
+		t.Errorf("set %v to 1200 failed, got %v", dataPartitionTimeOutSec, server.cluster.cfg.DataPartitionTimeOutSec)
+		return
+	}
+	if value, ok := server.cluster.runtimeConfig.getOverride(dataPartitionTimeOutSec); !ok || value != "1200" {
+		t.Errorf("expect the value overridden, got %v %v", value, ok)
+	}
+	cv := newClusterValue(server.cluster)
+	if cv.RuntimeConfig[dataPartitionTimeOutSec] != "1200" {
+		t.Errorf("expect the value persisted, got %v", cv.RuntimeConfig)
+	}
+	item := runtimeConfigItemOf(dataPartitionTimeOutSec)
+	if err := server.cluster.setRuntimeConfig(item, "0"); err == nil || server.cluster.cfg.DataPartitionTimeOutSec != 1200 {
+		t.Errorf("expect the invalid value rejected, got err %v value %v", err, server.cluster.cfg.DataPartitionTimeOutSec)
+	}
+	reqURL = fmt.Sprintf("%v%v?name=%v", hostAddr, proto.AdminGetConfig, dataPartitionTimeOutSec)
+	process(reqURL, t)
+	reqURL = fmt.Sprintf("%v%v", hostAddr, proto.AdminListConfig)
+	process(reqURL, t)
+}
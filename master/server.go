@@ -83,6 +83,8 @@ type Server struct {
 	reverseProxy *httputil.ReverseProxy
 	metaReady    bool
 	apiServer    *http.Server
+
+	followerReads *followerReadCache
 }
 
 // NewServer creates a new server
@@ -96,6 +98,7 @@ func (m *Server) Start(cfg *config.Config) (err error) {
 	gConfig = m.config
 	m.leaderInfo = &LeaderInfo{}
 	m.reverseProxy = m.newReverseProxy()
+	m.followerReads = newFollowerReadCache()
 	if err = m.checkConfig(cfg); err != nil {
 		log.LogError(errors.Stack(err))
 		return
@@ -188,6 +191,94 @@ func (m *Server) checkConfig(cfg *config.Config) (err error) {
 		m.config.metaNodeReservedMem = defaultMetaNodeReservedMem
 	}
 
+	m.config.eventWebhooks = cfg.GetStringSlice(cfgEventWebhooks)
+	m.config.eventKafkaProxy = cfg.GetString(cfgEventKafkaProxy)
+	m.config.eventKafkaTopic = cfg.GetString(cfgEventKafkaTopic)
+	if m.config.eventKafkaProxy != "" && m.config.eventKafkaTopic == "" {
+		return fmt.Errorf("%v,err:%v is required by %v", proto.ErrInvalidCfg, cfgEventKafkaTopic, cfgEventKafkaProxy)
+	}
+	if auditRetentionDays := cfg.GetString(cfgAuditRetentionDays); auditRetentionDays != "" {
+		if m.config.auditRetentionDays, err = strconv.ParseInt(auditRetentionDays, 10, 64); err != nil || m.config.auditRetentionDays <= 0 {
+			return fmt.Errorf("%v,err:%v must be a positive integer", proto.ErrInvalidCfg, cfgAuditRetentionDays)
+		}
+	}
+	m.config.rbacEnabled = cfg.GetBool(cfgRBACEnable)
+	if m.config.rbacTokens, err = parseRBACTokens(cfg.GetStringSlice(cfgRBACTokens)); err != nil {
+		return fmt.Errorf("%v,err:%v %v", proto.ErrInvalidCfg, cfgRBACTokens, err)
+	}
+	m.config.rbacAnonymousRole = cfg.GetString(cfgRBACAnonymousRole)
+	if m.config.rbacAnonymousRole != "" && !isValidRole(m.config.rbacAnonymousRole) {
+		return fmt.Errorf("%v,err:%v must be one of %v", proto.ErrInvalidCfg, cfgRBACAnonymousRole, proto.Roles)
+	}
+	m.config.metadataBackup = metadataBackupConfig{
+		endpoint:  cfg.GetString(cfgMetadataBackupEndpoint),
+		region:    cfg.GetString(cfgMetadataBackupRegion),
+		bucket:    cfg.GetString(cfgMetadataBackupBucket),
+		prefix:    cfg.GetString(cfgMetadataBackupPrefix),
+		accessKey: cfg.GetString(cfgMetadataBackupAccessKey),
+		secretKey: cfg.GetString(cfgMetadataBackupSecretKey),
+	}
+	if m.config.metadataBackup.endpoint != "" && m.config.metadataBackup.bucket == "" {
+		return fmt.Errorf("%v,err:%v is required by %v", proto.ErrInvalidCfg, cfgMetadataBackupBucket, cfgMetadataBackupEndpoint)
+	}
+	if interval := cfg.GetString(cfgMetadataBackupInterval); interval != "" {
+		if m.config.metadataBackupInterval, err = strconv.ParseInt(interval, 10, 64); err != nil || m.config.metadataBackupInterval < 0 {
+			return fmt.Errorf("%v,err:%v must be a non-negative integer", proto.ErrInvalidCfg, cfgMetadataBackupInterval)
+		}
+	}
+	if retention := cfg.GetString(cfgMetadataBackupRetention); retention != "" {
+		if m.config.metadataBackupRetention, err = strconv.ParseInt(retention, 10, 64); err != nil || m.config.metadataBackupRetention <= 0 {
+			return fmt.Errorf("%v,err:%v must be a positive integer", proto.ErrInvalidCfg, cfgMetadataBackupRetention)
+		}
+	}
+	if gracePeriod := cfg.GetString(cfgStalePartitionGracePeriod); gracePeriod != "" {
+		if m.config.stalePartitionGracePeriod, err = strconv.ParseInt(gracePeriod, 10, 64); err != nil || m.config.stalePartitionGracePeriod < 0 {
+			return fmt.Errorf("%v,err:%v must be a non-negative integer", proto.ErrInvalidCfg, cfgStalePartitionGracePeriod)
+		}
+	}
+	if limit := cfg.GetString(cfgReplicaNumChangeLimit); limit != "" {
+		if m.config.replicaNumChangeLimit, err = strconv.ParseInt(limit, 10, 64); err != nil || m.config.replicaNumChangeLimit <= 0 {
+			return fmt.Errorf("%v,err:%v must be a positive integer", proto.ErrInvalidCfg, cfgReplicaNumChangeLimit)
+		}
+	}
+	m.config.region = cfg.GetString(cfgRegion)
+	m.config.federationAuthToken = cfg.GetString(cfgFederationAuthToken)
+	if interval := cfg.GetString(cfgConsistencyCheckInterval); interval != "" {
+		if m.config.consistencyCheckInterval, err = strconv.ParseInt(interval, 10, 64); err != nil || m.config.consistencyCheckInterval < 0 {
+			return fmt.Errorf("%v,err:%v must be a non-negative integer", proto.ErrInvalidCfg, cfgConsistencyCheckInterval)
+		}
+	}
+	if sampleSize := cfg.GetString(cfgConsistencyCheckSampleSize); sampleSize != "" {
+		if m.config.consistencyCheckSampleSize, err = strconv.Atoi(sampleSize); err != nil || m.config.consistencyCheckSampleSize < 0 {
+			return fmt.Errorf("%v,err:%v must be a non-negative integer", proto.ErrInvalidCfg, cfgConsistencyCheckSampleSize)
+		}
+	}
+
+	if keyManager := cfg.GetString(cfgVolKeyManager); keyManager != "" {
+		m.config.volKeyManager = keyManager
+	}
+	if m.config.volKeyManager != volKeyManagerAuthnode && m.config.volKeyManager != volKeyManagerVault {
+		return fmt.Errorf("%v,err:%v must be %v or %v", proto.ErrInvalidCfg, cfgVolKeyManager, volKeyManagerAuthnode,
+			volKeyManagerVault)
+	}
+	m.config.vaultAddr = cfg.GetString(cfgVaultAddr)
+	m.config.vaultToken = cfg.GetString(cfgVaultToken)
+	m.config.vaultKeyName = cfg.GetString(cfgVaultKeyName)
+	if m.config.volKeyManager == volKeyManagerVault && (m.config.vaultAddr == "" || m.config.vaultKeyName == "") {
+		return fmt.Errorf("%v,err:%v and %v are required by %v", proto.ErrInvalidCfg, cfgVaultAddr, cfgVaultKeyName,
+			cfgVolKeyManager)
+	}
+	if threshold := cfg.GetString(cfgDiskRetireSectorThreshold); threshold != "" {
+		if m.config.diskRetireSectorThreshold, err = strconv.ParseUint(threshold, 10, 64); err != nil {
+			return fmt.Errorf("%v,err:%v must be a non-negative integer", proto.ErrInvalidCfg, cfgDiskRetireSectorThreshold)
+		}
+	}
+	if migrate := cfg.GetString(cfgDiskRetireMigrate); migrate != "" {
+		if m.config.diskRetireMigrate, err = strconv.ParseBool(migrate); err != nil {
+			return fmt.Errorf("%v,err:%v must be true or false", proto.ErrInvalidCfg, cfgDiskRetireMigrate)
+		}
+	}
+
 	retainLogs := cfg.GetString(CfgRetainLogs)
 	if retainLogs != "" {
 		if m.retainLogs, err = strconv.ParseUint(retainLogs, 10, 64); err != nil {
@@ -0,0 +1,186 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// A stale partition is a replica left on a node by a volume which has been purged from the master, such as the
+// replica on a node which is down when the volume is deleted. The GC deletes the stale replicas reported for
+// longer than the grace period, which leaves the time to recover the metadata of the master if the volume is lost
+// by mistake.
+
+const (
+	intervalToScanStalePartitions = 10 * time.Minute
+	maxStalePartitionsToReclaim   = 100 // max replicas to delete in a scan
+	maxReclaimedStalePartitions   = 1000
+)
+
+type stalePartitionGC struct {
+	sync.RWMutex
+	pending   map[string]*proto.StalePartition // keyed by the type, the ID and the node of the replica
+	reclaimed []*proto.StalePartition          // the latest first
+	lastScan  int64
+}
+
+func newStalePartitionGC() *stalePartitionGC {
+	return &stalePartitionGC{pending: make(map[string]*proto.StalePartition)}
+}
+
+func stalePartitionKey(partitionType string, partitionID uint64, addr string) string {
+	return fmt.Sprintf("%v_%v_%v", partitionType, partitionID, addr)
+}
+
+// update replaces the pending replicas with the found ones, the replicas found before keep the time they are
+// first found.
+func (gc *stalePartitionGC) update(found map[string]*proto.StalePartition, now int64) {
+	gc.Lock()
+	defer gc.Unlock()
+	for key, stale := range found {
+		if old, ok := gc.pending[key]; ok {
+			stale.FirstSeen = old.FirstSeen
+		}
+	}
+	gc.pending = found
+	gc.lastScan = now
+}
+
+func (gc *stalePartitionGC) reclaim(key string, stale *proto.StalePartition) {
+	gc.Lock()
+	defer gc.Unlock()
+	delete(gc.pending, key)
+	gc.reclaimed = append([]*proto.StalePartition{stale}, gc.reclaimed...)
+	if len(gc.reclaimed) > maxReclaimedStalePartitions {
+		gc.reclaimed = gc.reclaimed[:maxReclaimedStalePartitions]
+	}
+}
+
+// reset forgets the pending replicas, so the grace period restarts when the master becomes the leader again.
+func (gc *stalePartitionGC) reset() {
+	gc.Lock()
+	defer gc.Unlock()
+	gc.pending = make(map[string]*proto.StalePartition)
+	gc.lastScan = 0
+}
+
+func (gc *stalePartitionGC) view(gracePeriod int64) (view *proto.StalePartitionGCView) {
+	gc.RLock()
+	defer gc.RUnlock()
+	view = &proto.StalePartitionGCView{
+		GracePeriod: gracePeriod,
+		LastScan:    gc.lastScan,
+		Pending:     make([]*proto.StalePartition, 0, len(gc.pending)),
+		Reclaimed:   append([]*proto.StalePartition{}, gc.reclaimed...),
+	}
+	for _, stale := range gc.pending {
+		view.Pending = append(view.Pending, stale)
+	}
+	sort.Slice(view.Pending, func(i, j int) bool {
+		if view.Pending[i].FirstSeen != view.Pending[j].FirstSeen {
+			return view.Pending[i].FirstSeen < view.Pending[j].FirstSeen
+		}
+		return stalePartitionKey(view.Pending[i].PartitionType, view.Pending[i].PartitionID, view.Pending[i].NodeAddr) <
+			stalePartitionKey(view.Pending[j].PartitionType, view.Pending[j].PartitionID, view.Pending[j].NodeAddr)
+	})
+	return
+}
+
+func (c *Cluster) scheduleToCollectStalePartitions() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.collectStalePartitions()
+			} else {
+				c.stalePartitions.reset()
+			}
+			time.Sleep(intervalToScanStalePartitions)
+		}
+	}()
+}
+
+// collectStalePartitions finds the replicas of the purged volumes in the latest reports of the nodes, and deletes
+// the ones found for longer than the grace period. The GC only tracks the replicas if the grace period is 0.
+func (c *Cluster) collectStalePartitions() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("collectStalePartitions occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"collectStalePartitions occurred panic")
+		}
+	}()
+	now := time.Now().Unix()
+	found := make(map[string]*proto.StalePartition)
+	for _, orphan := range c.findOrphanPartitions() {
+		if orphan.Reason != orphanReasonVolNotFound {
+			continue
+		}
+		found[stalePartitionKey(orphan.PartitionType, orphan.PartitionID, orphan.NodeAddr)] = &proto.StalePartition{
+			PartitionType: orphan.PartitionType,
+			PartitionID:   orphan.PartitionID,
+			VolName:       orphan.VolName,
+			NodeAddr:      orphan.NodeAddr,
+			FirstSeen:     now,
+		}
+	}
+	c.stalePartitions.update(found, now)
+	gracePeriod := c.cfg.stalePartitionGracePeriod
+	if gracePeriod <= 0 {
+		return
+	}
+	count := 0
+	for key, stale := range found {
+		if now-stale.FirstSeen < gracePeriod {
+			continue
+		}
+		if count >= maxStalePartitionsToReclaim {
+			break
+		}
+		if err := c.reclaimStalePartition(stale); err != nil {
+			log.LogWarnf("action[collectStalePartitions] %v partition[%v] vol[%v] node[%v] err[%v]",
+				stale.PartitionType, stale.PartitionID, stale.VolName, stale.NodeAddr, err)
+			continue
+		}
+		count++
+		stale.Reclaimed = time.Now().Unix()
+		c.stalePartitions.reclaim(key, stale)
+		c.events.emit(proto.EventStalePartitionReclaimed, stale.NodeAddr, stale.VolName, stale.PartitionID,
+			fmt.Sprintf("stale %v partition is deleted", stale.PartitionType))
+	}
+}
+
+// reclaimStalePartition confirms the volume and the partition are not in the cluster before the replica is
+// deleted, the replica is checked against the latest report of the node again by cleanOrphanPartition.
+func (c *Cluster) reclaimStalePartition(stale *proto.StalePartition) (err error) {
+	if _, err = c.getVol(stale.VolName); err == nil {
+		return fmt.Errorf("vol[%v] exists", stale.VolName)
+	}
+	switch stale.PartitionType {
+	case proto.PartitionTypeData:
+		_, err = c.getDataPartitionByID(stale.PartitionID)
+	case proto.PartitionTypeMeta:
+		_, err = c.getMetaPartitionByID(stale.PartitionID)
+	}
+	if err == nil {
+		return fmt.Errorf("partition[%v] exists", stale.PartitionID)
+	}
+	return c.cleanOrphanPartition(stale.PartitionType, stale.PartitionID, stale.NodeAddr)
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestStalePartitionGC(t *testing.T) {
+	gc := newStalePartitionGC()
+	stale := func(id uint64, addr string, now int64) (string, *proto.StalePartition) {
+		return stalePartitionKey(proto.PartitionTypeData, id, addr), &proto.StalePartition{
+			PartitionType: proto.PartitionTypeData, PartitionID: id, VolName: "old", NodeAddr: addr, FirstSeen: now}
+	}
+	key1, stale1 := stale(1, "192.168.0.31:17310", 100)
+	key2, stale2 := stale(2, "192.168.0.32:17310", 100)
+	gc.update(map[string]*proto.StalePartition{key1: stale1, key2: stale2}, 100)
+
+	// the replica found again keeps the time it is first found, and the one not found is dropped
+	key1, stale1 = stale(1, "192.168.0.31:17310", 700)
+	key3, stale3 := stale(3, "192.168.0.33:17310", 700)
+	gc.update(map[string]*proto.StalePartition{key1: stale1, key3: stale3}, 700)
+	view := gc.view(600)
+	if view.LastScan != 700 || view.GracePeriod != 600 || len(view.Pending) != 2 {
+		t.Fatalf("unexpected view %+v", view)
+	}
+	if view.Pending[0].PartitionID != 1 || view.Pending[0].FirstSeen != 100 || view.Pending[1].PartitionID != 3 {
+		t.Errorf("unexpected pending replicas %+v %+v", view.Pending[0], view.Pending[1])
+	}
+
+	stale1.Reclaimed = 800
+	gc.reclaim(key1, stale1)
+	view = gc.view(600)
+	if len(view.Pending) != 1 || len(view.Reclaimed) != 1 || view.Reclaimed[0].PartitionID != 1 {
+		t.Errorf("unexpected view after reclaim %+v", view)
+	}
+
+	gc.reset()
+	view = gc.view(600)
+	if len(view.Pending) != 0 || view.LastScan != 0 || len(view.Reclaimed) != 1 {
+		t.Errorf("unexpected view after reset %+v", view)
+	}
+}
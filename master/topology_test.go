@@ -137,3 +137,35 @@ func TestAllocZones(t *testing.T) {
 		}
 	}
 }
+
+func TestRackAwareSelection(t *testing.T) {
+	zoneName := "rack"
+	zone := newZone(zoneName)
+	nodeSet := newNodeSet(1, 6, zoneName)
+	zone.putNodeSet(nodeSet)
+	racks := make(map[string]string)
+	for i := 0; i < 6; i++ {
+		dn := createDataNodeForTopo(fmt.Sprintf("127.0.1.%v:17310", i+1), zoneName, nodeSet)
+		dn.RackName = fmt.Sprintf("rack%v", i%3)
+		racks[dn.Addr] = dn.RackName
+		nodeSet.putDataNode(dn)
+	}
+	hosts, _, err := nodeSet.getAvailDataNodeHosts(nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usedRacks := make(map[string]bool)
+	for _, host := range hosts {
+		usedRacks[racks[host]] = true
+	}
+	if len(usedRacks) != 3 {
+		t.Errorf("expect hosts%v in 3 racks, but in %v racks", hosts, len(usedRacks))
+	}
+	rackOf := func(addr string) string { return racks[addr] }
+	if isQuorumInOneRack(hosts, 3, rackOf) {
+		t.Errorf("expect hosts%v not in one rack", hosts)
+	}
+	if !isQuorumInOneRack([]string{"127.0.1.1:17310", "127.0.1.4:17310", "127.0.1.2:17310"}, 3, rackOf) {
+		t.Errorf("expect the majority of replicas in rack0")
+	}
+}
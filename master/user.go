@@ -31,6 +31,7 @@ type User struct {
 	userStoreMutex sync.RWMutex
 	AKStoreMutex   sync.RWMutex
 	volUserMutex   sync.RWMutex
+	quotaMutex     sync.Mutex // serializes the quota checks with the changes of the owned volumes
 }
 
 func newUser(fsm *MetadataFsm, partition raftstore.Partition) (u *User) {
@@ -79,10 +80,8 @@ func (u *User) createKey(param *proto.UserCreateParam) (userInfo *proto.UserInfo
 		err = proto.ErrDuplicateUserID
 		return
 	}
-	_, exist = u.AKStore.Load(accessKey)
-	for exist {
-		accessKey = util.RandomString(accessKeyLength, util.Numeric|util.LowerLetter|util.UpperLetter)
-		_, exist = u.AKStore.Load(accessKey)
+	if _, exist = u.AKStore.Load(accessKey); exist {
+		accessKey = u.generateAccessKey()
 	}
 	userPolicy = proto.NewUserPolicy()
 	userInfo = &proto.UserInfo{UserID: userID, AccessKey: accessKey, SecretKey: secretKey, Policy: userPolicy,
@@ -168,6 +167,14 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 		return
 	}
 	var formerAK = userInfo.AccessKey
+	if param.RegenerateKeys {
+		if param.AccessKey == "" {
+			param.AccessKey = u.generateAccessKey()
+		}
+		if param.SecretKey == "" {
+			param.SecretKey = util.RandomString(secretKeyLength, util.Numeric|util.LowerLetter|util.UpperLetter)
+		}
+	}
 	if param.AccessKey != "" {
 		if !proto.IsValidAK(param.AccessKey) {
 			err = proto.ErrInvalidAccessKey
@@ -225,6 +232,17 @@ func (u *User) updateKey(param *proto.UserUpdateParam) (userInfo *proto.UserInfo
 	return
 }
 
+// generateAccessKey returns a random access key which is not used by any user.
+// It must be called with the AKStoreMutex held.
+func (u *User) generateAccessKey() (accessKey string) {
+	for {
+		accessKey = util.RandomString(accessKeyLength, util.Numeric|util.LowerLetter|util.UpperLetter)
+		if _, exist := u.AKStore.Load(accessKey); !exist {
+			return
+		}
+	}
+}
+
 func (u *User) getKeyInfo(ak string) (userInfo *proto.UserInfo, err error) {
 	var akUser *proto.AKUser
 	if akUser, err = u.getAKUser(ak); err != nil {
@@ -248,6 +266,71 @@ func (u *User) getUserInfo(userID string) (userInfo *proto.UserInfo, err error)
 	return
 }
 
+func (u *User) setQuota(params *proto.UserQuotaSetParam) (userInfo *proto.UserInfo, err error) {
+	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
+		return
+	}
+	userInfo.Mu.Lock()
+	defer userInfo.Mu.Unlock()
+	oldQuota := userInfo.Quota
+	userInfo.Quota = params.Quota
+	if err = u.syncUpdateUserInfo(userInfo); err != nil {
+		userInfo.Quota = oldQuota
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	log.LogInfof("action[setQuota], userID: %v, quota: %v", params.UserID, params.Quota)
+	return
+}
+
+// quotaInfo returns the quota of the user and the capacity and the number of the volumes owned by the user.
+func (u *User) quotaInfo(c *Cluster, userInfo *proto.UserInfo) (info *proto.UserQuotaInfo) {
+	userInfo.Mu.RLock()
+	info = &proto.UserQuotaInfo{UserID: userInfo.UserID, Quota: userInfo.Quota}
+	ownVols := append([]string{}, userInfo.Policy.OwnVols...)
+	userInfo.Mu.RUnlock()
+	for _, volName := range ownVols {
+		vol, err := c.getVol(volName)
+		if err != nil {
+			continue
+		}
+		info.UsedCapacity += vol.Capacity
+		info.UsedVolCount++
+	}
+	return
+}
+
+// checkQuota checks whether the user can own the volume with the capacity(GB) within the quota. The volume itself is
+// not counted if it is already owned by the user, so the expansion is checked in the same way as the creation.
+// It must be called with the quotaMutex held, and the user which does not exist has no quota.
+func (u *User) checkQuota(c *Cluster, userID, volName string, capacity uint64) (err error) {
+	var userInfo *proto.UserInfo
+	if userInfo, err = u.getUserInfo(userID); err != nil {
+		if err == proto.ErrUserNotExists {
+			err = nil
+		}
+		return
+	}
+	info := u.quotaInfo(c, userInfo)
+	if userInfo.Policy.IsOwn(volName) {
+		if vol, err := c.getVol(volName); err == nil {
+			info.UsedCapacity -= vol.Capacity
+			info.UsedVolCount--
+		}
+	}
+	if info.Quota.VolCount > 0 && info.UsedVolCount+1 > info.Quota.VolCount {
+		log.LogWarnf("action[checkQuota] userID[%v] vol[%v] owns [%v] volumes, exceeds the quota[%v]",
+			userID, volName, info.UsedVolCount+1, info.Quota.VolCount)
+		return proto.ErrUserQuotaExceeded
+	}
+	if info.Quota.Capacity > 0 && info.UsedCapacity+capacity > info.Quota.Capacity {
+		log.LogWarnf("action[checkQuota] userID[%v] vol[%v] owns [%v]GB capacity, exceeds the quota[%v]GB",
+			userID, volName, info.UsedCapacity+capacity, info.Quota.Capacity)
+		return proto.ErrUserQuotaExceeded
+	}
+	return
+}
+
 func (u *User) updatePolicy(params *proto.UserPermUpdateParam) (userInfo *proto.UserInfo, err error) {
 	if userInfo, err = u.getUserInfo(params.UserID); err != nil {
 		return
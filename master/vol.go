@@ -17,7 +17,9 @@ package master
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
@@ -26,15 +28,26 @@ import (
 )
 
 type VolVarargs struct {
-	zoneName       string
-	description    string
-	capacity       uint64 //GB
-	dpReplicaNum   uint8
-	followerRead   bool
-	authenticate   bool
-	enableToken    bool
-	dpSelectorName string
-	dpSelectorParm string
+	zoneName        string
+	description     string
+	capacity        uint64 //GB
+	dpReplicaNum    uint8
+	followerRead    bool
+	authenticate    bool
+	enableToken     bool
+	dpSelectorName  string
+	dpSelectorParm  string
+	placementPolicy string
+	placementZone   string
+	placementLabels map[string]string
+	qos             proto.VolQos
+	ipAcl           proto.VolIPAcl
+	trashTTL        uint64
+	metaStore       string
+	inodeRetention  uint64
+	verifyReadCrc   bool
+	compression     string
+	encryptionKeys  []*volEncryptionKey
 }
 
 // Vol represents a set of meta partitionMap and data partitionMap
@@ -69,6 +82,28 @@ type Vol struct {
 	description        string
 	dpSelectorName     string
 	dpSelectorParm     string
+	placementPolicy    string
+	placementZone      string
+	placementLabels    map[string]string // replaced as a whole when it is changed
+	qos                proto.VolQos
+	ipAcl              proto.VolIPAcl
+	dirQuotas          map[uint32]*proto.DirQuota // replaced as a whole when it is changed
+	maxQuotaID         uint32
+	snapshots          []*proto.VolSnapshot // sorted by ID, replaced as a whole when it is changed
+	maxSnapshotID      uint64
+	replication        *proto.VolReplication // replaced as a whole when it is changed
+	deleteTime         int64                 // the time when the volume is marked deleted
+	trashTTL           uint64
+	metaStore          string // the store of the new meta partitions, empty for the default of the meta nodes
+	inodeRetention     uint64 // seconds to keep the deleted inodes before purging them, 0 for the default of the meta nodes
+	expirationRules    []*proto.ExpirationRule // sorted by ID, replaced as a whole when it is changed
+	maxExpirationID    uint32
+	lifecycleRules     []*proto.LifecycleRule // sorted by ID, replaced as a whole when it is changed
+	maxLifecycleID     uint32
+	caseInsensitive    bool // the names are looked up case-insensitively by the meta partitions, only set on creation
+	verifyReadCrc      bool   // the data read is checked against the crc of the extent blocks by the data nodes and the clients
+	compression        string // algorithm the cold extents are compressed with by the data nodes, empty for none
+	encryptionKeys     []*volEncryptionKey // data keys sorted by version, the last is current, empty if not encrypted
 	sync.RWMutex
 }
 
@@ -103,6 +138,7 @@ func newVol(id uint64, name, owner, zoneName string, dpSize, capacity uint64, dp
 	vol.enableToken = enableToken
 	vol.tokens = make(map[string]*proto.Token, 0)
 	vol.description = description
+	vol.dirQuotas = make(map[uint32]*proto.DirQuota)
 	return
 }
 
@@ -127,6 +163,31 @@ func newVolFromVolValue(vv *volValue) (vol *Vol) {
 	vol.Status = vv.Status
 	vol.dpSelectorName = vv.DpSelectorName
 	vol.dpSelectorParm = vv.DpSelectorParm
+	vol.placementPolicy = vv.PlacementPolicy
+	vol.placementZone = vv.PlacementZone
+	vol.placementLabels = vv.PlacementLabels
+	vol.qos = vv.Qos
+	vol.ipAcl = vv.IPAcl
+	vol.dirQuotas = make(map[uint32]*proto.DirQuota, len(vv.DirQuotas))
+	for _, quota := range vv.DirQuotas {
+		vol.dirQuotas[quota.QuotaID] = quota
+	}
+	vol.maxQuotaID = vv.MaxQuotaID
+	vol.snapshots = vv.Snapshots
+	vol.maxSnapshotID = vv.MaxSnapshotID
+	vol.replication = vv.Replication
+	vol.deleteTime = vv.DeleteTime
+	vol.trashTTL = vv.TrashTTL
+	vol.metaStore = vv.MetaStore
+	vol.inodeRetention = vv.InodeRetention
+	vol.expirationRules = vv.ExpirationRules
+	vol.maxExpirationID = vv.MaxExpirationID
+	vol.lifecycleRules = vv.LifecycleRules
+	vol.maxLifecycleID = vv.MaxLifecycleID
+	vol.caseInsensitive = vv.CaseInsensitive
+	vol.verifyReadCrc = vv.VerifyReadCrc
+	vol.compression = vv.Compression
+	vol.encryptionKeys = vv.EncryptionKeys
 	return vol
 }
 
@@ -246,7 +307,9 @@ func (vol *Vol) checkDataPartitions(c *Cluster) (cnt int) {
 	for _, dp := range vol.dataPartitions.partitionMap {
 		dp.checkReplicaStatus(c.cfg.DataPartitionTimeOutSec)
 		dp.checkStatus(c.Name, true, c.cfg.DataPartitionTimeOutSec)
-		dp.checkLeader(c.cfg.DataPartitionTimeOutSec)
+		if dp.checkLeader(c.cfg.DataPartitionTimeOutSec) {
+			c.events.emit(proto.EventPartitionLostLeader, "", vol.Name, dp.PartitionID, "data partition lost the leader")
+		}
 		dp.checkMissingReplicas(c.Name, c.leaderInfo.addr, c.cfg.MissingDataPartitionInterval, c.cfg.IntervalToAlarmMissingDataPartition)
 		dp.checkReplicaNum(c, vol)
 		if dp.Status == proto.ReadWrite {
@@ -283,25 +346,6 @@ func (vol *Vol) releaseDataPartitions(releaseCount int, afterLoadSeconds int64)
 	log.LogInfo(msg)
 }
 
-func (vol *Vol) checkReplicaNum(c *Cluster) {
-	if !vol.NeedToLowerReplica {
-		return
-	}
-	var err error
-	dps := vol.cloneDataPartitionMap()
-	for _, dp := range dps {
-		host := dp.getToBeDecommissionHost(int(vol.dpReplicaNum))
-		if host == "" {
-			continue
-		}
-		if err = dp.removeOneReplicaByHost(c, host); err != nil {
-			log.LogErrorf("action[checkReplicaNum],vol[%v],err[%v]", vol.Name, err)
-			continue
-		}
-	}
-	vol.NeedToLowerReplica = false
-}
-
 func (vol *Vol) checkMetaPartitions(c *Cluster) {
 	var tasks []*proto.AdminTask
 	vol.checkSplitMetaPartition(c)
@@ -313,6 +357,9 @@ func (vol *Vol) checkMetaPartitions(c *Cluster) {
 	)
 	for _, mp := range mps {
 		doSplit = mp.checkStatus(c.Name, true, int(vol.mpReplicaNum), maxPartitionID)
+		if mp.checkSplitThreshold(c, maxPartitionID) {
+			doSplit = true
+		}
 		if doSplit {
 			nextStart := mp.Start + mp.MaxInodeID + defaultMetaPartitionInodeIDStep
 			if err = vol.splitMetaPartition(c, mp, nextStart); err != nil {
@@ -320,7 +367,9 @@ func (vol *Vol) checkMetaPartitions(c *Cluster) {
 			}
 		}
 
-		mp.checkLeader()
+		if mp.checkLeader() {
+			c.events.emit(proto.EventPartitionLostLeader, "", vol.Name, mp.PartitionID, "meta partition lost the leader")
+		}
 		mp.checkReplicaNum(c, vol.Name, vol.mpReplicaNum)
 		mp.checkEnd(c, maxPartitionID)
 		mp.reportMissingReplicas(c.Name, c.leaderInfo.addr, defaultMetaPartitionTimeOutSec, defaultIntervalToAlarmMissingMetaPartition)
@@ -490,6 +539,30 @@ func (vol *Vol) getMetaPartitionsView() (mpViews []*proto.MetaPartitionView) {
 	return
 }
 
+// getMetaPartitionsViewPage returns the views of at most limit meta partitions whose IDs are greater than
+// the marker in the order of ID, 0 limit means no limit.
+func (vol *Vol) getMetaPartitionsViewPage(marker uint64, limit int) (mpViews []*proto.MetaPartitionView) {
+	vol.mpsLock.RLock()
+	partitions := make([]*MetaPartition, 0)
+	for _, mp := range vol.MetaPartitions {
+		if mp.PartitionID > marker {
+			partitions = append(partitions, mp)
+		}
+	}
+	vol.mpsLock.RUnlock()
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].PartitionID < partitions[j].PartitionID
+	})
+	if limit > 0 && len(partitions) > limit {
+		partitions = partitions[:limit]
+	}
+	mpViews = make([]*proto.MetaPartitionView, 0, len(partitions))
+	for _, mp := range partitions {
+		mpViews = append(mpViews, getMetaPartitionView(mp))
+	}
+	return
+}
+
 func (vol *Vol) setMpsCache(body []byte) {
 	vol.Lock()
 	defer vol.Unlock()
@@ -531,6 +604,9 @@ func (vol *Vol) checkStatus(c *Cluster) {
 	if vol.Status != markDelete {
 		return
 	}
+	if vol.isInTrash(c.volDeletionDelay()) {
+		return
+	}
 	log.LogInfof("action[volCheckStatus] vol[%v],status[%v]", vol.Name, vol.Status)
 	metaTasks := vol.getTasksToDeleteMetaPartitions()
 	dataTasks := vol.getTasksToDeleteDataPartitions()
@@ -551,6 +627,12 @@ func (vol *Vol) checkStatus(c *Cluster) {
 	return
 }
 
+// isInTrash returns if the volume is marked deleted and still recoverable, the partitions of the volume are kept
+// until the retention window expires.
+func (vol *Vol) isInTrash(delay uint64) bool {
+	return vol.Status == markDelete && vol.deleteTime > 0 && vol.deleteTime+int64(delay) > time.Now().Unix()
+}
+
 func (vol *Vol) deleteMetaPartitionFromMetaNode(c *Cluster, task *proto.AdminTask) {
 	mp, err := vol.metaPartition(task.PartitionID)
 	if err != nil {
@@ -755,7 +837,12 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 		wg          sync.WaitGroup
 	)
 	errChannel := make(chan error, vol.mpReplicaNum)
-	if hosts, peers, err = c.chooseTargetMetaHosts("", nil, nil, int(vol.mpReplicaNum), vol.crossZone, vol.zoneName); err != nil {
+	if vol.usesPlacementPolicy() {
+		hosts, peers, err = c.choosePlacementHosts(vol, c.metaPlacementNodes(), nil, nil, int(vol.mpReplicaNum))
+	} else {
+		hosts, peers, err = c.chooseTargetMetaHosts("", nil, nil, int(vol.mpReplicaNum), vol.crossZone, vol.zoneName)
+	}
+	if err != nil {
 		log.LogErrorf("action[doCreateMetaPartition] chooseTargetMetaHosts err[%v]", err)
 		return nil, errors.NewError(err)
 	}
@@ -813,14 +900,25 @@ func (vol *Vol) doCreateMetaPartition(c *Cluster, start, end uint64) (mp *MetaPa
 
 func getVolVarargs(vol *Vol) *VolVarargs {
 	return &VolVarargs{
-		zoneName:       vol.zoneName,
-		description:    vol.description,
-		capacity:       vol.Capacity,
-		dpReplicaNum:   vol.dpReplicaNum,
-		followerRead:   vol.FollowerRead,
-		authenticate:   vol.authenticate,
-		enableToken:    vol.enableToken,
-		dpSelectorName: vol.dpSelectorName,
-		dpSelectorParm: vol.dpSelectorParm,
+		zoneName:        vol.zoneName,
+		description:     vol.description,
+		capacity:        vol.Capacity,
+		dpReplicaNum:    vol.dpReplicaNum,
+		followerRead:    vol.FollowerRead,
+		authenticate:    vol.authenticate,
+		enableToken:     vol.enableToken,
+		dpSelectorName:  vol.dpSelectorName,
+		dpSelectorParm:  vol.dpSelectorParm,
+		placementPolicy: vol.placementPolicy,
+		placementZone:   vol.placementZone,
+		placementLabels: vol.placementLabels,
+		qos:             vol.qos,
+		ipAcl:           vol.ipAcl,
+		trashTTL:        vol.trashTTL,
+		metaStore:       vol.metaStore,
+		inodeRetention:  vol.inodeRetention,
+		verifyReadCrc:   vol.verifyReadCrc,
+		compression:     vol.compression,
+		encryptionKeys:  vol.encryptionKeys,
 	}
 }
@@ -0,0 +1,227 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/data/stream"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	cloneVolumeReadSize = 4 * util.MB
+)
+
+// createCloneVol creates the target volume of a clone with the settings of the source volume.
+func (c *Cluster) createCloneVol(srcName, dstName, owner string) (src, dst *Vol, err error) {
+	if src, err = c.getVol(srcName); err != nil {
+		return
+	}
+	if src.authenticate {
+		err = fmt.Errorf("cloning vol[%v] with authentication is not supported", srcName)
+		return
+	}
+	if owner == "" {
+		owner = src.Owner
+	}
+	dst, err = c.createVol(dstName, owner, src.zoneName, fmt.Sprintf("clone of %v", srcName),
+		defaultInitMetaPartitionCount, int(src.dpReplicaNum), int(src.dataPartitionSize/util.GB), int(src.Capacity),
+		src.FollowerRead, false, src.crossZone, src.enableToken, src.caseInsensitive)
+	return
+}
+
+// cloneVolume copies the directory tree, the attributes and the file data of the source volume into the
+// target volume through the meta nodes and data nodes. The files written during the copy may be copied
+// partially, so the source volume should not be written until the task finishes.
+func (c *Cluster) cloneVolume(srcName, dstName string, progress progressFunc) (err error) {
+	var (
+		src, dst *Vol
+		copier   *volumeCopier
+	)
+	if src, err = c.getVol(srcName); err != nil {
+		return
+	}
+	if dst, err = c.getVol(dstName); err != nil {
+		return
+	}
+	total := 0
+	for _, mp := range src.cloneMetaPartitionMap() {
+		total += int(mp.InodeCount)
+	}
+	if copier, err = newVolumeCopier(src.Name, dst.Name, c.masterAddrs(), total, progress); err != nil {
+		return
+	}
+	defer copier.close()
+	if err = copier.copyDir(proto.RootIno, proto.RootIno); err != nil {
+		return
+	}
+	log.LogWarnf("action[cloneVolume] clusterID[%v] vol[%v] is cloned to vol[%v], inodes[%v]",
+		c.Name, srcName, dstName, copier.done)
+	return
+}
+
+// masterAddrs returns the http addresses of all masters, which are used by the clients created in master.
+func (c *Cluster) masterAddrs() (addrs []string) {
+	addrs = make([]string, 0, len(AddrDatabase))
+	for _, addr := range AddrDatabase {
+		addrs = append(addrs, addr)
+	}
+	return
+}
+
+type volumeClient struct {
+	mw *meta.MetaWrapper
+	ec *stream.ExtentClient
+}
+
+func newVolumeClient(volName string, masters []string) (client *volumeClient, err error) {
+	client = &volumeClient{}
+	if client.mw, err = meta.NewMetaWrapper(&meta.MetaConfig{Volume: volName, Masters: masters}); err != nil {
+		return
+	}
+	if client.ec, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            volName,
+		Masters:           masters,
+		OnAppendExtentKey: client.mw.AppendExtentKey,
+		OnGetExtents:      client.mw.GetExtents,
+		OnTruncate:        client.mw.Truncate,
+	}); err != nil {
+		_ = client.mw.Close()
+		return
+	}
+	return
+}
+
+func (client *volumeClient) close() {
+	_ = client.ec.Close()
+	_ = client.mw.Close()
+}
+
+// volumeCopier copies the inodes of a volume into another volume.
+type volumeCopier struct {
+	src, dst *volumeClient
+	links    map[uint64]uint64 // the source inodes with multiple links to the copied inodes
+	buf      []byte
+	done     int
+	total    int
+	progress progressFunc
+}
+
+func newVolumeCopier(srcName, dstName string, masters []string, total int, progress progressFunc) (copier *volumeCopier, err error) {
+	copier = &volumeCopier{
+		links:    make(map[uint64]uint64),
+		buf:      make([]byte, cloneVolumeReadSize),
+		total:    total,
+		progress: progress,
+	}
+	if copier.src, err = newVolumeClient(srcName, masters); err != nil {
+		return
+	}
+	if copier.dst, err = newVolumeClient(dstName, masters); err != nil {
+		copier.src.close()
+		return
+	}
+	return
+}
+
+func (copier *volumeCopier) close() {
+	copier.src.close()
+	copier.dst.close()
+}
+
+func (copier *volumeCopier) copyDir(srcParent, dstParent uint64) (err error) {
+	var dentries []proto.Dentry
+	if dentries, err = copier.src.mw.ReadDir_ll(srcParent); err != nil {
+		return fmt.Errorf("read dir[%v] failed: %v", srcParent, err)
+	}
+	for _, dentry := range dentries {
+		if err = copier.copyDentry(dentry, dstParent); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (copier *volumeCopier) copyDentry(dentry proto.Dentry, dstParent uint64) (err error) {
+	var srcInfo, dstInfo *proto.InodeInfo
+	if srcInfo, err = copier.src.mw.InodeGet_ll(dentry.Inode); err != nil {
+		return fmt.Errorf("get inode[%v] of [%v] failed: %v", dentry.Inode, dentry.Name, err)
+	}
+	if dstInode, ok := copier.links[srcInfo.Inode]; ok {
+		if _, err = copier.dst.mw.Link(dstParent, dentry.Name, dstInode); err != nil {
+			return fmt.Errorf("link [%v] to inode[%v] failed: %v", dentry.Name, dstInode, err)
+		}
+		return
+	}
+	if dstInfo, err = copier.dst.mw.Create_ll(dstParent, dentry.Name, srcInfo.Mode, srcInfo.Uid, srcInfo.Gid, srcInfo.Target); err != nil {
+		return fmt.Errorf("create [%v] in dir[%v] failed: %v", dentry.Name, dstParent, err)
+	}
+	switch {
+	case proto.IsDir(srcInfo.Mode):
+		err = copier.copyDir(srcInfo.Inode, dstInfo.Inode)
+	case proto.IsRegular(srcInfo.Mode):
+		if srcInfo.Nlink > 1 {
+			copier.links[srcInfo.Inode] = dstInfo.Inode
+		}
+		err = copier.copyData(srcInfo, dstInfo.Inode)
+	}
+	if err != nil {
+		return
+	}
+	if err = copier.dst.mw.Setattr(dstInfo.Inode, proto.AttrModifyTime|proto.AttrAccessTime, 0, 0, 0,
+		srcInfo.AccessTime.Unix(), srcInfo.ModifyTime.Unix()); err != nil {
+		return fmt.Errorf("set attributes of [%v] failed: %v", dentry.Name, err)
+	}
+	copier.done++
+	copier.progress(copier.done, copier.total)
+	return
+}
+
+func (copier *volumeCopier) copyData(srcInfo *proto.InodeInfo, dstInode uint64) (err error) {
+	if err = copier.src.ec.OpenStream(srcInfo.Inode); err != nil {
+		return
+	}
+	defer copier.src.ec.CloseStream(srcInfo.Inode)
+	if err = copier.dst.ec.OpenStream(dstInode); err != nil {
+		return
+	}
+	defer copier.dst.ec.CloseStream(dstInode)
+	var offset, read int
+	for offset < int(srcInfo.Size) {
+		size := len(copier.buf)
+		if int(srcInfo.Size)-offset < size {
+			size = int(srcInfo.Size) - offset
+		}
+		if read, err = copier.src.ec.Read(srcInfo.Inode, copier.buf, offset, size); err != nil && err != io.EOF {
+			return fmt.Errorf("read inode[%v] offset[%v] failed: %v", srcInfo.Inode, offset, err)
+		}
+		if read == 0 {
+			break
+		}
+		if _, err = copier.dst.ec.Write(dstInode, offset, copier.buf[:read], 0); err != nil {
+			return fmt.Errorf("write inode[%v] offset[%v] failed: %v", dstInode, offset, err)
+		}
+		offset += read
+	}
+	if err = copier.dst.ec.Flush(dstInode); err != nil {
+		return fmt.Errorf("flush inode[%v] failed: %v", dstInode, err)
+	}
+	return
+}
@@ -0,0 +1,264 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The replication pairs of the volumes are kept in the metadata of the volumes, and the replicators report the
+// progress of the pairs to the leader, which keeps it in memory. The lag of a pair is the time since the start of
+// the last pass finished by the replicator, since the changes made before it are in the standby volume.
+
+const (
+	intervalToCheckReplicationLag = time.Minute
+	defaultReplicationMaxLag      = 3600
+)
+
+type replicationTracker struct {
+	sync.RWMutex
+	status  map[string]*proto.VolReplicationStatus // keyed by the name of the volume
+	lagging map[string]bool                        // the volumes alarmed for lagging behind
+}
+
+func newReplicationTracker() *replicationTracker {
+	return &replicationTracker{
+		status:  make(map[string]*proto.VolReplicationStatus),
+		lagging: make(map[string]bool),
+	}
+}
+
+func (t *replicationTracker) get(volName string) *proto.VolReplicationStatus {
+	t.RLock()
+	defer t.RUnlock()
+	return t.status[volName]
+}
+
+func (t *replicationTracker) put(volName string, status *proto.VolReplicationStatus) {
+	t.Lock()
+	defer t.Unlock()
+	t.status[volName] = status
+}
+
+// forget drops the progress of the volume, such as after the pair is changed.
+func (t *replicationTracker) forget(volName string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.status, volName)
+	delete(t.lagging, volName)
+}
+
+// setLagging returns whether the lagging state of the volume is changed.
+func (t *replicationTracker) setLagging(volName string, lagging bool) bool {
+	t.Lock()
+	defer t.Unlock()
+	if t.lagging[volName] == lagging {
+		return false
+	}
+	if lagging {
+		t.lagging[volName] = true
+	} else {
+		delete(t.lagging, volName)
+	}
+	return true
+}
+
+func (t *replicationTracker) reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.status = make(map[string]*proto.VolReplicationStatus)
+	t.lagging = make(map[string]bool)
+}
+
+// replicationLag returns the seconds the standby volume lags behind, the pair lags since it is created until
+// the first pass is finished.
+func replicationLag(replication *proto.VolReplication, status *proto.VolReplicationStatus, now int64) int64 {
+	if !replication.IsActive() {
+		return 0
+	}
+	synced := replication.CreateTime
+	if status != nil && status.LastSyncTime > synced {
+		synced = status.LastSyncTime
+	}
+	if now < synced {
+		return 0
+	}
+	return now - synced
+}
+
+func (vol *Vol) getReplication() *proto.VolReplication {
+	vol.RLock()
+	defer vol.RUnlock()
+	return vol.replication
+}
+
+func (c *Cluster) volReplicationView(vol *Vol, now int64) *proto.VolReplicationView {
+	view := &proto.VolReplicationView{VolName: vol.Name, Replication: vol.getReplication()}
+	if view.Replication != nil {
+		view.Status = c.replications.get(vol.Name)
+		view.Lag = replicationLag(view.Replication, view.Status, now)
+	}
+	return view
+}
+
+// listVolReplications returns the replications of the volumes sorted by the names of the volumes.
+func (c *Cluster) listVolReplications() (views []*proto.VolReplicationView) {
+	now := time.Now().Unix()
+	views = make([]*proto.VolReplicationView, 0)
+	for _, vol := range c.copyVols() {
+		if vol.Status == markDelete || vol.getReplication() == nil {
+			continue
+		}
+		views = append(views, c.volReplicationView(vol, now))
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].VolName < views[j].VolName })
+	return
+}
+
+func (c *Cluster) getVolToReplicate(name, authKey string) (vol *Vol, err error) {
+	if vol, err = c.getVol(name); err != nil {
+		return nil, proto.ErrVolNotExists
+	}
+	if vol.Status == markDelete {
+		return nil, proto.ErrVolNotExists
+	}
+	if !matchKey(vol.Owner, authKey) {
+		return nil, proto.ErrVolAuthKeyNotMatch
+	}
+	return
+}
+
+func (c *Cluster) updateVolReplication(vol *Vol, replication *proto.VolReplication) (err error) {
+	vol.Lock()
+	defer vol.Unlock()
+	oldReplication := vol.replication
+	vol.replication = replication
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.replication = oldReplication
+		return proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+// setVolReplication replicates the volume to the volume of the standby cluster, or stops the replication if the
+// target volume is empty. The progress is kept if only the max lag is changed.
+func (c *Cluster) setVolReplication(name, authKey string, targetMasters []string, targetVol string, maxLag int64) (view *proto.VolReplicationView, err error) {
+	var vol *Vol
+	if vol, err = c.getVolToReplicate(name, authKey); err != nil {
+		return
+	}
+	var (
+		replication *proto.VolReplication
+		kept        bool
+	)
+	if targetVol != "" {
+		if len(targetMasters) == 0 {
+			return nil, keyNotFound(targetMasterKey)
+		}
+		replication = &proto.VolReplication{
+			TargetMasters: targetMasters,
+			TargetVol:     targetVol,
+			MaxLag:        maxLag,
+			CreateTime:    time.Now().Unix(),
+		}
+		if old := vol.getReplication(); old.IsActive() && old.TargetVol == targetVol &&
+			fmt.Sprint(old.TargetMasters) == fmt.Sprint(targetMasters) {
+			replication.CreateTime, kept = old.CreateTime, true
+		}
+	}
+	if err = c.updateVolReplication(vol, replication); err != nil {
+		return
+	}
+	if !kept {
+		c.replications.forget(name)
+	}
+	log.LogWarnf("action[setVolReplication] vol[%v] replication[%+v]", name, replication)
+	return c.volReplicationView(vol, time.Now().Unix()), nil
+}
+
+// failoverVolReplication stops the replication of the volume, and the standby volume becomes the primary.
+func (c *Cluster) failoverVolReplication(name, authKey string) (view *proto.VolReplicationView, err error) {
+	var vol *Vol
+	if vol, err = c.getVolToReplicate(name, authKey); err != nil {
+		return
+	}
+	old := vol.getReplication()
+	if !old.IsActive() {
+		return nil, fmt.Errorf("vol[%v] is not replicated", name)
+	}
+	replication := *old
+	replication.FailoverTime = time.Now().Unix()
+	if err = c.updateVolReplication(vol, &replication); err != nil {
+		return
+	}
+	c.replications.setLagging(name, false)
+	log.LogWarnf("action[failoverVolReplication] vol[%v] target[%v] masters%v status[%+v]", name,
+		replication.TargetVol, replication.TargetMasters, c.replications.get(name))
+	return c.volReplicationView(vol, time.Now().Unix()), nil
+}
+
+// reportVolReplication records the progress of the replication reported by the replicator, which stops
+// replicating the volume on the error.
+func (c *Cluster) reportVolReplication(name string, status *proto.VolReplicationStatus) (err error) {
+	var vol *Vol
+	if vol, err = c.getVol(name); err != nil {
+		return proto.ErrVolNotExists
+	}
+	if !vol.getReplication().IsActive() {
+		return fmt.Errorf("vol[%v] is not replicated", name)
+	}
+	status.ReportTime = time.Now().Unix()
+	c.replications.put(name, status)
+	return
+}
+
+func (c *Cluster) scheduleToCheckReplicationLag() {
+	go func() {
+		for {
+			if c.partition != nil && c.partition.IsRaftLeader() {
+				c.checkReplicationLag()
+			} else {
+				c.replications.reset()
+			}
+			time.Sleep(intervalToCheckReplicationLag)
+		}
+	}()
+}
+
+func (c *Cluster) checkReplicationLag() {
+	defer func() {
+		if r := recover(); r != nil {
+			log.LogWarnf("checkReplicationLag occurred panic,err[%v]", r)
+			WarnBySpecialKey(fmt.Sprintf("%v_%v_scheduling_job_panic", c.Name, ModuleName),
+				"checkReplicationLag occurred panic")
+		}
+	}()
+	for _, view := range c.listVolReplications() {
+		lagging := view.Replication.IsActive() && view.Replication.MaxLag > 0 && view.Lag > view.Replication.MaxLag
+		if !c.replications.setLagging(view.VolName, lagging) || !lagging {
+			continue
+		}
+		msg := fmt.Sprintf("vol[%v] replicated to vol[%v] of %v lags %vs behind, more than %vs", view.VolName,
+			view.Replication.TargetVol, view.Replication.TargetMasters, view.Lag, view.Replication.MaxLag)
+		Warn(c.Name, msg)
+		c.events.emit(proto.EventReplicationLagging, "", view.VolName, 0, msg)
+	}
+}
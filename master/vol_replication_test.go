@@ -0,0 +1,52 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestReplicationLag(t *testing.T) {
+	replication := &proto.VolReplication{TargetVol: "standby", CreateTime: 1000}
+	if lag := replicationLag(replication, nil, 1300); lag != 300 {
+		t.Errorf("expect the lag since the replication is set, but got %v", lag)
+	}
+	status := &proto.VolReplicationStatus{PassStart: 1250, LastSyncTime: 1200}
+	if lag := replicationLag(replication, status, 1300); lag != 100 {
+		t.Errorf("expect the lag since the last sync, but got %v", lag)
+	}
+	replication.FailoverTime = 1400
+	if lag := replicationLag(replication, status, 1500); lag != 0 {
+		t.Errorf("expect no lag after the failover, but got %v", lag)
+	}
+}
+
+func TestReplicationTracker(t *testing.T) {
+	tracker := newReplicationTracker()
+	tracker.put("vol", &proto.VolReplicationStatus{LastSyncTime: 100})
+	if !tracker.setLagging("vol", true) || tracker.setLagging("vol", true) {
+		t.Errorf("expect the lagging state changed only once")
+	}
+	tracker.forget("vol")
+	if tracker.get("vol") != nil || !tracker.setLagging("vol", true) {
+		t.Errorf("expect the progress and the lagging state forgotten")
+	}
+	tracker.reset()
+	if tracker.setLagging("vol", false) {
+		t.Errorf("expect the lagging state reset")
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package master
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	// the time to wait for the heartbeats of the replicas if the snapshot request to the leader times out
+	volSnapshotWaitTime     = 2 * time.Minute
+	volSnapshotWaitInterval = 5 * time.Second
+)
+
+func (vol *Vol) getSnapshots() []*proto.VolSnapshot {
+	vol.RLock()
+	defer vol.RUnlock()
+	return vol.snapshots
+}
+
+func (vol *Vol) getSnapshot(id uint64) (snapshot *proto.VolSnapshot, err error) {
+	for _, snapshot = range vol.getSnapshots() {
+		if snapshot.ID == id {
+			return
+		}
+	}
+	return nil, fmt.Errorf("snapshot[%v] of vol[%v] not exists", id, vol.Name)
+}
+
+// setSnapshot replaces the snapshot of the same ID, or removes the snapshot with the ID if it is nil.
+func (c *Cluster) setSnapshot(vol *Vol, id uint64, snapshot *proto.VolSnapshot) (err error) {
+	vol.Lock()
+	defer vol.Unlock()
+	snapshots := make([]*proto.VolSnapshot, 0, len(vol.snapshots))
+	for _, s := range vol.snapshots {
+		if s.ID != id {
+			snapshots = append(snapshots, s)
+		} else if snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	oldSnapshots := vol.snapshots
+	vol.snapshots = snapshots
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.snapshots = oldSnapshots
+		return proto.ErrPersistenceByRaft
+	}
+	return
+}
+
+func (c *Cluster) setSnapshotStatus(vol *Vol, snapshot *proto.VolSnapshot, status string) {
+	s := *snapshot
+	s.Status = status
+	if err := c.setSnapshot(vol, s.ID, &s); err != nil {
+		log.LogErrorf("action[setSnapshotStatus] vol[%v] snapshot[%v] status[%v] err[%v]", vol.Name, s.ID, status, err)
+	}
+}
+
+// createVolSnapshot allocates the epoch of the new snapshot, and the meta partitions of the volume store their
+// metadata at the epoch by the returned task in background.
+//
+// The snapshot is not a point in time of the whole volume. The meta partitions take it one after another, each at
+// its own raft index, so a file moved across them or an operation over several of them may be seen half done. The
+// writes should be stopped for a consistent snapshot. The data of the snapshot is kept by the barriers on the servers
+// rather than by the clients: the task freezes all the replicas of the data partitions before the meta partitions
+// take the snapshot, so the extents are no longer overwritten in place, and the meta partitions reject the changes
+// of the extents stamped with the epochs older than their latest snapshot. The snapshot fails if any replica can not
+// be frozen.
+func (c *Cluster) createVolSnapshot(volName, name string) (snapshot *proto.VolSnapshot, task *proto.AsyncTaskInfo, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	if vol.status() == markDelete {
+		err = proto.ErrVolNotExists
+		return
+	}
+	if c.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskVolSnapshot, volName) {
+		err = fmt.Errorf("the snapshot of vol[%v] is being operated, retry later", volName)
+		return
+	}
+	var maxPartitionID uint64
+	for id := range vol.cloneMetaPartitionMap() {
+		if id > maxPartitionID {
+			maxPartitionID = id
+		}
+	}
+	vol.Lock()
+	for _, s := range vol.snapshots {
+		if s.Name == name {
+			vol.Unlock()
+			err = fmt.Errorf("snapshot[%v] of vol[%v] already exists", name, volName)
+			return
+		}
+	}
+	oldSnapshots, oldMaxSnapshotID := vol.snapshots, vol.maxSnapshotID
+	vol.maxSnapshotID++
+	snapshot = &proto.VolSnapshot{
+		ID:                 vol.maxSnapshotID,
+		Name:               name,
+		CreateTime:         time.Now().Unix(),
+		Status:             proto.VolSnapshotCreating,
+		MaxMetaPartitionID: maxPartitionID,
+	}
+	vol.snapshots = append(append([]*proto.VolSnapshot{}, oldSnapshots...), snapshot)
+	if err = c.syncUpdateVol(vol); err != nil {
+		vol.snapshots, vol.maxSnapshotID = oldSnapshots, oldMaxSnapshotID
+		vol.Unlock()
+		err = proto.ErrPersistenceByRaft
+		return
+	}
+	vol.Unlock()
+	log.LogWarnf("action[createVolSnapshot] clusterID[%v] vol[%v] snapshot[%v] name[%v]", c.Name, volName, snapshot.ID, name)
+	task, err = c.asyncTasks.submitVolumeTask(proto.AsyncTaskVolSnapshot, volName, func(progress progressFunc) error {
+		err := c.freezeVolDataPartitions(vol, snapshot.ID)
+		if err == nil {
+			err = c.applyVolSnapshot(vol, snapshot, proto.MetaSnapshotCreate, progress)
+		}
+		if err != nil {
+			c.setSnapshotStatus(vol, snapshot, proto.VolSnapshotFailed)
+		} else {
+			c.setSnapshotStatus(vol, snapshot, proto.VolSnapshotAvailable)
+		}
+		return err
+	})
+	if err != nil {
+		c.setSnapshotStatus(vol, snapshot, proto.VolSnapshotFailed)
+	}
+	return
+}
+
+// freezeVolDataPartitions freezes all the replicas of the data partitions of the volume at the epoch, unless the
+// volume is deleted. The random writes in flight are finished before the replicas reply.
+func (c *Cluster) freezeVolDataPartitions(vol *Vol, epoch uint64) (err error) {
+	if vol.status() == markDelete {
+		return proto.ErrVolNotExists
+	}
+	var failed []uint64
+	for _, dp := range vol.cloneDataPartitionMap() {
+		if e := c.syncDataPartitionSnapshot(dp, epoch, proto.DataSnapshotFreeze); e != nil {
+			log.LogErrorf("action[freezeVolDataPartitions] vol[%v] epoch[%v] data partition[%v] err[%v]",
+				vol.Name, epoch, dp.PartitionID, e)
+			failed = append(failed, dp.PartitionID)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("freeze vol[%v] at epoch[%v] failed on data partitions%v", vol.Name, epoch, failed)
+	}
+	return
+}
+
+// releaseVolDataPartitions releases the data partitions after the volume has no snapshot. The replicas failing to
+// be released stay frozen, the clients write into new extents then.
+func (c *Cluster) releaseVolDataPartitions(vol *Vol) {
+	vol.RLock()
+	epoch := vol.maxSnapshotID
+	vol.RUnlock()
+	for _, dp := range vol.cloneDataPartitionMap() {
+		if err := c.syncDataPartitionSnapshot(dp, epoch, proto.DataSnapshotRelease); err != nil {
+			log.LogErrorf("action[releaseVolDataPartitions] vol[%v] epoch[%v] data partition[%v] err[%v]",
+				vol.Name, epoch, dp.PartitionID, err)
+		}
+	}
+}
+
+// syncDataPartitionSnapshot sends the snapshot operation to all the replicas of the data partition.
+func (c *Cluster) syncDataPartitionSnapshot(dp *DataPartition, epoch uint64, operation string) (err error) {
+	dp.RLock()
+	hosts := append([]string{}, dp.Hosts...)
+	dp.RUnlock()
+	for _, host := range hosts {
+		var dataNode *DataNode
+		if dataNode, err = c.dataNode(host); err != nil {
+			return
+		}
+		task := proto.NewAdminTask(proto.OpDataPartitionSnapshot, host,
+			&proto.DataPartitionSnapshotRequest{PartitionID: dp.PartitionID, SnapshotID: epoch, Operation: operation})
+		dp.resetTaskID(task)
+		if _, err = dataNode.TaskManager.syncSendAdminTask(task); err != nil {
+			return fmt.Errorf("replica[%v]: %v", host, err)
+		}
+	}
+	return
+}
+
+// volFrozenEpoch returns the epoch the new replicas of the volume are frozen at, 0 if the volume has no snapshot.
+func (c *Cluster) volFrozenEpoch(volName string) uint64 {
+	vol, err := c.getVol(volName)
+	if err != nil {
+		return 0
+	}
+	vol.RLock()
+	defer vol.RUnlock()
+	if len(vol.snapshots) == 0 {
+		return 0
+	}
+	return vol.maxSnapshotID
+}
+
+// deleteVolSnapshot deletes the snapshot from the meta partitions by the returned task in background, and the
+// snapshot is removed from the volume after it is deleted from all the meta partitions.
+func (c *Cluster) deleteVolSnapshot(volName string, id uint64) (snapshot *proto.VolSnapshot, task *proto.AsyncTaskInfo, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	if snapshot, err = vol.getSnapshot(id); err != nil {
+		return
+	}
+	if c.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskVolSnapshot, volName) {
+		err = fmt.Errorf("the snapshot of vol[%v] is being operated, retry later", volName)
+		return
+	}
+	deleting := *snapshot
+	deleting.Status = proto.VolSnapshotDeleting
+	if err = c.setSnapshot(vol, id, &deleting); err != nil {
+		return
+	}
+	snapshot = &deleting
+	log.LogWarnf("action[deleteVolSnapshot] clusterID[%v] vol[%v] snapshot[%v] name[%v]", c.Name, volName, id, snapshot.Name)
+	task, err = c.asyncTasks.submitVolumeTask(proto.AsyncTaskVolSnapshot, volName, func(progress progressFunc) error {
+		if err := c.applyVolSnapshot(vol, snapshot, proto.MetaSnapshotDelete, progress); err != nil {
+			return err
+		}
+		if err := c.setSnapshot(vol, id, nil); err != nil {
+			return err
+		}
+		if len(vol.getSnapshots()) == 0 {
+			c.releaseVolDataPartitions(vol)
+		}
+		return nil
+	})
+	return
+}
+
+// rollbackVolSnapshot replaces the metadata of the meta partitions with the snapshot by the returned task in
+// background. All the replicas of the meta partitions in the snapshot must keep the snapshot, otherwise the
+// replicas would be inconsistent after the rollback.
+func (c *Cluster) rollbackVolSnapshot(volName string, id uint64) (snapshot *proto.VolSnapshot, task *proto.AsyncTaskInfo, err error) {
+	var vol *Vol
+	if vol, err = c.getVol(volName); err != nil {
+		return
+	}
+	if snapshot, err = vol.getSnapshot(id); err != nil {
+		return
+	}
+	if snapshot.Status != proto.VolSnapshotAvailable {
+		err = fmt.Errorf("snapshot[%v] of vol[%v] is %v", id, volName, snapshot.Status)
+		return
+	}
+	if c.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskVolSnapshot, volName) {
+		err = fmt.Errorf("the snapshot of vol[%v] is being operated, retry later", volName)
+		return
+	}
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		if mp.PartitionID > snapshot.MaxMetaPartitionID {
+			continue
+		}
+		if addr, ok := mp.replicaLackingSnapshot(id); !ok {
+			err = fmt.Errorf("replica[%v] of meta partition[%v] does not keep snapshot[%v]", addr, mp.PartitionID, id)
+			return
+		}
+	}
+	log.LogWarnf("action[rollbackVolSnapshot] clusterID[%v] vol[%v] snapshot[%v] name[%v]", c.Name, volName, id, snapshot.Name)
+	task, err = c.asyncTasks.submitVolumeTask(proto.AsyncTaskVolSnapshot, volName, func(progress progressFunc) error {
+		return c.applyVolSnapshot(vol, snapshot, proto.MetaSnapshotRollback, progress)
+	})
+	return
+}
+
+// replicaLackingSnapshot returns the first replica which does not report the snapshot in the heartbeat.
+func (mp *MetaPartition) replicaLackingSnapshot(id uint64) (addr string, ok bool) {
+	mp.RLock()
+	defer mp.RUnlock()
+	if len(mp.Replicas) < int(mp.ReplicaNum) {
+		return "", false
+	}
+	for _, mr := range mp.Replicas {
+		var found bool
+		for _, snapshotID := range mr.Snapshots {
+			if snapshotID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return mr.Addr, false
+		}
+	}
+	return "", true
+}
+
+// applyVolSnapshot sends the snapshot operation to the leaders of the meta partitions in the snapshot. The meta
+// partitions failing to apply the operation are skipped, so that the operation can be retried on them.
+func (c *Cluster) applyVolSnapshot(vol *Vol, snapshot *proto.VolSnapshot, operation string, progress progressFunc) (err error) {
+	mps := make([]*MetaPartition, 0)
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		if mp.PartitionID <= snapshot.MaxMetaPartitionID {
+			mps = append(mps, mp)
+		}
+	}
+	sort.Slice(mps, func(i, j int) bool { return mps[i].PartitionID < mps[j].PartitionID })
+	var failed []uint64
+	for i, mp := range mps {
+		if e := c.syncMetaPartitionSnapshot(mp, snapshot.ID, operation); e != nil {
+			log.LogErrorf("action[applyVolSnapshot] vol[%v] snapshot[%v] operation[%v] meta partition[%v] err[%v]",
+				vol.Name, snapshot.ID, operation, mp.PartitionID, e)
+			failed = append(failed, mp.PartitionID)
+		}
+		progress(i+1, len(mps))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%v snapshot[%v] of vol[%v] failed on meta partitions%v", operation, snapshot.ID, vol.Name, failed)
+	}
+	return
+}
+
+// syncMetaPartitionSnapshot sends the snapshot operation to the leader of the meta partition. Storing or removing
+// a large snapshot may outlast the request, in which case the result is checked by the heartbeats of the replicas.
+func (c *Cluster) syncMetaPartitionSnapshot(mp *MetaPartition, id uint64, operation string) (err error) {
+	var leader *MetaReplica
+	mp.RLock()
+	leader, err = mp.getMetaReplicaLeader()
+	mp.RUnlock()
+	if err != nil {
+		return
+	}
+	task := proto.NewAdminTask(proto.OpMetaPartitionSnapshot, leader.Addr,
+		&proto.MetaPartitionSnapshotRequest{PartitionID: mp.PartitionID, SnapshotID: id, Operation: operation})
+	resetMetaPartitionTaskID(task, mp.PartitionID)
+	if _, err = leader.metaNode.Sender.syncSendAdminTask(task); err == nil || operation == proto.MetaSnapshotRollback {
+		return
+	}
+	for deadline := time.Now().Add(volSnapshotWaitTime); time.Now().Before(deadline); {
+		time.Sleep(volSnapshotWaitInterval)
+		all, none := mp.reportSnapshot(id)
+		if (operation == proto.MetaSnapshotCreate && all) || (operation == proto.MetaSnapshotDelete && none) {
+			return nil
+		}
+	}
+	return
+}
+
+// reportSnapshot returns if all the replicas or none of the replicas report the snapshot in the heartbeats.
+func (mp *MetaPartition) reportSnapshot(id uint64) (all, none bool) {
+	mp.RLock()
+	defer mp.RUnlock()
+	all, none = len(mp.Replicas) >= int(mp.ReplicaNum), true
+	for _, mr := range mp.Replicas {
+		var found bool
+		for _, snapshotID := range mr.Snapshots {
+			if snapshotID == id {
+				found = true
+				break
+			}
+		}
+		all = all && found
+		none = none && !found
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package master
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/master/mocktest"
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaPartitionReportSnapshot(t *testing.T) {
+	mp := newMetaPartition(1, 0, 100, 3, "snapshot", 1)
+	mp.Replicas = []*MetaReplica{
+		{Addr: "192.168.0.1:17210", Snapshots: []uint64{1, 2}},
+		{Addr: "192.168.0.2:17210", Snapshots: []uint64{1, 2}},
+	}
+	if all, none := mp.reportSnapshot(1); all || none {
+		t.Errorf("expect snapshot 1 not reported by all the replicas since a replica is missing")
+	}
+	mp.Replicas = append(mp.Replicas, &MetaReplica{Addr: "192.168.0.3:17210", Snapshots: []uint64{1}})
+	if all, none := mp.reportSnapshot(1); !all || none {
+		t.Errorf("expect snapshot 1 reported by all the replicas")
+	}
+	if all, none := mp.reportSnapshot(3); all || !none {
+		t.Errorf("expect snapshot 3 reported by none of the replicas")
+	}
+	if addr, ok := mp.replicaLackingSnapshot(2); ok || addr != "192.168.0.3:17210" {
+		t.Errorf("expect replica 192.168.0.3:17210 lacking snapshot 2, but got %v %v", addr, ok)
+	}
+}
+
+func TestVolSnapshotBarrier(t *testing.T) {
+	// the vol is created on the nodes of its own zone, so it does not depend on the nodes left by the other tests
+	zone := "zone_snapshot"
+	dataServers := make([]*mocktest.MockDataServer, 0)
+	for _, addr := range []string{"127.0.0.1:9121", "127.0.0.1:9122", "127.0.0.1:9123"} {
+		mds := mocktest.NewMockDataServer(addr, zone)
+		mds.Start()
+		dataServers = append(dataServers, mds)
+	}
+	for _, addr := range []string{"127.0.0.1:8121", "127.0.0.1:8122", "127.0.0.1:8123"} {
+		mocktest.NewMockMetaServer(addr, zone).Start()
+	}
+	server.cluster.checkDataNodeHeartbeat()
+	server.cluster.checkMetaNodeHeartbeat()
+	time.Sleep(5 * time.Second)
+	name := "snapshot_barrier"
+	process(fmt.Sprintf("%v%v?name=%v&replicas=3&type=extent&capacity=100&owner=cfs&mpCount=2&zoneName=%v",
+		hostAddr, proto.AdminCreateVol, name, zone), t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vol.cloneDataPartitionMap()) == 0 {
+		t.Fatalf("expect the data partitions of vol %v created", name)
+	}
+	// the snapshot is sent to the leaders of the meta partitions, which are reported by the heartbeats
+	server.cluster.checkMetaNodeHeartbeat()
+	for _, mp := range vol.cloneMetaPartitionMap() {
+		for i := 0; ; i++ {
+			mp.RLock()
+			_, err = mp.getMetaReplicaLeader()
+			mp.RUnlock()
+			if err == nil {
+				break
+			}
+			if i == 30 {
+				t.Fatalf("expect the leader of meta partition %v reported, but got %v", mp.PartitionID, err)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+	// checkBarrier checks the latest operation sent to all the replicas of the data partitions
+	checkBarrier := func(epoch uint64, operation string) {
+		for _, dp := range vol.cloneDataPartitionMap() {
+			for _, mds := range dataServers {
+				if req := mds.DataPartitionSnapshot(dp.PartitionID); req == nil || req.SnapshotID != epoch || req.Operation != operation {
+					t.Errorf("expect data partition %v %v at epoch %v on %v, but got %+v",
+						dp.PartitionID, operation, epoch, mds.TcpAddr, req)
+				}
+			}
+		}
+	}
+	waitTask := func() {
+		for i := 0; server.cluster.asyncTasks.isVolumeTaskRunning(proto.AsyncTaskVolSnapshot, name); i++ {
+			if i == 60 {
+				t.Fatal("expect the snapshot task of vol finished")
+			}
+			time.Sleep(time.Second)
+		}
+	}
+
+	snapshot, _, err := server.cluster.createVolSnapshot(name, "barrier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view := newSimpleView(vol); view.SnapshotCount != 1 || view.SnapshotEpoch != snapshot.ID {
+		t.Errorf("expect the snapshot in the view of the vol, but got count %v epoch %v", view.SnapshotCount, view.SnapshotEpoch)
+	}
+	waitTask()
+	if s, err := vol.getSnapshot(snapshot.ID); err != nil || s.Status != proto.VolSnapshotAvailable {
+		t.Errorf("expect the snapshot available, but got %v %v", s, err)
+	}
+	// the replicas are frozen before the meta partitions take the snapshot
+	checkBarrier(snapshot.ID, proto.DataSnapshotFreeze)
+	if epoch := server.cluster.volFrozenEpoch(name); epoch != snapshot.ID {
+		t.Errorf("expect the new replicas frozen at epoch %v, but got %v", snapshot.ID, epoch)
+	}
+
+	// the replicas are released after the vol has no snapshot
+	if _, _, err = server.cluster.deleteVolSnapshot(name, snapshot.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitTask()
+	if len(vol.getSnapshots()) != 0 {
+		t.Errorf("expect the snapshot deleted, but got %v", vol.getSnapshots())
+	}
+	checkBarrier(snapshot.ID, proto.DataSnapshotRelease)
+	if epoch := server.cluster.volFrozenEpoch(name); epoch != 0 {
+		t.Errorf("expect the new replicas not frozen, but got epoch %v", epoch)
+	}
+
+	// no snapshot is taken of the vol deleted
+	markDeleteVol(name, t)
+	if _, _, err = server.cluster.createVolSnapshot(name, "deleted"); err != proto.ErrVolNotExists {
+		t.Errorf("expect the snapshot of the deleted vol refused, but got %v", err)
+	}
+}
@@ -64,6 +64,41 @@ func TestVol(t *testing.T) {
 	vol.deleteVolFromStore(server.cluster)
 }
 
+func TestVolRestore(t *testing.T) {
+	name := "restore"
+	createVol(name, t)
+	process(fmt.Sprintf("%v%v?volDeletionDelay=3600", hostAddr, proto.AdminSetNodeInfo), t)
+	defer process(fmt.Sprintf("%v%v?volDeletionDelay=0", hostAddr, proto.AdminSetNodeInfo), t)
+	markDeleteVol(name, t)
+	vol, err := server.cluster.getVol(name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !vol.isInTrash(server.cluster.volDeletionDelay()) {
+		t.Errorf("expect vol[%v] in the trash", name)
+		return
+	}
+	// the partitions are kept since the retention window does not expire
+	vol.checkStatus(server.cluster)
+	if _, err = server.cluster.getVol(name); err != nil {
+		t.Errorf("expect vol[%v] kept in the trash, but got %v", name, err)
+		return
+	}
+	reqURL := fmt.Sprintf("%v%v?name=%v&authKey=%v", hostAddr, proto.AdminRestoreVol, name, buildAuthKey("cfs"))
+	fmt.Println(reqURL)
+	process(reqURL, t)
+	if vol.Status != normal || vol.deleteTime != 0 {
+		t.Errorf("restoreVol failed,expect status[%v],real status[%v] deleteTime[%v]", normal, vol.Status, vol.deleteTime)
+	}
+	markDeleteVol(name, t)
+	vol.deleteTime -= 3600
+	if vol.isInTrash(server.cluster.volDeletionDelay()) {
+		t.Errorf("expect vol[%v] purged after the retention window expires", name)
+	}
+	vol.checkStatus(server.cluster)
+}
+
 func createVol(name string, t *testing.T) {
 	reqURL := fmt.Sprintf("%v%v?name=%v&replicas=3&type=extent&capacity=100&owner=cfs&mpCount=2&zoneName=%v", hostAddr, proto.AdminCreateVol, name, testZone2)
 	fmt.Println(reqURL)
@@ -17,11 +17,11 @@ package metanode
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
 	"strconv"
 
-	"bytes"
-
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
 )
@@ -52,13 +52,32 @@ func (m *MetaNode) registerAPIHandler() (err error) {
 	http.HandleFunc("/getPartitionById", m.getPartitionByIDHandler)
 	http.HandleFunc("/getInode", m.getInodeHandler)
 	http.HandleFunc("/getExtentsByInode", m.getExtentsByInodeHandler)
-	// get all inodes of the partitionID
-	http.HandleFunc("/getAllInodes", m.getAllInodesHandler)
+	http.HandleFunc("/getXAttrs", m.getXAttrsHandler)
+	http.HandleFunc("/getACL", m.getACLHandler)
+	http.HandleFunc("/getLocks", m.getLocksHandler)
+	http.HandleFunc("/getSummary", m.getSummaryHandler)
+	http.HandleFunc("/getEvents", m.getEventsHandler)
+	http.HandleFunc("/getOrphanReport", m.getOrphanReportHandler)
+	http.HandleFunc("/getReplicaCheck", m.getReplicaCheckHandler)
+	http.HandleFunc("/checkNLink", m.checkNLinkHandler)
+	http.HandleFunc("/getNLinkCheck", m.getNLinkCheckHandler)
+	http.HandleFunc("/getRaftStatus", m.getRaftStatusHandler)
+	http.HandleFunc("/getDeletedInodes", m.getDeletedInodesHandler)
+	http.HandleFunc("/purgeInode", m.purgeInodeHandler)
+	http.HandleFunc("/cancelPurge", m.cancelPurgeHandler)
+	http.HandleFunc("/getTransactions", m.getTransactionsHandler)
+	http.HandleFunc("/exportPartition", m.exportPartitionHandler)
+	http.HandleFunc("/importPartition", m.importPartitionHandler)
+	http.HandleFunc("/dumpInodes", m.dumpInodesHandler)
+	http.HandleFunc("/dumpDentries", m.dumpDentriesHandler)
+	// get all inodes of the partitionID, kept for the old tools
+	http.HandleFunc("/getAllInodes", m.dumpInodesHandler)
 	// get dentry information
 	http.HandleFunc("/getDentry", m.getDentryHandler)
 	http.HandleFunc("/getDirectory", m.getDirectoryHandler)
 	http.HandleFunc("/getAllDentry", m.getAllDentriesHandler)
 	http.HandleFunc("/getParams", m.getParamsHandler)
+	http.HandleFunc("/getMemoryUsage", m.getMemoryUsageHandler)
 	return
 }
 
@@ -74,6 +93,16 @@ func (m *MetaNode) getParamsHandler(w http.ResponseWriter,
 	}
 }
 
+// getMemoryUsageHandler replies the estimated memory used by the partitions, the largest first.
+func (m *MetaNode) getMemoryUsageHandler(w http.ResponseWriter, r *http.Request) {
+	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
+	resp.Data = m.metadataManager.MemoryUsage()
+	data, _ := resp.Marshal()
+	if _, err := w.Write(data); err != nil {
+		log.LogErrorf("[getMemoryUsageHandler] response %s", err)
+	}
+}
+
 func (m *MetaNode) getPartitionsHandler(w http.ResponseWriter,
 	r *http.Request) {
 	resp := NewAPIResponse(http.StatusOK, http.StatusText(http.StatusOK))
@@ -111,65 +140,93 @@ func (m *MetaNode) getPartitionByIDHandler(w http.ResponseWriter, r *http.Reques
 	msg["peers"] = conf.Peers
 	msg["nodeId"] = conf.NodeId
 	msg["cursor"] = conf.Cursor
+	msg["inodeCount"] = uint64(mp.GetInodeTree().Len())
+	msg["dentryCount"] = uint64(mp.GetDentryTree().Len())
+	msg["applyID"] = mp.GetAppliedID()
+	if status := mp.GetRaftStatus(); status != nil {
+		msg["raftStatus"] = status
+	}
 	resp.Data = msg
 	resp.Code = http.StatusOK
 	resp.Msg = http.StatusText(http.StatusOK)
 }
 
-func (m *MetaNode) getAllInodesHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
-
-	defer func() {
+// dumpInodesHandler streams the inodes of the meta partition matching the filter as JSON lines in the order of
+// their IDs. The response is chunked, so the large partitions can be dumped without holding them in memory.
+func (m *MetaNode) dumpInodesHandler(w http.ResponseWriter, r *http.Request) {
+	mp, filter, ok := m.parseMetaDumpRequest(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	dw := newMetaDumpWriter(w)
+	count := filter.dumpInodes(mp.GetInodeTree(), func(inode *Inode) bool {
+		data, err := inode.MarshalToJSON()
 		if err != nil {
-			msg := fmt.Sprintf("[getAllInodesHandler] err(%v)", err)
-			if _, e := w.Write([]byte(msg)); e != nil {
-				log.LogErrorf("[getAllInodesHandler] failed to write response: err(%v) msg(%v)", e, msg)
-			}
+			dw.err = err
+			return false
 		}
-	}()
-
-	if err = r.ParseForm(); err != nil {
-		return
+		return dw.write(data, []byte{'\n'})
+	})
+	if dw.err == nil {
+		dw.flush()
 	}
-	id, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
-	if err != nil {
+	if dw.err != nil {
+		log.LogErrorf("[dumpInodesHandler] pid(%v) inodes(%v) err(%v)", r.FormValue("pid"), count, dw.err)
 		return
 	}
-	mp, err := m.metadataManager.GetPartition(id)
-	if err != nil {
+	log.LogInfof("[dumpInodesHandler] pid(%v) inodes(%v)", r.FormValue("pid"), count)
+}
+
+// dumpDentriesHandler streams the dentries of the meta partition matching the filter as JSON lines in the order of
+// their parents and names.
+func (m *MetaNode) dumpDentriesHandler(w http.ResponseWriter, r *http.Request) {
+	mp, filter, ok := m.parseMetaDumpRequest(w, r)
+	if !ok {
 		return
 	}
-
-	var inode *Inode
-
-	f := func(i BtreeItem) bool {
-		var (
-			data []byte
-			e    error
-		)
-
-		if inode != nil {
-			if _, e = w.Write([]byte("\n")); e != nil {
-				log.LogErrorf("[getAllInodesHandler] failed to write response: %v", e)
-				return false
-			}
-		}
-
-		inode = i.(*Inode)
-		if data, e = inode.MarshalToJSON(); e != nil {
-			log.LogErrorf("[getAllInodesHandler] failed to marshal to json: %v", e)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	dw := newMetaDumpWriter(w)
+	count := filter.dumpDentries(mp.GetDentryTree(), func(dentry *Dentry) bool {
+		data, err := json.Marshal(dentry)
+		if err != nil {
+			dw.err = err
 			return false
 		}
+		return dw.write(data, []byte{'\n'})
+	})
+	if dw.err == nil {
+		dw.flush()
+	}
+	if dw.err != nil {
+		log.LogErrorf("[dumpDentriesHandler] pid(%v) dentries(%v) err(%v)", r.FormValue("pid"), count, dw.err)
+		return
+	}
+	log.LogInfof("[dumpDentriesHandler] pid(%v) dentries(%v)", r.FormValue("pid"), count)
+}
 
-		if _, e = w.Write(data); e != nil {
-			log.LogErrorf("[getAllInodesHandler] failed to write response: %v", e)
-			return false
+// parseMetaDumpRequest returns the meta partition and the filter of the dump request, or replies the error.
+func (m *MetaNode) parseMetaDumpRequest(w http.ResponseWriter, r *http.Request) (mp MetaPartition,
+	filter *metaDumpFilter, ok bool) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err == nil {
+		filter, err = parseMetaDumpFilter(r)
+	}
+	if err == nil {
+		if mp, err = m.metadataManager.GetPartition(pid); err != nil {
+			resp.Code = http.StatusNotFound
 		}
-
-		return true
 	}
-
-	mp.GetInodeTree().Ascend(f)
+	if err != nil {
+		resp.Msg = err.Error()
+		data, _ := resp.Marshal()
+		w.WriteHeader(resp.Code)
+		w.Write(data)
+		return nil, nil, false
+	}
+	return mp, filter, true
 }
 
 func (m *MetaNode) getInodeHandler(w http.ResponseWriter, r *http.Request) {
@@ -201,6 +258,12 @@ func (m *MetaNode) getInodeHandler(w http.ResponseWriter, r *http.Request) {
 		PartitionID: pid,
 		Inode:       id,
 	}
+	if value := r.FormValue("snapshot"); value != "" {
+		if req.SnapshotID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
 	p := &Packet{}
 	err = mp.InodeGet(req, p)
 	if err != nil {
@@ -260,6 +323,438 @@ func (m *MetaNode) getExtentsByInodeHandler(w http.ResponseWriter,
 	return
 }
 
+// getXAttrsHandler replies all the extended attributes of the inode.
+func (m *MetaNode) getXAttrsHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getXAttrsHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	id, err := strconv.ParseUint(r.FormValue("ino"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetAllXAttrs(id)
+}
+
+// getACLHandler replies the owner, the mode and the POSIX ACLs of the inode.
+func (m *MetaNode) getACLHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getACLHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	id, err := strconv.ParseUint(r.FormValue("ino"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	info := mp.GetACL(id)
+	if info == nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = fmt.Sprintf("inode[%v] not found", id)
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = info
+}
+
+// getLocksHandler replies the file locks of the inode, or the ones of all the inodes if the inode is not given.
+func (m *MetaNode) getLocksHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getLocksHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	var id uint64
+	if ino := r.FormValue("ino"); ino != "" {
+		if id, err = strconv.ParseUint(ino, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetLocks(id)
+}
+
+// getSummaryHandler replies the summary of the directory.
+func (m *MetaNode) getSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getSummaryHandler] response %s", err)
+		}
+	}()
+	var (
+		pid uint64
+		ino uint64
+		err error
+	)
+	if pid, err = strconv.ParseUint(r.FormValue("pid"), 10, 64); err == nil {
+		ino, err = strconv.ParseUint(r.FormValue("ino"), 10, 64)
+	}
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	summary := mp.GetSummary(ino)
+	if summary == nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = fmt.Sprintf("directory %v not found", ino)
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = summary
+}
+
+// getEventsHandler replies the change events of the meta partition after the sequence.
+func (m *MetaNode) getOrphanReportHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getOrphanReportHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetOrphanReport()
+}
+
+// getReplicaCheckHandler replies the result of the latest replica check of the meta partition, which is null if
+// the replica is not the leader or has not checked yet.
+func (m *MetaNode) getReplicaCheckHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getReplicaCheckHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetReplicaCheckReport()
+}
+
+// checkNLinkHandler starts checking the link counts of the inodes of the meta partition in background, and fixes
+// the mismatches if fix is true.
+func (m *MetaNode) checkNLinkHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[checkNLinkHandler] response %s", err)
+		}
+	}()
+	mp, err := m.getLeaderPartition(r)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	var (
+		fix   bool
+		limit int
+	)
+	if value := r.FormValue("fix"); value != "" {
+		if fix, err = strconv.ParseBool(value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	if value := r.FormValue("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	if err = mp.StartNLinkCheck(fix, limit); err != nil {
+		resp.Code = http.StatusConflict
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetNLinkCheckReport()
+}
+
+// getNLinkCheckHandler replies the progress or the result of the latest link count check of the meta partition.
+func (m *MetaNode) getNLinkCheckHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getNLinkCheckHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetNLinkCheckReport()
+}
+
+// getRaftStatusHandler replies the raft progress of the replica of the meta partition, with the lag of the other
+// replicas if it is the leader.
+func (m *MetaNode) getRaftStatusHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getRaftStatusHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetRaftProgress()
+}
+
+// getDeletedInodesHandler replies the deleted files of the meta partition which are not purged yet.
+func (m *MetaNode) getDeletedInodesHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getDeletedInodesHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	var marker uint64
+	if value := r.FormValue("marker"); value != "" {
+		if marker, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	var limit int
+	if value := r.FormValue("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.ListDeletedInodes(marker, limit)
+}
+
+// purgeInodeHandler purges the deleted file without waiting for the retention of the volume.
+func (m *MetaNode) purgeInodeHandler(w http.ResponseWriter, r *http.Request) {
+	m.changePurgeHandler(w, r, MetaPartition.PurgeInode)
+}
+
+// cancelPurgeHandler holds the deleted file from being purged, so that it can be linked again.
+func (m *MetaNode) cancelPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	m.changePurgeHandler(w, r, MetaPartition.CancelPurge)
+}
+
+func (m *MetaNode) changePurgeHandler(w http.ResponseWriter, r *http.Request, change func(MetaPartition, uint64) error) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[changePurgeHandler] response %s", err)
+		}
+	}()
+	mp, err := m.getLeaderPartition(r)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	ino, err := strconv.ParseUint(r.FormValue("ino"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if err = change(mp, ino); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+}
+
+func (m *MetaNode) getTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getTransactionsHandler] response %s", err)
+		}
+	}()
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.GetTransactions()
+}
+
+func (m *MetaNode) getEventsHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[getEventsHandler] response %s", err)
+		}
+	}()
+	var (
+		pid   uint64
+		from  uint64
+		limit int
+		err   error
+	)
+	if pid, err = strconv.ParseUint(r.FormValue("pid"), 10, 64); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if value := r.FormValue("from"); value != "" {
+		if from, err = strconv.ParseUint(value, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	if value := r.FormValue("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+	mp, err := m.metadataManager.GetPartition(pid)
+	if err != nil {
+		resp.Code = http.StatusNotFound
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = mp.ReadEvents(from, limit)
+}
+
 func (m *MetaNode) getDentryHandler(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	name := r.FormValue("name")
@@ -302,6 +797,9 @@ func (m *MetaNode) getDentryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp.Code = http.StatusSeeOther
+	if p.ResultCode == proto.OpOk {
+		resp.Code = http.StatusOK
+	}
 	resp.Msg = p.GetResultMsg()
 	if len(p.Data) > 0 {
 		resp.Data = json.RawMessage(p.Data)
@@ -310,65 +808,40 @@ func (m *MetaNode) getDentryHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// getAllDentriesHandler replies the dentries of the meta partition matching the filter in a single JSON response,
+// it is kept for the old tools and streams the dentries as dumpDentriesHandler.
 func (m *MetaNode) getAllDentriesHandler(w http.ResponseWriter, r *http.Request) {
-	r.ParseForm()
-	resp := NewAPIResponse(http.StatusSeeOther, "")
-	shouldSkip := false
-	defer func() {
-		if !shouldSkip {
-			data, _ := resp.Marshal()
-			if _, err := w.Write(data); err != nil {
-				log.LogErrorf("[getAllDentriesHandler] response %s", err)
-			}
-		}
-	}()
-	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
-	if err != nil {
-		resp.Code = http.StatusBadRequest
-		resp.Msg = err.Error()
-		return
-	}
-	mp, err := m.metadataManager.GetPartition(pid)
-	if err != nil {
-		resp.Code = http.StatusNotFound
-		resp.Msg = err.Error()
+	mp, filter, ok := m.parseMetaDumpRequest(w, r)
+	if !ok {
 		return
 	}
-	buff := bytes.NewBufferString(`{"code": 200, "msg": "OK", "data":[`)
-	if _, err := w.Write(buff.Bytes()); err != nil {
+	dw := newMetaDumpWriter(w)
+	if !dw.write([]byte(`{"code": 200, "msg": "OK", "data":[`)) {
 		return
 	}
-	buff.Reset()
 	var (
-		val       []byte
 		delimiter = []byte{',', '\n'}
 		isFirst   = true
 	)
-	mp.GetDentryTree().Ascend(func(i BtreeItem) bool {
-		if !isFirst {
-			if _, err = w.Write(delimiter); err != nil {
-				return false
-			}
-		} else {
-			isFirst = false
-		}
-		val, err = json.Marshal(i)
+	count := filter.dumpDentries(mp.GetDentryTree(), func(dentry *Dentry) bool {
+		val, err := json.Marshal(dentry)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(err.Error()))
+			dw.err = err
 			return false
 		}
-		if _, err = w.Write(val); err != nil {
-			return false
+		if isFirst {
+			isFirst = false
+			return dw.write(val)
 		}
-		return true
+		return dw.write(delimiter, val)
 	})
-	shouldSkip = true
-	buff.WriteString(`]}`)
-	if _, err = w.Write(buff.Bytes()); err != nil {
-		log.LogErrorf("[getAllDentriesHandler] response %s", err)
+	if dw.err == nil && dw.write([]byte(`]}`)) {
+		dw.flush()
+	}
+	// the response is broken, the client finds the JSON truncated
+	if dw.err != nil {
+		log.LogErrorf("[getAllDentriesHandler] pid(%v) dentries(%v) err(%v)", r.FormValue("pid"), count, dw.err)
 	}
-	return
 }
 
 func (m *MetaNode) getDirectoryHandler(w http.ResponseWriter, r *http.Request) {
@@ -399,6 +872,13 @@ func (m *MetaNode) getDirectoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	req := ReadDirReq{
 		ParentID: pIno,
+		Marker:   r.FormValue("marker"),
+	}
+	if limit := r.FormValue("limit"); limit != "" {
+		if req.Limit, err = strconv.ParseUint(limit, 10, 64); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
 	}
 	p := &Packet{}
 	if err = mp.ReadDir(&req, p); err != nil {
@@ -413,3 +893,106 @@ func (m *MetaNode) getDirectoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	return
 }
+
+// getLeaderPartition returns the meta partition of the request, the local replica must be the leader.
+func (m *MetaNode) getLeaderPartition(r *http.Request) (mp MetaPartition, err error) {
+	pid, err := strconv.ParseUint(r.FormValue("pid"), 10, 64)
+	if err != nil {
+		return
+	}
+	if mp, err = m.metadataManager.GetPartition(pid); err != nil {
+		return
+	}
+	if leader, isLeader := mp.IsLeader(); !isLeader {
+		err = fmt.Errorf("partition %v is not the leader, leader is %v", pid, leader)
+	}
+	return
+}
+
+// exportPartitionHandler streams a consistent snapshot of the meta partition in the response, or writes it to the
+// local file of the path.
+func (m *MetaNode) exportPartitionHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	mp, err := m.getLeaderPartition(r)
+	if err != nil {
+		resp.Msg = err.Error()
+		data, _ := resp.Marshal()
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(data)
+		return
+	}
+	filePath := r.FormValue("path")
+	if filePath == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if _, err = mp.ExportSnapshot(w); err != nil {
+			// the response is broken, the client finds the snapshot truncated
+			log.LogErrorf("[exportPartitionHandler] pid(%v) err(%v)", r.FormValue("pid"), err)
+		}
+		return
+	}
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[exportPartitionHandler] response %s", err)
+		}
+	}()
+	var (
+		f      *os.File
+		header *proto.MetaPartitionExportHeader
+	)
+	if f, err = os.OpenFile(filePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if header, err = mp.ExportSnapshot(f); err == nil {
+		err = f.Sync()
+	}
+	f.Close()
+	if err != nil {
+		os.Remove(filePath)
+		resp.Code = http.StatusInternalServerError
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = header
+}
+
+// importPartitionHandler imports the snapshot in the request body, or in the local file of the path, into the
+// meta partition.
+func (m *MetaNode) importPartitionHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	resp := NewAPIResponse(http.StatusBadRequest, "")
+	defer func() {
+		data, _ := resp.Marshal()
+		if _, err := w.Write(data); err != nil {
+			log.LogErrorf("[importPartitionHandler] response %s", err)
+		}
+	}()
+	mp, err := m.getLeaderPartition(r)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	var reader io.Reader = r.Body
+	if filePath := r.FormValue("path"); filePath != "" {
+		var f *os.File
+		if f, err = os.Open(filePath); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		defer f.Close()
+		reader = f
+	}
+	header, result, err := mp.ImportSnapshot(reader)
+	if err != nil {
+		resp.Code = http.StatusInternalServerError
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Code = http.StatusOK
+	resp.Msg = http.StatusText(http.StatusOK)
+	resp.Data = map[string]interface{}{"header": header, "result": result}
+}
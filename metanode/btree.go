@@ -26,10 +26,12 @@ type (
 	BtreeItem = btree.Item
 )
 
-// BTree is the wrapper of Google's btree.
+// BTree is the wrapper of Google's btree. The items of the tree with a store are kept in the RocksDB, and the
+// btree only caches some of them.
 type BTree struct {
 	sync.RWMutex
-	tree *btree.BTree
+	tree  *btree.BTree
+	store *treeStore
 }
 
 // NewBtree creates a new btree.
@@ -41,6 +43,9 @@ func NewBtree() *BTree {
 
 // Get returns the object of the given key in the btree.
 func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
+	if b.store != nil {
+		return b.storeGet(key)
+	}
 	b.RLock()
 	item = b.tree.Get(key)
 	b.RUnlock()
@@ -48,6 +53,9 @@ func (b *BTree) Get(key BtreeItem) (item BtreeItem) {
 }
 
 func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
+	if b.store != nil {
+		return b.storeGet(key)
+	}
 	b.Lock()
 	item = b.tree.CopyGet(key)
 	b.Unlock()
@@ -56,9 +64,7 @@ func (b *BTree) CopyGet(key BtreeItem) (item BtreeItem) {
 
 // Find searches for the given key in the btree.
 func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
-	b.RLock()
-	item := b.tree.Get(key)
-	b.RUnlock()
+	item := b.Get(key)
 	if item == nil {
 		return
 	}
@@ -66,6 +72,10 @@ func (b *BTree) Find(key BtreeItem, fn func(i BtreeItem)) {
 }
 
 func (b *BTree) CopyFind(key BtreeItem, fn func(i BtreeItem)) {
+	if b.store != nil {
+		fn(b.storeGet(key))
+		return
+	}
 	b.Lock()
 	item := b.tree.CopyGet(key)
 	fn(item)
@@ -74,6 +84,9 @@ func (b *BTree) CopyFind(key BtreeItem, fn func(i BtreeItem)) {
 
 // Has checks if the key exists in the btree.
 func (b *BTree) Has(key BtreeItem) (ok bool) {
+	if b.store != nil {
+		return b.storeGet(key) != nil
+	}
 	b.RLock()
 	ok = b.tree.Has(key)
 	b.RUnlock()
@@ -82,20 +95,33 @@ func (b *BTree) Has(key BtreeItem) (ok bool) {
 
 // Delete deletes the object by the given key.
 func (b *BTree) Delete(key BtreeItem) (item BtreeItem) {
+	if b.store != nil {
+		return b.storeDelete(key, nil)
+	}
 	b.Lock()
 	item = b.tree.Delete(key)
 	b.Unlock()
 	return
 }
 
-func (b *BTree) Execute(fn func(tree *btree.BTree) interface{}) interface{} {
+// DeleteIf deletes the object by the given key if it is accepted.
+func (b *BTree) DeleteIf(key BtreeItem, accept func(item BtreeItem) bool) (item BtreeItem) {
+	if b.store != nil {
+		return b.storeDelete(key, accept)
+	}
 	b.Lock()
 	defer b.Unlock()
-	return fn(b.tree)
+	if item = b.tree.Get(key); item == nil || !accept(item) {
+		return nil
+	}
+	return b.tree.Delete(key)
 }
 
 // ReplaceOrInsert is the wrapper of google's btree ReplaceOrInsert.
 func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok bool) {
+	if b.store != nil {
+		return b.storeReplaceOrInsert(key, replace)
+	}
 	b.Lock()
 	if replace {
 		item = b.tree.ReplaceOrInsert(key)
@@ -116,10 +142,21 @@ func (b *BTree) ReplaceOrInsert(key BtreeItem, replace bool) (item BtreeItem, ok
 	return
 }
 
+// Update writes the object modified in place back to the store, nothing is done for the tree without a store.
+func (b *BTree) Update(item BtreeItem) {
+	if b.store != nil {
+		b.storeUpdate(item)
+	}
+}
+
 // Ascend is the wrapper of the google's btree Ascend.
 // This function scans the entire btree. When the data is huge, it is not recommended to use this function online.
 // Instead, it is recommended to call GetTree to obtain the snapshot of the current btree, and then do the scan on the snapshot.
 func (b *BTree) Ascend(fn func(i BtreeItem) bool) {
+	if b.store != nil {
+		b.storeAscend(b.store.prefix, b.store.end, fn)
+		return
+	}
 	b.RLock()
 	b.tree.Ascend(fn)
 	b.RUnlock()
@@ -127,6 +164,10 @@ func (b *BTree) Ascend(fn func(i BtreeItem) bool) {
 
 // AscendRange is the wrapper of the google's btree AscendRange.
 func (b *BTree) AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i BtreeItem) bool) {
+	if b.store != nil {
+		b.storeAscend(b.store.key(greaterOrEqual), b.store.key(lessThan), iterator)
+		return
+	}
 	b.RLock()
 	b.tree.AscendRange(greaterOrEqual, lessThan, iterator)
 	b.RUnlock()
@@ -134,6 +175,10 @@ func (b *BTree) AscendRange(greaterOrEqual, lessThan BtreeItem, iterator func(i
 
 // AscendGreaterOrEqual is the wrapper of the google's btree AscendGreaterOrEqual
 func (b *BTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem) bool) {
+	if b.store != nil {
+		b.storeAscend(b.store.key(pivot), b.store.end, iterator)
+		return
+	}
 	b.RLock()
 	b.tree.AscendGreaterOrEqual(pivot, iterator)
 	b.RUnlock()
@@ -141,6 +186,9 @@ func (b *BTree) AscendGreaterOrEqual(pivot BtreeItem, iterator func(i BtreeItem)
 
 // GetTree returns the snapshot of a btree.
 func (b *BTree) GetTree() *BTree {
+	if b.store != nil {
+		return b.storeSnapshot()
+	}
 	b.Lock()
 	t := b.tree.Clone()
 	b.Unlock()
@@ -151,23 +199,51 @@ func (b *BTree) GetTree() *BTree {
 
 // Reset resets the current btree.
 func (b *BTree) Reset() {
+	if b.store != nil {
+		b.storeReset()
+		return
+	}
 	b.Lock()
 	b.tree.Clear(true)
 	b.Unlock()
 }
 
+// Release deletes the items of the tree from the store once the tree is replaced, the snapshots of the tree are
+// not affected. The tree without a store is left to the garbage collector.
+func (b *BTree) Release() {
+	if b.store != nil {
+		b.storeReset()
+	}
+}
+
 // Len returns the total number of items in the btree.
 func (b *BTree) Len() (size int) {
 	b.RLock()
-	size = b.tree.Len()
+	if b.store != nil {
+		size = b.store.count
+	} else {
+		size = b.tree.Len()
+	}
 	b.RUnlock()
 	return
 }
 
 // MaxItem returns the largest item in the btree.
 func (b *BTree) MaxItem() BtreeItem {
+	if b.store != nil {
+		return b.storeMaxItem()
+	}
 	b.RLock()
 	item := b.tree.Max()
 	b.RUnlock()
 	return item
 }
+
+// AscendInMemory calls the iterator for the items kept in the memory, which are all the items of the tree without
+// a store, or the cached items of the tree with a store.
+func (b *BTree) AscendInMemory(fn func(i BtreeItem) bool) {
+	b.Lock()
+	t := b.tree.Clone()
+	b.Unlock()
+	t.Ascend(fn)
+}
@@ -0,0 +1,375 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/util/btree"
+	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/tecbot/gorocksdb"
+)
+
+// The inodes and the dentries of the partitions with the rocksdb store are written through to a RocksDB shared by
+// the partitions on the node, and only the recently used ones are cached in the btrees, so the metadata of a
+// partition is not limited by the memory. The items modified in place must be written back by BTree.Update.
+//
+// The RocksDB is not the durable copy of the metadata. The partitions still dump the snapshots into the files and
+// replay the raft logs after them, so the RocksDB is cleared when it is opened, and filled by loading the snapshots.
+// The snapshot of a tree taken by GetTree reads a snapshot of the RocksDB, which is released with the tree.
+
+const (
+	defaultMetaStoreCacheItems = 100000
+	metaStoreBlockCacheSize    = 256 * 1024 * 1024
+	metaStoreWriteBufferSize   = 64 * 1024 * 1024
+	metaStoreTreeIDSize        = 8
+)
+
+// metaStore is the RocksDB which keeps the inodes and the dentries of the partitions with the rocksdb store.
+type metaStore struct {
+	dir        string
+	db         *gorocksdb.DB
+	wo         *gorocksdb.WriteOptions
+	cacheItems int // the max items cached by each tree
+	nextTreeID uint64
+}
+
+func openMetaStore(dir string, cacheItems int) (ms *metaStore, err error) {
+	if err = os.RemoveAll(dir); err != nil {
+		return
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	tableOpts := gorocksdb.NewDefaultBlockBasedTableOptions()
+	tableOpts.SetBlockCache(gorocksdb.NewLRUCache(metaStoreBlockCacheSize))
+	opts := gorocksdb.NewDefaultOptions()
+	opts.SetBlockBasedTableFactory(tableOpts)
+	opts.SetCreateIfMissing(true)
+	opts.SetWriteBufferSize(metaStoreWriteBufferSize)
+	var db *gorocksdb.DB
+	if db, err = gorocksdb.OpenDb(opts, dir); err != nil {
+		err = fmt.Errorf("open meta store[%v] failed: %v", dir, err)
+		return
+	}
+	// the write ahead log is useless since the store is cleared when it is opened
+	wo := gorocksdb.NewDefaultWriteOptions()
+	wo.DisableWAL(true)
+	if cacheItems <= 0 {
+		cacheItems = defaultMetaStoreCacheItems
+	}
+	ms = &metaStore{dir: dir, db: db, wo: wo, cacheItems: cacheItems}
+	log.LogInfof("openMetaStore: dir(%v) cacheItems(%v)", dir, cacheItems)
+	return
+}
+
+// newTree returns an empty tree whose items are kept in the store, the keys are prefixed by the ID of the tree.
+func (ms *metaStore) newTree(codec *treeCodec) *BTree {
+	prefix := make([]byte, metaStoreTreeIDSize)
+	binary.BigEndian.PutUint64(prefix, atomic.AddUint64(&ms.nextTreeID, 1))
+	return &BTree{
+		tree:  btree.New(defaultBTreeDegree),
+		store: newTreeStore(ms, codec, prefix, nil, ms.cacheItems),
+	}
+}
+
+// treeCodec encodes the items of a tree into the keys and the values of the RocksDB. The order of the encoded keys
+// must be the order of the items.
+type treeCodec struct {
+	key    func(item BtreeItem) []byte
+	value  func(item BtreeItem) []byte
+	decode func(key, value []byte) (BtreeItem, error)
+}
+
+var inodeTreeCodec = &treeCodec{
+	key:   func(item BtreeItem) []byte { return item.(*Inode).MarshalKey() },
+	value: func(item BtreeItem) []byte { return item.(*Inode).MarshalValue() },
+	decode: func(key, value []byte) (item BtreeItem, err error) {
+		ino := NewInode(0, 0)
+		if err = ino.UnmarshalKey(key); err != nil {
+			return
+		}
+		if err = ino.UnmarshalValue(value); err != nil {
+			return
+		}
+		return ino, nil
+	},
+}
+
+var dentryTreeCodec = &treeCodec{
+	key:   func(item BtreeItem) []byte { return item.(*Dentry).MarshalKey() },
+	value: func(item BtreeItem) []byte { return item.(*Dentry).MarshalValue() },
+	decode: func(key, value []byte) (item BtreeItem, err error) {
+		dentry := &Dentry{}
+		if err = dentry.UnmarshalKey(key); err != nil {
+			return
+		}
+		if err = dentry.UnmarshalValue(value); err != nil {
+			return
+		}
+		return dentry, nil
+	},
+}
+
+// cachedItem is an item cached in the btree of a tree in the store.
+type cachedItem struct {
+	key  string
+	item BtreeItem
+}
+
+// treeStore keeps the items of a tree in the metaStore. It is protected by the lock of the BTree.
+type treeStore struct {
+	ms         *metaStore
+	codec      *treeCodec
+	prefix     []byte
+	end        []byte              // the prefix of the next tree
+	snap       *gorocksdb.Snapshot // the snapshot read by the tree, nil for the live tree
+	ro         *gorocksdb.ReadOptions
+	count      int
+	cacheItems int
+	lru        *list.List               // the cached items, the most recently used first
+	elements   map[string]*list.Element // the cached items by the keys
+}
+
+func newTreeStore(ms *metaStore, codec *treeCodec, prefix []byte, snap *gorocksdb.Snapshot, cacheItems int) (s *treeStore) {
+	s = &treeStore{
+		ms:         ms,
+		codec:      codec,
+		prefix:     prefix,
+		end:        make([]byte, metaStoreTreeIDSize),
+		snap:       snap,
+		ro:         gorocksdb.NewDefaultReadOptions(),
+		cacheItems: cacheItems,
+		lru:        list.New(),
+		elements:   make(map[string]*list.Element),
+	}
+	binary.BigEndian.PutUint64(s.end, binary.BigEndian.Uint64(prefix)+1)
+	if snap != nil {
+		s.ro.SetSnapshot(snap)
+		runtime.SetFinalizer(s, (*treeStore).release)
+	}
+	return
+}
+
+func (s *treeStore) release() {
+	s.ms.db.ReleaseSnapshot(s.snap)
+	s.ro.Destroy()
+}
+
+func (s *treeStore) key(item BtreeItem) []byte {
+	return append(append(make([]byte, 0, len(s.prefix)+32), s.prefix...), s.codec.key(item)...)
+}
+
+// fatal exits the process since the items in the cache and in the RocksDB diverge once a write fails.
+func (s *treeStore) fatal(op string, err error) {
+	log.LogFatalf("meta store[%v] %v failed: %v", s.ms.dir, op, err)
+}
+
+func (s *treeStore) read(key []byte) (item BtreeItem) {
+	value, err := s.ms.db.GetBytes(s.ro, key)
+	if err != nil {
+		s.fatal("get", err)
+		return
+	}
+	if value == nil {
+		return
+	}
+	if item, err = s.codec.decode(key[len(s.prefix):], value); err != nil {
+		s.fatal("decode", err)
+	}
+	return
+}
+
+func (s *treeStore) write(key []byte, item BtreeItem) {
+	if err := s.ms.db.Put(s.ms.wo, key, s.codec.value(item)); err != nil {
+		s.fatal("put", err)
+	}
+}
+
+func (s *treeStore) cache(tree *btree.BTree, key []byte, item BtreeItem) {
+	if s.cacheItems == 0 {
+		return
+	}
+	tree.ReplaceOrInsert(item)
+	if e, ok := s.elements[string(key)]; ok {
+		e.Value.(*cachedItem).item = item
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.elements[string(key)] = s.lru.PushFront(&cachedItem{key: string(key), item: item})
+	for s.lru.Len() > s.cacheItems {
+		evicted := s.lru.Remove(s.lru.Back()).(*cachedItem)
+		delete(s.elements, evicted.key)
+		tree.Delete(evicted.item)
+	}
+}
+
+func (s *treeStore) uncache(tree *btree.BTree, key []byte, item BtreeItem) {
+	tree.Delete(item)
+	if e, ok := s.elements[string(key)]; ok {
+		s.lru.Remove(e)
+		delete(s.elements, string(key))
+	}
+}
+
+// load returns the item of the key from the cache, or from the RocksDB and caches it.
+func (b *BTree) load(key []byte, item BtreeItem) BtreeItem {
+	if cached := b.tree.Get(item); cached != nil {
+		b.store.lru.MoveToFront(b.store.elements[string(key)])
+		return cached
+	}
+	if item = b.store.read(key); item != nil {
+		b.store.cache(b.tree, key, item)
+	}
+	return item
+}
+
+func (b *BTree) storeGet(key BtreeItem) BtreeItem {
+	b.Lock()
+	defer b.Unlock()
+	return b.load(b.store.key(key), key)
+}
+
+func (b *BTree) storeDelete(key BtreeItem, accept func(item BtreeItem) bool) (item BtreeItem) {
+	b.Lock()
+	defer b.Unlock()
+	k := b.store.key(key)
+	if item = b.load(k, key); item == nil || (accept != nil && !accept(item)) {
+		return nil
+	}
+	if err := b.store.ms.db.Delete(b.store.ms.wo, k); err != nil {
+		b.store.fatal("delete", err)
+	}
+	b.store.uncache(b.tree, k, item)
+	b.store.count--
+	return
+}
+
+func (b *BTree) storeReplaceOrInsert(item BtreeItem, replace bool) (BtreeItem, bool) {
+	b.Lock()
+	defer b.Unlock()
+	k := b.store.key(item)
+	existing := b.load(k, item)
+	if existing != nil && !replace {
+		return existing, false
+	}
+	b.store.write(k, item)
+	b.store.cache(b.tree, k, item)
+	if existing == nil {
+		b.store.count++
+	}
+	return existing, true
+}
+
+func (b *BTree) storeUpdate(item BtreeItem) {
+	b.Lock()
+	defer b.Unlock()
+	k := b.store.key(item)
+	b.store.write(k, item)
+	b.store.cache(b.tree, k, item)
+}
+
+// storeAscend calls the iterator for the items from the start key until the end key. The items are read from
+// an implicit snapshot of the RocksDB without holding the lock, and the cached ones are passed instead if any.
+func (b *BTree) storeAscend(start, end []byte, iterator func(i BtreeItem) bool) {
+	it := b.store.ms.db.NewIterator(b.store.ro)
+	defer it.Close()
+	for it.Seek(start); it.Valid(); it.Next() {
+		key := append([]byte(nil), it.Key().Data()...)
+		if bytes.Compare(key, end) >= 0 {
+			return
+		}
+		item, err := b.store.codec.decode(key[len(b.store.prefix):], it.Value().Data())
+		if err != nil {
+			b.store.fatal("decode", err)
+			return
+		}
+		if b.store.snap == nil {
+			b.RLock()
+			if cached := b.tree.Get(item); cached != nil {
+				item = cached
+			}
+			b.RUnlock()
+		}
+		if !iterator(item) {
+			return
+		}
+	}
+	if err := it.Err(); err != nil {
+		b.store.fatal("iterate", err)
+	}
+}
+
+func (b *BTree) storeMaxItem() (item BtreeItem) {
+	it := b.store.ms.db.NewIterator(b.store.ro)
+	defer it.Close()
+	if it.SeekForPrev(b.store.end); !it.Valid() {
+		return
+	}
+	key := append([]byte(nil), it.Key().Data()...)
+	if !bytes.HasPrefix(key, b.store.prefix) {
+		return
+	}
+	item, err := b.store.codec.decode(key[len(b.store.prefix):], it.Value().Data())
+	if err != nil {
+		b.store.fatal("decode", err)
+	}
+	return
+}
+
+// storeSnapshot returns the tree reading a snapshot of the RocksDB, the items are not cached by the snapshot.
+func (b *BTree) storeSnapshot() *BTree {
+	b.RLock()
+	defer b.RUnlock()
+	s := newTreeStore(b.store.ms, b.store.codec, b.store.prefix, b.store.ms.db.NewSnapshot(), 0)
+	s.count = b.store.count
+	return &BTree{tree: btree.New(defaultBTreeDegree), store: s}
+}
+
+// storeReset deletes all the items of the tree from the RocksDB.
+func (b *BTree) storeReset() {
+	b.Lock()
+	defer b.Unlock()
+	it := b.store.ms.db.NewIterator(b.store.ro)
+	defer it.Close()
+	wb := gorocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	for it.Seek(b.store.prefix); it.Valid(); it.Next() {
+		key := append([]byte(nil), it.Key().Data()...)
+		if bytes.Compare(key, b.store.end) >= 0 {
+			break
+		}
+		wb.Delete(key)
+		if wb.Count() >= 1024 {
+			if err := b.store.ms.db.Write(b.store.ms.wo, wb); err != nil {
+				b.store.fatal("reset", err)
+			}
+			wb.Clear()
+		}
+	}
+	if err := b.store.ms.db.Write(b.store.ms.wo, wb); err != nil {
+		b.store.fatal("reset", err)
+	}
+	b.tree.Clear(false)
+	b.store.lru.Init()
+	b.store.elements = make(map[string]*list.Element)
+	b.store.count = 0
+}
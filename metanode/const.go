@@ -117,6 +117,22 @@ const (
 	opFSMDeleteDentryBatch
 	opFSMUnlinkInodeBatch
 	opFSMEvictInodeBatch
+	opFSMVolSnapshot
+	opFSMSetLock
+	opFSMRenewLock
+	opFSMUpdateSummary
+	opFSMRenameDentry
+	opFSMPurgeOrphanInode
+	opFSMTxPrepare
+	opFSMTxCommit
+	opFSMTxAbort
+	opFSMTxDone
+	opFSMExportTick
+	opFSMImportBatch
+	opFSMPurgeInode
+	opFSMCancelPurge
+	opFSMReplicaCheck
+	opFSMFixNLink
 )
 
 var (
@@ -133,6 +149,9 @@ const (
 	defaultMetadataDir = "metadataDir"
 	defaultRaftDir     = "raftDir"
 	defaultAuthTimeout = 5 // seconds
+
+	// the max raft logs a learner falls behind the leader when it is promoted to a voter
+	defaultLearnerPromoteMaxLag = 100
 )
 
 // Configuration keys
@@ -147,6 +166,11 @@ const (
 	cfgDeleteBatchCount  = "deleteBatchCount"
 	cfgTotalMem          = "totalMem"
 	cfgZoneName          = "zoneName"
+	cfgRackName          = "rackName"
+
+	cfgMetaStore           = "metaStore"           // the default store of the partitions, memory or rocksdb
+	cfgMetaStoreDir        = "metaStoreDir"        // the dir of the RocksDB, metadataDir/metastore by default
+	cfgMetaStoreCacheItems = "metaStoreCacheItems" // the max inodes or dentries cached by each partition
 
 	metaNodeDeleteBatchCountKey = "batchCount"
 )
@@ -18,6 +18,7 @@ import (
 	"github.com/chubaofs/chubaofs/proto"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // DataPartition defines the struct of data partition that will be used on the meta node.
@@ -47,6 +48,9 @@ func NewDataPartitionsView() *DataPartitionsView {
 type Vol struct {
 	sync.RWMutex
 	dataPartitionView map[uint64]*DataPartition
+	inodeRetention    int64 // seconds to keep the deleted inodes, 0 for the default
+	expirationRules   map[uint32]*proto.ExpirationRule
+	lifecycleRules    map[uint32]*proto.LifecycleRule
 }
 
 // NewVol returns a new volume instance.
@@ -70,6 +74,52 @@ func (v *Vol) UpdatePartitions(partitions *DataPartitionsView) {
 	}
 }
 
+// InodeRetention returns the seconds to keep the deleted inodes of the volume, 0 for the default.
+func (v *Vol) InodeRetention() int64 {
+	return atomic.LoadInt64(&v.inodeRetention)
+}
+
+// SetInodeRetention sets the seconds to keep the deleted inodes of the volume.
+func (v *Vol) SetInodeRetention(retention int64) {
+	atomic.StoreInt64(&v.inodeRetention, retention)
+}
+
+// ExpirationRules returns the expiration rules of the volume by their IDs.
+func (v *Vol) ExpirationRules() map[uint32]*proto.ExpirationRule {
+	v.RLock()
+	defer v.RUnlock()
+	return v.expirationRules
+}
+
+// SetExpirationRules replaces the expiration rules of the volume.
+func (v *Vol) SetExpirationRules(rules []*proto.ExpirationRule) {
+	expirationRules := make(map[uint32]*proto.ExpirationRule, len(rules))
+	for _, rule := range rules {
+		expirationRules[rule.RuleID] = rule
+	}
+	v.Lock()
+	v.expirationRules = expirationRules
+	v.Unlock()
+}
+
+// LifecycleRules returns the lifecycle rules of the volume by their IDs.
+func (v *Vol) LifecycleRules() map[uint32]*proto.LifecycleRule {
+	v.RLock()
+	defer v.RUnlock()
+	return v.lifecycleRules
+}
+
+// SetLifecycleRules replaces the lifecycle rules of the volume.
+func (v *Vol) SetLifecycleRules(rules []*proto.LifecycleRule) {
+	lifecycleRules := make(map[uint32]*proto.LifecycleRule, len(rules))
+	for _, rule := range rules {
+		lifecycleRules[rule.RuleID] = rule
+	}
+	v.Lock()
+	v.lifecycleRules = lifecycleRules
+	v.Unlock()
+}
+
 func (v *Vol) replaceOrInsert(partition *DataPartition) {
 	v.Lock()
 	defer v.Unlock()
@@ -65,6 +65,14 @@ func (fl *freeList) Remove(ino uint64) {
 	}
 }
 
+// Has checks if the item is on the list.
+func (fl *freeList) Has(ino uint64) bool {
+	fl.Lock()
+	defer fl.Unlock()
+	_, ok := fl.index[ino]
+	return ok
+}
+
 func (fl *freeList) Len() int {
 	fl.Lock()
 	defer fl.Unlock()
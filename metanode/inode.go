@@ -28,6 +28,8 @@ import (
 
 const (
 	DeleteMarkFlag = 1 << 0
+	PurgeHoldFlag  = 1 << 1 // the deleted inode is kept until it is linked again or purged by force
+	PurgeForceFlag = 1 << 2 // the deleted inode is purged without waiting for the retention
 )
 
 // Inode wraps necessary properties of `Inode` information in the file system.
@@ -43,6 +45,8 @@ const (
 //  +-------+------+------+-----+----+----+----+--------+------------------+
 //  | bytes |  4   |  8   |  8  | 8  | 8  | 8  |   4    |      ExtLen      |
 //  +-------+------+------+-----+----+----+----+--------+------------------+
+// The value of the inode with the VersionedFlag has the Epoch before the ExtLen, and the count and the
+// InodeVersion list after the extents.
 // Marshal entity:
 //  +-------+-----------+--------------+-----------+--------------+
 //  | item  | KeyLength | MarshaledKey | ValLength | MarshaledVal |
@@ -64,8 +68,10 @@ type Inode struct {
 	NLink      uint32 // NodeLink counts
 	Flag       int32
 	Reserved   uint64 // reserved space
+	Epoch      uint64 // the latest volume snapshot when the inode is written
 	//Extents    *ExtentsTree
-	Extents *SortedExtents
+	Extents  *SortedExtents
+	Versions []*InodeVersion // the states kept for the volume snapshots, from the newest to the oldest
 }
 
 type InodeBatch []*Inode
@@ -91,6 +97,10 @@ func (i *Inode) String() string {
 	buff.WriteString(fmt.Sprintf("Flag[%d]", i.Flag))
 	buff.WriteString(fmt.Sprintf("Reserved[%d]", i.Reserved))
 	buff.WriteString(fmt.Sprintf("Extents[%s]", i.Extents))
+	if i.isVersioned() {
+		buff.WriteString(fmt.Sprintf("Epoch[%d]", i.Epoch))
+		buff.WriteString(fmt.Sprintf("Versions[%v]", i.Versions))
+	}
 	buff.WriteString("}")
 	return buff.String()
 }
@@ -141,6 +151,11 @@ func (i *Inode) Copy() BtreeItem {
 	newIno.Flag = i.Flag
 	newIno.Reserved = i.Reserved
 	newIno.Extents = i.Extents.Clone()
+	newIno.Epoch = i.Epoch
+	// the versions are never changed once they are kept
+	if len(i.Versions) > 0 {
+		newIno.Versions = append([]*InodeVersion(nil), i.Versions...)
+	}
 	i.RUnlock()
 	return newIno
 }
@@ -308,7 +323,11 @@ func (i *Inode) MarshalValue() (val []byte) {
 	if err = binary.Write(buff, binary.BigEndian, &i.NLink); err != nil {
 		panic(err)
 	}
-	if err = binary.Write(buff, binary.BigEndian, &i.Flag); err != nil {
+	flag := i.Flag
+	if i.isVersioned() {
+		flag |= VersionedFlag
+	}
+	if err = binary.Write(buff, binary.BigEndian, &flag); err != nil {
 		panic(err)
 	}
 	if err = binary.Write(buff, binary.BigEndian, &i.Reserved); err != nil {
@@ -319,7 +338,12 @@ func (i *Inode) MarshalValue() (val []byte) {
 	if err != nil {
 		panic(err)
 	}
-	if _, err = buff.Write(extData); err != nil {
+	if i.isVersioned() {
+		err = i.marshalVersions(buff, extData)
+	} else {
+		_, err = buff.Write(extData)
+	}
+	if err != nil {
 		panic(err)
 	}
 
@@ -376,6 +400,10 @@ func (i *Inode) UnmarshalValue(val []byte) (err error) {
 	if err = binary.Read(buff, binary.BigEndian, &i.Reserved); err != nil {
 		return
 	}
+	if i.Flag&VersionedFlag != 0 {
+		i.Flag &^= VersionedFlag
+		return i.unmarshalVersions(buff)
+	}
 	if buff.Len() == 0 {
 		return
 	}
@@ -471,15 +499,32 @@ func (i *Inode) ShouldDelete() (ok bool) {
 	return
 }
 
-// inode should delay remove if as 3 conditions:
-// 1. DeleteMarkFlag is unset
-// 2. NLink == 0
-// 3. AccessTime is 7 days ago
-func (i *Inode) ShouldDelayDelete() (ok bool) {
+// ShouldDelayDelete returns if the removal of the deleted inode should be delayed. The AccessTime of the inode is
+// the time when its last link is removed. If the retention is 0, the inodes with NLink == 0 and the DeleteMarkFlag
+// unset are kept for InodeNLink0DelayDeleteSeconds, and the evicted ones are removed at once. Otherwise both of them
+// are kept for the retention seconds. The held inodes are always kept and the ones purged by force are never.
+func (i *Inode) ShouldDelayDelete(retention int64) (ok bool) {
+	i.RLock()
+	defer i.RUnlock()
+	if i.NLink != 0 || i.Flag&PurgeForceFlag != 0 {
+		return false
+	}
+	if i.Flag&PurgeHoldFlag != 0 {
+		return true
+	}
+	if retention == 0 {
+		if i.Flag&DeleteMarkFlag != 0 {
+			return false
+		}
+		retention = InodeNLink0DelayDeleteSeconds
+	}
+	return time.Now().Unix()-i.AccessTime < retention
+}
+
+// IsPurgeHeld returns if the deleted inode is held from being purged.
+func (i *Inode) IsPurgeHeld() (ok bool) {
 	i.RLock()
-	ok = (i.Flag&DeleteMarkFlag != DeleteMarkFlag) &&
-		(i.NLink == 0) &&
-		time.Now().Unix()-i.AccessTime < InodeNLink0DelayDeleteSeconds
+	ok = i.Flag&PurgeHoldFlag != 0
 	i.RUnlock()
 	return
 }
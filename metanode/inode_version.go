@@ -0,0 +1,294 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// VersionedFlag marks the marshaled value of the inode which has the snapshot epoch and the versions, it is never
+// set in memory. The value of the inode without them is marshaled as before, so it can be read by the older nodes.
+const VersionedFlag = 1 << 3
+
+// InodeVersion is a state of the inode kept for the volume snapshots. The epoch of a state is the latest snapshot
+// when it is written, so a snapshot sees the newest state whose epoch is smaller than the snapshot ID. The state of
+// the inode is kept as a version when it is changed for the first time after a newer snapshot is taken.
+// Marshal value:
+//  +-------+-------+------+-----+-----+------+-----+----+----+-------+------+--------+------------------+
+//  | item  | Epoch | Type | Uid | Gid | Size | Gen | AT | MT | NLink | Flag | ExtLen | MarshaledExtents |
+//  +-------+-------+------+-----+-----+------+-----+----+----+-------+------+--------+------------------+
+//  | bytes |   8   |  4   |  4  |  4  |  8   |  8  | 8  | 8  |   4   |  4   |   4    |      ExtLen      |
+//  +-------+-------+------+-----+-----+------+-----+----+----+-------+------+--------+------------------+
+type InodeVersion struct {
+	Epoch      uint64
+	Type       uint32
+	Uid        uint32
+	Gid        uint32
+	Size       uint64
+	Generation uint64
+	AccessTime int64
+	ModifyTime int64
+	NLink      uint32
+	Flag       int32
+	Extents    *SortedExtents
+}
+
+// String returns the string format of the version.
+func (v *InodeVersion) String() string {
+	return fmt.Sprintf("InodeVersion{Epoch[%d]Type[%d]Size[%d]Gen[%d]MT[%d]NLink[%d]Flag[%d]Extents[%s]}",
+		v.Epoch, v.Type, v.Size, v.Generation, v.ModifyTime, v.NLink, v.Flag, v.Extents)
+}
+
+// saveVersion keeps the current state as a version if it is written before the epoch, and the state written from
+// then on has the epoch. It must be called before the inode is changed, with the lock of the inode held.
+func (i *Inode) saveVersion(epoch uint64) {
+	if i.Epoch >= epoch {
+		return
+	}
+	version := &InodeVersion{
+		Epoch:      i.Epoch,
+		Type:       i.Type,
+		Uid:        i.Uid,
+		Gid:        i.Gid,
+		Size:       i.Size,
+		Generation: i.Generation,
+		AccessTime: i.AccessTime,
+		ModifyTime: i.ModifyTime,
+		NLink:      i.NLink,
+		Flag:       i.Flag,
+		Extents:    i.Extents.Clone(),
+	}
+	// the versions are kept from the newest to the oldest
+	i.Versions = append([]*InodeVersion{version}, i.Versions...)
+	i.Epoch = epoch
+}
+
+// versionAt returns a copy of the inode seen by the snapshot, or nil if it is created after the snapshot.
+func (i *Inode) versionAt(snapshotID uint64) *Inode {
+	i.RLock()
+	defer i.RUnlock()
+	version := &InodeVersion{
+		Epoch:      i.Epoch,
+		Type:       i.Type,
+		Uid:        i.Uid,
+		Gid:        i.Gid,
+		Size:       i.Size,
+		Generation: i.Generation,
+		AccessTime: i.AccessTime,
+		ModifyTime: i.ModifyTime,
+		NLink:      i.NLink,
+		Flag:       i.Flag,
+		Extents:    i.Extents,
+	}
+	for j := 0; version.Epoch >= snapshotID; j++ {
+		if j == len(i.Versions) {
+			return nil
+		}
+		version = i.Versions[j]
+	}
+	ino := &Inode{
+		Inode:      i.Inode,
+		Type:       version.Type,
+		Uid:        version.Uid,
+		Gid:        version.Gid,
+		Size:       version.Size,
+		Generation: version.Generation,
+		CreateTime: i.CreateTime,
+		AccessTime: version.AccessTime,
+		ModifyTime: version.ModifyTime,
+		NLink:      version.NLink,
+		Flag:       version.Flag,
+		Epoch:      version.Epoch,
+		Extents:    version.Extents.Clone(),
+	}
+	if size := len(i.LinkTarget); size > 0 {
+		ino.LinkTarget = make([]byte, size)
+		copy(ino.LinkTarget, i.LinkTarget)
+	}
+	return ino
+}
+
+// pruneVersions drops the versions not seen by any of the snapshots, the IDs of which are sorted in ascending
+// order. It returns if any version is dropped.
+func (i *Inode) pruneVersions(snapshotIDs []uint64) (pruned bool) {
+	i.Lock()
+	defer i.Unlock()
+	if len(i.Versions) == 0 {
+		return
+	}
+	versions := make([]*InodeVersion, 0, len(i.Versions))
+	newer := i.Epoch
+	for _, version := range i.Versions {
+		// the version is seen by the snapshots in (version.Epoch, newer]
+		for _, id := range snapshotIDs {
+			if id > version.Epoch && id <= newer {
+				versions = append(versions, version)
+				break
+			}
+		}
+		newer = version.Epoch
+	}
+	if pruned = len(versions) != len(i.Versions); !pruned {
+		return
+	}
+	if len(versions) == 0 {
+		versions = nil
+	}
+	i.Versions = versions
+	return
+}
+
+// rangeVersionExtents calls the function with the extents of the versions until it returns false.
+func (i *Inode) rangeVersionExtents(f func(ek proto.ExtentKey) bool) {
+	i.RLock()
+	defer i.RUnlock()
+	for _, version := range i.Versions {
+		stop := false
+		version.Extents.Range(func(ek proto.ExtentKey) bool {
+			stop = !f(ek)
+			return !stop
+		})
+		if stop {
+			return
+		}
+	}
+}
+
+// excludeVersionExtents returns the extents which are not referenced by the versions, the others must be kept
+// for the snapshots.
+func (i *Inode) excludeVersionExtents(eks []proto.ExtentKey) []proto.ExtentKey {
+	if len(eks) == 0 {
+		return eks
+	}
+	referenced := make(map[volSnapshotExtent]struct{})
+	i.rangeVersionExtents(func(ek proto.ExtentKey) bool {
+		referenced[newVolSnapshotExtent(&ek)] = struct{}{}
+		return true
+	})
+	if len(referenced) == 0 {
+		return eks
+	}
+	result := make([]proto.ExtentKey, 0, len(eks))
+	for _, ek := range eks {
+		if _, ok := referenced[newVolSnapshotExtent(&ek)]; !ok {
+			result = append(result, ek)
+		}
+	}
+	return result
+}
+
+// isVersioned returns if the snapshot epoch and the versions are marshaled with the inode.
+func (i *Inode) isVersioned() bool {
+	return i.Epoch != 0 || len(i.Versions) > 0
+}
+
+// marshalVersions writes the epoch, the extents with their length and the versions of the inode.
+func (i *Inode) marshalVersions(buff *bytes.Buffer, extData []byte) (err error) {
+	if err = binary.Write(buff, binary.BigEndian, &i.Epoch); err != nil {
+		return
+	}
+	if err = writeBytesWithLength(buff, extData); err != nil {
+		return
+	}
+	if err = binary.Write(buff, binary.BigEndian, uint32(len(i.Versions))); err != nil {
+		return
+	}
+	for _, version := range i.Versions {
+		if err = version.marshal(buff); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// unmarshalVersions reads what is written by marshalVersions.
+func (i *Inode) unmarshalVersions(buff *bytes.Buffer) (err error) {
+	if err = binary.Read(buff, binary.BigEndian, &i.Epoch); err != nil {
+		return
+	}
+	if i.Extents == nil {
+		i.Extents = NewSortedExtents()
+	}
+	if err = readExtentsWithLength(buff, i.Extents); err != nil {
+		return
+	}
+	var count uint32
+	if err = binary.Read(buff, binary.BigEndian, &count); err != nil {
+		return
+	}
+	if count == 0 {
+		return
+	}
+	i.Versions = make([]*InodeVersion, count)
+	for j := range i.Versions {
+		i.Versions[j] = &InodeVersion{}
+		if err = i.Versions[j].unmarshal(buff); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (v *InodeVersion) marshal(buff *bytes.Buffer) (err error) {
+	fields := []interface{}{&v.Epoch, &v.Type, &v.Uid, &v.Gid, &v.Size, &v.Generation, &v.AccessTime, &v.ModifyTime,
+		&v.NLink, &v.Flag}
+	for _, field := range fields {
+		if err = binary.Write(buff, binary.BigEndian, field); err != nil {
+			return
+		}
+	}
+	extData, err := v.Extents.MarshalBinary()
+	if err != nil {
+		return
+	}
+	return writeBytesWithLength(buff, extData)
+}
+
+func (v *InodeVersion) unmarshal(buff *bytes.Buffer) (err error) {
+	fields := []interface{}{&v.Epoch, &v.Type, &v.Uid, &v.Gid, &v.Size, &v.Generation, &v.AccessTime, &v.ModifyTime,
+		&v.NLink, &v.Flag}
+	for _, field := range fields {
+		if err = binary.Read(buff, binary.BigEndian, field); err != nil {
+			return
+		}
+	}
+	v.Extents = NewSortedExtents()
+	return readExtentsWithLength(buff, v.Extents)
+}
+
+func writeBytesWithLength(buff *bytes.Buffer, data []byte) (err error) {
+	if err = binary.Write(buff, binary.BigEndian, uint32(len(data))); err != nil {
+		return
+	}
+	_, err = buff.Write(data)
+	return
+}
+
+func readExtentsWithLength(buff *bytes.Buffer, extents *SortedExtents) (err error) {
+	var length uint32
+	if err = binary.Read(buff, binary.BigEndian, &length); err != nil {
+		return
+	}
+	data := make([]byte, length)
+	if _, err = io.ReadFull(buff, data); err != nil {
+		return
+	}
+	return extents.UnmarshalBinary(data)
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestInodeVersionMarshal(t *testing.T) {
+	inode := newVolSnapshotTestInode(2, 1025)
+	plain := inode.MarshalValue()
+	inode.DoWriteFunc(func() {
+		inode.saveVersion(3)
+	})
+	inode.AppendExtents([]proto.ExtentKey{{FileOffset: 4096, PartitionId: 1, ExtentId: 1026, Size: 4096}}, 100)
+	inode.SetDeleteMark()
+
+	decoded := NewInode(0, 0)
+	if err := decoded.UnmarshalValue(inode.MarshalValue()); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Flag != DeleteMarkFlag || decoded.Epoch != 3 || decoded.Size != 8192 || decoded.Extents.Size() != 8192 {
+		t.Fatalf("unexpected inode: %v", decoded)
+	}
+	if len(decoded.Versions) != 1 || decoded.Versions[0].Epoch != 0 || decoded.Versions[0].Size != 4096 ||
+		len(decoded.Versions[0].Extents.CopyExtents()) != 1 {
+		t.Fatalf("unexpected versions: %v", decoded.Versions)
+	}
+
+	// the inode without versions is marshaled as before
+	unversioned := newVolSnapshotTestInode(2, 1025)
+	unversioned.CreateTime, unversioned.AccessTime, unversioned.ModifyTime = inode.CreateTime, inode.CreateTime, inode.CreateTime
+	if !bytes.Equal(unversioned.MarshalValue(), plain) {
+		t.Fatalf("expect the value of the unversioned inode unchanged")
+	}
+}
+
+func TestInodeVersions(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "version", Start: 1, End: 100}, nil).(*metaPartition)
+	mp.fsmCreateInode(newVolSnapshotTestInode(2, 1025))
+	setUid := func(uid uint32) {
+		mp.fsmSetAttr(&SetattrRequest{Inode: 2, Mode: 0644, Valid: proto.AttrUid, Uid: uid})
+	}
+	// the snapshots are taken without storing the metadata
+	mp.volSnapshots[1] = nil
+	setUid(10)
+	setUid(11)
+	mp.volSnapshots[2] = nil
+	mp.fsmCreateInode(newVolSnapshotTestInode(3, 1026))
+	mp.volSnapshots[3] = nil
+	setUid(30)
+
+	uidAt := func(ino, snapshotID uint64) (uid uint32, status uint8) {
+		resp := mp.getInodeAt(NewInode(ino, 0), snapshotID)
+		if resp.Status == proto.OpOk {
+			uid = resp.Msg.Uid
+		}
+		return uid, resp.Status
+	}
+	expects := []struct {
+		ino, snapshotID uint64
+		uid             uint32
+		status          uint8
+	}{
+		{2, 1, 0, proto.OpOk},
+		{2, 2, 11, proto.OpOk},
+		{2, 3, 11, proto.OpOk},
+		{3, 2, 0, proto.OpNotExistErr},
+		{3, 3, 0, proto.OpOk},
+		{2, 4, 0, proto.OpArgMismatchErr},
+	}
+	for _, expect := range expects {
+		if uid, status := uidAt(expect.ino, expect.snapshotID); uid != expect.uid || status != expect.status {
+			t.Errorf("inode %v at snapshot %v: expect %v %v, got %v %v", expect.ino, expect.snapshotID, expect.uid,
+				expect.status, uid, status)
+		}
+	}
+	inode := mp.inodeTree.Get(NewInode(2, 0)).(*Inode)
+	if inode.Uid != 30 || inode.Epoch != 3 || len(inode.Versions) != 2 {
+		t.Fatalf("unexpected inode: %v", inode)
+	}
+
+	// the version only seen by snapshot 2 and 3 is dropped with them
+	delete(mp.volSnapshots, 2)
+	delete(mp.volSnapshots, 3)
+	mp.pruneInodeVersions()
+	inode = mp.inodeTree.Get(NewInode(2, 0)).(*Inode)
+	if len(inode.Versions) != 1 || inode.Versions[0].Epoch != 0 {
+		t.Fatalf("unexpected versions: %v", inode.Versions)
+	}
+	if uid, status := uidAt(2, 1); uid != 0 || status != proto.OpOk {
+		t.Fatalf("expect the inode at snapshot 1 kept, got %v %v", uid, status)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/iputil"
+)
+
+var volIPAcls proto.VolIPAclStore
+
+// isAdminOpcode returns whether the opcode is of the admin tasks of the master, which carry no partition IDs of
+// the clients.
+func isAdminOpcode(opcode uint8) bool {
+	switch opcode {
+	case proto.OpCreateMetaPartition, proto.OpMetaNodeHeartbeat, proto.OpDeleteMetaPartition,
+		proto.OpUpdateMetaPartition, proto.OpLoadMetaPartition, proto.OpDecommissionMetaPartition,
+		proto.OpAddMetaPartitionRaftMember, proto.OpRemoveMetaPartitionRaftMember,
+		proto.OpResetMetaPartitionRaftMember, proto.OpPromoteMetaPartitionRaftMember,
+		proto.OpMetaPartitionTryToLeader, proto.OpMetaPartitionSnapshot:
+		return true
+	}
+	return false
+}
+
+// isPeerOpcode returns whether the opcode is sent among the replicas of a partition.
+func isPeerOpcode(opcode uint8) bool {
+	switch opcode {
+	case proto.OpMetaFreeInodesOnRaftFollower, proto.OpMetaGetReferencedInodes, proto.OpMetaGetReplicaChecksum:
+		return true
+	}
+	return false
+}
+
+// checkVolIPAcl returns an error if the client is denied by the volume of the requested partition. The requests of
+// the clients carry the partition IDs as "pid", and are rejected if the partition IDs can't be decoded, since the
+// volumes are unknown. Only the requests among the replicas of the partition skip the check if they come from the
+// peers, the followers don't proxy the requests of the restricted volumes. The requests are not decoded to check if
+// no volume is restricted.
+func (m *metadataManager) checkVolIPAcl(p *Packet, remoteAddr string) error {
+	if volIPAcls.IsEmpty() || isAdminOpcode(p.Opcode) {
+		return nil
+	}
+	partitionID := p.PartitionID
+	if p.Opcode != proto.OpMetaFreeInodesOnRaftFollower {
+		req := &struct {
+			PartitionID uint64 `json:"pid"`
+		}{}
+		if err := json.Unmarshal(p.Data[:p.Size], req); err != nil {
+			return fmt.Errorf("partition of %v is unknown: %v", p.GetOpMsg(), err)
+		}
+		partitionID = req.PartitionID
+	}
+	if partitionID == 0 {
+		return fmt.Errorf("partition of %v is unknown", p.GetOpMsg())
+	}
+	// the request of a missing partition is failed by the operation itself
+	mp, err := m.getPartition(partitionID)
+	if err != nil {
+		return nil
+	}
+	config := mp.GetBaseConfig()
+	acl := volIPAcls.Get(config.VolName)
+	if acl == nil {
+		return nil
+	}
+	remoteIP := iputil.IPOfAddr(remoteAddr)
+	if isPeerOpcode(p.Opcode) {
+		for _, peer := range config.Peers {
+			if iputil.IPOfAddr(peer.Addr).Equal(remoteIP) {
+				return nil
+			}
+		}
+	}
+	if !acl.Allowed(remoteIP) {
+		return fmt.Errorf("client %v is denied by vol %v", remoteIP, config.VolName)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestCheckVolIPAcl(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "restricted", Start: 1, End: 100,
+		Peers: []proto.Peer{{ID: 1, Addr: "192.168.0.1:17210"}}}, nil)
+	m := &metadataManager{partitions: map[uint64]MetaPartition{1: mp}}
+	defer volIPAcls.Update(nil)
+	volIPAcls.Update(map[string]proto.VolIPAcl{"restricted": {Allow: []string{"10.0.0.0/8"}}})
+
+	newPacket := func(opcode uint8, req interface{}) *Packet {
+		p := &Packet{}
+		p.Opcode = opcode
+		p.Data, _ = json.Marshal(req)
+		p.Size = uint32(len(p.Data))
+		return p
+	}
+	inodeGet := newPacket(proto.OpMetaInodeGet, &proto.InodeGetRequest{PartitionID: 1, Inode: 1})
+	if err := m.checkVolIPAcl(inodeGet, "10.1.2.3:5000"); err != nil {
+		t.Errorf("allowed client is denied: %v", err)
+	}
+	if err := m.checkVolIPAcl(inodeGet, "172.16.0.1:5000"); err == nil {
+		t.Errorf("denied client is allowed")
+	}
+	// the peers are trusted by the requests among the replicas only
+	if err := m.checkVolIPAcl(inodeGet, "192.168.0.1:5000"); err == nil {
+		t.Errorf("client op of the peer is allowed")
+	}
+	checksum := newPacket(proto.OpMetaGetReplicaChecksum, &proto.GetReplicaChecksumRequest{PartitionID: 1})
+	if err := m.checkVolIPAcl(checksum, "192.168.0.1:5000"); err != nil {
+		t.Errorf("replica op of the peer is denied: %v", err)
+	}
+	// the requests of the unknown partitions are rejected
+	if err := m.checkVolIPAcl(newPacket(proto.OpMetaInodeGet, &proto.InodeGetRequest{Inode: 1}),
+		"10.1.2.3:5000"); err == nil {
+		t.Errorf("request without partition is allowed")
+	}
+	malformed := &Packet{}
+	malformed.Opcode = proto.OpMetaInodeGet
+	malformed.Data = []byte("{")
+	malformed.Size = uint32(len(malformed.Data))
+	if err := m.checkVolIPAcl(malformed, "10.1.2.3:5000"); err == nil {
+		t.Errorf("malformed request is allowed")
+	}
+
+	// an invalid ACL keeps the last valid one
+	volIPAcls.Update(map[string]proto.VolIPAcl{"restricted": {Allow: []string{"10.0.0"}}})
+	if err := m.checkVolIPAcl(inodeGet, "172.16.0.1:5000"); err == nil {
+		t.Errorf("invalid ACL lifts the restriction")
+	}
+	if err := m.checkVolIPAcl(inodeGet, "10.1.2.3:5000"); err != nil {
+		t.Errorf("invalid ACL drops the last valid one: %v", err)
+	}
+	// and denies all the clients if the volume has none
+	volIPAcls.Update(nil)
+	volIPAcls.Update(map[string]proto.VolIPAcl{"restricted": {Allow: []string{"10.0.0"}}})
+	if err := m.checkVolIPAcl(inodeGet, "10.1.2.3:5000"); err == nil {
+		t.Errorf("invalid ACL allows the clients")
+	}
+}
@@ -23,6 +23,8 @@ import (
 	_ "net/http/pprof"
 	"os"
 	"path"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,6 +41,7 @@ import (
 
 const partitionPrefix = "partition_"
 const ExpiredPartitionPrefix = "expired_"
+const metaStoreDirName = "metastore"
 
 // MetadataManager manages all the meta partitions.
 type MetadataManager interface {
@@ -47,6 +50,8 @@ type MetadataManager interface {
 	//CreatePartition(id string, start, end uint64, peers []proto.Peer) error
 	HandleMetadataOperation(conn net.Conn, p *Packet, remoteAddr string) error
 	GetPartition(id uint64) (MetaPartition, error)
+	MemoryUsage() *proto.MetaNodeMemory
+	Range(f func(i uint64, p MetaPartition) bool)
 }
 
 // MetadataManagerConfig defines the configures in the metadata manager.
@@ -55,6 +60,10 @@ type MetadataManagerConfig struct {
 	RootDir   string
 	ZoneName  string
 	RaftStore raftstore.RaftStore
+
+	MetaStore           string // the default store of the partitions
+	MetaStoreDir        string
+	MetaStoreCacheItems int
 }
 
 type metadataManager struct {
@@ -68,6 +77,12 @@ type metadataManager struct {
 	partitions         map[uint64]MetaPartition // Key: metaRangeId, Val: metaPartition
 	metaNode           *MetaNode
 	flDeleteBatchCount atomic.Value
+
+	metaStore           string // the default store of the partitions
+	metaStoreDir        string
+	metaStoreCacheItems int
+	storeMu             sync.Mutex
+	store               *metaStore // opened once a partition with the rocksdb store is loaded
 }
 
 // HandleMetadataOperation handles the metadata operations.
@@ -76,6 +91,12 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 	metric := exporter.NewTPCnt(p.GetOpMsg())
 	defer metric.Set(err)
 
+	if err = m.checkVolIPAcl(p, remoteAddr); err != nil {
+		p.PacketErrorWithBody(proto.OpNotPerm, []byte(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("%s [%s] req: %d - %s", remoteAddr, p.GetOpMsg(), p.GetReqID(), err.Error())
+		return
+	}
 	switch p.Opcode {
 	case proto.OpMetaCreateInode:
 		err = m.opCreateInode(conn, p, remoteAddr)
@@ -133,6 +154,12 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opRemoveMetaPartitionRaftMember(conn, p, remoteAddr)
 	case proto.OpMetaPartitionTryToLeader:
 		err = m.opMetaPartitionTryToLeader(conn, p, remoteAddr)
+	case proto.OpResetMetaPartitionRaftMember:
+		err = m.opResetMetaPartitionRaftMember(conn, p, remoteAddr)
+	case proto.OpMetaPartitionSnapshot:
+		err = m.opMetaPartitionSnapshot(conn, p, remoteAddr)
+	case proto.OpPromoteMetaPartitionRaftMember:
+		err = m.opPromoteMetaPartitionRaftMember(conn, p, remoteAddr)
 	case proto.OpMetaBatchInodeGet:
 		err = m.opMetaBatchInodeGet(conn, p, remoteAddr)
 	case proto.OpMetaDeleteInode:
@@ -152,6 +179,36 @@ func (m *metadataManager) HandleMetadataOperation(conn net.Conn, p *Packet,
 		err = m.opMetaRemoveXAttr(conn, p, remoteAddr)
 	case proto.OpMetaListXAttr:
 		err = m.opMetaListXAttr(conn, p, remoteAddr)
+	// operations for file locks
+	case proto.OpMetaSetLock:
+		err = m.opMetaSetLock(conn, p, remoteAddr)
+	case proto.OpMetaGetLock:
+		err = m.opMetaGetLock(conn, p, remoteAddr)
+	case proto.OpMetaRenewLock:
+		err = m.opMetaRenewLock(conn, p, remoteAddr)
+	case proto.OpMetaUpdateSummary:
+		err = m.opMetaUpdateSummary(conn, p, remoteAddr)
+	case proto.OpMetaGetEvents:
+		err = m.opMetaGetEvents(conn, p, remoteAddr)
+	case proto.OpMetaGetReferencedInodes:
+		err = m.opMetaGetReferencedInodes(conn, p, remoteAddr)
+	case proto.OpMetaGetReplicaChecksum:
+		err = m.opMetaGetReplicaChecksum(conn, p, remoteAddr)
+	case proto.OpMetaCountInodeLinks:
+		err = m.opMetaCountInodeLinks(conn, p, remoteAddr)
+	// operations for transactions
+	case proto.OpMetaTxStart:
+		err = m.opMetaTxStart(conn, p, remoteAddr)
+	case proto.OpMetaTxRename:
+		err = m.opMetaTxRename(conn, p, remoteAddr)
+	case proto.OpMetaTxPrepare:
+		err = m.opMetaTxPrepare(conn, p, remoteAddr)
+	case proto.OpMetaTxCommit:
+		err = m.opMetaTxCommit(conn, p, remoteAddr)
+	case proto.OpMetaTxAbort:
+		err = m.opMetaTxAbort(conn, p, remoteAddr)
+	case proto.OpMetaTxGetStatus:
+		err = m.opMetaTxGetStatus(conn, p, remoteAddr)
 	// operations for multipart session
 	case proto.OpCreateMultipart:
 		err = m.opCreateMultipart(conn, p, remoteAddr)
@@ -380,6 +437,8 @@ func (m *metadataManager) createPartition(request *proto.CreateMetaPartitionRequ
 		NodeId:      m.nodeId,
 		RootDir:     path.Join(m.rootDir, partitionPrefix+partitionId),
 		ConnPool:    m.connPool,
+		MetaStore:   request.MetaStore,
+		IgnoreCase:  request.CaseInsensitive,
 	}
 	mpc.AfterStop = func() {
 		m.detachPartition(request.PartitionID)
@@ -438,6 +497,27 @@ func (m *metadataManager) GetPartition(id uint64) (mp MetaPartition, err error)
 	return
 }
 
+// MemoryUsage returns the memory used by the process and estimated for each partition, the largest first.
+func (m *metadataManager) MemoryUsage() *proto.MetaNodeMemory {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	usage := &proto.MetaNodeMemory{HeapAlloc: ms.HeapAlloc, HeapSys: ms.HeapSys}
+	partitions := make([]MetaPartition, 0)
+	m.Range(func(id uint64, mp MetaPartition) bool {
+		partitions = append(partitions, mp)
+		return true
+	})
+	for _, mp := range partitions {
+		partition := mp.MemoryUsage()
+		usage.Partitions = append(usage.Partitions, partition)
+		usage.Total += partition.Total()
+	}
+	sort.Slice(usage.Partitions, func(i, j int) bool {
+		return usage.Partitions[i].Total() > usage.Partitions[j].Total()
+	})
+	return usage
+}
+
 // MarshalJSON only marshals the base information of every partition.
 func (m *metadataManager) MarshalJSON() (data []byte, err error) {
 	m.mu.RLock()
@@ -454,7 +534,30 @@ func NewMetadataManager(conf MetadataManagerConfig, metaNode *MetaNode) Metadata
 		raftStore:  conf.RaftStore,
 		partitions: make(map[uint64]MetaPartition),
 		metaNode:   metaNode,
+
+		metaStore:           conf.MetaStore,
+		metaStoreDir:        conf.MetaStoreDir,
+		metaStoreCacheItems: conf.MetaStoreCacheItems,
+	}
+}
+
+// openMetaStore returns the RocksDB of the partitions with the given store, nil if the partitions keep the items
+// in the memory. The RocksDB is opened by the first partition with the rocksdb store.
+func (m *metadataManager) openMetaStore(store string) (ms *metaStore, err error) {
+	if store == "" {
+		store = m.metaStore
+	}
+	if store != proto.MetaStoreRocksDB {
+		return
+	}
+	m.storeMu.Lock()
+	defer m.storeMu.Unlock()
+	if m.store == nil {
+		if m.store, err = openMetaStore(m.metaStoreDir, m.metaStoreCacheItems); err != nil {
+			return
+		}
 	}
+	return m.store, nil
 }
 
 // isExpiredPartition return whether one partition is expired
@@ -21,12 +21,13 @@ import (
 	"net"
 	"os"
 	"runtime"
+	"time"
 
+	raftProto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
-	raftProto "github.com/tiglabs/raft/proto"
 )
 
 const (
@@ -70,12 +71,21 @@ func (m *metadataManager) opMasterHeartbeat(conn net.Conn, p *Packet,
 			VolName:     mConf.VolName,
 			InodeCnt:    uint64(partition.GetInodeTree().Len()),
 			DentryCnt:   uint64(partition.GetDentryTree().Len()),
+			ApplyID:     partition.GetAppliedID(),
+			Snapshots:   partition.GetVolSnapshots(),
+		}
+		for _, peer := range mConf.Peers {
+			mpr.Peers = append(mpr.Peers, peer.Addr)
 		}
 		addr, isLeader := partition.IsLeader()
 		if addr == "" {
 			mpr.Status = proto.Unavailable
 		}
 		mpr.IsLeader = isLeader
+		if isLeader {
+			mpr.QuotaUsage = partition.GetQuotaUsage()
+			mpr.ReplicaCheck = partition.GetReplicaCheckReport()
+		}
 		if mConf.Cursor >= mConf.End {
 			mpr.Status = proto.ReadOnly
 		}
@@ -835,8 +845,7 @@ func (m *metadataManager) opAddMetaPartitionRaftMember(conn net.Conn,
 		m.respondToClient(conn, p)
 		return
 	}
-	_, err = mp.ChangeMember(raftProto.ConfAddNode,
-		raftProto.Peer{ID: req.AddPeer.ID}, reqData)
+	_, err = mp.ChangeMember(raftProto.ConfAddNode, raftPeer(req.AddPeer), reqData)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
 		m.respondToClient(conn, p)
@@ -848,6 +857,56 @@ func (m *metadataManager) opAddMetaPartitionRaftMember(conn net.Conn,
 	return
 }
 
+// opPromoteMetaPartitionRaftMember promotes the learner to a voter after it catches up with the leader.
+func (m *metadataManager) opPromoteMetaPartitionRaftMember(conn net.Conn,
+	p *Packet, remoteAddr string) (err error) {
+	var reqData []byte
+	req := &proto.PromoteMetaPartitionRaftMemberRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpTryOtherAddr, ([]byte)(proto.ErrMetaPartitionNotExists.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return nil
+	}
+	if err = mp.CanPromoteRaftMember(req.PromotePeer); err != nil {
+		err = errors.NewErrorf("[opPromoteMetaPartitionRaftMember]: partitionID= %d, %s", req.PartitionId, err)
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	reqData, err = json.Marshal(req)
+	if err != nil {
+		err = errors.NewErrorf("[opPromoteMetaPartitionRaftMember]: partitionID= %d, "+
+			"Marshal %s", req.PartitionId, err)
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	_, err = mp.ChangeMember(raftProto.ConfUpdateNode, raftProto.Peer{ID: req.PromotePeer.ID}, reqData)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	p.PacketOkReply()
+	m.respondToClient(conn, p)
+	log.LogInfof("%s [opPromoteMetaPartitionRaftMember] req: %d - %v", remoteAddr, p.GetReqID(), req)
+	return
+}
+
 func (m *metadataManager) opRemoveMetaPartitionRaftMember(conn net.Conn,
 	p *Packet, remoteAddr string) (err error) {
 	var reqData []byte
@@ -913,6 +972,83 @@ func (m *metadataManager) opRemoveMetaPartitionRaftMember(conn net.Conn,
 	return
 }
 
+// opResetMetaPartitionRaftMember is executed on every surviving replica, the request
+// is not forwarded to the leader since the partition has lost its quorum.
+func (m *metadataManager) opResetMetaPartitionRaftMember(conn net.Conn,
+	p *Packet, remoteAddr string) (err error) {
+	req := &proto.ResetMetaPartitionRaftMemberRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	mp, err := m.getPartition(req.PartitionId)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	if len(req.NewPeers) == 0 {
+		err = errors.NewErrorf("[opResetMetaPartitionRaftMember]: partitionID= %d, "+
+			"new peers is empty", req.PartitionId)
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	if err = mp.ResetMember(req.NewPeers); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	log.LogWarnf("[opResetMetaPartitionRaftMember]: partitionID(%v) reset peers to %v from %v",
+		req.PartitionId, req.NewPeers, remoteAddr)
+	p.PacketOkReply()
+	m.respondToClient(conn, p)
+	return
+}
+
+func (m *metadataManager) opMetaPartitionSnapshot(conn net.Conn,
+	p *Packet, remoteAddr string) (err error) {
+	req := &proto.MetaPartitionSnapshotRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
+	decode := json.NewDecoder(bytes.NewBuffer(p.Data))
+	decode.UseNumber()
+	if err = decode.Decode(adminTask); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	if _, ok := mp.IsLeader(); !ok {
+		err = errors.NewErrorf("[opMetaPartitionSnapshot]: partitionID= %d is not leader", req.PartitionID)
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return
+	}
+	if err = mp.VolSnapshot(req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		return err
+	}
+	log.LogWarnf("[opMetaPartitionSnapshot]: partitionID(%v) snapshot(%v) operation(%v) from %v",
+		req.PartitionID, req.SnapshotID, req.Operation, remoteAddr)
+	p.PacketOkReply()
+	m.respondToClient(conn, p)
+	return
+}
+
 func (m *metadataManager) opMetaBatchInodeGet(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
 	req := &proto.BatchInodeGetRequest{}
@@ -941,11 +1077,20 @@ func (m *metadataManager) opMetaBatchInodeGet(conn net.Conn, p *Packet,
 
 func (m *metadataManager) opMetaPartitionTryToLeader(conn net.Conn, p *Packet,
 	remoteAddr string) (err error) {
+	req := &proto.TransferLeaderRequest{}
+	adminTask := &proto.AdminTask{
+		Request: req,
+	}
 	mp, err := m.getPartition(p.PartitionID)
 	if err != nil {
 		goto errDeal
 	}
-	if err = mp.TryToLeader(p.PartitionID); err != nil {
+	// the request is only carried by the tasks of transferring the leader, which wait for the result
+	if len(p.Data) != 0 && json.Unmarshal(p.Data, adminTask) == nil && req.WaitSeconds > 0 {
+		if err = mp.TransferLeader(time.Duration(req.WaitSeconds) * time.Second); err != nil {
+			goto errDeal
+		}
+	} else if err = mp.TryToLeader(p.PartitionID); err != nil {
 		goto errDeal
 	}
 	p.PacketOkReply()
@@ -1137,6 +1282,355 @@ func (m *metadataManager) opMetaListXAttr(conn net.Conn, p *Packet, remoteAddr s
 	return
 }
 
+func (m *metadataManager) opMetaSetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.SetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.SetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaSetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaRenewLock(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.RenewLockRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.RenewLock(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaRenewLock] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaUpdateSummary(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.UpdateSummaryRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.UpdateSummary(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaUpdateSummary] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetEvents(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetEventsRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetEvents(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetEvents] req: %d - %v, resp: %v, body: %s",
+		remoteAddr, p.GetReqID(), req, p.GetResultMsg(), p.Data)
+	return
+}
+
+func (m *metadataManager) opMetaGetReferencedInodes(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetReferencedInodesRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.GetReferencedInodes(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetReferencedInodes] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.PartitionID, p.GetResultMsg())
+	return
+}
+
+// opMetaGetReplicaChecksum replies the checksums of the replica check by the replica itself, it is not forwarded
+// to the leader.
+func (m *metadataManager) opMetaGetReplicaChecksum(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.GetReplicaChecksumRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	err = mp.GetReplicaChecksum(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaGetReplicaChecksum] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaCountInodeLinks(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.CountInodeLinksRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.CountInodeLinks(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaCountInodeLinks] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxStart(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxStartRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxStart(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxStart] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.Tx, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxRename(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxRenameRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxRename(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxRename] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.TxID, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxPrepare(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxPrepare(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxPrepare] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.TxID, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxCommit(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxCommit(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxCommit] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.TxID, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxAbort(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxAbort(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxAbort] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.TxID, p.GetResultMsg())
+	return
+}
+
+func (m *metadataManager) opMetaTxGetStatus(conn net.Conn, p *Packet, remoteAddr string) (err error) {
+	req := &proto.TxRequest{}
+	if err = json.Unmarshal(p.Data, req); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	mp, err := m.getPartition(req.PartitionID)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, ([]byte)(err.Error()))
+		m.respondToClient(conn, p)
+		err = errors.NewErrorf("[%v] req: %v, resp: %v", p.GetOpMsgWithReqAndResult(), req, err.Error())
+		return
+	}
+	if !m.serveProxy(conn, mp, p) {
+		return
+	}
+	err = mp.TxGetStatus(req, p)
+	_ = m.respondToClient(conn, p)
+	log.LogDebugf("%s [opMetaTxGetStatus] req: %d - %v, resp: %v", remoteAddr, p.GetReqID(),
+		req.TxID, p.GetResultMsg())
+	return
+}
+
 func (m *metadataManager) opMetaBatchExtentsAdd(conn net.Conn, p *Packet, remoteAddr string) (err error) {
 	req := &proto.AppendExtentKeysRequest{}
 	if err = json.Unmarshal(p.Data, req); err != nil {
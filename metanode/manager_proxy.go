@@ -45,6 +45,13 @@ func (m *metadataManager) serveProxy(conn net.Conn, mp MetaPartition,
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		goto end
 	}
+	// the leader checks the client IP restrictions against the IP of the proxy, so the clients of the restricted
+	// volumes retry the leader themselves.
+	if volIPAcls.Get(mp.GetBaseConfig().VolName) != nil {
+		err = ErrNotALeader
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		goto end
+	}
 
 	mConn, err = m.connPool.GetConnect(leaderAddr)
 	if err != nil {
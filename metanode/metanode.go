@@ -16,6 +16,7 @@ package metanode
 
 import (
 	"os"
+	"path"
 	syslog "log"
 	"strings"
 	"time"
@@ -56,6 +57,10 @@ type MetaNode struct {
 	raftHeartbeatPort string
 	raftReplicatePort string
 	zoneName          string
+	rackName          string
+	metaStore         string // the default store of the partitions
+	metaStoreDir      string
+	metaStoreCache    int
 	httpStopC         chan uint8
 
 	control common.Control
@@ -126,6 +131,7 @@ func doStart(s common.Server, cfg *config.Config) (err error) {
 	go m.startUpdateNodeInfo()
 
 	exporter.Init(cfg.GetString("role"), cfg)
+	go m.startRaftMetrics()
 
 	// check local partition compare with master ,if lack,then not start
 	if err = m.checkLocalPartitionMatchWithMaster(); err != nil {
@@ -147,6 +153,7 @@ func doShutdown(s common.Server) {
 		return
 	}
 	m.stopUpdateNodeInfo()
+	m.stopRaftMetrics()
 	// shutdown node and release the resource
 	m.stopServer()
 	m.stopMetaManager()
@@ -171,6 +178,10 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	m.raftHeartbeatPort = cfg.GetString(cfgRaftHeartbeatPort)
 	m.raftReplicatePort = cfg.GetString(cfgRaftReplicaPort)
 	m.zoneName = cfg.GetString(cfgZoneName)
+	m.rackName = cfg.GetString(cfgRackName)
+	m.metaStore = cfg.GetString(cfgMetaStore)
+	m.metaStoreDir = cfg.GetString(cfgMetaStoreDir)
+	m.metaStoreCache = int(cfg.GetInt64(cfgMetaStoreCacheItems))
 	configTotalMem, _ = strconv.ParseUint(cfg.GetString(cfgTotalMem), 10, 64)
 
 	if configTotalMem == 0 {
@@ -190,6 +201,12 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	if m.metadataDir == "" {
 		return fmt.Errorf("bad metadataDir config")
 	}
+	if !proto.IsValidMetaStore(m.metaStore) {
+		return fmt.Errorf("bad metaStore config")
+	}
+	if m.metaStoreDir == "" {
+		m.metaStoreDir = path.Join(m.metadataDir, metaStoreDirName)
+	}
 	if m.listen == "" {
 		return fmt.Errorf("bad listen config")
 	}
@@ -221,6 +238,7 @@ func (m *MetaNode) parseConfig(cfg *config.Config) (err error) {
 	log.LogInfof("[parseConfig] load raftHeartbeatPort[%v].", m.raftHeartbeatPort)
 	log.LogInfof("[parseConfig] load raftReplicatePort[%v].", m.raftReplicatePort)
 	log.LogInfof("[parseConfig] load zoneName[%v].", m.zoneName)
+	log.LogInfof("[parseConfig] load rackName[%v].", m.rackName)
 
 	addrs := cfg.GetSlice(proto.MasterAddr)
 	masters := make([]string, 0, len(addrs))
@@ -262,6 +280,10 @@ func (m *MetaNode) startMetaManager() (err error) {
 		RootDir:   m.metadataDir,
 		RaftStore: m.raftStore,
 		ZoneName:  m.zoneName,
+
+		MetaStore:           m.metaStore,
+		MetaStoreDir:        m.metaStoreDir,
+		MetaStoreCacheItems: m.metaStoreCache,
 	}
 	m.metadataManager = NewMetadataManager(conf, m)
 	if err = m.metadataManager.Start(); err == nil {
@@ -294,7 +316,7 @@ func (m *MetaNode) register() (err error) {
 			step++
 		}
 		var nodeID uint64
-		if nodeID, err = masterClient.NodeAPI().AddMetaNode(nodeAddress, m.zoneName); err != nil {
+		if nodeID, err = masterClient.NodeAPI().AddMetaNode(nodeAddress, m.zoneName, m.rackName); err != nil {
 			log.LogErrorf("register: register to master fail: address(%v) err(%s)", nodeAddress, err)
 			time.Sleep(3 * time.Second)
 			continue
@@ -72,4 +72,10 @@ func (m *MetaNode) updateNodeInfo() {
 	}
 	updateDeleteBatchCount(clusterInfo.MetaNodeDeleteBatchCount)
 	updateDeleteWorkerSleepMs(clusterInfo.MetaNodeDeleteWorkerSleepMs)
+	volIPAcl, err := masterClient.AdminAPI().GetVolIPAcl()
+	if err != nil {
+		log.LogErrorf("[updateNodeInfo] get vol ip acl: %s", err.Error())
+		return
+	}
+	volIPAcls.Update(volIPAcl)
 }
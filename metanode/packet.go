@@ -62,6 +62,25 @@ func NewPacketToBatchDeleteExtent(dp *DataPartition, exts []*proto.ExtentKey) *P
 	return p
 }
 
+// NewPacketToTransitExtents returns a new packet to convert the extents of a replica of the data partition to the
+// storage class, which is not forwarded to the other replicas.
+func NewPacketToTransitExtents(partitionID uint64, extentIDs []uint64, class string) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpTransitExtents
+	p.ExtentType = proto.NormalExtentType
+	p.PartitionID = partitionID
+	p.Data, _ = json.Marshal(&proto.TransitExtentsRequest{
+		PartitionID:  partitionID,
+		ExtentIDs:    extentIDs,
+		StorageClass: class,
+	})
+	p.Size = uint32(len(p.Data))
+	p.ReqID = proto.GenerateRequestID()
+
+	return p
+}
+
 // NewPacketToDeleteExtent returns a new packet to delete the extent.
 func NewPacketToFreeInodeOnRaftFollower(partitionID uint64, freeInodes []byte) *Packet {
 	p := new(Packet)
@@ -76,3 +95,45 @@ func NewPacketToFreeInodeOnRaftFollower(partitionID uint64, freeInodes []byte) *
 
 	return p
 }
+
+// NewPacketToGetReferencedInodes returns a new packet to check the inodes referenced by a meta partition.
+func NewPacketToGetReferencedInodes(req *proto.GetReferencedInodesRequest) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = proto.OpMetaGetReferencedInodes
+	p.PartitionID = req.PartitionID
+	p.ExtentType = proto.NormalExtentType
+	p.ReqID = proto.GenerateRequestID()
+	p.Data, _ = json.Marshal(req)
+	p.Size = uint32(len(p.Data))
+
+	return p
+}
+
+// NewPacketToTx returns a new packet of the transaction request among the meta partitions.
+func NewPacketToTx(opcode uint8, req *proto.TxRequest) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = opcode
+	p.PartitionID = req.PartitionID
+	p.ExtentType = proto.NormalExtentType
+	p.ReqID = proto.GenerateRequestID()
+	p.Data, _ = json.Marshal(req)
+	p.Size = uint32(len(p.Data))
+
+	return p
+}
+
+// NewPacketToMetaPartition returns a new packet of the request to the meta partition.
+func NewPacketToMetaPartition(opcode uint8, partitionID uint64, req interface{}) *Packet {
+	p := new(Packet)
+	p.Magic = proto.ProtoMagic
+	p.Opcode = opcode
+	p.PartitionID = partitionID
+	p.ExtentType = proto.NormalExtentType
+	p.ReqID = proto.GenerateRequestID()
+	p.Data, _ = json.Marshal(req)
+	p.Size = uint32(len(p.Data))
+
+	return p
+}
@@ -20,20 +20,23 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 
 	"github.com/chubaofs/chubaofs/cmd/common"
+	raftproto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/raftstore"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/log"
-	raftproto "github.com/tiglabs/raft/proto"
 )
 
 var (
@@ -64,10 +67,12 @@ type MetaPartitionConfig struct {
 	// Identity for raftStore group. RaftStore nodes in the same raftStore group must have the same groupID.
 	PartitionId uint64              `json:"partition_id"`
 	VolName     string              `json:"vol_name"`
-	Start       uint64              `json:"start"` // Minimal Inode ID of this range. (Required during initialization)
-	End         uint64              `json:"end"`   // Maximal Inode ID of this range. (Required during initialization)
-	Peers       []proto.Peer        `json:"peers"` // Peers information of the raftStore
-	Cursor      uint64              `json:"-"`     // Cursor ID of the inode that have been assigned
+	IgnoreCase  bool                `json:"ignore_case"`
+	Start       uint64              `json:"start"`      // Minimal Inode ID of this range. (Required during initialization)
+	End         uint64              `json:"end"`        // Maximal Inode ID of this range. (Required during initialization)
+	Peers       []proto.Peer        `json:"peers"`      // Peers information of the raftStore
+	MetaStore   string              `json:"meta_store"` // The store of the inodes and the dentries, empty for the default of the node
+	Cursor      uint64              `json:"-"`          // Cursor ID of the inode that have been assigned
 	NodeId      uint64              `json:"-"`
 	RootDir     string              `json:"-"`
 	BeforeStart func()              `json:"-"`
@@ -127,6 +132,11 @@ type OpExtend interface {
 	BatchGetXAttr(req *proto.BatchGetXAttrRequest, p *Packet) (err error)
 	RemoveXAttr(req *proto.RemoveXAttrRequest, p *Packet) (err error)
 	ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err error)
+	GetAllXAttrs(ino uint64) *proto.XAttrInfo
+	GetACL(ino uint64) *proto.ACLInfo
+	GetQuotaUsage() map[uint32]*proto.QuotaUsage
+	UpdateSummary(req *proto.UpdateSummaryRequest, p *Packet) (err error)
+	GetSummary(ino uint64) *proto.DirSummary
 }
 
 // OpDentry defines the interface for the dentry operations.
@@ -148,6 +158,53 @@ type OpExtent interface {
 	BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error)
 }
 
+// OpLock defines the interface for the file lock operations.
+type OpLock interface {
+	SetLock(req *proto.SetLockRequest, p *Packet) (err error)
+	GetLock(req *proto.GetLockRequest, p *Packet) (err error)
+	RenewLock(req *proto.RenewLockRequest, p *Packet) (err error)
+	GetLocks(ino uint64) []*proto.InodeLocks
+}
+
+// OpEvent defines the interface for reading the change events.
+type OpEvent interface {
+	GetEvents(req *proto.GetEventsRequest, p *Packet) (err error)
+	ReadEvents(from uint64, limit int) *proto.GetEventsResponse
+}
+
+// OpOrphan defines the interface for finding the orphan inodes.
+type OpOrphan interface {
+	GetReferencedInodes(req *proto.GetReferencedInodesRequest, p *Packet) (err error)
+	GetOrphanReport() *proto.OrphanReport
+	ListDeletedInodes(marker uint64, limit int) []*proto.DeletedInode
+	PurgeInode(ino uint64) (err error)
+	CancelPurge(ino uint64) (err error)
+}
+
+// OpReplicaCheck defines the interface for checking the replicas of the meta partition.
+type OpReplicaCheck interface {
+	GetReplicaChecksum(req *proto.GetReplicaChecksumRequest, p *Packet) (err error)
+	GetReplicaCheckReport() *proto.ReplicaCheckReport
+}
+
+// OpNLinkCheck defines the interface for checking the link counts of the inodes.
+type OpNLinkCheck interface {
+	CountInodeLinks(req *proto.CountInodeLinksRequest, p *Packet) (err error)
+	StartNLinkCheck(fix bool, limit int) (err error)
+	GetNLinkCheckReport() *proto.NLinkCheckReport
+}
+
+// OpTransaction defines the interface for the transactions among the meta partitions.
+type OpTransaction interface {
+	TxStart(req *proto.TxStartRequest, p *Packet) (err error)
+	TxRename(req *proto.TxRenameRequest, p *Packet) (err error)
+	TxPrepare(req *proto.TxRequest, p *Packet) (err error)
+	TxCommit(req *proto.TxRequest, p *Packet) (err error)
+	TxAbort(req *proto.TxRequest, p *Packet) (err error)
+	TxGetStatus(req *proto.TxRequest, p *Packet) (err error)
+	GetTransactions() []*proto.TxInfo
+}
+
 type OpMultipart interface {
 	GetMultipart(req *proto.GetMultipartRequest, p *Packet) (err error)
 	CreateMultipart(req *proto.CreateMultipartRequest, p *Packet) (err error)
@@ -164,23 +221,41 @@ type OpMeta interface {
 	OpPartition
 	OpExtend
 	OpMultipart
+	OpLock
+	OpEvent
+	OpOrphan
+	OpTransaction
+	OpReplicaCheck
+	OpNLinkCheck
 }
 
 // OpPartition defines the interface for the partition operations.
 type OpPartition interface {
 	IsLeader() (leaderAddr string, isLeader bool)
 	GetCursor() uint64
+	GetAppliedID() uint64
+	GetRaftStatus() *raftstore.PartitionStatus
+	GetRaftProgress() *proto.MetaPartitionRaftStatus
+	GetSnapshotIndex() uint64
 	GetBaseConfig() MetaPartitionConfig
 	ResponseLoadMetaPartition(p *Packet) (err error)
 	PersistMetadata() (err error)
 	ChangeMember(changeType raftproto.ConfChangeType, peer raftproto.Peer, context []byte) (resp interface{}, err error)
+	ResetMember(peers []proto.Peer) (err error)
 	Reset() (err error)
 	UpdatePartition(req *UpdatePartitionReq, resp *UpdatePartitionResp) (err error)
 	DeleteRaft() error
 	IsExsitPeer(peer proto.Peer) bool
 	TryToLeader(groupID uint64) error
+	TransferLeader(timeout time.Duration) error
 	CanRemoveRaftMember(peer proto.Peer) error
+	CanPromoteRaftMember(peer proto.Peer) error
 	IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error)
+	VolSnapshot(req *proto.MetaPartitionSnapshotRequest) (err error)
+	GetVolSnapshots() []uint64
+	MemoryUsage() *proto.MetaPartitionMemory
+	ExportSnapshot(w io.Writer) (header *proto.MetaPartitionExportHeader, err error)
+	ImportSnapshot(r io.Reader) (header *proto.MetaPartitionExportHeader, result *proto.MetaPartitionImportResult, err error)
 }
 
 // MetaPartition defines the interface for the meta partition operations.
@@ -203,7 +278,9 @@ type metaPartition struct {
 	config                 *MetaPartitionConfig
 	size                   uint64 // For partition all file size
 	applyID                uint64 // Inode/Dentry max applyID, this index will be update after restoring from the dumped data.
+	snapshotIndex          uint64 // the apply index of the latest snapshot stored
 	dentryTree             *BTree
+	foldTree               *BTree // the dentries indexed by the folded names, nil if the names are case-sensitive
 	inodeTree              *BTree // btree for inodes
 	extendTree             *BTree // btree for inode extend (XAttr) management
 	multipartTree          *BTree // collection for multipart management
@@ -217,7 +294,27 @@ type metaPartition struct {
 	extReset               chan struct{}
 	vol                    *Vol
 	manager                *metadataManager
+	metaStore              *metaStore // the RocksDB of the inodes and the dentries, nil if they are kept in the memory
 	isLoadingMetaPartition bool
+	volSnapshots           map[uint64]map[volSnapshotExtent]proto.ExtentKey // extents referenced by the volume snapshots
+	volSnapshotsLock       sync.RWMutex
+	volSnapshotsStoring    map[uint64]*volSnapshotStore // the snapshots being stored in background
+	volSnapshotBarrier     sync.RWMutex                 // orders the requests stamped with the epochs and the snapshots
+	fileLocks              map[uint64]proto.FileLocks   // the advisory locks of the files
+	fileLocksLock          sync.RWMutex
+	events                 *EventLog // the latest change events
+	orphanReport           *proto.OrphanReport
+	orphanSuspects         map[uint64]struct{} // the unreferenced inodes found by the last scan
+	orphanLock             sync.RWMutex
+	txTree                 *BTree              // the records of the transactions
+	txLocks                map[string]string   // the dentries locked by the prepared transactions
+	txInflight             map[string]struct{} // the transactions being coordinated by the leader
+	txLock                 sync.Mutex
+	replicaCheck           *replicaCheck // the latest replica check applied
+	replicaCheckReport     *proto.ReplicaCheckReport
+	replicaCheckLock       sync.RWMutex
+	nlinkReport            *proto.NLinkCheckReport // the latest link count check
+	nlinkLock              sync.RWMutex
 }
 
 func (mp *metaPartition) ForceSetMetaPartitionToLoadding() {
@@ -317,9 +414,7 @@ func (mp *metaPartition) startRaft() (err error) {
 	for _, peer := range mp.config.Peers {
 		addr := strings.Split(peer.Addr, ":")[0]
 		rp := raftstore.PeerAddress{
-			Peer: raftproto.Peer{
-				ID: peer.ID,
-			},
+			Peer:          raftPeer(peer),
 			Address:       addr,
 			HeartbeatPort: heartbeatPort,
 			ReplicaPort:   replicaPort,
@@ -375,19 +470,27 @@ func (mp *metaPartition) getRaftPort() (heartbeat, replica int, err error) {
 // NewMetaPartition creates a new meta partition with the specified configuration.
 func NewMetaPartition(conf *MetaPartitionConfig, manager *metadataManager) MetaPartition {
 	mp := &metaPartition{
-		config:        conf,
-		dentryTree:    NewBtree(),
-		inodeTree:     NewBtree(),
-		extendTree:    NewBtree(),
-		multipartTree: NewBtree(),
-		stopC:         make(chan bool),
-		storeChan:     make(chan *storeMsg, 100),
-		freeList:      newFreeList(),
-		extDelCh:      make(chan []proto.ExtentKey, 10000),
-		extReset:      make(chan struct{}),
-		vol:           NewVol(),
-		manager:       manager,
-	}
+		config:              conf,
+		dentryTree:          NewBtree(),
+		inodeTree:           NewBtree(),
+		extendTree:          NewBtree(),
+		multipartTree:       NewBtree(),
+		stopC:               make(chan bool),
+		storeChan:           make(chan *storeMsg, 100),
+		freeList:            newFreeList(),
+		extDelCh:            make(chan []proto.ExtentKey, 10000),
+		extReset:            make(chan struct{}),
+		vol:                 NewVol(),
+		manager:             manager,
+		volSnapshots:        make(map[uint64]map[volSnapshotExtent]proto.ExtentKey),
+		volSnapshotsStoring: make(map[uint64]*volSnapshotStore),
+		fileLocks:           make(map[uint64]proto.FileLocks),
+		events:              NewEventLog(defaultEventLogCapacity),
+		txTree:              NewBtree(),
+		txLocks:             make(map[string]string),
+		txInflight:          make(map[string]struct{}),
+	}
+	mp.foldTree = mp.newFoldTree()
 	return mp
 }
 
@@ -426,6 +529,19 @@ func (mp *metaPartition) GetCursor() uint64 {
 	return atomic.LoadUint64(&mp.config.Cursor)
 }
 
+// GetAppliedID returns the applied index of the partition.
+func (mp *metaPartition) GetAppliedID() uint64 {
+	return atomic.LoadUint64(&mp.applyID)
+}
+
+// GetRaftStatus returns the raft status of the partition, or nil if the raft partition is not started.
+func (mp *metaPartition) GetRaftStatus() *raftstore.PartitionStatus {
+	if mp.raftPartition == nil {
+		return nil
+	}
+	return mp.raftPartition.Status()
+}
+
 // PersistMetadata is the wrapper of persistMetadata.
 func (mp *metaPartition) PersistMetadata() (err error) {
 	mp.config.sortPeers()
@@ -446,6 +562,9 @@ func (mp *metaPartition) LoadSnapshot(snapshotPath string) (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
 	err = mp.loadApplyID(snapshotPath)
 	return
 }
@@ -454,6 +573,10 @@ func (mp *metaPartition) load() (err error) {
 	if err = mp.loadMetadata(); err != nil {
 		return
 	}
+	if err = mp.initTrees(); err != nil {
+		return
+	}
+	mp.foldTree = mp.newFoldTree()
 	snapshotPath := path.Join(mp.config.RootDir, snapshotDir)
 	if err = mp.loadInode(snapshotPath); err != nil {
 		return
@@ -467,10 +590,38 @@ func (mp *metaPartition) load() (err error) {
 	if err = mp.loadMultipart(snapshotPath); err != nil {
 		return
 	}
+	if err = mp.loadTransaction(snapshotPath); err != nil {
+		return
+	}
+	if err = mp.loadVolSnapshots(); err != nil {
+		return
+	}
 	err = mp.loadApplyID(snapshotPath)
 	return
 }
 
+// initTrees replaces the trees of the inodes and the dentries by the ones in the RocksDB if the partition uses the
+// rocksdb store. It is called before the snapshot is loaded.
+func (mp *metaPartition) initTrees() (err error) {
+	if mp.manager == nil {
+		return
+	}
+	if mp.metaStore, err = mp.manager.openMetaStore(mp.config.MetaStore); err != nil || mp.metaStore == nil {
+		return
+	}
+	mp.inodeTree = mp.metaStore.newTree(inodeTreeCodec)
+	mp.dentryTree = mp.metaStore.newTree(dentryTreeCodec)
+	return
+}
+
+// newTree returns an empty tree kept in the store of the partition.
+func (mp *metaPartition) newTree(codec *treeCodec) *BTree {
+	if mp.metaStore == nil {
+		return NewBtree()
+	}
+	return mp.metaStore.newTree(codec)
+}
+
 func (mp *metaPartition) store(sm *storeMsg) (err error) {
 	tmpDir := path.Join(mp.config.RootDir, snapshotDirTmp)
 	if _, err = os.Stat(tmpDir); err == nil {
@@ -494,6 +645,7 @@ func (mp *metaPartition) store(sm *storeMsg) (err error) {
 		mp.storeDentry,
 		mp.storeExtend,
 		mp.storeMultipart,
+		mp.storeTransaction,
 	}
 	for _, storeFunc := range storeFuncs {
 		var crc uint32
@@ -564,12 +716,44 @@ func (mp *metaPartition) nextInodeID() (inodeId uint64, err error) {
 	}
 }
 
+// raftPeer returns the raft peer of the replica, a learner is a non-voting raft peer.
+func raftPeer(peer proto.Peer) raftproto.Peer {
+	rp := raftproto.Peer{ID: peer.ID}
+	if peer.IsLearner {
+		rp.Type = raftproto.PeerLearner
+	}
+	return rp
+}
+
 // ChangeMember changes the raft member with the specified one.
 func (mp *metaPartition) ChangeMember(changeType raftproto.ConfChangeType, peer raftproto.Peer, context []byte) (resp interface{}, err error) {
 	resp, err = mp.raftPartition.ChangeMember(changeType, peer, context)
 	return
 }
 
+// ResetMember forcibly resets the raft members with the specified peers. It is only used to recover
+// the partition whose majority of replicas are lost, so the local node must be one of the new peers.
+func (mp *metaPartition) ResetMember(peers []proto.Peer) (err error) {
+	var isMember bool
+	raftPeers := make([]raftproto.Peer, 0, len(peers))
+	for _, peer := range peers {
+		if peer.ID == mp.config.NodeId {
+			isMember = true
+		}
+		raftPeers = append(raftPeers, raftPeer(peer))
+	}
+	if !isMember {
+		err = errors.NewErrorf("[ResetMember]: node[%v] is not in new peers %v", mp.config.NodeId, peers)
+		return
+	}
+	if err = mp.raftPartition.ResetMember(raftPeers); err != nil {
+		return
+	}
+	mp.config.Peers = peers
+	err = mp.PersistMetadata()
+	return
+}
+
 // GetBaseConfig returns the configuration stored in the meta partition. TODO remove? no usage?
 func (mp *metaPartition) GetBaseConfig() MetaPartitionConfig {
 	return *mp.config
@@ -619,6 +803,10 @@ func (mp *metaPartition) TryToLeader(groupID uint64) error {
 	return mp.raftPartition.TryToLeader(groupID)
 }
 
+func (mp *metaPartition) TransferLeader(timeout time.Duration) error {
+	return mp.raftPartition.TransferLeader(timeout)
+}
+
 // ResponseLoadMetaPartition loads the snapshot signature. TODO remove? no usage?
 func (mp *metaPartition) ResponseLoadMetaPartition(p *Packet) (err error) {
 	resp := &proto.MetaPartitionLoadResponse{
@@ -654,11 +842,12 @@ func (mp *metaPartition) MarshalJSON() ([]byte, error) {
 func (mp *metaPartition) Reset() (err error) {
 	mp.inodeTree.Reset()
 	mp.dentryTree.Reset()
+	mp.foldTree = mp.newFoldTree()
 	mp.config.Cursor = 0
 	mp.applyID = 0
 
 	// remove files
-	filenames := []string{applyIDFile, dentryFile, inodeFile, extendFile, multipartFile}
+	filenames := []string{applyIDFile, dentryFile, inodeFile, extendFile, multipartFile, txFile}
 	for _, filename := range filenames {
 		filepath := path.Join(mp.config.RootDir, filename)
 		if err = os.Remove(filepath); err != nil {
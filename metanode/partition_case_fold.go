@@ -0,0 +1,149 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The names of the dentries of a case-insensitive volume are looked up case-insensitively but preserved. The dentries
+// are kept by the names they are created with, and indexed by their folded names in the fold tree, so a name is
+// resolved to the dentry whose name differs from it only in case, and at most one such dentry exists in a directory.
+
+// foldedDentry is the item of the fold tree, which maps the folded name of a dentry to the name of it.
+type foldedDentry struct {
+	ParentId uint64
+	Folded   string
+	Name     string
+}
+
+// Less tests whether the current foldedDentry item is less than the given one.
+func (f *foldedDentry) Less(than BtreeItem) bool {
+	other, ok := than.(*foldedDentry)
+	return ok && (f.ParentId < other.ParentId || (f.ParentId == other.ParentId && f.Folded < other.Folded))
+}
+
+// Copy returns a copy of the foldedDentry.
+func (f *foldedDentry) Copy() BtreeItem {
+	item := *f
+	return &item
+}
+
+// foldName replaces each rune of the name by the smallest rune equivalent to it under the simple Unicode case
+// folding, so the names equal by strings.EqualFold are folded to the same name. Invalid UTF-8 bytes are kept.
+func foldName(name string) string {
+	var sb strings.Builder
+	sb.Grow(len(name))
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteByte(name[i])
+		} else {
+			sb.WriteRune(foldRune(r))
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// newFoldTree returns an empty fold tree if the names of the partition are case-insensitive, or nil.
+func (mp *metaPartition) newFoldTree() *BTree {
+	if !mp.config.IgnoreCase {
+		return nil
+	}
+	return NewBtree()
+}
+
+// rebuildFoldTree indexes the dentries again, it is called after the dentry tree is replaced.
+func (mp *metaPartition) rebuildFoldTree() {
+	tree := mp.newFoldTree()
+	if tree == nil {
+		return
+	}
+	mp.dentryTree.Ascend(func(i BtreeItem) bool {
+		d := i.(*Dentry)
+		tree.ReplaceOrInsert(&foldedDentry{ParentId: d.ParentId, Folded: foldName(d.Name), Name: d.Name}, true)
+		return true
+	})
+	mp.foldTree = tree
+}
+
+// foldDentry indexes the dentry by its folded name.
+func (mp *metaPartition) foldDentry(d *Dentry) {
+	if mp.foldTree == nil {
+		return
+	}
+	mp.foldTree.ReplaceOrInsert(&foldedDentry{ParentId: d.ParentId, Folded: foldName(d.Name), Name: d.Name}, true)
+}
+
+// unfoldDentry drops the index of the dentry deleted.
+func (mp *metaPartition) unfoldDentry(d *Dentry) {
+	if mp.foldTree == nil {
+		return
+	}
+	mp.foldTree.Delete(&foldedDentry{ParentId: d.ParentId, Folded: foldName(d.Name)})
+}
+
+// resolveDentryName returns the name of the dentry in the directory which differs from the name only in case, or the
+// name itself if there is no such dentry or the names are case-sensitive.
+func (mp *metaPartition) resolveDentryName(parentID uint64, name string) string {
+	if mp.foldTree == nil {
+		return name
+	}
+	if item := mp.foldTree.Get(&foldedDentry{ParentId: parentID, Folded: foldName(name)}); item != nil {
+		return item.(*foldedDentry).Name
+	}
+	return name
+}
+
+// isCaseRename returns if the rename only changes the case of the name. Such a dentry is renamed in place when the
+// destination is created, so the source is not deleted.
+func (mp *metaPartition) isCaseRename(parentID uint64, name string, dstParentID uint64, dstName string) bool {
+	return mp.foldTree != nil && parentID == dstParentID && name != dstName && foldName(name) == foldName(dstName)
+}
+
+// txCaseRenamed returns if the dentry deleted by the rename transaction is the one renamed in place.
+func (mp *metaPartition) txCaseRenamed(record *TxRecord, op *proto.TxOp) bool {
+	if record.Type != proto.TxTypeRename {
+		return false
+	}
+	for _, dst := range record.Ops {
+		if (dst.Type == proto.TxOpCreateDentry || dst.Type == proto.TxOpUpdateDentry) &&
+			mp.isCaseRename(op.ParentID, op.Name, dst.ParentID, dst.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestFoldName(t *testing.T) {
+	equal := [][2]string{{"Readme.TXT", "README.txt"}, {"straße", "STRA\u1e9eE"}, {"Ωmega", "ωMEGA"}, {"\u212a", "k"}}
+	for _, names := range equal {
+		if foldName(names[0]) != foldName(names[1]) {
+			t.Fatalf("expect %q and %q folded to the same name, got %q %q", names[0], names[1],
+				foldName(names[0]), foldName(names[1]))
+		}
+	}
+	if foldName("a\xffb") != "A\xffB" {
+		t.Fatalf("expect the invalid bytes kept, got %q", foldName("a\xffb"))
+	}
+	if foldName("ab") == foldName("a b") {
+		t.Fatalf("expect the different names folded differently")
+	}
+}
+
+func TestCaseInsensitiveDentry(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100, IgnoreCase: true}, nil).(*metaPartition)
+	mp.inodeTree.ReplaceOrInsert(NewInode(proto.RootIno, uint32(os.ModeDir)), true)
+	if status := mp.fsmCreateDentry(&Dentry{ParentId: proto.RootIno, Name: "Readme.txt", Inode: 10, Type: 0644}, false); status != proto.OpOk {
+		t.Fatalf("create dentry failed: %v", status)
+	}
+	if status := mp.fsmCreateDentry(&Dentry{ParentId: proto.RootIno, Name: "README.TXT", Inode: 11, Type: 0644}, false); status != proto.OpExistErr {
+		t.Fatalf("expect the names differing in case conflicting, got %v", status)
+	}
+	if d, status := mp.getDentry(&Dentry{ParentId: proto.RootIno, Name: "readme.TXT"}); status != proto.OpOk || d.Name != "Readme.txt" {
+		t.Fatalf("expect the name looked up case-insensitively and preserved, got %v %v", d, status)
+	}
+
+	// a rename only changing the case creates the destination and then deletes the source
+	if status := mp.fsmCreateDentry(&Dentry{ParentId: proto.RootIno, Name: "README.txt", Inode: 10, Type: 0644}, false); status != proto.OpOk {
+		t.Fatalf("rename the case failed: %v", status)
+	}
+	resp := mp.fsmRenameDentry(&proto.DeleteDentryRequest{ParentID: proto.RootIno, Name: "Readme.txt", DstParentID: proto.RootIno, DstName: "README.txt"})
+	if resp.Status != proto.OpOk || resp.Msg.Inode != 10 {
+		t.Fatalf("expect the renamed dentry kept, got %v %v", resp.Status, resp.Msg)
+	}
+	if d, status := mp.getDentry(&Dentry{ParentId: proto.RootIno, Name: "readme.txt"}); status != proto.OpOk || d.Name != "README.txt" {
+		t.Fatalf("expect the dentry renamed in place, got %v %v", d, status)
+	}
+	if mp.dentryTree.Len() != 1 || mp.foldTree.Len() != 1 {
+		t.Fatalf("expect one dentry, got %v dentries %v folded", mp.dentryTree.Len(), mp.foldTree.Len())
+	}
+
+	if resp = mp.fsmDeleteDentry(&Dentry{ParentId: proto.RootIno, Name: "readme.txt"}, false); resp.Status != proto.OpOk {
+		t.Fatalf("delete dentry failed: %v", resp.Status)
+	}
+	if mp.dentryTree.Len() != 0 || mp.foldTree.Len() != 0 {
+		t.Fatalf("expect no dentries, got %v dentries %v folded", mp.dentryTree.Len(), mp.foldTree.Len())
+	}
+}
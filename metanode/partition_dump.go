@@ -0,0 +1,183 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+const (
+	metaDumpFlushCount = 1000
+	metaDumpBufferSize = 64 * 1024
+)
+
+// Types of the inodes and the dentries dumped
+const (
+	dumpTypeFile    = "file"
+	dumpTypeDir     = "dir"
+	dumpTypeSymlink = "symlink"
+)
+
+// metaDumpFilter selects the inodes or the dentries dumped. The range is of the inode IDs, or of the parent IDs of
+// the dentries, which is the order they are dumped in. The modify time only applies to the inodes.
+type metaDumpFilter struct {
+	start       uint64
+	end         uint64
+	types       map[string]bool // all the types if it is empty
+	mtimeAfter  int64           // unix seconds, inclusive
+	mtimeBefore int64           // unix seconds, exclusive, 0 for no limit
+	limit       int             // 0 for no limit
+}
+
+// parseMetaDumpFilter parses the filter from the query parameters start, end, type, mtimeAfter, mtimeBefore and
+// limit, all of which are optional.
+func parseMetaDumpFilter(r *http.Request) (filter *metaDumpFilter, err error) {
+	filter = &metaDumpFilter{end: math.MaxUint64, types: make(map[string]bool)}
+	parseUint := func(key string, value *uint64) {
+		if s := r.FormValue(key); s != "" && err == nil {
+			*value, err = strconv.ParseUint(s, 10, 64)
+		}
+	}
+	parseInt := func(key string, value *int64) {
+		if s := r.FormValue(key); s != "" && err == nil {
+			*value, err = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	var limit int64
+	parseUint("start", &filter.start)
+	parseUint("end", &filter.end)
+	parseInt("mtimeAfter", &filter.mtimeAfter)
+	parseInt("mtimeBefore", &filter.mtimeBefore)
+	parseInt("limit", &limit)
+	if err != nil {
+		return
+	}
+	filter.limit = int(limit)
+	if s := r.FormValue("type"); s != "" {
+		for _, t := range strings.Split(s, ",") {
+			switch t {
+			case dumpTypeFile, dumpTypeDir, dumpTypeSymlink:
+				filter.types[t] = true
+			default:
+				return nil, fmt.Errorf("unknown type %v", t)
+			}
+		}
+	}
+	if filter.start > filter.end || filter.limit < 0 {
+		return nil, fmt.Errorf("invalid range [%v, %v] or limit %v", filter.start, filter.end, filter.limit)
+	}
+	return
+}
+
+func (f *metaDumpFilter) matchType(mode uint32) bool {
+	if len(f.types) == 0 {
+		return true
+	}
+	switch {
+	case proto.IsDir(mode):
+		return f.types[dumpTypeDir]
+	case proto.IsSymlink(mode):
+		return f.types[dumpTypeSymlink]
+	default:
+		return f.types[dumpTypeFile]
+	}
+}
+
+func (f *metaDumpFilter) matchInode(inode *Inode) (ok bool) {
+	inode.DoReadFunc(func() {
+		ok = f.matchType(inode.Type) && inode.ModifyTime >= f.mtimeAfter &&
+			(f.mtimeBefore == 0 || inode.ModifyTime < f.mtimeBefore)
+	})
+	return
+}
+
+// dumpInodes calls the function with the inodes of the tree matching the filter in the order of their IDs, until it
+// returns false or the limit is reached. The tree is a snapshot got by GetInodeTree, so the partition is not blocked
+// however slow the function is.
+func (f *metaDumpFilter) dumpInodes(inodeTree *BTree, fn func(inode *Inode) bool) (count int) {
+	inodeTree.AscendGreaterOrEqual(&Inode{Inode: f.start}, func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		if inode.Inode > f.end {
+			return false
+		}
+		if !f.matchInode(inode) {
+			return true
+		}
+		count++
+		return fn(inode) && (f.limit == 0 || count < f.limit)
+	})
+	return
+}
+
+// dumpDentries calls the function with the dentries of the tree whose parents are in the range and which match the
+// type, in the order of their parents and names, until it returns false or the limit is reached.
+func (f *metaDumpFilter) dumpDentries(dentryTree *BTree, fn func(dentry *Dentry) bool) (count int) {
+	dentryTree.AscendGreaterOrEqual(&Dentry{ParentId: f.start}, func(i BtreeItem) bool {
+		dentry := i.(*Dentry)
+		if dentry.ParentId > f.end {
+			return false
+		}
+		if !f.matchType(dentry.Type) {
+			return true
+		}
+		count++
+		return fn(dentry) && (f.limit == 0 || count < f.limit)
+	})
+	return
+}
+
+// metaDumpWriter writes the dumped items into the chunked response, and flushes them every metaDumpFlushCount
+// items, so that neither the meta node nor the client holds the whole partition in memory.
+type metaDumpWriter struct {
+	w       http.ResponseWriter
+	buff    *bufio.Writer
+	pending int
+	err     error
+}
+
+func newMetaDumpWriter(w http.ResponseWriter) *metaDumpWriter {
+	return &metaDumpWriter{w: w, buff: bufio.NewWriterSize(w, metaDumpBufferSize)}
+}
+
+// write writes the parts of an item, it returns false if the response is broken, e.g. the client is gone.
+func (dw *metaDumpWriter) write(parts ...[]byte) bool {
+	for _, part := range parts {
+		if _, dw.err = dw.buff.Write(part); dw.err != nil {
+			return false
+		}
+	}
+	if dw.pending++; dw.pending >= metaDumpFlushCount {
+		return dw.flush()
+	}
+	return true
+}
+
+func (dw *metaDumpWriter) flush() bool {
+	dw.pending = 0
+	if dw.err = dw.buff.Flush(); dw.err != nil {
+		return false
+	}
+	if flusher, ok := dw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestMetaDumpFilter(t *testing.T) {
+	inodeTree := NewBtree()
+	dentryTree := NewBtree()
+	for ino := uint64(1); ino <= 10; ino++ {
+		mode := uint32(0644)
+		if ino%3 == 0 {
+			mode = proto.Mode(os.ModeDir | 0755)
+		}
+		inode := NewInode(ino, mode)
+		inode.ModifyTime = int64(ino * 100)
+		inodeTree.ReplaceOrInsert(inode, true)
+		dentryTree.ReplaceOrInsert(&Dentry{ParentId: ino / 4, Name: fmt.Sprintf("f%v", ino), Inode: ino, Type: mode}, true)
+	}
+	dumpInodes := func(query string) (inos []uint64) {
+		filter, err := parseMetaDumpFilter(httptest.NewRequest("GET", "/dumpInodes?pid=1&"+query, nil))
+		if err != nil {
+			t.Fatalf("query %v: %v", query, err)
+		}
+		filter.dumpInodes(inodeTree, func(inode *Inode) bool {
+			inos = append(inos, inode.Inode)
+			return true
+		})
+		return
+	}
+	expects := map[string][]uint64{
+		"":                               {1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		"start=4&end=7":                  {4, 5, 6, 7},
+		"type=dir":                       {3, 6, 9},
+		"type=file&start=5&limit=2":      {5, 7},
+		"mtimeAfter=500&mtimeBefore=900": {5, 6, 7, 8},
+		"type=symlink":                   nil,
+		"start=11":                       nil,
+	}
+	for query, expect := range expects {
+		if inos := dumpInodes(query); !reflect.DeepEqual(inos, expect) {
+			t.Errorf("query %v: expect %v, got %v", query, expect, inos)
+		}
+	}
+	for _, query := range []string{"start=5&end=4", "type=socket", "limit=-1", "mtimeAfter=x"} {
+		if _, err := parseMetaDumpFilter(httptest.NewRequest("GET", "/dumpInodes?pid=1&"+query, nil)); err == nil {
+			t.Errorf("query %v: expect an error", query)
+		}
+	}
+
+	filter, _ := parseMetaDumpFilter(httptest.NewRequest("GET", "/dumpDentries?pid=1&start=1&end=2&type=file", nil))
+	var names []string
+	filter.dumpDentries(dentryTree, func(dentry *Dentry) bool {
+		names = append(names, dentry.Name)
+		return true
+	})
+	if expect := []string{"f4", "f5", "f7", "f10", "f8"}; !reflect.DeepEqual(names, expect) {
+		t.Fatalf("expect dentries %v, got %v", expect, names)
+	}
+}
+
+func TestMetaDumpWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	dw := newMetaDumpWriter(recorder)
+	for i := 0; i < metaDumpFlushCount; i++ {
+		if !dw.write([]byte("{}"), []byte{'\n'}) {
+			t.Fatal(dw.err)
+		}
+	}
+	// the items are flushed once the count is reached
+	if !recorder.Flushed || strings.Count(recorder.Body.String(), "\n") != metaDumpFlushCount {
+		t.Fatalf("expect %v items flushed, got %v", metaDumpFlushCount, strings.Count(recorder.Body.String(), "\n"))
+	}
+	dw.write([]byte("{}"), []byte{'\n'})
+	if dw.flush(); strings.Count(recorder.Body.String(), "\n") != metaDumpFlushCount+1 {
+		t.Fatalf("expect the pending item flushed")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+const (
+	defaultEventLogCapacity = 8192 // the events kept by each meta partition
+	defaultEventReadLimit   = 1000
+)
+
+// EventLog keeps the latest change events of a meta partition in a ring. The events are recorded when the raft
+// logs are applied, so all the replicas keep the same events by the raft indexes. The events are not kept in the
+// snapshots, the ones applied before the meta node starts are lost.
+type EventLog struct {
+	sync.RWMutex
+	events  []*proto.MetaEvent
+	head    int    // the index of the oldest event in the ring
+	base    uint64 // the events after the base are complete
+	started bool
+}
+
+// NewEventLog returns a new event log with the capacity.
+func NewEventLog(capacity int) *EventLog {
+	return &EventLog{events: make([]*proto.MetaEvent, 0, capacity)}
+}
+
+// Record appends the event applied at the index, the oldest event is dropped if the ring is full.
+func (l *EventLog) Record(index uint64, event *proto.MetaEvent) {
+	event.Seq = index
+	event.Time = time.Now().Unix()
+	l.Lock()
+	defer l.Unlock()
+	if !l.started {
+		// the logs before the first applied one are applied before the start
+		l.base, l.started = index-1, true
+	}
+	if len(l.events) < cap(l.events) {
+		l.events = append(l.events, event)
+		return
+	}
+	l.base = l.events[l.head].Seq
+	l.events[l.head] = event
+	l.head = (l.head + 1) % len(l.events)
+}
+
+// Reset drops all the events, the events after the base are recorded from now on.
+func (l *EventLog) Reset(base uint64) {
+	l.Lock()
+	defer l.Unlock()
+	l.events = l.events[:0]
+	l.head = 0
+	l.base, l.started = base, true
+}
+
+// Read returns at most limit events after the sequence. The applied index is the one before the read, all the
+// events applied before it are recorded.
+func (l *EventLog) Read(from uint64, limit int, applied uint64) (resp *proto.GetEventsResponse) {
+	l.Lock()
+	defer l.Unlock()
+	if !l.started {
+		l.base, l.started = applied, true
+	}
+	resp = &proto.GetEventsResponse{Base: l.base, Applied: applied, Events: make([]*proto.MetaEvent, 0)}
+	count := len(l.events)
+	at := func(i int) *proto.MetaEvent { return l.events[(l.head+i)%count] }
+	for i := sort.Search(count, func(i int) bool { return at(i).Seq > from }); i < count && len(resp.Events) < limit; i++ {
+		resp.Events = append(resp.Events, at(i))
+	}
+	return
+}
+
+// recordEvent records the change event of the meta partition applied at the index.
+func (mp *metaPartition) recordEvent(index uint64, event *proto.MetaEvent) {
+	event.PartitionID = mp.config.PartitionId
+	mp.events.Record(index, event)
+}
+
+// GetEvents replies the change events after the sequence in the request.
+func (mp *metaPartition) GetEvents(req *proto.GetEventsRequest, p *Packet) (err error) {
+	var encoded []byte
+	if encoded, err = json.Marshal(mp.ReadEvents(req.From, req.Limit)); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// ReadEvents returns the change events after the sequence.
+func (mp *metaPartition) ReadEvents(from uint64, limit int) *proto.GetEventsResponse {
+	if limit <= 0 || limit > defaultEventReadLimit {
+		limit = defaultEventReadLimit
+	}
+	return mp.events.Read(from, limit, mp.GetAppliedID())
+}
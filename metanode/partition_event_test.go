@@ -0,0 +1,48 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestEventLog(t *testing.T) {
+	l := NewEventLog(3)
+	if resp := l.Read(0, 10, 5); resp.Base != 5 || len(resp.Events) != 0 {
+		t.Fatalf("expect the events before the first read lost, got base %v events %v", resp.Base, len(resp.Events))
+	}
+
+	l = NewEventLog(3)
+	for seq := uint64(10); seq < 15; seq++ {
+		l.Record(seq, &proto.MetaEvent{Type: proto.MetaEventCreate, Inode: seq})
+	}
+	resp := l.Read(0, 10, 14)
+	if resp.Base != 11 || len(resp.Events) != 3 || resp.Events[0].Seq != 12 || resp.Events[2].Seq != 14 {
+		t.Fatalf("expect events 12-14 after base 11, got base %v events %v", resp.Base, resp.Events)
+	}
+	if resp = l.Read(12, 1, 14); len(resp.Events) != 1 || resp.Events[0].Seq != 13 {
+		t.Fatalf("expect event 13 after 12, got %v", resp.Events)
+	}
+	if resp = l.Read(14, 10, 14); len(resp.Events) != 0 {
+		t.Fatalf("expect no event after 14, got %v", resp.Events)
+	}
+
+	l.Reset(20)
+	if resp = l.Read(0, 10, 20); resp.Base != 20 || len(resp.Events) != 0 {
+		t.Fatalf("expect no event after reset, got base %v events %v", resp.Base, resp.Events)
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The leader of each meta partition enforces the expiration rules of the volume on the dentries of the partition
+// periodically. A dentry is covered by a rule if the rule is volume wide, its parent is the root directory of the
+// rule, or its parent is tagged with the rule by the expiration extend attribute. The covered subdirectories are
+// tagged when they are scanned, so the rule reaches one more level of the subtree by each scan. The expired files
+// are unlinked like being removed by the clients, and purged after the inode retention of the volume, so that the
+// files still opened are not broken.
+const (
+	expirationScanInterval     = 10 * time.Minute
+	expirationBatchCount       = 1000
+	expirationReadDeadlineTime = 60
+)
+
+func (mp *metaPartition) expirationWorker() {
+	t := time.NewTicker(expirationScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			continue
+		}
+		rules := mp.vol.ExpirationRules()
+		if len(rules) == 0 {
+			continue
+		}
+		if err := mp.enforceExpirationRules(rules); err != nil {
+			log.LogWarnf("expirationWorker: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+		}
+	}
+}
+
+// expiringFile is a file dentry covered by the expiration rules.
+type expiringFile struct {
+	dentry *Dentry
+	rules  []*proto.ExpirationRule
+}
+
+// enforceExpirationRules scans the dentries of the meta partition in batches, tags the covered subdirectories and
+// deletes the expired files.
+func (mp *metaPartition) enforceExpirationRules(rules map[uint32]*proto.ExpirationRule) (err error) {
+	views, err := masterClient.ClientAPI().GetMetaPartitions(mp.config.VolName)
+	if err != nil {
+		return errors.NewErrorf("get meta partitions of volume(%v): %v", mp.config.VolName, err)
+	}
+	marker := &Dentry{}
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		default:
+		}
+		dirs, files, last := mp.collectExpirationCandidates(rules, marker)
+		if last == nil {
+			return
+		}
+		if err = mp.tagRuleDirs(views, proto.ExpirationXAttrKey, dirs); err != nil {
+			return
+		}
+		if err = mp.expireFiles(views, files, time.Now().Unix()); err != nil {
+			return
+		}
+		// the smallest dentry after the last one of the batch
+		marker = &Dentry{ParentId: last.ParentId, Name: last.Name + "\x00"}
+	}
+}
+
+// collectExpirationCandidates returns the rule IDs to tag the covered subdirectories with, and the covered files
+// of a batch of the dentries from the marker. The last dentry of the batch is nil if there are no more dentries.
+func (mp *metaPartition) collectExpirationCandidates(rules map[uint32]*proto.ExpirationRule, marker *Dentry) (
+	dirs map[uint64][]uint32, files []*expiringFile, last *Dentry) {
+	var (
+		parentID    uint64
+		parentRules []*proto.ExpirationRule
+		count       int
+	)
+	dirs = make(map[uint64][]uint32)
+	mp.dentryTree.AscendGreaterOrEqual(marker, func(i BtreeItem) bool {
+		dentry := i.(*Dentry)
+		if last == nil || dentry.ParentId != parentID {
+			parentID = dentry.ParentId
+			parentRules = mp.expirationRulesOfDir(rules, parentID)
+		}
+		last = dentry
+		count++
+		if len(parentRules) > 0 {
+			if proto.IsDir(dentry.Type) {
+				ids := make([]uint32, 0, len(parentRules))
+				for _, rule := range parentRules {
+					if rule.RootInode != proto.RootIno {
+						ids = append(ids, rule.RuleID)
+					}
+				}
+				if len(ids) > 0 {
+					dirs[dentry.Inode] = ids
+				}
+			} else {
+				files = append(files, &expiringFile{dentry: dentry, rules: parentRules})
+			}
+		}
+		return count < expirationBatchCount
+	})
+	return
+}
+
+// expirationRulesOfDir returns the rules covering the files in the directory, whose inode is in the meta partition
+// since the dentries are stored with their parents.
+func (mp *metaPartition) expirationRulesOfDir(rules map[uint32]*proto.ExpirationRule, ino uint64) (
+	covering []*proto.ExpirationRule) {
+	tagged := make(map[uint32]bool)
+	for _, id := range mp.ruleTags(ino, proto.ExpirationXAttrKey) {
+		tagged[id] = true
+	}
+	for _, rule := range rules {
+		if rule.RootInode == proto.RootIno || rule.RootInode == ino || tagged[rule.RuleID] {
+			covering = append(covering, rule)
+		}
+	}
+	return
+}
+
+// ruleTags returns the rule IDs the local directory is tagged with by the extend attribute.
+func (mp *metaPartition) ruleTags(ino uint64, key string) []uint32 {
+	item := mp.extendTree.Get(NewExtend(ino))
+	if item == nil {
+		return nil
+	}
+	value, _ := item.(*Extend).Get([]byte(key))
+	return proto.ParseQuotaIDs(string(value))
+}
+
+// mergeRuleTags returns the tags with the rule IDs, and whether any of the IDs is new.
+func mergeRuleTags(tags, ids []uint32) (merged []uint32, changed bool) {
+	exist := make(map[uint32]bool, len(tags))
+	for _, id := range tags {
+		exist[id] = true
+	}
+	merged = tags
+	for _, id := range ids {
+		if !exist[id] {
+			exist[id] = true
+			merged = append(merged, id)
+			changed = true
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	return
+}
+
+// tagRuleDirs tags the subdirectories with the rule IDs by the extend attribute, the tags of the deleted rules are
+// kept and ignored.
+func (mp *metaPartition) tagRuleDirs(views []*proto.MetaPartitionView, key string, dirs map[uint64][]uint32) (err error) {
+	if len(dirs) == 0 {
+		return
+	}
+	inos := make([]uint64, 0, len(dirs))
+	for ino := range dirs {
+		inos = append(inos, ino)
+	}
+	for _, group := range mp.groupInodesByPartition(views, inos) {
+		if group.view == nil {
+			for _, ino := range group.inos {
+				tags, changed := mergeRuleTags(mp.ruleTags(ino, key), dirs[ino])
+				if !changed {
+					continue
+				}
+				extend := NewExtend(ino)
+				extend.Put([]byte(key), []byte(proto.FormatQuotaIDs(tags)))
+				if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
+					return
+				}
+			}
+			continue
+		}
+		resp := &proto.BatchGetXAttrResponse{}
+		if err = mp.sendToMetaPartition(group.view, proto.OpMetaBatchGetXAttr, &proto.BatchGetXAttrRequest{
+			VolName:     mp.config.VolName,
+			PartitionId: group.view.PartitionID,
+			Inodes:      group.inos,
+			Keys:        []string{key},
+		}, resp); err != nil {
+			return
+		}
+		existing := make(map[uint64][]uint32, len(resp.XAttrs))
+		for _, info := range resp.XAttrs {
+			existing[info.Inode] = proto.ParseQuotaIDs(string(info.Get(key)))
+		}
+		for _, ino := range group.inos {
+			tags, changed := mergeRuleTags(existing[ino], dirs[ino])
+			if !changed {
+				continue
+			}
+			if err = mp.sendToMetaPartition(group.view, proto.OpMetaSetXAttr, &proto.SetXAttrRequest{
+				VolName:     mp.config.VolName,
+				PartitionId: group.view.PartitionID,
+				Inode:       ino,
+				Key:         key,
+				Value:       proto.FormatQuotaIDs(tags),
+			}, nil); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// expireFiles deletes the dentries of the files expired by any of their rules, and unlinks their inodes.
+func (mp *metaPartition) expireFiles(views []*proto.MetaPartitionView, files []*expiringFile, now int64) (err error) {
+	if len(files) == 0 {
+		return
+	}
+	inos := make([]uint64, 0, len(files))
+	for _, file := range files {
+		inos = append(inos, file.dentry.Inode)
+	}
+	times, err := mp.getInodeTimes(views, inos)
+	if err != nil {
+		return
+	}
+	var expired DentryBatch
+	for _, file := range files {
+		t, ok := times[file.dentry.Inode]
+		if !ok {
+			continue
+		}
+		for _, rule := range file.rules {
+			if rule.Expired(t[0], t[1], now) {
+				expired = append(expired, file.dentry)
+				break
+			}
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	val, err := expired.Marshal()
+	if err != nil {
+		return
+	}
+	// the dentries renamed or replaced after the scan are not deleted since their inodes are checked
+	r, err := mp.submit(opFSMDeleteDentryBatch, val)
+	if err != nil {
+		return
+	}
+	deleted := make([]uint64, 0, len(expired))
+	for i, resp := range r.([]*DentryResponse) {
+		if resp.Status == proto.OpOk {
+			deleted = append(deleted, expired[i].Inode)
+		}
+	}
+	log.LogInfof("expireFiles: partitionID(%v) expired(%v) deleted(%v)", mp.config.PartitionId, len(expired), len(deleted))
+	return mp.unlinkExpiredInodes(views, deleted)
+}
+
+// getInodeTimes returns the modify and access times of the inodes found.
+func (mp *metaPartition) getInodeTimes(views []*proto.MetaPartitionView, inos []uint64) (
+	times map[uint64][2]int64, err error) {
+	times = make(map[uint64][2]int64, len(inos))
+	for _, group := range mp.groupInodesByPartition(views, inos) {
+		if group.view == nil {
+			for _, ino := range group.inos {
+				item := mp.inodeTree.Get(NewInode(ino, 0))
+				if item == nil {
+					continue
+				}
+				inode := item.(*Inode)
+				inode.RLock()
+				times[ino] = [2]int64{inode.ModifyTime, inode.AccessTime}
+				inode.RUnlock()
+			}
+			continue
+		}
+		resp := &proto.BatchInodeGetResponse{}
+		if err = mp.sendToMetaPartition(group.view, proto.OpMetaBatchInodeGet, &proto.BatchInodeGetRequest{
+			VolName:     mp.config.VolName,
+			PartitionID: group.view.PartitionID,
+			Inodes:      group.inos,
+		}, resp); err != nil {
+			return
+		}
+		for _, info := range resp.Infos {
+			times[info.Inode] = [2]int64{info.ModifyTime.Unix(), info.AccessTime.Unix()}
+		}
+	}
+	return
+}
+
+// unlinkExpiredInodes unlinks the inodes of the deleted dentries without evicting them.
+func (mp *metaPartition) unlinkExpiredInodes(views []*proto.MetaPartitionView, inos []uint64) (err error) {
+	for _, group := range mp.groupInodesByPartition(views, inos) {
+		if group.view == nil {
+			var inodes InodeBatch
+			for _, ino := range group.inos {
+				inodes = append(inodes, NewInode(ino, 0))
+			}
+			var val []byte
+			if val, err = inodes.Marshal(); err != nil {
+				return
+			}
+			if _, err = mp.submit(opFSMUnlinkInodeBatch, val); err != nil {
+				return
+			}
+			continue
+		}
+		if err = mp.sendToMetaPartition(group.view, proto.OpMetaBatchUnlinkInode, &proto.BatchUnlinkInodeRequest{
+			VolName:     mp.config.VolName,
+			PartitionID: group.view.PartitionID,
+			Inodes:      group.inos,
+		}, nil); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// inodeGroup is the inodes of a meta partition, the view is nil for the local meta partition.
+type inodeGroup struct {
+	view *proto.MetaPartitionView
+	inos []uint64
+}
+
+// groupInodesByPartition groups the inodes by the meta partitions of their ranges, the inodes out of any range
+// are dropped.
+func (mp *metaPartition) groupInodesByPartition(views []*proto.MetaPartitionView, inos []uint64) (groups []*inodeGroup) {
+	local := &inodeGroup{}
+	remote := make(map[uint64]*inodeGroup)
+	for _, ino := range inos {
+		if ino >= mp.config.Start && ino <= mp.config.End {
+			local.inos = append(local.inos, ino)
+			continue
+		}
+		for _, view := range views {
+			if ino >= view.Start && ino <= view.End {
+				group, ok := remote[view.PartitionID]
+				if !ok {
+					group = &inodeGroup{view: view}
+					remote[view.PartitionID] = group
+					groups = append(groups, group)
+				}
+				group.inos = append(group.inos, ino)
+				break
+			}
+		}
+	}
+	if len(local.inos) > 0 {
+		groups = append(groups, local)
+	}
+	return
+}
+
+// sendToMetaPartition sends the request to the meta partition of the volume, and decodes the reply into the resp
+// if it is not nil.
+func (mp *metaPartition) sendToMetaPartition(view *proto.MetaPartitionView, opcode uint8, req, resp interface{}) (
+	err error) {
+	addr := view.LeaderAddr
+	if addr == "" && len(view.Members) > 0 {
+		// the request is forwarded to the leader by the member
+		addr = view.Members[0]
+	}
+	if addr == "" {
+		return errors.New("no available member")
+	}
+	p := NewPacketToMetaPartition(opcode, view.PartitionID, req)
+	if err = mp.sendPacket(addr, p, expirationReadDeadlineTime); err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		return errors.NewErrorf("%s response: %s", p.GetUniqueLogId(), p.GetResultMsg())
+	}
+	if resp != nil {
+		err = json.Unmarshal(p.Data, resp)
+	}
+	return
+}
+
+// sendPacket sends the packet to the meta node and reads the reply into the packet.
+func (mp *metaPartition) sendPacket(addr string, p *Packet, deadline int) (err error) {
+	conn, err := mp.config.ConnPool.GetConnect(addr)
+	defer func() {
+		if err != nil {
+			mp.config.ConnPool.PutConnect(conn, ForceClosedConnect)
+		} else {
+			mp.config.ConnPool.PutConnect(conn, NoClosedConnect)
+		}
+	}()
+	if err != nil {
+		return
+	}
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	return p.ReadFromConn(conn, deadline)
+}
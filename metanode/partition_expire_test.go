@@ -0,0 +1,87 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestCollectExpirationCandidates(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	// /logs(2) is the root of rule 1, /logs/app(3) is tagged with rule 1 and /cache(4) is not covered
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "cache", Inode: 4, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "logs", Inode: 2, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "app", Inode: 3, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "a.log", Inode: 10, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 3, Name: "b.log", Inode: 11, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 3, Name: "sub", Inode: 5, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 4, Name: "c.tmp", Inode: 12, Type: 0644}, true)
+	extend := NewExtend(3)
+	extend.Put([]byte(proto.ExpirationXAttrKey), []byte("1,7"))
+	mp.extendTree.ReplaceOrInsert(extend, true)
+	rules := map[uint32]*proto.ExpirationRule{
+		1: {RuleID: 1, Path: "/logs", RootInode: 2, Days: 7, Basis: proto.ExpireByModifyTime},
+	}
+
+	dirs, files, last := mp.collectExpirationCandidates(rules, &Dentry{})
+	if last == nil || last.Inode != 12 {
+		t.Fatalf("expect all the dentries scanned, got last %v", last)
+	}
+	if !reflect.DeepEqual(dirs, map[uint64][]uint32{3: {1}, 5: {1}}) {
+		t.Fatalf("expect the subdirectories of /logs tagged, got %v", dirs)
+	}
+	if len(files) != 2 || files[0].dentry.Inode != 10 || files[1].dentry.Inode != 11 {
+		t.Fatalf("expect the files under /logs covered, got %v", files)
+	}
+	if dirs, files, last = mp.collectExpirationCandidates(rules, &Dentry{ParentId: 4, Name: "c.tmp\x00"}); last != nil {
+		t.Fatalf("expect no dentries after the last one, got %v %v %v", dirs, files, last)
+	}
+
+	// a volume wide rule covers every file but tags no directories
+	rules[2] = &proto.ExpirationRule{RuleID: 2, Path: "/", RootInode: proto.RootIno, Days: 30, Basis: proto.ExpireByAccessTime}
+	dirs, files, _ = mp.collectExpirationCandidates(rules, &Dentry{})
+	if len(files) != 3 || len(files[0].rules) != 2 || len(files[2].rules) != 1 || files[2].rules[0].RuleID != 2 {
+		t.Fatalf("expect all the files covered, got %v", files)
+	}
+	if len(dirs[4]) != 0 || !reflect.DeepEqual(dirs[3], []uint32{1}) {
+		t.Fatalf("expect the volume wide rule not tagged, got %v", dirs)
+	}
+}
+
+func TestMergeRuleTags(t *testing.T) {
+	if tags, changed := mergeRuleTags([]uint32{3, 1}, []uint32{1}); changed || !reflect.DeepEqual(tags, []uint32{1, 3}) {
+		t.Fatalf("expect the tags unchanged, got %v %v", tags, changed)
+	}
+	if tags, changed := mergeRuleTags(nil, []uint32{2, 1}); !changed || !reflect.DeepEqual(tags, []uint32{1, 2}) {
+		t.Fatalf("expect the tags added, got %v %v", tags, changed)
+	}
+}
+
+func TestExpirationRuleExpired(t *testing.T) {
+	const day = 24 * 3600
+	now := int64(100 * day)
+	byMtime := &proto.ExpirationRule{Days: 7, Basis: proto.ExpireByModifyTime}
+	byAtime := &proto.ExpirationRule{Days: 7, Basis: proto.ExpireByAccessTime}
+	if !byMtime.Expired(now-8*day, now, now) || byMtime.Expired(now-6*day, now-8*day, now) {
+		t.Fatalf("expect the file expired by the modify time")
+	}
+	if !byAtime.Expired(now, now-8*day, now) || byAtime.Expired(now-8*day, now-6*day, now) {
+		t.Fatalf("expect the file expired by the access time")
+	}
+}
@@ -0,0 +1,291 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	exportVersion       = 1
+	exportMaxRecordSize = 64 * MB
+	importBatchCount    = 1000   // the max items imported by a raft log
+	importBatchSize     = 4 * MB // the max size of the items imported by a raft log
+	exportBufferSize    = 1 * MB
+)
+
+// The snapshot of a meta partition is exported as a sequence of the records, each of which is a 4 bytes big endian
+// length followed by the data. The first record is the JSON encoded header, the followings are the binary encoded
+// MetaItems of the inodes, the dentries, the extended attributes and the multiparts, and an empty record ends the
+// snapshot. The transactions and the extents to be deleted are not exported.
+
+func writeExportRecord(w io.Writer, data []byte) (err error) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	if _, err = w.Write(length[:]); err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
+
+func readExportRecord(r io.Reader) (data []byte, err error) {
+	var length [4]byte
+	if _, err = io.ReadFull(r, length[:]); err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("the snapshot is truncated")
+		}
+		return
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > exportMaxRecordSize {
+		return nil, fmt.Errorf("record size %v exceeds the limit", size)
+	}
+	data = make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	return
+}
+
+// ExportSnapshot writes a consistent snapshot of the meta partition to the writer. The trees are copied when the
+// raft log of the export is applied, so it must be called on the leader.
+func (mp *metaPartition) ExportSnapshot(w io.Writer) (header *proto.MetaPartitionExportHeader, err error) {
+	resp, err := mp.submit(opFSMExportTick, nil)
+	if err != nil {
+		return
+	}
+	msg, ok := resp.(*storeMsg)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response %v", resp)
+	}
+	header = &proto.MetaPartitionExportHeader{
+		Version:     exportVersion,
+		VolName:     mp.config.VolName,
+		PartitionID: mp.config.PartitionId,
+		Start:       mp.config.Start,
+		End:         mp.config.End,
+		Cursor:      mp.config.Cursor,
+		ApplyID:     msg.applyIndex,
+		InodeCount:  uint64(msg.inodeTree.Len()),
+		DentryCount: uint64(msg.dentryTree.Len()),
+	}
+	err = writeSnapshot(w, header, msg)
+	return
+}
+
+func writeSnapshot(w io.Writer, header *proto.MetaPartitionExportHeader, msg *storeMsg) (err error) {
+	bw := bufio.NewWriterSize(w, exportBufferSize)
+	var data []byte
+	if data, err = json.Marshal(header); err != nil {
+		return
+	}
+	if err = writeExportRecord(bw, data); err != nil {
+		return
+	}
+	write := func(item *MetaItem) bool {
+		if data, err = item.MarshalBinary(); err != nil {
+			return false
+		}
+		err = writeExportRecord(bw, data)
+		return err == nil
+	}
+	msg.inodeTree.Ascend(func(i BtreeItem) bool {
+		ino := i.(*Inode)
+		return write(NewMetaItem(opFSMCreateInode, ino.MarshalKey(), ino.MarshalValue()))
+	})
+	if err != nil {
+		return
+	}
+	msg.dentryTree.Ascend(func(i BtreeItem) bool {
+		dentry := i.(*Dentry)
+		return write(NewMetaItem(opFSMCreateDentry, dentry.MarshalKey(), dentry.MarshalValue()))
+	})
+	if err != nil {
+		return
+	}
+	msg.extendTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*Extend).Bytes(); err != nil {
+			return false
+		}
+		return write(NewMetaItem(opFSMSetXAttr, nil, raw))
+	})
+	if err != nil {
+		return
+	}
+	msg.multipartTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*Multipart).Bytes(); err != nil {
+			return false
+		}
+		return write(NewMetaItem(opFSMCreateMultipart, nil, raw))
+	})
+	if err != nil {
+		return
+	}
+	if err = writeExportRecord(bw, nil); err != nil {
+		return
+	}
+	err = bw.Flush()
+	return
+}
+
+// ImportSnapshot reads the snapshot exported by ExportSnapshot and imports the items into the meta partition by
+// batches of raft logs. The existing items are replaced, so the import is merged into the partition and can be
+// retried after a failure. The inodes out of the range of the partition are refused.
+func (mp *metaPartition) ImportSnapshot(r io.Reader) (header *proto.MetaPartitionExportHeader,
+	result *proto.MetaPartitionImportResult, err error) {
+	br := bufio.NewReaderSize(r, exportBufferSize)
+	var data []byte
+	if data, err = readExportRecord(br); err != nil {
+		return
+	}
+	header = &proto.MetaPartitionExportHeader{}
+	if err = json.Unmarshal(data, header); err != nil {
+		return
+	}
+	if header.Version != exportVersion {
+		return nil, nil, fmt.Errorf("unknown snapshot version %v", header.Version)
+	}
+	result = &proto.MetaPartitionImportResult{}
+	batch := bytes.NewBuffer(nil)
+	count := 0
+	flush := func() (err error) {
+		if count == 0 {
+			return
+		}
+		var resp interface{}
+		if resp, err = mp.submit(opFSMImportBatch, batch.Bytes()); err != nil {
+			return
+		}
+		imported, ok := resp.(*proto.MetaPartitionImportResult)
+		if !ok {
+			return fmt.Errorf("unexpected response %v", resp)
+		}
+		result.Inodes += imported.Inodes
+		result.Dentries += imported.Dentries
+		result.Extends += imported.Extends
+		result.Multiparts += imported.Multiparts
+		batch, count = bytes.NewBuffer(nil), 0
+		return
+	}
+	for {
+		if data, err = readExportRecord(br); err != nil {
+			return
+		}
+		if len(data) == 0 {
+			err = flush()
+			break
+		}
+		if err = mp.checkImportItem(data); err != nil {
+			return
+		}
+		if err = writeExportRecord(batch, data); err != nil {
+			return
+		}
+		if count++; count >= importBatchCount || batch.Len() >= importBatchSize {
+			if err = flush(); err != nil {
+				return
+			}
+		}
+	}
+	log.LogInfof("ImportSnapshot: partitionID(%v) header(%v) result(%v) err(%v)",
+		mp.config.PartitionId, header, result, err)
+	return
+}
+
+func (mp *metaPartition) checkImportItem(data []byte) (err error) {
+	item := NewMetaItem(0, nil, nil)
+	if err = item.UnmarshalBinary(data); err != nil {
+		return
+	}
+	switch item.Op {
+	case opFSMCreateInode:
+		ino := NewInode(0, 0)
+		if err = ino.UnmarshalKey(item.K); err != nil {
+			return
+		}
+		if ino.Inode < mp.config.Start || ino.Inode > mp.config.End {
+			return fmt.Errorf("inode %v is out of the range [%v, %v]", ino.Inode, mp.config.Start, mp.config.End)
+		}
+	case opFSMCreateDentry, opFSMSetXAttr, opFSMCreateMultipart:
+	default:
+		return fmt.Errorf("unknown op %v", item.Op)
+	}
+	return
+}
+
+// fsmImportBatch applies the items of the snapshot imported. The watchers are told to rescan the partition by
+// a lost event.
+func (mp *metaPartition) fsmImportBatch(data []byte, index uint64) (result *proto.MetaPartitionImportResult, err error) {
+	result = &proto.MetaPartitionImportResult{}
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		var raw []byte
+		if raw, err = readExportRecord(r); err != nil {
+			return
+		}
+		item := NewMetaItem(0, nil, nil)
+		if err = item.UnmarshalBinary(raw); err != nil {
+			return
+		}
+		switch item.Op {
+		case opFSMCreateInode:
+			ino := NewInode(0, 0)
+			if err = ino.UnmarshalKey(item.K); err != nil {
+				return
+			}
+			if err = ino.UnmarshalValue(item.V); err != nil {
+				return
+			}
+			mp.inodeTree.ReplaceOrInsert(ino, true)
+			mp.checkAndInsertFreeList(ino)
+			if mp.config.Cursor < ino.Inode {
+				mp.config.Cursor = ino.Inode
+			}
+			result.Inodes++
+		case opFSMCreateDentry:
+			dentry := &Dentry{}
+			if err = dentry.UnmarshalKey(item.K); err != nil {
+				return
+			}
+			if err = dentry.UnmarshalValue(item.V); err != nil {
+				return
+			}
+			mp.dentryTree.ReplaceOrInsert(dentry, true)
+			mp.foldDentry(dentry)
+			result.Dentries++
+		case opFSMSetXAttr:
+			var extend *Extend
+			if extend, err = NewExtendFromBytes(item.V); err != nil {
+				return
+			}
+			mp.extendTree.ReplaceOrInsert(extend, true)
+			result.Extends++
+		case opFSMCreateMultipart:
+			mp.multipartTree.ReplaceOrInsert(MultipartFromBytes(item.V), true)
+			result.Multiparts++
+		}
+	}
+	mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventLost})
+	return
+}
@@ -0,0 +1,85 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestExportImportSnapshot(t *testing.T) {
+	src := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	src.inodeTree.ReplaceOrInsert(NewInode(1, uint32(os.ModeDir)), true)
+	src.inodeTree.ReplaceOrInsert(NewInode(10, 0644), true)
+	src.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 10, Type: 0644}, true)
+	extend := NewExtend(10)
+	extend.Put([]byte("user.k"), []byte("v"))
+	src.extendTree.ReplaceOrInsert(extend, true)
+	msg := &storeMsg{
+		inodeTree:     src.getInodeTree(),
+		dentryTree:    src.getDentryTree(),
+		extendTree:    src.extendTree.GetTree(),
+		multipartTree: src.multipartTree.GetTree(),
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := writeSnapshot(buf, &proto.MetaPartitionExportHeader{Version: exportVersion}, msg); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	dst := NewMetaPartition(&MetaPartitionConfig{PartitionId: 2, Start: 1, End: 100}, nil).(*metaPartition)
+	if _, err := readExportRecord(buf); err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	batch := bytes.NewBuffer(nil)
+	for {
+		data, err := readExportRecord(buf)
+		if err != nil {
+			t.Fatalf("read record: %v", err)
+		}
+		if len(data) == 0 {
+			break
+		}
+		if err = dst.checkImportItem(data); err != nil {
+			t.Fatalf("check record: %v", err)
+		}
+		writeExportRecord(batch, data)
+	}
+	result, err := dst.fsmImportBatch(batch.Bytes(), 1)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if result.Inodes != 2 || result.Dentries != 1 || result.Extends != 1 {
+		t.Fatalf("unexpected result %v", *result)
+	}
+	if item := dst.dentryTree.Get(&Dentry{ParentId: 1, Name: "a"}); item == nil || item.(*Dentry).Inode != 10 {
+		t.Fatalf("expect the dentry of inode 10, got %v", item)
+	}
+	if item := dst.extendTree.Get(NewExtend(10)); item == nil {
+		t.Fatalf("expect the extend attributes of inode 10")
+	}
+	if dst.config.Cursor != 10 {
+		t.Fatalf("expect the cursor 10, got %v", dst.config.Cursor)
+	}
+
+	dst.config.End = 5
+	ino := NewInode(10, 0644)
+	data, _ := NewMetaItem(opFSMCreateInode, ino.MarshalKey(), ino.MarshalValue()).MarshalBinary()
+	if err = dst.checkImportItem(data); err == nil {
+		t.Fatalf("expect the inode out of the range refused")
+	}
+}
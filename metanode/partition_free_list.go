@@ -47,6 +47,11 @@ func (mp *metaPartition) startFreeList() (err error) {
 	// start vol update ticket
 	go mp.updateVolWorker()
 	go mp.deleteWorker()
+	go mp.orphanScanWorker()
+	go mp.expirationWorker()
+	go mp.lifecycleWorker()
+	go mp.replicaCheckWorker()
+	go mp.txRecoveryWorker()
 	mp.startToDeleteExtents()
 	return
 }
@@ -64,6 +69,19 @@ func (mp *metaPartition) updateVolView(convert func(view *proto.DataPartitionsVi
 	return nil
 }
 
+// updateVolSettings fetches the seconds to keep the deleted inodes, the expiration rules and the lifecycle rules of
+// the volume from master.
+func (mp *metaPartition) updateVolSettings() {
+	view, err := masterClient.AdminAPI().GetVolumeSimpleInfo(mp.config.VolName)
+	if err != nil {
+		log.LogErrorf("updateVolWorker: get volume info fail: volume(%v) err(%v)", mp.config.VolName, err)
+		return
+	}
+	mp.vol.SetInodeRetention(int64(view.InodeRetention))
+	mp.vol.SetExpirationRules(view.ExpirationRules)
+	mp.vol.SetLifecycleRules(view.LifecycleRules)
+}
+
 func (mp *metaPartition) updateVolWorker() {
 	t := time.NewTicker(UpdateVolTicket)
 	var convert = func(view *proto.DataPartitionsView) *DataPartitionsView {
@@ -81,6 +99,7 @@ func (mp *metaPartition) updateVolWorker() {
 		return newView
 	}
 	mp.updateVolView(convert)
+	mp.updateVolSettings()
 	for {
 		select {
 		case <-mp.stopC:
@@ -88,6 +107,7 @@ func (mp *metaPartition) updateVolWorker() {
 			return
 		case <-t.C:
 			mp.updateVolView(convert)
+			mp.updateVolSettings()
 		}
 	}
 }
@@ -129,6 +149,7 @@ func (mp *metaPartition) deleteWorker() {
 		}
 
 		batchCount := DeleteBatchCount()
+		retention := mp.vol.InodeRetention()
 		delayDeleteInos := make([]uint64, 0)
 		for idx = 0; idx < int(batchCount); idx++ {
 			// batch get free inoded from the freeList
@@ -137,9 +158,12 @@ func (mp *metaPartition) deleteWorker() {
 				break
 			}
 
-			//check inode nlink == 0 and deletMarkFlag unset
 			if inode, ok := mp.inodeTree.CopyGet(&Inode{Inode: ino}).(*Inode); ok {
-				if inode.ShouldDelayDelete() {
+				// the inode is linked again after it is deleted
+				if !inode.ShouldDelete() && !inode.IsTempFile() {
+					continue
+				}
+				if inode.ShouldDelayDelete(retention) {
 					log.LogDebugf("[metaPartition] deleteWorker delay to remove inode: %v as NLink is 0", inode)
 					delayDeleteInos = append(delayDeleteInos, ino)
 					continue
@@ -227,6 +251,10 @@ func (mp *metaPartition) deleteMarkedInodes(inoSlice []uint64) {
 		}
 		inode.Extents.Range(func(ek proto.ExtentKey) bool {
 			ext := &ek
+			if mp.isVolSnapshotExtent(ext) {
+				log.LogWritef("mp(%v) ino(%v) keepSnapshotExtent(%v)", mp.config.PartitionId, inode.Inode, ext.String())
+				return true
+			}
 			_, ok := allDeleteExtents[ext.GetExtentKey()]
 			if !ok {
 				allDeleteExtents[ext.GetExtentKey()] = inode.Inode
@@ -306,6 +334,10 @@ func (mp *metaPartition) notifyRaftFollowerToFreeInodes(wg *sync.WaitGroup, targ
 }
 
 func (mp *metaPartition) doDeleteMarkedInodes(ext *proto.ExtentKey) (err error) {
+	// the extent referenced by a volume snapshot is deleted when the snapshot is deleted
+	if mp.isVolSnapshotExtent(ext) {
+		return
+	}
 	// get the data node view
 	dp := mp.vol.GetPartition(ext.PartitionId)
 	if dp == nil {
@@ -27,11 +27,11 @@ import (
 	"os"
 	"path"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	raftproto "github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/exporter"
 	"github.com/chubaofs/chubaofs/util/log"
-	"github.com/tiglabs/raft"
-	raftproto "github.com/tiglabs/raft/proto"
 )
 
 // Apply applies the given operational commands.
@@ -73,7 +73,11 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmExtentsTruncate(ino)
+		iresp := mp.fsmExtentsTruncate(ino)
+		if iresp.Status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventModify, Inode: ino.Inode})
+		}
+		resp = iresp
 	case opFSMCreateLinkInode:
 		ino := NewInode(0, 0)
 		if err = ino.Unmarshal(msg.V); err != nil {
@@ -86,6 +90,72 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		resp = mp.fsmEvictInode(ino)
+	case opFSMPurgeOrphanInode:
+		inodes, err := InodeBatchUnmarshal(msg.V)
+		if err != nil {
+			return nil, err
+		}
+		mp.fsmPurgeOrphanInodes(inodes)
+	case opFSMTxPrepare:
+		tx := &proto.TxInfo{}
+		if err = json.Unmarshal(msg.V, tx); err != nil {
+			return
+		}
+		resp = mp.fsmTxPrepare(tx)
+	case opFSMTxCommit:
+		req := &proto.TxRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmTxCommit(req, index)
+	case opFSMTxAbort:
+		req := &proto.TxRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmTxAbort(req.TxID)
+	case opFSMTxDone:
+		req := &proto.TxRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmTxDone(req.TxID)
+	case opFSMExportTick:
+		// the trees are copied at the index for the export, it is dropped by the followers
+		resp = &storeMsg{
+			command:       opFSMExportTick,
+			applyIndex:    index,
+			inodeTree:     mp.getInodeTree(),
+			dentryTree:    mp.getDentryTree(),
+			extendTree:    mp.extendTree.GetTree(),
+			multipartTree: mp.multipartTree.GetTree(),
+		}
+	case opFSMImportBatch:
+		resp, err = mp.fsmImportBatch(msg.V, index)
+	case opFSMReplicaCheck:
+		var bounds []uint64
+		if err = json.Unmarshal(msg.V, &bounds); err != nil {
+			return
+		}
+		resp = mp.fsmReplicaCheck(index, bounds)
+	case opFSMFixNLink:
+		var mismatches []*proto.NLinkMismatch
+		if err = json.Unmarshal(msg.V, &mismatches); err != nil {
+			return
+		}
+		resp = mp.fsmFixNLinks(mismatches)
+	case opFSMPurgeInode:
+		ino := NewInode(0, 0)
+		if err = ino.UnmarshalKey(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmPurgeInode(ino)
+	case opFSMCancelPurge:
+		ino := NewInode(0, 0)
+		if err = ino.UnmarshalKey(msg.V); err != nil {
+			return
+		}
+		resp = mp.fsmCancelPurge(ino)
 	case opFSMEvictInodeBatch:
 		inodes, err := InodeBatchUnmarshal(msg.V)
 		if err != nil {
@@ -99,30 +169,65 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		err = mp.fsmSetAttr(req)
+		mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventAttr, Inode: req.Inode})
 	case opFSMCreateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmCreateDentry(den, false)
+		status := mp.fsmCreateDentry(den, false)
+		if status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventCreate, ParentID: den.ParentId, Name: den.Name,
+				Inode: den.Inode, Mode: den.Type})
+		}
+		resp = status
 	case opFSMDeleteDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmDeleteDentry(den, false)
+		dresp := mp.fsmDeleteDentry(den, false)
+		if dresp.Status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventDelete, ParentID: den.ParentId, Name: den.Name,
+				Inode: dresp.Msg.Inode, Mode: dresp.Msg.Type})
+		}
+		resp = dresp
+	case opFSMRenameDentry:
+		req := &proto.DeleteDentryRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		dresp := mp.fsmRenameDentry(req)
+		if dresp.Status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventRename, ParentID: req.ParentID, Name: req.Name,
+				Inode: dresp.Msg.Inode, Mode: dresp.Msg.Type, DstParentID: req.DstParentID, DstName: req.DstName})
+		}
+		resp = dresp
 	case opFSMDeleteDentryBatch:
 		db, err := DentryBatchUnmarshal(msg.V)
 		if err != nil {
 			return nil, err
 		}
-		resp = mp.fsmBatchDeleteDentry(db)
+		dresps := mp.fsmBatchDeleteDentry(db)
+		for i, dresp := range dresps {
+			if dresp.Status == proto.OpOk {
+				mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventDelete, ParentID: db[i].ParentId,
+					Name: db[i].Name, Inode: db[i].Inode, Mode: db[i].Type})
+			}
+		}
+		resp = dresps
 	case opFSMUpdateDentry:
 		den := &Dentry{}
 		if err = den.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmUpdateDentry(den)
+		ino := den.Inode
+		dresp := mp.fsmUpdateDentry(den)
+		if dresp.Status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventReplace, ParentID: den.ParentId, Name: den.Name,
+				Inode: ino, Mode: den.Type})
+		}
+		resp = dresp
 	case opFSMUpdatePartition:
 		req := &UpdatePartitionReq{}
 		if err = json.Unmarshal(msg.V, req); err != nil {
@@ -134,12 +239,17 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		if err = ino.Unmarshal(msg.V); err != nil {
 			return
 		}
-		resp = mp.fsmAppendExtents(ino)
+		status := mp.fsmAppendExtents(ino)
+		if status == proto.OpOk {
+			mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventModify, Inode: ino.Inode})
+		}
+		resp = status
 	case opFSMStoreTick:
 		inodeTree := mp.getInodeTree()
 		dentryTree := mp.getDentryTree()
 		extendTree := mp.extendTree.GetTree()
 		multipartTree := mp.multipartTree.GetTree()
+		txTree := mp.txTree.GetTree()
 		msg := &storeMsg{
 			command:       opFSMStoreTick,
 			applyIndex:    index,
@@ -147,6 +257,7 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			dentryTree:    dentryTree,
 			extendTree:    extendTree,
 			multipartTree: multipartTree,
+			txTree:        txTree,
 		}
 		mp.storeChan <- msg
 	case opFSMInternalDeleteInode:
@@ -163,12 +274,14 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 			return
 		}
 		err = mp.fsmSetXAttr(extend)
+		mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventXAttr, Inode: extend.inode})
 	case opFSMRemoveXAttr:
 		var extend *Extend
 		if extend, err = NewExtendFromBytes(msg.V); err != nil {
 			return
 		}
 		err = mp.fsmRemoveXAttr(extend)
+		mp.recordEvent(index, &proto.MetaEvent{Type: proto.MetaEventXAttr, Inode: extend.inode})
 	case opFSMCreateMultipart:
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
@@ -181,6 +294,30 @@ func (mp *metaPartition) Apply(command []byte, index uint64) (resp interface{},
 		var multipart *Multipart
 		multipart = MultipartFromBytes(msg.V)
 		resp = mp.fsmAppendMultipart(multipart)
+	case opFSMVolSnapshot:
+		req := &proto.MetaPartitionSnapshotRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmVolSnapshot(req)
+	case opFSMSetLock:
+		req := &proto.SetLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmSetLock(req)
+	case opFSMRenewLock:
+		req := &proto.RenewLockRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		mp.fsmRenewLock(req)
+	case opFSMUpdateSummary:
+		req := &proto.UpdateSummaryRequest{}
+		if err = json.Unmarshal(msg.V, req); err != nil {
+			return
+		}
+		resp = mp.fsmUpdateSummary(req)
 	case opFSMSyncCursor:
 		var cursor uint64
 		cursor = binary.BigEndian.Uint64(msg.V)
@@ -217,7 +354,11 @@ func (mp *metaPartition) ApplyMemberChange(confChange *raftproto.ConfChange, ind
 		}
 		updated, err = mp.confRemoveNode(req, index)
 	case raftproto.ConfUpdateNode:
-		//updated, err = mp.confUpdateNode(req, index)
+		req := &proto.PromoteMetaPartitionRaftMemberRequest{}
+		if err = json.Unmarshal(confChange.Context, req); err != nil {
+			return
+		}
+		updated, err = mp.confPromoteNode(req, index)
 	}
 	if err != nil {
 		return
@@ -245,19 +386,26 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 		index         int
 		appIndexID    uint64
 		cursor        uint64
-		inodeTree     = NewBtree()
-		dentryTree    = NewBtree()
+		inodeTree     = mp.newTree(inodeTreeCodec)
+		dentryTree    = mp.newTree(dentryTreeCodec)
 		extendTree    = NewBtree()
 		multipartTree = NewBtree()
+		txTree        = NewBtree()
 	)
 	defer func() {
 		if err == io.EOF {
 			mp.applyID = appIndexID
+			mp.inodeTree.Release()
+			mp.dentryTree.Release()
 			mp.inodeTree = inodeTree
 			mp.dentryTree = dentryTree
+			mp.rebuildFoldTree()
 			mp.extendTree = extendTree
 			mp.multipartTree = multipartTree
+			mp.txTree = txTree
+			mp.rebuildTxLocks()
 			mp.config.Cursor = cursor
+			mp.events.Reset(appIndexID)
 			err = nil
 			// store message
 			mp.storeChan <- &storeMsg{
@@ -267,11 +415,14 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 				dentryTree:    mp.dentryTree,
 				extendTree:    mp.extendTree,
 				multipartTree: mp.multipartTree,
+				txTree:        mp.txTree,
 			}
 			mp.extReset <- struct{}{}
 			log.LogDebugf("ApplySnapshot: finish with EOF: partitionID(%v) applyID(%v)", mp.config.PartitionId, mp.applyID)
 			return
 		}
+		inodeTree.Release()
+		dentryTree.Release()
 		log.LogErrorf("ApplySnapshot: stop with error: partitionID(%v) err(%v)", mp.config.PartitionId, err)
 	}()
 	for {
@@ -323,6 +474,13 @@ func (mp *metaPartition) ApplySnapshot(peers []raftproto.Peer, iter raftproto.Sn
 			var multipart = MultipartFromBytes(snap.V)
 			multipartTree.ReplaceOrInsert(multipart, true)
 			log.LogDebugf("ApplySnapshot: create multipart: partitionID(%v) multipart(%v)", mp.config.PartitionId, multipart)
+		case opFSMTxPrepare:
+			var record *TxRecord
+			if record, err = TxRecordFromBytes(snap.V); err != nil {
+				return
+			}
+			txTree.ReplaceOrInsert(record, true)
+			log.LogDebugf("ApplySnapshot: create transaction: partitionID(%v) txID(%v)", mp.config.PartitionId, record.TxID)
 		case opExtentFileSnapshot:
 			fileName := string(snap.K)
 			fileName = path.Join(mp.config.RootDir, fileName)
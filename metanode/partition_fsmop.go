@@ -107,6 +107,20 @@ func (mp *metaPartition) confAddNode(req *proto.AddMetaPartitionRaftMemberReques
 	return
 }
 
+// confPromoteNode promotes the learner to a voter of the raft group.
+func (mp *metaPartition) confPromoteNode(req *proto.PromoteMetaPartitionRaftMemberRequest, index uint64) (updated bool, err error) {
+	for i, peer := range mp.config.Peers {
+		if peer.ID == req.PromotePeer.ID && peer.IsLearner {
+			mp.config.Peers[i].IsLearner = false
+			updated = true
+			break
+		}
+	}
+	log.LogInfof("PromoteRaftNode PartitionID(%v) nodeID(%v) peer(%v) updated(%v) index(%v)",
+		req.PartitionId, mp.config.NodeId, req.PromotePeer, updated, index)
+	return
+}
+
 func (mp *metaPartition) confRemoveNode(req *proto.RemoveMetaPartitionRaftMemberRequest, index uint64) (updated bool, err error) {
 	var canRemoveSelf bool
 	if canRemoveSelf, err = mp.canRemoveSelf(); err != nil {
@@ -226,6 +240,23 @@ func (mp *metaPartition) CanRemoveRaftMember(peer proto.Peer) error {
 	return fmt.Errorf("downReplicas(%v) too much,so donnot offline (%v)", downReplicas, peer)
 }
 
+// CanPromoteRaftMember checks if the learner has caught up with the leader and can be promoted to a voter.
+func (mp *metaPartition) CanPromoteRaftMember(peer proto.Peer) error {
+	for _, p := range mp.config.Peers {
+		if p.ID != peer.ID {
+			continue
+		}
+		if !p.IsLearner {
+			return fmt.Errorf("peer(%v) is not a learner", peer)
+		}
+		if !mp.raftPartition.IsReplicaCaughtUp(peer.ID, defaultLearnerPromoteMaxLag) {
+			return fmt.Errorf("learner(%v) has not caught up with the leader", peer)
+		}
+		return nil
+	}
+	return fmt.Errorf("peer(%v) not exists", peer)
+}
+
 func (mp *metaPartition) IsEquareCreateMetaPartitionRequst(request *proto.CreateMetaPartitionRequest) (err error) {
 	if len(mp.config.Peers) != len(request.Members) {
 		return fmt.Errorf("Exsit unavali Partition(%v) partitionHosts(%v) requestHosts(%v)", mp.config.PartitionId, mp.config.Peers, request.Members)
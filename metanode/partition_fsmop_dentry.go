@@ -17,8 +17,6 @@ package metanode
 import (
 	"strings"
 
-	"github.com/chubaofs/chubaofs/util/btree"
-
 	"github.com/chubaofs/chubaofs/proto"
 )
 
@@ -37,6 +35,30 @@ func NewDentryResponse() *DentryResponse {
 func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 	forceUpdate bool) (status uint8) {
 	status = proto.OpOk
+	// the dentry whose name differs only in case, which is renamed in place if it is the same inode
+	var renamed *Dentry
+	if name := mp.resolveDentryName(dentry.ParentId, dentry.Name); name != dentry.Name {
+		if d, _ := mp.getDentry(&Dentry{ParentId: dentry.ParentId, Name: name}); d != nil && d.Inode == dentry.Inode &&
+			proto.OsModeType(d.Type) == proto.OsModeType(dentry.Type) {
+			renamed = d
+		} else {
+			dentry.Name = name
+		}
+	}
+	if !forceUpdate && mp.txLocked(dentry.ParentId, dentry.Name) {
+		status = proto.OpAgain
+		return
+	}
+	if renamed != nil {
+		if !forceUpdate && mp.txLocked(renamed.ParentId, renamed.Name) {
+			status = proto.OpAgain
+			return
+		}
+		mp.dentryTree.Delete(renamed)
+		mp.dentryTree.ReplaceOrInsert(dentry, true)
+		mp.foldDentry(dentry)
+		return
+	}
 	item := mp.inodeTree.CopyGet(NewInode(dentry.ParentId, 0))
 	var parIno *Inode
 	if !forceUpdate {
@@ -69,9 +91,12 @@ func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 
 		status = proto.OpExistErr
 	} else {
+		mp.foldDentry(dentry)
 		if !forceUpdate {
+			mp.versionInode(parIno)
 			parIno.IncNLink()
 			parIno.SetMtime()
+			mp.inodeTree.Update(parIno)
 		}
 	}
 
@@ -81,6 +106,9 @@ func (mp *metaPartition) fsmCreateDentry(dentry *Dentry,
 // Query a dentry from the dentry tree with specified dentry info.
 func (mp *metaPartition) getDentry(dentry *Dentry) (*Dentry, uint8) {
 	status := proto.OpOk
+	if name := mp.resolveDentryName(dentry.ParentId, dentry.Name); name != dentry.Name {
+		dentry = &Dentry{ParentId: dentry.ParentId, Name: name}
+	}
 	item := mp.dentryTree.Get(dentry)
 	if item == nil {
 		status = proto.OpNotExistErr
@@ -95,18 +123,16 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 	resp *DentryResponse) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
+	dentry.Name = mp.resolveDentryName(dentry.ParentId, dentry.Name)
+	if mp.txLocked(dentry.ParentId, dentry.Name) {
+		resp.Status = proto.OpAgain
+		return
+	}
 
 	var item interface{}
 	if checkInode {
-		item = mp.dentryTree.Execute(func(tree *btree.BTree) interface{} {
-			d := tree.CopyGet(dentry)
-			if d == nil {
-				return nil
-			}
-			if d.(*Dentry).Inode != dentry.Inode {
-				return nil
-			}
-			return mp.dentryTree.tree.Delete(dentry)
+		item = mp.dentryTree.DeleteIf(dentry, func(d BtreeItem) bool {
+			return d.(*Dentry).Inode == dentry.Inode
 		})
 	} else {
 		item = mp.dentryTree.Delete(dentry)
@@ -116,13 +142,16 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 		resp.Status = proto.OpNotExistErr
 		return
 	} else {
+		mp.unfoldDentry(dentry)
 		mp.inodeTree.CopyFind(NewInode(dentry.ParentId, 0),
 			func(item BtreeItem) {
 				if item != nil {
 					ino := item.(*Inode)
 					if !ino.ShouldDelete() {
+						mp.versionInode(ino)
 						item.(*Inode).DecNLink()
 						item.(*Inode).SetMtime()
+						mp.inodeTree.Update(item)
 					}
 				}
 			})
@@ -131,6 +160,20 @@ func (mp *metaPartition) fsmDeleteDentry(dentry *Dentry, checkInode bool) (
 	return
 }
 
+// fsmRenameDentry deletes the source dentry of a rename. If the rename only changes the case of the name, the dentry
+// has been renamed in place when the destination is created, so it is returned instead.
+func (mp *metaPartition) fsmRenameDentry(req *proto.DeleteDentryRequest) (resp *DentryResponse) {
+	if !mp.isCaseRename(req.ParentID, req.Name, req.DstParentID, req.DstName) {
+		return mp.fsmDeleteDentry(&Dentry{ParentId: req.ParentID, Name: req.Name}, false)
+	}
+	resp = NewDentryResponse()
+	var dentry *Dentry
+	if dentry, resp.Status = mp.getDentry(&Dentry{ParentId: req.DstParentID, Name: req.DstName}); dentry != nil {
+		resp.Msg = dentry
+	}
+	return
+}
+
 // batch Delete dentry from the dentry tree.
 func (mp *metaPartition) fsmBatchDeleteDentry(db DentryBatch) []*DentryResponse {
 	result := make([]*DentryResponse, 0, len(db))
@@ -144,6 +187,11 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 	resp *DentryResponse) {
 	resp = NewDentryResponse()
 	resp.Status = proto.OpOk
+	dentry.Name = mp.resolveDentryName(dentry.ParentId, dentry.Name)
+	if mp.txLocked(dentry.ParentId, dentry.Name) {
+		resp.Status = proto.OpAgain
+		return
+	}
 	mp.dentryTree.CopyFind(dentry, func(item BtreeItem) {
 		if item == nil {
 			resp.Status = proto.OpNotExistErr
@@ -151,6 +199,7 @@ func (mp *metaPartition) fsmUpdateDentry(dentry *Dentry) (
 		}
 		d := item.(*Dentry)
 		d.Inode, dentry.Inode = dentry.Inode, d.Inode
+		mp.dentryTree.Update(d)
 		resp.Msg = dentry
 	})
 	return
@@ -164,12 +213,21 @@ func (mp *metaPartition) readDir(req *ReadDirReq) (resp *ReadDirResp) {
 	resp = &ReadDirResp{}
 	begDentry := &Dentry{
 		ParentId: req.ParentID,
+		Name:     req.Marker,
 	}
 	endDentry := &Dentry{
 		ParentId: req.ParentID + 1,
 	}
 	mp.dentryTree.AscendRange(begDentry, endDentry, func(i BtreeItem) bool {
 		d := i.(*Dentry)
+		if req.Marker != "" && d.Name == req.Marker {
+			return true
+		}
+		if req.Limit > 0 && uint64(len(resp.Children)) >= req.Limit {
+			// there are more dentries than the limit
+			resp.NextMarker = resp.Children[len(resp.Children)-1].Name
+			return false
+		}
 		resp.Children = append(resp.Children, proto.Dentry{
 			Inode: d.Inode,
 			Type:  d.Type,
@@ -0,0 +1,52 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReadDirLimit(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1}, nil).(*metaPartition)
+	for i := 0; i < 5; i++ {
+		mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: fmt.Sprintf("d%v", i), Inode: uint64(10 + i)}, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "other", Inode: 20}, true)
+
+	if resp := mp.readDir(&ReadDirReq{ParentID: 1}); len(resp.Children) != 5 || resp.NextMarker != "" {
+		t.Fatalf("expect all 5 dentries, got %v next %v", len(resp.Children), resp.NextMarker)
+	}
+	var names []string
+	marker := ""
+	for {
+		resp := mp.readDir(&ReadDirReq{ParentID: 1, Marker: marker, Limit: 2})
+		if len(resp.Children) > 2 {
+			t.Fatalf("expect at most 2 dentries, got %v", len(resp.Children))
+		}
+		for _, child := range resp.Children {
+			names = append(names, child.Name)
+		}
+		if marker = resp.NextMarker; marker == "" {
+			break
+		}
+	}
+	if fmt.Sprint(names) != "[d0 d1 d2 d3 d4]" {
+		t.Fatalf("unexpected dentries %v", names)
+	}
+	if resp := mp.readDir(&ReadDirReq{ParentID: 1, Marker: "d3", Limit: 1}); len(resp.Children) != 1 || resp.NextMarker != "" {
+		t.Fatalf("expect the last dentry without next marker, got %v next %v", resp.Children, resp.NextMarker)
+	}
+}
@@ -14,6 +14,10 @@
 
 package metanode
 
+import (
+	"github.com/chubaofs/chubaofs/proto"
+)
+
 type ExtendOpResult struct {
 	Status uint8
 	Extend *Extend
@@ -44,3 +48,25 @@ func (mp *metaPartition) fsmRemoveXAttr(extend *Extend) (err error) {
 	})
 	return
 }
+
+// fsmUpdateSummary adds the delta to the summary of the directory, it returns nil if the directory does not exist
+// any more, e.g. the delta is sent after the directory is removed.
+func (mp *metaPartition) fsmUpdateSummary(req *proto.UpdateSummaryRequest) (summary *proto.DirSummary) {
+	item := mp.inodeTree.Get(NewInode(req.Inode, 0))
+	if item == nil || !proto.IsDir(item.(*Inode).Type) {
+		return
+	}
+	summary = &proto.DirSummary{}
+	if treeItem := mp.extendTree.CopyGet(NewExtend(req.Inode)); treeItem != nil {
+		if value, exist := treeItem.(*Extend).Get([]byte(proto.SummaryXAttrKey)); exist {
+			if parsed, err := proto.ParseDirSummary(value); err == nil {
+				summary = parsed
+			}
+		}
+	}
+	summary.Add(&req.Delta)
+	extend := NewExtend(req.Inode)
+	extend.Put([]byte(proto.SummaryXAttrKey), summary.Marshal())
+	mp.fsmSetXAttr(extend)
+	return
+}
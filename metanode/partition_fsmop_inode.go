@@ -36,6 +36,8 @@ func NewInodeResponse() *InodeResponse {
 // Create and inode and attach it to the inode tree.
 func (mp *metaPartition) fsmCreateInode(ino *Inode) (status uint8) {
 	status = proto.OpOk
+	// the inode is not seen by the volume snapshots taken before it is created
+	ino.Epoch = mp.latestVolSnapshot()
 	if _, ok := mp.inodeTree.ReplaceOrInsert(ino, false); !ok {
 		status = proto.OpExistErr
 	}
@@ -55,7 +57,13 @@ func (mp *metaPartition) fsmCreateLinkInode(ino *Inode) (resp *InodeResponse) {
 		resp.Status = proto.OpNotExistErr
 		return
 	}
+	mp.versionInode(i)
 	i.IncNLink()
+	// the deleted inode held from being purged is restored by the link
+	i.DoWriteFunc(func() {
+		i.Flag &^= PurgeHoldFlag
+	})
+	mp.inodeTree.Update(i)
 	resp.Msg = i
 	return
 }
@@ -122,8 +130,10 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode) (resp *InodeResponse) {
 	}
 
 	resp.Msg = inode
+	mp.versionInode(inode)
 
-	if inode.IsEmptyDir() {
+	deleted := inode.IsEmptyDir()
+	if deleted {
 		mp.inodeTree.Delete(inode)
 	}
 
@@ -138,6 +148,9 @@ func (mp *metaPartition) fsmUnlinkInode(ino *Inode) (resp *InodeResponse) {
 			}
 		})
 	}
+	if !deleted {
+		mp.inodeTree.Update(inode)
+	}
 
 	return
 }
@@ -194,6 +207,14 @@ func (mp *metaPartition) internalDeleteBatch(val []byte) error {
 }
 
 func (mp *metaPartition) internalDeleteInode(ino *Inode) {
+	// the inode may be restored by rolling back to a volume snapshot after it is chosen to be freed
+	if item := mp.inodeTree.CopyGet(ino); item != nil {
+		if inode := item.(*Inode); !inode.ShouldDelete() && !inode.IsTempFile() {
+			log.LogWarnf("internalDeleteInode: skip restored inode: partitionID(%v) inode(%v)",
+				mp.config.PartitionId, inode.Inode)
+			return
+		}
+	}
 	mp.inodeTree.Delete(ino)
 	mp.freeList.Remove(ino.Inode)
 	mp.extendTree.Delete(&Extend{inode: ino.Inode}) // Also delete extend attribute.
@@ -212,8 +233,10 @@ func (mp *metaPartition) fsmAppendExtents(ino *Inode) (status uint8) {
 		status = proto.OpNotExistErr
 		return
 	}
+	mp.versionInode(ino2)
 	eks := ino.Extents.CopyExtents()
-	delExtents := ino2.AppendExtents(eks, ino.ModifyTime)
+	delExtents := ino2.excludeVersionExtents(ino2.AppendExtents(eks, ino.ModifyTime))
+	mp.inodeTree.Update(ino2)
 	log.LogInfof("fsmAppendExtents inode(%v) exts(%v)", ino2.Inode, delExtents)
 	mp.extDelCh <- delExtents
 	return
@@ -238,7 +261,9 @@ func (mp *metaPartition) fsmExtentsTruncate(ino *Inode) (resp *InodeResponse) {
 		return
 	}
 
-	delExtents := i.ExtentsTruncate(ino.Size, ino.ModifyTime)
+	mp.versionInode(i)
+	delExtents := i.excludeVersionExtents(i.ExtentsTruncate(ino.Size, ino.ModifyTime))
+	mp.inodeTree.Update(i)
 
 	// now we should delete the extent
 	log.LogInfof("fsmExtentsTruncate inode(%v) exts(%v)", i.Inode, delExtents)
@@ -261,13 +286,17 @@ func (mp *metaPartition) fsmEvictInode(ino *Inode) (resp *InodeResponse) {
 	}
 	if proto.IsDir(i.Type) {
 		if i.IsEmptyDir() {
+			mp.versionInode(i)
 			i.SetDeleteMark()
+			mp.inodeTree.Update(i)
 		}
 		return
 	}
 
 	if i.IsTempFile() {
+		mp.versionInode(i)
 		i.SetDeleteMark()
+		mp.inodeTree.Update(i)
 		mp.freeList.Push(i.Inode)
 	}
 	return
@@ -280,6 +309,27 @@ func (mp *metaPartition) fsmBatchEvictInode(ib InodeBatch) (resp []*InodeRespons
 	return
 }
 
+// fsmPurgeOrphanInodes marks the orphan files deleted, and puts them into the free list.
+func (mp *metaPartition) fsmPurgeOrphanInodes(ib InodeBatch) {
+	for _, ino := range ib {
+		item := mp.inodeTree.CopyGet(ino)
+		if item == nil {
+			continue
+		}
+		i := item.(*Inode)
+		if proto.IsDir(i.Type) || i.ShouldDelete() {
+			continue
+		}
+		mp.versionInode(i)
+		i.DoWriteFunc(func() {
+			i.NLink = 0
+			i.Flag |= DeleteMarkFlag
+		})
+		mp.inodeTree.Update(i)
+		mp.freeList.Push(i.Inode)
+	}
+}
+
 func (mp *metaPartition) checkAndInsertFreeList(ino *Inode) {
 	if proto.IsDir(ino.Type) {
 		return
@@ -302,6 +352,8 @@ func (mp *metaPartition) fsmSetAttr(req *SetattrRequest) (err error) {
 	if ino.ShouldDelete() {
 		return
 	}
+	mp.versionInode(ino)
 	ino.SetAttr(req)
+	mp.inodeTree.Update(ino)
 	return
 }
@@ -0,0 +1,81 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The expiry of the locks is decided by the time in the requests set by the leader, so that all the replicas
+// keep the same locks.
+
+func (mp *metaPartition) fsmSetLock(req *proto.SetLockRequest) (status uint8) {
+	mp.fileLocksLock.Lock()
+	defer mp.fileLocksLock.Unlock()
+	locks := mp.fileLocks[req.Inode].Expire(req.Now)
+	lock := req.Lock
+	if lock.Type != proto.LockTypeUnlock && locks.Conflict(&lock, req.Now) != nil {
+		status = proto.OpExistErr
+	} else {
+		lock.Expire = req.Now + req.Lease*int64(time.Second)
+		locks = locks.Set(&lock)
+		status = proto.OpOk
+	}
+	if len(locks) == 0 {
+		delete(mp.fileLocks, req.Inode)
+	} else {
+		mp.fileLocks[req.Inode] = locks
+	}
+	return
+}
+
+func (mp *metaPartition) fsmRenewLock(req *proto.RenewLockRequest) {
+	mp.fileLocksLock.Lock()
+	defer mp.fileLocksLock.Unlock()
+	expire := req.Now + req.Lease*int64(time.Second)
+	for _, item := range req.Items {
+		locks := mp.fileLocks[item.Inode].Expire(req.Now)
+		for _, held := range item.Locks {
+			held.Session = req.Session
+			var renewed bool
+			for _, lock := range locks {
+				if lock.Same(held) {
+					lock.Expire = expire
+					renewed = true
+					break
+				}
+			}
+			if !renewed && locks.Conflict(held, req.Now) == nil {
+				held.Expire = expire
+				locks = locks.Set(held)
+			}
+		}
+		if len(locks) == 0 {
+			delete(mp.fileLocks, item.Inode)
+		} else {
+			mp.fileLocks[item.Inode] = locks
+		}
+	}
+	// purge the expired locks of the files which are not touched any more
+	for ino, locks := range mp.fileLocks {
+		if locks = locks.Expire(req.Now); len(locks) == 0 {
+			delete(mp.fileLocks, ino)
+		} else {
+			mp.fileLocks[ino] = locks
+		}
+	}
+}
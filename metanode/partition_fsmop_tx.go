@@ -0,0 +1,308 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// TxPrepareResp defines the result of preparing a transaction on the meta partition.
+type TxPrepareResp struct {
+	Status  uint8
+	Record  *TxRecord
+	Existed bool // the transaction is prepared before
+}
+
+func (mp *metaPartition) getTxRecord(txID string) *TxRecord {
+	item := mp.txTree.Get(&TxRecord{TxInfo: proto.TxInfo{TxID: txID}})
+	if item == nil {
+		return nil
+	}
+	return item.(*TxRecord)
+}
+
+// txLocked checks if the dentry is locked by a prepared transaction.
+func (mp *metaPartition) txLocked(parentID uint64, name string) bool {
+	mp.txLock.Lock()
+	defer mp.txLock.Unlock()
+	_, ok := mp.txLocks[txDentryKey(parentID, name)]
+	return ok
+}
+
+// txUnlock unlocks the dentries of the transaction on the meta partition.
+func (mp *metaPartition) txUnlock(record *TxRecord) {
+	mp.txLock.Lock()
+	defer mp.txLock.Unlock()
+	for _, op := range record.PartitionOps(mp.config.PartitionId) {
+		if op.IsInodeOp() {
+			continue
+		}
+		key := txDentryKey(op.ParentID, op.Name)
+		if mp.txLocks[key] == record.TxID {
+			delete(mp.txLocks, key)
+		}
+	}
+}
+
+// rebuildTxLocks locks the dentries of the prepared transactions after the records are loaded.
+func (mp *metaPartition) rebuildTxLocks() {
+	mp.txLock.Lock()
+	defer mp.txLock.Unlock()
+	mp.txLocks = make(map[string]string)
+	mp.txTree.Ascend(func(i BtreeItem) bool {
+		record := i.(*TxRecord)
+		if record.State != proto.TxStatePrepared {
+			return true
+		}
+		for _, op := range record.PartitionOps(mp.config.PartitionId) {
+			if !op.IsInodeOp() {
+				mp.txLocks[txDentryKey(op.ParentID, op.Name)] = record.TxID
+			}
+		}
+		return true
+	})
+}
+
+// txValidate checks if the operation can be committed on the meta partition. A create operation which replaces an
+// existing regular file becomes an update operation. It is called with the lock of the transactions held.
+func (mp *metaPartition) txValidate(op *proto.TxOp) (status uint8) {
+	if op.IsInodeOp() {
+		return mp.txValidateInode(op)
+	}
+	if op.Inode == 0 {
+		return proto.OpArgMismatchErr
+	}
+	if _, ok := mp.txLocks[txDentryKey(op.ParentID, op.Name)]; ok {
+		return proto.OpAgain
+	}
+	switch op.Type {
+	case proto.TxOpDeleteDentry:
+		item := mp.dentryTree.Get(&Dentry{ParentId: op.ParentID, Name: mp.resolveDentryName(op.ParentID, op.Name)})
+		if item == nil || item.(*Dentry).Inode != op.Inode {
+			return proto.OpNotExistErr
+		}
+	case proto.TxOpCreateDentry:
+		item := mp.inodeTree.Get(NewInode(op.ParentID, 0))
+		if item == nil || item.(*Inode).ShouldDelete() {
+			return proto.OpNotExistErr
+		}
+		if !proto.IsDir(item.(*Inode).Type) {
+			return proto.OpArgMismatchErr
+		}
+		if item = mp.dentryTree.Get(&Dentry{ParentId: op.ParentID, Name: mp.resolveDentryName(op.ParentID, op.Name)}); item == nil {
+			return proto.OpOk
+		}
+		d := item.(*Dentry)
+		if proto.OsModeType(d.Type) != proto.OsModeType(op.Mode) {
+			return proto.OpArgMismatchErr
+		}
+		if d.Name != op.Name && d.Inode == op.Inode {
+			// only the case of the name is changed by the rename
+			return proto.OpOk
+		}
+		if !op.Replace || !proto.IsRegular(op.Mode) {
+			return proto.OpExistErr
+		}
+		op.Type, op.OldInode = proto.TxOpUpdateDentry, d.Inode
+	default:
+		return proto.OpArgMismatchErr
+	}
+	return proto.OpOk
+}
+
+// txValidateInode checks if the inode operation can be applied on the meta partition. The mode of the inode linked
+// is filled in the operation.
+func (mp *metaPartition) txValidateInode(op *proto.TxOp) (status uint8) {
+	switch op.Type {
+	case proto.TxOpCreateInode:
+		if op.Inode == 0 || op.Inode < mp.config.Start || op.Inode > mp.config.End {
+			return proto.OpArgMismatchErr
+		}
+		if mp.inodeTree.Has(NewInode(op.Inode, 0)) {
+			return proto.OpExistErr
+		}
+	case proto.TxOpLinkInode:
+		item := mp.inodeTree.Get(NewInode(op.Inode, 0))
+		if item == nil || item.(*Inode).ShouldDelete() {
+			return proto.OpNotExistErr
+		}
+		if proto.IsDir(item.(*Inode).Type) {
+			return proto.OpArgMismatchErr
+		}
+		op.Mode = item.(*Inode).Type
+	}
+	return proto.OpOk
+}
+
+// fsmTxPrepare validates the operations of the transaction on the meta partition in order. The dentries of the
+// dentry operations are locked until the transaction is committed or aborted, and the inode operations are applied
+// and rolled back by the record if the transaction is aborted. The record of a prepared transaction is returned as
+// it is.
+func (mp *metaPartition) fsmTxPrepare(tx *proto.TxInfo) (resp *TxPrepareResp) {
+	resp = &TxPrepareResp{Status: proto.OpOk}
+	if record := mp.getTxRecord(tx.TxID); record != nil {
+		resp.Record, resp.Existed = record, true
+		return
+	}
+	record := NewTxRecord(tx).Copy().(*TxRecord)
+	record.State = proto.TxStatePrepared
+	mp.txLock.Lock()
+	defer mp.txLock.Unlock()
+	for _, op := range record.Ops {
+		if op.PartitionID != mp.config.PartitionId {
+			continue
+		}
+		if resp.Status = mp.txValidate(op); resp.Status != proto.OpOk {
+			return
+		}
+		record.resolveInodes()
+	}
+	for _, op := range record.PartitionOps(mp.config.PartitionId) {
+		if op.IsInodeOp() {
+			mp.txApplyInode(record, op)
+			continue
+		}
+		mp.txLocks[txDentryKey(op.ParentID, op.Name)] = record.TxID
+	}
+	mp.txTree.ReplaceOrInsert(record, true)
+	resp.Record = record
+	return
+}
+
+// txApplyInode applies the inode operation when the transaction is prepared.
+func (mp *metaPartition) txApplyInode(record *TxRecord, op *proto.TxOp) {
+	switch op.Type {
+	case proto.TxOpCreateInode:
+		ino := NewInode(op.Inode, op.Mode)
+		ino.Uid, ino.Gid = op.Uid, op.Gid
+		// the times are the same on all the replicas
+		ino.CreateTime, ino.AccessTime, ino.ModifyTime = record.CreateTime, record.CreateTime, record.CreateTime
+		mp.fsmCreateInode(ino)
+		if mp.config.Cursor < op.Inode {
+			mp.config.Cursor = op.Inode
+		}
+		if len(op.XAttrs) > 0 {
+			extend := NewExtend(op.Inode)
+			for key, value := range op.XAttrs {
+				extend.Put([]byte(key), []byte(value))
+			}
+			_ = mp.fsmSetXAttr(extend)
+		}
+	case proto.TxOpLinkInode:
+		mp.fsmCreateLinkInode(NewInode(op.Inode, 0))
+	}
+}
+
+// txRollbackInode reverts the inode operation applied when the transaction is prepared.
+func (mp *metaPartition) txRollbackInode(op *proto.TxOp) {
+	switch op.Type {
+	case proto.TxOpCreateInode:
+		mp.extendTree.Delete(NewExtend(op.Inode))
+		mp.fsmUnlinkInode(NewInode(op.Inode, 0))
+	case proto.TxOpLinkInode:
+		mp.fsmUnlinkInode(NewInode(op.Inode, 0))
+	}
+}
+
+// fsmTxCommit applies the operations of the prepared transaction on the meta partition. The coordinator keeps the
+// record as committed until all the participants commit, the participants drop it.
+func (mp *metaPartition) fsmTxCommit(req *proto.TxRequest, index uint64) (status uint8) {
+	status = proto.OpOk
+	record := mp.getTxRecord(req.TxID)
+	if record == nil || record.State == proto.TxStateCommitted {
+		return
+	}
+	mp.txUnlock(record)
+	for _, op := range record.PartitionOps(mp.config.PartitionId) {
+		mp.txApply(record, op, index)
+	}
+	if record.Coordinator != mp.config.PartitionId {
+		mp.txTree.Delete(record)
+		return
+	}
+	// the record is replaced rather than modified since it may be read by the snapshots
+	committed := record.Copy().(*TxRecord)
+	if req.Tx != nil {
+		// the operations prepared by the participants
+		committed.Ops = req.Tx.Ops
+	}
+	committed.State = proto.TxStateCommitted
+	mp.txTree.ReplaceOrInsert(committed, true)
+	return
+}
+
+// txApply applies the operation of the transaction, and records the change event of it.
+func (mp *metaPartition) txApply(record *TxRecord, op *proto.TxOp, index uint64) {
+	event := &proto.MetaEvent{ParentID: op.ParentID, Name: op.Name, Inode: op.Inode, Mode: op.Mode}
+	switch op.Type {
+	case proto.TxOpCreateDentry:
+		if mp.fsmCreateDentry(&Dentry{ParentId: op.ParentID, Name: op.Name, Inode: op.Inode, Type: op.Mode},
+			false) != proto.OpOk {
+			return
+		}
+		event.Type = proto.MetaEventCreate
+	case proto.TxOpUpdateDentry:
+		if mp.fsmUpdateDentry(&Dentry{ParentId: op.ParentID, Name: op.Name, Inode: op.Inode}).Status != proto.OpOk {
+			return
+		}
+		event.Type = proto.MetaEventReplace
+	case proto.TxOpDeleteDentry:
+		if !mp.txCaseRenamed(record, op) &&
+			mp.fsmDeleteDentry(&Dentry{ParentId: op.ParentID, Name: op.Name, Inode: op.Inode}, true).Status != proto.OpOk {
+			return
+		}
+		event.Type = proto.MetaEventDelete
+		if record.Type == proto.TxTypeRename {
+			for _, dst := range record.Ops {
+				if dst.Type == proto.TxOpCreateDentry || dst.Type == proto.TxOpUpdateDentry {
+					event.Type, event.DstParentID, event.DstName = proto.MetaEventRename, dst.ParentID, dst.Name
+				}
+			}
+		}
+	default:
+		return
+	}
+	mp.recordEvent(index, event)
+}
+
+// fsmTxAbort unlocks the dentries of the prepared transaction, rolls back the inode operations in the reverse order
+// and drops the record of it.
+func (mp *metaPartition) fsmTxAbort(txID string) (status uint8) {
+	status = proto.OpOk
+	record := mp.getTxRecord(txID)
+	if record == nil {
+		return
+	}
+	if record.State == proto.TxStateCommitted {
+		// a committed transaction is never aborted
+		return proto.OpArgMismatchErr
+	}
+	mp.txUnlock(record)
+	ops := record.PartitionOps(mp.config.PartitionId)
+	for i := len(ops) - 1; i >= 0; i-- {
+		mp.txRollbackInode(ops[i])
+	}
+	mp.txTree.Delete(record)
+	return
+}
+
+// fsmTxDone drops the record of the committed transaction after all the participants commit.
+func (mp *metaPartition) fsmTxDone(txID string) (status uint8) {
+	status = proto.OpOk
+	if record := mp.getTxRecord(txID); record != nil && record.State == proto.TxStateCommitted {
+		mp.txTree.Delete(record)
+	}
+	return
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newTxRenameInfo(txID string, dstName string) *proto.TxInfo {
+	return &proto.TxInfo{
+		TxID:        txID,
+		Type:        proto.TxTypeRename,
+		Coordinator: 1,
+		Ops: []*proto.TxOp{
+			{PartitionID: 1, Type: proto.TxOpDeleteDentry, ParentID: 1, Name: "a", Inode: 10, Mode: 0644},
+			{PartitionID: 1, Type: proto.TxOpCreateDentry, ParentID: 2, Name: dstName, Inode: 10, Mode: 0644, Replace: true},
+		},
+	}
+}
+
+func TestTxRename(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1}, nil).(*metaPartition)
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, uint32(os.ModeDir)), true)
+	mp.inodeTree.ReplaceOrInsert(NewInode(2, uint32(os.ModeDir)), true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 10, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "b", Inode: 11, Type: 0644}, true)
+
+	resp := mp.fsmTxPrepare(newTxRenameInfo("tx1", "b"))
+	if resp.Status != proto.OpOk || resp.Existed {
+		t.Fatalf("prepare: status %v existed %v", resp.Status, resp.Existed)
+	}
+	if op := resp.Record.Ops[1]; op.Type != proto.TxOpUpdateDentry || op.OldInode != 11 {
+		t.Fatalf("expect the replace of inode 11, got %v", *op)
+	}
+	if resp = mp.fsmTxPrepare(newTxRenameInfo("tx1", "b")); !resp.Existed {
+		t.Fatalf("expect the prepared record")
+	}
+	if resp = mp.fsmTxPrepare(newTxRenameInfo("tx2", "c")); resp.Status != proto.OpAgain {
+		t.Fatalf("expect the locked dentry, got status %v", resp.Status)
+	}
+	if status := mp.fsmDeleteDentry(&Dentry{ParentId: 1, Name: "a"}, false).Status; status != proto.OpAgain {
+		t.Fatalf("expect the locked dentry, got status %v", status)
+	}
+
+	if status := mp.fsmTxCommit(&proto.TxRequest{TxID: "tx1"}, 1); status != proto.OpOk {
+		t.Fatalf("commit: status %v", status)
+	}
+	if mp.dentryTree.Get(&Dentry{ParentId: 1, Name: "a"}) != nil {
+		t.Fatalf("expect the source dentry deleted")
+	}
+	if item := mp.dentryTree.Get(&Dentry{ParentId: 2, Name: "b"}); item == nil || item.(*Dentry).Inode != 10 {
+		t.Fatalf("expect the destination dentry of inode 10, got %v", item)
+	}
+	if record := mp.getTxRecord("tx1"); record == nil || record.State != proto.TxStateCommitted {
+		t.Fatalf("expect the committed record, got %v", record)
+	}
+	if status := mp.fsmTxAbort("tx1"); status == proto.OpOk {
+		t.Fatalf("expect the committed transaction not aborted")
+	}
+	mp.fsmTxDone("tx1")
+	if mp.getTxRecord("tx1") != nil {
+		t.Fatalf("expect the record dropped")
+	}
+
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 10, Type: 0644}, true)
+	if resp = mp.fsmTxPrepare(newTxRenameInfo("tx3", "c")); resp.Status != proto.OpOk {
+		t.Fatalf("prepare: status %v", resp.Status)
+	}
+	mp.fsmTxAbort("tx3")
+	if mp.txLocked(1, "a") || mp.txLocked(2, "c") || mp.getTxRecord("tx3") != nil {
+		t.Fatalf("expect the aborted transaction unlocked and dropped")
+	}
+}
+
+func TestTxCreateRollback(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, uint32(os.ModeDir)), true)
+	newTx := func(txID string, ino uint64) *proto.TxInfo {
+		return &proto.TxInfo{
+			TxID:        txID,
+			Type:        proto.TxTypeCreate,
+			Coordinator: 1,
+			Ops: []*proto.TxOp{
+				{PartitionID: 1, Type: proto.TxOpCreateInode, Inode: ino, Mode: uint32(os.ModeDir),
+					XAttrs: map[string]string{proto.QuotaXAttrKey: "1"}},
+				{PartitionID: 1, Type: proto.TxOpCreateDentry, ParentID: 1, Name: "d"},
+			},
+		}
+	}
+
+	resp := mp.fsmTxPrepare(newTx("tx1", 10))
+	if resp.Status != proto.OpOk {
+		t.Fatalf("prepare: status %v", resp.Status)
+	}
+	if op := resp.Record.Ops[1]; op.Inode != 10 || op.Mode != uint32(os.ModeDir) {
+		t.Fatalf("expect the dentry of the inode created, got %v", *op)
+	}
+	if !mp.inodeTree.Has(NewInode(10, 0)) || mp.extendTree.Get(NewExtend(10)) == nil {
+		t.Fatalf("expect the inode and its extend attributes created when prepared")
+	}
+	mp.fsmTxAbort("tx1")
+	if mp.inodeTree.Has(NewInode(10, 0)) || mp.extendTree.Get(NewExtend(10)) != nil {
+		t.Fatalf("expect the inode rolled back")
+	}
+
+	if resp = mp.fsmTxPrepare(newTx("tx2", 11)); resp.Status != proto.OpOk {
+		t.Fatalf("prepare: status %v", resp.Status)
+	}
+	mp.fsmTxCommit(&proto.TxRequest{TxID: "tx2"}, 1)
+	if item := mp.dentryTree.Get(&Dentry{ParentId: 1, Name: "d"}); item == nil || item.(*Dentry).Inode != 11 {
+		t.Fatalf("expect the dentry of inode 11, got %v", item)
+	}
+	if mp.config.Cursor != 11 {
+		t.Fatalf("expect the cursor 11, got %v", mp.config.Cursor)
+	}
+}
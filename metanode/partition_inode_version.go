@@ -0,0 +1,127 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The inodes keep their states seen by the volume snapshots as the versions, so that an inode can be read as of
+// a snapshot without loading the metadata stored by the snapshot. The epoch of the partition is the latest snapshot
+// kept by it, which is changed only by the snapshot operations applied through the raft, so the versions are kept
+// identically by the replicas. The versions not seen by any snapshot are dropped when the snapshots are deleted.
+// The versions of an inode are dropped with it when it is removed from the partition.
+
+// latestVolSnapshot returns the ID of the latest volume snapshot kept by the partition, or 0 if there is none.
+func (mp *metaPartition) latestVolSnapshot() (id uint64) {
+	mp.volSnapshotsLock.RLock()
+	defer mp.volSnapshotsLock.RUnlock()
+	for snapshotID := range mp.volSnapshots {
+		if snapshotID > id {
+			id = snapshotID
+		}
+	}
+	return
+}
+
+// versionInode keeps the state of the inode for the snapshots before it is changed. The inode must be got by
+// CopyGet and updated into the tree after it is changed.
+func (mp *metaPartition) versionInode(ino *Inode) {
+	epoch := mp.latestVolSnapshot()
+	if epoch == 0 {
+		return
+	}
+	ino.DoWriteFunc(func() {
+		ino.saveVersion(epoch)
+	})
+}
+
+// getInodeAt returns the inode as of the volume snapshot.
+func (mp *metaPartition) getInodeAt(ino *Inode, snapshotID uint64) (resp *InodeResponse) {
+	resp = NewInodeResponse()
+	mp.volSnapshotsLock.RLock()
+	_, ok := mp.volSnapshots[snapshotID]
+	mp.volSnapshotsLock.RUnlock()
+	if !ok {
+		resp.Status = proto.OpArgMismatchErr
+		return
+	}
+	item := mp.inodeTree.Get(ino)
+	if item == nil {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	version := item.(*Inode).versionAt(snapshotID)
+	if version == nil || version.ShouldDelete() {
+		resp.Status = proto.OpNotExistErr
+		return
+	}
+	resp.Status = proto.OpOk
+	resp.Msg = version
+	return
+}
+
+// rebaseInodeVersions makes the inodes restored by the rollback written at the latest epoch. The states seen by the
+// snapshots taken after the one rolled back to are taken from the replaced inodes, whose states seen by the older
+// snapshots are the same as the restored ones.
+func (mp *metaPartition) rebaseInodeVersions(inodeTree, replaced *BTree) {
+	epoch := mp.latestVolSnapshot()
+	if epoch == 0 {
+		return
+	}
+	inodeTree.Ascend(func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		history := inode
+		if item := replaced.Get(inode); item != nil {
+			history = item.(*Inode).Copy().(*Inode)
+		}
+		history.DoWriteFunc(func() {
+			history.saveVersion(epoch)
+		})
+		inode.DoWriteFunc(func() {
+			inode.Epoch, inode.Versions = history.Epoch, history.Versions
+		})
+		return true
+	})
+}
+
+// pruneInodeVersions drops the versions which are not seen by the snapshots kept by the partition.
+func (mp *metaPartition) pruneInodeVersions() {
+	snapshotIDs := mp.keptVolSnapshots()
+	inos := make([]uint64, 0)
+	mp.inodeTree.Ascend(func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		inode.DoReadFunc(func() {
+			if len(inode.Versions) > 0 {
+				inos = append(inos, inode.Inode)
+			}
+		})
+		return true
+	})
+	pruned := 0
+	for _, ino := range inos {
+		item := mp.inodeTree.CopyGet(NewInode(ino, 0))
+		if item == nil {
+			continue
+		}
+		if inode := item.(*Inode); inode.pruneVersions(snapshotIDs) {
+			mp.inodeTree.Update(inode)
+			pruned++
+		}
+	}
+	log.LogInfof("pruneInodeVersions: partitionID(%v) volume(%v) snapshots(%v) versioned(%v) pruned(%v)",
+		mp.config.PartitionId, mp.config.VolName, snapshotIDs, len(inos), pruned)
+}
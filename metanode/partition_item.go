@@ -129,6 +129,7 @@ type MetaItemIterator struct {
 	dentryTree    *BTree
 	extendTree    *BTree
 	multipartTree *BTree
+	txTree        *BTree
 
 	filenames []string
 
@@ -148,6 +149,7 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 	si.dentryTree = mp.dentryTree.GetTree()
 	si.extendTree = mp.extendTree.GetTree()
 	si.multipartTree = mp.multipartTree.GetTree()
+	si.txTree = mp.txTree.GetTree()
 	si.dataCh = make(chan interface{})
 	si.errorCh = make(chan error, 1)
 	si.closeCh = make(chan struct{})
@@ -228,6 +230,13 @@ func newMetaItemIterator(mp *metaPartition) (si *MetaItemIterator, err error) {
 		if checkClose() {
 			return
 		}
+		// process transactions
+		iter.txTree.Ascend(func(i BtreeItem) bool {
+			return produceItem(i)
+		})
+		if checkClose() {
+			return
+		}
 		// process extent del files
 		var err error
 		var raw []byte
@@ -309,6 +318,14 @@ func (si *MetaItemIterator) Next() (data []byte, err error) {
 			return
 		}
 		snap = NewMetaItem(opFSMCreateMultipart, nil, raw)
+	case *TxRecord:
+		var raw []byte
+		if raw, err = typedItem.Bytes(); err != nil {
+			si.err = err
+			si.Close()
+			return
+		}
+		snap = NewMetaItem(opFSMTxPrepare, nil, raw)
 	case *fileData:
 		snap = NewMetaItem(opExtentFileSnapshot, []byte(typedItem.filename), typedItem.data)
 	default:
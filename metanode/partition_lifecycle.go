@@ -0,0 +1,369 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The leader of each meta partition enforces the lifecycle rules of the volume on the dentries of the partition
+// periodically, which cover the files like the expiration rules. A file due by any of its rules is transited to the
+// storage class of the due rule with the most days: each replica of the data partitions of its extents is asked to
+// convert the extents, and then the storage class extend attribute of the file is set with its modify time. The
+// file is skipped while it is in the class since then, and transited again if it is modified, since the written
+// extents are converted back to the replica class by the data nodes. The data nodes convert the extents in
+// background, so a file is reported in the class once all the replicas accept the transition.
+const (
+	lifecycleScanInterval     = 10 * time.Minute
+	lifecycleReadDeadlineTime = 60
+)
+
+func (mp *metaPartition) lifecycleWorker() {
+	t := time.NewTicker(lifecycleScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			continue
+		}
+		rules := mp.vol.LifecycleRules()
+		if len(rules) == 0 {
+			continue
+		}
+		if err := mp.enforceLifecycleRules(rules); err != nil {
+			log.LogWarnf("lifecycleWorker: partitionID(%v) err(%v)", mp.config.PartitionId, err)
+		}
+	}
+}
+
+// transitingFile is a file dentry covered by the lifecycle rules.
+type transitingFile struct {
+	dentry *Dentry
+	rules  []*proto.LifecycleRule
+}
+
+// enforceLifecycleRules scans the dentries of the meta partition in batches, tags the covered subdirectories and
+// transits the due files.
+func (mp *metaPartition) enforceLifecycleRules(rules map[uint32]*proto.LifecycleRule) (err error) {
+	views, err := masterClient.ClientAPI().GetMetaPartitions(mp.config.VolName)
+	if err != nil {
+		return errors.NewErrorf("get meta partitions of volume(%v): %v", mp.config.VolName, err)
+	}
+	marker := &Dentry{}
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		default:
+		}
+		dirs, files, last := mp.collectLifecycleCandidates(rules, marker)
+		if last == nil {
+			return
+		}
+		if err = mp.tagRuleDirs(views, proto.LifecycleXAttrKey, dirs); err != nil {
+			return
+		}
+		if err = mp.transitFiles(views, files, time.Now().Unix()); err != nil {
+			return
+		}
+		marker = &Dentry{ParentId: last.ParentId, Name: last.Name + "\x00"}
+	}
+}
+
+// collectLifecycleCandidates returns the rule IDs to tag the covered subdirectories with, and the covered files of a
+// batch of the dentries from the marker. The last dentry of the batch is nil if there are no more dentries.
+func (mp *metaPartition) collectLifecycleCandidates(rules map[uint32]*proto.LifecycleRule, marker *Dentry) (
+	dirs map[uint64][]uint32, files []*transitingFile, last *Dentry) {
+	var (
+		parentID    uint64
+		parentRules []*proto.LifecycleRule
+		count       int
+	)
+	dirs = make(map[uint64][]uint32)
+	mp.dentryTree.AscendGreaterOrEqual(marker, func(i BtreeItem) bool {
+		dentry := i.(*Dentry)
+		if last == nil || dentry.ParentId != parentID {
+			parentID = dentry.ParentId
+			parentRules = mp.lifecycleRulesOfDir(rules, parentID)
+		}
+		last = dentry
+		count++
+		if len(parentRules) > 0 {
+			if proto.IsDir(dentry.Type) {
+				ids := make([]uint32, 0, len(parentRules))
+				for _, rule := range parentRules {
+					if rule.RootInode != proto.RootIno {
+						ids = append(ids, rule.RuleID)
+					}
+				}
+				if len(ids) > 0 {
+					dirs[dentry.Inode] = ids
+				}
+			} else if proto.IsRegular(dentry.Type) {
+				files = append(files, &transitingFile{dentry: dentry, rules: parentRules})
+			}
+		}
+		return count < expirationBatchCount
+	})
+	return
+}
+
+// lifecycleRulesOfDir returns the rules covering the files in the local directory.
+func (mp *metaPartition) lifecycleRulesOfDir(rules map[uint32]*proto.LifecycleRule, ino uint64) (
+	covering []*proto.LifecycleRule) {
+	tagged := make(map[uint32]bool)
+	for _, id := range mp.ruleTags(ino, proto.LifecycleXAttrKey) {
+		tagged[id] = true
+	}
+	for _, rule := range rules {
+		if rule.RootInode == proto.RootIno || rule.RootInode == ino || tagged[rule.RuleID] {
+			covering = append(covering, rule)
+		}
+	}
+	return
+}
+
+// targetStorageClass returns the storage class of the due rule with the most days, or the one with the largest ID
+// among them, and empty if none of the rules is due.
+func targetStorageClass(rules []*proto.LifecycleRule, modifyTime, accessTime, now int64) (class string) {
+	var target *proto.LifecycleRule
+	for _, rule := range rules {
+		if !rule.Due(modifyTime, accessTime, now) {
+			continue
+		}
+		if target == nil || rule.Days > target.Days || (rule.Days == target.Days && rule.RuleID > target.RuleID) {
+			target = rule
+		}
+	}
+	if target == nil {
+		return ""
+	}
+	return target.StorageClass
+}
+
+// transitFiles transits the due files which are not in the storage classes of their rules yet.
+func (mp *metaPartition) transitFiles(views []*proto.MetaPartitionView, files []*transitingFile, now int64) (err error) {
+	if len(files) == 0 {
+		return
+	}
+	inos := make([]uint64, 0, len(files))
+	for _, file := range files {
+		inos = append(inos, file.dentry.Inode)
+	}
+	times, err := mp.getInodeTimes(views, inos)
+	if err != nil {
+		return
+	}
+	values, err := mp.getXAttrs(views, inos, proto.StorageClassXAttrKey)
+	if err != nil {
+		return
+	}
+	targets := make(map[string][]uint64)
+	for _, file := range files {
+		ino := file.dentry.Inode
+		t, ok := times[ino]
+		if !ok {
+			continue
+		}
+		target := targetStorageClass(file.rules, t[0], t[1], now)
+		if target == "" {
+			continue
+		}
+		// the files never transited and the ones modified after being transited back are in the replica class
+		class, modifyTime := proto.ParseStorageClassXAttr(values[ino])
+		if class == target && (target == proto.StorageClassReplica || modifyTime == t[0]) {
+			continue
+		}
+		targets[target] = append(targets[target], ino)
+	}
+	for class, classInos := range targets {
+		if err = mp.transitInodes(views, classInos, class, times); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// transitInodes asks the replicas of the data partitions to convert the extents of the files to the storage class,
+// and sets the storage class of the files all the replicas of whose extents accept the transition.
+func (mp *metaPartition) transitInodes(views []*proto.MetaPartitionView, inos []uint64, class string,
+	times map[uint64][2]int64) (err error) {
+	extents, err := mp.getInodeExtents(views, inos)
+	if err != nil {
+		return
+	}
+	partitions := make(map[uint64]map[uint64]bool)
+	for _, eks := range extents {
+		for _, ek := range eks {
+			if storage.IsTinyExtent(ek.ExtentId) {
+				continue
+			}
+			if partitions[ek.PartitionId] == nil {
+				partitions[ek.PartitionId] = make(map[uint64]bool)
+			}
+			partitions[ek.PartitionId][ek.ExtentId] = true
+		}
+	}
+	failed := make(map[uint64]bool)
+	for partitionID, extentIDs := range partitions {
+		ids := make([]uint64, 0, len(extentIDs))
+		for extentID := range extentIDs {
+			ids = append(ids, extentID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if transitErr := mp.transitExtents(partitionID, ids, class); transitErr != nil {
+			log.LogWarnf("transitInodes: partitionID(%v) dataPartitionID(%v) class(%v) err(%v)",
+				mp.config.PartitionId, partitionID, class, transitErr)
+			failed[partitionID] = true
+		}
+	}
+	var transited int
+	for _, ino := range inos {
+		eks, ok := extents[ino]
+		if !ok {
+			continue
+		}
+		accepted := true
+		for _, ek := range eks {
+			if failed[ek.PartitionId] {
+				accepted = false
+				break
+			}
+		}
+		if !accepted {
+			continue
+		}
+		if err = mp.setXAttr(views, ino, proto.StorageClassXAttrKey,
+			proto.FormatStorageClassXAttr(class, times[ino][0])); err != nil {
+			return
+		}
+		transited++
+	}
+	log.LogInfof("transitInodes: partitionID(%v) class(%v) files(%v) transited(%v)", mp.config.PartitionId, class,
+		len(inos), transited)
+	return
+}
+
+// transitExtents sends the transition of the extents to every replica of the data partition, since each replica
+// converts its own copy.
+func (mp *metaPartition) transitExtents(partitionID uint64, extentIDs []uint64, class string) (err error) {
+	dp := mp.vol.GetPartition(partitionID)
+	if dp == nil {
+		return errors.NewErrorf("unknown dataPartitionID=%d in vol", partitionID)
+	}
+	for _, host := range dp.Hosts {
+		p := NewPacketToTransitExtents(partitionID, extentIDs, class)
+		if err = mp.sendPacket(host, p, lifecycleReadDeadlineTime); err != nil {
+			return errors.NewErrorf("transit extents on %v: %v", host, err)
+		}
+		if p.ResultCode != proto.OpOk {
+			return errors.NewErrorf("%s response of %v: %s", p.GetUniqueLogId(), host, p.GetResultMsg())
+		}
+	}
+	return
+}
+
+// getInodeExtents returns the extents of the inodes found.
+func (mp *metaPartition) getInodeExtents(views []*proto.MetaPartitionView, inos []uint64) (
+	extents map[uint64][]proto.ExtentKey, err error) {
+	extents = make(map[uint64][]proto.ExtentKey, len(inos))
+	for _, group := range mp.groupInodesByPartition(views, inos) {
+		for _, ino := range group.inos {
+			if group.view == nil {
+				item := mp.inodeTree.Get(NewInode(ino, 0))
+				if item == nil {
+					continue
+				}
+				extents[ino] = item.(*Inode).Extents.CopyExtents()
+				continue
+			}
+			resp := &proto.GetExtentsResponse{}
+			if err = mp.sendToMetaPartition(group.view, proto.OpMetaExtentsList, &proto.GetExtentsRequest{
+				VolName:     mp.config.VolName,
+				PartitionID: group.view.PartitionID,
+				Inode:       ino,
+			}, resp); err != nil {
+				return
+			}
+			extents[ino] = resp.Extents
+		}
+	}
+	return
+}
+
+// getXAttrs returns the values of the extend attribute of the inodes which have it.
+func (mp *metaPartition) getXAttrs(views []*proto.MetaPartitionView, inos []uint64, key string) (
+	values map[uint64]string, err error) {
+	values = make(map[uint64]string, len(inos))
+	for _, group := range mp.groupInodesByPartition(views, inos) {
+		if group.view == nil {
+			for _, ino := range group.inos {
+				item := mp.extendTree.Get(NewExtend(ino))
+				if item == nil {
+					continue
+				}
+				if value, ok := item.(*Extend).Get([]byte(key)); ok {
+					values[ino] = string(value)
+				}
+			}
+			continue
+		}
+		resp := &proto.BatchGetXAttrResponse{}
+		if err = mp.sendToMetaPartition(group.view, proto.OpMetaBatchGetXAttr, &proto.BatchGetXAttrRequest{
+			VolName:     mp.config.VolName,
+			PartitionId: group.view.PartitionID,
+			Inodes:      group.inos,
+			Keys:        []string{key},
+		}, resp); err != nil {
+			return
+		}
+		for _, info := range resp.XAttrs {
+			if value, ok := info.XAttrs[key]; ok {
+				values[info.Inode] = value
+			}
+		}
+	}
+	return
+}
+
+// setXAttr sets the extend attribute of the inode.
+func (mp *metaPartition) setXAttr(views []*proto.MetaPartitionView, ino uint64, key, value string) (err error) {
+	for _, group := range mp.groupInodesByPartition(views, []uint64{ino}) {
+		if group.view == nil {
+			extend := NewExtend(ino)
+			extend.Put([]byte(key), []byte(value))
+			_, err = mp.putExtend(opFSMSetXAttr, extend)
+			return
+		}
+		return mp.sendToMetaPartition(group.view, proto.OpMetaSetXAttr, &proto.SetXAttrRequest{
+			VolName:     mp.config.VolName,
+			PartitionId: group.view.PartitionID,
+			Inode:       ino,
+			Key:         key,
+			Value:       value,
+		}, nil)
+	}
+	return
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestCollectLifecycleCandidates(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	// /data(2) is the root of rule 1, /data/old(3) is tagged with rule 1 and /data/link is a symlink
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "data", Inode: 2, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "top", Inode: 13, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "old", Inode: 3, Type: uint32(os.ModeDir)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "a.dat", Inode: 10, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 2, Name: "link", Inode: 11, Type: uint32(os.ModeSymlink)}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 3, Name: "b.dat", Inode: 12, Type: 0644}, true)
+	extend := NewExtend(3)
+	extend.Put([]byte(proto.LifecycleXAttrKey), []byte("1"))
+	mp.extendTree.ReplaceOrInsert(extend, true)
+	rules := map[uint32]*proto.LifecycleRule{
+		1: {RuleID: 1, Path: "/data", RootInode: 2, Days: 30, Basis: proto.ExpireByAccessTime, StorageClass: proto.StorageClassCold},
+	}
+
+	dirs, files, last := mp.collectLifecycleCandidates(rules, &Dentry{})
+	if last == nil || last.Inode != 12 {
+		t.Fatalf("expect all the dentries scanned, got last %v", last)
+	}
+	if !reflect.DeepEqual(dirs, map[uint64][]uint32{3: {1}}) {
+		t.Fatalf("expect the subdirectory of /data tagged, got %v", dirs)
+	}
+	if len(files) != 2 || files[0].dentry.Inode != 10 || files[1].dentry.Inode != 12 {
+		t.Fatalf("expect the regular files under /data covered, got %v", files)
+	}
+}
+
+func TestTargetStorageClass(t *testing.T) {
+	const day = 24 * 3600
+	now := int64(100 * day)
+	rules := []*proto.LifecycleRule{
+		{RuleID: 1, Days: 7, Basis: proto.ExpireByModifyTime, StorageClass: proto.StorageClassCold},
+		{RuleID: 2, Days: 30, Basis: proto.ExpireByAccessTime, StorageClass: proto.StorageClassReplica},
+	}
+	if class := targetStorageClass(rules, now-day, now-day, now); class != "" {
+		t.Fatalf("expect no rule due, got %v", class)
+	}
+	if class := targetStorageClass(rules, now-8*day, now-day, now); class != proto.StorageClassCold {
+		t.Fatalf("expect the file due by rule 1, got %v", class)
+	}
+	if class := targetStorageClass(rules, now-40*day, now-40*day, now); class != proto.StorageClassReplica {
+		t.Fatalf("expect the due rule with the most days to win, got %v", class)
+	}
+	zero := []*proto.LifecycleRule{{RuleID: 3, Basis: proto.ExpireByModifyTime, StorageClass: proto.StorageClassCold}}
+	if class := targetStorageClass(zero, now, now, now); class != proto.StorageClassCold {
+		t.Fatalf("expect the rule of 0 days always due, got %v", class)
+	}
+}
+
+func TestStorageClassXAttr(t *testing.T) {
+	if class, mtime := proto.ParseStorageClassXAttr(proto.FormatStorageClassXAttr(proto.StorageClassCold, 1234)); class != proto.StorageClassCold || mtime != 1234 {
+		t.Fatalf("expect the storage class parsed back, got %v %v", class, mtime)
+	}
+	for _, value := range []string{"", "cold", "ec:1", "cold:x"} {
+		if class, mtime := proto.ParseStorageClassXAttr(value); class != proto.StorageClassReplica || mtime != 0 {
+			t.Fatalf("expect %q parsed as the replica class, got %v %v", value, class, mtime)
+		}
+	}
+}
+
+func TestTransitFilesInClass(t *testing.T) {
+	const day = 24 * 3600
+	now := int64(100 * day)
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	cold := &proto.LifecycleRule{RuleID: 1, Days: 7, Basis: proto.ExpireByModifyTime, StorageClass: proto.StorageClassCold}
+	replica := &proto.LifecycleRule{RuleID: 2, Days: 7, Basis: proto.ExpireByModifyTime, StorageClass: proto.StorageClassReplica}
+	var files []*transitingFile
+	// the cold file is transited since its last modification, and the others have never been transited
+	for ino, rule := range map[uint64]*proto.LifecycleRule{10: cold, 11: replica} {
+		inode := NewInode(ino, 0644)
+		inode.ModifyTime, inode.AccessTime = now-8*day, now-8*day
+		mp.inodeTree.ReplaceOrInsert(inode, true)
+		files = append(files, &transitingFile{dentry: &Dentry{ParentId: 1, Inode: ino}, rules: []*proto.LifecycleRule{rule}})
+	}
+	extend := NewExtend(10)
+	extend.Put([]byte(proto.StorageClassXAttrKey), []byte(proto.FormatStorageClassXAttr(proto.StorageClassCold, now-8*day)))
+	mp.extendTree.ReplaceOrInsert(extend, true)
+	// no data partitions or raft is available, so any transition fails
+	if err := mp.transitFiles(nil, files, now); err != nil {
+		t.Fatalf("expect the files in the classes skipped, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"unsafe"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The memory of a partition is estimated by the sizes of the structures of its items, the btrees add about a
+// pointer to each item. A raft log entry is estimated by the size of a typical metadata operation.
+const (
+	btreeItemMemSize = uint64(unsafe.Sizeof(BtreeItem(nil)))
+	raftEntryMemSize = 256
+)
+
+var (
+	inodeMemSize     = uint64(unsafe.Sizeof(Inode{})+unsafe.Sizeof(SortedExtents{})) + btreeItemMemSize
+	extentKeyMemSize = uint64(unsafe.Sizeof(proto.ExtentKey{}))
+	dentryMemSize    = uint64(unsafe.Sizeof(Dentry{})) + btreeItemMemSize
+	extendMemSize    = uint64(unsafe.Sizeof(Extend{})) + btreeItemMemSize
+	multipartMemSize = uint64(unsafe.Sizeof(Multipart{})) + btreeItemMemSize
+	partMemSize      = uint64(unsafe.Sizeof(Part{}))
+)
+
+// MemoryUsage returns the estimated memory used by the partition. It walks the items in the memory, so it is
+// not cheap for the huge partitions.
+func (mp *metaPartition) MemoryUsage() (usage *proto.MetaPartitionMemory) {
+	usage = &proto.MetaPartitionMemory{
+		PartitionID: mp.config.PartitionId,
+		VolName:     mp.config.VolName,
+		MetaStore:   proto.MetaStoreMemory,
+	}
+	if mp.metaStore != nil {
+		usage.MetaStore = proto.MetaStoreRocksDB
+	}
+	mp.inodeTree.AscendInMemory(func(i BtreeItem) bool {
+		ino := i.(*Inode)
+		ino.RLock()
+		usage.InodeTree += inodeMemSize + uint64(len(ino.LinkTarget))
+		usage.Extents += uint64(ino.Extents.Len()) * extentKeyMemSize
+		ino.RUnlock()
+		usage.InodeCount++
+		return true
+	})
+	mp.dentryTree.AscendInMemory(func(i BtreeItem) bool {
+		usage.DentryTree += dentryMemSize + uint64(len(i.(*Dentry).Name))
+		usage.DentryCount++
+		return true
+	})
+	mp.extendTree.AscendInMemory(func(i BtreeItem) bool {
+		usage.Extend += extendMemSize
+		i.(*Extend).Range(func(key, value []byte) bool {
+			usage.Extend += uint64(len(key) + len(value))
+			return true
+		})
+		return true
+	})
+	mp.multipartTree.AscendInMemory(func(i BtreeItem) bool {
+		m := i.(*Multipart)
+		m.mu.RLock()
+		usage.Multipart += multipartMemSize + uint64(len(m.id)+len(m.key)) + uint64(len(m.parts))*partMemSize
+		m.mu.RUnlock()
+		return true
+	})
+	if status := mp.GetRaftStatus(); status != nil {
+		entries := uint64(status.PendQueue + status.RecvQueue + status.AppQueue)
+		if status.Index > status.Applied {
+			entries += status.Index - status.Applied
+		}
+		usage.RaftLog = entries * raftEntryMemSize
+	}
+	return
+}
@@ -0,0 +1,318 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/time/rate"
+)
+
+// The link counts of the inodes may be left wrong by the historical bugs, which either keep the files after all
+// their dentries are removed, or remove the files still linked. The leader checks the link counts on request, by
+// counting the dentries linking to its inodes in all the meta partitions of the volume, and the children of its
+// directories, page by page at a limited rate. The links may change while the dentries are scanned, so the
+// mismatches are counted again after a delay, and only the ones found the same by both passes are reported. They
+// are fixed through the raft if the link counts are still the same when the fix is applied.
+// The inodes without any dentry are left to the orphan scan.
+const (
+	nlinkCheckPageCount     = 10000
+	defaultNLinkCheckLimit  = 100000 // dentries per second
+	nlinkCheckConfirmDelay  = time.Minute
+	nlinkFixBatchCount      = 1000
+	nlinkReportPublishPages = 10
+)
+
+// StartNLinkCheck starts checking the link counts of the inodes in background, the mismatches are fixed if fix is
+// true. The limit is the max number of the dentries scanned per second.
+func (mp *metaPartition) StartNLinkCheck(fix bool, limit int) (err error) {
+	if limit <= 0 {
+		limit = defaultNLinkCheckLimit
+	}
+	mp.nlinkLock.Lock()
+	defer mp.nlinkLock.Unlock()
+	if mp.nlinkReport != nil && mp.nlinkReport.EndTime == 0 {
+		return fmt.Errorf("the link counts of partition %v are being checked since %v", mp.config.PartitionId,
+			time.Unix(mp.nlinkReport.StartTime, 0).Format(proto.TimeFormat))
+	}
+	report := &proto.NLinkCheckReport{
+		PartitionID: mp.config.PartitionId,
+		Fix:         fix,
+		Limit:       limit,
+		StartTime:   time.Now().Unix(),
+		Mismatches:  make([]*proto.NLinkMismatch, 0),
+	}
+	mp.nlinkReport = report
+	go mp.checkNLinks(*report)
+	return
+}
+
+// GetNLinkCheckReport returns the progress or the result of the latest link count check, the start time is 0 if it
+// is not checked yet.
+func (mp *metaPartition) GetNLinkCheckReport() *proto.NLinkCheckReport {
+	mp.nlinkLock.RLock()
+	defer mp.nlinkLock.RUnlock()
+	if mp.nlinkReport == nil {
+		return &proto.NLinkCheckReport{PartitionID: mp.config.PartitionId, Mismatches: make([]*proto.NLinkMismatch, 0)}
+	}
+	return mp.nlinkReport
+}
+
+// publishNLinkReport replaces the report read by GetNLinkCheckReport with a copy of the one being updated.
+func (mp *metaPartition) publishNLinkReport(report *proto.NLinkCheckReport) {
+	published := *report
+	published.Mismatches = append(make([]*proto.NLinkMismatch, 0, len(report.Mismatches)), report.Mismatches...)
+	mp.nlinkLock.Lock()
+	mp.nlinkReport = &published
+	mp.nlinkLock.Unlock()
+}
+
+func (mp *metaPartition) checkNLinks(report proto.NLinkCheckReport) {
+	defer func() {
+		report.EndTime = time.Now().Unix()
+		mp.publishNLinkReport(&report)
+		log.LogInfof("checkNLinks: partitionID(%v) fix(%v) inodes(%v) dentries(%v) suspects(%v) mismatches(%v) err(%v)",
+			report.PartitionID, report.Fix, report.Inodes, report.Dentries, report.Suspects, len(report.Mismatches),
+			report.Error)
+	}()
+	views, err := masterClient.ClientAPI().GetMetaPartitions(mp.config.VolName)
+	if err != nil {
+		report.Error = fmt.Sprintf("get meta partitions of volume(%v): %v", mp.config.VolName, err)
+		return
+	}
+	limiter := rate.NewLimiter(rate.Limit(report.Limit), nlinkCheckPageCount)
+	start, end := mp.config.Start, mp.config.End
+
+	links, children, err := mp.countInodeLinks(views, start, end, nil, limiter, &report)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+	suspects := make(map[uint64]*proto.NLinkMismatch)
+	mp.inodeTree.Ascend(func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		if inode.Inode < start || inode.Inode > end {
+			return true
+		}
+		report.Inodes++
+		if m := nlinkMismatch(inode, links, children); m != nil {
+			suspects[m.Inode] = m
+		}
+		return true
+	})
+	report.Suspects = len(suspects)
+	mp.publishNLinkReport(&report)
+	if len(suspects) == 0 {
+		return
+	}
+
+	select {
+	case <-time.After(nlinkCheckConfirmDelay):
+	case <-mp.stopC:
+		report.Error = "the partition is stopped"
+		return
+	}
+	if links, children, err = mp.countInodeLinks(views, start, end, suspects, limiter, &report); err != nil {
+		report.Error = err.Error()
+		return
+	}
+	mismatches := make([]*proto.NLinkMismatch, 0, len(suspects))
+	for ino, suspect := range suspects {
+		item := mp.inodeTree.Get(NewInode(ino, 0))
+		if item == nil {
+			continue
+		}
+		if m := nlinkMismatch(item.(*Inode), links, children); m != nil && m.NLink == suspect.NLink &&
+			m.Expected == suspect.Expected {
+			mismatches = append(mismatches, m)
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].Inode < mismatches[j].Inode })
+	report.Mismatches = mismatches
+	if report.Fix {
+		if err = mp.fixNLinks(mismatches); err != nil {
+			report.Error = err.Error()
+		}
+	}
+}
+
+// countInodeLinks counts the dentries linking to the inodes in the range and the children of the directories in
+// the range in all the meta partitions of the volume. Only the inodes in the filter are counted if it is not nil.
+func (mp *metaPartition) countInodeLinks(views []*proto.MetaPartitionView, start, end uint64,
+	filter map[uint64]*proto.NLinkMismatch, limiter *rate.Limiter, report *proto.NLinkCheckReport) (links,
+	children map[uint64]uint32, err error) {
+	links = make(map[uint64]uint32)
+	children = make(map[uint64]uint32)
+	merge := func(counts, to map[uint64]uint32) {
+		for ino, count := range counts {
+			if _, ok := filter[ino]; ok || filter == nil {
+				to[ino] += count
+			}
+		}
+	}
+	pages := 0
+	for _, view := range views {
+		req := &proto.CountInodeLinksRequest{
+			VolName:     mp.config.VolName,
+			PartitionID: view.PartitionID,
+			Start:       start,
+			End:         end,
+			Limit:       nlinkCheckPageCount,
+		}
+		for {
+			select {
+			case <-time.After(limiter.ReserveN(time.Now(), nlinkCheckPageCount).Delay()):
+			case <-mp.stopC:
+				return nil, nil, errors.New("the partition is stopped")
+			}
+			resp := &proto.CountInodeLinksResponse{}
+			if view.PartitionID == mp.config.PartitionId {
+				resp = mp.countInodeLinksPage(req)
+			} else if err = mp.sendToMetaPartition(view, proto.OpMetaCountInodeLinks, req, resp); err != nil {
+				return nil, nil, errors.NewErrorf("count links in meta partition(%v): %v", view.PartitionID, err)
+			}
+			merge(resp.Links, links)
+			merge(resp.Children, children)
+			report.Dentries += uint64(resp.Scanned)
+			if pages++; pages%nlinkReportPublishPages == 0 {
+				mp.publishNLinkReport(report)
+			}
+			if resp.Done {
+				break
+			}
+			req.ParentID, req.Name = resp.ParentID, resp.Name
+		}
+	}
+	return
+}
+
+// countInodeLinksPage counts the links and the children in a page of the dentries after the marker.
+func (mp *metaPartition) countInodeLinksPage(req *proto.CountInodeLinksRequest) (resp *proto.CountInodeLinksResponse) {
+	limit := req.Limit
+	if limit <= 0 || limit > nlinkCheckPageCount {
+		limit = nlinkCheckPageCount
+	}
+	resp = &proto.CountInodeLinksResponse{
+		Links:    make(map[uint64]uint32),
+		Children: make(map[uint64]uint32),
+		Done:     true,
+	}
+	mp.dentryTree.AscendGreaterOrEqual(&Dentry{ParentId: req.ParentID, Name: req.Name + "\x00"}, func(i BtreeItem) bool {
+		if resp.Scanned >= limit {
+			resp.Done = false
+			return false
+		}
+		d := i.(*Dentry)
+		resp.Scanned++
+		resp.ParentID, resp.Name = d.ParentId, d.Name
+		if d.Inode >= req.Start && d.Inode <= req.End {
+			resp.Links[d.Inode]++
+		}
+		if d.ParentId >= req.Start && d.ParentId <= req.End {
+			resp.Children[d.ParentId]++
+		}
+		return true
+	})
+	return
+}
+
+// nlinkMismatch returns the mismatch of the inode, or nil if its link count is right or it is not linked.
+func nlinkMismatch(inode *Inode, links, children map[uint64]uint32) *proto.NLinkMismatch {
+	if inode.ShouldDelete() || inode.IsTempFile() {
+		return nil
+	}
+	var expected uint32
+	if proto.IsDir(inode.Type) {
+		if links[inode.Inode] == 0 && inode.Inode != proto.RootIno {
+			return nil
+		}
+		expected = 2 + children[inode.Inode]
+	} else if expected = links[inode.Inode]; expected == 0 {
+		return nil
+	}
+	if nlink := inode.GetNLink(); nlink != expected {
+		return &proto.NLinkMismatch{Inode: inode.Inode, Mode: inode.Type, NLink: nlink, Expected: expected}
+	}
+	return nil
+}
+
+// fixNLinks sets the link counts of the mismatched inodes through the raft.
+func (mp *metaPartition) fixNLinks(mismatches []*proto.NLinkMismatch) (err error) {
+	for start := 0; start < len(mismatches); start += nlinkFixBatchCount {
+		end := start + nlinkFixBatchCount
+		if end > len(mismatches) {
+			end = len(mismatches)
+		}
+		var data []byte
+		if data, err = json.Marshal(mismatches[start:end]); err != nil {
+			return
+		}
+		var resp interface{}
+		if resp, err = mp.submit(opFSMFixNLink, data); err != nil {
+			return
+		}
+		fixed := resp.(map[uint64]struct{})
+		for _, m := range mismatches[start:end] {
+			if _, ok := fixed[m.Inode]; ok {
+				m.Fixed = true
+				log.LogWarnf("fixNLinks: partitionID(%v) inode(%v) nlink(%v) is fixed to (%v)", mp.config.PartitionId,
+					m.Inode, m.NLink, m.Expected)
+			}
+		}
+	}
+	return
+}
+
+// fsmFixNLinks sets the link counts of the inodes which are not changed since they are checked.
+func (mp *metaPartition) fsmFixNLinks(mismatches []*proto.NLinkMismatch) (fixed map[uint64]struct{}) {
+	fixed = make(map[uint64]struct{})
+	epoch := mp.latestVolSnapshot()
+	for _, m := range mismatches {
+		item := mp.inodeTree.CopyGet(NewInode(m.Inode, 0))
+		if item == nil {
+			continue
+		}
+		inode := item.(*Inode)
+		var ok bool
+		inode.DoWriteFunc(func() {
+			if ok = inode.NLink == m.NLink && inode.Flag&DeleteMarkFlag == 0; ok {
+				inode.saveVersion(epoch)
+				inode.NLink = m.Expected
+			}
+		})
+		if ok {
+			mp.inodeTree.Update(inode)
+			fixed[m.Inode] = struct{}{}
+		}
+	}
+	return
+}
+
+// CountInodeLinks replies the numbers of the links and the children in a page of the dentries of the partition.
+func (mp *metaPartition) CountInodeLinks(req *proto.CountInodeLinksRequest, p *Packet) (err error) {
+	var encoded []byte
+	if encoded, err = json.Marshal(mp.countInodeLinksPage(req)); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
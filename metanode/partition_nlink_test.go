@@ -0,0 +1,93 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestNLinkCheck(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	root := NewInode(proto.RootIno, uint32(os.ModeDir))
+	root.NLink = 3
+	dir := NewInode(2, uint32(os.ModeDir))
+	dir.NLink = 2
+	linked := NewInode(3, 0644)
+	linked.NLink = 1
+	lost := NewInode(4, 0644)
+	lost.NLink = 1
+	for _, ino := range []*Inode{root, dir, linked, lost} {
+		mp.inodeTree.ReplaceOrInsert(ino, true)
+	}
+	// the directory has a child not counted, the file has a hard link not counted, and the lost file has no dentry
+	dentries := []*Dentry{
+		{ParentId: proto.RootIno, Name: "dir", Inode: 2, Type: uint32(os.ModeDir)},
+		{ParentId: 2, Name: "a", Inode: 3, Type: 0644},
+		{ParentId: 2, Name: "b", Inode: 3, Type: 0644},
+		{ParentId: 2, Name: "c", Inode: 200, Type: 0644},
+	}
+	for _, d := range dentries {
+		mp.dentryTree.ReplaceOrInsert(d, true)
+	}
+
+	req := &proto.CountInodeLinksRequest{Start: 1, End: 100, Limit: 3}
+	links, children := make(map[uint64]uint32), make(map[uint64]uint32)
+	for pages := 1; ; pages++ {
+		resp := mp.countInodeLinksPage(req)
+		for ino, count := range resp.Links {
+			links[ino] += count
+		}
+		for ino, count := range resp.Children {
+			children[ino] += count
+		}
+		if resp.Done {
+			if pages != 2 {
+				t.Fatalf("expect 2 pages, got %v", pages)
+			}
+			break
+		}
+		req.ParentID, req.Name = resp.ParentID, resp.Name
+	}
+	if links[3] != 2 || links[200] != 0 || children[2] != 3 || children[proto.RootIno] != 1 {
+		t.Fatalf("unexpected counts: links %v children %v", links, children)
+	}
+
+	mismatches := make([]*proto.NLinkMismatch, 0)
+	for _, ino := range []*Inode{root, dir, linked, lost} {
+		if m := nlinkMismatch(ino, links, children); m != nil {
+			mismatches = append(mismatches, m)
+		}
+	}
+	if len(mismatches) != 2 || mismatches[0].Inode != 2 || mismatches[0].Expected != 5 ||
+		mismatches[1].Inode != 3 || mismatches[1].Expected != 2 {
+		t.Fatalf("unexpected mismatches: %v %v", mismatches[0], mismatches[len(mismatches)-1])
+	}
+
+	// the link count of the file changes after it is checked
+	mp.inodeTree.CopyGet(NewInode(3, 0)).(*Inode).IncNLink()
+	fixed := mp.fsmFixNLinks(mismatches)
+	if _, ok := fixed[2]; !ok || len(fixed) != 1 {
+		t.Fatalf("expect only the directory fixed, got %v", fixed)
+	}
+	if nlink := mp.inodeTree.Get(NewInode(2, 0)).(*Inode).GetNLink(); nlink != 5 {
+		t.Fatalf("expect the link count of the directory fixed to 5, got %v", nlink)
+	}
+	if nlink := mp.inodeTree.Get(NewInode(3, 0)).(*Inode).GetNLink(); nlink != 2 {
+		t.Fatalf("expect the link count of the file kept, got %v", nlink)
+	}
+}
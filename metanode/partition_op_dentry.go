@@ -54,12 +54,22 @@ func (mp *metaPartition) DeleteDentry(req *DeleteDentryReq, p *Packet) (err erro
 		ParentId: req.ParentID,
 		Name:     req.Name,
 	}
-	val, err := dentry.Marshal()
+	var (
+		op  uint32 = opFSMDeleteDentry
+		val []byte
+	)
+	if req.DstName != "" {
+		// the destination of the rename is recorded in the change event
+		op = opFSMRenameDentry
+		val, err = json.Marshal(req)
+	} else {
+		val, err = dentry.Marshal()
+	}
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
 		return
 	}
-	r, err := mp.submit(opFSMDeleteDentry, val)
+	r, err := mp.submit(op, val)
 	if err != nil {
 		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
 		return
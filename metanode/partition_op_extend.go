@@ -16,11 +16,31 @@ package metanode
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
 
 func (mp *metaPartition) SetXAttr(req *proto.SetXAttrRequest, p *Packet) (err error) {
+	if len(req.Key) == 0 || len(req.Key) > proto.XAttrNameMax || len(req.Value) > proto.XAttrValueMax {
+		err = fmt.Errorf("invalid xattr: name(%v) value size(%v)", req.Key, len(req.Value))
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	if mp.xattrSize(req.Inode, req.Key)+len(req.Key)+len(req.Value) > proto.XAttrTotalMax {
+		err = fmt.Errorf("xattrs of inode(%v) exceed %v bytes", req.Inode, proto.XAttrTotalMax)
+		p.PacketErrorWithBody(proto.OpDiskNoSpaceErr, []byte(err.Error()))
+		return
+	}
+	if proto.IsACLXAttr(req.Key) {
+		// the ACLs are kept in the canonical form so that all the replicas and the clients read the same entries
+		var acl proto.ACL
+		if acl, err = proto.ParseACL([]byte(req.Value)); err != nil {
+			p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+			return
+		}
+		req.Value = string(acl.Bytes())
+	}
 	var extend = NewExtend(req.Inode)
 	extend.Put([]byte(req.Key), []byte(req.Value))
 	if _, err = mp.putExtend(opFSMSetXAttr, extend); err != nil {
@@ -43,6 +63,7 @@ func (mp *metaPartition) GetXAttr(req *proto.GetXAttrRequest, p *Packet) (err er
 		extend := treeItem.(*Extend)
 		if value, exist := extend.Get([]byte(req.Key)); exist {
 			response.Value = string(value)
+			response.Exist = true
 		}
 	}
 	var encoded []byte
@@ -122,6 +143,142 @@ func (mp *metaPartition) ListXAttr(req *proto.ListXAttrRequest, p *Packet) (err
 	return
 }
 
+// xattrSize returns the bytes of the names and the values of the attributes of the inode except the given one.
+func (mp *metaPartition) xattrSize(ino uint64, except string) (size int) {
+	treeItem := mp.extendTree.Get(NewExtend(ino))
+	if treeItem == nil {
+		return
+	}
+	treeItem.(*Extend).Range(func(key, value []byte) bool {
+		if string(key) != except {
+			size += len(key) + len(value)
+		}
+		return true
+	})
+	return
+}
+
+// GetAllXAttrs returns all the attributes of the inode.
+func (mp *metaPartition) GetAllXAttrs(ino uint64) (info *proto.XAttrInfo) {
+	info = &proto.XAttrInfo{Inode: ino, XAttrs: make(map[string]string)}
+	treeItem := mp.extendTree.Get(NewExtend(ino))
+	if treeItem == nil {
+		return
+	}
+	treeItem.(*Extend).Range(func(key, value []byte) bool {
+		info.XAttrs[string(key)] = string(value)
+		return true
+	})
+	return
+}
+
+// GetACL returns the owner, the mode and the POSIX ACLs of the inode, nil if the inode does not exist.
+func (mp *metaPartition) GetACL(ino uint64) (info *proto.ACLInfo) {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil {
+		return
+	}
+	inode := item.(*Inode)
+	info = &proto.ACLInfo{Inode: ino}
+	inode.RLock()
+	info.Mode, info.Uid, info.Gid = inode.Type, inode.Uid, inode.Gid
+	inode.RUnlock()
+	treeItem := mp.extendTree.Get(NewExtend(ino))
+	if treeItem == nil {
+		return
+	}
+	extend := treeItem.(*Extend)
+	if value, exist := extend.Get([]byte(proto.XAttrACLAccess)); exist {
+		info.Access, _ = proto.ParseACL(value)
+	}
+	if value, exist := extend.Get([]byte(proto.XAttrACLDefault)); exist {
+		info.Default, _ = proto.ParseACL(value)
+	}
+	return
+}
+
+// GetQuotaUsage sums the bytes and the files of the inodes by the directory quotas recorded in the quota extend
+// attribute of the inodes. The inodes to be deleted are not counted.
+func (mp *metaPartition) GetQuotaUsage() (usage map[uint32]*proto.QuotaUsage) {
+	usage = make(map[uint32]*proto.QuotaUsage)
+	mp.extendTree.GetTree().Ascend(func(i BtreeItem) bool {
+		extend := i.(*Extend)
+		value, exist := extend.Get([]byte(proto.QuotaXAttrKey))
+		if !exist {
+			return true
+		}
+		item := mp.inodeTree.Get(NewInode(extend.inode, 0))
+		if item == nil {
+			return true
+		}
+		ino := item.(*Inode)
+		if ino.ShouldDelete() || ino.IsTempFile() {
+			return true
+		}
+		ino.RLock()
+		var size uint64
+		if proto.IsRegular(ino.Type) {
+			size = ino.Size
+		}
+		ino.RUnlock()
+		for _, id := range proto.ParseQuotaIDs(string(value)) {
+			if _, ok := usage[id]; !ok {
+				usage[id] = &proto.QuotaUsage{}
+			}
+			usage[id].UsedBytes += size
+			usage[id].UsedFiles++
+		}
+		return true
+	})
+	return
+}
+
+// UpdateSummary adds the delta to the summary of the directory, the packet replies the summary after the update so
+// that the client propagates the recursive delta to the parent.
+func (mp *metaPartition) UpdateSummary(req *proto.UpdateSummaryRequest, p *Packet) (err error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMUpdateSummary, data)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	summary, ok := resp.(*proto.DirSummary)
+	if !ok || summary == nil {
+		p.PacketErrorWithBody(proto.OpNotExistErr, nil)
+		return
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(&proto.UpdateSummaryResponse{Summary: *summary}); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// GetSummary returns the summary of the directory, nil if the directory does not exist.
+func (mp *metaPartition) GetSummary(ino uint64) (summary *proto.DirSummary) {
+	item := mp.inodeTree.Get(NewInode(ino, 0))
+	if item == nil || !proto.IsDir(item.(*Inode).Type) {
+		return
+	}
+	summary = &proto.DirSummary{}
+	treeItem := mp.extendTree.Get(NewExtend(ino))
+	if treeItem == nil {
+		return
+	}
+	if value, exist := treeItem.(*Extend).Get([]byte(proto.SummaryXAttrKey)); exist {
+		if parsed, err := proto.ParseDirSummary(value); err == nil {
+			summary = parsed
+		}
+	}
+	return
+}
+
 func (mp *metaPartition) putExtend(op uint32, extend *Extend) (resp interface{}, err error) {
 	var marshaled []byte
 	if marshaled, err = extend.Bytes(); err != nil {
@@ -23,6 +23,11 @@ import (
 
 // ExtentAppend appends an extent.
 func (mp *metaPartition) ExtentAppend(req *proto.AppendExtentKeyRequest, p *Packet) (err error) {
+	mp.volSnapshotBarrier.RLock()
+	defer mp.volSnapshotBarrier.RUnlock()
+	if !mp.checkSnapshotEpoch(req.SnapshotEpoch, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	ext := req.Extent
 	ino.Extents.Append(ext)
@@ -71,6 +76,11 @@ func (mp *metaPartition) ExtentsList(req *proto.GetExtentsRequest, p *Packet) (e
 
 // ExtentsTruncate truncates an extent.
 func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (err error) {
+	mp.volSnapshotBarrier.RLock()
+	defer mp.volSnapshotBarrier.RUnlock()
+	if !mp.checkSnapshotEpoch(req.SnapshotEpoch, p) {
+		return
+	}
 	ino := NewInode(req.Inode, proto.Mode(os.ModePerm))
 	ino.Size = req.Size
 	val, err := ino.Marshal()
@@ -89,6 +99,11 @@ func (mp *metaPartition) ExtentsTruncate(req *ExtentsTruncateReq, p *Packet) (er
 }
 
 func (mp *metaPartition) BatchExtentAppend(req *proto.AppendExtentKeysRequest, p *Packet) (err error) {
+	mp.volSnapshotBarrier.RLock()
+	defer mp.volSnapshotBarrier.RUnlock()
+	if !mp.checkSnapshotEpoch(req.SnapshotEpoch, p) {
+		return
+	}
 	ino := NewInode(req.Inode, 0)
 	extents := req.Extents
 	for _, extent := range extents {
@@ -171,12 +171,21 @@ func (mp *metaPartition) UnlinkInodeBatch(req *BatchUnlinkInoReq, p *Packet) (er
 // InodeGet executes the inodeGet command from the client.
 func (mp *metaPartition) InodeGet(req *InodeGetReq, p *Packet) (err error) {
 	ino := NewInode(req.Inode, 0)
-	retMsg := mp.getInode(ino)
+	var retMsg *InodeResponse
+	if req.SnapshotID != 0 {
+		retMsg = mp.getInodeAt(ino, req.SnapshotID)
+	} else {
+		retMsg = mp.getInode(ino)
+	}
 	ino = retMsg.Msg
 	var (
 		reply  []byte
 		status = proto.OpNotExistErr
 	)
+	// the snapshot is not kept by the partition
+	if retMsg.Status == proto.OpArgMismatchErr {
+		status = retMsg.Status
+	}
 	if retMsg.Status == proto.OpOk {
 		resp := &proto.InodeGetResponse{
 			Info: &proto.InodeInfo{},
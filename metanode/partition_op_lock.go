@@ -0,0 +1,117 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The file locks are replicated by raft but not kept in the snapshots, the mount points take the lost locks
+// again when they renew the leases.
+
+// SetLock locks or unlocks a range of the file, the packet replies OpExistErr if the lock conflicts with others.
+func (mp *metaPartition) SetLock(req *proto.SetLockRequest, p *Packet) (err error) {
+	if req.Lock.Start > req.Lock.End || req.Lock.Type > proto.LockTypeUnlock {
+		err = fmt.Errorf("invalid lock: %v", req.Lock)
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte(err.Error()))
+		return
+	}
+	if req.Lease <= 0 {
+		req.Lease = proto.DefaultLockLease
+	}
+	req.Now = time.Now().UnixNano()
+	data, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	resp, err := mp.submit(opFSMSetLock, data)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	if status := resp.(uint8); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	p.PacketOkReply()
+	return
+}
+
+// GetLock replies the lock conflicting with the given one.
+func (mp *metaPartition) GetLock(req *proto.GetLockRequest, p *Packet) (err error) {
+	resp := &proto.GetLockResponse{}
+	mp.fileLocksLock.RLock()
+	if conflict := mp.fileLocks[req.Inode].Conflict(&req.Lock, time.Now().UnixNano()); conflict != nil {
+		lock := *conflict
+		resp.Lock = &lock
+	}
+	mp.fileLocksLock.RUnlock()
+	var encoded []byte
+	if encoded, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// RenewLock renews the leases of the locks held by a mount point.
+func (mp *metaPartition) RenewLock(req *proto.RenewLockRequest, p *Packet) (err error) {
+	if req.Lease <= 0 {
+		req.Lease = proto.DefaultLockLease
+	}
+	req.Now = time.Now().UnixNano()
+	data, err := json.Marshal(req)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	if _, err = mp.submit(opFSMRenewLock, data); err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.PacketOkReply()
+	return
+}
+
+// GetLocks returns the unexpired locks of the inodes, all the inodes with locks if the inode is 0.
+func (mp *metaPartition) GetLocks(ino uint64) (locks []*proto.InodeLocks) {
+	now := time.Now().UnixNano()
+	mp.fileLocksLock.RLock()
+	defer mp.fileLocksLock.RUnlock()
+	for id, fileLocks := range mp.fileLocks {
+		if ino != 0 && id != ino {
+			continue
+		}
+		item := &proto.InodeLocks{Inode: id}
+		for _, lock := range fileLocks {
+			if lock.Expire > now {
+				copied := *lock
+				item.Locks = append(item.Locks, &copied)
+			}
+		}
+		if len(item.Locks) > 0 {
+			locks = append(locks, item)
+		}
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Inode < locks[j].Inode })
+	return
+}
@@ -0,0 +1,442 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The operations across the meta partitions are committed atomically by two-phase commit. The meta partition of the
+// first operation coordinates the transaction: it prepares the operations on itself and then the participants in
+// order, and commits them if all the participants are prepared or aborts them otherwise. A prepared meta partition
+// locks the dentries of the dentry operations and applies the inode operations, whose changes are rolled back by
+// the record if the transaction is aborted. The transaction is committed once the coordinator commits. The records
+// of the transactions are kept in the snapshots, so the leader of the coordinator finishes the committed ones and
+// aborts the expired prepared ones after a crash, and the participants ask the coordinator for the result of the
+// transactions which stay prepared for too long.
+const (
+	txTimeout          = 60 // seconds, the prepared transactions older than it are aborted by the coordinator
+	txRecoveryInterval = 10 * time.Second
+	txReadDeadlineTime = 10
+)
+
+// TxRename renames the dentry by a transaction coordinated by the meta partition of the source parent.
+func (mp *metaPartition) TxRename(req *proto.TxRenameRequest, p *Packet) (err error) {
+	tx := &proto.TxInfo{
+		TxID:        req.TxID,
+		Type:        proto.TxTypeRename,
+		Coordinator: mp.config.PartitionId,
+		CreateTime:  time.Now().Unix(),
+		Ops: []*proto.TxOp{
+			{
+				PartitionID: mp.config.PartitionId,
+				Type:        proto.TxOpDeleteDentry,
+				ParentID:    req.SrcParentID,
+				Name:        req.SrcName,
+				Inode:       req.Inode,
+				Mode:        req.Mode,
+			},
+			{
+				PartitionID: req.DstPartitionID,
+				Members:     req.DstMembers,
+				Type:        proto.TxOpCreateDentry,
+				ParentID:    req.DstParentID,
+				Name:        req.DstName,
+				Inode:       req.Inode,
+				Mode:        req.Mode,
+				Replace:     true,
+			},
+		},
+	}
+	status, record := mp.runTx(tx)
+	if status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	resp := &proto.TxRenameResponse{}
+	for _, op := range record.Ops {
+		if op.Type == proto.TxOpUpdateDentry {
+			resp.OldInode = op.OldInode
+		}
+	}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// TxStart runs the transaction of the client coordinated by the meta partition, and replies the operations committed.
+func (mp *metaPartition) TxStart(req *proto.TxStartRequest, p *Packet) (err error) {
+	tx := req.Tx
+	if tx == nil || tx.TxID == "" || len(tx.Ops) == 0 || tx.Ops[0].PartitionID != mp.config.PartitionId {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte("invalid transaction"))
+		return
+	}
+	tx.Coordinator, tx.State, tx.CreateTime = mp.config.PartitionId, 0, time.Now().Unix()
+	status, record := mp.runTx(tx)
+	if status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	reply, err := json.Marshal(&proto.TxResponse{State: proto.TxStateCommitted, Ops: record.Ops})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// txAllocInodes allocates the inodes created by the transaction on the meta partition before it is prepared.
+func (mp *metaPartition) txAllocInodes(tx *proto.TxInfo) (status uint8) {
+	for _, op := range tx.Ops {
+		if op.PartitionID != mp.config.PartitionId || op.Type != proto.TxOpCreateInode || op.Inode != 0 {
+			continue
+		}
+		var err error
+		if op.Inode, err = mp.nextInodeID(); err != nil {
+			return proto.OpInodeFullErr
+		}
+	}
+	return proto.OpOk
+}
+
+// submitTx submits the request of the transaction to the raft.
+func (mp *metaPartition) submitTx(op uint32, req interface{}) (resp interface{}, err error) {
+	var val []byte
+	if val, err = json.Marshal(req); err != nil {
+		return
+	}
+	return mp.submit(op, val)
+}
+
+// runTx coordinates the transaction by two-phase commit. It returns the committed record, whose operations are the
+// ones prepared by all the meta partitions.
+func (mp *metaPartition) runTx(tx *proto.TxInfo) (status uint8, record *TxRecord) {
+	mp.txLock.Lock()
+	if _, ok := mp.txInflight[tx.TxID]; ok {
+		mp.txLock.Unlock()
+		return proto.OpAgain, nil
+	}
+	mp.txInflight[tx.TxID] = struct{}{}
+	mp.txLock.Unlock()
+	defer func() {
+		mp.txLock.Lock()
+		delete(mp.txInflight, tx.TxID)
+		mp.txLock.Unlock()
+	}()
+
+	// phase 1: prepare the operations on the coordinator and the participants
+	if status = mp.txAllocInodes(tx); status != proto.OpOk {
+		return
+	}
+	resp, err := mp.submitTx(opFSMTxPrepare, tx)
+	if err != nil {
+		log.LogWarnf("runTx: prepare failed: partitionID(%v) txID(%v) err(%v)", mp.config.PartitionId, tx.TxID, err)
+		return proto.OpAgain, nil
+	}
+	prepared := resp.(*TxPrepareResp)
+	if prepared.Existed {
+		// the request is retried by the client
+		if prepared.Record.State == proto.TxStateCommitted {
+			return proto.OpOk, prepared.Record.Copy().(*TxRecord)
+		}
+		return proto.OpAgain, nil
+	}
+	if prepared.Status != proto.OpOk {
+		return prepared.Status, nil
+	}
+	record = prepared.Record.Copy().(*TxRecord)
+	for _, participant := range record.Participants() {
+		// the participant gets the inodes resolved by the meta partitions prepared before
+		prepare := record.Copy().(*TxRecord)
+		prepare.State = 0
+		var txResp *proto.TxResponse
+		txResp, status, err = mp.sendTx(participant.pid, participant.members, proto.OpMetaTxPrepare,
+			&proto.TxRequest{VolName: mp.config.VolName, PartitionID: participant.pid, TxID: tx.TxID,
+				Tx: &prepare.TxInfo})
+		if err != nil || status != proto.OpOk {
+			log.LogWarnf("runTx: prepare failed: partitionID(%v) txID(%v) participant(%v) status(%v) err(%v)",
+				mp.config.PartitionId, tx.TxID, participant.pid, status, err)
+			mp.abortTx(record)
+			if err != nil {
+				status = proto.OpAgain
+			}
+			return status, nil
+		}
+		record.mergeOps(participant.pid, txResp.Ops)
+		record.resolveInodes()
+	}
+
+	// phase 2: the transaction is committed once the coordinator commits
+	if _, err = mp.submitTx(opFSMTxCommit, &proto.TxRequest{TxID: tx.TxID, Tx: &record.TxInfo}); err != nil {
+		// the transaction is aborted or committed by the recovery
+		log.LogWarnf("runTx: commit failed: partitionID(%v) txID(%v) err(%v)", mp.config.PartitionId, tx.TxID, err)
+		return proto.OpAgain, nil
+	}
+	mp.finishTx(record)
+	return proto.OpOk, record
+}
+
+// mergeOps replaces the operations of the meta partition with the ones prepared by it.
+func (r *TxRecord) mergeOps(pid uint64, prepared []*proto.TxOp) {
+	i := 0
+	for j, op := range r.Ops {
+		if op.PartitionID == pid && i < len(prepared) {
+			r.Ops[j] = prepared[i]
+			i++
+		}
+	}
+}
+
+// abortTx aborts the transaction on the coordinator and then the participants. A participant which fails to abort
+// asks the coordinator for the result later.
+func (mp *metaPartition) abortTx(record *TxRecord) {
+	resp, err := mp.submitTx(opFSMTxAbort, &proto.TxRequest{TxID: record.TxID})
+	if err != nil || resp.(uint8) != proto.OpOk {
+		log.LogWarnf("abortTx: partitionID(%v) txID(%v) resp(%v) err(%v)", mp.config.PartitionId, record.TxID, resp, err)
+		return
+	}
+	for _, participant := range record.Participants() {
+		if _, status, err := mp.sendTx(participant.pid, participant.members, proto.OpMetaTxAbort,
+			&proto.TxRequest{VolName: mp.config.VolName, PartitionID: participant.pid, TxID: record.TxID}); err != nil ||
+			status != proto.OpOk {
+			log.LogWarnf("abortTx: partitionID(%v) txID(%v) participant(%v) status(%v) err(%v)",
+				mp.config.PartitionId, record.TxID, participant.pid, status, err)
+		}
+	}
+}
+
+// finishTx commits the transaction on the participants, and drops the record of the coordinator if all of them
+// commit. Otherwise the commits are sent again by the recovery.
+func (mp *metaPartition) finishTx(record *TxRecord) {
+	for _, participant := range record.Participants() {
+		if _, status, err := mp.sendTx(participant.pid, participant.members, proto.OpMetaTxCommit,
+			&proto.TxRequest{VolName: mp.config.VolName, PartitionID: participant.pid, TxID: record.TxID}); err != nil ||
+			status != proto.OpOk {
+			log.LogWarnf("finishTx: partitionID(%v) txID(%v) participant(%v) status(%v) err(%v)",
+				mp.config.PartitionId, record.TxID, participant.pid, status, err)
+			return
+		}
+	}
+	if _, err := mp.submitTx(opFSMTxDone, &proto.TxRequest{TxID: record.TxID}); err != nil {
+		log.LogWarnf("finishTx: partitionID(%v) txID(%v) err(%v)", mp.config.PartitionId, record.TxID, err)
+	}
+}
+
+// sendTx sends the request of the transaction to the meta partition. The members of the meta partition are got
+// from the master if they are not known.
+func (mp *metaPartition) sendTx(pid uint64, members []string, opcode uint8, req *proto.TxRequest) (
+	resp *proto.TxResponse, status uint8, err error) {
+	if len(members) == 0 {
+		var partition *proto.MetaPartitionInfo
+		if partition, err = masterClient.ClientAPI().GetMetaPartition(pid); err != nil {
+			return
+		}
+		members = partition.Hosts
+	}
+	err = errors.New("no available member")
+	for _, addr := range members {
+		// the request is forwarded to the leader by the member
+		if resp, status, err = mp.sendTxTo(addr, opcode, req); err == nil {
+			return
+		}
+	}
+	return
+}
+
+func (mp *metaPartition) sendTxTo(addr string, opcode uint8, req *proto.TxRequest) (
+	resp *proto.TxResponse, status uint8, err error) {
+	p := NewPacketToTx(opcode, req)
+	conn, err := mp.config.ConnPool.GetConnect(addr)
+	defer func() {
+		if err != nil {
+			mp.config.ConnPool.PutConnect(conn, ForceClosedConnect)
+		} else {
+			mp.config.ConnPool.PutConnect(conn, NoClosedConnect)
+		}
+	}()
+	if err != nil {
+		return
+	}
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConn(conn, txReadDeadlineTime); err != nil {
+		return
+	}
+	resp = &proto.TxResponse{}
+	if status = p.ResultCode; status == proto.OpOk && p.Size > 0 {
+		err = json.Unmarshal(p.Data, resp)
+	}
+	return
+}
+
+// TxPrepare prepares the operations of the transaction on the participant.
+func (mp *metaPartition) TxPrepare(req *proto.TxRequest, p *Packet) (err error) {
+	if req.Tx == nil {
+		p.PacketErrorWithBody(proto.OpArgMismatchErr, []byte("no transaction"))
+		return
+	}
+	if status := mp.txAllocInodes(req.Tx); status != proto.OpOk {
+		p.PacketErrorWithBody(status, nil)
+		return
+	}
+	resp, err := mp.submitTx(opFSMTxPrepare, req.Tx)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	prepared := resp.(*TxPrepareResp)
+	if prepared.Status != proto.OpOk {
+		p.PacketErrorWithBody(prepared.Status, nil)
+		return
+	}
+	reply, err := json.Marshal(&proto.TxResponse{
+		State: prepared.Record.State,
+		Ops:   prepared.Record.PartitionOps(mp.config.PartitionId),
+	})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// TxCommit commits the prepared transaction on the participant.
+func (mp *metaPartition) TxCommit(req *proto.TxRequest, p *Packet) (err error) {
+	resp, err := mp.submitTx(opFSMTxCommit, &proto.TxRequest{TxID: req.TxID})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.ResultCode = resp.(uint8)
+	return
+}
+
+// TxAbort aborts the prepared transaction on the participant.
+func (mp *metaPartition) TxAbort(req *proto.TxRequest, p *Packet) (err error) {
+	resp, err := mp.submitTx(opFSMTxAbort, &proto.TxRequest{TxID: req.TxID})
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpAgain, []byte(err.Error()))
+		return
+	}
+	p.ResultCode = resp.(uint8)
+	return
+}
+
+// TxGetStatus replies the state of the transaction kept by the coordinator. The transaction is aborted or done if
+// there is no record of it.
+func (mp *metaPartition) TxGetStatus(req *proto.TxRequest, p *Packet) (err error) {
+	resp := &proto.TxResponse{State: proto.TxStateUnknown}
+	if record := mp.getTxRecord(req.TxID); record != nil {
+		resp.State = record.State
+	}
+	reply, err := json.Marshal(resp)
+	if err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(reply)
+	return
+}
+
+// GetTransactions returns the transactions kept by the meta partition.
+func (mp *metaPartition) GetTransactions() []*proto.TxInfo {
+	txs := make([]*proto.TxInfo, 0)
+	mp.txTree.GetTree().Ascend(func(i BtreeItem) bool {
+		txs = append(txs, &i.(*TxRecord).Copy().(*TxRecord).TxInfo)
+		return true
+	})
+	return txs
+}
+
+func (mp *metaPartition) txRecoveryWorker() {
+	t := time.NewTicker(txRecoveryInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			continue
+		}
+		mp.recoverTxs()
+	}
+}
+
+// recoverTxs finishes the committed transactions coordinated by the meta partition and aborts the expired prepared
+// ones, and resolves the prepared transactions of the participant by the coordinator.
+func (mp *metaPartition) recoverTxs() {
+	now := time.Now().Unix()
+	records := make([]*TxRecord, 0)
+	mp.txTree.GetTree().Ascend(func(i BtreeItem) bool {
+		records = append(records, i.(*TxRecord).Copy().(*TxRecord))
+		return true
+	})
+	for _, record := range records {
+		mp.txLock.Lock()
+		_, inflight := mp.txInflight[record.TxID]
+		mp.txLock.Unlock()
+		if inflight {
+			continue
+		}
+		if record.Coordinator == mp.config.PartitionId {
+			switch {
+			case record.State == proto.TxStateCommitted:
+				mp.finishTx(record)
+			case now-record.CreateTime > txTimeout:
+				log.LogWarnf("recoverTxs: abort expired transaction: partitionID(%v) txID(%v)",
+					mp.config.PartitionId, record.TxID)
+				mp.abortTx(record)
+			}
+			continue
+		}
+		if now-record.CreateTime <= 2*txTimeout {
+			continue
+		}
+		resp, status, err := mp.sendTx(record.Coordinator, nil, proto.OpMetaTxGetStatus,
+			&proto.TxRequest{VolName: mp.config.VolName, PartitionID: record.Coordinator, TxID: record.TxID})
+		if err != nil || status != proto.OpOk {
+			log.LogWarnf("recoverTxs: get status failed: partitionID(%v) txID(%v) coordinator(%v) status(%v) err(%v)",
+				mp.config.PartitionId, record.TxID, record.Coordinator, status, err)
+			continue
+		}
+		var op uint32
+		switch resp.State {
+		case proto.TxStateCommitted:
+			op = opFSMTxCommit
+		case proto.TxStateUnknown:
+			op = opFSMTxAbort
+		default:
+			continue
+		}
+		if _, err = mp.submitTx(op, &proto.TxRequest{TxID: record.TxID}); err != nil {
+			log.LogWarnf("recoverTxs: partitionID(%v) txID(%v) state(%v) err(%v)",
+				mp.config.PartitionId, record.TxID, resp.State, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The leader of each meta partition scans the inodes periodically to find the orphan ones, which are not referenced
+// by any dentry or multipart upload of the volume, e.g. the client fails between creating the inode and the dentry.
+// An inode is purged only if it is found unreferenced by two scans in a row, so that the inodes being linked or
+// renamed during a scan are not purged by mistake. The directories are reported only.
+const (
+	orphanScanInterval          = 24 * time.Hour
+	orphanGracePeriod           = time.Hour // the inodes created recently are not checked
+	orphanCheckBatchCount       = 10000
+	orphanPurgeBatchCount       = 1000
+	orphanCheckReadDeadlineTime = 120
+)
+
+func (mp *metaPartition) orphanScanWorker() {
+	t := time.NewTicker(orphanScanInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			mp.orphanLock.Lock()
+			mp.orphanSuspects = nil
+			mp.orphanLock.Unlock()
+			continue
+		}
+		mp.scanOrphans()
+	}
+}
+
+// scanOrphans finds the orphan inodes of the meta partition and purges the ones confirmed by the last scan.
+func (mp *metaPartition) scanOrphans() {
+	report := &proto.OrphanReport{
+		PartitionID: mp.config.PartitionId,
+		StartTime:   time.Now().Unix(),
+		Orphans:     make([]*proto.OrphanInode, 0),
+	}
+	defer func() {
+		report.EndTime = time.Now().Unix()
+		mp.orphanLock.Lock()
+		mp.orphanReport = report
+		mp.orphanLock.Unlock()
+		log.LogInfof("scanOrphans: partitionID(%v) scanned(%v) requeued(%v) suspects(%v) orphans(%v) err(%v)",
+			report.PartitionID, report.Scanned, report.Requeued, report.Suspects, len(report.Orphans), report.Error)
+	}()
+
+	candidates := mp.collectOrphanCandidates(report)
+	if err := mp.excludeReferencedInodes(candidates); err != nil {
+		report.Error = err.Error()
+		return
+	}
+
+	confirmed := make([]*Inode, 0)
+	mp.orphanLock.Lock()
+	suspects := make(map[uint64]struct{}, len(candidates))
+	for ino, inode := range candidates {
+		if _, ok := mp.orphanSuspects[ino]; ok {
+			confirmed = append(confirmed, inode)
+			continue
+		}
+		suspects[ino] = struct{}{}
+	}
+	mp.orphanSuspects = suspects
+	mp.orphanLock.Unlock()
+	report.Suspects = uint64(len(suspects))
+
+	sort.Slice(confirmed, func(i, j int) bool { return confirmed[i].Inode < confirmed[j].Inode })
+	files := make(InodeBatch, 0, orphanPurgeBatchCount)
+	orphans := make([]*proto.OrphanInode, 0, orphanPurgeBatchCount)
+	for _, inode := range confirmed {
+		orphan := &proto.OrphanInode{
+			Inode:      inode.Inode,
+			Mode:       inode.Type,
+			NLink:      inode.GetNLink(),
+			Size:       inode.Size,
+			CreateTime: inode.CreateTime,
+		}
+		report.Orphans = append(report.Orphans, orphan)
+		if proto.IsDir(inode.Type) {
+			continue
+		}
+		files = append(files, NewInode(inode.Inode, 0))
+		orphans = append(orphans, orphan)
+		if len(files) < orphanPurgeBatchCount {
+			continue
+		}
+		if err := mp.purgeOrphanInodes(files, orphans); err != nil {
+			report.Error = err.Error()
+			return
+		}
+		files, orphans = files[:0], orphans[:0]
+	}
+	if err := mp.purgeOrphanInodes(files, orphans); err != nil {
+		report.Error = err.Error()
+	}
+}
+
+// collectOrphanCandidates returns the inodes to be checked against the dentries, and puts the deleted inodes
+// missed by the free list back to it.
+func (mp *metaPartition) collectOrphanCandidates(report *proto.OrphanReport) (candidates map[uint64]*Inode) {
+	candidates = make(map[uint64]*Inode)
+	deadline := time.Now().Add(-orphanGracePeriod).Unix()
+	mp.inodeTree.Ascend(func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		report.Scanned++
+		if inode.Inode == proto.RootIno {
+			return true
+		}
+		isDir := proto.IsDir(inode.Type)
+		if !isDir && (inode.ShouldDelete() || inode.IsTempFile()) {
+			if !mp.freeList.Has(inode.Inode) {
+				mp.freeList.Push(inode.Inode)
+				report.Requeued++
+			}
+			return true
+		}
+		if inode.ShouldDelete() || inode.CreateTime > deadline {
+			return true
+		}
+		candidates[inode.Inode] = inode
+		return true
+	})
+	return
+}
+
+// excludeReferencedInodes removes the inodes referenced by any meta partition of the volume from the candidates.
+func (mp *metaPartition) excludeReferencedInodes(candidates map[uint64]*Inode) (err error) {
+	if len(candidates) == 0 {
+		return
+	}
+	views, err := masterClient.ClientAPI().GetMetaPartitions(mp.config.VolName)
+	if err != nil {
+		return errors.NewErrorf("get meta partitions of volume(%v): %v", mp.config.VolName, err)
+	}
+	inos := make([]uint64, 0, len(candidates))
+	for ino := range candidates {
+		inos = append(inos, ino)
+	}
+	sort.Slice(inos, func(i, j int) bool { return inos[i] < inos[j] })
+	for start := 0; start < len(inos); start += orphanCheckBatchCount {
+		end := start + orphanCheckBatchCount
+		if end > len(inos) {
+			end = len(inos)
+		}
+		for _, view := range views {
+			var referenced []uint64
+			if view.PartitionID == mp.config.PartitionId {
+				referenced = mp.referencedInodes(inos[start:end])
+			} else if referenced, err = mp.getReferencedInodes(view, inos[start:end]); err != nil {
+				return errors.NewErrorf("check meta partition(%v): %v", view.PartitionID, err)
+			}
+			for _, ino := range referenced {
+				delete(candidates, ino)
+			}
+		}
+	}
+	return
+}
+
+// getReferencedInodes asks the meta partition of the volume which of the inodes are referenced by it.
+func (mp *metaPartition) getReferencedInodes(view *proto.MetaPartitionView, inos []uint64) (referenced []uint64, err error) {
+	addr := view.LeaderAddr
+	if addr == "" && len(view.Members) > 0 {
+		// the request is forwarded to the leader by the member
+		addr = view.Members[0]
+	}
+	if addr == "" {
+		return nil, errors.New("no available member")
+	}
+	p := NewPacketToGetReferencedInodes(&proto.GetReferencedInodesRequest{
+		VolName:     mp.config.VolName,
+		PartitionID: view.PartitionID,
+		Inodes:      inos,
+	})
+	conn, err := mp.config.ConnPool.GetConnect(addr)
+	defer func() {
+		if err != nil {
+			mp.config.ConnPool.PutConnect(conn, ForceClosedConnect)
+		} else {
+			mp.config.ConnPool.PutConnect(conn, NoClosedConnect)
+		}
+	}()
+	if err != nil {
+		return
+	}
+	if err = p.WriteToConn(conn); err != nil {
+		return
+	}
+	if err = p.ReadFromConn(conn, orphanCheckReadDeadlineTime); err != nil {
+		return
+	}
+	if p.ResultCode != proto.OpOk {
+		return nil, errors.NewErrorf("%s response: %s", p.GetUniqueLogId(), p.GetResultMsg())
+	}
+	resp := &proto.GetReferencedInodesResponse{}
+	if err = json.Unmarshal(p.Data, resp); err != nil {
+		return
+	}
+	return resp.Inodes, nil
+}
+
+// referencedInodes returns the inodes referenced by the dentries or the multipart uploads of the meta partition.
+func (mp *metaPartition) referencedInodes(inos []uint64) (referenced []uint64) {
+	referenced = make([]uint64, 0)
+	unchecked := make(map[uint64]struct{}, len(inos))
+	for _, ino := range inos {
+		unchecked[ino] = struct{}{}
+	}
+	check := func(ino uint64) bool {
+		if _, ok := unchecked[ino]; ok {
+			referenced = append(referenced, ino)
+			delete(unchecked, ino)
+		}
+		return len(unchecked) > 0
+	}
+	if len(unchecked) == 0 {
+		return
+	}
+	mp.dentryTree.Ascend(func(i BtreeItem) bool {
+		return check(i.(*Dentry).Inode)
+	})
+	if len(unchecked) == 0 {
+		return
+	}
+	mp.multipartTree.Ascend(func(i BtreeItem) bool {
+		for _, part := range i.(*Multipart).Parts() {
+			if !check(part.Inode) {
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
+
+// purgeOrphanInodes marks the orphan files deleted through the raft, they are removed with the extents by the
+// delete worker.
+func (mp *metaPartition) purgeOrphanInodes(files InodeBatch, orphans []*proto.OrphanInode) (err error) {
+	if len(files) == 0 {
+		return
+	}
+	val, err := files.Marshal()
+	if err != nil {
+		return
+	}
+	if _, err = mp.submit(opFSMPurgeOrphanInode, val); err != nil {
+		return
+	}
+	for _, orphan := range orphans {
+		orphan.Purged = true
+	}
+	return
+}
+
+// GetReferencedInodes replies the inodes in the request referenced by the meta partition.
+func (mp *metaPartition) GetReferencedInodes(req *proto.GetReferencedInodesRequest, p *Packet) (err error) {
+	var encoded []byte
+	resp := &proto.GetReferencedInodesResponse{Inodes: mp.referencedInodes(req.Inodes)}
+	if encoded, err = json.Marshal(resp); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// GetOrphanReport returns the result of the latest orphan scan, the start time is 0 if it is not scanned yet.
+func (mp *metaPartition) GetOrphanReport() *proto.OrphanReport {
+	mp.orphanLock.RLock()
+	defer mp.orphanLock.RUnlock()
+	if mp.orphanReport == nil {
+		return &proto.OrphanReport{PartitionID: mp.config.PartitionId, Orphans: make([]*proto.OrphanInode, 0)}
+	}
+	return mp.orphanReport
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestOrphanInodes(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1}, nil).(*metaPartition)
+	for ino := uint64(2); ino <= 5; ino++ {
+		inode := NewInode(ino, uint32(0644))
+		inode.CreateTime -= int64(2 * orphanGracePeriod.Seconds())
+		mp.inodeTree.ReplaceOrInsert(inode, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: proto.RootIno, Name: "a", Inode: 2}, true)
+	multipart := &Multipart{id: "id", key: "key"}
+	multipart.InsertPart(&Part{ID: 1, Inode: 3}, true)
+	mp.multipartTree.ReplaceOrInsert(multipart, true)
+
+	if referenced := mp.referencedInodes([]uint64{2, 3, 4}); len(referenced) != 2 {
+		t.Fatalf("expect inodes 2 and 3 referenced, got %v", referenced)
+	}
+
+	report := &proto.OrphanReport{}
+	candidates := mp.collectOrphanCandidates(report)
+	if report.Scanned != 4 || len(candidates) != 4 {
+		t.Fatalf("expect 4 candidates, got scanned %v candidates %v", report.Scanned, len(candidates))
+	}
+
+	mp.fsmPurgeOrphanInodes(InodeBatch{NewInode(4, 0)})
+	inode := mp.inodeTree.Get(NewInode(4, 0)).(*Inode)
+	if !inode.ShouldDelete() || inode.GetNLink() != 0 || !mp.freeList.Has(4) {
+		t.Fatalf("expect inode 4 purged, got %v", inode)
+	}
+	mp.freeList.Remove(4)
+	report = &proto.OrphanReport{}
+	if candidates = mp.collectOrphanCandidates(report); len(candidates) != 3 || report.Requeued != 1 {
+		t.Fatalf("expect inode 4 requeued, got candidates %v requeued %v", len(candidates), report.Requeued)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// The deleted files are kept by the free list for the retention of the volume before their extents are purged.
+// An administrator can list them, purge them at once, or cancel the purge to hold them until they are linked
+// into the namespace again, e.g. by the Link of the SDK, so that the files removed by mistake can be restored.
+
+const defaultDeletedInodeListLimit = 1000
+
+// ListDeletedInodes returns the deleted files not purged yet, whose inode numbers are larger than the marker.
+func (mp *metaPartition) ListDeletedInodes(marker uint64, limit int) (inodes []*proto.DeletedInode) {
+	if limit <= 0 {
+		limit = defaultDeletedInodeListLimit
+	}
+	retention := mp.vol.InodeRetention()
+	inodes = make([]*proto.DeletedInode, 0)
+	mp.inodeTree.AscendGreaterOrEqual(&Inode{Inode: marker + 1}, func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		if proto.IsDir(inode.Type) || !inode.IsTempFile() {
+			return true
+		}
+		inodes = append(inodes, inode.deletedInfo(retention))
+		return len(inodes) < limit
+	})
+	return
+}
+
+func (i *Inode) deletedInfo(retention int64) (info *proto.DeletedInode) {
+	i.RLock()
+	defer i.RUnlock()
+	info = &proto.DeletedInode{
+		Inode:      i.Inode,
+		Size:       i.Size,
+		Evicted:    i.Flag&DeleteMarkFlag != 0,
+		Held:       i.Flag&PurgeHoldFlag != 0,
+		DeleteTime: i.AccessTime,
+	}
+	switch {
+	case info.Held:
+	case i.Flag&PurgeForceFlag != 0:
+		info.PurgeTime = i.AccessTime
+	case retention != 0:
+		info.PurgeTime = i.AccessTime + retention
+	case info.Evicted:
+		info.PurgeTime = i.AccessTime
+	default:
+		info.PurgeTime = i.AccessTime + InodeNLink0DelayDeleteSeconds
+	}
+	return
+}
+
+// PurgeInode purges the deleted file without waiting for the retention, even if it is held.
+func (mp *metaPartition) PurgeInode(ino uint64) (err error) {
+	return mp.submitPurge(opFSMPurgeInode, ino)
+}
+
+// CancelPurge holds the deleted file from being purged until it is linked again or purged by force.
+func (mp *metaPartition) CancelPurge(ino uint64) (err error) {
+	return mp.submitPurge(opFSMCancelPurge, ino)
+}
+
+func (mp *metaPartition) submitPurge(op uint32, ino uint64) (err error) {
+	resp, err := mp.submit(op, NewInode(ino, 0).MarshalKey())
+	if err != nil {
+		return
+	}
+	switch resp.(uint8) {
+	case proto.OpOk:
+	case proto.OpNotExistErr:
+		err = fmt.Errorf("inode %v not found", ino)
+	default:
+		err = fmt.Errorf("inode %v is not a deleted file", ino)
+	}
+	return
+}
+
+func (mp *metaPartition) getDeletedInode(ino *Inode) (inode *Inode, status uint8) {
+	item := mp.inodeTree.CopyGet(ino)
+	if item == nil {
+		return nil, proto.OpNotExistErr
+	}
+	inode = item.(*Inode)
+	if proto.IsDir(inode.Type) || !inode.IsTempFile() {
+		return nil, proto.OpArgMismatchErr
+	}
+	return inode, proto.OpOk
+}
+
+func (mp *metaPartition) fsmPurgeInode(ino *Inode) (status uint8) {
+	inode, status := mp.getDeletedInode(ino)
+	if status != proto.OpOk {
+		return
+	}
+	mp.versionInode(inode)
+	inode.DoWriteFunc(func() {
+		inode.Flag = (inode.Flag | DeleteMarkFlag | PurgeForceFlag) &^ PurgeHoldFlag
+	})
+	mp.inodeTree.Update(inode)
+	mp.freeList.Push(inode.Inode)
+	return
+}
+
+// fsmCancelPurge clears the delete mark of the evicted file, so that it can be linked again.
+func (mp *metaPartition) fsmCancelPurge(ino *Inode) (status uint8) {
+	inode, status := mp.getDeletedInode(ino)
+	if status != proto.OpOk {
+		return
+	}
+	mp.versionInode(inode)
+	inode.DoWriteFunc(func() {
+		inode.Flag = (inode.Flag | PurgeHoldFlag) &^ (DeleteMarkFlag | PurgeForceFlag)
+	})
+	mp.inodeTree.Update(inode)
+	return
+}
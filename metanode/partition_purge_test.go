@@ -0,0 +1,84 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestShouldDelayDelete(t *testing.T) {
+	now := time.Now().Unix()
+	unlinked := &Inode{Inode: 10, AccessTime: now - 3600}
+	evicted := &Inode{Inode: 11, AccessTime: now - 3600, Flag: DeleteMarkFlag}
+	if !unlinked.ShouldDelayDelete(0) || evicted.ShouldDelayDelete(0) {
+		t.Fatalf("expect the unlinked inode delayed and the evicted one removed by default")
+	}
+	if !unlinked.ShouldDelayDelete(7200) || !evicted.ShouldDelayDelete(7200) {
+		t.Fatalf("expect the deleted inodes kept within the retention")
+	}
+	if unlinked.ShouldDelayDelete(60) || evicted.ShouldDelayDelete(60) {
+		t.Fatalf("expect the deleted inodes removed after the retention")
+	}
+	evicted.Flag |= PurgeHoldFlag
+	if !evicted.ShouldDelayDelete(60) {
+		t.Fatalf("expect the held inode kept")
+	}
+}
+
+func TestPurgeDeletedInode(t *testing.T) {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	mp.vol.SetInodeRetention(3600)
+	mp.inodeTree.ReplaceOrInsert(NewInode(1, uint32(os.ModeDir)), true)
+	mp.inodeTree.ReplaceOrInsert(&Inode{Inode: 10, Type: 0644, NLink: 1}, true)
+	mp.inodeTree.ReplaceOrInsert(&Inode{Inode: 11, Type: 0644, AccessTime: time.Now().Unix(), Flag: DeleteMarkFlag}, true)
+	mp.inodeTree.ReplaceOrInsert(&Inode{Inode: 12, Type: 0644, AccessTime: time.Now().Unix()}, true)
+
+	inodes := mp.ListDeletedInodes(0, 0)
+	if len(inodes) != 2 || inodes[0].Inode != 11 || !inodes[0].Evicted || inodes[1].Inode != 12 {
+		t.Fatalf("expect the deleted inodes 11 and 12, got %v", inodes)
+	}
+	if inodes = mp.ListDeletedInodes(11, 1); len(inodes) != 1 || inodes[0].Inode != 12 {
+		t.Fatalf("expect the deleted inode 12 after the marker, got %v", inodes)
+	}
+	if status := mp.fsmCancelPurge(NewInode(10, 0)); status != proto.OpArgMismatchErr {
+		t.Fatalf("expect the linked inode refused, got status %v", status)
+	}
+
+	if status := mp.fsmCancelPurge(NewInode(11, 0)); status != proto.OpOk {
+		t.Fatalf("cancel: status %v", status)
+	}
+	inode := mp.inodeTree.Get(NewInode(11, 0)).(*Inode)
+	if inode.ShouldDelete() || !inode.IsPurgeHeld() || !inode.ShouldDelayDelete(1) {
+		t.Fatalf("expect the inode held, got %v", inode)
+	}
+	if resp := mp.fsmCreateLinkInode(NewInode(11, 0)); resp.Status != proto.OpOk {
+		t.Fatalf("link: status %v", resp.Status)
+	}
+	if inode = mp.inodeTree.Get(NewInode(11, 0)).(*Inode); inode.IsPurgeHeld() || inode.GetNLink() != 1 {
+		t.Fatalf("expect the inode restored, got %v", inode)
+	}
+
+	if status := mp.fsmPurgeInode(NewInode(12, 0)); status != proto.OpOk {
+		t.Fatalf("purge: status %v", status)
+	}
+	inode = mp.inodeTree.Get(NewInode(12, 0)).(*Inode)
+	if !inode.ShouldDelete() || inode.ShouldDelayDelete(3600) || !mp.freeList.Has(12) {
+		t.Fatalf("expect the inode purged at once, got %v", inode)
+	}
+}
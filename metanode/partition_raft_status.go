@@ -0,0 +1,184 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/raftstore"
+	"github.com/chubaofs/chubaofs/util/exporter"
+)
+
+const (
+	raftMetricsInterval = 30 * time.Second
+
+	MetricMetaPartitionCommitIndex   = "metaPartition_commit_index"
+	MetricMetaPartitionAppliedIndex  = "metaPartition_applied_index"
+	MetricMetaPartitionSnapshotIndex = "metaPartition_snapshot_index"
+	MetricMetaPartitionFollowerLag   = "metaPartition_follower_lag"
+)
+
+var raftMetricsStopC = make(chan struct{}, 1)
+
+// GetSnapshotIndex returns the apply index of the latest snapshot stored, the raft log before which is truncated.
+func (mp *metaPartition) GetSnapshotIndex() uint64 {
+	return atomic.LoadUint64(&mp.snapshotIndex)
+}
+
+// GetRaftProgress returns the raft progress of the replica, and the ones of the other replicas if it is the leader.
+func (mp *metaPartition) GetRaftProgress() *proto.MetaPartitionRaftStatus {
+	status := &proto.MetaPartitionRaftStatus{
+		PartitionID:   mp.config.PartitionId,
+		VolName:       mp.config.VolName,
+		NodeID:        mp.config.NodeId,
+		Applied:       mp.GetAppliedID(),
+		SnapshotIndex: mp.GetSnapshotIndex(),
+		Replicas:      make([]*proto.RaftReplicaStatus, 0),
+	}
+	addrs := make(map[uint64]string, len(mp.config.Peers))
+	for _, peer := range mp.config.Peers {
+		addrs[peer.ID] = peer.Addr
+	}
+	status.Addr = addrs[status.NodeID]
+	raftStatus := mp.GetRaftStatus()
+	if raftStatus == nil {
+		status.Stopped = true
+		return status
+	}
+	fillRaftProgress(status, raftStatus, addrs)
+	return status
+}
+
+func fillRaftProgress(status *proto.MetaPartitionRaftStatus, raftStatus *raftstore.PartitionStatus, addrs map[uint64]string) {
+	status.State = raftStatus.State
+	status.Leader = raftStatus.Leader
+	status.Term = raftStatus.Term
+	status.Index = raftStatus.Index
+	status.Commit = raftStatus.Commit
+	status.PendQueue = raftStatus.PendQueue
+	status.AppQueue = raftStatus.AppQueue
+	status.Stopped = raftStatus.Stopped
+	status.RestoringSnapshot = raftStatus.RestoringSnapshot
+	for id, replica := range raftStatus.Replicas {
+		r := &proto.RaftReplicaStatus{
+			NodeID:       id,
+			Addr:         addrs[id],
+			Match:        replica.Match,
+			Commit:       replica.Commit,
+			Next:         replica.Next,
+			State:        replica.State,
+			Active:       replica.Active,
+			Learner:      replica.Learner,
+			Snapshotting: replica.Snapshoting,
+			Paused:       replica.Paused,
+			LastActive:   replica.LastActive.Unix(),
+		}
+		if raftStatus.Index > replica.Match {
+			r.Lag = raftStatus.Index - replica.Match
+		}
+		status.Replicas = append(status.Replicas, r)
+	}
+	sort.Slice(status.Replicas, func(i, j int) bool { return status.Replicas[i].NodeID < status.Replicas[j].NodeID })
+}
+
+// raftMetrics exports the raft progress of the meta partitions on the meta node.
+type raftMetrics struct {
+	commit   *exporter.GaugeVec
+	applied  *exporter.GaugeVec
+	snapshot *exporter.GaugeVec
+	lag      *exporter.GaugeVec
+	exported map[*exporter.GaugeVec]map[string][]string // the label values set by the last round
+}
+
+func newRaftMetrics() *raftMetrics {
+	labels := []string{"volName", "partitionID"}
+	rm := &raftMetrics{
+		commit:   exporter.NewGaugeVec(MetricMetaPartitionCommitIndex, "", labels),
+		applied:  exporter.NewGaugeVec(MetricMetaPartitionAppliedIndex, "", labels),
+		snapshot: exporter.NewGaugeVec(MetricMetaPartitionSnapshotIndex, "", labels),
+		lag:      exporter.NewGaugeVec(MetricMetaPartitionFollowerLag, "", append(labels, "replica")),
+		exported: make(map[*exporter.GaugeVec]map[string][]string),
+	}
+	if rm.commit == nil || rm.applied == nil || rm.snapshot == nil || rm.lag == nil {
+		return nil
+	}
+	return rm
+}
+
+// update sets the metrics of the partitions, and deletes the ones of the partitions not on the node any more or
+// of the followers of the partitions not led by the node any more.
+func (rm *raftMetrics) update(statuses []*proto.MetaPartitionRaftStatus) {
+	current := make(map[*exporter.GaugeVec]map[string][]string)
+	set := func(vec *exporter.GaugeVec, val uint64, lvs ...string) {
+		if current[vec] == nil {
+			current[vec] = make(map[string][]string)
+		}
+		current[vec][strings.Join(lvs, ",")] = lvs
+		vec.SetWithLabelValues(float64(val), lvs...)
+	}
+	for _, status := range statuses {
+		pid := strconv.FormatUint(status.PartitionID, 10)
+		set(rm.commit, status.Commit, status.VolName, pid)
+		set(rm.applied, status.Applied, status.VolName, pid)
+		set(rm.snapshot, status.SnapshotIndex, status.VolName, pid)
+		for _, replica := range status.Replicas {
+			if replica.NodeID != status.NodeID {
+				set(rm.lag, replica.Lag, status.VolName, pid, replica.Addr)
+			}
+		}
+	}
+	for vec, exported := range rm.exported {
+		for key, lvs := range exported {
+			if _, ok := current[vec][key]; !ok {
+				vec.DeleteLabelValues(lvs...)
+			}
+		}
+	}
+	rm.exported = current
+}
+
+func (m *MetaNode) startRaftMetrics() {
+	rm := newRaftMetrics()
+	if rm == nil {
+		return
+	}
+	ticker := time.NewTicker(raftMetricsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-raftMetricsStopC:
+			return
+		case <-ticker.C:
+		}
+		statuses := make([]*proto.MetaPartitionRaftStatus, 0)
+		m.metadataManager.Range(func(id uint64, mp MetaPartition) bool {
+			statuses = append(statuses, mp.GetRaftProgress())
+			return true
+		})
+		rm.update(statuses)
+	}
+}
+
+func (m *MetaNode) stopRaftMetrics() {
+	select {
+	case raftMetricsStopC <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"testing"
+
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/raftstore"
+)
+
+func TestFillRaftProgress(t *testing.T) {
+	status := &proto.MetaPartitionRaftStatus{NodeID: 1}
+	raftStatus := &raftstore.PartitionStatus{
+		State:  "StateLeader",
+		Leader: 1,
+		Index:  120,
+		Commit: 110,
+		Replicas: map[uint64]*raft.ReplicaStatus{
+			3: {Match: 20, Commit: 20},
+			1: {Match: 120, Commit: 110},
+			2: {Match: 130, Commit: 110},
+		},
+	}
+	fillRaftProgress(status, raftStatus, map[uint64]string{1: "a", 2: "b", 3: "c"})
+	if status.Commit != 110 || status.Index != 120 || len(status.Replicas) != 3 {
+		t.Fatalf("unexpected status %v", status)
+	}
+	for i, expect := range []struct {
+		addr string
+		lag  uint64
+	}{{"a", 0}, {"b", 0}, {"c", 100}} {
+		if r := status.Replicas[i]; r.Addr != expect.addr || r.Lag != expect.lag {
+			t.Fatalf("expect replica %v lag %v, got %v lag %v", expect.addr, expect.lag, r.Addr, r.Lag)
+		}
+	}
+
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, NodeId: 1, Peers: []proto.Peer{{ID: 1, Addr: "a"}}}, nil).(*metaPartition)
+	mp.applyID, mp.snapshotIndex = 100, 90
+	if progress := mp.GetRaftProgress(); !progress.Stopped || progress.Addr != "a" || progress.Applied != 100 ||
+		progress.SnapshotIndex != 90 {
+		t.Fatalf("expect the progress without the raft, got %v", progress)
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/errors"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The leader of each meta partition checks the replicas periodically to find the silent drift between them. The
+// leader splits the inodes into ranges and submits a replica check through the raft, each replica copies the trees
+// when the check is applied, and computes the checksums of the ranges in the background. So the checksums of the
+// replicas are computed at the same raft index, and the leader compares the ones of the followers with its own.
+// The times of the inodes are not checked since they are set by the local clocks of the replicas. The divergences
+// of the latest check are reported to the master by the heartbeats.
+const (
+	replicaCheckInterval         = 6 * time.Hour
+	replicaCheckRangeCount       = 10000 // the max inodes of a range
+	replicaCheckMaxDivergences   = 100
+	replicaCheckWaitTime         = 10 * time.Minute // the max time to wait for the checksums of the followers
+	replicaCheckRetryInterval    = 10 * time.Second
+	replicaCheckReadDeadlineTime = 30
+)
+
+// replicaCheck is the latest replica check applied by the replica, the checksum is set when the done is closed.
+type replicaCheck struct {
+	checkID  uint64
+	done     chan struct{}
+	checksum *proto.ReplicaChecksum
+}
+
+func (mp *metaPartition) replicaCheckWorker() {
+	t := time.NewTicker(replicaCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-mp.stopC:
+			return
+		case <-t.C:
+		}
+		if _, isLeader := mp.IsLeader(); !isLeader {
+			mp.replicaCheckLock.Lock()
+			mp.replicaCheckReport = nil
+			mp.replicaCheckLock.Unlock()
+			continue
+		}
+		mp.checkReplicas()
+	}
+}
+
+// checkReplicas submits a replica check and compares the checksums of the followers with the leader.
+func (mp *metaPartition) checkReplicas() {
+	report := &proto.ReplicaCheckReport{
+		PartitionID: mp.config.PartitionId,
+		StartTime:   time.Now().Unix(),
+		Checked:     make([]string, 0),
+		Divergences: make([]*proto.ReplicaDivergence, 0),
+	}
+	defer func() {
+		report.EndTime = time.Now().Unix()
+		mp.replicaCheckLock.Lock()
+		mp.replicaCheckReport = report
+		mp.replicaCheckLock.Unlock()
+		if len(report.Divergences) > 0 {
+			log.LogWarnf("checkReplicas: partitionID(%v) checkID(%v) divergences(%v)", mp.config.PartitionId,
+				report.CheckID, len(report.Divergences))
+		}
+	}()
+	bounds := replicaCheckBounds(mp.getInodeTree(), replicaCheckRangeCount)
+	data, err := json.Marshal(bounds)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+	resp, err := mp.submit(opFSMReplicaCheck, data)
+	if err != nil {
+		report.Error = err.Error()
+		return
+	}
+	check := resp.(*replicaCheck)
+	report.CheckID = check.checkID
+	select {
+	case <-check.done:
+	case <-mp.stopC:
+		return
+	}
+	report.Ranges = len(check.checksum.Ranges)
+	for _, peer := range mp.config.Peers {
+		if peer.ID == mp.config.NodeId {
+			continue
+		}
+		checksum, err := mp.waitReplicaChecksum(peer.Addr, check.checkID)
+		if err != nil {
+			log.LogWarnf("checkReplicas: partitionID(%v) checkID(%v) replica(%v) err(%v)", mp.config.PartitionId,
+				check.checkID, peer.Addr, err)
+			report.Error = fmt.Sprintf("replica %v: %v", peer.Addr, err)
+			continue
+		}
+		report.Checked = append(report.Checked, peer.Addr)
+		for _, divergence := range compareReplicaChecksums(peer.Addr, check.checksum, checksum) {
+			if len(report.Divergences) >= replicaCheckMaxDivergences {
+				break
+			}
+			report.Divergences = append(report.Divergences, divergence)
+		}
+	}
+}
+
+// replicaCheckBounds returns the starts of the ranges, each of which has the count of the inodes at most.
+func replicaCheckBounds(inodeTree *BTree, count int) (bounds []uint64) {
+	bounds = []uint64{0}
+	n := 0
+	inodeTree.Ascend(func(i BtreeItem) bool {
+		if n++; n > count {
+			bounds = append(bounds, i.(*Inode).Inode)
+			n = 1
+		}
+		return true
+	})
+	return
+}
+
+// waitReplicaChecksum gets the checksums of the check from the replica, and waits if the replica has not applied
+// or computed the check.
+func (mp *metaPartition) waitReplicaChecksum(addr string, checkID uint64) (checksum *proto.ReplicaChecksum, err error) {
+	deadline := time.Now().Add(replicaCheckWaitTime)
+	for {
+		p := NewPacketToMetaPartition(proto.OpMetaGetReplicaChecksum, mp.config.PartitionId,
+			&proto.GetReplicaChecksumRequest{VolName: mp.config.VolName, PartitionID: mp.config.PartitionId, CheckID: checkID})
+		if err = mp.sendPacket(addr, p, replicaCheckReadDeadlineTime); err != nil {
+			return
+		}
+		switch p.ResultCode {
+		case proto.OpOk:
+			checksum = &proto.ReplicaChecksum{}
+			err = json.Unmarshal(p.Data, checksum)
+			return
+		case proto.OpAgain:
+			if time.Now().After(deadline) {
+				return nil, errors.NewErrorf("wait timeout: %s", p.GetResultMsg())
+			}
+		default:
+			return nil, errors.NewErrorf("%s response: %s", p.GetUniqueLogId(), p.GetResultMsg())
+		}
+		select {
+		case <-mp.stopC:
+			return nil, errors.New("partition stopped")
+		case <-time.After(replicaCheckRetryInterval):
+		}
+	}
+}
+
+// compareReplicaChecksums returns the ranges of the replica whose checksums differ from the leader.
+func compareReplicaChecksums(addr string, leader, replica *proto.ReplicaChecksum) (divergences []*proto.ReplicaDivergence) {
+	if len(leader.Ranges) != len(replica.Ranges) {
+		return []*proto.ReplicaDivergence{{Addr: addr, End: math.MaxUint64,
+			Issue: fmt.Sprintf("ranges[%v] differ from the leader[%v]", len(replica.Ranges), len(leader.Ranges))}}
+	}
+	for i, l := range leader.Ranges {
+		r := replica.Ranges[i]
+		var issue string
+		switch {
+		case r.InodeCount != l.InodeCount:
+			issue = fmt.Sprintf("inode count[%v] differs from the leader[%v]", r.InodeCount, l.InodeCount)
+		case r.InodeCRC != l.InodeCRC:
+			issue = "inode checksum differs from the leader"
+		case r.DentryCount != l.DentryCount:
+			issue = fmt.Sprintf("dentry count[%v] differs from the leader[%v]", r.DentryCount, l.DentryCount)
+		case r.DentryCRC != l.DentryCRC:
+			issue = "dentry checksum differs from the leader"
+		default:
+			continue
+		}
+		divergences = append(divergences, &proto.ReplicaDivergence{Addr: addr, Start: l.Start, End: l.End, Issue: issue})
+	}
+	return
+}
+
+// fsmReplicaCheck copies the trees at the index of the check, and computes the checksums in the background.
+func (mp *metaPartition) fsmReplicaCheck(index uint64, bounds []uint64) *replicaCheck {
+	check := &replicaCheck{checkID: index, done: make(chan struct{})}
+	inodeTree, dentryTree := mp.getInodeTree(), mp.getDentryTree()
+	go func() {
+		check.checksum = computeReplicaChecksum(index, bounds, inodeTree, dentryTree)
+		close(check.done)
+	}()
+	mp.replicaCheckLock.Lock()
+	mp.replicaCheck = check
+	mp.replicaCheckLock.Unlock()
+	return check
+}
+
+// computeReplicaChecksum computes the checksums of the ranges starting at the bounds.
+func computeReplicaChecksum(checkID uint64, bounds []uint64, inodeTree, dentryTree *BTree) *proto.ReplicaChecksum {
+	if len(bounds) == 0 || bounds[0] != 0 {
+		bounds = append([]uint64{0}, bounds...)
+	}
+	ranges := make([]*proto.ReplicaCheckRange, len(bounds))
+	inodeHashes := make([]hash.Hash32, len(bounds))
+	dentryHashes := make([]hash.Hash32, len(bounds))
+	for i, start := range bounds {
+		ranges[i] = &proto.ReplicaCheckRange{Start: start, End: math.MaxUint64}
+		if i > 0 {
+			ranges[i-1].End = start
+		}
+		inodeHashes[i], dentryHashes[i] = crc32.NewIEEE(), crc32.NewIEEE()
+	}
+	rangeOf := func(ino uint64) int {
+		return sort.Search(len(bounds), func(i int) bool { return bounds[i] > ino }) - 1
+	}
+	inodeTree.Ascend(func(i BtreeItem) bool {
+		inode := i.(*Inode)
+		idx := rangeOf(inode.Inode)
+		ranges[idx].InodeCount++
+		inodeHashes[idx].Write(inode.checksumBytes())
+		return true
+	})
+	dentryTree.Ascend(func(i BtreeItem) bool {
+		dentry := i.(*Dentry)
+		idx := rangeOf(dentry.ParentId)
+		ranges[idx].DentryCount++
+		dentryHashes[idx].Write(dentry.MarshalKey())
+		dentryHashes[idx].Write(dentry.MarshalValue())
+		return true
+	})
+	for i := range ranges {
+		ranges[i].InodeCRC, ranges[i].DentryCRC = inodeHashes[i].Sum32(), dentryHashes[i].Sum32()
+	}
+	return &proto.ReplicaChecksum{CheckID: checkID, Ranges: ranges}
+}
+
+// checksumBytes returns the fields of the inode to be checked between the replicas, which are all except the times.
+func (i *Inode) checksumBytes() []byte {
+	buff := bytes.NewBuffer(make([]byte, 0, 128))
+	i.RLock()
+	defer i.RUnlock()
+	_ = binary.Write(buff, binary.BigEndian, i.Inode)
+	_ = binary.Write(buff, binary.BigEndian, i.Type)
+	_ = binary.Write(buff, binary.BigEndian, i.Uid)
+	_ = binary.Write(buff, binary.BigEndian, i.Gid)
+	_ = binary.Write(buff, binary.BigEndian, i.Size)
+	_ = binary.Write(buff, binary.BigEndian, i.Generation)
+	_ = binary.Write(buff, binary.BigEndian, i.NLink)
+	_ = binary.Write(buff, binary.BigEndian, i.Flag)
+	buff.Write(i.LinkTarget)
+	if i.Extents != nil {
+		extents, _ := i.Extents.MarshalBinary()
+		buff.Write(extents)
+	}
+	return buff.Bytes()
+}
+
+// GetReplicaChecksum replies the checksums of the replica check, or asks the leader to try again if the check is
+// not applied or computed yet.
+func (mp *metaPartition) GetReplicaChecksum(req *proto.GetReplicaChecksumRequest, p *Packet) (err error) {
+	mp.replicaCheckLock.RLock()
+	check := mp.replicaCheck
+	mp.replicaCheckLock.RUnlock()
+	if check == nil || check.checkID < req.CheckID {
+		p.PacketErrorWithBody(proto.OpAgain, []byte("check not applied"))
+		return
+	}
+	if check.checkID > req.CheckID {
+		p.PacketErrorWithBody(proto.OpNotExistErr, []byte("check replaced by a later one"))
+		return
+	}
+	select {
+	case <-check.done:
+	default:
+		p.PacketErrorWithBody(proto.OpAgain, []byte("check in progress"))
+		return
+	}
+	var encoded []byte
+	if encoded, err = json.Marshal(check.checksum); err != nil {
+		p.PacketErrorWithBody(proto.OpErr, []byte(err.Error()))
+		return
+	}
+	p.PacketOkWithBody(encoded)
+	return
+}
+
+// GetReplicaCheckReport returns the result of the latest replica check by the leader, nil if it is not checked yet.
+func (mp *metaPartition) GetReplicaCheckReport() *proto.ReplicaCheckReport {
+	mp.replicaCheckLock.RLock()
+	defer mp.replicaCheckLock.RUnlock()
+	return mp.replicaCheckReport
+}
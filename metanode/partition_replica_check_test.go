@@ -0,0 +1,85 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newReplicaCheckPartition() *metaPartition {
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, Start: 1, End: 100}, nil).(*metaPartition)
+	for ino := uint64(1); ino <= 5; ino++ {
+		mp.inodeTree.ReplaceOrInsert(&Inode{Inode: ino, Type: 0644, NLink: 1, ModifyTime: time.Now().Unix()}, true)
+	}
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 1, Name: "a", Inode: 2, Type: 0644}, true)
+	mp.dentryTree.ReplaceOrInsert(&Dentry{ParentId: 4, Name: "b", Inode: 5, Type: 0644}, true)
+	return mp
+}
+
+func TestReplicaCheckBounds(t *testing.T) {
+	mp := newReplicaCheckPartition()
+	if bounds := replicaCheckBounds(mp.inodeTree, 2); !reflect.DeepEqual(bounds, []uint64{0, 3, 5}) {
+		t.Fatalf("expect the bounds [0 3 5], got %v", bounds)
+	}
+	if bounds := replicaCheckBounds(mp.inodeTree, 10); !reflect.DeepEqual(bounds, []uint64{0}) {
+		t.Fatalf("expect a single range, got %v", bounds)
+	}
+}
+
+func TestCompareReplicaChecksums(t *testing.T) {
+	leader, follower := newReplicaCheckPartition(), newReplicaCheckPartition()
+	bounds := []uint64{0, 3, 5}
+	// the times are not checked
+	follower.inodeTree.Get(NewInode(1, 0)).(*Inode).ModifyTime++
+	l := computeReplicaChecksum(10, bounds, leader.inodeTree, leader.dentryTree)
+	if len(l.Ranges) != 3 || l.Ranges[0].InodeCount != 2 || l.Ranges[1].DentryCount != 1 || l.Ranges[2].End == 0 {
+		t.Fatalf("unexpected ranges %v", l.Ranges)
+	}
+	if divergences := compareReplicaChecksums("b", l,
+		computeReplicaChecksum(10, bounds, follower.inodeTree, follower.dentryTree)); len(divergences) != 0 {
+		t.Fatalf("expect the replicas consistent, got %v", divergences)
+	}
+
+	follower.inodeTree.Get(NewInode(2, 0)).(*Inode).Size = 100
+	follower.dentryTree.Delete(&Dentry{ParentId: 4, Name: "b"})
+	divergences := compareReplicaChecksums("b", l, computeReplicaChecksum(10, bounds, follower.inodeTree, follower.dentryTree))
+	if len(divergences) != 2 || divergences[0].Start != 0 || divergences[0].End != 3 || divergences[1].Start != 3 {
+		t.Fatalf("expect the first two ranges diverged, got %v", divergences)
+	}
+}
+
+func TestGetReplicaChecksum(t *testing.T) {
+	mp := newReplicaCheckPartition()
+	get := func(checkID uint64) *Packet {
+		p := &Packet{}
+		_ = mp.GetReplicaChecksum(&proto.GetReplicaChecksumRequest{PartitionID: 1, CheckID: checkID}, p)
+		return p
+	}
+	if p := get(10); p.ResultCode != proto.OpAgain {
+		t.Fatalf("expect the check not applied, got %v", p.ResultCode)
+	}
+	check := mp.fsmReplicaCheck(10, []uint64{0})
+	<-check.done
+	if p := get(10); p.ResultCode != proto.OpOk {
+		t.Fatalf("expect the checksums replied, got %v", p.ResultCode)
+	}
+	if p := get(9); p.ResultCode != proto.OpNotExistErr {
+		t.Fatalf("expect the earlier check replaced, got %v", p.ResultCode)
+	}
+}
@@ -42,6 +42,7 @@ const (
 	dentryFile      = "dentry"
 	extendFile      = "extend"
 	multipartFile   = "multipart"
+	txFile          = "transaction"
 	applyIDFile     = "apply"
 	SnapshotSign    = ".sign"
 	metadataFile    = "meta"
@@ -77,6 +78,8 @@ func (mp *metaPartition) loadMetadata() (err error) {
 	mp.config.Start = mConf.Start
 	mp.config.End = mConf.End
 	mp.config.Peers = mConf.Peers
+	mp.config.MetaStore = mConf.MetaStore
+	mp.config.IgnoreCase = mConf.IgnoreCase
 	mp.config.Cursor = mp.config.Start
 
 	log.LogInfof("loadMetadata: load complete: partitionID(%v) volume(%v) range(%v,%v) cursor(%v)",
@@ -135,8 +138,8 @@ func (mp *metaPartition) loadInode(rootDir string) (err error) {
 			err = errors.NewErrorf("[loadInode] Unmarshal: %s", err.Error())
 			return
 		}
-		mp.fsmCreateInode(ino)
 		mp.checkAndInsertFreeList(ino)
+		mp.fsmCreateInode(ino)
 		if mp.config.Cursor < ino.Inode {
 			mp.config.Cursor = ino.Inode
 		}
@@ -295,6 +298,49 @@ func (mp *metaPartition) loadMultipart(rootDir string) error {
 	return nil
 }
 
+// loadTransaction loads the records of the transactions and locks the dentries of the prepared ones.
+func (mp *metaPartition) loadTransaction(rootDir string) (err error) {
+	filename := path.Join(rootDir, txFile)
+	if _, err = os.Stat(filename); err != nil {
+		return nil
+	}
+	fp, err := os.OpenFile(filename, os.O_RDONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = fp.Close()
+	}()
+	var mem mmap.MMap
+	if mem, err = mmap.Map(fp, mmap.RDONLY, 0); err != nil {
+		return
+	}
+	defer func() {
+		_ = mem.Unmap()
+	}()
+	var offset, n int
+	// read number of transactions
+	var numTxs uint64
+	numTxs, n = binary.Uvarint(mem)
+	offset += n
+	for i := uint64(0); i < numTxs; i++ {
+		// read length
+		var numBytes uint64
+		numBytes, n = binary.Uvarint(mem[offset:])
+		offset += n
+		var record *TxRecord
+		if record, err = TxRecordFromBytes(mem[offset : offset+int(numBytes)]); err != nil {
+			return
+		}
+		mp.txTree.ReplaceOrInsert(record, true)
+		offset += int(numBytes)
+	}
+	mp.rebuildTxLocks()
+	log.LogInfof("loadTransaction: load complete: partitionID(%v) numTxs(%v) filename(%v)",
+		mp.config.PartitionId, numTxs, filename)
+	return
+}
+
 func (mp *metaPartition) loadApplyID(rootDir string) (err error) {
 	filename := path.Join(rootDir, applyIDFile)
 	if _, err = os.Stat(filename); err != nil {
@@ -604,3 +650,70 @@ func (mp *metaPartition) storeMultipart(rootDir string, sm *storeMsg) (crc uint3
 		mp.config.PartitionId, mp.config.VolName, multipartTree.Len(), crc)
 	return
 }
+
+func (mp *metaPartition) storeTransaction(rootDir string, sm *storeMsg) (crc uint32, err error) {
+	var txTree = sm.txTree
+	if txTree == nil {
+		txTree = NewBtree()
+	}
+	var fp = path.Join(rootDir, txFile)
+	var f *os.File
+	f, err = os.OpenFile(fp, os.O_RDWR|os.O_TRUNC|os.O_APPEND|os.O_CREATE, 0755)
+	if err != nil {
+		return
+	}
+	defer func() {
+		closeErr := f.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	var writer = bufio.NewWriterSize(f, 4*1024*1024)
+	var crc32 = crc32.NewIEEE()
+	var varintTmp = make([]byte, binary.MaxVarintLen64)
+	var n int
+	// write number of transactions
+	n = binary.PutUvarint(varintTmp, uint64(txTree.Len()))
+	if _, err = writer.Write(varintTmp[:n]); err != nil {
+		return
+	}
+	if _, err = crc32.Write(varintTmp[:n]); err != nil {
+		return
+	}
+	txTree.Ascend(func(i BtreeItem) bool {
+		var raw []byte
+		if raw, err = i.(*TxRecord).Bytes(); err != nil {
+			return false
+		}
+		// write length
+		n = binary.PutUvarint(varintTmp, uint64(len(raw)))
+		if _, err = writer.Write(varintTmp[:n]); err != nil {
+			return false
+		}
+		if _, err = crc32.Write(varintTmp[:n]); err != nil {
+			return false
+		}
+		// write raw
+		if _, err = writer.Write(raw); err != nil {
+			return false
+		}
+		if _, err = crc32.Write(raw); err != nil {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return
+	}
+
+	if err = writer.Flush(); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	crc = crc32.Sum32()
+	log.LogInfof("storeTransaction: store complete: partitoinID(%v) volume(%v) numTxs(%v) crc(%v)",
+		mp.config.PartitionId, mp.config.VolName, txTree.Len(), crc)
+	return
+}
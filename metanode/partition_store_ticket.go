@@ -16,6 +16,7 @@ package metanode
 
 import (
 	"encoding/binary"
+	"sync/atomic"
 	"time"
 
 	"github.com/chubaofs/chubaofs/cmd/common"
@@ -31,6 +32,7 @@ type storeMsg struct {
 	dentryTree    *BTree
 	extendTree    *BTree
 	multipartTree *BTree
+	txTree        *BTree
 }
 
 func (mp *metaPartition) startSchedule(curIndex uint64) {
@@ -38,6 +40,7 @@ func (mp *metaPartition) startSchedule(curIndex uint64) {
 	timer.Stop()
 	timerCursor := time.NewTimer(intervalToSyncCursor)
 	scheduleState := common.StateStopped
+	atomic.StoreUint64(&mp.snapshotIndex, curIndex)
 	dumpFunc := func(msg *storeMsg) {
 		log.LogDebugf("[startSchedule] partitionId=%d: nowAppID"+
 			"=%d, applyID=%d", mp.config.PartitionId, curIndex,
@@ -52,6 +55,7 @@ func (mp *metaPartition) startSchedule(curIndex uint64) {
 					" truncate raft log")
 			}
 			curIndex = msg.applyIndex
+			atomic.StoreUint64(&mp.snapshotIndex, curIndex)
 		} else {
 			// retry again
 			mp.storeChan <- msg
@@ -0,0 +1,443 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/storage"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	volSnapshotDirPrefix    = "volsnapshot_"
+	volSnapshotDirTmpPrefix = ".volsnapshot_"
+)
+
+// volSnapshotExtent identifies an extent referenced by the inodes. The tiny extents are shared by the files,
+// so the range in the tiny extent is a part of the identity.
+type volSnapshotExtent struct {
+	partitionID  uint64
+	extentID     uint64
+	extentOffset uint64
+	size         uint32
+}
+
+func newVolSnapshotExtent(ek *proto.ExtentKey) (ref volSnapshotExtent) {
+	ref = volSnapshotExtent{partitionID: ek.PartitionId, extentID: ek.ExtentId}
+	if storage.IsTinyExtent(ek.ExtentId) {
+		ref.extentOffset, ref.size = ek.ExtentOffset, ek.Size
+	}
+	return
+}
+
+// collectExtents returns the extents referenced by the inodes of the tree and their versions.
+func collectExtents(inodeTree *BTree) (extents map[volSnapshotExtent]proto.ExtentKey) {
+	extents = make(map[volSnapshotExtent]proto.ExtentKey)
+	collect := func(ek proto.ExtentKey) bool {
+		extents[newVolSnapshotExtent(&ek)] = ek
+		return true
+	}
+	inodeTree.Ascend(func(i BtreeItem) bool {
+		i.(*Inode).Extents.Range(collect)
+		i.(*Inode).rangeVersionExtents(collect)
+		return true
+	})
+	return
+}
+
+// volSnapshotStore is a snapshot being stored in background. The snapshot is taken as the copy-on-write view of the
+// trees when it is applied, and stored out of the raft apply loop, so that a large partition does not block the
+// apply of the following logs.
+type volSnapshotStore struct {
+	done    chan struct{}
+	err     error
+	deleted bool // the snapshot is deleted before it is stored
+}
+
+// VolSnapshot replicates the operation on the volume snapshot to all the replicas by raft. The creation returns after
+// the snapshot is stored locally, and it is ordered after the requests stamped with the older epochs, see
+// checkSnapshotEpoch.
+func (mp *metaPartition) VolSnapshot(req *proto.MetaPartitionSnapshotRequest) (err error) {
+	var (
+		data []byte
+		resp interface{}
+	)
+	if data, err = json.Marshal(req); err != nil {
+		return
+	}
+	if req.Operation == proto.MetaSnapshotCreate {
+		mp.volSnapshotBarrier.Lock()
+		resp, err = mp.submit(opFSMVolSnapshot, data)
+		mp.volSnapshotBarrier.Unlock()
+	} else {
+		resp, err = mp.submit(opFSMVolSnapshot, data)
+	}
+	if err != nil {
+		return
+	}
+	if e, ok := resp.(error); ok && e != nil {
+		return e
+	}
+	if req.Operation == proto.MetaSnapshotCreate {
+		err = mp.waitVolSnapshotStored(req.SnapshotID)
+	}
+	return
+}
+
+// checkSnapshotEpoch rejects the request changing the extents of the files if it is stamped with an epoch older than
+// the latest snapshot of the partition, since the client may not know the extents are kept by the snapshot. The
+// barrier is held for read until the request is submitted, so the requests accepted are applied before the
+// following snapshot.
+func (mp *metaPartition) checkSnapshotEpoch(epoch uint64, p *Packet) bool {
+	if latest := mp.latestVolSnapshot(); epoch < latest {
+		p.PacketErrorWithBody(proto.OpStaleEpochErr,
+			[]byte(fmt.Sprintf("snapshot epoch %v is older than %v", epoch, latest)))
+		return false
+	}
+	return true
+}
+
+// GetVolSnapshots returns the IDs of the volume snapshots stored by the partition.
+func (mp *metaPartition) GetVolSnapshots() (ids []uint64) {
+	mp.volSnapshotsLock.RLock()
+	defer mp.volSnapshotsLock.RUnlock()
+	for id := range mp.volSnapshots {
+		if _, ok := mp.volSnapshotsStoring[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return
+}
+
+// keptVolSnapshots returns the IDs of the volume snapshots kept by the partition, including the ones being stored.
+func (mp *metaPartition) keptVolSnapshots() (ids []uint64) {
+	mp.volSnapshotsLock.RLock()
+	defer mp.volSnapshotsLock.RUnlock()
+	for id := range mp.volSnapshots {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return
+}
+
+// isVolSnapshotExtent returns if the extent is referenced by any snapshot, such extent must not be deleted. The
+// extents of the snapshots being stored are not known yet, so it waits for them to be stored, and must not be called
+// in the raft apply loop.
+func (mp *metaPartition) isVolSnapshotExtent(ek *proto.ExtentKey) bool {
+	mp.waitVolSnapshotsStored()
+	return mp.referencedByVolSnapshots(ek)
+}
+
+// referencedByVolSnapshots returns if the extent is referenced by any snapshot stored.
+func (mp *metaPartition) referencedByVolSnapshots(ek *proto.ExtentKey) bool {
+	ref := newVolSnapshotExtent(ek)
+	mp.volSnapshotsLock.RLock()
+	defer mp.volSnapshotsLock.RUnlock()
+	for _, extents := range mp.volSnapshots {
+		if _, ok := extents[ref]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// waitVolSnapshotsStored waits for all the snapshots being stored.
+func (mp *metaPartition) waitVolSnapshotsStored() {
+	for {
+		var store *volSnapshotStore
+		mp.volSnapshotsLock.RLock()
+		for _, store = range mp.volSnapshotsStoring {
+			break
+		}
+		mp.volSnapshotsLock.RUnlock()
+		if store == nil {
+			return
+		}
+		<-store.done
+	}
+}
+
+// waitVolSnapshotStored waits for the snapshot to be stored, and returns the error if it fails to be stored.
+func (mp *metaPartition) waitVolSnapshotStored(id uint64) (err error) {
+	mp.volSnapshotsLock.RLock()
+	store, storing := mp.volSnapshotsStoring[id]
+	_, ok := mp.volSnapshots[id]
+	mp.volSnapshotsLock.RUnlock()
+	if storing {
+		<-store.done
+		return store.err
+	}
+	if !ok {
+		return fmt.Errorf("snapshot[%v] is not stored", id)
+	}
+	return
+}
+
+func (mp *metaPartition) volSnapshotDir(id uint64) string {
+	return path.Join(mp.config.RootDir, volSnapshotDirPrefix+strconv.FormatUint(id, 10))
+}
+
+// fsmVolSnapshot applies the operation on the volume snapshot. The error is returned as the response,
+// so that the raft log is applied on all the replicas even if the operation fails on some of them.
+func (mp *metaPartition) fsmVolSnapshot(req *proto.MetaPartitionSnapshotRequest) (err error) {
+	switch req.Operation {
+	case proto.MetaSnapshotCreate:
+		err = mp.createVolSnapshot(req.SnapshotID)
+	case proto.MetaSnapshotDelete:
+		err = mp.deleteVolSnapshot(req.SnapshotID)
+	case proto.MetaSnapshotRollback:
+		err = mp.rollbackVolSnapshot(req.SnapshotID)
+	default:
+		err = fmt.Errorf("unknown snapshot operation[%v]", req.Operation)
+	}
+	if err != nil {
+		log.LogErrorf("fsmVolSnapshot: partitionID(%v) volume(%v) snapshot(%v) operation(%v) err(%v)",
+			mp.config.PartitionId, mp.config.VolName, req.SnapshotID, req.Operation, err)
+		return
+	}
+	log.LogWarnf("fsmVolSnapshot: partitionID(%v) volume(%v) snapshot(%v) operation(%v) done",
+		mp.config.PartitionId, mp.config.VolName, req.SnapshotID, req.Operation)
+	return
+}
+
+// createVolSnapshot takes the copy-on-write view of the metadata of the partition, and stores it into the snapshot
+// directory in background. It is applied at the same raft index on all the replicas, so the snapshots of the
+// replicas are identical. The epoch of the partition is advanced by the snapshot once it is applied.
+func (mp *metaPartition) createVolSnapshot(id uint64) (err error) {
+	sm := &storeMsg{
+		inodeTree:     mp.getInodeTree(),
+		dentryTree:    mp.getDentryTree(),
+		extendTree:    mp.extendTree.GetTree(),
+		multipartTree: mp.multipartTree.GetTree(),
+	}
+	// the snapshot is stored again if the raft log is replayed
+	mp.volSnapshotsLock.RLock()
+	prev := mp.volSnapshotsStoring[id]
+	mp.volSnapshotsLock.RUnlock()
+	if prev != nil {
+		<-prev.done
+	}
+	store := &volSnapshotStore{done: make(chan struct{})}
+	mp.volSnapshotsLock.Lock()
+	if _, ok := mp.volSnapshots[id]; !ok {
+		mp.volSnapshots[id] = nil
+	}
+	mp.volSnapshotsStoring[id] = store
+	mp.volSnapshotsLock.Unlock()
+	go mp.storeVolSnapshot(id, sm, store)
+	return
+}
+
+// storeVolSnapshot stores the view of the snapshot, and the snapshot is reported to master once it is stored. The
+// snapshot failing to be stored is dropped.
+func (mp *metaPartition) storeVolSnapshot(id uint64, sm *storeMsg, store *volSnapshotStore) {
+	err := mp.doStoreVolSnapshot(id, sm)
+	var extents map[volSnapshotExtent]proto.ExtentKey
+	if err == nil {
+		extents = collectExtents(sm.inodeTree)
+	}
+	mp.volSnapshotsLock.Lock()
+	delete(mp.volSnapshotsStoring, id)
+	deleted := store.deleted
+	if err != nil {
+		delete(mp.volSnapshots, id)
+	} else if !deleted {
+		mp.volSnapshots[id] = extents
+	}
+	store.err = err
+	mp.volSnapshotsLock.Unlock()
+	close(store.done)
+	if err != nil {
+		log.LogErrorf("storeVolSnapshot: partitionID(%v) volume(%v) snapshot(%v) err(%v)",
+			mp.config.PartitionId, mp.config.VolName, id, err)
+		return
+	}
+	if deleted {
+		// the extents only referenced by the snapshot are kept while it is being stored
+		_ = os.RemoveAll(mp.volSnapshotDir(id))
+		mp.deleteUnreferencedExtents(extents)
+	}
+	log.LogWarnf("storeVolSnapshot: partitionID(%v) volume(%v) snapshot(%v) deleted(%v) inodes(%v) extents(%v)",
+		mp.config.PartitionId, mp.config.VolName, id, deleted, sm.inodeTree.Len(), len(extents))
+}
+
+func (mp *metaPartition) doStoreVolSnapshot(id uint64, sm *storeMsg) (err error) {
+	tmpDir := path.Join(mp.config.RootDir, volSnapshotDirTmpPrefix+strconv.FormatUint(id, 10))
+	_ = os.RemoveAll(tmpDir)
+	if err = os.MkdirAll(tmpDir, 0775); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tmpDir)
+		}
+	}()
+	var storeFuncs = []func(dir string, sm *storeMsg) (uint32, error){
+		mp.storeInode,
+		mp.storeDentry,
+		mp.storeExtend,
+		mp.storeMultipart,
+	}
+	for _, storeFunc := range storeFuncs {
+		if _, err = storeFunc(tmpDir, sm); err != nil {
+			return
+		}
+	}
+	dir := mp.volSnapshotDir(id)
+	if err = os.RemoveAll(dir); err != nil {
+		return
+	}
+	err = os.Rename(tmpDir, dir)
+	return
+}
+
+// deleteVolSnapshot removes the snapshot directory and the inode versions only seen by the snapshot, and deletes the
+// extents which are only referenced by the snapshot.
+func (mp *metaPartition) deleteVolSnapshot(id uint64) (err error) {
+	mp.volSnapshotsLock.Lock()
+	extents, ok := mp.volSnapshots[id]
+	delete(mp.volSnapshots, id)
+	if store, storing := mp.volSnapshotsStoring[id]; storing {
+		store.deleted = true
+	}
+	mp.volSnapshotsLock.Unlock()
+	if err = os.RemoveAll(mp.volSnapshotDir(id)); err != nil {
+		return
+	}
+	mp.pruneInodeVersions()
+	if ok {
+		mp.deleteUnreferencedExtents(extents)
+	}
+	return
+}
+
+// rollbackVolSnapshot replaces the metadata of the partition with the snapshot. The snapshot is kept, and the
+// extents which are only referenced by the replaced metadata are deleted. The inode cursor never goes back.
+func (mp *metaPartition) rollbackVolSnapshot(id uint64) (err error) {
+	// the replica lagging behind may still be storing the snapshot
+	if err = mp.waitVolSnapshotStored(id); err != nil {
+		return
+	}
+	dir := mp.volSnapshotDir(id)
+	if _, err = os.Stat(dir); err != nil {
+		return
+	}
+	shadow := &metaPartition{
+		config: &MetaPartitionConfig{
+			PartitionId: mp.config.PartitionId,
+			VolName:     mp.config.VolName,
+			Start:       mp.config.Start,
+			End:         mp.config.End,
+			Cursor:      mp.config.Start,
+		},
+		dentryTree:    NewBtree(),
+		inodeTree:     NewBtree(),
+		extendTree:    NewBtree(),
+		multipartTree: NewBtree(),
+		freeList:      newFreeList(),
+	}
+	if err = shadow.loadInode(dir); err != nil {
+		return
+	}
+	if err = shadow.loadDentry(dir); err != nil {
+		return
+	}
+	if err = shadow.loadExtend(dir); err != nil {
+		return
+	}
+	if err = shadow.loadMultipart(dir); err != nil {
+		return
+	}
+	replacedTree := mp.getInodeTree()
+	replaced := collectExtents(replacedTree)
+	mp.rebaseInodeVersions(shadow.inodeTree, replacedTree)
+	mp.inodeTree = shadow.inodeTree
+	mp.dentryTree = shadow.dentryTree
+	mp.rebuildFoldTree()
+	mp.extendTree = shadow.extendTree
+	mp.multipartTree = shadow.multipartTree
+	mp.freeList = shadow.freeList
+	mp.pruneInodeVersions()
+	mp.deleteUnreferencedExtents(replaced)
+	return
+}
+
+// deleteUnreferencedExtents deletes the extents which are neither referenced by the inodes nor by the snapshots.
+func (mp *metaPartition) deleteUnreferencedExtents(extents map[volSnapshotExtent]proto.ExtentKey) {
+	referenced := collectExtents(mp.getInodeTree())
+	eks := make([]proto.ExtentKey, 0)
+	for ref, ek := range extents {
+		if _, ok := referenced[ref]; ok {
+			continue
+		}
+		// the extents of the snapshots being stored are checked again before they are deleted
+		if mp.referencedByVolSnapshots(&ek) {
+			continue
+		}
+		eks = append(eks, ek)
+	}
+	log.LogInfof("deleteUnreferencedExtents: partitionID(%v) volume(%v) extents(%v)",
+		mp.config.PartitionId, mp.config.VolName, len(eks))
+	if len(eks) > 0 {
+		mp.extDelCh <- eks
+	}
+}
+
+// loadVolSnapshots loads the extents referenced by the snapshots of the partition, and removes the temporary
+// directories of the snapshots which are not completely stored.
+func (mp *metaPartition) loadVolSnapshots() (err error) {
+	var infos []os.FileInfo
+	if infos, err = ioutil.ReadDir(mp.config.RootDir); err != nil {
+		return
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(info.Name(), volSnapshotDirTmpPrefix) {
+			_ = os.RemoveAll(path.Join(mp.config.RootDir, info.Name()))
+			continue
+		}
+		if !strings.HasPrefix(info.Name(), volSnapshotDirPrefix) {
+			continue
+		}
+		id, e := strconv.ParseUint(strings.TrimPrefix(info.Name(), volSnapshotDirPrefix), 10, 64)
+		if e != nil {
+			continue
+		}
+		shadow := &metaPartition{
+			config:    &MetaPartitionConfig{PartitionId: mp.config.PartitionId, VolName: mp.config.VolName},
+			inodeTree: NewBtree(),
+			freeList:  newFreeList(),
+		}
+		if err = shadow.loadInode(path.Join(mp.config.RootDir, info.Name())); err != nil {
+			return
+		}
+		mp.volSnapshots[id] = collectExtents(shadow.inodeTree)
+		log.LogInfof("loadVolSnapshots: partitionID(%v) volume(%v) snapshot(%v) inodes(%v)",
+			mp.config.PartitionId, mp.config.VolName, id, shadow.inodeTree.Len())
+	}
+	return
+}
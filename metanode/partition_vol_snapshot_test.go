@@ -0,0 +1,125 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func newVolSnapshotTestInode(ino, extentID uint64) *Inode {
+	inode := NewInode(ino, 0644)
+	inode.Extents.Append(proto.ExtentKey{PartitionId: 1, ExtentId: extentID, Size: 4096})
+	inode.Size = 4096
+	return inode
+}
+
+func TestVolSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vol_snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "snapshot", Start: 1, End: 100, RootDir: dir}, nil).(*metaPartition)
+	mp.fsmCreateInode(newVolSnapshotTestInode(2, 1025))
+	if err = mp.fsmVolSnapshot(&proto.MetaPartitionSnapshotRequest{SnapshotID: 1, Operation: proto.MetaSnapshotCreate}); err != nil {
+		t.Fatal(err)
+	}
+	if !mp.isVolSnapshotExtent(&proto.ExtentKey{PartitionId: 1, ExtentId: 1025}) {
+		t.Errorf("expect extent 1025 referenced by the snapshot")
+	}
+
+	// replace inode 2 with inode 3 after the snapshot
+	mp.internalDeleteInode(NewInode(2, 0))
+	if mp.inodeTree.Len() != 1 {
+		t.Fatalf("expect the live inode not deleted internally")
+	}
+	mp.inodeTree.Delete(NewInode(2, 0))
+	mp.fsmCreateInode(newVolSnapshotTestInode(3, 1026))
+
+	if err = mp.fsmVolSnapshot(&proto.MetaPartitionSnapshotRequest{SnapshotID: 1, Operation: proto.MetaSnapshotRollback}); err != nil {
+		t.Fatal(err)
+	}
+	if mp.inodeTree.CopyGet(NewInode(2, 0)) == nil || mp.inodeTree.CopyGet(NewInode(3, 0)) != nil {
+		t.Errorf("expect only inode 2 after rollback")
+	}
+	if eks := <-mp.extDelCh; len(eks) != 1 || eks[0].ExtentId != 1026 {
+		t.Errorf("expect extent 1026 deleted after rollback, but got %v", eks)
+	}
+
+	// the extents of the snapshot are still referenced by inode 2
+	if err = mp.fsmVolSnapshot(&proto.MetaPartitionSnapshotRequest{SnapshotID: 1, Operation: proto.MetaSnapshotDelete}); err != nil {
+		t.Fatal(err)
+	}
+	if len(mp.extDelCh) != 0 || len(mp.GetVolSnapshots()) != 0 {
+		t.Errorf("expect no extent deleted and no snapshot left")
+	}
+	if _, err = os.Stat(mp.volSnapshotDir(1)); !os.IsNotExist(err) {
+		t.Errorf("expect the snapshot directory removed, but got %v", err)
+	}
+}
+
+func TestVolSnapshotCopyOnWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vol_snapshot_cow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	mp := NewMetaPartition(&MetaPartitionConfig{PartitionId: 1, VolName: "snapshot", Start: 1, End: 100, RootDir: dir}, nil).(*metaPartition)
+	mp.fsmCreateInode(newVolSnapshotTestInode(2, 1025))
+	if err = mp.fsmVolSnapshot(&proto.MetaPartitionSnapshotRequest{SnapshotID: 1, Operation: proto.MetaSnapshotCreate}); err != nil {
+		t.Fatal(err)
+	}
+	// the epoch is advanced once the snapshot is applied, even if it is still being stored
+	if epoch := mp.latestVolSnapshot(); epoch != 1 {
+		t.Errorf("expect epoch 1 after the snapshot is applied, but got %v", epoch)
+	}
+	// the changes applied after the snapshot are not seen by it
+	ino := NewInode(2, 0)
+	ino.Extents.Append(proto.ExtentKey{FileOffset: 4096, PartitionId: 1, ExtentId: 1026, Size: 4096})
+	if status := mp.fsmAppendExtents(ino); status != proto.OpOk {
+		t.Fatalf("expect the extent appended, but got status %v", status)
+	}
+	if err = mp.waitVolSnapshotStored(1); err != nil {
+		t.Fatal(err)
+	}
+	if ids := mp.GetVolSnapshots(); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("expect snapshot 1 reported after it is stored, but got %v", ids)
+	}
+	shadow := &metaPartition{config: mp.config, inodeTree: NewBtree(), freeList: newFreeList()}
+	if err = shadow.loadInode(mp.volSnapshotDir(1)); err != nil {
+		t.Fatal(err)
+	}
+	item := shadow.inodeTree.Get(NewInode(2, 0))
+	if item == nil || item.(*Inode).Extents.Len() != 1 {
+		t.Fatalf("expect inode 2 stored with one extent in the snapshot, but got %v", item)
+	}
+	if !mp.isVolSnapshotExtent(&proto.ExtentKey{PartitionId: 1, ExtentId: 1025}) ||
+		mp.isVolSnapshotExtent(&proto.ExtentKey{PartitionId: 1, ExtentId: 1026}) {
+		t.Errorf("expect only extent 1025 referenced by the snapshot")
+	}
+
+	// the requests stamped with the epochs older than the snapshot are rejected
+	p := &Packet{}
+	if mp.checkSnapshotEpoch(0, p) || p.ResultCode != proto.OpStaleEpochErr {
+		t.Errorf("expect the request stamped with epoch 0 rejected, but got result %v", p.GetResultMsg())
+	}
+	if p = (&Packet{}); !mp.checkSnapshotEpoch(1, p) {
+		t.Errorf("expect the request stamped with epoch 1 accepted, but got result %v", p.GetResultMsg())
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metanode
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/btree"
+)
+
+// TxRecord is the record of a transaction kept by a meta partition, which is persisted in the snapshot so that the
+// transaction is recovered after the crash. The coordinator keeps the record until all the participants commit, and a
+// participant keeps it from the prepare to the commit or the abort.
+type TxRecord struct {
+	proto.TxInfo
+}
+
+// NewTxRecord returns a new record of the transaction.
+func NewTxRecord(tx *proto.TxInfo) *TxRecord {
+	return &TxRecord{TxInfo: *tx}
+}
+
+// TxRecordFromBytes unmarshals the record.
+func TxRecordFromBytes(raw []byte) (record *TxRecord, err error) {
+	record = &TxRecord{}
+	if err = json.Unmarshal(raw, record); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Less tests whether the current item is less than the given one.
+func (r *TxRecord) Less(than btree.Item) bool {
+	tr, ok := than.(*TxRecord)
+	return ok && r.TxID < tr.TxID
+}
+
+// Copy returns a copy of the record.
+func (r *TxRecord) Copy() btree.Item {
+	record := &TxRecord{TxInfo: r.TxInfo}
+	record.Ops = make([]*proto.TxOp, 0, len(r.Ops))
+	for _, op := range r.Ops {
+		copied := *op
+		record.Ops = append(record.Ops, &copied)
+	}
+	return record
+}
+
+// Bytes marshals the record.
+func (r *TxRecord) Bytes() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// PartitionOps returns the operations of the transaction on the meta partition.
+func (r *TxRecord) PartitionOps(pid uint64) []*proto.TxOp {
+	ops := make([]*proto.TxOp, 0, len(r.Ops))
+	for _, op := range r.Ops {
+		if op.PartitionID == pid {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// txParticipant defines a meta partition of the transaction except the coordinator.
+type txParticipant struct {
+	pid     uint64
+	members []string
+}
+
+// Participants returns the meta partitions of the transaction except the coordinator, in the order of their first
+// operations.
+func (r *TxRecord) Participants() []*txParticipant {
+	participants := make([]*txParticipant, 0)
+	seen := make(map[uint64]struct{})
+	for _, op := range r.Ops {
+		if _, ok := seen[op.PartitionID]; ok || op.PartitionID == r.Coordinator {
+			continue
+		}
+		seen[op.PartitionID] = struct{}{}
+		participants = append(participants, &txParticipant{pid: op.PartitionID, members: op.Members})
+	}
+	return participants
+}
+
+// resolveInodes fills the dentry operations without inodes with the inodes of the previous inode operations.
+func (r *TxRecord) resolveInodes() {
+	var last *proto.TxOp
+	for _, op := range r.Ops {
+		if op.IsInodeOp() {
+			last = op
+			continue
+		}
+		if op.Inode == 0 && last != nil && last.Inode != 0 {
+			op.Inode, op.Mode = last.Inode, last.Mode
+		}
+	}
+}
+
+// txDentryKey returns the key of the dentry locked by the transactions.
+func txDentryKey(parentID uint64, name string) string {
+	return fmt.Sprintf("%v/%v", parentID, name)
+}
@@ -79,6 +79,15 @@ const (
 
 	disabledActions               = "disabledActions"
 	configSignatureIgnoredActions = "signatureIgnoredActions"
+
+	// The token to call the admin APIs of the master, such as creating the buckets and getting the users,
+	// which is required if the access control is enabled on the master. The token should be granted the
+	// admin role by the "rbacTokens" of the master.
+	// Example:
+	//		{
+	//			"masterAuthToken": "xxxxxx"
+	//		}
+	configMasterAuthToken = "masterAuthToken"
 )
 
 // Default of configuration value
@@ -176,9 +185,12 @@ func (o *ObjectNode) loadConfig(cfg *config.Config) (err error) {
 	strict := cfg.GetBool(configStrict)
 	log.LogInfof("loadConfig: strict: %v", strict)
 
+	authToken := cfg.GetString(configMasterAuthToken)
+
 	o.mc = master.NewMasterClient(masters, false)
+	o.mc.SetAuthToken(authToken)
 	o.vm = NewVolumeManager(masters, strict)
-	o.userStore = NewUserInfoStore(masters, strict)
+	o.userStore = NewUserInfoStore(masters, strict, authToken)
 
 	return
 }
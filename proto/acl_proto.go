@@ -0,0 +1,324 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The POSIX ACLs are kept in the extended attributes of the inodes by the names used by Linux, so that
+// getfacl and setfacl work through the mount points.
+const (
+	XAttrACLAccess  = "system.posix_acl_access"
+	XAttrACLDefault = "system.posix_acl_default"
+)
+
+// The tags of the ACL entries.
+const (
+	ACLUserObj  uint16 = 0x01
+	ACLUser     uint16 = 0x02
+	ACLGroupObj uint16 = 0x04
+	ACLGroup    uint16 = 0x08
+	ACLMask     uint16 = 0x10
+	ACLOther    uint16 = 0x20
+)
+
+// The permissions of the ACL entries.
+const (
+	ACLRead    uint16 = 0x04
+	ACLWrite   uint16 = 0x02
+	ACLExecute uint16 = 0x01
+)
+
+const (
+	aclXAttrVersion = 2
+	aclHeaderSize   = 4
+	aclEntrySize    = 8
+	aclUndefinedID  = ^uint32(0)
+)
+
+var (
+	ErrInvalidACL = errors.New("invalid posix acl")
+)
+
+// ACLEntry defines an entry of the POSIX ACL.
+type ACLEntry struct {
+	Tag  uint16 `json:"tag"`
+	Perm uint16 `json:"perm"`
+	ID   uint32 `json:"id"` // the uid or the gid of the named entries
+}
+
+// ACL defines the POSIX ACL, the entries are sorted by the tags and the IDs.
+type ACL []ACLEntry
+
+// ACLInfo defines the access ACL and the default ACL of an inode.
+type ACLInfo struct {
+	Inode   uint64 `json:"ino"`
+	Mode    uint32 `json:"mode"`
+	Uid     uint32 `json:"uid"`
+	Gid     uint32 `json:"gid"`
+	Access  ACL    `json:"access"`
+	Default ACL    `json:"default"`
+}
+
+// IsACLXAttr checks if the extended attribute keeps a POSIX ACL.
+func IsACLXAttr(name string) bool {
+	return name == XAttrACLAccess || name == XAttrACLDefault
+}
+
+// ParseACL decodes the ACL from the value of the extended attribute in the format of Linux.
+func ParseACL(raw []byte) (acl ACL, err error) {
+	if len(raw) < aclHeaderSize || (len(raw)-aclHeaderSize)%aclEntrySize != 0 {
+		return nil, ErrInvalidACL
+	}
+	if binary.LittleEndian.Uint32(raw) != aclXAttrVersion {
+		return nil, ErrInvalidACL
+	}
+	count := (len(raw) - aclHeaderSize) / aclEntrySize
+	acl = make(ACL, 0, count)
+	for i := 0; i < count; i++ {
+		data := raw[aclHeaderSize+i*aclEntrySize:]
+		entry := ACLEntry{
+			Tag:  binary.LittleEndian.Uint16(data),
+			Perm: binary.LittleEndian.Uint16(data[2:]),
+			ID:   binary.LittleEndian.Uint32(data[4:]),
+		}
+		if entry.Tag != ACLUser && entry.Tag != ACLGroup {
+			entry.ID = aclUndefinedID
+		}
+		acl = append(acl, entry)
+	}
+	sort.Slice(acl, func(i, j int) bool {
+		if acl[i].Tag != acl[j].Tag {
+			return acl[i].Tag < acl[j].Tag
+		}
+		return acl[i].ID < acl[j].ID
+	})
+	if err = acl.Validate(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Bytes encodes the ACL into the value of the extended attribute in the format of Linux.
+func (acl ACL) Bytes() []byte {
+	raw := make([]byte, aclHeaderSize+len(acl)*aclEntrySize)
+	binary.LittleEndian.PutUint32(raw, aclXAttrVersion)
+	for i, entry := range acl {
+		data := raw[aclHeaderSize+i*aclEntrySize:]
+		binary.LittleEndian.PutUint16(data, entry.Tag)
+		binary.LittleEndian.PutUint16(data[2:], entry.Perm)
+		binary.LittleEndian.PutUint32(data[4:], entry.ID)
+	}
+	return raw
+}
+
+// Validate checks the sorted ACL by the rules of POSIX: exactly one owner, owning group and other entry,
+// no duplicated named entries, and a mask entry if there is any named entry.
+func (acl ACL) Validate() error {
+	counts := make(map[uint16]int)
+	for i, entry := range acl {
+		if entry.Perm&^(ACLRead|ACLWrite|ACLExecute) != 0 {
+			return ErrInvalidACL
+		}
+		switch entry.Tag {
+		case ACLUserObj, ACLGroupObj, ACLMask, ACLOther:
+		case ACLUser, ACLGroup:
+			if i > 0 && acl[i-1].Tag == entry.Tag && acl[i-1].ID == entry.ID {
+				return ErrInvalidACL
+			}
+		default:
+			return ErrInvalidACL
+		}
+		counts[entry.Tag]++
+	}
+	if counts[ACLUserObj] != 1 || counts[ACLGroupObj] != 1 || counts[ACLOther] != 1 || counts[ACLMask] > 1 {
+		return ErrInvalidACL
+	}
+	if counts[ACLUser]+counts[ACLGroup] > 0 && counts[ACLMask] == 0 {
+		return ErrInvalidACL
+	}
+	return nil
+}
+
+// Equiv checks if the ACL is equivalent to the permission bits of the mode, that is, it has no named entry
+// and no mask entry.
+func (acl ACL) Equiv() bool {
+	return len(acl) == 3
+}
+
+func (acl ACL) find(tag uint16) *ACLEntry {
+	for i := range acl {
+		if acl[i].Tag == tag {
+			return &acl[i]
+		}
+	}
+	return nil
+}
+
+// Mode returns the permission bits of the mode reflected by the ACL, the group bits are the ones of the mask
+// entry if it exists.
+func (acl ACL) Mode() uint32 {
+	var mode uint32
+	if entry := acl.find(ACLUserObj); entry != nil {
+		mode |= uint32(entry.Perm) << 6
+	}
+	group := acl.find(ACLMask)
+	if group == nil {
+		group = acl.find(ACLGroupObj)
+	}
+	if group != nil {
+		mode |= uint32(group.Perm) << 3
+	}
+	if entry := acl.find(ACLOther); entry != nil {
+		mode |= uint32(entry.Perm)
+	}
+	return mode
+}
+
+// Chmod returns a copy of the ACL whose owner, mask (or owning group) and other entries are changed to the
+// permission bits of the mode.
+func (acl ACL) Chmod(mode uint32) ACL {
+	result := make(ACL, len(acl))
+	copy(result, acl)
+	hasMask := result.find(ACLMask) != nil
+	for i := range result {
+		switch result[i].Tag {
+		case ACLUserObj:
+			result[i].Perm = uint16(mode>>6) & 0x7
+		case ACLMask:
+			result[i].Perm = uint16(mode>>3) & 0x7
+		case ACLGroupObj:
+			if !hasMask {
+				result[i].Perm = uint16(mode>>3) & 0x7
+			}
+		case ACLOther:
+			result[i].Perm = uint16(mode) & 0x7
+		}
+	}
+	return result
+}
+
+// Inherit returns the access ACL of a new inode created in the directory whose default ACL is the receiver,
+// and the permission bits of the new inode. The permissions of the ACL are limited by the requested mode.
+func (acl ACL) Inherit(mode uint32) (ACL, uint32) {
+	result := make(ACL, len(acl))
+	copy(result, acl)
+	hasMask := result.find(ACLMask) != nil
+	for i := range result {
+		switch result[i].Tag {
+		case ACLUserObj:
+			result[i].Perm &= uint16(mode>>6) & 0x7
+		case ACLMask:
+			result[i].Perm &= uint16(mode>>3) & 0x7
+		case ACLGroupObj:
+			if !hasMask {
+				result[i].Perm &= uint16(mode>>3) & 0x7
+			}
+		case ACLOther:
+			result[i].Perm &= uint16(mode) & 0x7
+		}
+	}
+	return result, mode&^0777 | result.Mode()
+}
+
+// Permit checks if the caller is granted the wanted permissions by the ACL of the inode, which is owned by the
+// given user and group.
+func (acl ACL) Permit(owner, group, uid uint32, gids []uint32, want uint16) bool {
+	var mask = ACLRead | ACLWrite | ACLExecute
+	if entry := acl.find(ACLMask); entry != nil {
+		mask = entry.Perm
+	}
+	if uid == owner {
+		entry := acl.find(ACLUserObj)
+		return entry != nil && entry.Perm&want == want
+	}
+	for _, entry := range acl {
+		if entry.Tag == ACLUser && entry.ID == uid {
+			return entry.Perm&mask&want == want
+		}
+	}
+	// the caller is granted if any matched group entry grants the permissions
+	var matched bool
+	for _, entry := range acl {
+		var id uint32
+		switch entry.Tag {
+		case ACLGroupObj:
+			id = group
+		case ACLGroup:
+			id = entry.ID
+		default:
+			continue
+		}
+		for _, gid := range gids {
+			if gid != id {
+				continue
+			}
+			if entry.Perm&mask&want == want {
+				return true
+			}
+			matched = true
+			break
+		}
+	}
+	if matched {
+		return false
+	}
+	entry := acl.find(ACLOther)
+	return entry != nil && entry.Perm&want == want
+}
+
+func formatACLPerm(perm uint16) string {
+	var builder strings.Builder
+	for _, bit := range []struct {
+		perm uint16
+		char byte
+	}{{ACLRead, 'r'}, {ACLWrite, 'w'}, {ACLExecute, 'x'}} {
+		if perm&bit.perm != 0 {
+			builder.WriteByte(bit.char)
+		} else {
+			builder.WriteByte('-')
+		}
+	}
+	return builder.String()
+}
+
+// String formats the ACL as the short text form of getfacl, one entry per line.
+func (acl ACL) String() string {
+	var builder strings.Builder
+	for _, entry := range acl {
+		switch entry.Tag {
+		case ACLUserObj:
+			builder.WriteString("user::")
+		case ACLUser:
+			builder.WriteString(fmt.Sprintf("user:%v:", entry.ID))
+		case ACLGroupObj:
+			builder.WriteString("group::")
+		case ACLGroup:
+			builder.WriteString(fmt.Sprintf("group:%v:", entry.ID))
+		case ACLMask:
+			builder.WriteString("mask::")
+		case ACLOther:
+			builder.WriteString("other::")
+		}
+		builder.WriteString(formatACLPerm(entry.Perm))
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
@@ -23,22 +23,36 @@ const (
 	AdminCreateDataPartition       = "/dataPartition/create"
 	AdminDecommissionDataPartition = "/dataPartition/decommission"
 	AdminDiagnoseDataPartition     = "/dataPartition/diagnose"
+	AdminResetDataPartition        = "/dataPartition/reset"
 	AdminDeleteDataReplica         = "/dataReplica/delete"
 	AdminAddDataReplica            = "/dataReplica/add"
+	AdminTransferDataLeader        = "/dataPartition/transferLeader"
+	AdminSetDataPartitionStatus    = "/dataPartition/setStatus"
 	AdminDeleteVol                 = "/vol/delete"
+	AdminRestoreVol                = "/vol/restore"
 	AdminUpdateVol                 = "/vol/update"
 	AdminVolShrink                 = "/vol/shrink"
+	AdminRotateVolKey              = "/vol/rotateKey"
 	AdminVolExpand                 = "/vol/expand"
+	AdminCloneVol                  = "/vol/clone"
 	AdminCreateVol                 = "/admin/createVol"
 	AdminGetVol                    = "/admin/getVol"
 	AdminClusterFreeze             = "/cluster/freeze"
 	AdminClusterStat               = "/cluster/stat"
+	AdminClusterHealth             = "/cluster/health"
 	AdminGetIP                     = "/admin/getIp"
 	AdminCreateMetaPartition       = "/metaPartition/create"
 	AdminSetMetaNodeThreshold      = "/threshold/set"
 	AdminListVols                  = "/vol/list"
 	AdminSetNodeInfo               = "/admin/setNodeInfo"
 	AdminGetNodeInfo               = "/admin/getNodeInfo"
+	AdminRecordCliAudit            = "/admin/cliAudit"
+	AdminRollingRestart            = "/admin/rollingRestart"
+	AdminGetVolQos                 = "/admin/getVolQos"
+	AdminGetVolIPAcl               = "/admin/getVolIPAcl"
+	AdminGetVerifyReadCrcVols      = "/admin/getVerifyReadCrcVols"
+	AdminGetCompressionVols        = "/admin/getCompressionVols"
+	AdminGetVolEncryptionKeys      = "/admin/getVolEncryptionKeys"
 
 	//graphql master api
 	AdminClusterAPI = "/api/cluster"
@@ -68,18 +82,111 @@ const (
 	AddDataNode                    = "/dataNode/add"
 	DecommissionDataNode           = "/dataNode/decommission"
 	DecommissionDisk               = "/disk/decommission"
+	AdminDecommissionDiskAsync     = "/disk/decommissionAsync"
+	AdminSetDiskRetiring           = "/disk/setRetiring"
 	GetDataNode                    = "/dataNode/get"
+	GetDataNodePartitions          = "/dataNode/partitions"
 	AddMetaNode                    = "/metaNode/add"
 	DecommissionMetaNode           = "/metaNode/decommission"
 	GetMetaNode                    = "/metaNode/get"
+	GetMetaNodePartitions          = "/metaNode/partitions"
 	AdminUpdateMetaNode            = "/metaNode/update"
 	AdminUpdateDataNode            = "/dataNode/update"
+	AdminSetMetaNodeLabels         = "/metaNode/setLabels"
+	AdminSetDataNodeLabels         = "/dataNode/setLabels"
+	AdminDecommissionDryRun        = "/admin/decommission/dryRun"
 	AdminGetInvalidNodes           = "/invalid/nodes"
 	AdminLoadMetaPartition         = "/metaPartition/load"
 	AdminDiagnoseMetaPartition     = "/metaPartition/diagnose"
+	AdminListMetaPartitions        = "/metaPartition/list"
 	AdminDecommissionMetaPartition = "/metaPartition/decommission"
+	AdminResetMetaPartition        = "/metaPartition/reset"
 	AdminAddMetaReplica            = "/metaReplica/add"
 	AdminDeleteMetaReplica         = "/metaReplica/delete"
+	AdminTransferMetaLeader        = "/metaPartition/transferLeader"
+
+	// APIs of the partition replicas unknown to the master
+	AdminListOrphanPartitions  = "/orphanPartition/list"
+	AdminCleanOrphanPartitions = "/orphanPartition/clean"
+	AdminStalePartitionGC      = "/orphanPartition/gc"
+
+	// API for the findings of the scheduled consistency checker
+	AdminGetConsistencyReport = "/consistency/report"
+
+	// APIs for the async tasks of admin operations
+	AdminGetTask   = "/task/get"
+	AdminListTasks = "/task/list"
+
+	// APIs for the directory quotas of volumes
+	QuotaSet    = "/quota/set"
+	QuotaDelete = "/quota/delete"
+	QuotaList   = "/quota/list"
+
+	// APIs for the expiration rules of volumes
+	ExpirationSet    = "/expiration/set"
+	ExpirationDelete = "/expiration/delete"
+	ExpirationList   = "/expiration/list"
+
+	// APIs for the lifecycle rules of volumes
+	LifecycleSet    = "/lifecycle/set"
+	LifecycleDelete = "/lifecycle/delete"
+	LifecycleList   = "/lifecycle/list"
+
+	// APIs for the snapshots of volumes
+	VolSnapshotCreate   = "/vol/snapshot/create"
+	VolSnapshotList     = "/vol/snapshot/list"
+	VolSnapshotDelete   = "/vol/snapshot/delete"
+	VolSnapshotRollback = "/vol/snapshot/rollback"
+
+	// APIs for the replication of volumes to the standby clusters
+	VolReplicationSet      = "/vol/replication/set"
+	VolReplicationGet      = "/vol/replication/get"
+	VolReplicationList     = "/vol/replication/list"
+	VolReplicationReport   = "/vol/replication/report"
+	VolReplicationFailover = "/vol/replication/failover"
+
+	// APIs for the rebalancers of the partitions
+	AdminMetaRebalanceStatus = "/metaRebalance/status"
+	AdminMetaRebalanceSet    = "/metaRebalance/set"
+	AdminMetaRebalancePause  = "/metaRebalance/pause"
+	AdminMetaRebalanceResume = "/metaRebalance/resume"
+	AdminDataRebalanceStatus = "/dataRebalance/status"
+	AdminDataRebalanceSet    = "/dataRebalance/set"
+	AdminDataRebalancePause  = "/dataRebalance/pause"
+	AdminDataRebalanceResume = "/dataRebalance/resume"
+
+	// APIs for the events
+	AdminListEvents = "/admin/events"
+
+	// APIs for the audit records of the admin calls
+	AdminListAuditRecords = "/admin/audit/list"
+
+	// APIs for the federation of the clusters
+	AdminAddFederationPeer    = "/federation/peer/add"
+	AdminRemoveFederationPeer = "/federation/peer/remove"
+	AdminListFederationPeers  = "/federation/peer/list"
+	AdminGetFederation        = "/federation/info"
+
+	// APIs for the alert rules
+	AdminSetAlertRule    = "/alertRule/set"
+	AdminDeleteAlertRule = "/alertRule/delete"
+	AdminListAlertRules  = "/alertRule/list"
+
+	// APIs for the runtime config of the master
+	AdminGetConfig  = "/admin/config/get"
+	AdminSetConfig  = "/admin/config/set"
+	AdminListConfig = "/admin/config/list"
+
+	// APIs for the backups of the metadata of the masters
+	AdminCreateMetadataBackup  = "/admin/backup/create"
+	AdminListMetadataBackups   = "/admin/backup/list"
+	AdminRestoreMetadataBackup = "/admin/backup/restore"
+
+	// API for the forecasts of the time the volumes and the zones become full
+	AdminCapacityForecast = "/admin/capacityForecast"
+
+	// API for the OpenAPI document of the master APIs
+	AdminAPISpec = "/apispec"
 
 	// Operation response
 	GetMetaNodeTaskResponse = "/metaNode/response" // Method: 'POST', ContentType: 'application/json'
@@ -88,6 +195,7 @@ const (
 	GetTopologyView = "/topo/get"
 	UpdateZone      = "/zone/update"
 	GetAllZones     = "/zone/list"
+	MoveZoneNode    = "/zone/moveNode"
 
 	//token
 	TokenGetURI    = "/token/get"
@@ -96,8 +204,11 @@ const (
 	TokenUpdateURI = "/token/update"
 
 	// Header keys
-	SkipOwnerValidation = "Skip-Owner-Validation"
-	ForceDelete         = "Force-Delete"
+	SkipOwnerValidation   = "Skip-Owner-Validation"
+	ForceDelete           = "Force-Delete"
+	FollowerReadStaleness = "Follower-Read-Staleness"
+	AuditCaller           = "Audit-Caller" // the identity of the caller recorded in the audit records
+	AuthTicket            = "Auth-Ticket"  // the access request with the authnode ticket to call the admin APIs
 
 	// APIs for user management
 	UserCreate          = "/user/create"
@@ -111,6 +222,8 @@ const (
 	UserTransferVol     = "/user/transferVol"
 	UserList            = "/user/list"
 	UsersOfVol          = "/vol/users"
+	UserSetQuota        = "/user/setQuota"
+	UserGetQuota        = "/user/quota"
 	//graphql api for header
 	HeadAuthorized  = "Authorization"
 	ParamAuthorized = "_authorization"
@@ -125,6 +238,42 @@ const (
 	ReadWriteToken = 2
 )
 
+// The replica placement policies of a volume, more policies can be registered to the master
+const (
+	PlacementDefault          = ""
+	PlacementZoneSpread       = "zone-spread"       // one replica per zone
+	PlacementZonePinned       = "zone-pinned"       // all replicas in the placement zone
+	PlacementRackDiverse      = "rack-diverse"      // one replica per rack
+	PlacementCapacityWeighted = "capacity-weighted" // the nodes with more available space are more likely chosen
+	PlacementRoundRobin       = "round-robin"       // the nodes are chosen in turn
+)
+
+// VolQos defines the IOPS and the bandwidth limits of a volume, which are enforced by each data node and each
+// client separately. A limit of 0 means unlimited.
+type VolQos struct {
+	ReadIops  uint64 // read operations per second
+	WriteIops uint64 // write operations per second
+	ReadBps   uint64 // read bytes per second
+	WriteBps  uint64 // written bytes per second
+}
+
+// IsLimited returns whether any limit is set.
+func (q VolQos) IsLimited() bool {
+	return q.ReadIops != 0 || q.WriteIops != 0 || q.ReadBps != 0 || q.WriteBps != 0
+}
+
+// VolIPAcl restricts the clients of a volume by their IPs, which is enforced by the meta nodes and the data nodes.
+// A client is denied if its IP is in any denied CIDR, or the allowed CIDRs are set and its IP is in none of them.
+type VolIPAcl struct {
+	Allow []string // the allowed CIDRs or IPs, all the clients are allowed if empty
+	Deny  []string // the denied CIDRs or IPs
+}
+
+// IsSet returns whether any client is restricted.
+func (a VolIPAcl) IsSet() bool {
+	return len(a.Allow) != 0 || len(a.Deny) != 0
+}
+
 type Token struct {
 	TokenType int8
 	Value     string
@@ -151,6 +300,7 @@ type ClusterInfo struct {
 	MetaNodeDeleteWorkerSleepMs uint64
 	DataNodeDeleteLimitRate     uint64
 	DataNodeAutoRepairLimitRate uint64
+	DataNodeRepairBandwidth     uint64
 }
 
 // CreateDataPartitionRequest defines the request to create a data partition.
@@ -163,6 +313,7 @@ type CreateDataPartitionRequest struct {
 	Members       []Peer
 	Hosts         []string
 	CreateType    int
+	SnapshotEpoch uint64 // the partition is frozen at the epoch if the volume has snapshots
 }
 
 // CreateDataPartitionResponse defines the response to the request of creating a data partition.
@@ -205,6 +356,12 @@ type RemoveDataPartitionRaftMemberRequest struct {
 	RemovePeer  Peer
 }
 
+// ResetDataPartitionRaftMemberRequest defines the request of resetting the raft members of a data partition.
+type ResetDataPartitionRaftMemberRequest struct {
+	PartitionId uint64
+	NewPeers    []Peer
+}
+
 // AddMetaPartitionRaftMemberRequest defines the request of add raftMember a meta partition.
 type AddMetaPartitionRaftMemberRequest struct {
 	PartitionId uint64
@@ -217,6 +374,26 @@ type RemoveMetaPartitionRaftMemberRequest struct {
 	RemovePeer  Peer
 }
 
+// PromoteMetaPartitionRaftMemberRequest defines the request of promoting a learner of a meta partition to a voter.
+type PromoteMetaPartitionRaftMemberRequest struct {
+	PartitionId uint64
+	PromotePeer Peer
+}
+
+// ResetMetaPartitionRaftMemberRequest defines the request of resetting the raft members of a meta partition.
+type ResetMetaPartitionRaftMemberRequest struct {
+	PartitionId uint64
+	NewPeers    []Peer
+}
+
+// TransferLeaderRequest defines the request of making a replica the raft leader of a data or meta partition.
+// The node waits for the replica to become the leader if WaitSeconds is positive, otherwise it only starts
+// the campaign.
+type TransferLeaderRequest struct {
+	PartitionId uint64
+	WaitSeconds int64
+}
+
 // LoadDataPartitionRequest defines the request of loading a data partition.
 type LoadDataPartitionRequest struct {
 	PartitionId uint64
@@ -259,8 +436,9 @@ type LoadMetaPartitionMetricResponse struct {
 
 // HeartBeatRequest define the heartbeat request.
 type HeartBeatRequest struct {
-	CurrTime   int64
-	MasterAddr string
+	CurrTime      int64
+	MasterAddr    string
+	RetiringDisks []string // disks of the data node retired by the master, no partition is created on them
 }
 
 // PartitionReport defines the partition report.
@@ -274,6 +452,14 @@ type PartitionReport struct {
 	IsLeader        bool
 	ExtentCount     int
 	NeedCompare     bool
+	ApplyID         uint64   // applied index of the raft log
+	CorruptExtents  []uint64 // extents with the corrupt blocks found by the scrubber and not repaired
+	// the compressed extents, the size of their data and the size of their files
+	CompressedExtents int
+	CompressedRawSize uint64
+	CompressedSize    uint64
+	KeyVersion        uint32 // version of the key the new extents are encrypted with, 0 if they are not encrypted
+	StaleKeyExtents   int    // extents not encrypted by the current key yet
 }
 
 // DataNodeHeartbeatResponse defines the response to the data node heartbeat.
@@ -290,19 +476,46 @@ type DataNodeHeartbeatResponse struct {
 	Status              uint8
 	Result              string
 	BadDisks            []string
+	DiskSmarts          []*DiskSmart
+}
+
+// DiskSmart is the SMART health of a disk of a data node collected by smartctl. The sector counters are of the ATA
+// disks and the media errors, the life used and the spare of the NVMe disks.
+type DiskSmart struct {
+	Path                    string // mount point of the disk
+	Device                  string
+	Passed                  bool // the overall health self-assessment of the disk
+	Temperature             int  // celsius
+	PowerOnHours            uint64
+	ReallocatedSectors      uint64
+	PendingSectors          uint64
+	UncorrectableSectors    uint64
+	MediaErrors             uint64
+	PercentageUsed          int
+	AvailableSpare          int
+	AvailableSpareThreshold int
+	CriticalWarning         int
+	FailingAttributes       []string `json:",omitempty"` // names of the ATA attributes at or below their thresholds
+	CollectTime             int64
+	Error                   string `json:",omitempty"` // error to collect the attributes, the others are invalid
 }
 
 // MetaPartitionReport defines the meta partition report.
 type MetaPartitionReport struct {
-	PartitionID uint64
-	Start       uint64
-	End         uint64
-	Status      int
-	MaxInodeID  uint64
-	IsLeader    bool
-	VolName     string
-	InodeCnt    uint64
-	DentryCnt   uint64
+	PartitionID  uint64
+	Start        uint64
+	End          uint64
+	Status       int
+	MaxInodeID   uint64
+	IsLeader     bool
+	VolName      string
+	InodeCnt     uint64
+	DentryCnt    uint64
+	ApplyID      uint64                 // applied index of the raft log
+	Peers        []string               // addresses of the raft peers of the replica
+	QuotaUsage   map[uint32]*QuotaUsage `graphql:"-"` // usage of the directory quotas, reported by the leader only
+	Snapshots    []uint64               // IDs of the volume snapshots kept by the replica
+	ReplicaCheck *ReplicaCheckReport    // the latest replica check, reported by the leader only
 }
 
 // MetaNodeHeartbeatResponse defines the response to the meta node heartbeat request.
@@ -426,6 +639,107 @@ type MetaPartitionView struct {
 	Status      int8
 }
 
+// Status filters of listing the partitions
+const (
+	PartitionFilterUnhealthy = "unhealthy"
+	PartitionFilterReadOnly  = "readonly"
+	PartitionFilterReadWrite = "rw"
+)
+
+// Statuses to force the data partitions to, auto returns the partition to the status decided by the master
+const (
+	PartitionStatusReadOnly = "readonly"
+	PartitionStatusWritable = "writable"
+	PartitionStatusAuto     = "auto"
+)
+
+// Types of the partitions
+const (
+	PartitionTypeData = "data"
+	PartitionTypeMeta = "meta"
+)
+
+// OrphanPartitionView defines a partition replica on a node, which is unknown to the master,
+// or whose partition does not take the node as a member.
+type OrphanPartitionView struct {
+	PartitionType string
+	PartitionID   uint64
+	VolName       string
+	NodeAddr      string
+	Reason        string
+}
+
+// StalePartition defines a partition replica left on a node by a volume which has been purged from the master.
+type StalePartition struct {
+	PartitionType string
+	PartitionID   uint64
+	VolName       string
+	NodeAddr      string
+	FirstSeen     int64 // the unix time the replica is first found
+	Reclaimed     int64 // the unix time the replica is deleted, 0 if it is pending
+}
+
+// StalePartitionGCView defines the view of the stale partition GC of the master.
+type StalePartitionGCView struct {
+	GracePeriod int64             // seconds a stale replica is found before it is deleted, 0 if the GC only tracks them
+	LastScan    int64             // the unix time of the last scan, 0 if not scanned since the master became the leader
+	Pending     []*StalePartition // the stale replicas not deleted yet
+	Reclaimed   []*StalePartition // the latest stale replicas deleted, the latest first
+}
+
+// ReplicaInconsistency defines an inconsistency between the replicas of a partition found by the consistency checker.
+type ReplicaInconsistency struct {
+	PartitionID uint64
+	VolName     string
+	Addr        string // the replica inconsistent with the others, empty if no majority is agreed
+	Issue       string
+	FoundTime   int64 // the unix time the inconsistency is found by the latest check of the partition
+}
+
+// ConsistencyReport defines the findings of the consistency checker of the master, which checks the partitions
+// in rounds, and a sample of the partitions is checked against the replicas by each round.
+type ConsistencyReport struct {
+	Interval                   int64 // seconds between the rounds, 0 if the checker is disabled
+	SampleSize                 int   // max partitions of each type checked against the replicas by a round
+	Rounds                     uint64
+	CheckTime                  int64 // the unix time the last round finished, 0 if not checked since the master became the leader
+	CheckedMetaPartitions      int   // the meta partitions checked against the replicas by the last round
+	CheckedDataPartitions      int   // the data partitions checked against the replicas by the last round
+	MetaPartitionDiagnosis     *MetaPartitionDiagnosis
+	MetaReplicaInconsistencies []ReplicaInconsistency
+	DataReplicaInconsistencies []ReplicaInconsistency
+}
+
+// MetaPartitionListItem defines the view of a meta partition in the list
+type MetaPartitionListItem struct {
+	VolName    string
+	ReplicaNum uint8
+	*MetaPartitionView
+}
+
+// MetaPartitionListView defines the result of listing the meta partitions with filters
+type MetaPartitionListView struct {
+	Total      int // count of the partitions matching the filters before paging
+	Partitions []*MetaPartitionListItem
+}
+
+// NodePartitionView defines the view of a partition replica hosted on a node
+type NodePartitionView struct {
+	PartitionID uint64
+	VolName     string
+	IsLeader    bool
+	Status      int8   // status of the replica reported by the node
+	Used        uint64 // used size of the data partition replica
+	DiskPath    string // disk of the data partition replica
+	InodeCount  uint64 // inode count of the meta partition replica
+	DentryCount uint64 // dentry count of the meta partition replica
+	ReplicaNum  uint8
+	Hosts       []string
+	LeaderAddr  string
+	IsRecover   bool
+	IsMissing   bool // the replica has not been reported by the node for a long time
+}
+
 type OSSSecure struct {
 	AccessKey string
 	SecretKey string
@@ -498,6 +812,29 @@ type SimpleVolView struct {
 	Description        string
 	DpSelectorName     string
 	DpSelectorParm     string
+	PlacementPolicy    string
+	PlacementZone      string
+	PlacementLabels    map[string]string `graphql:"-"` // the labels the nodes of the replicas must have
+	Qos                VolQos
+	IPAcl              VolIPAcl
+	SnapshotCount      int    // the overwrites are written into new extents if the volume has snapshots
+	SnapshotEpoch      uint64 // the latest snapshot, stamped on the requests changing the extents of the files
+	TrashTTL           uint64 // seconds to keep the removed files in the trash of the clients, 0 if the trash is disabled
+	MetaStore          string // the store of the new meta partitions, empty means the default of the meta nodes
+	InodeRetention     uint64 // seconds to keep the deleted inodes before purging them, 0 means the default of the meta nodes
+	ExpirationRules    []*ExpirationRule
+	LifecycleRules     []*LifecycleRule
+	CaseInsensitive    bool // the names are looked up case-insensitively but preserved, only set on creation
+	VerifyReadCrc      bool   // the data read is checked against the crc of the extent blocks, and read from another replica if it is corrupt
+	Compression        string // algorithm the cold extents are compressed with by the data nodes, empty for none
+	Encrypted          bool   // the extents are encrypted at rest by the data nodes, it can not be disabled
+	KeyVersion         uint32 // version of the data key the new extents are encrypted with
+}
+
+// VolEncryptionKeys defines the data keys of an encrypted volume by their versions, the keys are encoded in base64.
+type VolEncryptionKeys struct {
+	Current uint32
+	Keys    map[uint32]string
 }
 
 // MasterAPIAccessResp defines the response for getting meta partition
@@ -513,6 +850,8 @@ type VolInfo struct {
 	Status     uint8
 	TotalSize  uint64
 	UsedSize   uint64
+	DeleteTime int64 // the time when the volume is deleted, only set when listing the deleted volumes
+	ExpireTime int64 // the time after which the deleted volume is purged
 }
 
 func NewVolInfo(name, owner string, createTime int64, status uint8, totalSize, usedSize uint64) *VolInfo {
@@ -544,3 +883,61 @@ type NodeSetView struct {
 type TopologyView struct {
 	Zones []*ZoneView
 }
+
+// Types of the async tasks
+const (
+	AsyncTaskDecommissionDataPartition = "DecommissionDataPartition"
+	AsyncTaskDecommissionMetaPartition = "DecommissionMetaPartition"
+	AsyncTaskAddDataReplica            = "AddDataReplica"
+	AsyncTaskAddMetaReplica            = "AddMetaReplica"
+	AsyncTaskDeleteDataReplica         = "DeleteDataReplica"
+	AsyncTaskDeleteMetaReplica         = "DeleteMetaReplica"
+	AsyncTaskDecommissionDisk          = "DecommissionDisk"
+	AsyncTaskCloneVolume               = "CloneVolume"
+	AsyncTaskRollingRestart            = "RollingRestart"
+	AsyncTaskSetDirQuota               = "SetDirQuota"
+	AsyncTaskVolSnapshot               = "VolSnapshot"
+)
+
+// Status of the async tasks
+const (
+	AsyncTaskRunning   = "Running"
+	AsyncTaskSucceeded = "Succeeded"
+	AsyncTaskFailed    = "Failed"
+)
+
+// PartitionOperationPlan defines the expected result of an admin operation on a partition,
+// which is returned instead of executing the operation in dry-run mode.
+type PartitionOperationPlan struct {
+	Operation            string // path of the admin API
+	PartitionID          uint64
+	VolName              string
+	ReplicaNum           uint8
+	HostsBefore          []string
+	HostsAfter           []string
+	RemovedAddrs         []string
+	TargetAddr           string // the node to host the new replica, empty if no replica is added
+	TargetAvailableSpace uint64 // available disk space of the target data node or memory of the target meta node
+	MoveSize             uint64 // size of the data to be copied to the target, unknown for meta partitions
+}
+
+// AsyncTaskInfo defines the information of a long-running admin operation executed by master in background.
+type AsyncTaskInfo struct {
+	ID          uint64
+	Type        string
+	PartitionID uint64
+	Addr        string
+	DiskPath    string // only for the tasks of decommissioning a disk
+	VolName     string // only for the tasks of a volume
+	Status      string
+	Err         string
+	Total       int // number of the partitions or inodes to be processed by the task, 0 for the single partition tasks
+	Done        int // number of the partitions or inodes which have been processed
+	CreateTime  int64
+	UpdateTime  int64
+}
+
+// IsFinished returns true if the task has succeeded or failed.
+func (t *AsyncTaskInfo) IsFinished() bool {
+	return t.Status == AsyncTaskSucceeded || t.Status == AsyncTaskFailed
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Metrics of the alert rules. The metrics of the cluster are sampled with the cluster as the target, and the usage
+// ratios of the zones and the nodes are sampled with each zone or node as the target.
+const (
+	AlertMetricInactiveDataNodes         = "inactiveDataNodes"
+	AlertMetricInactiveMetaNodes         = "inactiveMetaNodes"
+	AlertMetricCorruptDataPartitions     = "corruptDataPartitions"
+	AlertMetricLackReplicaDataPartitions = "lackReplicaDataPartitions"
+	AlertMetricCorruptMetaPartitions     = "corruptMetaPartitions"
+	AlertMetricLackReplicaMetaPartitions = "lackReplicaMetaPartitions"
+	AlertMetricBadDisks                  = "badDisks"
+	AlertMetricRaftLagReplicas           = "raftLagReplicas"
+	AlertMetricDataUsedRatio             = "dataUsedRatio"
+	AlertMetricMetaUsedRatio             = "metaUsedRatio"
+	AlertMetricZoneDataUsedRatio         = "zoneDataUsedRatio"
+	AlertMetricZoneMetaUsedRatio         = "zoneMetaUsedRatio"
+	AlertMetricDataNodeUsedRatio         = "dataNodeUsedRatio"
+	AlertMetricMetaNodeUsedRatio         = "metaNodeUsedRatio"
+)
+
+// AlertMetrics lists the metrics of the alert rules.
+var AlertMetrics = []string{
+	AlertMetricInactiveDataNodes, AlertMetricInactiveMetaNodes, AlertMetricCorruptDataPartitions,
+	AlertMetricLackReplicaDataPartitions, AlertMetricCorruptMetaPartitions, AlertMetricLackReplicaMetaPartitions,
+	AlertMetricBadDisks, AlertMetricRaftLagReplicas, AlertMetricDataUsedRatio, AlertMetricMetaUsedRatio,
+	AlertMetricZoneDataUsedRatio, AlertMetricZoneMetaUsedRatio, AlertMetricDataNodeUsedRatio,
+	AlertMetricMetaNodeUsedRatio,
+}
+
+// AlertOperators lists the operators to compare the metric with the threshold.
+var AlertOperators = []string{">", ">=", "<", "<=", "=="}
+
+// AlertRule defines a threshold rule evaluated by the master periodically. An alert of a target fires once the
+// condition "Metric Operator Threshold" of the target lasts for Duration seconds, and is resolved once the condition
+// no longer holds. The firing and the resolving of the alerts are emitted as events.
+type AlertRule struct {
+	Name      string
+	Metric    string
+	Operator  string
+	Threshold float64
+	Duration  int64
+	Disabled  bool
+}
+
+// AlertView defines the state of the alert of a rule on a target whose condition holds.
+type AlertView struct {
+	Rule   string
+	Target string
+	Value  float64
+	Since  int64 // unix time since when the condition holds
+	Firing bool
+}
+
+// AlertRulesView defines the alert rules and the alerts whose conditions hold.
+type AlertRulesView struct {
+	Rules  []*AlertRule
+	Alerts []*AlertView
+}
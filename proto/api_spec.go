@@ -0,0 +1,633 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import "net/http"
+
+// Types of the parameters of the master APIs, named after the Go types of the values.
+const (
+	APIParamString  = "string"
+	APIParamBool    = "bool"
+	APIParamInt     = "int"
+	APIParamInt64   = "int64"
+	APIParamUint64  = "uint64"
+	APIParamFloat64 = "float64"
+)
+
+// Tags grouping the master APIs.
+const (
+	APITagCluster   = "cluster"
+	APITagVolume    = "volume"
+	APITagPartition = "partition"
+	APITagNode      = "node"
+	APITagClient    = "client"
+	APITagUser      = "user"
+	APITagMonitor   = "monitor"
+)
+
+// APIParam describes a query parameter of a master API.
+type APIParam struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// APISpec describes a master HTTP API. The OpenAPI document served by the master and the requests of
+// the master SDK are both generated from the specs, so a new API or parameter is added here first.
+type APISpec struct {
+	Name     string // the operation ID, unique among the APIs
+	Path     string
+	Methods  []string
+	Tag      string
+	Summary  string
+	ReadOnly bool // the reply can be served by the followers
+	Params   []APIParam
+	Body     interface{} // a sample of the JSON body of the request, nil if the API takes no body
+	Response interface{} // a sample of the data of the reply, nil if the data is a message
+}
+
+var (
+	apiGet     = []string{http.MethodGet}
+	apiPost    = []string{http.MethodPost}
+	apiGetPost = []string{http.MethodGet, http.MethodPost}
+
+	paramVolName         = APIParam{Name: "name", Type: APIParamString, Required: true, Description: "the name of the volume"}
+	paramVolAuthKey      = APIParam{Name: "authKey", Type: APIParamString, Required: true, Description: "the md5 of the owner of the volume"}
+	paramPartitionID     = APIParam{Name: "id", Type: APIParamUint64, Required: true, Description: "the ID of the partition"}
+	paramNodeAddr        = APIParam{Name: "addr", Type: APIParamString, Required: true, Description: "the address of the node"}
+	paramZoneName        = APIParam{Name: "zoneName", Type: APIParamString, Description: "the name of the zone"}
+	paramRackName        = APIParam{Name: "rackName", Type: APIParamString, Description: "the name of the rack"}
+	paramNodeLabels      = APIParam{Name: "labels", Type: APIParamString, Required: true, Description: "the comma separated key=value labels, empty removes all the labels"}
+	paramKeywords        = APIParam{Name: "keywords", Type: APIParamString, Description: "the keywords contained in the names"}
+	paramLimit           = APIParam{Name: "limit", Type: APIParamInt, Description: "the max number of the items, 0 for no limit"}
+	paramAsync           = APIParam{Name: "async", Type: APIParamBool, Description: "run the operation in background and reply the task"}
+	paramDryRun          = APIParam{Name: "dryRun", Type: APIParamBool, Description: "reply the plan of the operation without executing it"}
+	paramUserID          = APIParam{Name: "user", Type: APIParamString, Required: true, Description: "the ID of the user"}
+	paramHighRatio       = APIParam{Name: "highRatio", Type: APIParamFloat64, Description: "the usage ratio of the nodes to move the partitions out"}
+	paramLowRatio        = APIParam{Name: "lowRatio", Type: APIParamFloat64, Description: "the usage ratio of the nodes to move the partitions in"}
+	paramRebalanceLimit  = APIParam{Name: "limit", Type: APIParamUint64, Description: "the max number of the partitions migrating at the same time, 0 disables the rebalancer"}
+	paramRebalanceBw     = APIParam{Name: "bandwidth", Type: APIParamUint64, Description: "the bytes per second of the partitions started to migrate"}
+	paramSnapshotID      = APIParam{Name: "snapshotId", Type: APIParamUint64, Required: true, Description: "the ID of the snapshot"}
+	paramTokenValue      = APIParam{Name: "token", Type: APIParamString, Required: true, Description: "the value of the token"}
+	paramTokenType       = APIParam{Name: "tokenType", Type: APIParamInt, Required: true, Description: "the type of the token, 1 for read-only and 2 for read-write"}
+	paramFollowerRead    = APIParam{Name: "followerRead", Type: APIParamBool, Description: "allow the clients to read from the followers"}
+	paramVolCapacity     = APIParam{Name: "capacity", Type: APIParamUint64, Description: "the capacity of the volume in GB"}
+	paramVolDescription  = APIParam{Name: "description", Type: APIParamString, Description: "the description of the volume"}
+	paramVolReplicaNum   = APIParam{Name: "replicaNum", Type: APIParamInt, Description: "the number of the replicas of the data partitions"}
+	paramVolEnableToken  = APIParam{Name: "enableToken", Type: APIParamBool, Description: "require the clients to mount by the tokens"}
+	paramVolAuthenticate = APIParam{Name: "authenticate", Type: APIParamBool, Description: "require the clients to be authenticated by the authnode"}
+	paramConfigName      = APIParam{Name: "name", Type: APIParamString, Required: true, Description: "the name of the parameter"}
+)
+
+// MasterAPISpecs lists the HTTP APIs of the master.
+var MasterAPISpecs = []*APISpec{
+	// cluster
+	{Name: "getClusterInfo", Path: AdminGetIP, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the name of the cluster and the address of the client", Response: &ClusterInfo{}},
+	{Name: "getCluster", Path: AdminGetCluster, Methods: apiGet, Tag: APITagCluster, ReadOnly: true,
+		Summary: "Get the overview of the cluster", Response: &ClusterView{}},
+	{Name: "freezeCluster", Path: AdminClusterFreeze, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Freeze the cluster to stop creating the data partitions automatically",
+		Params:  []APIParam{{Name: "enable", Type: APIParamBool, Required: true, Description: "freeze or unfreeze"}}},
+	{Name: "addRaftNode", Path: AddRaftNode, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Add a master into the raft group",
+		Params:  []APIParam{{Name: "id", Type: APIParamUint64, Required: true, Description: "the raft ID of the master"}, paramNodeAddr}},
+	{Name: "removeRaftNode", Path: RemoveRaftNode, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Remove a master from the raft group",
+		Params:  []APIParam{{Name: "id", Type: APIParamUint64, Required: true, Description: "the raft ID of the master"}, paramNodeAddr}},
+	{Name: "getClusterStat", Path: AdminClusterStat, Methods: apiGet, Tag: APITagCluster, ReadOnly: true,
+		Summary: "Get the space statistics of the cluster and the zones", Response: &ClusterStatInfo{}},
+	{Name: "getClusterHealth", Path: AdminClusterHealth, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the health summary of the masters, the nodes and the partitions",
+		Params: []APIParam{
+			{Name: "maxRaftLag", Type: APIParamUint64, Description: "the raft lag of the followers to report"},
+			{Name: "threshold", Type: APIParamFloat64, Description: "the used ratio of the nodes to report"},
+		},
+		Response: &ClusterHealth{}},
+	{Name: "recordCliAudit", Path: AdminRecordCliAudit, Methods: apiPost, Tag: APITagCluster,
+		Summary: "Record a mutating command of the CLI", Body: &CliAuditRecord{}},
+	{Name: "rollingRestart", Path: AdminRollingRestart, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Restart the nodes of a role batch by batch through the node agents",
+		Params: []APIParam{
+			{Name: "role", Type: APIParamString, Required: true, Description: "the role of the nodes, such as master, metanode and datanode"},
+			{Name: "hosts", Type: APIParamString, Description: "the comma separated addresses of the nodes, all the nodes of the role if empty"},
+			{Name: "batch", Type: APIParamInt, Description: "the number of the nodes restarted at the same time"},
+			{Name: "agentPort", Type: APIParamInt, Description: "the port of the node agents"},
+			{Name: "timeout", Type: APIParamInt, Description: "the seconds to wait for a node to be healthy"},
+		},
+		Response: &AsyncTaskInfo{}},
+	{Name: "getNodeInfo", Path: AdminGetNodeInfo, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Get the cluster settings of the nodes", Response: map[string]string{}},
+	{Name: "setNodeInfo", Path: AdminSetNodeInfo, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Set the cluster settings of the nodes, only the given settings are changed",
+		Params: []APIParam{
+			{Name: "batchCount", Type: APIParamUint64, Description: "the inodes deleted by the meta nodes in a batch"},
+			{Name: "markDeleteRate", Type: APIParamUint64, Description: "the extents deleted by the data nodes per second"},
+			{Name: "deleteWorkerSleepMs", Type: APIParamUint64, Description: "the milliseconds the delete workers of the meta nodes sleep"},
+			{Name: "autoRepairRate", Type: APIParamUint64, Description: "the data partitions repaired by the data nodes at the same time"},
+			{Name: "autoSupplementLimit", Type: APIParamUint64, Description: "the partitions recovering from the replicas supplemented automatically"},
+			{Name: "volDeletionDelay", Type: APIParamUint64, Description: "the seconds to keep the deleted volumes recoverable"},
+			{Name: "mpSplitInodeCount", Type: APIParamUint64, Description: "the inodes of a meta partition to split the volume"},
+			{Name: "mpSplitMemory", Type: APIParamUint64, Description: "the memory of a meta partition to split the volume"},
+			{Name: "decommissionLimit", Type: APIParamUint64, Description: "the data partitions migrating from a decommissioned data node at the same time"},
+			{Name: "decommissionBandwidth", Type: APIParamUint64, Description: "the bytes per second to repair a migrated data partition"},
+		}},
+	{Name: "setMetaNodeThreshold", Path: AdminSetMetaNodeThreshold, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Set the memory ratio of the meta nodes to stop creating the meta partitions",
+		Params:  []APIParam{{Name: "threshold", Type: APIParamFloat64, Required: true, Description: "the memory ratio"}}},
+	{Name: "getTopology", Path: GetTopologyView, Methods: apiGet, Tag: APITagCluster, ReadOnly: true,
+		Summary: "Get the zones and the node sets of the cluster", Response: &TopologyView{}},
+	{Name: "listZones", Path: GetAllZones, Methods: apiGet, Tag: APITagCluster, ReadOnly: true,
+		Summary: "List the zones of the cluster", Response: []*ZoneView{}},
+	{Name: "updateZone", Path: UpdateZone, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Enable or disable a zone",
+		Params: []APIParam{
+			{Name: "name", Type: APIParamString, Required: true, Description: "the name of the zone"},
+			{Name: "enable", Type: APIParamBool, Required: true, Description: "enable or disable"},
+		}},
+	{Name: "moveZoneNode", Path: MoveZoneNode, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Move a data node or a meta node into a zone",
+		Params: []APIParam{
+			{Name: "type", Type: APIParamString, Required: true, Description: "the type of the node, data or meta"},
+			paramNodeAddr,
+			{Name: "zoneName", Type: APIParamString, Required: true, Description: "the name of the zone"},
+		}},
+	{Name: "getMetaRebalance", Path: AdminMetaRebalanceStatus, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the meta rebalancer and the meta partitions being migrated", Response: &RebalanceView{}},
+	{Name: "setMetaRebalance", Path: AdminMetaRebalanceSet, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Set the memory usage ratios and the limits of the meta rebalancer",
+		Params:  []APIParam{paramHighRatio, paramLowRatio, paramRebalanceLimit, paramRebalanceBw}},
+	{Name: "pauseMetaRebalance", Path: AdminMetaRebalancePause, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Pause the meta rebalancer"},
+	{Name: "resumeMetaRebalance", Path: AdminMetaRebalanceResume, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Resume the meta rebalancer"},
+	{Name: "getDataRebalance", Path: AdminDataRebalanceStatus, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the data rebalancer and the data partitions being migrated", Response: &RebalanceView{}},
+	{Name: "setDataRebalance", Path: AdminDataRebalanceSet, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Set the disk usage ratios and the limits of the data rebalancer",
+		Params:  []APIParam{paramHighRatio, paramLowRatio, paramRebalanceLimit, paramRebalanceBw}},
+	{Name: "pauseDataRebalance", Path: AdminDataRebalancePause, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Pause the data rebalancer"},
+	{Name: "resumeDataRebalance", Path: AdminDataRebalanceResume, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Resume the data rebalancer"},
+	{Name: "getTask", Path: AdminGetTask, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the progress of an async task",
+		Params:  []APIParam{{Name: "id", Type: APIParamUint64, Required: true, Description: "the ID of the task"}}, Response: &AsyncTaskInfo{}},
+	{Name: "listTasks", Path: AdminListTasks, Methods: apiGet, Tag: APITagCluster,
+		Summary: "List the async tasks", Response: []*AsyncTaskInfo{}},
+	{Name: "getConfig", Path: AdminGetConfig, Methods: apiGet, Tag: APITagCluster,
+		Summary:  "Get a parameter of the master which can be changed at runtime",
+		Params:   []APIParam{paramConfigName},
+		Response: &RuntimeConfigItem{}},
+	{Name: "setConfig", Path: AdminSetConfig, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Change a parameter of the master without restarting, the value overrides the config file",
+		Params: []APIParam{
+			paramConfigName,
+			{Name: "value", Type: APIParamString, Required: true, Description: "the new value of the parameter"},
+		}},
+	{Name: "listConfig", Path: AdminListConfig, Methods: apiGet, Tag: APITagCluster,
+		Summary: "List the parameters of the master which can be changed at runtime", Response: []*RuntimeConfigItem{}},
+	{Name: "createMetadataBackup", Path: AdminCreateMetadataBackup, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Back up the metadata of the masters to the object store now", Response: &MetadataBackupInfo{}},
+	{Name: "listMetadataBackups", Path: AdminListMetadataBackups, Methods: apiGet, Tag: APITagCluster,
+		Summary: "List the backups of the metadata of the cluster in the object store", Response: []*MetadataBackupInfo{}},
+	{Name: "restoreMetadataBackup", Path: AdminRestoreMetadataBackup, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Restore the metadata of an empty cluster from a backup, the latest one before the time by default",
+		Params: []APIParam{
+			{Name: "key", Type: APIParamString, Description: "the key of the backup to restore"},
+			{Name: "time", Type: APIParamInt64, Description: "the unix time to restore the latest backup taken before, now by default"},
+		},
+		Response: &MetadataBackupInfo{}},
+	{Name: "getCapacityForecast", Path: AdminCapacityForecast, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Forecast the time the volumes and the zones become full by the growth of the used space",
+		Params: []APIParam{
+			{Name: "name", Type: APIParamString, Description: "the name of the volume, all the volumes and the zones if empty"},
+			{Name: "days", Type: APIParamInt, Description: "the days of the recent samples to forecast by, 7 by default"},
+		},
+		Response: &CapacityForecastView{}},
+	{Name: "addFederationPeer", Path: AdminAddFederationPeer, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Register a peer cluster in the federation, or replace the peer of the same name",
+		Params: []APIParam{
+			{Name: "name", Type: APIParamString, Required: true, Description: "the name of the peer cluster"},
+			{Name: "region", Type: APIParamString, Description: "the region of the peer cluster"},
+			{Name: "masters", Type: APIParamString, Required: true, Description: "the comma separated addresses of the masters of the peer cluster"},
+		}},
+	{Name: "removeFederationPeer", Path: AdminRemoveFederationPeer, Methods: apiGetPost, Tag: APITagCluster,
+		Summary: "Remove a peer cluster from the federation",
+		Params:  []APIParam{{Name: "name", Type: APIParamString, Required: true, Description: "the name of the peer cluster"}}},
+	{Name: "listFederationPeers", Path: AdminListFederationPeers, Methods: apiGet, Tag: APITagCluster,
+		Summary: "List the peer clusters registered in the federation", Response: []*FederationPeer{}},
+	{Name: "getFederation", Path: AdminGetFederation, Methods: apiGet, Tag: APITagCluster,
+		Summary: "Get the capacity and the health of the local cluster and the peer clusters", Response: &FederationView{}},
+
+	// volume
+	{Name: "createVol", Path: AdminCreateVol, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Create a volume",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "owner", Type: APIParamString, Required: true, Description: "the owner of the volume"},
+			{Name: "mpCount", Type: APIParamInt, Description: "the number of the initial meta partitions"},
+			{Name: "size", Type: APIParamUint64, Description: "the size of the data partitions in GB"},
+			paramVolCapacity, paramVolReplicaNum, paramFollowerRead, paramVolAuthenticate, paramVolEnableToken,
+			{Name: "crossZone", Type: APIParamBool, Description: "place the replicas across the zones"},
+			paramZoneName, paramVolDescription,
+			{Name: "caseInsensitive", Type: APIParamBool, Description: "look up the names case-insensitively but preserve them, which can not be changed later"},
+		}},
+	{Name: "getVolSimpleInfo", Path: AdminGetVol, Methods: apiGet, Tag: APITagVolume, ReadOnly: true,
+		Summary: "Get the information of a volume", Params: []APIParam{paramVolName}, Response: &SimpleVolView{}},
+	{Name: "cloneVol", Path: AdminCloneVol, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Clone the metadata of a volume into a new volume in background",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "target", Type: APIParamString, Required: true, Description: "the name of the new volume"},
+			{Name: "owner", Type: APIParamString, Description: "the owner of the new volume, the owner of the source volume if empty"},
+		},
+		Response: &AsyncTaskInfo{}},
+	{Name: "deleteVol", Path: AdminDeleteVol, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Delete a volume, which is recoverable within the deletion delay", Params: []APIParam{paramVolName, paramVolAuthKey}},
+	{Name: "restoreVol", Path: AdminRestoreVol, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Restore a deleted volume", Params: []APIParam{paramVolName, paramVolAuthKey}},
+	{Name: "updateVol", Path: AdminUpdateVol, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Update a volume, only the given settings are changed",
+		Params: []APIParam{
+			paramVolName, paramVolAuthKey, paramVolCapacity, paramVolReplicaNum, paramFollowerRead,
+			paramVolEnableToken, paramVolAuthenticate, paramZoneName, paramVolDescription,
+			{Name: "dpSelectorName", Type: APIParamString, Description: "the name of the data partition selector of the clients"},
+			{Name: "dpSelectorParm", Type: APIParamString, Description: "the parameter of the data partition selector"},
+			{Name: "placementPolicy", Type: APIParamString, Description: "the replica placement policy registered to the master, default resets the policy"},
+			{Name: "placementZone", Type: APIParamString, Description: "the zone of the zone-pinned placement policy"},
+			{Name: "placementLabels", Type: APIParamString, Description: "the comma separated key=value labels the nodes of the new replicas must have, empty removes the constraints"},
+			{Name: "readIopsLimit", Type: APIParamUint64, Description: "the read IOPS limit, 0 for no limit"},
+			{Name: "writeIopsLimit", Type: APIParamUint64, Description: "the write IOPS limit, 0 for no limit"},
+			{Name: "readBpsLimit", Type: APIParamUint64, Description: "the read bytes per second limit, 0 for no limit"},
+			{Name: "writeBpsLimit", Type: APIParamUint64, Description: "the write bytes per second limit, 0 for no limit"},
+			{Name: "trashTTL", Type: APIParamUint64, Description: "the seconds to keep the removed files in the trash, 0 disables the trash"},
+			{Name: "metaStore", Type: APIParamString, Description: "the store of the new meta partitions, memory or rocksdb, empty for the default of the meta nodes"},
+			{Name: "inodeRetention", Type: APIParamUint64, Description: "the seconds to keep the deleted inodes before purging them, 0 for the default of the meta nodes"},
+			{Name: "ipAllow", Type: APIParamString, Description: "the comma separated CIDRs of the allowed clients, empty allows all the clients"},
+			{Name: "ipDeny", Type: APIParamString, Description: "the comma separated CIDRs of the denied clients, empty denies none"},
+			{Name: "verifyReadCrc", Type: APIParamBool, Description: "check the data read against the crc of the extent blocks, and read it from another replica if it is corrupt"},
+			{Name: "compression", Type: APIParamString, Description: "the algorithm the data nodes compress the cold extents with, lz4 or deflate, none disables the compression"},
+			{Name: "encrypted", Type: APIParamBool, Description: "encrypt the extents at rest on the data nodes by the data key of the volume, which can not be disabled"},
+		}},
+	{Name: "rotateVolKey", Path: AdminRotateVolKey, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Generate a new data key of an encrypted volume, which the extents are encrypted with again",
+		Params:  []APIParam{paramVolName, paramVolAuthKey}},
+	{Name: "getVolQos", Path: AdminGetVolQos, Methods: apiGet, Tag: APITagVolume,
+		Summary: "Get the IOPS and the bandwidth limits of the limited volumes", Response: map[string]VolQos{}},
+	{Name: "getVolIPAcl", Path: AdminGetVolIPAcl, Methods: apiGet, Tag: APITagVolume,
+		Summary: "Get the client IP restrictions of the restricted volumes", Response: map[string]VolIPAcl{}},
+	{Name: "getVerifyReadCrcVols", Path: AdminGetVerifyReadCrcVols, Methods: apiGet, Tag: APITagVolume,
+		Summary: "Get the names of the volumes whose data read is checked against the crc", Response: []string{}},
+	{Name: "getCompressionVols", Path: AdminGetCompressionVols, Methods: apiGet, Tag: APITagVolume,
+		Summary: "Get the compression algorithms of the volumes with compression", Response: map[string]string{}},
+	{Name: "getVolEncryptionKeys", Path: AdminGetVolEncryptionKeys, Methods: apiGet, Tag: APITagVolume,
+		Summary:  "Get the data keys of the encrypted volumes encrypted by the session key of the ticket with the caps master:getvolkeys",
+		Response: ""},
+	{Name: "shrinkVol", Path: AdminVolShrink, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Shrink the capacity of a volume",
+		Params:  []APIParam{paramVolName, paramVolAuthKey, {Name: "capacity", Type: APIParamUint64, Required: true, Description: "the capacity in GB"}}},
+	{Name: "expandVol", Path: AdminVolExpand, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Expand the capacity of a volume",
+		Params:  []APIParam{paramVolName, paramVolAuthKey, {Name: "capacity", Type: APIParamUint64, Required: true, Description: "the capacity in GB"}}},
+	{Name: "listVols", Path: AdminListVols, Methods: apiGet, Tag: APITagVolume, ReadOnly: true,
+		Summary: "List the volumes in the order of name",
+		Params: []APIParam{
+			paramKeywords,
+			{Name: "deleted", Type: APIParamBool, Description: "list the deleted volumes instead"},
+			{Name: "marker", Type: APIParamString, Description: "list the volumes whose names are greater than the marker"},
+			paramLimit,
+		},
+		Response: []*VolInfo{}},
+	{Name: "setDirQuota", Path: QuotaSet, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Set the limits of the quota of a directory",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "path", Type: APIParamString, Required: true, Description: "the path of the directory"},
+			{Name: "maxBytes", Type: APIParamUint64, Description: "the max bytes, 0 for no limit"},
+			{Name: "maxFiles", Type: APIParamUint64, Description: "the max files, 0 for no limit"},
+		},
+		Response: &DirQuotaReply{}},
+	{Name: "deleteDirQuota", Path: QuotaDelete, Methods: apiGetPost, Tag: APITagVolume,
+		Summary:  "Delete the quota of a directory",
+		Params:   []APIParam{paramVolName, {Name: "quotaId", Type: APIParamUint64, Required: true, Description: "the ID of the quota"}},
+		Response: &DirQuotaReply{}},
+	{Name: "listDirQuotas", Path: QuotaList, Methods: apiGet, Tag: APITagVolume,
+		Summary: "List the directory quotas of a volume", Params: []APIParam{paramVolName}, Response: []*DirQuota{}},
+	{Name: "setExpirationRule", Path: ExpirationSet, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Set the expiration rule of a directory, the files not modified or accessed for the days are deleted",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "path", Type: APIParamString, Required: true, Description: "the path of the directory, / for the whole volume"},
+			{Name: "days", Type: APIParamUint64, Required: true, Description: "the days after which the files expire"},
+			{Name: "basis", Type: APIParamString, Description: "the time the expiration is based on, mtime or atime, mtime by default"},
+		},
+		Response: &ExpirationRuleReply{}},
+	{Name: "deleteExpirationRule", Path: ExpirationDelete, Methods: apiGetPost, Tag: APITagVolume,
+		Summary:  "Delete an expiration rule of a volume",
+		Params:   []APIParam{paramVolName, {Name: "ruleId", Type: APIParamUint64, Required: true, Description: "the ID of the rule"}},
+		Response: &ExpirationRuleReply{}},
+	{Name: "listExpirationRules", Path: ExpirationList, Methods: apiGet, Tag: APITagVolume,
+		Summary: "List the expiration rules of a volume", Params: []APIParam{paramVolName}, Response: []*ExpirationRule{}},
+	{Name: "setLifecycleRule", Path: LifecycleSet, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Set the lifecycle rule of a directory, the files not modified or accessed for the days are transited to the storage class",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "path", Type: APIParamString, Required: true, Description: "the path of the directory, / for the whole volume"},
+			{Name: "days", Type: APIParamUint64, Required: true, Description: "the days after which the files are transited"},
+			{Name: "basis", Type: APIParamString, Description: "the time the transition is based on, mtime or atime, mtime by default"},
+			{Name: "storageClass", Type: APIParamString, Required: true, Description: "the storage class to transit the files to, replica or cold"},
+		},
+		Response: &LifecycleRuleReply{}},
+	{Name: "deleteLifecycleRule", Path: LifecycleDelete, Methods: apiGetPost, Tag: APITagVolume,
+		Summary:  "Delete a lifecycle rule of a volume",
+		Params:   []APIParam{paramVolName, {Name: "ruleId", Type: APIParamUint64, Required: true, Description: "the ID of the rule"}},
+		Response: &LifecycleRuleReply{}},
+	{Name: "listLifecycleRules", Path: LifecycleList, Methods: apiGet, Tag: APITagVolume,
+		Summary: "List the lifecycle rules of a volume", Params: []APIParam{paramVolName}, Response: []*LifecycleRule{}},
+	{Name: "createVolSnapshot", Path: VolSnapshotCreate, Methods: apiGetPost, Tag: APITagVolume,
+		Summary:  "Create a snapshot of a volume",
+		Params:   []APIParam{paramVolName, {Name: "snapshot", Type: APIParamString, Required: true, Description: "the name of the snapshot"}},
+		Response: &VolSnapshotReply{}},
+	{Name: "listVolSnapshots", Path: VolSnapshotList, Methods: apiGet, Tag: APITagVolume,
+		Summary: "List the snapshots of a volume", Params: []APIParam{paramVolName}, Response: []*VolSnapshot{}},
+	{Name: "deleteVolSnapshot", Path: VolSnapshotDelete, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Delete a snapshot of a volume", Params: []APIParam{paramVolName, paramSnapshotID}, Response: &VolSnapshotReply{}},
+	{Name: "rollbackVolSnapshot", Path: VolSnapshotRollback, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Replace the metadata of a volume with a snapshot", Params: []APIParam{paramVolName, paramSnapshotID}, Response: &VolSnapshotReply{}},
+	{Name: "setVolReplication", Path: VolReplicationSet, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Set or remove the replication of a volume to the volume of a standby cluster",
+		Params: []APIParam{
+			paramVolName, paramVolAuthKey,
+			{Name: "targetMaster", Type: APIParamString, Description: "the comma separated masters of the standby cluster"},
+			{Name: "targetVol", Type: APIParamString, Description: "the standby volume, empty to remove the replication"},
+			{Name: "maxLag", Type: APIParamInt64, Description: "seconds the standby volume lags behind before alarming"},
+		},
+		Response: &VolReplicationView{}},
+	{Name: "getVolReplication", Path: VolReplicationGet, Methods: apiGet, Tag: APITagVolume,
+		Summary: "Get the replication of a volume and its lag", Params: []APIParam{paramVolName}, Response: &VolReplicationView{}},
+	{Name: "listVolReplications", Path: VolReplicationList, Methods: apiGet, Tag: APITagVolume,
+		Summary: "List the replications of the volumes", Response: []*VolReplicationView{}},
+	{Name: "reportVolReplication", Path: VolReplicationReport, Methods: apiPost, Tag: APITagVolume,
+		Summary: "Report the progress of the replication of a volume by a replicator", Params: []APIParam{paramVolName},
+		Body: &VolReplicationStatus{}},
+	{Name: "failoverVolReplication", Path: VolReplicationFailover, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Stop the replication of a volume to fail over to the standby volume",
+		Params:  []APIParam{paramVolName, paramVolAuthKey}, Response: &VolReplicationView{}},
+	{Name: "addToken", Path: TokenAddURI, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Add a mount token of a volume", Params: []APIParam{paramVolName, paramTokenType, paramVolAuthKey}},
+	{Name: "updateToken", Path: TokenUpdateURI, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Update the type of a mount token", Params: []APIParam{paramVolName, paramTokenType, paramTokenValue, paramVolAuthKey}},
+	{Name: "deleteToken", Path: TokenDelURI, Methods: apiGetPost, Tag: APITagVolume,
+		Summary: "Delete a mount token of a volume", Params: []APIParam{paramVolName, paramTokenValue, paramVolAuthKey}},
+
+	// partition
+	{Name: "getDataPartition", Path: AdminGetDataPartition, Methods: apiGet, Tag: APITagPartition, ReadOnly: true,
+		Summary:  "Get a data partition",
+		Params:   []APIParam{paramPartitionID, {Name: "name", Type: APIParamString, Description: "the name of the volume"}},
+		Response: &DataPartitionInfo{}},
+	{Name: "createDataPartition", Path: AdminCreateDataPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Create the data partitions of a volume",
+		Params:  []APIParam{paramVolName, {Name: "count", Type: APIParamInt, Required: true, Description: "the number of the data partitions"}}},
+	{Name: "loadDataPartition", Path: AdminLoadDataPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Check the replicas of a data partition", Params: []APIParam{paramPartitionID, paramVolName}},
+	{Name: "decommissionDataPartition", Path: AdminDecommissionDataPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Migrate a replica of a data partition to another node, the reply is the task if async or the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync, paramDryRun}},
+	{Name: "resetDataPartition", Path: AdminResetDataPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Reset the raft members of a data partition to the alive replicas, the reply is the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramDryRun}},
+	{Name: "diagnoseDataPartition", Path: AdminDiagnoseDataPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Diagnose the data partitions of the cluster", Response: &DataPartitionDiagnosis{}},
+	{Name: "addDataReplica", Path: AdminAddDataReplica, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Add a replica of a data partition, the reply is the task if async",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync}},
+	{Name: "deleteDataReplica", Path: AdminDeleteDataReplica, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Delete a replica of a data partition, the reply is the task if async or the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync, paramDryRun}},
+	{Name: "transferDataLeader", Path: AdminTransferDataLeader, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Transfer the raft leader of a data partition to a replica", Params: []APIParam{paramPartitionID, paramNodeAddr}},
+	{Name: "setDataPartitionStatus", Path: AdminSetDataPartitionStatus, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Force a data partition read-only or writable, or return it to the status decided by the master",
+		Params: []APIParam{
+			paramPartitionID,
+			{Name: "status", Type: APIParamString, Required: true, Description: "readonly, writable or auto"},
+			{Name: "reason", Type: APIParamString, Description: "the reason to force the status, such as the investigation"},
+		}},
+	{Name: "getMetaPartition", Path: ClientMetaPartition, Methods: apiGet, Tag: APITagPartition, ReadOnly: true,
+		Summary: "Get a meta partition", Params: []APIParam{paramPartitionID}, Response: &MetaPartitionInfo{}},
+	{Name: "createMetaPartition", Path: AdminCreateMetaPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Split the last meta partition of a volume",
+		Params:  []APIParam{paramVolName, {Name: "start", Type: APIParamUint64, Required: true, Description: "the start inode of the new meta partition"}}},
+	{Name: "loadMetaPartition", Path: AdminLoadMetaPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Check the replicas of a meta partition", Params: []APIParam{paramPartitionID}},
+	{Name: "decommissionMetaPartition", Path: AdminDecommissionMetaPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Migrate a replica of a meta partition to another node, the reply is the task if async or the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync, paramDryRun}},
+	{Name: "resetMetaPartition", Path: AdminResetMetaPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Reset the raft members of a meta partition to the alive replicas, the reply is the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramDryRun}},
+	{Name: "diagnoseMetaPartition", Path: AdminDiagnoseMetaPartition, Methods: apiGetPost, Tag: APITagPartition,
+		Summary:  "Diagnose the meta partitions of the cluster or a volume",
+		Params:   []APIParam{{Name: "name", Type: APIParamString, Description: "the name of the volume, all the volumes if empty"}},
+		Response: &MetaPartitionDiagnosis{}},
+	{Name: "listMetaPartitions", Path: AdminListMetaPartitions, Methods: apiGet, Tag: APITagPartition,
+		Summary: "List the meta partitions matching the filters, the empty filters match all",
+		Params: []APIParam{
+			{Name: "name", Type: APIParamString, Description: "the name of the volume"},
+			{Name: "status", Type: APIParamString, Description: "the status of the meta partitions"},
+			{Name: "addr", Type: APIParamString, Description: "the address of a meta node holding the replicas"},
+			{Name: "offset", Type: APIParamInt, Description: "the number of the matched meta partitions to skip"},
+			paramLimit,
+		},
+		Response: &MetaPartitionListView{}},
+	{Name: "addMetaReplica", Path: AdminAddMetaReplica, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Add a replica of a meta partition, the reply is the task if async",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync}},
+	{Name: "deleteMetaReplica", Path: AdminDeleteMetaReplica, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Delete a replica of a meta partition, the reply is the task if async or the plan if dryRun",
+		Params:  []APIParam{paramPartitionID, paramNodeAddr, paramAsync, paramDryRun}},
+	{Name: "transferMetaLeader", Path: AdminTransferMetaLeader, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Transfer the raft leader of a meta partition to a replica", Params: []APIParam{paramPartitionID, paramNodeAddr}},
+	{Name: "listOrphanPartitions", Path: AdminListOrphanPartitions, Methods: apiGet, Tag: APITagPartition,
+		Summary: "List the partition replicas reported by the nodes but unknown to the master", Response: []*OrphanPartitionView{}},
+	{Name: "cleanOrphanPartition", Path: AdminCleanOrphanPartitions, Methods: apiGetPost, Tag: APITagPartition,
+		Summary: "Delete an orphan partition replica from the node",
+		Params: []APIParam{
+			{Name: "type", Type: APIParamString, Required: true, Description: "the type of the partition, data or meta"},
+			paramPartitionID, paramNodeAddr,
+		}},
+	{Name: "getStalePartitionGC", Path: AdminStalePartitionGC, Methods: apiGet, Tag: APITagPartition,
+		Summary:  "Get the stale replicas of the purged volumes pending and deleted by the stale partition GC",
+		Response: &StalePartitionGCView{}},
+	{Name: "getConsistencyReport", Path: AdminGetConsistencyReport, Methods: apiGet, Tag: APITagPartition,
+		Summary:  "Get the findings cached by the scheduled consistency checker of the partitions",
+		Response: &ConsistencyReport{}},
+
+	// node
+	{Name: "addDataNode", Path: AddDataNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Register a data node and get its ID", Params: []APIParam{paramNodeAddr, paramZoneName, paramRackName}, Response: uint64(0)},
+	{Name: "getDataNode", Path: GetDataNode, Methods: apiGet, Tag: APITagNode,
+		Summary: "Get a data node", Params: []APIParam{paramNodeAddr}, Response: &DataNodeInfo{}},
+	{Name: "getDataNodePartitions", Path: GetDataNodePartitions, Methods: apiGet, Tag: APITagNode,
+		Summary: "List the data partitions on a data node", Params: []APIParam{paramNodeAddr}, Response: []*NodePartitionView{}},
+	{Name: "decommissionDataNode", Path: DecommissionDataNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Migrate all the data partitions off a data node", Params: []APIParam{paramNodeAddr}},
+	{Name: "decommissionDisk", Path: DecommissionDisk, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Migrate all the data partitions off a disk of a data node",
+		Params:  []APIParam{paramNodeAddr, {Name: "disk", Type: APIParamString, Required: true, Description: "the path of the disk"}}},
+	{Name: "decommissionDiskAsync", Path: AdminDecommissionDiskAsync, Methods: apiGetPost, Tag: APITagNode,
+		Summary:  "Migrate all the data partitions off a disk of a data node in background",
+		Params:   []APIParam{paramNodeAddr, {Name: "disk", Type: APIParamString, Required: true, Description: "the path of the disk"}},
+		Response: &AsyncTaskInfo{}},
+	{Name: "setDiskRetiring", Path: AdminSetDiskRetiring, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Mark a disk of a data node retiring and migrate the data partitions off it, or cancel it",
+		Params: []APIParam{paramNodeAddr, {Name: "disk", Type: APIParamString, Required: true, Description: "the path of the disk"},
+			{Name: "retiring", Type: APIParamBool, Description: "true to retire the disk, false to cancel it"},
+			{Name: "reason", Type: APIParamString, Description: "why the disk is retired"}}},
+	{Name: "updateDataNode", Path: AdminUpdateDataNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Update the ID of a data node",
+		Params:  []APIParam{paramNodeAddr, {Name: "id", Type: APIParamUint64, Required: true, Description: "the ID of the node"}}, Response: uint64(0)},
+	{Name: "setDataNodeLabels", Path: AdminSetDataNodeLabels, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Replace the labels of a data node, which the placement labels of the volumes are matched against",
+		Params:  []APIParam{paramNodeAddr, paramNodeLabels}},
+	{Name: "dataNodeTaskResponse", Path: GetDataNodeTaskResponse, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Report the result of an admin task by a data node", Body: &AdminTask{}},
+	{Name: "addMetaNode", Path: AddMetaNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Register a meta node and get its ID", Params: []APIParam{paramNodeAddr, paramZoneName, paramRackName}, Response: uint64(0)},
+	{Name: "getMetaNode", Path: GetMetaNode, Methods: apiGet, Tag: APITagNode,
+		Summary: "Get a meta node", Params: []APIParam{paramNodeAddr}, Response: &MetaNodeInfo{}},
+	{Name: "getMetaNodePartitions", Path: GetMetaNodePartitions, Methods: apiGet, Tag: APITagNode,
+		Summary: "List the meta partitions on a meta node", Params: []APIParam{paramNodeAddr}, Response: []*NodePartitionView{}},
+	{Name: "decommissionMetaNode", Path: DecommissionMetaNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Migrate all the meta partitions off a meta node", Params: []APIParam{paramNodeAddr}},
+	{Name: "updateMetaNode", Path: AdminUpdateMetaNode, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Update the ID of a meta node",
+		Params:  []APIParam{paramNodeAddr, {Name: "id", Type: APIParamUint64, Required: true, Description: "the ID of the node"}}, Response: uint64(0)},
+	{Name: "setMetaNodeLabels", Path: AdminSetMetaNodeLabels, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Replace the labels of a meta node, which the placement labels of the volumes are matched against",
+		Params:  []APIParam{paramNodeAddr, paramNodeLabels}},
+	{Name: "metaNodeTaskResponse", Path: GetMetaNodeTaskResponse, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "Report the result of an admin task by a meta node", Body: &AdminTask{}},
+	{Name: "decommissionDryRun", Path: AdminDecommissionDryRun, Methods: apiGet, Tag: APITagNode,
+		Summary: "Report the partitions to migrate, the bytes to move, the destination candidates and the partitions dropping below the quorum if a node is decommissioned",
+		Params:  []APIParam{paramNodeAddr}, Response: &DecommissionDryRun{}},
+	{Name: "getInvalidNodes", Path: AdminGetInvalidNodes, Methods: apiGetPost, Tag: APITagNode,
+		Summary: "List the nodes whose IDs conflict with the others"},
+
+	// client
+	{Name: "getVol", Path: ClientVol, Methods: apiGetPost, Tag: APITagClient, ReadOnly: true,
+		Summary: "Get the volume to mount, the reply is encrypted by the session key if the ticket of the authnode is given",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "authKey", Type: APIParamString, Description: "the md5 of the owner of the volume"},
+			{Name: ClientMessage, Type: APIParamString, Description: "the ticket message of the authnode"},
+		},
+		Response: &VolView{}},
+	{Name: "getVolStat", Path: ClientVolStat, Methods: apiGet, Tag: APITagClient, ReadOnly: true,
+		Summary: "Get the space statistics of a volume", Params: []APIParam{paramVolName}, Response: &VolStatInfo{}},
+	{Name: "getMetaPartitions", Path: ClientMetaPartitions, Methods: apiGet, Tag: APITagClient, ReadOnly: true,
+		Summary: "Get the meta partitions of a volume in the order of ID",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "marker", Type: APIParamUint64, Description: "get the meta partitions whose IDs are greater than the marker"},
+			paramLimit,
+		},
+		Response: []*MetaPartitionView{}},
+	{Name: "getDataPartitions", Path: ClientDataPartitions, Methods: apiGet, Tag: APITagClient, ReadOnly: true,
+		Summary: "Get the data partitions of a volume in the order of ID",
+		Params: []APIParam{
+			paramVolName,
+			{Name: "marker", Type: APIParamUint64, Description: "get the data partitions whose IDs are greater than the marker"},
+			paramLimit,
+		},
+		Response: &DataPartitionsView{}},
+	{Name: "getToken", Path: TokenGetURI, Methods: apiGetPost, Tag: APITagClient,
+		Summary: "Get a mount token of a volume", Params: []APIParam{paramVolName, paramTokenValue}, Response: &Token{}},
+
+	// user
+	{Name: "createUser", Path: UserCreate, Methods: apiPost, Tag: APITagUser,
+		Summary: "Create a user", Body: &UserCreateParam{}, Response: &UserInfo{}},
+	{Name: "deleteUser", Path: UserDelete, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Delete a user", Params: []APIParam{paramUserID}},
+	{Name: "updateUser", Path: UserUpdate, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Update the keys and the type of a user", Body: &UserUpdateParam{}, Response: &UserInfo{}},
+	{Name: "updateUserPolicy", Path: UserUpdatePolicy, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Grant the permissions of a volume to a user", Body: &UserPermUpdateParam{}, Response: &UserInfo{}},
+	{Name: "removeUserPolicy", Path: UserRemovePolicy, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Revoke the permissions of a volume from a user", Body: &UserPermRemoveParam{}, Response: &UserInfo{}},
+	{Name: "deleteUserVolPolicy", Path: UserDeleteVolPolicy, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Revoke the permissions of a volume from all the users", Params: []APIParam{paramVolName}},
+	{Name: "getUserAKInfo", Path: UserGetAKInfo, Methods: apiGet, Tag: APITagUser,
+		Summary: "Get the user of an access key",
+		Params:  []APIParam{{Name: "ak", Type: APIParamString, Required: true, Description: "the access key"}}, Response: &UserInfo{}},
+	{Name: "getUserInfo", Path: UserGetInfo, Methods: apiGet, Tag: APITagUser,
+		Summary: "Get a user", Params: []APIParam{paramUserID}, Response: &UserInfo{}},
+	{Name: "listUsers", Path: UserList, Methods: apiGet, Tag: APITagUser,
+		Summary: "List the users", Params: []APIParam{paramKeywords}, Response: []*UserInfo{}},
+	{Name: "transferUserVol", Path: UserTransferVol, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Transfer a volume to another user", Body: &UserTransferVolParam{}, Response: &UserInfo{}},
+	{Name: "listUsersOfVol", Path: UsersOfVol, Methods: apiGet, Tag: APITagUser,
+		Summary: "List the users having the permissions of a volume", Params: []APIParam{paramVolName}, Response: []string{}},
+	{Name: "setUserQuota", Path: UserSetQuota, Methods: apiGetPost, Tag: APITagUser,
+		Summary: "Set the quota of a user", Body: &UserQuotaSetParam{}, Response: &UserQuotaInfo{}},
+	{Name: "getUserQuota", Path: UserGetQuota, Methods: apiGet, Tag: APITagUser,
+		Summary: "Get the quota of a user and its usage", Params: []APIParam{paramUserID}, Response: &UserQuotaInfo{}},
+
+	// monitor
+	{Name: "listEvents", Path: AdminListEvents, Methods: apiGet, Tag: APITagMonitor,
+		Summary: "List the recent events emitted by the master",
+		Params: []APIParam{
+			{Name: "since", Type: APIParamUint64, Description: "list the events after the event ID"},
+			{Name: "type", Type: APIParamString, Description: "the type of the events, all the types if empty"},
+			paramLimit,
+		},
+		Response: []*Event{}},
+	{Name: "listAuditRecords", Path: AdminListAuditRecords, Methods: apiGet, Tag: APITagMonitor,
+		Summary: "List the latest audit records of the admin calls",
+		Params: []APIParam{
+			{Name: "since", Type: APIParamInt64, Description: "list the records started since the unix time"},
+			{Name: "limit", Type: APIParamInt, Description: "the max number of the records, 0 for the default limit"},
+		},
+		Response: []*AuditRecord{}},
+	{Name: "setAlertRule", Path: AdminSetAlertRule, Methods: apiGetPost, Tag: APITagMonitor,
+		Summary: "Add an alert rule, or replace the alert rule of the same name",
+		Params: []APIParam{
+			{Name: "name", Type: APIParamString, Required: true, Description: "the name of the rule"},
+			{Name: "metric", Type: APIParamString, Required: true, Description: "the metric of the cluster to evaluate"},
+			{Name: "operator", Type: APIParamString, Required: true, Description: "the comparison operator, such as > and <="},
+			{Name: "threshold", Type: APIParamFloat64, Required: true, Description: "the threshold compared with the metric"},
+			{Name: "duration", Type: APIParamInt64, Description: "the seconds the condition holds before firing"},
+			{Name: "disabled", Type: APIParamBool, Description: "disable the rule"},
+		}},
+	{Name: "deleteAlertRule", Path: AdminDeleteAlertRule, Methods: apiGetPost, Tag: APITagMonitor,
+		Summary: "Delete an alert rule",
+		Params:  []APIParam{{Name: "name", Type: APIParamString, Required: true, Description: "the name of the rule"}}},
+	{Name: "listAlertRules", Path: AdminListAlertRules, Methods: apiGet, Tag: APITagMonitor,
+		Summary: "List the alert rules and the firing alerts", Response: &AlertRulesView{}},
+	{Name: "getAPISpec", Path: AdminAPISpec, Methods: apiGet, Tag: APITagMonitor,
+		Summary: "Get the OpenAPI document of the master APIs"},
+}
+
+// MasterAPISpecOf returns the spec of the master API of the path, nil if the path is unknown.
+func MasterAPISpecOf(path string) *APISpec {
+	for _, spec := range MasterAPISpecs {
+		if spec.Path == path {
+			return spec
+		}
+	}
+	return nil
+}
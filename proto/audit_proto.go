@@ -0,0 +1,35 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Sources of the audit records.
+const (
+	AuditSourceAPI = "api" // the admin API calls served by the master
+	AuditSourceCLI = "cli" // the mutating commands reported by the CLI
+)
+
+// AuditRecord represents an admin call which changes the cluster. The records are persisted by the masters
+// through raft, so they survive the changes of the leader.
+type AuditRecord struct {
+	Time     int64             // unix time when the call started
+	Source   string            // AuditSourceAPI or AuditSourceCLI
+	Caller   string            // the identity of the caller, such as the user and the host of the CLI
+	Remote   string            // the address the call came from
+	API      string            // the path of the admin API, or the command of the CLI
+	Params   map[string]string `json:",omitempty"` // the secret values are redacted
+	Code     int32             // the reply code of the API, or the exit code of the CLI
+	Result   string            `json:",omitempty"` // the error message, empty if the call succeeded
+	Duration int64             // milliseconds
+}
@@ -48,6 +48,7 @@ const (
 	OwnerVOLRsc     = "OwnerVOL"
 	NoneOwnerVOLRsc = "NoneOwnerVOL"
 	VOLAccess       = "*"
+	masterRoleRsc   = "role"
 )
 
 // api
@@ -198,6 +199,12 @@ const (
 
 	//Master API ClientVol
 	MsgMasterFetchVolViewReq MsgType = MsgMasterAPIAccessReq + 0x10000
+
+	//Master admin APIs, the permission is checked by the role granted in the caps
+	MsgMasterAdminAPIReq MsgType = MsgMasterAPIAccessReq + 0x20000
+
+	//Master API to fetch the data keys of the encrypted volumes, called by the data nodes
+	MsgMasterFetchVolKeysReq MsgType = MsgMasterAPIAccessReq + 0x30000
 )
 
 // HTTPAuthReply uniform response structure
@@ -222,6 +229,7 @@ var MsgType2ResourceMap = map[MsgType]string{
 	MsgAuthOSGetCapsReq:      "auth:osgetcaps",
 
 	MsgMasterFetchVolViewReq: "master:getvol",
+	MsgMasterFetchVolKeysReq: "master:getvolkeys",
 }
 
 // AuthGetTicketReq defines the message from client to authnode
@@ -466,6 +474,17 @@ func checkTicketCaps(ticket *cryptoutil.Ticket, kind string, cap string) (err er
 	return
 }
 
+// CheckMasterRoleCaps checks whether the ticket grants the role on the admin APIs of master,
+// by the API caps in the form of "master:role:<role>".
+func CheckMasterRoleCaps(ticket *cryptoutil.Ticket, role string) (err error) {
+	rule := MasterNode + capSeparator + masterRoleRsc + capSeparator + role
+	if err = checkTicketCaps(ticket, APIRsc, rule); err != nil {
+		err = fmt.Errorf("checkTicketCaps failed: %s", err.Error())
+		return
+	}
+	return
+}
+
 // ParseVerifier checks the verifier structure for replay attack mitigation
 func ParseVerifier(verifier string, key []byte) (ts int64, err error) {
 	var (
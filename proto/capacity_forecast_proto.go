@@ -0,0 +1,35 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// CapacityForecast defines the forecast of the time a volume or a zone becomes full, by the growth of the
+// used space in the recent samples.
+type CapacityForecast struct {
+	Name         string
+	Total        uint64  // bytes of the capacity of the volume, or of the data nodes of the zone
+	Used         uint64  // bytes used by the latest sample
+	GrowthPerDay float64 // bytes the used space grows per day, negative if the used space shrinks
+	DaysToFull   float64 // -1 if the used space is not growing
+	FullTime     int64   // the unix time the used space reaches the total, 0 if the used space is not growing
+	Samples      int     // the number of the samples the forecast is made from
+	Since        int64   // the unix time of the earliest sample
+}
+
+// CapacityForecastView defines the forecasts of the volumes and the zones.
+type CapacityForecastView struct {
+	Days  int // the days of the recent samples the forecasts are made from
+	Vols  []*CapacityForecast
+	Zones []*CapacityForecast
+}
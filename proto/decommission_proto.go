@@ -0,0 +1,48 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// DecommissionDryRun reports the impact of decommissioning a node, nothing is migrated by the dry run.
+type DecommissionDryRun struct {
+	Addr           string
+	NodeType       string // data or meta
+	PartitionCount int
+	MigrateBytes   uint64   // the used space of the data partitions, or the estimated memory of the meta partitions
+	BelowQuorum    []uint64 // the partitions whose live replicas drop below the quorum while the replica is moved
+	Blocked        []uint64 // the partitions which can not be decommissioned now, such as the recovering ones
+	NoCandidate    []uint64 // the partitions without any node to hold the new replica
+	Candidates     []*DecommissionCandidate
+	Partitions     []*DecommissionPartitionImpact
+}
+
+// DecommissionPartitionImpact is the impact of moving the replica of a partition off the decommissioned node.
+type DecommissionPartitionImpact struct {
+	PartitionID    uint64
+	VolName        string
+	Bytes          uint64
+	ReplicaNum     int
+	LiveReplicas   int // the live replicas except the one on the decommissioned node
+	BelowQuorum    bool
+	CandidateCount int    // the nodes the new replica may be placed on
+	Error          string // why the partition can not be decommissioned now
+}
+
+// DecommissionCandidate is a node which may hold the new replicas of the partitions moved off the decommissioned node.
+type DecommissionCandidate struct {
+	Addr       string
+	ZoneName   string
+	Avail      uint64 // the available disk space of a data node, or the available memory of a meta node
+	Partitions int    // the partitions the node is a candidate for
+}
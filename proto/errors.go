@@ -38,6 +38,8 @@ var (
 	ErrReshuffleArray         = errors.New("the array to be reshuffled is nil")
 
 	ErrIllegalDataReplica = errors.New("data replica is illegal")
+	ErrDataCorrupted      = errors.New("data does not match the crc of the extent blocks")
+	ErrExtentFrozen       = errors.New("extent is frozen by the volume snapshots")
 
 	ErrMissingReplica       = errors.New("a missing data replica is found")
 	ErrHasOneMissingReplica = errors.New("there is a missing replica")
@@ -81,6 +83,8 @@ var (
 	ErrInvalidAccessKey                = errors.New("invalid access key")
 	ErrInvalidSecretKey                = errors.New("invalid secret key")
 	ErrIsOwner                         = errors.New("user owns the volume")
+	ErrTaskNotExists                   = errors.New("task not exists")
+	ErrUserQuotaExceeded               = errors.New("user quota exceeded")
 )
 
 // http response error code and error message definitions
@@ -144,6 +148,8 @@ const (
 	ErrCodeInvalidAccessKey
 	ErrCodeInvalidSecretKey
 	ErrCodeIsOwner
+	ErrCodeTaskNotExists
+	ErrCodeUserQuotaExceeded
 )
 
 // Err2CodeMap error map to code
@@ -205,6 +211,8 @@ var Err2CodeMap = map[error]int32{
 	ErrInvalidAccessKey:                ErrCodeInvalidAccessKey,
 	ErrInvalidSecretKey:                ErrCodeInvalidSecretKey,
 	ErrIsOwner:                         ErrCodeIsOwner,
+	ErrTaskNotExists:                   ErrCodeTaskNotExists,
+	ErrUserQuotaExceeded:               ErrCodeUserQuotaExceeded,
 }
 
 func ParseErrorCode(code int32) error {
@@ -273,6 +281,8 @@ var code2ErrMap = map[int32]error{
 	ErrCodeInvalidAccessKey:                ErrInvalidAccessKey,
 	ErrCodeInvalidSecretKey:                ErrInvalidSecretKey,
 	ErrCodeIsOwner:                         ErrIsOwner,
+	ErrCodeTaskNotExists:                   ErrTaskNotExists,
+	ErrCodeUserQuotaExceeded:               ErrUserQuotaExceeded,
 }
 
 type GeneralResp struct {
@@ -0,0 +1,43 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Types of the events emitted by the master.
+const (
+	EventNodeInactive            = "NodeInactive"
+	EventPartitionLostLeader     = "PartitionLostLeader"
+	EventDecommissionFinished    = "DecommissionFinished"
+	EventDiskError               = "DiskError"
+	EventAlertFired              = "AlertFired"
+	EventAlertResolved           = "AlertResolved"
+	EventStalePartitionReclaimed = "StalePartitionReclaimed"
+	EventReplicationLagging      = "ReplicationLagging"
+	EventPartitionStatusForced   = "PartitionStatusForced"
+	EventReplicaInconsistent     = "ReplicaInconsistent"
+	EventDiskRetiring            = "DiskRetiring"
+)
+
+// Event defines a structured event emitted by the master, which is pushed to the webhooks and the kafka topics
+// configured on the master. The ID increases by event, and restarts when the leader of the masters changes.
+type Event struct {
+	ID          uint64
+	Time        int64
+	Cluster     string
+	Type        string
+	Addr        string `json:",omitempty"`
+	VolName     string `json:",omitempty"`
+	PartitionID uint64 `json:",omitempty"`
+	Message     string
+}
@@ -0,0 +1,54 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// ExpirationXAttrKey is the extend attribute which records the IDs of the expiration rules of a directory, in the
+// same format as the quota extend attribute. The subdirectories of the root directory of a rule are tagged by the
+// meta nodes when the rule is enforced, so the rule covers the directory subtree.
+const ExpirationXAttrKey = "cfs.expire"
+
+const (
+	ExpireByModifyTime = "mtime"
+	ExpireByAccessTime = "atime"
+)
+
+// ExpirationRule deletes the files in the directory subtree, or in the whole volume if the path is "/", which are
+// not modified or accessed for the days. The rules are enforced by the leaders of the meta partitions.
+type ExpirationRule struct {
+	RuleID    uint32
+	Path      string
+	RootInode uint64
+	Days      uint32
+	Basis     string // ExpireByModifyTime or ExpireByAccessTime
+}
+
+// IsValidExpirationBasis returns if the basis of the expiration rule is known.
+func IsValidExpirationBasis(basis string) bool {
+	return basis == ExpireByModifyTime || basis == ExpireByAccessTime
+}
+
+// Expired returns if the file of the times is expired by the rule at the time, all of which are unix seconds.
+func (r *ExpirationRule) Expired(modifyTime, accessTime, now int64) bool {
+	t := modifyTime
+	if r.Basis == ExpireByAccessTime {
+		t = accessTime
+	}
+	return now-t > int64(r.Days)*24*3600
+}
+
+// ExpirationRuleReply defines the reply of setting or deleting an expiration rule.
+type ExpirationRuleReply struct {
+	Rule *ExpirationRule
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// FederationPeer is a peer cluster registered on the master, whose capacity and health are shown in the
+// federation view.
+type FederationPeer struct {
+	Name    string // the name of the peer cluster
+	Region  string
+	Masters []string
+	AddTime int64
+}
+
+// FederationClusterView represents the capacity and the health of a cluster in the federation.
+type FederationClusterView struct {
+	Name              string
+	Region            string
+	Local             bool // the cluster of the master serving the view
+	Masters           []string
+	LeaderAddr        string
+	Reachable         bool
+	Error             string // the error of getting the view of the peer cluster
+	DataNodeStatInfo  *NodeStatInfo
+	MetaNodeStatInfo  *NodeStatInfo
+	Volumes           int
+	DataNodes         int
+	InactiveDataNodes int
+	MetaNodes         int
+	InactiveMetaNodes int
+	Healthy           bool
+	Issues            []string
+}
+
+// FederationView aggregates the capacity and the health of the local cluster and the peer clusters.
+type FederationView struct {
+	Clusters          []*FederationClusterView // the local cluster first, then the peers sorted by the regions and the names
+	ReachableClusters int
+	HealthyClusters   int
+	DataTotalGB       uint64 // of the reachable clusters
+	DataUsedGB        uint64
+	MetaTotalGB       uint64
+	MetaUsedGB        uint64
+	Volumes           int
+	DataNodes         int
+	MetaNodes         int
+}
@@ -228,6 +228,9 @@ type DeleteDentryRequest struct {
 	PartitionID uint64 `json:"pid"`
 	ParentID    uint64 `json:"pino"`
 	Name        string `json:"name"`
+	// the destination if the dentry is deleted by a rename, which is recorded in the change event
+	DstParentID uint64 `json:"dpino,omitempty"`
+	DstName     string `json:"dname,omitempty"`
 }
 
 type BatchDeleteDentryRequest struct {
@@ -269,6 +272,7 @@ type InodeGetRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	Inode       uint64 `json:"ino"`
+	SnapshotID  uint64 `json:"snap,omitempty"` // read the inode as of the volume snapshot if it is not 0
 }
 
 // InodeGetResponse defines the response to the InodeGetRequest.
@@ -293,19 +297,23 @@ type ReadDirRequest struct {
 	VolName     string `json:"vol"`
 	PartitionID uint64 `json:"pid"`
 	ParentID    uint64 `json:"pino"`
+	Marker      string `json:"marker,omitempty"` // the dentries after the name are read
+	Limit       uint64 `json:"limit,omitempty"`  // all the dentries are read if it is 0
 }
 
 // ReadDirResponse defines the response to the request of reading dir.
 type ReadDirResponse struct {
-	Children []Dentry `json:"children"`
+	Children   []Dentry `json:"children"`
+	NextMarker string   `json:"next,omitempty"` // the marker of the next page, empty if there is no more
 }
 
 // BatchAppendExtentKeyRequest defines the request to append an extent key.
 type AppendExtentKeyRequest struct {
-	VolName     string    `json:"vol"`
-	PartitionID uint64    `json:"pid"`
-	Inode       uint64    `json:"ino"`
-	Extent      ExtentKey `json:"ek"`
+	VolName       string    `json:"vol"`
+	PartitionID   uint64    `json:"pid"`
+	Inode         uint64    `json:"ino"`
+	Extent        ExtentKey `json:"ek"`
+	SnapshotEpoch uint64    `json:"epoch"` // the latest volume snapshot known by the client
 }
 
 // GetExtentsRequest defines the reques to get extents.
@@ -324,10 +332,11 @@ type GetExtentsResponse struct {
 
 // TruncateRequest defines the request to truncate.
 type TruncateRequest struct {
-	VolName     string `json:"vol"`
-	PartitionID uint64 `json:"pid"`
-	Inode       uint64 `json:"ino"`
-	Size        uint64 `json:"sz"`
+	VolName       string `json:"vol"`
+	PartitionID   uint64 `json:"pid"`
+	Inode         uint64 `json:"ino"`
+	Size          uint64 `json:"sz"`
+	SnapshotEpoch uint64 `json:"epoch"` // the latest volume snapshot known by the client
 }
 
 // SetAttrRequest defines the request to set attribute.
@@ -367,12 +376,20 @@ type DeleteInodeBatchRequest struct {
 
 // AppendExtentKeysRequest defines the request to append an extent key.
 type AppendExtentKeysRequest struct {
-	VolName     string      `json:"vol"`
-	PartitionId uint64      `json:"pid"`
-	Inode       uint64      `json:"ino"`
-	Extents     []ExtentKey `json:"eks"`
+	VolName       string      `json:"vol"`
+	PartitionId   uint64      `json:"pid"`
+	Inode         uint64      `json:"ino"`
+	Extents       []ExtentKey `json:"eks"`
+	SnapshotEpoch uint64      `json:"epoch"` // the latest volume snapshot known by the client
 }
 
+// The limits of the extended attributes, the ones of the names and the values are the same as Linux.
+const (
+	XAttrNameMax  = 255         // the max bytes of the name of an attribute
+	XAttrValueMax = 64 * 1024   // the max bytes of the value of an attribute
+	XAttrTotalMax = 1024 * 1024 // the max bytes of the names and the values of all the attributes of an inode
+)
+
 type SetXAttrRequest struct {
 	VolName     string `json:"vol"`
 	PartitionId uint64 `json:"pid"`
@@ -394,6 +411,7 @@ type GetXAttrResponse struct {
 	Inode       uint64 `json:"ino"`
 	Key         string `json:"key"`
 	Value       string `json:"val"`
+	Exist       bool   `json:"exist"` // false if the inode has no such attribute
 }
 
 type RemoveXAttrRequest struct {
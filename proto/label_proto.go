@@ -0,0 +1,71 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The labels are attached to the meta nodes and the data nodes by the admin, such as disk=nvme or gen=2023. The
+// placement labels of a volume restrict the replicas of its partitions to the nodes having all of them. Both are
+// passed as the comma separated key=value pairs.
+
+var labelRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.\-/]*$`)
+
+// ParseLabels parses the comma separated key=value pairs, an empty string is parsed as no labels.
+func ParseLabels(value string) (labels map[string]string, err error) {
+	labels = make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("label[%v] is not in the form of key=value", pair)
+		}
+		key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if !labelRegexp.MatchString(key) || !labelRegexp.MatchString(val) {
+			return nil, fmt.Errorf("label[%v] has invalid characters, only letters, digits, '_', '.', '-' and '/' are allowed", pair)
+		}
+		if _, ok := labels[key]; ok {
+			return nil, fmt.Errorf("label key[%v] is duplicated", key)
+		}
+		labels[key] = val
+	}
+	return
+}
+
+// FormatLabels formats the labels as the comma separated key=value pairs sorted by the keys.
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, val := range labels {
+		pairs = append(pairs, key+"="+val)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// MatchLabels returns whether the labels have all the constraints.
+func MatchLabels(labels, constraints map[string]string) bool {
+	for key, val := range constraints {
+		if v, ok := labels[key]; !ok || v != val {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The storage classes of the files. The extents of the replica class are stored raw and may be cached by the cache
+// disks of the data nodes, the extents of the cold class are moved out of the cache disks and compressed at rest.
+// Both classes are kept by the replicas of the data partitions, erasure coding is not supported.
+const (
+	StorageClassReplica = "replica"
+	StorageClassCold    = "cold"
+)
+
+// IsValidStorageClass returns if the storage class is known.
+func IsValidStorageClass(class string) bool {
+	return class == StorageClassReplica || class == StorageClassCold
+}
+
+// LifecycleXAttrKey is the extend attribute which records the IDs of the lifecycle rules of a directory, in the
+// same format as the expiration extend attribute.
+const LifecycleXAttrKey = "cfs.lifecycle"
+
+// StorageClassXAttrKey is the extend attribute of a file which records its storage class and its modify time when
+// it is transited, so that a file modified after the transition is transited again.
+const StorageClassXAttrKey = "cfs.storage_class"
+
+// FormatStorageClassXAttr returns the value of the storage class extend attribute.
+func FormatStorageClassXAttr(class string, modifyTime int64) string {
+	return fmt.Sprintf("%v:%v", class, modifyTime)
+}
+
+// ParseStorageClassXAttr parses the value of the storage class extend attribute, the class is StorageClassReplica
+// if the value is empty or malformed.
+func ParseStorageClassXAttr(value string) (class string, modifyTime int64) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || !IsValidStorageClass(parts[0]) {
+		return StorageClassReplica, 0
+	}
+	modifyTime, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return StorageClassReplica, 0
+	}
+	return parts[0], modifyTime
+}
+
+// LifecycleRule transits the files in the directory subtree, or in the whole volume if the path is "/", which are
+// not modified or accessed for the days to the storage class. The rules are enforced by the leaders of the meta
+// partitions, and the extents are converted by the data nodes.
+type LifecycleRule struct {
+	RuleID       uint32
+	Path         string
+	RootInode    uint64
+	Days         uint32
+	Basis        string // ExpireByModifyTime or ExpireByAccessTime
+	StorageClass string
+}
+
+// Due returns if the file of the times is due to be transited by the rule at the time, all of which are unix
+// seconds.
+func (r *LifecycleRule) Due(modifyTime, accessTime, now int64) bool {
+	t := modifyTime
+	if r.Basis == ExpireByAccessTime {
+		t = accessTime
+	}
+	return now-t >= int64(r.Days)*24*3600
+}
+
+// LifecycleRuleReply defines the reply of setting or deleting a lifecycle rule.
+type LifecycleRuleReply struct {
+	Rule *LifecycleRule
+}
+
+// TransitExtentsRequest asks a replica of the data partition to convert the extents to the storage class.
+type TransitExtentsRequest struct {
+	PartitionID  uint64
+	ExtentIDs    []uint64
+	StorageClass string
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The advisory locks of the files are kept by the meta partitions of the inodes, so that the flock and the
+// fcntl locks taken through a mount point are honored by all the mount points of the volume. The locks are
+// leased by the mount points which renew them periodically, the locks of a dead mount point expire after
+// the lease.
+
+// The types of the file locks, which are the same as Linux.
+const (
+	LockTypeRead   uint32 = 0
+	LockTypeWrite  uint32 = 1
+	LockTypeUnlock uint32 = 2
+)
+
+const (
+	DefaultLockLease = 30 // in seconds
+	LockEndMax       = uint64(1<<63 - 1)
+)
+
+// FileLock defines a lock of the byte range of a file, the flock locks cover the whole files.
+type FileLock struct {
+	Session string `json:"session"` // the mount point holding the lock
+	Owner   uint64 `json:"owner"`   // the lock owner in the mount point
+	Pid     uint32 `json:"pid"`
+	Start   uint64 `json:"start"`
+	End     uint64 `json:"end"` // inclusive
+	Type    uint32 `json:"type"`
+	Flock   bool   `json:"flock"`
+	Expire  int64  `json:"expire"` // unix time in nanoseconds, set by the meta node
+}
+
+// FileLocks defines the locks of a file.
+type FileLocks []*FileLock
+
+func (l *FileLock) sameOwner(o *FileLock) bool {
+	return l.Session == o.Session && l.Owner == o.Owner && l.Flock == o.Flock
+}
+
+func (l *FileLock) overlaps(o *FileLock) bool {
+	return l.Start <= o.End && o.Start <= l.End
+}
+
+// Conflicts checks if the lock conflicts with the other one. The flock locks and the fcntl locks do not
+// conflict with each other, and the read locks are shared.
+func (l *FileLock) Conflicts(o *FileLock) bool {
+	if l.Flock != o.Flock || l.sameOwner(o) || !l.overlaps(o) {
+		return false
+	}
+	return l.Type == LockTypeWrite || o.Type == LockTypeWrite
+}
+
+// Same checks if the locks are held by the same owner on the same range with the same type.
+func (l *FileLock) Same(o *FileLock) bool {
+	return l.sameOwner(o) && l.Start == o.Start && l.End == o.End && l.Type == o.Type
+}
+
+// Conflict returns the first unexpired lock conflicting with the given one, nil if there is none.
+func (locks FileLocks) Conflict(lock *FileLock, now int64) *FileLock {
+	for _, l := range locks {
+		if l.Expire > now && l.Conflicts(lock) {
+			return l
+		}
+	}
+	return nil
+}
+
+// Set returns the locks after the owner of the given lock locks or unlocks the range. The existing locks of
+// the owner on the range are replaced, and the ones partially covered by the range are split.
+func (locks FileLocks) Set(lock *FileLock) FileLocks {
+	result := make(FileLocks, 0, len(locks)+2)
+	for _, l := range locks {
+		if !l.sameOwner(lock) || !l.overlaps(lock) {
+			result = append(result, l)
+			continue
+		}
+		if l.Start < lock.Start {
+			head := *l
+			head.End = lock.Start - 1
+			result = append(result, &head)
+		}
+		if l.End > lock.End {
+			tail := *l
+			tail.Start = lock.End + 1
+			result = append(result, &tail)
+		}
+	}
+	if lock.Type != LockTypeUnlock {
+		result = append(result, lock)
+	}
+	return result
+}
+
+// Expire returns the unexpired locks.
+func (locks FileLocks) Expire(now int64) FileLocks {
+	result := locks[:0]
+	for _, l := range locks {
+		if l.Expire > now {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// SetLockRequest defines the request to lock or unlock a range of a file.
+type SetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionID uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+	Lease       int64    `json:"lease"` // in seconds
+	Now         int64    `json:"now"`   // set by the leader, the replicas expire the locks by it
+}
+
+// GetLockRequest defines the request to query the lock conflicting with the given one.
+type GetLockRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionID uint64   `json:"pid"`
+	Inode       uint64   `json:"ino"`
+	Lock        FileLock `json:"lock"`
+}
+
+// GetLockResponse defines the response of the lock query, the lock is nil if there is no conflict.
+type GetLockResponse struct {
+	Lock *FileLock `json:"lock"`
+}
+
+// RenewLockItem defines the locks held by a mount point on an inode.
+type RenewLockItem struct {
+	Inode uint64    `json:"ino"`
+	Locks FileLocks `json:"locks"`
+}
+
+// RenewLockRequest defines the request to renew the leases of the locks held by a mount point. The locks which
+// are lost by the meta partition, e.g. by a restart, are taken again if they do not conflict with others.
+type RenewLockRequest struct {
+	VolName     string          `json:"vol"`
+	PartitionID uint64          `json:"pid"`
+	Session     string          `json:"session"`
+	Items       []RenewLockItem `json:"items"`
+	Lease       int64           `json:"lease"` // in seconds
+	Now         int64           `json:"now"`   // set by the leader
+}
+
+// InodeLocks defines the locks of an inode.
+type InodeLocks struct {
+	Inode uint64    `json:"ino"`
+	Locks FileLocks `json:"locks"`
+}
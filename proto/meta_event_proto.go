@@ -0,0 +1,61 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The meta partitions record the changes of the metadata as events when they are applied, the events are kept in
+// the memory for a while and read by the watchers by the sequences, which are the raft indexes of the changes.
+// A rename is recorded as a create event of the new dentry followed by a rename event of the old one.
+
+// The types of the change events.
+const (
+	MetaEventCreate  = "create"  // a dentry is created
+	MetaEventDelete  = "delete"  // a dentry is deleted
+	MetaEventRename  = "rename"  // a dentry is deleted since it is renamed to the destination
+	MetaEventReplace = "replace" // a dentry is pointed to another inode by a rename
+	MetaEventAttr    = "attr"    // the attributes of an inode are changed
+	MetaEventModify  = "modify"  // the data of a file is changed
+	MetaEventXAttr   = "xattr"   // the extended attributes of an inode are changed
+	MetaEventLost    = "lost"    // the events of the meta partition are lost, the watcher should rescan the metadata
+)
+
+// MetaEvent defines a change of the metadata of a meta partition.
+type MetaEvent struct {
+	PartitionID uint64 `json:"pid"`
+	Seq         uint64 `json:"seq"`
+	Time        int64  `json:"time"` // the unix time when the change is applied
+	Type        string `json:"type"`
+	ParentID    uint64 `json:"pino,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Inode       uint64 `json:"ino"`
+	Mode        uint32 `json:"mode,omitempty"`
+	DstParentID uint64 `json:"dpino,omitempty"`
+	DstName     string `json:"dname,omitempty"`
+}
+
+// GetEventsRequest defines the request to read the events after the sequence.
+type GetEventsRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	From        uint64 `json:"from"`
+	Limit       int    `json:"limit"`
+}
+
+// GetEventsResponse defines the events after the sequence. The events after the base are complete, the ones
+// before it are dropped, and there is no more event before the applied index if the events are not limited.
+type GetEventsResponse struct {
+	Base    uint64       `json:"base"`
+	Applied uint64       `json:"applied"`
+	Events  []*MetaEvent `json:"events"`
+}
@@ -0,0 +1,44 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// MetaNodeMemory is the memory used by a meta node, broken down by the meta partitions.
+type MetaNodeMemory struct {
+	HeapAlloc  uint64 // the bytes of the allocated heap objects of the process
+	HeapSys    uint64 // the bytes of the heap obtained from the OS
+	Total      uint64 // the estimated bytes of all the partitions
+	Partitions []*MetaPartitionMemory
+}
+
+// MetaPartitionMemory is the estimated memory used by a meta partition on a meta node. The inodes and the dentries
+// of a partition with the rocksdb store are counted only if they are cached in the memory.
+type MetaPartitionMemory struct {
+	PartitionID uint64
+	VolName     string
+	MetaStore   string
+	InodeCount  int    // the inodes in the memory
+	DentryCount int    // the dentries in the memory
+	InodeTree   uint64 // the inodes except their extent keys
+	DentryTree  uint64
+	Extents     uint64 // the extent keys of the inodes
+	Extend      uint64 // the extended attributes
+	Multipart   uint64 // the sessions of the multipart uploads
+	RaftLog     uint64 // the raft log entries not applied yet
+}
+
+// Total returns the estimated bytes used by the partition.
+func (m *MetaPartitionMemory) Total() uint64 {
+	return m.InodeTree + m.DentryTree + m.Extents + m.Extend + m.Multipart + m.RaftLog
+}
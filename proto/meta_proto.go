@@ -25,10 +25,23 @@ type CreateNameSpaceResponse struct {
 	Result string
 }
 
-// Peer defines the peer of the node id and address.
+// Peer defines the peer of the node id and address. A learner replicates the raft logs without voting until it
+// is promoted.
 type Peer struct {
-	ID   uint64 `json:"id"`
-	Addr string `json:"addr"`
+	ID        uint64 `json:"id"`
+	Addr      string `json:"addr"`
+	IsLearner bool   `json:"isLearner,omitempty"`
+}
+
+// The stores of the inodes and the dentries of the meta partitions.
+const (
+	MetaStoreMemory  = "memory"  // all the inodes and the dentries are kept in the memory
+	MetaStoreRocksDB = "rocksdb" // the inodes and the dentries are kept in the RocksDB with a cache in the memory
+)
+
+// IsValidMetaStore returns whether the store of the meta partitions is known, empty means the default of the meta node.
+func IsValidMetaStore(store string) bool {
+	return store == "" || store == MetaStoreMemory || store == MetaStoreRocksDB
 }
 
 // CreateMetaPartitionRequest defines the request to create a meta partition.
@@ -39,6 +52,9 @@ type CreateMetaPartitionRequest struct {
 	End         uint64
 	PartitionID uint64
 	Members     []Peer
+	MetaStore   string // the store of the partition, empty means the default of the meta node
+	// the names of the dentries are looked up case-insensitively but preserved
+	CaseInsensitive bool
 }
 
 // CreateMetaPartitionResponse defines the response to the request of creating a meta partition.
@@ -48,3 +64,34 @@ type CreateMetaPartitionResponse struct {
 	Status      uint8
 	Result      string
 }
+
+// MetaPartitionExportHeader defines the header of the snapshot exported from a meta partition.
+type MetaPartitionExportHeader struct {
+	Version     uint32
+	VolName     string
+	PartitionID uint64
+	Start       uint64
+	End         uint64
+	Cursor      uint64
+	ApplyID     uint64 // the snapshot is consistent at the raft index
+	InodeCount  uint64
+	DentryCount uint64
+}
+
+// MetaPartitionImportResult defines the items imported into a meta partition.
+type MetaPartitionImportResult struct {
+	Inodes     uint64
+	Dentries   uint64
+	Extends    uint64
+	Multiparts uint64
+}
+
+// DeletedInode defines an inode deleted but not purged yet by a meta partition.
+type DeletedInode struct {
+	Inode      uint64 `json:"ino"`
+	Size       uint64 `json:"size"`
+	Evicted    bool   `json:"evicted"`    // no client opens the inode any more
+	Held       bool   `json:"held"`       // the inode is kept until it is linked again or purged by force
+	DeleteTime int64  `json:"deleteTime"` // the time when the last link of the inode is removed
+	PurgeTime  int64  `json:"purgeTime"`  // the time after which the inode is purged, 0 if it is held
+}
@@ -0,0 +1,24 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// MetadataBackupInfo defines a backup of the metadata of the masters in the object store.
+type MetadataBackupInfo struct {
+	Key     string // the key of the object in the bucket
+	Cluster string
+	Time    int64  // the unix time the backup was taken
+	Applied uint64 // the raft index of the masters applied by the backup
+	Size    int64  // the bytes of the object
+}
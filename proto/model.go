@@ -28,6 +28,7 @@ type MetaNodeInfo struct {
 	Addr                      string
 	IsActive                  bool
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	MaxMemAvailWeight         uint64 `json:"MaxMemAvailWeight"`
 	Total                     uint64 `json:"TotalWeight"`
 	Used                      uint64 `json:"UsedWeight"`
@@ -39,6 +40,9 @@ type MetaNodeInfo struct {
 	MetaPartitionCount        int
 	NodeSetID                 uint64
 	PersistenceMetaPartitions []uint64
+	HeartbeatLatency          float64 // moving average of the seconds the heartbeats are replied in
+	HealthScore               float64 // 0 to 100 by the heartbeat latency and the load, 0 if the node is inactive
+	Labels                    map[string]string
 }
 
 // DataNode stores all the information about a data node
@@ -48,6 +52,7 @@ type DataNodeInfo struct {
 	AvailableSpace            uint64
 	ID                        uint64
 	ZoneName                  string `json:"Zone"`
+	RackName                  string `json:"Rack"`
 	Addr                      string
 	ReportTime                time.Time
 	IsActive                  bool
@@ -59,6 +64,11 @@ type DataNodeInfo struct {
 	NodeSetID                 uint64
 	PersistenceDataPartitions []uint64
 	BadDisks                  []string
+	HeartbeatLatency          float64 // moving average of the seconds the heartbeats are replied in
+	HealthScore               float64 // 0 to 100 by the heartbeat latency, the bad disks and the load, 0 if the node is inactive
+	Labels                    map[string]string
+	DiskSmarts                []*DiskSmart
+	RetiringDisks             map[string]string // reasons of the disks retired by the master by their paths
 }
 
 // MetaPartition defines the structure of a meta partition
@@ -111,10 +121,13 @@ type ClusterView struct {
 
 // NodeView provides the view of the data or meta node.
 type NodeView struct {
-	Addr       string
-	Status     bool
-	ID         uint64
-	IsWritable bool
+	Addr        string
+	Status      bool
+	ID          uint64
+	IsWritable  bool
+	ZoneName    string
+	RackName    string
+	HealthScore float64
 }
 
 type BadPartitionView struct {
@@ -172,6 +185,9 @@ type DataPartitionInfo struct {
 	OfflinePeerID           uint64
 	FileInCoreMap           map[string]*FileInCore
 	FilesWithMissingReplica map[string]int64 // key: file name, value: last time when a missing replica is found
+	ForcedStatus            int8             // the status forced by the admin, 0 if the status is decided by the master
+	ForceReason             string
+	ForceTime               int64
 }
 
 //FileInCore define file in data partition
@@ -200,14 +216,74 @@ type DataReplica struct {
 	IsLeader        bool
 	NeedsToCompare  bool
 	DiskPath        string
+	ApplyID         uint64
+	CorruptExtents  []uint64 // extents with the corrupt blocks found by the scrubber and not repaired
+	// the compressed extents, the size of their data and the size of their files
+	CompressedExtents int
+	CompressedRawSize uint64
+	CompressedSize    uint64
+	KeyVersion        uint32 // version of the key the new extents are encrypted with, 0 if they are not encrypted
+	StaleKeyExtents   int    // extents not encrypted by the current key yet
 }
 
 // data partition diagnosis represents the inactive data nodes, corrupt data partitions, and data partitions lack of replicas
 type DataPartitionDiagnosis struct {
+	InactiveDataNodes            []string
+	CorruptDataPartitionIDs      []uint64
+	LackReplicaDataPartitionIDs  []uint64
+	BadDataPartitionIDs          []BadPartitionView
+	RackRiskDataPartitionIDs     []uint64 // partitions whose majority of replicas are in the same rack
+	ForcedStatusDataPartitionIDs []uint64 // partitions whose status is forced by the admin
+}
+
+// ClusterHealth represents the health summary of the cluster, which is degraded if any issue is found.
+type ClusterHealth struct {
+	Healthy                     bool
+	Issues                      []string // summary of each kind of issue found
 	InactiveDataNodes           []string
+	InactiveMetaNodes           []string
 	CorruptDataPartitionIDs     []uint64
 	LackReplicaDataPartitionIDs []uint64
-	BadDataPartitionIDs         []BadPartitionView
+	CorruptMetaPartitionIDs     []uint64
+	LackReplicaMetaPartitionIDs []uint64
+	BadDisks                    []BadDiskView
+	RetiringDisks               []BadDiskView // disks retired for the failure predicted by their SMART attributes
+	RaftLagReplicas             []RaftLagView
+	MaxRaftLag                  uint64  // the followers lagging behind the leader more than it are reported
+	DataUsedRatio               float64 // used ratio of the disk space of all data nodes
+	MetaUsedRatio               float64 // used ratio of the memory of all meta nodes
+	CapacityThreshold           float64 // the cluster and the nodes whose used ratio exceeds it are reported
+	FullDataNodes               []string
+	FullMetaNodes               []string
+}
+
+// BadDiskView represents a disk with errors reported by a data node.
+type BadDiskView struct {
+	Addr string
+	Path string
+}
+
+// RaftLagView represents a follower replica whose applied index lags behind the leader.
+type RaftLagView struct {
+	PartitionType string
+	PartitionID   uint64
+	Addr          string
+	LeaderApplyID uint64
+	ApplyID       uint64
+}
+
+// CliAuditRecord represents a mutating invocation of the CLI.
+type CliAuditRecord struct {
+	Time     int64  // unix time when the command started
+	User     string // the operating system user who ran the command
+	Host     string // the host name where the command ran
+	Profile  string
+	Cluster  []string // the master addresses of the target cluster
+	Command  string
+	Args     []string
+	ExitCode int
+	Result   string // the error message, empty if the command succeeded
+	Duration int64  // milliseconds
 }
 
 // meta partition diagnosis represents the inactive meta nodes, corrupt meta partitions, and meta partitions lack of replicas
@@ -216,4 +292,15 @@ type MetaPartitionDiagnosis struct {
 	CorruptMetaPartitionIDs     []uint64
 	LackReplicaMetaPartitionIDs []uint64
 	BadMetaPartitionIDs         []BadPartitionView
+	PeerInconsistentPartitions  []PeerInconsistentPartition
+	RackRiskMetaPartitionIDs    []uint64 // partitions whose majority of replicas are in the same rack
+}
+
+// PeerInconsistentPartition defines a meta partition whose raft peers are inconsistent with its hosts
+type PeerInconsistentPartition struct {
+	PartitionID  uint64
+	VolName      string
+	Hosts        []string
+	Peers        []string            // the raft peers recorded by master
+	ReplicaPeers map[string][]string // the raft peers reported by the replicas, which are inconsistent with the hosts
 }
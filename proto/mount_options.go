@@ -47,6 +47,10 @@ const (
 	EnableXattr
 	NearRead
 	EnablePosixACL
+	EnableFileLock
+	EnableSummary
+	EnableTransaction
+	ReadCrc
 
 	MaxMountOption
 )
@@ -112,6 +116,10 @@ func InitMountOptions(opts []MountOption) {
 	opts[MaxCPUs] = MountOption{"maxcpus", "The maximum number of CPUs that can be executing", "", int64(-1)}
 	opts[EnableXattr] = MountOption{"enableXattr", "Enable xattr support", "", false}
 	opts[EnablePosixACL] = MountOption{"enablePosixACL", "enable posix ACL support", "", false}
+	opts[EnableFileLock] = MountOption{"enableFileLock", "Enable flock and fcntl locks across the mount points", "", false}
+	opts[EnableSummary] = MountOption{"enableSummary", "Maintain the summaries of the directories", "", false}
+	opts[EnableTransaction] = MountOption{"enableTransaction", "Rename across the meta partitions atomically", "", false}
+	opts[ReadCrc] = MountOption{"readCrc", "Check the crc of all the data read, no read is sent by zero copy", "", false}
 
 	for i := 0; i < MaxMountOption; i++ {
 		flag.StringVar(&opts[i].cmdlineValue, opts[i].keyword, "", opts[i].description)
@@ -209,36 +217,40 @@ func (opt *MountOption) GetInt64() int64 {
 }
 
 type MountOptions struct {
-	Config         *config.Config
-	MountPoint     string
-	Volname        string
-	Owner          string
-	Master         string
-	Logpath        string
-	Loglvl         string
-	Profport       string
-	IcacheTimeout  int64
-	LookupValid    int64
-	AttrValid      int64
-	ReadRate       int64
-	WriteRate      int64
-	EnSyncWrite    int64
-	AutoInvalData  int64
-	UmpDatadir     string
-	Rdonly         bool
-	WriteCache     bool
-	KeepCache      bool
-	FollowerRead   bool
-	Authenticate   bool
-	TicketMess     auth.TicketMess
-	TokenKey       string
-	AccessKey      string
-	SecretKey      string
-	DisableDcache  bool
-	SubDir         string
-	FsyncOnClose   bool
-	MaxCPUs        int64
-	EnableXattr    bool
-	NearRead       bool
-	EnablePosixACL bool
+	Config            *config.Config
+	MountPoint        string
+	Volname           string
+	Owner             string
+	Master            string
+	Logpath           string
+	Loglvl            string
+	Profport          string
+	IcacheTimeout     int64
+	LookupValid       int64
+	AttrValid         int64
+	ReadRate          int64
+	WriteRate         int64
+	EnSyncWrite       int64
+	AutoInvalData     int64
+	UmpDatadir        string
+	Rdonly            bool
+	WriteCache        bool
+	KeepCache         bool
+	FollowerRead      bool
+	Authenticate      bool
+	TicketMess        auth.TicketMess
+	TokenKey          string
+	AccessKey         string
+	SecretKey         string
+	DisableDcache     bool
+	SubDir            string
+	FsyncOnClose      bool
+	MaxCPUs           int64
+	EnableXattr       bool
+	NearRead          bool
+	EnablePosixACL    bool
+	EnableFileLock    bool
+	EnableSummary     bool
+	EnableTransaction bool
+	ReadCrc           bool
 }
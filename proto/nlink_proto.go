@@ -0,0 +1,63 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// CountInodeLinksRequest defines the request to count the dentries of a meta partition which link to the inodes in
+// the range, or whose parents are in the range. The dentries after the marker are scanned in the order of their
+// parents and names.
+type CountInodeLinksRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	Start       uint64 `json:"start"`
+	End         uint64 `json:"end"`
+	ParentID    uint64 `json:"pino"` // the marker
+	Name        string `json:"name"`
+	Limit       int    `json:"limit"` // the max number of the dentries scanned
+}
+
+// CountInodeLinksResponse defines the numbers of the dentries linking to the inodes and the children of the
+// directories in a page of the dentries, and the marker of the next page.
+type CountInodeLinksResponse struct {
+	Links    map[uint64]uint32 `json:"links"`
+	Children map[uint64]uint32 `json:"children"`
+	Scanned  int               `json:"scanned"`
+	ParentID uint64            `json:"pino"`
+	Name     string            `json:"name"`
+	Done     bool              `json:"done"` // all the dentries are scanned
+}
+
+// NLinkMismatch defines an inode whose link count differs from the dentries. The expected link count of a file is
+// the number of the dentries linking to it, and the one of a directory is 2 plus the number of its children.
+type NLinkMismatch struct {
+	Inode    uint64 `json:"ino"`
+	Mode     uint32 `json:"mode"`
+	NLink    uint32 `json:"nlink"`
+	Expected uint32 `json:"expected"`
+	Fixed    bool   `json:"fixed"`
+}
+
+// NLinkCheckReport defines the result of the latest link count check of a meta partition.
+type NLinkCheckReport struct {
+	PartitionID uint64           `json:"pid"`
+	Fix         bool             `json:"fix"`   // the mismatches are fixed, or reported only
+	Limit       int              `json:"limit"` // the max number of the dentries scanned per second
+	StartTime   int64            `json:"start"`
+	EndTime     int64            `json:"end"`      // 0 if the check is running
+	Inodes      uint64           `json:"inodes"`   // the inodes checked
+	Dentries    uint64           `json:"dentries"` // the dentries scanned in all the meta partitions of the volume
+	Suspects    int              `json:"suspects"` // the mismatches found by the first pass
+	Mismatches  []*NLinkMismatch `json:"mismatches"`
+	Error       string           `json:"error"`
+}
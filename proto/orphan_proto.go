@@ -0,0 +1,50 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// GetReferencedInodesRequest defines the request to check which of the inodes are referenced by the dentries or
+// the multipart uploads of a meta partition.
+type GetReferencedInodesRequest struct {
+	VolName     string   `json:"vol"`
+	PartitionID uint64   `json:"pid"`
+	Inodes      []uint64 `json:"inos"`
+}
+
+// GetReferencedInodesResponse defines the inodes referenced by the meta partition.
+type GetReferencedInodesResponse struct {
+	Inodes []uint64 `json:"inos"`
+}
+
+// OrphanInode defines an inode which is not referenced by any dentry of the volume.
+type OrphanInode struct {
+	Inode      uint64 `json:"ino"`
+	Mode       uint32 `json:"mode"`
+	NLink      uint32 `json:"nlink"`
+	Size       uint64 `json:"size"`
+	CreateTime int64  `json:"ctime"`
+	Purged     bool   `json:"purged"` // the directories are reported only
+}
+
+// OrphanReport defines the result of the latest orphan inode scan of a meta partition.
+type OrphanReport struct {
+	PartitionID uint64         `json:"pid"`
+	StartTime   int64          `json:"start"`
+	EndTime     int64          `json:"end"`
+	Scanned     uint64         `json:"scanned"`  // the inodes scanned
+	Requeued    uint64         `json:"requeued"` // the deleted inodes put back to the free list
+	Suspects    uint64         `json:"suspects"` // the unreferenced inodes to be confirmed by the next scan
+	Orphans     []*OrphanInode `json:"orphans"`
+	Error       string         `json:"error"`
+}
@@ -98,17 +98,40 @@ const (
 	OpMetaRemoveXAttr     uint8 = 0x37
 	OpMetaListXAttr       uint8 = 0x38
 	OpMetaBatchGetXAttr   uint8 = 0x39
+	OpMetaSetLock         uint8 = 0x3A
+	OpMetaGetLock         uint8 = 0x3B
+	OpMetaRenewLock       uint8 = 0x3C
+	OpMetaUpdateSummary   uint8 = 0x3D
+	OpMetaGetEvents       uint8 = 0x3E
+
+	//Operations: MetaNode Leader -> MetaNode Leader
+	OpMetaGetReferencedInodes uint8 = 0x3F
+	OpMetaCountInodeLinks     uint8 = 0x4E
+
+	//Operations: MetaNode Leader -> MetaNode Replicas
+	OpMetaGetReplicaChecksum uint8 = 0x4F
 
 	// Operations: Master -> MetaNode
-	OpCreateMetaPartition           uint8 = 0x40
-	OpMetaNodeHeartbeat             uint8 = 0x41
-	OpDeleteMetaPartition           uint8 = 0x42
-	OpUpdateMetaPartition           uint8 = 0x43
-	OpLoadMetaPartition             uint8 = 0x44
-	OpDecommissionMetaPartition     uint8 = 0x45
-	OpAddMetaPartitionRaftMember    uint8 = 0x46
-	OpRemoveMetaPartitionRaftMember uint8 = 0x47
-	OpMetaPartitionTryToLeader      uint8 = 0x48
+	OpCreateMetaPartition            uint8 = 0x40
+	OpMetaNodeHeartbeat              uint8 = 0x41
+	OpDeleteMetaPartition            uint8 = 0x42
+	OpUpdateMetaPartition            uint8 = 0x43
+	OpLoadMetaPartition              uint8 = 0x44
+	OpDecommissionMetaPartition      uint8 = 0x45
+	OpAddMetaPartitionRaftMember     uint8 = 0x46
+	OpRemoveMetaPartitionRaftMember  uint8 = 0x47
+	OpMetaPartitionTryToLeader       uint8 = 0x48
+	OpResetMetaPartitionRaftMember   uint8 = 0x49
+	OpMetaPartitionSnapshot          uint8 = 0x4A
+	OpPromoteMetaPartitionRaftMember uint8 = 0x4B
+
+	// Operations: transactions among the meta partitions
+	OpMetaTxRename    uint8 = 0x50 // Client -> MetaNode
+	OpMetaTxPrepare   uint8 = 0x51
+	OpMetaTxCommit    uint8 = 0x52
+	OpMetaTxAbort     uint8 = 0x53
+	OpMetaTxGetStatus uint8 = 0x54
+	OpMetaTxStart     uint8 = 0x55 // Client -> MetaNode
 
 	// Operations: Master -> DataNode
 	OpCreateDataPartition           uint8 = 0x60
@@ -121,6 +144,11 @@ const (
 	OpAddDataPartitionRaftMember    uint8 = 0x67
 	OpRemoveDataPartitionRaftMember uint8 = 0x68
 	OpDataPartitionTryToLeader      uint8 = 0x69
+	OpResetDataPartitionRaftMember  uint8 = 0x6A
+	OpDataPartitionSnapshot         uint8 = 0x6B
+
+	// Operations: MetaNode -> DataNode
+	OpTransitExtents uint8 = 0x6C
 
 	// Operations: MultipartInfo
 	OpCreateMultipart  uint8 = 0x70
@@ -150,6 +178,8 @@ const (
 	OpTryOtherAddr     uint8 = 0xFC
 	OpNotPerm          uint8 = 0xFD
 	OpNotEmtpy         uint8 = 0xFE
+	OpStaleEpochErr    uint8 = 0xF1 // the request is stamped with an epoch older than the latest volume snapshot
+	OpFrozenErr        uint8 = 0xF2 // the extent is frozen by the volume snapshots and not overwritten in place
 	OpOk               uint8 = 0xF0
 
 	OpPing uint8 = 0xFF
@@ -169,6 +199,11 @@ const (
 	NormalExtentType = 1
 )
 
+// ReadFlagZeroCopy is set in the first byte of the arg of a stream read if the client does not need the crc of the
+// data, so the data nodes may send the data from the extent file to the connection by zero copy. It is set in the
+// replies sent so as well, of which the crc is not set.
+const ReadFlagZeroCopy uint8 = 1 << 0
+
 const (
 	NormalCreateDataPartition         = 0
 	DecommissionedCreateDataPartition = 1
@@ -351,8 +386,20 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpRemoveMetaPartitionRaftMember"
 	case OpMetaPartitionTryToLeader:
 		m = "OpMetaPartitionTryToLeader"
+	case OpResetMetaPartitionRaftMember:
+		m = "OpResetMetaPartitionRaftMember"
+	case OpMetaPartitionSnapshot:
+		m = "OpMetaPartitionSnapshot"
+	case OpPromoteMetaPartitionRaftMember:
+		m = "OpPromoteMetaPartitionRaftMember"
 	case OpDataPartitionTryToLeader:
 		m = "OpDataPartitionTryToLeader"
+	case OpResetDataPartitionRaftMember:
+		m = "OpResetDataPartitionRaftMember"
+	case OpDataPartitionSnapshot:
+		m = "OpDataPartitionSnapshot"
+	case OpTransitExtents:
+		m = "OpTransitExtents"
 	case OpMetaDeleteInode:
 		m = "OpMetaDeleteInode"
 	case OpMetaBatchDeleteInode:
@@ -369,6 +416,34 @@ func (p *Packet) GetOpMsg() (m string) {
 		m = "OpMetaListXAttr"
 	case OpMetaBatchGetXAttr:
 		m = "OpMetaBatchGetXAttr"
+	case OpMetaSetLock:
+		m = "OpMetaSetLock"
+	case OpMetaGetLock:
+		m = "OpMetaGetLock"
+	case OpMetaRenewLock:
+		m = "OpMetaRenewLock"
+	case OpMetaUpdateSummary:
+		m = "OpMetaUpdateSummary"
+	case OpMetaGetEvents:
+		m = "OpMetaGetEvents"
+	case OpMetaGetReferencedInodes:
+		m = "OpMetaGetReferencedInodes"
+	case OpMetaGetReplicaChecksum:
+		m = "OpMetaGetReplicaChecksum"
+	case OpMetaCountInodeLinks:
+		m = "OpMetaCountInodeLinks"
+	case OpMetaTxRename:
+		m = "OpMetaTxRename"
+	case OpMetaTxPrepare:
+		m = "OpMetaTxPrepare"
+	case OpMetaTxCommit:
+		m = "OpMetaTxCommit"
+	case OpMetaTxAbort:
+		m = "OpMetaTxAbort"
+	case OpMetaTxGetStatus:
+		m = "OpMetaTxGetStatus"
+	case OpMetaTxStart:
+		m = "OpMetaTxStart"
 	case OpCreateMultipart:
 		m = "OpCreateMultipart"
 	case OpGetMultipart:
@@ -418,6 +493,10 @@ func (p *Packet) GetResultMsg() (m string) {
 		m = "NotPerm"
 	case OpNotEmtpy:
 		m = "DirNotEmpty"
+	case OpStaleEpochErr:
+		m = "StaleEpochErr"
+	case OpFrozenErr:
+		m = "FrozenErr"
 	default:
 		return fmt.Sprintf("Unknown ResultCode(%v)", p.ResultCode)
 	}
@@ -587,6 +666,17 @@ func (p *Packet) PacketOkReply() {
 	p.ArgLen = 0
 }
 
+// SetZeroCopyRead sets ReadFlagZeroCopy in the arg of the stream read or its reply.
+func (p *Packet) SetZeroCopyRead() {
+	p.Arg = []byte{ReadFlagZeroCopy}
+	p.ArgLen = uint32(len(p.Arg))
+}
+
+// IsZeroCopyRead returns if ReadFlagZeroCopy is set in the arg of the stream read or its reply.
+func (p *Packet) IsZeroCopyRead() bool {
+	return p.ArgLen > 0 && len(p.Arg) > 0 && p.Arg[0]&ReadFlagZeroCopy != 0
+}
+
 // PacketOkWithBody sets the result code as OpOk, and sets the body with the give data.
 func (p *Packet) PacketOkWithBody(reply []byte) {
 	p.Size = uint32(len(reply))
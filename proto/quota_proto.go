@@ -0,0 +1,79 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// QuotaXAttrKey is the extend attribute which records the IDs of the directory quotas of an inode. The inodes in
+// the directory subtree of a quota are tagged when the quota is set, and the new inodes inherit the quotas of
+// the parent directory when they are created by the clients.
+const QuotaXAttrKey = "cfs.quota"
+
+// DirQuota limits the bytes and the files in the directory subtree of a volume, a limit of 0 means unlimited.
+// The usage is summed by master from the reports of the meta partition leaders.
+type DirQuota struct {
+	QuotaID   uint32
+	Path      string
+	RootInode uint64
+	MaxBytes  uint64
+	MaxFiles  uint64
+	UsedBytes uint64
+	UsedFiles uint64
+}
+
+// BytesExceeded returns if the bytes of the subtree reach the limit.
+func (q *DirQuota) BytesExceeded() bool {
+	return q.MaxBytes > 0 && q.UsedBytes >= q.MaxBytes
+}
+
+// FilesExceeded returns if the files of the subtree reach the limit.
+func (q *DirQuota) FilesExceeded() bool {
+	return q.MaxFiles > 0 && q.UsedFiles >= q.MaxFiles
+}
+
+// DirQuotaReply defines the reply of setting or deleting a directory quota. The task updates the quota of the inodes
+// in the directory subtree, which is nil if the inodes are not changed.
+type DirQuotaReply struct {
+	Quota *DirQuota
+	Task  *AsyncTaskInfo
+}
+
+// QuotaUsage defines the bytes and the files of the inodes of a directory quota in a meta partition.
+type QuotaUsage struct {
+	UsedBytes uint64
+	UsedFiles uint64
+}
+
+// ParseQuotaIDs parses the value of the quota extend attribute.
+func ParseQuotaIDs(value string) (ids []uint32) {
+	for _, s := range strings.Split(value, ",") {
+		if id, err := strconv.ParseUint(s, 10, 32); err == nil && id > 0 {
+			ids = append(ids, uint32(id))
+		}
+	}
+	return
+}
+
+// FormatQuotaIDs formats the quota IDs into the value of the quota extend attribute.
+func FormatQuotaIDs(ids []uint32) string {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, strconv.FormatUint(uint64(id), 10))
+	}
+	return strings.Join(values, ",")
+}
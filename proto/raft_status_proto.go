@@ -0,0 +1,52 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// MetaPartitionRaftStatus defines the raft progress of a replica of the meta partition.
+type MetaPartitionRaftStatus struct {
+	PartitionID       uint64               `json:"pid"`
+	VolName           string               `json:"vol"`
+	NodeID            uint64               `json:"nodeId"`
+	Addr              string               `json:"addr"`
+	State             string               `json:"state"`
+	Leader            uint64               `json:"leader"` // the node ID of the leader, 0 if unknown
+	Term              uint64               `json:"term"`
+	Index             uint64               `json:"index"`   // the last index of the raft log
+	Commit            uint64               `json:"commit"`  // the committed index
+	Applied           uint64               `json:"applied"` // the index applied to the metadata
+	SnapshotIndex     uint64               `json:"snapshot"`
+	PendQueue         int                  `json:"pendQueue"`
+	AppQueue          int                  `json:"appQueue"`
+	Stopped           bool                 `json:"stopped"`
+	RestoringSnapshot bool                 `json:"restoringSnapshot"`
+	Replicas          []*RaftReplicaStatus `json:"replicas"` // the progress of the replicas known by the leader
+}
+
+// RaftReplicaStatus defines the progress of a replica known by the raft leader, the lag is the raft log entries not
+// replicated to the replica yet.
+type RaftReplicaStatus struct {
+	NodeID       uint64 `json:"nodeId"`
+	Addr         string `json:"addr"`
+	Match        uint64 `json:"match"`
+	Commit       uint64 `json:"commit"`
+	Next         uint64 `json:"next"`
+	Lag          uint64 `json:"lag"`
+	State        string `json:"state"`
+	Active       bool   `json:"active"`
+	Learner      bool   `json:"learner"`
+	Snapshotting bool   `json:"snapshotting"`
+	Paused       bool   `json:"paused"`
+	LastActive   int64  `json:"lastActive"`
+}
@@ -0,0 +1,26 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Roles of the callers of the admin APIs on master, which are granted by the tokens in the config of master,
+// or by the API caps "master:role:<role>" of the authnode tickets.
+const (
+	RoleAdmin       = "admin"       // calls all the APIs
+	RoleVolumeOwner = "volumeOwner" // manages the volumes owned by the caller
+	RoleMonitor     = "monitor"     // calls the read-only APIs
+)
+
+// Roles lists the roles from the most privileged.
+var Roles = []string{RoleAdmin, RoleVolumeOwner, RoleMonitor}
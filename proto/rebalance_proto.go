@@ -0,0 +1,54 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// RebalanceConfig defines the configuration of a rebalancer. The partitions are migrated from the nodes whose usage
+// ratio is above HighRatio to the nodes whose usage ratio stays below LowRatio after the migration, and no more than
+// Limit partitions are migrating at the same time. The rebalancer is disabled if Limit is 0.
+type RebalanceConfig struct {
+	HighRatio float64
+	LowRatio  float64
+	Limit     uint64
+	Bandwidth uint64 // usage per second of the partitions started to migrate, 0 for unlimited
+	Paused    bool
+}
+
+// RebalanceNodeView defines the load of a node seen by a rebalancer.
+type RebalanceNodeView struct {
+	Addr           string
+	ZoneName       string
+	Total          uint64
+	Used           uint64
+	Ratio          float64
+	PartitionCount int
+	PartitionUsed  uint64 // estimated usage of the partitions on the node
+}
+
+// RebalanceMigration defines a partition replica migrated by a rebalancer.
+type RebalanceMigration struct {
+	PartitionID uint64
+	VolName     string
+	Source      string
+	Target      string
+	Used        uint64 // estimated usage of the partition
+	StartTime   int64
+}
+
+// RebalanceView defines the status of a rebalancer.
+type RebalanceView struct {
+	RebalanceConfig
+	Nodes      []*RebalanceNodeView
+	Migrations []*RebalanceMigration
+}
@@ -0,0 +1,60 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// GetReplicaChecksumRequest defines the request of the leader to get the checksums of a replica check from a
+// replica of the meta partition, the check is identified by the raft index it is applied at.
+type GetReplicaChecksumRequest struct {
+	VolName     string `json:"vol"`
+	PartitionID uint64 `json:"pid"`
+	CheckID     uint64 `json:"check"`
+}
+
+// ReplicaCheckRange defines the checksums of the inodes in the range [Start, End) of the inode numbers, and of
+// the dentries whose parents are in the range.
+type ReplicaCheckRange struct {
+	Start       uint64 `json:"start"`
+	End         uint64 `json:"end"`
+	InodeCount  uint64 `json:"inodes"`
+	InodeCRC    uint32 `json:"inodeCrc"`
+	DentryCount uint64 `json:"dentries"`
+	DentryCRC   uint32 `json:"dentryCrc"`
+}
+
+// ReplicaChecksum defines the checksums of a replica of the meta partition at the raft index of the check.
+type ReplicaChecksum struct {
+	CheckID uint64               `json:"check"`
+	Ranges  []*ReplicaCheckRange `json:"ranges"`
+}
+
+// ReplicaDivergence defines a range of a replica whose checksums differ from the leader.
+type ReplicaDivergence struct {
+	Addr  string `json:"addr"`
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+	Issue string `json:"issue"`
+}
+
+// ReplicaCheckReport defines the result of the latest replica check of a meta partition by the leader.
+type ReplicaCheckReport struct {
+	PartitionID uint64               `json:"pid"`
+	CheckID     uint64               `json:"check"` // the raft index the replicas are checked at
+	StartTime   int64                `json:"start"`
+	EndTime     int64                `json:"end"`
+	Ranges      int                  `json:"ranges"`
+	Checked     []string             `json:"checked"` // the replicas compared with the leader
+	Divergences []*ReplicaDivergence `json:"divergences"`
+	Error       string               `json:"error"`
+}
@@ -0,0 +1,52 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// VolReplication defines the pair replicating a volume to the volume of a standby cluster asynchronously. The
+// replicators mirror the volume to the standby volume pass by pass, and stop once the pair is failed over.
+type VolReplication struct {
+	TargetMasters []string // the masters of the standby cluster
+	TargetVol     string
+	MaxLag        int64 // seconds the standby volume lags behind before alarming
+	CreateTime    int64
+	FailoverTime  int64 // the standby volume is the primary after the pair is failed over
+}
+
+// IsActive returns whether the volume is still replicated to the standby volume.
+func (r *VolReplication) IsActive() bool {
+	return r != nil && r.FailoverTime == 0
+}
+
+// VolReplicationStatus defines the progress of the replication of a volume reported by the replicator.
+type VolReplicationStatus struct {
+	Replicator   string
+	PassStart    int64 // the start time of the running pass, 0 if no pass is running
+	LastSyncTime int64 // the start time of the last finished pass, the changes before it are in the standby volume
+	LastPassTime int64 // seconds the last finished pass took
+	Files        uint64
+	Bytes        uint64
+	Deleted      uint64
+	Errors       uint64 // the entries failed to replicate in the last finished pass
+	LastError    string
+	ReportTime   int64
+}
+
+// VolReplicationView defines the view of the replication of a volume.
+type VolReplicationView struct {
+	VolName     string
+	Replication *VolReplication
+	Status      *VolReplicationStatus
+	Lag         int64 // seconds the standby volume lags behind
+}
@@ -0,0 +1,25 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// RuntimeConfigItem defines a parameter of the master which can be changed without restarting the master.
+// The value set at runtime is persisted by raft, and overrides the value in the config file of the master.
+type RuntimeConfigItem struct {
+	Name        string
+	Value       string
+	Static      string // the value from the config file or the default when the master started
+	Overridden  bool   // the value is set at runtime
+	Description string
+}
@@ -0,0 +1,102 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"encoding/json"
+)
+
+// SummaryXAttrKey is the extend attribute which records the summary of a directory. The clients send the changes
+// of the directories to the meta partitions asynchronously, and the meta partitions add them up to the summaries
+// and return the parents, so that the changes are propagated level by level up to the root.
+const SummaryXAttrKey = "cfs.summary"
+
+// DirSummary defines the entries directly in a directory and the ones in the whole subtree of it. The recursive
+// counts include the direct ones, and the subdirectories do not include the directory itself.
+type DirSummary struct {
+	Parent   uint64 `json:"parent"` // 0 if the parent is not known yet
+	Files    int64  `json:"files"`
+	Subdirs  int64  `json:"subdirs"`
+	Bytes    int64  `json:"bytes"`
+	RFiles   int64  `json:"rfiles"`
+	RSubdirs int64  `json:"rsubdirs"`
+	RBytes   int64  `json:"rbytes"`
+}
+
+// ParseDirSummary parses the summary recorded in the extend attribute.
+func ParseDirSummary(value []byte) (summary *DirSummary, err error) {
+	summary = &DirSummary{}
+	if len(value) == 0 {
+		return
+	}
+	if err = json.Unmarshal(value, summary); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Marshal returns the encoded summary kept in the extend attribute.
+func (s *DirSummary) Marshal() []byte {
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// Add adds the delta to the summary, the parent is replaced if the delta has one.
+func (s *DirSummary) Add(delta *DirSummary) {
+	if delta.Parent != 0 {
+		s.Parent = delta.Parent
+	}
+	s.Files += delta.Files
+	s.Subdirs += delta.Subdirs
+	s.Bytes += delta.Bytes
+	s.RFiles += delta.RFiles
+	s.RSubdirs += delta.RSubdirs
+	s.RBytes += delta.RBytes
+}
+
+// Recursive returns the recursive part of the delta, which is propagated to the ancestors.
+func (s *DirSummary) Recursive() *DirSummary {
+	return &DirSummary{RFiles: s.RFiles, RSubdirs: s.RSubdirs, RBytes: s.RBytes}
+}
+
+// Negate returns the delta which reverts the counts of the delta.
+func (s *DirSummary) Negate() *DirSummary {
+	return &DirSummary{
+		Files:    -s.Files,
+		Subdirs:  -s.Subdirs,
+		Bytes:    -s.Bytes,
+		RFiles:   -s.RFiles,
+		RSubdirs: -s.RSubdirs,
+		RBytes:   -s.RBytes,
+	}
+}
+
+// IsZero checks if the delta changes nothing.
+func (s *DirSummary) IsZero() bool {
+	return *s == DirSummary{}
+}
+
+// UpdateSummaryRequest defines the request to add the delta to the summary of a directory.
+type UpdateSummaryRequest struct {
+	VolName     string     `json:"vol"`
+	PartitionID uint64     `json:"pid"`
+	Inode       uint64     `json:"ino"`
+	Delta       DirSummary `json:"delta"`
+}
+
+// UpdateSummaryResponse defines the summary of the directory after the update.
+type UpdateSummaryResponse struct {
+	Summary DirSummary `json:"summary"`
+}
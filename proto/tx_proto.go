@@ -0,0 +1,117 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// The types of the transactions.
+const (
+	TxTypeRename uint8 = iota + 1
+	TxTypeLink
+	TxTypeCreate
+)
+
+// The operations in the transactions. The dentry operations lock the dentries when they are prepared and apply the
+// changes when they are committed, while the inode operations apply the changes when they are prepared and roll
+// them back when they are aborted.
+const (
+	TxOpCreateDentry uint8 = iota + 1
+	TxOpDeleteDentry
+	TxOpUpdateDentry
+	TxOpCreateInode
+	TxOpLinkInode
+)
+
+// The states of the transactions.
+const (
+	TxStatePrepared uint8 = iota + 1
+	TxStateCommitted
+	TxStateUnknown // the transaction is aborted or done
+)
+
+// TxOp defines an operation of a transaction on a meta partition. The inode of a dentry operation is the one of the
+// previous inode operation if it is 0, e.g. the inode created or linked by the transaction.
+type TxOp struct {
+	PartitionID uint64            `json:"pid"`
+	Members     []string          `json:"members,omitempty"` // the members of the meta partition known by the client
+	Type        uint8             `json:"type"`
+	ParentID    uint64            `json:"pino"`
+	Name        string            `json:"name"`
+	Inode       uint64            `json:"ino"`
+	Mode        uint32            `json:"mode"`
+	Uid         uint32            `json:"uid,omitempty"`
+	Gid         uint32            `json:"gid,omitempty"`
+	XAttrs      map[string]string `json:"xattrs,omitempty"`  // the extend attributes of the inode created
+	Replace     bool              `json:"replace,omitempty"` // the dentry created replaces the existing regular file
+	OldInode    uint64            `json:"oldino,omitempty"`  // the inode of the dentry replaced
+}
+
+// IsInodeOp checks if the operation changes an inode rather than a dentry.
+func (op *TxOp) IsInodeOp() bool {
+	return op.Type == TxOpCreateInode || op.Type == TxOpLinkInode
+}
+
+// TxInfo defines a transaction among the meta partitions, which is coordinated by the meta partition of the first
+// operation by two-phase commit. The meta partitions are prepared in the order of their first operations.
+type TxInfo struct {
+	TxID        string  `json:"txid"`
+	Type        uint8   `json:"type"`
+	Coordinator uint64  `json:"coord"`
+	State       uint8   `json:"state"`
+	CreateTime  int64   `json:"ctime"`
+	Ops         []*TxOp `json:"ops"`
+}
+
+// TxRenameRequest defines the request to rename a dentry by a transaction, which is sent to the meta partition of
+// the source parent.
+type TxRenameRequest struct {
+	VolName        string   `json:"vol"`
+	PartitionID    uint64   `json:"pid"`
+	TxID           string   `json:"txid"` // generated by the client, the retries of the request share it
+	SrcParentID    uint64   `json:"spino"`
+	SrcName        string   `json:"sname"`
+	Inode          uint64   `json:"ino"`
+	Mode           uint32   `json:"mode"`
+	DstPartitionID uint64   `json:"dpid"`
+	DstMembers     []string `json:"dmembers"`
+	DstParentID    uint64   `json:"dpino"`
+	DstName        string   `json:"dname"`
+}
+
+// TxRenameResponse defines the response to the rename request.
+type TxRenameResponse struct {
+	OldInode uint64 `json:"oldino"` // the inode replaced by the rename, 0 if there is none
+}
+
+// TxStartRequest defines the request to run a transaction, which is sent to the meta partition of the first
+// operation.
+type TxStartRequest struct {
+	VolName     string  `json:"vol"`
+	PartitionID uint64  `json:"pid"`
+	Tx          *TxInfo `json:"tx"`
+}
+
+// TxRequest defines the request among the meta partitions of a transaction.
+type TxRequest struct {
+	VolName     string  `json:"vol"`
+	PartitionID uint64  `json:"pid"`
+	TxID        string  `json:"txid"`
+	Tx          *TxInfo `json:"tx,omitempty"` // only for the prepare
+}
+
+// TxResponse defines the response among the meta partitions of a transaction, and the response to the client which
+// starts the transaction.
+type TxResponse struct {
+	State uint8   `json:"state"`
+	Ops   []*TxOp `json:"ops,omitempty"` // the operations prepared on the meta partition
+}
@@ -101,10 +101,26 @@ type UserInfo struct {
 	UserType    UserType     `json:"user_type" graphql:"user_type"`
 	CreateTime  string       `json:"create_time" graphql:"create_time"`
 	Description string       `json:"description" graphql:"description"`
+	Quota       UserQuota    `json:"quota" graphql:"quota"`
 	Mu          sync.RWMutex `json:"-" graphql:"-"`
 	EMPTY       bool         //graphql need ???
 }
 
+// UserQuota limits the volumes owned by the user, which is checked when a volume is created, cloned, expanded or
+// transferred to the user. A limit of 0 means unlimited.
+type UserQuota struct {
+	Capacity uint64 `json:"capacity" graphql:"capacity"`   // total capacity of the volumes, unit: GB
+	VolCount uint64 `json:"vol_count" graphql:"vol_count"` // number of the volumes
+}
+
+// UserQuotaInfo represents the quota of the user and the usage of it.
+type UserQuotaInfo struct {
+	UserID       string    `json:"user_id"`
+	Quota        UserQuota `json:"quota"`
+	UsedCapacity uint64    `json:"used_capacity"` // total capacity of the owned volumes, unit: GB
+	UsedVolCount uint64    `json:"used_vol_count"`
+}
+
 func (i *UserInfo) String() string {
 	if i == nil {
 		return "nil"
@@ -339,11 +355,17 @@ type UserTransferVolParam struct {
 	Force   bool   `json:"force"`
 }
 
+type UserQuotaSetParam struct {
+	UserID string    `json:"user_id"`
+	Quota  UserQuota `json:"quota"`
+}
+
 type UserUpdateParam struct {
-	UserID      string   `json:"user_id"`
-	AccessKey   string   `json:"access_key"`
-	SecretKey   string   `json:"secret_key"`
-	Type        UserType `json:"type"`
-	Password    string   `json:"password"`
-	Description string   `json:"description"`
+	UserID         string   `json:"user_id"`
+	AccessKey      string   `json:"access_key"`
+	SecretKey      string   `json:"secret_key"`
+	Type           UserType `json:"type"`
+	Password       string   `json:"password"`
+	Description    string   `json:"description"`
+	RegenerateKeys bool     `json:"regenerate_keys"` // generate the access key and secret key which are not specified
 }
@@ -0,0 +1,69 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chubaofs/chubaofs/util/exporter"
+	"github.com/chubaofs/chubaofs/util/iputil"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// VolIPAclStore holds the client IP restrictions of the restricted volumes, which the meta nodes and the data nodes
+// pull from the master.
+type VolIPAclStore struct {
+	sync.RWMutex
+	acls map[string]*iputil.ACL
+}
+
+// Update replaces the client IP restrictions of the volumes. An invalid restriction doesn't lift the restriction of
+// the volume, the last valid one is kept, or all the clients are denied if the volume has none.
+func (s *VolIPAclStore) Update(volIPAcl map[string]VolIPAcl) {
+	acls := make(map[string]*iputil.ACL, len(volIPAcl))
+	s.RLock()
+	for name, ipAcl := range volIPAcl {
+		acl, err := iputil.NewACL(ipAcl.Allow, ipAcl.Deny)
+		if err != nil {
+			if acl = s.acls[name]; acl == nil {
+				acl = iputil.DenyAll()
+			}
+			msg := fmt.Sprintf("action[VolIPAclStore.Update] vol[%v] acl[%v] err[%v], keep the last valid acl",
+				name, ipAcl, err)
+			log.LogError(msg)
+			exporter.Warning(msg)
+		}
+		acls[name] = acl
+	}
+	s.RUnlock()
+	s.Lock()
+	s.acls = acls
+	s.Unlock()
+}
+
+// Get returns the ACL of the volume, nil if the volume is not restricted.
+func (s *VolIPAclStore) Get(volName string) *iputil.ACL {
+	s.RLock()
+	defer s.RUnlock()
+	return s.acls[volName]
+}
+
+// IsEmpty returns whether no volume is restricted.
+func (s *VolIPAclStore) IsEmpty() bool {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.acls) == 0
+}
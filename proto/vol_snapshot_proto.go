@@ -0,0 +1,70 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package proto
+
+// Status of the volume snapshots
+const (
+	VolSnapshotCreating  = "creating"
+	VolSnapshotAvailable = "available"
+	VolSnapshotDeleting  = "deleting"
+	VolSnapshotFailed    = "failed"
+)
+
+// VolSnapshot defines a snapshot of the metadata of a volume, taken by each meta partition at a point in time of its
+// own. The ID is the snapshot epoch, which increases with every snapshot of the volume and is never reused.
+type VolSnapshot struct {
+	ID                 uint64
+	Name               string
+	CreateTime         int64
+	Status             string
+	MaxMetaPartitionID uint64 // the meta partitions created after the snapshot are not in the snapshot
+}
+
+// VolSnapshotReply defines the reply of the snapshot operations, the task applies the operation to the meta
+// partitions of the volume in background.
+type VolSnapshotReply struct {
+	Snapshot *VolSnapshot
+	Task     *AsyncTaskInfo
+}
+
+// Operations on the snapshot of a meta partition
+const (
+	MetaSnapshotCreate   = "create"
+	MetaSnapshotDelete   = "delete"
+	MetaSnapshotRollback = "rollback"
+)
+
+// Operations on the data partitions for the volume snapshots
+const (
+	DataSnapshotFreeze  = "freeze"
+	DataSnapshotRelease = "release"
+)
+
+// DataPartitionSnapshotRequest defines the request to freeze the extents of the data partition before a snapshot is
+// taken, or to release them after the volume has no snapshot. It is sent to all the replicas of the partition, and
+// the request of an epoch older than the one of the replica is ignored.
+type DataPartitionSnapshotRequest struct {
+	PartitionID uint64
+	SnapshotID  uint64
+	Operation   string
+}
+
+// MetaPartitionSnapshotRequest defines the request to create, delete or roll back to a snapshot of the meta
+// partition. It is sent to the leader of the partition, which replicates the operation by raft.
+type MetaPartitionSnapshotRequest struct {
+	PartitionID uint64
+	SnapshotID  uint64
+	Operation   string
+}
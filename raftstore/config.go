@@ -16,7 +16,7 @@ package raftstore
 
 import (
 	"fmt"
-	"github.com/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 )
 
 // Constants for network port definition.
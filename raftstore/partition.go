@@ -15,10 +15,12 @@
 package raftstore
 
 import (
+	"fmt"
 	"os"
+	"time"
 
-	"github.com/tiglabs/raft"
-	"github.com/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
 )
 
 // PartitionStatus is a type alias of raft.Status
@@ -40,6 +42,10 @@ type Partition interface {
 	// ChaneMember submits member change event and information to raft log.
 	ChangeMember(changeType proto.ConfChangeType, peer proto.Peer, context []byte) (resp interface{}, err error)
 
+	// ResetMember forcibly replaces the raft members with the given peers. It is only used
+	// to recover a partition which permanently lost the majority of its replicas.
+	ResetMember(peers []proto.Peer) error
+
 	// Stop removes the raft partition from raft server and shuts down this partition.
 	Stop() error
 
@@ -66,7 +72,15 @@ type Partition interface {
 
 	TryToLeader(nodeID uint64) error
 
+	// TransferLeader makes the local replica the leader of the raft group by campaigning, and waits until it
+	// becomes the leader or the timeout is reached. It is used to drain the leadership before maintenance.
+	TransferLeader(timeout time.Duration) error
+
 	IsOfflinePeer() bool
+
+	// IsReplicaCaughtUp returns true if the replica falls behind the committed raft log by no more than the lag.
+	// It is only valid on the leader, which tracks the replication progress of the replicas.
+	IsReplicaCaughtUp(nodeID uint64, maxLag uint64) bool
 }
 
 // Default implementation of the Partition interface.
@@ -89,6 +103,13 @@ func (p *partition) ChangeMember(changeType proto.ConfChangeType, peer proto.Pee
 	return
 }
 
+// ResetMember forcibly replaces the raft members with the given peers.
+func (p *partition) ResetMember(peers []proto.Peer) (err error) {
+	future := p.raft.ResetMember(p.id, peers)
+	_, err = future.Response()
+	return
+}
+
 // Stop removes the raft partition from raft server and shuts down this partition.
 func (p *partition) Stop() (err error) {
 	err = p.raft.RemoveRaft(p.id)
@@ -101,6 +122,33 @@ func (p *partition) TryToLeader(nodeID uint64) (err error) {
 	return
 }
 
+const (
+	transferLeaderCheckInterval = 100 * time.Millisecond
+)
+
+// TransferLeader makes the local replica the leader of the raft group by campaigning, and waits until it
+// becomes the leader or the timeout is reached. The campaign fails if the raft log of the local replica
+// falls behind the others.
+func (p *partition) TransferLeader(timeout time.Duration) (err error) {
+	if p.IsRaftLeader() {
+		return
+	}
+	if err = p.TryToLeader(p.id); err != nil {
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	for !p.IsRaftLeader() {
+		if time.Now().After(deadline) {
+			leader, term := p.LeaderTerm()
+			err = fmt.Errorf("partition[%v] failed to become the leader in %v, current leader[%v] term[%v]",
+				p.id, timeout, leader, term)
+			return
+		}
+		time.Sleep(transferLeaderCheckInterval)
+	}
+	return
+}
+
 // Delete stops and deletes the partition.
 func (p *partition) Delete() (err error) {
 	if err = p.Stop(); err != nil {
@@ -127,6 +175,9 @@ func (p *partition) IsOfflinePeer() bool {
 	active := 0
 	sumPeers := 0
 	for _, peer := range status.Replicas {
+		if peer.Learner {
+			continue
+		}
 		if peer.Active == true {
 			active++
 		}
@@ -136,6 +187,19 @@ func (p *partition) IsOfflinePeer() bool {
 	return active >= (int(sumPeers)/2 + 1)
 }
 
+// IsReplicaCaughtUp returns true if the replica falls behind the committed raft log by no more than the lag.
+func (p *partition) IsReplicaCaughtUp(nodeID uint64, maxLag uint64) bool {
+	if !p.IsRaftLeader() {
+		return false
+	}
+	status := p.Status()
+	replica, ok := status.Replicas[nodeID]
+	if !ok || replica.Snapshoting {
+		return false
+	}
+	return replica.Match+maxLag >= status.Commit
+}
+
 // IsRaftLeader returns true if this node is the leader of the raft group it belongs to.
 func (p *partition) IsRaftLeader() (isLeader bool) {
 	isLeader = p.raft != nil && p.raft.IsLeader(p.id)
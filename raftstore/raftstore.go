@@ -17,11 +17,11 @@ package raftstore
 import (
 	"fmt"
 	syslog "log"
-	"github.com/tiglabs/raft"
-	"github.com/tiglabs/raft/logger"
-	"github.com/tiglabs/raft/proto"
-	"github.com/tiglabs/raft/storage/wal"
-	raftlog "github.com/tiglabs/raft/util/log"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/logger"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/proto"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft/storage/wal"
+	raftlog "github.com/chubaofs/chubaofs/depends/tiglabs/raft/util/log"
 	"os"
 	"path"
 	"strconv"
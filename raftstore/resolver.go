@@ -16,8 +16,8 @@ package raftstore
 
 import (
 	"fmt"
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
 	"github.com/chubaofs/chubaofs/util/errors"
-	"github.com/tiglabs/raft"
 	"strings"
 	"sync"
 )
@@ -21,12 +21,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/storage"
 	"github.com/chubaofs/chubaofs/util"
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/exporter"
-	"github.com/tiglabs/raft"
 )
 
 var (
@@ -304,6 +304,10 @@ func (p *Packet) identificationErrorResultCode(errLog string, errMsg string) {
 		p.ResultCode = proto.OpAgain
 	} else if strings.Contains(errMsg, raft.ErrNotLeader.Error()) {
 		p.ResultCode = proto.OpTryOtherAddr
+	} else if strings.Contains(errMsg, proto.ErrDataCorrupted.Error()) {
+		p.ResultCode = proto.OpErr
+	} else if strings.Contains(errMsg, proto.ErrExtentFrozen.Error()) {
+		p.ResultCode = proto.OpFrozenErr
 	} else {
 		p.ResultCode = proto.OpIntraGroupNetErr
 	}
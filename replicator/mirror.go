@@ -0,0 +1,304 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// passStats counts the entries replicated by a pass.
+type passStats struct {
+	files   uint64 // the directories, the files and the symlinks created or rewritten
+	bytes   uint64
+	deleted uint64
+	errors  uint64
+	lastErr error
+}
+
+func (s *passStats) fail(path string, err error) {
+	s.errors++
+	s.lastErr = fmt.Errorf("%v: %v", path, err)
+	log.LogWarnf("replicate path(%v) fail: err(%v)", path, err)
+}
+
+// fileType returns the type bits of the mode.
+func fileType(mode uint32) uint32 {
+	return mode &^ uint32(0777)
+}
+
+// needCopy returns whether the data of the source file differs from the standby file, which is rewritten with
+// the modify time of the source file.
+func needCopy(src, dst *proto.InodeInfo) bool {
+	return dst == nil || src.Size != dst.Size || src.ModifyTime.Unix() != dst.ModifyTime.Unix()
+}
+
+// mirrorDir makes the children of the standby directory the same as the ones of the source directory
+// recursively. The hard links of a source file are replicated as separate files.
+func (w *volReplicator) mirrorDir(srcIno, dstIno uint64, dirPath string, stats *passStats) (err error) {
+	if w.stopped() {
+		return errStopped
+	}
+	var srcChildren, dstChildren []proto.Dentry
+	if srcChildren, err = w.src.mw.ReadDir_ll(srcIno); err != nil {
+		if err == syscall.ENOENT {
+			// removed since the parent is read, the standby directory is removed by the next pass
+			err = nil
+		}
+		return
+	}
+	if dstChildren, err = w.dst.mw.ReadDir_ll(dstIno); err != nil {
+		return
+	}
+	srcInfos := batchInodeGet(w.src, srcChildren)
+	dstInfos := batchInodeGet(w.dst, dstChildren)
+	dstByName := make(map[string]proto.Dentry, len(dstChildren))
+	for _, dentry := range dstChildren {
+		dstByName[dentry.Name] = dentry
+	}
+
+	for _, dentry := range srcChildren {
+		srcInfo := srcInfos[dentry.Inode]
+		if srcInfo == nil {
+			// removed since the directory is read
+			continue
+		}
+		childPath := path.Join(dirPath, dentry.Name)
+		var dstInfo *proto.InodeInfo
+		if dstDentry, ok := dstByName[dentry.Name]; ok {
+			delete(dstByName, dentry.Name)
+			if dstInfo = dstInfos[dstDentry.Inode]; dstInfo == nil || fileType(dstInfo.Mode) != fileType(srcInfo.Mode) ||
+				proto.IsSymlink(srcInfo.Mode) && !bytes.Equal(srcInfo.Target, dstInfo.Target) {
+				if err = w.removeEntry(dstIno, dentry.Name, dstDentry.Type, stats); err != nil {
+					if err == errStopped {
+						return
+					}
+					stats.fail(childPath, err)
+					continue
+				}
+				dstInfo = nil
+			}
+		}
+		if err = w.mirrorEntry(dstIno, dentry.Name, childPath, srcInfo, dstInfo, stats); err == errStopped {
+			return
+		} else if err != nil && !w.removedFromSource(srcInfo.Inode) {
+			stats.fail(childPath, err)
+		}
+	}
+
+	for name, dentry := range dstByName {
+		if err = w.removeEntry(dstIno, name, dentry.Type, stats); err == errStopped {
+			return
+		} else if err != nil {
+			stats.fail(path.Join(dirPath, name), err)
+		}
+	}
+	return nil
+}
+
+// removedFromSource returns whether the source inode is removed, such as the file deleted while it is copied.
+func (w *volReplicator) removedFromSource(ino uint64) bool {
+	_, err := w.src.mw.InodeGet_ll(ino)
+	return err == syscall.ENOENT
+}
+
+func batchInodeGet(v *volume, dentries []proto.Dentry) map[uint64]*proto.InodeInfo {
+	inodes := make([]uint64, 0, len(dentries))
+	for _, dentry := range dentries {
+		inodes = append(inodes, dentry.Inode)
+	}
+	infos := make(map[uint64]*proto.InodeInfo, len(dentries))
+	for _, info := range v.mw.BatchInodeGet(inodes) {
+		infos[info.Inode] = info
+	}
+	return infos
+}
+
+// mirrorEntry replicates the source entry to the standby directory, the standby entry is nil if it does not
+// exist or has been removed for having another type.
+func (w *volReplicator) mirrorEntry(dstParent uint64, name, entryPath string, srcInfo, dstInfo *proto.InodeInfo, stats *passStats) (err error) {
+	switch {
+	case proto.IsDir(srcInfo.Mode):
+		if dstInfo == nil {
+			if dstInfo, err = w.dst.mw.Create_ll(dstParent, name, srcInfo.Mode, srcInfo.Uid, srcInfo.Gid, nil); err != nil {
+				return
+			}
+			stats.files++
+		}
+		if err = w.syncAttr(srcInfo, dstInfo, 0); err != nil {
+			return
+		}
+		return w.mirrorDir(srcInfo.Inode, dstInfo.Inode, entryPath, stats)
+	case proto.IsSymlink(srcInfo.Mode):
+		if dstInfo == nil {
+			if dstInfo, err = w.dst.mw.Create_ll(dstParent, name, srcInfo.Mode, srcInfo.Uid, srcInfo.Gid, srcInfo.Target); err != nil {
+				return
+			}
+			stats.files++
+		}
+		return w.syncAttr(srcInfo, dstInfo, 0)
+	case proto.IsRegular(srcInfo.Mode):
+		if needCopy(srcInfo, dstInfo) {
+			return w.copyFile(dstParent, name, srcInfo, dstInfo != nil, stats)
+		}
+		return w.syncAttr(srcInfo, dstInfo, 0)
+	default:
+		log.LogDebugf("mirrorEntry: skip the special file: volume(%v) path(%v) mode(%o)", w.volName, entryPath, srcInfo.Mode)
+		return nil
+	}
+}
+
+// syncAttr sets the mode and the owner of the standby inode, and the times if they are required by valid.
+func (w *volReplicator) syncAttr(srcInfo, dstInfo *proto.InodeInfo, valid uint32) error {
+	if srcInfo.Mode != dstInfo.Mode || srcInfo.Uid != dstInfo.Uid || srcInfo.Gid != dstInfo.Gid {
+		valid |= proto.AttrMode | proto.AttrUid | proto.AttrGid
+	}
+	if valid == 0 {
+		return nil
+	}
+	return w.dst.mw.Setattr(dstInfo.Inode, valid, srcInfo.Mode, srcInfo.Uid, srcInfo.Gid,
+		srcInfo.AccessTime.Unix(), srcInfo.ModifyTime.Unix())
+}
+
+// copyFile writes the data of the source file into a new standby inode, which replaces the standby file at
+// once, so the standby file is never seen partially written.
+func (w *volReplicator) copyFile(dstParent uint64, name string, srcInfo *proto.InodeInfo, exists bool, stats *passStats) (err error) {
+	var tmpInfo *proto.InodeInfo
+	if tmpInfo, err = w.dst.mw.InodeCreate_ll(srcInfo.Mode, srcInfo.Uid, srcInfo.Gid, nil); err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			w.releaseInode(tmpInfo.Inode)
+		}
+	}()
+	var written int
+	if written, err = w.copyData(srcInfo, tmpInfo.Inode); err != nil {
+		return
+	}
+	if err = w.syncAttr(srcInfo, tmpInfo, proto.AttrModifyTime|proto.AttrAccessTime); err != nil {
+		return
+	}
+	if exists {
+		var oldIno uint64
+		if oldIno, err = w.dst.mw.DentryUpdate_ll(dstParent, name, tmpInfo.Inode); err != nil {
+			return
+		}
+		w.releaseInode(oldIno)
+	} else if err = w.dst.mw.DentryCreate_ll(dstParent, name, tmpInfo.Inode, srcInfo.Mode); err != nil {
+		return
+	}
+	stats.files++
+	stats.bytes += uint64(written)
+	return
+}
+
+func (w *volReplicator) copyData(srcInfo *proto.InodeInfo, dstIno uint64) (written int, err error) {
+	if err = w.src.ec.OpenStream(srcInfo.Inode); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := w.src.ec.CloseStream(srcInfo.Inode); closeErr != nil {
+			log.LogWarnf("copyData: close source stream fail: volume(%v) inode(%v) err(%v)", w.volName, srcInfo.Inode, closeErr)
+		}
+	}()
+	if err = w.dst.ec.OpenStream(dstIno); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := w.dst.ec.CloseStream(dstIno); closeErr != nil {
+			log.LogWarnf("copyData: close target stream fail: volume(%v) inode(%v) err(%v)", w.pair.TargetVol, dstIno, closeErr)
+		}
+	}()
+	var readN, writeN int
+	for offset := 0; offset < int(srcInfo.Size); offset += readN {
+		if w.stopped() {
+			return written, errStopped
+		}
+		size := len(w.buf)
+		if int(srcInfo.Size)-offset < size {
+			size = int(srcInfo.Size) - offset
+		}
+		readN, err = w.src.ec.Read(srcInfo.Inode, w.buf, offset, size)
+		if err != nil && err != io.EOF {
+			return
+		}
+		if readN > 0 {
+			if writeN, err = w.dst.ec.Write(dstIno, offset, w.buf[:readN], 0); err != nil {
+				return
+			}
+			written += writeN
+		}
+		if err == io.EOF || readN == 0 {
+			break
+		}
+	}
+	err = w.dst.ec.Flush(dstIno)
+	return
+}
+
+// removeEntry removes the standby entry, and the children of the directory recursively.
+func (w *volReplicator) removeEntry(dstParent uint64, name string, mode uint32, stats *passStats) (err error) {
+	if w.stopped() {
+		return errStopped
+	}
+	isDir := proto.IsDir(mode)
+	if isDir {
+		var ino uint64
+		if ino, _, err = w.dst.mw.Lookup_ll(dstParent, name); err != nil {
+			return
+		}
+		var children []proto.Dentry
+		if children, err = w.dst.mw.ReadDir_ll(ino); err != nil {
+			return
+		}
+		for _, child := range children {
+			if err = w.removeEntry(ino, child.Name, child.Type, stats); err != nil {
+				return
+			}
+		}
+	}
+	var info *proto.InodeInfo
+	if info, err = w.dst.mw.Delete_ll(dstParent, name, isDir); err != nil {
+		if err == syscall.ENOENT {
+			err = nil
+		}
+		return
+	}
+	if info != nil && info.Nlink == 0 && !isDir {
+		if err = w.dst.mw.Evict(info.Inode); err != nil {
+			return
+		}
+	}
+	stats.deleted++
+	return
+}
+
+// releaseInode unlinks and evicts the standby inode which is not linked by any dentry.
+func (w *volReplicator) releaseInode(ino uint64) {
+	if _, err := w.dst.mw.InodeUnlink_ll(ino); err != nil {
+		log.LogWarnf("releaseInode: unlink inode fail: volume(%v) inode(%v) err(%v)", w.pair.TargetVol, ino, err)
+		return
+	}
+	if err := w.dst.mw.Evict(ino); err != nil {
+		log.LogWarnf("releaseInode: evict inode fail: volume(%v) inode(%v) err(%v)", w.pair.TargetVol, ino, err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+func TestNeedCopy(t *testing.T) {
+	mtime := time.Unix(1600000000, 0)
+	src := &proto.InodeInfo{Mode: proto.Mode(0644), Size: 10, ModifyTime: mtime}
+	for _, c := range []struct {
+		dst  *proto.InodeInfo
+		copy bool
+	}{
+		{nil, true},
+		{&proto.InodeInfo{Mode: proto.Mode(0600), Size: 10, ModifyTime: mtime.Add(time.Millisecond)}, false},
+		{&proto.InodeInfo{Size: 11, ModifyTime: mtime}, true},
+		{&proto.InodeInfo{Size: 10, ModifyTime: mtime.Add(time.Second)}, true},
+	} {
+		if got := needCopy(src, c.dst); got != c.copy {
+			t.Errorf("needCopy of %+v is %v, expect %v", c.dst, got, c.copy)
+		}
+	}
+}
+
+func TestFileType(t *testing.T) {
+	if fileType(proto.Mode(os.ModeDir|0755)) != fileType(proto.Mode(os.ModeDir|0700)) {
+		t.Errorf("expect the same type of the directories")
+	}
+	if fileType(proto.Mode(os.ModeDir|0755)) == fileType(proto.Mode(0755)) ||
+		fileType(proto.Mode(os.ModeSymlink|0777)) == fileType(proto.Mode(0777)) {
+		t.Errorf("expect different types of the directory, the symlink and the file")
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package replicator replicates the volumes to the volumes of the standby clusters asynchronously. The replicator
+// runs in the source cluster, it gets the replication pairs of the volumes from the master, mirrors the metadata
+// and the data of each volume to the standby volume pass by pass, and reports the progress to the master, which
+// alarms if the standby volume lags behind more than the max lag of the pair.
+package replicator
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/cmd/common"
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/master"
+	"github.com/chubaofs/chubaofs/util/config"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// Configuration items that act on the replicator.
+const (
+	// String array configuration item, the masters of the source cluster.
+	configMasterAddr = proto.MasterAddr
+
+	// The token to call the admin APIs of the master, which is required if the access control is enabled on the
+	// master. The token should be granted the admin role by the "rbacTokens" of the master.
+	configMasterAuthToken = "masterAuthToken"
+
+	// String array configuration item, the volumes replicated by the replicator, all the replicated volumes if
+	// empty. Each volume should be replicated by only one replicator.
+	configVolumes = "volumes"
+
+	// Seconds between the starts of the passes replicating a volume.
+	configPassInterval = "passInterval"
+)
+
+const (
+	defaultPassInterval    = 60
+	intervalToRefreshPairs = 30 * time.Second
+)
+
+// Replicator replicates the volumes by the replication pairs in the master.
+type Replicator struct {
+	masters      []string
+	mc           *master.MasterClient
+	volumes      map[string]bool
+	passInterval time.Duration
+	host         string
+	workers      map[string]*volReplicator // keyed by the name of the source volume
+	stopC        chan struct{}
+	wg           sync.WaitGroup
+	control      common.Control
+}
+
+func NewServer() *Replicator {
+	return &Replicator{}
+}
+
+func (r *Replicator) Start(cfg *config.Config) (err error) {
+	return r.control.Start(r, cfg, handleStart)
+}
+
+func (r *Replicator) Shutdown() {
+	r.control.Shutdown(r, handleShutdown)
+}
+
+func (r *Replicator) Sync() {
+	r.control.Sync()
+}
+
+func (r *Replicator) loadConfig(cfg *config.Config) (err error) {
+	masters := cfg.GetStringSlice(configMasterAddr)
+	if len(masters) == 0 {
+		return config.NewIllegalConfigError(configMasterAddr)
+	}
+	r.masters = masters
+	r.mc = master.NewMasterClient(masters, false)
+	r.mc.SetAuthToken(cfg.GetString(configMasterAuthToken))
+	log.LogInfof("loadConfig: setup config: %v(%v)", configMasterAddr, strings.Join(masters, ","))
+
+	r.volumes = make(map[string]bool)
+	for _, volume := range cfg.GetStringSlice(configVolumes) {
+		r.volumes[volume] = true
+	}
+	log.LogInfof("loadConfig: setup config: %v(%v)", configVolumes, cfg.GetStringSlice(configVolumes))
+
+	passInterval := cfg.GetInt64(configPassInterval)
+	if passInterval <= 0 {
+		passInterval = defaultPassInterval
+	}
+	r.passInterval = time.Duration(passInterval) * time.Second
+	log.LogInfof("loadConfig: setup config: %v(%v)", configPassInterval, passInterval)
+	return
+}
+
+func handleStart(s common.Server, cfg *config.Config) (err error) {
+	r, ok := s.(*Replicator)
+	if !ok {
+		return errors.New("Invalid Node Type!")
+	}
+	if err = r.loadConfig(cfg); err != nil {
+		return
+	}
+	if r.host, err = os.Hostname(); err != nil {
+		return
+	}
+	r.workers = make(map[string]*volReplicator)
+	r.stopC = make(chan struct{})
+	r.wg.Add(1)
+	go r.refreshPairs()
+	log.LogInfo("replicator start success")
+	return
+}
+
+func handleShutdown(s common.Server) {
+	r, ok := s.(*Replicator)
+	if !ok {
+		return
+	}
+	close(r.stopC)
+	r.wg.Wait()
+}
+
+// refreshPairs starts the workers of the pairs added to the master, and stops the workers of the pairs removed,
+// changed or failed over.
+func (r *Replicator) refreshPairs() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(intervalToRefreshPairs)
+	defer ticker.Stop()
+	for {
+		r.updateWorkers()
+		select {
+		case <-r.stopC:
+			for name, worker := range r.workers {
+				worker.stop()
+				delete(r.workers, name)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Replicator) updateWorkers() {
+	views, err := r.mc.AdminAPI().ListVolReplications()
+	if err != nil {
+		log.LogWarnf("updateWorkers: list replications fail: err(%v)", err)
+		return
+	}
+	pairs := make(map[string]*proto.VolReplication, len(views))
+	for _, view := range views {
+		if view.Replication.IsActive() && (len(r.volumes) == 0 || r.volumes[view.VolName]) {
+			pairs[view.VolName] = view.Replication
+		}
+	}
+	for name, worker := range r.workers {
+		if pair, ok := pairs[name]; !ok || pair.CreateTime != worker.pair.CreateTime {
+			log.LogInfof("updateWorkers: stop replicating volume(%v) to volume(%v)", name, worker.pair.TargetVol)
+			worker.stop()
+			delete(r.workers, name)
+		}
+	}
+	for name, pair := range pairs {
+		if _, ok := r.workers[name]; ok {
+			continue
+		}
+		log.LogInfof("updateWorkers: start replicating volume(%v) to volume(%v) of %v",
+			name, pair.TargetVol, pair.TargetMasters)
+		worker := newVolReplicator(r, name, pair)
+		r.workers[name] = worker
+		go worker.run()
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package replicator
+
+import (
+	"errors"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/sdk/data/stream"
+	"github.com/chubaofs/chubaofs/sdk/meta"
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+var errStopped = errors.New("replication stopped")
+
+// volume is the client of the metadata and the data of a volume.
+type volume struct {
+	name string
+	mw   *meta.MetaWrapper
+	ec   *stream.ExtentClient
+}
+
+func openVolume(name string, masters []string) (v *volume, err error) {
+	var mw *meta.MetaWrapper
+	if mw, err = meta.NewMetaWrapper(&meta.MetaConfig{Volume: name, Masters: masters}); err != nil {
+		return
+	}
+	var ec *stream.ExtentClient
+	if ec, err = stream.NewExtentClient(&stream.ExtentConfig{
+		Volume:            name,
+		Masters:           masters,
+		FollowerRead:      true,
+		OnAppendExtentKey: mw.AppendExtentKey,
+		OnGetExtents:      mw.GetExtents,
+		OnTruncate:        mw.Truncate,
+	}); err != nil {
+		_ = mw.Close()
+		return
+	}
+	return &volume{name: name, mw: mw, ec: ec}, nil
+}
+
+func (v *volume) close() {
+	if err := v.ec.Close(); err != nil {
+		log.LogWarnf("close: close extent client fail: volume(%v) err(%v)", v.name, err)
+	}
+	if err := v.mw.Close(); err != nil {
+		log.LogWarnf("close: close meta wrapper fail: volume(%v) err(%v)", v.name, err)
+	}
+}
+
+// volReplicator replicates a volume to the standby volume of the pair pass by pass. A pass mirrors the tree of
+// the volume to the standby volume, and the changes made before the start of the pass are in the standby volume
+// once the pass is finished.
+type volReplicator struct {
+	r       *Replicator
+	volName string
+	pair    *proto.VolReplication
+	src     *volume
+	dst     *volume
+	status  proto.VolReplicationStatus
+	buf     []byte
+	stopC   chan struct{}
+	doneC   chan struct{}
+}
+
+func newVolReplicator(r *Replicator, volName string, pair *proto.VolReplication) *volReplicator {
+	return &volReplicator{
+		r:       r,
+		volName: volName,
+		pair:    pair,
+		status:  proto.VolReplicationStatus{Replicator: r.host},
+		stopC:   make(chan struct{}),
+		doneC:   make(chan struct{}),
+	}
+}
+
+// stop stops the replication and waits for the running pass to abort.
+func (w *volReplicator) stop() {
+	close(w.stopC)
+	<-w.doneC
+}
+
+func (w *volReplicator) stopped() bool {
+	select {
+	case <-w.stopC:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *volReplicator) run() {
+	defer close(w.doneC)
+	defer func() {
+		if w.src != nil {
+			w.src.close()
+		}
+		if w.dst != nil {
+			w.dst.close()
+		}
+	}()
+	for {
+		start := time.Now()
+		if err := w.open(); err != nil {
+			log.LogErrorf("run: open volumes fail: volume(%v) target(%v) err(%v)", w.volName, w.pair.TargetVol, err)
+			w.status.LastError = err.Error()
+			w.report()
+		} else if err = w.pass(); err == errStopped {
+			return
+		}
+		select {
+		case <-w.stopC:
+			return
+		case <-time.After(w.r.passInterval - time.Since(start)):
+		}
+	}
+}
+
+func (w *volReplicator) open() (err error) {
+	if w.src == nil {
+		if w.src, err = openVolume(w.volName, w.r.masters); err != nil {
+			return
+		}
+	}
+	if w.dst == nil {
+		if w.dst, err = openVolume(w.pair.TargetVol, w.pair.TargetMasters); err != nil {
+			return
+		}
+	}
+	if w.buf == nil {
+		w.buf = make([]byte, 2*util.BlockSize)
+	}
+	return
+}
+
+// pass mirrors the volume to the standby volume, the entries failed to replicate are counted as the errors and
+// replicated by the next pass.
+func (w *volReplicator) pass() (err error) {
+	w.status.PassStart = time.Now().Unix()
+	w.report()
+	stats := &passStats{}
+	err = w.mirrorDir(proto.RootIno, proto.RootIno, "/", stats)
+	if err == errStopped {
+		return
+	}
+	if err != nil {
+		stats.fail("/", err)
+	}
+	if stats.errors == 0 {
+		w.status.LastSyncTime = w.status.PassStart
+	}
+	w.status.LastPassTime = time.Now().Unix() - w.status.PassStart
+	w.status.PassStart = 0
+	w.status.Files, w.status.Bytes, w.status.Deleted, w.status.Errors = stats.files, stats.bytes, stats.deleted, stats.errors
+	w.status.LastError = ""
+	if stats.lastErr != nil {
+		w.status.LastError = stats.lastErr.Error()
+	}
+	log.LogInfof("pass: volume(%v) target(%v) status(%+v)", w.volName, w.pair.TargetVol, w.status)
+	w.report()
+	return nil
+}
+
+func (w *volReplicator) report() {
+	status := w.status
+	if err := w.r.mc.AdminAPI().ReportVolReplication(w.volName, &status); err != nil {
+		log.LogWarnf("report: report replication fail: volume(%v) err(%v)", w.volName, err)
+	}
+}
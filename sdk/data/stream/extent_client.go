@@ -81,6 +81,7 @@ type ExtentConfig struct {
 	Masters           []string
 	FollowerRead      bool
 	NearRead          bool
+	ReadCrc           bool // the crc of all the data read is checked, so no read is replied by zero copy
 	ReadRate          int64
 	WriteRate         int64
 	OnAppendExtentKey AppendExtentKeyFunc
@@ -102,6 +103,7 @@ type ExtentClient struct {
 	getExtents      GetExtentsFunc
 	truncate        TruncateFunc
 	evictIcache     EvictIcacheFunc //May be null, must check before using
+	readCrc         bool
 }
 
 // NewExtentClient returns a new extent client.
@@ -126,6 +128,7 @@ retry:
 	client.getExtents = config.OnGetExtents
 	client.truncate = config.OnTruncate
 	client.evictIcache = config.OnEvictIcache
+	client.readCrc = config.ReadCrc
 	client.dataWrapper.InitFollowerRead(config.FollowerRead)
 	client.dataWrapper.SetNearRead(config.NearRead)
 
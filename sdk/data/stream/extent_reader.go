@@ -31,15 +31,20 @@ type ExtentReader struct {
 	key          *proto.ExtentKey
 	dp           *wrapper.DataPartition
 	followerRead bool
+	verifyCrc    bool // the data mismatching the crc is read from another replica instead of failing the read
+	zeroCopy     bool // the data may be replied by zero copy without the crc
 }
 
 // NewExtentReader returns a new extent reader.
-func NewExtentReader(inode uint64, key *proto.ExtentKey, dp *wrapper.DataPartition, followerRead bool) *ExtentReader {
+func NewExtentReader(inode uint64, key *proto.ExtentKey, dp *wrapper.DataPartition, followerRead, verifyCrc,
+	zeroCopy bool) *ExtentReader {
 	return &ExtentReader{
 		inode:        inode,
 		key:          key,
 		dp:           dp,
 		followerRead: followerRead,
+		verifyCrc:    verifyCrc,
+		zeroCopy:     zeroCopy,
 	}
 }
 
@@ -55,6 +60,9 @@ func (reader *ExtentReader) Read(req *ExtentRequest) (readBytes int, err error)
 	size := req.Size
 
 	reqPacket := NewReadPacket(reader.key, offset, size, reader.inode, req.FileOffset, reader.followerRead)
+	if reader.zeroCopy {
+		reqPacket.SetZeroCopyRead()
+	}
 	sc := NewStreamConn(reader.dp, reader.followerRead)
 
 	log.LogDebugf("ExtentReader Read enter: size(%v) req(%v) reqPacket(%v)", size, req, reqPacket)
@@ -103,6 +111,12 @@ func (reader *ExtentReader) checkStreamReply(request *Packet, reply *Packet) (er
 		return TryOtherAddrError
 	}
 
+	// the data nodes reply OpErr to the read only if the data does not match the crc of the extent blocks, the
+	// message may be truncated to the size of the buffer so it is not checked
+	if reply.ResultCode == proto.OpErr {
+		return reader.readFromOtherReplica(request, reply)
+	}
+
 	if reply.ResultCode != proto.OpOk {
 		if request.Opcode == proto.OpStreamFollowerRead {
 			log.LogWarnf("checkStreamReply: ResultCode(%v) NOK, OpStreamFollowerRead return TryOtherAddrError, "+
@@ -116,10 +130,26 @@ func (reader *ExtentReader) checkStreamReply(request *Packet, reply *Packet) (er
 		err = errors.New(fmt.Sprintf("checkStreamReply: inconsistent req and reply, req(%v) reply(%v)", request, reply))
 		return
 	}
+	// the replies sent by zero copy have no crc, they are sent only if the request allows it
+	if reply.IsZeroCopyRead() && request.IsZeroCopyRead() {
+		return nil
+	}
 	expectCrc := crc32.ChecksumIEEE(reply.Data[:reply.Size])
 	if reply.CRC != expectCrc {
+		if reader.verifyCrc {
+			return reader.readFromOtherReplica(request, reply)
+		}
 		err = errors.New(fmt.Sprintf("checkStreamReply: inconsistent CRC, expectCRC(%v) replyCRC(%v)", expectCrc, reply.CRC))
 		return
 	}
 	return nil
 }
+
+// readFromOtherReplica turns the request into a follower read, since only the leader serves the normal read, and
+// returns TryOtherAddrError so that the data which is found corrupt is read from the other replicas.
+func (reader *ExtentReader) readFromOtherReplica(request *Packet, reply *Packet) error {
+	log.LogErrorf("readFromOtherReplica: corrupt data, ino(%v) req(%v) reply(%v) resultCode(%v)", reader.inode,
+		request, reply, reply.ResultCode)
+	request.Opcode = proto.OpStreamFollowerRead
+	return TryOtherAddrError
+}
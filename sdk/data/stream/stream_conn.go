@@ -28,6 +28,7 @@ import (
 
 var (
 	TryOtherAddrError = errors.New("TryOtherAddrError")
+	ExtentFrozenError = errors.New("ExtentFrozenError") // the extent is kept by the volume snapshots
 )
 
 const (
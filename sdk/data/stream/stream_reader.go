@@ -80,7 +80,9 @@ func (s *Streamer) GetExtentReader(ek *proto.ExtentKey) (*ExtentReader, error) {
 	if err != nil {
 		return nil, err
 	}
-	reader := NewExtentReader(s.inode, ek, partition, s.client.dataWrapper.FollowerRead())
+	verifyCrc := s.client.dataWrapper.VerifyReadCrc()
+	reader := NewExtentReader(s.inode, ek, partition, s.client.dataWrapper.FollowerRead(), verifyCrc,
+		!verifyCrc && !s.client.readCrc)
 	return reader, nil
 }
 
@@ -94,6 +96,10 @@ func (s *Streamer) read(data []byte, offset int, size int) (total int, err error
 
 	ctx := context.Background()
 	s.client.readLimiter.Wait(ctx)
+	if err = s.client.dataWrapper.QosLimiter().WaitRead(ctx, size); err != nil {
+		log.LogWarnf("Streamer read: ino(%v) wait for qos err(%v)", s.inode, err)
+		err = nil
+	}
 
 	requests = s.extents.PrepareReadRequests(offset, size, data)
 	for _, req := range requests {
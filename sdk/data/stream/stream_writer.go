@@ -275,10 +275,19 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 
 	ctx := context.Background()
 	s.client.writeLimiter.Wait(ctx)
+	if err = s.client.dataWrapper.QosLimiter().WaitWrite(ctx, size); err != nil {
+		log.LogWarnf("Streamer write: ino(%v) wait for qos err(%v)", s.inode, err)
+		err = nil
+	}
 
 	requests := s.extents.PrepareWriteRequests(offset, size, data)
 	log.LogDebugf("Streamer write: ino(%v) prepared requests(%v)", s.inode, requests)
 
+	// The extents referenced by the snapshots of the volume are kept, so the overwrites are written into new extents.
+	// The snapshots are seen by the updates of the view of the volume, and the data nodes refuse to overwrite the
+	// extents frozen for the snapshots before that, in which case the rest of the overwrite is written as well.
+	hasSnapshots := s.client.dataWrapper.HasSnapshots()
+
 	// Must flush before doing overwrite
 	for _, req := range requests {
 		if req.ExtentKey == nil {
@@ -295,8 +304,15 @@ func (s *Streamer) write(data []byte, offset, size, flags int) (total int, err e
 
 	for _, req := range requests {
 		var writeSize int
-		if req.ExtentKey != nil {
+		if req.ExtentKey != nil && !hasSnapshots {
 			writeSize, err = s.doOverwrite(req, direct)
+			if err == ExtentFrozenError {
+				log.LogWarnf("Streamer write: ino(%v) extent frozen, write into new extents, req(%v) written(%v)",
+					s.inode, req, writeSize)
+				var size int
+				size, err = s.doWrite(req.Data[writeSize:], req.FileOffset+writeSize, req.Size-writeSize, direct)
+				writeSize += size
+			}
 		} else {
 			writeSize, err = s.doWrite(req.Data, req.FileOffset, req.Size, direct)
 		}
@@ -376,6 +392,10 @@ func (s *Streamer) doOverwrite(req *ExtentRequest, direct bool) (total int, err
 		reqPacket.Data = nil
 		log.LogDebugf("doOverwrite: ino(%v) req(%v) reqPacket(%v) err(%v) replyPacket(%v)", s.inode, req, reqPacket, err, replyPacket)
 
+		if err == nil && replyPacket.ResultCode == proto.OpFrozenErr {
+			err = ExtentFrozenError
+			break
+		}
 		if err != nil || replyPacket.ResultCode != proto.OpOk {
 			err = errors.New(fmt.Sprintf("doOverwrite: failed or reply NOK: err(%v) ino(%v) req(%v) replyPacket(%v)", err, s.inode, req, replyPacket))
 			break
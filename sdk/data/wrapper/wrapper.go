@@ -19,6 +19,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
@@ -26,6 +27,7 @@ import (
 	"github.com/chubaofs/chubaofs/util/errors"
 	"github.com/chubaofs/chubaofs/util/iputil"
 	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/chubaofs/chubaofs/util/qos"
 )
 
 var (
@@ -55,6 +57,11 @@ type Wrapper struct {
 	stopC                 chan struct{}
 
 	dpSelector DataPartitionSelector
+	qos        proto.VolQos
+	qosLimiter *qos.Limiter
+
+	snapshotCount int32
+	verifyReadCrc int32 // 1 if the data read is checked against the crc and read from another replica if corrupt
 
 	HostsStatus map[string]bool
 }
@@ -68,6 +75,7 @@ func NewDataPartitionWrapper(volName string, masters []string) (w *Wrapper, err
 	w.volName = volName
 	w.partitions = make(map[uint64]*DataPartition)
 	w.HostsStatus = make(map[string]bool)
+	w.qosLimiter = qos.NewLimiter()
 	if err = w.updateClusterInfo(); err != nil {
 		err = errors.Trace(err, "NewDataPartitionWrapper:")
 		return
@@ -127,12 +135,16 @@ func (w *Wrapper) getSimpleVolView() (err error) {
 	w.followerRead = view.FollowerRead
 	w.dpSelectorName = view.DpSelectorName
 	w.dpSelectorParm = view.DpSelectorParm
+	w.updateQos(view.Qos)
+	atomic.StoreInt32(&w.snapshotCount, int32(view.SnapshotCount))
+	w.setVerifyReadCrc(view.VerifyReadCrc)
 
 	log.LogInfof("getSimpleVolView: get volume simple info: ID(%v) name(%v) owner(%v) status(%v) capacity(%v) "+
 		"metaReplicas(%v) dataReplicas(%v) mpCnt(%v) dpCnt(%v) followerRead(%v) createTime(%v) dpSelectorName(%v) "+
-		"dpSelectorParm(%v)",
+		"dpSelectorParm(%v) qos(%+v) snapshotCount(%v) verifyReadCrc(%v)",
 		view.ID, view.Name, view.Owner, view.Status, view.Capacity, view.MpReplicaNum, view.DpReplicaNum, view.MpCnt,
-		view.DpCnt, view.FollowerRead, view.CreateTime, view.DpSelectorName, view.DpSelectorParm)
+		view.DpCnt, view.FollowerRead, view.CreateTime, view.DpSelectorName, view.DpSelectorParm, view.Qos,
+		view.SnapshotCount, view.VerifyReadCrc)
 	return nil
 }
 
@@ -174,9 +186,56 @@ func (w *Wrapper) updateSimpleVolView() (err error) {
 		w.Unlock()
 	}
 
+	if w.qos != view.Qos {
+		log.LogInfof("updateSimpleVolView: update qos from old(%+v) to new(%+v)", w.qos, view.Qos)
+		w.updateQos(view.Qos)
+	}
+
+	if snapshotCount := int32(view.SnapshotCount); atomic.LoadInt32(&w.snapshotCount) != snapshotCount {
+		log.LogInfof("updateSimpleVolView: update snapshotCount from old(%v) to new(%v)",
+			atomic.LoadInt32(&w.snapshotCount), snapshotCount)
+		atomic.StoreInt32(&w.snapshotCount, snapshotCount)
+	}
+
+	if w.VerifyReadCrc() != view.VerifyReadCrc {
+		log.LogInfof("updateSimpleVolView: update verifyReadCrc from old(%v) to new(%v)",
+			w.VerifyReadCrc(), view.VerifyReadCrc)
+		w.setVerifyReadCrc(view.VerifyReadCrc)
+	}
+
 	return nil
 }
 
+func (w *Wrapper) updateQos(volQos proto.VolQos) {
+	w.qos = volQos
+	w.qosLimiter.Update(volQos.ReadIops, volQos.WriteIops, volQos.ReadBps, volQos.WriteBps)
+}
+
+// HasSnapshots returns if the volume has snapshots, the extents may be referenced by the snapshots and must not
+// be overwritten in place.
+func (w *Wrapper) HasSnapshots() bool {
+	return atomic.LoadInt32(&w.snapshotCount) > 0
+}
+
+// VerifyReadCrc returns if the data read is checked against the crc, the corrupt data is read from another replica
+// instead of being returned.
+func (w *Wrapper) VerifyReadCrc() bool {
+	return atomic.LoadInt32(&w.verifyReadCrc) == 1
+}
+
+func (w *Wrapper) setVerifyReadCrc(verify bool) {
+	var value int32
+	if verify {
+		value = 1
+	}
+	atomic.StoreInt32(&w.verifyReadCrc, value)
+}
+
+// QosLimiter returns the limiter of the QoS limits of the volume.
+func (w *Wrapper) QosLimiter() *qos.Limiter {
+	return w.qosLimiter
+}
+
 func (w *Wrapper) updateDataPartition(isInit bool) (err error) {
 
 	var dpv *proto.DataPartitionsView
@@ -15,357 +15,954 @@
 package master
 
 import (
+	"context"
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
 
 type AdminAPI struct {
-	mc *MasterClient
+	mc  *MasterClient
+	ctx context.Context
+}
+
+// WithContext returns the admin APIs whose requests are canceled when the context is done.
+func (api *AdminAPI) WithContext(ctx context.Context) *AdminAPI {
+	return &AdminAPI{mc: api.mc, ctx: ctx}
 }
 
 func (api *AdminAPI) GetCluster() (cv *proto.ClusterView, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.AdminGetCluster)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	cv = &proto.ClusterView{}
-	if err = json.Unmarshal(buf, &cv); err != nil {
-		return
-	}
-	return
+	return newGetClusterRequest().serve(api.ctx, api.mc)
 }
+
 func (api *AdminAPI) GetClusterStat() (cs *proto.ClusterStatInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminClusterStat)
+	request := newGetClusterStatRequest()
 	request.addHeader("isTimeOut", "false")
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
+	return request.serve(api.ctx, api.mc)
+}
+
+// GetClusterHealth returns the health summary of the cluster. The followers lagging behind the leader more than
+// maxRaftLag and the nodes whose used ratio exceeds capacityThreshold are reported, the zero values mean the defaults.
+func (api *AdminAPI) GetClusterHealth(maxRaftLag uint64, capacityThreshold float64) (health *proto.ClusterHealth, err error) {
+	request := newGetClusterHealthRequest()
+	request.addHeader("isTimeOut", "false")
+	if maxRaftLag > 0 {
+		request.withMaxRaftLag(maxRaftLag)
 	}
-	cs = &proto.ClusterStatInfo{}
-	if err = json.Unmarshal(buf, &cs); err != nil {
-		return
+	if capacityThreshold > 0 {
+		request.withThreshold(capacityThreshold)
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
-func (api *AdminAPI) ListZones() (zoneViews []*proto.ZoneView, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.GetAllZones)
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	zoneViews = make([]*proto.ZoneView, 0)
-	if err = json.Unmarshal(buf, &zoneViews); err != nil {
+
+func (api *AdminAPI) RecordCliAudit(record *proto.CliAuditRecord) (err error) {
+	var request recordCliAuditRequest
+	if request, err = newRecordCliAuditRequest().withBody(record); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
-func (api *AdminAPI) Topo() (topo *proto.TopologyView, err error ){
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.GetTopologyView)
-	if buf, err = api.mc.serveRequest(request); err != nil{
-		return
+
+// RollingRestart restarts the nodes of the role batch by batch through the node agents, all the nodes of the role
+// are restarted if nodes is empty. The zero values of batch, agentPort and timeout mean the defaults.
+func (api *AdminAPI) RollingRestart(role string, nodes []string, batch, agentPort int, timeout time.Duration) (task *proto.AsyncTaskInfo, err error) {
+	request := newRollingRestartRequest().withRole(role)
+	if len(nodes) > 0 {
+		request.withHosts(strings.Join(nodes, ","))
 	}
-	topo = &proto.TopologyView{}
-	if err = json.Unmarshal(buf, &topo); err != nil {
-		return
+	if batch > 0 {
+		request.withBatch(batch)
 	}
-	return
+	if agentPort > 0 {
+		request.withAgentPort(agentPort)
+	}
+	if timeout > 0 {
+		request.withTimeout(int(timeout / time.Second))
+	}
+	return request.serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListZones() (zoneViews []*proto.ZoneView, err error) {
+	return newListZonesRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) Topo() (topo *proto.TopologyView, err error) {
+	return newGetTopologyRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) UpdateZoneStatus(zoneName string, enable bool) (err error) {
+	return newUpdateZoneRequest().
+		withName(zoneName).
+		withEnable(enable).
+		serve(api.ctx, api.mc)
+}
+
+// MoveZoneNode moves the data node or the meta node into the zone, the nodeType is
+// proto.PartitionTypeData or proto.PartitionTypeMeta.
+func (api *AdminAPI) MoveZoneNode(nodeType, nodeAddr, zoneName string) (err error) {
+	return newMoveZoneNodeRequest().
+		withType(nodeType).
+		withAddr(nodeAddr).
+		withZoneName(zoneName).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) GetDataPartition(volName string, partitionID uint64) (partition *proto.DataPartitionInfo, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.AdminGetDataPartition)
-	request.addParam("id", strconv.Itoa(int(partitionID)))
-	request.addParam("name", volName)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	partition = &proto.DataPartitionInfo{}
-	if err = json.Unmarshal(buf, &partition); err != nil {
-		return
-	}
-	return
+	return newGetDataPartitionRequest().
+		withID(partitionID).
+		withName(volName).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DiagnoseDataPartition() (diagnosis *proto.DataPartitionDiagnosis, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.AdminDiagnoseDataPartition)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	diagnosis = &proto.DataPartitionDiagnosis{}
-	if err = json.Unmarshal(buf, &diagnosis); err != nil {
-		return
-	}
-	return
+	return newDiagnoseDataPartitionRequest().serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DiagnoseMetaPartition() (diagnosis *proto.MetaPartitionDiagnosis, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.AdminDiagnoseMetaPartition)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	diagnosis = &proto.MetaPartitionDiagnosis{}
-	if err = json.Unmarshal(buf, &diagnosis); err != nil {
-		return
-	}
-	return
+	return newDiagnoseMetaPartitionRequest().serve(api.ctx, api.mc)
+}
+
+// DiagnoseVolMetaPartition diagnoses the meta partitions of the volume only.
+func (api *AdminAPI) DiagnoseVolMetaPartition(volName string) (diagnosis *proto.MetaPartitionDiagnosis, err error) {
+	return newDiagnoseMetaPartitionRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+// ListMetaPartitions lists the meta partitions matching the filters, the empty filters match all.
+// A zero limit means no limit.
+func (api *AdminAPI) ListMetaPartitions(volName, status, nodeAddr string, offset, limit int) (view *proto.MetaPartitionListView, err error) {
+	return newListMetaPartitionsRequest().
+		withName(volName).
+		withStatus(status).
+		withAddr(nodeAddr).
+		withOffset(offset).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) LoadDataPartition(volName string, partitionID uint64) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminLoadDataPartition)
-	request.addParam("id", strconv.Itoa(int(partitionID)))
-	request.addParam("name", volName)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newLoadDataPartitionRequest().
+		withID(partitionID).
+		withName(volName).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) CreateDataPartition(volName string, count int) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminCreateDataPartition)
-	request.addParam("name", volName)
-	request.addParam("count", strconv.Itoa(count))
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newCreateDataPartitionRequest().
+		withName(volName).
+		withCount(count).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DecommissionDataPartition(dataPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminDecommissionDataPartition)
-	request.addParam("id", strconv.FormatUint(dataPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDecommissionDataPartitionRequest().
+		withID(dataPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ResetDataPartition(dataPartitionID uint64) (err error) {
+	request := newResetDataPartitionRequest().withID(dataPartitionID)
+	request.addHeader("isTimeOut", "false")
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DecommissionMetaPartition(metaPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminDecommissionMetaPartition)
-	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDecommissionMetaPartitionRequest().
+		withID(metaPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ResetMetaPartition(metaPartitionID uint64) (err error) {
+	request := newResetMetaPartitionRequest().withID(metaPartitionID)
+	request.addHeader("isTimeOut", "false")
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DeleteDataReplica(dataPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteDataReplica)
-	request.addParam("id", strconv.FormatUint(dataPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDeleteDataReplicaRequest().
+		withID(dataPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) AddDataReplica(dataPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminAddDataReplica)
-	request.addParam("id", strconv.FormatUint(dataPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newAddDataReplicaRequest().
+		withID(dataPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) DeleteMetaReplica(metaPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteMetaReplica)
-	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDeleteMetaReplicaRequest().
+		withID(metaPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) AddMetaReplica(metaPartitionID uint64, nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminAddMetaReplica)
-	request.addParam("id", strconv.FormatUint(metaPartitionID, 10))
-	request.addParam("addr", nodeAddr)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
+	return newAddMetaReplicaRequest().
+		withID(metaPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+// TransferDataPartitionLeader makes the replica on the node the raft leader of the data partition.
+func (api *AdminAPI) TransferDataPartitionLeader(dataPartitionID uint64, nodeAddr string) (err error) {
+	return newTransferDataLeaderRequest().
+		withID(dataPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+// SetDataPartitionStatus forces the data partition read-only or writable by the status of proto.PartitionStatusReadOnly
+// and proto.PartitionStatusWritable, or returns it to the status decided by the master by proto.PartitionStatusAuto.
+func (api *AdminAPI) SetDataPartitionStatus(dataPartitionID uint64, status, reason string) (err error) {
+	request := newSetDataPartitionStatusRequest().withID(dataPartitionID).withStatus(status)
+	if reason != "" {
+		request = request.withReason(reason)
+	}
+	return request.serve(api.ctx, api.mc)
+}
+
+// TransferMetaPartitionLeader makes the replica on the node the raft leader of the meta partition.
+func (api *AdminAPI) TransferMetaPartitionLeader(metaPartitionID uint64, nodeAddr string) (err error) {
+	return newTransferMetaLeaderRequest().
+		withID(metaPartitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+// SubmitPartitionTask submits an admin operation on the partition, such as decommission, add replica and delete
+// replica, to be executed in background. The returned task ID can be used to query the progress by GetTaskStatus.
+func (api *AdminAPI) SubmitPartitionTask(path string, partitionID uint64, nodeAddr string) (task *proto.AsyncTaskInfo, err error) {
+	var req *request
+	switch path {
+	case proto.AdminDecommissionDataPartition:
+		req = newDecommissionDataPartitionRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	case proto.AdminAddDataReplica:
+		req = newAddDataReplicaRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	case proto.AdminDeleteDataReplica:
+		req = newDeleteDataReplicaRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	case proto.AdminDecommissionMetaPartition:
+		req = newDecommissionMetaPartitionRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	case proto.AdminAddMetaReplica:
+		req = newAddMetaReplicaRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	case proto.AdminDeleteMetaReplica:
+		req = newDeleteMetaReplicaRequest().withID(partitionID).withAddr(nodeAddr).withAsync(true).request
+	default:
+		return nil, fmt.Errorf("operation %v can not be submitted as a task", path)
+	}
+	// the reply of the operations depends on the params, so it is decoded here rather than by the generated serve
+	task = &proto.AsyncTaskInfo{}
+	if err = api.mc.serveRequestInto(api.ctx, req, task); err != nil {
+		return nil, err
 	}
 	return
 }
 
-func (api *AdminAPI) DeleteVolume(volName, authKey string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminDeleteVol)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
+// PlanPartitionOperation returns the expected result of an admin operation on the partition, such as decommission,
+// delete replica and reset, without executing it. The node address is ignored by the operations which do not need it.
+func (api *AdminAPI) PlanPartitionOperation(path string, partitionID uint64, nodeAddr string) (plan *proto.PartitionOperationPlan, err error) {
+	var req *request
+	switch path {
+	case proto.AdminDecommissionDataPartition:
+		req = newDecommissionDataPartitionRequest().withID(partitionID).withAddr(nodeAddr).withDryRun(true).request
+	case proto.AdminDeleteDataReplica:
+		req = newDeleteDataReplicaRequest().withID(partitionID).withAddr(nodeAddr).withDryRun(true).request
+	case proto.AdminResetDataPartition:
+		req = newResetDataPartitionRequest().withID(partitionID).withDryRun(true).request
+	case proto.AdminDecommissionMetaPartition:
+		req = newDecommissionMetaPartitionRequest().withID(partitionID).withAddr(nodeAddr).withDryRun(true).request
+	case proto.AdminDeleteMetaReplica:
+		req = newDeleteMetaReplicaRequest().withID(partitionID).withAddr(nodeAddr).withDryRun(true).request
+	case proto.AdminResetMetaPartition:
+		req = newResetMetaPartitionRequest().withID(partitionID).withDryRun(true).request
+	default:
+		return nil, fmt.Errorf("operation %v can not be planned", path)
+	}
+	plan = &proto.PartitionOperationPlan{}
+	if err = api.mc.serveRequestInto(api.ctx, req, plan); err != nil {
+		return nil, err
 	}
 	return
 }
 
+func (api *AdminAPI) GetTaskStatus(taskID uint64) (task *proto.AsyncTaskInfo, err error) {
+	return newGetTaskRequest().withID(taskID).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListTasks() (tasks []*proto.AsyncTaskInfo, err error) {
+	return newListTasksRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListOrphanPartitions() (orphans []*proto.OrphanPartitionView, err error) {
+	return newListOrphanPartitionsRequest().serve(api.ctx, api.mc)
+}
+
+// GetStalePartitionGC returns the stale replicas of the purged volumes pending and deleted by the stale partition GC.
+func (api *AdminAPI) GetStalePartitionGC() (view *proto.StalePartitionGCView, err error) {
+	return newGetStalePartitionGCRequest().serve(api.ctx, api.mc)
+}
+
+// GetConsistencyReport returns the findings cached by the consistency checker of the master.
+func (api *AdminAPI) GetConsistencyReport() (report *proto.ConsistencyReport, err error) {
+	return newGetConsistencyReportRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) CleanOrphanPartition(partitionType string, partitionID uint64, nodeAddr string) (err error) {
+	return newCleanOrphanPartitionRequest().
+		withType(partitionType).
+		withID(partitionID).
+		withAddr(nodeAddr).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) DeleteVolume(volName, authKey string) (err error) {
+	return newDeleteVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		serve(api.ctx, api.mc)
+}
+
+// RestoreVolume recovers the volume deleted within the retention window.
+func (api *AdminAPI) RestoreVolume(volName, authKey string) (err error) {
+	return newRestoreVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		serve(api.ctx, api.mc)
+}
+
 func (api *AdminAPI) UpdateVolume(volName string, capacity uint64, replicas int, followerRead, authenticate, enableToken bool, authKey, zoneName string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminUpdateVol)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	request.addParam("capacity", strconv.FormatUint(capacity, 10))
-	request.addParam("replicaNum", strconv.Itoa(replicas))
-	request.addParam("followerRead", strconv.FormatBool(followerRead))
-	request.addParam("enableToken", strconv.FormatBool(enableToken))
-	request.addParam("authenticate", strconv.FormatBool(authenticate))
-	request.addParam("zoneName", zoneName)
-	if _, err = api.mc.serveRequest(request); err != nil {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withCapacity(capacity).
+		withReplicaNum(replicas).
+		withFollowerRead(followerRead).
+		withEnableToken(enableToken).
+		withAuthenticate(authenticate).
+		withZoneName(zoneName).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumePlacement sets the replica placement policy of the volume, the zone is required by the zone-pinned policy
+// only. The policy "default" resets the placement policy.
+func (api *AdminAPI) SetVolumePlacement(volName, authKey, policy, zoneName string) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withPlacementPolicy(policy).
+		withPlacementZone(zoneName).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumePlacementLabels restricts the new replicas of the partitions of the volume to the nodes having all the
+// labels, the empty labels remove the constraints.
+func (api *AdminAPI) SetVolumePlacementLabels(volName, authKey string, labels map[string]string) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withPlacementLabels(proto.FormatLabels(labels)).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeQos sets the IOPS and the bandwidth limits of the volume, 0 removes the limit.
+func (api *AdminAPI) SetVolumeQos(volName, authKey string, qos proto.VolQos) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withReadIopsLimit(qos.ReadIops).
+		withWriteIopsLimit(qos.WriteIops).
+		withReadBpsLimit(qos.ReadBps).
+		withWriteBpsLimit(qos.WriteBps).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeIPAcl sets the CIDRs of the allowed and the denied clients of the volume, an empty list removes the
+// restriction.
+func (api *AdminAPI) SetVolumeIPAcl(volName, authKey string, ipAcl proto.VolIPAcl) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withIpAllow(strings.Join(ipAcl.Allow, ",")).
+		withIpDeny(strings.Join(ipAcl.Deny, ",")).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeTrashTTL sets the seconds to keep the removed files in the trash of the clients, 0 disables the trash.
+func (api *AdminAPI) SetVolumeTrashTTL(volName, authKey string, ttl uint64) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withTrashTTL(ttl).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeVerifyReadCrc sets if the data read from the volume is checked against the crc of the extent blocks.
+func (api *AdminAPI) SetVolumeVerifyReadCrc(volName, authKey string, verify bool) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withVerifyReadCrc(verify).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeCompression sets the algorithm the data nodes compress the cold extents of the volume with, none disables
+// the compression.
+func (api *AdminAPI) SetVolumeCompression(volName, authKey, algorithm string) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withCompression(algorithm).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeEncrypted encrypts the extents of the volume at rest on the data nodes, the encryption can not be
+// disabled once it is enabled.
+func (api *AdminAPI) SetVolumeEncrypted(volName, authKey string, encrypted bool) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withEncrypted(encrypted).
+		serve(api.ctx, api.mc)
+}
+
+// RotateVolumeKey generates a new data key of the encrypted volume, the data nodes encrypt the extents with it again.
+func (api *AdminAPI) RotateVolumeKey(volName, authKey string) (err error) {
+	return newRotateVolKeyRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeMetaStore sets the store of the new meta partitions of the volume, empty for the default of the meta nodes.
+func (api *AdminAPI) SetVolumeMetaStore(volName, authKey, metaStore string) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withMetaStore(metaStore).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolumeInodeRetention sets the seconds to keep the deleted inodes of the volume before purging them, 0 for the
+// default of the meta nodes.
+func (api *AdminAPI) SetVolumeInodeRetention(volName, authKey string, retention uint64) (err error) {
+	return newUpdateVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withInodeRetention(retention).
+		serve(api.ctx, api.mc)
+}
+
+// GetVolQos returns the IOPS and the bandwidth limits of the volumes which are limited.
+func (api *AdminAPI) GetVolQos() (volQos map[string]proto.VolQos, err error) {
+	return newGetVolQosRequest().serve(api.ctx, api.mc)
+}
+
+// GetVolIPAcl returns the client IP restrictions of the volumes which are restricted.
+func (api *AdminAPI) GetVolIPAcl() (volIPAcl map[string]proto.VolIPAcl, err error) {
+	return newGetVolIPAclRequest().serve(api.ctx, api.mc)
+}
+
+// GetVerifyReadCrcVols returns the names of the volumes whose data read is checked against the crc.
+func (api *AdminAPI) GetVerifyReadCrcVols() (names []string, err error) {
+	return newGetVerifyReadCrcVolsRequest().serve(api.ctx, api.mc)
+}
+
+// GetCompressionVols returns the compression algorithms of the volumes with compression.
+func (api *AdminAPI) GetCompressionVols() (algorithms map[string]string, err error) {
+	return newGetCompressionVolsRequest().serve(api.ctx, api.mc)
+}
+
+// GetVolEncryptionKeys returns the data keys of the encrypted volumes, the client must be set the ticket with the
+// caps "API:master:getvolkeys:access" by SetAuthTicket.
+func (api *AdminAPI) GetVolEncryptionKeys() (keys map[string]*proto.VolEncryptionKeys, err error) {
+	message, err := newGetVolEncryptionKeysRequest().serve(api.ctx, api.mc)
+	if err != nil {
+		return
+	}
+	data, err := api.mc.decodeTicketReply(message)
+	if err != nil {
 		return
 	}
+	keys = make(map[string]*proto.VolEncryptionKeys)
+	err = json.Unmarshal(data, &keys)
 	return
 }
 
-func (api *AdminAPI) VolShrink(volName string, capacity uint64, authKey string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminVolShrink)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	request.addParam("capacity", strconv.FormatUint(capacity, 10))
-	if _, err = api.mc.serveRequest(request); err != nil {
+// SetDirQuota sets the limits of the directory quota of the path, the quota is created if the path has no quota.
+func (api *AdminAPI) SetDirQuota(volName, path string, maxBytes, maxFiles uint64) (reply *proto.DirQuotaReply, err error) {
+	return newSetDirQuotaRequest().
+		withName(volName).
+		withPath(path).
+		withMaxBytes(maxBytes).
+		withMaxFiles(maxFiles).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) DeleteDirQuota(volName string, quotaID uint32) (reply *proto.DirQuotaReply, err error) {
+	return newDeleteDirQuotaRequest().
+		withName(volName).
+		withQuotaID(uint64(quotaID)).
+		serve(api.ctx, api.mc)
+}
+
+// ListDirQuotas returns the directory quotas of the volume and their usage.
+func (api *AdminAPI) ListDirQuotas(volName string) (quotas []*proto.DirQuota, err error) {
+	return newListDirQuotasRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+// SetExpirationRule sets the expiration rule of the directory, the files in its subtree which are not modified or
+// accessed, by the basis, for the days are deleted. The rule is created if the directory has no rule.
+func (api *AdminAPI) SetExpirationRule(volName, path string, days uint32, basis string) (reply *proto.ExpirationRuleReply, err error) {
+	return newSetExpirationRuleRequest().
+		withName(volName).
+		withPath(path).
+		withDays(uint64(days)).
+		withBasis(basis).
+		serve(api.ctx, api.mc)
+}
+
+// DeleteExpirationRule deletes the expiration rule of the volume.
+func (api *AdminAPI) DeleteExpirationRule(volName string, ruleID uint32) (reply *proto.ExpirationRuleReply, err error) {
+	return newDeleteExpirationRuleRequest().
+		withName(volName).
+		withRuleID(uint64(ruleID)).
+		serve(api.ctx, api.mc)
+}
+
+// ListExpirationRules returns the expiration rules of the volume.
+func (api *AdminAPI) ListExpirationRules(volName string) (rules []*proto.ExpirationRule, err error) {
+	return newListExpirationRulesRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+// SetLifecycleRule sets the lifecycle rule of the directory, the files in its subtree which are not modified or
+// accessed, by the basis, for the days are transited to the storage class. The rule is created if the directory has
+// no rule.
+func (api *AdminAPI) SetLifecycleRule(volName, path string, days uint32, basis, class string) (reply *proto.LifecycleRuleReply, err error) {
+	return newSetLifecycleRuleRequest().
+		withName(volName).
+		withPath(path).
+		withDays(uint64(days)).
+		withBasis(basis).
+		withStorageClass(class).
+		serve(api.ctx, api.mc)
+}
+
+// DeleteLifecycleRule deletes the lifecycle rule of the volume.
+func (api *AdminAPI) DeleteLifecycleRule(volName string, ruleID uint32) (reply *proto.LifecycleRuleReply, err error) {
+	return newDeleteLifecycleRuleRequest().
+		withName(volName).
+		withRuleID(uint64(ruleID)).
+		serve(api.ctx, api.mc)
+}
+
+// ListLifecycleRules returns the lifecycle rules of the volume.
+func (api *AdminAPI) ListLifecycleRules(volName string) (rules []*proto.LifecycleRule, err error) {
+	return newListLifecycleRulesRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+// CreateVolSnapshot creates a snapshot of the volume, the metadata is stored by the returned task in background.
+func (api *AdminAPI) CreateVolSnapshot(volName, snapshot string) (reply *proto.VolSnapshotReply, err error) {
+	return newCreateVolSnapshotRequest().
+		withName(volName).
+		withSnapshot(snapshot).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListVolSnapshots(volName string) (snapshots []*proto.VolSnapshot, err error) {
+	return newListVolSnapshotsRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) DeleteVolSnapshot(volName string, snapshotID uint64) (reply *proto.VolSnapshotReply, err error) {
+	return newDeleteVolSnapshotRequest().
+		withName(volName).
+		withSnapshotID(snapshotID).
+		serve(api.ctx, api.mc)
+}
+
+// RollbackVolSnapshot replaces the metadata of the volume with the snapshot, the clients should be unmounted.
+func (api *AdminAPI) RollbackVolSnapshot(volName string, snapshotID uint64) (reply *proto.VolSnapshotReply, err error) {
+	return newRollbackVolSnapshotRequest().
+		withName(volName).
+		withSnapshotID(snapshotID).
+		serve(api.ctx, api.mc)
+}
+
+// SetVolReplication replicates the volume to the volume of the standby cluster asynchronously, or stops the
+// replication if the target volume is empty.
+func (api *AdminAPI) SetVolReplication(volName, authKey string, targetMasters []string, targetVol string, maxLag int64) (view *proto.VolReplicationView, err error) {
+	return newSetVolReplicationRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withTargetMaster(strings.Join(targetMasters, ",")).
+		withTargetVol(targetVol).
+		withMaxLag(maxLag).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) GetVolReplication(volName string) (view *proto.VolReplicationView, err error) {
+	return newGetVolReplicationRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListVolReplications() (views []*proto.VolReplicationView, err error) {
+	return newListVolReplicationsRequest().serve(api.ctx, api.mc)
+}
+
+// ReportVolReplication reports the progress of the replication of the volume, which fails if the volume is no
+// longer replicated.
+func (api *AdminAPI) ReportVolReplication(volName string, status *proto.VolReplicationStatus) (err error) {
+	var request reportVolReplicationRequest
+	if request, err = newReportVolReplicationRequest().withName(volName).withBody(status); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
+}
+
+// FailoverVolReplication stops the replication of the volume, and the standby volume becomes the primary.
+func (api *AdminAPI) FailoverVolReplication(volName, authKey string) (view *proto.VolReplicationView, err error) {
+	return newFailoverVolReplicationRequest().withName(volName).withAuthKey(authKey).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) VolShrink(volName string, capacity uint64, authKey string) (err error) {
+	return newShrinkVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withCapacity(capacity).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) VolExpand(volName string, capacity uint64, authKey string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminVolExpand)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	request.addParam("capacity", strconv.FormatUint(capacity, 10))
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newExpandVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withCapacity(capacity).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) CreateVolume(volName, owner string, mpCount int,
-	dpSize uint64, capacity uint64, replicas int, followerRead bool, zoneName string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminCreateVol)
-	request.addParam("name", volName)
-	request.addParam("owner", owner)
-	request.addParam("mpCount", strconv.Itoa(mpCount))
-	request.addParam("size", strconv.FormatUint(dpSize, 10))
-	request.addParam("capacity", strconv.FormatUint(capacity, 10))
-	request.addParam("followerRead", strconv.FormatBool(followerRead))
-	request.addParam("zoneName", zoneName)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	dpSize uint64, capacity uint64, replicas int, followerRead bool, zoneName string, caseInsensitive bool) (err error) {
+	return newCreateVolRequest().
+		withName(volName).
+		withOwner(owner).
+		withMpCount(mpCount).
+		withSize(dpSize).
+		withCapacity(capacity).
+		withFollowerRead(followerRead).
+		withZoneName(zoneName).
+		withCaseInsensitive(caseInsensitive).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) CreateDefaultVolume(volName, owner string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminCreateVol)
-	request.addParam("name", volName)
-	request.addParam("owner", owner)
-	request.addParam("capacity", "10")
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newCreateVolRequest().
+		withName(volName).
+		withOwner(owner).
+		withCapacity(10).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) CloneVolume(srcName, dstName, owner string) (task *proto.AsyncTaskInfo, err error) {
+	request := newCloneVolRequest().
+		withName(srcName).
+		withTarget(dstName)
+	if owner != "" {
+		request.withOwner(owner)
+	}
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) GetVolumeSimpleInfo(volName string) (vv *proto.SimpleVolView, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminGetVol)
-	request.addParam("name", volName)
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	vv = &proto.SimpleVolView{}
-	if err = json.Unmarshal(buf, &vv); err != nil {
-		return
-	}
-	return
+	return newGetVolSimpleInfoRequest().withName(volName).serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) GetClusterInfo() (ci *proto.ClusterInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminGetIP)
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	ci = &proto.ClusterInfo{}
-	if err = json.Unmarshal(buf, &ci); err != nil {
-		return
-	}
-	return
+	return newGetClusterInfoRequest().serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) CreateMetaPartition(volName string, inodeStart uint64) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminCreateMetaPartition)
-	request.addParam("name", volName)
-	request.addParam("start", strconv.FormatUint(inodeStart, 10))
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newCreateMetaPartitionRequest().
+		withName(volName).
+		withStart(inodeStart).
+		serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) ListVols(keywords string) (volsInfo []*proto.VolInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminListVols)
-	request.addParam("keywords", keywords)
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	volsInfo = make([]*proto.VolInfo, 0)
-	if err = json.Unmarshal(buf, &volsInfo); err != nil {
-		return
+	return newListVolsRequest().withKeywords(keywords).serve(api.ctx, api.mc)
+}
+
+// ListDeletedVols lists the deleted volumes which are kept in the retention window.
+func (api *AdminAPI) ListDeletedVols(keywords string) (volsInfo []*proto.VolInfo, err error) {
+	return newListVolsRequest().
+		withKeywords(keywords).
+		withDeleted(true).
+		serve(api.ctx, api.mc)
+}
+
+// ListVolsPage lists at most limit volumes whose names are greater than the marker in the order of name.
+func (api *AdminAPI) ListVolsPage(keywords, marker string, limit int) (volsInfo []*proto.VolInfo, err error) {
+	return newListVolsRequest().
+		withKeywords(keywords).
+		withMarker(marker).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
+}
+
+// RangeVols lists the volumes page by page in the order of name, and calls f with each page until f returns
+// an error or all the volumes are listed. The listing ends if a page is not full, including the masters which
+// do not support paging and return all the volumes at once.
+func (api *AdminAPI) RangeVols(keywords string, pageSize int, f func(volsInfo []*proto.VolInfo) error) (err error) {
+	var marker string
+	for {
+		var volsInfo []*proto.VolInfo
+		if volsInfo, err = api.ListVolsPage(keywords, marker, pageSize); err != nil {
+			return
+		}
+		if len(volsInfo) > 0 {
+			if err = f(volsInfo); err != nil {
+				return
+			}
+			marker = volsInfo[len(volsInfo)-1].Name
+		}
+		if pageSize <= 0 || len(volsInfo) != pageSize {
+			return
+		}
 	}
-	return
 }
 
 func (api *AdminAPI) IsFreezeCluster(isFreeze bool) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminClusterFreeze)
-	request.addParam("enable", strconv.FormatBool(isFreeze))
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newFreezeClusterRequest().withEnable(isFreeze).serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) SetMetaNodeThreshold(threshold float64) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminSetMetaNodeThreshold)
-	request.addParam("threshold", strconv.FormatFloat(threshold, 'f', 6, 64))
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newSetMetaNodeThresholdRequest().withThreshold(threshold).serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) SetDeleteParas(batchCount, markDeleteRate, deleteWorkerSleepMs, autoRepairRate string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminSetNodeInfo)
-	request.addParam("batchCount", batchCount)
-	request.addParam("markDeleteRate", markDeleteRate)
-	request.addParam("deleteWorkerSleepMs", deleteWorkerSleepMs)
-	request.addParam("autoRepairRate", autoRepairRate)
+	request := newSetNodeInfoRequest()
+	for _, param := range []struct {
+		value string
+		set   func(uint64) setNodeInfoRequest
+	}{
+		{batchCount, request.withBatchCount},
+		{markDeleteRate, request.withMarkDeleteRate},
+		{deleteWorkerSleepMs, request.withDeleteWorkerSleepMs},
+		{autoRepairRate, request.withAutoRepairRate},
+	} {
+		if param.value == "" {
+			continue
+		}
+		var value uint64
+		if value, err = strconv.ParseUint(param.value, 10, 64); err != nil {
+			return
+		}
+		param.set(value)
+	}
+	return request.serve(api.ctx, api.mc)
+}
 
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+// SetAutoReplicaSupplementLimit sets the max number of partitions recovering from the replicas added automatically
+// by the master for the lacked replicas. The automatic replica supplement is disabled if the limit is 0.
+func (api *AdminAPI) SetAutoReplicaSupplementLimit(limit uint64) (err error) {
+	return newSetNodeInfoRequest().withAutoSupplementLimit(limit).serve(api.ctx, api.mc)
+}
+
+// SetVolDeletionDelay sets the seconds to keep the deleted volumes recoverable before they are purged, the volumes
+// are purged immediately if the delay is 0.
+func (api *AdminAPI) SetVolDeletionDelay(delay uint64) (err error) {
+	return newSetNodeInfoRequest().withVolDeletionDelay(delay).serve(api.ctx, api.mc)
+}
+
+// SetMetaPartitionSplitThreshold sets the inodes and the estimated memory in bytes of a meta partition to trigger
+// the split of the last meta partition of the volume, 0 for no limit.
+func (api *AdminAPI) SetMetaPartitionSplitThreshold(inodeCount, memory uint64) (err error) {
+	return newSetNodeInfoRequest().
+		withMpSplitInodeCount(inodeCount).
+		withMpSplitMemory(memory).
+		serve(api.ctx, api.mc)
+}
+
+// SetDecommissionControls sets the max number of the data partitions migrating from a decommissioned data node at
+// the same time and the bytes per second to repair a migrated data partition, 0 for no limit.
+func (api *AdminAPI) SetDecommissionControls(limit, bandwidth uint64) (err error) {
+	return newSetNodeInfoRequest().
+		withDecommissionLimit(limit).
+		withDecommissionBandwidth(bandwidth).
+		serve(api.ctx, api.mc)
+}
+
+// GetMetaRebalance returns the configuration of the meta rebalancer, the memory usage of the meta nodes and the
+// meta partitions being migrated.
+func (api *AdminAPI) GetMetaRebalance() (view *proto.RebalanceView, err error) {
+	return newGetMetaRebalanceRequest().serve(api.ctx, api.mc)
+}
+
+// SetMetaRebalance sets the memory usage ratios of the meta rebalancer, the max number of the meta partitions
+// migrating at the same time and the estimated memory per second of the meta partitions started to migrate.
+// The meta rebalancer is disabled if the limit is 0.
+func (api *AdminAPI) SetMetaRebalance(highRatio, lowRatio float64, limit, bandwidth uint64) (err error) {
+	return newSetMetaRebalanceRequest().
+		withHighRatio(highRatio).
+		withLowRatio(lowRatio).
+		withLimit(limit).
+		withBandwidth(bandwidth).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) PauseMetaRebalance() (err error) {
+	return newPauseMetaRebalanceRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ResumeMetaRebalance() (err error) {
+	return newResumeMetaRebalanceRequest().serve(api.ctx, api.mc)
+}
+
+// GetDataRebalance returns the configuration of the data rebalancer, the disk usage of the data nodes and the
+// data partitions being migrated.
+func (api *AdminAPI) GetDataRebalance() (view *proto.RebalanceView, err error) {
+	return newGetDataRebalanceRequest().serve(api.ctx, api.mc)
+}
+
+// SetDataRebalance sets the disk usage ratios of the data rebalancer, the max number of the data partitions
+// migrating at the same time and the bytes per second of the data partitions started to migrate.
+// The data rebalancer is disabled if the limit is 0.
+func (api *AdminAPI) SetDataRebalance(highRatio, lowRatio float64, limit, bandwidth uint64) (err error) {
+	return newSetDataRebalanceRequest().
+		withHighRatio(highRatio).
+		withLowRatio(lowRatio).
+		withLimit(limit).
+		withBandwidth(bandwidth).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) PauseDataRebalance() (err error) {
+	return newPauseDataRebalanceRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ResumeDataRebalance() (err error) {
+	return newResumeDataRebalanceRequest().serve(api.ctx, api.mc)
 }
 
 func (api *AdminAPI) GetDeleteParas() (delParas map[string]string, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AdminGetNodeInfo)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
+	return newGetNodeInfoRequest().serve(api.ctx, api.mc)
+}
+
+// ListEvents returns the recent events emitted by the master after the event ID, filtered by the event type if it
+// is not empty. All the events are returned if the limit is 0.
+func (api *AdminAPI) ListEvents(since uint64, eventType string, limit int) (events []*proto.Event, err error) {
+	return newListEventsRequest().
+		withSince(since).
+		withType(eventType).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
+}
+
+// ListAuditRecords returns the latest audit records of the admin calls started since the unix time,
+// up to the limit, 0 means the default limit of the master.
+func (api *AdminAPI) ListAuditRecords(since int64, limit int) (records []*proto.AuditRecord, err error) {
+	return newListAuditRecordsRequest().
+		withSince(since).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
+}
+
+// SetAlertRule adds the alert rule, or replaces the alert rule of the same name.
+func (api *AdminAPI) SetAlertRule(rule *proto.AlertRule) (err error) {
+	return newSetAlertRuleRequest().
+		withName(rule.Name).
+		withMetric(rule.Metric).
+		withOperator(rule.Operator).
+		withThreshold(rule.Threshold).
+		withDuration(rule.Duration).
+		withDisabled(rule.Disabled).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) DeleteAlertRule(name string) (err error) {
+	return newDeleteAlertRuleRequest().withName(name).serve(api.ctx, api.mc)
+}
+
+// ListAlertRules returns the alert rules and the alerts whose conditions hold.
+func (api *AdminAPI) ListAlertRules() (view *proto.AlertRulesView, err error) {
+	return newListAlertRulesRequest().serve(api.ctx, api.mc)
+}
+
+// GetConfig returns the parameter of the master which can be changed at runtime.
+func (api *AdminAPI) GetConfig(name string) (item *proto.RuntimeConfigItem, err error) {
+	return newGetConfigRequest().withName(name).serve(api.ctx, api.mc)
+}
+
+// SetConfig changes the parameter of the master without restarting, the value is persisted and overrides
+// the value in the config file of the master.
+func (api *AdminAPI) SetConfig(name, value string) (err error) {
+	return newSetConfigRequest().
+		withName(name).
+		withValue(value).
+		serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListConfig() (items []*proto.RuntimeConfigItem, err error) {
+	return newListConfigRequest().serve(api.ctx, api.mc)
+}
+
+// CreateMetadataBackup backs up the metadata of the masters to the object store now.
+func (api *AdminAPI) CreateMetadataBackup() (info *proto.MetadataBackupInfo, err error) {
+	return newCreateMetadataBackupRequest().serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListMetadataBackups() (backups []*proto.MetadataBackupInfo, err error) {
+	return newListMetadataBackupsRequest().serve(api.ctx, api.mc)
+}
+
+// RestoreMetadataBackup restores the metadata of an empty cluster from the backup of the key, or from the
+// latest backup taken before the unix time if the key is empty. The current time is used if the time is 0.
+func (api *AdminAPI) RestoreMetadataBackup(key string, before int64) (info *proto.MetadataBackupInfo, err error) {
+	request := newRestoreMetadataBackupRequest()
+	if key != "" {
+		request = request.withKey(key)
 	}
-	var buf []byte
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
+	if before > 0 {
+		request = request.withTime(before)
 	}
-	delParas = make(map[string]string)
-	if err = json.Unmarshal(buf, &delParas); err != nil {
-		return
+	return request.serve(api.ctx, api.mc)
+}
+
+// GetCapacityForecast forecasts the time the volume becomes full, or all the volumes and the zones if the name
+// is empty, by the samples of the recent days. The default days of the master are used if the days is 0.
+func (api *AdminAPI) GetCapacityForecast(name string, days int) (view *proto.CapacityForecastView, err error) {
+	request := newGetCapacityForecastRequest()
+	if name != "" {
+		request = request.withName(name)
 	}
-	return
+	if days > 0 {
+		request = request.withDays(days)
+	}
+	return request.serve(api.ctx, api.mc)
+}
+
+// AddFederationPeer registers the peer cluster in the federation, or replaces the peer of the same name.
+func (api *AdminAPI) AddFederationPeer(name, region string, masters []string) (err error) {
+	return newAddFederationPeerRequest().withName(name).withRegion(region).
+		withMasters(strings.Join(masters, ",")).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) RemoveFederationPeer(name string) (err error) {
+	return newRemoveFederationPeerRequest().withName(name).serve(api.ctx, api.mc)
+}
+
+func (api *AdminAPI) ListFederationPeers() (peers []*proto.FederationPeer, err error) {
+	return newListFederationPeersRequest().serve(api.ctx, api.mc)
+}
+
+// GetFederation returns the capacity and the health of the local cluster and the peer clusters, which are got
+// from the masters of the peers by the master.
+func (api *AdminAPI) GetFederation() (view *proto.FederationView, err error) {
+	request := newGetFederationRequest()
+	request.addHeader("isTimeOut", "false")
+	return request.serve(api.ctx, api.mc)
 }
@@ -15,9 +15,8 @@
 package master
 
 import (
+	"context"
 	"encoding/json"
-	"net/http"
-	"net/url"
 	"strconv"
 
 	"github.com/chubaofs/chubaofs/proto"
@@ -30,46 +29,37 @@ func (d Decoder) Decode(raw []byte) ([]byte, error) {
 }
 
 type ClientAPI struct {
-	mc *MasterClient
+	mc  *MasterClient
+	ctx context.Context
+}
+
+// WithContext returns the client APIs whose requests are canceled when the context is done.
+func (api *ClientAPI) WithContext(ctx context.Context) *ClientAPI {
+	return &ClientAPI{mc: api.mc, ctx: ctx}
 }
 
 func (api *ClientAPI) GetVolume(volName string, authKey string) (vv *proto.VolView, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.ClientVol)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	vv = &proto.VolView{}
-	if err = json.Unmarshal(data, vv); err != nil {
-		return
-	}
-	return
+	return newGetVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetVolumeWithoutAuthKey(volName string) (vv *proto.VolView, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.ClientVol)
-	request.addParam("name", volName)
+	request := newGetVolRequest().withName(volName)
 	request.addHeader(proto.SkipOwnerValidation, strconv.FormatBool(true))
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	vv = &proto.VolView{}
-	if err = json.Unmarshal(data, vv); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetVolumeWithAuthnode(volName string, authKey string, token string, decoder Decoder) (vv *proto.VolView, err error) {
 	var body []byte
-	var request = newAPIRequest(http.MethodPost, proto.ClientVol)
-	request.addParam("name", volName)
-	request.addParam("authKey", authKey)
-	request.addParam(proto.ClientMessage, token)
-	if body, err = api.mc.serveRequest(request); err != nil {
+	request := newGetVolRequest().
+		withName(volName).
+		withAuthKey(authKey).
+		withToken(token)
+	// the reply encrypted by the session key is only served by the leader
+	request.readOnly = false
+	if body, err = api.mc.serveRequest(api.ctx, request.request); err != nil {
 		return
 	}
 	if decoder != nil {
@@ -85,71 +75,87 @@ func (api *ClientAPI) GetVolumeWithAuthnode(volName string, authKey string, toke
 }
 
 func (api *ClientAPI) GetVolumeStat(volName string) (info *proto.VolStatInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.ClientVolStat)
-	request.addParam("name", volName)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	info = &proto.VolStatInfo{}
-	if err = json.Unmarshal(data, info); err != nil {
-		return
-	}
-	return
+	return newGetVolStatRequest().withName(volName).serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetToken(volName, tokenKey string) (token *proto.Token, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.TokenGetURI)
-	request.addParam("name", volName)
-	request.addParam("token", url.QueryEscape(tokenKey))
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	token = &proto.Token{}
-	if err = json.Unmarshal(data, token); err != nil {
-		return
-	}
-	return
+	return newGetTokenRequest().
+		withName(volName).
+		withToken(tokenKey).
+		serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetMetaPartition(partitionID uint64) (partition *proto.MetaPartitionInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.ClientMetaPartition)
-	request.addParam("id", strconv.FormatUint(partitionID, 10))
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	partition = &proto.MetaPartitionInfo{}
-	if err = json.Unmarshal(data, partition); err != nil {
-		return
-	}
-	return
+	return newGetMetaPartitionRequest().withID(partitionID).serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetMetaPartitions(volName string) (views []*proto.MetaPartitionView, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.ClientMetaPartitions)
-	request.addParam("name", volName)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	if err = json.Unmarshal(data, &views); err != nil {
-		return
-	}
-	return
+	return newGetMetaPartitionsRequest().withName(volName).serve(api.ctx, api.mc)
 }
 
 func (api *ClientAPI) GetDataPartitions(volName string) (view *proto.DataPartitionsView, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.ClientDataPartitions)
-	request.addParam("name", volName)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
+	return newGetDataPartitionsRequest().withName(volName).serve(api.ctx, api.mc)
+}
+
+// GetMetaPartitionsPage gets at most limit meta partitions of the volume whose IDs are greater than the marker
+// in the order of ID.
+func (api *ClientAPI) GetMetaPartitionsPage(volName string, marker uint64, limit int) (views []*proto.MetaPartitionView, err error) {
+	return newGetMetaPartitionsRequest().
+		withName(volName).
+		withMarker(marker).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
+}
+
+// RangeMetaPartitions gets the meta partitions of the volume page by page in the order of ID, and calls f with
+// each page until f returns an error or all the partitions are got.
+func (api *ClientAPI) RangeMetaPartitions(volName string, pageSize int, f func(views []*proto.MetaPartitionView) error) (err error) {
+	var marker uint64
+	for {
+		var views []*proto.MetaPartitionView
+		if views, err = api.GetMetaPartitionsPage(volName, marker, pageSize); err != nil {
+			return
+		}
+		if len(views) > 0 {
+			if err = f(views); err != nil {
+				return
+			}
+			marker = views[len(views)-1].PartitionID
+		}
+		if pageSize <= 0 || len(views) != pageSize {
+			return
+		}
 	}
-	view = &proto.DataPartitionsView{}
-	if err = json.Unmarshal(data, view); err != nil {
-		return
+}
+
+// GetDataPartitionsPage gets at most limit data partitions of the volume whose IDs are greater than the marker
+// in the order of ID.
+func (api *ClientAPI) GetDataPartitionsPage(volName string, marker uint64, limit int) (view *proto.DataPartitionsView, err error) {
+	return newGetDataPartitionsRequest().
+		withName(volName).
+		withMarker(marker).
+		withLimit(limit).
+		serve(api.ctx, api.mc)
+}
+
+// RangeDataPartitions gets the data partitions of the volume page by page in the order of ID, and calls f with
+// each page until f returns an error or all the partitions are got.
+func (api *ClientAPI) RangeDataPartitions(volName string, pageSize int, f func(partitions []*proto.DataPartitionResponse) error) (err error) {
+	var marker uint64
+	for {
+		var view *proto.DataPartitionsView
+		if view, err = api.GetDataPartitionsPage(volName, marker, pageSize); err != nil {
+			return
+		}
+		partitions := view.DataPartitions
+		if len(partitions) > 0 {
+			if err = f(partitions); err != nil {
+				return
+			}
+			marker = partitions[len(partitions)-1].PartitionID
+		}
+		if pageSize <= 0 || len(partitions) != pageSize {
+			return
+		}
 	}
-	return
 }
@@ -0,0 +1,3712 @@
+// Code generated by gen_api.go from proto.MasterAPISpecs. DO NOT EDIT.
+
+package master
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+// getClusterInfoRequest is the request of /admin/getIp: Get the name of the cluster and the address of the client.
+type getClusterInfoRequest struct{ *request }
+
+func newGetClusterInfoRequest() getClusterInfoRequest {
+	return getClusterInfoRequest{newAPIRequest(http.MethodGet, proto.AdminGetIP)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getClusterInfoRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ClusterInfo, error) {
+	result := &proto.ClusterInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getClusterRequest is the request of /admin/getCluster: Get the overview of the cluster.
+type getClusterRequest struct{ *request }
+
+func newGetClusterRequest() getClusterRequest {
+	return getClusterRequest{newReadOnlyAPIRequest(http.MethodGet, proto.AdminGetCluster)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getClusterRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ClusterView, error) {
+	result := &proto.ClusterView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// freezeClusterRequest is the request of /cluster/freeze: Freeze the cluster to stop creating the data partitions automatically.
+type freezeClusterRequest struct{ *request }
+
+func newFreezeClusterRequest() freezeClusterRequest {
+	return freezeClusterRequest{newAPIRequest(http.MethodGet, proto.AdminClusterFreeze)}
+}
+
+// withEnable sets the param "enable", freeze or unfreeze.
+func (r freezeClusterRequest) withEnable(value bool) freezeClusterRequest {
+	r.addParam("enable", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r freezeClusterRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// addRaftNodeRequest is the request of /raftNode/add: Add a master into the raft group.
+type addRaftNodeRequest struct{ *request }
+
+func newAddRaftNodeRequest() addRaftNodeRequest {
+	return addRaftNodeRequest{newAPIRequest(http.MethodGet, proto.AddRaftNode)}
+}
+
+// withID sets the param "id", the raft ID of the master.
+func (r addRaftNodeRequest) withID(value uint64) addRaftNodeRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r addRaftNodeRequest) withAddr(value string) addRaftNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r addRaftNodeRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// removeRaftNodeRequest is the request of /raftNode/remove: Remove a master from the raft group.
+type removeRaftNodeRequest struct{ *request }
+
+func newRemoveRaftNodeRequest() removeRaftNodeRequest {
+	return removeRaftNodeRequest{newAPIRequest(http.MethodGet, proto.RemoveRaftNode)}
+}
+
+// withID sets the param "id", the raft ID of the master.
+func (r removeRaftNodeRequest) withID(value uint64) removeRaftNodeRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r removeRaftNodeRequest) withAddr(value string) removeRaftNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r removeRaftNodeRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getClusterStatRequest is the request of /cluster/stat: Get the space statistics of the cluster and the zones.
+type getClusterStatRequest struct{ *request }
+
+func newGetClusterStatRequest() getClusterStatRequest {
+	return getClusterStatRequest{newReadOnlyAPIRequest(http.MethodGet, proto.AdminClusterStat)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getClusterStatRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ClusterStatInfo, error) {
+	result := &proto.ClusterStatInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getClusterHealthRequest is the request of /cluster/health: Get the health summary of the masters, the nodes and the partitions.
+type getClusterHealthRequest struct{ *request }
+
+func newGetClusterHealthRequest() getClusterHealthRequest {
+	return getClusterHealthRequest{newAPIRequest(http.MethodGet, proto.AdminClusterHealth)}
+}
+
+// withMaxRaftLag sets the param "maxRaftLag", the raft lag of the followers to report.
+func (r getClusterHealthRequest) withMaxRaftLag(value uint64) getClusterHealthRequest {
+	r.addParam("maxRaftLag", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withThreshold sets the param "threshold", the used ratio of the nodes to report.
+func (r getClusterHealthRequest) withThreshold(value float64) getClusterHealthRequest {
+	r.addParam("threshold", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getClusterHealthRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ClusterHealth, error) {
+	result := &proto.ClusterHealth{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// recordCliAuditRequest is the request of /admin/cliAudit: Record a mutating command of the CLI.
+type recordCliAuditRequest struct{ *request }
+
+func newRecordCliAuditRequest() recordCliAuditRequest {
+	return recordCliAuditRequest{newAPIRequest(http.MethodPost, proto.AdminRecordCliAudit)}
+}
+
+// withBody sets the JSON body of the request.
+func (r recordCliAuditRequest) withBody(body *proto.CliAuditRecord) (recordCliAuditRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r recordCliAuditRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// rollingRestartRequest is the request of /admin/rollingRestart: Restart the nodes of a role batch by batch through the node agents.
+type rollingRestartRequest struct{ *request }
+
+func newRollingRestartRequest() rollingRestartRequest {
+	return rollingRestartRequest{newAPIRequest(http.MethodGet, proto.AdminRollingRestart)}
+}
+
+// withRole sets the param "role", the role of the nodes, such as master, metanode and datanode.
+func (r rollingRestartRequest) withRole(value string) rollingRestartRequest {
+	r.addParam("role", value)
+	return r
+}
+
+// withHosts sets the param "hosts", the comma separated addresses of the nodes, all the nodes of the role if empty.
+func (r rollingRestartRequest) withHosts(value string) rollingRestartRequest {
+	r.addParam("hosts", value)
+	return r
+}
+
+// withBatch sets the param "batch", the number of the nodes restarted at the same time.
+func (r rollingRestartRequest) withBatch(value int) rollingRestartRequest {
+	r.addParam("batch", strconv.Itoa(value))
+	return r
+}
+
+// withAgentPort sets the param "agentPort", the port of the node agents.
+func (r rollingRestartRequest) withAgentPort(value int) rollingRestartRequest {
+	r.addParam("agentPort", strconv.Itoa(value))
+	return r
+}
+
+// withTimeout sets the param "timeout", the seconds to wait for a node to be healthy.
+func (r rollingRestartRequest) withTimeout(value int) rollingRestartRequest {
+	r.addParam("timeout", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r rollingRestartRequest) serve(ctx context.Context, mc *MasterClient) (*proto.AsyncTaskInfo, error) {
+	result := &proto.AsyncTaskInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getNodeInfoRequest is the request of /admin/getNodeInfo: Get the cluster settings of the nodes.
+type getNodeInfoRequest struct{ *request }
+
+func newGetNodeInfoRequest() getNodeInfoRequest {
+	return getNodeInfoRequest{newAPIRequest(http.MethodGet, proto.AdminGetNodeInfo)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getNodeInfoRequest) serve(ctx context.Context, mc *MasterClient) (map[string]string, error) {
+	result := make(map[string]string, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setNodeInfoRequest is the request of /admin/setNodeInfo: Set the cluster settings of the nodes, only the given settings are changed.
+type setNodeInfoRequest struct{ *request }
+
+func newSetNodeInfoRequest() setNodeInfoRequest {
+	return setNodeInfoRequest{newAPIRequest(http.MethodGet, proto.AdminSetNodeInfo)}
+}
+
+// withBatchCount sets the param "batchCount", the inodes deleted by the meta nodes in a batch.
+func (r setNodeInfoRequest) withBatchCount(value uint64) setNodeInfoRequest {
+	r.addParam("batchCount", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withMarkDeleteRate sets the param "markDeleteRate", the extents deleted by the data nodes per second.
+func (r setNodeInfoRequest) withMarkDeleteRate(value uint64) setNodeInfoRequest {
+	r.addParam("markDeleteRate", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withDeleteWorkerSleepMs sets the param "deleteWorkerSleepMs", the milliseconds the delete workers of the meta nodes sleep.
+func (r setNodeInfoRequest) withDeleteWorkerSleepMs(value uint64) setNodeInfoRequest {
+	r.addParam("deleteWorkerSleepMs", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAutoRepairRate sets the param "autoRepairRate", the data partitions repaired by the data nodes at the same time.
+func (r setNodeInfoRequest) withAutoRepairRate(value uint64) setNodeInfoRequest {
+	r.addParam("autoRepairRate", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAutoSupplementLimit sets the param "autoSupplementLimit", the partitions recovering from the replicas supplemented automatically.
+func (r setNodeInfoRequest) withAutoSupplementLimit(value uint64) setNodeInfoRequest {
+	r.addParam("autoSupplementLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withVolDeletionDelay sets the param "volDeletionDelay", the seconds to keep the deleted volumes recoverable.
+func (r setNodeInfoRequest) withVolDeletionDelay(value uint64) setNodeInfoRequest {
+	r.addParam("volDeletionDelay", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withMpSplitInodeCount sets the param "mpSplitInodeCount", the inodes of a meta partition to split the volume.
+func (r setNodeInfoRequest) withMpSplitInodeCount(value uint64) setNodeInfoRequest {
+	r.addParam("mpSplitInodeCount", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withMpSplitMemory sets the param "mpSplitMemory", the memory of a meta partition to split the volume.
+func (r setNodeInfoRequest) withMpSplitMemory(value uint64) setNodeInfoRequest {
+	r.addParam("mpSplitMemory", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withDecommissionLimit sets the param "decommissionLimit", the data partitions migrating from a decommissioned data node at the same time.
+func (r setNodeInfoRequest) withDecommissionLimit(value uint64) setNodeInfoRequest {
+	r.addParam("decommissionLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withDecommissionBandwidth sets the param "decommissionBandwidth", the bytes per second to repair a migrated data partition.
+func (r setNodeInfoRequest) withDecommissionBandwidth(value uint64) setNodeInfoRequest {
+	r.addParam("decommissionBandwidth", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setNodeInfoRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// setMetaNodeThresholdRequest is the request of /threshold/set: Set the memory ratio of the meta nodes to stop creating the meta partitions.
+type setMetaNodeThresholdRequest struct{ *request }
+
+func newSetMetaNodeThresholdRequest() setMetaNodeThresholdRequest {
+	return setMetaNodeThresholdRequest{newAPIRequest(http.MethodGet, proto.AdminSetMetaNodeThreshold)}
+}
+
+// withThreshold sets the param "threshold", the memory ratio.
+func (r setMetaNodeThresholdRequest) withThreshold(value float64) setMetaNodeThresholdRequest {
+	r.addParam("threshold", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setMetaNodeThresholdRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getTopologyRequest is the request of /topo/get: Get the zones and the node sets of the cluster.
+type getTopologyRequest struct{ *request }
+
+func newGetTopologyRequest() getTopologyRequest {
+	return getTopologyRequest{newReadOnlyAPIRequest(http.MethodGet, proto.GetTopologyView)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getTopologyRequest) serve(ctx context.Context, mc *MasterClient) (*proto.TopologyView, error) {
+	result := &proto.TopologyView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listZonesRequest is the request of /zone/list: List the zones of the cluster.
+type listZonesRequest struct{ *request }
+
+func newListZonesRequest() listZonesRequest {
+	return listZonesRequest{newReadOnlyAPIRequest(http.MethodGet, proto.GetAllZones)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listZonesRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.ZoneView, error) {
+	result := make([]*proto.ZoneView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateZoneRequest is the request of /zone/update: Enable or disable a zone.
+type updateZoneRequest struct{ *request }
+
+func newUpdateZoneRequest() updateZoneRequest {
+	return updateZoneRequest{newAPIRequest(http.MethodGet, proto.UpdateZone)}
+}
+
+// withName sets the param "name", the name of the zone.
+func (r updateZoneRequest) withName(value string) updateZoneRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withEnable sets the param "enable", enable or disable.
+func (r updateZoneRequest) withEnable(value bool) updateZoneRequest {
+	r.addParam("enable", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r updateZoneRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// moveZoneNodeRequest is the request of /zone/moveNode: Move a data node or a meta node into a zone.
+type moveZoneNodeRequest struct{ *request }
+
+func newMoveZoneNodeRequest() moveZoneNodeRequest {
+	return moveZoneNodeRequest{newAPIRequest(http.MethodGet, proto.MoveZoneNode)}
+}
+
+// withType sets the param "type", the type of the node, data or meta.
+func (r moveZoneNodeRequest) withType(value string) moveZoneNodeRequest {
+	r.addParam("type", value)
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r moveZoneNodeRequest) withAddr(value string) moveZoneNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withZoneName sets the param "zoneName", the name of the zone.
+func (r moveZoneNodeRequest) withZoneName(value string) moveZoneNodeRequest {
+	r.addParam("zoneName", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r moveZoneNodeRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getMetaRebalanceRequest is the request of /metaRebalance/status: Get the meta rebalancer and the meta partitions being migrated.
+type getMetaRebalanceRequest struct{ *request }
+
+func newGetMetaRebalanceRequest() getMetaRebalanceRequest {
+	return getMetaRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminMetaRebalanceStatus)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getMetaRebalanceRequest) serve(ctx context.Context, mc *MasterClient) (*proto.RebalanceView, error) {
+	result := &proto.RebalanceView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setMetaRebalanceRequest is the request of /metaRebalance/set: Set the memory usage ratios and the limits of the meta rebalancer.
+type setMetaRebalanceRequest struct{ *request }
+
+func newSetMetaRebalanceRequest() setMetaRebalanceRequest {
+	return setMetaRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminMetaRebalanceSet)}
+}
+
+// withHighRatio sets the param "highRatio", the usage ratio of the nodes to move the partitions out.
+func (r setMetaRebalanceRequest) withHighRatio(value float64) setMetaRebalanceRequest {
+	r.addParam("highRatio", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// withLowRatio sets the param "lowRatio", the usage ratio of the nodes to move the partitions in.
+func (r setMetaRebalanceRequest) withLowRatio(value float64) setMetaRebalanceRequest {
+	r.addParam("lowRatio", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the partitions migrating at the same time, 0 disables the rebalancer.
+func (r setMetaRebalanceRequest) withLimit(value uint64) setMetaRebalanceRequest {
+	r.addParam("limit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withBandwidth sets the param "bandwidth", the bytes per second of the partitions started to migrate.
+func (r setMetaRebalanceRequest) withBandwidth(value uint64) setMetaRebalanceRequest {
+	r.addParam("bandwidth", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setMetaRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// pauseMetaRebalanceRequest is the request of /metaRebalance/pause: Pause the meta rebalancer.
+type pauseMetaRebalanceRequest struct{ *request }
+
+func newPauseMetaRebalanceRequest() pauseMetaRebalanceRequest {
+	return pauseMetaRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminMetaRebalancePause)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r pauseMetaRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// resumeMetaRebalanceRequest is the request of /metaRebalance/resume: Resume the meta rebalancer.
+type resumeMetaRebalanceRequest struct{ *request }
+
+func newResumeMetaRebalanceRequest() resumeMetaRebalanceRequest {
+	return resumeMetaRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminMetaRebalanceResume)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r resumeMetaRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getDataRebalanceRequest is the request of /dataRebalance/status: Get the data rebalancer and the data partitions being migrated.
+type getDataRebalanceRequest struct{ *request }
+
+func newGetDataRebalanceRequest() getDataRebalanceRequest {
+	return getDataRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminDataRebalanceStatus)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getDataRebalanceRequest) serve(ctx context.Context, mc *MasterClient) (*proto.RebalanceView, error) {
+	result := &proto.RebalanceView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setDataRebalanceRequest is the request of /dataRebalance/set: Set the disk usage ratios and the limits of the data rebalancer.
+type setDataRebalanceRequest struct{ *request }
+
+func newSetDataRebalanceRequest() setDataRebalanceRequest {
+	return setDataRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminDataRebalanceSet)}
+}
+
+// withHighRatio sets the param "highRatio", the usage ratio of the nodes to move the partitions out.
+func (r setDataRebalanceRequest) withHighRatio(value float64) setDataRebalanceRequest {
+	r.addParam("highRatio", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// withLowRatio sets the param "lowRatio", the usage ratio of the nodes to move the partitions in.
+func (r setDataRebalanceRequest) withLowRatio(value float64) setDataRebalanceRequest {
+	r.addParam("lowRatio", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the partitions migrating at the same time, 0 disables the rebalancer.
+func (r setDataRebalanceRequest) withLimit(value uint64) setDataRebalanceRequest {
+	r.addParam("limit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withBandwidth sets the param "bandwidth", the bytes per second of the partitions started to migrate.
+func (r setDataRebalanceRequest) withBandwidth(value uint64) setDataRebalanceRequest {
+	r.addParam("bandwidth", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setDataRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// pauseDataRebalanceRequest is the request of /dataRebalance/pause: Pause the data rebalancer.
+type pauseDataRebalanceRequest struct{ *request }
+
+func newPauseDataRebalanceRequest() pauseDataRebalanceRequest {
+	return pauseDataRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminDataRebalancePause)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r pauseDataRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// resumeDataRebalanceRequest is the request of /dataRebalance/resume: Resume the data rebalancer.
+type resumeDataRebalanceRequest struct{ *request }
+
+func newResumeDataRebalanceRequest() resumeDataRebalanceRequest {
+	return resumeDataRebalanceRequest{newAPIRequest(http.MethodGet, proto.AdminDataRebalanceResume)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r resumeDataRebalanceRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getTaskRequest is the request of /task/get: Get the progress of an async task.
+type getTaskRequest struct{ *request }
+
+func newGetTaskRequest() getTaskRequest {
+	return getTaskRequest{newAPIRequest(http.MethodGet, proto.AdminGetTask)}
+}
+
+// withID sets the param "id", the ID of the task.
+func (r getTaskRequest) withID(value uint64) getTaskRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getTaskRequest) serve(ctx context.Context, mc *MasterClient) (*proto.AsyncTaskInfo, error) {
+	result := &proto.AsyncTaskInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listTasksRequest is the request of /task/list: List the async tasks.
+type listTasksRequest struct{ *request }
+
+func newListTasksRequest() listTasksRequest {
+	return listTasksRequest{newAPIRequest(http.MethodGet, proto.AdminListTasks)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listTasksRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.AsyncTaskInfo, error) {
+	result := make([]*proto.AsyncTaskInfo, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getConfigRequest is the request of /admin/config/get: Get a parameter of the master which can be changed at runtime.
+type getConfigRequest struct{ *request }
+
+func newGetConfigRequest() getConfigRequest {
+	return getConfigRequest{newAPIRequest(http.MethodGet, proto.AdminGetConfig)}
+}
+
+// withName sets the param "name", the name of the parameter.
+func (r getConfigRequest) withName(value string) getConfigRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getConfigRequest) serve(ctx context.Context, mc *MasterClient) (*proto.RuntimeConfigItem, error) {
+	result := &proto.RuntimeConfigItem{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setConfigRequest is the request of /admin/config/set: Change a parameter of the master without restarting, the value overrides the config file.
+type setConfigRequest struct{ *request }
+
+func newSetConfigRequest() setConfigRequest {
+	return setConfigRequest{newAPIRequest(http.MethodGet, proto.AdminSetConfig)}
+}
+
+// withName sets the param "name", the name of the parameter.
+func (r setConfigRequest) withName(value string) setConfigRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withValue sets the param "value", the new value of the parameter.
+func (r setConfigRequest) withValue(value string) setConfigRequest {
+	r.addParam("value", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setConfigRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// listConfigRequest is the request of /admin/config/list: List the parameters of the master which can be changed at runtime.
+type listConfigRequest struct{ *request }
+
+func newListConfigRequest() listConfigRequest {
+	return listConfigRequest{newAPIRequest(http.MethodGet, proto.AdminListConfig)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listConfigRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.RuntimeConfigItem, error) {
+	result := make([]*proto.RuntimeConfigItem, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createMetadataBackupRequest is the request of /admin/backup/create: Back up the metadata of the masters to the object store now.
+type createMetadataBackupRequest struct{ *request }
+
+func newCreateMetadataBackupRequest() createMetadataBackupRequest {
+	return createMetadataBackupRequest{newAPIRequest(http.MethodGet, proto.AdminCreateMetadataBackup)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r createMetadataBackupRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetadataBackupInfo, error) {
+	result := &proto.MetadataBackupInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listMetadataBackupsRequest is the request of /admin/backup/list: List the backups of the metadata of the cluster in the object store.
+type listMetadataBackupsRequest struct{ *request }
+
+func newListMetadataBackupsRequest() listMetadataBackupsRequest {
+	return listMetadataBackupsRequest{newAPIRequest(http.MethodGet, proto.AdminListMetadataBackups)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listMetadataBackupsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.MetadataBackupInfo, error) {
+	result := make([]*proto.MetadataBackupInfo, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// restoreMetadataBackupRequest is the request of /admin/backup/restore: Restore the metadata of an empty cluster from a backup, the latest one before the time by default.
+type restoreMetadataBackupRequest struct{ *request }
+
+func newRestoreMetadataBackupRequest() restoreMetadataBackupRequest {
+	return restoreMetadataBackupRequest{newAPIRequest(http.MethodGet, proto.AdminRestoreMetadataBackup)}
+}
+
+// withKey sets the param "key", the key of the backup to restore.
+func (r restoreMetadataBackupRequest) withKey(value string) restoreMetadataBackupRequest {
+	r.addParam("key", value)
+	return r
+}
+
+// withTime sets the param "time", the unix time to restore the latest backup taken before, now by default.
+func (r restoreMetadataBackupRequest) withTime(value int64) restoreMetadataBackupRequest {
+	r.addParam("time", strconv.FormatInt(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r restoreMetadataBackupRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetadataBackupInfo, error) {
+	result := &proto.MetadataBackupInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getCapacityForecastRequest is the request of /admin/capacityForecast: Forecast the time the volumes and the zones become full by the growth of the used space.
+type getCapacityForecastRequest struct{ *request }
+
+func newGetCapacityForecastRequest() getCapacityForecastRequest {
+	return getCapacityForecastRequest{newAPIRequest(http.MethodGet, proto.AdminCapacityForecast)}
+}
+
+// withName sets the param "name", the name of the volume, all the volumes and the zones if empty.
+func (r getCapacityForecastRequest) withName(value string) getCapacityForecastRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withDays sets the param "days", the days of the recent samples to forecast by, 7 by default.
+func (r getCapacityForecastRequest) withDays(value int) getCapacityForecastRequest {
+	r.addParam("days", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getCapacityForecastRequest) serve(ctx context.Context, mc *MasterClient) (*proto.CapacityForecastView, error) {
+	result := &proto.CapacityForecastView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addFederationPeerRequest is the request of /federation/peer/add: Register a peer cluster in the federation, or replace the peer of the same name.
+type addFederationPeerRequest struct{ *request }
+
+func newAddFederationPeerRequest() addFederationPeerRequest {
+	return addFederationPeerRequest{newAPIRequest(http.MethodGet, proto.AdminAddFederationPeer)}
+}
+
+// withName sets the param "name", the name of the peer cluster.
+func (r addFederationPeerRequest) withName(value string) addFederationPeerRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withRegion sets the param "region", the region of the peer cluster.
+func (r addFederationPeerRequest) withRegion(value string) addFederationPeerRequest {
+	r.addParam("region", value)
+	return r
+}
+
+// withMasters sets the param "masters", the comma separated addresses of the masters of the peer cluster.
+func (r addFederationPeerRequest) withMasters(value string) addFederationPeerRequest {
+	r.addParam("masters", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r addFederationPeerRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// removeFederationPeerRequest is the request of /federation/peer/remove: Remove a peer cluster from the federation.
+type removeFederationPeerRequest struct{ *request }
+
+func newRemoveFederationPeerRequest() removeFederationPeerRequest {
+	return removeFederationPeerRequest{newAPIRequest(http.MethodGet, proto.AdminRemoveFederationPeer)}
+}
+
+// withName sets the param "name", the name of the peer cluster.
+func (r removeFederationPeerRequest) withName(value string) removeFederationPeerRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r removeFederationPeerRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// listFederationPeersRequest is the request of /federation/peer/list: List the peer clusters registered in the federation.
+type listFederationPeersRequest struct{ *request }
+
+func newListFederationPeersRequest() listFederationPeersRequest {
+	return listFederationPeersRequest{newAPIRequest(http.MethodGet, proto.AdminListFederationPeers)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listFederationPeersRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.FederationPeer, error) {
+	result := make([]*proto.FederationPeer, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getFederationRequest is the request of /federation/info: Get the capacity and the health of the local cluster and the peer clusters.
+type getFederationRequest struct{ *request }
+
+func newGetFederationRequest() getFederationRequest {
+	return getFederationRequest{newAPIRequest(http.MethodGet, proto.AdminGetFederation)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getFederationRequest) serve(ctx context.Context, mc *MasterClient) (*proto.FederationView, error) {
+	result := &proto.FederationView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createVolRequest is the request of /admin/createVol: Create a volume.
+type createVolRequest struct{ *request }
+
+func newCreateVolRequest() createVolRequest {
+	return createVolRequest{newAPIRequest(http.MethodGet, proto.AdminCreateVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r createVolRequest) withName(value string) createVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withOwner sets the param "owner", the owner of the volume.
+func (r createVolRequest) withOwner(value string) createVolRequest {
+	r.addParam("owner", value)
+	return r
+}
+
+// withMpCount sets the param "mpCount", the number of the initial meta partitions.
+func (r createVolRequest) withMpCount(value int) createVolRequest {
+	r.addParam("mpCount", strconv.Itoa(value))
+	return r
+}
+
+// withSize sets the param "size", the size of the data partitions in GB.
+func (r createVolRequest) withSize(value uint64) createVolRequest {
+	r.addParam("size", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withCapacity sets the param "capacity", the capacity of the volume in GB.
+func (r createVolRequest) withCapacity(value uint64) createVolRequest {
+	r.addParam("capacity", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withReplicaNum sets the param "replicaNum", the number of the replicas of the data partitions.
+func (r createVolRequest) withReplicaNum(value int) createVolRequest {
+	r.addParam("replicaNum", strconv.Itoa(value))
+	return r
+}
+
+// withFollowerRead sets the param "followerRead", allow the clients to read from the followers.
+func (r createVolRequest) withFollowerRead(value bool) createVolRequest {
+	r.addParam("followerRead", strconv.FormatBool(value))
+	return r
+}
+
+// withAuthenticate sets the param "authenticate", require the clients to be authenticated by the authnode.
+func (r createVolRequest) withAuthenticate(value bool) createVolRequest {
+	r.addParam("authenticate", strconv.FormatBool(value))
+	return r
+}
+
+// withEnableToken sets the param "enableToken", require the clients to mount by the tokens.
+func (r createVolRequest) withEnableToken(value bool) createVolRequest {
+	r.addParam("enableToken", strconv.FormatBool(value))
+	return r
+}
+
+// withCrossZone sets the param "crossZone", place the replicas across the zones.
+func (r createVolRequest) withCrossZone(value bool) createVolRequest {
+	r.addParam("crossZone", strconv.FormatBool(value))
+	return r
+}
+
+// withZoneName sets the param "zoneName", the name of the zone.
+func (r createVolRequest) withZoneName(value string) createVolRequest {
+	r.addParam("zoneName", value)
+	return r
+}
+
+// withDescription sets the param "description", the description of the volume.
+func (r createVolRequest) withDescription(value string) createVolRequest {
+	r.addParam("description", value)
+	return r
+}
+
+// withCaseInsensitive sets the param "caseInsensitive", look up the names case-insensitively but preserve them, which can not be changed later.
+func (r createVolRequest) withCaseInsensitive(value bool) createVolRequest {
+	r.addParam("caseInsensitive", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r createVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getVolSimpleInfoRequest is the request of /admin/getVol: Get the information of a volume.
+type getVolSimpleInfoRequest struct{ *request }
+
+func newGetVolSimpleInfoRequest() getVolSimpleInfoRequest {
+	return getVolSimpleInfoRequest{newReadOnlyAPIRequest(http.MethodGet, proto.AdminGetVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getVolSimpleInfoRequest) withName(value string) getVolSimpleInfoRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolSimpleInfoRequest) serve(ctx context.Context, mc *MasterClient) (*proto.SimpleVolView, error) {
+	result := &proto.SimpleVolView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cloneVolRequest is the request of /vol/clone: Clone the metadata of a volume into a new volume in background.
+type cloneVolRequest struct{ *request }
+
+func newCloneVolRequest() cloneVolRequest {
+	return cloneVolRequest{newAPIRequest(http.MethodGet, proto.AdminCloneVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r cloneVolRequest) withName(value string) cloneVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withTarget sets the param "target", the name of the new volume.
+func (r cloneVolRequest) withTarget(value string) cloneVolRequest {
+	r.addParam("target", value)
+	return r
+}
+
+// withOwner sets the param "owner", the owner of the new volume, the owner of the source volume if empty.
+func (r cloneVolRequest) withOwner(value string) cloneVolRequest {
+	r.addParam("owner", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r cloneVolRequest) serve(ctx context.Context, mc *MasterClient) (*proto.AsyncTaskInfo, error) {
+	result := &proto.AsyncTaskInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteVolRequest is the request of /vol/delete: Delete a volume, which is recoverable within the deletion delay.
+type deleteVolRequest struct{ *request }
+
+func newDeleteVolRequest() deleteVolRequest {
+	return deleteVolRequest{newAPIRequest(http.MethodGet, proto.AdminDeleteVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteVolRequest) withName(value string) deleteVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r deleteVolRequest) withAuthKey(value string) deleteVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// restoreVolRequest is the request of /vol/restore: Restore a deleted volume.
+type restoreVolRequest struct{ *request }
+
+func newRestoreVolRequest() restoreVolRequest {
+	return restoreVolRequest{newAPIRequest(http.MethodGet, proto.AdminRestoreVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r restoreVolRequest) withName(value string) restoreVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r restoreVolRequest) withAuthKey(value string) restoreVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r restoreVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// updateVolRequest is the request of /vol/update: Update a volume, only the given settings are changed.
+type updateVolRequest struct{ *request }
+
+func newUpdateVolRequest() updateVolRequest {
+	return updateVolRequest{newAPIRequest(http.MethodGet, proto.AdminUpdateVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r updateVolRequest) withName(value string) updateVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r updateVolRequest) withAuthKey(value string) updateVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// withCapacity sets the param "capacity", the capacity of the volume in GB.
+func (r updateVolRequest) withCapacity(value uint64) updateVolRequest {
+	r.addParam("capacity", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withReplicaNum sets the param "replicaNum", the number of the replicas of the data partitions.
+func (r updateVolRequest) withReplicaNum(value int) updateVolRequest {
+	r.addParam("replicaNum", strconv.Itoa(value))
+	return r
+}
+
+// withFollowerRead sets the param "followerRead", allow the clients to read from the followers.
+func (r updateVolRequest) withFollowerRead(value bool) updateVolRequest {
+	r.addParam("followerRead", strconv.FormatBool(value))
+	return r
+}
+
+// withEnableToken sets the param "enableToken", require the clients to mount by the tokens.
+func (r updateVolRequest) withEnableToken(value bool) updateVolRequest {
+	r.addParam("enableToken", strconv.FormatBool(value))
+	return r
+}
+
+// withAuthenticate sets the param "authenticate", require the clients to be authenticated by the authnode.
+func (r updateVolRequest) withAuthenticate(value bool) updateVolRequest {
+	r.addParam("authenticate", strconv.FormatBool(value))
+	return r
+}
+
+// withZoneName sets the param "zoneName", the name of the zone.
+func (r updateVolRequest) withZoneName(value string) updateVolRequest {
+	r.addParam("zoneName", value)
+	return r
+}
+
+// withDescription sets the param "description", the description of the volume.
+func (r updateVolRequest) withDescription(value string) updateVolRequest {
+	r.addParam("description", value)
+	return r
+}
+
+// withDpSelectorName sets the param "dpSelectorName", the name of the data partition selector of the clients.
+func (r updateVolRequest) withDpSelectorName(value string) updateVolRequest {
+	r.addParam("dpSelectorName", value)
+	return r
+}
+
+// withDpSelectorParm sets the param "dpSelectorParm", the parameter of the data partition selector.
+func (r updateVolRequest) withDpSelectorParm(value string) updateVolRequest {
+	r.addParam("dpSelectorParm", value)
+	return r
+}
+
+// withPlacementPolicy sets the param "placementPolicy", the replica placement policy registered to the master, default resets the policy.
+func (r updateVolRequest) withPlacementPolicy(value string) updateVolRequest {
+	r.addParam("placementPolicy", value)
+	return r
+}
+
+// withPlacementZone sets the param "placementZone", the zone of the zone-pinned placement policy.
+func (r updateVolRequest) withPlacementZone(value string) updateVolRequest {
+	r.addParam("placementZone", value)
+	return r
+}
+
+// withPlacementLabels sets the param "placementLabels", the comma separated key=value labels the nodes of the new replicas must have, empty removes the constraints.
+func (r updateVolRequest) withPlacementLabels(value string) updateVolRequest {
+	r.addParam("placementLabels", value)
+	return r
+}
+
+// withReadIopsLimit sets the param "readIopsLimit", the read IOPS limit, 0 for no limit.
+func (r updateVolRequest) withReadIopsLimit(value uint64) updateVolRequest {
+	r.addParam("readIopsLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withWriteIopsLimit sets the param "writeIopsLimit", the write IOPS limit, 0 for no limit.
+func (r updateVolRequest) withWriteIopsLimit(value uint64) updateVolRequest {
+	r.addParam("writeIopsLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withReadBpsLimit sets the param "readBpsLimit", the read bytes per second limit, 0 for no limit.
+func (r updateVolRequest) withReadBpsLimit(value uint64) updateVolRequest {
+	r.addParam("readBpsLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withWriteBpsLimit sets the param "writeBpsLimit", the write bytes per second limit, 0 for no limit.
+func (r updateVolRequest) withWriteBpsLimit(value uint64) updateVolRequest {
+	r.addParam("writeBpsLimit", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withTrashTTL sets the param "trashTTL", the seconds to keep the removed files in the trash, 0 disables the trash.
+func (r updateVolRequest) withTrashTTL(value uint64) updateVolRequest {
+	r.addParam("trashTTL", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withMetaStore sets the param "metaStore", the store of the new meta partitions, memory or rocksdb, empty for the default of the meta nodes.
+func (r updateVolRequest) withMetaStore(value string) updateVolRequest {
+	r.addParam("metaStore", value)
+	return r
+}
+
+// withInodeRetention sets the param "inodeRetention", the seconds to keep the deleted inodes before purging them, 0 for the default of the meta nodes.
+func (r updateVolRequest) withInodeRetention(value uint64) updateVolRequest {
+	r.addParam("inodeRetention", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withIpAllow sets the param "ipAllow", the comma separated CIDRs of the allowed clients, empty allows all the clients.
+func (r updateVolRequest) withIpAllow(value string) updateVolRequest {
+	r.addParam("ipAllow", value)
+	return r
+}
+
+// withIpDeny sets the param "ipDeny", the comma separated CIDRs of the denied clients, empty denies none.
+func (r updateVolRequest) withIpDeny(value string) updateVolRequest {
+	r.addParam("ipDeny", value)
+	return r
+}
+
+// withVerifyReadCrc sets the param "verifyReadCrc", check the data read against the crc of the extent blocks, and read it from another replica if it is corrupt.
+func (r updateVolRequest) withVerifyReadCrc(value bool) updateVolRequest {
+	r.addParam("verifyReadCrc", strconv.FormatBool(value))
+	return r
+}
+
+// withCompression sets the param "compression", the algorithm the data nodes compress the cold extents with, lz4 or deflate, none disables the compression.
+func (r updateVolRequest) withCompression(value string) updateVolRequest {
+	r.addParam("compression", value)
+	return r
+}
+
+// withEncrypted sets the param "encrypted", encrypt the extents at rest on the data nodes by the data key of the volume, which can not be disabled.
+func (r updateVolRequest) withEncrypted(value bool) updateVolRequest {
+	r.addParam("encrypted", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r updateVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// rotateVolKeyRequest is the request of /vol/rotateKey: Generate a new data key of an encrypted volume, which the extents are encrypted with again.
+type rotateVolKeyRequest struct{ *request }
+
+func newRotateVolKeyRequest() rotateVolKeyRequest {
+	return rotateVolKeyRequest{newAPIRequest(http.MethodGet, proto.AdminRotateVolKey)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r rotateVolKeyRequest) withName(value string) rotateVolKeyRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r rotateVolKeyRequest) withAuthKey(value string) rotateVolKeyRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r rotateVolKeyRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getVolQosRequest is the request of /admin/getVolQos: Get the IOPS and the bandwidth limits of the limited volumes.
+type getVolQosRequest struct{ *request }
+
+func newGetVolQosRequest() getVolQosRequest {
+	return getVolQosRequest{newAPIRequest(http.MethodGet, proto.AdminGetVolQos)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolQosRequest) serve(ctx context.Context, mc *MasterClient) (map[string]proto.VolQos, error) {
+	result := make(map[string]proto.VolQos, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getVolIPAclRequest is the request of /admin/getVolIPAcl: Get the client IP restrictions of the restricted volumes.
+type getVolIPAclRequest struct{ *request }
+
+func newGetVolIPAclRequest() getVolIPAclRequest {
+	return getVolIPAclRequest{newAPIRequest(http.MethodGet, proto.AdminGetVolIPAcl)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolIPAclRequest) serve(ctx context.Context, mc *MasterClient) (map[string]proto.VolIPAcl, error) {
+	result := make(map[string]proto.VolIPAcl, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getVerifyReadCrcVolsRequest is the request of /admin/getVerifyReadCrcVols: Get the names of the volumes whose data read is checked against the crc.
+type getVerifyReadCrcVolsRequest struct{ *request }
+
+func newGetVerifyReadCrcVolsRequest() getVerifyReadCrcVolsRequest {
+	return getVerifyReadCrcVolsRequest{newAPIRequest(http.MethodGet, proto.AdminGetVerifyReadCrcVols)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVerifyReadCrcVolsRequest) serve(ctx context.Context, mc *MasterClient) ([]string, error) {
+	result := make([]string, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getCompressionVolsRequest is the request of /admin/getCompressionVols: Get the compression algorithms of the volumes with compression.
+type getCompressionVolsRequest struct{ *request }
+
+func newGetCompressionVolsRequest() getCompressionVolsRequest {
+	return getCompressionVolsRequest{newAPIRequest(http.MethodGet, proto.AdminGetCompressionVols)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getCompressionVolsRequest) serve(ctx context.Context, mc *MasterClient) (map[string]string, error) {
+	result := make(map[string]string, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getVolEncryptionKeysRequest is the request of /admin/getVolEncryptionKeys: Get the data keys of the encrypted volumes encrypted by the session key of the ticket with the caps master:getvolkeys.
+type getVolEncryptionKeysRequest struct{ *request }
+
+func newGetVolEncryptionKeysRequest() getVolEncryptionKeysRequest {
+	return getVolEncryptionKeysRequest{newAPIRequest(http.MethodGet, proto.AdminGetVolEncryptionKeys)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolEncryptionKeysRequest) serve(ctx context.Context, mc *MasterClient) (string, error) {
+	var result string
+	err := mc.serveRequestInto(ctx, r.request, &result)
+	return result, err
+}
+
+// shrinkVolRequest is the request of /vol/shrink: Shrink the capacity of a volume.
+type shrinkVolRequest struct{ *request }
+
+func newShrinkVolRequest() shrinkVolRequest {
+	return shrinkVolRequest{newAPIRequest(http.MethodGet, proto.AdminVolShrink)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r shrinkVolRequest) withName(value string) shrinkVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r shrinkVolRequest) withAuthKey(value string) shrinkVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// withCapacity sets the param "capacity", the capacity in GB.
+func (r shrinkVolRequest) withCapacity(value uint64) shrinkVolRequest {
+	r.addParam("capacity", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r shrinkVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// expandVolRequest is the request of /vol/expand: Expand the capacity of a volume.
+type expandVolRequest struct{ *request }
+
+func newExpandVolRequest() expandVolRequest {
+	return expandVolRequest{newAPIRequest(http.MethodGet, proto.AdminVolExpand)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r expandVolRequest) withName(value string) expandVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r expandVolRequest) withAuthKey(value string) expandVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// withCapacity sets the param "capacity", the capacity in GB.
+func (r expandVolRequest) withCapacity(value uint64) expandVolRequest {
+	r.addParam("capacity", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r expandVolRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// listVolsRequest is the request of /vol/list: List the volumes in the order of name.
+type listVolsRequest struct{ *request }
+
+func newListVolsRequest() listVolsRequest {
+	return listVolsRequest{newReadOnlyAPIRequest(http.MethodGet, proto.AdminListVols)}
+}
+
+// withKeywords sets the param "keywords", the keywords contained in the names.
+func (r listVolsRequest) withKeywords(value string) listVolsRequest {
+	r.addParam("keywords", value)
+	return r
+}
+
+// withDeleted sets the param "deleted", list the deleted volumes instead.
+func (r listVolsRequest) withDeleted(value bool) listVolsRequest {
+	r.addParam("deleted", strconv.FormatBool(value))
+	return r
+}
+
+// withMarker sets the param "marker", list the volumes whose names are greater than the marker.
+func (r listVolsRequest) withMarker(value string) listVolsRequest {
+	r.addParam("marker", value)
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the items, 0 for no limit.
+func (r listVolsRequest) withLimit(value int) listVolsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listVolsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.VolInfo, error) {
+	result := make([]*proto.VolInfo, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setDirQuotaRequest is the request of /quota/set: Set the limits of the quota of a directory.
+type setDirQuotaRequest struct{ *request }
+
+func newSetDirQuotaRequest() setDirQuotaRequest {
+	return setDirQuotaRequest{newAPIRequest(http.MethodGet, proto.QuotaSet)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r setDirQuotaRequest) withName(value string) setDirQuotaRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withPath sets the param "path", the path of the directory.
+func (r setDirQuotaRequest) withPath(value string) setDirQuotaRequest {
+	r.addParam("path", value)
+	return r
+}
+
+// withMaxBytes sets the param "maxBytes", the max bytes, 0 for no limit.
+func (r setDirQuotaRequest) withMaxBytes(value uint64) setDirQuotaRequest {
+	r.addParam("maxBytes", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withMaxFiles sets the param "maxFiles", the max files, 0 for no limit.
+func (r setDirQuotaRequest) withMaxFiles(value uint64) setDirQuotaRequest {
+	r.addParam("maxFiles", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r setDirQuotaRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DirQuotaReply, error) {
+	result := &proto.DirQuotaReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteDirQuotaRequest is the request of /quota/delete: Delete the quota of a directory.
+type deleteDirQuotaRequest struct{ *request }
+
+func newDeleteDirQuotaRequest() deleteDirQuotaRequest {
+	return deleteDirQuotaRequest{newAPIRequest(http.MethodGet, proto.QuotaDelete)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteDirQuotaRequest) withName(value string) deleteDirQuotaRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withQuotaID sets the param "quotaId", the ID of the quota.
+func (r deleteDirQuotaRequest) withQuotaID(value uint64) deleteDirQuotaRequest {
+	r.addParam("quotaId", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r deleteDirQuotaRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DirQuotaReply, error) {
+	result := &proto.DirQuotaReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listDirQuotasRequest is the request of /quota/list: List the directory quotas of a volume.
+type listDirQuotasRequest struct{ *request }
+
+func newListDirQuotasRequest() listDirQuotasRequest {
+	return listDirQuotasRequest{newAPIRequest(http.MethodGet, proto.QuotaList)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listDirQuotasRequest) withName(value string) listDirQuotasRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listDirQuotasRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.DirQuota, error) {
+	result := make([]*proto.DirQuota, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setExpirationRuleRequest is the request of /expiration/set: Set the expiration rule of a directory, the files not modified or accessed for the days are deleted.
+type setExpirationRuleRequest struct{ *request }
+
+func newSetExpirationRuleRequest() setExpirationRuleRequest {
+	return setExpirationRuleRequest{newAPIRequest(http.MethodGet, proto.ExpirationSet)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r setExpirationRuleRequest) withName(value string) setExpirationRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withPath sets the param "path", the path of the directory, / for the whole volume.
+func (r setExpirationRuleRequest) withPath(value string) setExpirationRuleRequest {
+	r.addParam("path", value)
+	return r
+}
+
+// withDays sets the param "days", the days after which the files expire.
+func (r setExpirationRuleRequest) withDays(value uint64) setExpirationRuleRequest {
+	r.addParam("days", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withBasis sets the param "basis", the time the expiration is based on, mtime or atime, mtime by default.
+func (r setExpirationRuleRequest) withBasis(value string) setExpirationRuleRequest {
+	r.addParam("basis", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r setExpirationRuleRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ExpirationRuleReply, error) {
+	result := &proto.ExpirationRuleReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteExpirationRuleRequest is the request of /expiration/delete: Delete an expiration rule of a volume.
+type deleteExpirationRuleRequest struct{ *request }
+
+func newDeleteExpirationRuleRequest() deleteExpirationRuleRequest {
+	return deleteExpirationRuleRequest{newAPIRequest(http.MethodGet, proto.ExpirationDelete)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteExpirationRuleRequest) withName(value string) deleteExpirationRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withRuleID sets the param "ruleId", the ID of the rule.
+func (r deleteExpirationRuleRequest) withRuleID(value uint64) deleteExpirationRuleRequest {
+	r.addParam("ruleId", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r deleteExpirationRuleRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ExpirationRuleReply, error) {
+	result := &proto.ExpirationRuleReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listExpirationRulesRequest is the request of /expiration/list: List the expiration rules of a volume.
+type listExpirationRulesRequest struct{ *request }
+
+func newListExpirationRulesRequest() listExpirationRulesRequest {
+	return listExpirationRulesRequest{newAPIRequest(http.MethodGet, proto.ExpirationList)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listExpirationRulesRequest) withName(value string) listExpirationRulesRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listExpirationRulesRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.ExpirationRule, error) {
+	result := make([]*proto.ExpirationRule, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setLifecycleRuleRequest is the request of /lifecycle/set: Set the lifecycle rule of a directory, the files not modified or accessed for the days are transited to the storage class.
+type setLifecycleRuleRequest struct{ *request }
+
+func newSetLifecycleRuleRequest() setLifecycleRuleRequest {
+	return setLifecycleRuleRequest{newAPIRequest(http.MethodGet, proto.LifecycleSet)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r setLifecycleRuleRequest) withName(value string) setLifecycleRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withPath sets the param "path", the path of the directory, / for the whole volume.
+func (r setLifecycleRuleRequest) withPath(value string) setLifecycleRuleRequest {
+	r.addParam("path", value)
+	return r
+}
+
+// withDays sets the param "days", the days after which the files are transited.
+func (r setLifecycleRuleRequest) withDays(value uint64) setLifecycleRuleRequest {
+	r.addParam("days", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withBasis sets the param "basis", the time the transition is based on, mtime or atime, mtime by default.
+func (r setLifecycleRuleRequest) withBasis(value string) setLifecycleRuleRequest {
+	r.addParam("basis", value)
+	return r
+}
+
+// withStorageClass sets the param "storageClass", the storage class to transit the files to, replica or cold.
+func (r setLifecycleRuleRequest) withStorageClass(value string) setLifecycleRuleRequest {
+	r.addParam("storageClass", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r setLifecycleRuleRequest) serve(ctx context.Context, mc *MasterClient) (*proto.LifecycleRuleReply, error) {
+	result := &proto.LifecycleRuleReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteLifecycleRuleRequest is the request of /lifecycle/delete: Delete a lifecycle rule of a volume.
+type deleteLifecycleRuleRequest struct{ *request }
+
+func newDeleteLifecycleRuleRequest() deleteLifecycleRuleRequest {
+	return deleteLifecycleRuleRequest{newAPIRequest(http.MethodGet, proto.LifecycleDelete)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteLifecycleRuleRequest) withName(value string) deleteLifecycleRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withRuleID sets the param "ruleId", the ID of the rule.
+func (r deleteLifecycleRuleRequest) withRuleID(value uint64) deleteLifecycleRuleRequest {
+	r.addParam("ruleId", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r deleteLifecycleRuleRequest) serve(ctx context.Context, mc *MasterClient) (*proto.LifecycleRuleReply, error) {
+	result := &proto.LifecycleRuleReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listLifecycleRulesRequest is the request of /lifecycle/list: List the lifecycle rules of a volume.
+type listLifecycleRulesRequest struct{ *request }
+
+func newListLifecycleRulesRequest() listLifecycleRulesRequest {
+	return listLifecycleRulesRequest{newAPIRequest(http.MethodGet, proto.LifecycleList)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listLifecycleRulesRequest) withName(value string) listLifecycleRulesRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listLifecycleRulesRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.LifecycleRule, error) {
+	result := make([]*proto.LifecycleRule, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createVolSnapshotRequest is the request of /vol/snapshot/create: Create a snapshot of a volume.
+type createVolSnapshotRequest struct{ *request }
+
+func newCreateVolSnapshotRequest() createVolSnapshotRequest {
+	return createVolSnapshotRequest{newAPIRequest(http.MethodGet, proto.VolSnapshotCreate)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r createVolSnapshotRequest) withName(value string) createVolSnapshotRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withSnapshot sets the param "snapshot", the name of the snapshot.
+func (r createVolSnapshotRequest) withSnapshot(value string) createVolSnapshotRequest {
+	r.addParam("snapshot", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r createVolSnapshotRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolSnapshotReply, error) {
+	result := &proto.VolSnapshotReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listVolSnapshotsRequest is the request of /vol/snapshot/list: List the snapshots of a volume.
+type listVolSnapshotsRequest struct{ *request }
+
+func newListVolSnapshotsRequest() listVolSnapshotsRequest {
+	return listVolSnapshotsRequest{newAPIRequest(http.MethodGet, proto.VolSnapshotList)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listVolSnapshotsRequest) withName(value string) listVolSnapshotsRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listVolSnapshotsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.VolSnapshot, error) {
+	result := make([]*proto.VolSnapshot, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteVolSnapshotRequest is the request of /vol/snapshot/delete: Delete a snapshot of a volume.
+type deleteVolSnapshotRequest struct{ *request }
+
+func newDeleteVolSnapshotRequest() deleteVolSnapshotRequest {
+	return deleteVolSnapshotRequest{newAPIRequest(http.MethodGet, proto.VolSnapshotDelete)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteVolSnapshotRequest) withName(value string) deleteVolSnapshotRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withSnapshotID sets the param "snapshotId", the ID of the snapshot.
+func (r deleteVolSnapshotRequest) withSnapshotID(value uint64) deleteVolSnapshotRequest {
+	r.addParam("snapshotId", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r deleteVolSnapshotRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolSnapshotReply, error) {
+	result := &proto.VolSnapshotReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// rollbackVolSnapshotRequest is the request of /vol/snapshot/rollback: Replace the metadata of a volume with a snapshot.
+type rollbackVolSnapshotRequest struct{ *request }
+
+func newRollbackVolSnapshotRequest() rollbackVolSnapshotRequest {
+	return rollbackVolSnapshotRequest{newAPIRequest(http.MethodGet, proto.VolSnapshotRollback)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r rollbackVolSnapshotRequest) withName(value string) rollbackVolSnapshotRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withSnapshotID sets the param "snapshotId", the ID of the snapshot.
+func (r rollbackVolSnapshotRequest) withSnapshotID(value uint64) rollbackVolSnapshotRequest {
+	r.addParam("snapshotId", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r rollbackVolSnapshotRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolSnapshotReply, error) {
+	result := &proto.VolSnapshotReply{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setVolReplicationRequest is the request of /vol/replication/set: Set or remove the replication of a volume to the volume of a standby cluster.
+type setVolReplicationRequest struct{ *request }
+
+func newSetVolReplicationRequest() setVolReplicationRequest {
+	return setVolReplicationRequest{newAPIRequest(http.MethodGet, proto.VolReplicationSet)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r setVolReplicationRequest) withName(value string) setVolReplicationRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r setVolReplicationRequest) withAuthKey(value string) setVolReplicationRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// withTargetMaster sets the param "targetMaster", the comma separated masters of the standby cluster.
+func (r setVolReplicationRequest) withTargetMaster(value string) setVolReplicationRequest {
+	r.addParam("targetMaster", value)
+	return r
+}
+
+// withTargetVol sets the param "targetVol", the standby volume, empty to remove the replication.
+func (r setVolReplicationRequest) withTargetVol(value string) setVolReplicationRequest {
+	r.addParam("targetVol", value)
+	return r
+}
+
+// withMaxLag sets the param "maxLag", seconds the standby volume lags behind before alarming.
+func (r setVolReplicationRequest) withMaxLag(value int64) setVolReplicationRequest {
+	r.addParam("maxLag", strconv.FormatInt(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r setVolReplicationRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolReplicationView, error) {
+	result := &proto.VolReplicationView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getVolReplicationRequest is the request of /vol/replication/get: Get the replication of a volume and its lag.
+type getVolReplicationRequest struct{ *request }
+
+func newGetVolReplicationRequest() getVolReplicationRequest {
+	return getVolReplicationRequest{newAPIRequest(http.MethodGet, proto.VolReplicationGet)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getVolReplicationRequest) withName(value string) getVolReplicationRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolReplicationRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolReplicationView, error) {
+	result := &proto.VolReplicationView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listVolReplicationsRequest is the request of /vol/replication/list: List the replications of the volumes.
+type listVolReplicationsRequest struct{ *request }
+
+func newListVolReplicationsRequest() listVolReplicationsRequest {
+	return listVolReplicationsRequest{newAPIRequest(http.MethodGet, proto.VolReplicationList)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listVolReplicationsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.VolReplicationView, error) {
+	result := make([]*proto.VolReplicationView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// reportVolReplicationRequest is the request of /vol/replication/report: Report the progress of the replication of a volume by a replicator.
+type reportVolReplicationRequest struct{ *request }
+
+func newReportVolReplicationRequest() reportVolReplicationRequest {
+	return reportVolReplicationRequest{newAPIRequest(http.MethodPost, proto.VolReplicationReport)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r reportVolReplicationRequest) withName(value string) reportVolReplicationRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withBody sets the JSON body of the request.
+func (r reportVolReplicationRequest) withBody(body *proto.VolReplicationStatus) (reportVolReplicationRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r reportVolReplicationRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// failoverVolReplicationRequest is the request of /vol/replication/failover: Stop the replication of a volume to fail over to the standby volume.
+type failoverVolReplicationRequest struct{ *request }
+
+func newFailoverVolReplicationRequest() failoverVolReplicationRequest {
+	return failoverVolReplicationRequest{newAPIRequest(http.MethodGet, proto.VolReplicationFailover)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r failoverVolReplicationRequest) withName(value string) failoverVolReplicationRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r failoverVolReplicationRequest) withAuthKey(value string) failoverVolReplicationRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r failoverVolReplicationRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolReplicationView, error) {
+	result := &proto.VolReplicationView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addTokenRequest is the request of /token/add: Add a mount token of a volume.
+type addTokenRequest struct{ *request }
+
+func newAddTokenRequest() addTokenRequest {
+	return addTokenRequest{newAPIRequest(http.MethodGet, proto.TokenAddURI)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r addTokenRequest) withName(value string) addTokenRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withTokenType sets the param "tokenType", the type of the token, 1 for read-only and 2 for read-write.
+func (r addTokenRequest) withTokenType(value int) addTokenRequest {
+	r.addParam("tokenType", strconv.Itoa(value))
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r addTokenRequest) withAuthKey(value string) addTokenRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r addTokenRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// updateTokenRequest is the request of /token/update: Update the type of a mount token.
+type updateTokenRequest struct{ *request }
+
+func newUpdateTokenRequest() updateTokenRequest {
+	return updateTokenRequest{newAPIRequest(http.MethodGet, proto.TokenUpdateURI)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r updateTokenRequest) withName(value string) updateTokenRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withTokenType sets the param "tokenType", the type of the token, 1 for read-only and 2 for read-write.
+func (r updateTokenRequest) withTokenType(value int) updateTokenRequest {
+	r.addParam("tokenType", strconv.Itoa(value))
+	return r
+}
+
+// withToken sets the param "token", the value of the token.
+func (r updateTokenRequest) withToken(value string) updateTokenRequest {
+	r.addParam("token", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r updateTokenRequest) withAuthKey(value string) updateTokenRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r updateTokenRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// deleteTokenRequest is the request of /token/delete: Delete a mount token of a volume.
+type deleteTokenRequest struct{ *request }
+
+func newDeleteTokenRequest() deleteTokenRequest {
+	return deleteTokenRequest{newAPIRequest(http.MethodGet, proto.TokenDelURI)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteTokenRequest) withName(value string) deleteTokenRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withToken sets the param "token", the value of the token.
+func (r deleteTokenRequest) withToken(value string) deleteTokenRequest {
+	r.addParam("token", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r deleteTokenRequest) withAuthKey(value string) deleteTokenRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteTokenRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getDataPartitionRequest is the request of /dataPartition/get: Get a data partition.
+type getDataPartitionRequest struct{ *request }
+
+func newGetDataPartitionRequest() getDataPartitionRequest {
+	return getDataPartitionRequest{newReadOnlyAPIRequest(http.MethodGet, proto.AdminGetDataPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r getDataPartitionRequest) withID(value uint64) getDataPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getDataPartitionRequest) withName(value string) getDataPartitionRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DataPartitionInfo, error) {
+	result := &proto.DataPartitionInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createDataPartitionRequest is the request of /dataPartition/create: Create the data partitions of a volume.
+type createDataPartitionRequest struct{ *request }
+
+func newCreateDataPartitionRequest() createDataPartitionRequest {
+	return createDataPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminCreateDataPartition)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r createDataPartitionRequest) withName(value string) createDataPartitionRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withCount sets the param "count", the number of the data partitions.
+func (r createDataPartitionRequest) withCount(value int) createDataPartitionRequest {
+	r.addParam("count", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r createDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// loadDataPartitionRequest is the request of /dataPartition/load: Check the replicas of a data partition.
+type loadDataPartitionRequest struct{ *request }
+
+func newLoadDataPartitionRequest() loadDataPartitionRequest {
+	return loadDataPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminLoadDataPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r loadDataPartitionRequest) withID(value uint64) loadDataPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withName sets the param "name", the name of the volume.
+func (r loadDataPartitionRequest) withName(value string) loadDataPartitionRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r loadDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// decommissionDataPartitionRequest is the request of /dataPartition/decommission: Migrate a replica of a data partition to another node, the reply is the task if async or the plan if dryRun.
+type decommissionDataPartitionRequest struct{ *request }
+
+func newDecommissionDataPartitionRequest() decommissionDataPartitionRequest {
+	return decommissionDataPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminDecommissionDataPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r decommissionDataPartitionRequest) withID(value uint64) decommissionDataPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionDataPartitionRequest) withAddr(value string) decommissionDataPartitionRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r decommissionDataPartitionRequest) withAsync(value bool) decommissionDataPartitionRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r decommissionDataPartitionRequest) withDryRun(value bool) decommissionDataPartitionRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r decommissionDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// resetDataPartitionRequest is the request of /dataPartition/reset: Reset the raft members of a data partition to the alive replicas, the reply is the plan if dryRun.
+type resetDataPartitionRequest struct{ *request }
+
+func newResetDataPartitionRequest() resetDataPartitionRequest {
+	return resetDataPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminResetDataPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r resetDataPartitionRequest) withID(value uint64) resetDataPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r resetDataPartitionRequest) withDryRun(value bool) resetDataPartitionRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r resetDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// diagnoseDataPartitionRequest is the request of /dataPartition/diagnose: Diagnose the data partitions of the cluster.
+type diagnoseDataPartitionRequest struct{ *request }
+
+func newDiagnoseDataPartitionRequest() diagnoseDataPartitionRequest {
+	return diagnoseDataPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminDiagnoseDataPartition)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r diagnoseDataPartitionRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DataPartitionDiagnosis, error) {
+	result := &proto.DataPartitionDiagnosis{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addDataReplicaRequest is the request of /dataReplica/add: Add a replica of a data partition, the reply is the task if async.
+type addDataReplicaRequest struct{ *request }
+
+func newAddDataReplicaRequest() addDataReplicaRequest {
+	return addDataReplicaRequest{newAPIRequest(http.MethodGet, proto.AdminAddDataReplica)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r addDataReplicaRequest) withID(value uint64) addDataReplicaRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r addDataReplicaRequest) withAddr(value string) addDataReplicaRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r addDataReplicaRequest) withAsync(value bool) addDataReplicaRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r addDataReplicaRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// deleteDataReplicaRequest is the request of /dataReplica/delete: Delete a replica of a data partition, the reply is the task if async or the plan if dryRun.
+type deleteDataReplicaRequest struct{ *request }
+
+func newDeleteDataReplicaRequest() deleteDataReplicaRequest {
+	return deleteDataReplicaRequest{newAPIRequest(http.MethodGet, proto.AdminDeleteDataReplica)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r deleteDataReplicaRequest) withID(value uint64) deleteDataReplicaRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r deleteDataReplicaRequest) withAddr(value string) deleteDataReplicaRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r deleteDataReplicaRequest) withAsync(value bool) deleteDataReplicaRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r deleteDataReplicaRequest) withDryRun(value bool) deleteDataReplicaRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteDataReplicaRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// transferDataLeaderRequest is the request of /dataPartition/transferLeader: Transfer the raft leader of a data partition to a replica.
+type transferDataLeaderRequest struct{ *request }
+
+func newTransferDataLeaderRequest() transferDataLeaderRequest {
+	return transferDataLeaderRequest{newAPIRequest(http.MethodGet, proto.AdminTransferDataLeader)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r transferDataLeaderRequest) withID(value uint64) transferDataLeaderRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r transferDataLeaderRequest) withAddr(value string) transferDataLeaderRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r transferDataLeaderRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// setDataPartitionStatusRequest is the request of /dataPartition/setStatus: Force a data partition read-only or writable, or return it to the status decided by the master.
+type setDataPartitionStatusRequest struct{ *request }
+
+func newSetDataPartitionStatusRequest() setDataPartitionStatusRequest {
+	return setDataPartitionStatusRequest{newAPIRequest(http.MethodGet, proto.AdminSetDataPartitionStatus)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r setDataPartitionStatusRequest) withID(value uint64) setDataPartitionStatusRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withStatus sets the param "status", readonly, writable or auto.
+func (r setDataPartitionStatusRequest) withStatus(value string) setDataPartitionStatusRequest {
+	r.addParam("status", value)
+	return r
+}
+
+// withReason sets the param "reason", the reason to force the status, such as the investigation.
+func (r setDataPartitionStatusRequest) withReason(value string) setDataPartitionStatusRequest {
+	r.addParam("reason", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setDataPartitionStatusRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getMetaPartitionRequest is the request of /metaPartition/get: Get a meta partition.
+type getMetaPartitionRequest struct{ *request }
+
+func newGetMetaPartitionRequest() getMetaPartitionRequest {
+	return getMetaPartitionRequest{newReadOnlyAPIRequest(http.MethodGet, proto.ClientMetaPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r getMetaPartitionRequest) withID(value uint64) getMetaPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetaPartitionInfo, error) {
+	result := &proto.MetaPartitionInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createMetaPartitionRequest is the request of /metaPartition/create: Split the last meta partition of a volume.
+type createMetaPartitionRequest struct{ *request }
+
+func newCreateMetaPartitionRequest() createMetaPartitionRequest {
+	return createMetaPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminCreateMetaPartition)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r createMetaPartitionRequest) withName(value string) createMetaPartitionRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withStart sets the param "start", the start inode of the new meta partition.
+func (r createMetaPartitionRequest) withStart(value uint64) createMetaPartitionRequest {
+	r.addParam("start", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r createMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// loadMetaPartitionRequest is the request of /metaPartition/load: Check the replicas of a meta partition.
+type loadMetaPartitionRequest struct{ *request }
+
+func newLoadMetaPartitionRequest() loadMetaPartitionRequest {
+	return loadMetaPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminLoadMetaPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r loadMetaPartitionRequest) withID(value uint64) loadMetaPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r loadMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// decommissionMetaPartitionRequest is the request of /metaPartition/decommission: Migrate a replica of a meta partition to another node, the reply is the task if async or the plan if dryRun.
+type decommissionMetaPartitionRequest struct{ *request }
+
+func newDecommissionMetaPartitionRequest() decommissionMetaPartitionRequest {
+	return decommissionMetaPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminDecommissionMetaPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r decommissionMetaPartitionRequest) withID(value uint64) decommissionMetaPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionMetaPartitionRequest) withAddr(value string) decommissionMetaPartitionRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r decommissionMetaPartitionRequest) withAsync(value bool) decommissionMetaPartitionRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r decommissionMetaPartitionRequest) withDryRun(value bool) decommissionMetaPartitionRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r decommissionMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// resetMetaPartitionRequest is the request of /metaPartition/reset: Reset the raft members of a meta partition to the alive replicas, the reply is the plan if dryRun.
+type resetMetaPartitionRequest struct{ *request }
+
+func newResetMetaPartitionRequest() resetMetaPartitionRequest {
+	return resetMetaPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminResetMetaPartition)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r resetMetaPartitionRequest) withID(value uint64) resetMetaPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r resetMetaPartitionRequest) withDryRun(value bool) resetMetaPartitionRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r resetMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// diagnoseMetaPartitionRequest is the request of /metaPartition/diagnose: Diagnose the meta partitions of the cluster or a volume.
+type diagnoseMetaPartitionRequest struct{ *request }
+
+func newDiagnoseMetaPartitionRequest() diagnoseMetaPartitionRequest {
+	return diagnoseMetaPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminDiagnoseMetaPartition)}
+}
+
+// withName sets the param "name", the name of the volume, all the volumes if empty.
+func (r diagnoseMetaPartitionRequest) withName(value string) diagnoseMetaPartitionRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r diagnoseMetaPartitionRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetaPartitionDiagnosis, error) {
+	result := &proto.MetaPartitionDiagnosis{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listMetaPartitionsRequest is the request of /metaPartition/list: List the meta partitions matching the filters, the empty filters match all.
+type listMetaPartitionsRequest struct{ *request }
+
+func newListMetaPartitionsRequest() listMetaPartitionsRequest {
+	return listMetaPartitionsRequest{newAPIRequest(http.MethodGet, proto.AdminListMetaPartitions)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listMetaPartitionsRequest) withName(value string) listMetaPartitionsRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withStatus sets the param "status", the status of the meta partitions.
+func (r listMetaPartitionsRequest) withStatus(value string) listMetaPartitionsRequest {
+	r.addParam("status", value)
+	return r
+}
+
+// withAddr sets the param "addr", the address of a meta node holding the replicas.
+func (r listMetaPartitionsRequest) withAddr(value string) listMetaPartitionsRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withOffset sets the param "offset", the number of the matched meta partitions to skip.
+func (r listMetaPartitionsRequest) withOffset(value int) listMetaPartitionsRequest {
+	r.addParam("offset", strconv.Itoa(value))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the items, 0 for no limit.
+func (r listMetaPartitionsRequest) withLimit(value int) listMetaPartitionsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listMetaPartitionsRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetaPartitionListView, error) {
+	result := &proto.MetaPartitionListView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addMetaReplicaRequest is the request of /metaReplica/add: Add a replica of a meta partition, the reply is the task if async.
+type addMetaReplicaRequest struct{ *request }
+
+func newAddMetaReplicaRequest() addMetaReplicaRequest {
+	return addMetaReplicaRequest{newAPIRequest(http.MethodGet, proto.AdminAddMetaReplica)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r addMetaReplicaRequest) withID(value uint64) addMetaReplicaRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r addMetaReplicaRequest) withAddr(value string) addMetaReplicaRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r addMetaReplicaRequest) withAsync(value bool) addMetaReplicaRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r addMetaReplicaRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// deleteMetaReplicaRequest is the request of /metaReplica/delete: Delete a replica of a meta partition, the reply is the task if async or the plan if dryRun.
+type deleteMetaReplicaRequest struct{ *request }
+
+func newDeleteMetaReplicaRequest() deleteMetaReplicaRequest {
+	return deleteMetaReplicaRequest{newAPIRequest(http.MethodGet, proto.AdminDeleteMetaReplica)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r deleteMetaReplicaRequest) withID(value uint64) deleteMetaReplicaRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r deleteMetaReplicaRequest) withAddr(value string) deleteMetaReplicaRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withAsync sets the param "async", run the operation in background and reply the task.
+func (r deleteMetaReplicaRequest) withAsync(value bool) deleteMetaReplicaRequest {
+	r.addParam("async", strconv.FormatBool(value))
+	return r
+}
+
+// withDryRun sets the param "dryRun", reply the plan of the operation without executing it.
+func (r deleteMetaReplicaRequest) withDryRun(value bool) deleteMetaReplicaRequest {
+	r.addParam("dryRun", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteMetaReplicaRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// transferMetaLeaderRequest is the request of /metaPartition/transferLeader: Transfer the raft leader of a meta partition to a replica.
+type transferMetaLeaderRequest struct{ *request }
+
+func newTransferMetaLeaderRequest() transferMetaLeaderRequest {
+	return transferMetaLeaderRequest{newAPIRequest(http.MethodGet, proto.AdminTransferMetaLeader)}
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r transferMetaLeaderRequest) withID(value uint64) transferMetaLeaderRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r transferMetaLeaderRequest) withAddr(value string) transferMetaLeaderRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r transferMetaLeaderRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// listOrphanPartitionsRequest is the request of /orphanPartition/list: List the partition replicas reported by the nodes but unknown to the master.
+type listOrphanPartitionsRequest struct{ *request }
+
+func newListOrphanPartitionsRequest() listOrphanPartitionsRequest {
+	return listOrphanPartitionsRequest{newAPIRequest(http.MethodGet, proto.AdminListOrphanPartitions)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listOrphanPartitionsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.OrphanPartitionView, error) {
+	result := make([]*proto.OrphanPartitionView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cleanOrphanPartitionRequest is the request of /orphanPartition/clean: Delete an orphan partition replica from the node.
+type cleanOrphanPartitionRequest struct{ *request }
+
+func newCleanOrphanPartitionRequest() cleanOrphanPartitionRequest {
+	return cleanOrphanPartitionRequest{newAPIRequest(http.MethodGet, proto.AdminCleanOrphanPartitions)}
+}
+
+// withType sets the param "type", the type of the partition, data or meta.
+func (r cleanOrphanPartitionRequest) withType(value string) cleanOrphanPartitionRequest {
+	r.addParam("type", value)
+	return r
+}
+
+// withID sets the param "id", the ID of the partition.
+func (r cleanOrphanPartitionRequest) withID(value uint64) cleanOrphanPartitionRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r cleanOrphanPartitionRequest) withAddr(value string) cleanOrphanPartitionRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r cleanOrphanPartitionRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getStalePartitionGCRequest is the request of /orphanPartition/gc: Get the stale replicas of the purged volumes pending and deleted by the stale partition GC.
+type getStalePartitionGCRequest struct{ *request }
+
+func newGetStalePartitionGCRequest() getStalePartitionGCRequest {
+	return getStalePartitionGCRequest{newAPIRequest(http.MethodGet, proto.AdminStalePartitionGC)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getStalePartitionGCRequest) serve(ctx context.Context, mc *MasterClient) (*proto.StalePartitionGCView, error) {
+	result := &proto.StalePartitionGCView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getConsistencyReportRequest is the request of /consistency/report: Get the findings cached by the scheduled consistency checker of the partitions.
+type getConsistencyReportRequest struct{ *request }
+
+func newGetConsistencyReportRequest() getConsistencyReportRequest {
+	return getConsistencyReportRequest{newAPIRequest(http.MethodGet, proto.AdminGetConsistencyReport)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getConsistencyReportRequest) serve(ctx context.Context, mc *MasterClient) (*proto.ConsistencyReport, error) {
+	result := &proto.ConsistencyReport{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// addDataNodeRequest is the request of /dataNode/add: Register a data node and get its ID.
+type addDataNodeRequest struct{ *request }
+
+func newAddDataNodeRequest() addDataNodeRequest {
+	return addDataNodeRequest{newAPIRequest(http.MethodGet, proto.AddDataNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r addDataNodeRequest) withAddr(value string) addDataNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withZoneName sets the param "zoneName", the name of the zone.
+func (r addDataNodeRequest) withZoneName(value string) addDataNodeRequest {
+	r.addParam("zoneName", value)
+	return r
+}
+
+// withRackName sets the param "rackName", the name of the rack.
+func (r addDataNodeRequest) withRackName(value string) addDataNodeRequest {
+	r.addParam("rackName", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r addDataNodeRequest) serve(ctx context.Context, mc *MasterClient) (uint64, error) {
+	var result uint64
+	err := mc.serveRequestInto(ctx, r.request, &result)
+	return result, err
+}
+
+// getDataNodeRequest is the request of /dataNode/get: Get a data node.
+type getDataNodeRequest struct{ *request }
+
+func newGetDataNodeRequest() getDataNodeRequest {
+	return getDataNodeRequest{newAPIRequest(http.MethodGet, proto.GetDataNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r getDataNodeRequest) withAddr(value string) getDataNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getDataNodeRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DataNodeInfo, error) {
+	result := &proto.DataNodeInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getDataNodePartitionsRequest is the request of /dataNode/partitions: List the data partitions on a data node.
+type getDataNodePartitionsRequest struct{ *request }
+
+func newGetDataNodePartitionsRequest() getDataNodePartitionsRequest {
+	return getDataNodePartitionsRequest{newAPIRequest(http.MethodGet, proto.GetDataNodePartitions)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r getDataNodePartitionsRequest) withAddr(value string) getDataNodePartitionsRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getDataNodePartitionsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.NodePartitionView, error) {
+	result := make([]*proto.NodePartitionView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decommissionDataNodeRequest is the request of /dataNode/decommission: Migrate all the data partitions off a data node.
+type decommissionDataNodeRequest struct{ *request }
+
+func newDecommissionDataNodeRequest() decommissionDataNodeRequest {
+	return decommissionDataNodeRequest{newAPIRequest(http.MethodGet, proto.DecommissionDataNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionDataNodeRequest) withAddr(value string) decommissionDataNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r decommissionDataNodeRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// decommissionDiskRequest is the request of /disk/decommission: Migrate all the data partitions off a disk of a data node.
+type decommissionDiskRequest struct{ *request }
+
+func newDecommissionDiskRequest() decommissionDiskRequest {
+	return decommissionDiskRequest{newAPIRequest(http.MethodGet, proto.DecommissionDisk)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionDiskRequest) withAddr(value string) decommissionDiskRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withDisk sets the param "disk", the path of the disk.
+func (r decommissionDiskRequest) withDisk(value string) decommissionDiskRequest {
+	r.addParam("disk", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r decommissionDiskRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// decommissionDiskAsyncRequest is the request of /disk/decommissionAsync: Migrate all the data partitions off a disk of a data node in background.
+type decommissionDiskAsyncRequest struct{ *request }
+
+func newDecommissionDiskAsyncRequest() decommissionDiskAsyncRequest {
+	return decommissionDiskAsyncRequest{newAPIRequest(http.MethodGet, proto.AdminDecommissionDiskAsync)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionDiskAsyncRequest) withAddr(value string) decommissionDiskAsyncRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withDisk sets the param "disk", the path of the disk.
+func (r decommissionDiskAsyncRequest) withDisk(value string) decommissionDiskAsyncRequest {
+	r.addParam("disk", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r decommissionDiskAsyncRequest) serve(ctx context.Context, mc *MasterClient) (*proto.AsyncTaskInfo, error) {
+	result := &proto.AsyncTaskInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setDiskRetiringRequest is the request of /disk/setRetiring: Mark a disk of a data node retiring and migrate the data partitions off it, or cancel it.
+type setDiskRetiringRequest struct{ *request }
+
+func newSetDiskRetiringRequest() setDiskRetiringRequest {
+	return setDiskRetiringRequest{newAPIRequest(http.MethodGet, proto.AdminSetDiskRetiring)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r setDiskRetiringRequest) withAddr(value string) setDiskRetiringRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withDisk sets the param "disk", the path of the disk.
+func (r setDiskRetiringRequest) withDisk(value string) setDiskRetiringRequest {
+	r.addParam("disk", value)
+	return r
+}
+
+// withRetiring sets the param "retiring", true to retire the disk, false to cancel it.
+func (r setDiskRetiringRequest) withRetiring(value bool) setDiskRetiringRequest {
+	r.addParam("retiring", strconv.FormatBool(value))
+	return r
+}
+
+// withReason sets the param "reason", why the disk is retired.
+func (r setDiskRetiringRequest) withReason(value string) setDiskRetiringRequest {
+	r.addParam("reason", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setDiskRetiringRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// updateDataNodeRequest is the request of /dataNode/update: Update the ID of a data node.
+type updateDataNodeRequest struct{ *request }
+
+func newUpdateDataNodeRequest() updateDataNodeRequest {
+	return updateDataNodeRequest{newAPIRequest(http.MethodGet, proto.AdminUpdateDataNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r updateDataNodeRequest) withAddr(value string) updateDataNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withID sets the param "id", the ID of the node.
+func (r updateDataNodeRequest) withID(value uint64) updateDataNodeRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r updateDataNodeRequest) serve(ctx context.Context, mc *MasterClient) (uint64, error) {
+	var result uint64
+	err := mc.serveRequestInto(ctx, r.request, &result)
+	return result, err
+}
+
+// setDataNodeLabelsRequest is the request of /dataNode/setLabels: Replace the labels of a data node, which the placement labels of the volumes are matched against.
+type setDataNodeLabelsRequest struct{ *request }
+
+func newSetDataNodeLabelsRequest() setDataNodeLabelsRequest {
+	return setDataNodeLabelsRequest{newAPIRequest(http.MethodGet, proto.AdminSetDataNodeLabels)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r setDataNodeLabelsRequest) withAddr(value string) setDataNodeLabelsRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withLabels sets the param "labels", the comma separated key=value labels, empty removes all the labels.
+func (r setDataNodeLabelsRequest) withLabels(value string) setDataNodeLabelsRequest {
+	r.addParam("labels", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setDataNodeLabelsRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// dataNodeTaskResponseRequest is the request of /dataNode/response: Report the result of an admin task by a data node.
+type dataNodeTaskResponseRequest struct{ *request }
+
+func newDataNodeTaskResponseRequest() dataNodeTaskResponseRequest {
+	return dataNodeTaskResponseRequest{newAPIRequest(http.MethodPost, proto.GetDataNodeTaskResponse)}
+}
+
+// withBody sets the JSON body of the request.
+func (r dataNodeTaskResponseRequest) withBody(body *proto.AdminTask) (dataNodeTaskResponseRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r dataNodeTaskResponseRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// addMetaNodeRequest is the request of /metaNode/add: Register a meta node and get its ID.
+type addMetaNodeRequest struct{ *request }
+
+func newAddMetaNodeRequest() addMetaNodeRequest {
+	return addMetaNodeRequest{newAPIRequest(http.MethodGet, proto.AddMetaNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r addMetaNodeRequest) withAddr(value string) addMetaNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withZoneName sets the param "zoneName", the name of the zone.
+func (r addMetaNodeRequest) withZoneName(value string) addMetaNodeRequest {
+	r.addParam("zoneName", value)
+	return r
+}
+
+// withRackName sets the param "rackName", the name of the rack.
+func (r addMetaNodeRequest) withRackName(value string) addMetaNodeRequest {
+	r.addParam("rackName", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r addMetaNodeRequest) serve(ctx context.Context, mc *MasterClient) (uint64, error) {
+	var result uint64
+	err := mc.serveRequestInto(ctx, r.request, &result)
+	return result, err
+}
+
+// getMetaNodeRequest is the request of /metaNode/get: Get a meta node.
+type getMetaNodeRequest struct{ *request }
+
+func newGetMetaNodeRequest() getMetaNodeRequest {
+	return getMetaNodeRequest{newAPIRequest(http.MethodGet, proto.GetMetaNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r getMetaNodeRequest) withAddr(value string) getMetaNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getMetaNodeRequest) serve(ctx context.Context, mc *MasterClient) (*proto.MetaNodeInfo, error) {
+	result := &proto.MetaNodeInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getMetaNodePartitionsRequest is the request of /metaNode/partitions: List the meta partitions on a meta node.
+type getMetaNodePartitionsRequest struct{ *request }
+
+func newGetMetaNodePartitionsRequest() getMetaNodePartitionsRequest {
+	return getMetaNodePartitionsRequest{newAPIRequest(http.MethodGet, proto.GetMetaNodePartitions)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r getMetaNodePartitionsRequest) withAddr(value string) getMetaNodePartitionsRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getMetaNodePartitionsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.NodePartitionView, error) {
+	result := make([]*proto.NodePartitionView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// decommissionMetaNodeRequest is the request of /metaNode/decommission: Migrate all the meta partitions off a meta node.
+type decommissionMetaNodeRequest struct{ *request }
+
+func newDecommissionMetaNodeRequest() decommissionMetaNodeRequest {
+	return decommissionMetaNodeRequest{newAPIRequest(http.MethodGet, proto.DecommissionMetaNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionMetaNodeRequest) withAddr(value string) decommissionMetaNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r decommissionMetaNodeRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// updateMetaNodeRequest is the request of /metaNode/update: Update the ID of a meta node.
+type updateMetaNodeRequest struct{ *request }
+
+func newUpdateMetaNodeRequest() updateMetaNodeRequest {
+	return updateMetaNodeRequest{newAPIRequest(http.MethodGet, proto.AdminUpdateMetaNode)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r updateMetaNodeRequest) withAddr(value string) updateMetaNodeRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withID sets the param "id", the ID of the node.
+func (r updateMetaNodeRequest) withID(value uint64) updateMetaNodeRequest {
+	r.addParam("id", strconv.FormatUint(value, 10))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r updateMetaNodeRequest) serve(ctx context.Context, mc *MasterClient) (uint64, error) {
+	var result uint64
+	err := mc.serveRequestInto(ctx, r.request, &result)
+	return result, err
+}
+
+// setMetaNodeLabelsRequest is the request of /metaNode/setLabels: Replace the labels of a meta node, which the placement labels of the volumes are matched against.
+type setMetaNodeLabelsRequest struct{ *request }
+
+func newSetMetaNodeLabelsRequest() setMetaNodeLabelsRequest {
+	return setMetaNodeLabelsRequest{newAPIRequest(http.MethodGet, proto.AdminSetMetaNodeLabels)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r setMetaNodeLabelsRequest) withAddr(value string) setMetaNodeLabelsRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// withLabels sets the param "labels", the comma separated key=value labels, empty removes all the labels.
+func (r setMetaNodeLabelsRequest) withLabels(value string) setMetaNodeLabelsRequest {
+	r.addParam("labels", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setMetaNodeLabelsRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// metaNodeTaskResponseRequest is the request of /metaNode/response: Report the result of an admin task by a meta node.
+type metaNodeTaskResponseRequest struct{ *request }
+
+func newMetaNodeTaskResponseRequest() metaNodeTaskResponseRequest {
+	return metaNodeTaskResponseRequest{newAPIRequest(http.MethodPost, proto.GetMetaNodeTaskResponse)}
+}
+
+// withBody sets the JSON body of the request.
+func (r metaNodeTaskResponseRequest) withBody(body *proto.AdminTask) (metaNodeTaskResponseRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r metaNodeTaskResponseRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// decommissionDryRunRequest is the request of /admin/decommission/dryRun: Report the partitions to migrate, the bytes to move, the destination candidates and the partitions dropping below the quorum if a node is decommissioned.
+type decommissionDryRunRequest struct{ *request }
+
+func newDecommissionDryRunRequest() decommissionDryRunRequest {
+	return decommissionDryRunRequest{newAPIRequest(http.MethodGet, proto.AdminDecommissionDryRun)}
+}
+
+// withAddr sets the param "addr", the address of the node.
+func (r decommissionDryRunRequest) withAddr(value string) decommissionDryRunRequest {
+	r.addParam("addr", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r decommissionDryRunRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DecommissionDryRun, error) {
+	result := &proto.DecommissionDryRun{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getInvalidNodesRequest is the request of /invalid/nodes: List the nodes whose IDs conflict with the others.
+type getInvalidNodesRequest struct{ *request }
+
+func newGetInvalidNodesRequest() getInvalidNodesRequest {
+	return getInvalidNodesRequest{newAPIRequest(http.MethodGet, proto.AdminGetInvalidNodes)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r getInvalidNodesRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getVolRequest is the request of /client/vol: Get the volume to mount, the reply is encrypted by the session key if the ticket of the authnode is given.
+type getVolRequest struct{ *request }
+
+func newGetVolRequest() getVolRequest {
+	return getVolRequest{newReadOnlyAPIRequest(http.MethodGet, proto.ClientVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getVolRequest) withName(value string) getVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withAuthKey sets the param "authKey", the md5 of the owner of the volume.
+func (r getVolRequest) withAuthKey(value string) getVolRequest {
+	r.addParam("authKey", value)
+	return r
+}
+
+// withToken sets the param "Token", the ticket message of the authnode.
+func (r getVolRequest) withToken(value string) getVolRequest {
+	r.addParam("Token", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolView, error) {
+	result := &proto.VolView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getVolStatRequest is the request of /client/volStat: Get the space statistics of a volume.
+type getVolStatRequest struct{ *request }
+
+func newGetVolStatRequest() getVolStatRequest {
+	return getVolStatRequest{newReadOnlyAPIRequest(http.MethodGet, proto.ClientVolStat)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getVolStatRequest) withName(value string) getVolStatRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getVolStatRequest) serve(ctx context.Context, mc *MasterClient) (*proto.VolStatInfo, error) {
+	result := &proto.VolStatInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getMetaPartitionsRequest is the request of /client/metaPartitions: Get the meta partitions of a volume in the order of ID.
+type getMetaPartitionsRequest struct{ *request }
+
+func newGetMetaPartitionsRequest() getMetaPartitionsRequest {
+	return getMetaPartitionsRequest{newReadOnlyAPIRequest(http.MethodGet, proto.ClientMetaPartitions)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getMetaPartitionsRequest) withName(value string) getMetaPartitionsRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withMarker sets the param "marker", get the meta partitions whose IDs are greater than the marker.
+func (r getMetaPartitionsRequest) withMarker(value uint64) getMetaPartitionsRequest {
+	r.addParam("marker", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the items, 0 for no limit.
+func (r getMetaPartitionsRequest) withLimit(value int) getMetaPartitionsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getMetaPartitionsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.MetaPartitionView, error) {
+	result := make([]*proto.MetaPartitionView, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getDataPartitionsRequest is the request of /client/partitions: Get the data partitions of a volume in the order of ID.
+type getDataPartitionsRequest struct{ *request }
+
+func newGetDataPartitionsRequest() getDataPartitionsRequest {
+	return getDataPartitionsRequest{newReadOnlyAPIRequest(http.MethodGet, proto.ClientDataPartitions)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getDataPartitionsRequest) withName(value string) getDataPartitionsRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withMarker sets the param "marker", get the data partitions whose IDs are greater than the marker.
+func (r getDataPartitionsRequest) withMarker(value uint64) getDataPartitionsRequest {
+	r.addParam("marker", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the items, 0 for no limit.
+func (r getDataPartitionsRequest) withLimit(value int) getDataPartitionsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getDataPartitionsRequest) serve(ctx context.Context, mc *MasterClient) (*proto.DataPartitionsView, error) {
+	result := &proto.DataPartitionsView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getTokenRequest is the request of /token/get: Get a mount token of a volume.
+type getTokenRequest struct{ *request }
+
+func newGetTokenRequest() getTokenRequest {
+	return getTokenRequest{newAPIRequest(http.MethodGet, proto.TokenGetURI)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r getTokenRequest) withName(value string) getTokenRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withToken sets the param "token", the value of the token.
+func (r getTokenRequest) withToken(value string) getTokenRequest {
+	r.addParam("token", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getTokenRequest) serve(ctx context.Context, mc *MasterClient) (*proto.Token, error) {
+	result := &proto.Token{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createUserRequest is the request of /user/create: Create a user.
+type createUserRequest struct{ *request }
+
+func newCreateUserRequest() createUserRequest {
+	return createUserRequest{newAPIRequest(http.MethodPost, proto.UserCreate)}
+}
+
+// withBody sets the JSON body of the request.
+func (r createUserRequest) withBody(body *proto.UserCreateParam) (createUserRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r createUserRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteUserRequest is the request of /user/delete: Delete a user.
+type deleteUserRequest struct{ *request }
+
+func newDeleteUserRequest() deleteUserRequest {
+	return deleteUserRequest{newAPIRequest(http.MethodGet, proto.UserDelete)}
+}
+
+// withUser sets the param "user", the ID of the user.
+func (r deleteUserRequest) withUser(value string) deleteUserRequest {
+	r.addParam("user", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteUserRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// updateUserRequest is the request of /user/update: Update the keys and the type of a user.
+type updateUserRequest struct{ *request }
+
+func newUpdateUserRequest() updateUserRequest {
+	return updateUserRequest{newAPIRequest(http.MethodPost, proto.UserUpdate)}
+}
+
+// withBody sets the JSON body of the request.
+func (r updateUserRequest) withBody(body *proto.UserUpdateParam) (updateUserRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r updateUserRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// updateUserPolicyRequest is the request of /user/updatePolicy: Grant the permissions of a volume to a user.
+type updateUserPolicyRequest struct{ *request }
+
+func newUpdateUserPolicyRequest() updateUserPolicyRequest {
+	return updateUserPolicyRequest{newAPIRequest(http.MethodPost, proto.UserUpdatePolicy)}
+}
+
+// withBody sets the JSON body of the request.
+func (r updateUserPolicyRequest) withBody(body *proto.UserPermUpdateParam) (updateUserPolicyRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r updateUserPolicyRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// removeUserPolicyRequest is the request of /user/removePolicy: Revoke the permissions of a volume from a user.
+type removeUserPolicyRequest struct{ *request }
+
+func newRemoveUserPolicyRequest() removeUserPolicyRequest {
+	return removeUserPolicyRequest{newAPIRequest(http.MethodPost, proto.UserRemovePolicy)}
+}
+
+// withBody sets the JSON body of the request.
+func (r removeUserPolicyRequest) withBody(body *proto.UserPermRemoveParam) (removeUserPolicyRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r removeUserPolicyRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deleteUserVolPolicyRequest is the request of /user/deleteVolPolicy: Revoke the permissions of a volume from all the users.
+type deleteUserVolPolicyRequest struct{ *request }
+
+func newDeleteUserVolPolicyRequest() deleteUserVolPolicyRequest {
+	return deleteUserVolPolicyRequest{newAPIRequest(http.MethodGet, proto.UserDeleteVolPolicy)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r deleteUserVolPolicyRequest) withName(value string) deleteUserVolPolicyRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteUserVolPolicyRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// getUserAKInfoRequest is the request of /user/akInfo: Get the user of an access key.
+type getUserAKInfoRequest struct{ *request }
+
+func newGetUserAKInfoRequest() getUserAKInfoRequest {
+	return getUserAKInfoRequest{newAPIRequest(http.MethodGet, proto.UserGetAKInfo)}
+}
+
+// withAk sets the param "ak", the access key.
+func (r getUserAKInfoRequest) withAk(value string) getUserAKInfoRequest {
+	r.addParam("ak", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getUserAKInfoRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getUserInfoRequest is the request of /user/info: Get a user.
+type getUserInfoRequest struct{ *request }
+
+func newGetUserInfoRequest() getUserInfoRequest {
+	return getUserInfoRequest{newAPIRequest(http.MethodGet, proto.UserGetInfo)}
+}
+
+// withUser sets the param "user", the ID of the user.
+func (r getUserInfoRequest) withUser(value string) getUserInfoRequest {
+	r.addParam("user", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getUserInfoRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listUsersRequest is the request of /user/list: List the users.
+type listUsersRequest struct{ *request }
+
+func newListUsersRequest() listUsersRequest {
+	return listUsersRequest{newAPIRequest(http.MethodGet, proto.UserList)}
+}
+
+// withKeywords sets the param "keywords", the keywords contained in the names.
+func (r listUsersRequest) withKeywords(value string) listUsersRequest {
+	r.addParam("keywords", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listUsersRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.UserInfo, error) {
+	result := make([]*proto.UserInfo, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// transferUserVolRequest is the request of /user/transferVol: Transfer a volume to another user.
+type transferUserVolRequest struct{ *request }
+
+func newTransferUserVolRequest() transferUserVolRequest {
+	return transferUserVolRequest{newAPIRequest(http.MethodPost, proto.UserTransferVol)}
+}
+
+// withBody sets the JSON body of the request.
+func (r transferUserVolRequest) withBody(body *proto.UserTransferVolParam) (transferUserVolRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r transferUserVolRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserInfo, error) {
+	result := &proto.UserInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listUsersOfVolRequest is the request of /vol/users: List the users having the permissions of a volume.
+type listUsersOfVolRequest struct{ *request }
+
+func newListUsersOfVolRequest() listUsersOfVolRequest {
+	return listUsersOfVolRequest{newAPIRequest(http.MethodGet, proto.UsersOfVol)}
+}
+
+// withName sets the param "name", the name of the volume.
+func (r listUsersOfVolRequest) withName(value string) listUsersOfVolRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listUsersOfVolRequest) serve(ctx context.Context, mc *MasterClient) ([]string, error) {
+	result := make([]string, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setUserQuotaRequest is the request of /user/setQuota: Set the quota of a user.
+type setUserQuotaRequest struct{ *request }
+
+func newSetUserQuotaRequest() setUserQuotaRequest {
+	return setUserQuotaRequest{newAPIRequest(http.MethodPost, proto.UserSetQuota)}
+}
+
+// withBody sets the JSON body of the request.
+func (r setUserQuotaRequest) withBody(body *proto.UserQuotaSetParam) (setUserQuotaRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return r, err
+	}
+	r.addBody(data)
+	return r, nil
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r setUserQuotaRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserQuotaInfo, error) {
+	result := &proto.UserQuotaInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getUserQuotaRequest is the request of /user/quota: Get the quota of a user and its usage.
+type getUserQuotaRequest struct{ *request }
+
+func newGetUserQuotaRequest() getUserQuotaRequest {
+	return getUserQuotaRequest{newAPIRequest(http.MethodGet, proto.UserGetQuota)}
+}
+
+// withUser sets the param "user", the ID of the user.
+func (r getUserQuotaRequest) withUser(value string) getUserQuotaRequest {
+	r.addParam("user", value)
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r getUserQuotaRequest) serve(ctx context.Context, mc *MasterClient) (*proto.UserQuotaInfo, error) {
+	result := &proto.UserQuotaInfo{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listEventsRequest is the request of /admin/events: List the recent events emitted by the master.
+type listEventsRequest struct{ *request }
+
+func newListEventsRequest() listEventsRequest {
+	return listEventsRequest{newAPIRequest(http.MethodGet, proto.AdminListEvents)}
+}
+
+// withSince sets the param "since", list the events after the event ID.
+func (r listEventsRequest) withSince(value uint64) listEventsRequest {
+	r.addParam("since", strconv.FormatUint(value, 10))
+	return r
+}
+
+// withType sets the param "type", the type of the events, all the types if empty.
+func (r listEventsRequest) withType(value string) listEventsRequest {
+	r.addParam("type", value)
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the items, 0 for no limit.
+func (r listEventsRequest) withLimit(value int) listEventsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listEventsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.Event, error) {
+	result := make([]*proto.Event, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listAuditRecordsRequest is the request of /admin/audit/list: List the latest audit records of the admin calls.
+type listAuditRecordsRequest struct{ *request }
+
+func newListAuditRecordsRequest() listAuditRecordsRequest {
+	return listAuditRecordsRequest{newAPIRequest(http.MethodGet, proto.AdminListAuditRecords)}
+}
+
+// withSince sets the param "since", list the records started since the unix time.
+func (r listAuditRecordsRequest) withSince(value int64) listAuditRecordsRequest {
+	r.addParam("since", strconv.FormatInt(value, 10))
+	return r
+}
+
+// withLimit sets the param "limit", the max number of the records, 0 for the default limit.
+func (r listAuditRecordsRequest) withLimit(value int) listAuditRecordsRequest {
+	r.addParam("limit", strconv.Itoa(value))
+	return r
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listAuditRecordsRequest) serve(ctx context.Context, mc *MasterClient) ([]*proto.AuditRecord, error) {
+	result := make([]*proto.AuditRecord, 0)
+	if err := mc.serveRequestInto(ctx, r.request, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// setAlertRuleRequest is the request of /alertRule/set: Add an alert rule, or replace the alert rule of the same name.
+type setAlertRuleRequest struct{ *request }
+
+func newSetAlertRuleRequest() setAlertRuleRequest {
+	return setAlertRuleRequest{newAPIRequest(http.MethodGet, proto.AdminSetAlertRule)}
+}
+
+// withName sets the param "name", the name of the rule.
+func (r setAlertRuleRequest) withName(value string) setAlertRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// withMetric sets the param "metric", the metric of the cluster to evaluate.
+func (r setAlertRuleRequest) withMetric(value string) setAlertRuleRequest {
+	r.addParam("metric", value)
+	return r
+}
+
+// withOperator sets the param "operator", the comparison operator, such as > and <=.
+func (r setAlertRuleRequest) withOperator(value string) setAlertRuleRequest {
+	r.addParam("operator", value)
+	return r
+}
+
+// withThreshold sets the param "threshold", the threshold compared with the metric.
+func (r setAlertRuleRequest) withThreshold(value float64) setAlertRuleRequest {
+	r.addParam("threshold", strconv.FormatFloat(value, 'f', -1, 64))
+	return r
+}
+
+// withDuration sets the param "duration", the seconds the condition holds before firing.
+func (r setAlertRuleRequest) withDuration(value int64) setAlertRuleRequest {
+	r.addParam("duration", strconv.FormatInt(value, 10))
+	return r
+}
+
+// withDisabled sets the param "disabled", disable the rule.
+func (r setAlertRuleRequest) withDisabled(value bool) setAlertRuleRequest {
+	r.addParam("disabled", strconv.FormatBool(value))
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r setAlertRuleRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// deleteAlertRuleRequest is the request of /alertRule/delete: Delete an alert rule.
+type deleteAlertRuleRequest struct{ *request }
+
+func newDeleteAlertRuleRequest() deleteAlertRuleRequest {
+	return deleteAlertRuleRequest{newAPIRequest(http.MethodGet, proto.AdminDeleteAlertRule)}
+}
+
+// withName sets the param "name", the name of the rule.
+func (r deleteAlertRuleRequest) withName(value string) deleteAlertRuleRequest {
+	r.addParam("name", value)
+	return r
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r deleteAlertRuleRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
+
+// listAlertRulesRequest is the request of /alertRule/list: List the alert rules and the firing alerts.
+type listAlertRulesRequest struct{ *request }
+
+func newListAlertRulesRequest() listAlertRulesRequest {
+	return listAlertRulesRequest{newAPIRequest(http.MethodGet, proto.AdminListAlertRules)}
+}
+
+// serve sends the request to the masters and decodes the data of the reply.
+func (r listAlertRulesRequest) serve(ctx context.Context, mc *MasterClient) (*proto.AlertRulesView, error) {
+	result := &proto.AlertRulesView{}
+	if err := mc.serveRequestInto(ctx, r.request, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getAPISpecRequest is the request of /apispec: Get the OpenAPI document of the master APIs.
+type getAPISpecRequest struct{ *request }
+
+func newGetAPISpecRequest() getAPISpecRequest {
+	return getAPISpecRequest{newAPIRequest(http.MethodGet, proto.AdminAPISpec)}
+}
+
+// serve sends the request to the masters, the message of the reply is dropped.
+func (r getAPISpecRequest) serve(ctx context.Context, mc *MasterClient) error {
+	return mc.serveRequestInto(ctx, r.request, nil)
+}
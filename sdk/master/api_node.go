@@ -15,112 +15,118 @@
 package master
 
 import (
-	"encoding/json"
-	"net/http"
-	"strconv"
+	"context"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
 
 type NodeAPI struct {
-	mc *MasterClient
+	mc  *MasterClient
+	ctx context.Context
 }
 
-func (api *NodeAPI) AddDataNode(serverAddr, zoneName string) (id uint64, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AddDataNode)
-	request.addParam("addr", serverAddr)
-	request.addParam("zoneName", zoneName)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	id, err = strconv.ParseUint(string(data), 10, 64)
-	return
+// WithContext returns the node APIs whose requests are canceled when the context is done.
+func (api *NodeAPI) WithContext(ctx context.Context) *NodeAPI {
+	return &NodeAPI{mc: api.mc, ctx: ctx}
 }
 
-func (api *NodeAPI) AddMetaNode(serverAddr, zoneName string) (id uint64, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.AddMetaNode)
-	request.addParam("addr", serverAddr)
-	request.addParam("zoneName", zoneName)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	id, err = strconv.ParseUint(string(data), 10, 64)
-	return
+func (api *NodeAPI) AddDataNode(serverAddr, zoneName, rackName string) (id uint64, err error) {
+	return newAddDataNodeRequest().
+		withAddr(serverAddr).
+		withZoneName(zoneName).
+		withRackName(rackName).
+		serve(api.ctx, api.mc)
+}
+
+func (api *NodeAPI) AddMetaNode(serverAddr, zoneName, rackName string) (id uint64, err error) {
+	return newAddMetaNodeRequest().
+		withAddr(serverAddr).
+		withZoneName(zoneName).
+		withRackName(rackName).
+		serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) GetDataNode(serverHost string) (node *proto.DataNodeInfo, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.GetDataNode)
-	request.addParam("addr", serverHost)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	node = &proto.DataNodeInfo{}
-	if err = json.Unmarshal(buf, &node); err != nil {
-		return
-	}
-	return
+	return newGetDataNodeRequest().withAddr(serverHost).serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) GetMetaNode(serverHost string) (node *proto.MetaNodeInfo, err error) {
-	var buf []byte
-	var request = newAPIRequest(http.MethodGet, proto.GetMetaNode)
-	request.addParam("addr", serverHost)
-	if buf, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	node = &proto.MetaNodeInfo{}
-	if err = json.Unmarshal(buf, &node); err != nil {
-		return
-	}
-	return
+	return newGetMetaNodeRequest().withAddr(serverHost).serve(api.ctx, api.mc)
+}
+
+func (api *NodeAPI) GetDataNodePartitions(serverHost string) (partitions []*proto.NodePartitionView, err error) {
+	return newGetDataNodePartitionsRequest().withAddr(serverHost).serve(api.ctx, api.mc)
+}
+
+func (api *NodeAPI) GetMetaNodePartitions(serverHost string) (partitions []*proto.NodePartitionView, err error) {
+	return newGetMetaNodePartitionsRequest().withAddr(serverHost).serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) ResponseMetaNodeTask(task *proto.AdminTask) (err error) {
-	var encoded []byte
-	if encoded, err = json.Marshal(task); err != nil {
+	var request metaNodeTaskResponseRequest
+	if request, err = newMetaNodeTaskResponseRequest().withBody(task); err != nil {
 		return
 	}
-	var request = newAPIRequest(http.MethodPost, proto.GetMetaNodeTaskResponse)
-	request.addBody(encoded)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) ResponseDataNodeTask(task *proto.AdminTask) (err error) {
-
-	var encoded []byte
-	if encoded, err = json.Marshal(task); err != nil {
+	var request dataNodeTaskResponseRequest
+	if request, err = newDataNodeTaskResponseRequest().withBody(task); err != nil {
 		return
 	}
-	var request = newAPIRequest(http.MethodPost, proto.GetDataNodeTaskResponse)
-	request.addBody(encoded)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) DataNodeDecommission(nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.DecommissionDataNode)
-	request.addParam("addr", nodeAddr)
+	request := newDecommissionDataNodeRequest().withAddr(nodeAddr)
 	request.addHeader("isTimeOut", "false")
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
+}
+
+// DataNodeDiskDecommission submits a task to migrate all the data partitions off the disk of the data node.
+// The returned task ID can be used to query the progress by GetTaskStatus.
+func (api *NodeAPI) DataNodeDiskDecommission(nodeAddr, diskPath string) (task *proto.AsyncTaskInfo, err error) {
+	return newDecommissionDiskAsyncRequest().
+		withAddr(nodeAddr).
+		withDisk(diskPath).
+		serve(api.ctx, api.mc)
+}
+
+// SetDiskRetiring marks the disk of the data node retiring for the reason or cancels it, the data partitions on the
+// retiring disk are migrated off it unless the master is configured not to.
+func (api *NodeAPI) SetDiskRetiring(nodeAddr, diskPath string, retiring bool, reason string) (err error) {
+	return newSetDiskRetiringRequest().
+		withAddr(nodeAddr).
+		withDisk(diskPath).
+		withRetiring(retiring).
+		withReason(reason).
+		serve(api.ctx, api.mc)
 }
 
 func (api *NodeAPI) MetaNodeDecommission(nodeAddr string) (err error) {
-	var request = newAPIRequest(http.MethodGet, proto.DecommissionMetaNode)
-	request.addParam("addr", nodeAddr)
+	request := newDecommissionMetaNodeRequest().withAddr(nodeAddr)
 	request.addHeader("isTimeOut", "false")
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
+}
+
+// SetDataNodeLabels replaces the labels of the data node, the empty labels remove all of them.
+func (api *NodeAPI) SetDataNodeLabels(nodeAddr string, labels map[string]string) (err error) {
+	return newSetDataNodeLabelsRequest().
+		withAddr(nodeAddr).
+		withLabels(proto.FormatLabels(labels)).
+		serve(api.ctx, api.mc)
+}
+
+// SetMetaNodeLabels replaces the labels of the meta node, the empty labels remove all of them.
+func (api *NodeAPI) SetMetaNodeLabels(nodeAddr string, labels map[string]string) (err error) {
+	return newSetMetaNodeLabelsRequest().
+		withAddr(nodeAddr).
+		withLabels(proto.FormatLabels(labels)).
+		serve(api.ctx, api.mc)
+}
+
+// DecommissionDryRun reports the impact of decommissioning the data node or the meta node without migrating anything.
+func (api *NodeAPI) DecommissionDryRun(nodeAddr string) (dryRun *proto.DecommissionDryRun, err error) {
+	return newDecommissionDryRunRequest().withAddr(nodeAddr).serve(api.ctx, api.mc)
 }
@@ -1,176 +1,93 @@
 package master
 
 import (
-	"encoding/json"
-	"net/http"
+	"context"
 
 	"github.com/chubaofs/chubaofs/proto"
 )
 
 type UserAPI struct {
-	mc *MasterClient
+	mc  *MasterClient
+	ctx context.Context
+}
+
+// WithContext returns the user APIs whose requests are canceled when the context is done.
+func (api *UserAPI) WithContext(ctx context.Context) *UserAPI {
+	return &UserAPI{mc: api.mc, ctx: ctx}
 }
 
 func (api *UserAPI) CreateUser(param *proto.UserCreateParam) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserCreate)
-	var reqBody []byte
-	if reqBody, err = json.Marshal(param); err != nil {
-		return
-	}
-	request.addBody(reqBody)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
+	var request createUserRequest
+	if request, err = newCreateUserRequest().withBody(param); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) DeleteUser(userID string) (err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserDelete)
-	request.addParam("user", userID)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDeleteUserRequest().withUser(userID).serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) UpdateUser(param *proto.UserUpdateParam) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserUpdate)
-	var reqBody []byte
-	if reqBody, err = json.Marshal(param); err != nil {
-		return
-	}
-	request.addBody(reqBody)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
+	var request updateUserRequest
+	if request, err = newUpdateUserRequest().withBody(param); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) GetAKInfo(accesskey string) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.UserGetAKInfo)
-	request.addParam("ak", accesskey)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
-		return
-	}
-	return
+	return newGetUserAKInfoRequest().withAk(accesskey).serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) GetUserInfo(userID string) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.UserGetInfo)
-	request.addParam("user", userID)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
-		return
-	}
-	return
+	return newGetUserInfoRequest().withUser(userID).serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) UpdatePolicy(param *proto.UserPermUpdateParam) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserUpdatePolicy)
-	var reqBody []byte
-	if reqBody, err = json.Marshal(param); err != nil {
-		return
-	}
-	request.addBody(reqBody)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
+	var request updateUserPolicyRequest
+	if request, err = newUpdateUserPolicyRequest().withBody(param); err != nil {
 		return
 	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
-		return
-	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) RemovePolicy(param *proto.UserPermRemoveParam) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserRemovePolicy)
-	var reqBody []byte
-	if reqBody, err = json.Marshal(param); err != nil {
-		return
-	}
-	request.addBody(reqBody)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
+	var request removeUserPolicyRequest
+	if request, err = newRemoveUserPolicyRequest().withBody(param); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) DeleteVolPolicy(vol string) (err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserDeleteVolPolicy)
-	request.addParam("name", vol)
-	if _, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	return
+	return newDeleteUserVolPolicyRequest().withName(vol).serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) TransferVol(param *proto.UserTransferVolParam) (userInfo *proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodPost, proto.UserTransferVol)
-	var reqBody []byte
-	if reqBody, err = json.Marshal(param); err != nil {
-		return
-	}
-	request.addBody(reqBody)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	userInfo = &proto.UserInfo{}
-	if err = json.Unmarshal(data, userInfo); err != nil {
+	var request transferUserVolRequest
+	if request, err = newTransferUserVolRequest().withBody(param); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) ListUsers(keywords string) (users []*proto.UserInfo, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.UserList)
-	request.addParam("keywords", keywords)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	users = make([]*proto.UserInfo, 0)
-	if err = json.Unmarshal(data, &users); err != nil {
-		return
-	}
-	return
+	return newListUsersRequest().withKeywords(keywords).serve(api.ctx, api.mc)
 }
 
 func (api *UserAPI) ListUsersOfVol(vol string) (users []string, err error) {
-	var request = newAPIRequest(http.MethodGet, proto.UsersOfVol)
-	request.addParam("name", vol)
-	var data []byte
-	if data, err = api.mc.serveRequest(request); err != nil {
-		return
-	}
-	users = make([]string, 0)
-	if err = json.Unmarshal(data, &users); err != nil {
+	return newListUsersOfVolRequest().withName(vol).serve(api.ctx, api.mc)
+}
+
+func (api *UserAPI) SetQuota(param *proto.UserQuotaSetParam) (quotaInfo *proto.UserQuotaInfo, err error) {
+	var request setUserQuotaRequest
+	if request, err = newSetUserQuotaRequest().withBody(param); err != nil {
 		return
 	}
-	return
+	return request.serve(api.ctx, api.mc)
+}
+
+func (api *UserAPI) GetQuota(userID string) (quotaInfo *proto.UserQuotaInfo, err error) {
+	return newGetUserQuotaRequest().withUser(userID).serve(api.ctx, api.mc)
 }
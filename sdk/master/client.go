@@ -16,26 +16,37 @@ package master
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/chubaofs/chubaofs/depends/tiglabs/raft"
 	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/cryptoutil"
 	"github.com/chubaofs/chubaofs/util/log"
 )
 
 const (
-	requestTimeout = 30 * time.Second
+	requestTimeout         = 30 * time.Second
+	defaultRetryTimes      = 5
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultMaxRetryBackoff = 8 * time.Second
 )
 
 var (
 	ErrNoValidMaster = errors.New("no valid master")
+
+	errLeaderChanging = errors.New("leader is changing")
 )
 
 type MasterClient struct {
@@ -45,6 +56,22 @@ type MasterClient struct {
 	leaderAddr string
 	timeout    time.Duration
 
+	// the request is retried with exponential backoff if no master serves it
+	retryTimes      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+
+	// the max staleness of the replies of the read-only requests served by the followers,
+	// 0 means the read-only requests are sent to the leader
+	followerReadStaleness time.Duration
+
+	// the identity of the caller, which is recorded in the audit records of the admin calls on the master
+	caller string
+	// the credentials to call the admin APIs if the access control is enabled on the master
+	authToken  string
+	authTicket *proto.APIAccessReq
+	sessionKey []byte
+
 	adminAPI  *AdminAPI
 	clientAPI *ClientAPI
 	nodeAPI   *NodeAPI
@@ -91,14 +118,186 @@ func (c *MasterClient) setLeader(addr string) {
 
 // Change the request timeout
 func (c *MasterClient) SetTimeout(timeout uint16) {
+	c.SetRequestTimeout(time.Duration(timeout) * time.Second)
+}
+
+// SetRequestTimeout sets the timeout of sending a request to a master and reading the reply,
+// 0 means no timeout.
+func (c *MasterClient) SetRequestTimeout(timeout time.Duration) {
+	c.Lock()
+	c.timeout = timeout
+	c.Unlock()
+}
+
+// SetCaller sets the identity of the caller sent with the requests, such as the user and the host.
+func (c *MasterClient) SetCaller(caller string) {
+	c.Lock()
+	c.caller = caller
+	c.Unlock()
+}
+
+// SetAuthToken sets the token to call the admin APIs, whose role is granted by the config of the master.
+func (c *MasterClient) SetAuthToken(token string) {
+	c.Lock()
+	c.authToken = token
+	c.Unlock()
+}
+
+// SetAuthTicket sets the ticket issued by the authnode to the client for the master service, the role of the
+// client is granted by the API caps "master:role:<role>" of the ticket. The session key is encoded in base64.
+func (c *MasterClient) SetAuthTicket(clientID, ticket, sessionKey string) (err error) {
+	var key []byte
+	if key, err = cryptoutil.Base64Decode(sessionKey); err != nil {
+		return
+	}
+	c.Lock()
+	c.authTicket = &proto.APIAccessReq{
+		Type:      proto.MsgMasterAdminAPIReq,
+		ClientID:  clientID,
+		ServiceID: proto.MasterServiceID,
+		Ticket:    ticket,
+	}
+	c.sessionKey = key
+	c.Unlock()
+	return
+}
+
+// decodeTicketReply decodes the reply encrypted by the session key of the ticket set by SetAuthTicket.
+func (c *MasterClient) decodeTicketReply(message string) (data []byte, err error) {
+	c.RLock()
+	ticket, key := c.authTicket, c.sessionKey
+	c.RUnlock()
+	if ticket == nil {
+		return nil, fmt.Errorf("no ticket is set")
+	}
+	plaintext, err := cryptoutil.DecodeMessage(message, key)
+	if err != nil {
+		return
+	}
+	var resp proto.MasterAPIAccessResp
+	if err = json.Unmarshal(plaintext, &resp); err != nil {
+		return
+	}
+	if resp.APIResp.Type != ticket.Type+1 || resp.APIResp.ClientID != ticket.ClientID ||
+		resp.APIResp.ServiceID != ticket.ServiceID {
+		return nil, fmt.Errorf("reply verification failed")
+	}
+	return resp.Data, nil
+}
+
+// setCredentialHeaders sets the identity and the credentials of the caller into the headers of the request.
+// The access request with the ticket is generated for every request, since its verifier expires soon.
+func (c *MasterClient) setCredentialHeaders(req *http.Request) (err error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.caller != "" {
+		req.Header.Set(proto.AuditCaller, c.caller)
+	}
+	if c.authToken != "" {
+		req.Header.Set(proto.HeadAuthorized, "Bearer "+c.authToken)
+	}
+	if c.authTicket != nil {
+		accessReq := *c.authTicket
+		var data []byte
+		if accessReq.Verifier, _, err = cryptoutil.GenVerifier(c.sessionKey); err != nil {
+			return
+		}
+		if data, err = json.Marshal(accessReq); err != nil {
+			return
+		}
+		req.Header.Set(proto.AuthTicket, cryptoutil.Base64Encode(data))
+	}
+	return
+}
+
+// SetRetry sets the times to retry a request if no master serves it, such as the leader is changing or
+// the masters are unreachable. The interval before the first retry is backoff, and it is doubled for each
+// retry up to maxBackoff.
+func (c *MasterClient) SetRetry(times int, backoff, maxBackoff time.Duration) {
 	c.Lock()
-	c.timeout = time.Duration(timeout) * time.Second
+	c.retryTimes, c.retryBackoff, c.maxRetryBackoff = times, backoff, maxBackoff
 	c.Unlock()
 }
 
-func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
+// retryBackoffOf returns the interval before the retry, and false if the request should not be retried.
+func (c *MasterClient) retryBackoffOf(retry int) (backoff time.Duration, ok bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if retry >= c.retryTimes {
+		return 0, false
+	}
+	backoff = c.retryBackoff
+	for i := 0; i < retry && backoff < c.maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > c.maxRetryBackoff {
+		backoff = c.maxRetryBackoff
+	}
+	return backoff, true
+}
+
+// requestTimeoutOf returns the timeout of the request, 0 means no timeout.
+func (c *MasterClient) requestTimeoutOf(r *request) time.Duration {
+	if isTimeOut, err := strconv.ParseBool(r.header["isTimeOut"]); err == nil && !isTimeOut {
+		return 0
+	}
+	c.RLock()
+	defer c.RUnlock()
+	return c.timeout
+}
+
+// SetFollowerRead enables the read-only requests to be served by the followers, with the replies not older
+// than the staleness. The requests fall back to the leader if no follower serves them. The staleness of 0
+// disables the follower read.
+func (c *MasterClient) SetFollowerRead(staleness time.Duration) {
+	c.Lock()
+	c.followerReadStaleness = staleness
+	c.Unlock()
+}
+
+// FollowerReadStaleness returns the max staleness of the replies served by the followers.
+func (c *MasterClient) FollowerReadStaleness() (staleness time.Duration) {
+	c.RLock()
+	staleness = c.followerReadStaleness
+	c.RUnlock()
+	return
+}
+
+func (c *MasterClient) serveRequest(ctx context.Context, r *request) (repsData []byte, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if staleness := c.FollowerReadStaleness(); r.readOnly && staleness > 0 {
+		if repsData, err = c.serveFollowerRead(ctx, r, staleness); err != ErrNoValidMaster {
+			return
+		}
+		log.LogWarnf("serveRequest: no follower serves request(%v), fall back to leader", r.path)
+	}
+	for retry := 0; ; retry++ {
+		if repsData, err = c.serveLeaderRequest(ctx, r); err != errLeaderChanging && err != ErrNoValidMaster {
+			return
+		}
+		backoff, ok := c.retryBackoffOf(retry)
+		if !ok {
+			return nil, ErrNoValidMaster
+		}
+		log.LogWarnf("serveRequest: request(%v) failed: %v, retry after %v", r.path, err, backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// serveLeaderRequest sends the request to the leader, and to the other masters which proxy the request to the
+// leader if the leader fails. errLeaderChanging is returned if the masters show that the leader is changing.
+func (c *MasterClient) serveLeaderRequest(ctx context.Context, r *request) (repsData []byte, err error) {
 	leaderAddr, nodes := c.prepareRequest()
 	host := leaderAddr
+	leaderChanging := false
 	for i := -1; i < len(nodes); i++ {
 		if i == -1 {
 			if host == "" {
@@ -107,67 +306,137 @@ func (c *MasterClient) serveRequest(r *request) (repsData []byte, err error) {
 		} else {
 			host = nodes[i]
 		}
-		var resp *http.Response
-		var schema string
-		if c.useSSL {
-			schema = "https"
-		} else {
-			schema = "http"
+		var replied, changing bool
+		repsData, replied, changing, err = c.requestHost(ctx, host, r, r.header)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-		var url = fmt.Sprintf("%s://%s%s", schema, host,
-			r.path)
-		resp, err = c.httpRequest(r.method, url, r.params, r.header, r.body)
-		if err != nil {
-			log.LogErrorf("serveRequest: send http request fail: method(%v) url(%v) err(%v)", r.method, url, err)
+		if changing {
+			leaderChanging = true
 			continue
 		}
-		stateCode := resp.StatusCode
-		repsData, err = ioutil.ReadAll(resp.Body)
-		_ = resp.Body.Close()
-		if err != nil {
-			log.LogErrorf("serveRequest: read http response body fail: err(%v)", err)
+		if !replied {
 			continue
 		}
-		switch stateCode {
-		case http.StatusForbidden:
-			curMasterAddr := strings.TrimSpace(string(repsData))
-			curMasterAddr = strings.Replace(curMasterAddr, "\n", "", -1)
-			if len(curMasterAddr) == 0 {
-				log.LogWarnf("serveRequest: server response status 403: request(%s) status"+
-					"(403), body is empty", host)
-				err = ErrNoValidMaster
-				return
-			}
-			repsData, err = c.serveRequest(r)
+		if err == nil && leaderAddr != host {
+			c.setLeader(host)
+		}
+		return
+	}
+	if leaderChanging {
+		return nil, errLeaderChanging
+	}
+	return nil, ErrNoValidMaster
+}
+
+// serveFollowerRead sends the read-only request to the followers, which serve the request with the reply not
+// older than the staleness. ErrNoValidMaster is returned if no follower serves the request.
+func (c *MasterClient) serveFollowerRead(ctx context.Context, r *request, staleness time.Duration) (repsData []byte, err error) {
+	leaderAddr, nodes := c.prepareRequest()
+	header := make(map[string]string, len(r.header)+1)
+	for k, v := range r.header {
+		header[k] = v
+	}
+	header[proto.FollowerReadStaleness] = strconv.FormatInt(int64(staleness/time.Millisecond), 10)
+	offset := rand.Intn(len(nodes) + 1)
+	for i := 0; i < len(nodes); i++ {
+		host := nodes[(offset+i)%len(nodes)]
+		if host == leaderAddr {
+			continue
+		}
+		var replied, changing bool
+		if repsData, replied, changing, err = c.requestHost(ctx, host, r, header); replied && !changing {
 			return
-		case http.StatusOK:
-			if leaderAddr != host {
-				c.setLeader(host)
-			}
-			var body = &struct {
-				Code int32           `json:"code"`
-				Msg  string          `json:"msg"`
-				Data json.RawMessage `json:"data"`
-			}{}
-			if err := json.Unmarshal(repsData, body); err != nil {
-				log.LogErrorf("unmarshal response body err:%v", err)
-				return nil, fmt.Errorf("unmarshal response body err:%v", err)
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, ErrNoValidMaster
+}
 
+// requestHost sends the request to the master. replied is false if the master does not reply, and changing
+// is true if the reply shows that the leader is changing, the request should be sent to the other masters
+// in both cases.
+func (c *MasterClient) requestHost(ctx context.Context, host string, r *request, header map[string]string) (repsData []byte, replied, changing bool, err error) {
+	if timeout := c.requestTimeoutOf(r); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var resp *http.Response
+	var schema string
+	if c.useSSL {
+		schema = "https"
+	} else {
+		schema = "http"
+	}
+	var url = fmt.Sprintf("%s://%s%s", schema, host,
+		r.path)
+	resp, err = c.httpRequest(ctx, r.method, url, r.params, header, r.body)
+	if err != nil {
+		log.LogErrorf("serveRequest: send http request fail: method(%v) url(%v) err(%v)", r.method, url, err)
+		return
+	}
+	stateCode := resp.StatusCode
+	repsData, err = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		log.LogErrorf("serveRequest: read http response body fail: err(%v)", err)
+		return
+	}
+	switch stateCode {
+	case http.StatusForbidden, http.StatusBadRequest, http.StatusBadGateway:
+		// the leader has not loaded the metadata, or the master has no leader, or the master
+		// fails to proxy the request to the old leader
+		if stateCode == http.StatusBadRequest && !isLeaderChangingReply(repsData) {
+			log.LogErrorf("serveRequest: bad request: host(%v) uri(%v) body(%s)", host, r.path, repsData)
+			err = fmt.Errorf("bad request: %s", strings.TrimSpace(string(repsData)))
+			return
+		}
+		log.LogWarnf("serveRequest: leader is changing: host(%v) uri(%v) status(%v) body(%s)",
+			host, r.path, stateCode, strings.Replace(string(repsData), "\n", "", -1))
+		changing = true
+		return
+	case http.StatusOK:
+		var body = &struct {
+			Code int32           `json:"code"`
+			Msg  string          `json:"msg"`
+			Data json.RawMessage `json:"data"`
+		}{}
+		replied = true
+		if err := json.Unmarshal(repsData, body); err != nil {
+			log.LogErrorf("unmarshal response body err:%v", err)
+			return nil, replied, false, fmt.Errorf("unmarshal response body err:%v", err)
+
+		}
+		// o represent proto.ErrCodeSuccess
+		if body.Code != 0 {
+			log.LogWarnf("serveRequest: code[%v], msg[%v], data[%v] ", body.Code, body.Msg, body.Data)
+			if body.Code == proto.ErrCodeNoLeader || strings.Contains(body.Msg, raft.ErrNotLeader.Error()) {
+				// the leadership is lost when the request is being executed
+				changing = true
 			}
-			// o represent proto.ErrCodeSuccess
-			if body.Code != 0 {
-				log.LogWarnf("serveRequest: code[%v], msg[%v], data[%v] ", body.Code, body.Msg, body.Data)
-				return nil, proto.ParseErrorCode(body.Code)
-			}
-			return []byte(body.Data), nil
-		default:
-			log.LogErrorf("serveRequest: unknown status: host(%v) uri(%v) status(%v) body(%s).",
-				resp.Request.URL.String(), host, stateCode, strings.Replace(string(repsData), "\n", "", -1))
-			continue
+			return nil, replied, changing, proto.ParseErrorCode(body.Code)
 		}
+		return []byte(body.Data), replied, false, nil
+	default:
+		log.LogErrorf("serveRequest: unknown status: host(%v) uri(%v) status(%v) body(%s).",
+			resp.Request.URL.String(), host, stateCode, strings.Replace(string(repsData), "\n", "", -1))
+		err = fmt.Errorf("unknown status %v", stateCode)
+		return
 	}
-	err = ErrNoValidMaster
-	return
+}
+
+// isLeaderChangingReply returns true if the body of the bad request reply is the leader address
+// or "no leader", which means the leader is changing.
+func isLeaderChangingReply(body []byte) bool {
+	reply := strings.TrimSpace(string(body))
+	if reply == "no leader" {
+		return true
+	}
+	_, _, err := net.SplitHostPort(reply)
+	return err == nil
 }
 
 // Nodes returns all master addresses.
@@ -187,24 +456,12 @@ func (c *MasterClient) prepareRequest() (addr string, nodes []string) {
 	return
 }
 
-func (c *MasterClient) httpRequest(method, url string, param, header map[string]string, reqData []byte) (resp *http.Response, err error) {
-	client := http.DefaultClient
+func (c *MasterClient) httpRequest(ctx context.Context, method, url string, param, header map[string]string, reqData []byte) (resp *http.Response, err error) {
 	reader := bytes.NewReader(reqData)
-	if header["isTimeOut"] != "" {
-		var isTimeOut bool
-		if isTimeOut, err = strconv.ParseBool(header["isTimeOut"]); err != nil {
-			return
-		}
-		if isTimeOut {
-			client.Timeout = c.timeout
-		}
-	} else {
-		client.Timeout = c.timeout
-	}
 	var req *http.Request
 	fullUrl := c.mergeRequestUrl(url, param)
 	log.LogDebugf("httpRequest: merge request url: method(%v) url(%v) bodyLength[%v].", method, fullUrl, len(reqData))
-	if req, err = http.NewRequest(method, fullUrl, reader); err != nil {
+	if req, err = http.NewRequestWithContext(ctx, method, fullUrl, reader); err != nil {
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
@@ -212,7 +469,10 @@ func (c *MasterClient) httpRequest(method, url string, param, header map[string]
 	for k, v := range header {
 		req.Header.Set(k, v)
 	}
-	resp, err = client.Do(req)
+	if err = c.setCredentialHeaders(req); err != nil {
+		return
+	}
+	resp, err = http.DefaultClient.Do(req)
 	return
 }
 
@@ -230,29 +490,21 @@ func (c *MasterClient) updateMaster(address string) {
 	c.leaderAddr = address
 }
 
-func (c *MasterClient) mergeRequestUrl(url string, params map[string]string) string {
-	if params != nil && len(params) > 0 {
-		buff := bytes.NewBuffer([]byte(url))
-		isFirstParam := true
-		for k, v := range params {
-			if isFirstParam {
-				buff.WriteString("?")
-				isFirstParam = false
-			} else {
-				buff.WriteString("&")
-			}
-			buff.WriteString(k)
-			buff.WriteString("=")
-			buff.WriteString(v)
-		}
-		return buff.String()
+func (c *MasterClient) mergeRequestUrl(reqURL string, params map[string]string) string {
+	if len(params) == 0 {
+		return reqURL
+	}
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
 	}
-	return url
+	return reqURL + "?" + values.Encode()
 }
 
 // NewMasterHelper returns a new MasterClient instance.
 func NewMasterClient(masters []string, useSSL bool) *MasterClient {
-	var mc = &MasterClient{masters: masters, useSSL: useSSL, timeout: requestTimeout}
+	var mc = &MasterClient{masters: masters, useSSL: useSSL, timeout: requestTimeout, retryTimes: defaultRetryTimes,
+		retryBackoff: defaultRetryBackoff, maxRetryBackoff: defaultMaxRetryBackoff}
 	mc.adminAPI = &AdminAPI{mc: mc}
 	mc.clientAPI = &ClientAPI{mc: mc}
 	mc.nodeAPI = &NodeAPI{mc: mc}
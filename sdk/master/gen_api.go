@@ -0,0 +1,208 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build ignore
+// +build ignore
+
+// gen_api generates the requests of the master APIs from proto.MasterAPISpecs into api_gen.go.
+// Run "go generate" in this directory after changing the specs.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chubaofs/chubaofs/proto"
+)
+
+const output = "api_gen.go"
+
+var paramTypes = map[string]string{
+	proto.APIParamString:  "value",
+	proto.APIParamBool:    "strconv.FormatBool(value)",
+	proto.APIParamInt:     "strconv.Itoa(value)",
+	proto.APIParamInt64:   "strconv.FormatInt(value, 10)",
+	proto.APIParamUint64:  "strconv.FormatUint(value, 10)",
+	proto.APIParamFloat64: "strconv.FormatFloat(value, 'f', -1, 64)",
+}
+
+func main() {
+	paths, err := loadPathConsts()
+	if err != nil {
+		fail(err)
+	}
+	body := &bytes.Buffer{}
+	for _, spec := range proto.MasterAPISpecs {
+		if err = generate(body, spec, paths); err != nil {
+			fail(err)
+		}
+	}
+	imports := []string{`"context"`, `"net/http"`}
+	if bytes.Contains(body.Bytes(), []byte("json.")) {
+		imports = append(imports, `"encoding/json"`)
+	}
+	if bytes.Contains(body.Bytes(), []byte("strconv.")) {
+		imports = append(imports, `"strconv"`)
+	}
+	sort.Strings(imports)
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by gen_api.go from proto.MasterAPISpecs. DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package master\n\nimport (\n\t%v\n\n\t\"github.com/chubaofs/chubaofs/proto\"\n)\n",
+		strings.Join(imports, "\n\t"))
+	buf.Write(body.Bytes())
+	var src []byte
+	if src, err = format.Source(buf.Bytes()); err != nil {
+		fail(fmt.Errorf("format the generated code: %v", err))
+	}
+	if err = ioutil.WriteFile(output, src, 0644); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "gen_api: %v\n", err)
+	os.Exit(1)
+}
+
+// loadPathConsts returns the names of the constants of the paths in package proto.
+func loadPathConsts() (paths map[string]string, err error) {
+	var pkg *build.Package
+	if pkg, err = build.Import("github.com/chubaofs/chubaofs/proto", ".", build.FindOnly); err != nil {
+		return
+	}
+	fset := token.NewFileSet()
+	var pkgs map[string]*ast.Package
+	if pkgs, err = parser.ParseDir(fset, pkg.Dir, nil, 0); err != nil {
+		return
+	}
+	paths = make(map[string]string)
+	for _, p := range pkgs {
+		files := make([]string, 0, len(p.Files))
+		for name := range p.Files {
+			files = append(files, name)
+		}
+		sort.Strings(files)
+		for _, name := range files {
+			for _, decl := range p.Files[name].Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+				for _, s := range gen.Specs {
+					vs := s.(*ast.ValueSpec)
+					for i, value := range vs.Values {
+						lit, ok := value.(*ast.BasicLit)
+						if !ok || lit.Kind != token.STRING || i >= len(vs.Names) {
+							continue
+						}
+						path, _ := strconv.Unquote(lit.Value)
+						if _, ok = paths[path]; !ok && strings.HasPrefix(path, "/") {
+							paths[path] = vs.Names[i].Name
+						}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func generate(buf *bytes.Buffer, spec *proto.APISpec, paths map[string]string) error {
+	pathConst, ok := paths[spec.Path]
+	if !ok {
+		return fmt.Errorf("no constant of the path %v", spec.Path)
+	}
+	typeName := spec.Name + "Request"
+	method := spec.Methods[0]
+	if spec.Body != nil {
+		method = http.MethodPost
+	}
+	newRequest := "newAPIRequest"
+	if spec.ReadOnly {
+		newRequest = "newReadOnlyAPIRequest"
+	}
+	fmt.Fprintf(buf, "\n// %v is the request of %v: %v.\n", typeName, spec.Path, spec.Summary)
+	fmt.Fprintf(buf, "type %v struct{ *request }\n\n", typeName)
+	fmt.Fprintf(buf, "func new%v() %v {\n", exported(typeName), typeName)
+	fmt.Fprintf(buf, "\treturn %v{%v(http.Method%v, proto.%v)}\n}\n", typeName, newRequest,
+		exported(strings.ToLower(method)), pathConst)
+
+	names := make(map[string]bool)
+	for _, param := range spec.Params {
+		format, ok := paramTypes[param.Type]
+		if !ok {
+			return fmt.Errorf("unknown type %v of the param %v of %v", param.Type, param.Name, spec.Path)
+		}
+		if names[param.Name] {
+			return fmt.Errorf("duplicated param %v of %v", param.Name, spec.Path)
+		}
+		names[param.Name] = true
+		fmt.Fprintf(buf, "\n// with%v sets the param %q, %v.\n", exported(param.Name), param.Name, param.Description)
+		fmt.Fprintf(buf, "func (r %v) with%v(value %v) %v {\n", typeName, exported(param.Name), param.Type, typeName)
+		fmt.Fprintf(buf, "\tr.addParam(%q, %v)\n\treturn r\n}\n", param.Name, format)
+	}
+
+	if spec.Body != nil {
+		fmt.Fprintf(buf, "\n// withBody sets the JSON body of the request.\n")
+		fmt.Fprintf(buf, "func (r %v) withBody(body %v) (%v, error) {\n", typeName, reflect.TypeOf(spec.Body), typeName)
+		fmt.Fprintf(buf, "\tdata, err := json.Marshal(body)\n\tif err != nil {\n\t\treturn r, err\n\t}\n")
+		fmt.Fprintf(buf, "\tr.addBody(data)\n\treturn r, nil\n}\n")
+	}
+
+	if spec.Response == nil {
+		fmt.Fprintf(buf, "\n// serve sends the request to the masters, the message of the reply is dropped.\n")
+		fmt.Fprintf(buf, "func (r %v) serve(ctx context.Context, mc *MasterClient) error {\n", typeName)
+		fmt.Fprintf(buf, "\treturn mc.serveRequestInto(ctx, r.request, nil)\n}\n")
+		return nil
+	}
+	t := reflect.TypeOf(spec.Response)
+	fmt.Fprintf(buf, "\n// serve sends the request to the masters and decodes the data of the reply.\n")
+	fmt.Fprintf(buf, "func (r %v) serve(ctx context.Context, mc *MasterClient) (%v, error) {\n", typeName, t)
+	switch t.Kind() {
+	case reflect.Ptr:
+		fmt.Fprintf(buf, "\tresult := &%v{}\n", t.Elem())
+		fmt.Fprintf(buf, "\tif err := mc.serveRequestInto(ctx, r.request, result); err != nil {\n\t\treturn nil, err\n\t}\n")
+	case reflect.Slice, reflect.Map:
+		fmt.Fprintf(buf, "\tresult := make(%v, 0)\n", t)
+		fmt.Fprintf(buf, "\tif err := mc.serveRequestInto(ctx, r.request, &result); err != nil {\n\t\treturn nil, err\n\t}\n")
+	default:
+		fmt.Fprintf(buf, "\tvar result %v\n", t)
+		fmt.Fprintf(buf, "\terr := mc.serveRequestInto(ctx, r.request, &result)\n\treturn result, err\n}\n")
+		return nil
+	}
+	fmt.Fprintf(buf, "\treturn result, nil\n}\n")
+	return nil
+}
+
+func exported(name string) string {
+	if name == "id" {
+		return "ID"
+	}
+	if strings.HasSuffix(name, "Id") {
+		name = strings.TrimSuffix(name, "Id") + "ID"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -14,12 +14,23 @@
 
 package master
 
+import (
+	"context"
+	"encoding/json"
+)
+
+// The requests of the master APIs are generated from proto.MasterAPISpecs.
+//go:generate go run gen_api.go
+
 type request struct {
 	method string
 	path   string
 	params map[string]string
 	header map[string]string
 	body   []byte
+
+	// the read-only request can be served by the followers
+	readOnly bool
 }
 
 func (r *request) addParam(key, value string) {
@@ -42,3 +53,19 @@ func newAPIRequest(method string, path string) *request {
 		header: make(map[string]string),
 	}
 }
+
+func newReadOnlyAPIRequest(method string, path string) *request {
+	r := newAPIRequest(method, path)
+	r.readOnly = true
+	return r
+}
+
+// serveRequestInto serves the request and decodes the data of the reply into the result,
+// the data is dropped if the result is nil.
+func (c *MasterClient) serveRequestInto(ctx context.Context, r *request, result interface{}) (err error) {
+	var data []byte
+	if data, err = c.serveRequest(ctx, r); err != nil || result == nil {
+		return
+	}
+	return json.Unmarshal(data, result)
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/chubaofs/chubaofs/proto"
 	"github.com/chubaofs/chubaofs/util/log"
+	"github.com/google/uuid"
 )
 
 // Low-level API, i.e. work with inode
@@ -86,6 +87,19 @@ func (mw *MetaWrapper) Create_ll(parentID uint64, name string, mode, uid, gid ui
 		return nil, syscall.ENOENT
 	}
 
+	// The new inode inherits the directory quotas of the parent.
+	quotaIDs, err := mw.getInodeQuotas(parentID)
+	if err != nil {
+		log.LogErrorf("Create_ll: get quotas of parent failed, parentID(%v) err(%v)", parentID, err)
+		return nil, err
+	}
+	if err = mw.checkDirQuotas(quotaIDs, true); err != nil {
+		return nil, err
+	}
+	if mw.enableTransaction && proto.IsDir(mode) {
+		return mw.mkdirByTx(parentMP, parentID, name, mode, uid, gid, quotaIDs)
+	}
+
 	// Create Inode
 
 	//	mp = mw.getLatestPartition()
@@ -132,9 +146,59 @@ create_dentry:
 		}
 		return nil, statusToErrno(status)
 	}
+	if len(quotaIDs) > 0 {
+		if err = mw.XAttrSet_ll(info.Inode, []byte(proto.QuotaXAttrKey), []byte(proto.FormatQuotaIDs(quotaIDs))); err != nil {
+			log.LogErrorf("Create_ll: set quotas failed, ino(%v) quotas(%v) err(%v)", info.Inode, quotaIDs, err)
+		} else {
+			mw.cacheInodeQuotas(info.Inode, quotaIDs)
+		}
+	}
 	return info, nil
 }
 
+// mkdirByTx creates the directory by a transaction of the meta partitions, so the inode is never left without the
+// dentry. The quotas of the parent are set on the directory in the same transaction.
+func (mw *MetaWrapper) mkdirByTx(parentMP *MetaPartition, parentID uint64, name string, mode, uid, gid uint32,
+	quotaIDs []uint32) (*proto.InodeInfo, error) {
+	var xattrs map[string]string
+	if len(quotaIDs) > 0 {
+		xattrs = map[string]string{proto.QuotaXAttrKey: proto.FormatQuotaIDs(quotaIDs)}
+	}
+	rwPartitions := mw.getRWPartitions()
+	length := len(rwPartitions)
+	epoch := atomic.AddUint64(&mw.epoch, 1)
+	for i := 0; i < length; i++ {
+		mp := rwPartitions[(int(epoch)+i)%length]
+		tx := &proto.TxInfo{
+			TxID: uuid.New().String(),
+			Type: proto.TxTypeCreate,
+			Ops: []*proto.TxOp{
+				{PartitionID: mp.PartitionID, Members: mp.Members, Type: proto.TxOpCreateInode, Mode: mode, Uid: uid,
+					Gid: gid, XAttrs: xattrs},
+				{PartitionID: parentMP.PartitionID, Members: parentMP.Members, Type: proto.TxOpCreateDentry,
+					ParentID: parentID, Name: name},
+			},
+		}
+		status, ops, err := mw.txStart(mp, tx)
+		if err == nil && status == statusFull {
+			// the inodes of the meta partition run out
+			continue
+		}
+		if err != nil || status != statusOK {
+			return nil, statusToErrno(status)
+		}
+		status, info, err := mw.iget(mp, ops[0].Inode)
+		if err != nil || status != statusOK {
+			return nil, statusToErrno(status)
+		}
+		if len(quotaIDs) > 0 {
+			mw.cacheInodeQuotas(info.Inode, quotaIDs)
+		}
+		return info, nil
+	}
+	return nil, syscall.ENOMEM
+}
+
 func (mw *MetaWrapper) Lookup_ll(parentID uint64, name string) (inode uint64, mode uint32, err error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
@@ -362,6 +426,24 @@ func (mw *MetaWrapper) Delete_ll(parentID uint64, name string, isDir bool) (*pro
 }
 
 func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID uint64, dstName string) (err error) {
+	// The inodes are not moved across the directory quotas, so the callers are expected to copy them instead.
+	if srcParentID != dstParentID {
+		srcQuotaIDs, err := mw.getInodeQuotas(srcParentID)
+		if err != nil {
+			return err
+		}
+		dstQuotaIDs, err := mw.getInodeQuotas(dstParentID)
+		if err != nil {
+			return err
+		}
+		if !equalQuotaIDs(srcQuotaIDs, dstQuotaIDs) {
+			return syscall.EXDEV
+		}
+	}
+	return mw.rename(srcParentID, srcName, dstParentID, dstName)
+}
+
+func (mw *MetaWrapper) rename(srcParentID uint64, srcName string, dstParentID uint64, dstName string) (err error) {
 	var oldInode uint64
 
 	srcParentMP := mw.getPartitionByInode(srcParentID)
@@ -378,6 +460,9 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	if err != nil || status != statusOK {
 		return statusToErrno(status)
 	}
+	if mw.enableTransaction {
+		return mw.renameByTx(srcParentMP, dstParentMP, srcParentID, srcName, dstParentID, dstName, inode, mode)
+	}
 	srcMP := mw.getPartitionByInode(inode)
 	if srcMP == nil {
 		return syscall.ENOENT
@@ -408,7 +493,7 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	}
 
 	// delete dentry from src parent
-	status, _, err = mw.ddelete(srcParentMP, srcParentID, srcName)
+	status, _, err = mw.ddeleteRenamed(srcParentMP, srcParentID, srcName, dstParentID, dstName)
 	if err != nil {
 		return statusToErrno(status)
 	} else if status != statusOK {
@@ -441,17 +526,74 @@ func (mw *MetaWrapper) Rename_ll(srcParentID uint64, srcName string, dstParentID
 	return nil
 }
 
+// renameByTx moves the dentry by a transaction of the meta partitions, so the dentry is never left in both or
+// neither of the directories. The link count of the inode is unchanged, and the replaced inode is unlinked after
+// the transaction, which is purged by the orphan scan if the client fails before that.
+func (mw *MetaWrapper) renameByTx(srcParentMP, dstParentMP *MetaPartition, srcParentID uint64, srcName string,
+	dstParentID uint64, dstName string, inode uint64, mode uint32) error {
+	if srcParentID == dstParentID && srcName == dstName {
+		return nil
+	}
+	req := &proto.TxRenameRequest{
+		VolName:        mw.volname,
+		PartitionID:    srcParentMP.PartitionID,
+		TxID:           uuid.New().String(),
+		SrcParentID:    srcParentID,
+		SrcName:        srcName,
+		Inode:          inode,
+		Mode:           mode,
+		DstPartitionID: dstParentMP.PartitionID,
+		DstMembers:     dstParentMP.Members,
+		DstParentID:    dstParentID,
+		DstName:        dstName,
+	}
+	status, oldInode, err := mw.txRename(srcParentMP, req)
+	if err != nil || status != statusOK {
+		return statusToErrno(status)
+	}
+	if oldInode != 0 {
+		inodeMP := mw.getPartitionByInode(oldInode)
+		if inodeMP != nil {
+			mw.iunlink(inodeMP, oldInode)
+			// evict oldInode to avoid oldInode becomes orphan inode
+			mw.ievict(inodeMP, oldInode)
+		}
+	}
+	return nil
+}
+
+// ReadDir_ll reads all the dentries of the directory page by page.
 func (mw *MetaWrapper) ReadDir_ll(parentID uint64) ([]proto.Dentry, error) {
+	var (
+		children = make([]proto.Dentry, 0)
+		page     []proto.Dentry
+		marker   string
+		err      error
+	)
+	for {
+		if page, marker, err = mw.ReadDirLimit_ll(parentID, marker, ReadDirPageSize); err != nil {
+			return nil, err
+		}
+		children = append(children, page...)
+		if marker == "" {
+			return children, nil
+		}
+	}
+}
+
+// ReadDirLimit_ll reads at most limit dentries of the directory after the marker, which are sorted by the names.
+// The returned marker is the one of the next page, it is empty if there is no more.
+func (mw *MetaWrapper) ReadDirLimit_ll(parentID uint64, marker string, limit uint64) ([]proto.Dentry, string, error) {
 	parentMP := mw.getPartitionByInode(parentID)
 	if parentMP == nil {
-		return nil, syscall.ENOENT
+		return nil, "", syscall.ENOENT
 	}
 
-	status, children, err := mw.readdir(parentMP, parentID)
+	status, children, next, err := mw.readdir(parentMP, parentID, marker, limit)
 	if err != nil || status != statusOK {
-		return nil, statusToErrno(status)
+		return nil, "", statusToErrno(status)
 	}
-	return children, nil
+	return children, next, nil
 }
 
 func (mw *MetaWrapper) DentryCreate_ll(parentID uint64, name string, inode uint64, mode uint32) error {
@@ -556,6 +698,9 @@ func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.In
 		log.LogErrorf("Link: No target inode partition, ino(%v)", ino)
 		return nil, syscall.ENOENT
 	}
+	if mw.enableTransaction {
+		return mw.linkByTx(parentMP, mp, parentID, name, ino)
+	}
 
 	// increase inode nlink
 	status, info, err := mw.ilink(mp, ino)
@@ -576,6 +721,30 @@ func (mw *MetaWrapper) Link(parentID uint64, name string, ino uint64) (*proto.In
 	return info, nil
 }
 
+// linkByTx links the inode by a transaction of the meta partitions, so the link count of the inode always matches
+// the dentries.
+func (mw *MetaWrapper) linkByTx(parentMP, mp *MetaPartition, parentID uint64, name string, ino uint64) (
+	*proto.InodeInfo, error) {
+	tx := &proto.TxInfo{
+		TxID: uuid.New().String(),
+		Type: proto.TxTypeLink,
+		Ops: []*proto.TxOp{
+			{PartitionID: mp.PartitionID, Members: mp.Members, Type: proto.TxOpLinkInode, Inode: ino},
+			{PartitionID: parentMP.PartitionID, Members: parentMP.Members, Type: proto.TxOpCreateDentry,
+				ParentID: parentID, Name: name},
+		},
+	}
+	status, _, err := mw.txStart(mp, tx)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	status, info, err := mw.iget(mp, ino)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return info, nil
+}
+
 func (mw *MetaWrapper) Evict(inode uint64) error {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -846,6 +1015,7 @@ func (mw *MetaWrapper) XAttrSet_ll(inode uint64, name, value []byte) error {
 	return nil
 }
 
+// XAttrGet_ll is a low-level meta api that gets the specified xattr, the result has no such xattr if it is missing.
 func (mw *MetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo, error) {
 	mp := mw.getPartitionByInode(inode)
 	if mp == nil {
@@ -853,13 +1023,16 @@ func (mw *MetaWrapper) XAttrGet_ll(inode uint64, name string) (*proto.XAttrInfo,
 		return nil, syscall.ENOENT
 	}
 
-	value, status, err := mw.getXAttr(mp, inode, name)
+	value, exist, status, err := mw.getXAttr(mp, inode, name)
 	if err != nil || status != statusOK {
 		return nil, statusToErrno(status)
 	}
 
+	// the missing attribute is not in the result
 	xAttrValues := make(map[string]string)
-	xAttrValues[name] = string(value)
+	if exist {
+		xAttrValues[name] = string(value)
+	}
 
 	xAttr := &proto.XAttrInfo{
 		Inode:  inode,
@@ -902,3 +1075,95 @@ func (mw *MetaWrapper) XAttrsList_ll(inode uint64) ([]string, error) {
 
 	return keys, nil
 }
+
+// SetLock_ll is a low-level meta api that locks or unlocks a range of the file, it returns EAGAIN if the lock
+// conflicts with the ones held by others.
+func (mw *MetaWrapper) SetLock_ll(inode uint64, lock *proto.FileLock) error {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("SetLock_ll: no such partition, ino(%v)", inode)
+		return syscall.ENOENT
+	}
+	status, err := mw.setLock(mp, inode, lock)
+	if err != nil {
+		return statusToErrno(status)
+	}
+	switch status {
+	case statusOK:
+		return nil
+	case statusExist:
+		return syscall.EAGAIN
+	default:
+		return statusToErrno(status)
+	}
+}
+
+// GetLock_ll is a low-level meta api that returns the lock conflicting with the given one, nil if there is none.
+func (mw *MetaWrapper) GetLock_ll(inode uint64, lock *proto.FileLock) (*proto.FileLock, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetLock_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	conflict, status, err := mw.getLock(mp, inode, lock)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return conflict, nil
+}
+
+// RenewLocks renews the leases of the locks held by the session, which are grouped by the meta partitions.
+func (mw *MetaWrapper) RenewLocks(session string, locks map[uint64]proto.FileLocks) error {
+	items := make(map[*MetaPartition][]proto.RenewLockItem)
+	for ino, fileLocks := range locks {
+		mp := mw.getPartitionByInode(ino)
+		if mp == nil {
+			log.LogErrorf("RenewLocks: no such partition, ino(%v)", ino)
+			continue
+		}
+		items[mp] = append(items[mp], proto.RenewLockItem{Inode: ino, Locks: fileLocks})
+	}
+	var lastErr error
+	for mp, renewItems := range items {
+		status, err := mw.renewLock(mp, session, renewItems)
+		if err != nil || status != statusOK {
+			lastErr = statusToErrno(status)
+		}
+	}
+	return lastErr
+}
+
+// UpdateSummary_ll is a low-level meta api that adds the delta to the summary of the directory, it returns the
+// summary after the update, or ENOENT if the directory does not exist.
+func (mw *MetaWrapper) UpdateSummary_ll(inode uint64, delta *proto.DirSummary) (*proto.DirSummary, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("UpdateSummary_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	summary, status, err := mw.updateSummary(mp, inode, delta)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	return summary, nil
+}
+
+// GetSummary_ll is a low-level meta api that returns the summary of the directory, which is empty if the directory
+// has not been changed since the summary is enabled.
+func (mw *MetaWrapper) GetSummary_ll(inode uint64) (*proto.DirSummary, error) {
+	mp := mw.getPartitionByInode(inode)
+	if mp == nil {
+		log.LogErrorf("GetSummary_ll: no such partition, ino(%v)", inode)
+		return nil, syscall.ENOENT
+	}
+	value, _, status, err := mw.getXAttr(mp, inode, proto.SummaryXAttrKey)
+	if err != nil || status != statusOK {
+		return nil, statusToErrno(status)
+	}
+	summary, err := proto.ParseDirSummary([]byte(value))
+	if err != nil {
+		log.LogErrorf("GetSummary_ll: ino(%v) value(%v) err(%v)", inode, value, err)
+		return nil, syscall.EIO
+	}
+	return summary, nil
+}
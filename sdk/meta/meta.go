@@ -34,6 +34,9 @@ import (
 const (
 	HostsSeparator                = ","
 	RefreshMetaPartitionsInterval = time.Minute * 5
+	RefreshDirQuotasInterval      = time.Minute
+	PurgeTrashInterval            = time.Hour
+	ReadDirPageSize               = 1000 // the dentries read by a request
 )
 
 const (
@@ -46,6 +49,7 @@ const (
 	statusError
 	statusInval
 	statusNotPerm
+	statusNoSpace
 )
 
 const (
@@ -75,6 +79,8 @@ type MetaConfig struct {
 	TicketMess       auth.TicketMess
 	ValidateOwner    bool
 	OnAsyncTaskError AsyncTaskErrorFunc
+	// Rename across the meta partitions by the transactions of the meta nodes
+	EnableTransaction bool
 }
 
 type MetaWrapper struct {
@@ -125,6 +131,23 @@ type MetaWrapper struct {
 	// Used to trigger and throttle instant partition updates
 	forceUpdate      chan struct{}
 	forceUpdateLimit *rate.Limiter
+
+	// Directory quotas of the volume, and the cached quotas of the inodes
+	quotaLock   sync.RWMutex
+	dirQuotas   map[uint32]*proto.DirQuota
+	inodeQuotas map[uint64][]uint32
+
+	// Seconds to keep the removed files in the trash, 0 if the trash is disabled
+	trashTTL     uint64
+	trashLock    sync.Mutex
+	trashCkpt    string
+	trashCkptIno uint64
+
+	// The latest volume snapshot, stamped on the requests changing the extents of the files
+	snapshotEpoch uint64
+
+	// Rename across the meta partitions atomically by the transactions
+	enableTransaction bool
 }
 
 //the ticket from authnode
@@ -160,6 +183,7 @@ func NewMetaWrapper(config *MetaConfig) (*MetaWrapper, error) {
 	mw.ownerValidation = config.ValidateOwner
 	mw.mc = masterSDK.NewMasterClient(config.Masters, false)
 	mw.onAsyncTaskError = config.OnAsyncTaskError
+	mw.enableTransaction = config.EnableTransaction
 	mw.conns = util.NewConnectPool()
 	mw.partitions = make(map[uint64]*MetaPartition)
 	mw.ranges = btree.New(32)
@@ -206,6 +230,8 @@ func (mw *MetaWrapper) initMetaWrapper() (err error) {
 		return err
 	}
 
+	_ = mw.updateDirQuotas()
+	_ = mw.updateVolSimpleInfo()
 	return nil
 }
 
@@ -258,6 +284,8 @@ func parseStatus(result uint8) (status int) {
 		status = statusInval
 	case proto.OpNotPerm:
 		status = statusNotPerm
+	case proto.OpDiskNoSpaceErr:
+		status = statusNoSpace
 	default:
 		status = statusError
 	}
@@ -281,6 +309,8 @@ func statusToErrno(status int) error {
 		return syscall.EINVAL
 	case statusNotPerm:
 		return syscall.EPERM
+	case statusNoSpace:
+		return syscall.ENOSPC
 	case statusError:
 		return syscall.EAGAIN
 	default:
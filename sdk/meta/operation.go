@@ -239,11 +239,19 @@ func (mw *MetaWrapper) dupdate(mp *MetaPartition, parentID uint64, name string,
 }
 
 func (mw *MetaWrapper) ddelete(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, err error) {
+	return mw.ddeleteRenamed(mp, parentID, name, 0, "")
+}
+
+// ddeleteRenamed deletes the dentry, the destination is given if the dentry is deleted by a rename so that the
+// meta partition records it in the change event.
+func (mw *MetaWrapper) ddeleteRenamed(mp *MetaPartition, parentID uint64, name string, dstParentID uint64, dstName string) (status int, inode uint64, err error) {
 	req := &proto.DeleteDentryRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		ParentID:    parentID,
 		Name:        name,
+		DstParentID: dstParentID,
+		DstName:     dstName,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -279,6 +287,83 @@ func (mw *MetaWrapper) ddelete(mp *MetaPartition, parentID uint64, name string)
 	return statusOK, resp.Inode, nil
 }
 
+// txRename renames the dentry by the transaction coordinated by the meta partition of the source parent.
+func (mw *MetaWrapper) txRename(mp *MetaPartition, req *proto.TxRenameRequest) (status int, oldInode uint64, err error) {
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxRename
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("txRename: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("txRename: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txRename: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.TxRenameResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("txRename: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("txRename: packet(%v) mp(%v) req(%v) oldIno(%v)", packet, mp, *req, resp.OldInode)
+	return statusOK, resp.OldInode, nil
+}
+
+// txStart runs the transaction coordinated by the meta partition of the first operation, and returns the operations
+// committed.
+func (mw *MetaWrapper) txStart(mp *MetaPartition, tx *proto.TxInfo) (status int, ops []*proto.TxOp, err error) {
+	req := &proto.TxStartRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Tx:          tx,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaTxStart
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("txStart: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("txStart: packet(%v) mp(%v) txID(%v) err(%v)", packet, mp, tx.TxID, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("txStart: packet(%v) mp(%v) txID(%v) result(%v)", packet, mp, tx.TxID, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.TxResponse)
+	err = packet.UnmarshalData(resp)
+	if err != nil {
+		log.LogErrorf("txStart: packet(%v) mp(%v) err(%v) PacketData(%v)", packet, mp, err, string(packet.Data))
+		return
+	}
+	log.LogDebugf("txStart: packet(%v) mp(%v) txID(%v)", packet, mp, tx.TxID)
+	return statusOK, resp.Ops, nil
+}
+
 func (mw *MetaWrapper) lookup(mp *MetaPartition, parentID uint64, name string) (status int, inode uint64, mode uint32, err error) {
 	req := &proto.LookupRequest{
 		VolName:     mw.volname,
@@ -414,11 +499,14 @@ func (mw *MetaWrapper) batchIget(wg *sync.WaitGroup, mp *MetaPartition, inodes [
 	}
 }
 
-func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int, children []proto.Dentry, err error) {
+// readdir reads at most limit dentries after the marker, the next marker is empty if there is no more.
+func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64, marker string, limit uint64) (status int, children []proto.Dentry, next string, err error) {
 	req := &proto.ReadDirRequest{
 		VolName:     mw.volname,
 		PartitionID: mp.PartitionID,
 		ParentID:    parentID,
+		Marker:      marker,
+		Limit:       limit,
 	}
 
 	packet := proto.NewPacketReqID()
@@ -452,15 +540,16 @@ func (mw *MetaWrapper) readdir(mp *MetaPartition, parentID uint64) (status int,
 		return
 	}
 	log.LogDebugf("readdir: packet(%v) mp(%v) req(%v)", packet, mp, *req)
-	return statusOK, resp.Children, nil
+	return statusOK, resp.Children, resp.NextMarker, nil
 }
 
 func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent proto.ExtentKey) (status int, err error) {
 	req := &proto.AppendExtentKeyRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		Inode:       inode,
-		Extent:      extent,
+		VolName:       mw.volname,
+		PartitionID:   mp.PartitionID,
+		Inode:         inode,
+		Extent:        extent,
+		SnapshotEpoch: mw.SnapshotEpoch(),
 	}
 
 	packet := proto.NewPacketReqID()
@@ -474,7 +563,7 @@ func (mw *MetaWrapper) appendExtentKey(mp *MetaPartition, inode uint64, extent p
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendStampedToMetaPartition(mp, packet, req, &req.SnapshotEpoch)
 	if err != nil {
 		log.LogErrorf("appendExtentKey: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -529,10 +618,11 @@ func (mw *MetaWrapper) getExtents(mp *MetaPartition, inode uint64) (status int,
 
 func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64) (status int, err error) {
 	req := &proto.TruncateRequest{
-		VolName:     mw.volname,
-		PartitionID: mp.PartitionID,
-		Inode:       inode,
-		Size:        size,
+		VolName:       mw.volname,
+		PartitionID:   mp.PartitionID,
+		Inode:         inode,
+		Size:          size,
+		SnapshotEpoch: mw.SnapshotEpoch(),
 	}
 
 	packet := proto.NewPacketReqID()
@@ -548,7 +638,7 @@ func (mw *MetaWrapper) truncate(mp *MetaPartition, inode, size uint64) (status i
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendStampedToMetaPartition(mp, packet, req, &req.SnapshotEpoch)
 	if err != nil {
 		log.LogErrorf("truncate: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -849,10 +939,11 @@ func (mw *MetaWrapper) removeMultipart(mp *MetaPartition, path, multipartId stri
 
 func (mw *MetaWrapper) appendExtentKeys(mp *MetaPartition, inode uint64, extents []proto.ExtentKey) (status int, err error) {
 	req := &proto.AppendExtentKeysRequest{
-		VolName:     mw.volname,
-		PartitionId: mp.PartitionID,
-		Inode:       inode,
-		Extents:     extents,
+		VolName:       mw.volname,
+		PartitionId:   mp.PartitionID,
+		Inode:         inode,
+		Extents:       extents,
+		SnapshotEpoch: mw.SnapshotEpoch(),
 	}
 
 	packet := proto.NewPacketReqID()
@@ -867,7 +958,7 @@ func (mw *MetaWrapper) appendExtentKeys(mp *MetaPartition, inode uint64, extents
 	metric := exporter.NewTPCnt(packet.GetOpMsg())
 	defer metric.Set(err)
 
-	packet, err = mw.sendToMetaPartition(mp, packet)
+	packet, err = mw.sendStampedToMetaPartition(mp, packet, req, &req.SnapshotEpoch)
 	if err != nil {
 		log.LogErrorf("batch append extent: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
 		return
@@ -921,7 +1012,7 @@ func (mw *MetaWrapper) setXAttr(mp *MetaPartition, inode uint64, name []byte, va
 	return
 }
 
-func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (value string, status int, err error) {
+func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (value string, exist bool, status int, err error) {
 	req := &proto.GetXAttrRequest{
 		VolName:     mw.volname,
 		PartitionId: mp.PartitionID,
@@ -958,7 +1049,8 @@ func (mw *MetaWrapper) getXAttr(mp *MetaPartition, inode uint64, name string) (v
 		log.LogErrorf("get xattr: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
 		return
 	}
-	value = resp.Value
+	// the meta nodes which do not report the existence reply the empty value for the missing attribute
+	value, exist = resp.Value, resp.Exist || resp.Value != ""
 
 	log.LogDebugf("get xattr: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
 	return
@@ -1125,3 +1217,200 @@ func (mw *MetaWrapper) batchGetXAttr(mp *MetaPartition, inodes []uint64, keys []
 
 	return resp.XAttrs, nil
 }
+
+func (mw *MetaWrapper) setLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (status int, err error) {
+	req := &proto.SetLockRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+		Lease:       proto.DefaultLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaSetLock
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("setLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK && status != statusExist {
+		log.LogErrorf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("setLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
+func (mw *MetaWrapper) getLock(mp *MetaPartition, inode uint64, lock *proto.FileLock) (conflict *proto.FileLock, status int, err error) {
+	req := &proto.GetLockRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Lock:        *lock,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetLock
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.GetLockResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getLock: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	conflict = resp.Lock
+
+	log.LogDebugf("getLock: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+	return
+}
+
+func (mw *MetaWrapper) renewLock(mp *MetaPartition, session string, items []proto.RenewLockItem) (status int, err error) {
+	req := &proto.RenewLockRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Session:     session,
+		Items:       items,
+		Lease:       proto.DefaultLockLease,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaRenewLock
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("renewLock: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("renewLock: packet(%v) mp(%v) session(%v) err(%v)", packet, mp, session, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("renewLock: packet(%v) mp(%v) session(%v) result(%v)", packet, mp, session, packet.GetResultMsg())
+		return
+	}
+
+	log.LogDebugf("renewLock: packet(%v) mp(%v) session(%v) items(%v)", packet, mp, session, len(items))
+	return
+}
+
+func (mw *MetaWrapper) updateSummary(mp *MetaPartition, inode uint64, delta *proto.DirSummary) (summary *proto.DirSummary, status int, err error) {
+	req := &proto.UpdateSummaryRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		Inode:       inode,
+		Delta:       *delta,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaUpdateSummary
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("updateSummary: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("updateSummary: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("updateSummary: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp := new(proto.UpdateSummaryResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("updateSummary: packet(%v) mp(%v) req(%v) err(%v) PacketData(%v)", packet, mp, *req, err, string(packet.Data))
+		return
+	}
+	summary = &resp.Summary
+
+	log.LogDebugf("updateSummary: packet(%v) mp(%v) req(%v) summary(%v)", packet, mp, *req, *summary)
+	return
+}
+
+func (mw *MetaWrapper) getEvents(mp *MetaPartition, from uint64, limit int) (resp *proto.GetEventsResponse, status int, err error) {
+	req := &proto.GetEventsRequest{
+		VolName:     mw.volname,
+		PartitionID: mp.PartitionID,
+		From:        from,
+		Limit:       limit,
+	}
+
+	packet := proto.NewPacketReqID()
+	packet.Opcode = proto.OpMetaGetEvents
+	err = packet.MarshalData(req)
+	if err != nil {
+		log.LogErrorf("getEvents: req(%v) err(%v)", *req, err)
+		return
+	}
+
+	metric := exporter.NewTPCnt(packet.GetOpMsg())
+	defer metric.Set(err)
+
+	packet, err = mw.sendToMetaPartition(mp, packet)
+	if err != nil {
+		log.LogErrorf("getEvents: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	status = parseStatus(packet.ResultCode)
+	if status != statusOK {
+		log.LogErrorf("getEvents: packet(%v) mp(%v) req(%v) result(%v)", packet, mp, *req, packet.GetResultMsg())
+		return
+	}
+
+	resp = new(proto.GetEventsResponse)
+	if err = packet.UnmarshalData(resp); err != nil {
+		log.LogErrorf("getEvents: packet(%v) mp(%v) req(%v) err(%v)", packet, mp, *req, err)
+		return
+	}
+
+	log.LogDebugf("getEvents: packet(%v) mp(%v) req(%v) events(%v)", packet, mp, *req, len(resp.Events))
+	return
+}
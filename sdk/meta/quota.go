@@ -0,0 +1,115 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"syscall"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// updateDirQuotas fetches the directory quotas of the volume with the usage from master. The cached quotas of
+// the inodes are dropped, so that the inodes tagged by master meanwhile are checked with the new quotas.
+func (mw *MetaWrapper) updateDirQuotas() (err error) {
+	var quotas []*proto.DirQuota
+	if quotas, err = mw.mc.AdminAPI().ListDirQuotas(mw.volname); err != nil {
+		log.LogWarnf("updateDirQuotas: get dir quotas fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	dirQuotas := make(map[uint32]*proto.DirQuota, len(quotas))
+	for _, quota := range quotas {
+		dirQuotas[quota.QuotaID] = quota
+	}
+	mw.quotaLock.Lock()
+	mw.dirQuotas = dirQuotas
+	mw.inodeQuotas = make(map[uint64][]uint32)
+	mw.quotaLock.Unlock()
+	return
+}
+
+// getInodeQuotas returns the IDs of the directory quotas of the inode. Nothing is fetched from the meta partition
+// if the volume has no quota.
+func (mw *MetaWrapper) getInodeQuotas(ino uint64) (ids []uint32, err error) {
+	var (
+		info *proto.XAttrInfo
+		ok   bool
+	)
+	mw.quotaLock.RLock()
+	if len(mw.dirQuotas) == 0 {
+		mw.quotaLock.RUnlock()
+		return
+	}
+	ids, ok = mw.inodeQuotas[ino]
+	mw.quotaLock.RUnlock()
+	if ok {
+		return
+	}
+	if info, err = mw.XAttrGet_ll(ino, proto.QuotaXAttrKey); err != nil {
+		return
+	}
+	ids = proto.ParseQuotaIDs(string(info.Get(proto.QuotaXAttrKey)))
+	mw.cacheInodeQuotas(ino, ids)
+	return
+}
+
+func (mw *MetaWrapper) cacheInodeQuotas(ino uint64, ids []uint32) {
+	mw.quotaLock.Lock()
+	if mw.inodeQuotas != nil {
+		mw.inodeQuotas[ino] = ids
+	}
+	mw.quotaLock.Unlock()
+}
+
+// checkDirQuotas returns EDQUOT if the bytes or the files, when creating a file, of any quota reach the limit.
+func (mw *MetaWrapper) checkDirQuotas(ids []uint32, create bool) error {
+	mw.quotaLock.RLock()
+	defer mw.quotaLock.RUnlock()
+	for _, id := range ids {
+		quota, ok := mw.dirQuotas[id]
+		if !ok {
+			continue
+		}
+		if quota.BytesExceeded() || (create && quota.FilesExceeded()) {
+			log.LogWarnf("checkDirQuotas: volume(%v) quota(%v) path(%v) bytes(%v/%v) files(%v/%v) exceeded",
+				mw.volname, id, quota.Path, quota.UsedBytes, quota.MaxBytes, quota.UsedFiles, quota.MaxFiles)
+			return syscall.EDQUOT
+		}
+	}
+	return nil
+}
+
+// CheckWriteQuota returns EDQUOT if the bytes of any directory quota of the inode reach the limit. The inode is
+// allowed to be written if its quotas fail to be fetched.
+func (mw *MetaWrapper) CheckWriteQuota(ino uint64) error {
+	ids, err := mw.getInodeQuotas(ino)
+	if err != nil {
+		log.LogWarnf("CheckWriteQuota: volume(%v) ino(%v) err(%v)", mw.volname, ino, err)
+		return nil
+	}
+	return mw.checkDirQuotas(ids, false)
+}
+
+func equalQuotaIDs(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
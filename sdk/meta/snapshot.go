@@ -0,0 +1,59 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package meta
+
+import (
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The requests changing the extents of the files are stamped with the latest volume snapshot known by the client,
+// and the meta partitions reject the ones stamped with an epoch older than their latest snapshot, since the client
+// may not know the extents are kept by the snapshot.
+
+// SnapshotEpoch returns the latest volume snapshot known by the client.
+func (mw *MetaWrapper) SnapshotEpoch() uint64 {
+	return atomic.LoadUint64(&mw.snapshotEpoch)
+}
+
+// setSnapshotEpoch moves the epoch forward, the epochs of the snapshots never go back.
+func (mw *MetaWrapper) setSnapshotEpoch(epoch uint64) {
+	for {
+		old := atomic.LoadUint64(&mw.snapshotEpoch)
+		if epoch <= old || atomic.CompareAndSwapUint64(&mw.snapshotEpoch, old, epoch) {
+			return
+		}
+	}
+}
+
+// sendStampedToMetaPartition sends the request stamped with the epoch. If the meta partition has taken a newer
+// snapshot, the epoch is fetched from master, and the request is stamped with it and sent again.
+func (mw *MetaWrapper) sendStampedToMetaPartition(mp *MetaPartition, packet *proto.Packet, req interface{}, epoch *uint64) (resp *proto.Packet, err error) {
+	if resp, err = mw.sendToMetaPartition(mp, packet); err != nil || resp.ResultCode != proto.OpStaleEpochErr {
+		return
+	}
+	log.LogWarnf("sendStampedToMetaPartition: stale epoch, mp(%v) req(%v) epoch(%v) result(%v)",
+		mp.PartitionID, packet, *epoch, resp.GetResultMsg())
+	if err = mw.updateVolSimpleInfo(); err != nil {
+		return
+	}
+	*epoch = mw.SnapshotEpoch()
+	if err = packet.MarshalData(req); err != nil {
+		return
+	}
+	packet.ReqID = proto.GenerateRequestID()
+	return mw.sendToMetaPartition(mp, packet)
+}
@@ -0,0 +1,219 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The removed files are moved to "/.Trash/<checkpoint>/<parent inode>/<name>", where the checkpoint is the
+// hour of the removal. The removed directories are moved along with the files removed from them in the same
+// checkpoint, so a tree removed by "rm -rf" is found under "/.Trash/<checkpoint>/<parent inode>" as a whole.
+const (
+	TrashDirName          = ".Trash"
+	TrashCheckpointFormat = "2006-01-02-15"
+)
+
+// updateVolSimpleInfo fetches the trash TTL and the latest snapshot of the volume from master.
+func (mw *MetaWrapper) updateVolSimpleInfo() (err error) {
+	var view *proto.SimpleVolView
+	if view, err = mw.mc.AdminAPI().GetVolumeSimpleInfo(mw.volname); err != nil {
+		log.LogWarnf("updateVolSimpleInfo: get volume simple info fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	atomic.StoreUint64(&mw.trashTTL, view.TrashTTL)
+	mw.setSnapshotEpoch(view.SnapshotEpoch)
+	return
+}
+
+// TrashEnabled returns if the removed files are moved to the trash instead of being deleted.
+func (mw *MetaWrapper) TrashEnabled() bool {
+	return atomic.LoadUint64(&mw.trashTTL) > 0
+}
+
+// Trash_ll moves the file to the trash. The directory is deleted as Delete_ll does, and the files moved to the
+// trash from it are moved along. The returned inode info is always nil, since no inode is unlinked.
+func (mw *MetaWrapper) Trash_ll(parentID uint64, name string, isDir bool) (*proto.InodeInfo, error) {
+	if parentID == proto.RootIno && name == TrashDirName {
+		mw.resetTrashCheckpoint()
+		return mw.Delete_ll(parentID, name, isDir)
+	}
+	ckptIno, err := mw.trashCheckpoint()
+	if err != nil {
+		log.LogErrorf("Trash_ll: prepare trash fail: parentID(%v) name(%v) err(%v)", parentID, name, err)
+		return nil, err
+	}
+	if !isDir {
+		var dstParentID uint64
+		if dstParentID, err = mw.lookupOrMkdir(ckptIno, strconv.FormatUint(parentID, 10)); err != nil {
+			// the checkpoint may be purged by others
+			mw.resetTrashCheckpoint()
+			return nil, err
+		}
+		return nil, mw.rename(parentID, name, dstParentID, mw.trashName(dstParentID, name))
+	}
+
+	ino, _, err := mw.Lookup_ll(parentID, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = mw.Delete_ll(parentID, name, true); err != nil {
+		return nil, err
+	}
+	dirName := strconv.FormatUint(ino, 10)
+	if _, _, err = mw.Lookup_ll(ckptIno, dirName); err == syscall.ENOENT {
+		// nothing was removed from the directory in this checkpoint
+		return nil, nil
+	}
+	var dstParentID uint64
+	if err == nil {
+		dstParentID, err = mw.lookupOrMkdir(ckptIno, strconv.FormatUint(parentID, 10))
+	}
+	if err == nil {
+		err = mw.rename(ckptIno, dirName, dstParentID, mw.trashName(dstParentID, name))
+	}
+	if err != nil {
+		// the directory is deleted anyway, and the removed files are still kept in the trash
+		log.LogWarnf("Trash_ll: move the removed files of dir fail: parentID(%v) name(%v) ino(%v) err(%v)",
+			parentID, name, ino, err)
+	}
+	return nil, nil
+}
+
+// trashCheckpoint returns the inode of the trash directory of the current checkpoint, which is created if absent.
+func (mw *MetaWrapper) trashCheckpoint() (ino uint64, err error) {
+	ckpt := time.Now().UTC().Format(TrashCheckpointFormat)
+	mw.trashLock.Lock()
+	defer mw.trashLock.Unlock()
+	if mw.trashCkpt == ckpt {
+		return mw.trashCkptIno, nil
+	}
+	var trashIno uint64
+	if trashIno, err = mw.lookupOrMkdir(proto.RootIno, TrashDirName); err != nil {
+		return
+	}
+	if ino, err = mw.lookupOrMkdir(trashIno, ckpt); err != nil {
+		return
+	}
+	mw.trashCkpt, mw.trashCkptIno = ckpt, ino
+	return
+}
+
+func (mw *MetaWrapper) resetTrashCheckpoint() {
+	mw.trashLock.Lock()
+	mw.trashCkpt, mw.trashCkptIno = "", 0
+	mw.trashLock.Unlock()
+}
+
+// trashName returns the name which is not used in the trash directory yet.
+func (mw *MetaWrapper) trashName(parentID uint64, name string) string {
+	if _, _, err := mw.Lookup_ll(parentID, name); err == syscall.ENOENT {
+		return name
+	}
+	return fmt.Sprintf("%v_%v", name, time.Now().UnixNano())
+}
+
+func (mw *MetaWrapper) lookupOrMkdir(parentID uint64, name string) (ino uint64, err error) {
+	if ino, _, err = mw.Lookup_ll(parentID, name); err != syscall.ENOENT {
+		return
+	}
+	var info *proto.InodeInfo
+	info, err = mw.Create_ll(parentID, name, proto.Mode(os.ModeDir|0777), 0, 0, nil)
+	if err == syscall.EEXIST {
+		// created by others meanwhile
+		ino, _, err = mw.Lookup_ll(parentID, name)
+		return
+	}
+	if err != nil {
+		return
+	}
+	return info.Inode, nil
+}
+
+// PurgeTrash deletes the checkpoints of the trash periodically once they are kept longer than the trash TTL.
+// The clients purge the trash independently, and a checkpoint failed to purge is retried next time.
+func (mw *MetaWrapper) PurgeTrash() {
+	t := time.NewTicker(PurgeTrashInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			mw.purgeTrash()
+		case <-mw.closeCh:
+			return
+		}
+	}
+}
+
+func (mw *MetaWrapper) purgeTrash() {
+	ttl := atomic.LoadUint64(&mw.trashTTL)
+	trashIno, _, err := mw.Lookup_ll(proto.RootIno, TrashDirName)
+	if err != nil {
+		return
+	}
+	ckpts, err := mw.ReadDir_ll(trashIno)
+	if err != nil {
+		log.LogWarnf("purgeTrash: read trash fail: volume(%v) err(%v)", mw.volname, err)
+		return
+	}
+	for _, ckpt := range ckpts {
+		t, e := time.Parse(TrashCheckpointFormat, ckpt.Name)
+		if e != nil {
+			continue
+		}
+		// the files removed after the trash is disabled are deleted directly, so the trash is emptied at last
+		if ttl > 0 && t.Add(time.Hour).Add(time.Duration(ttl)*time.Second).After(time.Now()) {
+			continue
+		}
+		if err = mw.removeAll(trashIno, ckpt.Name, ckpt.Inode, proto.IsDir(ckpt.Type)); err != nil {
+			log.LogWarnf("purgeTrash: purge checkpoint fail: volume(%v) checkpoint(%v) err(%v)",
+				mw.volname, ckpt.Name, err)
+			continue
+		}
+		mw.resetTrashCheckpoint()
+		log.LogInfof("purgeTrash: volume(%v) checkpoint(%v) purged", mw.volname, ckpt.Name)
+	}
+}
+
+// removeAll deletes the tree recursively, and evicts the unlinked inodes.
+func (mw *MetaWrapper) removeAll(parentID uint64, name string, ino uint64, isDir bool) (err error) {
+	if isDir {
+		var children []proto.Dentry
+		if children, err = mw.ReadDir_ll(ino); err != nil {
+			return
+		}
+		for _, child := range children {
+			if err = mw.removeAll(ino, child.Name, child.Inode, proto.IsDir(child.Type)); err != nil {
+				return
+			}
+		}
+	}
+	var info *proto.InodeInfo
+	if info, err = mw.Delete_ll(parentID, name, isDir); err != nil {
+		return
+	}
+	if info != nil && !isDir && info.Nlink == 0 {
+		err = mw.Evict(info.Inode)
+	}
+	return
+}
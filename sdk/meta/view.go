@@ -211,6 +211,8 @@ func (mw *MetaWrapper) refresh() {
 
 	t := time.NewTimer(RefreshMetaPartitionsInterval)
 	defer t.Stop()
+	quotaTicker := time.NewTicker(RefreshDirQuotasInterval)
+	defer quotaTicker.Stop()
 
 	for {
 		select {
@@ -224,6 +226,9 @@ func (mw *MetaWrapper) refresh() {
 				log.LogErrorf("updateVolStatInfo fail cause: %v", err)
 			}
 			t.Reset(RefreshMetaPartitionsInterval)
+		case <-quotaTicker.C:
+			_ = mw.updateDirQuotas()
+			_ = mw.updateVolSimpleInfo()
 		case <-mw.forceUpdate:
 			log.LogInfof("Start forceUpdateMetaPartitions")
 			mw.partMutex.Lock()
@@ -0,0 +1,185 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package meta
+
+import (
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/proto"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+const (
+	WatchInterval  = 500 * time.Millisecond
+	watchBatchSize = 1000
+)
+
+// EventHandler handles the change events delivered by a watcher.
+type EventHandler func(event *proto.MetaEvent)
+
+// Watcher reads the change events of all the meta partitions of the volume periodically, and delivers the ones of
+// the watched subtree in the order of each meta partition. The events of different meta partitions are not
+// ordered. A lost event is delivered if the events of a meta partition are dropped before they are read, e.g. the
+// leader restarts, and the watcher should rescan the metadata of the subtree.
+type Watcher struct {
+	mw      *MetaWrapper
+	handler EventHandler
+	cursors map[uint64]uint64 // the sequences read of the meta partitions
+	tracked map[uint64]struct{}
+	stopC   chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Watch starts to deliver the events happened since now in the subtree of the root directory. The inodes in the
+// subtree are scanned first unless the root is the root of the volume.
+func (mw *MetaWrapper) Watch(root uint64, handler EventHandler) (w *Watcher, err error) {
+	w = &Watcher{
+		mw:      mw,
+		handler: handler,
+		cursors: make(map[uint64]uint64),
+		stopC:   make(chan struct{}),
+	}
+	for _, mp := range w.partitions() {
+		resp, status, e := mw.getEvents(mp, 0, 1)
+		if e != nil || status != statusOK {
+			log.LogErrorf("Watch: mp(%v) status(%v) err(%v)", mp, status, e)
+			return nil, statusToErrno(statusError)
+		}
+		w.cursors[mp.PartitionID] = resp.Applied
+	}
+	if root != proto.RootIno {
+		w.tracked = make(map[uint64]struct{})
+		w.track(root, true)
+	}
+	w.wg.Add(1)
+	go w.run()
+	return
+}
+
+// Stop stops delivering the events.
+func (w *Watcher) Stop() {
+	close(w.stopC)
+	w.wg.Wait()
+}
+
+func (w *Watcher) partitions() []*MetaPartition {
+	w.mw.RLock()
+	defer w.mw.RUnlock()
+	partitions := make([]*MetaPartition, 0, len(w.mw.partitions))
+	for _, mp := range w.mw.partitions {
+		partitions = append(partitions, mp)
+	}
+	return partitions
+}
+
+func (w *Watcher) run() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stopC:
+			return
+		case <-ticker.C:
+		}
+		for _, mp := range w.partitions() {
+			w.poll(mp)
+		}
+	}
+}
+
+// poll reads and delivers the events of the meta partition until there is no more.
+func (w *Watcher) poll(mp *MetaPartition) {
+	// the events of a new meta partition are all read
+	cursor := w.cursors[mp.PartitionID]
+	for {
+		resp, status, err := w.mw.getEvents(mp, cursor, watchBatchSize)
+		if err != nil || status != statusOK {
+			log.LogWarnf("Watcher: mp(%v) cursor(%v) status(%v) err(%v)", mp, cursor, status, err)
+			return
+		}
+		if cursor < resp.Base {
+			w.handler(&proto.MetaEvent{PartitionID: mp.PartitionID, Seq: resp.Base, Type: proto.MetaEventLost})
+		}
+		for _, event := range resp.Events {
+			if w.filter(event) {
+				w.handler(event)
+			}
+			cursor = event.Seq
+		}
+		if len(resp.Events) < watchBatchSize {
+			if cursor < resp.Applied {
+				cursor = resp.Applied
+			}
+			w.cursors[mp.PartitionID] = cursor
+			return
+		}
+		w.cursors[mp.PartitionID] = cursor
+	}
+}
+
+// track adds the inode to the watched subtree, the entries in it are added too if it is a directory.
+func (w *Watcher) track(ino uint64, isDir bool) {
+	w.tracked[ino] = struct{}{}
+	if !isDir {
+		return
+	}
+	children, err := w.mw.ReadDir_ll(ino)
+	if err != nil {
+		log.LogWarnf("Watcher: scan ino(%v) err(%v)", ino, err)
+		return
+	}
+	for _, child := range children {
+		w.track(child.Inode, proto.IsDir(child.Type))
+	}
+}
+
+func (w *Watcher) isTracked(ino uint64) bool {
+	_, ok := w.tracked[ino]
+	return ok
+}
+
+// filter checks if the event happens in the watched subtree, and updates the inodes of the subtree by it.
+func (w *Watcher) filter(event *proto.MetaEvent) bool {
+	if w.tracked == nil {
+		return true
+	}
+	switch event.Type {
+	case proto.MetaEventCreate, proto.MetaEventReplace:
+		if !w.isTracked(event.ParentID) {
+			return false
+		}
+		w.tracked[event.Inode] = struct{}{}
+		return true
+	case proto.MetaEventDelete:
+		if !w.isTracked(event.ParentID) {
+			return false
+		}
+		delete(w.tracked, event.Inode)
+		return true
+	case proto.MetaEventRename:
+		from, to := w.isTracked(event.ParentID), w.isTracked(event.DstParentID)
+		if to && !from {
+			// the entries moved into the subtree are scanned
+			w.track(event.Inode, proto.IsDir(event.Mode))
+		} else if !to {
+			delete(w.tracked, event.Inode)
+		}
+		return from || to
+	default:
+		return w.isTracked(event.Inode)
+	}
+}
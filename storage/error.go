@@ -33,8 +33,21 @@ var (
 	ExtentIsFullError         = errors.New("extent is full")
 	BrokenExtentError         = errors.New("extent has been broken")
 	BrokenDiskError           = errors.New("disk has broken")
+	ExtentCompressedError     = errors.New("extent is compressed")
+	ExtentPackedError         = errors.New("extent is packed")
+	ExtentNotSendableError    = errors.New("extent can not be sent by zero copy")
 )
 
+func NewBlockCrcMismatchErr(extentID uint64, blockNo int, expected, actual uint32) (err error) {
+	err = fmt.Errorf("block crc mismatch: extent(%v) block(%v) expected(%v) actual(%v)", extentID, blockNo, expected, actual)
+	return
+}
+
+func NewExtentKeyNotFoundErr(partitionID, extentID uint64, keyVersion uint32) (err error) {
+	err = fmt.Errorf("encryption key not found: partition(%v) extent(%v) key version(%v)", partitionID, extentID, keyVersion)
+	return
+}
+
 func NewParameterMismatchErr(msg string) (err error) {
 	err = fmt.Errorf("parameter mismatch error: %s", msg)
 	return
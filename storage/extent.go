@@ -43,12 +43,13 @@ const (
 )
 
 type ExtentInfo struct {
-	FileID     uint64 `json:"fileId"`
-	Size       uint64 `json:"size"`
-	Crc        uint32 `json:"Crc"`
-	IsDeleted  bool   `json:"deleted"`
-	ModifyTime int64  `json:"modTime"`
-	Source     string `json:"src"`
+	FileID     uint64   `json:"fileId"`
+	Size       uint64   `json:"size"`
+	Crc        uint32   `json:"Crc"`
+	IsDeleted  bool     `json:"deleted"`
+	ModifyTime int64    `json:"modTime"`
+	Source     string   `json:"src"`
+	Sources    []string `json:"srcs,omitempty"` // the other replicas to repair the extent from if the source fails
 }
 
 func (ei *ExtentInfo) String() (m string) {
@@ -63,13 +64,19 @@ func (ei *ExtentInfo) String() (m string) {
 // This extent implementation manages all header info and data body in one single entry file.
 // Header of extent include inode value of this extent block and Crc blocks of data blocks.
 type Extent struct {
-	file       *os.File
-	filePath   string
-	extentID   uint64
-	modifyTime int64
-	dataSize   int64
-	hasClose   int32
-	header     []byte
+	file        *os.File
+	filePath    string
+	extentID    uint64
+	modifyTime  int64
+	dataSize    int64
+	hasClose    int32
+	header      []byte
+	compressed  *compressedExtent // the index of the file if the extent is compressed
+	packed      *packedExtent     // the location in the pack file if the extent is packed
+	partitionID uint64
+	keyVersion  uint32         // version of the key the file is encrypted with, 0 if it is plain
+	keyring     *extentKeyring // keys of the volume of the store
+	cipher      atomic.Value   // *extentCipher of the file, set on the first IO
 	sync.Mutex
 }
 
@@ -146,19 +153,94 @@ func (e *Extent) RestoreFromFS() (err error) {
 		err = fmt.Errorf("stat file %v: %v", e.file.Name(), err)
 		return
 	}
+	size := info.Size()
+	if e.keyVersion > 0 {
+		size = cipherDataSize(size, 0)
+	}
 	if IsTinyExtent(e.extentID) {
-		watermark := info.Size()
+		watermark := size
 		if watermark%PageSize != 0 {
 			watermark = watermark + (PageSize - watermark%PageSize)
 		}
 		e.dataSize = watermark
 		return
 	}
-	e.dataSize = info.Size()
+	e.dataSize = size
 	atomic.StoreInt64(&e.modifyTime, info.ModTime().Unix())
+	if e.packed != nil {
+		e.dataSize = e.packed.Size
+		atomic.StoreInt64(&e.modifyTime, e.packed.ModifyTime)
+		return
+	}
+	if strings.HasSuffix(e.filePath, CompressedExtentSuffix) {
+		if e.compressed, err = loadCompressedExtent(e.file); err != nil {
+			return
+		}
+		e.dataSize = e.compressed.rawSize
+	}
 	return
 }
 
+// IsCompressed tells if the extent is compressed.
+func (e *Extent) IsCompressed() bool {
+	return e.compressed != nil
+}
+
+// dataFile returns the file of the extent which is read and written through its cipher if it is encrypted.
+func (e *Extent) dataFile() (f cipherFile, err error) {
+	f.file = e.file
+	if e.keyVersion == 0 {
+		return
+	}
+	if c, _ := e.cipher.Load().(*extentCipher); c != nil {
+		f.cipher = c
+		return
+	}
+	var key []byte
+	if e.keyring != nil {
+		key = e.keyring.key(e.keyVersion)
+	}
+	if key == nil {
+		return f, NewExtentKeyNotFoundErr(e.partitionID, e.extentID, e.keyVersion)
+	}
+	var head int64
+	if e.compressed != nil {
+		head = e.compressed.headSize()
+	}
+	if f.cipher, err = newExtentCipher(key, e.partitionID, e.extentID, head); err != nil {
+		return
+	}
+	e.cipher.Store(f.cipher)
+	return
+}
+
+// switchFile switches the tiny extent to the file encrypted by the key of the version, it is called with the IO on
+// the extent blocked.
+func (e *Extent) switchFile(file *os.File, filePath string, keyVersion uint32) {
+	e.Lock()
+	defer e.Unlock()
+	old := e.file
+	e.file, e.filePath, e.keyVersion = file, filePath, keyVersion
+	e.cipher.Store((*extentCipher)(nil))
+	old.Close()
+}
+
+// readAt reads the data of the extent at the offset, which is decrypted if the extent is encrypted, decompressed if it
+// is compressed and read from the pack file if it is packed.
+func (e *Extent) readAt(p []byte, off int64) (n int, err error) {
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	if e.packed != nil {
+		return e.packed.readAt(f, p, off)
+	}
+	if e.compressed != nil {
+		return e.compressed.readAt(f, p, off)
+	}
+	return f.ReadAt(p, off)
+}
+
 // Size returns length of the extent (not including the header).
 func (e *Extent) Size() (size int64) {
 	return e.dataSize
@@ -190,7 +272,11 @@ func (e *Extent) WriteTiny(data []byte, offset, size int64, crc uint32, writeTyp
 		return ParameterMismatchError
 	}
 
-	if _, err = e.file.WriteAt(data[:size], int64(offset)); err != nil {
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	if _, err = f.WriteAt(data[:size], int64(offset)); err != nil {
 		return
 	}
 	if isSync {
@@ -220,7 +306,17 @@ func (e *Extent) Write(data []byte, offset, size int64, crc uint32, writeType in
 	if err = e.checkOffsetAndSize(offset, size); err != nil {
 		return
 	}
-	if _, err = e.file.WriteAt(data[:size], int64(offset)); err != nil {
+	if e.compressed != nil {
+		return ExtentCompressedError
+	}
+	if e.packed != nil {
+		return ExtentPackedError
+	}
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	if _, err = f.WriteAt(data[:size], int64(offset)); err != nil {
 		return
 	}
 	blockNo := offset / util.BlockSize
@@ -259,7 +355,7 @@ func (e *Extent) Read(data []byte, offset, size int64, isRepairRead bool) (crc u
 	if err = e.checkOffsetAndSize(offset, size); err != nil {
 		return
 	}
-	if _, err = e.file.ReadAt(data[:size], offset); err != nil {
+	if _, err = e.readAt(data[:size], offset); err != nil {
 		return
 	}
 	crc = crc32.ChecksumIEEE(data)
@@ -268,7 +364,11 @@ func (e *Extent) Read(data []byte, offset, size int64, isRepairRead bool) (crc u
 
 // ReadTiny read data from a tiny extent.
 func (e *Extent) ReadTiny(data []byte, offset, size int64, isRepairRead bool) (crc uint32, err error) {
-	_, err = e.file.ReadAt(data[:size], offset)
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	_, err = f.ReadAt(data[:size], offset)
 	if isRepairRead && err == io.EOF {
 		err = nil
 	}
@@ -312,7 +412,7 @@ func (e *Extent) autoComputeExtentCrc(crcFunc UpdateCrcFunc) (crc uint32, err er
 		}
 		bdata := make([]byte, util.BlockSize)
 		offset := int64(blockNo * util.BlockSize)
-		readN, err := e.file.ReadAt(bdata[:util.BlockSize], offset)
+		readN, err := e.readAt(bdata[:util.BlockSize], offset)
 		if readN == 0 && err != nil {
 			break
 		}
@@ -335,6 +435,132 @@ const (
 )
 
 // DeleteTiny deletes a tiny extent.
+// verifyBlockCrc reads each block which has a persisted crc and checks the data against it.
+func (e *Extent) verifyBlockCrc() (err error) {
+	var blockCnt int
+	blockCnt = int(e.Size() / util.BlockSize)
+	if e.Size()%util.BlockSize != 0 {
+		blockCnt += 1
+	}
+	bdata := make([]byte, util.BlockSize)
+	for blockNo := 0; blockNo < blockCnt; blockNo++ {
+		blockCrc := binary.BigEndian.Uint32(e.header[blockNo*util.PerBlockCrcSize : (blockNo+1)*util.PerBlockCrcSize])
+		if blockCrc == 0 {
+			continue
+		}
+		offset := int64(blockNo * util.BlockSize)
+		readN, err := e.readAt(bdata[:util.BlockSize], offset)
+		if readN == 0 && err != nil {
+			return err
+		}
+		if actual := crc32.ChecksumIEEE(bdata[:readN]); actual != blockCrc {
+			return NewBlockCrcMismatchErr(e.extentID, blockNo, blockCrc, actual)
+		}
+	}
+	return nil
+}
+
+// scrubBlocks reads the blocks which have a persisted crc like verifyBlockCrc, and returns all the blocks whose data
+// mismatch their crc. The wait function is called with the size of each block before it is read.
+func (e *Extent) scrubBlocks(wait func(size int)) (corrupt []int, err error) {
+	blockCnt := int(e.Size() / util.BlockSize)
+	if e.Size()%util.BlockSize != 0 {
+		blockCnt += 1
+	}
+	bdata := make([]byte, util.BlockSize)
+	for blockNo := 0; blockNo < blockCnt; blockNo++ {
+		blockCrc := e.blockCrc(blockNo)
+		if blockCrc == 0 {
+			continue
+		}
+		wait(util.BlockSize)
+		readN, err := e.readAt(bdata[:util.BlockSize], int64(blockNo*util.BlockSize))
+		if readN == 0 && err != nil {
+			return corrupt, err
+		}
+		if crc32.ChecksumIEEE(bdata[:readN]) != blockCrc {
+			corrupt = append(corrupt, blockNo)
+		}
+	}
+	return corrupt, nil
+}
+
+// verifyReadBlocks checks the blocks overlapped by the data read at the offset against their persisted crc, and
+// returns the corrupt ones. A block fully covered by the data is checked with it, the others are read from the file.
+// The crc of a block is updated after its data is written, so a mismatched block is read and checked once more.
+func (e *Extent) verifyReadBlocks(data []byte, offset, size int64) (corrupt []int, err error) {
+	var bdata []byte
+	dataSize := e.Size()
+	for blockNo := int(offset / util.BlockSize); int64(blockNo)*util.BlockSize < offset+size; blockNo++ {
+		blockCrc := e.blockCrc(blockNo)
+		if blockCrc == 0 {
+			continue
+		}
+		start := int64(blockNo) * util.BlockSize
+		end := start + util.BlockSize
+		if end > dataSize {
+			end = dataSize
+		}
+		if end <= start {
+			break
+		}
+		if start >= offset && end <= offset+size && crc32.ChecksumIEEE(data[start-offset:end-offset]) == blockCrc {
+			continue
+		}
+		if bdata == nil {
+			bdata = make([]byte, util.BlockSize)
+		}
+		readN, err := e.readAt(bdata[:end-start], start)
+		if readN == 0 && err != nil {
+			return corrupt, err
+		}
+		if crc32.ChecksumIEEE(bdata[:readN]) != e.blockCrc(blockNo) {
+			corrupt = append(corrupt, blockNo)
+		}
+	}
+	return corrupt, nil
+}
+
+func (e *Extent) blockCrc(blockNo int) uint32 {
+	return binary.BigEndian.Uint32(e.header[blockNo*util.PerBlockCrcSize : (blockNo+1)*util.PerBlockCrcSize])
+}
+
+// repairBlock overwrites the corrupt block with the data read from another replica, which must match the persisted
+// crc of the block. The block is read again before it is written, so that the data written since it is scrubbed is
+// not overwritten.
+func (e *Extent) repairBlock(blockNo int, data []byte) (err error) {
+	if e.compressed != nil {
+		return ExtentCompressedError
+	}
+	if e.packed != nil {
+		return ExtentPackedError
+	}
+	blockCrc := e.blockCrc(blockNo)
+	if blockCrc == 0 || len(data) == 0 || len(data) > util.BlockSize {
+		return NewParameterMismatchErr(fmt.Sprintf("extent(%v) block(%v) crc(%v) size(%v)", e.extentID, blockNo,
+			blockCrc, len(data)))
+	}
+	if actual := crc32.ChecksumIEEE(data); actual != blockCrc {
+		return NewBlockCrcMismatchErr(e.extentID, blockNo, blockCrc, actual)
+	}
+	offset := int64(blockNo * util.BlockSize)
+	local := make([]byte, len(data))
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	if readN, err := f.ReadAt(local, offset); readN == 0 && err != nil {
+		return err
+	}
+	if crc32.ChecksumIEEE(local) == blockCrc {
+		return nil
+	}
+	if _, err = f.WriteAt(data, offset); err != nil {
+		return
+	}
+	return e.file.Sync()
+}
+
 func (e *Extent) DeleteTiny(offset, size int64) (hasDelete bool, err error) {
 	if int(offset)%PageSize != 0 {
 		return false, ParameterMismatchError
@@ -381,20 +607,27 @@ func (e *Extent) TinyExtentRecover(data []byte, offset, size int64, crc uint32,
 	log.LogDebugf("before file (%v) getRealBlockNo (%v) isEmptyPacket(%v)"+
 		"offset(%v) size(%v) e.datasize(%v)", e.filePath, e.getRealBlockCnt(), isEmptyPacket, offset, size, e.dataSize)
 	if isEmptyPacket {
-		finfo, err := e.file.Stat()
+		var f cipherFile
+		if f, err = e.dataFile(); err != nil {
+			return err
+		}
+		fileSize, err := f.Size()
 		if err != nil {
 			return err
 		}
-		if offset < finfo.Size() {
+		if offset < fileSize {
 			return fmt.Errorf("error empty packet on (%v) offset(%v) size(%v)"+
-				" isEmptyPacket(%v) filesize(%v) e.dataSize(%v)", e.file.Name(), offset, size, isEmptyPacket, finfo.Size(), e.dataSize)
+				" isEmptyPacket(%v) filesize(%v) e.dataSize(%v)", e.file.Name(), offset, size, isEmptyPacket, fileSize, e.dataSize)
 		}
-		if err = syscall.Ftruncate(int(e.file.Fd()), offset+size); err != nil {
+		if err = f.Extend(offset + size); err != nil {
 			return err
 		}
 		err = fallocate(int(e.file.Fd()), FallocFLPunchHole|FallocFLKeepSize, offset, size)
 	} else {
-		_, err = e.file.WriteAt(data[:size], int64(offset))
+		var f cipherFile
+		if f, err = e.dataFile(); err == nil {
+			_, err = f.WriteAt(data[:size], int64(offset))
+		}
 	}
 	if err != nil {
 		return
@@ -0,0 +1,487 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/compress"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// A normal extent which is not modified for a while may be compressed into the file of its ID with the suffix ".z".
+// The blocks of the extent are compressed one by one, so a read only decompresses the blocks it overlaps. The file is
+// the header, the index of the blocks and the compressed blocks:
+//
+//	header: magic "CFSZ" (4) | algorithm code (1) | reserved (3) | size of the data (8) | crc (4)
+//	index:  offset of the block in the file (8) | length of the block (4) | flags (4), for each block
+//
+// The crc is of the header before it and the index, and the size of the data and the blocks are checked against the
+// size of the file before the file is read, so a corrupt head fails the load instead of the reads.
+// A block which can not be compressed smaller is stored raw. The compressed extents can not be written, they are
+// decompressed back before the writes, and the clients read the same data from them since they are decompressed by
+// the data nodes.
+
+const (
+	CompressedExtentSuffix  = ".z"
+	compressedExtentMagic   = "CFSZ"
+	compressedHeaderSize    = 20
+	compressedIndexItemSize = 16
+	compressedBlockRaw      = 1 // the block is stored raw
+	// an extent compressed larger than the ratio of its size is left raw
+	compressedMaxRatio = 0.9
+)
+
+type compressedBlock struct {
+	offset int64
+	length uint32
+	flags  uint32
+}
+
+// compressedExtent is the index of the file of a compressed extent, the last block read is cached for the small
+// sequential reads.
+type compressedExtent struct {
+	algorithm string
+	rawSize   int64
+	blocks    []compressedBlock
+	cacheLock sync.Mutex
+	cacheNo   int
+	cacheData []byte
+}
+
+func loadCompressedExtent(file *os.File) (ce *compressedExtent, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+	fileSize := info.Size()
+	header := make([]byte, compressedHeaderSize)
+	if _, err = file.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("read header of compressed extent %v: %v", file.Name(), err)
+	}
+	if string(header[:4]) != compressedExtentMagic {
+		return nil, fmt.Errorf("compressed extent %v has bad magic", file.Name())
+	}
+	ce = &compressedExtent{cacheNo: -1}
+	if ce.algorithm, err = compress.Algorithm(header[4]); err != nil {
+		return nil, err
+	}
+	ce.rawSize = int64(binary.BigEndian.Uint64(header[8:16]))
+	// each block takes an index item at least, so the size of the data is bounded by the size of the file
+	if ce.rawSize < 0 || (ce.rawSize+util.BlockSize-1)/util.BlockSize > (fileSize-compressedHeaderSize)/compressedIndexItemSize {
+		return nil, fmt.Errorf("compressed extent %v of size %v has bad data size %v", file.Name(), fileSize, ce.rawSize)
+	}
+	blockCnt := int((ce.rawSize + util.BlockSize - 1) / util.BlockSize)
+	index := make([]byte, blockCnt*compressedIndexItemSize)
+	if _, err = file.ReadAt(index, compressedHeaderSize); err != nil {
+		return nil, fmt.Errorf("read index of compressed extent %v: %v", file.Name(), err)
+	}
+	if crc := crc32.Update(crc32.ChecksumIEEE(header[:16]), crc32.IEEETable, index); crc != binary.BigEndian.Uint32(header[16:20]) {
+		return nil, fmt.Errorf("compressed extent %v has bad crc", file.Name())
+	}
+	headSize := int64(compressedHeaderSize + len(index))
+	ce.blocks = make([]compressedBlock, blockCnt)
+	for i := range ce.blocks {
+		item := index[i*compressedIndexItemSize:]
+		block := &ce.blocks[i]
+		block.offset = int64(binary.BigEndian.Uint64(item[0:8]))
+		block.length = binary.BigEndian.Uint32(item[8:12])
+		block.flags = binary.BigEndian.Uint32(item[12:16])
+		if block.offset < headSize || block.length > util.BlockSize || block.offset > fileSize-int64(block.length) ||
+			block.flags&compressedBlockRaw != 0 && int(block.length) != ce.blockSize(i) {
+			return nil, fmt.Errorf("compressed extent %v of size %v has bad block %v at %v of length %v", file.Name(),
+				fileSize, i, block.offset, block.length)
+		}
+	}
+	return
+}
+
+// headSize returns the size of the header and the index of the file.
+func (ce *compressedExtent) headSize() int64 {
+	return compressedHeadSize(ce.rawSize)
+}
+
+// compressedHeadSize returns the size of the header and the index of the compressed file of the data of the size.
+func compressedHeadSize(rawSize int64) int64 {
+	return compressedHeaderSize + (rawSize+util.BlockSize-1)/util.BlockSize*compressedIndexItemSize
+}
+
+// compressedHeadSizeOf returns the size of the header and the index of the compressed file.
+func compressedHeadSizeOf(filePath string) (head int64, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	ce, err := loadCompressedExtent(file)
+	if err != nil {
+		return
+	}
+	return ce.headSize(), nil
+}
+
+func (ce *compressedExtent) blockSize(blockNo int) int {
+	if size := ce.rawSize - int64(blockNo)*util.BlockSize; size < util.BlockSize {
+		return int(size)
+	}
+	return util.BlockSize
+}
+
+func (ce *compressedExtent) readBlock(file cipherFile, blockNo int) (data []byte, err error) {
+	ce.cacheLock.Lock()
+	if ce.cacheNo == blockNo {
+		data = ce.cacheData
+		ce.cacheLock.Unlock()
+		return
+	}
+	ce.cacheLock.Unlock()
+	block := ce.blocks[blockNo]
+	buf := make([]byte, block.length)
+	if _, err = file.ReadAt(buf, block.offset); err != nil {
+		return
+	}
+	if block.flags&compressedBlockRaw != 0 {
+		data = buf
+	} else if data, err = compress.Decompress(ce.algorithm, buf, ce.blockSize(blockNo)); err != nil {
+		return nil, fmt.Errorf("decompress block %v of %v: %v", blockNo, file.Name(), err)
+	}
+	ce.cacheLock.Lock()
+	ce.cacheNo, ce.cacheData = blockNo, data
+	ce.cacheLock.Unlock()
+	return
+}
+
+// readAt reads the decompressed data at the offset like ReadAt of the file.
+func (ce *compressedExtent) readAt(file cipherFile, p []byte, off int64) (n int, err error) {
+	for n < len(p) {
+		pos := off + int64(n)
+		if pos >= ce.rawSize {
+			return n, io.EOF
+		}
+		blockNo := int(pos / util.BlockSize)
+		var data []byte
+		if data, err = ce.readBlock(file, blockNo); err != nil {
+			return
+		}
+		n += copy(p[n:], data[pos-int64(blockNo)*util.BlockSize:])
+	}
+	return
+}
+
+// writeCompressedExtent compresses the data of the size read from the source into the file, of which the blocks are
+// encrypted by the cipher, and returns the size of the file. The wait function is called with the size of each block
+// before it is read.
+func writeCompressedExtent(src io.ReaderAt, rawSize int64, dstPath, algorithm string, dstCipher *extentCipher, wait func(size int)) (fileSize int64, err error) {
+	code, err := compress.Code(algorithm)
+	if err != nil {
+		return
+	}
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+	blocks := cipherFile{file: dst, cipher: dstCipher}
+	blockCnt := int((rawSize + util.BlockSize - 1) / util.BlockSize)
+	head := make([]byte, compressedHeaderSize+blockCnt*compressedIndexItemSize)
+	copy(head, compressedExtentMagic)
+	head[4] = code
+	binary.BigEndian.PutUint64(head[8:16], uint64(rawSize))
+	fileSize = int64(len(head))
+	buf := make([]byte, util.BlockSize)
+	for blockNo := 0; blockNo < blockCnt; blockNo++ {
+		size := util.BlockSize
+		if rest := rawSize - int64(blockNo)*util.BlockSize; rest < util.BlockSize {
+			size = int(rest)
+		}
+		if wait != nil {
+			wait(size)
+		}
+		if _, err = src.ReadAt(buf[:size], int64(blockNo)*util.BlockSize); err != nil {
+			return
+		}
+		var block []byte
+		if block, err = compress.Compress(algorithm, buf[:size]); err != nil {
+			return
+		}
+		var flags uint32
+		if len(block) >= size {
+			block, flags = buf[:size], compressedBlockRaw
+		}
+		if _, err = blocks.WriteAt(block, fileSize); err != nil {
+			return
+		}
+		item := head[compressedHeaderSize+blockNo*compressedIndexItemSize:]
+		binary.BigEndian.PutUint64(item[0:8], uint64(fileSize))
+		binary.BigEndian.PutUint32(item[8:12], uint32(len(block)))
+		binary.BigEndian.PutUint32(item[12:16], flags)
+		fileSize += int64(len(block))
+	}
+	crc := crc32.Update(crc32.ChecksumIEEE(head[:16]), crc32.IEEETable, head[compressedHeaderSize:])
+	binary.BigEndian.PutUint32(head[16:20], crc)
+	if _, err = dst.WriteAt(head, 0); err != nil {
+		return
+	}
+	err = dst.Sync()
+	return
+}
+
+// writeDecompressedExtent writes the data of the compressed extent into the file encrypted by the cipher. A block
+// which can not be decompressed is left as a hole, which is found corrupt against its crc and repaired from the other
+// replicas.
+func writeDecompressedExtent(src cipherFile, ce *compressedExtent, dstPath string, dstCipher *extentCipher) (err error) {
+	file, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	dst := cipherFile{file: file, cipher: dstCipher}
+	for blockNo := range ce.blocks {
+		data, readErr := ce.readBlock(src, blockNo)
+		if readErr != nil {
+			log.LogErrorf("writeDecompressedExtent: %v block(%v) err(%v)", src.Name(), blockNo, readErr)
+			continue
+		}
+		if _, err = dst.WriteAt(data, int64(blockNo)*util.BlockSize); err != nil {
+			return
+		}
+	}
+	if err = dst.Extend(ce.rawSize); err != nil {
+		return
+	}
+	return file.Sync()
+}
+
+// IsCompressedExtent tells if the normal extent is compressed.
+func (s *ExtentStore) IsCompressedExtent(extentID uint64) (compressed bool) {
+	_, compressed = s.compressedExtents.Load(extentID)
+	return
+}
+
+// CompressionStats returns the number of the compressed extents, the size of their data and the size of their files.
+func (s *ExtentStore) CompressionStats() (count int, rawBytes, compressedBytes uint64) {
+	s.eiMutex.RLock()
+	defer s.eiMutex.RUnlock()
+	for extentID, ei := range s.extentInfoMap {
+		value, ok := s.compressedExtents.Load(extentID)
+		if !ok || ei.IsDeleted {
+			continue
+		}
+		count++
+		rawBytes += ei.Size
+		compressedBytes += uint64(value.(int64))
+	}
+	return
+}
+
+// CompressCandidates returns the normal extents not modified for the cold age which are not compressed yet, the ones
+// which can not be compressed smaller and the packed ones are left out.
+func (s *ExtentStore) CompressCandidates(coldAge int64) (extentIDs []uint64) {
+	now := time.Now().Unix()
+	s.eiMutex.RLock()
+	for extentID, ei := range s.extentInfoMap {
+		if IsTinyExtent(extentID) || ei.IsDeleted || ei.Size == 0 || now-ei.ModifyTime < coldAge ||
+			now-ei.ModifyTime <= UpdateCrcInterval || s.IsCompressedExtent(extentID) || s.IsPackedExtent(extentID) {
+			continue
+		}
+		if _, ok := s.incompressibleExtents.Load(extentID); ok {
+			continue
+		}
+		extentIDs = append(extentIDs, extentID)
+	}
+	s.eiMutex.RUnlock()
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// CompressExtent compresses the normal extent with the algorithm. The extent is compressed without blocking the IO
+// on it, the wait function is called with the size of each block before it is read to limit the rate. The extent is
+// switched to the compressed file only if it is not modified during the compression, and is left raw if it can not
+// be compressed smaller.
+func (s *ExtentStore) CompressExtent(extentID uint64, algorithm string, wait func(size int)) (err error) {
+	if IsTinyExtent(extentID) || algorithm == compress.AlgorithmNone || !compress.Valid(algorithm) {
+		return NewParameterMismatchErr(fmt.Sprintf("extent(%v) can not be compressed with %v", extentID, algorithm))
+	}
+	if s.IsCompressedExtent(extentID) || s.IsPackedExtent(extentID) {
+		return
+	}
+	srcPath := s.extentPath(extentID)
+	dstPath := srcPath + CompressedExtentSuffix
+	tempPath := dstPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	before, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	keyVersion := s.ExtentKeyVersion(extentID)
+	srcCipher, err := s.extentCipher(extentID, keyVersion, 0)
+	if err != nil {
+		return
+	}
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	src := cipherFile{file: file, cipher: srcCipher}
+	rawSize, err := src.Size()
+	if err != nil {
+		file.Close()
+		return
+	}
+	dstCipher, err := s.extentCipher(extentID, keyVersion, compressedHeadSize(rawSize))
+	if err != nil {
+		file.Close()
+		return
+	}
+	fileSize, err := writeCompressedExtent(src, rawSize, tempPath, algorithm, dstCipher, wait)
+	file.Close()
+	if err != nil {
+		return
+	}
+	if float64(fileSize) > float64(rawSize)*compressedMaxRatio {
+		s.incompressibleExtents.Store(extentID, true)
+		os.Remove(tempPath)
+		log.LogDebugf("CompressExtent: extent(%v) of partition(%v) is incompressible, %v -> %v", extentID,
+			s.partitionID, rawSize, fileSize)
+		return
+	}
+	if err = os.Chtimes(tempPath, time.Now(), before.ModTime()); err != nil {
+		return
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	if !s.HasExtent(extentID) {
+		return ExtentNotFoundError
+	}
+	if s.extentPath(extentID) != srcPath {
+		return fmt.Errorf("extent(%v) of partition(%v) is switched during the compression", extentID, s.partitionID)
+	}
+	after, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		return fmt.Errorf("extent(%v) of partition(%v) is modified during the compression", extentID, s.partitionID)
+	}
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		return
+	}
+	s.cache.Del(extentID)
+	s.compressedExtents.Store(extentID, fileSize)
+	if removeErr := os.Remove(srcPath); removeErr != nil {
+		log.LogWarnf("CompressExtent: remove %v err(%v)", srcPath, removeErr)
+	}
+	log.LogDebugf("CompressExtent: extent(%v) of partition(%v) %v -> %v by %v", extentID, s.partitionID,
+		rawSize, fileSize, algorithm)
+	return
+}
+
+// DecompressExtent decompresses the compressed extent back before it is written.
+func (s *ExtentStore) DecompressExtent(extentID uint64) (err error) {
+	if !s.IsCompressedExtent(extentID) {
+		return
+	}
+	srcPath := s.extentPath(extentID)
+	dstPath := strings.TrimSuffix(srcPath, CompressedExtentSuffix)
+	tempPath := dstPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	before, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	ce, err := loadCompressedExtent(src)
+	if err != nil {
+		return
+	}
+	keyVersion := s.ExtentKeyVersion(extentID)
+	srcCipher, err := s.extentCipher(extentID, keyVersion, ce.headSize())
+	if err != nil {
+		return
+	}
+	dstCipher, err := s.extentCipher(extentID, keyVersion, 0)
+	if err != nil {
+		return
+	}
+	if err = writeDecompressedExtent(cipherFile{file: src, cipher: srcCipher}, ce, tempPath, dstCipher); err != nil {
+		return
+	}
+	if err = os.Chtimes(tempPath, time.Now(), before.ModTime()); err != nil {
+		return
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	if !s.IsCompressedExtent(extentID) {
+		return
+	}
+	if !s.HasExtent(extentID) {
+		return ExtentNotFoundError
+	}
+	if s.extentPath(extentID) != srcPath {
+		return fmt.Errorf("extent(%v) of partition(%v) is switched during the decompression", extentID, s.partitionID)
+	}
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		return
+	}
+	s.cache.Del(extentID)
+	s.compressedExtents.Delete(extentID)
+	if removeErr := os.Remove(srcPath); removeErr != nil {
+		log.LogWarnf("DecompressExtent: remove %v err(%v)", srcPath, removeErr)
+	}
+	log.LogDebugf("DecompressExtent: extent(%v) of partition(%v)", extentID, s.partitionID)
+	return
+}
+
+// lockRawExtent holds the read lock of the tiers with the extent decompressed and unpacked, so that it can be
+// written.
+func (s *ExtentStore) lockRawExtent(extentID uint64) (err error) {
+	s.tierLock.RLock()
+	for s.IsCompressedExtent(extentID) || s.IsPackedExtent(extentID) {
+		s.tierLock.RUnlock()
+		if s.IsPackedExtent(extentID) {
+			err = s.UnpackExtent(extentID)
+		} else {
+			err = s.DecompressExtent(extentID)
+		}
+		if err != nil {
+			return
+		}
+		s.tierLock.RLock()
+	}
+	return
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"hash/crc32"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/compress"
+)
+
+func newTestExtentStore(t *testing.T, dir string) *ExtentStore {
+	s, err := NewExtentStore(dir, 1, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// newTestExtentData returns the compressible data of the size, which is random in the first 1KB of each block.
+func newTestExtentData(size int, seed int64) []byte {
+	random := rand.New(rand.NewSource(seed))
+	data := bytes.Repeat([]byte("the cold data of the extent "), size/28+1)[:size]
+	for off := 0; off < size; off += util.BlockSize {
+		end := off + 1024
+		if end > size {
+			end = size
+		}
+		random.Read(data[off:end])
+	}
+	return data
+}
+
+func writeTestExtent(t *testing.T, s *ExtentStore, extentID uint64, data []byte) {
+	if err := s.Create(extentID); err != nil {
+		t.Fatal(err)
+	}
+	for off := 0; off < len(data); off += util.BlockSize {
+		end := off + util.BlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := s.Write(extentID, int64(off), int64(end-off), data[off:end], crc32.ChecksumIEEE(data[off:end]),
+			AppendWriteType, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checkTestExtent(t *testing.T, s *ExtentStore, extentID uint64, data []byte) {
+	buf := make([]byte, util.BlockSize)
+	// the reads are unaligned to the blocks
+	for off, size := 0, 5000; off < len(data); off += size {
+		if off+size > len(data) {
+			size = len(data) - off
+		}
+		if _, err := s.Read(extentID, int64(off), int64(size), buf[:size], false); err != nil {
+			t.Fatalf("read extent %v at %v: %v", extentID, off, err)
+		}
+		if !bytes.Equal(buf[:size], data[off:off+size]) {
+			t.Fatalf("extent %v mismatches at %v", extentID, off)
+		}
+	}
+}
+
+func TestCompressExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_compress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestExtentStore(t, dir)
+	data := newTestExtentData(3*util.BlockSize+1000, 1)
+	writeTestExtent(t, s, 1025, data)
+	for _, algorithm := range []string{compress.AlgorithmLZ4, compress.AlgorithmDeflate} {
+		if err = s.CompressExtent(1025, algorithm, nil); err != nil {
+			t.Fatal(err)
+		}
+		if !s.IsCompressedExtent(1025) {
+			t.Fatalf("%v: extent is not compressed", algorithm)
+		}
+		if count, rawBytes, compressedBytes := s.CompressionStats(); count != 1 || rawBytes != uint64(len(data)) ||
+			compressedBytes >= rawBytes/2 {
+			t.Errorf("%v: unexpected stats %v %v %v", algorithm, count, rawBytes, compressedBytes)
+		}
+		checkTestExtent(t, s, 1025, data)
+
+		// the compressed extent is loaded after the restart
+		s.Close()
+		s = newTestExtentStore(t, dir)
+		if !s.IsCompressedExtent(1025) {
+			t.Fatalf("%v: extent is not compressed after the restart", algorithm)
+		}
+		checkTestExtent(t, s, 1025, data)
+
+		// and decompressed back to be written
+		copy(data[100:], "overwritten")
+		if err = s.Write(1025, 100, 11, data[100:111], crc32.ChecksumIEEE(data[100:111]), RandomWriteType,
+			true); err != nil {
+			t.Fatal(err)
+		}
+		if s.IsCompressedExtent(1025) {
+			t.Fatalf("%v: extent is compressed after the write", algorithm)
+		}
+		checkTestExtent(t, s, 1025, data)
+	}
+
+	// the random data is left raw
+	random := make([]byte, 2*util.BlockSize)
+	rand.New(rand.NewSource(2)).Read(random)
+	writeTestExtent(t, s, 1026, random)
+	if err = s.CompressExtent(1026, compress.AlgorithmLZ4, nil); err != nil || s.IsCompressedExtent(1026) {
+		t.Fatalf("expect the random data left raw, err %v", err)
+	}
+	s.Close()
+}
+
+func TestLoadCorruptCompressedExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_compress_corrupt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestExtentStore(t, dir)
+	data := newTestExtentData(2*util.BlockSize, 1)
+	writeTestExtent(t, s, 1025, data)
+	if err = s.CompressExtent(1025, compress.AlgorithmLZ4, nil); err != nil {
+		t.Fatal(err)
+	}
+	filePath := s.extentPath(1025)
+	s.Close()
+	head, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head = head[:compressedHeadSize(int64(len(data)))]
+
+	for name, corrupt := range map[string]func(head []byte){
+		// the size of the data takes more index than the file holds
+		"raw size": func(head []byte) { head[8] = 0x7f },
+		"magic":    func(head []byte) { head[0] = 'X' },
+		// the offset of the first block points beyond the file
+		"block offset": func(head []byte) { head[compressedHeaderSize] = 0x10 },
+		"block flags":  func(head []byte) { head[compressedHeaderSize+15] ^= compressedBlockRaw },
+	} {
+		corrupted := append([]byte{}, head...)
+		corrupt(corrupted)
+		file, err := os.OpenFile(filePath, os.O_RDWR, 0666)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = file.WriteAt(corrupted, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = loadCompressedExtent(file); err == nil {
+			t.Errorf("%v: corrupt head is loaded", name)
+		}
+		// the corrupt extent is left out by the store to be repaired from the other replicas
+		s = newTestExtentStore(t, dir)
+		if s.HasExtent(1025) {
+			t.Errorf("%v: corrupt extent is loaded by the store", name)
+		}
+		s.Close()
+		if _, err = file.WriteAt(head, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = loadCompressedExtent(file); err != nil {
+			t.Fatalf("%v: restored head is not loaded: %v", name, err)
+		}
+		file.Close()
+	}
+}
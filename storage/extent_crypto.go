@@ -0,0 +1,646 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/xts"
+)
+
+// The extents of the encrypted volumes are encrypted by AES-256 in the XTS mode, of which the keys are derived by HKDF
+// from the data keys of the volumes for each extent. The data units are the sectors of 4KB of the file, numbered from
+// 0, and the last sector of the file is padded with zeros to the AES block of 16 bytes. The size of the padding is
+// kept by as many zeros appended to the file after it, so the size of the data is the size of the file less twice the
+// size of the padding, which is found without the key. The writes not aligned to the sectors decrypt and encrypt
+// again the sectors they share with the data around them, so the writes of an extent are serialized with the reads
+// and the other writes of it. The header and the index of a compressed file are left plain, and its sectors are
+// counted from the end of the index.
+//
+// The keys have versions to be rotated. The version of the key an extent is encrypted with is in the name of its
+// file, "<id>.e<version>", the files without it are plain. The new extents are encrypted by the current key, and
+// EncryptExtent encrypts the others with it. The sizes and the crc of the extents are the ones of the plain data, so
+// the replicas are compared and repaired whether they are encrypted or not.
+//
+// The mode does not authenticate the data, which is checked against the crc of the blocks instead. The holes of the
+// tiny extents are not encrypted, they are skipped by the repair.
+
+// EncryptedExtentInfix is put between the ID of an encrypted extent and the version of its key in the name of its file.
+const EncryptedExtentInfix = ".e"
+
+const (
+	cipherSectorSize = 4 * util.KB
+	cipherBlockSize  = aes.BlockSize
+	cipherKeyInfo    = "chubaofs extent xts"
+)
+
+// extentKeyring holds the data keys of the volume of a store by their versions.
+type extentKeyring struct {
+	sync.RWMutex
+	current uint32
+	keys    map[uint32][]byte
+}
+
+func (kr *extentKeyring) currentVersion() uint32 {
+	kr.RLock()
+	defer kr.RUnlock()
+	return kr.current
+}
+
+func (kr *extentKeyring) key(version uint32) []byte {
+	kr.RLock()
+	defer kr.RUnlock()
+	return kr.keys[version]
+}
+
+// extentCipher encrypts and decrypts the data at any offset of the file of an extent.
+type extentCipher struct {
+	sync.RWMutex // serializes the writes of the file with its reads
+	xts          *xts.Cipher
+	head         int64 // size of the plain head of the file
+}
+
+// newExtentCipher returns the cipher of the file of the extent, of which the head of the size is left plain. Only the
+// compressed files have the head, and their keys differ from the ones of the raw files.
+func newExtentCipher(key []byte, partitionID, extentID uint64, head int64) (c *extentCipher, err error) {
+	info := make([]byte, len(cipherKeyInfo)+17)
+	copy(info, cipherKeyInfo)
+	binary.BigEndian.PutUint64(info[len(cipherKeyInfo):], partitionID)
+	binary.BigEndian.PutUint64(info[len(cipherKeyInfo)+8:], extentID)
+	if head > 0 {
+		info[len(info)-1] = 1
+	}
+	// AES-256 in the XTS mode takes a key for the data and another one for the tweaks
+	xtsKey := make([]byte, 64)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, key, nil, info), xtsKey); err != nil {
+		return
+	}
+	c = &extentCipher{head: head}
+	if c.xts, err = xts.NewCipher(aes.NewCipher, xtsKey); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// cipherFileSize returns the size of the encrypted file of the data of the size, of which the head of the size is
+// plain.
+func cipherFileSize(dataSize, head int64) int64 {
+	if dataSize <= head {
+		return dataSize
+	}
+	padding := (cipherBlockSize - (dataSize-head)%cipherBlockSize) % cipherBlockSize
+	return dataSize + 2*padding
+}
+
+// cipherDataSize returns the size of the data of the encrypted file of the size, of which the head of the size is
+// plain. It is the reverse of cipherFileSize.
+func cipherDataSize(fileSize, head int64) int64 {
+	if fileSize <= head {
+		return fileSize
+	}
+	padding := (fileSize - head) % cipherBlockSize
+	if fileSize-head < cipherBlockSize {
+		// too short to be a whole block, which is left by a crash
+		return head
+	}
+	return fileSize - 2*padding
+}
+
+// paddedEnd returns the end of the data of the size in the file, which is padded to the AES block.
+func (c *extentCipher) paddedEnd(dataSize int64) int64 {
+	if dataSize <= c.head {
+		return dataSize
+	}
+	return c.head + (dataSize-c.head+cipherBlockSize-1)/cipherBlockSize*cipherBlockSize
+}
+
+// sector returns the offset and the number of the sector the offset of the file is in, the offset is not in the head.
+func (c *extentCipher) sector(offset int64) (start int64, sectorNum uint64) {
+	sectorNum = uint64((offset - c.head) / cipherSectorSize)
+	return c.head + int64(sectorNum)*cipherSectorSize, sectorNum
+}
+
+// readSector reads the plain data of the sector at the offset into the buffer of a sector, the data beyond the end of
+// the data of the size is zero.
+func (c *extentCipher) readSector(file *os.File, buf []byte, offset, dataSize int64) (err error) {
+	for i := range buf {
+		buf[i] = 0
+	}
+	if dataSize <= offset {
+		return
+	}
+	_, sectorNum := c.sector(offset)
+	length := c.paddedEnd(dataSize) - offset
+	if length > cipherSectorSize {
+		length = cipherSectorSize
+	}
+	if _, err = readFileAt(file, buf[:length], offset); err != nil {
+		return
+	}
+	c.xts.Decrypt(buf[:length], buf[:length], sectorNum)
+	for i := dataSize - offset; i < length; i++ {
+		buf[i] = 0
+	}
+	return
+}
+
+// fillTail encrypts again the last sector of the file with the data of the size, which is not a whole sector, with
+// the zeros up to the new size, before the data is written past it.
+func (c *extentCipher) fillTail(file *os.File, dataSize, newSize int64) (err error) {
+	if dataSize <= c.head || (dataSize-c.head)%cipherSectorSize == 0 || newSize <= dataSize {
+		return
+	}
+	offset, sectorNum := c.sector(dataSize)
+	buf := make([]byte, cipherSectorSize)
+	if err = c.readSector(file, buf, offset, dataSize); err != nil {
+		return
+	}
+	length := c.paddedEnd(newSize) - offset
+	if length > cipherSectorSize {
+		length = cipherSectorSize
+	}
+	c.xts.Encrypt(buf[:length], buf[:length], sectorNum)
+	_, err = writeFileAt(file, buf[:length], offset)
+	return
+}
+
+// cipherFile reads and writes the file through the cipher, the data is left as it is if the cipher is nil.
+type cipherFile struct {
+	file   *os.File
+	cipher *extentCipher
+}
+
+func (f cipherFile) Name() string {
+	return f.file.Name()
+}
+
+// Size returns the size of the data of the file.
+func (f cipherFile) Size() (size int64, err error) {
+	info, err := f.file.Stat()
+	if err != nil {
+		return
+	}
+	if f.cipher == nil {
+		return info.Size(), nil
+	}
+	return cipherDataSize(info.Size(), f.cipher.head), nil
+}
+
+func (f cipherFile) ReadAt(p []byte, off int64) (n int, err error) {
+	c := f.cipher
+	if c == nil {
+		return readFileAt(f.file, p, off)
+	}
+	if len(p) == 0 {
+		return
+	}
+	c.RLock()
+	defer c.RUnlock()
+	if off < c.head {
+		plain := p
+		if int64(len(plain)) > c.head-off {
+			plain = plain[:c.head-off]
+		}
+		if n, err = readFileAt(f.file, plain, off); err != nil || n == len(p) {
+			return
+		}
+	}
+	size, err := f.Size()
+	if err != nil {
+		return
+	}
+	end := off + int64(len(p))
+	if end > size {
+		end = size
+	}
+	var buf []byte
+	for pos := off + int64(n); pos < end; {
+		start, sectorNum := c.sector(pos)
+		// the whole sectors are decrypted in place
+		if whole := (end - pos) / cipherSectorSize * cipherSectorSize; pos == start && whole > 0 {
+			data := p[pos-off : pos-off+whole]
+			if _, err = readFileAt(f.file, data, pos); err != nil {
+				return
+			}
+			for i := int64(0); i < whole; i += cipherSectorSize {
+				c.xts.Decrypt(data[i:i+cipherSectorSize], data[i:i+cipherSectorSize], sectorNum)
+				sectorNum++
+			}
+			pos += whole
+			continue
+		}
+		if buf == nil {
+			buf = make([]byte, cipherSectorSize)
+		}
+		if err = c.readSector(f.file, buf, start, size); err != nil {
+			return
+		}
+		pos += int64(copy(p[pos-off:end-off], buf[pos-start:]))
+	}
+	if end > off {
+		n = int(end - off)
+	}
+	if n < len(p) {
+		err = io.EOF
+	}
+	return
+}
+
+// WriteAt encrypts the data into a new buffer, the data of the packets is sent to the followers after it is written.
+func (f cipherFile) WriteAt(p []byte, off int64) (n int, err error) {
+	c := f.cipher
+	if c == nil {
+		return writeFileAt(f.file, p, off)
+	}
+	if len(p) == 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if off < c.head {
+		plain := p
+		if int64(len(plain)) > c.head-off {
+			plain = plain[:c.head-off]
+		}
+		if n, err = writeFileAt(f.file, plain, off); err != nil || n == len(p) {
+			return
+		}
+	}
+	size, err := f.Size()
+	if err != nil {
+		return
+	}
+	start, end := off+int64(n), off+int64(len(p))
+	first, sectorNum := c.sector(start)
+	last, _ := c.sector(end - 1)
+	newSize := size
+	if end > newSize {
+		newSize = end
+	}
+	// the file is sized before the data is written, the zeros appended keep the size of the padding
+	if newSize > size {
+		if err = f.file.Truncate(cipherFileSize(newSize, c.head)); err != nil {
+			return
+		}
+		if first > size {
+			if err = c.fillTail(f.file, size, newSize); err != nil {
+				return
+			}
+		}
+	}
+	bufEnd := c.paddedEnd(newSize)
+	if bufEnd > last+cipherSectorSize {
+		bufEnd = last + cipherSectorSize
+	}
+	buf := make([]byte, bufEnd-first)
+	var sector []byte
+	if start > first || (last == first && end < bufEnd) {
+		sector = make([]byte, cipherSectorSize)
+		if err = c.readSector(f.file, sector, first, size); err != nil {
+			return
+		}
+		copy(buf, sector)
+	}
+	if last > first && end < bufEnd {
+		if sector == nil {
+			sector = make([]byte, cipherSectorSize)
+		}
+		if err = c.readSector(f.file, sector, last, size); err != nil {
+			return
+		}
+		copy(buf[last-first:], sector)
+	}
+	copy(buf[start-first:], p[n:])
+	for i := 0; i < len(buf); i += cipherSectorSize {
+		j := i + cipherSectorSize
+		if j > len(buf) {
+			j = len(buf)
+		}
+		c.xts.Encrypt(buf[i:j], buf[i:j], sectorNum)
+		sectorNum++
+	}
+	m, err := writeFileAt(f.file, buf, first)
+	if written := int64(m) - (start - first); written > 0 {
+		if written > end-start {
+			written = end - start
+		}
+		n += int(written)
+	}
+	return
+}
+
+// Extend extends the data of the file to the size like Truncate of the file, the last sector of the file is filled
+// with zeros before it is followed by the hole.
+func (f cipherFile) Extend(size int64) (err error) {
+	c := f.cipher
+	if c == nil {
+		return f.file.Truncate(size)
+	}
+	c.Lock()
+	defer c.Unlock()
+	dataSize, err := f.Size()
+	if err != nil {
+		return
+	}
+	if size < dataSize {
+		return fmt.Errorf("shrink encrypted file %v from %v to %v", f.file.Name(), dataSize, size)
+	}
+	if size == dataSize {
+		return
+	}
+	if err = f.file.Truncate(cipherFileSize(size, c.head)); err != nil {
+		return
+	}
+	return c.fillTail(f.file, dataSize, size)
+}
+
+// extentFileNameOf returns the name of the file of the extent encrypted by the key of the version, 0 if it is plain.
+func extentFileNameOf(extentID uint64, keyVersion uint32, compressed bool) (name string) {
+	name = strconv.FormatUint(extentID, 10)
+	if keyVersion > 0 {
+		name += EncryptedExtentInfix + strconv.FormatUint(uint64(keyVersion), 10)
+	}
+	if compressed {
+		name += CompressedExtentSuffix
+	}
+	return
+}
+
+// SetEncryptionKeys sets the data keys of the volume of the store by their versions, the new extents are encrypted by
+// the key of the current version. The keys are never removed, the extents are encrypted by the old ones until they
+// are encrypted again.
+func (s *ExtentStore) SetEncryptionKeys(current uint32, keys map[uint32][]byte) {
+	s.keyring.Lock()
+	defer s.keyring.Unlock()
+	if s.keyring.keys == nil {
+		s.keyring.keys = make(map[uint32][]byte)
+	}
+	for version, key := range keys {
+		s.keyring.keys[version] = key
+	}
+	if _, ok := s.keyring.keys[current]; ok && current > s.keyring.current {
+		s.keyring.current = current
+	}
+}
+
+// CurrentKeyVersion returns the version of the key the new extents are encrypted with, 0 if they are not encrypted.
+func (s *ExtentStore) CurrentKeyVersion() uint32 {
+	return s.keyring.currentVersion()
+}
+
+// ExtentKeyVersion returns the version of the key the extent is encrypted with, 0 if it is not encrypted.
+func (s *ExtentStore) ExtentKeyVersion(extentID uint64) uint32 {
+	if value, ok := s.encryptedExtents.Load(extentID); ok {
+		return value.(uint32)
+	}
+	return 0
+}
+
+func (s *ExtentStore) setKeyVersionOnLoad(extentID uint64, keyVersion uint32) {
+	if keyVersion > 0 {
+		s.encryptedExtents.Store(extentID, keyVersion)
+	}
+}
+
+// extentCipher returns the cipher of the file of the extent encrypted by the key of the version, nil if it is plain.
+// The head is the size of the header and the index if the file is compressed, 0 if it is not.
+func (s *ExtentStore) extentCipher(extentID uint64, keyVersion uint32, head int64) (c *extentCipher, err error) {
+	if keyVersion == 0 {
+		return
+	}
+	key := s.keyring.key(keyVersion)
+	if key == nil {
+		return nil, NewExtentKeyNotFoundErr(s.partitionID, extentID, keyVersion)
+	}
+	return newExtentCipher(key, s.partitionID, extentID, head)
+}
+
+// EncryptionStats returns the version of the current key, the number of the extents encrypted by it and the number
+// of the others.
+func (s *ExtentStore) EncryptionStats() (current uint32, encrypted, stale int) {
+	current = s.keyring.currentVersion()
+	s.eiMutex.RLock()
+	defer s.eiMutex.RUnlock()
+	for extentID, ei := range s.extentInfoMap {
+		if ei.IsDeleted {
+			continue
+		}
+		if version := s.ExtentKeyVersion(extentID); version > 0 && version == current {
+			encrypted++
+		} else if current > 0 {
+			stale++
+		}
+	}
+	return
+}
+
+// EncryptCandidates returns the extents not encrypted by the current key, the normal extents modified in the cold age
+// are left out since they are likely to be modified again.
+func (s *ExtentStore) EncryptCandidates(coldAge int64) (extentIDs []uint64) {
+	current := s.keyring.currentVersion()
+	if current == 0 {
+		return
+	}
+	now := time.Now().Unix()
+	s.eiMutex.RLock()
+	for extentID, ei := range s.extentInfoMap {
+		if ei.IsDeleted || s.ExtentKeyVersion(extentID) == current {
+			continue
+		}
+		if !IsTinyExtent(extentID) && (ei.Size == 0 || now-ei.ModifyTime < coldAge) {
+			continue
+		}
+		extentIDs = append(extentIDs, extentID)
+	}
+	s.eiMutex.RUnlock()
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// EncryptExtent encrypts the extent by the current key. The extent is encrypted without blocking the IO on it, the
+// wait function is called with the size of each piece before it is read to limit the rate. The extent is switched to
+// the encrypted file only if it is not modified during the encryption, the tiny extents which are written all the
+// time are retried by the next round.
+func (s *ExtentStore) EncryptExtent(extentID uint64, wait func(size int)) (err error) {
+	current := s.keyring.currentVersion()
+	if current == 0 {
+		return NewParameterMismatchErr(fmt.Sprintf("partition(%v) has no encryption key", s.partitionID))
+	}
+	version := s.ExtentKeyVersion(extentID)
+	if version == current {
+		return
+	}
+	// the packed extents are plain, they are encrypted in the files of their own
+	if err = s.UnpackExtent(extentID); err != nil {
+		return
+	}
+	compressed := s.IsCompressedExtent(extentID)
+	srcPath := s.extentPath(extentID)
+	dstPath := path.Join(path.Dir(srcPath), extentFileNameOf(extentID, current, compressed))
+	tempPath := dstPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	var head int64
+	if compressed {
+		if head, err = compressedHeadSizeOf(srcPath); err != nil {
+			return
+		}
+	}
+	srcCipher, err := s.extentCipher(extentID, version, head)
+	if err != nil {
+		return
+	}
+	dstCipher, err := s.extentCipher(extentID, current, head)
+	if err != nil {
+		return
+	}
+	before, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if err = encryptExtentFile(srcPath, tempPath, srcCipher, dstCipher, wait); err != nil {
+		return
+	}
+	if err = os.Chtimes(tempPath, time.Now(), before.ModTime()); err != nil {
+		return
+	}
+	var file *os.File
+	if IsTinyExtent(extentID) {
+		if file, err = os.OpenFile(tempPath, os.O_RDWR, 0666); err != nil {
+			return
+		}
+		defer func() {
+			if err != nil {
+				file.Close()
+			}
+		}()
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	if !s.HasExtent(extentID) {
+		return ExtentNotFoundError
+	}
+	if s.extentPath(extentID) != srcPath {
+		return fmt.Errorf("extent(%v) of partition(%v) is switched during the encryption", extentID, s.partitionID)
+	}
+	after, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		return fmt.Errorf("extent(%v) of partition(%v) is modified during the encryption", extentID, s.partitionID)
+	}
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		return
+	}
+	s.encryptedExtents.Store(extentID, current)
+	// the tiny extents are kept in the cache, they are switched to the new file in place
+	if e, ok := s.cache.Get(extentID); ok && IsTinyExtent(extentID) {
+		e.switchFile(file, dstPath, current)
+	} else {
+		s.cache.Del(extentID)
+		if file != nil {
+			file.Close()
+		}
+	}
+	if removeErr := os.Remove(srcPath); removeErr != nil {
+		log.LogWarnf("EncryptExtent: remove %v err(%v)", srcPath, removeErr)
+	}
+	log.LogDebugf("EncryptExtent: extent(%v) of partition(%v) key version %v -> %v", extentID, s.partitionID,
+		version, current)
+	return
+}
+
+// encryptExtentFile copies the file of an extent decrypted by the cipher of the source and encrypted by the one of
+// the destination. The holes of the file are kept, and the header and the index of a compressed file are left plain.
+func encryptExtentFile(srcPath, dstPath string, srcCipher, dstCipher *extentCipher, wait func(size int)) (err error) {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	src := cipherFile{file: file, cipher: srcCipher}
+	size, err := src.Size()
+	if err != nil {
+		return
+	}
+	dstFile, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0666)
+	if err != nil {
+		return
+	}
+	defer dstFile.Close()
+	dst := cipherFile{file: dstFile, cipher: dstCipher}
+	buf := make([]byte, util.BlockSize)
+	for offset := int64(0); offset < size; {
+		var start, end int64
+		if start, err = file.Seek(offset, SEEK_DATA); err != nil {
+			if strings.Contains(err.Error(), syscall.ENXIO.Error()) {
+				break
+			}
+			return
+		}
+		if end, err = file.Seek(start, SEEK_HOLE); err != nil {
+			return
+		}
+		// the file ends with the zeros of the size of the padding
+		if end > size {
+			end = size
+		}
+		for pos := start; pos < end; {
+			length := int64(len(buf))
+			if end-pos < length {
+				length = end - pos
+			}
+			if wait != nil {
+				wait(int(length))
+			}
+			var n int
+			if n, err = src.ReadAt(buf[:length], pos); n == 0 {
+				if err == nil || err == io.EOF {
+					err = fmt.Errorf("read %v at %v: unexpected end of file", srcPath, pos)
+				}
+				return
+			}
+			if _, err = dst.WriteAt(buf[:n], pos); err != nil {
+				return
+			}
+			pos += int64(n)
+		}
+		offset = end
+	}
+	if err = dst.Extend(size); err != nil {
+		return
+	}
+	return dstFile.Sync()
+}
@@ -0,0 +1,169 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+)
+
+func newTestCipherFile(t *testing.T, dir, name string, partitionID, extentID uint64, head int64) cipherFile {
+	c, err := newExtentCipher(bytes.Repeat([]byte{7}, 32), partitionID, extentID, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path.Join(dir, name), os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cipherFile{file: file, cipher: c}
+}
+
+func TestCipherFileSize(t *testing.T) {
+	for _, head := range []int64{0, 48} {
+		for dataSize := int64(0); dataSize < 3*cipherSectorSize; dataSize++ {
+			fileSize := cipherFileSize(dataSize, head)
+			if dataSize > head && (fileSize-head)%cipherBlockSize != (cipherBlockSize-(dataSize-head)%cipherBlockSize)%cipherBlockSize {
+				t.Fatalf("head %v: the file of size %v does not keep the padding of the data of size %v", head,
+					fileSize, dataSize)
+			}
+			if size := cipherDataSize(fileSize, head); size != dataSize {
+				t.Fatalf("head %v: expect data size %v of file size %v, got %v", head, dataSize, fileSize, size)
+			}
+		}
+	}
+}
+
+func TestCipherFileReadWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cipher_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	random := rand.New(rand.NewSource(1))
+	for _, head := range []int64{0, 48} {
+		f := newTestCipherFile(t, dir, "rw", 1, 1025, head)
+		model := make([]byte, head)
+		random.Read(model)
+		if _, err = f.WriteAt(model, 0); err != nil {
+			t.Fatal(err)
+		}
+		check := func(step int) {
+			if size, err := f.Size(); err != nil || size != int64(len(model)) {
+				t.Fatalf("head %v step %v: expect size %v, got %v %v", head, step, len(model), size, err)
+			}
+			data := make([]byte, len(model)+100)
+			n, err := f.ReadAt(data, 0)
+			if err != io.EOF || n != len(model) || !bytes.Equal(data[:n], model) {
+				t.Fatalf("head %v step %v: read %v of %v bytes, err %v", head, step, n, len(model), err)
+			}
+			// the reads at the unaligned offsets of the unaligned lengths
+			for i := 0; i < 20; i++ {
+				off := random.Int63n(int64(len(model)) + 1)
+				length := random.Intn(3 * cipherSectorSize)
+				data = make([]byte, length)
+				n, err = f.ReadAt(data, off)
+				expect := model[off:]
+				if len(expect) > length {
+					expect = expect[:length]
+				}
+				if n != len(expect) || !bytes.Equal(data[:n], expect) || (n < length && err != io.EOF) {
+					t.Fatalf("head %v step %v: read %v at %v: got %v bytes, err %v", head, step, length, off, n, err)
+				}
+			}
+		}
+		for step := 0; step < 200; step++ {
+			// appends and overwrites at the unaligned offsets of the unaligned lengths
+			off := head + random.Int63n(int64(len(model))-head+1)
+			data := make([]byte, 1+random.Intn(2*cipherSectorSize))
+			random.Read(data)
+			if n, err := f.WriteAt(data, off); err != nil || n != len(data) {
+				t.Fatalf("head %v step %v: write %v at %v: %v %v", head, step, len(data), off, n, err)
+			}
+			if end := off + int64(len(data)); end > int64(len(model)) {
+				model = append(model, make([]byte, end-int64(len(model)))...)
+			}
+			copy(model[off:], data)
+			check(step)
+		}
+		// the data past the end is written after the zeros up to the end of the last sector
+		size := int64(len(model))
+		if err = f.Extend(size + 10); err != nil {
+			t.Fatal(err)
+		}
+		model = append(model, make([]byte, 10)...)
+		check(-1)
+		off := size + 3*cipherSectorSize + 5
+		if _, err = f.WriteAt([]byte("tail"), off); err != nil {
+			t.Fatal(err)
+		}
+		zeros, _ := f.cipher.sector(size + 10)
+		zeros += cipherSectorSize
+		data := make([]byte, off+4)
+		if n, err := f.ReadAt(data, 0); n != len(data) || err != nil {
+			t.Fatalf("head %v: read %v bytes, err %v", head, n, err)
+		}
+		if !bytes.Equal(data[:size+10], model) || !bytes.Equal(data[size+10:zeros], make([]byte, zeros-size-10)) ||
+			string(data[off:]) != "tail" {
+			t.Fatalf("head %v: unexpected data written past the end", head)
+		}
+		f.file.Close()
+		os.Remove(f.Name())
+	}
+}
+
+func TestCipherFileKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cipher_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := bytes.Repeat([]byte("chubaofs"), cipherSectorSize/4)
+	files := []cipherFile{
+		newTestCipherFile(t, dir, "1_1025", 1, 1025, 0),
+		newTestCipherFile(t, dir, "1_1026", 1, 1026, 0),
+		newTestCipherFile(t, dir, "2_1025", 2, 1025, 0),
+		newTestCipherFile(t, dir, "1_1025.z", 1, 1025, 16),
+	}
+	var encrypted [][]byte
+	for _, f := range files {
+		if _, err = f.WriteAt(data, 0); err != nil {
+			t.Fatal(err)
+		}
+		f.file.Close()
+		raw, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(raw[16:], data[16:32]) {
+			t.Fatalf("%v: the data is written plain", f.Name())
+		}
+		// the sectors of the same data differ
+		if bytes.Equal(raw[32:48], raw[32+cipherSectorSize:48+cipherSectorSize]) {
+			t.Fatalf("%v: the sectors of the same data are encrypted the same", f.Name())
+		}
+		for i, other := range encrypted {
+			if bytes.Equal(raw[16:1024], other[16:1024]) {
+				t.Fatalf("%v: encrypted the same as %v", f.Name(), files[i].Name())
+			}
+		}
+		encrypted = append(encrypted, raw)
+	}
+}
@@ -0,0 +1,576 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The small normal extents which are not modified for a while may be packed one after another into a pack file of
+// the store, named by its ID with the prefix "PACK_", so that they take neither a file nor the rounded up blocks of
+// their own. The pack index maps each packed extent to its pack file, offset and size. It is replaced by a temporary
+// file, and the files of the extents are removed only after the index is persisted, so an extent found both in its
+// file and in the index after a restart is taken from the index.
+//
+// The packed extents can not be written, they are unpacked back before the writes like the compressed ones. A packed
+// extent deleted is dropped from the index in memory and recorded in the delete file of the normal extents as usual,
+// and the records after the ones the index was persisted with are replayed when the index is loaded. The pack files
+// are packed again once most of them are the garbage of the extents deleted or unpacked, and are removed once they
+// have no packed extent.
+
+const (
+	PackFilePrefix    = "PACK_"
+	PackIndexFileName = "EXTENT_PACK"
+)
+
+// packedExtent is the location of a packed extent, which is replaced rather than modified once it is indexed.
+type packedExtent struct {
+	PackID     uint64 `json:"pack"`
+	Offset     int64  `json:"offset"`
+	Size       int64  `json:"size"`
+	ModifyTime int64  `json:"modTime"`
+}
+
+// readAt reads the data of the packed extent at the offset like ReadAt of its own file.
+func (pe *packedExtent) readAt(file cipherFile, p []byte, off int64) (n int, err error) {
+	if off >= pe.Size {
+		return 0, io.EOF
+	}
+	if rest := pe.Size - off; int64(len(p)) > rest {
+		if n, err = file.ReadAt(p[:rest], pe.Offset+off); err == nil {
+			err = io.EOF
+		}
+		return
+	}
+	return file.ReadAt(p, pe.Offset+off)
+}
+
+type packIndex struct {
+	PackSeq         uint64                   `json:"packSeq"`         // ID of the last pack file created
+	DeleteLogOffset int64                    `json:"deleteLogOffset"` // size of the delete file of the normal extents
+	Extents         map[uint64]*packedExtent `json:"extents"`
+}
+
+// PackStats is the space taken by the packed extents of a store.
+type PackStats struct {
+	PackFiles     int    `json:"packFiles"`
+	PackedExtents int    `json:"packedExtents"`
+	PackedBytes   uint64 `json:"packedBytes"`   // size of the data of the packed extents
+	PackFileBytes uint64 `json:"packFileBytes"` // size of the pack files, including the garbage
+	SavedBytes    uint64 `json:"savedBytes"`    // space the packed extents would take more in the files of their own
+	RemovedBytes  uint64 `json:"removedBytes"`  // size of the pack files removed since the store is loaded
+}
+
+func (s *ExtentStore) packPath(packID uint64) string {
+	return path.Join(s.dataPath, PackFilePrefix+strconv.FormatUint(packID, 10))
+}
+
+func parsePackFileName(filename string) (packID uint64, isPack bool) {
+	if !strings.HasPrefix(filename, PackFilePrefix) {
+		return
+	}
+	packID, err := strconv.ParseUint(strings.TrimPrefix(filename, PackFilePrefix), 10, 64)
+	return packID, err == nil && packID > 0
+}
+
+func (s *ExtentStore) packedExtent(extentID uint64) *packedExtent {
+	if value, ok := s.packedExtents.Load(extentID); ok {
+		return value.(*packedExtent)
+	}
+	return nil
+}
+
+// IsPackedExtent tells if the normal extent is packed.
+func (s *ExtentStore) IsPackedExtent(extentID uint64) (packed bool) {
+	_, packed = s.packedExtents.Load(extentID)
+	return
+}
+
+// loadPackIndex loads the pack index before the extents are loaded. The deletes of the packed extents recorded after
+// the index was persisted are replayed, and the pack files not in the index, which are left if the store stopped
+// before the index was persisted, are removed.
+func (s *ExtentStore) loadPackIndex() (err error) {
+	index := &packIndex{}
+	data, err := ioutil.ReadFile(path.Join(s.dataPath, PackIndexFileName))
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, index); err != nil {
+			return fmt.Errorf("unmarshal pack index: %v", err)
+		}
+	}
+	deleted, err := s.readDeletedExtents(index.DeleteLogOffset)
+	if err != nil {
+		return
+	}
+	files, err := ioutil.ReadDir(s.dataPath)
+	if err != nil {
+		return
+	}
+	packs := make(map[uint64]int64)
+	for _, f := range files {
+		if packID, isPack := parsePackFileName(f.Name()); isPack {
+			packs[packID] = f.Size()
+			if packID > index.PackSeq {
+				index.PackSeq = packID
+			}
+		}
+	}
+	for extentID, pe := range index.Extents {
+		if deleted[extentID] {
+			continue
+		}
+		if _, ok := packs[pe.PackID]; !ok {
+			log.LogErrorf("loadPackIndex: partition(%v) extent(%v) is lost with pack file %v", s.partitionID,
+				extentID, pe.PackID)
+			continue
+		}
+		s.packedExtents.Store(extentID, pe)
+	}
+	atomic.StoreUint64(&s.packSeq, index.PackSeq)
+	for packID, size := range packs {
+		s.packFiles.Store(packID, size)
+	}
+	s.removeUnusedPacks()
+	return nil
+}
+
+// readDeletedExtents reads the normal extents recorded in the delete file from the offset.
+func (s *ExtentStore) readDeletedExtents(offset int64) (deleted map[uint64]bool, err error) {
+	deleted = make(map[uint64]bool)
+	stat, err := s.normalExtentDeleteFp.Stat()
+	if err != nil || stat.Size() <= offset {
+		return
+	}
+	data := make([]byte, stat.Size()-offset)
+	if _, err = s.normalExtentDeleteFp.ReadAt(data, offset); err != nil && err != io.EOF {
+		return
+	}
+	for i := 0; i+8 <= len(data); i += 8 {
+		deleted[binary.BigEndian.Uint64(data[i:i+8])] = true
+	}
+	return deleted, nil
+}
+
+// loadPackedExtents loads the packed extents after the other extents are loaded, and returns the max ID of them.
+func (s *ExtentStore) loadPackedExtents() (maxExtentID uint64) {
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		extentID := key.(uint64)
+		e, err := s.extent(extentID)
+		if err != nil {
+			log.LogErrorf("loadPackedExtents: partition(%v) extent(%v) err(%v)", s.partitionID, extentID, err)
+			s.packedExtents.Delete(extentID)
+			return true
+		}
+		ei := &ExtentInfo{FileID: extentID}
+		ei.UpdateExtentInfo(e, 0)
+		s.eiMutex.Lock()
+		s.extentInfoMap[extentID] = ei
+		s.eiMutex.Unlock()
+		e.Close()
+		if extentID > maxExtentID {
+			maxExtentID = extentID
+		}
+		return true
+	})
+	return
+}
+
+// persistPackIndex replaces the pack index with the packed extents in memory, it is called with the IO on the
+// extents blocked.
+func (s *ExtentStore) persistPackIndex() (err error) {
+	index := &packIndex{PackSeq: atomic.LoadUint64(&s.packSeq), Extents: make(map[uint64]*packedExtent)}
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		index.Extents[key.(uint64)] = value.(*packedExtent)
+		return true
+	})
+	stat, err := s.normalExtentDeleteFp.Stat()
+	if err != nil {
+		return
+	}
+	index.DeleteLogOffset = stat.Size()
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	fileName := path.Join(s.dataPath, PackIndexFileName+ExtentTempSuffix)
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return
+	}
+	defer os.Remove(fileName)
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(fileName, path.Join(s.dataPath, PackIndexFileName))
+}
+
+// removeUnusedPacks removes the pack files with no packed extent, it is called with the IO on the extents blocked.
+func (s *ExtentStore) removeUnusedPacks() {
+	used := make(map[uint64]bool)
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		used[value.(*packedExtent).PackID] = true
+		return true
+	})
+	s.packFiles.Range(func(key, value interface{}) bool {
+		packID := key.(uint64)
+		if used[packID] {
+			return true
+		}
+		if err := os.Remove(s.packPath(packID)); err != nil && !os.IsNotExist(err) {
+			log.LogWarnf("removeUnusedPacks: partition(%v) pack(%v) err(%v)", s.partitionID, packID, err)
+			return true
+		}
+		s.packFiles.Delete(packID)
+		atomic.AddUint64(&s.packRemovedBytes, uint64(value.(int64)))
+		return true
+	})
+}
+
+// PackStats returns the space taken by the packed extents.
+func (s *ExtentStore) PackStats() (stats PackStats) {
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		pe := value.(*packedExtent)
+		stats.PackedExtents++
+		stats.PackedBytes += uint64(pe.Size)
+		stats.SavedBytes += uint64((pe.Size + PageSize - 1) / PageSize * PageSize)
+		return true
+	})
+	s.packFiles.Range(func(key, value interface{}) bool {
+		stats.PackFiles++
+		stats.PackFileBytes += uint64(value.(int64))
+		return true
+	})
+	if stats.SavedBytes > stats.PackFileBytes {
+		stats.SavedBytes -= stats.PackFileBytes
+	} else {
+		stats.SavedBytes = 0
+	}
+	stats.RemovedBytes = atomic.LoadUint64(&s.packRemovedBytes)
+	return
+}
+
+// PackCandidates returns the normal extents in the files of their own no larger than the max size and not modified
+// for the cold age. The compressed, the encrypted and the cached extents are left out, and so are all the extents if
+// the store has an encryption key, since they are to be encrypted in the files of their own.
+func (s *ExtentStore) PackCandidates(coldAge, maxSize int64) (extentIDs []uint64) {
+	if s.keyring.currentVersion() > 0 {
+		return
+	}
+	now := time.Now().Unix()
+	s.eiMutex.RLock()
+	for extentID, ei := range s.extentInfoMap {
+		if IsTinyExtent(extentID) || ei.IsDeleted || ei.Size == 0 || ei.Size > uint64(maxSize) ||
+			now-ei.ModifyTime < coldAge || now-ei.ModifyTime <= UpdateCrcInterval {
+			continue
+		}
+		if s.IsPackedExtent(extentID) || s.IsCompressedExtent(extentID) || s.IsCachedExtent(extentID) ||
+			s.ExtentKeyVersion(extentID) > 0 {
+			continue
+		}
+		extentIDs = append(extentIDs, extentID)
+	}
+	s.eiMutex.RUnlock()
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// RepackCandidates returns the packed extents in the pack files of which the garbage is more than the ratio of their
+// size, they are packed again to reclaim the garbage.
+func (s *ExtentStore) RepackCandidates(garbageRatio float64) (extentIDs []uint64) {
+	live := make(map[uint64]int64)
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		pe := value.(*packedExtent)
+		live[pe.PackID] += pe.Size
+		return true
+	})
+	repack := make(map[uint64]bool)
+	s.packFiles.Range(func(key, value interface{}) bool {
+		packID, size := key.(uint64), value.(int64)
+		if live[packID] > 0 && float64(size-live[packID]) > float64(size)*garbageRatio {
+			repack[packID] = true
+		}
+		return true
+	})
+	if len(repack) == 0 {
+		return
+	}
+	s.packedExtents.Range(func(key, value interface{}) bool {
+		if repack[value.(*packedExtent).PackID] {
+			extentIDs = append(extentIDs, key.(uint64))
+		}
+		return true
+	})
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// ReclaimPacks removes the pack files of which all the packed extents are deleted or unpacked.
+func (s *ExtentStore) ReclaimPacks() {
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	s.removeUnusedPacks()
+}
+
+// packSource is an extent copied into a new pack file, from the file of its own or from another pack file.
+type packSource struct {
+	extentID uint64
+	srcPath  string
+	modTime  time.Time
+	from     *packedExtent // nil if the extent is in the file of its own
+	to       *packedExtent
+}
+
+// readPackSource reads the data of the extent to pack.
+func (s *ExtentStore) readPackSource(extentID uint64) (src *packSource, data []byte, err error) {
+	if IsTinyExtent(extentID) || !s.HasExtent(extentID) {
+		return nil, nil, NewParameterMismatchErr(fmt.Sprintf("extent(%v) of partition(%v) can not be packed",
+			extentID, s.partitionID))
+	}
+	src = &packSource{extentID: extentID, from: s.packedExtent(extentID)}
+	if src.from != nil {
+		src.srcPath = s.packPath(src.from.PackID)
+		src.modTime = time.Unix(src.from.ModifyTime, 0)
+		data, err = s.readPackedData(src.from)
+		return
+	}
+	if s.IsCompressedExtent(extentID) || s.IsCachedExtent(extentID) || s.ExtentKeyVersion(extentID) > 0 {
+		return nil, nil, NewParameterMismatchErr(fmt.Sprintf("extent(%v) of partition(%v) can not be packed",
+			extentID, s.partitionID))
+	}
+	src.srcPath = s.extentPath(extentID)
+	before, err := os.Stat(src.srcPath)
+	if err != nil {
+		return
+	}
+	src.modTime = before.ModTime()
+	if data, err = ioutil.ReadFile(src.srcPath); err != nil {
+		return
+	}
+	if int64(len(data)) != before.Size() {
+		return nil, nil, fmt.Errorf("extent(%v) of partition(%v) is modified during the packing", extentID,
+			s.partitionID)
+	}
+	return
+}
+
+func (s *ExtentStore) readPackedData(pe *packedExtent) (data []byte, err error) {
+	file, err := os.Open(s.packPath(pe.PackID))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	data = make([]byte, pe.Size)
+	_, err = file.ReadAt(data, pe.Offset)
+	return
+}
+
+// changedPackSource tells if the extent is deleted, modified or switched to another file since it is read to pack,
+// it is called with the IO on the extents blocked.
+func (s *ExtentStore) changedPackSource(src *packSource) bool {
+	if !s.HasExtent(src.extentID) || s.packedExtent(src.extentID) != src.from {
+		return true
+	}
+	if src.from != nil {
+		return false
+	}
+	if s.extentPath(src.extentID) != src.srcPath {
+		return true
+	}
+	after, err := os.Stat(src.srcPath)
+	return err != nil || !after.ModTime().Equal(src.modTime) || after.Size() != src.to.Size
+}
+
+// PackExtents packs the normal extents into a new pack file, which are either in the files of their own or in the
+// pack files with much garbage, and returns the number of the extents packed. The extents are copied without blocking
+// the IO on them, the wait function is called with the size of each extent before it is read to limit the rate. An
+// extent is switched to the new pack file only if it is not modified during the copy.
+func (s *ExtentStore) PackExtents(extentIDs []uint64, wait func(size int)) (packed int, err error) {
+	if len(extentIDs) == 0 {
+		return
+	}
+	packID := atomic.AddUint64(&s.packSeq, 1)
+	packPath := s.packPath(packID)
+	tempPath := packPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	dst, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return
+	}
+	sources := make([]*packSource, 0, len(extentIDs))
+	var packSize int64
+	for _, extentID := range extentIDs {
+		if ei, _ := s.Watermark(extentID); ei != nil && wait != nil {
+			wait(int(ei.Size))
+		}
+		src, data, readErr := s.readPackSource(extentID)
+		if readErr != nil {
+			log.LogWarnf("PackExtents: partition(%v) extent(%v) err(%v)", s.partitionID, extentID, readErr)
+			continue
+		}
+		if _, err = dst.WriteAt(data, packSize); err != nil {
+			dst.Close()
+			return
+		}
+		src.to = &packedExtent{PackID: packID, Offset: packSize, Size: int64(len(data)), ModifyTime: src.modTime.Unix()}
+		sources = append(sources, src)
+		packSize += int64(len(data))
+	}
+	if err = dst.Sync(); err != nil {
+		dst.Close()
+		return
+	}
+	if err = dst.Close(); err != nil {
+		return
+	}
+	if len(sources) == 0 {
+		os.Remove(tempPath)
+		return
+	}
+	if err = os.Rename(tempPath, packPath); err != nil {
+		return
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	switched := make([]*packSource, 0, len(sources))
+	for _, src := range sources {
+		if !s.changedPackSource(src) {
+			switched = append(switched, src)
+		}
+	}
+	if len(switched) == 0 {
+		os.Remove(packPath)
+		return
+	}
+	s.packFiles.Store(packID, packSize)
+	for _, src := range switched {
+		s.packedExtents.Store(src.extentID, src.to)
+	}
+	if err = s.persistPackIndex(); err != nil {
+		for _, src := range switched {
+			if src.from != nil {
+				s.packedExtents.Store(src.extentID, src.from)
+			} else {
+				s.packedExtents.Delete(src.extentID)
+			}
+		}
+		s.packFiles.Delete(packID)
+		os.Remove(packPath)
+		return
+	}
+	for _, src := range switched {
+		s.cache.Del(src.extentID)
+		if src.from != nil {
+			continue
+		}
+		if removeErr := os.Remove(src.srcPath); removeErr != nil {
+			log.LogWarnf("PackExtents: remove %v err(%v)", src.srcPath, removeErr)
+		}
+	}
+	s.removeUnusedPacks()
+	log.LogDebugf("PackExtents: partition(%v) packed %v extents into pack(%v) of size %v", s.partitionID,
+		len(switched), packID, packSize)
+	return len(switched), nil
+}
+
+// UnpackExtent moves the packed extent back to the file of its own before it is written.
+func (s *ExtentStore) UnpackExtent(extentID uint64) (err error) {
+	pe := s.packedExtent(extentID)
+	if pe == nil {
+		return
+	}
+	dstPath := path.Join(s.dataPath, extentFileNameOf(extentID, 0, false))
+	tempPath := dstPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	data, err := s.readPackedData(pe)
+	if err != nil {
+		return
+	}
+	if err = writeExtentFile(tempPath, data); err != nil {
+		return
+	}
+	if err = os.Chtimes(tempPath, time.Now(), time.Unix(pe.ModifyTime, 0)); err != nil {
+		return
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	if current := s.packedExtent(extentID); current != pe {
+		if current == nil {
+			os.Remove(tempPath)
+			return
+		}
+		return fmt.Errorf("extent(%v) of partition(%v) is packed again during the unpacking", extentID, s.partitionID)
+	}
+	if !s.HasExtent(extentID) {
+		return ExtentNotFoundError
+	}
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		return
+	}
+	s.packedExtents.Delete(extentID)
+	if err = s.persistPackIndex(); err != nil {
+		s.packedExtents.Store(extentID, pe)
+		os.Remove(dstPath)
+		return
+	}
+	s.cache.Del(extentID)
+	s.removeUnusedPacks()
+	log.LogDebugf("UnpackExtent: extent(%v) of partition(%v)", extentID, s.partitionID)
+	return
+}
+
+func writeExtentFile(filePath string, data []byte) (err error) {
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		return
+	}
+	return f.Sync()
+}
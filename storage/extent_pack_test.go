@@ -0,0 +1,198 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestPackExtents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestExtentStore(t, dir)
+	extents := make(map[uint64][]byte)
+	var extentIDs []uint64
+	for i := 0; i < 6; i++ {
+		extentID := uint64(1025 + i)
+		extents[extentID] = newTestExtentData(1000*(i+1)+i, int64(i))
+		writeTestExtent(t, s, extentID, extents[extentID])
+		extentIDs = append(extentIDs, extentID)
+	}
+	checkExtents := func(step string) {
+		for extentID, data := range extents {
+			if !s.HasExtent(extentID) {
+				t.Fatalf("%v: extent %v is lost", step, extentID)
+			}
+			checkTestExtent(t, s, extentID, data)
+		}
+	}
+
+	packed, err := s.PackExtents(extentIDs, nil)
+	if err != nil || packed != len(extentIDs) {
+		t.Fatalf("expect %v extents packed, got %v err %v", len(extentIDs), packed, err)
+	}
+	for _, extentID := range extentIDs {
+		if !s.IsPackedExtent(extentID) {
+			t.Fatalf("extent %v is not packed", extentID)
+		}
+		if _, err = os.Stat(path.Join(dir, fmt.Sprint(extentID))); !os.IsNotExist(err) {
+			t.Fatalf("file of packed extent %v is left, err %v", extentID, err)
+		}
+	}
+	if stats := s.PackStats(); stats.PackFiles != 1 || stats.PackedExtents != len(extentIDs) {
+		t.Errorf("unexpected pack stats %+v", stats)
+	}
+	checkExtents("pack")
+
+	// the packed extent is unpacked back to be written
+	data := extents[1026]
+	copy(data[10:], "overwritten")
+	if err = s.Write(1026, 10, 11, data[10:21], crc32.ChecksumIEEE(data[10:21]), RandomWriteType, true); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsPackedExtent(1026) {
+		t.Fatalf("extent is packed after the write")
+	}
+	if err = s.UnpackExtent(1027); err != nil || s.IsPackedExtent(1027) {
+		t.Fatalf("extent is not unpacked, err %v", err)
+	}
+	checkExtents("unpack")
+
+	// the extents packed and unpacked are loaded after the restart
+	s.Close()
+	s = newTestExtentStore(t, dir)
+	for extentID := range extents {
+		if s.IsPackedExtent(extentID) != (extentID != 1026 && extentID != 1027) {
+			t.Fatalf("extent %v is packed %v after the restart", extentID, s.IsPackedExtent(extentID))
+		}
+	}
+	checkExtents("restart")
+	s.Close()
+}
+
+func TestDeletePackedExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_pack_delete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestExtentStore(t, dir)
+	extents := make(map[uint64][]byte)
+	var extentIDs []uint64
+	for i := 0; i < 4; i++ {
+		extentID := uint64(1025 + i)
+		extents[extentID] = newTestExtentData(4000, int64(i))
+		writeTestExtent(t, s, extentID, extents[extentID])
+		extentIDs = append(extentIDs, extentID)
+	}
+	if _, err = s.PackExtents(extentIDs, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the deleted extents are left in the pack file as the garbage
+	for _, extentID := range extentIDs[:3] {
+		if err = s.MarkDelete(extentID, 0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if s.HasExtent(extentID) || s.IsPackedExtent(extentID) {
+			t.Fatalf("extent %v is not deleted", extentID)
+		}
+		delete(extents, extentID)
+	}
+	checkTestExtent(t, s, 1028, extents[1028])
+
+	// the deletes not persisted by the pack index are replayed after the restart
+	s.Close()
+	s = newTestExtentStore(t, dir)
+	for _, extentID := range extentIDs[:3] {
+		if s.HasExtent(extentID) {
+			t.Fatalf("deleted extent %v is loaded after the restart", extentID)
+		}
+	}
+	checkTestExtent(t, s, 1028, extents[1028])
+
+	// the pack file mostly of the garbage is packed again and removed
+	repack := s.RepackCandidates(0.5)
+	if len(repack) != 1 || repack[0] != 1028 {
+		t.Fatalf("expect extent 1028 to repack, got %v", repack)
+	}
+	if packed, err := s.PackExtents(repack, nil); err != nil || packed != 1 {
+		t.Fatalf("expect the extent repacked, got %v err %v", packed, err)
+	}
+	if stats := s.PackStats(); stats.PackFiles != 1 || stats.PackFileBytes != uint64(len(extents[1028])) {
+		t.Errorf("expect the pack file of the garbage removed, got %+v", stats)
+	}
+	if _, err = os.Stat(path.Join(dir, PackFilePrefix+"1")); !os.IsNotExist(err) {
+		t.Errorf("pack file of the garbage is left, err %v", err)
+	}
+	checkTestExtent(t, s, 1028, extents[1028])
+
+	// the pack file is removed with its last extent
+	if err = s.MarkDelete(1028, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	s.ReclaimPacks()
+	if stats := s.PackStats(); stats.PackFiles != 0 {
+		t.Errorf("expect no pack file, got %+v", stats)
+	}
+	s.Close()
+}
+
+func TestLoadPartiallyPackedExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_pack_crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestExtentStore(t, dir)
+	data := newTestExtentData(5000, 1)
+	writeTestExtent(t, s, 1025, data)
+	raw, err := ioutil.ReadFile(path.Join(dir, "1025"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.PackExtents([]uint64{1025}, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// the store stopped after the index was persisted but before the file of the extent was removed, and during
+	// the copy into another pack file
+	if err = ioutil.WriteFile(path.Join(dir, "1025"), raw, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(path.Join(dir, PackFilePrefix+"2"+ExtentTempSuffix), raw[:100], 0666); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestExtentStore(t, dir)
+	if !s.IsPackedExtent(1025) {
+		t.Fatalf("extent is not taken from the pack index")
+	}
+	for _, name := range []string{"1025", PackFilePrefix + "2" + ExtentTempSuffix} {
+		if _, err = os.Stat(path.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%v is left, err %v", name, err)
+		}
+	}
+	checkTestExtent(t, s, 1025, data)
+	s.Close()
+}
@@ -127,6 +127,19 @@ type ExtentStore struct {
 	verifyExtentFp                    *os.File
 	hasAllocSpaceExtentIDOnVerfiyFile uint64
 	hasDeleteNormalExtentsCache       sync.Map
+	cachePath                         string       // cache directory of the normal extents, empty if there is none
+	cacheHasSpace                     func() bool  // tells if a new extent can be created in the cache directory
+	cachedExtents                     sync.Map     // normal extents in the cache directory
+	extentHeats                       sync.Map     // reads of the normal extents, extent ID -> *extentHeat
+	tierLock                          sync.RWMutex // held exclusively to switch an extent between the files
+	compressedExtents                 sync.Map     // compressed normal extents, extent ID -> size of the file
+	incompressibleExtents             sync.Map     // normal extents which can not be compressed smaller
+	keyring                           *extentKeyring
+	encryptedExtents                  sync.Map // encrypted extents, extent ID -> version of the key
+	packedExtents                     sync.Map // packed normal extents, extent ID -> *packedExtent
+	packFiles                         sync.Map // pack files, pack ID -> size of the file
+	packSeq                           uint64   // ID of the last pack file created
+	packRemovedBytes                  uint64   // size of the pack files removed since the store is loaded
 }
 
 func MkdirAll(name string) (err error) {
@@ -137,6 +150,7 @@ func NewExtentStore(dataDir string, partitionID uint64, storeSize int) (s *Exten
 	s = new(ExtentStore)
 	s.dataPath = dataDir
 	s.partitionID = partitionID
+	s.keyring = new(extentKeyring)
 	if err = MkdirAll(dataDir); err != nil {
 		return nil, fmt.Errorf("NewExtentStore [%v] err[%v]", dataDir, err)
 	}
@@ -228,15 +242,21 @@ func (s *ExtentStore) SnapShot() (files []*proto.File, err error) {
 // Create creates an extent.
 func (s *ExtentStore) Create(extentID uint64) (err error) {
 	var e *Extent
-	name := path.Join(s.dataPath, strconv.Itoa(int(extentID)))
 	if s.HasExtent(extentID) {
 		err = ExtentExistsError
 		return err
 	}
-	e = NewExtentInCore(name, extentID)
+	if s.cachePath != "" && !IsTinyExtent(extentID) && s.cacheHasSpace() {
+		s.cachedExtents.Store(extentID, true)
+	}
+	s.setKeyVersionOnLoad(extentID, s.keyring.currentVersion())
+	name := s.extentPath(extentID)
+	e = s.newExtent(name, extentID)
 	e.header = make([]byte, util.BlockHeaderSize)
 	err = e.InitToFS()
 	if err != nil {
+		s.cachedExtents.Delete(extentID)
+		s.encryptedExtents.Delete(extentID)
 		return err
 	}
 	s.cache.Put(e)
@@ -255,6 +275,9 @@ func (s *ExtentStore) initBaseFileID() (err error) {
 		baseFileID uint64
 	)
 	baseFileID, _ = s.GetPersistenceBaseExtentID()
+	if err = s.loadPackIndex(); err != nil {
+		return fmt.Errorf("load pack index: %v", err)
+	}
 	files, err := ioutil.ReadDir(s.dataPath)
 	if err != nil {
 		return err
@@ -268,10 +291,30 @@ func (s *ExtentStore) initBaseFileID() (err error) {
 		loadErr  error
 	)
 	for _, f := range files {
-		if extentID, isExtent = s.ExtentID(f.Name()); !isExtent {
+		if strings.HasSuffix(f.Name(), ExtentTempSuffix) {
+			os.Remove(path.Join(s.dataPath, f.Name()))
+			continue
+		}
+		var (
+			keyVersion uint32
+			compressed bool
+		)
+		if extentID, keyVersion, compressed, isExtent = s.parseExtentFileName(f.Name()); !isExtent {
+			continue
+		}
+		// the store stopped before the file of the packed extent was removed
+		if s.IsPackedExtent(extentID) {
+			os.Remove(path.Join(s.dataPath, f.Name()))
+			continue
+		}
+		if s.HasExtent(extentID) && !s.replaceExtentFileOnLoad(extentID, keyVersion, compressed, path.Join(s.dataPath, f.Name())) {
 			continue
 		}
+		s.setCompressedOnLoad(extentID, compressed, f.Size())
+		s.setKeyVersionOnLoad(extentID, keyVersion)
 		if e, loadErr = s.extent(extentID); loadErr != nil {
+			s.compressedExtents.Delete(extentID)
+			s.encryptedExtents.Delete(extentID)
 			continue
 		}
 		ei = &ExtentInfo{FileID: extentID}
@@ -285,6 +328,9 @@ func (s *ExtentStore) initBaseFileID() (err error) {
 			baseFileID = extentID
 		}
 	}
+	if maxPackedID := s.loadPackedExtents(); maxPackedID > baseFileID {
+		baseFileID = maxPackedID
+	}
 	if baseFileID < MinExtentID {
 		baseFileID = MinExtentID
 	}
@@ -300,6 +346,10 @@ func (s *ExtentStore) Write(extentID uint64, offset, size int64, data []byte, cr
 		e  *Extent
 		ei *ExtentInfo
 	)
+	if err = s.lockRawExtent(extentID); err != nil {
+		return err
+	}
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei, _ = s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
@@ -344,6 +394,8 @@ func IsTinyExtent(extentID uint64) bool {
 // Read reads the extent based on the given id.
 func (s *ExtentStore) Read(extentID uint64, offset, size int64, nbuf []byte, isRepairRead bool) (crc uint32, err error) {
 	var e *Extent
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei := s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
@@ -354,11 +406,73 @@ func (s *ExtentStore) Read(extentID uint64, offset, size int64, nbuf []byte, isR
 		return
 	}
 	crc, err = e.Read(nbuf, offset, size, isRepairRead)
+	if err == nil && !isRepairRead {
+		s.recordRead(extentID)
+	}
 
 	return
 }
 
+// SendExtent sends the data of the normal extent at the offset to the connection by sendfile, so the data is not
+// copied through the user space. The header is sent by the function given once the extent is found sendable, or
+// ExtentNotSendableError is returned without sending anything, e.g. if the extent is encrypted or compressed.
+func (s *ExtentStore) SendExtent(extentID uint64, offset, size int64, conn syscall.Conn, header func() error) (err error) {
+	if !sendFileSupported || IsTinyExtent(extentID) {
+		return ExtentNotSendableError
+	}
+	f, fileOffset, err := s.pinExtentFile(extentID, offset, size)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if err = header(); err != nil {
+		return
+	}
+	if err = sendFile(conn, f, fileOffset, size); err != nil {
+		return
+	}
+	s.recordRead(extentID)
+	return
+}
+
+// pinExtentFile returns a duplicate of the file holding the data of the extent and the offset of the data in it. The
+// tier lock is only held to get the duplicate, so the slow connections sending from it never hold up the tiering,
+// compression or packing of the extents.
+func (s *ExtentStore) pinExtentFile(extentID uint64, offset, size int64) (f *os.File, fileOffset int64, err error) {
+	var e *Extent
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	if e, err = s.extentWithHeader(ei); err != nil {
+		return
+	}
+	if err = s.checkOffsetAndSize(extentID, offset, size); err != nil {
+		return
+	}
+	if err = e.checkOffsetAndSize(offset, size); err != nil {
+		return
+	}
+	data, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	if data.cipher != nil || e.compressed != nil || offset+size > e.dataSize {
+		err = ExtentNotSendableError
+		return
+	}
+	fileOffset = offset
+	if e.packed != nil {
+		fileOffset += e.packed.Offset
+	}
+	f, err = dupFile(data.file)
+	return
+}
+
 func (s *ExtentStore) tinyDelete(extentID uint64, offset, size int64) (err error) {
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	e, err := s.extentWithHeaderByExtentID(extentID)
 	if err != nil {
 		return nil
@@ -391,16 +505,24 @@ func (s *ExtentStore) MarkDelete(extentID uint64, offset, size int64) (err error
 		return s.tinyDelete(extentID, offset, size)
 	}
 
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei = s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
 	if ei == nil || ei.IsDeleted {
 		return
 	}
-	extentFilePath := path.Join(s.dataPath, strconv.FormatUint(extentID, 10))
-	if err = os.Remove(extentFilePath); err != nil {
+	if s.IsPackedExtent(extentID) {
+		// the data is left in the pack file as the garbage, the delete is replayed by the record below on load
+		s.packedExtents.Delete(extentID)
+	} else if err = os.Remove(s.extentPath(extentID)); err != nil {
 		return
 	}
+	s.cachedExtents.Delete(extentID)
+	s.compressedExtents.Delete(extentID)
+	s.incompressibleExtents.Delete(extentID)
+	s.encryptedExtents.Delete(extentID)
 	s.PersistenceHasDeleteExtent(extentID)
 	ei.IsDeleted = true
 	ei.ModifyTime = time.Now().Unix()
@@ -491,7 +613,7 @@ func (s *ExtentStore) GetStoreUsedSize() (used int64) {
 		}
 		if IsTinyExtent(einfo.FileID) {
 			stat := new(syscall.Stat_t)
-			err := syscall.Stat(fmt.Sprintf("%v/%v", s.dataPath, einfo.FileID), stat)
+			err := syscall.Stat(s.extentPath(einfo.FileID), stat)
 			if err != nil {
 				continue
 			}
@@ -559,6 +681,48 @@ func (s *ExtentStore) ExtentID(filename string) (extentID uint64, isExtent bool)
 	return
 }
 
+// parseExtentFileName parses the ID of the extent from the name of its file, which may be encrypted and compressed.
+func (s *ExtentStore) parseExtentFileName(filename string) (extentID uint64, keyVersion uint32, compressed, isExtent bool) {
+	if strings.HasSuffix(filename, CompressedExtentSuffix) {
+		compressed = true
+		filename = strings.TrimSuffix(filename, CompressedExtentSuffix)
+	}
+	if index := strings.Index(filename, EncryptedExtentInfix); index > 0 {
+		version, err := strconv.ParseUint(filename[index+len(EncryptedExtentInfix):], 10, 32)
+		if err != nil || version == 0 {
+			return 0, 0, false, false
+		}
+		keyVersion = uint32(version)
+		filename = filename[:index]
+	}
+	if extentID, isExtent = s.ExtentID(filename); !isExtent || (compressed && IsTinyExtent(extentID)) {
+		return 0, 0, false, false
+	}
+	return
+}
+
+// replaceExtentFileOnLoad tells if the file of the extent loaded before is replaced by the other file of it found in
+// the same directory, which is the case if the store stopped before the source of a compression, a decompression or
+// an encryption was removed. The file by the newer key is kept, and the raw one if they are by the same key, the
+// other one is removed.
+func (s *ExtentStore) replaceExtentFileOnLoad(extentID uint64, keyVersion uint32, compressed bool, filePath string) bool {
+	loadedVersion := s.ExtentKeyVersion(extentID)
+	if keyVersion < loadedVersion || (keyVersion == loadedVersion && (compressed || !s.IsCompressedExtent(extentID))) {
+		os.Remove(filePath)
+		return false
+	}
+	os.Remove(s.extentPath(extentID))
+	s.compressedExtents.Delete(extentID)
+	s.encryptedExtents.Delete(extentID)
+	return true
+}
+
+func (s *ExtentStore) setCompressedOnLoad(extentID uint64, compressed bool, fileSize int64) {
+	if compressed {
+		s.compressedExtents.Store(extentID, fileSize)
+	}
+}
+
 func (s *ExtentStore) initTinyExtent() (err error) {
 	s.availableTinyExtentC = make(chan uint64, TinyExtentCount)
 	s.brokenTinyExtentC = make(chan uint64, TinyExtentCount)
@@ -813,9 +977,19 @@ func (s *ExtentStore) GetExtentCount() (count int) {
 	return len(s.extentInfoMap)
 }
 
-func (s *ExtentStore) loadExtentFromDisk(extentID uint64, putCache bool) (e *Extent, err error) {
-	name := path.Join(s.dataPath, strconv.Itoa(int(extentID)))
+// newExtent returns the extent in core with the keys of the store to be encrypted.
+func (s *ExtentStore) newExtent(name string, extentID uint64) (e *Extent) {
 	e = NewExtentInCore(name, extentID)
+	e.partitionID = s.partitionID
+	e.keyVersion = s.ExtentKeyVersion(extentID)
+	e.keyring = s.keyring
+	e.packed = s.packedExtent(extentID)
+	return
+}
+
+func (s *ExtentStore) loadExtentFromDisk(extentID uint64, putCache bool) (e *Extent, err error) {
+	name := s.extentPath(extentID)
+	e = s.newExtent(name, extentID)
 	if err = e.RestoreFromFS(); err != nil {
 		err = fmt.Errorf("restore from file %v putCache %v system: %v", name, putCache, err)
 		return
@@ -927,6 +1101,107 @@ func (s *ExtentStore) autoComputeExtentCrc() {
 
 }
 
+// VerifyExtentsCrc checks the data of all the normal extents against the persisted block crc.
+// Blocks whose crc has not been computed yet are skipped.
+func (s *ExtentStore) VerifyExtentsCrc() (err error) {
+	extentInfos := make([]*ExtentInfo, 0)
+	s.eiMutex.RLock()
+	for _, ei := range s.extentInfoMap {
+		if IsTinyExtent(ei.FileID) || ei.IsDeleted || ei.Size == 0 {
+			continue
+		}
+		extentInfos = append(extentInfos, ei)
+	}
+	s.eiMutex.RUnlock()
+	sort.Sort(ExtentInfoArr(extentInfos))
+
+	for _, ei := range extentInfos {
+		var e *Extent
+		if e, err = s.extentWithHeader(ei); err != nil {
+			if ei.IsDeleted {
+				err = nil
+				continue
+			}
+			return
+		}
+		if err = e.verifyBlockCrc(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ScrubExtentIDs returns the normal extents whose data can be scrubbed, the ones modified recently are skipped
+// since the crc of their blocks may not be computed yet.
+func (s *ExtentStore) ScrubExtentIDs() (extentIDs []uint64) {
+	now := time.Now().Unix()
+	s.eiMutex.RLock()
+	for _, ei := range s.extentInfoMap {
+		if IsTinyExtent(ei.FileID) || ei.IsDeleted || ei.Size == 0 || now-ei.ModifyTime <= UpdateCrcInterval {
+			continue
+		}
+		extentIDs = append(extentIDs, ei.FileID)
+	}
+	s.eiMutex.RUnlock()
+	sort.Slice(extentIDs, func(i, j int) bool { return extentIDs[i] < extentIDs[j] })
+	return
+}
+
+// ScrubExtent checks the data of the normal extent against the persisted block crc, and returns the corrupt blocks.
+// The wait function is called with the size of each block before it is read, to limit the rate of the scrubbing.
+func (s *ExtentStore) ScrubExtent(extentID uint64, wait func(size int)) (corrupt []int, err error) {
+	if IsTinyExtent(extentID) {
+		return nil, fmt.Errorf("extent %v is tinyExtent", extentID)
+	}
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	e, err := s.extentWithHeader(ei)
+	if err != nil {
+		return
+	}
+	return e.scrubBlocks(wait)
+}
+
+// VerifyRead checks the data read from the normal extent at the offset against the persisted crc of the blocks it
+// overlaps, and returns the corrupt blocks. The blocks whose crc is not computed yet and the tiny extents, which have
+// no block crc, are not checked.
+func (s *ExtentStore) VerifyRead(extentID uint64, offset, size int64, data []byte) (corrupt []int, err error) {
+	if IsTinyExtent(extentID) || size <= 0 {
+		return
+	}
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	e, err := s.extentWithHeader(ei)
+	if err != nil {
+		return
+	}
+	return e.verifyReadBlocks(data[:size], offset, size)
+}
+
+// RepairExtentBlock overwrites the corrupt block of the normal extent with the data read from another replica,
+// which must match the persisted crc of the block.
+func (s *ExtentStore) RepairExtentBlock(extentID uint64, blockNo int, data []byte) (err error) {
+	if IsTinyExtent(extentID) {
+		return fmt.Errorf("extent %v is tinyExtent", extentID)
+	}
+	if err = s.lockRawExtent(extentID); err != nil {
+		return
+	}
+	defer s.tierLock.RUnlock()
+	s.eiMutex.RLock()
+	ei := s.extentInfoMap[extentID]
+	s.eiMutex.RUnlock()
+	e, err := s.extentWithHeader(ei)
+	if err != nil {
+		return
+	}
+	return e.repairBlock(blockNo, data)
+}
+
 func (s *ExtentStore) TinyExtentRecover(extentID uint64, offset, size int64, data []byte, crc uint32, isEmptyPacket bool) (err error) {
 	if !IsTinyExtent(extentID) {
 		return fmt.Errorf("extent %v not tinyExtent", extentID)
@@ -937,6 +1212,8 @@ func (s *ExtentStore) TinyExtentRecover(extentID uint64, offset, size int64, dat
 		ei *ExtentInfo
 	)
 
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei = s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
@@ -959,6 +1236,8 @@ func (s *ExtentStore) TinyExtentGetFinfoSize(extentID uint64) (size uint64, err
 	if !IsTinyExtent(extentID) {
 		return 0, fmt.Errorf("unavali extent id (%v)", extentID)
 	}
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei := s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
@@ -966,11 +1245,15 @@ func (s *ExtentStore) TinyExtentGetFinfoSize(extentID uint64) (size uint64, err
 		return
 	}
 
-	finfo, err := e.file.Stat()
+	f, err := e.dataFile()
+	if err != nil {
+		return
+	}
+	fileSize, err := f.Size()
 	if err != nil {
 		return 0, err
 	}
-	size = uint64(finfo.Size())
+	size = uint64(fileSize)
 
 	return
 }
@@ -980,6 +1263,8 @@ func (s *ExtentStore) TinyExtentAvaliOffset(extentID uint64, offset int64) (newO
 	if !IsTinyExtent(extentID) {
 		return 0, 0, fmt.Errorf("unavali extent(%v)", extentID)
 	}
+	s.tierLock.RLock()
+	defer s.tierLock.RUnlock()
 	s.eiMutex.RLock()
 	ei := s.extentInfoMap[extentID]
 	s.eiMutex.RUnlock()
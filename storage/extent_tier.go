@@ -0,0 +1,303 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chubaofs/chubaofs/util"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The normal extents of a store may be kept in a cache directory on a faster disk besides its data directory. The new
+// extents are created in the cache directory while it has space, and MoveExtent moves them between the directories.
+// The tiny extents and the metadata files of the store are always in the data directory.
+
+// ExtentTempSuffix is the suffix of the copy of an extent being moved between the directories or being compressed.
+const ExtentTempSuffix = ".tmp"
+
+// extentHeat records the reads of a normal extent to tell the cold extents from the hot ones.
+type extentHeat struct {
+	reads    int64 // reads since the last call to TierCandidates
+	lastRead int64 // unix seconds
+}
+
+// SetCacheDir sets the cache directory of the store and loads the extents in it. The function hasSpace tells if a new
+// extent can be created in the cache directory. It must be called before the store is used.
+func (s *ExtentStore) SetCacheDir(dir string, hasSpace func() bool) (err error) {
+	if err = MkdirAll(dir); err != nil {
+		return
+	}
+	s.removeTempFiles(dir)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	s.cachePath = dir
+	s.cacheHasSpace = hasSpace
+	baseExtentID := atomic.LoadUint64(&s.baseExtentID)
+	for _, f := range files {
+		extentID, keyVersion, compressed, isExtent := s.parseExtentFileName(f.Name())
+		if !isExtent || IsTinyExtent(extentID) {
+			continue
+		}
+		// Both the directories have the extent if the store stopped before the source of a move was removed, the
+		// copies are the same since the extent can not be modified during the move.
+		if s.HasExtent(extentID) && !s.IsCachedExtent(extentID) {
+			log.LogWarnf("SetCacheDir: extent(%v) of partition(%v) is in both %v and %v, remove the cached one",
+				extentID, s.partitionID, s.dataPath, dir)
+			os.Remove(path.Join(dir, f.Name()))
+			continue
+		}
+		if s.HasExtent(extentID) && !s.replaceExtentFileOnLoad(extentID, keyVersion, compressed, path.Join(dir, f.Name())) {
+			continue
+		}
+		s.cachedExtents.Store(extentID, true)
+		s.setCompressedOnLoad(extentID, compressed, f.Size())
+		s.setKeyVersionOnLoad(extentID, keyVersion)
+		e, loadErr := s.extent(extentID)
+		if loadErr != nil {
+			s.cachedExtents.Delete(extentID)
+			s.compressedExtents.Delete(extentID)
+			s.encryptedExtents.Delete(extentID)
+			continue
+		}
+		ei := &ExtentInfo{FileID: extentID}
+		ei.UpdateExtentInfo(e, 0)
+		s.eiMutex.Lock()
+		s.extentInfoMap[extentID] = ei
+		s.eiMutex.Unlock()
+		e.Close()
+		if extentID > baseExtentID {
+			baseExtentID = extentID
+		}
+	}
+	atomic.StoreUint64(&s.baseExtentID, baseExtentID)
+	log.LogInfof("SetCacheDir: partition(%v) cache dir(%v) maxBaseId(%v)", s.partitionID, dir, baseExtentID)
+	return
+}
+
+func (s *ExtentStore) removeTempFiles(dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ExtentTempSuffix) {
+			os.Remove(path.Join(dir, f.Name()))
+		}
+	}
+}
+
+// CacheDir returns the cache directory of the store, it is empty if the store has none.
+func (s *ExtentStore) CacheDir() string {
+	return s.cachePath
+}
+
+// IsCachedExtent tells if the normal extent is in the cache directory.
+func (s *ExtentStore) IsCachedExtent(extentID uint64) (cached bool) {
+	_, cached = s.cachedExtents.Load(extentID)
+	return
+}
+
+// CachedExtents returns the number and the total size of the extents in the cache directory.
+func (s *ExtentStore) CachedExtents() (count int, size uint64) {
+	s.eiMutex.RLock()
+	defer s.eiMutex.RUnlock()
+	for extentID, ei := range s.extentInfoMap {
+		if s.IsCachedExtent(extentID) && !ei.IsDeleted {
+			count++
+			size += ei.Size
+		}
+	}
+	return
+}
+
+// extentFileName returns the name of the file of the extent, which has the version of its key if the extent is
+// encrypted and a suffix if it is compressed.
+func (s *ExtentStore) extentFileName(extentID uint64) string {
+	return extentFileNameOf(extentID, s.ExtentKeyVersion(extentID), s.IsCompressedExtent(extentID))
+}
+
+func (s *ExtentStore) extentPath(extentID uint64) string {
+	if pe := s.packedExtent(extentID); pe != nil {
+		return s.packPath(pe.PackID)
+	}
+	if s.IsCachedExtent(extentID) {
+		return path.Join(s.cachePath, s.extentFileName(extentID))
+	}
+	return path.Join(s.dataPath, s.extentFileName(extentID))
+}
+
+func (s *ExtentStore) recordRead(extentID uint64) {
+	if s.cachePath == "" || IsTinyExtent(extentID) {
+		return
+	}
+	value, _ := s.extentHeats.LoadOrStore(extentID, &extentHeat{})
+	heat := value.(*extentHeat)
+	atomic.AddInt64(&heat.reads, 1)
+	atomic.StoreInt64(&heat.lastRead, time.Now().Unix())
+}
+
+// TierCandidates returns the cached extents neither modified nor read for the cold age to demote, coldest first, and
+// the extents out of the cache read at least promoteReads times since the last call to promote, hottest first. All
+// the cached extents not modified recently are demoted if drain is set, and none is promoted. The read counts are
+// reset by the call.
+func (s *ExtentStore) TierCandidates(coldAge, promoteReads int64, drain bool) (demote, promote []uint64) {
+	if s.cachePath == "" {
+		return
+	}
+	now := time.Now().Unix()
+	lastAccess := make(map[uint64]int64)
+	reads := make(map[uint64]int64)
+	s.eiMutex.RLock()
+	for extentID, ei := range s.extentInfoMap {
+		if IsTinyExtent(extentID) || ei.IsDeleted {
+			continue
+		}
+		var lastRead, readCount int64
+		if value, ok := s.extentHeats.Load(extentID); ok {
+			heat := value.(*extentHeat)
+			lastRead = atomic.LoadInt64(&heat.lastRead)
+			readCount = atomic.SwapInt64(&heat.reads, 0)
+		}
+		if s.IsCachedExtent(extentID) {
+			access := ei.ModifyTime
+			if lastRead > access {
+				access = lastRead
+			}
+			if now-ei.ModifyTime > RepairInterval && (drain || now-access >= coldAge) {
+				demote = append(demote, extentID)
+				lastAccess[extentID] = access
+			}
+		} else if !drain && promoteReads > 0 && readCount >= promoteReads && !s.IsPackedExtent(extentID) {
+			promote = append(promote, extentID)
+			reads[extentID] = readCount
+		}
+	}
+	s.eiMutex.RUnlock()
+	s.extentHeats.Range(func(key, value interface{}) bool {
+		if !s.HasExtent(key.(uint64)) {
+			s.extentHeats.Delete(key)
+		}
+		return true
+	})
+	sort.Slice(demote, func(i, j int) bool { return lastAccess[demote[i]] < lastAccess[demote[j]] })
+	sort.Slice(promote, func(i, j int) bool { return reads[promote[i]] > reads[promote[j]] })
+	return
+}
+
+// MoveExtent moves the normal extent into the cache directory if toCache is set, or out of it otherwise. The extent is
+// copied without blocking the IO on it, the wait function is called with the size of each piece before it is copied
+// to limit the rate. The extent is switched to the copy only if it is not modified during the copy.
+func (s *ExtentStore) MoveExtent(extentID uint64, toCache bool, wait func(size int)) (err error) {
+	if s.cachePath == "" || IsTinyExtent(extentID) || s.IsPackedExtent(extentID) {
+		return NewParameterMismatchErr(fmt.Sprintf("extent(%v) of partition(%v) can not be moved", extentID, s.partitionID))
+	}
+	if s.IsCachedExtent(extentID) == toCache {
+		return
+	}
+	srcPath := s.extentPath(extentID)
+	dstPath := path.Join(s.dataPath, s.extentFileName(extentID))
+	if toCache {
+		dstPath = path.Join(s.cachePath, s.extentFileName(extentID))
+	}
+	tempPath := dstPath + ExtentTempSuffix
+	defer func() {
+		if err != nil {
+			os.Remove(tempPath)
+		}
+	}()
+	before, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if err = copyExtentFile(srcPath, tempPath, wait); err != nil {
+		return
+	}
+	// keep the modify time, which the extent is restored with, across the move
+	if err = os.Chtimes(tempPath, time.Now(), before.ModTime()); err != nil {
+		return
+	}
+
+	s.tierLock.Lock()
+	defer s.tierLock.Unlock()
+	if !s.HasExtent(extentID) {
+		return ExtentNotFoundError
+	}
+	if s.extentPath(extentID) != srcPath {
+		return fmt.Errorf("extent(%v) of partition(%v) is compressed during the move", extentID, s.partitionID)
+	}
+	after, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	if !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		return fmt.Errorf("extent(%v) of partition(%v) is modified during the move", extentID, s.partitionID)
+	}
+	if err = os.Rename(tempPath, dstPath); err != nil {
+		return
+	}
+	s.cache.Del(extentID)
+	if toCache {
+		s.cachedExtents.Store(extentID, true)
+	} else {
+		s.cachedExtents.Delete(extentID)
+	}
+	if removeErr := os.Remove(srcPath); removeErr != nil {
+		log.LogWarnf("MoveExtent: remove %v err(%v)", srcPath, removeErr)
+	}
+	return
+}
+
+func copyExtentFile(srcPath, dstPath string, wait func(size int)) (err error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+	buf := make([]byte, util.BlockSize)
+	for {
+		if wait != nil {
+			wait(len(buf))
+		}
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err = dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	return dst.Sync()
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/util"
+)
+
+func newTestTieredStore(t *testing.T, dataDir, cacheDir string, hasSpace bool) *ExtentStore {
+	s := newTestExtentStore(t, dataDir)
+	if err := s.SetCacheDir(cacheDir, func() bool { return hasSpace }); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMoveExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_tier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dataDir, cacheDir := path.Join(dir, "data"), path.Join(dir, "cache")
+	s := newTestTieredStore(t, dataDir, cacheDir, true)
+	data := newTestExtentData(2*util.BlockSize+100, 1)
+	writeTestExtent(t, s, 1025, data)
+	if !s.IsCachedExtent(1025) {
+		t.Fatalf("new extent is not created in the cache")
+	}
+	if count, size := s.CachedExtents(); count != 1 || size != uint64(len(data)) {
+		t.Errorf("expect 1 cached extent of %v bytes, got %v of %v", len(data), count, size)
+	}
+
+	if err = s.MoveExtent(1025, false, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsCachedExtent(1025) {
+		t.Fatalf("extent is cached after the demotion")
+	}
+	if _, err = os.Stat(path.Join(cacheDir, "1025")); !os.IsNotExist(err) {
+		t.Errorf("demoted extent is left in the cache, err %v", err)
+	}
+	checkTestExtent(t, s, 1025, data)
+
+	// the extent read enough is promoted back
+	checkTestExtent(t, s, 1025, data)
+	if _, promote := s.TierCandidates(3600, 3, false); len(promote) != 1 || promote[0] != 1025 {
+		t.Fatalf("expect extent 1025 to promote, got %v", promote)
+	}
+	if err = s.MoveExtent(1025, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsCachedExtent(1025) {
+		t.Fatalf("extent is not cached after the promotion")
+	}
+	checkTestExtent(t, s, 1025, data)
+
+	// the extent stays in the cache after the restart
+	s.Close()
+	s = newTestTieredStore(t, dataDir, cacheDir, true)
+	if !s.IsCachedExtent(1025) {
+		t.Fatalf("extent is not cached after the restart")
+	}
+	checkTestExtent(t, s, 1025, data)
+	s.Close()
+}
+
+func TestMoveExtentConcurrently(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_tier_io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := newTestTieredStore(t, path.Join(dir, "data"), path.Join(dir, "cache"), true)
+	defer s.Close()
+	data := newTestExtentData(3*util.BlockSize, 1)
+	writeTestExtent(t, s, 1025, data)
+
+	// the extent is read from the source while it is copied
+	pieces := 0
+	if err = s.MoveExtent(1025, false, func(size int) {
+		pieces++
+		checkTestExtent(t, s, 1025, data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if pieces == 0 || s.IsCachedExtent(1025) {
+		t.Fatalf("extent is not moved by pieces, %v pieces", pieces)
+	}
+	checkTestExtent(t, s, 1025, data)
+
+	// the move is given up if the extent is written during the copy, and the write is kept
+	appended := newTestExtentData(1000, 2)
+	err = s.MoveExtent(1025, true, func(size int) {
+		if pieces == 0 {
+			return
+		}
+		pieces = 0
+		if err := s.Write(1025, int64(len(data)), int64(len(appended)), appended, crc32.ChecksumIEEE(appended),
+			AppendWriteType, true); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err == nil || s.IsCachedExtent(1025) {
+		t.Fatalf("extent written during the move is moved, err %v", err)
+	}
+	checkTestExtent(t, s, 1025, append(data, appended...))
+	if files, _ := ioutil.ReadDir(path.Join(dir, "cache")); len(files) != 0 {
+		t.Errorf("copy of the extent is left in the cache: %v", files[0].Name())
+	}
+}
+
+func TestLoadPartiallyMovedExtent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extent_tier_crash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dataDir, cacheDir := path.Join(dir, "data"), path.Join(dir, "cache")
+	s := newTestTieredStore(t, dataDir, cacheDir, false)
+	data := newTestExtentData(2*util.BlockSize, 1)
+	writeTestExtent(t, s, 1025, data)
+	s.Close()
+	raw, err := ioutil.ReadFile(path.Join(dataDir, "1025"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the store stopped during the copy, the partial copy is removed
+	if err = ioutil.WriteFile(path.Join(cacheDir, "1025"+ExtentTempSuffix), raw[:len(raw)/2], 0666); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestTieredStore(t, dataDir, cacheDir, false)
+	if s.IsCachedExtent(1025) {
+		t.Fatalf("partial copy is loaded")
+	}
+	if _, err = os.Stat(path.Join(cacheDir, "1025"+ExtentTempSuffix)); !os.IsNotExist(err) {
+		t.Errorf("partial copy is not removed, err %v", err)
+	}
+	checkTestExtent(t, s, 1025, data)
+	s.Close()
+
+	// the store stopped before the source of the promotion was removed, the source is kept
+	if err = ioutil.WriteFile(path.Join(cacheDir, "1025"), raw, 0666); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestTieredStore(t, dataDir, cacheDir, false)
+	if s.IsCachedExtent(1025) {
+		t.Fatalf("copy is loaded instead of the source")
+	}
+	if _, err = os.Stat(path.Join(cacheDir, "1025")); !os.IsNotExist(err) {
+		t.Errorf("copy is not removed, err %v", err)
+	}
+	checkTestExtent(t, s, 1025, data)
+	if err = s.MoveExtent(1025, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// the store stopped before the source of the demotion was removed, the copies are the same and the one out of
+	// the cache is kept
+	if err = ioutil.WriteFile(path.Join(dataDir, "1025"), raw, 0666); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestTieredStore(t, dataDir, cacheDir, false)
+	if s.IsCachedExtent(1025) {
+		t.Fatalf("cached copy is loaded")
+	}
+	if _, err = os.Stat(path.Join(cacheDir, "1025")); !os.IsNotExist(err) {
+		t.Errorf("cached copy is not removed, err %v", err)
+	}
+	checkTestExtent(t, s, 1025, data)
+	s.Close()
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chubaofs/chubaofs/util/iouring"
+	"github.com/chubaofs/chubaofs/util/log"
+)
+
+// The data of the extents is read and written by pread and pwrite by default. The io_uring engine submits them to the
+// rings shared by the stores instead, which saves the context switches of the blocked threads and, if the rings poll,
+// the syscalls of the small random IO. The rings are picked in turn. The IO is done by pread and pwrite again if the
+// ring fails rather than the file, and the engine falls back for good if a ring is broken.
+
+const (
+	IOEnginePsync   = "psync"
+	IOEngineIOUring = "io_uring"
+
+	DefaultIOUringEntries = 128 // entries of the submission queue of each ring
+	ioRingCount           = 4
+)
+
+// ioRing is the ring of the io_uring engine.
+type ioRing interface {
+	ReadAt(f *os.File, p []byte, off int64) (n int, err error)
+	WriteAt(f *os.File, p []byte, off int64) (n int, err error)
+	Close() error
+}
+
+type ioEngine struct {
+	next  uint64 // the ring to pick next, first to be aligned for the atomic operations
+	name  string
+	rings []ioRing
+}
+
+var (
+	ioEngineLock    sync.Mutex
+	currentIOEngine atomic.Value // *ioEngine
+
+	// newIORing sets up a ring of the io_uring engine, the tests replace it to mimic the rings.
+	newIORing = func(entries uint32, sqPoll bool) (ioRing, error) {
+		ring, err := iouring.New(entries, sqPoll)
+		if err != nil {
+			return nil, err
+		}
+		return ring, nil
+	}
+)
+
+func init() {
+	currentIOEngine.Store(&ioEngine{name: IOEnginePsync})
+}
+
+// SetIOEngine switches the extent IO of the stores to the engine, the engine in use is kept if the new one is not
+// available, such as io_uring on the kernels older than 5.1. The kernel polls the rings by the threads of its own if
+// sqPoll is set.
+func SetIOEngine(name string, entries uint32, sqPoll bool) (err error) {
+	engine := &ioEngine{name: name}
+	switch name {
+	case IOEnginePsync:
+	case IOEngineIOUring:
+		if entries == 0 {
+			entries = DefaultIOUringEntries
+		}
+		for i := 0; i < ioRingCount; i++ {
+			var ring ioRing
+			if ring, err = newIORing(entries, sqPoll); err != nil {
+				for _, r := range engine.rings {
+					r.Close()
+				}
+				return
+			}
+			engine.rings = append(engine.rings, ring)
+		}
+	default:
+		return fmt.Errorf("unknown io engine %v", name)
+	}
+	ioEngineLock.Lock()
+	old := currentIOEngine.Load().(*ioEngine)
+	currentIOEngine.Store(engine)
+	ioEngineLock.Unlock()
+	for _, r := range old.rings {
+		r.Close()
+	}
+	return
+}
+
+// IOEngine returns the name of the engine of the extent IO in use.
+func IOEngine() string {
+	return currentIOEngine.Load().(*ioEngine).name
+}
+
+// fallbackIOEngine switches the extent IO back to pread and pwrite if the engine is broken, the rings are left open
+// since the IO submitted to them may never complete.
+func fallbackIOEngine(engine *ioEngine, err error) {
+	ioEngineLock.Lock()
+	defer ioEngineLock.Unlock()
+	if currentIOEngine.Load().(*ioEngine) != engine {
+		return
+	}
+	currentIOEngine.Store(&ioEngine{name: IOEnginePsync})
+	log.LogErrorf("action[fallbackIOEngine] engine(%v) falls back to %v, err(%v)", engine.name, IOEnginePsync, err)
+}
+
+func (engine *ioEngine) ring() ioRing {
+	if len(engine.rings) == 0 {
+		return nil
+	}
+	return engine.rings[atomic.AddUint64(&engine.next, 1)%uint64(len(engine.rings))]
+}
+
+// readFileAt reads the file at the offset by the engine in use like ReadAt of the file.
+func readFileAt(f *os.File, p []byte, off int64) (n int, err error) {
+	engine := currentIOEngine.Load().(*ioEngine)
+	ring := engine.ring()
+	if ring == nil {
+		return f.ReadAt(p, off)
+	}
+	if n, err = ring.ReadAt(f, p, off); !iouring.IsRingError(err) {
+		return
+	}
+	if err == iouring.ErrBroken {
+		fallbackIOEngine(engine, err)
+	}
+	m, err := f.ReadAt(p[n:], off+int64(n))
+	return n + m, err
+}
+
+// writeFileAt writes the file at the offset by the engine in use like WriteAt of the file.
+func writeFileAt(f *os.File, p []byte, off int64) (n int, err error) {
+	engine := currentIOEngine.Load().(*ioEngine)
+	ring := engine.ring()
+	if ring == nil {
+		return f.WriteAt(p, off)
+	}
+	if n, err = ring.WriteAt(f, p, off); !iouring.IsRingError(err) {
+		return
+	}
+	if err == iouring.ErrBroken {
+		fallbackIOEngine(engine, err)
+	}
+	m, err := f.WriteAt(p[n:], off+int64(n))
+	return n + m, err
+}
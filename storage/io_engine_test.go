@@ -0,0 +1,183 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chubaofs/chubaofs/util/iouring"
+)
+
+// fakeRing does the IO by the file, and fails it by err after the first n bytes if err is set.
+type fakeRing struct {
+	n      int
+	err    error
+	ios    int
+	closed bool
+}
+
+func (r *fakeRing) do(io func(p []byte, off int64) (int, error), p []byte, off int64) (n int, err error) {
+	r.ios++
+	if r.err == nil {
+		return io(p, off)
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	if n, err = io(p, off); err != nil {
+		return
+	}
+	return n, r.err
+}
+
+func (r *fakeRing) ReadAt(f *os.File, p []byte, off int64) (int, error) {
+	return r.do(f.ReadAt, p, off)
+}
+
+func (r *fakeRing) WriteAt(f *os.File, p []byte, off int64) (int, error) {
+	return r.do(f.WriteAt, p, off)
+}
+
+func (r *fakeRing) Close() error {
+	r.closed = true
+	return nil
+}
+
+// setFakeIOEngine replaces the rings of the io_uring engine by the fake ones of the count, the ring set up after
+// them fails by the error.
+func setFakeIOEngine(count int, err error) (rings []*fakeRing) {
+	for i := 0; i < count; i++ {
+		rings = append(rings, &fakeRing{})
+	}
+	next := 0
+	newIORing = func(entries uint32, sqPoll bool) (ioRing, error) {
+		if next == count {
+			return nil, err
+		}
+		next++
+		return rings[next-1], nil
+	}
+	return
+}
+
+func resetIOEngine(newRing func(uint32, bool) (ioRing, error)) {
+	newIORing = newRing
+	SetIOEngine(IOEnginePsync, 0, false)
+}
+
+func TestSetIOEngine(t *testing.T) {
+	defer resetIOEngine(newIORing)
+	// the kernel refuses to poll, the rings set up are closed and the engine in use is kept
+	rings := setFakeIOEngine(2, iouring.ErrSQPollNotSupported)
+	if err := SetIOEngine(IOEngineIOUring, 0, true); err != iouring.ErrSQPollNotSupported {
+		t.Fatalf("expect polling refused, got %v", err)
+	}
+	if !rings[0].closed || !rings[1].closed {
+		t.Fatalf("expect the rings set up closed")
+	}
+	if IOEngine() != IOEnginePsync {
+		t.Fatalf("expect %v kept, got %v", IOEnginePsync, IOEngine())
+	}
+
+	rings = setFakeIOEngine(ioRingCount, nil)
+	if err := SetIOEngine(IOEngineIOUring, 0, false); err != nil || IOEngine() != IOEngineIOUring {
+		t.Fatalf("expect %v, got %v err %v", IOEngineIOUring, IOEngine(), err)
+	}
+	if err := SetIOEngine("aio", 0, false); err == nil || IOEngine() != IOEngineIOUring {
+		t.Fatalf("expect the unknown engine refused, got %v err %v", IOEngine(), err)
+	}
+	if err := SetIOEngine(IOEnginePsync, 0, false); err != nil || IOEngine() != IOEnginePsync {
+		t.Fatalf("expect %v, got %v err %v", IOEnginePsync, IOEngine(), err)
+	}
+	for _, ring := range rings {
+		if !ring.closed {
+			t.Fatalf("expect the rings closed with the engine")
+		}
+	}
+}
+
+func TestIOEngineFallback(t *testing.T) {
+	defer resetIOEngine(newIORing)
+	f, err := ioutil.TempFile("", "io_engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	data := newTestExtentData(10000, 1)
+	rings := setFakeIOEngine(ioRingCount, nil)
+	if err = SetIOEngine(IOEngineIOUring, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	check := func(step string) {
+		buf := make([]byte, len(data))
+		if n, err := readFileAt(f, buf, 0); err != nil || n != len(data) || !bytes.Equal(buf, data) {
+			t.Fatalf("%v: read %v bytes, err %v", step, n, err)
+		}
+	}
+	if n, err := writeFileAt(f, data, 0); err != nil || n != len(data) {
+		t.Fatalf("write %v bytes, err %v", n, err)
+	}
+	check("ring")
+
+	// the IO the closed ring stops at is done by pread and pwrite, and the engine is kept
+	for _, ring := range rings {
+		ring.n, ring.err = 100, iouring.ErrClosed
+	}
+	copy(data[50:], "written after the ring is closed")
+	if n, err := writeFileAt(f, data, 0); err != nil || n != len(data) {
+		t.Fatalf("write %v bytes, err %v", n, err)
+	}
+	check("closed")
+	if IOEngine() != IOEngineIOUring {
+		t.Fatalf("engine falls back for the closed ring")
+	}
+
+	// the file errors are not retried
+	fileErr := errors.New("bad file")
+	for _, ring := range rings {
+		ring.n, ring.err = 0, fileErr
+	}
+	if _, err = readFileAt(f, make([]byte, 10), 0); err != fileErr {
+		t.Fatalf("expect the file error, got %v", err)
+	}
+
+	// the engine falls back to pread and pwrite for good once a ring is broken
+	for _, ring := range rings {
+		ring.err = iouring.ErrBroken
+	}
+	copy(data[200:], "written after the ring is broken")
+	if n, err := writeFileAt(f, data, 0); err != nil || n != len(data) {
+		t.Fatalf("write %v bytes, err %v", n, err)
+	}
+	if IOEngine() != IOEnginePsync {
+		t.Fatalf("expect %v after the ring is broken, got %v", IOEnginePsync, IOEngine())
+	}
+	ios := 0
+	for _, ring := range rings {
+		ios += ring.ios
+	}
+	check("broken")
+	for _, ring := range rings {
+		ios -= ring.ios
+	}
+	if ios != 0 {
+		t.Fatalf("broken rings are used after the fallback")
+	}
+}
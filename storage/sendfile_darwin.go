@@ -0,0 +1,31 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// the extents are always read through the user space in Darwin(Apple MacOS).
+const sendFileSupported = false
+
+func sendFile(conn syscall.Conn, f *os.File, offset, size int64) (err error) {
+	return syscall.ENOSYS
+}
+
+func dupFile(f *os.File) (*os.File, error) {
+	return nil, syscall.ENOSYS
+}
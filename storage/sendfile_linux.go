@@ -0,0 +1,89 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+const sendFileSupported = true
+
+// sendFile sends the data of the file at the offset to the connection by sendfile, which leaves the offset of the file
+// as it is, so the file is shared by the reads in parallel.
+func sendFile(conn syscall.Conn, f *os.File, offset, size int64) (err error) {
+	dst, err := conn.SyscallConn()
+	if err != nil {
+		return
+	}
+	src, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	var sendErr error
+	if err = src.Control(func(srcFd uintptr) {
+		writeErr := dst.Write(func(dstFd uintptr) bool {
+			for size > 0 {
+				n, e := syscall.Sendfile(int(dstFd), int(srcFd), &offset, int(size))
+				if n > 0 {
+					size -= int64(n)
+				}
+				switch {
+				case e == syscall.EAGAIN:
+					// waits for the connection to be writable again
+					return false
+				case e == syscall.EINTR:
+				case e != nil:
+					sendErr = os.NewSyscallError("sendfile", e)
+					return true
+				case n == 0:
+					sendErr = io.ErrUnexpectedEOF
+					return true
+				}
+			}
+			return true
+		})
+		if sendErr == nil {
+			sendErr = writeErr
+		}
+	}); err != nil {
+		return
+	}
+	return sendErr
+}
+
+// dupFile duplicates the descriptor of the file, the duplicate keeps reading the same data after the file is closed,
+// replaced or removed by the tiering, compression or packing of the extent.
+func dupFile(f *os.File) (dup *os.File, err error) {
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	var (
+		fd     int
+		dupErr error
+	)
+	if err = raw.Control(func(srcFd uintptr) {
+		fd, dupErr = syscall.Dup(int(srcFd))
+	}); err != nil {
+		return
+	}
+	if dupErr != nil {
+		return nil, os.NewSyscallError("dup", dupErr)
+	}
+	syscall.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), f.Name()), nil
+}
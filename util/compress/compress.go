@@ -0,0 +1,112 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package compress compresses the blocks of data with the algorithms the data at rest may be compressed with. No
+// maintained lz4 or zstd library builds with the Go version of the project, so lz4 is implemented here, and deflate of
+// the standard library is used instead of zstd.
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Algorithms to compress the data with
+const (
+	AlgorithmNone    = ""
+	AlgorithmLZ4     = "lz4"     // fast, the lz4 block format
+	AlgorithmDeflate = "deflate" // slower with a higher ratio
+)
+
+var ErrCorrupted = errors.New("compressed data is corrupted")
+
+// codes of the algorithms persisted with the compressed data
+var algorithmCodes = map[string]uint8{
+	AlgorithmLZ4:     1,
+	AlgorithmDeflate: 2,
+}
+
+// Valid tells if the algorithm is supported, none is valid.
+func Valid(algorithm string) bool {
+	_, ok := algorithmCodes[algorithm]
+	return ok || algorithm == AlgorithmNone
+}
+
+// Code returns the code of the algorithm to persist.
+func Code(algorithm string) (code uint8, err error) {
+	var ok bool
+	if code, ok = algorithmCodes[algorithm]; !ok {
+		err = fmt.Errorf("unknown compression algorithm %v", algorithm)
+	}
+	return
+}
+
+// Algorithm returns the algorithm of the persisted code.
+func Algorithm(code uint8) (algorithm string, err error) {
+	for name, c := range algorithmCodes {
+		if c == code {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown compression code %v", code)
+}
+
+// Compress compresses the data with the algorithm.
+func Compress(algorithm string, src []byte) (dst []byte, err error) {
+	switch algorithm {
+	case AlgorithmLZ4:
+		return lz4Compress(make([]byte, 0, lz4CompressBound(len(src))), src), nil
+	case AlgorithmDeflate:
+		buf := bytes.NewBuffer(make([]byte, 0, len(src)/2))
+		var w *flate.Writer
+		if w, err = flate.NewWriter(buf, flate.DefaultCompression); err != nil {
+			return
+		}
+		if _, err = w.Write(src); err != nil {
+			return
+		}
+		if err = w.Close(); err != nil {
+			return
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm %v", algorithm)
+	}
+}
+
+// Decompress decompresses the data compressed with the algorithm from the data of the size.
+func Decompress(algorithm string, src []byte, size int) (dst []byte, err error) {
+	switch algorithm {
+	case AlgorithmLZ4:
+		if dst, err = lz4Decompress(make([]byte, 0, size), src, size); err != nil {
+			return
+		}
+	case AlgorithmDeflate:
+		r := flate.NewReader(bytes.NewReader(src))
+		defer r.Close()
+		dst = make([]byte, size)
+		if _, err = io.ReadFull(r, dst); err != nil {
+			return nil, ErrCorrupted
+		}
+	default:
+		return nil, fmt.Errorf("unknown compression algorithm %v", algorithm)
+	}
+	if len(dst) != size {
+		return nil, ErrCorrupted
+	}
+	return
+}
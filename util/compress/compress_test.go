@@ -0,0 +1,59 @@
+package compress
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	random := make([]byte, 128*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	text := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 3000)
+	inputs := map[string][]byte{
+		"empty":  {},
+		"short":  []byte("abc"),
+		"zeros":  make([]byte, 128*1024),
+		"text":   text,
+		"random": random,
+		"mixed":  append(append([]byte{}, random[:1000]...), text[:5000]...),
+	}
+	for _, algorithm := range []string{AlgorithmLZ4, AlgorithmDeflate} {
+		for name, input := range inputs {
+			compressed, err := Compress(algorithm, input)
+			if err != nil {
+				t.Fatalf("%v %v: %v", algorithm, name, err)
+			}
+			output, err := Decompress(algorithm, compressed, len(input))
+			if err != nil || !bytes.Equal(output, input) {
+				t.Fatalf("%v %v: decompressed data mismatch, err %v", algorithm, name, err)
+			}
+			if name == "text" && len(compressed) > len(input)/10 {
+				t.Errorf("%v: expect the text compressed, %v -> %v", algorithm, len(input), len(compressed))
+			}
+		}
+	}
+}
+
+func TestDecompressCorrupted(t *testing.T) {
+	text := bytes.Repeat([]byte("abcdefgh"), 1000)
+	compressed, _ := Compress(AlgorithmLZ4, text)
+	if _, err := Decompress(AlgorithmLZ4, compressed, len(text)-1); err != ErrCorrupted {
+		t.Errorf("expect the data larger than the size rejected, got %v", err)
+	}
+	if _, err := Decompress(AlgorithmLZ4, compressed[:len(compressed)/2], len(text)); err != ErrCorrupted {
+		t.Errorf("expect the truncated data rejected, got %v", err)
+	}
+	// the offset of the first match points before the data
+	if _, err := Decompress(AlgorithmLZ4, []byte{0x10, 'a', 0x10, 0x00, 0x00}, 100); err != ErrCorrupted {
+		t.Errorf("expect the bad offset rejected, got %v", err)
+	}
+	if code, err := Code(AlgorithmDeflate); err != nil || !Valid(AlgorithmDeflate) {
+		t.Fatal(err)
+	} else if algorithm, _ := Algorithm(code); algorithm != AlgorithmDeflate {
+		t.Errorf("expect %v, got %v", AlgorithmDeflate, algorithm)
+	}
+	if Valid("zstd") {
+		t.Errorf("expect zstd invalid")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package compress
+
+import (
+	"encoding/binary"
+)
+
+// The lz4 block format is a list of sequences, each of which is a token, the literals and a match. The high 4 bits of
+// the token are the length of the literals, and the low 4 bits are the length of the match minus 4, either of which
+// is followed by more bytes of the length if it is 15. The match is the little endian offset of 2 bytes back to the
+// data decompressed. The last sequence has only the literals, which are at least the last 5 bytes.
+
+const (
+	lz4MinMatch     = 4
+	lz4HashLog      = 16
+	lz4MaxOffset    = 65535
+	lz4LastLiterals = 5
+	lz4MFLimit      = 12 // a match starts at least 12 bytes before the end
+)
+
+func lz4CompressBound(size int) int {
+	return size + size/255 + 16
+}
+
+func lz4Hash(seq uint32) uint32 {
+	return (seq * 2654435761) >> (32 - lz4HashLog)
+}
+
+// lz4Compress appends the data compressed greedily into the lz4 block format to dst.
+func lz4Compress(dst, src []byte) []byte {
+	table := make([]int32, 1<<lz4HashLog) // positions plus 1 of the sequences of 4 bytes
+	anchor := 0
+	for i := 0; i < len(src)-lz4MFLimit; {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := lz4Hash(seq)
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if ref < 0 || i-ref > lz4MaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			i++
+			continue
+		}
+		matchLen := lz4MinMatch
+		for maxLen := len(src) - lz4LastLiterals - i; matchLen < maxLen && src[ref+matchLen] == src[i+matchLen]; {
+			matchLen++
+		}
+		dst = lz4AppendSequence(dst, src[anchor:i], i-ref, matchLen)
+		i += matchLen
+		anchor = i
+	}
+	return lz4AppendSequence(dst, src[anchor:], 0, 0)
+}
+
+// lz4AppendSequence appends a sequence, which is the last one if the offset is 0.
+func lz4AppendSequence(dst, literals []byte, offset, matchLen int) []byte {
+	var token byte
+	if len(literals) >= 15 {
+		token = 15 << 4
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	matchLen -= lz4MinMatch
+	if offset > 0 {
+		if matchLen >= 15 {
+			token |= 15
+		} else {
+			token |= byte(matchLen)
+		}
+	}
+	dst = append(dst, token)
+	if len(literals) >= 15 {
+		dst = lz4AppendLength(dst, len(literals)-15)
+	}
+	dst = append(dst, literals...)
+	if offset == 0 {
+		return dst
+	}
+	dst = append(dst, byte(offset), byte(offset>>8))
+	if matchLen >= 15 {
+		dst = lz4AppendLength(dst, matchLen-15)
+	}
+	return dst
+}
+
+func lz4AppendLength(dst []byte, n int) []byte {
+	for ; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+func lz4ReadLength(src []byte, i, n int) (int, int, error) {
+	for {
+		if i >= len(src) {
+			return 0, 0, ErrCorrupted
+		}
+		b := src[i]
+		i++
+		n += int(b)
+		if b != 255 {
+			return n, i, nil
+		}
+	}
+}
+
+// lz4Decompress appends the data decompressed from the lz4 block to dst, which is at most of the size.
+func lz4Decompress(dst, src []byte, size int) (_ []byte, err error) {
+	start := len(dst)
+	for i := 0; i < len(src); {
+		token := src[i]
+		i++
+		litLen := int(token >> 4)
+		if litLen == 15 {
+			if litLen, i, err = lz4ReadLength(src, i, litLen); err != nil {
+				return
+			}
+		}
+		if i+litLen > len(src) || len(dst)-start+litLen > size {
+			return nil, ErrCorrupted
+		}
+		dst = append(dst, src[i:i+litLen]...)
+		if i += litLen; i == len(src) {
+			break
+		}
+		if i+2 > len(src) {
+			return nil, ErrCorrupted
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		matchLen := int(token & 15)
+		if matchLen == 15 {
+			if matchLen, i, err = lz4ReadLength(src, i, matchLen); err != nil {
+				return
+			}
+		}
+		matchLen += lz4MinMatch
+		if offset == 0 || offset > len(dst)-start || len(dst)-start+matchLen > size {
+			return nil, ErrCorrupted
+		}
+		// the match may overlap the data it appends
+		pos := len(dst) - offset
+		for k := 0; k < matchLen; k++ {
+			dst = append(dst, dst[pos+k])
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package iouring reads and writes the files by the io_uring of Linux 5.1 or later, the ring is not available on the
+// older kernels and the other systems.
+package iouring
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var (
+	ErrNotSupported = errors.New("io_uring is not supported")
+	ErrClosed       = errors.New("io_uring is closed")
+	ErrBroken       = errors.New("io_uring is broken") // the ring fails to submit, the IO should be done without it
+
+	ErrSQPollNotSupported = errors.New("io_uring polling of the unregistered files is not supported")
+)
+
+// IsRingError tells if the IO fails by the ring rather than by the file, which can be done again without the ring.
+func IsRingError(err error) bool {
+	return err == ErrClosed || err == ErrBroken || err == ErrNotSupported
+}
+
+func pathError(op string, f *os.File, err error) error {
+	if IsRingError(err) {
+		return err
+	}
+	return &os.PathError{Op: op, Path: f.Name(), Err: err}
+}
+
+// ReadAt reads the file at the offset by the ring like ReadAt of the file, the read is retried from where it stops
+// until the buffer is full or the end of the file is reached.
+func (r *Ring) ReadAt(f *os.File, p []byte, off int64) (n int, err error) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	// the file is not closed while the ring uses its descriptor
+	if ctlErr := conn.Control(func(fd uintptr) {
+		for n < len(p) {
+			var m int
+			if m, err = r.submit(opRead, int(fd), p[n:], off+int64(n)); err != nil {
+				err = pathError("read", f, err)
+				return
+			}
+			if m == 0 {
+				err = io.EOF
+				return
+			}
+			n += m
+		}
+	}); ctlErr != nil {
+		return 0, ctlErr
+	}
+	return
+}
+
+// WriteAt writes the file at the offset by the ring like WriteAt of the file.
+func (r *Ring) WriteAt(f *os.File, p []byte, off int64) (n int, err error) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return
+	}
+	if ctlErr := conn.Control(func(fd uintptr) {
+		for n < len(p) {
+			var m int
+			if m, err = r.submit(opWrite, int(fd), p[n:], off+int64(n)); err != nil {
+				err = pathError("write", f, err)
+				return
+			}
+			if m == 0 {
+				err = &os.PathError{Op: "write", Path: f.Name(), Err: io.ErrShortWrite}
+				return
+			}
+			n += m
+		}
+	}); ctlErr != nil {
+		return 0, ctlErr
+	}
+	return
+}
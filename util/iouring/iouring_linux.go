@@ -0,0 +1,386 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iouring
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// The submissions are put into the submission queue under a lock and submitted by io_uring_enter, or picked by the
+// polling thread of the kernel if the ring polls. A goroutine waits for the completions and hands them to the
+// submitters by the user data of the entries. The submissions in flight are limited by the size of the submission
+// queue, so the completion queue of twice the size never overflows.
+
+const (
+	sysIOUringSetup = 425
+	sysIOUringEnter = 426
+
+	setupSQPoll = 1 << 1
+
+	enterGetEvents = 1 << 0
+	enterSQWakeup  = 1 << 1
+
+	sqNeedWakeup = 1 << 0
+
+	featSQPollNonfixed = 1 << 5 // the polling thread takes the files not registered to the ring, since Linux 5.11
+
+	offSQRing = 0
+	offCQRing = 0x8000000
+	offSQEs   = 0x10000000
+
+	opNop    = 0
+	opReadv  = 1
+	opWritev = 2
+	opRead   = opReadv
+	opWrite  = opWritev
+
+	sqeSize = 64
+	cqeSize = 16
+
+	sqPollIdle = 1000 // milliseconds the polling thread of the kernel spins before it sleeps
+	closeData  = 0    // the user data of the entry which wakes the completion goroutine up to close the ring
+
+	minEnterBackoff = time.Millisecond
+	maxEnterBackoff = time.Second
+)
+
+type sqringOffsets struct {
+	head        uint32
+	tail        uint32
+	ringMask    uint32
+	ringEntries uint32
+	flags       uint32
+	dropped     uint32
+	array       uint32
+	resv1       uint32
+	resv2       uint64
+}
+
+type cqringOffsets struct {
+	head        uint32
+	tail        uint32
+	ringMask    uint32
+	ringEntries uint32
+	overflow    uint32
+	cqes        uint32
+	flags       uint32
+	resv1       uint32
+	resv2       uint64
+}
+
+type params struct {
+	sqEntries    uint32
+	cqEntries    uint32
+	flags        uint32
+	sqThreadCPU  uint32
+	sqThreadIdle uint32
+	features     uint32
+	wqFd         uint32
+	resv         [3]uint32
+	sqOff        sqringOffsets
+	cqOff        cqringOffsets
+}
+
+type sqe struct {
+	opcode      uint8
+	flags       uint8
+	ioprio      uint16
+	fd          int32
+	off         uint64
+	addr        uint64
+	len         uint32
+	rwFlags     uint32
+	userData    uint64
+	bufIndex    uint16
+	personality uint16
+	spliceFdIn  int32
+	pad         [2]uint64
+}
+
+type cqe struct {
+	userData uint64
+	res      int32
+	flags    uint32
+}
+
+// request is a submission in flight, which keeps the buffer and its vector referenced until it completes.
+type request struct {
+	iov  syscall.Iovec
+	buf  []byte
+	done chan int32
+}
+
+// Ring is an io_uring shared by the goroutines.
+type Ring struct {
+	fd     int
+	sqPoll bool
+
+	sqRing  []byte
+	cqRing  []byte
+	sqes    []byte
+	sqHead  *uint32
+	sqTail  *uint32
+	sqMask  uint32
+	sqFlags *uint32
+	sqArray unsafe.Pointer
+	cqHead  *uint32
+	cqTail  *uint32
+	cqMask  uint32
+	cqes    unsafe.Pointer
+
+	slots      chan struct{} // limits the submissions in flight
+	sync.Mutex               // protects the submission queue and the requests
+	requests   map[uint64]*request
+	nextData   uint64
+	closed     bool
+	closedC    chan struct{} // closed with the ring, so the submitters do not wait for the slots any more
+	broken     bool          // io_uring_enter failed, the entries in the queue may be submitted or completed or not
+	stopped    chan struct{}
+}
+
+// setupRing sets up a ring by io_uring_setup, the tests replace it to mimic the kernels.
+var setupRing = func(entries uint32, p *params) (fd int, errno syscall.Errno) {
+	r1, _, errno := syscall.Syscall(sysIOUringSetup, uintptr(entries), uintptr(unsafe.Pointer(p)), 0)
+	return int(r1), errno
+}
+
+// New sets up a ring of the entries, the kernel polls the submission queue by a thread of its own if sqPoll is set,
+// which saves the syscalls of the submissions but takes a CPU while the ring is busy. The polling thread only takes the
+// files registered to the ring before Linux 5.11, so ErrSQPollNotSupported is returned on the older kernels rather
+// than failing every submission by EBADF.
+func New(entries uint32, sqPoll bool) (r *Ring, err error) {
+	p := &params{}
+	if sqPoll {
+		p.flags |= setupSQPoll
+		p.sqThreadIdle = sqPollIdle
+	}
+	fd, errno := setupRing(entries, p)
+	if errno != 0 {
+		if errno == syscall.ENOSYS {
+			return nil, ErrNotSupported
+		}
+		return nil, errno
+	}
+	r = &Ring{fd: fd, sqPoll: sqPoll}
+	ring := r
+	defer func() {
+		if err != nil {
+			ring.release()
+			r = nil
+		}
+	}()
+	if sqPoll && p.features&featSQPollNonfixed == 0 {
+		return nil, ErrSQPollNotSupported
+	}
+	if r.sqRing, err = syscall.Mmap(r.fd, offSQRing, int(p.sqOff.array+p.sqEntries*4),
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return
+	}
+	if r.cqRing, err = syscall.Mmap(r.fd, offCQRing, int(p.cqOff.cqes+p.cqEntries*cqeSize),
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return
+	}
+	if r.sqes, err = syscall.Mmap(r.fd, offSQEs, int(p.sqEntries*sqeSize),
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE); err != nil {
+		return
+	}
+	sq := unsafe.Pointer(&r.sqRing[0])
+	r.sqHead = (*uint32)(unsafe.Pointer(uintptr(sq) + uintptr(p.sqOff.head)))
+	r.sqTail = (*uint32)(unsafe.Pointer(uintptr(sq) + uintptr(p.sqOff.tail)))
+	r.sqMask = *(*uint32)(unsafe.Pointer(uintptr(sq) + uintptr(p.sqOff.ringMask)))
+	r.sqFlags = (*uint32)(unsafe.Pointer(uintptr(sq) + uintptr(p.sqOff.flags)))
+	r.sqArray = unsafe.Pointer(uintptr(sq) + uintptr(p.sqOff.array))
+	cq := unsafe.Pointer(&r.cqRing[0])
+	r.cqHead = (*uint32)(unsafe.Pointer(uintptr(cq) + uintptr(p.cqOff.head)))
+	r.cqTail = (*uint32)(unsafe.Pointer(uintptr(cq) + uintptr(p.cqOff.tail)))
+	r.cqMask = *(*uint32)(unsafe.Pointer(uintptr(cq) + uintptr(p.cqOff.ringMask)))
+	r.cqes = unsafe.Pointer(uintptr(cq) + uintptr(p.cqOff.cqes))
+	// one slot is kept for the entry to close the ring
+	r.slots = make(chan struct{}, p.sqEntries-1)
+	r.requests = make(map[uint64]*request)
+	r.nextData = closeData
+	r.closedC = make(chan struct{})
+	r.stopped = make(chan struct{})
+	go r.complete()
+	return r, nil
+}
+
+func (r *Ring) release() {
+	for _, m := range [][]byte{r.sqRing, r.cqRing, r.sqes} {
+		if m != nil {
+			syscall.Munmap(m)
+		}
+	}
+	syscall.Close(r.fd)
+}
+
+func (r *Ring) enter(toSubmit, minComplete, flags uint32) (err error) {
+	for {
+		_, _, errno := syscall.Syscall6(sysIOUringEnter, uintptr(r.fd), uintptr(toSubmit), uintptr(minComplete),
+			uintptr(flags), 0, 0)
+		if errno == syscall.EINTR || errno == syscall.EAGAIN {
+			continue
+		}
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	}
+}
+
+// push puts the entry into the submission queue and submits it, it is called with the lock held.
+func (r *Ring) push(opcode uint8, fd int, addr uintptr, length uint32, off int64, userData uint64) (err error) {
+	tail := atomic.LoadUint32(r.sqTail)
+	index := tail & r.sqMask
+	e := (*sqe)(unsafe.Pointer(&r.sqes[index*sqeSize]))
+	*e = sqe{opcode: opcode, fd: int32(fd), off: uint64(off), addr: uint64(addr), len: length, userData: userData}
+	*(*uint32)(unsafe.Pointer(uintptr(r.sqArray) + uintptr(index)*4)) = index
+	atomic.StoreUint32(r.sqTail, tail+1)
+	if !r.sqPoll {
+		return r.enter(1, 0, 0)
+	}
+	if atomic.LoadUint32(r.sqFlags)&sqNeedWakeup != 0 {
+		return r.enter(0, 0, enterSQWakeup)
+	}
+	return nil
+}
+
+// submit reads or writes the buffer at the offset of the file, and returns the bytes done by the single syscall.
+func (r *Ring) submit(opcode uint8, fd int, buf []byte, off int64) (n int, err error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	req := &request{buf: buf, done: make(chan int32, 1)}
+	req.iov.Base = &buf[0]
+	req.iov.SetLen(len(buf))
+	select {
+	case r.slots <- struct{}{}:
+	case <-r.closedC:
+		return 0, ErrClosed
+	}
+	defer func() { <-r.slots }()
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return 0, ErrClosed
+	}
+	if r.broken {
+		r.Unlock()
+		return 0, ErrBroken
+	}
+	r.nextData++
+	userData := r.nextData
+	r.requests[userData] = req
+	if err = r.push(opcode, fd, uintptr(unsafe.Pointer(&req.iov)), 1, off, userData); err != nil {
+		// the request is left to keep the buffer referenced, since the kernel may still read or write it
+		r.broken = true
+		r.Unlock()
+		return 0, ErrBroken
+	}
+	r.Unlock()
+	res := <-req.done
+	if res < 0 {
+		return 0, syscall.Errno(-res)
+	}
+	return int(res), nil
+}
+
+// complete hands the completions to the submitters until the ring is closed. The ring is marked broken if it fails to
+// wait for the completions, and the completions are polled with the backoff since then, the requests in flight are
+// not failed since the kernel may still read or write their buffers.
+func (r *Ring) complete() {
+	defer close(r.stopped)
+	var backoff time.Duration
+	for {
+		err := r.enter(0, 1, enterGetEvents)
+		head := atomic.LoadUint32(r.cqHead)
+		tail := atomic.LoadUint32(r.cqTail)
+		if err != nil && head == tail {
+			if r.setBroken() {
+				return
+			}
+			if backoff *= 2; backoff < minEnterBackoff {
+				backoff = minEnterBackoff
+			} else if backoff > maxEnterBackoff {
+				backoff = maxEnterBackoff
+			}
+			time.Sleep(backoff)
+			continue
+		}
+		backoff = 0
+		closing := false
+		for ; head != tail; head++ {
+			c := (*cqe)(unsafe.Pointer(uintptr(r.cqes) + uintptr(head&r.cqMask)*cqeSize))
+			if c.userData == closeData {
+				closing = true
+				continue
+			}
+			r.Lock()
+			req := r.requests[c.userData]
+			delete(r.requests, c.userData)
+			r.Unlock()
+			if req != nil {
+				req.done <- c.res
+			}
+		}
+		atomic.StoreUint32(r.cqHead, head)
+		if closing {
+			return
+		}
+	}
+}
+
+func (r *Ring) isBroken() bool {
+	r.Lock()
+	defer r.Unlock()
+	return r.broken
+}
+
+// setBroken marks the ring broken and tells if it is closed.
+func (r *Ring) setBroken() (closed bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.broken = true
+	return r.closed
+}
+
+// Close waits for the submissions in flight and releases the ring.
+func (r *Ring) Close() (err error) {
+	r.Lock()
+	if r.closed {
+		r.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.closedC)
+	r.Unlock()
+	for i := 0; i < cap(r.slots); i++ {
+		r.slots <- struct{}{}
+	}
+	r.Lock()
+	err = r.push(opNop, -1, 0, 0, 0, closeData)
+	r.Unlock()
+	if err != nil {
+		// the ring is left mapped, since the completion goroutine may still be waiting on it
+		return
+	}
+	<-r.stopped
+	r.release()
+	return
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iouring
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBroken(t *testing.T) {
+	r := newTestRing(t)
+	// the ring fails to wait for the completions without its descriptor
+	syscall.Close(r.fd)
+	for i := 0; !r.isBroken(); i++ {
+		if i == 100 {
+			t.Fatal("expect the ring broken")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	f, err := ioutil.TempFile("", "iouring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err = r.ReadAt(f, make([]byte, 10), 0); err != ErrBroken {
+		t.Fatalf("read by the broken ring: %v", err)
+	}
+	r.Close()
+	select {
+	case <-r.stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect the completion goroutine stopped with the ring")
+	}
+}
+
+func TestNewSQPollGate(t *testing.T) {
+	defer func(setup func(uint32, *params) (int, syscall.Errno)) { setupRing = setup }(setupRing)
+	var features uint32
+	var fds [2]int
+	setupRing = func(entries uint32, p *params) (fd int, errno syscall.Errno) {
+		if p.flags&setupSQPoll == 0 || p.sqThreadIdle != sqPollIdle {
+			t.Errorf("expect the ring set up to poll, flags %v", p.flags)
+		}
+		if err := syscall.Pipe(fds[:]); err != nil {
+			t.Fatal(err)
+		}
+		syscall.Close(fds[1])
+		p.features = features
+		return fds[0], 0
+	}
+	// the kernels before 5.11 poll the registered files only
+	if _, err := New(8, true); err != ErrSQPollNotSupported {
+		t.Fatalf("expect polling refused before 5.11, got %v", err)
+	}
+	if err := syscall.Close(fds[0]); err != syscall.EBADF {
+		t.Errorf("expect the ring refused closed, got %v", err)
+	}
+	// the gate is passed since 5.11, the mapping fails on the fake ring
+	features = featSQPollNonfixed
+	if _, err := New(8, true); err == nil || err == ErrSQPollNotSupported {
+		t.Fatalf("expect polling allowed since 5.11, got %v", err)
+	}
+
+	setupRing = func(entries uint32, p *params) (int, syscall.Errno) {
+		return -1, syscall.ENOSYS
+	}
+	if _, err := New(8, true); err != ErrNotSupported {
+		t.Fatalf("expect io_uring not supported before 5.1, got %v", err)
+	}
+}
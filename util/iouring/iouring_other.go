@@ -0,0 +1,40 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package iouring
+
+const (
+	opRead = iota
+	opWrite
+)
+
+// Ring is not available on the systems other than Linux.
+type Ring struct{}
+
+// New returns ErrNotSupported on the systems other than Linux.
+func New(entries uint32, sqPoll bool) (r *Ring, err error) {
+	return nil, ErrNotSupported
+}
+
+func (r *Ring) submit(opcode uint8, fd int, buf []byte, off int64) (n int, err error) {
+	return 0, ErrNotSupported
+}
+
+// Close does nothing on the systems other than Linux.
+func (r *Ring) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iouring
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func newTestRing(t *testing.T) *Ring {
+	r, err := New(8, false)
+	if err != nil {
+		t.Skipf("io_uring is not available: %v", err)
+	}
+	return r
+}
+
+func TestReadWriteAt(t *testing.T) {
+	r := newTestRing(t)
+	defer r.Close()
+	f, err := ioutil.TempFile("", "iouring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	data := bytes.Repeat([]byte("chubaofs"), 1024)
+	// more writes in parallel than the entries of the ring
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := r.WriteAt(f, data, int64(i*len(data))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	buf := make([]byte, len(data))
+	for i := 0; i < 32; i++ {
+		if n, err := r.ReadAt(f, buf, int64(i*len(data))); err != nil || n != len(data) || !bytes.Equal(buf, data) {
+			t.Fatalf("read %v: n %v err %v", i, n, err)
+		}
+	}
+	// the read beyond the end of the file is short like ReadAt of the file
+	n, err := r.ReadAt(f, buf, int64(32*len(data)-10))
+	if n != 10 || err != io.EOF {
+		t.Fatalf("read at the end: n %v err %v", n, err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	r := newTestRing(t)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := ioutil.TempFile("", "iouring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err = r.ReadAt(f, make([]byte, 10), 0); !IsRingError(err) {
+		t.Fatalf("read by the closed ring: %v", err)
+	}
+}
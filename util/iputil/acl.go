@@ -0,0 +1,104 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iputil
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ACL allows or denies the IPs by CIDRs. An IP is denied if it is in any denied CIDR, or the allowed CIDRs
+// are given and it is in none of them.
+type ACL struct {
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	denyAll bool
+}
+
+// DenyAll returns the ACL denying all the IPs.
+func DenyAll() *ACL {
+	return &ACL{denyAll: true}
+}
+
+// NewACL parses the allowed and the denied CIDRs, a single IP is taken as the CIDR of the IP only.
+func NewACL(allow, deny []string) (acl *ACL, err error) {
+	acl = &ACL{}
+	if acl.allow, err = ParseCIDRs(allow); err != nil {
+		return nil, err
+	}
+	if acl.deny, err = ParseCIDRs(deny); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// ParseCIDRs parses the CIDRs, such as "10.0.0.0/8", or the single IPs.
+func ParseCIDRs(cidrs []string) (nets []*net.IPNet, err error) {
+	nets = make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP or CIDR %v", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		var ipNet *net.IPNet
+		if _, ipNet, err = net.ParseCIDR(cidr); err != nil {
+			return nil, fmt.Errorf("invalid IP or CIDR %v", cidr)
+		}
+		nets = append(nets, ipNet)
+	}
+	return
+}
+
+// Allowed returns whether the IP is allowed by the ACL, a nil ACL allows all the IPs.
+func (acl *ACL) Allowed(ip net.IP) bool {
+	if acl == nil {
+		return true
+	}
+	if acl.denyAll {
+		return false
+	}
+	for _, ipNet := range acl.deny {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	if len(acl.allow) == 0 {
+		return true
+	}
+	for _, ipNet := range acl.allow {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPOfAddr returns the IP of the address, such as the remote address of a connection.
+func IPOfAddr(addr string) net.IP {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
@@ -0,0 +1,58 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package iputil
+
+import (
+	"testing"
+)
+
+func TestACL(t *testing.T) {
+	acl, err := NewACL([]string{"10.0.0.0/8", "192.168.1.5"}, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, allowed := range map[string]bool{
+		"10.2.3.4:17310": true,
+		"10.1.3.4:17310": false,
+		"192.168.1.5":    true,
+		"192.168.1.6":    false,
+		"172.16.0.1":     false,
+	} {
+		if got := acl.Allowed(IPOfAddr(addr)); got != allowed {
+			t.Errorf("%v allowed %v, expected %v", addr, got, allowed)
+		}
+	}
+
+	denyOnly, err := NewACL(nil, []string{"fe80::/10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !denyOnly.Allowed(IPOfAddr("172.16.0.1:80")) || denyOnly.Allowed(IPOfAddr("[fe80::1]:80")) {
+		t.Errorf("unexpected result of the deny only ACL")
+	}
+	var none *ACL
+	if !none.Allowed(IPOfAddr("172.16.0.1")) {
+		t.Errorf("nil ACL denies")
+	}
+	if DenyAll().Allowed(IPOfAddr("172.16.0.1")) || DenyAll().Allowed(nil) {
+		t.Errorf("deny all ACL allows")
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "10.0.0", "host"} {
+		if _, err = NewACL([]string{invalid}, nil); err == nil {
+			t.Errorf("%v is accepted", invalid)
+		}
+	}
+}
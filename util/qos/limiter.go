@@ -0,0 +1,115 @@
+// Copyright 2018 The Chubao Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package qos limits the IOPS and the bandwidth of the reads and the writes of a volume with token buckets.
+package qos
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultIopsBurst = 128
+	// the bandwidth bucket holds the tokens of one second, and no less than 1MB
+	minBandwidthBurst = 1024 * 1024
+)
+
+// Limiter limits the reads and the writes by IOPS and by bandwidth in bytes per second. A limit of 0 means unlimited.
+type Limiter struct {
+	readIops  *rate.Limiter
+	writeIops *rate.Limiter
+	readBps   *rate.Limiter
+	writeBps  *rate.Limiter
+}
+
+// NewLimiter returns an unlimited limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{
+		readIops:  rate.NewLimiter(rate.Inf, defaultIopsBurst),
+		writeIops: rate.NewLimiter(rate.Inf, defaultIopsBurst),
+		readBps:   rate.NewLimiter(rate.Inf, minBandwidthBurst),
+		writeBps:  rate.NewLimiter(rate.Inf, minBandwidthBurst),
+	}
+}
+
+// Update sets the limits, and the waiting requests are throttled by the new limits.
+func (l *Limiter) Update(readIops, writeIops, readBps, writeBps uint64) {
+	setIopsLimit(l.readIops, readIops)
+	setIopsLimit(l.writeIops, writeIops)
+	setBandwidthLimit(l.readBps, readBps)
+	setBandwidthLimit(l.writeBps, writeBps)
+}
+
+// Limits returns the current limits, 0 for unlimited.
+func (l *Limiter) Limits() (readIops, writeIops, readBps, writeBps uint64) {
+	return getLimit(l.readIops), getLimit(l.writeIops), getLimit(l.readBps), getLimit(l.writeBps)
+}
+
+// WaitRead blocks until a read of the size is allowed or the context is done.
+func (l *Limiter) WaitRead(ctx context.Context, size int) error {
+	return wait(ctx, l.readIops, l.readBps, size)
+}
+
+// WaitWrite blocks until a write of the size is allowed or the context is done.
+func (l *Limiter) WaitWrite(ctx context.Context, size int) error {
+	return wait(ctx, l.writeIops, l.writeBps, size)
+}
+
+func wait(ctx context.Context, iops, bps *rate.Limiter, size int) (err error) {
+	if err = iops.Wait(ctx); err != nil {
+		return
+	}
+	// the request larger than the bucket takes the tokens of several rounds
+	for size > 0 {
+		n := size
+		if burst := bps.Burst(); n > burst {
+			n = burst
+		}
+		if err = bps.WaitN(ctx, n); err != nil {
+			return
+		}
+		size -= n
+	}
+	return
+}
+
+func setIopsLimit(lim *rate.Limiter, limit uint64) {
+	if limit == 0 {
+		lim.SetLimit(rate.Inf)
+		return
+	}
+	lim.SetLimit(rate.Limit(limit))
+}
+
+func setBandwidthLimit(lim *rate.Limiter, limit uint64) {
+	if limit == 0 {
+		lim.SetLimit(rate.Inf)
+		return
+	}
+	burst := int(limit)
+	if burst < minBandwidthBurst {
+		burst = minBandwidthBurst
+	}
+	lim.SetBurst(burst)
+	lim.SetLimit(rate.Limit(limit))
+}
+
+func getLimit(lim *rate.Limiter) uint64 {
+	if lim.Limit() == rate.Inf {
+		return 0
+	}
+	return uint64(lim.Limit())
+}
@@ -0,0 +1,38 @@
+package qos
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLimiterUpdate(t *testing.T) {
+	l := NewLimiter()
+	l.Update(100, 200, 2*minBandwidthBurst, 0)
+	readIops, writeIops, readBps, writeBps := l.Limits()
+	if readIops != 100 || writeIops != 200 || readBps != 2*minBandwidthBurst || writeBps != 0 {
+		t.Errorf("unexpected limits %v %v %v %v", readIops, writeIops, readBps, writeBps)
+	}
+	l.Update(0, 0, 0, 0)
+	if readIops, writeIops, readBps, writeBps = l.Limits(); readIops+writeIops+readBps+writeBps != 0 {
+		t.Errorf("expect unlimited, but %v %v %v %v", readIops, writeIops, readBps, writeBps)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := NewLimiter()
+	ctx := context.Background()
+	// the unlimited requests larger than the bucket are not blocked
+	if err := l.WaitWrite(ctx, 10*minBandwidthBurst); err != nil {
+		t.Fatal(err)
+	}
+	l.Update(0, 0, minBandwidthBurst, 0)
+	start := time.Now()
+	// the first bucket is full, the second one takes a second
+	if err := l.WaitRead(ctx, 2*minBandwidthBurst); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("expect the read throttled for a second, but %v", elapsed)
+	}
+}